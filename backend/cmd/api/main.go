@@ -1,25 +1,35 @@
 package main
 
 import (
-	"log"
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/cache"
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/monitoring"
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/fx"
+	"github.com/Taf0711/financial-risk-monitor/internal/grpcapi"
 	"github.com/Taf0711/financial-risk-monitor/internal/handlers"
+	"github.com/Taf0711/financial-risk-monitor/internal/lifecycle"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/marketdata"
 	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
 	"github.com/Taf0711/financial-risk-monitor/internal/mock"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
+	"github.com/Taf0711/financial-risk-monitor/internal/reconciliation"
 	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/streaming"
+	"github.com/Taf0711/financial-risk-monitor/internal/tenancy"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
 	wsHandler "github.com/Taf0711/financial-risk-monitor/internal/websocket"
 )
 
@@ -27,58 +37,309 @@ func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal("Failed to load configuration", err)
+	}
+
+	// Structured logging for every component, including the standard library logger
+	logger, err := logging.Init(&cfg.Log)
+	if err != nil {
+		fatal("Failed to configure logging", err)
+	}
+
+	// Request tracing, exported over OTLP when enabled
+	tracer, err := tracing.Init(&cfg.Tracing)
+	if err != nil {
+		fatal("Failed to configure tracing", err)
 	}
 
 	// Initialize database connections
 	if err := database.InitPostgres(&cfg.Database); err != nil {
-		log.Fatal("Failed to connect to PostgreSQL:", err)
+		fatal("Failed to connect to PostgreSQL", err)
 	}
 
 	if err := database.InitRedis(&cfg.Redis); err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		fatal("Failed to connect to Redis", err)
+	}
+
+	// Cache hot dashboard reads in Redis, invalidated by writes to the tables behind them
+	cache.Init(&cfg.Cache)
+	if err := database.GetDB().Use(cache.GormPlugin{}); err != nil {
+		fatal("Failed to register cache invalidation", err)
+	}
+
+	if tracing.Enabled() {
+		if err := database.GetDB().Use(tracing.GormPlugin{}); err != nil {
+			fatal("Failed to instrument PostgreSQL", err)
+		}
+		database.GetRedis().AddHook(tracing.RedisHook{})
+		logger.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	// Per-tenant data keys encrypting the PII of tenants that turn encryption on
+	if _, err := tenancy.Init(&cfg.Tenancy); err != nil {
+		fatal("Failed to configure tenant encryption", err)
+	}
+
+	// Transaction monitoring rules and anomaly scoring behind the AML checks
+	if _, err := monitoring.Init(&cfg.Monitoring); err != nil {
+		fatal("Failed to configure transaction monitoring", err)
 	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: cfg.App.Name,
+		AppName:      cfg.App.Name,
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
 	// Middleware
 	app.Use(recover.New())
-	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3000",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
-		AllowCredentials: true,
-	}))
+	app.Use(middleware.RequestID())
+	app.Use(middleware.ErrorEnvelope())
+	app.Use(middleware.RequestLogger())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Metrics())
+	app.Use(middleware.SecurityHeaders(&cfg.Security))
+	app.Use(middleware.CORS(&cfg.CORS))
 
 	// Initialize services
 	authService := services.NewAuthService(&cfg.JWT)
 	authHandler := handlers.NewAuthHandler(authService)
+	userHandler := handlers.NewUserHandler(authService)
+	apiKeyService := services.NewAPIKeyService(&cfg.APIKey)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	accessService := services.NewAccessService()
+	auditService := services.NewAuditService()
 	portfolioHandler := handlers.NewPortfolioHandler()
-	transactionHandler := handlers.NewTransactionHandler()
+	transactionHandler := handlers.NewTransactionHandler(&cfg.Risk)
 	riskHandler := handlers.NewRiskHandler(&cfg.Risk)
 	alertHandler := handlers.NewAlertHandler()
+	liquidityHandler := handlers.NewLiquidityHandler()
+	marketDepthHandler := handlers.NewMarketDepthHandler()
+	benchmarkHandler := handlers.NewBenchmarkHandler()
+	bondHandler := handlers.NewBondHandler()
+	cryptoHandler := handlers.NewCryptoHandler()
+	exposureHandler := handlers.NewExposureHandler()
+	allocationHandler := handlers.NewAllocationHandler()
 	complianceHandler := handlers.NewComplianceHandler()
+	complianceRuleHandler := handlers.NewComplianceRuleHandler()
+	counterpartyHandler := handlers.NewCounterpartyHandler()
+	symbolListHandler := handlers.NewSymbolListHandler()
+	guidelineHandler := handlers.NewInvestmentGuidelineHandler()
+	kycProfileHandler := handlers.NewKYCProfileHandler()
+	caseHandler := handlers.NewCaseHandler()
+	reportHandler := handlers.NewReportHandler()
+	exportHandler := handlers.NewExportHandler(&cfg.Export, &cfg.Risk)
+	jobHandler := handlers.NewJobHandler(&cfg.Jobs, &cfg.Risk)
+	reconciliationHandler := handlers.NewReconciliationHandler(&cfg.Reconciliation)
+	batchHandler := handlers.NewBatchHandler(&cfg.Batch, &cfg.Risk, &cfg.Compliance)
+	portfolioTemplateHandler := handlers.NewPortfolioTemplateHandler()
+	corporateActionHandler := handlers.NewCorporateActionHandler()
+	tenantHandler := handlers.NewTenantHandler()
+	notificationHandler := handlers.NewNotificationHandler()
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler()
+	healthHandler := handlers.NewHealthHandler()
+	auditHandler := handlers.NewAuditHandler()
+	loggingHandler := handlers.NewLoggingHandler()
+	escalationHandler := handlers.NewEscalationHandler()
+	teamHandler := handlers.NewTeamHandler(accessService)
+	metricsHandler := handlers.NewMetricsHandler(&cfg.Metrics)
+	retentionHandler := handlers.NewRetentionHandler(&cfg.Retention)
+	graphqlHandler := handlers.NewGraphQLHandler()
+	docsHandler := handlers.NewDocsHandler()
+
+	// Background workers run until shutdown, which waits for them to finish
+	workers := lifecycle.New()
+
+	// Deliver alerts to email, Slack and webhook channels, and to users under their preferences
+	notifier := notifications.Init(&cfg.Notification)
+	preferenceService := services.NewNotificationPreferenceService()
+	notifier.SetRecipientResolver(preferenceService)
+	workers.OnShutdown("notifications", notifier.Drain)
+	workers.Go("notification_retries", func(ctx context.Context) {
+		notifier.StartRetryWorker(ctx, cfg.Notification.RetryBaseDelay)
+	})
+	workers.Go("notification_digests", func(ctx context.Context) {
+		preferenceService.StartDigestJob(ctx, cfg.Notification.DigestHour)
+	})
 
-	// Initialize WebSocket hub
-	hub := wsHandler.NewHub()
-	go hub.Run()
+	// Seed and schedule the compliance rule engine
+	ruleService := services.NewComplianceRuleService()
+	if err := ruleService.SeedDefaultRules(); err != nil {
+		logger.Error("Failed to seed default compliance rules", "error", err)
+	}
+	workers.Go("compliance_rules", func(ctx context.Context) {
+		ruleService.StartScheduler(ctx, cfg.Compliance.RuleEvaluationInterval)
+	})
 
-	// Initialize simple WebSocket hub for Fiber WebSocket connections
-	simpleHub := wsHandler.NewSimpleHub()
-	go simpleHub.Run()
+	// Re-run transaction monitoring over recent transactions to catch what rule changes now flag
+	workers.Go("aml_sweep", func(ctx context.Context) {
+		services.NewAMLService().StartSweepScheduler(ctx, cfg.Compliance.AMLSweepInterval, cfg.Compliance.AMLSweepDays)
+	})
 
-	// Health check
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"service": "Financial Risk Monitor API",
+	// Check portfolios against their investment guidelines on the same schedule
+	workers.Go("investment_guidelines", func(ctx context.Context) {
+		services.NewInvestmentGuidelineService().StartScheduler(ctx, cfg.Compliance.RuleEvaluationInterval)
+	})
+
+	// Expire KYC profiles past their review date or with expired documents
+	workers.Go("kyc_expiry", func(ctx context.Context) {
+		services.NewKYCProfileService().StartExpiryJob(ctx, cfg.Compliance.KYCExpiryCheckInterval)
+	})
+
+	// Watch portfolio leverage and margin between on-demand checks
+	workers.Go("leverage_monitor", func(ctx context.Context) {
+		services.NewLeverageService().StartMonitor(ctx, cfg.Risk.LeverageCheckInterval)
+	})
+
+	// Check the DV01 of portfolios holding bonds against their limits
+	workers.Go("interest_rate_monitor", func(ctx context.Context) {
+		services.NewFixedIncomeService().StartMonitor(ctx, cfg.Risk.InterestRateCheckInterval)
+	})
+
+	// Check crypto venue exposure and stablecoin pegs, and prune old crypto price samples
+	workers.Go("crypto_monitor", func(ctx context.Context) {
+		services.NewCryptoRiskService().StartMonitor(ctx, cfg.Risk.CryptoCheckInterval)
+	})
+
+	// Record every portfolio's risk metrics into the risk history, downsampling old snapshots
+	workers.Go("risk_history", func(ctx context.Context) {
+		services.NewRiskHistoryService(&cfg.Risk).StartSnapshotter(ctx, cfg.Risk.HistorySnapshotInterval)
+	})
+
+	// Run the end-of-day batch, which reclassifies liquidity and records NAV snapshots before the
+	// risk snapshots, compliance sweeps and reports that read them; without it those two run alone
+	if cfg.Batch.EODEnabled {
+		batchService := services.NewBatchService(&cfg.Batch, &cfg.Risk, &cfg.Compliance)
+		if interrupted, err := batchService.FailInterrupted(context.Background()); err != nil {
+			logger.Error("Failed to close interrupted batch runs", "error", err)
+		} else if interrupted > 0 {
+			logger.Warn("Closed batch runs interrupted by a restart", "runs", interrupted)
+		}
+		workers.Go("eod_batch", func(ctx context.Context) {
+			batchService.StartScheduler(ctx, cfg.Batch.EODHour)
+		})
+	} else {
+		// Reclassify position liquidity from symbol market data every night
+		workers.Go("liquidity_classification", func(ctx context.Context) {
+			services.NewLiquidityService().StartScheduler(ctx, cfg.Risk.LiquidityClassificationHour)
 		})
+
+		// Record every portfolio's end-of-day NAV, cash and positions into its NAV history
+		workers.Go("nav_snapshots", func(ctx context.Context) {
+			services.NewPortfolioSnapshotService().StartScheduler(ctx, cfg.Risk.NAVSnapshotHour)
+		})
+	}
+
+	// Check daily and weekly losses and the drawdown from the NAV peak against loss limits
+	workers.Go("drawdown_monitor", func(ctx context.Context) {
+		services.NewDrawdownService().StartMonitor(ctx, cfg.Risk.DrawdownCheckInterval)
+	})
+
+	// Recalculate the VaR and liquidity of portfolios queued by sharp price moves and large fills
+	workers.Go("intraday_risk", func(ctx context.Context) {
+		services.NewIntradayRiskService(&cfg.Risk).StartRecalculator(ctx)
+	})
+
+	// Check the exposure to each symbol across the firm against its firm-wide limit
+	workers.Go("firm_exposure_monitor", func(ctx context.Context) {
+		services.NewExposureService().StartMonitor(ctx, cfg.Risk.FirmExposureCheckInterval)
+	})
+
+	// Escalate alerts nobody has acknowledged through the tiers of their escalation policy
+	workers.Go("alert_escalation", func(ctx context.Context) {
+		services.NewEscalationService().StartScheduler(ctx, cfg.Alert.EscalationCheckInterval)
+	})
+
+	// Convert foreign positions into portfolio currencies at cached FX rates
+	if _, err := fx.Init(&cfg.FX); err != nil {
+		fatal("Failed to configure FX rates", err)
+	}
+	workers.Go("fx_monitor", func(ctx context.Context) {
+		services.NewCurrencyService().StartMonitor(ctx, cfg.FX.RevaluationInterval)
+	})
+
+	// Purge soft deleted records once they are past the retention period
+	workers.Go("retention_purge", func(ctx context.Context) {
+		services.NewRetentionService(&cfg.Retention).StartPurgeJob(ctx, cfg.Retention.PurgeInterval)
+	})
+
+	// Delete export files once they expire
+	workers.Go("export_purge", func(ctx context.Context) {
+		services.NewExportService(&cfg.Export).StartPurgeJob(ctx, cfg.Export.PurgeInterval)
 	})
 
+	// Run the queued Monte Carlo VaR, backtest and report jobs
+	workers.Go("job_workers", func(ctx context.Context) {
+		services.NewJobService(&cfg.Jobs, &cfg.Risk).StartWorkers(ctx)
+	})
+
+	// Reconcile positions against the custodian's daily feed and the files dropped in the inbox
+	feed, err := reconciliation.NewFeed(&cfg.Reconciliation)
+	if err != nil {
+		fatal("Failed to configure the custodian feed", err)
+	}
+	if feed != nil {
+		workers.Go("reconciliation_fetch", func(ctx context.Context) {
+			services.NewReconciliationService(&cfg.Reconciliation).StartFetcher(ctx, feed, cfg.Reconciliation.FetchHour)
+		})
+	}
+	if cfg.Reconciliation.InboxDir != "" {
+		inbox := reconciliation.NewInbox(cfg.Reconciliation.InboxDir)
+		workers.Go("reconciliation_inbox", func(ctx context.Context) {
+			services.NewReconciliationService(&cfg.Reconciliation).StartInboxWatcher(ctx, inbox, cfg.Reconciliation.PollInterval)
+		})
+	}
+
+	// Initialize the WebSocket gateway
+	hub := wsHandler.NewHub(&cfg.WS)
+	hub.SetPortfolioLister(portfolioLister(accessService))
+	workers.Go("websocket_hub", hub.Run)
+	streamHandler := handlers.NewStreamHandler(hub)
+	connectionHandler := handlers.NewConnectionHandler(hub)
+
+	// Relay alerts, risk, order and job updates published by any instance to local WebSocket clients
+	redisBridge := wsHandler.NewRedisBridge(database.GetRedis(), hub)
+	workers.Go("redis_bridge", redisBridge.Run)
+
+	// Ingest market prices into Redis, positions and WebSocket clients, and snapshot order books
+	priceIngestor, err := marketdata.NewIngestor(&cfg.PriceFeed)
+	if err != nil {
+		fatal("Failed to configure price feed", err)
+	}
+	if priceIngestor != nil {
+		workers.Go("price_ingestor", priceIngestor.Run)
+		workers.Go("market_depth_capture", func(ctx context.Context) {
+			priceIngestor.CaptureDepth(ctx, cfg.PriceFeed.DepthInterval)
+		})
+	}
+
+	// gRPC API for internal integrations, sharing the services of the REST handlers
+	if cfg.GRPC.Enabled {
+		grpcServer := grpcapi.NewServer(authService, apiKeyService, &cfg.Risk)
+		workers.Go("grpc_server", func(ctx context.Context) {
+			if err := grpcServer.ListenAndServe(ctx, cfg.GRPC.Port); err != nil {
+				fatal("Failed to start gRPC server", err)
+			}
+		})
+	}
+
+	// Stream transactions in from Kafka and alerts and risk updates out to it
+	streamer, err := streaming.NewStreamer(&cfg.Kafka, &cfg.Risk)
+	if err != nil {
+		fatal("Failed to configure Kafka streaming", err)
+	}
+	if streamer != nil {
+		workers.Go("kafka_streamer", streamer.Run)
+	}
+
+	// Health check
+	app.Get("/health", healthHandler.Check)
+
+	// Prometheus scrape endpoint
+	if cfg.Metrics.Enabled {
+		app.Get("/metrics", metricsHandler.GetMetrics)
+	}
+
 	// Serve dashboard at root
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendFile("./tests/mock_data_dashboard.html")
@@ -87,58 +348,423 @@ func main() {
 	// API routes
 	api := app.Group("/api/v1")
 
+	// OpenAPI document (public), regenerated with go generate ./internal/openapi
+	api.Get("/docs", docsHandler.GetSpec)
+
 	// Auth routes (public)
 	auth := api.Group("/auth")
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
+	auth.Post("/refresh", authHandler.Refresh)
+	auth.Post("/change-password", authHandler.ChangePassword)
+
+	// Server-Sent Events fallback for the WebSocket gateway, authenticated like it with ?token= or a
+	// Bearer token since EventSource cannot set headers
+	api.Get("/stream", middleware.WebSocketAuthMiddleware(authService), streamHandler.Stream)
+
+	// Protected routes, for users with a Bearer token or integrations with an X-API-Key
+	protected := api.Group("/", middleware.Authenticate(authService, apiKeyService), middleware.AuditTrail(auditService))
+	protected.Post("/auth/logout", authHandler.Logout)
+
+	// User administration routes
+	users := protected.Group("/users", middleware.RequirePermission(middleware.PermUserManage))
+	users.Put("/:id/status", authHandler.SetUserStatus)
+	users.Post("/:id/revoke-sessions", authHandler.RevokeUserSessions)
+
+	// API key administration routes
+	apiKeys := protected.Group("/api-keys", middleware.RequirePermission(middleware.PermAPIKeyManage))
+	apiKeys.Get("/", apiKeyHandler.GetAPIKeys)
+	apiKeys.Post("/", apiKeyHandler.CreateAPIKey)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeAPIKey)
 
-	// Protected routes
-	protected := api.Group("/", middleware.JWTMiddleware(authService))
+	canAccessPortfolio := middleware.PortfolioAccess(accessService, "id")
+	canModifyPortfolio := middleware.PortfolioModify(accessService, "id")
+	idempotent := middleware.Idempotency(database.GetRedis(), cfg.Idempotency.Window)
+	recordsRestore := middleware.RequirePermission(middleware.PermRecordsRestore)
+
+	// Soft deleted records awaiting restore or the retention purge
+	protected.Get("/deleted/:type", recordsRestore, retentionHandler.GetDeleted)
+
+	// GraphQL dashboard queries; each field checks its own permission
+	protected.Post("/graphql", graphqlHandler.Query)
 
 	// Portfolio routes
 	portfolios := protected.Group("/portfolios")
-	portfolios.Get("/", portfolioHandler.GetPortfolios)
-	portfolios.Get("/:id", portfolioHandler.GetPortfolio)
-	portfolios.Post("/", portfolioHandler.CreatePortfolio)
-	portfolios.Put("/:id", portfolioHandler.UpdatePortfolio)
-	portfolios.Delete("/:id", portfolioHandler.DeletePortfolio)
+	portfolioRead := middleware.RequirePermission(middleware.PermPortfolioRead)
+	portfolioWrite := middleware.RequirePermission(middleware.PermPortfolioWrite)
+	portfolios.Get("/", portfolioRead, portfolioHandler.GetPortfolios)
+	portfolios.Get("/:id", portfolioRead, canAccessPortfolio, portfolioHandler.GetPortfolio)
+	portfolios.Post("/", portfolioWrite, idempotent, portfolioHandler.CreatePortfolio)
+	portfolios.Put("/:id", portfolioWrite, portfolioHandler.UpdatePortfolio)
+	portfolios.Delete("/:id", portfolioWrite, portfolioHandler.DeletePortfolio)
+	portfolios.Post("/:id/restore", recordsRestore, portfolioHandler.RestorePortfolio)
+	portfolios.Post("/:id/clone", portfolioWrite, canAccessPortfolio, idempotent, portfolioHandler.ClonePortfolio)
 
 	// Position routes
-	portfolios.Get("/:id/positions", portfolioHandler.GetPositions)
-	portfolios.Post("/:id/positions", portfolioHandler.AddPosition)
-	portfolios.Put("/:id/positions/:positionId", portfolioHandler.UpdatePosition)
-	portfolios.Delete("/:id/positions/:positionId", portfolioHandler.DeletePosition)
+	portfolios.Get("/:id/positions", portfolioRead, canAccessPortfolio, portfolioHandler.GetPositions)
+	portfolios.Get("/:id/summary", portfolioRead, canAccessPortfolio, portfolioHandler.GetSummary)
+	portfolios.Get("/:id/pnl", portfolioRead, canAccessPortfolio, portfolioHandler.GetPnL)
+	portfolios.Get("/:id/nav-history", portfolioRead, canAccessPortfolio, portfolioHandler.GetNAVHistory)
+	portfolios.Get("/:id/performance", portfolioRead, canAccessPortfolio, portfolioHandler.GetPerformance)
+	portfolios.Get("/:id/cash", portfolioRead, canAccessPortfolio, portfolioHandler.GetCash)
+	portfolios.Get("/:id/cash/ledger", portfolioRead, canAccessPortfolio, portfolioHandler.GetCashLedger)
+	portfolios.Get("/:id/lots", portfolioRead, canAccessPortfolio, portfolioHandler.GetLots)
+	portfolios.Get("/:id/realized-gains", portfolioRead, canAccessPortfolio, portfolioHandler.GetRealizedGains)
+	portfolios.Get("/:id/activity", portfolioRead, canAccessPortfolio, portfolioHandler.GetActivity)
+	portfolios.Post("/:id/simulate", portfolioRead, canAccessPortfolio, portfolioHandler.Simulate)
+	portfolios.Post("/:id/positions", portfolioWrite, portfolioHandler.AddPosition)
+	portfolios.Put("/:id/positions/:positionId", portfolioWrite, portfolioHandler.UpdatePosition)
+	portfolios.Delete("/:id/positions/:positionId", portfolioWrite, portfolioHandler.DeletePosition)
+
+	// Position liquidity override routes
+	liquidityManage := middleware.RequirePermission(middleware.PermLiquidityManage)
+	portfolios.Put("/:id/positions/:positionId/liquidity", liquidityManage, canAccessPortfolio, liquidityHandler.SetLiquidityOverride)
+	portfolios.Delete("/:id/positions/:positionId/liquidity", liquidityManage, canAccessPortfolio, liquidityHandler.ClearLiquidityOverride)
+
+	// Crypto custody routes
+	portfolios.Get("/:id/crypto-custody", portfolioRead, canAccessPortfolio, cryptoHandler.GetCustody)
+	portfolios.Put("/:id/crypto-custody", portfolioWrite, canModifyPortfolio, cryptoHandler.SetCustody)
+
+	// Target allocation routes
+	portfolios.Get("/:id/target-allocation", portfolioRead, canAccessPortfolio, allocationHandler.GetTargets)
+	portfolios.Put("/:id/target-allocation", portfolioWrite, canModifyPortfolio, allocationHandler.SetTargets)
+	portfolios.Get("/:id/allocation-drift", portfolioRead, canAccessPortfolio, allocationHandler.GetDrift)
+
+	// Risk threshold routes; changes name the version they were made against
+	portfolios.Get("/:id/risk-thresholds", portfolioRead, canAccessPortfolio, riskHandler.GetThresholds)
+	portfolios.Put("/:id/risk-thresholds", portfolioWrite, canModifyPortfolio, riskHandler.UpdateThresholds)
+
+	// Portfolio supervisor routes
+	portfolioAssign := middleware.RequirePermission(middleware.PermPortfolioAssign)
+	portfolios.Get("/:id/supervisors", portfolioAssign, portfolioHandler.GetSupervisors)
+	portfolios.Post("/:id/supervisors", portfolioAssign, portfolioHandler.AddSupervisor)
+	portfolios.Delete("/:id/supervisors/:userId", portfolioAssign, portfolioHandler.RemoveSupervisor)
+	portfolios.Put("/:id/team", portfolioAssign, portfolioHandler.SetTeam)
+
+	// Portfolio hierarchy: books, strategies and sub-portfolios
+	portfolios.Get("/:id/hierarchy", portfolioRead, canAccessPortfolio, portfolioHandler.GetHierarchy)
+	portfolios.Put("/:id/parent", portfolioWrite, canModifyPortfolio, portfolioHandler.SetParent)
 
 	// Transaction routes
 	transactions := protected.Group("/transactions")
-	transactions.Get("/", transactionHandler.GetTransactions)
-	transactions.Get("/:id", transactionHandler.GetTransaction)
-	transactions.Post("/", transactionHandler.CreateTransaction)
-	transactions.Put("/:id", transactionHandler.UpdateTransaction)
-	transactions.Put("/:id/status", transactionHandler.UpdateTransactionStatus)
-	transactions.Delete("/:id", transactionHandler.DeleteTransaction)
+	transactions.Get("/", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetTransactions)
+	transactions.Get("/search", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.SearchTransactions)
+	transactions.Get("/export", middleware.RequirePermission(middleware.PermTransactionRead), exportHandler.ExportTransactions)
+	transactions.Post("/export", middleware.RequirePermission(middleware.PermTransactionRead), exportHandler.StartTransactionExport)
+	transactions.Post("/import", middleware.RequirePermission(middleware.PermTransactionWrite), transactionHandler.ImportTransactions)
+	transactions.Get("/imports/:id", middleware.RequirePermission(middleware.PermTransactionWrite), transactionHandler.GetImport)
+	transactions.Get("/amendments", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetAmendments)
+	transactions.Get("/amendments/:id", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetAmendment)
+	transactions.Post("/amendments/:id/approve", middleware.RequirePermission(middleware.PermTransactionApprove), transactionHandler.DecideAmendment)
+	transactions.Get("/:id", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetTransaction)
+	transactions.Post("/", middleware.RequirePermission(middleware.PermTransactionWrite), idempotent, transactionHandler.CreateTransaction)
+	transactions.Put("/:id", middleware.RequirePermission(middleware.PermTransactionWrite), transactionHandler.UpdateTransaction)
+	transactions.Put("/:id/status", middleware.RequirePermission(middleware.PermTransactionApprove), transactionHandler.UpdateTransactionStatus)
+	transactions.Post("/:id/approve", middleware.RequirePermission(middleware.PermTransactionApprove), transactionHandler.ApproveTransaction)
+	transactions.Put("/:id/order-status", middleware.RequirePermission(middleware.PermTransactionApprove), transactionHandler.UpdateOrderStatus)
+	transactions.Get("/:id/fills", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetFills)
+	transactions.Get("/:id/lots", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetTransactionLots)
+	transactions.Post("/:id/fills", middleware.RequirePermission(middleware.PermTransactionApprove), idempotent, transactionHandler.RecordFill)
+	transactions.Get("/:id/amendments", middleware.RequirePermission(middleware.PermTransactionRead), transactionHandler.GetTransactionAmendments)
+	transactions.Post("/:id/amendments", middleware.RequirePermission(middleware.PermTransactionWrite), transactionHandler.RequestAmendment)
+	transactions.Delete("/:id", middleware.RequirePermission(middleware.PermTransactionDelete), transactionHandler.DeleteTransaction)
+	transactions.Post("/:id/restore", recordsRestore, transactionHandler.RestoreTransaction)
 
 	// Risk metrics routes
-	risk := protected.Group("/risk")
-	risk.Get("/portfolio/:id/metrics", riskHandler.GetRiskMetrics)
-	risk.Get("/portfolio/:id/var", riskHandler.CalculateVAR)
-	risk.Get("/portfolio/:id/liquidity", riskHandler.CalculateLiquidityRisk)
-	risk.Get("/portfolio/:id/history", riskHandler.GetRiskHistory)
+	risk := protected.Group("/risk", middleware.RequirePermission(middleware.PermRiskRead))
+	risk.Get("/summary", riskHandler.GetSummary)
+	risk.Get("/portfolio/:id/metrics", canAccessPortfolio, riskHandler.GetRiskMetrics)
+	risk.Get("/portfolio/:id/metrics/latest", canAccessPortfolio, riskHandler.GetLatestRiskMetrics)
+	risk.Get("/portfolio/:id/metrics/:metricId/market-depth", canAccessPortfolio, marketDepthHandler.GetMetricMarketDepth)
+	risk.Get("/portfolio/:id/var", canAccessPortfolio, riskHandler.CalculateVAR)
+	risk.Post("/portfolio/:id/var", canAccessPortfolio, jobHandler.StartVaRJob)
+	risk.Get("/portfolio/:id/var/contributions", canAccessPortfolio, riskHandler.GetVaRContributions)
+	risk.Get("/portfolio/:id/liquidity", canAccessPortfolio, riskHandler.CalculateLiquidityRisk)
+	risk.Get("/portfolio/:id/liquidity/positions", canAccessPortfolio, liquidityHandler.GetPortfolioLiquidity)
+	risk.Get("/portfolio/:id/liquidation-schedule", canAccessPortfolio, liquidityHandler.GetLiquidationSchedule)
+	risk.Post("/portfolio/:id/liquidity/classify", liquidityManage, canAccessPortfolio, liquidityHandler.ClassifyPortfolio)
+	risk.Get("/market-data", liquidityHandler.GetMarketData)
+	risk.Put("/market-data/:symbol", liquidityManage, liquidityHandler.UpdateMarketData)
+	risk.Get("/market-depth/:symbol", marketDepthHandler.GetMarketDepth)
+	risk.Get("/market-depth/:symbol/latest", marketDepthHandler.GetLatestMarketDepth)
+	risk.Get("/benchmarks/:symbol/prices", benchmarkHandler.GetPrices)
+	risk.Post("/benchmarks/:symbol/prices", liquidityManage, benchmarkHandler.RecordPrices)
+	risk.Get("/bonds", bondHandler.GetBonds)
+	risk.Put("/bonds/:symbol", liquidityManage, bondHandler.UpsertBond)
+	risk.Delete("/bonds/:symbol", liquidityManage, bondHandler.DeleteBond)
+	risk.Get("/crypto-assets", cryptoHandler.GetAssets)
+	risk.Put("/crypto-assets/:symbol", liquidityManage, cryptoHandler.UpsertAsset)
+	risk.Delete("/crypto-assets/:symbol", liquidityManage, cryptoHandler.DeleteAsset)
+	risk.Post("/liquidity/classify", liquidityManage, liquidityHandler.ClassifyAll)
+	risk.Get("/portfolio/:id/concentration", canAccessPortfolio, riskHandler.CalculateConcentration)
+	risk.Get("/portfolio/:id/history", canAccessPortfolio, riskHandler.GetRiskHistory)
+	risk.Get("/portfolio/:id/history/export", canAccessPortfolio, exportHandler.ExportRiskHistory)
+	risk.Post("/portfolio/:id/history/export", canAccessPortfolio, exportHandler.StartRiskHistoryExport)
+	risk.Get("/portfolio/:id/backtest", canAccessPortfolio, riskHandler.GetBacktest)
+	risk.Post("/portfolio/:id/backtest", canAccessPortfolio, jobHandler.StartBacktestJob)
+	risk.Get("/portfolio/:id/leverage", canAccessPortfolio, riskHandler.GetLeverage)
+	risk.Get("/portfolio/:id/drawdown", canAccessPortfolio, riskHandler.GetDrawdown)
+	risk.Get("/portfolio/:id/currency-exposure", canAccessPortfolio, riskHandler.GetCurrencyExposure)
+	risk.Get("/portfolio/:id/interest-rate-risk", canAccessPortfolio, riskHandler.GetInterestRateRisk)
+	risk.Get("/portfolio/:id/crypto-risk", canAccessPortfolio, riskHandler.GetCryptoRisk)
+	risk.Get("/portfolio/:id/limit-utilization", canAccessPortfolio, riskHandler.GetLimitUtilization)
+	risk.Get("/portfolio/:id/rollup", canAccessPortfolio, riskHandler.GetRollup)
+
+	// Firm-wide exposure to a symbol across portfolios and its limits
+	exposureManage := middleware.RequirePermission(middleware.PermComplianceManage)
+	risk.Get("/exposure", exposureHandler.GetSymbolExposure)
+	risk.Get("/exposure-limits", exposureHandler.GetExposureLimits)
+	risk.Put("/exposure-limits/:symbol", exposureManage, exposureHandler.SetExposureLimit)
+	risk.Delete("/exposure-limits/:symbol", exposureManage, exposureHandler.DeleteExposureLimit)
 
 	// Alert routes
 	alerts := protected.Group("/alerts")
-	alerts.Get("/", alertHandler.GetAlerts)
-	alerts.Get("/active", alertHandler.GetActiveAlerts)
-	alerts.Get("/:id", alertHandler.GetAlert)
-	alerts.Put("/:id/acknowledge", alertHandler.AcknowledgeAlert)
-	alerts.Put("/:id/resolve", alertHandler.ResolveAlert)
-	alerts.Delete("/:id", alertHandler.DeleteAlert)
+	canAccessAlert := middleware.AlertAccess(accessService, "id")
+	alerts.Get("/", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetAlerts)
+	alerts.Get("/active", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetActiveAlerts)
+	alerts.Get("/counts", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetAlertCounts)
+	alerts.Get("/export", middleware.RequirePermission(middleware.PermAlertRead), exportHandler.ExportAlerts)
+	alerts.Post("/export", middleware.RequirePermission(middleware.PermAlertRead), exportHandler.StartAlertExport)
+	alerts.Post("/bulk", middleware.RequirePermission(middleware.PermAlertManage), alertHandler.BulkAlerts)
+	alerts.Post("/portfolio/:id/acknowledge", middleware.RequirePermission(middleware.PermAlertManage), canAccessPortfolio, alertHandler.AcknowledgePortfolioAlerts)
+	alerts.Get("/suppressions", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetSuppressions)
+	alerts.Post("/suppressions", middleware.RequirePermission(middleware.PermAlertManage), alertHandler.CreateSuppression)
+	alerts.Get("/suppressions/:id", middleware.RequirePermission(middleware.PermAlertRead), alertHandler.GetSuppression)
+	alerts.Delete("/suppressions/:id", middleware.RequirePermission(middleware.PermAlertManage), alertHandler.CancelSuppression)
+	alerts.Get("/:id", middleware.RequirePermission(middleware.PermAlertRead), canAccessAlert, alertHandler.GetAlert)
+	alerts.Put("/:id/acknowledge", middleware.RequirePermission(middleware.PermAlertManage), canAccessAlert, alertHandler.AcknowledgeAlert)
+	alerts.Put("/:id/resolve", middleware.RequirePermission(middleware.PermAlertManage), canAccessAlert, alertHandler.ResolveAlert)
+	alerts.Delete("/:id", middleware.RequirePermission(middleware.PermAlertDelete), canAccessAlert, alertHandler.DeleteAlert)
+	alerts.Post("/:id/restore", recordsRestore, alertHandler.RestoreAlert)
+
+	// Alert escalation routes
+	escalationRead := middleware.RequirePermission(middleware.PermAlertRead)
+	escalationManage := middleware.RequirePermission(middleware.PermEscalationManage)
+	teams := protected.Group("/teams")
+	teams.Get("/", escalationRead, escalationHandler.GetTeams)
+	teams.Post("/", escalationManage, escalationHandler.CreateTeam)
+	teams.Get("/:id", escalationRead, escalationHandler.GetTeam)
+	teams.Put("/:id", escalationManage, escalationHandler.UpdateTeam)
+	teams.Delete("/:id", escalationManage, escalationHandler.DeleteTeam)
+	teams.Get("/:id/shifts", escalationRead, escalationHandler.GetShifts)
+	teams.Post("/:id/shifts", escalationManage, escalationHandler.CreateShift)
+	teams.Delete("/:id/shifts/:shiftId", escalationManage, escalationHandler.DeleteShift)
+
+	// Team membership routes; members share the portfolios their team owns
+	teams.Get("/:id/members", escalationRead, teamHandler.GetMembers)
+	teams.Put("/:id/members/:userId", portfolioAssign, teamHandler.SetMember)
+	teams.Delete("/:id/members/:userId", portfolioAssign, teamHandler.RemoveMember)
+	escalationPolicies := protected.Group("/escalation-policies")
+	escalationPolicies.Get("/", escalationRead, escalationHandler.GetPolicies)
+	escalationPolicies.Post("/", escalationManage, escalationHandler.CreatePolicy)
+	escalationPolicies.Get("/:id", escalationRead, escalationHandler.GetPolicy)
+	escalationPolicies.Put("/:id", escalationManage, escalationHandler.UpdatePolicy)
+	escalationPolicies.Delete("/:id", escalationManage, escalationHandler.DeletePolicy)
 
 	// Compliance routes
 	compliance := protected.Group("/compliance")
-	compliance.Get("/portfolio/:id/check", complianceHandler.CheckCompliance)
-	compliance.Get("/portfolio/:id/position-limits", complianceHandler.CheckPositionLimits)
-	compliance.Post("/transaction/:id/aml-check", complianceHandler.CheckAML)
+	canAccessTransaction := middleware.TransactionAccess(accessService, "id")
+	complianceRead := middleware.RequirePermission(middleware.PermComplianceRead)
+	complianceScreen := middleware.RequirePermission(middleware.PermComplianceScreen)
+	complianceManage := middleware.RequirePermission(middleware.PermComplianceManage)
+	compliance.Get("/portfolio/:id/check", complianceRead, canAccessPortfolio, complianceHandler.CheckCompliance)
+	compliance.Get("/portfolio/:id/position-limits", complianceRead, canAccessPortfolio, complianceHandler.CheckPositionLimits)
+	compliance.Post("/transaction/:id/aml-check", complianceScreen, canAccessTransaction, complianceHandler.CheckAML)
+	compliance.Get("/transaction/:id/screenings", complianceRead, canAccessTransaction, complianceHandler.GetTransactionScreenings)
+	compliance.Get("/transaction/:id/monitoring", complianceRead, canAccessTransaction, complianceHandler.GetTransactionMonitoring)
+	compliance.Post("/aml-sweep", complianceManage, complianceHandler.AMLSweep)
+	compliance.Post("/screen", complianceScreen, complianceHandler.ScreenName)
+	compliance.Get("/sanctions", complianceRead, complianceHandler.GetSanctionsEntries)
+	compliance.Post("/sanctions/import", complianceManage, complianceHandler.ImportSanctionsList)
+
+	// Pre-trade compliance gateway for order flows
+	compliance.Post("/pre-trade-check", complianceRead, complianceHandler.PreTradeCheck)
+
+	// Restricted and watch list routes (:list is restricted or watch)
+	compliance.Get("/symbol-lists/:list", complianceRead, symbolListHandler.GetEntries)
+	compliance.Post("/symbol-lists/:list", complianceManage, symbolListHandler.CreateEntry)
+	compliance.Get("/symbol-lists/:list/:id", complianceRead, symbolListHandler.GetEntry)
+	compliance.Put("/symbol-lists/:list/:id", complianceManage, symbolListHandler.UpdateEntry)
+	compliance.Delete("/symbol-lists/:list/:id", complianceManage, symbolListHandler.DeleteEntry)
+
+	// Investment guideline (mandate) routes
+	compliance.Get("/portfolio/:id/guideline", complianceRead, canAccessPortfolio, guidelineHandler.GetGuideline)
+	compliance.Put("/portfolio/:id/guideline", complianceManage, canAccessPortfolio, guidelineHandler.SaveGuideline)
+	compliance.Delete("/portfolio/:id/guideline", complianceManage, canAccessPortfolio, guidelineHandler.DeleteGuideline)
+	compliance.Post("/portfolio/:id/guideline/evaluate", complianceScreen, canAccessPortfolio, guidelineHandler.EvaluateGuideline)
+
+	// Portfolio template routes; compliance manages the templates, which carry guidelines and thresholds
+	portfolioTemplates := protected.Group("/portfolio-templates")
+	portfolioTemplates.Get("/", portfolioRead, portfolioTemplateHandler.GetPortfolioTemplates)
+	portfolioTemplates.Post("/", complianceManage, portfolioTemplateHandler.CreatePortfolioTemplate)
+	portfolioTemplates.Get("/:id", portfolioRead, portfolioTemplateHandler.GetPortfolioTemplate)
+	portfolioTemplates.Put("/:id", complianceManage, portfolioTemplateHandler.UpdatePortfolioTemplate)
+	portfolioTemplates.Delete("/:id", complianceManage, portfolioTemplateHandler.DeletePortfolioTemplate)
+	portfolioTemplates.Post("/:id/portfolios", portfolioWrite, idempotent, portfolioTemplateHandler.CreatePortfolioFromTemplate)
+
+	// Corporate action routes: splits, dividends and symbol changes applied to every holder
+	corporateActions := protected.Group("/corporate-actions")
+	corporateActions.Get("/", portfolioRead, corporateActionHandler.GetCorporateActions)
+	corporateActions.Post("/", liquidityManage, corporateActionHandler.CreateCorporateAction)
+	corporateActions.Get("/:id", portfolioRead, corporateActionHandler.GetCorporateAction)
+	corporateActions.Delete("/:id", liquidityManage, corporateActionHandler.DeleteCorporateAction)
+	corporateActions.Get("/:id/preview", liquidityManage, corporateActionHandler.PreviewCorporateAction)
+	corporateActions.Get("/:id/adjustments", liquidityManage, corporateActionHandler.GetCorporateActionAdjustments)
+	corporateActions.Post("/:id/apply", liquidityManage, corporateActionHandler.ApplyCorporateAction)
+	corporateActions.Post("/:id/rollback", liquidityManage, corporateActionHandler.RollbackCorporateAction)
+
+	// KYC profile routes for users and counterparties
+	compliance.Get("/kyc-profiles", complianceRead, kycProfileHandler.GetProfiles)
+	compliance.Post("/kyc-profiles", complianceManage, kycProfileHandler.CreateProfile)
+	compliance.Get("/kyc-profiles/due", complianceRead, kycProfileHandler.GetDueForReview)
+	compliance.Get("/kyc-profiles/:id", complianceRead, kycProfileHandler.GetProfile)
+	compliance.Put("/kyc-profiles/:id", complianceManage, kycProfileHandler.UpdateProfile)
+	compliance.Post("/kyc-profiles/:id/review", complianceScreen, kycProfileHandler.ReviewProfile)
+	compliance.Delete("/kyc-profiles/:id", complianceManage, kycProfileHandler.DeleteProfile)
+
+	// Compliance rule routes
+	compliance.Get("/rules", complianceRead, complianceRuleHandler.GetRules)
+	compliance.Post("/rules", complianceManage, complianceRuleHandler.CreateRule)
+	compliance.Post("/rules/evaluate", complianceManage, complianceRuleHandler.EvaluateRules)
+	compliance.Get("/rules/:id", complianceRead, complianceRuleHandler.GetRule)
+	compliance.Put("/rules/:id", complianceManage, complianceRuleHandler.UpdateRule)
+	compliance.Delete("/rules/:id", complianceManage, complianceRuleHandler.DeleteRule)
+
+	// Counterparty routes
+	compliance.Get("/counterparties", complianceRead, counterpartyHandler.GetCounterparties)
+	compliance.Post("/counterparties", complianceManage, counterpartyHandler.CreateCounterparty)
+	compliance.Get("/counterparties/exposure", complianceScreen, counterpartyHandler.GetExposures)
+	compliance.Get("/counterparties/:id", complianceRead, counterpartyHandler.GetCounterparty)
+	compliance.Put("/counterparties/:id", complianceManage, counterpartyHandler.UpdateCounterparty)
+	compliance.Delete("/counterparties/:id", complianceManage, counterpartyHandler.DeleteCounterparty)
+	compliance.Get("/counterparties/:id/exposure", complianceScreen, counterpartyHandler.GetExposure)
+
+	// Position reconciliation routes
+	reconciliationRoutes := protected.Group("/reconciliation", complianceManage)
+	reconciliationRoutes.Get("/runs", reconciliationHandler.GetRuns)
+	reconciliationRoutes.Post("/runs", reconciliationHandler.UploadPositions)
+	reconciliationRoutes.Get("/runs/:id", reconciliationHandler.GetRun)
+	reconciliationRoutes.Get("/breaks", reconciliationHandler.GetBreaks)
+	reconciliationRoutes.Put("/breaks/:id", reconciliationHandler.UpdateBreak)
+
+	// SAR case management routes
+	cases := protected.Group("/cases")
+	caseRead := middleware.RequirePermission(middleware.PermCaseRead)
+	caseManage := middleware.RequirePermission(middleware.PermCaseManage)
+	cases.Get("/", caseRead, caseHandler.GetCases)
+	cases.Post("/", middleware.RequirePermission(middleware.PermCaseEscalate), caseHandler.EscalateCase)
+	cases.Get("/:id", caseRead, caseHandler.GetCase)
+	cases.Put("/:id", caseManage, caseHandler.UpdateCase)
+	cases.Put("/:id/assign", caseManage, caseHandler.AssignCase)
+	cases.Put("/:id/status", caseManage, caseHandler.UpdateCaseStatus)
+	cases.Post("/:id/notes", caseManage, caseHandler.AddCaseNote)
+	cases.Post("/:id/transactions", caseManage, caseHandler.AddCaseTransactions)
+	cases.Post("/:id/evidence", caseManage, caseHandler.UploadEvidence)
+	cases.Get("/:id/evidence/:evidenceId", caseRead, caseHandler.DownloadEvidence)
+	cases.Get("/:id/report", caseRead, caseHandler.ExportSARReport)
+
+	// Report routes
+	reportRoutes := protected.Group("/reports")
+	reportRead := middleware.RequirePermission(middleware.PermReportRead)
+	reportRoutes.Get("/", reportRead, reportHandler.GetReports)
+	reportRoutes.Post("/portfolio/:id", middleware.RequirePermission(middleware.PermReportGenerate), canAccessPortfolio, reportHandler.GenerateReport)
+	reportRoutes.Post("/portfolio/:id/jobs", middleware.RequirePermission(middleware.PermReportGenerate), canAccessPortfolio, jobHandler.StartReportJob)
+	reportRoutes.Get("/:id", reportRead, reportHandler.GetReport)
+	reportRoutes.Get("/:id/download", reportRead, reportHandler.DownloadReport)
+
+	// Export job routes; a job is only visible to the user who started it
+	exports := protected.Group("/exports")
+	exports.Get("/:id", exportHandler.GetExport)
+	exports.Get("/:id/download", exportHandler.DownloadExport)
+
+	// Background job routes; a job is only visible to the user who started it
+	jobs := protected.Group("/jobs")
+	jobs.Get("/", jobHandler.GetJobs)
+	jobs.Get("/:id", jobHandler.GetJob)
+
+	// Audit trail routes
+	audit := protected.Group("/audit", middleware.RequirePermission(middleware.PermAuditRead))
+	audit.Get("/", auditHandler.GetAuditLogs)
+	audit.Get("/export", auditHandler.ExportAuditLogs)
+
+	// Notification routes
+	notificationRoutes := protected.Group("/notifications", middleware.RequirePermission(middleware.PermNotificationManage))
+	notificationRoutes.Get("/channels", notificationHandler.GetChannels)
+	notificationRoutes.Post("/channels", notificationHandler.CreateChannel)
+	notificationRoutes.Put("/channels/:id", notificationHandler.UpdateChannel)
+	notificationRoutes.Delete("/channels/:id", notificationHandler.DeleteChannel)
+	notificationRoutes.Post("/channels/:id/test", notificationHandler.TestChannel)
+	notificationRoutes.Get("/deliveries", notificationHandler.GetDeliveries)
+	notificationRoutes.Post("/deliveries/:id/retry", notificationHandler.RetryDelivery)
+	notificationRoutes.Post("/deliveries/:id/redeliver", notificationHandler.RedeliverDelivery)
+
+	// Notification preferences and digest of the user making the request
+	notificationPreferences := protected.Group("/notification-preferences")
+	notificationPreferences.Get("/", notificationPreferenceHandler.GetPreferences)
+	notificationPreferences.Put("/", notificationPreferenceHandler.UpdatePreferences)
+	notificationPreferences.Delete("/", notificationPreferenceHandler.DeletePreferences)
+	notificationPreferences.Get("/digest", notificationPreferenceHandler.GetDigest)
+
+	// End-of-day batch routes
+	batchRoutes := protected.Group("/batch", middleware.RequirePermission(middleware.PermSystemManage))
+	batchRoutes.Get("/runs", batchHandler.GetBatchRuns)
+	batchRoutes.Post("/runs", batchHandler.StartBatchRun)
+	batchRoutes.Get("/runs/:id", batchHandler.GetBatchRun)
+
+	// Runtime administration routes
+	admin := protected.Group("/admin", middleware.RequirePermission(middleware.PermSystemManage))
+	admin.Get("/log-level", loggingHandler.GetLogLevel)
+	admin.Put("/log-level", loggingHandler.SetLogLevel)
+
+	// Mock data generator in development, with routes to pause it, tune it and inject scenarios
+	if cfg.App.Env == "development" {
+		mockGenerator, err := mock.NewMockDataGenerator(hub, &cfg.Mock)
+		if err != nil {
+			fatal("Failed to load mock scenario", err)
+		}
+		workers.Go("mock_data", mockGenerator.Run)
+
+		mockHandler := handlers.NewMockHandler(mockGenerator)
+		dev := protected.Group("/dev/mock", middleware.RequirePermission(middleware.PermSystemManage))
+		dev.Get("/", mockHandler.GetMockStatus)
+		dev.Post("/start", mockHandler.StartMock)
+		dev.Post("/stop", mockHandler.StopMock)
+		dev.Put("/profile", mockHandler.UpdateMockProfile)
+		dev.Post("/scenarios", mockHandler.InjectMockScenario)
+	}
+
+	// User management routes, for administrators; the permission check also applies API key scopes
+	adminUsers := admin.Group("/users", middleware.AdminMiddleware(), middleware.RequirePermission(middleware.PermUserManage))
+	adminUsers.Get("/", userHandler.GetUsers)
+	adminUsers.Get("/:id", userHandler.GetUser)
+	adminUsers.Put("/:id/role", userHandler.UpdateRole)
+	adminUsers.Post("/:id/deactivate", userHandler.Deactivate)
+	adminUsers.Post("/:id/reactivate", userHandler.Reactivate)
+	adminUsers.Post("/:id/reset-password", userHandler.ForcePasswordReset)
+	adminUsers.Put("/:id/tenant", tenantHandler.AssignUserTenant)
+	adminUsers.Get("/:id/personal-data", userHandler.ExportPersonalData)
+	adminUsers.Post("/:id/anonymize", userHandler.Anonymize)
+
+	// Retention policies deleting old alerts, transactions and audit entries and anonymizing closed
+	// accounts
+	retention := admin.Group("/retention", middleware.AdminMiddleware())
+	retention.Get("/policies", retentionHandler.GetRetentionPolicies)
+	retention.Put("/policies/:entity", retentionHandler.UpdateRetentionPolicy)
+	retention.Post("/run", retentionHandler.RunRetentionPolicies)
+
+	// Tenant routes; only platform administrators create tenants, while a tenant's administrators
+	// may change its settings
+	tenants := admin.Group("/tenants", middleware.AdminMiddleware())
+	tenants.Get("/", tenantHandler.GetTenants)
+	tenants.Post("/", tenantHandler.CreateTenant)
+	tenants.Get("/:id", tenantHandler.GetTenant)
+	tenants.Put("/:id", tenantHandler.UpdateTenant)
+	tenants.Put("/:id/settings", tenantHandler.UpdateTenantSettings)
+
+	// WebSocket and event stream clients of this instance, for platform administrators
+	wsAdmin := admin.Group("/ws", middleware.AdminMiddleware())
+	wsAdmin.Get("/connections", connectionHandler.GetConnections)
+	wsAdmin.Delete("/connections/:id", connectionHandler.DisconnectConnection)
 
 	// WebSocket endpoint
 	app.Use("/ws", func(c *fiber.Ctx) error {
@@ -146,23 +772,23 @@ func main() {
 		// requested upgrade to the WebSocket protocol.
 		if websocket.IsWebSocketUpgrade(c) {
 			c.Locals("allowed", true)
+			c.Locals("ip", c.IP())
 			return c.Next()
 		}
 		return fiber.ErrUpgradeRequired
-	})
+	}, middleware.WebSocketAuthMiddleware(authService))
 
 	app.Get("/ws", websocket.New(func(c *websocket.Conn) {
-		// Get user ID from query params
-		userID := c.Query("user_id", "anonymous")
+		// User comes from the validated JWT
+		userID, _ := c.Locals("user_id").(string)
+		role, _ := c.Locals("role").(string)
+		ip, _ := c.Locals("ip").(string)
 		clientID := uuid.New().String()
+		wsLogger := logger.With("component", "websocket", "user_id", userID, "client_id", clientID)
 
-		log.Printf("WebSocket client connected: user_id=%s, client_id=%s", userID, clientID)
+		wsLogger.Debug("WebSocket client connected")
 
-		// Register with simple hub
-		simpleHub.RegisterConnection(c)
-		defer simpleHub.UnregisterConnection(c)
-
-		// Send welcome message
+		// Send welcome message before the hub starts writing to the connection
 		welcome := map[string]interface{}{
 			"type":      "welcome",
 			"message":   "Connected to Financial Risk Monitor WebSocket",
@@ -172,56 +798,96 @@ func main() {
 		}
 
 		if err := c.WriteJSON(welcome); err != nil {
-			log.Println("WebSocket welcome error:", err)
+			wsLogger.Warn("Failed to send WebSocket welcome", "error", err)
 			return
 		}
 
-		// Keep connection alive and handle incoming messages
+		hub.RegisterConnection(c, wsHandler.Client{
+			ID:        clientID,
+			UserID:    userID,
+			Role:      role,
+			IP:        ip,
+			Transport: wsHandler.TransportWebSocket,
+		})
+		defer hub.UnregisterConnection(c)
+
+		// Handle subscription messages until the client disconnects
 		for {
-			mt, msg, err := c.ReadMessage()
+			_, msg, err := c.ReadMessage()
 			if err != nil {
-				log.Printf("WebSocket read error for client %s: %v", clientID, err)
+				wsLogger.Debug("WebSocket read ended", "error", err)
 				break
 			}
-			log.Printf("WebSocket received from %s: %s", clientID, msg)
 
-			// Echo message back (optional)
-			if err = c.WriteMessage(mt, msg); err != nil {
-				log.Printf("WebSocket write error for client %s: %v", clientID, err)
-				break
-			}
+			hub.HandleClientMessage(c, msg)
 		}
 
-		log.Printf("WebSocket client disconnected: %s", clientID)
+		wsLogger.Debug("WebSocket client disconnected")
 	}))
 
-	// Start mock data generator in development
-	if cfg.App.Env == "development" {
-		go startMockDataGenerator(hub, simpleHub)
-	}
-
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
+	// Disconnect clients first, since open event streams hold up the HTTP server's shutdown, then
+	// let in-flight requests finish before stopping the workers and flushing their pending writes
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-quit
-		log.Println("Shutting down server...")
-		if err := app.Shutdown(); err != nil {
-			log.Fatal("Server forced to shutdown:", err)
+		logger.Info("Shutting down server", "timeout", cfg.App.ShutdownTimeout.String())
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
+		defer cancel()
+		if err := hub.Shutdown(ctx); err != nil {
+			logger.Warn("WebSocket clients did not disconnect in time", "error", err)
+		}
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			logger.Warn("HTTP server did not shut down cleanly", "error", err)
+		}
+		if err := workers.Shutdown(ctx); err != nil {
+			logger.Warn("Background workers did not shut down cleanly", "error", err)
 		}
 	}()
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.App.Port)
+	logger.Info("Server starting", "port", cfg.App.Port, "env", cfg.App.Env)
 	if err := app.Listen(":" + cfg.App.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
+		fatal("Failed to start server", err)
 	}
+	<-stopped
+
+	// Export the spans of the last requests before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		logger.Warn("Failed to flush traces", "error", err)
+	}
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	logging.Logger().Error(msg, "error", err)
+	os.Exit(1)
 }
 
-func startMockDataGenerator(hub *wsHandler.Hub, simpleHub *wsHandler.SimpleHub) {
-	log.Println("Starting mock data generator...")
-	generator := mock.NewMockDataGenerator(hub)
-	generator.SetSimpleHub(simpleHub) // We'll need to add this method
-	generator.Start()
+// portfolioLister adapts the access service for WebSocket visibility checks
+func portfolioLister(accessService *services.AccessService) wsHandler.PortfolioLister {
+	return func(userID, role string) ([]string, bool, error) {
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		portfolioIDs, all, err := accessService.AccessiblePortfolioIDs(uid, role)
+		if err != nil {
+			return nil, false, err
+		}
+
+		ids := make([]string, 0, len(portfolioIDs))
+		for _, id := range portfolioIDs {
+			ids = append(ids, id.String())
+		}
+		return ids, all, nil
+	}
 }
@@ -0,0 +1,98 @@
+// Command migrate applies and rolls back the versioned database migrations in db/migrations
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up          Apply every pending migration
+  down [N]    Roll back the last N migrations (default 1)
+  version     Print the database schema version and the version this build expects
+  force V     Set the schema version to V without running migrations, clearing the dirty flag.
+              Use it once to adopt a database created by AutoMigrate before versioned migrations.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+	if _, err := logging.Init(&cfg.Log); err != nil {
+		fatal("Failed to configure logging", err)
+	}
+
+	if err := database.Connect(&cfg.Database); err != nil {
+		fatal("Failed to connect to PostgreSQL", err)
+	}
+	migrator, err := database.NewMigrator(database.GetDB())
+	if err != nil {
+		fatal("Failed to load migrations", err)
+	}
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fatal("Migration failed", err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			if steps, err = strconv.Atoi(os.Args[2]); err != nil || steps < 1 {
+				fatal("Invalid number of migrations", fmt.Errorf("%q is not a positive number", os.Args[2]))
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			fatal("Rollback failed", err)
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", rolledBack)
+
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			fatal("Failed to read schema version", err)
+		}
+		fmt.Printf("Schema version %d (dirty: %t), this build expects %d\n", version, dirty, migrator.Latest())
+
+	case "force":
+		if len(os.Args) < 3 {
+			fatal("Missing version", fmt.Errorf("usage: migrate force V"))
+		}
+		version, err := strconv.ParseUint(os.Args[2], 10, 32)
+		if err != nil {
+			fatal("Invalid version", err)
+		}
+		if err := migrator.Force(ctx, uint(version)); err != nil {
+			fatal("Failed to set schema version", err)
+		}
+		fmt.Printf("Schema version set to %d\n", version)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func fatal(msg string, err error) {
+	logging.Logger().Error(msg, "error", err)
+	os.Exit(1)
+}
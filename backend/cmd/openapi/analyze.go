@@ -0,0 +1,625 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/openapi"
+)
+
+// apperrorStatuses are the statuses of the apperror constructors
+var apperrorStatuses = map[string]int{
+	"BadRequest":   http.StatusBadRequest,
+	"Validation":   http.StatusUnprocessableEntity,
+	"Unauthorized": http.StatusUnauthorized,
+	"Forbidden":    http.StatusForbidden,
+	"NotFound":     http.StatusNotFound,
+	"Conflict":     http.StatusConflict,
+	"Internal":     http.StatusInternalServerError,
+
+	"VersionConflict":      http.StatusConflict,
+	"PreconditionRequired": http.StatusPreconditionRequired,
+}
+
+// requestHeaders are the request headers handlers read that are documented as parameters
+var requestHeaders = map[string]string{
+	fiber.HeaderIfMatch: "ETag of the record the update was made against; * applies it to any version",
+}
+
+// queryTypes are the schema types of the fiber.Ctx query accessors
+var queryTypes = map[string]string{
+	"Query":      "string",
+	"QueryInt":   "integer",
+	"QueryBool":  "boolean",
+	"QueryFloat": "number",
+}
+
+// handlerFacts is what a handler reads from requests and writes in responses
+type handlerFacts struct {
+	body       types.Type // Parsed with BodyParser
+	form       []formField
+	query      []openapi.Parameter // Query and header parameters
+	uuidParams map[string]bool
+	statuses   []int
+	responses  map[int]*responseFact
+	mediaType  string // Content type set for responses that are not JSON
+}
+
+type formField struct {
+	name string
+	file bool
+}
+
+type responseFact struct {
+	schema    *openapi.Schema // nil for a response without a JSON body
+	isError   bool
+	mediaType string
+}
+
+func newHandlerFacts() *handlerFacts {
+	return &handlerFacts{
+		uuidParams: make(map[string]bool),
+		responses:  make(map[int]*responseFact),
+	}
+}
+
+func (h *handlerFacts) addResponse(status int, r *responseFact) {
+	if _, ok := h.responses[status]; ok {
+		return
+	}
+	h.statuses = append(h.statuses, status)
+	h.responses[status] = r
+}
+
+func (h *handlerFacts) addQuery(param openapi.Parameter) {
+	for _, q := range h.query {
+		if q.Name == param.Name {
+			return
+		}
+	}
+	h.query = append(h.query, param)
+}
+
+func (h *handlerFacts) addForm(field formField) {
+	for _, f := range h.form {
+		if f.name == field.name {
+			return
+		}
+	}
+	h.form = append(h.form, field)
+}
+
+// merge adds the facts of a helper the handler calls with its fiber.Ctx
+func (h *handlerFacts) merge(other *handlerFacts) {
+	if h.body == nil {
+		h.body = other.body
+	}
+	for _, f := range other.form {
+		h.addForm(f)
+	}
+	for _, q := range other.query {
+		h.addQuery(q)
+	}
+	for name := range other.uuidParams {
+		h.uuidParams[name] = true
+	}
+	for _, status := range other.statuses {
+		h.addResponse(status, other.responses[status])
+	}
+	if h.mediaType == "" {
+		h.mediaType = other.mediaType
+	}
+}
+
+// analyzer reads handler bodies for the requests they parse and the responses they write
+type analyzer struct {
+	prog    *program
+	schemas *schemaBuilder
+	facts   map[*types.Func]*handlerFacts
+}
+
+func newAnalyzer(prog *program, schemas *schemaBuilder) *analyzer {
+	return &analyzer{prog: prog, schemas: schemas, facts: make(map[*types.Func]*handlerFacts)}
+}
+
+// analyze returns the facts of a function of this module; helpers it passes its fiber.Ctx to are
+// analyzed too
+func (a *analyzer) analyze(fn *types.Func) *handlerFacts {
+	if facts, ok := a.facts[fn]; ok {
+		return facts
+	}
+	facts := newHandlerFacts()
+	// Recorded first so recursive helpers end
+	a.facts[fn] = facts
+
+	decl, info := a.prog.funcs[fn], a.prog.infoFor(fn)
+	if decl == nil || decl.Body == nil || info == nil {
+		return facts
+	}
+	w := &funcWalker{analyzer: a, info: info, facts: facts, locals: make(map[types.Object]ast.Expr), keys: make(map[types.Object][]keyAssign)}
+	w.walk(decl.Body)
+	return facts
+}
+
+// funcWalker walks one function body
+type funcWalker struct {
+	*analyzer
+	info   *types.Info
+	facts  *handlerFacts
+	locals map[types.Object]ast.Expr    // Values assigned to local variables
+	keys   map[types.Object][]keyAssign // Keys set on local fiber.Map variables after they are built
+}
+
+type keyAssign struct {
+	key   string
+	value ast.Expr
+}
+
+func (w *funcWalker) walk(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			switch lhs := lhs.(type) {
+			case *ast.Ident:
+				if obj := w.info.ObjectOf(lhs); obj != nil {
+					if _, seen := w.locals[obj]; !seen {
+						w.locals[obj] = assign.Rhs[i]
+					}
+				}
+			case *ast.IndexExpr:
+				ident, ok := lhs.X.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if key, ok := w.stringConst(lhs.Index); ok {
+					obj := w.info.ObjectOf(ident)
+					w.keys[obj] = append(w.keys[obj], keyAssign{key: key, value: assign.Rhs[i]})
+				}
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			w.call(call)
+		}
+		return true
+	})
+}
+
+func (w *funcWalker) call(call *ast.CallExpr) {
+	fn := w.callee(call)
+	if fn == nil {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+
+	if sig.Recv() != nil && isFiberType(sig.Recv().Type(), "Ctx") {
+		w.ctxCall(call, fn.Name())
+		return
+	}
+	if sig.Recv() != nil && fn.Name() == "SetBodyStreamWriter" && fn.Pkg() != nil && fn.Pkg().Path() == "github.com/valyala/fasthttp" {
+		// A body streamed through c.Context(), such as an export
+		w.facts.addResponse(http.StatusOK, &responseFact{mediaType: "application/octet-stream"})
+		return
+	}
+
+	pkgPath := ""
+	if fn.Pkg() != nil {
+		pkgPath = fn.Pkg().Path()
+	}
+	switch {
+	case pkgPath == w.prog.module+"/internal/apperror":
+		if status, ok := apperrorStatuses[fn.Name()]; ok {
+			w.facts.addResponse(status, &responseFact{isError: true})
+		} else if fn.Name() == "New" && len(call.Args) > 0 {
+			if status, ok := w.intConst(call.Args[0]); ok {
+				w.facts.addResponse(status, &responseFact{isError: true})
+			}
+		}
+	case pkgPath == w.prog.module+"/internal/pagination" && fn.Name() == "Parse" && len(call.Args) == 2:
+		w.paginationParams(call.Args[1])
+	case pkgPath == "github.com/google/uuid" && fn.Name() == "Parse" && len(call.Args) == 1:
+		if inner, ok := call.Args[0].(*ast.CallExpr); ok {
+			if name, ok := w.ctxParam(inner); ok {
+				w.facts.uuidParams[name] = true
+			}
+		}
+	case strings.HasPrefix(pkgPath, w.prog.module+"/") && passesCtx(sig):
+		w.facts.merge(w.analyze(fn))
+	}
+}
+
+// ctxCall handles a call to a fiber.Ctx method
+func (w *funcWalker) ctxCall(call *ast.CallExpr, name string) {
+	switch name {
+	case "BodyParser":
+		if len(call.Args) == 1 && w.facts.body == nil {
+			if t := w.info.TypeOf(call.Args[0]); t != nil {
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem()
+				}
+				w.facts.body = t
+			}
+		}
+	case "Query", "QueryInt", "QueryBool", "QueryFloat":
+		if len(call.Args) == 0 {
+			return
+		}
+		key, ok := w.stringConst(call.Args[0])
+		if !ok {
+			return
+		}
+		param := openapi.Parameter{Name: key, In: "query", Schema: &openapi.Schema{Type: queryTypes[name]}}
+		if len(call.Args) > 1 {
+			if tv, ok := w.info.Types[call.Args[1]]; ok && tv.Value != nil {
+				param.Schema.Default = constantValue(tv.Value)
+			}
+		}
+		w.facts.addQuery(param)
+	case "Get":
+		if len(call.Args) == 0 {
+			return
+		}
+		if header, ok := w.stringConst(call.Args[0]); ok && requestHeaders[header] != "" {
+			w.facts.addQuery(openapi.Parameter{Name: header, In: "header", Description: requestHeaders[header],
+				Schema: &openapi.Schema{Type: "string"}})
+		}
+	case "FormFile", "FormValue":
+		if len(call.Args) == 1 {
+			if key, ok := w.stringConst(call.Args[0]); ok {
+				w.facts.addForm(formField{name: key, file: name == "FormFile"})
+			}
+		}
+	case "JSON":
+		if len(call.Args) == 0 {
+			return
+		}
+		schema, isError := w.jsonSchema(call.Args[0])
+		status, ok := w.status(call)
+		if !ok {
+			// A status chosen at run time, such as the health check's, is documented as 200
+			if isError {
+				return
+			}
+			status = http.StatusOK
+		}
+		w.facts.addResponse(status, &responseFact{schema: schema, isError: isError || status >= http.StatusBadRequest})
+	case "SendStatus":
+		if len(call.Args) == 1 {
+			if status, ok := w.intConst(call.Args[0]); ok {
+				w.facts.addResponse(status, &responseFact{isError: status >= http.StatusBadRequest})
+			}
+		}
+	case "Send", "SendString", "SendStream", "SendFile", "Download":
+		if status, ok := w.status(call); ok {
+			w.facts.addResponse(status, &responseFact{mediaType: "application/octet-stream"})
+		}
+	case "Set":
+		if len(call.Args) == 2 {
+			header, ok1 := w.stringConst(call.Args[0])
+			value, ok2 := w.stringConst(call.Args[1])
+			if ok1 && ok2 && strings.EqualFold(header, fiber.HeaderContentType) && w.facts.mediaType == "" {
+				w.facts.mediaType, _, _ = strings.Cut(value, ";")
+			}
+		}
+	case "Type":
+		if len(call.Args) > 0 && w.facts.mediaType == "" {
+			if ext, ok := w.stringConst(call.Args[0]); ok {
+				w.facts.mediaType, _, _ = strings.Cut(utils.GetMIME(ext), ";")
+			}
+		}
+	}
+}
+
+// status returns the status a response is written with: the constant passed to a Status call
+// earlier in the chain, or 200 without one. It fails for a status that is not a constant.
+func (w *funcWalker) status(call *ast.CallExpr) (int, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+	inner, ok := sel.X.(*ast.CallExpr)
+	if !ok {
+		return http.StatusOK, true
+	}
+	fn := w.callee(inner)
+	if fn == nil || fn.Name() != "Status" || len(inner.Args) != 1 {
+		return w.status(inner)
+	}
+	return w.intConst(inner.Args[0])
+}
+
+// jsonSchema describes the value passed to JSON and reports whether it is an error body
+func (w *funcWalker) jsonSchema(expr ast.Expr) (*openapi.Schema, bool) {
+	expr = ast.Unparen(expr)
+	var extra []keyAssign
+	if ident, ok := expr.(*ast.Ident); ok {
+		obj := w.info.ObjectOf(ident)
+		if value, ok := w.locals[obj]; ok {
+			if lit, ok := ast.Unparen(value).(*ast.CompositeLit); ok && isMapType(w.info.TypeOf(lit)) {
+				expr, extra = lit, w.keys[obj]
+			}
+		}
+	}
+
+	if lit, ok := expr.(*ast.CompositeLit); ok && isMapType(w.info.TypeOf(lit)) {
+		s := w.mapLiteral(lit, extra)
+		return s, s.Properties.Get("error") != nil
+	}
+
+	if call, ok := expr.(*ast.CallExpr); ok {
+		if fn := w.callee(call); fn != nil && fn.Pkg() != nil &&
+			fn.Pkg().Path() == w.prog.module+"/internal/pagination" && fn.Name() == "Response" && len(call.Args) == 3 {
+			return w.paginatedSchema(call.Args[0]), false
+		}
+	}
+
+	t := w.info.TypeOf(expr)
+	if t == nil {
+		return &openapi.Schema{}, false
+	}
+	if named, ok := derefNamed(t); ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == w.prog.module+"/internal/apperror" {
+		return nil, true
+	}
+	return w.schemas.schemaFor(t), false
+}
+
+// mapLiteral describes a fiber.Map literal and the keys set on it afterwards
+func (w *funcWalker) mapLiteral(lit *ast.CompositeLit, extra []keyAssign) *openapi.Schema {
+	s := &openapi.Schema{Type: "object"}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := w.stringConst(kv.Key)
+		if !ok || s.Properties.Get(key) != nil {
+			continue
+		}
+		s.Properties = append(s.Properties, openapi.Property{Name: key, Schema: w.valueSchema(kv.Value)})
+		s.Required = append(s.Required, key)
+	}
+	for _, k := range extra {
+		if s.Properties.Get(k.key) == nil {
+			s.Properties = append(s.Properties, openapi.Property{Name: k.key, Schema: w.valueSchema(k.value)})
+		}
+	}
+	return s
+}
+
+func (w *funcWalker) valueSchema(expr ast.Expr) *openapi.Schema {
+	expr = ast.Unparen(expr)
+	if lit, ok := expr.(*ast.CompositeLit); ok && isMapType(w.info.TypeOf(lit)) {
+		return w.mapLiteral(lit, nil)
+	}
+	if call, ok := expr.(*ast.CallExpr); ok {
+		if fn := w.callee(call); fn != nil && fn.Pkg() != nil &&
+			fn.Pkg().Path() == w.prog.module+"/internal/pagination" && fn.Name() == "Response" && len(call.Args) == 3 {
+			return w.paginatedSchema(call.Args[0])
+		}
+	}
+	t := w.info.TypeOf(expr)
+	if t == nil {
+		return &openapi.Schema{}
+	}
+	return w.schemas.schemaFor(t)
+}
+
+// paginatedSchema describes the page pagination.Response wraps results in
+func (w *funcWalker) paginatedSchema(data ast.Expr) *openapi.Schema {
+	dataSchema := &openapi.Schema{Type: "array", Items: &openapi.Schema{}}
+	if t := w.info.TypeOf(data); t != nil {
+		dataSchema = w.schemas.schemaFor(t)
+	}
+	dataSchema.Nullable = false
+	return &openapi.Schema{
+		Type: "object",
+		Properties: openapi.Properties{
+			{Name: "data", Schema: dataSchema},
+			{Name: "total", Schema: &openapi.Schema{Type: "integer", Format: "int64", Description: "Number of matches across all pages"}},
+			{Name: "limit", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Required: []string{"data", "total", "limit", "offset"},
+	}
+}
+
+// paginationParams adds the query parameters pagination.Parse reads for a list spec
+func (w *funcWalker) paginationParams(specExpr ast.Expr) {
+	var lit *ast.CompositeLit
+	if ident, ok := ast.Unparen(specExpr).(*ast.Ident); ok {
+		if v, ok := w.info.ObjectOf(ident).(*types.Var); ok {
+			lit, _ = ast.Unparen(w.prog.vars[v]).(*ast.CompositeLit)
+		}
+	}
+	if lit == nil {
+		return
+	}
+	specInfo := w.info
+	if v, ok := w.info.ObjectOf(ast.Unparen(specExpr).(*ast.Ident)).(*types.Var); ok && v.Pkg() != nil {
+		if pkg, ok := w.prog.packages[v.Pkg().Path()]; ok {
+			specInfo = pkg.info
+		}
+	}
+
+	var sortFields, filters []string
+	defaultLimit := 50
+	if obj := w.prog.lookup(w.prog.module+"/internal/pagination", "DefaultLimit"); obj != nil {
+		if c, ok := obj.(*types.Const); ok {
+			if n, ok := constant.Int64Val(c.Val()); ok {
+				defaultLimit = int(n)
+			}
+		}
+	}
+	hasDates := false
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		field, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch field.Name {
+		case "SortFields":
+			sortFields = mapKeys(specInfo, kv.Value)
+		case "Filters":
+			filters = mapKeys(specInfo, kv.Value)
+		case "DateColumn":
+			if tv, ok := specInfo.Types[kv.Value]; ok && tv.Value != nil && constant.StringVal(tv.Value) != "" {
+				hasDates = true
+			}
+		case "DefaultLimit":
+			if tv, ok := specInfo.Types[kv.Value]; ok && tv.Value != nil {
+				if n, ok := constant.Int64Val(tv.Value); ok {
+					defaultLimit = int(n)
+				}
+			}
+		}
+	}
+
+	w.facts.addQuery(openapi.Parameter{Name: "limit", In: "query", Description: "Page size, at most 1000",
+		Schema: &openapi.Schema{Type: "integer", Default: defaultLimit}})
+	w.facts.addQuery(openapi.Parameter{Name: "offset", In: "query", Description: "Number of results to skip",
+		Schema: &openapi.Schema{Type: "integer", Default: 0}})
+	if len(sortFields) > 0 {
+		w.facts.addQuery(openapi.Parameter{Name: "sort", In: "query",
+			Description: "Field to sort by, one of " + strings.Join(sortFields, ", ") + "; prefix it with - to sort in descending order",
+			Schema:      &openapi.Schema{Type: "string"}})
+		w.facts.addQuery(openapi.Parameter{Name: "order", In: "query",
+			Schema: &openapi.Schema{Type: "string", Enum: []string{"asc", "desc"}}})
+	}
+	if hasDates {
+		for _, name := range []string{"from", "to"} {
+			w.facts.addQuery(openapi.Parameter{Name: name, In: "query", Description: "RFC3339 time or YYYY-MM-DD date",
+				Schema: &openapi.Schema{Type: "string"}})
+		}
+	}
+	for _, filter := range filters {
+		w.facts.addQuery(openapi.Parameter{Name: filter, In: "query", Schema: &openapi.Schema{Type: "string"}})
+	}
+}
+
+// mapKeys lists the string keys of a map literal in the order they are written
+func mapKeys(info *types.Info, expr ast.Expr) []string {
+	lit, ok := ast.Unparen(expr).(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if tv, ok := info.Types[kv.Key]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+				keys = append(keys, constant.StringVal(tv.Value))
+			}
+		}
+	}
+	return keys
+}
+
+// ctxParam returns the name passed to c.Params
+func (w *funcWalker) ctxParam(call *ast.CallExpr) (string, bool) {
+	fn := w.callee(call)
+	if fn == nil || fn.Name() != "Params" || len(call.Args) == 0 {
+		return "", false
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv == nil || !isFiberType(recv.Type(), "Ctx") {
+		return "", false
+	}
+	return w.stringConst(call.Args[0])
+}
+
+// callee returns the function or method a call calls
+func (w *funcWalker) callee(call *ast.CallExpr) *types.Func {
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		fn, _ := w.info.Uses[fun].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		fn, _ := w.info.Uses[fun.Sel].(*types.Func)
+		return fn
+	case *ast.IndexExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			fn, _ := w.info.Uses[ident].(*types.Func)
+			return fn
+		}
+	}
+	return nil
+}
+
+func (w *funcWalker) stringConst(expr ast.Expr) (string, bool) {
+	tv, ok := w.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func (w *funcWalker) intConst(expr ast.Expr) (int, bool) {
+	tv, ok := w.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	n, ok := constant.Int64Val(tv.Value)
+	return int(n), ok
+}
+
+// passesCtx reports whether a function takes a fiber.Ctx
+func passesCtx(sig *types.Signature) bool {
+	for i := 0; i < sig.Params().Len(); i++ {
+		if isFiberType(sig.Params().At(i).Type(), "Ctx") {
+			return true
+		}
+	}
+	return false
+}
+
+// isMapType reports whether t is fiber.Map or another map with string keys
+func isMapType(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	m, ok := t.Underlying().(*types.Map)
+	if !ok {
+		return false
+	}
+	key, ok := m.Key().Underlying().(*types.Basic)
+	return ok && key.Kind() == types.String
+}
+
+func derefNamed(t types.Type) (*types.Named, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	return named, ok
+}
+
+func constantValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.Int:
+		n, _ := constant.Int64Val(v)
+		return n
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		return f
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/openapi"
+)
+
+const generatedHeader = "// Code generated by cmd/openapi. DO NOT EDIT.\n\npackage client\n\n"
+
+// reservedNames are declared by the hand-written client.go
+var reservedNames = map[string]bool{
+	"Client": true, "Option": true, "Error": true, "New": true, "SetToken": true,
+	"WithHTTPClient": true, "WithToken": true, "WithAPIKey": true,
+}
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]string{
+	"id": "ID", "ids": "IDs", "url": "URL", "uri": "URI", "api": "API", "uuid": "UUID", "http": "HTTP",
+	"json": "JSON", "ip": "IP", "sla": "SLA", "kyc": "KYC", "aml": "AML", "sar": "SAR", "fx": "FX",
+	"pnl": "PnL", "var": "VaR", "cvar": "CVaR", "dv01": "DV01", "ttl": "TTL", "csv": "CSV", "pdf": "PDF",
+	"jwt": "JWT", "lei": "LEI", "isin": "ISIN", "utc": "UTC", "ws": "WS",
+}
+
+// clientWriter writes the client's types and operations
+type clientWriter struct {
+	doc      *openapi.Document
+	types    bytes.Buffer
+	ops      bytes.Buffer
+	names    map[string]string // Component name -> Go type name
+	declared map[string]bool
+	imports  map[string]map[string]bool // File -> imports
+}
+
+func writeClient(dir string, doc *openapi.Document, ops []*operation) error {
+	w := &clientWriter{
+		doc:      doc,
+		names:    make(map[string]string),
+		declared: make(map[string]bool),
+		imports:  map[string]map[string]bool{"types": {}, "ops": {}},
+	}
+	for name := range reservedNames {
+		w.declared[name] = true
+	}
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		w.names[name] = w.declare(name)
+	}
+
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		w.writeStruct(&w.types, w.names[name], doc.Components.Schemas[name])
+	}
+	for _, o := range ops {
+		if o.op.OperationID != "" {
+			w.writeOperation(o)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := w.writeFile(filepath.Join(dir, "types_gen.go"), "types", w.types.Bytes()); err != nil {
+		return err
+	}
+	return w.writeFile(filepath.Join(dir, "operations_gen.go"), "ops", w.ops.Bytes())
+}
+
+func (w *clientWriter) writeFile(path, file string, body []byte) error {
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+	if imports := w.imports[file]; len(imports) > 0 {
+		// Standard library imports first, then the others
+		var std, other []string
+		for _, path := range sortedKeys(imports) {
+			if strings.Contains(strings.Split(path, "/")[0], ".") {
+				other = append(other, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		buf.WriteString("import (\n")
+		for _, path := range std {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, path := range other {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(body)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", path, err)
+	}
+	return os.WriteFile(path, src, 0o644)
+}
+
+// declare reserves a Go type name, suffixing it when it is taken
+func (w *clientWriter) declare(name string) string {
+	name = exportedName(name)
+	candidate := name
+	for i := 2; w.declared[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	w.declared[candidate] = true
+	return candidate
+}
+
+// writeStruct declares a struct for an object schema, and the structs of its inline objects
+func (w *clientWriter) writeStruct(buf *bytes.Buffer, name string, s *openapi.Schema) {
+	var nested bytes.Buffer
+	writeComment(buf, s.Description, "")
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	fields := make(map[string]bool)
+	for _, p := range s.Properties {
+		field := exportedName(p.Name)
+		for fields[field] {
+			field += "_"
+		}
+		fields[field] = true
+
+		goType := w.goType(&nested, p.Schema, name+field, required[p.Name], "types")
+		tag := p.Name
+		if !required[p.Name] {
+			tag += ",omitempty"
+		}
+		writeComment(buf, p.Schema.Description, "\t")
+		fmt.Fprintf(buf, "\t%s %s `json:%q`\n", field, goType, tag)
+	}
+	buf.WriteString("}\n\n")
+	buf.Write(nested.Bytes())
+}
+
+// goType returns the Go type of a schema. Inline objects are declared as structs named typeName.
+// Optional objects and nullable values are pointers.
+func (w *clientWriter) goType(buf *bytes.Buffer, s *openapi.Schema, typeName string, required bool, file string) string {
+	pointer := func(t string) string {
+		if !required || s.Nullable {
+			return "*" + t
+		}
+		return t
+	}
+
+	if s.Ref != "" {
+		return pointer(w.names[strings.TrimPrefix(s.Ref, refPrefix)])
+	}
+	nullable := func(t string) string {
+		if s.Nullable {
+			return "*" + t
+		}
+		return t
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			w.imports[file]["time"] = true
+			return nullable("time.Time")
+		case "uuid":
+			w.imports[file]["github.com/google/uuid"] = true
+			return nullable("uuid.UUID")
+		case "decimal":
+			w.imports[file]["github.com/shopspring/decimal"] = true
+			return nullable("decimal.Decimal")
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return nullable("string")
+	case "integer":
+		switch s.Format {
+		case "int64":
+			return nullable("int64")
+		case "int32":
+			return nullable("int32")
+		}
+		return nullable("int")
+	case "number":
+		if s.Format == "float" {
+			return nullable("float32")
+		}
+		return nullable("float64")
+	case "boolean":
+		return nullable("bool")
+	case "array":
+		items := &openapi.Schema{}
+		if s.Items != nil {
+			items = s.Items
+		}
+		return "[]" + w.goType(buf, items, typeName+"Item", true, file)
+	case "object":
+		if len(s.Properties) > 0 {
+			name := w.declare(typeName)
+			w.writeStruct(buf, name, s)
+			return pointer(name)
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Type != "" {
+			return "map[string]" + w.goType(buf, s.AdditionalProperties, typeName+"Value", true, file)
+		}
+		return "map[string]interface{}"
+	}
+	w.imports[file]["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+// writeOperation writes the method calling an operation and the struct of its parameters
+func (w *clientWriter) writeOperation(o *operation) {
+	op := o.op
+	buf := &w.ops
+	var nested bytes.Buffer // Inline request and response types, declared after the method
+	w.imports["ops"]["context"] = true
+	w.imports["ops"]["net/http"] = true
+
+	args := []string{"ctx context.Context"}
+	var pathArgs []string
+	var params []openapi.Parameter
+	taken := map[string]bool{"ctx": true, "body": true, "params": true, "r": true, "out": true, "err": true, "c": true, "contentType": true}
+	for _, p := range op.Parameters {
+		if p.In != "path" {
+			params = append(params, p)
+			continue
+		}
+		name := paramName(p.Name)
+		for taken[name] {
+			name += "_"
+		}
+		taken[name] = true
+		goType := "string"
+		if p.Schema != nil && p.Schema.Format == "uuid" {
+			goType = "uuid.UUID"
+			w.imports["ops"]["github.com/google/uuid"] = true
+		}
+		args = append(args, name+" "+goType)
+		pathArgs = append(pathArgs, name)
+	}
+
+	bodyLine := ""
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content[jsonMedia]; ok && media.Schema != nil {
+			args = append(args, "body "+w.goType(&nested, media.Schema, op.OperationID+"Request", true, "ops"))
+			bodyLine = "\tr.body = body\n"
+		} else {
+			w.imports["ops"]["io"] = true
+			args = append(args, "body io.Reader", "contentType string")
+			bodyLine = "\tr.setRawBody(body, contentType)\n"
+		}
+	}
+
+	paramsType := ""
+	if len(params) > 0 {
+		paramsType = w.declare(op.OperationID + "Params")
+		args = append(args, "params *"+paramsType)
+	}
+
+	// The first success response with a body decides what the method returns
+	result, kind := "", "none"
+	for _, status := range sortedKeys(op.Responses) {
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 200 || code >= 300 {
+			continue
+		}
+		for mediaType, media := range op.Responses[status].Content {
+			if mediaType == jsonMedia && media.Schema != nil && media.Schema.Format != "binary" {
+				result = w.goType(&nested, media.Schema, op.OperationID+"Response", true, "ops")
+				kind = "value"
+				if media.Schema.Ref != "" || len(media.Schema.Properties) > 0 {
+					kind = "pointer"
+				}
+			} else {
+				w.imports["ops"]["io"] = true
+				result, kind = "io.ReadCloser", "stream"
+			}
+		}
+		if kind != "none" {
+			break
+		}
+	}
+
+	// The description repeats the summary unless it only holds the middleware notes
+	text := op.Summary
+	if text == "" {
+		text = "Calls " + o.method + " " + o.path
+	}
+	if op.Description != "" {
+		if op.Summary != "" && strings.HasPrefix(op.Description, op.Summary) {
+			text = op.Description
+		} else {
+			text += "\n\n" + op.Description
+		}
+	}
+	writeComment(buf, op.OperationID+" "+lowerFirst(text), "")
+	buf.WriteString("//\n")
+	fmt.Fprintf(buf, "// %s %s\n", o.method, o.path)
+
+	returns := "error"
+	switch kind {
+	case "pointer":
+		returns = "(*" + result + ", error)"
+	case "value", "stream":
+		returns = "(" + result + ", error)"
+	}
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) %s {\n", op.OperationID, strings.Join(args, ", "), returns)
+	fmt.Fprintf(buf, "\tr := newRequest(http.Method%s, %q%s)\n", methodConst(o.method), o.path, prefixEach(", ", pathArgs))
+	buf.WriteString(bodyLine)
+	if paramsType != "" {
+		buf.WriteString("\tif params != nil {\n\t\tparams.apply(r)\n\t}\n")
+	}
+	switch kind {
+	case "pointer":
+		fmt.Fprintf(buf, "\tvar out %s\n\tif err := c.do(ctx, r, &out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n", result)
+	case "value":
+		fmt.Fprintf(buf, "\tvar out %s\n\terr := c.do(ctx, r, &out)\n\treturn out, err\n", result)
+	case "stream":
+		buf.WriteString("\treturn c.stream(ctx, r)\n")
+	default:
+		buf.WriteString("\treturn c.do(ctx, r, nil)\n")
+	}
+	buf.WriteString("}\n\n")
+	buf.Write(nested.Bytes())
+
+	if paramsType != "" {
+		w.writeParams(buf, paramsType, op.OperationID, params)
+	}
+}
+
+// writeParams declares the struct of an operation's query and header parameters. Zero values are
+// not sent.
+func (w *clientWriter) writeParams(buf *bytes.Buffer, name, operationID string, params []openapi.Parameter) {
+	fmt.Fprintf(buf, "// %s are the optional parameters of %s\n", name, operationID)
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	fields := make([]string, len(params))
+	seen := make(map[string]bool)
+	for i, p := range params {
+		field := exportedName(p.Name)
+		for seen[field] {
+			field += "_"
+		}
+		seen[field] = true
+		fields[i] = field
+
+		goType := "string"
+		if p.Schema != nil {
+			switch p.Schema.Type {
+			case "integer":
+				goType = "int"
+			case "number":
+				goType = "float64"
+			case "boolean":
+				goType = "bool"
+			}
+		}
+		writeComment(buf, p.Description, "\t")
+		fmt.Fprintf(buf, "\t%s %s\n", field, goType)
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(buf, "func (p *%s) apply(r *request) {\n", name)
+	for i, p := range params {
+		setter := "setQuery"
+		if p.In == "header" {
+			setter = "setHeader"
+		}
+		fmt.Fprintf(buf, "\tr.%s(%q, p.%s)\n", setter, p.Name, fields[i])
+	}
+	buf.WriteString("}\n\n")
+}
+
+// writeComment writes text as a comment wrapped at 100 columns
+func writeComment(buf *bytes.Buffer, text, indent string) {
+	for i, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			continue
+		}
+		if i > 0 {
+			buf.WriteString(indent + "//\n")
+		}
+		line := indent + "//"
+		for _, word := range words {
+			if len(line)+1+len(word) > 100 && line != indent+"//" {
+				buf.WriteString(line + "\n")
+				line = indent + "//"
+			}
+			line += " " + word
+		}
+		buf.WriteString(line + "\n")
+	}
+}
+
+// exportedName turns a JSON or component name such as portfolio_id into PortfolioID
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if initialism, ok := initialisms[strings.ToLower(part)]; ok && part == strings.ToLower(part) {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(upperFirst(part))
+	}
+	out := b.String()
+	if out == "" || unicode.IsDigit(rune(out[0])) {
+		out = "X" + out
+	}
+	return out
+}
+
+// paramName turns a path parameter such as positionId into positionID
+func paramName(name string) string {
+	exported := exportedName(name)
+	if strings.HasSuffix(exported, "Id") {
+		exported = strings.TrimSuffix(exported, "Id") + "ID"
+	}
+	if initialism, ok := initialisms[strings.ToLower(exported)]; ok && initialism == exported {
+		return strings.ToLower(exported)
+	}
+	if name := lowerFirst(exported); !token.IsKeyword(name) {
+		return name
+	}
+	return lowerFirst(exported) + "Param"
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+func methodConst(method string) string {
+	return upperFirst(strings.ToLower(method))
+}
+
+func prefixEach(prefix string, items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(prefix + item)
+	}
+	return b.String()
+}
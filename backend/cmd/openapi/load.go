@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// program is cmd/api and the packages it depends on. The packages of this module are type
+// checked from source, so handler bodies and doc comments can be read; the others are loaded from
+// the compiler's export data.
+type program struct {
+	fset     *token.FileSet
+	module   string
+	dir      string
+	packages map[string]*sourcePackage
+	funcs    map[*types.Func]*ast.FuncDecl
+	vars     map[*types.Var]ast.Expr // Values of package level variables
+	docs     map[token.Pos]string    // Doc comments of types and struct fields by the position of their name
+}
+
+type sourcePackage struct {
+	types *types.Package
+	files []*ast.File
+	info  *types.Info
+}
+
+// listedPackage is the part of go list's output the loader uses
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	Export     string
+	Module     *struct{ Path string }
+}
+
+// load type checks the packages matching pattern, relative to the root of the module dir is in
+func load(dir, pattern string) (*program, error) {
+	out, err := goCommand(dir, "list", "-m", "-json")
+	if err != nil {
+		return nil, err
+	}
+	var module struct{ Path, Dir string }
+	if err := json.Unmarshal(out, &module); err != nil {
+		return nil, err
+	}
+
+	out, err = goCommand(module.Dir, "list", "-deps", "-export", "-json", pattern)
+	if err != nil {
+		return nil, err
+	}
+	var listed []listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		listed = append(listed, pkg)
+	}
+
+	prog := &program{
+		fset:     token.NewFileSet(),
+		module:   module.Path,
+		dir:      module.Dir,
+		packages: make(map[string]*sourcePackage),
+		funcs:    make(map[*types.Func]*ast.FuncDecl),
+		vars:     make(map[*types.Var]ast.Expr),
+		docs:     make(map[token.Pos]string),
+	}
+
+	exports := make(map[string]string)
+	for _, pkg := range listed {
+		exports[pkg.ImportPath] = pkg.Export
+	}
+	exportData := importer.ForCompiler(prog.fset, "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok || file == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(file)
+	})
+	imp := importerFunc(func(path string) (*types.Package, error) {
+		if pkg, ok := prog.packages[path]; ok {
+			return pkg.types, nil
+		}
+		return exportData.Import(path)
+	})
+
+	// go list -deps lists dependencies before the packages importing them
+	for _, pkg := range listed {
+		if pkg.Module == nil || pkg.Module.Path != module.Path {
+			continue
+		}
+		if err := prog.check(pkg, imp); err != nil {
+			return nil, err
+		}
+	}
+	return prog, nil
+}
+
+func (p *program) check(pkg listedPackage, imp types.Importer) error {
+	src := &sourcePackage{
+		info: &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		},
+	}
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(p.fset, filepath.Join(pkg.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		src.files = append(src.files, file)
+	}
+
+	conf := types.Config{Importer: imp}
+	checked, err := conf.Check(pkg.ImportPath, p.fset, src.files, src.info)
+	if err != nil {
+		return fmt.Errorf("type checking %s: %w", pkg.ImportPath, err)
+	}
+	src.types = checked
+	p.packages[pkg.ImportPath] = src
+
+	for _, file := range src.files {
+		p.index(file, src.info)
+	}
+	return nil
+}
+
+// index records the functions, package level variables and doc comments of a file
+func (p *program) index(file *ast.File, info *types.Info) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if fn, ok := info.Defs[decl.Name].(*types.Func); ok {
+				p.funcs[fn] = decl
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for i, name := range spec.Names {
+						if v, ok := info.Defs[name].(*types.Var); ok && i < len(spec.Values) {
+							p.vars[v] = spec.Values[i]
+						}
+					}
+				case *ast.TypeSpec:
+					doc := spec.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					p.docs[spec.Name.Pos()] = commentText(doc, nil)
+				}
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if st, ok := n.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				text := commentText(field.Doc, field.Comment)
+				for _, name := range field.Names {
+					p.docs[name.Pos()] = text
+				}
+				if len(field.Names) == 0 {
+					p.docs[field.Type.Pos()] = text
+				}
+			}
+		}
+		return true
+	})
+}
+
+// commentText joins a doc comment and a line comment into one line of text
+func commentText(groups ...*ast.CommentGroup) string {
+	var parts []string
+	for _, group := range groups {
+		if text := strings.Join(strings.Fields(group.Text()), " "); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// lookup returns a type or constant declared by a package of the program
+func (p *program) lookup(pkgPath, name string) types.Object {
+	if pkg, ok := p.packages[pkgPath]; ok {
+		return pkg.types.Scope().Lookup(name)
+	}
+	for _, pkg := range p.packages {
+		for _, imported := range pkg.types.Imports() {
+			if imported.Path() == pkgPath {
+				return imported.Scope().Lookup(name)
+			}
+		}
+	}
+	return nil
+}
+
+// infoFor returns the type information of the package declaring a function
+func (p *program) infoFor(fn *types.Func) *types.Info {
+	if fn.Pkg() == nil {
+		return nil
+	}
+	if pkg, ok := p.packages[fn.Pkg().Path()]; ok {
+		return pkg.info
+	}
+	return nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func goCommand(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go %s: %w: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out, nil
+}
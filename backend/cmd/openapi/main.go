@@ -0,0 +1,60 @@
+// Command openapi generates the OpenAPI description of the REST API, served at /api/v1/docs, and
+// the Go client in pkg/client. It type checks cmd/api to find the routes and reads the handlers
+// for the bodies they parse, the query parameters and the responses they write.
+//
+// Run it from anywhere in the module, or with go generate ./internal/openapi:
+//
+//	go run ./cmd/openapi [-spec internal/openapi/openapi.json] [-client pkg/client]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "internal/openapi/openapi.json", "Where to write the OpenAPI document, relative to the module root")
+	clientDir := flag.String("client", "pkg/client", "Directory of the generated Go client, relative to the module root; empty skips it")
+	title := flag.String("title", "Financial Risk Monitor API", "Title of the API")
+	version := flag.String("version", "v1", "Version of the API")
+	flag.Parse()
+
+	if err := run(*specPath, *clientDir, *title, *version); err != nil {
+		fmt.Fprintln(os.Stderr, "openapi:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, clientDir, title, version string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	prog, err := load(wd, "./cmd/api")
+	if err != nil {
+		return err
+	}
+
+	routes, err := prog.findRoutes(prog.module + "/cmd/api")
+	if err != nil {
+		return err
+	}
+	gen := newGenerator(prog, routes)
+	doc := gen.document(title, version)
+
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(prog.dir, specPath), append(spec, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	if clientDir == "" {
+		return nil
+	}
+	return writeClient(filepath.Join(prog.dir, clientDir), doc, gen.ops)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/http"
+	"strings"
+)
+
+const fiberPath = "github.com/gofiber/fiber/v2"
+
+// routeMethods are the Fiber router methods that register a route, by HTTP method
+var routeMethods = map[string]string{
+	"Get":    http.MethodGet,
+	"Post":   http.MethodPost,
+	"Put":    http.MethodPut,
+	"Patch":  http.MethodPatch,
+	"Delete": http.MethodDelete,
+}
+
+// route is a route registered in cmd/api
+type route struct {
+	method     string
+	path       string      // In OpenAPI form, such as /api/v1/portfolios/{id}
+	handler    ast.Expr    // Last argument of the registration
+	fn         *types.Func // Handler function or method; nil for closures and handlers built by a call
+	middleware []ast.Expr  // Group and route middleware, outermost first
+	comment    string      // Comment above the registration or the section it is in
+}
+
+// group is a router returned by Group, or the app itself
+type group struct {
+	prefix     string
+	middleware []ast.Expr
+}
+
+// routeFinder walks the function registering the routes
+type routeFinder struct {
+	info   *types.Info
+	groups map[types.Object]*group
+	values map[types.Object]ast.Expr // Values assigned to the function's variables
+	routes []route
+}
+
+// findRoutes lists the routes a package's main function registers, in registration order
+func (p *program) findRoutes(pkgPath string) (*routeFinder, error) {
+	pkg, ok := p.packages[pkgPath]
+	if !ok {
+		return nil, fmt.Errorf("package %s not loaded", pkgPath)
+	}
+
+	f := &routeFinder{
+		info:   pkg.info,
+		groups: make(map[types.Object]*group),
+		values: make(map[types.Object]ast.Expr),
+	}
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Name.Name != "main" || fn.Recv != nil {
+				continue
+			}
+			comments := ast.NewCommentMap(p.fset, file, file.Comments)
+			f.walk(fn.Body, comments)
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no main function in %s", pkgPath)
+}
+
+func (f *routeFinder) walk(body *ast.BlockStmt, comments ast.CommentMap) {
+	section := ""
+	ast.Inspect(body, func(n ast.Node) bool {
+		stmt, ok := n.(ast.Stmt)
+		if !ok {
+			return true
+		}
+		if groups := comments[stmt]; len(groups) > 0 {
+			section = commentText(groups[len(groups)-1])
+		}
+
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if len(stmt.Lhs) != len(stmt.Rhs) {
+				return true
+			}
+			for i, lhs := range stmt.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				obj := f.info.ObjectOf(ident)
+				if obj == nil {
+					continue
+				}
+				f.values[obj] = stmt.Rhs[i]
+				if isFiberType(obj.Type(), "App") {
+					f.groups[obj] = &group{}
+				}
+				if g := f.groupCall(stmt.Rhs[i]); g != nil {
+					f.groups[obj] = g
+				}
+			}
+		case *ast.ExprStmt:
+			call, ok := stmt.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if r, ok := f.routeCall(call); ok {
+				r.comment = section
+				f.routes = append(f.routes, r)
+			}
+		}
+		return true
+	})
+}
+
+// groupCall returns the group created by a call to Group on a known router
+func (f *routeFinder) groupCall(expr ast.Expr) *group {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return nil
+	}
+	parent, name := f.routerMethod(call)
+	if parent == nil || name != "Group" {
+		return nil
+	}
+	prefix, ok := f.stringConst(call.Args[0])
+	if !ok {
+		return nil
+	}
+	return &group{
+		prefix:     joinPath(parent.prefix, prefix),
+		middleware: append(append([]ast.Expr{}, parent.middleware...), call.Args[1:]...),
+	}
+}
+
+// routeCall reads a route registration such as portfolios.Get("/:id", mw, handler.GetPortfolio)
+func (f *routeFinder) routeCall(call *ast.CallExpr) (route, bool) {
+	parent, name := f.routerMethod(call)
+	method, ok := routeMethods[name]
+	if parent == nil || !ok || len(call.Args) < 2 {
+		return route{}, false
+	}
+	path, ok := f.stringConst(call.Args[0])
+	if !ok {
+		return route{}, false
+	}
+
+	r := route{
+		method:     method,
+		path:       openAPIPath(joinPath(parent.prefix, path)),
+		handler:    call.Args[len(call.Args)-1],
+		middleware: append(append([]ast.Expr{}, parent.middleware...), call.Args[1:len(call.Args)-1]...),
+	}
+	if sel, ok := r.handler.(*ast.SelectorExpr); ok {
+		r.fn, _ = f.info.Uses[sel.Sel].(*types.Func)
+	} else if ident, ok := r.handler.(*ast.Ident); ok {
+		r.fn, _ = f.info.Uses[ident].(*types.Func)
+	}
+	return r, true
+}
+
+// routerMethod returns the router a method is called on and the method's name
+func (f *routeFinder) routerMethod(call *ast.CallExpr) (*group, string) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil, ""
+	}
+	return f.groups[f.info.ObjectOf(ident)], sel.Sel.Name
+}
+
+func (f *routeFinder) stringConst(expr ast.Expr) (string, bool) {
+	tv, ok := f.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// resolve follows a variable to the value assigned to it
+func (f *routeFinder) resolve(expr ast.Expr) ast.Expr {
+	for {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+		value, ok := f.values[f.info.ObjectOf(ident)]
+		if !ok {
+			return expr
+		}
+		expr = value
+	}
+}
+
+// joinPath joins a group prefix and a path the way Fiber does
+func joinPath(prefix, path string) string {
+	if path == "" {
+		return prefix
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	return strings.TrimRight(prefix, "/") + path
+}
+
+// openAPIPath turns a Fiber path such as /portfolios/:id/ into /portfolios/{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(segment[1:], "?") + "}"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// pathParams lists the parameters of an OpenAPI path
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+	return params
+}
+
+// isFiberType reports whether t is, or points to, the named Fiber type
+func isFiberType(t types.Type, name string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == fiberPath && named.Obj().Name() == name
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/openapi"
+)
+
+// refPrefix starts the references to component schemas
+const refPrefix = "#/components/schemas/"
+
+// wellKnown are the schemas of types whose JSON encoding is not their Go structure
+var wellKnown = map[string]func() *openapi.Schema{
+	"time.Time": func() *openapi.Schema { return &openapi.Schema{Type: "string", Format: "date-time"} },
+	"time.Duration": func() *openapi.Schema {
+		return &openapi.Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	},
+	"encoding/json.RawMessage": func() *openapi.Schema {
+		return &openapi.Schema{}
+	},
+	"github.com/google/uuid.UUID":     func() *openapi.Schema { return &openapi.Schema{Type: "string", Format: "uuid"} },
+	"github.com/google/uuid.NullUUID": func() *openapi.Schema { return &openapi.Schema{Type: "string", Format: "uuid", Nullable: true} },
+	"github.com/shopspring/decimal.Decimal": func() *openapi.Schema {
+		return &openapi.Schema{Type: "string", Format: "decimal"}
+	},
+	"github.com/shopspring/decimal.NullDecimal": func() *openapi.Schema {
+		return &openapi.Schema{Type: "string", Format: "decimal", Nullable: true}
+	},
+	"gorm.io/gorm.DeletedAt": func() *openapi.Schema {
+		return &openapi.Schema{Type: "string", Format: "date-time", Nullable: true}
+	},
+	fiberPath + ".Map": func() *openapi.Schema {
+		return &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}}
+	},
+}
+
+// component is a named struct type described once under components/schemas
+type component struct {
+	typeName string
+	pkgPath  string
+	schema   *openapi.Schema
+}
+
+// schemaBuilder describes Go types as JSON schemas, following encoding/json and the validate
+// tags. Named struct types become components, referenced by their qualified name until rename
+// gives them their final names.
+type schemaBuilder struct {
+	prog       *program
+	components map[string]*component
+}
+
+func newSchemaBuilder(prog *program) *schemaBuilder {
+	return &schemaBuilder{prog: prog, components: make(map[string]*component)}
+}
+
+func (b *schemaBuilder) schemaFor(t types.Type) *openapi.Schema {
+	switch t := types.Unalias(t).(type) {
+	case *types.Pointer:
+		s := b.schemaFor(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case *types.Named:
+		return b.namedSchema(t)
+	case *types.Basic:
+		return basicSchema(t)
+	case *types.Slice:
+		if basic, ok := t.Elem().Underlying().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return &openapi.Schema{Type: "string", Format: "byte"}
+		}
+		return &openapi.Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case *types.Array:
+		return &openapi.Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case *types.Map:
+		return &openapi.Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case *types.Struct:
+		return b.structSchema(t)
+	}
+	// Interfaces, and anything else, can hold any value
+	return &openapi.Schema{}
+}
+
+func (b *schemaBuilder) namedSchema(t *types.Named) *openapi.Schema {
+	obj := t.Obj()
+	qualified := obj.Name()
+	if obj.Pkg() != nil {
+		qualified = obj.Pkg().Path() + "." + obj.Name()
+	}
+	if schema, ok := wellKnown[qualified]; ok {
+		return schema()
+	}
+
+	if hasMethod(t, "MarshalJSON") {
+		return &openapi.Schema{}
+	}
+	if hasMethod(t, "MarshalText") {
+		return &openapi.Schema{Type: "string"}
+	}
+
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return b.schemaFor(t.Underlying())
+	}
+	if _, ok := b.components[qualified]; !ok {
+		c := &component{typeName: obj.Name(), schema: &openapi.Schema{}}
+		if obj.Pkg() != nil {
+			c.pkgPath = obj.Pkg().Path()
+		}
+		// Registered before the fields are described so recursive types refer to themselves
+		b.components[qualified] = c
+		*c.schema = *b.structSchema(st)
+		c.schema.Description = b.prog.docs[obj.Pos()]
+	}
+	return &openapi.Schema{Ref: refPrefix + qualified}
+}
+
+func basicSchema(t *types.Basic) *openapi.Schema {
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		return &openapi.Schema{Type: "boolean"}
+	case t.Info()&types.IsInteger != 0:
+		s := &openapi.Schema{Type: "integer"}
+		switch t.Kind() {
+		case types.Int64, types.Uint64:
+			s.Format = "int64"
+		case types.Int32, types.Uint32:
+			s.Format = "int32"
+		}
+		return s
+	case t.Info()&types.IsFloat != 0:
+		s := &openapi.Schema{Type: "number", Format: "double"}
+		if t.Kind() == types.Float32 {
+			s.Format = "float"
+		}
+		return s
+	case t.Info()&types.IsString != 0:
+		return &openapi.Schema{Type: "string"}
+	}
+	return &openapi.Schema{}
+}
+
+// structSchema describes the fields encoding/json writes for a struct, promoting the fields of
+// untagged embedded structs unless a shallower field has the same name
+func (b *schemaBuilder) structSchema(st *types.Struct) *openapi.Schema {
+	s := &openapi.Schema{Type: "object"}
+	b.addFields(s, st, map[string]bool{})
+	return s
+}
+
+func (b *schemaBuilder) addFields(s *openapi.Schema, st *types.Struct, shadowed map[string]bool) {
+	type field struct {
+		v        *types.Var
+		name     string
+		options  string
+		embedded types.Type // Struct whose fields are promoted
+	}
+
+	var fields []field
+	own := make(map[string]bool)
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(jsonTag, ",")
+
+		if v.Embedded() && name == "" {
+			t := v.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if _, ok := t.Underlying().(*types.Struct); ok {
+				fields = append(fields, field{v: v, embedded: t})
+				continue
+			}
+		}
+		if !v.Exported() {
+			continue
+		}
+		if name == "" {
+			name = v.Name()
+		}
+		fields = append(fields, field{v: v, name: name, options: options})
+		own[name] = true
+	}
+
+	hidden := make(map[string]bool, len(shadowed)+len(own))
+	for name := range shadowed {
+		hidden[name] = true
+	}
+	for name := range own {
+		hidden[name] = true
+	}
+
+	for _, f := range fields {
+		if f.embedded != nil {
+			b.addFields(s, f.embedded.Underlying().(*types.Struct), hidden)
+			continue
+		}
+		if shadowed[f.name] || s.Properties.Get(f.name) != nil {
+			continue
+		}
+
+		prop := b.schemaFor(f.v.Type())
+		if hasOption(f.options, "string") && (prop.Type == "integer" || prop.Type == "number" || prop.Type == "boolean") {
+			prop = &openapi.Schema{Type: "string", Nullable: prop.Nullable}
+		}
+		if prop.Ref == "" && prop.Description == "" {
+			prop.Description = b.prog.docs[f.v.Pos()]
+		}
+		required := applyValidate(prop, fieldTag(st, f.v))
+		s.Properties = append(s.Properties, openapi.Property{Name: f.name, Schema: prop})
+		if required {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+}
+
+func fieldTag(st *types.Struct, v *types.Var) string {
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i) == v {
+			return reflect.StructTag(st.Tag(i)).Get("validate")
+		}
+	}
+	return ""
+}
+
+// applyValidate adds the constraints of a validate tag to a schema and reports whether the tag
+// requires the field
+func applyValidate(s *openapi.Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	optional, required := false, false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// Later rules apply to the elements of a slice or map
+			return required
+		case "omitempty", "omitnil":
+			optional = true
+		case "required", "notblank":
+			required = !optional
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err == nil {
+				applyBound(s, name, n)
+			}
+		}
+	}
+	return required
+}
+
+// applyBound applies a min, max, len, gt, gte, lt or lte rule, which bound the length of strings
+// and slices and the value of numbers
+func applyBound(s *openapi.Schema, rule string, n float64) {
+	i := int(n)
+	switch s.Type {
+	case "string":
+		if s.Format != "" {
+			return
+		}
+		switch rule {
+		case "min", "gte":
+			s.MinLength = &i
+		case "max", "lte":
+			s.MaxLength = &i
+		case "len":
+			s.MinLength, s.MaxLength = &i, &i
+		}
+	case "array":
+		switch rule {
+		case "min", "gte":
+			s.MinItems = &i
+		case "max", "lte":
+			s.MaxItems = &i
+		case "len":
+			s.MinItems, s.MaxItems = &i, &i
+		}
+	case "integer", "number":
+		switch rule {
+		case "min", "gte":
+			s.Minimum = &n
+		case "gt":
+			s.Minimum, s.ExclusiveMinimum = &n, true
+		case "max", "lte":
+			s.Maximum = &n
+		case "lt":
+			s.Maximum, s.ExclusiveMaximum = &n, true
+		case "len":
+			s.Minimum, s.Maximum = &n, &n
+		}
+	}
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMethod reports whether a type or a pointer to it has a method
+func hasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// genericNames are type names that only make sense next to their package name, such as
+// graphql.Request
+var genericNames = map[string]bool{"Request": true, "Response": true, "Result": true, "Config": true}
+
+// componentNames gives each component the name of its Go type, prefixed with its package name
+// when types from several packages share the name or the name is generic
+func (b *schemaBuilder) componentNames() map[string]string {
+	count := make(map[string]int)
+	for _, c := range b.components {
+		count[c.typeName]++
+	}
+	names := make(map[string]string, len(b.components))
+	for qualified, c := range b.components {
+		name := c.typeName
+		if count[name] > 1 || genericNames[name] {
+			pkg := c.pkgPath[strings.LastIndex(c.pkgPath, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		names[qualified] = name
+	}
+	return names
+}
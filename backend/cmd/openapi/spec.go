@@ -0,0 +1,444 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/openapi"
+)
+
+const (
+	bearerScheme = "bearerAuth"
+	apiKeyScheme = "apiKeyAuth"
+	errorSchema  = "ErrorResponse"
+	jsonMedia    = "application/json"
+)
+
+// operation is a route with the OpenAPI operation generated for it
+type operation struct {
+	route
+	op *openapi.Operation
+}
+
+// generator builds the OpenAPI document from the routes and their handlers
+type generator struct {
+	prog     *program
+	routes   *routeFinder
+	schemas  *schemaBuilder
+	analyzer *analyzer
+	ops      []*operation
+}
+
+func newGenerator(prog *program, routes *routeFinder) *generator {
+	schemas := newSchemaBuilder(prog)
+	return &generator{
+		prog:     prog,
+		routes:   routes,
+		schemas:  schemas,
+		analyzer: newAnalyzer(prog, schemas),
+	}
+}
+
+func (g *generator) document(title, version string) *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       title,
+			Description: "Generated by cmd/openapi from the routes registered in cmd/api and the handlers serving them.",
+			Version:     version,
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			Schemas: make(map[string]*openapi.Schema),
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Access token returned by /api/v1/auth/login"},
+				apiKeyScheme: {Type: "apiKey", In: "header", Name: g.middlewareConst("APIKeyHeader", "X-API-Key"), Description: "API key for integrations"},
+			},
+		},
+	}
+
+	if obj := g.prog.lookup(g.prog.module+"/internal/middleware", "ErrorResponse"); obj != nil {
+		g.schemas.schemaFor(obj.Type())
+	}
+
+	for _, r := range g.routes.routes {
+		g.ops = append(g.ops, &operation{route: r, op: g.operation(r)})
+	}
+	g.assignOperationIDs()
+	g.hoistBodies()
+
+	// Component references use qualified Go names until every type has been seen
+	names := g.schemas.componentNames()
+	rename := func(s *openapi.Schema) {
+		if strings.HasPrefix(s.Ref, refPrefix) {
+			if name, ok := names[strings.TrimPrefix(s.Ref, refPrefix)]; ok {
+				s.Ref = refPrefix + name
+			}
+		}
+	}
+	for qualified, c := range g.schemas.components {
+		doc.Components.Schemas[names[qualified]] = c.schema
+		walkSchema(c.schema, rename)
+	}
+
+	seenTags := make(map[string]bool)
+	for _, o := range g.ops {
+		walkOperation(o.op, rename)
+		item, ok := doc.Paths[o.path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[o.path] = item
+		}
+		(*item)[strings.ToLower(o.method)] = o.op
+		for _, tag := range o.op.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+			}
+		}
+	}
+	return doc
+}
+
+func (g *generator) operation(r route) *openapi.Operation {
+	op := &openapi.Operation{
+		Tags:      []string{tagFor(r.path)},
+		Responses: make(map[string]*openapi.Response),
+	}
+
+	facts := newHandlerFacts()
+	doc := r.comment
+	if r.fn != nil {
+		facts = g.analyzer.analyze(r.fn)
+		if decl := g.prog.funcs[r.fn]; decl != nil && decl.Doc != nil {
+			doc = strings.TrimPrefix(commentText(decl.Doc), r.fn.Name()+" ")
+		}
+	}
+	op.Summary, op.Description = summarize(doc)
+
+	for _, name := range pathParams(r.path) {
+		schema := &openapi.Schema{Type: "string"}
+		if facts.uuidParams[name] {
+			schema.Format = "uuid"
+		}
+		op.Parameters = append(op.Parameters, openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	for _, q := range facts.query {
+		q.Schema = cloneSchema(q.Schema)
+		op.Parameters = append(op.Parameters, q)
+	}
+
+	var notes []string
+	errorStatuses := []int{}
+	for _, mw := range r.middleware {
+		g.applyMiddleware(op, mw, &errorStatuses, &notes)
+	}
+	switch len(op.Permissions) {
+	case 0:
+	case 1:
+		notes = append([]string{"Requires the " + op.Permissions[0] + " permission."}, notes...)
+	default:
+		notes = append([]string{"Requires the " + strings.Join(op.Permissions, " and ") + " permissions."}, notes...)
+	}
+	if len(notes) > 0 {
+		op.Description = strings.TrimSpace(op.Description + "\n\n" + strings.Join(notes, " "))
+	}
+
+	switch {
+	case len(facts.form) > 0:
+		s := &openapi.Schema{Type: "object"}
+		for _, f := range facts.form {
+			prop := &openapi.Schema{Type: "string"}
+			if f.file {
+				prop.Format = "binary"
+				s.Required = append(s.Required, f.name)
+			}
+			s.Properties = append(s.Properties, openapi.Property{Name: f.name, Schema: prop})
+		}
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{"multipart/form-data": {Schema: s}}}
+	case facts.body != nil:
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+			jsonMedia: {Schema: g.schemas.schemaFor(facts.body)},
+		}}
+	}
+
+	if isUpgrade(r.handler, g.routes.info) {
+		op.Responses["101"] = &openapi.Response{Description: "Switching Protocols"}
+	}
+	for _, status := range facts.statuses {
+		fact := facts.responses[status]
+		if fact.isError {
+			errorStatuses = append(errorStatuses, status)
+			continue
+		}
+		resp := &openapi.Response{Description: http.StatusText(status)}
+		switch {
+		case fact.schema != nil:
+			resp.Content = map[string]openapi.MediaType{jsonMedia: {Schema: fact.schema}}
+		case fact.mediaType != "":
+			mediaType := facts.mediaType
+			if mediaType == "" {
+				mediaType = fact.mediaType
+			}
+			resp.Content = map[string]openapi.MediaType{mediaType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	if len(op.Responses) == 0 && facts.mediaType != "" {
+		// Written straight to the response body, such as the metrics text or an event stream
+		op.Responses["200"] = &openapi.Response{
+			Description: "OK",
+			Content:     map[string]openapi.MediaType{facts.mediaType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+		}
+	}
+	if len(op.Responses) == 0 && len(facts.statuses) == 0 {
+		op.Responses["200"] = &openapi.Response{Description: "OK"}
+	}
+
+	errorRef := &openapi.Schema{Ref: refPrefix + g.prog.module + "/internal/middleware." + errorSchema}
+	for _, status := range errorStatuses {
+		key := strconv.Itoa(status)
+		if _, ok := op.Responses[key]; !ok {
+			op.Responses[key] = &openapi.Response{
+				Description: http.StatusText(status),
+				Content:     map[string]openapi.MediaType{jsonMedia: {Schema: cloneSchema(errorRef)}},
+			}
+		}
+	}
+	op.Responses["default"] = &openapi.Response{
+		Description: "Error",
+		Content:     map[string]openapi.MediaType{jsonMedia: {Schema: cloneSchema(errorRef)}},
+	}
+	return op
+}
+
+// applyMiddleware documents what a route's middleware requires of requests
+func (g *generator) applyMiddleware(op *openapi.Operation, expr ast.Expr, errorStatuses *[]int, notes *[]string) {
+	call, ok := g.routes.resolve(expr).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	var fn *types.Func
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		fn, _ = g.routes.info.Uses[fun.Sel].(*types.Func)
+	case *ast.Ident:
+		fn, _ = g.routes.info.Uses[fun].(*types.Func)
+	}
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != g.prog.module+"/internal/middleware" {
+		return
+	}
+
+	switch fn.Name() {
+	case "Authenticate":
+		op.Security = []openapi.SecurityRequirement{{bearerScheme: {}}, {apiKeyScheme: {}}}
+		*errorStatuses = append(*errorStatuses, http.StatusUnauthorized)
+	case "WebSocketAuthMiddleware":
+		op.Security = []openapi.SecurityRequirement{{bearerScheme: {}}}
+		op.Parameters = append(op.Parameters, openapi.Parameter{Name: "token", In: "query",
+			Description: "Access token, for clients that cannot set the Authorization header", Schema: &openapi.Schema{Type: "string"}})
+		*errorStatuses = append(*errorStatuses, http.StatusUnauthorized)
+	case "RequirePermission":
+		for _, arg := range call.Args {
+			if tv, ok := g.routes.info.Types[arg]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+				op.Permissions = append(op.Permissions, constant.StringVal(tv.Value))
+			}
+		}
+		*errorStatuses = append(*errorStatuses, http.StatusForbidden)
+	case "AdminMiddleware":
+		*notes = append(*notes, "Administrators only.")
+		*errorStatuses = append(*errorStatuses, http.StatusForbidden)
+	case "PortfolioAccess", "PortfolioModify", "AlertAccess", "TransactionAccess":
+		*errorStatuses = append(*errorStatuses, http.StatusForbidden)
+	case "Idempotency":
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:        g.middlewareConst("IdempotencyKeyHeader", "Idempotency-Key"),
+			In:          "header",
+			Description: "Makes the request safe to retry: a repeated key returns the stored response",
+			Schema:      &openapi.Schema{Type: "string", MaxLength: intPtr(255)},
+		})
+	}
+}
+
+// middlewareConst reads a string constant of the middleware package
+func (g *generator) middlewareConst(name, fallback string) string {
+	if c, ok := g.prog.lookup(g.prog.module+"/internal/middleware", name).(*types.Const); ok && c.Val().Kind() == constant.String {
+		return constant.StringVal(c.Val())
+	}
+	return fallback
+}
+
+// assignOperationIDs names operations after their handler method, prefixed with the handler's
+// resource when several handlers have a method of the same name
+func (g *generator) assignOperationIDs() {
+	byName := make(map[string]map[*types.Func]bool)
+	for _, o := range g.ops {
+		if o.fn == nil {
+			continue
+		}
+		if byName[o.fn.Name()] == nil {
+			byName[o.fn.Name()] = make(map[*types.Func]bool)
+		}
+		byName[o.fn.Name()][o.fn] = true
+	}
+
+	used := make(map[string]int)
+	for _, o := range g.ops {
+		if o.fn == nil {
+			continue
+		}
+		id := o.fn.Name()
+		if len(byName[id]) > 1 {
+			id = receiverName(o.fn) + id
+		}
+		used[id]++
+		if used[id] > 1 {
+			id += strconv.Itoa(used[id])
+		}
+		o.op.OperationID = id
+	}
+}
+
+// receiverName returns a method's receiver type without its Handler suffix
+func receiverName(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	named, ok := derefNamed(recv.Type())
+	if !ok {
+		return ""
+	}
+	return strings.TrimSuffix(named.Obj().Name(), "Handler")
+}
+
+// hoistBodies moves inline object request and response bodies into components named after their
+// operation, so the client can name their types
+func (g *generator) hoistBodies() {
+	hoist := func(content map[string]openapi.MediaType, name string) {
+		media, ok := content[jsonMedia]
+		if !ok || media.Schema == nil || media.Schema.Ref != "" || media.Schema.Type != "object" || len(media.Schema.Properties) == 0 {
+			return
+		}
+		qualified := "operation." + name
+		g.schemas.components[qualified] = &component{typeName: name, pkgPath: "operation", schema: media.Schema}
+		content[jsonMedia] = openapi.MediaType{Schema: &openapi.Schema{Ref: refPrefix + qualified}}
+	}
+
+	for _, o := range g.ops {
+		if o.op.OperationID == "" {
+			continue
+		}
+		if o.op.RequestBody != nil {
+			hoist(o.op.RequestBody.Content, o.op.OperationID+"Request")
+		}
+		for _, status := range sortedKeys(o.op.Responses) {
+			code, err := strconv.Atoi(status)
+			if err != nil || code >= 300 {
+				continue
+			}
+			hoist(o.op.Responses[status].Content, o.op.OperationID+"Response")
+			break
+		}
+	}
+}
+
+// summarize splits a doc comment into a summary, its first clause, and the full description
+func summarize(doc string) (string, string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return "", ""
+	}
+	doc = upperFirst(doc)
+	summary := doc
+	if i := strings.IndexAny(summary, ".;:("); i > 0 {
+		summary = summary[:i]
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == strings.TrimSuffix(doc, ".") {
+		return summary, ""
+	}
+	return summary, doc
+}
+
+// tagFor groups operations by the first segment after the API version, such as portfolios
+func tagFor(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) >= 3 && segments[0] == "api" {
+		return segments[2]
+	}
+	return "system"
+}
+
+// isUpgrade reports whether a handler is built by a WebSocket package
+func isUpgrade(handler ast.Expr, info *types.Info) bool {
+	call, ok := handler.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	return ok && fn.Pkg() != nil && strings.Contains(fn.Pkg().Path(), "websocket")
+}
+
+func walkOperation(op *openapi.Operation, fn func(*openapi.Schema)) {
+	for _, p := range op.Parameters {
+		walkSchema(p.Schema, fn)
+	}
+	if op.RequestBody != nil {
+		for _, media := range op.RequestBody.Content {
+			walkSchema(media.Schema, fn)
+		}
+	}
+	for _, resp := range op.Responses {
+		for _, media := range resp.Content {
+			walkSchema(media.Schema, fn)
+		}
+	}
+}
+
+func walkSchema(s *openapi.Schema, fn func(*openapi.Schema)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+	walkSchema(s.Items, fn)
+	walkSchema(s.AdditionalProperties, fn)
+	for _, p := range s.Properties {
+		walkSchema(p.Schema, fn)
+	}
+}
+
+func cloneSchema(s *openapi.Schema) *openapi.Schema {
+	c := *s
+	return &c
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func upperFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS screening_results;
+DROP TABLE IF EXISTS sanctions_entries;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty_country;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty_name;
@@ -0,0 +1,36 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_name TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_country TEXT;
+
+CREATE TABLE IF NOT EXISTS sanctions_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source VARCHAR(20) NOT NULL,
+    external_id VARCHAR(100),
+    name TEXT NOT NULL,
+    aliases TEXT,
+    entity_type VARCHAR(20),
+    country TEXT,
+    programs TEXT,
+    is_pep BOOLEAN DEFAULT false,
+    remarks TEXT,
+    listed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS screening_results (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID REFERENCES transactions(id) ON DELETE CASCADE,
+    subject_name TEXT NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    sanctions_hit BOOLEAN DEFAULT false,
+    pep_hit BOOLEAN DEFAULT false,
+    top_score DOUBLE PRECISION,
+    matches JSONB,
+    screened_by UUID REFERENCES users(id),
+    screened_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctions_entries_source ON sanctions_entries(source);
+CREATE INDEX IF NOT EXISTS idx_sanctions_entries_external_id ON sanctions_entries(external_id);
+CREATE INDEX IF NOT EXISTS idx_screening_results_transaction_id ON screening_results(transaction_id);
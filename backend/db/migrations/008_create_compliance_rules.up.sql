@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS compliance_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    description TEXT,
+    scope VARCHAR(20) NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    parameter TEXT,
+    operator VARCHAR(5) NOT NULL,
+    threshold DECIMAL(20, 8) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    portfolio_id UUID REFERENCES portfolios(id) ON DELETE CASCADE,
+    is_active BOOLEAN DEFAULT true,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_compliance_rules_scope ON compliance_rules(scope);
+CREATE INDEX IF NOT EXISTS idx_compliance_rules_portfolio_id ON compliance_rules(portfolio_id);
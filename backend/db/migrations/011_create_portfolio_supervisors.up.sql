@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS portfolio_supervisors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_portfolio_supervisor ON portfolio_supervisors(portfolio_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_portfolio_supervisors_user_id ON portfolio_supervisors(user_id);
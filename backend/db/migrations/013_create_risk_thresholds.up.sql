@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS risk_thresholds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    max_va_r95 DECIMAL(20, 8),
+    max_va_r99 DECIMAL(20, 8),
+    max_position_size DECIMAL(10, 4),
+    max_single_asset_exposure DECIMAL(10, 4),
+    max_sector_exposure DECIMAL(10, 4),
+    min_liquidity_ratio DECIMAL(10, 4),
+    max_leverage DECIMAL(10, 4),
+    max_concentration DECIMAL(10, 4),
+    max_daily_loss DECIMAL(10, 4),
+    max_weekly_loss DECIMAL(10, 4),
+    max_drawdown DECIMAL(10, 4),
+    require_stop_loss BOOLEAN DEFAULT true,
+    max_stop_loss_distance DECIMAL(10, 4),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_thresholds_portfolio_id ON risk_thresholds(portfolio_id);
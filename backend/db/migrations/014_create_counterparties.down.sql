@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_counterparty_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty_id;
+DROP TABLE IF EXISTS counterparties;
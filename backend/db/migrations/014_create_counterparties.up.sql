@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS counterparties (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    lei VARCHAR(20),
+    entity_type VARCHAR(20),
+    jurisdiction VARCHAR(100) NOT NULL,
+    risk_rating VARCHAR(20) DEFAULT 'MEDIUM',
+    kyc_status VARCHAR(20) DEFAULT 'PENDING',
+    kyc_verified_at TIMESTAMP WITH TIME ZONE,
+    kyc_expires_at TIMESTAMP WITH TIME ZONE,
+    exposure_limit DECIMAL(20, 2) DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_counterparties_name ON counterparties(name);
+CREATE INDEX IF NOT EXISTS idx_counterparties_lei ON counterparties(lei);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_id UUID REFERENCES counterparties(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_counterparty_id ON transactions(counterparty_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS case_evidences;
+DROP TABLE IF EXISTS case_notes;
+DROP TABLE IF EXISTS case_transactions;
+DROP TABLE IF EXISTS cases;
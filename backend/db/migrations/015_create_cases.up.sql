@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS cases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    case_number VARCHAR(32) NOT NULL UNIQUE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) DEFAULT 'OPEN',
+    priority VARCHAR(20) DEFAULT 'MEDIUM',
+    alert_id UUID REFERENCES alerts(id) ON DELETE SET NULL,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    narrative TEXT,
+    filing_reference VARCHAR(100),
+    filed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    filed_at TIMESTAMP WITH TIME ZONE,
+    dismissed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    dismissed_at TIMESTAMP WITH TIME ZONE,
+    dismissal_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cases_status ON cases(status);
+CREATE INDEX IF NOT EXISTS idx_cases_alert_id ON cases(alert_id);
+CREATE INDEX IF NOT EXISTS idx_cases_assignee_id ON cases(assignee_id);
+
+CREATE TABLE IF NOT EXISTS case_transactions (
+    case_id UUID NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    PRIMARY KEY (case_id, transaction_id)
+);
+
+CREATE TABLE IF NOT EXISTS case_notes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    case_id UUID NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    kind VARCHAR(20) DEFAULT 'NOTE',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_case_notes_case_id ON case_notes(case_id);
+
+CREATE TABLE IF NOT EXISTS case_evidences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    case_id UUID NOT NULL REFERENCES cases(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255),
+    size BIGINT,
+    sha256 VARCHAR(64),
+    description TEXT,
+    content BYTEA,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_case_evidences_case_id ON case_evidences(case_id);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    report_type VARCHAR(30) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100),
+    size BIGINT,
+    sha256 VARCHAR(64),
+    period_from TIMESTAMP WITH TIME ZONE,
+    period_to TIMESTAMP WITH TIME ZONE,
+    generated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    content BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_portfolio_id ON reports(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_reports_report_type ON reports(report_type);
+CREATE INDEX IF NOT EXISTS idx_reports_created_at ON reports(created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS pnl_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    market_value DECIMAL(20, 2),
+    cost_basis DECIMAL(20, 2),
+    unrealized_pnl DECIMAL(20, 2),
+    realized_pnl DECIMAL(20, 2),
+    daily_pnl DECIMAL(20, 2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pnl_history_portfolio_date ON pnl_history(portfolio_id, date);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_import_id;
+DROP INDEX IF EXISTS idx_transactions_portfolio_external_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS import_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_id;
+DROP TABLE IF EXISTS transaction_imports;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS transaction_imports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    imported_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255),
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    total_rows INTEGER DEFAULT 0,
+    imported INTEGER DEFAULT 0,
+    duplicates INTEGER DEFAULT 0,
+    failed INTEGER DEFAULT 0,
+    errors JSONB,
+    errors_truncated BOOLEAN DEFAULT FALSE,
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_imports_user_key ON transaction_imports(imported_by, idempotency_key);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS import_id UUID REFERENCES transaction_imports(id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_portfolio_external_id ON transactions(portfolio_id, external_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_import_id ON transactions(import_id);
@@ -0,0 +1,3 @@
+ALTER TABLE portfolios DROP COLUMN IF EXISTS maintenance_margin_rate;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS margin_loan;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS cash_balance;
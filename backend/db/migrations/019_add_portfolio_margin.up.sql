@@ -0,0 +1,3 @@
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS cash_balance DECIMAL(20, 2) DEFAULT 0;
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS margin_loan DECIMAL(20, 2) DEFAULT 0;
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS maintenance_margin_rate DECIMAL(10, 4) DEFAULT 0.25;
@@ -0,0 +1,5 @@
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS max_fx_exposure;
+
+ALTER TABLE positions DROP COLUMN IF EXISTS local_market_value;
+ALTER TABLE positions DROP COLUMN IF EXISTS fx_rate;
+ALTER TABLE positions DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,8 @@
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) DEFAULT 'USD';
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS fx_rate DECIMAL(20, 10) DEFAULT 1;
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS local_market_value DECIMAL(20, 2);
+
+-- Existing positions were valued as if everything were in US dollars
+UPDATE positions SET local_market_value = market_value WHERE local_market_value IS NULL;
+
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS max_fx_exposure DECIMAL(10, 4) DEFAULT 0.50;
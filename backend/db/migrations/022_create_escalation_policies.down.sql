@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS alert_escalations;
+
+ALTER TABLE alerts DROP COLUMN IF EXISTS escalation_policy_id;
+ALTER TABLE alerts DROP COLUMN IF EXISTS escalation_level;
+
+DROP TABLE IF EXISTS escalation_policies;
+DROP TABLE IF EXISTS on_call_shifts;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS position_liquidity;
+DROP TABLE IF EXISTS symbol_market_data;
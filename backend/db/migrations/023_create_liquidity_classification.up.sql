@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS symbol_market_data (
+    symbol VARCHAR(20) PRIMARY KEY,
+    average_daily_volume DECIMAL(24,4) NOT NULL,
+    bid_ask_spread DECIMAL(10,6) NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS position_liquidity (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    position_id UUID NOT NULL UNIQUE REFERENCES positions(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    calculated_class VARCHAR(10),
+    score DECIMAL(5,2),
+    days_to_liquidate DECIMAL(12,2),
+    average_daily_volume DECIMAL(24,4),
+    bid_ask_spread DECIMAL(10,6),
+    calculated_at TIMESTAMP WITH TIME ZONE,
+    override_class VARCHAR(10),
+    override_reason TEXT,
+    overridden_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    overridden_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS cash_ledger (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(20,2) NOT NULL,
+    balance_after DECIMAL(20,2) NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_ledger_portfolio_id ON cash_ledger(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_cash_ledger_transaction_id ON cash_ledger(transaction_id);
+
+-- Open the ledger of existing portfolios at their current cash balance
+INSERT INTO cash_ledger (portfolio_id, entry_type, amount, balance_after, description)
+SELECT id, 'ADJUSTMENT', cash_balance, cash_balance, 'Opening balance'
+FROM portfolios
+WHERE cash_balance <> 0;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS transaction_fills;
+ALTER TABLE transactions DROP COLUMN IF EXISTS average_fill_price;
+ALTER TABLE transactions DROP COLUMN IF EXISTS filled_quantity;
+ALTER TABLE transactions DROP COLUMN IF EXISTS order_status_reason;
+ALTER TABLE transactions DROP COLUMN IF EXISTS order_status;
@@ -0,0 +1,30 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS order_status VARCHAR(20);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS order_status_reason TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS filled_quantity DECIMAL(20, 8) DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS average_fill_price DECIMAL(20, 8) DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_fills (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    quantity DECIMAL(20,8) NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    amount DECIMAL(20,2) NOT NULL,
+    execution_id VARCHAR(100),
+    executed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_fills_transaction_id ON transaction_fills(transaction_id);
+
+-- Existing orders take the lifecycle state matching their status; completed ones were filled in full
+UPDATE transactions SET order_status = CASE status
+        WHEN 'COMPLETED' THEN 'FILLED'
+        WHEN 'FAILED' THEN 'REJECTED'
+        WHEN 'CANCELLED' THEN 'CANCELLED'
+        ELSE 'NEW'
+    END
+WHERE transaction_type IN ('BUY', 'SELL') AND order_status IS NULL;
+
+UPDATE transactions SET filled_quantity = quantity, average_fill_price = price
+WHERE order_status = 'FILLED';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_alerts_deleted_at;
+DROP INDEX IF EXISTS idx_transactions_deleted_at;
+DROP INDEX IF EXISTS idx_positions_deleted_at;
+DROP INDEX IF EXISTS idx_portfolios_deleted_at;
+
+ALTER TABLE alerts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE positions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS deleted_at;
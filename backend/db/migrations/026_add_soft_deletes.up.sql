@@ -0,0 +1,10 @@
+-- Deleting financial records marks them deleted; they are purged once past the retention period
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_portfolios_deleted_at ON portfolios(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_positions_deleted_at ON positions(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_alerts_deleted_at ON alerts(deleted_at);
@@ -0,0 +1,7 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS risk_violations;
+ALTER TABLE transactions DROP COLUMN IF EXISTS requires_review;
+ALTER TABLE transactions DROP COLUMN IF EXISTS risk_approved;
+ALTER TABLE transactions DROP COLUMN IF EXISTS take_profit;
+ALTER TABLE transactions DROP COLUMN IF EXISTS stop_loss;
+ALTER TABLE transactions DROP COLUMN IF EXISTS asset_type;
+ALTER TABLE transactions DROP COLUMN IF EXISTS side;
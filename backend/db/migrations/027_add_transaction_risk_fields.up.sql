@@ -0,0 +1,8 @@
+-- Pre-trade risk check fields that were only ever created by AutoMigrate
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS side TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS asset_type TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS stop_loss DECIMAL(20, 8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS take_profit DECIMAL(20, 8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS risk_approved BOOLEAN DEFAULT false;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS requires_review BOOLEAN DEFAULT false;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS risk_violations JSONB;
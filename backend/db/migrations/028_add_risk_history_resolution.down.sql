@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_risk_histories_resolution_recorded;
+DROP INDEX IF EXISTS idx_risk_histories_portfolio_metric_recorded;
+
+ALTER TABLE risk_histories DROP COLUMN IF EXISTS resolution;
@@ -0,0 +1,5 @@
+-- Snapshots are recorded as RAW and later averaged into HOURLY and then DAILY rows
+ALTER TABLE risk_histories ADD COLUMN IF NOT EXISTS resolution VARCHAR(10) NOT NULL DEFAULT 'RAW';
+
+CREATE INDEX IF NOT EXISTS idx_risk_histories_portfolio_metric_recorded ON risk_histories(portfolio_id, metric_type, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_risk_histories_resolution_recorded ON risk_histories(resolution, recorded_at);
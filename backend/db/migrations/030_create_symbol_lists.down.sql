@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS watch_lists;
+
+-- Only the latest entry of each symbol is kept, as the old table allowed one
+DELETE FROM restricted_lists r USING restricted_lists newer
+    WHERE r.symbol = newer.symbol AND r.created_at < newer.created_at;
+DROP INDEX IF EXISTS idx_restricted_lists_symbol;
+ALTER TABLE restricted_lists DROP COLUMN IF EXISTS effective_from;
+ALTER TABLE restricted_lists RENAME COLUMN effective_to TO expires_at;
+ALTER TABLE restricted_lists RENAME TO restricted_instruments;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_restricted_instruments_symbol ON restricted_instruments(symbol);
@@ -0,0 +1,20 @@
+-- The restricted list gains effective date ranges, so a symbol may be listed for several periods
+ALTER TABLE restricted_instruments RENAME TO restricted_lists;
+ALTER TABLE restricted_lists RENAME COLUMN expires_at TO effective_to;
+ALTER TABLE restricted_lists ADD COLUMN IF NOT EXISTS effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+DROP INDEX IF EXISTS idx_restricted_instruments_symbol;
+CREATE INDEX IF NOT EXISTS idx_restricted_lists_symbol ON restricted_lists(symbol);
+
+CREATE TABLE IF NOT EXISTS watch_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol VARCHAR(20) NOT NULL,
+    side VARCHAR(10),
+    reason TEXT NOT NULL,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    effective_to TIMESTAMP WITH TIME ZONE,
+    added_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_lists_symbol ON watch_lists(symbol);
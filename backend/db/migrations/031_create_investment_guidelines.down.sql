@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS credit_rating;
+ALTER TABLE positions DROP COLUMN IF EXISTS credit_rating;
+DROP TABLE IF EXISTS investment_guidelines;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS investment_guidelines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL UNIQUE REFERENCES portfolios(id) ON DELETE CASCADE,
+    allowed_asset_types JSONB,
+    max_crypto_percent DECIMAL(10, 4),
+    min_credit_rating VARCHAR(4),
+    esg_excluded_symbols JSONB,
+    esg_excluded_sectors JSONB,
+    is_active BOOLEAN DEFAULT true,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Bond ratings, checked against a guideline's minimum credit rating
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS credit_rating VARCHAR(4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS credit_rating VARCHAR(4);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS kyc_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id UUID NOT NULL,
+    status VARCHAR(20) DEFAULT 'PENDING',
+    risk_rating VARCHAR(20) DEFAULT 'MEDIUM',
+    documents JSONB,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    last_reviewed_at TIMESTAMP WITH TIME ZONE,
+    last_reviewed_by UUID REFERENCES users(id),
+    next_review_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_profiles_subject ON kyc_profiles(subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_profiles_next_review_at ON kyc_profiles(next_review_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS transaction_monitoring_evaluations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    features JSONB,
+    rules_evaluated JSONB,
+    hits JSONB,
+    anomaly_scorer VARCHAR(20),
+    anomaly_score DOUBLE PRECISION,
+    anomaly_error TEXT,
+    risk_score INTEGER DEFAULT 0,
+    requires_review BOOLEAN DEFAULT false,
+    evaluated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_monitoring_evaluations_transaction_id ON transaction_monitoring_evaluations(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_monitoring_evaluations_portfolio_id ON transaction_monitoring_evaluations(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_monitoring_evaluations_evaluated_at ON transaction_monitoring_evaluations(evaluated_at);
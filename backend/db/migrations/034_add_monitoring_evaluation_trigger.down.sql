@@ -0,0 +1 @@
+ALTER TABLE transaction_monitoring_evaluations DROP COLUMN IF EXISTS trigger;
@@ -0,0 +1 @@
+ALTER TABLE transaction_monitoring_evaluations ADD COLUMN IF NOT EXISTS trigger VARCHAR(20) DEFAULT 'AML_CHECK';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_approval_status;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS review_comment;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE transactions DROP COLUMN IF EXISTS approval_reason;
+ALTER TABLE transactions DROP COLUMN IF EXISTS approval_status;
+ALTER TABLE transactions DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,8 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS approval_status VARCHAR(20);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS approval_reason TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS review_comment TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_approval_status ON transactions(approval_status);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS benchmark_prices;
+
+ALTER TABLE portfolios DROP COLUMN IF EXISTS benchmark;
@@ -0,0 +1,13 @@
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS benchmark VARCHAR(20);
+
+CREATE TABLE IF NOT EXISTS benchmark_prices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    close DECIMAL(20, 8) NOT NULL,
+    source VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_benchmark_prices_symbol_date ON benchmark_prices(symbol, date);
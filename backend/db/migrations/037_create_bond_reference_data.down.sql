@@ -0,0 +1,3 @@
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS max_dv01;
+
+DROP TABLE IF EXISTS bond_reference_data;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS bond_reference_data (
+    symbol VARCHAR(20) PRIMARY KEY,
+    coupon_rate DECIMAL(10, 6) NOT NULL,
+    coupon_frequency INTEGER NOT NULL DEFAULT 2,
+    maturity_date DATE NOT NULL,
+    face_value DECIMAL(20, 8) NOT NULL DEFAULT 100,
+    credit_rating VARCHAR(4),
+    issuer VARCHAR(255),
+    updated_by UUID REFERENCES users(id),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS max_dv01 DECIMAL(10, 6) DEFAULT 0.001;
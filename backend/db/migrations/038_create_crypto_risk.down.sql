@@ -0,0 +1,6 @@
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS max_stablecoin_depeg;
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS max_venue_exposure;
+
+DROP TABLE IF EXISTS crypto_price_samples;
+DROP TABLE IF EXISTS crypto_custodies;
+DROP TABLE IF EXISTS crypto_assets;
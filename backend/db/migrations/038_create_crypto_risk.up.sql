@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS crypto_assets (
+    symbol VARCHAR(20) PRIMARY KEY,
+    chain VARCHAR(30) NOT NULL,
+    is_stablecoin BOOLEAN DEFAULT false,
+    peg_currency VARCHAR(3),
+    peg_price DECIMAL(20, 8),
+    updated_by UUID REFERENCES users(id),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS crypto_custodies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    venue_type VARCHAR(20) NOT NULL,
+    venue_name VARCHAR(255) NOT NULL,
+    counterparty_id UUID REFERENCES counterparties(id),
+    wallet_address VARCHAR(255),
+    quantity DECIMAL(20, 8) NOT NULL,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_crypto_custodies_portfolio_id ON crypto_custodies(portfolio_id);
+
+CREATE TABLE IF NOT EXISTS crypto_price_samples (
+    symbol VARCHAR(20) NOT NULL,
+    hour TIMESTAMP WITH TIME ZONE NOT NULL,
+    price DECIMAL(20, 8) NOT NULL,
+    PRIMARY KEY (symbol, hour)
+);
+
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS max_venue_exposure DECIMAL(10, 4) DEFAULT 0.50;
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS max_stablecoin_depeg DECIMAL(10, 4) DEFAULT 0.02;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS target_allocations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    key VARCHAR(30) NOT NULL,
+    target_weight DECIMAL(10, 4) NOT NULL,
+    tolerance DECIMAL(10, 4) NOT NULL,
+    updated_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (portfolio_id, kind, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_target_allocations_portfolio_id ON target_allocations(portfolio_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_portfolios_team_id;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS team_id;
+
+DROP TABLE IF EXISTS team_members;
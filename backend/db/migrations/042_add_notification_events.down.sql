@@ -0,0 +1,5 @@
+ALTER TABLE notification_deliveries DROP COLUMN IF EXISTS redelivery_of;
+ALTER TABLE notification_deliveries DROP COLUMN IF EXISTS payload;
+ALTER TABLE notification_deliveries DROP COLUMN IF EXISTS event;
+
+ALTER TABLE notification_channels DROP COLUMN IF EXISTS events;
@@ -0,0 +1,5 @@
+ALTER TABLE notification_channels ADD COLUMN IF NOT EXISTS events VARCHAR(255) NOT NULL DEFAULT 'alert.created';
+
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS event VARCHAR(40) NOT NULL DEFAULT 'alert.created';
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS payload JSONB;
+ALTER TABLE notification_deliveries ADD COLUMN IF NOT EXISTS redelivery_of UUID REFERENCES notification_deliveries(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_aml_pending;
+DROP INDEX IF EXISTS idx_transactions_risk_score;
+DROP INDEX IF EXISTS idx_transactions_amount;
+DROP INDEX IF EXISTS idx_transactions_symbol;
+DROP INDEX IF EXISTS idx_transactions_type_trade_date;
+DROP INDEX IF EXISTS idx_transactions_trade_date;
@@ -0,0 +1,8 @@
+-- Indexes behind GET /api/v1/transactions/search. The trade date falls back to when the
+-- transaction was recorded, so the expression index matches the search's date column.
+CREATE INDEX IF NOT EXISTS idx_transactions_trade_date ON transactions((COALESCE(executed_at, created_at)));
+CREATE INDEX IF NOT EXISTS idx_transactions_type_trade_date ON transactions(transaction_type, (COALESCE(executed_at, created_at)));
+CREATE INDEX IF NOT EXISTS idx_transactions_symbol ON transactions(symbol);
+CREATE INDEX IF NOT EXISTS idx_transactions_amount ON transactions(amount);
+CREATE INDEX IF NOT EXISTS idx_transactions_risk_score ON transactions(risk_score);
+CREATE INDEX IF NOT EXISTS idx_transactions_aml_pending ON transactions(created_at) WHERE aml_checked = false;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS portfolio_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    nav DECIMAL(20,2),
+    market_value DECIMAL(20,2),
+    cash_balance DECIMAL(20,2),
+    margin_loan DECIMAL(20,2),
+    positions JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_portfolio_snapshots_portfolio_date ON portfolio_snapshots(portfolio_id, date);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reconciliation_breaks;
+DROP TABLE IF EXISTS reconciliation_runs;
+
+ALTER TABLE portfolios DROP COLUMN IF EXISTS custodian_account;
@@ -0,0 +1,44 @@
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS custodian_account VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_portfolios_custodian_account ON portfolios(custodian_account);
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255),
+    as_of DATE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    portfolios INTEGER DEFAULT 0,
+    portfolio_ids JSONB,
+    positions INTEGER DEFAULT 0,
+    matched INTEGER DEFAULT 0,
+    breaks INTEGER DEFAULT 0,
+    unexplained INTEGER DEFAULT 0,
+    message TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_created_at ON reconciliation_runs(created_at);
+
+CREATE TABLE IF NOT EXISTS reconciliation_breaks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    break_type VARCHAR(20) NOT NULL,
+    internal_quantity DECIMAL(20,8),
+    external_quantity DECIMAL(20,8),
+    quantity_difference DECIMAL(20,8),
+    internal_price DECIMAL(20,8),
+    external_price DECIMAL(20,8),
+    status VARCHAR(20) NOT NULL,
+    explanation TEXT,
+    explained_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    explained_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_run_id ON reconciliation_breaks(run_id);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_portfolio_status ON reconciliation_breaks(portfolio_id, status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS batch_job_runs;
+DROP TABLE IF EXISTS batch_runs;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS batch_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pipeline VARCHAR(50) NOT NULL,
+    business_date DATE NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    triggered_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_batch_runs_started_at ON batch_runs(started_at);
+
+-- Only one run of a pipeline at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batch_runs_running ON batch_runs(pipeline) WHERE status = 'RUNNING';
+
+CREATE TABLE IF NOT EXISTS batch_job_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id UUID NOT NULL REFERENCES batch_runs(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    depends_on JSONB,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER DEFAULT 0,
+    output TEXT,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_batch_job_runs_run_name ON batch_job_runs(run_id, name);
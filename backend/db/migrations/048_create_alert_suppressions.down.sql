@@ -0,0 +1,3 @@
+ALTER TABLE alerts DROP COLUMN IF EXISTS suppression_id;
+
+DROP TABLE IF EXISTS alert_suppressions;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS alert_suppressions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID REFERENCES portfolios(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES compliance_rules(id) ON DELETE CASCADE,
+    source VARCHAR(100),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    cancelled_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_period ON alert_suppressions(starts_at, ends_at);
+CREATE INDEX IF NOT EXISTS idx_alert_suppressions_portfolio_id ON alert_suppressions(portfolio_id);
+
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS suppression_id UUID REFERENCES alert_suppressions(id) ON DELETE SET NULL;
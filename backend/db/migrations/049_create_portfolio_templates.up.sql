@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS portfolio_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    currency VARCHAR(3) NOT NULL,
+    benchmark VARCHAR(20),
+    cash_balance DECIMAL(20, 2) DEFAULT 0,
+    positions JSONB NOT NULL DEFAULT '[]',
+    thresholds JSONB,
+    guideline JSONB,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lot_closures;
+DROP TABLE IF EXISTS position_lots;
+ALTER TABLE transactions DROP COLUMN IF EXISTS realized_pnl;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS cost_basis_method;
@@ -0,0 +1,48 @@
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS cost_basis_method VARCHAR(10) NOT NULL DEFAULT 'FIFO';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS realized_pnl DECIMAL(20, 2);
+
+CREATE TABLE IF NOT EXISTS position_lots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    position_id UUID REFERENCES positions(id) ON DELETE SET NULL,
+    symbol VARCHAR(10) NOT NULL,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    fill_id UUID NOT NULL REFERENCES transaction_fills(id) ON DELETE CASCADE,
+    currency VARCHAR(3),
+    quantity DECIMAL(20, 8) NOT NULL,
+    remaining_quantity DECIMAL(20, 8) NOT NULL,
+    cost_price DECIMAL(20, 8) NOT NULL,
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (remaining_quantity >= 0 AND remaining_quantity <= quantity)
+);
+
+CREATE INDEX IF NOT EXISTS idx_position_lots_portfolio_id ON position_lots(portfolio_id);
+CREATE INDEX IF NOT EXISTS idx_position_lots_transaction_id ON position_lots(transaction_id);
+-- Open lots of a symbol, which sales consume
+CREATE INDEX IF NOT EXISTS idx_position_lots_open ON position_lots(portfolio_id, symbol) WHERE remaining_quantity > 0;
+
+CREATE TABLE IF NOT EXISTS lot_closures (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lot_id UUID REFERENCES position_lots(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(10) NOT NULL,
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    fill_id UUID NOT NULL REFERENCES transaction_fills(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    quantity DECIMAL(20, 8) NOT NULL,
+    cost_price DECIMAL(20, 8) NOT NULL,
+    sale_price DECIMAL(20, 8) NOT NULL,
+    fx_rate DECIMAL(20, 10) DEFAULT 1,
+    realized_pnl DECIMAL(20, 2) NOT NULL,
+    holding_term VARCHAR(10),
+    opened_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_closures_lot_id ON lot_closures(lot_id);
+CREATE INDEX IF NOT EXISTS idx_lot_closures_transaction_id ON lot_closures(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_lot_closures_portfolio_closed_at ON lot_closures(portfolio_id, closed_at);
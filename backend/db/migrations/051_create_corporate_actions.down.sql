@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS corporate_action_adjustments;
+DROP TABLE IF EXISTS corporate_actions;
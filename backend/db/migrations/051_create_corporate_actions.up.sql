@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS corporate_actions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol VARCHAR(10) NOT NULL,
+    action_type VARCHAR(20) NOT NULL,
+    ex_date DATE NOT NULL,
+    pay_date DATE,
+    ratio_from DECIMAL(20, 8),
+    ratio_to DECIMAL(20, 8),
+    cash_amount DECIMAL(20, 8),
+    currency VARCHAR(3),
+    new_symbol VARCHAR(10),
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    applied_at TIMESTAMP WITH TIME ZONE,
+    applied_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    rolled_back_at TIMESTAMP WITH TIME ZONE,
+    rolled_back_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_symbol ON corporate_actions(symbol);
+-- Pending actions the end-of-day batch applies once their ex-date is reached
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_pending ON corporate_actions(ex_date) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS corporate_action_adjustments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    action_id UUID NOT NULL REFERENCES corporate_actions(id) ON DELETE CASCADE,
+    portfolio_id UUID REFERENCES portfolios(id) ON DELETE CASCADE,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id UUID,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_action_adjustments_action_id ON corporate_action_adjustments(action_id);
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS alerts_tenant ON alerts;
+DROP TRIGGER IF EXISTS transactions_tenant ON transactions;
+DROP TRIGGER IF EXISTS portfolios_tenant_moved ON portfolios;
+DROP TRIGGER IF EXISTS portfolios_tenant ON portfolios;
+DROP FUNCTION IF EXISTS set_portfolio_child_tenant();
+DROP FUNCTION IF EXISTS move_portfolio_tenant();
+DROP FUNCTION IF EXISTS set_portfolio_tenant();
+
+-- first_name and last_name stay TEXT, since encrypted names would not fit back
+ALTER TABLE alerts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,70 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    settings JSONB NOT NULL DEFAULT '{}',
+    encrypt_pii BOOLEAN NOT NULL DEFAULT false,
+    data_key TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE RESTRICT;
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE RESTRICT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE RESTRICT;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_portfolios_tenant_id ON portfolios(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id ON transactions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_tenant_id ON alerts(tenant_id);
+
+-- Names encrypted with a tenant's data key are longer than the plain names
+ALTER TABLE users ALTER COLUMN first_name TYPE TEXT;
+ALTER TABLE users ALTER COLUMN last_name TYPE TEXT;
+
+-- A portfolio belongs to its owner's tenant, and its transactions and alerts to the portfolio's,
+-- whichever code path writes them
+CREATE OR REPLACE FUNCTION set_portfolio_tenant() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.tenant_id := (SELECT tenant_id FROM users WHERE id = NEW.user_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS portfolios_tenant ON portfolios;
+CREATE TRIGGER portfolios_tenant
+    BEFORE INSERT OR UPDATE OF user_id ON portfolios
+    FOR EACH ROW EXECUTE FUNCTION set_portfolio_tenant();
+
+-- A portfolio moving to an owner in another tenant takes its transactions and alerts along
+CREATE OR REPLACE FUNCTION move_portfolio_tenant() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE transactions SET tenant_id = NEW.tenant_id WHERE portfolio_id = NEW.id;
+    UPDATE alerts SET tenant_id = NEW.tenant_id WHERE portfolio_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS portfolios_tenant_moved ON portfolios;
+CREATE TRIGGER portfolios_tenant_moved
+    AFTER UPDATE OF user_id ON portfolios
+    FOR EACH ROW WHEN (OLD.tenant_id IS DISTINCT FROM NEW.tenant_id)
+    EXECUTE FUNCTION move_portfolio_tenant();
+
+CREATE OR REPLACE FUNCTION set_portfolio_child_tenant() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.tenant_id := (SELECT tenant_id FROM portfolios WHERE id = NEW.portfolio_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transactions_tenant ON transactions;
+CREATE TRIGGER transactions_tenant
+    BEFORE INSERT OR UPDATE OF portfolio_id ON transactions
+    FOR EACH ROW EXECUTE FUNCTION set_portfolio_child_tenant();
+
+DROP TRIGGER IF EXISTS alerts_tenant ON alerts;
+CREATE TRIGGER alerts_tenant
+    BEFORE INSERT OR UPDATE OF portfolio_id ON alerts
+    FOR EACH ROW EXECUTE FUNCTION set_portfolio_child_tenant();
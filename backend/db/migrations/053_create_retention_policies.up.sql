@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS retention_policies (
+    entity_type VARCHAR(50) PRIMARY KEY,
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO retention_policies (entity_type, retention_days) VALUES
+    ('alerts', 730),
+    ('transactions', 2555),
+    ('audit_logs', 3650),
+    ('closed_users', 90)
+ON CONFLICT (entity_type) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;
+
+-- Audit entries stay append-only, except for the retention job deleting those past their policy
+CREATE OR REPLACE FUNCTION prevent_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('app.retention_purge', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
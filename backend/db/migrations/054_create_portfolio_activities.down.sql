@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS risk_thresholds_activity ON risk_thresholds;
+DROP TRIGGER IF EXISTS alerts_activity ON alerts;
+DROP TRIGGER IF EXISTS transactions_activity ON transactions;
+DROP FUNCTION IF EXISTS record_threshold_activity();
+DROP FUNCTION IF EXISTS record_alert_activity();
+DROP FUNCTION IF EXISTS record_transaction_activity();
+
+DROP TABLE IF EXISTS portfolio_activities;
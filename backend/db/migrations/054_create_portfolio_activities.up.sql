@@ -0,0 +1,115 @@
+-- One timeline per portfolio of its transactions, alerts, threshold changes and status transitions,
+-- written by triggers so that every code path that changes them is recorded
+CREATE TABLE IF NOT EXISTS portfolio_activities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    activity_type VARCHAR(30) NOT NULL,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id UUID NOT NULL,
+    summary TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_activities_timeline ON portfolio_activities(portfolio_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_portfolio_activities_entity ON portfolio_activities(entity_type, entity_id);
+
+CREATE OR REPLACE FUNCTION record_transaction_activity() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details, occurred_at)
+        VALUES (NEW.portfolio_id, 'TRANSACTION', 'transaction', NEW.id,
+            trim(format('%s %s %s', NEW.transaction_type, trim_scale(NEW.quantity), COALESCE(NEW.symbol, ''))),
+            jsonb_build_object('transaction_type', NEW.transaction_type, 'symbol', NEW.symbol,
+                'quantity', NEW.quantity, 'price', NEW.price, 'amount', NEW.amount,
+                'currency', NEW.currency, 'status', NEW.status),
+            COALESCE(NEW.created_at, CURRENT_TIMESTAMP));
+    ELSIF NEW.status IS DISTINCT FROM OLD.status OR NEW.order_status IS DISTINCT FROM OLD.order_status THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'STATUS_CHANGE', 'transaction', NEW.id,
+            format('%s %s %s: %s to %s', NEW.transaction_type, trim_scale(NEW.quantity), COALESCE(NEW.symbol, ''),
+                COALESCE(OLD.order_status, OLD.status), COALESCE(NEW.order_status, NEW.status)),
+            jsonb_build_object('from_status', OLD.status, 'to_status', NEW.status,
+                'from_order_status', OLD.order_status, 'to_order_status', NEW.order_status,
+                'filled_quantity', NEW.filled_quantity));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transactions_activity ON transactions;
+CREATE TRIGGER transactions_activity
+    AFTER INSERT OR UPDATE OF status, order_status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_transaction_activity();
+
+CREATE OR REPLACE FUNCTION record_alert_activity() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details, occurred_at)
+        VALUES (NEW.portfolio_id, 'ALERT', 'alert', NEW.id, NEW.title,
+            jsonb_build_object('alert_type', NEW.alert_type, 'severity', NEW.severity,
+                'source', NEW.source, 'status', NEW.status),
+            COALESCE(NEW.created_at, CURRENT_TIMESTAMP));
+    ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'STATUS_CHANGE', 'alert', NEW.id,
+            format('%s: %s to %s', NEW.title, OLD.status, NEW.status),
+            jsonb_build_object('from_status', OLD.status, 'to_status', NEW.status,
+                'severity', NEW.severity, 'resolution', NULLIF(NEW.resolution, '')));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS alerts_activity ON alerts;
+CREATE TRIGGER alerts_activity
+    AFTER INSERT OR UPDATE OF status ON alerts
+    FOR EACH ROW EXECUTE FUNCTION record_alert_activity();
+
+-- Records the limits that changed, each as {"from", "to"}; the first thresholds of a portfolio are
+-- recorded with every limit
+CREATE OR REPLACE FUNCTION record_threshold_activity() RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg(new_limit.key, jsonb_build_object('from', old_limit.value, 'to', new_limit.value)), '{}')
+    INTO changes
+    FROM jsonb_each(to_jsonb(NEW)) AS new_limit
+    LEFT JOIN jsonb_each(CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END) AS old_limit
+        ON old_limit.key = new_limit.key
+    WHERE new_limit.key NOT IN ('id', 'portfolio_id', 'created_at', 'updated_at')
+        AND old_limit.value IS DISTINCT FROM new_limit.value;
+
+    IF changes <> '{}' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'THRESHOLD_CHANGE', 'risk_thresholds', NEW.id,
+            CASE WHEN TG_OP = 'INSERT' THEN 'Risk thresholds set'
+                ELSE format('Risk thresholds changed: %s', (SELECT string_agg(key, ', ' ORDER BY key) FROM jsonb_object_keys(changes) AS key))
+            END,
+            changes);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS risk_thresholds_activity ON risk_thresholds;
+CREATE TRIGGER risk_thresholds_activity
+    AFTER INSERT OR UPDATE ON risk_thresholds
+    FOR EACH ROW EXECUTE FUNCTION record_threshold_activity();
+
+-- Earlier transactions and alerts start the timeline; their past status transitions were not kept
+INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details, occurred_at)
+SELECT portfolio_id, 'TRANSACTION', 'transaction', id,
+    trim(format('%s %s %s', transaction_type, trim_scale(quantity), COALESCE(symbol, ''))),
+    jsonb_build_object('transaction_type', transaction_type, 'symbol', symbol, 'quantity', quantity,
+        'price', price, 'amount', amount, 'currency', currency, 'status', status),
+    created_at
+FROM transactions
+WHERE deleted_at IS NULL;
+
+INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details, occurred_at)
+SELECT portfolio_id, 'ALERT', 'alert', id, title,
+    jsonb_build_object('alert_type', alert_type, 'severity', severity, 'source', source, 'status', status),
+    created_at
+FROM alerts
+WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS alerts_version ON alerts;
+DROP TRIGGER IF EXISTS risk_thresholds_version ON risk_thresholds;
+DROP TRIGGER IF EXISTS portfolios_version ON portfolios;
+DROP FUNCTION IF EXISTS raise_record_version();
+
+ALTER TABLE alerts DROP COLUMN IF EXISTS version;
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS version;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS version;
@@ -0,0 +1,41 @@
+-- Portfolios, their risk thresholds and alerts carry a version that each change raises, so that an
+-- update made against a version that has since changed is refused instead of overwriting it
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- The database raises the version whichever code path writes the row. A writer setting the version
+-- itself gets the next one too, so a stale copy of the row saved back cannot lower it.
+CREATE OR REPLACE FUNCTION raise_record_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A portfolio's value follows every price tick; only its settings, owner, team and cash count as
+-- changes
+DROP TRIGGER IF EXISTS portfolios_version ON portfolios;
+CREATE TRIGGER portfolios_version
+    BEFORE UPDATE ON portfolios
+    FOR EACH ROW WHEN (
+        (OLD.user_id, OLD.team_id, OLD.name, OLD.description, OLD.currency, OLD.benchmark,
+            OLD.custodian_account, OLD.cash_balance, OLD.margin_loan, OLD.maintenance_margin_rate,
+            OLD.cost_basis_method, OLD.deleted_at, OLD.version)
+        IS DISTINCT FROM
+        (NEW.user_id, NEW.team_id, NEW.name, NEW.description, NEW.currency, NEW.benchmark,
+            NEW.custodian_account, NEW.cash_balance, NEW.margin_loan, NEW.maintenance_margin_rate,
+            NEW.cost_basis_method, NEW.deleted_at, NEW.version))
+    EXECUTE FUNCTION raise_record_version();
+
+DROP TRIGGER IF EXISTS risk_thresholds_version ON risk_thresholds;
+CREATE TRIGGER risk_thresholds_version
+    BEFORE UPDATE ON risk_thresholds
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION raise_record_version();
+
+DROP TRIGGER IF EXISTS alerts_version ON alerts;
+CREATE TRIGGER alerts_version
+    BEFORE UPDATE ON alerts
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION raise_record_version();
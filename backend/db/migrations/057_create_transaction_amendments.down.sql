@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS transaction_amendments_activity ON transaction_amendments;
+DROP FUNCTION IF EXISTS record_amendment_activity();
+
+DROP TABLE IF EXISTS transaction_amendments;
+
+DROP INDEX IF EXISTS idx_transactions_amends_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS superseded_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS amends_id;
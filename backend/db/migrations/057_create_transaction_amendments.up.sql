@@ -0,0 +1,58 @@
+-- Corrections and cancellations of completed transactions. The original keeps what it traded; an
+-- approved amendment marks it AMENDED and books a replacement, or marks it CANCELLED.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS amends_id UUID REFERENCES transactions(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_amends_id ON transactions(amends_id);
+
+CREATE TABLE IF NOT EXISTS transaction_amendments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('AMEND', 'CANCEL')),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    reason TEXT NOT NULL,
+    old_values JSONB NOT NULL DEFAULT '{}',
+    new_values JSONB NOT NULL DEFAULT '{}',
+    replacement_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    requested_by UUID NOT NULL REFERENCES users(id),
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_comment TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_amendments_transaction_id ON transaction_amendments(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_amendments_portfolio_status ON transaction_amendments(portfolio_id, status);
+-- One amendment of a transaction awaits review at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_amendments_pending
+    ON transaction_amendments(transaction_id) WHERE status = 'PENDING';
+
+CREATE OR REPLACE FUNCTION record_amendment_activity() RETURNS TRIGGER AS $$
+DECLARE
+    label TEXT := CASE NEW.action WHEN 'CANCEL' THEN 'Cancellation' ELSE 'Amendment' END;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details, occurred_at)
+        VALUES (NEW.portfolio_id, 'AMENDMENT', 'transaction_amendment', NEW.id,
+            format('%s requested: %s', label, NEW.reason),
+            jsonb_build_object('transaction_id', NEW.transaction_id, 'action', NEW.action, 'status', NEW.status,
+                'old_values', NEW.old_values, 'new_values', NEW.new_values),
+            COALESCE(NEW.created_at, CURRENT_TIMESTAMP));
+    ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'AMENDMENT', 'transaction_amendment', NEW.id,
+            format('%s %s', label, lower(NEW.status)),
+            jsonb_build_object('transaction_id', NEW.transaction_id, 'action', NEW.action, 'status', NEW.status,
+                'replacement_id', NEW.replacement_id, 'reviewed_by', NEW.reviewed_by,
+                'review_comment', NULLIF(NEW.review_comment, '')));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transaction_amendments_activity ON transaction_amendments;
+CREATE TRIGGER transaction_amendments_activity
+    AFTER INSERT OR UPDATE OF status ON transaction_amendments
+    FOR EACH ROW EXECUTE FUNCTION record_amendment_activity();
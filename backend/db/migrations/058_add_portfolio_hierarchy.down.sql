@@ -0,0 +1,41 @@
+CREATE OR REPLACE FUNCTION record_threshold_activity() RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg(new_limit.key, jsonb_build_object('from', old_limit.value, 'to', new_limit.value)), '{}')
+    INTO changes
+    FROM jsonb_each(to_jsonb(NEW)) AS new_limit
+    LEFT JOIN jsonb_each(CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END) AS old_limit
+        ON old_limit.key = new_limit.key
+    WHERE new_limit.key NOT IN ('id', 'portfolio_id', 'created_at', 'updated_at')
+        AND old_limit.value IS DISTINCT FROM new_limit.value;
+
+    IF changes <> '{}' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'THRESHOLD_CHANGE', 'risk_thresholds', NEW.id,
+            CASE WHEN TG_OP = 'INSERT' THEN 'Risk thresholds set'
+                ELSE format('Risk thresholds changed: %s', (SELECT string_agg(key, ', ' ORDER BY key) FROM jsonb_object_keys(changes) AS key))
+            END,
+            changes);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS portfolios_version ON portfolios;
+CREATE TRIGGER portfolios_version
+    BEFORE UPDATE ON portfolios
+    FOR EACH ROW WHEN (
+        (OLD.user_id, OLD.team_id, OLD.name, OLD.description, OLD.currency, OLD.benchmark,
+            OLD.custodian_account, OLD.cash_balance, OLD.margin_loan, OLD.maintenance_margin_rate,
+            OLD.cost_basis_method, OLD.deleted_at, OLD.version)
+        IS DISTINCT FROM
+        (NEW.user_id, NEW.team_id, NEW.name, NEW.description, NEW.currency, NEW.benchmark,
+            NEW.custodian_account, NEW.cash_balance, NEW.margin_loan, NEW.maintenance_margin_rate,
+            NEW.cost_basis_method, NEW.deleted_at, NEW.version))
+    EXECUTE FUNCTION raise_record_version();
+
+ALTER TABLE risk_thresholds DROP COLUMN IF EXISTS overrides;
+
+DROP INDEX IF EXISTS idx_portfolios_parent_id;
+ALTER TABLE portfolios DROP COLUMN IF EXISTS parent_id;
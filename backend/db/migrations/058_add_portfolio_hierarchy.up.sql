@@ -0,0 +1,68 @@
+-- Portfolios roll up into parent portfolios, such as strategies into books, and inherit the risk
+-- thresholds of their parent
+ALTER TABLE portfolios ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES portfolios(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_portfolios_parent_id ON portfolios(parent_id);
+
+-- Moving a portfolio in the hierarchy is a change to its settings
+DROP TRIGGER IF EXISTS portfolios_version ON portfolios;
+CREATE TRIGGER portfolios_version
+    BEFORE UPDATE ON portfolios
+    FOR EACH ROW WHEN (
+        (OLD.user_id, OLD.team_id, OLD.parent_id, OLD.name, OLD.description, OLD.currency, OLD.benchmark,
+            OLD.custodian_account, OLD.cash_balance, OLD.margin_loan, OLD.maintenance_margin_rate,
+            OLD.cost_basis_method, OLD.deleted_at, OLD.version)
+        IS DISTINCT FROM
+        (NEW.user_id, NEW.team_id, NEW.parent_id, NEW.name, NEW.description, NEW.currency, NEW.benchmark,
+            NEW.custodian_account, NEW.cash_balance, NEW.margin_loan, NEW.maintenance_margin_rate,
+            NEW.cost_basis_method, NEW.deleted_at, NEW.version))
+    EXECUTE FUNCTION raise_record_version();
+
+-- The limits set on the portfolio itself; the columns hold the effective limits, with the rest
+-- inherited. Existing thresholds were all set on their portfolio, so they keep every limit.
+ALTER TABLE risk_thresholds ADD COLUMN IF NOT EXISTS overrides JSONB NOT NULL DEFAULT '{}';
+
+UPDATE risk_thresholds SET overrides = jsonb_strip_nulls(jsonb_build_object(
+    'max_var_95', max_va_r95,
+    'max_var_99', max_va_r99,
+    'max_position_size', max_position_size,
+    'max_single_asset_exposure', max_single_asset_exposure,
+    'max_sector_exposure', max_sector_exposure,
+    'min_liquidity_ratio', min_liquidity_ratio,
+    'max_leverage', max_leverage,
+    'max_concentration', max_concentration,
+    'max_fx_exposure', max_fx_exposure,
+    'max_dv01', max_dv01,
+    'max_venue_exposure', max_venue_exposure,
+    'max_stablecoin_depeg', max_stablecoin_depeg,
+    'max_daily_loss', max_daily_loss,
+    'max_weekly_loss', max_weekly_loss,
+    'max_drawdown', max_drawdown,
+    'require_stop_loss', require_stop_loss,
+    'max_stop_loss_distance', max_stop_loss_distance
+));
+
+-- The activity of a threshold change lists the limits that changed, not the bookkeeping columns
+CREATE OR REPLACE FUNCTION record_threshold_activity() RETURNS TRIGGER AS $$
+DECLARE
+    changes JSONB;
+BEGIN
+    SELECT COALESCE(jsonb_object_agg(new_limit.key, jsonb_build_object('from', old_limit.value, 'to', new_limit.value)), '{}')
+    INTO changes
+    FROM jsonb_each(to_jsonb(NEW)) AS new_limit
+    LEFT JOIN jsonb_each(CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) ELSE '{}'::jsonb END) AS old_limit
+        ON old_limit.key = new_limit.key
+    WHERE new_limit.key NOT IN ('id', 'portfolio_id', 'created_at', 'updated_at', 'version', 'overrides')
+        AND old_limit.value IS DISTINCT FROM new_limit.value;
+
+    IF changes <> '{}' THEN
+        INSERT INTO portfolio_activities (portfolio_id, activity_type, entity_type, entity_id, summary, details)
+        VALUES (NEW.portfolio_id, 'THRESHOLD_CHANGE', 'risk_thresholds', NEW.id,
+            CASE WHEN TG_OP = 'INSERT' THEN 'Risk thresholds set'
+                ELSE format('Risk thresholds changed: %s', (SELECT string_agg(key, ', ' ORDER BY key) FROM jsonb_object_keys(changes) AS key))
+            END,
+            changes);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS exposure_limits;
@@ -0,0 +1,17 @@
+-- Firm-wide limits on the exposure to one symbol summed across portfolios: those of the tenant, or
+-- every portfolio for a limit the platform operator sets without a tenant
+CREATE TABLE IF NOT EXISTS exposure_limits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    max_exposure DECIMAL(20, 2) NOT NULL CHECK (max_exposure > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    notes TEXT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One limit per symbol for each tenant and one for the platform operator
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exposure_limits_tenant_symbol ON exposure_limits(tenant_id, symbol) WHERE tenant_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exposure_limits_platform_symbol ON exposure_limits(symbol) WHERE tenant_id IS NULL;
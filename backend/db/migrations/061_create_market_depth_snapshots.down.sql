@@ -0,0 +1,3 @@
+DELETE FROM retention_policies WHERE entity_type = 'market_depth_snapshots';
+
+DROP TABLE IF EXISTS market_depth_snapshots;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS market_depth_snapshots (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    symbol VARCHAR(20) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    bids JSONB NOT NULL,
+    asks JSONB NOT NULL,
+    best_bid DECIMAL(20,8) NOT NULL DEFAULT 0,
+    best_ask DECIMAL(20,8) NOT NULL DEFAULT 0,
+    mid_price DECIMAL(20,8) NOT NULL DEFAULT 0,
+    spread DECIMAL(10,6) NOT NULL DEFAULT 0,
+    bid_depth DECIMAL(24,4) NOT NULL DEFAULT 0,
+    ask_depth DECIMAL(24,4) NOT NULL DEFAULT 0,
+    captured_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_depth_snapshots_symbol ON market_depth_snapshots(symbol, captured_at DESC);
+CREATE INDEX IF NOT EXISTS idx_market_depth_snapshots_captured_at ON market_depth_snapshots(captured_at);
+
+INSERT INTO retention_policies (entity_type, retention_days) VALUES
+    ('market_depth_snapshots', 90)
+ON CONFLICT (entity_type) DO NOTHING;
@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL migrations so the binary carries the schema it expects
+package migrations
+
+import "embed"
+
+// Files holds the NNN_name.up.sql and NNN_name.down.sql migration pairs
+//
+//go:embed *.sql
+var Files embed.FS
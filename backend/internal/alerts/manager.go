@@ -3,15 +3,20 @@ package alerts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
 )
 
 type AlertManager struct {
@@ -32,6 +37,9 @@ func (am *AlertManager) CreateAlert(alert *models.Alert) error {
 	if err := am.db.Create(alert).Error; err != nil {
 		return fmt.Errorf("failed to create alert: %w", err)
 	}
+	metrics.AlertsCreated.With(alert.AlertType, alert.Severity).Inc()
+
+	notifications.Dispatch(alert)
 
 	// Cache in Redis for real-time access
 	ctx := context.Background()
@@ -48,7 +56,7 @@ func (am *AlertManager) CreateAlert(alert *models.Alert) error {
 	am.redisClient.SAdd(ctx, "active_alerts", alert.ID.String())
 
 	// Publish to WebSocket channel
-	am.redisClient.Publish(ctx, "alerts_channel", alertJSON)
+	database.PublishEvent(ctx, database.AlertsChannel, alertJSON)
 
 	return nil
 }
@@ -64,44 +72,66 @@ func (am *AlertManager) GetActiveAlerts(portfolioID uuid.UUID) ([]models.Alert,
 	return alerts, err
 }
 
-// AcknowledgeAlert marks an alert as acknowledged
-func (am *AlertManager) AcknowledgeAlert(alertID, userID uuid.UUID) error {
+// AcknowledgeAlert marks an alert as acknowledged, if it is still at the version the change was
+// made against, and returns it
+func (am *AlertManager) AcknowledgeAlert(alertID, userID uuid.UUID, version int64) (*models.Alert, error) {
 	now := time.Now()
 
-	err := am.db.Model(&models.Alert{}).
-		Where("id = ?", alertID).
-		Updates(map[string]interface{}{
-			"status":          "ACKNOWLEDGED",
-			"acknowledged_by": userID,
-			"acknowledged_at": now,
-		}).Error
-
+	alert, err := am.update(alertID, version, map[string]interface{}{
+		"status":          "ACKNOWLEDGED",
+		"acknowledged_by": userID,
+		"acknowledged_at": now,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update Redis cache
 	ctx := context.Background()
 	am.redisClient.SRem(ctx, "active_alerts", alertID.String())
 
-	return nil
+	return alert, nil
 }
 
-// ResolveAlert marks an alert as resolved
-func (am *AlertManager) ResolveAlert(alertID, userID uuid.UUID, resolution string) error {
+// ResolveAlert marks an alert as resolved, if it is still at the version the change was made
+// against, and returns it
+func (am *AlertManager) ResolveAlert(alertID, userID uuid.UUID, resolution string, version int64) (*models.Alert, error) {
 	now := time.Now()
 
-	err := am.db.Model(&models.Alert{}).
-		Where("id = ?", alertID).
-		Updates(map[string]interface{}{
-			"status":      "RESOLVED",
-			"resolution":  resolution,
-			"resolved_by": userID,
-			"resolved_at": now,
-		}).Error
+	alert, err := am.update(alertID, version, map[string]interface{}{
+		"status":      "RESOLVED",
+		"resolution":  resolution,
+		"resolved_by": userID,
+		"resolved_at": now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove from Redis
+	ctx := context.Background()
+	key := fmt.Sprintf("alert:%s", alertID)
+	am.redisClient.Del(ctx, key)
+	am.redisClient.SRem(ctx, "active_alerts", alertID.String())
+
+	notifications.DispatchEvent(notifications.EventAlertResolved, alert)
+
+	return alert, nil
+}
+
+// DismissAlert closes an alert as a false positive or not actionable, if it is still at the
+// version the change was made against, and returns it
+func (am *AlertManager) DismissAlert(alertID, userID uuid.UUID, reason string, version int64) (*models.Alert, error) {
+	now := time.Now()
 
+	alert, err := am.update(alertID, version, map[string]interface{}{
+		"status":      "DISMISSED",
+		"resolution":  reason,
+		"resolved_by": userID,
+		"resolved_at": now,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Remove from Redis
@@ -110,7 +140,61 @@ func (am *AlertManager) ResolveAlert(alertID, userID uuid.UUID, resolution strin
 	am.redisClient.Del(ctx, key)
 	am.redisClient.SRem(ctx, "active_alerts", alertID.String())
 
-	return nil
+	return alert, nil
+}
+
+// update applies changes to an alert at a version, or at any with models.AnyVersion, and returns
+// the alert as changed. An alert at another version is reported as a conflict with its current
+// state.
+func (am *AlertManager) update(alertID uuid.UUID, version int64, changes map[string]interface{}) (*models.Alert, error) {
+	var alert models.Alert
+	query := am.db.Model(&alert).Clauses(clause.Returning{}).Where("id = ?", alertID)
+	if version != models.AnyVersion {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Updates(changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &alert, nil
+	}
+
+	if err := am.db.First(&alert, alertID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Alert not found")
+		}
+		return nil, err
+	}
+	return nil, apperror.VersionConflict(
+		fmt.Sprintf("Alert was changed since version %d; reapply the change to version %d", version, alert.Version),
+		&alert)
+}
+
+// AcknowledgePortfolioAlerts acknowledges every active alert of a portfolio and returns them
+func (am *AlertManager) AcknowledgePortfolioAlerts(portfolioID, userID uuid.UUID) ([]models.Alert, error) {
+	var acknowledged []models.Alert
+	err := am.db.Model(&acknowledged).
+		Clauses(clause.Returning{}).
+		Where("portfolio_id = ? AND status = ?", portfolioID, "ACTIVE").
+		Updates(map[string]interface{}{
+			"status":          "ACKNOWLEDGED",
+			"acknowledged_by": userID,
+			"acknowledged_at": time.Now(),
+		}).Error
+
+	if err != nil || len(acknowledged) == 0 {
+		return nil, err
+	}
+
+	// Update Redis cache
+	members := make([]interface{}, len(acknowledged))
+	for i, alert := range acknowledged {
+		members[i] = alert.ID.String()
+	}
+	am.redisClient.SRem(context.Background(), "active_alerts", members...)
+
+	return acknowledged, nil
 }
 
 // CleanupOldAlerts removes alerts older than specified days
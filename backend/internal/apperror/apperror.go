@@ -0,0 +1,160 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// Code is a machine-readable error code clients can branch on instead of parsing messages
+type Code string
+
+const (
+	CodeBadRequest           Code = "BAD_REQUEST"
+	CodeValidation           Code = "VALIDATION_FAILED"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodeVersionConflict      Code = "VERSION_CONFLICT"
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeUnavailable          Code = "SERVICE_UNAVAILABLE"
+	CodeInternal             Code = "INTERNAL_ERROR"
+)
+
+// Error is an error together with the HTTP status and code it is reported with. Its message is
+// returned to clients; the underlying cause is only logged.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates an error reported with the given status and code
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest reports a request that cannot be processed as sent
+func BadRequest(message string) *Error {
+	return New(fiber.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation reports request fields that failed validation, listed in details
+func Validation(message string, details interface{}) *Error {
+	err := New(fiber.StatusUnprocessableEntity, CodeValidation, message)
+	err.Details = details
+	return err
+}
+
+// Unauthorized reports a request without valid credentials
+func Unauthorized(message string) *Error {
+	return New(fiber.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden reports a request the caller is not allowed to make
+func Forbidden(message string) *Error {
+	return New(fiber.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound reports a record that does not exist or that the caller cannot see
+func NotFound(message string) *Error {
+	return New(fiber.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict reports a request that conflicts with the current state of a record
+func Conflict(message string) *Error {
+	return New(fiber.StatusConflict, CodeConflict, message)
+}
+
+// VersionConflict reports an update made against a version of a record that has since changed.
+// The record's current state is returned in details so the client can reapply its change.
+func VersionConflict(message string, current interface{}) *Error {
+	err := New(fiber.StatusConflict, CodeVersionConflict, message)
+	err.Details = current
+	return err
+}
+
+// PreconditionRequired reports an update that does not name the version of the record it was
+// made against
+func PreconditionRequired(message string) *Error {
+	return New(fiber.StatusPreconditionRequired, CodePreconditionRequired, message)
+}
+
+// Internal reports an unexpected failure; err is logged but not returned to the client
+func Internal(message string, err error) *Error {
+	e := New(fiber.StatusInternalServerError, CodeInternal, message)
+	e.Err = err
+	return e
+}
+
+// CodeForStatus returns the code of errors reported with an HTTP status
+func CodeForStatus(status int) Code {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidation
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusPreconditionRequired:
+		return CodePreconditionRequired
+	case fiber.StatusTooManyRequests:
+		return CodeRateLimited
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	// Other statuses, such as 405, are coded after their status text, e.g. METHOD_NOT_ALLOWED
+	return Code(strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_")))
+}
+
+// From maps any error onto an Error: typed errors keep their status, Fiber errors such as an
+// unknown route keep theirs, validation errors are a 422, a missing record is a 404 and anything
+// else is an internal error
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &Error{Status: fiberErr.Code, Code: CodeForStatus(fiberErr.Code), Message: fiberErr.Message}
+	}
+
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		return Validation("Validation failed", fields)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("Record not found")
+	}
+
+	return Internal("Internal server error", err)
+}
@@ -0,0 +1,243 @@
+// Package batch runs pipelines of jobs that depend on each other, such as the end-of-day sequence
+// of snapshots, risk calculations, compliance sweeps and reports. A job starts once every job it
+// depends on has succeeded, independent jobs run at the same time, and a failed job is retried
+// before the jobs that depend on it are skipped.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Job is a step of a pipeline. Run returns a short summary of what it did; it is called again on
+// a retry, so it must be safe to repeat.
+type Job struct {
+	Name      string
+	DependsOn []string
+	Run       func(ctx context.Context) (string, error)
+}
+
+// Pipeline is a validated set of jobs with no missing dependencies or cycles
+type Pipeline struct {
+	name string
+	jobs []Job // Ordered so every job comes after the jobs it depends on
+}
+
+// NewPipeline checks the jobs' names and dependencies and orders them so each comes after its
+// dependencies, keeping the given order otherwise
+func NewPipeline(name string, jobs ...Job) (*Pipeline, error) {
+	byName := make(map[string]Job, len(jobs))
+	for _, job := range jobs {
+		if job.Name == "" || job.Run == nil {
+			return nil, errors.New("every job needs a name and a run function")
+		}
+		if _, ok := byName[job.Name]; ok {
+			return nil, fmt.Errorf("job %s is listed twice", job.Name)
+		}
+		byName[job.Name] = job
+	}
+	for _, job := range jobs {
+		for _, dependency := range job.DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("job %s depends on unknown job %s", job.Name, dependency)
+			}
+		}
+	}
+
+	ordered := make([]Job, 0, len(jobs))
+	placed := make(map[string]bool, len(jobs))
+	for len(ordered) < len(jobs) {
+		progressed := false
+		for _, job := range jobs {
+			if placed[job.Name] || !allOf(job.DependsOn, placed) {
+				continue
+			}
+			ordered = append(ordered, job)
+			placed[job.Name] = true
+			progressed = true
+		}
+		if !progressed {
+			var cycle []string
+			for _, job := range jobs {
+				if !placed[job.Name] {
+					cycle = append(cycle, job.Name)
+				}
+			}
+			return nil, fmt.Errorf("jobs %v depend on each other", cycle)
+		}
+	}
+	return &Pipeline{name: name, jobs: ordered}, nil
+}
+
+func allOf(names []string, set map[string]bool) bool {
+	for _, name := range names {
+		if !set[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// Jobs returns the jobs in the order they may run in
+func (p *Pipeline) Jobs() []Job {
+	return slices.Clone(p.jobs)
+}
+
+// Options bound how long jobs run and how often they are retried
+type Options struct {
+	Retries    int           // Attempts after the first before a job fails
+	RetryDelay time.Duration // Wait before the first retry, doubling for each one after
+	JobTimeout time.Duration // Deadline of each attempt; none when zero
+}
+
+// Result is the state of a job in a run
+type Result struct {
+	Name       string
+	Status     string // PENDING, RUNNING, SUCCEEDED, FAILED, SKIPPED
+	Attempts   int
+	Output     string
+	Error      string
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// Run executes the pipeline and returns the result of each job in pipeline order. observe is
+// called from the calling goroutine each time a job starts, finishes or is skipped. Cancelling ctx
+// stops jobs from starting and retrying; jobs already running finish their attempt, which is only
+// bounded by the job timeout.
+func (p *Pipeline) Run(ctx context.Context, opts Options, observe func(Result)) []Result {
+	results := make(map[string]*Result, len(p.jobs))
+	for _, job := range p.jobs {
+		results[job.Name] = &Result{Name: job.Name, Status: models.BatchStatusPending}
+	}
+
+	done := make(chan Result)
+	running := 0
+	for {
+		// Jobs are in dependency order, so a skip reaches the jobs after it in the same pass
+		for _, job := range p.jobs {
+			result := results[job.Name]
+			if result.Status != models.BatchStatusPending {
+				continue
+			}
+
+			if reason := p.blocked(ctx, job, results); reason != "" {
+				now := time.Now()
+				result.Status, result.Error, result.FinishedAt = models.BatchStatusSkipped, reason, &now
+				observe(*result)
+				continue
+			}
+			if !p.ready(job, results) {
+				continue
+			}
+
+			now := time.Now()
+			result.Status, result.StartedAt = models.BatchStatusRunning, &now
+			observe(*result)
+			running++
+			go func(job Job, result Result) {
+				done <- runJob(ctx, job, result, opts)
+			}(job, *result)
+		}
+
+		if running == 0 {
+			break
+		}
+		result := <-done
+		running--
+		*results[result.Name] = result
+		observe(result)
+	}
+
+	ordered := make([]Result, len(p.jobs))
+	for i, job := range p.jobs {
+		ordered[i] = *results[job.Name]
+	}
+	return ordered
+}
+
+// blocked returns why a pending job can no longer run, or "" if it still may
+func (p *Pipeline) blocked(ctx context.Context, job Job, results map[string]*Result) string {
+	if ctx.Err() != nil {
+		return "run stopped before the job started"
+	}
+	for _, dependency := range job.DependsOn {
+		switch results[dependency].Status {
+		case models.BatchStatusFailed, models.BatchStatusSkipped:
+			return fmt.Sprintf("dependency %s did not succeed", dependency)
+		}
+	}
+	return ""
+}
+
+func (p *Pipeline) ready(job Job, results map[string]*Result) bool {
+	for _, dependency := range job.DependsOn {
+		if results[dependency].Status != models.BatchStatusSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// runJob runs a job until it succeeds or runs out of retries
+func runJob(ctx context.Context, job Job, result Result, opts Options) Result {
+	delay := opts.RetryDelay
+	for {
+		result.Attempts++
+		output, err := attempt(ctx, job, opts.JobTimeout)
+		if err == nil {
+			result.Status, result.Output, result.Error = models.BatchStatusSucceeded, output, ""
+			break
+		}
+
+		result.Error = err.Error()
+		if result.Attempts > opts.Retries || !wait(ctx, delay) {
+			result.Status = models.BatchStatusFailed
+			break
+		}
+		delay *= 2
+	}
+
+	now := time.Now()
+	result.FinishedAt = &now
+	return result
+}
+
+// attempt runs a job once within the timeout, turning a panic into an error
+func attempt(ctx context.Context, job Job, timeout time.Duration) (output string, err error) {
+	ctx = context.WithoutCancel(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// wait sleeps for d and reports false if ctx was cancelled first
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
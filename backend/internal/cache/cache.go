@@ -0,0 +1,133 @@
+// Package cache is a Redis read-through cache for hot dashboard reads: risk metrics, portfolio
+// summaries and alert counts. Every entry belongs to a namespace whose generation is part of its
+// key, so writing to a table behind a namespace invalidates all of its entries at once by bumping
+// the generation. The TTL bounds how long an entry can outlive a write it raced with.
+//
+// The latest calculated VaR and liquidity of each portfolio are cached the same way, with a
+// generation per portfolio as well, so that a trade or a large price move invalidates only the
+// portfolios it touches.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+// Namespaces of cached reads
+const (
+	RiskMetrics = "risk_metrics"
+	Portfolios  = "portfolios"
+	Alerts      = "alerts"
+)
+
+var (
+	enabled bool
+	ttl     time.Duration
+	riskTTL time.Duration
+
+	riskPriceMove float64
+)
+
+// Init turns the cache on with the configured TTLs; until it is called every read goes to Postgres
+func Init(cfg *config.CacheConfig) {
+	enabled = cfg.Enabled && cfg.TTL > 0
+	ttl = cfg.TTL
+	riskTTL = cfg.RiskTTL
+	riskPriceMove = cfg.RiskPriceMove
+}
+
+func generationKey(namespace string) string {
+	return "cache:generation:" + namespace
+}
+
+// Get returns the cached value of key in a namespace, calling load and caching its result on a
+// miss. When the cache is disabled or Redis is unavailable it calls load.
+func Get[T any](ctx context.Context, namespace, key string, load func() (T, error)) (T, error) {
+	value, _, err := fetch(ctx, namespace, []string{generationKey(namespace)}, key, ttl, false, load)
+	return value, err
+}
+
+// fetch returns the cached value of key under the combined generations, and whether it came from
+// the cache. With refresh set it skips the cached value and replaces it with what load returns.
+func fetch[T any](ctx context.Context, namespace string, generationKeys []string, key string, entryTTL time.Duration,
+	refresh bool, load func() (T, error)) (T, bool, error) {
+	client := database.GetRedis()
+	if !enabled || entryTTL <= 0 || client == nil {
+		value, err := load()
+		return value, false, err
+	}
+
+	generations, err := client.MGet(ctx, generationKeys...).Result()
+	if err != nil {
+		logFailure(ctx, "Failed to read cache generation", namespace, err)
+		value, err := load()
+		return value, false, err
+	}
+	parts := make([]string, len(generations))
+	for i, generation := range generations {
+		// A namespace never invalidated has no generation yet
+		parts[i] = "0"
+		if generation != nil {
+			parts[i] = fmt.Sprint(generation)
+		}
+	}
+	entryKey := fmt.Sprintf("cache:%s:%s:%s", namespace, strings.Join(parts, "."), key)
+
+	if !refresh {
+		payload, err := client.Get(ctx, entryKey).Bytes()
+		if err == nil {
+			var value T
+			if err := json.Unmarshal(payload, &value); err == nil {
+				metrics.CacheRequests.With(namespace, "hit").Inc()
+				return value, true, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			logFailure(ctx, "Failed to read cache entry", namespace, err)
+		}
+		metrics.CacheRequests.With(namespace, "miss").Inc()
+	} else {
+		metrics.CacheRequests.With(namespace, "refresh").Inc()
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, false, err
+	}
+	if payload, err := json.Marshal(value); err == nil {
+		if err := client.Set(ctx, entryKey, payload, entryTTL).Err(); err != nil {
+			logFailure(ctx, "Failed to write cache entry", namespace, err)
+		}
+	}
+	return value, false, nil
+}
+
+// Invalidate drops every cached entry of the namespaces
+func Invalidate(ctx context.Context, namespaces ...string) {
+	client := database.GetRedis()
+	if !enabled || client == nil {
+		return
+	}
+	for _, namespace := range namespaces {
+		if err := client.Incr(ctx, generationKey(namespace)).Err(); err != nil {
+			logFailure(ctx, "Failed to invalidate cache", namespace, err)
+		}
+	}
+}
+
+func logFailure(ctx context.Context, msg, namespace string, err error) {
+	if errors.Is(err, database.ErrRedisUnavailable) {
+		return
+	}
+	logging.Component("cache").WarnContext(ctx, msg, "namespace", namespace, "error", err)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tableNamespaces maps each table behind a cached read to the namespaces a write to it invalidates.
+// Portfolio ownership and supervision decide which alerts a user counts.
+var tableNamespaces = map[string][]string{
+	"risk_metrics":          {RiskMetrics, Portfolios},
+	"alerts":                {Alerts, Portfolios},
+	"portfolios":            {Portfolios, RiskMetrics, Alerts},
+	"positions":             {Portfolios},
+	"portfolio_supervisors": {Alerts},
+	"users":                 {RiskMetrics},
+	"tenants":               {PortfolioRisk},
+}
+
+// portfolioRiskTables maps each table whose writes change a portfolio's calculated risk to the
+// field holding the portfolio's ID. Positions and portfolio values are left out since every price
+// update rewrites them; price moves invalidate the portfolios holding the symbol instead.
+var portfolioRiskTables = map[string]string{
+	"transactions":    "PortfolioID",
+	"risk_thresholds": "PortfolioID",
+}
+
+// GormPlugin invalidates cached reads after every create, update or delete of a table behind them
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "cache"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("*").Register("cache:invalidate_create", invalidateWritten); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("*").Register("cache:invalidate_update", invalidateWritten); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("*").Register("cache:invalidate_delete", invalidateWritten)
+}
+
+func invalidateWritten(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	if namespaces, ok := tableNamespaces[db.Statement.Table]; ok {
+		Invalidate(db.Statement.Context, namespaces...)
+	}
+	if field, ok := portfolioRiskTables[db.Statement.Table]; ok {
+		// A write by condition names no portfolio, so every portfolio's risk is dropped
+		if portfolioIDs := writtenPortfolios(db, field); len(portfolioIDs) > 0 {
+			InvalidatePortfolioRisk(db.Statement.Context, portfolioIDs...)
+		} else {
+			Invalidate(db.Statement.Context, PortfolioRisk)
+		}
+	}
+}
+
+// writtenPortfolios returns the portfolio IDs held by field in the records a statement wrote
+func writtenPortfolios(db *gorm.DB, field string) []uuid.UUID {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	portfolioField := db.Statement.Schema.LookUpField(field)
+	if portfolioField == nil {
+		return nil
+	}
+
+	var portfolioIDs []uuid.UUID
+	add := func(record reflect.Value) {
+		record = reflect.Indirect(record)
+		if record.Kind() != reflect.Struct {
+			return
+		}
+		value, zero := portfolioField.ValueOf(db.Statement.Context, record)
+		if id, ok := value.(uuid.UUID); ok && !zero && id != uuid.Nil {
+			portfolioIDs = append(portfolioIDs, id)
+		}
+	}
+	switch records := reflect.Indirect(db.Statement.ReflectValue); records.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < records.Len(); i++ {
+			add(records.Index(i))
+		}
+	default:
+		add(records)
+	}
+	return portfolioIDs
+}
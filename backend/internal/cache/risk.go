@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+)
+
+// PortfolioRisk holds the latest calculated VaR and liquidity of each portfolio
+const PortfolioRisk = "portfolio_risk"
+
+func portfolioRiskGeneration(portfolioID uuid.UUID) string {
+	return generationKey(PortfolioRisk + ":" + portfolioID.String())
+}
+
+// GetPortfolioRisk returns the cached risk calculation of a portfolio under key, calling load and
+// caching its result on a miss or when refresh is set, and reports whether it came from the cache.
+// Entries live for CACHE_RISK_TTL unless the portfolio's risk is invalidated first.
+func GetPortfolioRisk[T any](ctx context.Context, portfolioID uuid.UUID, key string, refresh bool, load func() (T, error)) (T, bool, error) {
+	generations := []string{generationKey(PortfolioRisk), portfolioRiskGeneration(portfolioID)}
+	return fetch(ctx, PortfolioRisk, generations, portfolioID.String()+":"+key, riskTTL, refresh, load)
+}
+
+// RiskPriceMove is the move, in percent, of a symbol's price that invalidates the cached risk of
+// the portfolios holding it
+func RiskPriceMove() float64 {
+	return riskPriceMove
+}
+
+// InvalidatePortfolioRisk drops the cached risk calculations of the portfolios
+func InvalidatePortfolioRisk(ctx context.Context, portfolioIDs ...uuid.UUID) {
+	client := database.GetRedis()
+	if !enabled || client == nil {
+		return
+	}
+	for _, portfolioID := range portfolioIDs {
+		if err := client.Incr(ctx, portfolioRiskGeneration(portfolioID)).Err(); err != nil {
+			logFailure(ctx, "Failed to invalidate cache", PortfolioRisk, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Anomaly scorers
+const (
+	ScorerNone = "none"
+	ScorerHTTP = "http"
+)
+
+const maxScoreResponseSize = 1 << 16
+
+// AnomalyScorer scores how unusual a transaction is from its features, from 0 to 1
+type AnomalyScorer interface {
+	Name() string
+	Score(ctx context.Context, tx *models.Transaction, f Features) (float64, error)
+}
+
+// NewAnomalyScorer builds the scorer selected by the configuration, or nil when anomaly scoring is
+// off
+func NewAnomalyScorer(cfg *config.MonitoringConfig) (AnomalyScorer, error) {
+	switch cfg.AnomalyScorer {
+	case ScorerNone, "":
+		return nil, nil
+	case ScorerHTTP:
+		if cfg.AnomalyURL == "" {
+			return nil, fmt.Errorf("MONITORING_ANOMALY_URL is required for the %s anomaly scorer", ScorerHTTP)
+		}
+		return NewHTTPScorer(cfg.AnomalyURL, cfg.AnomalyTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown anomaly scorer %q", cfg.AnomalyScorer)
+	}
+}
+
+// HTTPScorer posts {"transaction_id", "portfolio_id", "features"} to a model serving endpoint,
+// which answers {"score": 0.87}
+type HTTPScorer struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPScorer(scoreURL string, timeout time.Duration) *HTTPScorer {
+	return &HTTPScorer{
+		url:    scoreURL,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPScorer) Name() string {
+	return ScorerHTTP
+}
+
+func (s *HTTPScorer) Score(ctx context.Context, tx *models.Transaction, f Features) (float64, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"transaction_id": tx.ID,
+		"portfolio_id":   tx.PortfolioID,
+		"features":       f.Vector(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("anomaly scorer returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScoreResponseSize))
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("invalid anomaly score response: %w", err)
+	}
+	if result.Score == nil || *result.Score < 0 || *result.Score > 1 {
+		return 0, fmt.Errorf("anomaly score response has no score between 0 and 1")
+	}
+	return *result.Score, nil
+}
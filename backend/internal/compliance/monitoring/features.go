@@ -0,0 +1,151 @@
+// Package monitoring is the transaction monitoring pipeline of the AML checks. It extracts
+// features from a transaction and its portfolio's history, runs a configurable set of rules over
+// them and optionally scores them with an external anomaly model.
+package monitoring
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Features describe one transaction against the history of its portfolio. Counts and volumes over
+// a window include the transaction itself.
+type Features struct {
+	Amount           float64 `json:"amount"`
+	AmountZScore     float64 `json:"amount_zscore"` // Against the history's amounts; 0 with fewer than two
+	HistoryCount     int     `json:"history_count"` // Earlier transactions of the portfolio considered
+	HourOfDay        int     `json:"hour_of_day"`   // UTC, of execution when known
+	DayOfWeek        int     `json:"day_of_week"`   // 0 is Sunday
+	OffHours         bool    `json:"off_hours"`     // At a weekend, or before 06:00 or from 22:00 UTC
+	Velocity1h       int     `json:"velocity_1h"`
+	Velocity24h      int     `json:"velocity_24h"`
+	Volume24h        float64 `json:"volume_24h"`
+	NewSymbol        bool    `json:"new_symbol"`         // A symbol the portfolio has no history in, when it has history
+	NearThreshold24h int     `json:"near_threshold_24h"` // Transactions within 10% below the large amount threshold
+	RoundAmount      bool    `json:"round_amount"`       // A whole multiple of 1,000
+}
+
+// Vector flattens the features into named numbers, booleans as 0 or 1, for persistence and for
+// anomaly models
+func (f Features) Vector() map[string]float64 {
+	return map[string]float64{
+		"amount":             f.Amount,
+		"amount_zscore":      f.AmountZScore,
+		"history_count":      float64(f.HistoryCount),
+		"hour_of_day":        float64(f.HourOfDay),
+		"day_of_week":        float64(f.DayOfWeek),
+		"off_hours":          boolFeature(f.OffHours),
+		"velocity_1h":        float64(f.Velocity1h),
+		"velocity_24h":       float64(f.Velocity24h),
+		"volume_24h":         f.Volume24h,
+		"new_symbol":         boolFeature(f.NewSymbol),
+		"near_threshold_24h": float64(f.NearThreshold24h),
+		"round_amount":       boolFeature(f.RoundAmount),
+	}
+}
+
+func boolFeature(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ExtractFeatures computes the features of a transaction from the portfolio's history, as of when
+// the transaction was recorded. History entries recorded after the transaction, and the
+// transaction itself, are ignored, so a transaction can be re-evaluated later as it was first seen.
+func ExtractFeatures(tx *models.Transaction, history []models.Transaction, largeAmount decimal.Decimal) Features {
+	at := tx.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	executedAt := at
+	if tx.ExecutedAt != nil {
+		executedAt = *tx.ExecutedAt
+	}
+	executedAt = executedAt.UTC()
+
+	nearThreshold := largeAmount.Mul(decimal.NewFromFloat(0.9))
+	isNearThreshold := func(amount decimal.Decimal) bool {
+		return amount.GreaterThan(nearThreshold) && amount.LessThan(largeAmount)
+	}
+
+	f := Features{
+		Amount:      tx.Amount.InexactFloat64(),
+		HourOfDay:   executedAt.Hour(),
+		DayOfWeek:   int(executedAt.Weekday()),
+		Velocity1h:  1,
+		Velocity24h: 1,
+		Volume24h:   tx.Amount.InexactFloat64(),
+		RoundAmount: isRoundAmount(tx.Amount),
+	}
+	f.OffHours = executedAt.Weekday() == time.Saturday || executedAt.Weekday() == time.Sunday ||
+		f.HourOfDay < 6 || f.HourOfDay >= 22
+	if isNearThreshold(tx.Amount) {
+		f.NearThreshold24h = 1
+	}
+
+	var amounts []float64
+	heldSymbol := false
+	for _, prior := range history {
+		if prior.ID == tx.ID || prior.CreatedAt.After(at) {
+			continue
+		}
+		amount := prior.Amount.InexactFloat64()
+		amounts = append(amounts, amount)
+
+		if tx.Symbol != "" && strings.EqualFold(prior.Symbol, tx.Symbol) {
+			heldSymbol = true
+		}
+
+		age := at.Sub(prior.CreatedAt)
+		if age <= time.Hour {
+			f.Velocity1h++
+		}
+		if age <= 24*time.Hour {
+			f.Velocity24h++
+			f.Volume24h += amount
+			if isNearThreshold(prior.Amount) {
+				f.NearThreshold24h++
+			}
+		}
+	}
+
+	f.HistoryCount = len(amounts)
+	f.AmountZScore = zScore(f.Amount, amounts)
+	f.NewSymbol = tx.Symbol != "" && f.HistoryCount > 0 && !heldSymbol
+
+	return f
+}
+
+// zScore is how many standard deviations value lies from the mean of the sample
+func zScore(value float64, sample []float64) float64 {
+	if len(sample) < 2 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range sample {
+		sum += v
+	}
+	mean := sum / float64(len(sample))
+
+	var squares float64
+	for _, v := range sample {
+		squares += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(squares / float64(len(sample)))
+	if stddev == 0 {
+		return 0
+	}
+	return (value - mean) / stddev
+}
+
+func isRoundAmount(amount decimal.Decimal) bool {
+	return amount.IsPositive() && amount.Mod(decimal.NewFromInt(1000)).IsZero()
+}
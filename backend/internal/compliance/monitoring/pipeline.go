@@ -0,0 +1,193 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Pipeline extracts a transaction's features, runs the rule set over them and scores them for
+// anomalies
+type Pipeline struct {
+	rules            []Rule
+	scorer           AnomalyScorer
+	anomalyThreshold float64
+	largeAmount      decimal.Decimal
+	reviewScore      int
+	historyWindow    time.Duration
+	cfg              config.MonitoringConfig // Rebuilt from with a tenant's overrides
+}
+
+var (
+	defaultPipeline *Pipeline
+	pipelineMu      sync.RWMutex
+)
+
+func NewPipeline(cfg *config.MonitoringConfig) (*Pipeline, error) {
+	rules, err := NewRuleSet(cfg)
+	if err != nil {
+		return nil, err
+	}
+	scorer, err := NewAnomalyScorer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HistoryWindow < 24*time.Hour {
+		return nil, fmt.Errorf("monitoring history window must be at least 24h")
+	}
+
+	return &Pipeline{
+		rules:            rules,
+		scorer:           scorer,
+		anomalyThreshold: cfg.AnomalyThreshold,
+		largeAmount:      decimal.NewFromFloat(cfg.LargeAmount),
+		reviewScore:      cfg.ReviewScore,
+		historyWindow:    cfg.HistoryWindow,
+		cfg:              *cfg,
+	}, nil
+}
+
+// WithOverrides returns a pipeline running a tenant's rules and thresholds, sharing this pipeline's
+// anomaly scorer and history window
+func (p *Pipeline) WithOverrides(o *models.AMLOverrides) (*Pipeline, error) {
+	if o.IsZero() {
+		return p, nil
+	}
+
+	cfg := p.cfg
+	if len(o.Rules) > 0 {
+		cfg.Rules = o.Rules
+	}
+	if o.LargeAmount != nil {
+		cfg.LargeAmount = *o.LargeAmount
+	}
+	if o.VelocityLimit != nil {
+		cfg.VelocityLimit = *o.VelocityLimit
+	}
+	if o.StructuringCount != nil {
+		cfg.StructuringCount = *o.StructuringCount
+	}
+	if o.OutlierZScore != nil {
+		cfg.OutlierZScore = *o.OutlierZScore
+	}
+	if o.ReviewScore != nil {
+		cfg.ReviewScore = *o.ReviewScore
+	}
+	if cfg.LargeAmount <= 0 || cfg.VelocityLimit <= 0 || cfg.StructuringCount <= 0 || cfg.OutlierZScore <= 0 || cfg.ReviewScore <= 0 {
+		return nil, fmt.Errorf("monitoring thresholds must be positive")
+	}
+
+	rules, err := NewRuleSet(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	overridden := *p
+	overridden.rules = rules
+	overridden.largeAmount = decimal.NewFromFloat(cfg.LargeAmount)
+	overridden.reviewScore = cfg.ReviewScore
+	overridden.cfg = cfg
+	return &overridden, nil
+}
+
+// Init creates the shared pipeline
+func Init(cfg *config.MonitoringConfig) (*Pipeline, error) {
+	pipeline, err := NewPipeline(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineMu.Lock()
+	defaultPipeline = pipeline
+	pipelineMu.Unlock()
+
+	return pipeline, nil
+}
+
+// GetPipeline returns the shared pipeline, or nil if Init has not been called
+func GetPipeline() *Pipeline {
+	pipelineMu.RLock()
+	defer pipelineMu.RUnlock()
+	return defaultPipeline
+}
+
+// HistoryWindow is how far back the portfolio history a transaction is compared with reaches
+func (p *Pipeline) HistoryWindow() time.Duration {
+	return p.historyWindow
+}
+
+// RuleNames lists the rules the pipeline runs
+func (p *Pipeline) RuleNames() []string {
+	names := make([]string, 0, len(p.rules))
+	for _, rule := range p.rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+// Evaluate runs the pipeline over a transaction and its portfolio's history, returning the
+// evaluation trail for the caller to persist. History older than the window before the
+// transaction is ignored. A failing anomaly scorer is recorded on the trail and does not stop the
+// rules applying.
+func (p *Pipeline) Evaluate(ctx context.Context, tx *models.Transaction, history []models.Transaction) *models.TransactionMonitoringEvaluation {
+	at := tx.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	windowStart := at.Add(-p.historyWindow)
+	inWindow := make([]models.Transaction, 0, len(history))
+	for _, prior := range history {
+		if prior.CreatedAt.After(windowStart) {
+			inWindow = append(inWindow, prior)
+		}
+	}
+
+	features := ExtractFeatures(tx, inWindow, p.largeAmount)
+
+	evaluation := &models.TransactionMonitoringEvaluation{
+		TransactionID:  tx.ID,
+		PortfolioID:    tx.PortfolioID,
+		Features:       features.Vector(),
+		RulesEvaluated: p.RuleNames(),
+		Hits:           []models.MonitoringRuleHit{},
+		EvaluatedAt:    time.Now(),
+	}
+
+	for _, rule := range p.rules {
+		if hit := rule.Evaluate(features); hit != nil {
+			evaluation.Hits = append(evaluation.Hits, *hit)
+		}
+	}
+
+	if p.scorer != nil {
+		evaluation.AnomalyScorer = p.scorer.Name()
+		score, err := p.scorer.Score(ctx, tx, features)
+		if err != nil {
+			evaluation.AnomalyError = err.Error()
+		} else {
+			evaluation.AnomalyScore = &score
+			if score >= p.anomalyThreshold {
+				evaluation.Hits = append(evaluation.Hits, models.MonitoringRuleHit{
+					Rule:   RuleAnomaly,
+					Score:  anomalyRuleScore,
+					Detail: fmt.Sprintf("Anomaly score %.2f, threshold %.2f", score, p.anomalyThreshold),
+				})
+			}
+		}
+	}
+
+	for _, hit := range evaluation.Hits {
+		evaluation.RiskScore += hit.Score
+	}
+	if evaluation.RiskScore > 100 {
+		evaluation.RiskScore = 100
+	}
+	evaluation.RequiresReview = evaluation.RiskScore >= p.reviewScore
+
+	return evaluation
+}
@@ -0,0 +1,103 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Built-in monitoring rules
+const (
+	RuleLargeTransaction    = "LARGE_TRANSACTION"
+	RuleHighVelocity        = "HIGH_VELOCITY"
+	RulePossibleStructuring = "POSSIBLE_STRUCTURING"
+	RuleRoundAmount         = "ROUND_AMOUNT"
+	RuleAmountOutlier       = "AMOUNT_OUTLIER"
+	RuleOffHours            = "OFF_HOURS"
+	RuleNewSymbol           = "NEW_SYMBOL"
+
+	// RuleAnomaly is hit when the anomaly scorer scores a transaction at or above the threshold
+	RuleAnomaly = "ANOMALY"
+)
+
+const anomalyRuleScore = 40
+
+// Rule is one check of the monitoring pipeline. Evaluate returns nil unless the features trigger
+// the rule.
+type Rule interface {
+	Name() string
+	Evaluate(f Features) *models.MonitoringRuleHit
+}
+
+// featureRule is a rule that checks the features with a function returning the detail of a hit
+type featureRule struct {
+	name  string
+	score int
+	check func(f Features) (string, bool)
+}
+
+func (r featureRule) Name() string {
+	return r.name
+}
+
+func (r featureRule) Evaluate(f Features) *models.MonitoringRuleHit {
+	detail, hit := r.check(f)
+	if !hit {
+		return nil
+	}
+	return &models.MonitoringRuleHit{Rule: r.name, Score: r.score, Detail: detail}
+}
+
+// BuiltinRules returns every built-in rule, with its thresholds from the configuration
+func BuiltinRules(cfg *config.MonitoringConfig) []Rule {
+	return []Rule{
+		featureRule{RuleLargeTransaction, 30, func(f Features) (string, bool) {
+			return fmt.Sprintf("Amount %.2f exceeds %.2f", f.Amount, cfg.LargeAmount), f.Amount > cfg.LargeAmount
+		}},
+		featureRule{RuleHighVelocity, 40, func(f Features) (string, bool) {
+			return fmt.Sprintf("%d transactions in 24 hours, limit %d", f.Velocity24h, cfg.VelocityLimit), f.Velocity24h > cfg.VelocityLimit
+		}},
+		featureRule{RulePossibleStructuring, 50, func(f Features) (string, bool) {
+			return fmt.Sprintf("%d transactions just below %.2f in 24 hours", f.NearThreshold24h, cfg.LargeAmount), f.NearThreshold24h >= cfg.StructuringCount
+		}},
+		featureRule{RuleRoundAmount, 10, func(f Features) (string, bool) {
+			return fmt.Sprintf("Round amount %.2f", f.Amount), f.RoundAmount
+		}},
+		featureRule{RuleAmountOutlier, 30, func(f Features) (string, bool) {
+			return fmt.Sprintf("Amount is %.1f standard deviations from the portfolio's history", f.AmountZScore), math.Abs(f.AmountZScore) >= cfg.OutlierZScore
+		}},
+		featureRule{RuleOffHours, 10, func(f Features) (string, bool) {
+			return fmt.Sprintf("Executed off hours, at %02d:00 UTC on day %d", f.HourOfDay, f.DayOfWeek), f.OffHours
+		}},
+		featureRule{RuleNewSymbol, 10, func(f Features) (string, bool) {
+			return "First transaction of the portfolio in this symbol", f.NewSymbol
+		}},
+	}
+}
+
+// NewRuleSet selects the configured rules from the built-in rules, or all of them when none are
+// configured
+func NewRuleSet(cfg *config.MonitoringConfig) ([]Rule, error) {
+	builtin := BuiltinRules(cfg)
+	if len(cfg.Rules) == 0 {
+		return builtin, nil
+	}
+
+	byName := make(map[string]Rule, len(builtin))
+	for _, rule := range builtin {
+		byName[rule.Name()] = rule
+	}
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for _, name := range cfg.Rules {
+		rule, ok := byName[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown monitoring rule %q", name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,138 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// ValidateCounterparty normalises and checks a counterparty before it is stored
+func ValidateCounterparty(counterparty *models.Counterparty) error {
+	counterparty.RiskRating = strings.ToUpper(counterparty.RiskRating)
+	counterparty.KYCStatus = strings.ToUpper(counterparty.KYCStatus)
+	counterparty.EntityType = strings.ToUpper(counterparty.EntityType)
+
+	if strings.TrimSpace(counterparty.Name) == "" {
+		return fmt.Errorf("counterparty name is required")
+	}
+	if strings.TrimSpace(counterparty.Jurisdiction) == "" {
+		return fmt.Errorf("counterparty jurisdiction is required")
+	}
+
+	if counterparty.RiskRating == "" {
+		counterparty.RiskRating = models.CounterpartyRiskMedium
+	}
+	switch counterparty.RiskRating {
+	case models.CounterpartyRiskLow, models.CounterpartyRiskMedium, models.CounterpartyRiskHigh, models.CounterpartyRiskProhibited:
+	default:
+		return fmt.Errorf("unsupported risk rating: %s", counterparty.RiskRating)
+	}
+
+	if counterparty.KYCStatus == "" {
+		counterparty.KYCStatus = models.KYCStatusPending
+	}
+	switch counterparty.KYCStatus {
+	case models.KYCStatusPending, models.KYCStatusVerified, models.KYCStatusRejected, models.KYCStatusExpired:
+	default:
+		return fmt.Errorf("unsupported KYC status: %s", counterparty.KYCStatus)
+	}
+
+	if counterparty.ExposureLimit.IsNegative() {
+		return fmt.Errorf("exposure limit cannot be negative")
+	}
+
+	return nil
+}
+
+type CounterpartyChecker struct {
+	HighRiskJurisdictions []string
+}
+
+func NewCounterpartyChecker() *CounterpartyChecker {
+	return &CounterpartyChecker{
+		HighRiskJurisdictions: NewKYCAMLChecker().HighRiskCountries,
+	}
+}
+
+// CheckTransaction checks a trade of amount against its counterparty's status, KYC and exposure
+// limit; currentExposure is the counterparty's open exposure before this trade
+func (k *CounterpartyChecker) CheckTransaction(counterparty *models.Counterparty, amount, currentExposure decimal.Decimal) CounterpartyCheckResult {
+	result := CounterpartyCheckResult{
+		CounterpartyID: counterparty.ID.String(),
+		Passed:         true,
+		Flags:          []string{},
+	}
+
+	// Check 1: Prohibited or deactivated counterparties may not be traded with at all
+	if counterparty.RiskRating == models.CounterpartyRiskProhibited {
+		result.Flags = append(result.Flags, "COUNTERPARTY_PROHIBITED")
+		result.Blocked = true
+	}
+	if !counterparty.IsActive {
+		result.Flags = append(result.Flags, "COUNTERPARTY_INACTIVE")
+		result.Blocked = true
+	}
+
+	// Check 2: KYC must be verified and current
+	switch {
+	case counterparty.KYCStatus == models.KYCStatusRejected:
+		result.Flags = append(result.Flags, "COUNTERPARTY_KYC_REJECTED")
+		result.Blocked = true
+	case counterparty.KYCStatus == models.KYCStatusVerified && !counterparty.KYCCurrent(time.Now()),
+		counterparty.KYCStatus == models.KYCStatusExpired:
+		result.Flags = append(result.Flags, "COUNTERPARTY_KYC_EXPIRED")
+		result.RiskScore += 30
+	case counterparty.KYCStatus != models.KYCStatusVerified:
+		result.Flags = append(result.Flags, "COUNTERPARTY_KYC_PENDING")
+		result.RiskScore += 30
+	}
+
+	// Check 3: Risk rating and jurisdiction
+	if counterparty.RiskRating == models.CounterpartyRiskHigh {
+		result.Flags = append(result.Flags, "COUNTERPARTY_HIGH_RISK")
+		result.RiskScore += 30
+	}
+	for _, jurisdiction := range k.HighRiskJurisdictions {
+		if counterparty.Jurisdiction == jurisdiction {
+			result.Flags = append(result.Flags, "HIGH_RISK_JURISDICTION")
+			result.RiskScore += 40
+			break
+		}
+	}
+
+	// Check 4: Exposure limit
+	result.ProjectedExposure = currentExposure.Add(amount)
+	if counterparty.ExposureLimit.IsPositive() && result.ProjectedExposure.GreaterThan(counterparty.ExposureLimit) {
+		result.Flags = append(result.Flags, "COUNTERPARTY_EXPOSURE_LIMIT")
+		result.RiskScore += 40
+	}
+
+	if result.RiskScore > 100 {
+		result.RiskScore = 100
+	}
+	if result.Blocked {
+		result.RiskScore = 100
+	}
+
+	// Determine if transaction should be flagged
+	if result.Blocked || result.RiskScore >= 50 {
+		result.Passed = false
+		result.RequiresReview = !result.Blocked
+	}
+
+	return result
+}
+
+type CounterpartyCheckResult struct {
+	CounterpartyID    string          `json:"counterparty_id"`
+	Passed            bool            `json:"passed"`
+	Blocked           bool            `json:"blocked"`
+	RequiresReview    bool            `json:"requires_review"`
+	RiskScore         int             `json:"risk_score"`
+	ProjectedExposure decimal.Decimal `json:"projected_exposure"`
+	Flags             []string        `json:"flags"`
+}
@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+// Investment guidelines a portfolio or trade can breach
+const (
+	GuidelineAllowedAssetTypes = "ALLOWED_ASSET_TYPES"
+	GuidelineMaxCryptoPercent  = "MAX_CRYPTO_PERCENT"
+	GuidelineMinCreditRating   = "MIN_CREDIT_RATING"
+	GuidelineESGExcludedSymbol = "ESG_EXCLUDED_SYMBOL"
+	GuidelineESGExcludedSector = "ESG_EXCLUDED_SECTOR"
+)
+
+// GuidelineBreach is a holding or trade that breaches one guideline of a portfolio's mandate
+type GuidelineBreach struct {
+	Guideline   string `json:"guideline"`
+	Symbol      string `json:"symbol,omitempty"` // Empty for portfolio-wide limits
+	Description string `json:"description"`
+	Value       string `json:"value,omitempty"`
+	Limit       string `json:"limit,omitempty"`
+}
+
+// ValidateGuideline normalises a guideline and checks its limits
+func ValidateGuideline(g *models.InvestmentGuideline) error {
+	g.AllowedAssetTypes = upperAll(g.AllowedAssetTypes)
+	g.ESGExcludedSymbols = upperAll(g.ESGExcludedSymbols)
+	g.ESGExcludedSectors = upperAll(g.ESGExcludedSectors)
+	g.MinCreditRating = strings.ToUpper(strings.TrimSpace(g.MinCreditRating))
+
+	if g.MaxCryptoPercent != nil && (g.MaxCryptoPercent.IsNegative() || g.MaxCryptoPercent.GreaterThan(decimal.NewFromInt(100))) {
+		return fmt.Errorf("max_crypto_percent must be between 0 and 100")
+	}
+	if g.MinCreditRating != "" && models.CreditRatingRank(g.MinCreditRating) < 0 {
+		return fmt.Errorf("unsupported credit rating %s, use AAA to D", g.MinCreditRating)
+	}
+	return nil
+}
+
+func upperAll(values []string) []string {
+	upper := []string{}
+	for _, v := range values {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" && !slices.Contains(upper, v) {
+			upper = append(upper, v)
+		}
+	}
+	return upper
+}
+
+// EvaluateGuideline checks a portfolio's positions against its guideline. A symbol held in
+// several lots is reported once per guideline.
+func EvaluateGuideline(g *models.InvestmentGuideline, positions []models.Position) []GuidelineBreach {
+	breaches := []GuidelineBreach{}
+
+	seen := make(map[string]bool)
+	for _, pos := range positions {
+		if seen[pos.Symbol] {
+			continue
+		}
+		seen[pos.Symbol] = true
+		breaches = append(breaches, holdingBreaches(g, pos.Symbol, pos.AssetType, pos.CreditRating)...)
+	}
+
+	if g.MaxCryptoPercent != nil {
+		crypto, total := cryptoValue(positions)
+		if !total.IsZero() {
+			if percent := crypto.Div(total).Mul(decimal.NewFromInt(100)); percent.GreaterThan(*g.MaxCryptoPercent) {
+				breaches = append(breaches, cryptoBreach("Crypto is", percent, *g.MaxCryptoPercent))
+			}
+		}
+	}
+
+	return breaches
+}
+
+// EvaluateTrade checks a BUY against the guideline of the portfolio holding positions. The asset
+// type and rating of the instrument come from the trade, or else from a position already held in
+// it. SELLs only reduce holdings and cannot breach a mandate.
+func EvaluateTrade(g *models.InvestmentGuideline, trade *models.Transaction, positions []models.Position) []GuidelineBreach {
+	if trade.TransactionType != "BUY" {
+		return []GuidelineBreach{}
+	}
+
+	assetType, rating := trade.AssetType, trade.CreditRating
+	for _, pos := range positions {
+		if strings.EqualFold(pos.Symbol, trade.Symbol) {
+			if assetType == "" {
+				assetType = pos.AssetType
+			}
+			if rating == "" {
+				rating = pos.CreditRating
+			}
+		}
+	}
+
+	breaches := holdingBreaches(g, trade.Symbol, assetType, rating)
+
+	if g.MaxCryptoPercent != nil && isCrypto(trade.Symbol, assetType) {
+		crypto, total := cryptoValue(positions)
+		crypto, total = crypto.Add(trade.Amount), total.Add(trade.Amount)
+		if !total.IsZero() {
+			if percent := crypto.Div(total).Mul(decimal.NewFromInt(100)); percent.GreaterThan(*g.MaxCryptoPercent) {
+				breaches = append(breaches, cryptoBreach("Crypto would be", percent, *g.MaxCryptoPercent))
+			}
+		}
+	}
+
+	return breaches
+}
+
+// holdingBreaches checks one instrument against the guideline's asset type, credit rating and ESG
+// limits. An instrument of unknown asset type is not checked against the allowed types; a bond
+// without a rating breaches a minimum rating.
+func holdingBreaches(g *models.InvestmentGuideline, symbol, assetType, rating string) []GuidelineBreach {
+	breaches := []GuidelineBreach{}
+	assetType = strings.ToUpper(assetType)
+	classification := calculator.ClassifySymbol(symbol, assetType)
+
+	if len(g.AllowedAssetTypes) > 0 && assetType != "" && !slices.Contains(g.AllowedAssetTypes, assetType) {
+		breaches = append(breaches, GuidelineBreach{
+			Guideline:   GuidelineAllowedAssetTypes,
+			Symbol:      symbol,
+			Description: fmt.Sprintf("%s is a %s, which the mandate does not allow", symbol, assetType),
+			Value:       assetType,
+			Limit:       strings.Join(g.AllowedAssetTypes, ", "),
+		})
+	}
+
+	if g.MinCreditRating != "" && classification.AssetClass == "FIXED_INCOME" {
+		rank := models.CreditRatingRank(rating)
+		if rank < 0 || rank > models.CreditRatingRank(g.MinCreditRating) {
+			description := fmt.Sprintf("%s is rated %s, below the minimum of %s", symbol, rating, g.MinCreditRating)
+			if rank < 0 {
+				description = fmt.Sprintf("%s has no credit rating, the mandate requires %s or better", symbol, g.MinCreditRating)
+			}
+			breaches = append(breaches, GuidelineBreach{
+				Guideline:   GuidelineMinCreditRating,
+				Symbol:      symbol,
+				Description: description,
+				Value:       rating,
+				Limit:       g.MinCreditRating,
+			})
+		}
+	}
+
+	if slices.Contains(g.ESGExcludedSymbols, strings.ToUpper(symbol)) {
+		breaches = append(breaches, GuidelineBreach{
+			Guideline:   GuidelineESGExcludedSymbol,
+			Symbol:      symbol,
+			Description: fmt.Sprintf("%s is excluded by the mandate's ESG policy", symbol),
+			Value:       symbol,
+		})
+	}
+	if slices.Contains(g.ESGExcludedSectors, classification.Sector) {
+		breaches = append(breaches, GuidelineBreach{
+			Guideline:   GuidelineESGExcludedSector,
+			Symbol:      symbol,
+			Description: fmt.Sprintf("%s is in the %s sector, excluded by the mandate's ESG policy", symbol, classification.Sector),
+			Value:       classification.Sector,
+		})
+	}
+
+	return breaches
+}
+
+func isCrypto(symbol, assetType string) bool {
+	return calculator.ClassifySymbol(symbol, assetType).AssetClass == "CRYPTO"
+}
+
+// cryptoValue returns the market value of the crypto positions and of all positions
+func cryptoValue(positions []models.Position) (decimal.Decimal, decimal.Decimal) {
+	crypto, total := decimal.Zero, decimal.Zero
+	for _, pos := range positions {
+		total = total.Add(pos.MarketValue)
+		if isCrypto(pos.Symbol, pos.AssetType) {
+			crypto = crypto.Add(pos.MarketValue)
+		}
+	}
+	return crypto, total
+}
+
+func cryptoBreach(subject string, percent, limit decimal.Decimal) GuidelineBreach {
+	return GuidelineBreach{
+		Guideline:   GuidelineMaxCryptoPercent,
+		Description: fmt.Sprintf("%s %s%% of the portfolio, limit %s%%", subject, percent.StringFixed(2), limit.StringFixed(2)),
+		Value:       percent.StringFixed(2),
+		Limit:       limit.StringFixed(2),
+	}
+}
@@ -0,0 +1,239 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Rule scopes
+const (
+	ScopePortfolio   = "PORTFOLIO"
+	ScopeTransaction = "TRANSACTION"
+)
+
+// Portfolio metrics
+const (
+	MetricTotalValue        = "TOTAL_VALUE"
+	MetricPositionCount     = "POSITION_COUNT"
+	MetricMaxPositionWeight = "MAX_POSITION_WEIGHT" // % of portfolio
+	MetricConcentrationHHI  = "CONCENTRATION_HHI"   // Herfindahl index, 0-1
+	MetricLiquidityRatio    = "LIQUIDITY_RATIO"     // Share of HIGH liquidity positions, 0-1
+	MetricAssetTypeWeight   = "ASSET_TYPE_WEIGHT"   // % of portfolio in Parameter asset type
+	MetricSymbolWeight      = "SYMBOL_WEIGHT"       // % of portfolio in Parameter symbol
+)
+
+// Transaction metrics
+const (
+	MetricAmount      = "AMOUNT"
+	MetricQuantity    = "QUANTITY"
+	MetricRiskScore   = "RISK_SCORE"
+	MetricDailyCount  = "DAILY_TRANSACTION_COUNT" // Transactions in the portfolio over the last 24h
+	MetricDailyVolume = "DAILY_VOLUME"            // Summed amount in the portfolio over the last 24h
+)
+
+var portfolioMetrics = map[string]bool{
+	MetricTotalValue: true, MetricPositionCount: true, MetricMaxPositionWeight: true,
+	MetricConcentrationHHI: true, MetricLiquidityRatio: true, MetricAssetTypeWeight: true,
+	MetricSymbolWeight: true,
+}
+
+var transactionMetrics = map[string]bool{
+	MetricAmount: true, MetricQuantity: true, MetricRiskScore: true,
+	MetricDailyCount: true, MetricDailyVolume: true,
+}
+
+var operators = map[string]bool{
+	"GT": true, "GTE": true, "LT": true, "LTE": true, "EQ": true, "NEQ": true,
+}
+
+var severities = map[string]bool{
+	"LOW": true, "MEDIUM": true, "HIGH": true, "CRITICAL": true,
+}
+
+// ValidateRule checks that a rule's scope, metric, operator and severity are consistent
+func ValidateRule(rule *models.ComplianceRule) error {
+	rule.Scope = strings.ToUpper(rule.Scope)
+	rule.Metric = strings.ToUpper(rule.Metric)
+	rule.Operator = strings.ToUpper(rule.Operator)
+	rule.Severity = strings.ToUpper(rule.Severity)
+
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+
+	switch rule.Scope {
+	case ScopePortfolio:
+		if !portfolioMetrics[rule.Metric] {
+			return fmt.Errorf("unsupported portfolio metric: %s", rule.Metric)
+		}
+	case ScopeTransaction:
+		if !transactionMetrics[rule.Metric] {
+			return fmt.Errorf("unsupported transaction metric: %s", rule.Metric)
+		}
+	default:
+		return fmt.Errorf("unsupported rule scope: %s", rule.Scope)
+	}
+
+	if (rule.Metric == MetricAssetTypeWeight || rule.Metric == MetricSymbolWeight) && rule.Parameter == "" {
+		return fmt.Errorf("metric %s requires a parameter", rule.Metric)
+	}
+	if !operators[rule.Operator] {
+		return fmt.Errorf("unsupported operator: %s", rule.Operator)
+	}
+	if !severities[rule.Severity] {
+		return fmt.Errorf("unsupported severity: %s", rule.Severity)
+	}
+
+	return nil
+}
+
+// Compare applies a rule operator; it returns true when the rule is breached
+func Compare(value decimal.Decimal, operator string, threshold decimal.Decimal) bool {
+	switch operator {
+	case "GT":
+		return value.GreaterThan(threshold)
+	case "GTE":
+		return value.GreaterThanOrEqual(threshold)
+	case "LT":
+		return value.LessThan(threshold)
+	case "LTE":
+		return value.LessThanOrEqual(threshold)
+	case "EQ":
+		return value.Equal(threshold)
+	case "NEQ":
+		return !value.Equal(threshold)
+	}
+	return false
+}
+
+// PortfolioMetric computes a portfolio-scoped metric from its positions
+func PortfolioMetric(metric, parameter string, positions []models.Position) decimal.Decimal {
+	totalValue := decimal.Zero
+	for _, pos := range positions {
+		totalValue = totalValue.Add(pos.MarketValue)
+	}
+
+	hundred := decimal.NewFromInt(100)
+
+	switch metric {
+	case MetricTotalValue:
+		return totalValue
+	case MetricPositionCount:
+		return decimal.NewFromInt(int64(len(positions)))
+	}
+
+	if totalValue.IsZero() {
+		return decimal.Zero
+	}
+
+	switch metric {
+	case MetricMaxPositionWeight:
+		maxWeight := decimal.Zero
+		for _, pos := range positions {
+			if w := pos.MarketValue.Div(totalValue); w.GreaterThan(maxWeight) {
+				maxWeight = w
+			}
+		}
+		return maxWeight.Mul(hundred)
+
+	case MetricConcentrationHHI:
+		hhi := decimal.Zero
+		for _, pos := range positions {
+			w := pos.MarketValue.Div(totalValue)
+			hhi = hhi.Add(w.Mul(w))
+		}
+		return hhi
+
+	case MetricLiquidityRatio:
+		liquid := decimal.Zero
+		for _, pos := range positions {
+			if pos.Liquidity == "" || pos.Liquidity == "HIGH" {
+				liquid = liquid.Add(pos.MarketValue)
+			}
+		}
+		return liquid.Div(totalValue)
+
+	case MetricAssetTypeWeight:
+		sum := decimal.Zero
+		for _, pos := range positions {
+			if strings.EqualFold(pos.AssetType, parameter) {
+				sum = sum.Add(pos.MarketValue)
+			}
+		}
+		return sum.Div(totalValue).Mul(hundred)
+
+	case MetricSymbolWeight:
+		sum := decimal.Zero
+		for _, pos := range positions {
+			if strings.EqualFold(pos.Symbol, parameter) {
+				sum = sum.Add(pos.MarketValue)
+			}
+		}
+		return sum.Div(totalValue).Mul(hundred)
+	}
+
+	return decimal.Zero
+}
+
+// TransactionMetric computes a transaction-scoped metric; recent holds the portfolio's
+// transactions from the last 24 hours
+func TransactionMetric(metric string, tx *models.Transaction, recent []models.Transaction) decimal.Decimal {
+	switch metric {
+	case MetricAmount:
+		return tx.Amount
+	case MetricQuantity:
+		return tx.Quantity
+	case MetricRiskScore:
+		return decimal.NewFromInt(int64(tx.RiskScore))
+	case MetricDailyCount:
+		return decimal.NewFromInt(int64(len(recent)))
+	case MetricDailyVolume:
+		sum := decimal.Zero
+		for _, r := range recent {
+			sum = sum.Add(r.Amount)
+		}
+		return sum
+	}
+	return decimal.Zero
+}
+
+// DefaultRules returns rules equivalent to the built-in position limit and AML checks,
+// used to seed the rule table on first start
+func DefaultRules() []models.ComplianceRule {
+	return []models.ComplianceRule{
+		{
+			Name:        "Single position limit",
+			Description: "No single position may exceed 25% of the portfolio",
+			Scope:       ScopePortfolio,
+			Metric:      MetricMaxPositionWeight,
+			Operator:    "GT",
+			Threshold:   decimal.NewFromInt(25),
+			Severity:    "HIGH",
+			IsActive:    true,
+		},
+		{
+			Name:        "Large transaction",
+			Description: "Transactions above $10,000 require AML review",
+			Scope:       ScopeTransaction,
+			Metric:      MetricAmount,
+			Operator:    "GT",
+			Threshold:   decimal.NewFromInt(10000),
+			Severity:    "HIGH",
+			IsActive:    true,
+		},
+		{
+			Name:        "Transaction velocity",
+			Description: "More than 10 transactions in 24 hours",
+			Scope:       ScopeTransaction,
+			Metric:      MetricDailyCount,
+			Operator:    "GT",
+			Threshold:   decimal.NewFromInt(10),
+			Severity:    "MEDIUM",
+			IsActive:    true,
+		},
+	}
+}
@@ -0,0 +1,307 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Supported list sources
+const (
+	SourceOFAC     = "OFAC"
+	SourceEU       = "EU"
+	SourceUN       = "UN"
+	SourcePEP      = "PEP"
+	SourceInternal = "INTERNAL"
+)
+
+// ParseList parses a list file into sanctions entries according to its source format.
+// OFAC lists use the SDN.CSV layout, UN and EU lists use their published XML layouts
+// and PEP/INTERNAL lists use a generic CSV with a header row.
+func ParseList(source string, r io.Reader) ([]models.SanctionsEntry, error) {
+	switch strings.ToUpper(source) {
+	case SourceOFAC:
+		return parseOFAC(r)
+	case SourceUN:
+		return parseUN(r)
+	case SourceEU:
+		return parseEU(r)
+	case SourcePEP, SourceInternal:
+		return parseGenericCSV(strings.ToUpper(source), r)
+	default:
+		return nil, fmt.Errorf("unsupported list source: %s", source)
+	}
+}
+
+// parseOFAC reads the OFAC SDN.CSV file, which has no header row:
+// ent_num, SDN_Name, SDN_Type, Program, Title, Call_Sign, Vess_type, Tonnage, GRT, Vess_flag, Vess_owner, Remarks
+func parseOFAC(r io.Reader) ([]models.SanctionsEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	entries := []models.SanctionsEntry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OFAC record: %w", err)
+		}
+		if len(record) < 4 {
+			continue
+		}
+
+		entityType := "ENTITY"
+		switch strings.ToLower(ofacField(record[2])) {
+		case "individual":
+			entityType = "INDIVIDUAL"
+		case "vessel":
+			entityType = "VESSEL"
+		case "aircraft":
+			entityType = "AIRCRAFT"
+		}
+
+		entry := models.SanctionsEntry{
+			Source:     SourceOFAC,
+			ExternalID: ofacField(record[0]),
+			Name:       ofacField(record[1]),
+			EntityType: entityType,
+			Programs:   ofacField(record[3]),
+		}
+		if len(record) > 11 {
+			entry.Remarks = ofacField(record[11])
+		}
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// ofacField converts the OFAC "-0-" null marker to an empty string
+func ofacField(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "-0-" {
+		return ""
+	}
+	return value
+}
+
+type unConsolidatedList struct {
+	Individuals []struct {
+		DataID     string `xml:"DATAID"`
+		FirstName  string `xml:"FIRST_NAME"`
+		SecondName string `xml:"SECOND_NAME"`
+		ThirdName  string `xml:"THIRD_NAME"`
+		FourthName string `xml:"FOURTH_NAME"`
+		ListType   string `xml:"UN_LIST_TYPE"`
+		Comments   string `xml:"COMMENTS1"`
+		Aliases    []struct {
+			Name string `xml:"ALIAS_NAME"`
+		} `xml:"INDIVIDUAL_ALIAS"`
+		Nationality []string `xml:"NATIONALITY>VALUE"`
+	} `xml:"INDIVIDUALS>INDIVIDUAL"`
+	Entities []struct {
+		DataID    string `xml:"DATAID"`
+		FirstName string `xml:"FIRST_NAME"`
+		ListType  string `xml:"UN_LIST_TYPE"`
+		Comments  string `xml:"COMMENTS1"`
+		Aliases   []struct {
+			Name string `xml:"ALIAS_NAME"`
+		} `xml:"ENTITY_ALIAS"`
+	} `xml:"ENTITIES>ENTITY"`
+}
+
+// parseUN reads the UN Security Council consolidated list XML
+func parseUN(r io.Reader) ([]models.SanctionsEntry, error) {
+	var list unConsolidatedList
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid UN list: %w", err)
+	}
+
+	entries := []models.SanctionsEntry{}
+	for _, ind := range list.Individuals {
+		name := joinNonEmpty(" ", ind.FirstName, ind.SecondName, ind.ThirdName, ind.FourthName)
+		if name == "" {
+			continue
+		}
+		aliases := []string{}
+		for _, a := range ind.Aliases {
+			aliases = append(aliases, a.Name)
+		}
+		entries = append(entries, models.SanctionsEntry{
+			Source:     SourceUN,
+			ExternalID: ind.DataID,
+			Name:       name,
+			Aliases:    joinNonEmpty(";", aliases...),
+			EntityType: "INDIVIDUAL",
+			Country:    joinNonEmpty(";", ind.Nationality...),
+			Programs:   ind.ListType,
+			Remarks:    ind.Comments,
+		})
+	}
+
+	for _, ent := range list.Entities {
+		if strings.TrimSpace(ent.FirstName) == "" {
+			continue
+		}
+		aliases := []string{}
+		for _, a := range ent.Aliases {
+			aliases = append(aliases, a.Name)
+		}
+		entries = append(entries, models.SanctionsEntry{
+			Source:     SourceUN,
+			ExternalID: ent.DataID,
+			Name:       strings.TrimSpace(ent.FirstName),
+			Aliases:    joinNonEmpty(";", aliases...),
+			EntityType: "ENTITY",
+			Programs:   ent.ListType,
+			Remarks:    ent.Comments,
+		})
+	}
+
+	return entries, nil
+}
+
+type euExport struct {
+	Entities []struct {
+		LogicalID   string `xml:"logicalId,attr"`
+		Remark      string `xml:"remark"`
+		SubjectType struct {
+			Code string `xml:"code,attr"`
+		} `xml:"subjectType"`
+		Regulation []struct {
+			Programme string `xml:"programme,attr"`
+		} `xml:"regulation"`
+		NameAliases []struct {
+			WholeName string `xml:"wholeName,attr"`
+		} `xml:"nameAlias"`
+		Citizenships []struct {
+			Country string `xml:"countryDescription,attr"`
+		} `xml:"citizenship"`
+	} `xml:"sanctionEntity"`
+}
+
+// parseEU reads the EU financial sanctions (FSF) XML export
+func parseEU(r io.Reader) ([]models.SanctionsEntry, error) {
+	var export euExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid EU list: %w", err)
+	}
+
+	entries := []models.SanctionsEntry{}
+	for _, ent := range export.Entities {
+		if len(ent.NameAliases) == 0 {
+			continue
+		}
+
+		aliases := []string{}
+		for _, a := range ent.NameAliases[1:] {
+			aliases = append(aliases, a.WholeName)
+		}
+		countries := []string{}
+		for _, c := range ent.Citizenships {
+			countries = append(countries, c.Country)
+		}
+		programmes := []string{}
+		for _, reg := range ent.Regulation {
+			programmes = append(programmes, reg.Programme)
+		}
+
+		entityType := "ENTITY"
+		if ent.SubjectType.Code == "person" {
+			entityType = "INDIVIDUAL"
+		}
+
+		entries = append(entries, models.SanctionsEntry{
+			Source:     SourceEU,
+			ExternalID: ent.LogicalID,
+			Name:       ent.NameAliases[0].WholeName,
+			Aliases:    joinNonEmpty(";", aliases...),
+			EntityType: entityType,
+			Country:    joinNonEmpty(";", countries...),
+			Programs:   joinNonEmpty(";", programmes...),
+			Remarks:    ent.Remark,
+		})
+	}
+
+	return entries, nil
+}
+
+// parseGenericCSV reads a CSV with a header row containing at least a "name" column.
+// Optional columns: external_id, aliases, entity_type, country, programs, remarks.
+// Every entry on a PEP list is flagged as a politically exposed person.
+func parseGenericCSV(source string, r io.Reader) ([]models.SanctionsEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header row: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, col := range header {
+		columns[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("list must contain a name column")
+	}
+
+	field := func(record []string, col string) string {
+		if i, ok := columns[col]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entries := []models.SanctionsEntry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s record: %w", source, err)
+		}
+
+		entry := models.SanctionsEntry{
+			Source:     source,
+			ExternalID: field(record, "external_id"),
+			Name:       field(record, "name"),
+			Aliases:    field(record, "aliases"),
+			EntityType: strings.ToUpper(field(record, "entity_type")),
+			Country:    field(record, "country"),
+			Programs:   field(record, "programs"),
+			Remarks:    field(record, "remarks"),
+			IsPEP:      source == SourcePEP,
+		}
+		if entry.EntityType == "" {
+			entry.EntityType = "INDIVIDUAL"
+		}
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	parts := []string{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,209 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Matcher scores how closely two names resemble each other
+type Matcher struct {
+	MatchThreshold     float64 // Scores at or above this are treated as a confirmed match
+	PotentialThreshold float64 // Scores at or above this require manual review
+}
+
+// NewMatcher creates a matcher with thresholds suited to sanctions screening
+func NewMatcher() *Matcher {
+	return &Matcher{
+		MatchThreshold:     0.93,
+		PotentialThreshold: 0.85,
+	}
+}
+
+// honorifics are dropped before comparison since lists apply them inconsistently
+var honorifics = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "sir": true,
+	"sheikh": true, "haji": true, "general": true, "col": true,
+}
+
+// Normalize lowercases a name, strips punctuation and removes honorifics
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(foldAccent(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	tokens := []string{}
+	for _, token := range strings.Fields(b.String()) {
+		if !honorifics[token] {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// Score returns a similarity between 0 and 1 for two names
+func (m *Matcher) Score(a, b string) float64 {
+	na, nb := Normalize(a), Normalize(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+
+	// Compare the raw strings, the token-sorted strings (handles "Last, First"
+	// ordering) and the best per-token alignment, keeping the highest score
+	best := JaroWinkler(na, nb)
+	if s := JaroWinkler(sortTokens(na), sortTokens(nb)); s > best {
+		best = s
+	}
+	if s := tokenSetScore(na, nb); s > best {
+		best = s
+	}
+
+	return best
+}
+
+// Classify maps a score onto a screening status
+func (m *Matcher) Classify(score float64) string {
+	switch {
+	case score >= m.MatchThreshold:
+		return StatusMatch
+	case score >= m.PotentialThreshold:
+		return StatusPotentialMatch
+	default:
+		return StatusClear
+	}
+}
+
+// JaroWinkler computes the Jaro-Winkler similarity of two strings
+func JaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	matchDistance := max(len(ra), len(rb))/2 - 1
+	if matchDistance < 0 {
+		matchDistance = 0
+	}
+
+	aMatches := make([]bool, len(ra))
+	bMatches := make([]bool, len(rb))
+	matches := 0
+
+	for i := range ra {
+		start := max(0, i-matchDistance)
+		end := min(len(rb), i+matchDistance+1)
+		for j := start; j < end; j++ {
+			if bMatches[j] || ra[i] != rb[j] {
+				continue
+			}
+			aMatches[i] = true
+			bMatches[j] = true
+			matches++
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// Count transpositions
+	transpositions := 0
+	k := 0
+	for i := range ra {
+		if !aMatches[i] {
+			continue
+		}
+		for !bMatches[k] {
+			k++
+		}
+		if ra[i] != rb[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	// Winkler prefix bonus (up to 4 characters)
+	prefix := 0
+	for i := 0; i < min(4, len(ra), len(rb)); i++ {
+		if ra[i] != rb[i] {
+			break
+		}
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// tokenSetScore aligns every token of the shorter name with its best match in
+// the longer one, so "Ivan Petrov" still scores highly against "Ivan Sergeyevich Petrov"
+func tokenSetScore(a, b string) float64 {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	if len(ta) > len(tb) {
+		ta, tb = tb, ta
+	}
+	// A single shared token (e.g. a common surname) is not enough evidence
+	if len(ta) < 2 {
+		return 0
+	}
+
+	total := 0.0
+	for _, x := range ta {
+		best := 0.0
+		for _, y := range tb {
+			if s := JaroWinkler(x, y); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+
+	// Penalize unmatched extra tokens slightly
+	coverage := float64(len(ta)) / float64(len(tb))
+	return (total / float64(len(ta))) * (0.9 + 0.1*coverage)
+}
+
+func sortTokens(s string) string {
+	tokens := strings.Fields(s)
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// foldAccent maps common Latin accented characters to their base letter
+func foldAccent(r rune) rune {
+	switch r {
+	case 'à', 'á', 'â', 'ã', 'ä', 'å':
+		return 'a'
+	case 'ç':
+		return 'c'
+	case 'è', 'é', 'ê', 'ë':
+		return 'e'
+	case 'ì', 'í', 'î', 'ï':
+		return 'i'
+	case 'ñ':
+		return 'n'
+	case 'ò', 'ó', 'ô', 'õ', 'ö', 'ø':
+		return 'o'
+	case 'ù', 'ú', 'û', 'ü':
+		return 'u'
+	case 'ý', 'ÿ':
+		return 'y'
+	}
+	return r
+}
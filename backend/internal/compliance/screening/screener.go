@@ -0,0 +1,289 @@
+package screening
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Screening statuses
+const (
+	StatusClear          = "CLEAR"
+	StatusPotentialMatch = "POTENTIAL_MATCH"
+	StatusMatch          = "MATCH"
+)
+
+// Subject types
+const (
+	SubjectCustomer     = "CUSTOMER"
+	SubjectCounterparty = "COUNTERPARTY"
+	SubjectAdHoc        = "AD_HOC"
+)
+
+// Match describes a single list entry that resembles the screened name
+type Match struct {
+	EntryID    uuid.UUID `json:"entry_id"`
+	Source     string    `json:"source"`
+	ListedName string    `json:"listed_name"`
+	MatchedOn  string    `json:"matched_on"`
+	Score      float64   `json:"score"`
+	IsPEP      bool      `json:"is_pep"`
+	EntityType string    `json:"entity_type"`
+	Country    string    `json:"country"`
+	Programs   string    `json:"programs"`
+}
+
+// Screener screens names against imported sanctions and PEP lists
+type Screener struct {
+	db      *gorm.DB
+	matcher *Matcher
+
+	mu      sync.RWMutex
+	entries []models.SanctionsEntry
+	loaded  bool
+}
+
+var (
+	defaultScreener     *Screener
+	defaultScreenerOnce sync.Once
+)
+
+// GetScreener returns the shared screener so the in-memory list cache is reused
+func GetScreener() *Screener {
+	defaultScreenerOnce.Do(func() {
+		defaultScreener = NewScreener()
+	})
+	return defaultScreener
+}
+
+// NewScreener creates a new screener backed by the database
+func NewScreener() *Screener {
+	return &Screener{
+		db:      database.GetDB(),
+		matcher: NewMatcher(),
+	}
+}
+
+// ImportList replaces all entries for a source with the supplied entries
+func (s *Screener) ImportList(source string, entries []models.SanctionsEntry) error {
+	source = strings.ToUpper(source)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source = ?", source).Delete(&models.SanctionsEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(entries, 500).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s list: %w", source, err)
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// ListEntries returns list entries, optionally filtered by source
+func (s *Screener) ListEntries(source string, limit, offset int) ([]models.SanctionsEntry, int64, error) {
+	var entries []models.SanctionsEntry
+	var total int64
+
+	query := s.db.Model(&models.SanctionsEntry{})
+	if source != "" {
+		query = query.Where("source = ?", strings.ToUpper(source))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("name ASC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// ScreenName compares a name against every list entry and returns matches above
+// the potential-match threshold, best first
+func (s *Screener) ScreenName(name, country string) ([]Match, error) {
+	entries, err := s.loadEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []Match{}
+	for _, entry := range entries {
+		bestScore := s.matcher.Score(name, entry.Name)
+		matchedOn := entry.Name
+
+		for _, alias := range strings.Split(entry.Aliases, ";") {
+			if alias = strings.TrimSpace(alias); alias == "" {
+				continue
+			}
+			if score := s.matcher.Score(name, alias); score > bestScore {
+				bestScore = score
+				matchedOn = alias
+			}
+		}
+
+		// A nationality match strengthens borderline hits
+		if country != "" && entry.Country != "" && bestScore >= s.matcher.PotentialThreshold-0.03 &&
+			strings.Contains(strings.ToLower(entry.Country), strings.ToLower(country)) {
+			bestScore = min(1, bestScore+0.03)
+		}
+
+		if bestScore < s.matcher.PotentialThreshold {
+			continue
+		}
+
+		matches = append(matches, Match{
+			EntryID:    entry.ID,
+			Source:     entry.Source,
+			ListedName: entry.Name,
+			MatchedOn:  matchedOn,
+			Score:      bestScore,
+			IsPEP:      entry.IsPEP,
+			EntityType: entry.EntityType,
+			Country:    entry.Country,
+			Programs:   entry.Programs,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+// Screen screens a single subject and persists the result
+func (s *Screener) Screen(name, country, subjectType string, transactionID, screenedBy *uuid.UUID) (*models.ScreeningResult, error) {
+	matches, err := s.ScreenName(name, country)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ScreeningResult{
+		TransactionID: transactionID,
+		SubjectName:   name,
+		SubjectType:   subjectType,
+		Status:        StatusClear,
+		ScreenedBy:    screenedBy,
+		ScreenedAt:    time.Now(),
+		Matches:       models.JSON{"matches": matches},
+	}
+
+	for _, m := range matches {
+		if m.Score > result.TopScore {
+			result.TopScore = m.Score
+		}
+		if m.IsPEP {
+			result.PEPHit = true
+		} else if s.matcher.Classify(m.Score) == StatusMatch {
+			result.SanctionsHit = true
+		}
+	}
+
+	// PEP hits need enhanced due diligence but are not sanctions matches
+	sanctionsScore := 0.0
+	for _, m := range matches {
+		if !m.IsPEP && m.Score > sanctionsScore {
+			sanctionsScore = m.Score
+		}
+	}
+	result.Status = s.matcher.Classify(sanctionsScore)
+	if result.Status == StatusClear && result.PEPHit {
+		result.Status = StatusPotentialMatch
+	}
+
+	if err := s.db.Create(result).Error; err != nil {
+		return nil, fmt.Errorf("failed to store screening result: %w", err)
+	}
+
+	return result, nil
+}
+
+// ScreenTransaction screens the portfolio owner and the counterparty of a transaction. A proposed
+// trade that has not been stored yet is screened with results not linked to any transaction.
+func (s *Screener) ScreenTransaction(tx *models.Transaction, screenedBy *uuid.UUID) ([]models.ScreeningResult, error) {
+	results := []models.ScreeningResult{}
+
+	var transactionID *uuid.UUID
+	if tx.ID != uuid.Nil {
+		transactionID = &tx.ID
+	}
+
+	var portfolio models.Portfolio
+	if err := s.db.Preload("User").First(&portfolio, tx.PortfolioID).Error; err != nil {
+		return nil, fmt.Errorf("portfolio not found: %w", err)
+	}
+
+	customerName := strings.TrimSpace(portfolio.User.FirstName + " " + portfolio.User.LastName)
+	if customerName != "" {
+		result, err := s.Screen(customerName, "", SubjectCustomer, transactionID, screenedBy)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	if tx.CounterpartyName != "" {
+		result, err := s.Screen(tx.CounterpartyName, tx.CounterpartyCountry, SubjectCounterparty, transactionID, screenedBy)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// GetTransactionScreenings returns all screening results recorded for a transaction
+func (s *Screener) GetTransactionScreenings(transactionID uuid.UUID) ([]models.ScreeningResult, error) {
+	var results []models.ScreeningResult
+	err := s.db.Where("transaction_id = ?", transactionID).
+		Order("screened_at DESC").
+		Find(&results).Error
+	return results, err
+}
+
+func (s *Screener) loadEntries() ([]models.SanctionsEntry, error) {
+	s.mu.RLock()
+	if s.loaded {
+		entries := s.entries
+		s.mu.RUnlock()
+		return entries, nil
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded {
+		return s.entries, nil
+	}
+
+	var entries []models.SanctionsEntry
+	if err := s.db.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load sanctions lists: %w", err)
+	}
+
+	s.entries = entries
+	s.loaded = true
+	return entries, nil
+}
+
+func (s *Screener) invalidate() {
+	s.mu.Lock()
+	s.entries = nil
+	s.loaded = false
+	s.mu.Unlock()
+}
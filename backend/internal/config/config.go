@@ -1,9 +1,11 @@
 package config
 
 import (
+    "errors"
     "log"
     "os"
     "strconv"
+    "strings"
     "time"
 
     "github.com/joho/godotenv"
@@ -11,20 +13,72 @@ import (
 
 type Config struct {
     App      AppConfig
+    CORS     CORSConfig
+    Security SecurityHeadersConfig
+    Log      LogConfig
     Database DatabaseConfig
     Redis    RedisConfig
     JWT      JWTConfig
+    APIKey   APIKeyConfig
     WS       WebSocketConfig
     Risk     RiskConfig
     Alert    AlertConfig
+    Compliance ComplianceConfig
+    Monitoring MonitoringConfig
+    Notification NotificationConfig
+    PriceFeed PriceFeedConfig
+    Idempotency IdempotencyConfig
+    FX FXConfig
+    Metrics MetricsConfig
+    Tracing TracingConfig
+    Retention RetentionConfig
+    Export ExportConfig
+    Jobs JobConfig
+    Cache CacheConfig
+    GRPC GRPCConfig
+    Kafka KafkaConfig
+    Mock MockConfig
+    Reconciliation ReconciliationConfig
+    Batch BatchConfig
+    Tenancy TenancyConfig
 }
 
 type AppConfig struct {
-    Env  string
-    Port string
-    Name string
+    Env             string
+    Port            string
+    Name            string
+    ShutdownTimeout time.Duration // How long shutdown waits for clients, requests and background workers
 }
 
+// CORSConfig sets which browser origins may call the API. Origins and methods are comma-separated;
+// credentials cannot be allowed for every origin ("*").
+type CORSConfig struct {
+    AllowOrigins     []string
+    AllowMethods     []string
+    AllowCredentials bool
+    MaxAge           time.Duration // How long browsers may cache a preflight response
+}
+
+// SecurityHeadersConfig sets the headers added to every response. HSTS is left out when its max age
+// is zero, and so is any other header left empty.
+type SecurityHeadersConfig struct {
+    Enabled               bool
+    HSTSMaxAge            time.Duration
+    HSTSIncludeSubdomains bool
+    ContentSecurityPolicy string
+    FrameOptions          string
+    ReferrerPolicy        string
+}
+
+// LogConfig sets the initial log level (debug, info, warn, error) and the output format (json, text)
+type LogConfig struct {
+    Level  string
+    Format string
+}
+
+// DatabaseConfig connects to Postgres. SQL is logged at LogLevel (silent, error, warn or info,
+// where info logs every statement), and statements slower than SlowQueryThreshold are logged as
+// warnings with the handler that ran them. Both default to quieter settings outside development.
 type DatabaseConfig struct {
     Host     string
     Port     string
@@ -32,48 +86,325 @@ type DatabaseConfig struct {
     Password string
     DBName   string
     SSLMode  string
+    MigrateOnStart bool // Apply pending migrations at startup instead of with the migrate command
+    MaxOpenConns       int           // 0 for no limit
+    MaxIdleConns       int
+    ConnMaxLifetime    time.Duration // 0 to reuse connections for ever
+    ConnMaxIdleTime    time.Duration
+    LogLevel           string
+    SlowQueryThreshold time.Duration // 0 disables slow query logging
+    LogParameters      bool          // Log statements with their values rather than placeholders
+    Timescale              bool          // Turn the time-series tables into TimescaleDB hypertables at startup
+    TimescaleChunkInterval time.Duration // Time range each hypertable chunk covers
+    TimescaleCompressAfter time.Duration // Age at which chunks are compressed; 0 disables compression
 }
 
+// RedisConfig connects to Redis. When Required is false the server starts and keeps running
+// without Redis, with caching and pub/sub disabled until it comes back.
 type RedisConfig struct {
     Host     string
     Port     string
     Password string
     DB       int
+    Required bool
+    BreakerThreshold    int           // Consecutive failures that open the circuit
+    HealthCheckInterval time.Duration // How often Redis is probed to detect outages and recovery
+    EventBufferSize     int           // Recent alert and risk update events kept for WebSocket replay
 }
 
 type JWTConfig struct {
-    Secret string
-    Expiry time.Duration
+    Secret        string
+    Expiry        time.Duration
+    RefreshExpiry time.Duration
 }
 
+// APIKeyConfig sets the rate limit of API keys minted without one, in requests per minute
+type APIKeyConfig struct {
+    DefaultRateLimit int
+}
+
+// Slow WebSocket client policies, applied when a client's send queue is full
+const (
+    SlowClientDisconnect = "disconnect"
+    SlowClientDropOldest = "drop_oldest"
+)
+
 type WebSocketConfig struct {
     ReadBufferSize  int
     WriteBufferSize int
+    SendQueueSize    int           // Messages queued per client before the slow client policy applies
+    WriteTimeout     time.Duration // Deadline for each write to a client
+    SlowClientPolicy string        // disconnect or drop_oldest
+    PingInterval     time.Duration // How often WebSocket clients are pinged; 0 disables pings
+    PongTimeout      time.Duration // How long a WebSocket client may go without a pong or message before it is disconnected; 0 for ever
 }
 
 type RiskConfig struct {
     VARConfidenceLevel  float64
     VARTimeHorizon      int
+    VaRTimeout            time.Duration // Longest a VaR calculation may run for a request
+    MonteCarloSimulations int           // Monte Carlo paths when a request does not choose
+    MonteCarloWorkers     int           // Goroutines a Monte Carlo simulation is split across; 0 uses every CPU
+    MonteCarloSeed        int           // Non-zero seeds Monte Carlo simulations for reproducible results
     LiquidityThreshold  float64
     PositionLimitPercent float64
+    LeverageCheckInterval time.Duration
+    LiquidityClassificationHour int // Hour of the day (UTC) at which positions are reclassified
+    NAVSnapshotHour int // Hour of the day (UTC) at which end-of-day portfolio valuations are recorded
+    RejectBuysExceedingCash bool // Reject BUY orders larger than the portfolio's available cash
+    PreTradeEnforcement string   // What happens to new orders the risk engine rejects: OFF records them, HOLD holds them for approval, REJECT refuses them
+    SummaryWorkers int           // Portfolios the risk summary calculates at once
+    SummaryMaxAge  time.Duration // Stored metrics older than this are recalculated by the risk summary
+    HistorySnapshotInterval time.Duration // How often every portfolio's metrics are recorded into the risk history
+    HistoryHourlyAfter      time.Duration // Age at which snapshots are averaged into hourly rows
+    HistoryDailyAfter       time.Duration // Age at which hourly rows are averaged into daily rows
+    InterestRateCheckInterval time.Duration // How often the DV01 of portfolios holding bonds is checked; 0 disables
+    CryptoCheckInterval       time.Duration // How often the venue exposure and stablecoin pegs of portfolios holding crypto are checked; 0 disables
+    DrawdownCheckInterval     time.Duration // How often portfolios with NAV history are checked against their loss limits; 0 disables
+    FirmExposureCheckInterval time.Duration // How often the exposure to each symbol across a firm is checked against its firm-wide limit; 0 disables
+    RecalcPriceMove    float64       // Move, in percent, of a held symbol's price that queues its holders for an intraday risk recalculation; 0 disables
+    RecalcTradePercent float64       // Size of a fill, in percent of the portfolio's value with cash, that queues the portfolio for a recalculation; 0 disables
+    RecalcDebounce     time.Duration // How long a queued portfolio waits for further triggers before it is recalculated; 0 disables intraday recalculation
+    RecalcMaxDelay     time.Duration // Longest a queued portfolio waits while triggers keep arriving
+    RecalcWorkers      int           // Portfolios recalculated at once
 }
 
 type AlertConfig struct {
     CleanupDays int
     BatchSize   int
+    EscalationCheckInterval time.Duration // How often unacknowledged alerts are checked against escalation policies
 }
 
+type ComplianceConfig struct {
+    RuleEvaluationInterval time.Duration
+    KYCExpiryCheckInterval time.Duration // How often verified KYC profiles are checked for lapsed reviews and documents
+    AMLSweepInterval       time.Duration // How often recent transactions are re-run through transaction monitoring; 0 disables
+    AMLSweepDays           int           // Days of transactions a sweep re-evaluates unless the request says otherwise
+}
+
+// MonitoringConfig configures the transaction monitoring pipeline of the AML checks. Rules names
+// the rules to run, all of them when empty. AnomalyScorer is "none" or "http"; the http scorer
+// posts each transaction's features to AnomalyURL and flags scores at or above AnomalyThreshold.
+type MonitoringConfig struct {
+    Rules            []string
+    HistoryWindow    time.Duration // Portfolio history features are computed over
+    LargeAmount      float64       // Reporting threshold for large transactions and structuring
+    VelocityLimit    int           // Transactions allowed in 24 hours
+    StructuringCount int           // Transactions just below LargeAmount in 24 hours that suggest structuring
+    OutlierZScore    float64       // Amount z-score against the portfolio's history that counts as an outlier
+    ReviewScore      int           // Monitoring score at which a transaction requires review
+    AnomalyScorer    string
+    AnomalyURL       string
+    AnomalyThreshold float64
+    AnomalyTimeout   time.Duration
+}
+
+type NotificationConfig struct {
+    SMTPHost       string
+    SMTPPort       string
+    SMTPUsername   string
+    SMTPPassword   string
+    SMTPFrom       string
+    MaxAttempts    int
+    RetryBaseDelay time.Duration
+    HTTPTimeout    time.Duration
+    DigestHour     int // UTC hour the daily notification digests are emailed at
+}
+
+// PriceFeedConfig selects where market prices come from. Source is "simulated", "http" or "none";
+// only one instance per deployment should ingest prices. The order books of the same symbols are
+// snapshotted every DepthInterval, from DepthURL for the http source.
+type PriceFeedConfig struct {
+    Source        string
+    URL           string
+    APIKey        string
+    Symbols       []string // Empty means the symbols currently held in positions
+    PollInterval  time.Duration
+    BatchInterval time.Duration
+    PriceTTL      time.Duration
+    DepthURL      string
+    DepthInterval time.Duration // Zero disables order book snapshots
+    DepthLevels   int           // Price levels kept per side
+}
+
+// IdempotencyConfig sets how long a response is replayed for a repeated Idempotency-Key
+type IdempotencyConfig struct {
+    Window time.Duration
+}
+
+// FXConfig selects where exchange rates come from. Source is "static" or "http"; rates are
+// cached in Redis for CacheTTL.
+type FXConfig struct {
+    Source              string
+    URL                 string
+    APIKey              string
+    StaticRates         []string // CCY=rate overrides of the built-in rates, quoted per US dollar
+    CacheTTL            time.Duration
+    RevaluationInterval time.Duration
+}
+
+// MetricsConfig exposes Prometheus metrics at /metrics. When Token is set scrapers must send it as
+// a bearer token.
+type MetricsConfig struct {
+    Enabled bool
+    Token   string
+}
+
+// TracingConfig exports request traces over OTLP/HTTP. Endpoint is the collector base URL; spans
+// are posted to Endpoint/v1/traces. Headers are key=value pairs sent with every export, e.g. an
+// API key for a hosted collector.
+type TracingConfig struct {
+    Enabled     bool
+    Endpoint    string
+    Headers     []string
+    ServiceName string
+    SampleRatio float64
+}
+
+// RetentionConfig sets how long soft deleted portfolios, positions, transactions and alerts are
+// kept before the purge job removes them for good. Days of zero or less keeps them indefinitely.
+// The job also applies the retention policies every PurgeInterval.
+type RetentionConfig struct {
+    Days          int
+    PurgeInterval time.Duration
+}
+
+// ExportConfig sizes the CSV and XLSX exports. Exports of up to SyncMaxRows rows are streamed in
+// the response; larger ones run as a job whose file is kept for TTL. Exports over MaxRows are refused.
+type ExportConfig struct {
+    SyncMaxRows   int64
+    MaxRows       int64
+    TTL           time.Duration
+    PurgeInterval time.Duration
+}
+
+// JobConfig sizes the queue of background jobs such as Monte Carlo VaR and reports. Each instance
+// runs Workers jobs at a time from the shared Redis queue; a job running for longer than Timeout
+// fails. Finished jobs and their results are kept for TTL. Every SweepInterval, jobs whose queue
+// entry was lost are queued again and jobs whose worker stopped are failed.
+type JobConfig struct {
+    Workers       int // 0 stops this instance from running jobs
+    Timeout       time.Duration
+    TTL           time.Duration
+    SweepInterval time.Duration
+}
+
+// CacheConfig sets up the Redis read-through cache of risk metrics, portfolio summaries and alert
+// counts. Writes invalidate entries; TTL bounds how stale an entry racing a write can get.
+// Calculated VaR and liquidity are cached per portfolio for RiskTTL, invalidated by trades,
+// threshold changes and moves of a held symbol's price of RiskPriceMove percent or more.
+type CacheConfig struct {
+    Enabled bool
+    TTL     time.Duration
+    RiskTTL       time.Duration // 0 disables caching of risk calculations
+    RiskPriceMove float64
+}
+
+// GRPCConfig serves the gRPC API for internal integrations over cleartext HTTP/2 on its own port
+type GRPCConfig struct {
+    Enabled bool
+    Port    string
+}
+
+// KafkaConfig streams transactions in from and alerts and risk updates out to a Kafka cluster.
+// Topics are TopicPrefix followed by the topic name. Format is json or avro; avro registers and
+// resolves schemas with the Confluent schema registry at SchemaRegistryURL. Consumed transactions
+// are imported on behalf of IngestUserID, whose portfolio access applies to them.
+type KafkaConfig struct {
+    Enabled           bool
+    Brokers           []string
+    ClientID          string
+    TLS               bool
+    SASLMechanism     string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512; empty disables SASL
+    SASLUsername      string
+    SASLPassword      string
+    TopicPrefix       string
+    TransactionsTopic string
+    AlertsTopic       string
+    RiskUpdatesTopic  string
+    Format            string // json or avro
+    SchemaRegistryURL string
+    Compression       string // none, gzip, snappy or zstd
+    ConsumerGroup     string
+    StartOffset       string // earliest or latest, for partitions the group has no committed offset for
+    IngestUserID      string // Empty disables the transaction consumer
+}
+
+// MockConfig makes the development mock data generator reproducible. A non-zero Seed fixes its
+// random choices; ScenarioFile is a demo scenario (YAML) whose profile, prices and seed it uses,
+// with Seed taking precedence over the scenario's.
+type MockConfig struct {
+    Seed         int64
+    ScenarioFile string
+}
+
+// ReconciliationConfig sets where custodian position files come from and how closely they must
+// match. Source is http to fetch from URL every day at FetchHour (UTC), or empty to reconcile only
+// uploaded files; files dropped into InboxDir, e.g. by an SFTP transfer, are reconciled every
+// PollInterval either way. PriceTolerance is a fraction of our price.
+type ReconciliationConfig struct {
+    Source            string
+    URL               string
+    APIKey            string
+    FetchHour         int
+    InboxDir          string
+    PollInterval      time.Duration
+    QuantityTolerance float64
+    PriceTolerance    float64
+}
+
+// BatchConfig schedules the end-of-day batch, which runs every day at EODHour (UTC) when enabled
+// and in place of the separate nightly liquidity classification and NAV snapshot jobs. A failed
+// job is retried JobRetries times, waiting RetryDelay and then twice as long each time.
+type BatchConfig struct {
+    EODEnabled   bool
+    EODHour      int
+    JobRetries   int
+    RetryDelay   time.Duration
+    JobTimeout   time.Duration
+    ReportFormat string // Format of the daily risk reports: pdf or csv
+}
+
+// TenancyConfig holds the master key wrapping each tenant's data key for PII encryption: 32 bytes,
+// base64 encoded. Without it tenants cannot turn PII encryption on.
+type TenancyConfig struct {
+    MasterKey string
+}
+
+// Load reads the configuration from the environment, .env.<APP_ENV> and .env, in that order of
+// precedence. Defaults that differ by environment, such as CORS origins and security headers, are
+// stricter in production.
 func Load() (*Config, error) {
-    err := godotenv.Load()
-    if err != nil {
-        log.Printf("Warning: .env file not found")
-    }
+    appEnv := loadEnvFiles()
+    production := appEnv == "production"
+    development := appEnv == "development"
 
-    return &Config{
+    cfg := &Config{
         App: AppConfig{
-            Env:  getEnv("APP_ENV", "development"),
-            Port: getEnv("APP_PORT", "8080"),
-            Name: getEnv("APP_NAME", "Financial Risk Monitor"),
+            Env:             appEnv,
+            Port:            getEnv("APP_PORT", "8080"),
+            Name:            getEnv("APP_NAME", "Financial Risk Monitor"),
+            ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", "30s"),
+        },
+        CORS: CORSConfig{
+            AllowOrigins:     getEnvAsListOr("CORS_ALLOW_ORIGINS", profileDefault(production, "", "http://localhost:3000")),
+            AllowMethods:     getEnvAsListOr("CORS_ALLOW_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
+            AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
+            MaxAge:           getEnvAsDuration("CORS_MAX_AGE", "0s"),
+        },
+        Security: SecurityHeadersConfig{
+            Enabled:               getEnvAsBool("SECURITY_HEADERS_ENABLED", true),
+            HSTSMaxAge:            getEnvAsDuration("HSTS_MAX_AGE", profileDefault(production, "8760h", "0s")),
+            HSTSIncludeSubdomains: getEnvAsBool("HSTS_INCLUDE_SUBDOMAINS", production),
+            ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", profileDefault(production,
+                "default-src 'none'; frame-ancestors 'none'",
+                "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self' ws: wss:; frame-ancestors 'none'")),
+            FrameOptions:   getEnv("X_FRAME_OPTIONS", "DENY"),
+            ReferrerPolicy: getEnv("REFERRER_POLICY", "no-referrer"),
+        },
+        Log: LogConfig{
+            Level:  getEnv("LOG_LEVEL", "info"),
+            Format: getEnv("LOG_FORMAT", "json"),
         },
         Database: DatabaseConfig{
             Host:     getEnv("DB_HOST", "localhost"),
@@ -82,32 +413,255 @@ func Load() (*Config, error) {
             Password: getEnv("DB_PASSWORD", ""),
             DBName:   getEnv("DB_NAME", "financial_risk_db"),
             SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+            MigrateOnStart: getEnvAsBool("DB_MIGRATE_ON_START", false),
+            MaxOpenConns:       getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+            MaxIdleConns:       getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
+            ConnMaxLifetime:    getEnvAsDuration("DB_CONN_MAX_LIFETIME", "30m"),
+            ConnMaxIdleTime:    getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", "5m"),
+            LogLevel:           getEnv("DB_LOG_LEVEL", profileDefault(development, "info", "warn")),
+            SlowQueryThreshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", "200ms"),
+            LogParameters:      getEnvAsBool("DB_LOG_PARAMETERS", development),
+            Timescale:              getEnvAsBool("DB_TIMESCALE_ENABLED", false),
+            TimescaleChunkInterval: getEnvAsDuration("DB_TIMESCALE_CHUNK_INTERVAL", "168h"),
+            TimescaleCompressAfter: getEnvAsDuration("DB_TIMESCALE_COMPRESS_AFTER", "720h"),
         },
         Redis: RedisConfig{
             Host:     getEnv("REDIS_HOST", "localhost"),
             Port:     getEnv("REDIS_PORT", "6379"),
             Password: getEnv("REDIS_PASSWORD", ""),
             DB:       getEnvAsInt("REDIS_DB", 0),
+            Required: getEnvAsBool("REDIS_REQUIRED", true),
+            BreakerThreshold:    getEnvAsInt("REDIS_BREAKER_THRESHOLD", 5),
+            HealthCheckInterval: getEnvAsDuration("REDIS_HEALTH_CHECK_INTERVAL", "10s"),
+            EventBufferSize:     getEnvAsInt("REDIS_EVENT_BUFFER_SIZE", 1000),
         },
         JWT: JWTConfig{
-            Secret: getEnv("JWT_SECRET", "your-secret-key"),
-            Expiry: getEnvAsDuration("JWT_EXPIRY", "24h"),
+            Secret:        getEnv("JWT_SECRET", "your-secret-key"),
+            Expiry:        getEnvAsDuration("JWT_EXPIRY", "15m"),
+            RefreshExpiry: getEnvAsDuration("JWT_REFRESH_EXPIRY", "168h"),
+        },
+        APIKey: APIKeyConfig{
+            DefaultRateLimit: getEnvAsInt("API_KEY_DEFAULT_RATE_LIMIT", 600),
         },
         WS: WebSocketConfig{
             ReadBufferSize:  getEnvAsInt("WS_READ_BUFFER_SIZE", 1024),
             WriteBufferSize: getEnvAsInt("WS_WRITE_BUFFER_SIZE", 1024),
+            SendQueueSize:    getEnvAsInt("WS_SEND_QUEUE_SIZE", 256),
+            WriteTimeout:     getEnvAsDuration("WS_WRITE_TIMEOUT", "10s"),
+            SlowClientPolicy: getEnv("WS_SLOW_CLIENT_POLICY", SlowClientDisconnect),
+            PingInterval:     getEnvAsDuration("WS_PING_INTERVAL", "30s"),
+            PongTimeout:      getEnvAsDuration("WS_PONG_TIMEOUT", "75s"),
         },
         Risk: RiskConfig{
             VARConfidenceLevel:   getEnvAsFloat("VAR_CONFIDENCE_LEVEL", 0.95),
             VARTimeHorizon:       getEnvAsInt("VAR_TIME_HORIZON", 1),
+            VaRTimeout:            getEnvAsDuration("VAR_TIMEOUT", "10s"),
+            MonteCarloSimulations: getEnvAsInt("VAR_MONTE_CARLO_SIMULATIONS", 10000),
+            MonteCarloWorkers:     getEnvAsInt("VAR_MONTE_CARLO_WORKERS", 0),
+            MonteCarloSeed:        getEnvAsInt("VAR_MONTE_CARLO_SEED", 0),
             LiquidityThreshold:   getEnvAsFloat("LIQUIDITY_THRESHOLD", 0.3),
             PositionLimitPercent: getEnvAsFloat("POSITION_LIMIT_PERCENT", 25.0),
+            LeverageCheckInterval: getEnvAsDuration("LEVERAGE_CHECK_INTERVAL", "5m"),
+            LiquidityClassificationHour: getEnvAsInt("LIQUIDITY_CLASSIFICATION_HOUR", 2),
+            NAVSnapshotHour: getEnvAsInt("NAV_SNAPSHOT_HOUR", 22),
+            RejectBuysExceedingCash: getEnvAsBool("REJECT_BUYS_EXCEEDING_CASH", true),
+            PreTradeEnforcement: strings.ToUpper(getEnv("PRE_TRADE_ENFORCEMENT", "OFF")),
+            SummaryWorkers: getEnvAsInt("RISK_SUMMARY_WORKERS", 4),
+            SummaryMaxAge:  getEnvAsDuration("RISK_SUMMARY_MAX_AGE", "15m"),
+            HistorySnapshotInterval: getEnvAsDuration("RISK_HISTORY_INTERVAL", "15m"),
+            HistoryHourlyAfter:      getEnvAsDuration("RISK_HISTORY_HOURLY_AFTER", "48h"),
+            HistoryDailyAfter:       getEnvAsDuration("RISK_HISTORY_DAILY_AFTER", "2160h"),
+            InterestRateCheckInterval: getEnvAsDuration("INTEREST_RATE_CHECK_INTERVAL", "15m"),
+            CryptoCheckInterval:       getEnvAsDuration("CRYPTO_CHECK_INTERVAL", "5m"),
+            DrawdownCheckInterval:     getEnvAsDuration("DRAWDOWN_CHECK_INTERVAL", "5m"),
+            FirmExposureCheckInterval: getEnvAsDuration("FIRM_EXPOSURE_CHECK_INTERVAL", "5m"),
+            RecalcPriceMove:    getEnvAsFloat("RISK_RECALC_PRICE_MOVE", 2.0),
+            RecalcTradePercent: getEnvAsFloat("RISK_RECALC_TRADE_PERCENT", 5.0),
+            RecalcDebounce:     getEnvAsDuration("RISK_RECALC_DEBOUNCE", "30s"),
+            RecalcMaxDelay:     getEnvAsDuration("RISK_RECALC_MAX_DELAY", "2m"),
+            RecalcWorkers:      getEnvAsInt("RISK_RECALC_WORKERS", 4),
         },
         Alert: AlertConfig{
             CleanupDays: getEnvAsInt("ALERT_CLEANUP_DAYS", 30),
             BatchSize:   getEnvAsInt("ALERT_BATCH_SIZE", 100),
+            EscalationCheckInterval: getEnvAsDuration("ALERT_ESCALATION_INTERVAL", "1m"),
+        },
+        Compliance: ComplianceConfig{
+            RuleEvaluationInterval: getEnvAsDuration("COMPLIANCE_RULE_INTERVAL", "5m"),
+            KYCExpiryCheckInterval: getEnvAsDuration("KYC_EXPIRY_INTERVAL", "1h"),
+            AMLSweepInterval:       getEnvAsDuration("AML_SWEEP_INTERVAL", "24h"),
+            AMLSweepDays:           getEnvAsInt("AML_SWEEP_DAYS", 30),
+        },
+        Monitoring: MonitoringConfig{
+            Rules:            getEnvAsList("MONITORING_RULES"),
+            HistoryWindow:    getEnvAsDuration("MONITORING_HISTORY_WINDOW", "2160h"),
+            LargeAmount:      getEnvAsFloat("MONITORING_LARGE_AMOUNT", 10000),
+            VelocityLimit:    getEnvAsInt("MONITORING_VELOCITY_LIMIT", 10),
+            StructuringCount: getEnvAsInt("MONITORING_STRUCTURING_COUNT", 3),
+            OutlierZScore:    getEnvAsFloat("MONITORING_OUTLIER_ZSCORE", 3),
+            ReviewScore:      getEnvAsInt("MONITORING_REVIEW_SCORE", 50),
+            AnomalyScorer:    getEnv("MONITORING_ANOMALY_SCORER", "none"),
+            AnomalyURL:       getEnv("MONITORING_ANOMALY_URL", ""),
+            AnomalyThreshold: getEnvAsFloat("MONITORING_ANOMALY_THRESHOLD", 0.8),
+            AnomalyTimeout:   getEnvAsDuration("MONITORING_ANOMALY_TIMEOUT", "2s"),
+        },
+        Notification: NotificationConfig{
+            SMTPHost:       getEnv("SMTP_HOST", ""),
+            SMTPPort:       getEnv("SMTP_PORT", "587"),
+            SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+            SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+            SMTPFrom:       getEnv("SMTP_FROM", "alerts@riskmonitor.local"),
+            MaxAttempts:    getEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+            RetryBaseDelay: getEnvAsDuration("NOTIFICATION_RETRY_BASE_DELAY", "30s"),
+            HTTPTimeout:    getEnvAsDuration("NOTIFICATION_HTTP_TIMEOUT", "10s"),
+            DigestHour:     getEnvAsInt("NOTIFICATION_DIGEST_HOUR", 7),
+        },
+        PriceFeed: PriceFeedConfig{
+            Source:        getEnv("PRICE_FEED_SOURCE", "simulated"),
+            URL:           getEnv("PRICE_FEED_URL", ""),
+            APIKey:        getEnv("PRICE_FEED_API_KEY", ""),
+            Symbols:       getEnvAsList("PRICE_FEED_SYMBOLS"),
+            PollInterval:  getEnvAsDuration("PRICE_FEED_POLL_INTERVAL", "2s"),
+            BatchInterval: getEnvAsDuration("PRICE_BATCH_INTERVAL", "2s"),
+            PriceTTL:      getEnvAsDuration("PRICE_TTL", "5m"),
+            DepthURL:      getEnv("PRICE_FEED_DEPTH_URL", ""),
+            DepthInterval: getEnvAsDuration("MARKET_DEPTH_INTERVAL", "1m"),
+            DepthLevels:   getEnvAsInt("MARKET_DEPTH_LEVELS", 10),
+        },
+        Idempotency: IdempotencyConfig{
+            Window: getEnvAsDuration("IDEMPOTENCY_WINDOW", "24h"),
+        },
+        FX: FXConfig{
+            Source:              getEnv("FX_SOURCE", "static"),
+            URL:                 getEnv("FX_RATES_URL", ""),
+            APIKey:              getEnv("FX_API_KEY", ""),
+            StaticRates:         getEnvAsList("FX_STATIC_RATES"),
+            CacheTTL:            getEnvAsDuration("FX_CACHE_TTL", "1h"),
+            RevaluationInterval: getEnvAsDuration("FX_REVALUATION_INTERVAL", "15m"),
+        },
+        Metrics: MetricsConfig{
+            Enabled: getEnvAsBool("METRICS_ENABLED", true),
+            Token:   getEnv("METRICS_TOKEN", ""),
+        },
+        Tracing: TracingConfig{
+            Enabled:     getEnvAsBool("TRACING_ENABLED", false),
+            Endpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+            Headers:     getEnvAsList("OTEL_EXPORTER_OTLP_HEADERS"),
+            ServiceName: getEnv("OTEL_SERVICE_NAME", "financial-risk-monitor"),
+            SampleRatio: getEnvAsFloat("OTEL_TRACES_SAMPLER_ARG", 1.0),
+        },
+        Retention: RetentionConfig{
+            Days:          getEnvAsInt("SOFT_DELETE_RETENTION_DAYS", 2555),
+            PurgeInterval: getEnvAsDuration("PURGE_INTERVAL", "24h"),
+        },
+        Export: ExportConfig{
+            SyncMaxRows:   int64(getEnvAsInt("EXPORT_SYNC_MAX_ROWS", 50000)),
+            MaxRows:       int64(getEnvAsInt("EXPORT_MAX_ROWS", 1000000)),
+            TTL:           getEnvAsDuration("EXPORT_TTL", "24h"),
+            PurgeInterval: getEnvAsDuration("EXPORT_PURGE_INTERVAL", "1h"),
         },
-    }, nil
+        Jobs: JobConfig{
+            Workers:       getEnvAsInt("JOB_WORKERS", 2),
+            Timeout:       getEnvAsDuration("JOB_TIMEOUT", "15m"),
+            TTL:           getEnvAsDuration("JOB_TTL", "24h"),
+            SweepInterval: getEnvAsDuration("JOB_SWEEP_INTERVAL", "1m"),
+        },
+        Cache: CacheConfig{
+            Enabled: getEnvAsBool("CACHE_ENABLED", true),
+            TTL:     getEnvAsDuration("CACHE_TTL", "30s"),
+            RiskTTL:       getEnvAsDuration("CACHE_RISK_TTL", "5m"),
+            RiskPriceMove: getEnvAsFloat("CACHE_RISK_PRICE_MOVE", 1.0),
+        },
+        GRPC: GRPCConfig{
+            Enabled: getEnvAsBool("GRPC_ENABLED", false),
+            Port:    getEnv("GRPC_PORT", "9090"),
+        },
+        Kafka: KafkaConfig{
+            Enabled:           getEnvAsBool("KAFKA_ENABLED", false),
+            Brokers:           getEnvAsList("KAFKA_BROKERS"),
+            ClientID:          getEnv("KAFKA_CLIENT_ID", "financial-risk-monitor"),
+            TLS:               getEnvAsBool("KAFKA_TLS", false),
+            SASLMechanism:     getEnv("KAFKA_SASL_MECHANISM", ""),
+            SASLUsername:      getEnv("KAFKA_SASL_USERNAME", ""),
+            SASLPassword:      getEnv("KAFKA_SASL_PASSWORD", ""),
+            TopicPrefix:       getEnv("KAFKA_TOPIC_PREFIX", "riskmonitor."),
+            TransactionsTopic: getEnv("KAFKA_TRANSACTIONS_TOPIC", "transactions"),
+            AlertsTopic:       getEnv("KAFKA_ALERTS_TOPIC", "alerts"),
+            RiskUpdatesTopic:  getEnv("KAFKA_RISK_UPDATES_TOPIC", "risk-updates"),
+            Format:            getEnv("KAFKA_FORMAT", "json"),
+            SchemaRegistryURL: getEnv("KAFKA_SCHEMA_REGISTRY_URL", ""),
+            Compression:       getEnv("KAFKA_COMPRESSION", "none"),
+            ConsumerGroup:     getEnv("KAFKA_CONSUMER_GROUP", "financial-risk-monitor"),
+            StartOffset:       getEnv("KAFKA_START_OFFSET", "earliest"),
+            IngestUserID:      getEnv("KAFKA_INGEST_USER_ID", ""),
+        },
+        Mock: MockConfig{
+            Seed:         int64(getEnvAsInt("MOCK_SEED", 0)),
+            ScenarioFile: getEnv("MOCK_SCENARIO", ""),
+        },
+        Reconciliation: ReconciliationConfig{
+            Source:            getEnv("RECON_SOURCE", ""),
+            URL:               getEnv("RECON_URL", ""),
+            APIKey:            getEnv("RECON_API_KEY", ""),
+            FetchHour:         getEnvAsInt("RECON_FETCH_HOUR", 6),
+            InboxDir:          getEnv("RECON_INBOX_DIR", ""),
+            PollInterval:      getEnvAsDuration("RECON_POLL_INTERVAL", "5m"),
+            QuantityTolerance: getEnvAsFloat("RECON_QUANTITY_TOLERANCE", 0.0001),
+            PriceTolerance:    getEnvAsFloat("RECON_PRICE_TOLERANCE", 0.01),
+        },
+        Batch: BatchConfig{
+            EODEnabled:   getEnvAsBool("BATCH_EOD_ENABLED", true),
+            EODHour:      getEnvAsInt("BATCH_EOD_HOUR", 22),
+            JobRetries:   getEnvAsInt("BATCH_JOB_RETRIES", 2),
+            RetryDelay:   getEnvAsDuration("BATCH_RETRY_DELAY", "1m"),
+            JobTimeout:   getEnvAsDuration("BATCH_JOB_TIMEOUT", "30m"),
+            ReportFormat: getEnv("BATCH_REPORT_FORMAT", "pdf"),
+        },
+        Tenancy: TenancyConfig{
+            MasterKey: getEnv("TENANT_MASTER_KEY", ""),
+        },
+    }
+
+    if cfg.CORS.AllowCredentials {
+        for _, origin := range cfg.CORS.AllowOrigins {
+            if origin == "*" {
+                return nil, errors.New("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOW_ORIGINS=*")
+            }
+        }
+    }
+
+    return cfg, nil
+}
+
+// loadEnvFiles loads the .env.<APP_ENV> profile and then .env, and returns APP_ENV. Neither file
+// overrides variables that are already set, so the environment wins over the profile and the
+// profile over .env. APP_ENV itself may come from the environment or .env.
+func loadEnvFiles() string {
+    appEnv := os.Getenv("APP_ENV")
+    if appEnv == "" {
+        if values, err := godotenv.Read(); err == nil {
+            appEnv = values["APP_ENV"]
+        }
+    }
+    if appEnv == "" {
+        appEnv = "development"
+    }
+
+    if err := godotenv.Load(".env." + appEnv); err == nil {
+        log.Printf("Loaded .env.%s", appEnv)
+    }
+    if err := godotenv.Load(); err != nil {
+        log.Printf("Warning: .env file not found")
+    }
+    return appEnv
+}
+
+// profileDefault picks the default for production or for other environments
+func profileDefault(production bool, productionValue, otherValue string) string {
+    if production {
+        return productionValue
+    }
+    return otherValue
 }
 
 func getEnv(key, defaultValue string) string {
@@ -133,6 +687,34 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
     return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+    valueStr := getEnv(key, "")
+    if value, err := strconv.ParseBool(valueStr); err == nil {
+        return value
+    }
+    return defaultValue
+}
+
+func getEnvAsList(key string) []string {
+    return splitList(getEnv(key, ""))
+}
+
+func splitList(list string) []string {
+    var values []string
+    for _, value := range strings.Split(list, ",") {
+        if value = strings.TrimSpace(value); value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// getEnvAsListOr reads a comma-separated list, using the comma-separated default when the
+// variable is unset
+func getEnvAsListOr(key, defaultValue string) []string {
+    return splitList(getEnv(key, defaultValue))
+}
+
 func getEnvAsDuration(key string, defaultValue string) time.Duration {
     valueStr := getEnv(key, defaultValue)
     if value, err := time.ParseDuration(valueStr); err == nil {
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// eventBufferSize is the number of recent events kept per replayable channel
+var eventBufferSize = 1000
+
+// replayableChannels are the pub/sub channels whose events are numbered and buffered so that
+// WebSocket clients can recover the events they missed while disconnected
+var replayableChannels = map[string]bool{
+	AlertsChannel:      true,
+	RiskUpdatesChannel: true,
+}
+
+// publishEvent numbers an event, appends it to the channel's stream, trimmed to roughly the
+// buffer size, and publishes it wrapped with its sequence number. Stream IDs are 0-<seq> so that
+// a replay can start at any sequence number.
+var publishEvent = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[2], '0-' .. seq, 'payload', ARGV[1])
+redis.call('PUBLISH', ARGV[3], '{"seq":' .. seq .. ',"event":' .. ARGV[1] .. '}')
+return seq
+`)
+
+// Event is a buffered event of a replayable channel
+type Event struct {
+	Seq     int64
+	Payload string
+}
+
+// IsReplayable reports whether a channel's events are published with sequence numbers
+func IsReplayable(channel string) bool {
+	return replayableChannels[channel]
+}
+
+func eventSeqKey(channel string) string {
+	return "events:seq:" + channel
+}
+
+func eventStreamKey(channel string) string {
+	return "events:" + channel
+}
+
+// PublishEvent publishes a JSON object to a pub/sub channel. Events of replayable channels are
+// numbered and buffered first, and published as {"seq": N, "event": payload}.
+func PublishEvent(ctx context.Context, channel string, payload []byte) error {
+	if RedisClient == nil {
+		return ErrRedisUnavailable
+	}
+	if !IsReplayable(channel) {
+		return RedisClient.Publish(ctx, channel, payload).Err()
+	}
+	keys := []string{eventSeqKey(channel), eventStreamKey(channel)}
+	return publishEvent.Run(ctx, RedisClient, keys, payload, eventBufferSize, channel).Err()
+}
+
+// ReplayEvents returns up to limit buffered events of a replayable channel numbered after
+// afterSeq, the latest sequence number published, and whether events after afterSeq have already
+// been trimmed from the buffer
+func ReplayEvents(ctx context.Context, channel string, afterSeq int64, limit int) ([]Event, int64, bool, error) {
+	if !IsReplayable(channel) {
+		return nil, 0, false, fmt.Errorf("channel %s is not replayable", channel)
+	}
+	if RedisClient == nil {
+		return nil, 0, false, ErrRedisUnavailable
+	}
+
+	latest, err := RedisClient.Get(ctx, eventSeqKey(channel)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, false, err
+	}
+	if afterSeq >= latest {
+		return nil, latest, false, nil
+	}
+
+	entries, err := RedisClient.XRangeN(ctx, eventStreamKey(channel), "0-"+strconv.FormatInt(afterSeq+1, 10), "+", int64(limit)).Result()
+	if err != nil {
+		return nil, latest, false, err
+	}
+
+	events := make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		seq, err := strconv.ParseInt(strings.TrimPrefix(entry.ID, "0-"), 10, 64)
+		if err != nil {
+			continue
+		}
+		payload, _ := entry.Values["payload"].(string)
+		events = append(events, Event{Seq: seq, Payload: payload})
+	}
+
+	truncated := len(events) == 0 || events[0].Seq > afterSeq+1
+	return events, latest, truncated, nil
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/db/migrations"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// migrationLockID is the Postgres advisory lock held while a migration runs, so that instances
+// starting together apply each migration once
+const migrationLockID = 7_311_402_558
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change read from a NNN_name.up.sql and NNN_name.down.sql pair
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaVersionError is returned when the database schema is not at the version this build expects
+type SchemaVersionError struct {
+	Current  uint
+	Expected uint
+	Dirty    bool
+}
+
+func (e *SchemaVersionError) Error() string {
+	switch {
+	case e.Dirty:
+		return fmt.Sprintf("database schema is dirty at version %d: a migration failed part way; repair it and run `migrate force %d`",
+			e.Current, e.Current)
+	case e.Current > e.Expected:
+		return fmt.Sprintf("database schema is at version %d, newer than version %d this build expects", e.Current, e.Expected)
+	}
+	return fmt.Sprintf("database schema is at version %d but this build expects version %d: run `migrate up`",
+		e.Current, e.Expected)
+}
+
+// LoadMigrations reads the migration pairs in a directory, ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[uint(version)]
+		if migration == nil {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		list = append(list, *migration)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Migrator applies the embedded migrations and tracks the schema version in schema_migrations,
+// the same table the golang-migrate CLI uses, so either can be pointed at a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+func NewMigrator(gormDB *gorm.DB) (*Migrator, error) {
+	db, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	list, err := LoadMigrations(migrations.Files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: list,
+		logger:     logging.Component("migrate"),
+	}, nil
+}
+
+// Latest is the schema version this build expects
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the database's schema version, 0 when no migration has run, and whether the
+// last migration failed part way
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, false, err
+	}
+	return readVersion(ctx, m.db)
+}
+
+// Verify checks the database schema is clean and at the version this build expects
+func (m *Migrator) Verify(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty || version != m.Latest() {
+		return &SchemaVersionError{Current: version, Expected: m.Latest(), Dirty: dirty}
+	}
+	return nil
+}
+
+// Up applies every pending migration in order and returns how many were applied. Each runs in
+// its own transaction together with the version update.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	for _, migration := range m.migrations {
+		ran, err := m.step(ctx, migration.Version-1, migration.Version, migration.Up)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			m.logger.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+			applied++
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back the latest steps migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+		migration := m.migrations[i]
+		previous := uint(0)
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+
+		version, dirty, err := m.Version(ctx)
+		if err != nil {
+			return rolledBack, err
+		}
+		if dirty {
+			return rolledBack, &SchemaVersionError{Current: version, Expected: m.Latest(), Dirty: true}
+		}
+		if version < migration.Version {
+			continue
+		}
+		if migration.Down == "" {
+			return rolledBack, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+
+		if _, err := m.step(ctx, migration.Version, previous, migration.Down); err != nil {
+			return rolledBack, fmt.Errorf("rolling back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		m.logger.InfoContext(ctx, "Rolled back migration", "version", migration.Version, "name", migration.Name)
+		rolledBack++
+	}
+	return rolledBack, nil
+}
+
+// Force sets the schema version without running any migration and clears the dirty flag, for
+// adopting a database created before versioned migrations or one repaired by hand
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// step runs a migration script when the schema is at version from and moves it to version to,
+// reporting whether it ran. The advisory lock makes a concurrent runner wait and then skip it.
+func (m *Migrator) step(ctx context.Context, from, to uint, script string) (bool, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return false, err
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return false, err
+	}
+	version, dirty, err := readVersion(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	if dirty {
+		return false, &SchemaVersionError{Current: version, Expected: m.Latest(), Dirty: true}
+	}
+	if version != from {
+		if to > from && version >= to {
+			return false, nil
+		}
+		return false, fmt.Errorf("database schema is at version %d, expected %d", version, from)
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return false, err
+	}
+	if err := writeVersion(ctx, tx, to); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	return err
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func readVersion(ctx context.Context, db queryer) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+// writeVersion records the schema version; version 0 is an empty schema and has no row
+func writeVersion(ctx context.Context, tx *sql.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", int64(version))
+	return err
+}
@@ -1,51 +1,88 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
-	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
 )
 
 var DB *gorm.DB
 
+// InitPostgres connects to the database and checks its schema is at the version this build
+// expects, first applying pending migrations when MigrateOnStart is set, and then sets up the
+// TimescaleDB hypertables when enabled
 func InitPostgres(cfg *config.DatabaseConfig) error {
+	if err := Connect(cfg); err != nil {
+		return err
+	}
+
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	ctx := context.Background()
+	if cfg.MigrateOnStart {
+		if _, err := migrator.Up(ctx); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+	if err := migrator.Verify(ctx); err != nil {
+		return err
+	}
+	if err := InitTimescale(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to set up TimescaleDB: %w", err)
+	}
+
+	logging.Component("database").Info("Database connected", "schema_version", migrator.Latest())
+	return nil
+}
+
+// Connect opens the database connection pool without touching the schema, for the migrate command
+func Connect(cfg *config.DatabaseConfig) error {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
 		cfg.Host, cfg.User, cfg.Password, cfg.DBName, cfg.Port, cfg.SSLMode)
 
-	var err error
+	sqlLogger, err := newSQLLogger(cfg)
+	if err != nil {
+		return err
+	}
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: sqlLogger,
 	})
-
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto migrate models
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Portfolio{},
-		&models.Position{},
-		&models.Transaction{},
-		&models.RiskMetric{},
-		&models.RiskHistory{},
-		&models.Alert{},
-	)
-
+	sqlDB, err := DB.DB()
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		return err
 	}
-
-	log.Println("Database connected and migrated successfully")
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	return nil
 }
 
+// PingPostgres checks the database connection is alive
+func PingPostgres(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func GetDB() *gorm.DB {
 	return DB
 }
@@ -2,33 +2,125 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
 )
 
-var RedisClient *redis.Client
+var (
+	RedisClient *redis.Client
 
+	breaker       *redisBreaker
+	redisRequired = true
+)
+
+// Pub/sub channels relayed to WebSocket clients
+const (
+	AlertsChannel      = "alerts_channel"
+	RiskUpdatesChannel = "risk_updates"
+	PricesChannel      = "price_updates"
+	OrdersChannel      = "order_updates"
+	JobsChannel        = "job_updates"
+)
+
+// RedisStatus describes the Redis connection for health checks
+type RedisStatus struct {
+	Status              string    `json:"status"` // up, down
+	Required            bool      `json:"required"`
+	Since               time.Time `json:"since"` // When the status last changed
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// InitRedis connects to Redis behind a circuit breaker. When Redis is unreachable it returns an
+// error only if it is required; otherwise the server starts with the circuit open and the health
+// check reconnects once Redis is back.
 func InitRedis(cfg *config.RedisConfig) error {
 	RedisClient = redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	})
+	breaker = newRedisBreaker(cfg.BreakerThreshold)
+	redisRequired = cfg.Required
+	if cfg.EventBufferSize > 0 {
+		eventBufferSize = cfg.EventBufferSize
+	}
+	RedisClient.AddHook(breaker)
 
-	ctx := context.Background()
-	_, err := RedisClient.Ping(ctx).Result()
-	if err != nil {
-		return fmt.Errorf("failed to connect to Redis: %w", err)
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), breakerProbeKey{}, true), 5*time.Second)
+	defer cancel()
+	if err := RedisClient.Ping(ctx).Err(); err != nil {
+		if cfg.Required {
+			return fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		breaker.fail(err)
+	} else {
+		logging.Component("redis").Info("Redis connected", "addr", RedisClient.Options().Addr)
 	}
 
-	log.Println("Redis connected successfully")
+	go monitorRedis(cfg.HealthCheckInterval)
 	return nil
 }
 
+// monitorRedis pings Redis at a fixed interval, opening the circuit when it stops answering and
+// closing it when it answers again
+func monitorRedis(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), breakerProbeKey{}, true), interval)
+		err := RedisClient.Ping(ctx).Err()
+		cancel()
+		if err != nil {
+			breaker.fail(err)
+		} else {
+			breaker.succeed()
+		}
+	}
+}
+
+// GetRedisStatus reports whether Redis is reachable
+func GetRedisStatus() RedisStatus {
+	if breaker == nil {
+		return RedisStatus{Status: "down", Required: redisRequired, LastError: "not initialized"}
+	}
+	status := breaker.status()
+	status.Required = redisRequired
+	return status
+}
+
+// RedisAvailable reports whether commands are currently being sent to Redis
+func RedisAvailable() bool {
+	return breaker != nil && !breaker.isOpen()
+}
+
+// IsRedisDegraded reports whether err is Redis being unavailable while the server runs without
+// requiring it, in which case callers should carry on without Redis
+func IsRedisDegraded(err error) bool {
+	return !redisRequired && (errors.Is(err, ErrRedisUnavailable) || isConnectionError(err))
+}
+
+// PriceKey is the Redis key holding the latest price of a symbol
+func PriceKey(symbol string) string {
+	return "price:" + symbol
+}
+
+// FXRateKey is the Redis key holding the cached exchange rate of a currency against the US dollar
+func FXRateKey(currency string) string {
+	return "fx:rate:" + currency
+}
+
 func GetRedis() *redis.Client {
 	return RedisClient
 }
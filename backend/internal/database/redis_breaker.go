@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// ErrRedisUnavailable is returned without contacting Redis while the circuit breaker is open
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
+// breakerProbeKey marks the health check context, which is let through an open circuit
+type breakerProbeKey struct{}
+
+// redisBreaker is a go-redis hook that stops sending commands to Redis after repeated connection
+// failures, so callers fail immediately instead of each waiting out a dial timeout. The circuit
+// stays open until a health check ping succeeds.
+type redisBreaker struct {
+	threshold int
+
+	mu        sync.RWMutex
+	open      bool
+	failures  int
+	lastError string
+	changedAt time.Time
+}
+
+func newRedisBreaker(threshold int) *redisBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &redisBreaker{threshold: threshold, changedAt: time.Now()}
+}
+
+func (b *redisBreaker) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (b *redisBreaker) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		probe := ctx.Value(breakerProbeKey{}) != nil
+		if b.isOpen() && !probe {
+			cmd.SetErr(ErrRedisUnavailable)
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmd)
+		if !probe {
+			// Health checks update the circuit themselves
+			b.record(err)
+		}
+		return err
+	}
+}
+
+func (b *redisBreaker) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		probe := ctx.Value(breakerProbeKey{}) != nil
+		if b.isOpen() && !probe {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrRedisUnavailable)
+			}
+			return ErrRedisUnavailable
+		}
+		err := next(ctx, cmds)
+		if !probe {
+			b.record(err)
+		}
+		return err
+	}
+}
+
+func (b *redisBreaker) isOpen() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.open
+}
+
+// record counts connection failures; replies from Redis, including misses, prove it is up
+func (b *redisBreaker) record(err error) {
+	if isConnectionError(err) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.failures++
+		b.lastError = err.Error()
+		if !b.open && b.failures >= b.threshold {
+			b.trip()
+		}
+		return
+	}
+
+	if err == nil || isRedisReply(err) {
+		b.succeed()
+	}
+}
+
+func (b *redisBreaker) succeed() {
+	b.mu.RLock()
+	clean := !b.open && b.failures == 0
+	b.mu.RUnlock()
+	if clean {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if b.open {
+		b.open = false
+		b.changedAt = time.Now()
+		logging.Component("redis").Info("Redis reachable again; caching and pub/sub resumed")
+	}
+}
+
+// fail opens the circuit at once, for a failed health check
+func (b *redisBreaker) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastError = err.Error()
+	if !b.open {
+		b.trip()
+	}
+}
+
+// trip opens the circuit; callers hold the lock
+func (b *redisBreaker) trip() {
+	b.open = true
+	b.changedAt = time.Now()
+	logging.Component("redis").Warn("Redis unavailable; caching and pub/sub are disabled until it recovers", "error", b.lastError)
+}
+
+func (b *redisBreaker) status() RedisStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	status := RedisStatus{
+		Status:              "up",
+		Since:               b.changedAt,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.open {
+		status.Status = "down"
+	}
+	return status
+}
+
+// isConnectionError reports whether err means Redis could not be reached, as opposed to a reply
+// (redis.Nil included) or the caller giving up
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || isRedisReply(err) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, redis.ErrClosed) || errors.Is(err, redis.ErrPoolTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func isRedisReply(err error) bool {
+	var reply redis.Error
+	return errors.As(err, &reply)
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// handlerPackage is the package whose functions are reported as the handler behind a query
+const handlerPackage = "/internal/handlers."
+
+// sqlLogger logs GORM's statements through the application's logger, so that they carry the
+// request ID and are written in the configured format. Statements slower than the threshold are
+// logged as warnings with the caller and handler that ran them, whatever the log level.
+type sqlLogger struct {
+	logger        *slog.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+	parameters    bool
+}
+
+func newSQLLogger(cfg *config.DatabaseConfig) (*sqlLogger, error) {
+	level, err := parseSQLLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlLogger{
+		logger:        logging.Component("sql"),
+		level:         level,
+		slowThreshold: cfg.SlowQueryThreshold,
+		parameters:    cfg.LogParameters,
+	}, nil
+}
+
+// parseSQLLogLevel reads DB_LOG_LEVEL
+func parseSQLLogLevel(value string) (logger.LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn", "warning":
+		return logger.Warn, nil
+	case "info", "":
+		return logger.Info, nil
+	}
+	return 0, fmt.Errorf("invalid DB_LOG_LEVEL %q: must be silent, error, warn or info", value)
+}
+
+func (l *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *sqlLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *sqlLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *sqlLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace logs a statement once it has run. Record not found is how lookups report a miss, so it is
+// not logged as an error.
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.ErrorContext(ctx, "Query failed", l.attrs(sql, rows, elapsed, "error", err.Error())...)
+	case slow && l.level > logger.Silent:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "Slow query",
+			l.attrs(sql, rows, elapsed, "threshold_ms", l.slowThreshold.Milliseconds(), "handler", callingHandler())...)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.logger.InfoContext(ctx, "Query", l.attrs(sql, rows, elapsed)...)
+	}
+}
+
+// ParamsFilter leaves the values out of logged statements unless LogParameters is set, as they
+// may hold personal data
+func (l *sqlLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if !l.parameters {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func (l *sqlLogger) attrs(sql string, rows int64, elapsed time.Duration, extra ...any) []any {
+	attrs := []any{
+		"sql", sql,
+		"rows", rows,
+		"duration_ms", float64(elapsed.Microseconds()) / 1000,
+		"caller", utils.FileWithLineNum(),
+	}
+	return append(attrs, extra...)
+}
+
+// callingHandler returns the HTTP handler on the stack that ran a statement, or an empty string
+// for statements run by workers and startup
+func callingHandler() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if i := strings.Index(frame.Function, handlerPackage); i >= 0 {
+			return strings.NewReplacer("(*", "", ")", "").Replace(frame.Function[i+len(handlerPackage):])
+		}
+		if !more {
+			return ""
+		}
+	}
+}
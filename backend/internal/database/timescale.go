@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// timescaleLockID is the Postgres advisory lock held while the TimescaleDB objects are set up, so
+// that instances starting together convert each table once
+const timescaleLockID = 7_311_402_559
+
+// ErrTimescaleUnavailable is returned when DB_TIMESCALE_ENABLED is set on a server without the
+// timescaledb extension
+var ErrTimescaleUnavailable = errors.New("DB_TIMESCALE_ENABLED is set but the timescaledb extension is not available on the server")
+
+// Continuous aggregates of the risk history, kept by TimescaleDB in place of downsampling
+const (
+	RiskHistoryHourlyView = "risk_history_hourly"
+	RiskHistoryDailyView  = "risk_history_daily"
+)
+
+// Hypertable is a time-series table partitioned into chunks by TimescaleDB
+type Hypertable struct {
+	Table      string
+	TimeColumn string
+	SegmentBy  string // Columns compressed chunks are grouped by, those queries filter on
+	// Prepare readies the table for conversion: TimescaleDB requires the time column in every
+	// unique index and to be NOT NULL
+	Prepare []string
+}
+
+// ContinuousAggregate is a materialized view over a hypertable that TimescaleDB refreshes
+type ContinuousAggregate struct {
+	View      string
+	Query     string
+	Refresh   string // How often the view is refreshed
+	Lookback  string // How far back each refresh looks, for late rows
+	LagBehind string // Newest period left to the real-time part of the view
+}
+
+var hypertables = []Hypertable{
+	{
+		Table:      "risk_histories",
+		TimeColumn: "recorded_at",
+		SegmentBy:  "portfolio_id, metric_type",
+		Prepare: []string{
+			"ALTER TABLE risk_histories ALTER COLUMN recorded_at SET NOT NULL",
+			"ALTER TABLE risk_histories DROP CONSTRAINT IF EXISTS risk_histories_pkey",
+			"ALTER TABLE risk_histories ADD PRIMARY KEY (id, recorded_at)",
+		},
+	},
+	{
+		Table:      "crypto_price_samples",
+		TimeColumn: "hour",
+		SegmentBy:  "symbol",
+	},
+}
+
+var continuousAggregates = []ContinuousAggregate{
+	{
+		View:      RiskHistoryHourlyView,
+		Query:     riskHistoryBuckets("1 hour"),
+		Refresh:   "30 minutes",
+		Lookback:  "3 days",
+		LagBehind: "1 hour",
+	},
+	{
+		View:      RiskHistoryDailyView,
+		Query:     riskHistoryBuckets("1 day"),
+		Refresh:   "1 hour",
+		Lookback:  "7 days",
+		LagBehind: "1 day",
+	},
+}
+
+// riskHistoryBuckets aggregates the risk history per portfolio and metric into buckets of a
+// width, with the columns RiskHistoryService.Aggregate reads
+func riskHistoryBuckets(width string) string {
+	return `SELECT portfolio_id, metric_type, time_bucket(INTERVAL '` + width + `', recorded_at) AS bucket,
+			MAX(value) AS max_value, AVG(value) AS avg_value, last(value, recorded_at) AS last_value,
+			COUNT(*) AS samples
+		FROM risk_histories
+		GROUP BY portfolio_id, metric_type, bucket`
+}
+
+var timescaleEnabled atomic.Bool
+
+// TimescaleEnabled reports whether the time-series tables are hypertables with their continuous
+// aggregates in place
+func TimescaleEnabled() bool {
+	return timescaleEnabled.Load()
+}
+
+// InitTimescale turns the time-series tables into hypertables, sets up their compression and
+// creates the continuous aggregates of the risk history. Every step is skipped when already done,
+// so it runs at each start; it does nothing unless DB_TIMESCALE_ENABLED is set.
+func InitTimescale(ctx context.Context, cfg *config.DatabaseConfig) error {
+	if !cfg.Timescale {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	// Session advisory locks and continuous aggregates, which cannot be created in a
+	// transaction, need the statements on one connection
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var available bool
+	err = conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')").Scan(&available)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return ErrTimescaleUnavailable
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", timescaleLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", timescaleLockID)
+
+	if _, err := conn.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS timescaledb"); err != nil {
+		return fmt.Errorf("failed to create the timescaledb extension: %w", err)
+	}
+
+	logger := logging.Component("database")
+	for _, table := range hypertables {
+		created, err := createHypertable(ctx, conn, table, cfg)
+		if err != nil {
+			return fmt.Errorf("hypertable %s: %w", table.Table, err)
+		}
+		if created {
+			logger.InfoContext(ctx, "Converted table to hypertable", "table", table.Table)
+		}
+	}
+	for _, aggregate := range continuousAggregates {
+		created, err := createContinuousAggregate(ctx, conn, aggregate)
+		if err != nil {
+			return fmt.Errorf("continuous aggregate %s: %w", aggregate.View, err)
+		}
+		if created {
+			logger.InfoContext(ctx, "Created continuous aggregate", "view", aggregate.View)
+		}
+	}
+
+	timescaleEnabled.Store(true)
+	logger.InfoContext(ctx, "TimescaleDB enabled",
+		"chunk_interval", cfg.TimescaleChunkInterval.String(), "compress_after", cfg.TimescaleCompressAfter.String())
+	return nil
+}
+
+// createHypertable converts a table, moving its rows into chunks, and applies the compression
+// settings, reporting whether the table was converted
+func createHypertable(ctx context.Context, conn *sql.Conn, table Hypertable, cfg *config.DatabaseConfig) (bool, error) {
+	var exists, compressed bool
+	err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) > 0, COALESCE(bool_or(compression_enabled), false) FROM timescaledb_information.hypertables WHERE hypertable_name = $1",
+		table.Table).Scan(&exists, &compressed)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		defer tx.Rollback()
+
+		for _, statement := range table.Prepare {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return false, err
+			}
+		}
+		_, err = tx.ExecContext(ctx,
+			"SELECT create_hypertable($1::regclass, $2::name, chunk_time_interval => make_interval(secs => $3), migrate_data => true)",
+			table.Table, table.TimeColumn, cfg.TimescaleChunkInterval.Seconds())
+		if err != nil {
+			return false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return false, err
+		}
+	}
+
+	if cfg.TimescaleCompressAfter <= 0 {
+		_, err = conn.ExecContext(ctx, "SELECT remove_compression_policy($1::regclass, if_exists => true)", table.Table)
+		return !exists, err
+	}
+	// The settings cannot change once chunks are compressed
+	if !compressed {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf(
+			"ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = '%s', timescaledb.compress_orderby = '%s DESC')",
+			table.Table, table.SegmentBy, table.TimeColumn))
+		if err != nil {
+			return !exists, err
+		}
+	}
+	// The policy is replaced so that a changed DB_TIMESCALE_COMPRESS_AFTER takes effect
+	if _, err := conn.ExecContext(ctx, "SELECT remove_compression_policy($1::regclass, if_exists => true)", table.Table); err != nil {
+		return !exists, err
+	}
+	_, err = conn.ExecContext(ctx,
+		"SELECT add_compression_policy($1::regclass, compress_after => make_interval(secs => $2))",
+		table.Table, cfg.TimescaleCompressAfter.Seconds())
+	return !exists, err
+}
+
+// createContinuousAggregate creates a continuous aggregate and materializes the existing rows,
+// reporting whether it was created, and adds its refresh policy. The views answer with real-time
+// data for the period not yet materialized.
+func createContinuousAggregate(ctx context.Context, conn *sql.Conn, aggregate ContinuousAggregate) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM timescaledb_information.continuous_aggregates WHERE view_name = $1)",
+		aggregate.View).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		_, err = conn.ExecContext(ctx, "CREATE MATERIALIZED VIEW "+aggregate.View+
+			" WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS "+aggregate.Query+" WITH NO DATA")
+		if err != nil {
+			return false, err
+		}
+		_, err = conn.ExecContext(ctx, fmt.Sprintf(
+			"CALL refresh_continuous_aggregate('%s', NULL, now() - INTERVAL '%s')", aggregate.View, aggregate.LagBehind))
+		if err != nil {
+			return true, err
+		}
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(
+		"SELECT add_continuous_aggregate_policy('%s', start_offset => INTERVAL '%s', end_offset => INTERVAL '%s', "+
+			"schedule_interval => INTERVAL '%s', if_not_exists => true)",
+		aggregate.View, aggregate.Lookback, aggregate.LagBehind, aggregate.Refresh))
+	return !exists, err
+}
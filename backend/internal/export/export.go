@@ -0,0 +1,56 @@
+// Package export writes tabular data as CSV or XLSX one row at a time, so exports of any size are
+// streamed to the client or into storage without holding the rows in memory.
+package export
+
+import (
+	"errors"
+	"io"
+)
+
+// Output formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for a format other than csv or xlsx
+var ErrUnsupportedFormat = errors.New("unsupported export format, use csv or xlsx")
+
+// Column is a column of an export
+type Column struct {
+	Header string
+	// Numeric values are written as numbers in XLSX, so spreadsheets can sum and sort them
+	Numeric bool
+}
+
+// Writer writes the rows of an export after its header row. Close must be called to complete the
+// file; it does not close the underlying writer.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// NewWriter starts an export in format, writing the header row of columns. sheet names the XLSX
+// worksheet.
+func NewWriter(w io.Writer, format, sheet string, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet, columns)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Supported reports whether format is csv or xlsx
+func Supported(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
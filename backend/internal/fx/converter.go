@@ -0,0 +1,238 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// PivotCurrency is the currency every rate is quoted against. It is also the default currency of
+// portfolios, positions and transactions.
+const PivotCurrency = "USD"
+
+var one = decimal.NewFromInt(1)
+
+// Converter converts amounts between currencies. Rates are fetched from the provider and cached
+// in Redis, so every instance converts at the same rates until the cache expires. While Redis is
+// unavailable the last fetched rates are used from memory for the same period.
+type Converter struct {
+	provider    Provider
+	redisClient *redis.Client
+	cacheTTL    time.Duration
+
+	// fetchMu keeps concurrent cache misses from each calling the provider
+	fetchMu sync.Mutex
+
+	localMu      sync.RWMutex
+	localRates   map[string]decimal.Decimal
+	localFetched time.Time
+}
+
+var (
+	defaultConverter *Converter
+	converterMu      sync.RWMutex
+)
+
+func NewConverter(cfg *config.FXConfig) (*Converter, error) {
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{
+		provider:    provider,
+		redisClient: database.GetRedis(),
+		cacheTTL:    cfg.CacheTTL,
+	}, nil
+}
+
+// Init creates the shared converter
+func Init(cfg *config.FXConfig) (*Converter, error) {
+	converter, err := NewConverter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	converterMu.Lock()
+	defaultConverter = converter
+	converterMu.Unlock()
+
+	return converter, nil
+}
+
+// GetConverter returns the shared converter, or nil if Init has not been called
+func GetConverter() *Converter {
+	converterMu.RLock()
+	defer converterMu.RUnlock()
+	return defaultConverter
+}
+
+// Normalize upper-cases a currency code, treating an empty code as the pivot currency
+func Normalize(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return PivotCurrency
+	}
+	return currency
+}
+
+// Rate returns how many units of to one unit of from buys
+func (c *Converter) Rate(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return one, nil
+	}
+
+	rates, err := c.pivotRates(ctx, []string{from, to})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return rates[to].Div(rates[from]), nil
+}
+
+// Rates returns the rate from each currency into base
+func (c *Converter) Rates(ctx context.Context, base string, currencies []string) (map[string]decimal.Decimal, error) {
+	base = Normalize(base)
+	wanted := []string{base}
+	for _, currency := range currencies {
+		wanted = append(wanted, Normalize(currency))
+	}
+
+	pivot, err := c.pivotRates(ctx, wanted)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]decimal.Decimal, len(currencies))
+	for _, currency := range wanted[1:] {
+		rates[currency] = pivot[base].Div(pivot[currency])
+	}
+	return rates, nil
+}
+
+// Convert converts an amount between currencies
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	rate, err := c.Rate(ctx, from, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate), nil
+}
+
+// Refresh fetches every rate from the provider and replaces the cached rates
+func (c *Converter) Refresh(ctx context.Context) (map[string]decimal.Decimal, error) {
+	rates, err := c.provider.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s FX provider: %w", c.provider.Name(), err)
+	}
+
+	c.localMu.Lock()
+	c.localRates, c.localFetched = rates, time.Now()
+	c.localMu.Unlock()
+
+	pipe := c.redisClient.Pipeline()
+	for currency, rate := range rates {
+		pipe.Set(ctx, database.FXRateKey(currency), rate.String(), c.cacheTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+		// The fetched rates are still served from memory until they expire
+		logging.Component("fx").WarnContext(ctx, "Failed to cache FX rates", "error", err)
+	}
+	return rates, nil
+}
+
+// pivotRates returns the rate against the pivot currency of each currency, reading the cache and
+// fetching from the provider when any of them is missing
+func (c *Converter) pivotRates(ctx context.Context, currencies []string) (map[string]decimal.Decimal, error) {
+	rates, missing := c.cachedRates(ctx, currencies)
+	if len(missing) == 0 {
+		return rates, nil
+	}
+
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	// Another caller may have refreshed the cache while we waited
+	if rates, missing = c.cachedRates(ctx, currencies); len(missing) == 0 {
+		return rates, nil
+	}
+
+	fetched, err := c.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, currency := range missing {
+		rate, ok := fetched[currency]
+		if !ok {
+			return nil, fmt.Errorf("no exchange rate for %s", currency)
+		}
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+func (c *Converter) cachedRates(ctx context.Context, currencies []string) (map[string]decimal.Decimal, []string) {
+	rates := make(map[string]decimal.Decimal, len(currencies))
+	var keys, lookup []string
+	for _, currency := range currencies {
+		if currency == PivotCurrency {
+			rates[currency] = one
+			continue
+		}
+		keys = append(keys, database.FXRateKey(currency))
+		lookup = append(lookup, currency)
+	}
+	if len(keys) == 0 {
+		return rates, nil
+	}
+
+	values, err := c.redisClient.MGet(ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		if !errors.Is(err, database.ErrRedisUnavailable) {
+			logging.Component("fx").WarnContext(ctx, "Failed to read cached FX rates", "error", err)
+		}
+		return c.localLookup(rates, lookup)
+	}
+
+	var missing []string
+	for i, currency := range lookup {
+		raw, _ := values[i].(string)
+		rate, err := decimal.NewFromString(raw)
+		if err != nil || !rate.IsPositive() {
+			missing = append(missing, currency)
+			continue
+		}
+		rates[currency] = rate
+	}
+	return rates, missing
+}
+
+// localLookup fills rates from the in-memory copy of the last fetch while it is fresh
+func (c *Converter) localLookup(rates map[string]decimal.Decimal, currencies []string) (map[string]decimal.Decimal, []string) {
+	c.localMu.RLock()
+	defer c.localMu.RUnlock()
+
+	if time.Since(c.localFetched) > c.cacheTTL {
+		return rates, currencies
+	}
+
+	var missing []string
+	for _, currency := range currencies {
+		rate, ok := c.localRates[currency]
+		if !ok {
+			missing = append(missing, currency)
+			continue
+		}
+		rates[currency] = rate
+	}
+	return rates, missing
+}
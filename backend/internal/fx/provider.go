@@ -0,0 +1,188 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// Rate sources
+const (
+	SourceStatic = "static"
+	SourceHTTP   = "http"
+)
+
+const (
+	maxRatesResponseSize = 1 << 20
+	httpProviderTimeout  = 10 * time.Second
+)
+
+// referenceRates are the built-in rates of the static provider, in units per US dollar
+var referenceRates = map[string]string{
+	"EUR": "0.92",
+	"GBP": "0.79",
+	"JPY": "150.00",
+	"CHF": "0.88",
+	"CAD": "1.36",
+	"AUD": "1.52",
+	"NZD": "1.64",
+	"SEK": "10.50",
+	"NOK": "10.60",
+	"DKK": "6.87",
+	"HKD": "7.82",
+	"SGD": "1.34",
+	"CNY": "7.20",
+	"INR": "83.00",
+	"ZAR": "18.50",
+}
+
+// Provider fetches exchange rates in units of each currency per US dollar
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]decimal.Decimal, error)
+}
+
+// NewProvider builds the rate provider selected by the configuration
+func NewProvider(cfg *config.FXConfig) (Provider, error) {
+	switch cfg.Source {
+	case SourceStatic, "":
+		return NewStaticProvider(cfg.StaticRates)
+	case SourceHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("FX_RATES_URL is required for the %s FX source", SourceHTTP)
+		}
+		return NewHTTPProvider(cfg.URL, cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown FX source %q", cfg.Source)
+	}
+}
+
+// StaticProvider serves fixed rates, for development and for deployments without a rate feed
+type StaticProvider struct {
+	rates map[string]decimal.Decimal
+}
+
+// NewStaticProvider starts from the reference rates and applies CCY=rate overrides
+func NewStaticProvider(overrides []string) (*StaticProvider, error) {
+	rates := make(map[string]decimal.Decimal, len(referenceRates)+len(overrides))
+	for currency, rate := range referenceRates {
+		rates[currency] = decimal.RequireFromString(rate)
+	}
+
+	for _, override := range overrides {
+		currency, value, ok := strings.Cut(override, "=")
+		rate, err := decimal.NewFromString(strings.TrimSpace(value))
+		if !ok || err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid FX rate %q, expected CCY=rate", override)
+		}
+		rates[Normalize(currency)] = rate
+	}
+	return &StaticProvider{rates: rates}, nil
+}
+
+func (p *StaticProvider) Name() string {
+	return SourceStatic
+}
+
+func (p *StaticProvider) Fetch(ctx context.Context) (map[string]decimal.Decimal, error) {
+	rates := make(map[string]decimal.Decimal, len(p.rates))
+	for currency, rate := range p.rates {
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+// HTTPProvider fetches rates with GET <url>?base=USD. The endpoint returns either
+// {"base": "USD", "rates": {"EUR": 0.92, ...}} or an object mapping currencies to rates.
+type HTTPProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPProvider(ratesURL, apiKey string) *HTTPProvider {
+	return &HTTPProvider{
+		url:    ratesURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: httpProviderTimeout},
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return SourceHTTP
+}
+
+func (p *HTTPProvider) Fetch(ctx context.Context) (map[string]decimal.Decimal, error) {
+	endpoint, err := url.Parse(p.url)
+	if err != nil {
+		return nil, err
+	}
+	query := endpoint.Query()
+	query.Set("base", PivotCurrency)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("X-API-Key", p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates endpoint returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRatesResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	return decodeRates(body)
+}
+
+// decodeRates accepts a {"base", "rates"} envelope or a bare currency-to-rate object. Rates that
+// are not positive are dropped.
+func decodeRates(body []byte) (map[string]decimal.Decimal, error) {
+	var envelope struct {
+		Base  string                     `json:"base"`
+		Rates map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Rates != nil {
+		if envelope.Base != "" && Normalize(envelope.Base) != PivotCurrency {
+			return nil, fmt.Errorf("rates are quoted against %s, expected %s", envelope.Base, PivotCurrency)
+		}
+	} else {
+		envelope.Rates = nil
+		if err := json.Unmarshal(body, &envelope.Rates); err != nil {
+			return nil, fmt.Errorf("invalid rates response: %w", err)
+		}
+	}
+
+	rates := make(map[string]decimal.Decimal, len(envelope.Rates))
+	for currency, rate := range envelope.Rates {
+		if rate.IsPositive() {
+			rates[Normalize(currency)] = rate
+		}
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("rates response contained no rates")
+	}
+	return rates, nil
+}
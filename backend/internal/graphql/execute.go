@@ -0,0 +1,463 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Request is a GraphQL request as clients post it
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Error is an error of a request, with the path of the field it occurred at
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is the result of a request. Fields that failed are null in Data and have an entry in
+// Errors.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Execute parses, validates and executes a query. It reports false, with the errors found, when
+// the request is invalid and was not executed.
+func (s *Schema) Execute(ctx context.Context, req Request) (*Response, bool) {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return invalid(err.Error()), false
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return invalid(err.Error()), false
+	}
+	if op.kind != "query" {
+		return invalid("Only queries are supported"), false
+	}
+
+	e := &executor{
+		ctx:  ctx,
+		doc:  doc,
+		vars: make(map[string]interface{}, len(op.variables)),
+		args: make(map[*field]map[string]interface{}),
+	}
+	declared := make(map[string]bool, len(op.variables))
+	for _, def := range op.variables {
+		declared[def.name] = true
+		if value, ok := req.Variables[def.name]; ok {
+			e.vars[def.name] = value
+		} else if def.defaultValue != nil {
+			e.vars[def.name] = def.defaultValue
+		}
+	}
+
+	v := &validator{executor: e, schema: s, declared: declared, spreading: make(map[string]bool)}
+	v.validate(s.Query, op.selections, 1)
+	if len(v.errs) > 0 {
+		return &Response{Errors: v.errs}, false
+	}
+
+	data := e.execute(s.Query, []interface{}{nil}, [][]interface{}{{}}, op.selections)[0]
+	return &Response{Data: data, Errors: e.errors}, true
+}
+
+func invalid(message string) *Response {
+	return &Response{Errors: []Error{{Message: message}}}
+}
+
+// operation returns the named operation, or the document's only one when name is empty
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("operationName is required for a document with several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown operation %q", name)
+}
+
+type executor struct {
+	ctx    context.Context
+	doc    *document
+	vars   map[string]interface{}
+	args   map[*field]map[string]interface{} // Coerced arguments of each field, set by validation
+	errors []Error
+}
+
+// validator checks a query against the schema before it runs, and coerces its arguments
+type validator struct {
+	*executor
+	schema    *Schema
+	declared  map[string]bool
+	spreading map[string]bool // Fragments being expanded, to detect cycles
+	errs      []Error
+}
+
+func (v *validator) fail(format string, a ...interface{}) {
+	v.errs = append(v.errs, Error{Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) validate(obj *Object, selections []selection, depth int) {
+	if v.schema.MaxDepth > 0 && depth > v.schema.MaxDepth {
+		v.fail("Query is nested deeper than %d levels", v.schema.MaxDepth)
+		return
+	}
+
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			v.validateDirectives(sel.directives)
+			if sel.name == "__typename" {
+				if len(sel.args) > 0 || sel.selections != nil {
+					v.fail("Field __typename takes no arguments or selections")
+				}
+				continue
+			}
+
+			def, ok := obj.Fields[sel.name]
+			if !ok {
+				v.fail("Cannot query field %q on type %q", sel.name, obj.Name)
+				continue
+			}
+			if args, err := v.coerceArgs(def.Args, sel.args); err != nil {
+				v.fail("Field %q: %v", sel.name, err)
+			} else {
+				v.args[sel] = args
+			}
+
+			switch {
+			case def.Type == nil && sel.selections != nil:
+				v.fail("Field %q of type %q must not have a selection", sel.name, obj.Name)
+			case def.Type != nil && sel.selections == nil:
+				v.fail("Field %q of type %q must have a selection of subfields", sel.name, obj.Name)
+			case def.Type != nil:
+				v.validate(def.Type, sel.selections, depth+1)
+			}
+		case *fragmentSpread:
+			v.validateDirectives(sel.directives)
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.fail("Unknown fragment %q", sel.name)
+				continue
+			}
+			if frag.typeCondition != obj.Name {
+				v.fail("Fragment %q on %q cannot be spread on type %q", sel.name, frag.typeCondition, obj.Name)
+				continue
+			}
+			if v.spreading[sel.name] {
+				v.fail("Fragment %q spreads itself", sel.name)
+				continue
+			}
+			v.spreading[sel.name] = true
+			v.validate(obj, frag.selections, depth)
+			delete(v.spreading, sel.name)
+		case *inlineFragment:
+			v.validateDirectives(sel.directives)
+			if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				v.fail("Fragment on %q cannot be spread on type %q", sel.typeCondition, obj.Name)
+				continue
+			}
+			v.validate(obj, sel.selections, depth)
+		}
+	}
+}
+
+func (v *validator) validateDirectives(directives []directive) {
+	for _, d := range directives {
+		if d.name != "include" && d.name != "skip" {
+			v.fail("Unknown directive @%s", d.name)
+			continue
+		}
+		if _, err := v.coerceArgs(map[string]Arg{"if": {Kind: ArgBoolean, Required: true}}, d.args); err != nil {
+			v.fail("Directive @%s: %v", d.name, err)
+		}
+	}
+}
+
+// coerceArgs substitutes variables into the given arguments and converts them to their declared
+// kinds, adding the defaults of those omitted
+func (v *validator) coerceArgs(decls map[string]Arg, args []argument) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(decls))
+	for _, arg := range args {
+		decl, ok := decls[arg.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown argument %q", arg.name)
+		}
+		if _, dup := values[arg.name]; dup {
+			return nil, fmt.Errorf("argument %q given twice", arg.name)
+		}
+
+		value := arg.value
+		if name, isVar := value.(variable); isVar {
+			if !v.declared[string(name)] {
+				return nil, fmt.Errorf("variable $%s is not defined", name)
+			}
+			if value, ok = v.vars[string(name)]; !ok {
+				continue
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		coerced, err := decl.coerce(value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", arg.name, err)
+		}
+		values[arg.name] = coerced
+	}
+
+	for name, decl := range decls {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		if decl.Default != nil {
+			values[name] = decl.Default
+		} else if decl.Required {
+			return nil, fmt.Errorf("argument %q is required", name)
+		}
+	}
+	return values, nil
+}
+
+// collectedField is a response key and the field nodes selected under it
+type collectedField struct {
+	key   string
+	name  string
+	nodes []*field
+}
+
+// collectFields flattens fragments and merges the fields selected under the same response key
+func (e *executor) collectFields(selections []selection, fields []*collectedField, byKey map[string]*collectedField) []*collectedField {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			if cf, ok := byKey[key]; ok {
+				cf.nodes = append(cf.nodes, sel)
+				continue
+			}
+			cf := &collectedField{key: key, name: sel.name, nodes: []*field{sel}}
+			byKey[key] = cf
+			fields = append(fields, cf)
+		case *fragmentSpread:
+			if e.included(sel.directives) {
+				fields = e.collectFields(e.doc.fragments[sel.name].selections, fields, byKey)
+			}
+		case *inlineFragment:
+			if e.included(sel.directives) {
+				fields = e.collectFields(sel.selections, fields, byKey)
+			}
+		}
+	}
+	return fields
+}
+
+// included evaluates a selection's @include and @skip directives
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		value := d.args[0].value
+		if name, ok := value.(variable); ok {
+			value = e.vars[string(name)]
+		}
+		cond, _ := value.(bool)
+		if d.name == "include" && !cond || d.name == "skip" && cond {
+			return false
+		}
+	}
+	return true
+}
+
+// execute resolves a selection against every source of a query level at once, so that loaders
+// fetch the values of all of them in one batch. paths holds the response path of each source.
+func (e *executor) execute(obj *Object, sources []interface{}, paths [][]interface{}, selections []selection) []*resultMap {
+	results := make([]*resultMap, len(sources))
+	for i := range results {
+		results[i] = &resultMap{values: make(map[string]interface{})}
+	}
+	if len(sources) == 0 {
+		return results
+	}
+
+	for _, cf := range e.collectFields(selections, nil, make(map[string]*collectedField)) {
+		for _, result := range results {
+			result.set(cf.key, nil)
+		}
+		if cf.name == "__typename" {
+			for _, result := range results {
+				result.values[cf.key] = obj.Name
+			}
+			continue
+		}
+
+		def := obj.Fields[cf.name]
+		if def.Authorize != nil {
+			if err := def.Authorize(e.ctx); err != nil {
+				e.fail(err, appendPath(paths[0], cf.key))
+				continue
+			}
+		}
+
+		params := ResolveParams{Args: e.args[cf.nodes[0]]}
+		values := make([]interface{}, len(sources))
+		for i, source := range sources {
+			params.Source = source
+			value, err := def.Resolve(e.ctx, params)
+			if err != nil {
+				e.fail(err, appendPath(paths[i], cf.key))
+				continue
+			}
+			values[i] = value
+		}
+		for i, value := range values {
+			if thunk, ok := value.(Thunk); ok {
+				loaded, err := thunk()
+				if err != nil {
+					e.fail(err, appendPath(paths[i], cf.key))
+				}
+				values[i] = loaded
+			}
+		}
+
+		if def.Type == nil {
+			for i, value := range values {
+				results[i].values[cf.key] = value
+			}
+			continue
+		}
+
+		// Gather the objects of every source to resolve their fields together
+		type slot struct{ result, index int }
+		var (
+			children     []interface{}
+			childPaths   [][]interface{}
+			slots        []slot
+			subselection []selection
+		)
+		for _, node := range cf.nodes {
+			subselection = append(subselection, node.selections...)
+		}
+		for i, value := range values {
+			path := appendPath(paths[i], cf.key)
+			if !def.List {
+				if isNull(value) {
+					continue
+				}
+				children = append(children, value)
+				childPaths = append(childPaths, path)
+				slots = append(slots, slot{i, -1})
+				continue
+			}
+
+			// A nil slice, such as a loader's for a key without values, is an empty list
+			if value == nil {
+				continue
+			}
+			list := reflect.ValueOf(value)
+			if list.Kind() != reflect.Slice {
+				e.fail(errors.New("Expected a list"), path)
+				continue
+			}
+			items := make([]interface{}, list.Len())
+			results[i].values[cf.key] = items
+			for j := range items {
+				item := listItem(list.Index(j))
+				if isNull(item) {
+					continue
+				}
+				children = append(children, item)
+				childPaths = append(childPaths, appendPath(path, j))
+				slots = append(slots, slot{i, j})
+			}
+		}
+
+		resolved := e.execute(def.Type, children, childPaths, subselection)
+		for k, s := range slots {
+			if s.index < 0 {
+				results[s.result].values[cf.key] = resolved[k]
+			} else {
+				results[s.result].values[cf.key].([]interface{})[s.index] = resolved[k]
+			}
+		}
+	}
+	return results
+}
+
+func (e *executor) fail(err error, path []interface{}) {
+	e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+}
+
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	out := make([]interface{}, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// listItem returns a list element as a source, pointing into the list for struct elements
+func listItem(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Struct && v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// resultMap is an object of the response, encoded with its fields in the order they were selected
+type resultMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *resultMap) set(key string, value interface{}) {
+	m.keys = append(m.keys, key)
+	m.values[key] = value
+}
+
+func (m *resultMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,516 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed query: its operations and named fragments
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []variableDef
+	selections []selection
+}
+
+type variableDef struct {
+	name         string
+	defaultValue interface{}
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	args       []argument
+	directives []directive
+	selections []selection
+}
+
+// responseKey is the field's key in the result: its alias, or its name
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []directive
+	selections    []selection
+}
+
+type argument struct {
+	name  string
+	value interface{}
+}
+
+type directive struct {
+	name string
+	args []argument
+}
+
+// Literal values are parsed into Go values: int64, float64, string, bool, nil, []interface{} and
+// map[string]interface{}, plus these for variables and enum values
+type (
+	variable  string
+	enumValue string
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// parser is a recursive descent parser of GraphQL executable documents. Schema definitions are
+// not supported.
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func parse(src string) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, syntaxErr
+		}
+	}()
+
+	p := &parser{src: strings.TrimPrefix(src, "\ufeff")}
+	p.next()
+
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peekPunct("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: p.parseSelectionSet()})
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			frag := p.parseFragment()
+			if _, exists := doc.fragments[frag.name]; exists {
+				p.fail("duplicate fragment " + frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokenName:
+			doc.operations = append(doc.operations, p.parseOperation())
+		default:
+			p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, syntaxError{"document has no operation"}
+	}
+	return doc, nil
+}
+
+type syntaxError struct {
+	message string
+}
+
+func (e syntaxError) Error() string {
+	return "syntax error: " + e.message
+}
+
+func (p *parser) fail(message string) {
+	line := strings.Count(p.src[:p.tok.pos], "\n") + 1
+	panic(syntaxError{fmt.Sprintf("%s at line %d", message, line)})
+}
+
+func (p *parser) unexpected() {
+	if p.tok.kind == tokenEOF {
+		p.fail("unexpected end of document")
+	}
+	p.fail(fmt.Sprintf("unexpected %q", p.tok.value))
+}
+
+func (p *parser) parseOperation() *operation {
+	op := &operation{kind: p.expectName()}
+	switch op.kind {
+	case "query", "mutation", "subscription":
+	default:
+		p.fail("unknown operation type " + op.kind)
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.expectName()
+	}
+	if p.skipPunct("(") {
+		for !p.skipPunct(")") {
+			op.variables = append(op.variables, p.parseVariableDef())
+		}
+	}
+	p.parseDirectives()
+	op.selections = p.parseSelectionSet()
+	return op
+}
+
+func (p *parser) parseVariableDef() variableDef {
+	p.expectPunct("$")
+	def := variableDef{name: p.expectName()}
+	p.expectPunct(":")
+	p.parseType()
+	if p.skipPunct("=") {
+		def.defaultValue = p.parseValue(true)
+	}
+	p.parseDirectives()
+	return def
+}
+
+// parseType skips a variable's type; arguments are coerced by the field's declared types instead
+func (p *parser) parseType() {
+	if p.skipPunct("[") {
+		p.parseType()
+		p.expectPunct("]")
+	} else {
+		p.expectName()
+	}
+	p.skipPunct("!")
+}
+
+func (p *parser) parseFragment() *fragment {
+	p.expectName() // fragment
+	frag := &fragment{name: p.expectName()}
+	if frag.name == "on" {
+		p.fail("fragment cannot be named on")
+	}
+	if p.expectName() != "on" {
+		p.fail("expected on")
+	}
+	frag.typeCondition = p.expectName()
+	p.parseDirectives()
+	frag.selections = p.parseSelectionSet()
+	return frag
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expectPunct("{")
+	var selections []selection
+	for !p.skipPunct("}") {
+		selections = append(selections, p.parseSelection())
+	}
+	if len(selections) == 0 {
+		p.fail("empty selection set")
+	}
+	return selections
+}
+
+func (p *parser) parseSelection() selection {
+	if p.skipPunct("...") {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			return &fragmentSpread{name: p.expectName(), directives: p.parseDirectives()}
+		}
+		inline := &inlineFragment{}
+		if p.tok.kind == tokenName {
+			p.expectName() // on
+			inline.typeCondition = p.expectName()
+		}
+		inline.directives = p.parseDirectives()
+		inline.selections = p.parseSelectionSet()
+		return inline
+	}
+
+	f := &field{name: p.expectName()}
+	if p.skipPunct(":") {
+		f.alias, f.name = f.name, p.expectName()
+	}
+	f.args = p.parseArguments(false)
+	f.directives = p.parseDirectives()
+	if p.peekPunct("{") {
+		f.selections = p.parseSelectionSet()
+	}
+	return f
+}
+
+func (p *parser) parseArguments(constant bool) []argument {
+	if !p.skipPunct("(") {
+		return nil
+	}
+	var args []argument
+	for !p.skipPunct(")") {
+		arg := argument{name: p.expectName()}
+		p.expectPunct(":")
+		arg.value = p.parseValue(constant)
+		args = append(args, arg)
+	}
+	return args
+}
+
+func (p *parser) parseDirectives() []directive {
+	var directives []directive
+	for p.skipPunct("@") {
+		directives = append(directives, directive{name: p.expectName(), args: p.parseArguments(false)})
+	}
+	return directives
+}
+
+func (p *parser) parseValue(constant bool) interface{} {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				p.fail("variable not allowed in a default value")
+			}
+			p.next()
+			return variable(p.expectName())
+		case "[":
+			p.next()
+			list := []interface{}{}
+			for !p.skipPunct("]") {
+				list = append(list, p.parseValue(constant))
+			}
+			return list
+		case "{":
+			p.next()
+			object := map[string]interface{}{}
+			for !p.skipPunct("}") {
+				name := p.expectName()
+				p.expectPunct(":")
+				object[name] = p.parseValue(constant)
+			}
+			return object
+		}
+	case tokenInt:
+		p.next()
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			p.fail("invalid integer " + tok.value)
+		}
+		return n
+	case tokenFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.fail("invalid number " + tok.value)
+		}
+		return f
+	case tokenString:
+		p.next()
+		return tok.value
+	case tokenName:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(tok.value)
+	}
+	p.unexpected()
+	return nil
+}
+
+func (p *parser) peekPunct(value string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == value
+}
+
+func (p *parser) skipPunct(value string) bool {
+	if p.peekPunct(value) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(value string) {
+	if !p.skipPunct(value) {
+		p.fail(fmt.Sprintf("expected %q", value))
+	}
+}
+
+func (p *parser) expectName() string {
+	if p.tok.kind != tokenName {
+		if p.tok.kind == tokenEOF {
+			p.unexpected()
+		}
+		p.fail(fmt.Sprintf("expected a name, found %q", p.tok.value))
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+// next reads the next token, skipping whitespace, commas and comments
+func (p *parser) next() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+			continue
+		}
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, value: "...", pos: start}
+	case strings.IndexByte("!$()&:=@[]{}|", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.tok = p.readNumber()
+	case c == '"':
+		p.tok = p.readString()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.tok = token{kind: tokenPunct, value: string(r), pos: start}
+		p.fail(fmt.Sprintf("unexpected character %q", r))
+	}
+}
+
+func (p *parser) readNumber() token {
+	start := p.pos
+	kind := tokenInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	p.readDigits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		p.readDigits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		p.readDigits()
+	}
+	return token{kind: kind, value: p.src[start:p.pos], pos: start}
+}
+
+func (p *parser) readDigits() {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.tok = token{kind: tokenEOF, pos: start}
+		p.fail("invalid number")
+	}
+}
+
+// readString reads a quoted string; block strings are not supported
+func (p *parser) readString() token {
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.tok = token{kind: tokenEOF, pos: start}
+			p.fail("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return token{kind: tokenString, value: b.String(), pos: start}
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				p.pos++
+				continue
+			}
+			escape := p.src[p.pos+1]
+			p.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					p.fail("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					p.fail("invalid unicode escape")
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				p.fail(fmt.Sprintf("invalid escape \\%c", escape))
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
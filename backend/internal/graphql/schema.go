@@ -0,0 +1,130 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Schema is the queries a GraphQL endpoint serves. Mutations are not supported.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth is the deepest nesting of fields a query may select; 0 is unlimited
+	MaxDepth int
+}
+
+// Object is an object type: a set of fields resolved against a source value
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an object type. Scalar fields leave Type nil and their resolved value is
+// encoded as JSON; object fields resolve to the source values of Type, or a slice of them when
+// List is set.
+type Field struct {
+	Type *Object
+	List bool
+	Args map[string]Arg
+
+	// Authorize, when set, is checked before the field is resolved. A field the caller may not
+	// see resolves to null with the error returned.
+	Authorize func(ctx context.Context) error
+
+	// Resolve returns the field's value for a source, or a Thunk that loads it once the field
+	// has been resolved for every source of the query level
+	Resolve func(ctx context.Context, p ResolveParams) (interface{}, error)
+}
+
+// ResolveParams are the inputs of a field's resolver
+type ResolveParams struct {
+	Source interface{}
+	Args   map[string]interface{}
+}
+
+// Thunk defers a field's value so that the loads of a query level can be batched
+type Thunk func() (interface{}, error)
+
+// ArgKind is the scalar type of an argument
+type ArgKind int
+
+const (
+	ArgString ArgKind = iota
+	ArgID
+	ArgInt
+	ArgBoolean
+)
+
+func (k ArgKind) String() string {
+	switch k {
+	case ArgID:
+		return "ID"
+	case ArgInt:
+		return "Int"
+	case ArgBoolean:
+		return "Boolean"
+	}
+	return "String"
+}
+
+// Arg declares an argument of a field. Coerced values are strings for String and ID, ints for Int
+// and bools for Boolean; an argument that is omitted and has no default is absent from Args.
+type Arg struct {
+	Kind     ArgKind
+	Required bool
+	Default  interface{}
+}
+
+// String returns a string argument, or "" when it was not given
+func (p ResolveParams) String(name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+// Int returns an int argument, or def when it was not given
+func (p ResolveParams) Int(name string, def int) int {
+	if n, ok := p.Args[name].(int); ok {
+		return n
+	}
+	return def
+}
+
+// coerce converts an argument value, with variables substituted, to the argument's kind
+func (a Arg) coerce(value interface{}) (interface{}, error) {
+	switch a.Kind {
+	case ArgString, ArgID:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int64:
+			if a.Kind == ArgID {
+				return fmt.Sprint(v), nil
+			}
+		case float64:
+			if a.Kind == ArgID && v == math.Trunc(v) {
+				return fmt.Sprint(int64(v)), nil
+			}
+		case enumValue:
+			if a.Kind == ArgString {
+				return string(v), nil
+			}
+		}
+	case ArgInt:
+		switch v := value.(type) {
+		case int64:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		case float64: // Variables decoded from JSON
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		}
+	case ArgBoolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s", a.Kind)
+}
@@ -0,0 +1,198 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/screening"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// handlers implements the RPCs with the services behind the equivalent REST endpoints
+type handlers struct {
+	riskConfig       *config.RiskConfig
+	portfolioService *services.PortfolioService
+	accessService    *services.AccessService
+	dashboard        *services.DashboardService
+	amlService       *services.AMLService
+	screener         *screening.Screener
+}
+
+func newHandlers(riskConfig *config.RiskConfig) *handlers {
+	return &handlers{
+		riskConfig:       riskConfig,
+		portfolioService: services.NewPortfolioService(),
+		accessService:    services.NewAccessService(),
+		dashboard:        services.NewDashboardService(),
+		amlService:       services.NewAMLService(),
+		screener:         screening.GetScreener(),
+	}
+}
+
+func (h *handlers) methods() map[string]method {
+	return map[string]method{
+		"/riskmonitor.v1.PortfolioService/GetPortfolio":      {middleware.PermPortfolioRead, h.getPortfolio},
+		"/riskmonitor.v1.PortfolioService/ListPortfolios":    {middleware.PermPortfolioRead, h.listPortfolios},
+		"/riskmonitor.v1.RiskService/GetLatestRiskMetrics":   {middleware.PermRiskRead, h.getLatestRiskMetrics},
+		"/riskmonitor.v1.RiskService/CalculateVaR":           {middleware.PermRiskRead, h.calculateVaR},
+		"/riskmonitor.v1.ComplianceService/ScreenName":       {middleware.PermComplianceScreen, h.screenName},
+		"/riskmonitor.v1.ComplianceService/CheckTransaction": {middleware.PermComplianceScreen, h.checkTransaction},
+	}
+}
+
+// portfolioID reads the portfolio_id field of a request and checks the caller can see the
+// portfolio. Portfolios the caller cannot see are reported as not found so IDs cannot be probed.
+func (h *handlers) portfolioID(c *caller, request []byte) (uuid.UUID, error) {
+	fields, err := decodeStrings(request)
+	if err != nil {
+		return uuid.Nil, status(codeInvalidArgument, err.Error())
+	}
+	portfolioID, err := uuid.Parse(fields[1])
+	if err != nil {
+		return uuid.Nil, status(codeInvalidArgument, "Invalid portfolio ID")
+	}
+
+	allowed, err := h.accessService.CanAccessPortfolio(c.userID, c.role, portfolioID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !allowed {
+		return uuid.Nil, status(codeNotFound, "Portfolio not found")
+	}
+	return portfolioID, nil
+}
+
+func (h *handlers) getPortfolio(ctx context.Context, c *caller, request []byte) (message, error) {
+	portfolioID, err := h.portfolioID(c, request)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio, err := h.portfolioService.GetPortfolioByID(portfolioID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return nil, status(codeNotFound, "Portfolio not found")
+		}
+		return nil, err
+	}
+	return portfolioMessage{portfolio: portfolio, withPositions: true}, nil
+}
+
+func (h *handlers) listPortfolios(ctx context.Context, c *caller, request []byte) (message, error) {
+	ids, all, err := h.accessService.AccessiblePortfolioIDs(c.userID, c.role)
+	if err != nil {
+		return nil, err
+	}
+	portfolios, err := h.portfolioService.GetPortfoliosByIDs(ids, all)
+	if err != nil {
+		return nil, err
+	}
+	return listPortfoliosResponse{portfolios: portfolios}, nil
+}
+
+func (h *handlers) getLatestRiskMetrics(ctx context.Context, c *caller, request []byte) (message, error) {
+	portfolioID, err := h.portfolioID(c, request)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := h.dashboard.LatestRiskMetrics(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	return latestRiskMetricsResponse{metrics: metrics}, nil
+}
+
+func (h *handlers) calculateVaR(ctx context.Context, c *caller, request []byte) (message, error) {
+	portfolioID, err := h.portfolioID(c, request)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.GetDB().WithContext(ctx)
+	var portfolio models.Portfolio
+	if err := db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status(codeNotFound, "Portfolio not found")
+		}
+		return nil, err
+	}
+	if len(portfolio.Positions) == 0 {
+		return nil, status(codeFailedPrecondition, "Portfolio has no positions")
+	}
+
+	metric, lvar, err := services.VaRMetric(&portfolio, h.riskConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Create(metric).Error; err != nil {
+		return nil, err
+	}
+
+	return varResult{
+		portfolio:            &portfolio,
+		metric:               metric,
+		confidenceLevel:      h.riskConfig.VARConfidenceLevel,
+		liquidityAdjustedVaR: lvar.Value,
+	}, nil
+}
+
+func (h *handlers) screenName(ctx context.Context, c *caller, request []byte) (message, error) {
+	fields, err := decodeStrings(request)
+	if err != nil {
+		return nil, status(codeInvalidArgument, err.Error())
+	}
+	name, country := strings.TrimSpace(fields[1]), fields[2]
+	if name == "" {
+		return nil, status(codeInvalidArgument, "Name is required")
+	}
+
+	result, err := h.screener.Screen(name, country, screening.SubjectAdHoc, nil, &c.userID)
+	if err != nil {
+		return nil, err
+	}
+	return screeningResultMessage{result}, nil
+}
+
+func (h *handlers) checkTransaction(ctx context.Context, c *caller, request []byte) (message, error) {
+	fields, err := decodeStrings(request)
+	if err != nil {
+		return nil, status(codeInvalidArgument, err.Error())
+	}
+	transactionID, err := uuid.Parse(fields[1])
+	if err != nil {
+		return nil, status(codeInvalidArgument, "Invalid transaction ID")
+	}
+
+	// Transactions in portfolios the caller cannot see are reported as not found
+	portfolioID, err := h.accessService.TransactionPortfolioID(transactionID)
+	if err != nil {
+		return nil, status(codeNotFound, "Transaction not found")
+	}
+	allowed, err := h.accessService.CanAccessPortfolio(c.userID, c.role, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, status(codeNotFound, "Transaction not found")
+	}
+
+	var transaction models.Transaction
+	if err := database.GetDB().WithContext(ctx).First(&transaction, transactionID).Error; err != nil {
+		return nil, status(codeNotFound, "Transaction not found")
+	}
+
+	report, err := h.amlService.CheckTransaction(ctx, &transaction, &c.userID)
+	if err != nil {
+		return nil, err
+	}
+	return amlReportMessage{report}, nil
+}
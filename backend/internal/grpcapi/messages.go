@@ -0,0 +1,140 @@
+package grpcapi
+
+import (
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// Response messages of proto/riskmonitor/v1, encoded from the models the REST handlers return.
+// Field numbers must match the .proto files.
+
+type portfolioMessage struct {
+	portfolio     *models.Portfolio
+	withPositions bool
+}
+
+func (m portfolioMessage) marshal(e *encoder) {
+	p := m.portfolio
+	e.string(1, p.ID.String())
+	e.string(2, p.UserID.String())
+	e.string(3, p.Name)
+	e.string(4, p.Description)
+	e.string(5, p.Currency)
+	e.string(6, p.TotalValue.String())
+	e.string(7, p.CashBalance.String())
+	e.string(8, p.MarginLoan.String())
+	if m.withPositions {
+		for i := range p.Positions {
+			e.message(9, positionMessage{&p.Positions[i]})
+		}
+	}
+	e.timestamp(10, p.CreatedAt)
+	e.timestamp(11, p.UpdatedAt)
+}
+
+type positionMessage struct {
+	position *models.Position
+}
+
+func (m positionMessage) marshal(e *encoder) {
+	p := m.position
+	e.string(1, p.ID.String())
+	e.string(2, p.Symbol)
+	e.string(3, p.AssetType)
+	e.string(4, p.Quantity.String())
+	e.string(5, p.AveragePrice.String())
+	e.string(6, p.CurrentPrice.String())
+	e.string(7, p.MarketValue.String())
+	e.string(8, p.PnL.String())
+	e.string(9, p.Weight.String())
+	e.string(10, p.Liquidity)
+	e.string(11, p.Currency)
+}
+
+type listPortfoliosResponse struct {
+	portfolios []models.Portfolio
+}
+
+func (m listPortfoliosResponse) marshal(e *encoder) {
+	for i := range m.portfolios {
+		e.message(1, portfolioMessage{portfolio: &m.portfolios[i]})
+	}
+}
+
+type riskMetricMessage struct {
+	metric *models.RiskMetric
+}
+
+func (m riskMetricMessage) marshal(e *encoder) {
+	r := m.metric
+	e.string(1, r.ID.String())
+	e.string(2, r.PortfolioID.String())
+	e.string(3, r.MetricType)
+	e.string(4, r.Value.String())
+	e.string(5, r.Threshold.String())
+	e.string(6, r.Status)
+	e.timestamp(7, r.CalculatedAt)
+	e.int64(8, int64(r.TimeHorizon))
+	e.string(9, r.ConfidenceLevel.String())
+}
+
+type latestRiskMetricsResponse struct {
+	metrics []models.RiskMetric
+}
+
+func (m latestRiskMetricsResponse) marshal(e *encoder) {
+	for i := range m.metrics {
+		e.message(1, riskMetricMessage{&m.metrics[i]})
+	}
+}
+
+type varResult struct {
+	portfolio            *models.Portfolio
+	metric               *models.RiskMetric
+	confidenceLevel      float64
+	liquidityAdjustedVaR float64
+}
+
+func (m varResult) marshal(e *encoder) {
+	e.string(1, m.portfolio.ID.String())
+	e.string(2, m.metric.Value.String())
+	e.string(3, services.SimplifiedVaRPercent(m.portfolio, m.metric.Value).String())
+	e.string(4, m.metric.Threshold.String())
+	e.string(5, m.metric.Status)
+	e.double(6, m.confidenceLevel)
+	e.int64(7, int64(m.metric.TimeHorizon))
+	e.string(8, m.portfolio.ValueWithCash().String())
+	e.double(9, m.liquidityAdjustedVaR)
+}
+
+type screeningResultMessage struct {
+	result *models.ScreeningResult
+}
+
+func (m screeningResultMessage) marshal(e *encoder) {
+	r := m.result
+	e.string(1, r.ID.String())
+	e.string(2, r.SubjectName)
+	e.string(3, r.SubjectType)
+	e.string(4, r.Status)
+	e.bool(5, r.SanctionsHit)
+	e.bool(6, r.PEPHit)
+	e.double(7, r.TopScore)
+	e.timestamp(8, r.ScreenedAt)
+}
+
+type amlReportMessage struct {
+	report *services.AMLCheckReport
+}
+
+func (m amlReportMessage) marshal(e *encoder) {
+	r := m.report
+	e.string(1, r.TransactionID.String())
+	e.string(2, r.Status)
+	e.int64(3, int64(r.RiskScore))
+	e.strings(4, r.Flags)
+	for i := range r.Screenings {
+		e.message(5, screeningResultMessage{&r.Screenings[i]})
+	}
+	e.string(6, r.Notes)
+}
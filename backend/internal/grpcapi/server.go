@@ -0,0 +1,276 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// maxMessageSize is the largest request message accepted, as in gRPC's default
+const maxMessageSize = 4 << 20
+
+// code is a gRPC status code
+type code int
+
+const (
+	codeOK                 code = 0
+	codeInvalidArgument    code = 3
+	codeNotFound           code = 5
+	codePermissionDenied   code = 7
+	codeResourceExhausted  code = 8
+	codeFailedPrecondition code = 9
+	codeUnimplemented      code = 12
+	codeInternal           code = 13
+	codeUnavailable        code = 14
+	codeUnauthenticated    code = 16
+)
+
+// statusError is an error returned to the client with a gRPC status code
+type statusError struct {
+	code    code
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func status(c code, message string) error {
+	return &statusError{code: c, message: message}
+}
+
+// caller is the authenticated user of a call, limited to its API key's scopes when it used one
+type caller struct {
+	userID uuid.UUID
+	role   string
+	scopes map[middleware.Permission]bool
+}
+
+func (c *caller) allowed(perm middleware.Permission) bool {
+	if c.scopes != nil && !c.scopes[perm] {
+		return false
+	}
+	return middleware.HasPermission(c.role, perm)
+}
+
+// method is a unary RPC: the permission it requires and its handler, which decodes the request
+type method struct {
+	perm   middleware.Permission
+	handle func(ctx context.Context, c *caller, request []byte) (message, error)
+}
+
+// Server serves the PortfolioService, RiskService and ComplianceService RPCs of
+// proto/riskmonitor/v1 over cleartext HTTP/2, calling the services the REST handlers use.
+// Only unary calls with uncompressed messages are supported.
+type Server struct {
+	methods       map[string]method // By path: /riskmonitor.v1.Service/Method
+	authService   *services.AuthService
+	apiKeyService *services.APIKeyService
+	logger        *slog.Logger
+}
+
+func NewServer(authService *services.AuthService, apiKeyService *services.APIKeyService, riskConfig *config.RiskConfig) *Server {
+	s := &Server{
+		authService:   authService,
+		apiKeyService: apiKeyService,
+		logger:        logging.Component("grpc"),
+	}
+	s.methods = newHandlers(riskConfig).methods()
+	return s
+}
+
+// ListenAndServe serves gRPC on the given port until the listener fails or ctx is cancelled, when
+// it stops accepting calls and waits for those in progress
+func (s *Server) ListenAndServe(ctx context.Context, port string) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           s,
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.logger.Info("gRPC server listening", "port", port)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+		return server.Shutdown(context.WithoutCancel(ctx))
+	}
+}
+
+// ServeHTTP handles a gRPC call. The outcome is reported in the grpc-status and grpc-message
+// trailers; HTTP errors are only used for requests that are not gRPC calls.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	ctx := logging.WithNewRequestID(r.Context())
+	if timeout, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	response, err := s.call(ctx, r)
+	if err == nil {
+		payload := encode(response)
+		frame := make([]byte, 5, 5+len(payload))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+		if _, err = w.Write(append(frame, payload...)); err != nil {
+			s.logger.DebugContext(ctx, "Failed to write gRPC response", "method", r.URL.Path, "error", err)
+			return
+		}
+	}
+
+	c, message := codeOK, ""
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			c, message = statusErr.code, statusErr.message
+		} else {
+			s.logger.ErrorContext(ctx, "gRPC call failed", "method", r.URL.Path, "error", err)
+			c, message = codeInternal, "Internal error"
+		}
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(c)))
+	if message != "" {
+		w.Header().Set("Grpc-Message", url.PathEscape(message))
+	}
+}
+
+// call authenticates a call, checks the method's permission and runs it
+func (s *Server) call(ctx context.Context, r *http.Request) (message, error) {
+	m, ok := s.methods[r.URL.Path]
+	if !ok {
+		return nil, status(codeUnimplemented, "Unknown method "+r.URL.Path)
+	}
+
+	c, err := s.authenticate(ctx, r.Header)
+	if err != nil {
+		return nil, err
+	}
+	if !c.allowed(m.perm) {
+		return nil, status(codePermissionDenied, "Insufficient permissions")
+	}
+
+	request, err := readMessage(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return m.handle(ctx, c, request)
+}
+
+// authenticate accepts the same credentials as the REST API, sent as metadata: an x-api-key, which
+// takes precedence, or an authorization bearer token
+func (s *Server) authenticate(ctx context.Context, md http.Header) (*caller, error) {
+	if rawKey := md.Get(middleware.APIKeyHeader); rawKey != "" {
+		key, err := s.apiKeyService.Authenticate(ctx, rawKey)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				return nil, status(codeUnauthenticated, "Invalid API key")
+			}
+			return nil, err
+		}
+
+		limit, err := s.apiKeyService.Allow(ctx, key)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to check API key rate limit", "api_key_id", key.ID, "error", err)
+			return nil, status(codeUnavailable, "Rate limit unavailable")
+		}
+		if !limit.Allowed {
+			return nil, status(codeResourceExhausted, "API key rate limit exceeded")
+		}
+
+		scopes := make(map[middleware.Permission]bool, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes[middleware.Permission(scope)] = true
+		}
+		return &caller{userID: key.UserID, role: key.User.Role, scopes: scopes}, nil
+	}
+
+	token, ok := strings.CutPrefix(md.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, status(codeUnauthenticated, "Missing credentials")
+	}
+	claims, err := s.authService.ValidateToken(token)
+	if err != nil {
+		return nil, status(codeUnauthenticated, "Invalid or expired token")
+	}
+
+	userID, _ := (*claims)["user_id"].(string)
+	role, _ := (*claims)["role"].(string)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, status(codeUnauthenticated, "Invalid user ID")
+	}
+	return &caller{userID: id, role: role}, nil
+}
+
+// readMessage reads the single length-prefixed message of a unary request
+func readMessage(body io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		return nil, status(codeInvalidArgument, "Missing request message")
+	}
+	if header[0] != 0 {
+		return nil, status(codeUnimplemented, "Compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return nil, status(codeResourceExhausted, fmt.Sprintf("Request message larger than %d bytes", maxMessageSize))
+	}
+
+	request := make([]byte, length)
+	if _, err := io.ReadFull(body, request); err != nil {
+		return nil, status(codeInvalidArgument, "Truncated request message")
+	}
+	return request, nil
+}
+
+// parseTimeout parses a grpc-timeout header: up to eight digits and a unit
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,141 @@
+package grpcapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errMalformed = errors.New("malformed protobuf message")
+
+// message is a response message that can be encoded in the protobuf wire format
+type message interface {
+	marshal(e *encoder)
+}
+
+// encoder writes protobuf fields. Fields holding their zero value are left out, as proto3 does.
+type encoder struct {
+	buf []byte
+}
+
+func encode(m message) []byte {
+	e := &encoder{}
+	m.marshal(e)
+	return e.buf
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *encoder) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) strings(field int, values []string) {
+	for _, s := range values {
+		e.tag(field, wireBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+		e.buf = append(e.buf, s...)
+	}
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.tag(field, wireVarint)
+		e.buf = append(e.buf, 1)
+	}
+}
+
+// int64 writes an int32 or int64 field; negative values take ten bytes, as in protobuf
+func (e *encoder) int64(field int, v int64) {
+	if v != 0 {
+		e.tag(field, wireVarint)
+		e.buf = binary.AppendUvarint(e.buf, uint64(v))
+	}
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v != 0 {
+		e.tag(field, wireFixed64)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+	}
+}
+
+// message writes an embedded message, including an empty one as repeated fields need
+func (e *encoder) message(field int, m message) {
+	sub := encode(m)
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(sub)))
+	e.buf = append(e.buf, sub...)
+}
+
+// timestamp writes a google.protobuf.Timestamp, leaving out the zero time
+func (e *encoder) timestamp(field int, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	e.message(field, timestamp(t))
+}
+
+type timestamp time.Time
+
+func (t timestamp) marshal(e *encoder) {
+	e.int64(1, time.Time(t).Unix())
+	e.int64(2, int64(time.Time(t).Nanosecond()))
+}
+
+// decodeStrings reads the string fields of a request message by field number; fields of other
+// types are skipped. Repeated strings keep the last value.
+func decodeStrings(data []byte) (map[int]string, error) {
+	fields := make(map[int]string)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+		field, wireType := int(key>>3), int(key&7)
+
+		switch wireType {
+		case wireVarint:
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return nil, errMalformed
+			}
+			data = data[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wireType == wireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, errMalformed
+			}
+			data = data[size:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return nil, errMalformed
+			}
+			fields[field] = string(data[n : n+int(length)])
+			data = data[n+int(length):]
+		default:
+			return nil, errMalformed
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// activityListSpec lists the filters and sort fields the activity feed accepts
+var activityListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"occurred_at": "occurred_at",
+	},
+	DefaultSort: "occurred_at",
+	DateColumn:  "occurred_at",
+	Filters: map[string]string{
+		"activity_type": "activity_type",
+		"entity_type":   "entity_type",
+		"entity_id":     "entity_id",
+	},
+}
+
+// GetActivity returns a page of a portfolio's timeline, latest first: transactions recorded,
+// alerts raised, risk threshold changes and transaction, order and alert status transitions.
+// Access is checked by the PortfolioAccess middleware.
+func (h *PortfolioHandler) GetActivity(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	params, err := pagination.Parse(c, activityListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	if value, ok := params.Filters["entity_id"]; ok {
+		if _, err := uuid.Parse(value); err != nil {
+			return apperror.BadRequest("Invalid entity_id")
+		}
+	}
+
+	activities, total, err := h.activityService.List(portfolioID, activityListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve portfolio activity", err)
+	}
+
+	return c.JSON(pagination.Response(activities, total, params))
+}
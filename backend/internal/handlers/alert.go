@@ -1,51 +1,121 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/alerts"
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
 )
 
 type AlertHandler struct {
-	alertManager *alerts.AlertManager
+	alertManager       *alerts.AlertManager
+	alertService       *services.AlertService
+	suppressionService *services.AlertSuppressionService
+	dashboard          *services.DashboardService
+	accessService      *services.AccessService
+	auditService       *services.AuditService
 }
 
 func NewAlertHandler() *AlertHandler {
 	return &AlertHandler{
-		alertManager: alerts.NewAlertManager(),
+		alertManager:       alerts.NewAlertManager(),
+		alertService:       services.NewAlertService(),
+		suppressionService: services.NewAlertSuppressionService(),
+		dashboard:          services.NewDashboardService(),
+		accessService:      services.NewAccessService(),
+		auditService:       services.NewAuditService(),
 	}
 }
 
-// GetAlerts returns all alerts
+// alertListSpec lists the filters and sort fields the alert listings accept
+var alertListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"severity":   "severity",
+		"status":     "status",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"portfolio_id": "portfolio_id",
+		"status":       "status",
+		"severity":     "severity",
+		"alert_type":   "alert_type",
+		"source":       "source",
+	},
+}
+
+// GetAlerts returns a page of the alerts for portfolios the user can access
 func (h *AlertHandler) GetAlerts(c *fiber.Ctx) error {
-	var alerts []models.Alert
+	return h.listAlerts(c, "")
+}
 
-	if err := database.GetDB().Find(&alerts).Error; err != nil {
+// GetActiveAlerts returns a page of the active alerts for portfolios the user can access
+func (h *AlertHandler) GetActiveAlerts(c *fiber.Ctx) error {
+	return h.listAlerts(c, "ACTIVE")
+}
+
+// GetAlertCounts returns the number of active alerts by severity across the user's portfolios
+func (h *AlertHandler) GetAlertCounts(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	counts, err := h.dashboard.ActiveAlertCounts(c.UserContext(), userID, role)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve alerts",
+			"error": "Failed to count alerts",
 		})
 	}
 
-	return c.JSON(alerts)
+	return c.JSON(counts)
 }
 
-// GetActiveAlerts returns only active alerts
-func (h *AlertHandler) GetActiveAlerts(c *fiber.Ctx) error {
-	var alerts []models.Alert
+func (h *AlertHandler) listAlerts(c *fiber.Ctx, status string) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	params, err := pagination.Parse(c, alertListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	if err := database.GetDB().Where("status = ?", "ACTIVE").Find(&alerts).Error; err != nil {
+	query := h.accessService.ScopeQuery(database.GetDB().Model(&models.Alert{}), "portfolio_id", userID, role)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var alerts []models.Alert
+	total, err := pagination.Find(query, alertListSpec, params, &alerts)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve active alerts",
+			"error": "Failed to retrieve alerts",
 		})
 	}
 
-	return c.JSON(alerts)
+	return c.JSON(pagination.Response(alerts, total, params))
 }
 
-// GetAlert returns a specific alert
+// GetAlert returns a specific alert with its escalation timeline
 func (h *AlertHandler) GetAlert(c *fiber.Ctx) error {
 	alertID := c.Params("id")
 	alertUUID, err := uuid.Parse(alertID)
@@ -56,16 +126,28 @@ func (h *AlertHandler) GetAlert(c *fiber.Ctx) error {
 	}
 
 	var alert models.Alert
-	if err := database.GetDB().First(&alert, alertUUID).Error; err != nil {
+	err = database.GetDB().Preload("Escalations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).First(&alert, alertUUID).Error
+	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Alert not found",
 		})
 	}
 
+	setETag(c, alert.Version)
 	return c.JSON(alert)
 }
 
-// AcknowledgeAlert acknowledges an alert
+// AlertChangeRequest names the version of an alert a change was made against, when If-Match is
+// not sent, with the resolution of an alert being resolved
+type AlertChangeRequest struct {
+	Version    *int64 `json:"version"`
+	Resolution string `json:"resolution"`
+}
+
+// AcknowledgeAlert acknowledges an alert. The change names the version it was made against in
+// If-Match or the version field, and is refused with the current alert if that has changed.
 func (h *AlertHandler) AcknowledgeAlert(c *fiber.Ctx) error {
 	alertID := c.Params("id")
 	alertUUID, err := uuid.Parse(alertID)
@@ -83,19 +165,38 @@ func (h *AlertHandler) AcknowledgeAlert(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.alertManager.AcknowledgeAlert(alertUUID, userUUID)
+	// The body is optional when If-Match is sent
+	var req AlertChangeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+	version, err := expectedVersion(c, req.Version)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to acknowledge alert",
-		})
+		return err
 	}
 
+	before := h.alertSnapshot(alertUUID)
+
+	alert, err := h.alertManager.AcknowledgeAlert(alertUUID, userUUID, version)
+	if err != nil {
+		return serviceError(err, "Failed to acknowledge alert")
+	}
+
+	recordAudit(c, h.auditService, "alert.acknowledge", "alert", alertID, before, services.Snapshot(alert))
+
+	setETag(c, alert.Version)
 	return c.JSON(fiber.Map{
 		"message": "Alert acknowledged successfully",
+		"data":    alert,
 	})
 }
 
-// ResolveAlert resolves an alert
+// ResolveAlert resolves an alert. The change names the version it was made against in If-Match or
+// the version field, and is refused with the current alert if that has changed.
 func (h *AlertHandler) ResolveAlert(c *fiber.Ctx) error {
 	alertID := c.Params("id")
 	alertUUID, err := uuid.Parse(alertID)
@@ -113,25 +214,240 @@ func (h *AlertHandler) ResolveAlert(c *fiber.Ctx) error {
 		})
 	}
 
-	var req struct {
-		Resolution string `json:"resolution"`
+	var req AlertChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	version, err := expectedVersion(c, req.Version)
+	if err != nil {
+		return err
+	}
+
+	before := h.alertSnapshot(alertUUID)
+
+	alert, err := h.alertManager.ResolveAlert(alertUUID, userUUID, req.Resolution, version)
+	if err != nil {
+		return serviceError(err, "Failed to resolve alert")
+	}
+
+	recordAudit(c, h.auditService, "alert.resolve", "alert", alertID, before, services.Snapshot(alert))
+
+	setETag(c, alert.Version)
+	return c.JSON(fiber.Map{
+		"message": "Alert resolved successfully",
+		"data":    alert,
+	})
+}
+
+// maxBulkAlerts caps how many alerts one bulk request may change
+const maxBulkAlerts = 500
+
+// BulkAlertRequest applies one action to many alerts
+type BulkAlertRequest struct {
+	Action     string   `json:"action"` // acknowledge, resolve or dismiss
+	AlertIDs   []string `json:"alert_ids"`
+	Resolution string   `json:"resolution"` // Resolution or dismissal reason
+	// Versions the alerts were read at, by alert ID. Every alert needs one unless the request sends
+	// If-Match: *, which changes the alerts left out whatever their version.
+	Versions map[string]int64 `json:"versions,omitempty"`
+}
+
+// BulkAlertResult is the outcome of a bulk action for one alert
+type BulkAlertResult struct {
+	AlertID string        `json:"alert_id"`
+	Success bool          `json:"success"`
+	Status  string        `json:"status,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Code    apperror.Code `json:"code,omitempty"` // PRECONDITION_REQUIRED or VERSION_CONFLICT for version failures
+}
+
+// BulkAlerts acknowledges, resolves or dismisses a list of alerts. Each alert succeeds or fails
+// on its own; alerts the user cannot see are reported as not found, an alert without a version
+// fails as PRECONDITION_REQUIRED unless If-Match is *, and one changed since its version fails
+// with the conflict.
+func (h *AlertHandler) BulkAlerts(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
 	}
 
+	var req BulkAlertRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
 
-	err = h.alertManager.ResolveAlert(alertUUID, userUUID, req.Resolution)
+	var apply func(alertID uuid.UUID, version int64) error
+	switch req.Action {
+	case "acknowledge":
+		apply = func(alertID uuid.UUID, version int64) error {
+			_, err := h.alertManager.AcknowledgeAlert(alertID, userID, version)
+			return err
+		}
+	case "resolve":
+		apply = func(alertID uuid.UUID, version int64) error {
+			_, err := h.alertManager.ResolveAlert(alertID, userID, req.Resolution, version)
+			return err
+		}
+	case "dismiss":
+		apply = func(alertID uuid.UUID, version int64) error {
+			_, err := h.alertManager.DismissAlert(alertID, userID, req.Resolution, version)
+			return err
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "action must be acknowledge, resolve or dismiss",
+		})
+	}
+	if len(req.AlertIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "alert_ids is required",
+		})
+	}
+	if len(req.AlertIDs) > maxBulkAlerts {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("at most %d alerts can be changed at once", maxBulkAlerts),
+		})
+	}
+
+	anyVersion := strings.TrimSpace(c.Get(fiber.HeaderIfMatch)) == "*"
+
+	ids := make([]uuid.UUID, 0, len(req.AlertIDs))
+	for _, raw := range req.AlertIDs {
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	var found []models.Alert
+	query := h.accessService.ScopeQuery(database.GetDB().Model(&models.Alert{}), "portfolio_id", userID, role)
+	if err := query.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve alerts",
+		})
+	}
+	alertsByID := make(map[uuid.UUID]models.Alert, len(found))
+	for _, alert := range found {
+		alertsByID[alert.ID] = alert
+	}
+
+	results := make([]BulkAlertResult, 0, len(req.AlertIDs))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	succeeded := 0
+	for _, raw := range req.AlertIDs {
+		result := BulkAlertResult{AlertID: raw}
+
+		alertID, err := uuid.Parse(raw)
+		alert, ok := alertsByID[alertID]
+		switch {
+		case err != nil:
+			result.Error = "Invalid alert ID"
+		case seen[alertID]:
+			result.Error = "Duplicate alert ID"
+		case !ok:
+			result.Error = "Alert not found"
+		default:
+			seen[alertID] = true
+			if reason := bulkActionBlocked(req.Action, alert.Status); reason != "" {
+				result.Error = reason
+				result.Status = alert.Status
+				break
+			}
+			version, listed := req.Versions[raw]
+			switch {
+			case listed && version < 1:
+				result.Error = "version must be a version the alert was read with"
+				result.Status = alert.Status
+			case !listed && anyVersion:
+				version = models.AnyVersion
+			case !listed:
+				result.Error = "Send the version the alert was read with in versions, or If-Match: * to change it at any version"
+				result.Code = apperror.CodePreconditionRequired
+				result.Status = alert.Status
+			}
+			if result.Error != "" {
+				break
+			}
+			if err := apply(alertID, version); err != nil {
+				result.Error = "Failed to " + req.Action + " alert"
+				result.Status = alert.Status
+				var appErr *apperror.Error
+				if errors.As(err, &appErr) && appErr.Code == apperror.CodeVersionConflict {
+					result.Error = appErr.Message
+					result.Code = appErr.Code
+				}
+				break
+			}
+
+			after := h.alertSnapshot(alertID)
+			recordAudit(c, h.auditService, "alert."+req.Action, "alert", alertID.String(), services.Snapshot(alert), after)
+			result.Success = true
+			result.Status, _ = after["status"].(string)
+			succeeded++
+		}
+
+		results = append(results, result)
+	}
+
+	return c.JSON(fiber.Map{
+		"action":    req.Action,
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// bulkActionBlocked explains why an alert in status cannot take action, or returns ""
+func bulkActionBlocked(action, status string) string {
+	switch status {
+	case "RESOLVED", "DISMISSED":
+		return "Alert is already " + strings.ToLower(status)
+	case "ACKNOWLEDGED":
+		if action == "acknowledge" {
+			return "Alert is already acknowledged"
+		}
+	}
+	return ""
+}
+
+// AcknowledgePortfolioAlerts acknowledges every active alert of a portfolio
+func (h *AlertHandler) AcknowledgePortfolioAlerts(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	acknowledged, err := h.alertManager.AcknowledgePortfolioAlerts(portfolioID, userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to resolve alert",
+			"error": "Failed to acknowledge alerts",
 		})
 	}
 
+	alertIDs := make([]uuid.UUID, len(acknowledged))
+	for i, alert := range acknowledged {
+		alertIDs[i] = alert.ID
+		recordAudit(c, h.auditService, "alert.acknowledge", "alert", alert.ID.String(), fiber.Map{"status": "ACTIVE"}, services.Snapshot(alert))
+	}
+
 	return c.JSON(fiber.Map{
-		"message": "Alert resolved successfully",
+		"message":      fmt.Sprintf("%d alerts acknowledged", len(acknowledged)),
+		"acknowledged": len(acknowledged),
+		"alert_ids":    alertIDs,
 	})
 }
 
@@ -158,7 +474,52 @@ func (h *AlertHandler) DeleteAlert(c *fiber.Ctx) error {
 		})
 	}
 
+	recordAudit(c, h.auditService, "alert.delete", "alert", alertID, alert, nil)
+
 	return c.JSON(fiber.Map{
 		"message": "Alert deleted successfully",
 	})
 }
+
+// RestoreAlert undeletes a soft deleted alert
+func (h *AlertHandler) RestoreAlert(c *fiber.Ctx) error {
+	alertID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid alert ID",
+		})
+	}
+
+	alert, err := h.alertService.RestoreAlert(alertID)
+	if err != nil {
+		switch err.Error() {
+		case "deleted alert not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Deleted alert not found",
+			})
+		case "portfolio is deleted; restore the portfolio first":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore alert",
+		})
+	}
+
+	recordAudit(c, h.auditService, "alert.restore", "alert", alert.ID.String(), nil, alert)
+
+	return c.JSON(fiber.Map{
+		"message": "Alert restored successfully",
+		"data":    alert,
+	})
+}
+
+// alertSnapshot loads the current state of an alert for the audit trail
+func (h *AlertHandler) alertSnapshot(alertID uuid.UUID) models.JSON {
+	var alert models.Alert
+	if err := database.GetDB().First(&alert, alertID).Error; err != nil {
+		return nil
+	}
+	return services.Snapshot(alert)
+}
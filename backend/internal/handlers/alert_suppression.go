@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// alertSuppressionListSpec lists the filters and sort fields the suppression window listing accepts
+var alertSuppressionListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"starts_at":  "starts_at",
+		"ends_at":    "ends_at",
+		"created_at": "created_at",
+	},
+	DefaultSort: "starts_at",
+	DateColumn:  "starts_at",
+	Filters: map[string]string{
+		"portfolio_id": "portfolio_id",
+		"rule_id":      "rule_id",
+		"source":       "source",
+	},
+}
+
+// GetSuppressions returns a page of the alert suppression windows covering the user's portfolios,
+// including those covering every portfolio; ?active=true keeps the windows open now
+func (h *AlertHandler) GetSuppressions(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	params, err := pagination.Parse(c, alertSuppressionListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	for _, filter := range []string{"portfolio_id", "rule_id"} {
+		if value, ok := params.Filters[filter]; ok {
+			if _, err := uuid.Parse(value); err != nil {
+				return apperror.BadRequest("Invalid " + filter)
+			}
+		}
+	}
+
+	ids, all, err := h.accessService.AccessiblePortfolioIDs(userID, role)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve suppression windows", err)
+	}
+	query := database.GetDB().Model(&models.AlertSuppression{})
+	if !all {
+		query = query.Where("portfolio_id IS NULL OR portfolio_id IN ?", ids)
+	}
+	if c.QueryBool("active") {
+		now := time.Now()
+		query = query.Where("starts_at <= ? AND ends_at > ? AND cancelled_at IS NULL", now, now)
+	}
+
+	suppressions, total, err := h.suppressionService.List(query, alertSuppressionListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve suppression windows", err)
+	}
+
+	return c.JSON(pagination.Response(suppressions, total, params))
+}
+
+// CreateSuppression opens a window during which alerts of a portfolio, compliance rule or alert
+// source are raised as SUPPRESSED instead of ACTIVE. Only global roles may open a window without
+// a portfolio, which covers every portfolio.
+func (h *AlertHandler) CreateSuppression(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	var req services.AlertSuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+	if err := h.checkSuppressionAccess(userID, role, req.PortfolioID, "Portfolio not found"); err != nil {
+		return err
+	}
+
+	suppression, err := h.suppressionService.Create(req, userID)
+	if err != nil {
+		return suppressionError(err, "Failed to create suppression window")
+	}
+
+	recordAudit(c, h.auditService, "alert_suppression.create", "alert_suppression", suppression.ID.String(), nil, suppression)
+
+	return c.Status(fiber.StatusCreated).JSON(suppression)
+}
+
+// GetSuppression returns an alert suppression window
+func (h *AlertHandler) GetSuppression(c *fiber.Ctx) error {
+	suppression, err := h.accessibleSuppression(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(suppression)
+}
+
+// CancelSuppression ends an alert suppression window early, or calls off one that has not started.
+// Alerts it already suppressed keep their status.
+func (h *AlertHandler) CancelSuppression(c *fiber.Ctx) error {
+	before, err := h.accessibleSuppression(c)
+	if err != nil {
+		return err
+	}
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+	if before.PortfolioID == nil {
+		if err := h.checkSuppressionAccess(userID, role, nil, ""); err != nil {
+			return err
+		}
+	}
+
+	suppression, err := h.suppressionService.Cancel(before.ID, userID)
+	if err != nil {
+		return suppressionError(err, "Failed to cancel suppression window")
+	}
+
+	recordAudit(c, h.auditService, "alert_suppression.cancel", "alert_suppression", suppression.ID.String(), before, suppression)
+
+	return c.JSON(suppression)
+}
+
+// accessibleSuppression loads the suppression window in the id route parameter if the user may see it
+func (h *AlertHandler) accessibleSuppression(c *fiber.Ctx) (*models.AlertSuppression, error) {
+	suppressionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest("Invalid suppression window ID")
+	}
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return nil, apperror.Unauthorized("Invalid user ID")
+	}
+
+	suppression, err := h.suppressionService.Get(suppressionID)
+	if err != nil {
+		return nil, suppressionError(err, "Failed to retrieve suppression window")
+	}
+	// Windows covering every portfolio are visible to all
+	if suppression.PortfolioID != nil {
+		if err := h.checkSuppressionAccess(userID, role, suppression.PortfolioID, "Suppression window not found"); err != nil {
+			return nil, err
+		}
+	}
+	return suppression, nil
+}
+
+// checkSuppressionAccess lets a user manage the windows of the portfolios they can access, answering
+// notFound rather than 403 for the others, and global roles those covering every portfolio. Those
+// span tenants, so users in a tenant may not manage them.
+func (h *AlertHandler) checkSuppressionAccess(userID uuid.UUID, role string, portfolioID *uuid.UUID, notFound string) error {
+	if portfolioID == nil {
+		if !services.HasGlobalScope(role) {
+			return apperror.Forbidden("Only administrators and compliance officers may suppress alerts across every portfolio")
+		}
+		tenantID, err := h.accessService.TenantOf(userID)
+		if err != nil {
+			return apperror.Internal("Failed to check access", err)
+		}
+		if tenantID != nil {
+			return apperror.Forbidden("Windows covering every portfolio span tenants; suppress alerts per portfolio instead")
+		}
+		return nil
+	}
+
+	allowed, err := h.accessService.CanAccessPortfolio(userID, role, *portfolioID)
+	if err != nil {
+		return apperror.Internal("Failed to check access", err)
+	}
+	if !allowed {
+		return apperror.NotFound(notFound)
+	}
+	return nil
+}
+
+// suppressionError passes on the service's bad request, not found and conflict errors
+func suppressionError(err error, message string) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Internal(message, err)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type AllocationHandler struct {
+	allocation   *services.AllocationService
+	auditService *services.AuditService
+}
+
+func NewAllocationHandler() *AllocationHandler {
+	return &AllocationHandler{
+		allocation:   services.NewAllocationService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetTargets lists the weights a portfolio aims to hold by symbol and asset class
+func (h *AllocationHandler) GetTargets(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	targets, err := h.allocation.GetTargets(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve target allocation",
+		})
+	}
+
+	return c.JSON(targets)
+}
+
+// SetTargets replaces a portfolio's target allocation
+func (h *AllocationHandler) SetTargets(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req struct {
+		Targets []services.TargetAllocationInput `json:"targets"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before, err := h.allocation.GetTargets(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve target allocation",
+		})
+	}
+
+	targets, err := h.allocation.SetTargets(portfolioID, req.Targets, userID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "target_allocation.update", "portfolio", portfolioID.String(), before, targets)
+
+	return c.JSON(fiber.Map{
+		"message": "Target allocation updated successfully",
+		"data":    targets,
+	})
+}
+
+// GetDrift compares a portfolio's current weights with its target allocation and suggests the
+// trades that would rebalance the targets drifted beyond tolerance
+func (h *AllocationHandler) GetDrift(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.allocation.GetDrift(c.UserContext(), portfolioID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate allocation drift",
+		})
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	auditService  *services.AuditService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// GetAPIKeys lists API keys, optionally only those acting as one user (user_id query param)
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	var userID *uuid.UUID
+	if param := c.Query("user_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		userID = &id
+	}
+
+	keys, err := h.apiKeyService.ListKeys(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve API keys",
+		})
+	}
+
+	return c.JSON(keys)
+}
+
+// CreateAPIKey mints an API key. The key is only returned in this response.
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	callerID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req services.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	ownerID := callerID
+	if req.UserID != nil {
+		ownerID = *req.UserID
+	}
+	owner, err := h.apiKeyService.GetOwner(ownerID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// A key never holds more than its owner's role grants
+	for _, scope := range req.Scopes {
+		if !middleware.IsPermission(scope) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown scope: " + scope,
+			})
+		}
+		if !middleware.HasPermission(owner.Role, middleware.Permission(scope)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Scope " + scope + " is not granted to the owner's role",
+			})
+		}
+	}
+
+	created, err := h.apiKeyService.CreateKey(req, owner, callerID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "api_key.create", "api_key", created.APIKey.ID.String(), nil, created.APIKey)
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// RevokeAPIKey stops an API key from authenticating
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid API key ID",
+		})
+	}
+
+	key, err := h.apiKeyService.RevokeKey(keyID)
+	if err != nil {
+		if err.Error() == "API key not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke API key",
+		})
+	}
+
+	recordAudit(c, h.auditService, "api_key.revoke", "api_key", key.ID.String(), nil, fiber.Map{"revoked_at": key.RevokedAt})
+
+	return c.JSON(fiber.Map{
+		"message": "API key revoked successfully",
+		"data":    key,
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	maxAuditExport    = 10000
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetAuditLogs returns audit entries matching the query filters
+func (h *AuditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	filter.Limit = c.QueryInt("limit", defaultAuditLimit)
+	if filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	filter.Offset = c.QueryInt("offset", 0)
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	logs, total, err := h.auditService.List(userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve audit logs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   logs,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// ExportAuditLogs downloads audit entries matching the query filters as CSV (default) or JSON
+func (h *AuditHandler) ExportAuditLogs(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	filter.Limit = maxAuditExport
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported export format, use csv or json",
+		})
+	}
+
+	logs, _, err := h.auditService.List(userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve audit logs",
+		})
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		return c.JSON(logs)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	return writeAuditCSV(c, logs)
+}
+
+func writeAuditCSV(c *fiber.Ctx, logs []models.AuditLog) error {
+	w := csv.NewWriter(c)
+	header := []string{"id", "created_at", "user_id", "user_email", "user_role", "action", "entity_type", "entity_id", "ip_address", "before", "after"}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, entry := range logs {
+		userID := ""
+		if entry.UserID != nil {
+			userID = entry.UserID.String()
+		}
+		record := []string{
+			entry.ID.String(),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			userID,
+			entry.UserEmail,
+			entry.UserRole,
+			entry.Action,
+			entry.EntityType,
+			entry.EntityID,
+			entry.IPAddress,
+			jsonString(entry.Before),
+			jsonString(entry.After),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func jsonString(v models.JSON) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// parseAuditFilter reads user_id, action, entity_type, entity_id, from and to (RFC3339) query params
+func parseAuditFilter(c *fiber.Ctx) (services.AuditFilter, error) {
+	filter := services.AuditFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return filter, errors.New("invalid user_id")
+		}
+		filter.UserID = &id
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, errors.New("invalid from, expected RFC3339")
+		}
+		filter.From = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, errors.New("invalid to, expected RFC3339")
+		}
+		filter.To = &t
+	}
+
+	return filter, nil
+}
+
+// recordAudit writes a detailed audit entry for the current request. Failures are logged rather than
+// surfaced so an audit outage does not undo a change that has already been committed.
+func recordAudit(c *fiber.Ctx, auditService *services.AuditService, action, entityType, entityID string, before, after interface{}) {
+	c.Locals(middleware.AuditRecordedKey, true)
+
+	if err := auditService.Record(middleware.AuditActor(c), action, entityType, entityID, before, after); err != nil {
+		logging.Component("audit").ErrorContext(c.UserContext(), "Failed to record audit entry",
+			"action", action, "entity_type", entityType, "entity_id", entityID, "error", err)
+	}
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
 )
 
 type AuthHandler struct {
@@ -25,6 +30,9 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
 
 	user, err := h.authService.Register(req)
 	if err != nil {
@@ -48,8 +56,74 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	response, err := h.authService.Login(req, clientInfo(c))
+	if err != nil {
+		if err.Error() == "password reset required" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Password reset required; set a new password with /auth/change-password",
+			})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// ChangePassword replaces the password of the user whose current password is supplied
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	var req services.ChangePasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if err := h.authService.ChangePassword(req); err != nil {
+		switch err.Error() {
+		case "invalid credentials", "account is disabled":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.HasPrefix(err.Error(), "new password") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to change password",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Password changed successfully",
+	})
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req services.RefreshRequest
 
-	response, err := h.authService.Login(req)
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	response, err := h.authService.Refresh(req.RefreshToken, clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": err.Error(),
@@ -58,3 +132,95 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	return c.JSON(response)
 }
+
+// Logout revokes the current access token and the supplied refresh token
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req services.RefreshRequest
+	// The refresh token is optional; an empty body only revokes the access token
+	_ = c.BodyParser(&req)
+
+	jti, _ := c.Locals("jti").(string)
+	exp, _ := c.Locals("token_exp").(float64)
+
+	if err := h.authService.Logout(jti, time.Unix(int64(exp), 0), req.RefreshToken); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to log out",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+// SetUserStatus enables or disables a user account
+func (h *AuthHandler) SetUserStatus(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		IsActive *bool `json:"is_active"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.IsActive == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "is_active is required",
+		})
+	}
+
+	user, err := h.authService.SetUserActive(userID, *req.IsActive)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update user status",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User status updated successfully",
+		"data":    user,
+	})
+}
+
+// RevokeUserSessions signs a user out of every session
+func (h *AuthHandler) RevokeUserSessions(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := h.authService.RevokeUserSessions(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke sessions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User sessions revoked successfully",
+	})
+}
+
+// currentUser returns the authenticated user's ID and role
+func currentUser(c *fiber.Ctx) (uuid.UUID, string, error) {
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+
+	id, err := uuid.Parse(userID)
+	return id, role, err
+}
+
+func clientInfo(c *fiber.Ctx) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Get("User-Agent"),
+		IPAddress: c.IP(),
+	}
+}
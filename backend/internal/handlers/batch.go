@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type BatchHandler struct {
+	batchService *services.BatchService
+	auditService *services.AuditService
+}
+
+func NewBatchHandler(cfg *config.BatchConfig, riskCfg *config.RiskConfig, complianceCfg *config.ComplianceConfig) *BatchHandler {
+	return &BatchHandler{
+		batchService: services.NewBatchService(cfg, riskCfg, complianceCfg),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// batchRunListSpec lists the filters and sort fields the batch run listing accepts
+var batchRunListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"started_at":    "started_at",
+		"business_date": "business_date",
+	},
+	DefaultSort: "started_at",
+	DateColumn:  "started_at",
+	Filters: map[string]string{
+		"pipeline": "pipeline",
+		"status":   "status",
+		"source":   "source",
+	},
+}
+
+// GetBatchRuns returns a page of batch runs, newest first by default
+func (h *BatchHandler) GetBatchRuns(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, batchRunListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	runs, total, err := h.batchService.ListRuns(batchRunListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve batch runs", err)
+	}
+
+	return c.JSON(pagination.Response(runs, total, params))
+}
+
+// StartBatchRun starts the end-of-day batch and returns the run with its jobs pending; poll
+// GetBatchRun for progress. Only one run may be in progress at a time.
+func (h *BatchHandler) StartBatchRun(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	run, err := h.batchService.StartEOD(userID)
+	if err != nil {
+		return batchError(err, "Failed to start the batch run")
+	}
+
+	recordAudit(c, h.auditService, "batch_run.start", "batch_run", run.ID.String(), nil, run)
+
+	return c.Status(fiber.StatusAccepted).JSON(run)
+}
+
+// GetBatchRun returns a batch run with the status, attempts and output of each of its jobs
+func (h *BatchHandler) GetBatchRun(c *fiber.Ctx) error {
+	runID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid batch run ID")
+	}
+
+	run, err := h.batchService.GetRun(runID)
+	if err != nil {
+		return batchError(err, "Failed to retrieve batch run")
+	}
+
+	return c.JSON(run)
+}
+
+// batchError passes on the service's not found and conflict errors
+func batchError(err error, message string) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Internal(message, err)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type BenchmarkHandler struct {
+	performance  *services.PerformanceService
+	auditService *services.AuditService
+}
+
+func NewBenchmarkHandler() *BenchmarkHandler {
+	return &BenchmarkHandler{
+		performance:  services.NewPerformanceService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetPrices returns the daily closes of a benchmark between ?from= and ?to= (YYYY-MM-DD), the
+// last year by default
+func (h *BenchmarkHandler) GetPrices(c *fiber.Ctx) error {
+	to := time.Now().UTC()
+	from := to.AddDate(-1, 0, 0)
+	var err error
+	if param := c.Query("from"); param != "" {
+		if from, err = time.Parse("2006-01-02", param); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "from must be a YYYY-MM-DD date",
+			})
+		}
+	}
+	if param := c.Query("to"); param != "" {
+		if to, err = time.Parse("2006-01-02", param); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must be a YYYY-MM-DD date",
+			})
+		}
+	}
+
+	prices, err := h.performance.GetPrices(c.Params("symbol"), from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve benchmark prices",
+		})
+	}
+
+	return c.JSON(prices)
+}
+
+// RecordPrices loads daily closes of a benchmark, such as its history before the price feed
+// started quoting it
+func (h *BenchmarkHandler) RecordPrices(c *fiber.Ctx) error {
+	var req struct {
+		Prices []services.BenchmarkPriceInput `json:"prices"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	prices, err := h.performance.RecordPrices(c.Params("symbol"), req.Prices)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "benchmark_prices.record", "benchmark", prices[0].Symbol, nil, fiber.Map{
+		"prices": len(prices),
+		"from":   prices[0].Date,
+		"to":     prices[len(prices)-1].Date,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Benchmark prices recorded successfully",
+		"count":   len(prices),
+	})
+}
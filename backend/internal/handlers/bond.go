@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type BondHandler struct {
+	fixedIncome  *services.FixedIncomeService
+	auditService *services.AuditService
+}
+
+func NewBondHandler() *BondHandler {
+	return &BondHandler{
+		fixedIncome:  services.NewFixedIncomeService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetBonds lists the bond reference data interest rate risk is measured from
+func (h *BondHandler) GetBonds(c *fiber.Ctx) error {
+	bonds, err := h.fixedIncome.ListBonds()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve bonds",
+		})
+	}
+
+	return c.JSON(bonds)
+}
+
+// UpsertBond sets the coupon, maturity, face value and credit rating of a bond
+func (h *BondHandler) UpsertBond(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req services.BondReferenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	bond, err := h.fixedIncome.UpsertBond(c.Params("symbol"), req, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "bond.update", "bond_reference_data", bond.Symbol, nil, bond)
+
+	return c.JSON(fiber.Map{
+		"message": "Bond reference data updated successfully",
+		"data":    bond,
+	})
+}
+
+// DeleteBond removes the reference data of a bond
+func (h *BondHandler) DeleteBond(c *fiber.Ctx) error {
+	symbol := strings.ToUpper(c.Params("symbol"))
+	if err := h.fixedIncome.DeleteBond(symbol); err != nil {
+		if err.Error() == "bond not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Bond not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete bond",
+		})
+	}
+
+	recordAudit(c, h.auditService, "bond.delete", "bond_reference_data", symbol, nil, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Bond reference data deleted successfully",
+	})
+}
@@ -0,0 +1,656 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type CaseHandler struct {
+	caseService  *services.CaseService
+	auditService *services.AuditService
+}
+
+func NewCaseHandler() *CaseHandler {
+	return &CaseHandler{
+		caseService:  services.NewCaseService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// caseListSpec lists the filters and sort fields GetCases accepts
+var caseListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"priority":   "priority",
+		"status":     "status",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"status":      "status",
+		"priority":    "priority",
+		"assignee_id": "assignee_id",
+		"alert_id":    "alert_id",
+	},
+}
+
+// GetCases returns a page of cases
+func (h *CaseHandler) GetCases(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	params, err := pagination.Parse(c, caseListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	cases, total, err := h.caseService.ListCases(userID, caseListSpec, params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve cases",
+		})
+	}
+
+	return c.JSON(pagination.Response(cases, total, params))
+}
+
+// GetCase returns a case with its transactions, timeline and evidence
+func (h *CaseHandler) GetCase(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	caseRecord, err := h.caseService.GetCase(caseID, userID)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	return c.JSON(caseRecord)
+}
+
+// EscalateCase opens a case from an alert and/or flagged transactions
+func (h *CaseHandler) EscalateCase(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Title          string   `json:"title"`
+		Description    string   `json:"description"`
+		Priority       string   `json:"priority"`
+		AlertID        string   `json:"alert_id"`
+		TransactionIDs []string `json:"transaction_ids"`
+		AssigneeID     string   `json:"assignee_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	escalation := services.EscalateCaseRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+	}
+	if escalation.AlertID, err = parseOptionalUUID(req.AlertID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid alert_id",
+		})
+	}
+	if escalation.AssigneeID, err = parseOptionalUUID(req.AssigneeID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid assignee_id",
+		})
+	}
+	if escalation.TransactionIDs, err = parseUUIDs(req.TransactionIDs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction_ids",
+		})
+	}
+
+	caseRecord, err := h.caseService.EscalateCase(escalation, userID, role)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.escalate", "case", caseRecord.ID.String(), nil, caseSnapshot(caseRecord))
+
+	return c.Status(fiber.StatusCreated).JSON(caseRecord)
+}
+
+// UpdateCase edits a case's details and SAR narrative
+func (h *CaseHandler) UpdateCase(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		Priority    *string `json:"priority"`
+		Narrative   *string `json:"narrative"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := h.caseSnapshot(caseID, userID)
+
+	caseRecord, err := h.caseService.UpdateCase(caseID, userID, services.CaseUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		Narrative:   req.Narrative,
+	})
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.update", "case", caseID.String(), before, caseSnapshot(caseRecord))
+
+	return c.JSON(fiber.Map{
+		"message": "Case updated successfully",
+		"data":    caseRecord,
+	})
+}
+
+// AssignCase assigns a case to an investigator
+func (h *CaseHandler) AssignCase(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		AssigneeID string `json:"assignee_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	assigneeID, err := uuid.Parse(req.AssigneeID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid assignee_id",
+		})
+	}
+
+	before := h.caseSnapshot(caseID, userID)
+
+	caseRecord, err := h.caseService.AssignCase(caseID, assigneeID, userID)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.assign", "case", caseID.String(), before, caseSnapshot(caseRecord))
+
+	return c.JSON(fiber.Map{
+		"message": "Case assigned successfully",
+		"data":    caseRecord,
+	})
+}
+
+// UpdateCaseStatus moves a case through OPEN -> INVESTIGATING -> FILED/DISMISSED
+func (h *CaseHandler) UpdateCaseStatus(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Status          string `json:"status"`
+		Reason          string `json:"reason"`
+		Narrative       string `json:"narrative"`
+		FilingReference string `json:"filing_reference"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := h.caseSnapshot(caseID, userID)
+
+	caseRecord, err := h.caseService.TransitionCase(caseID, services.CaseTransition{
+		Status:          req.Status,
+		Reason:          req.Reason,
+		Narrative:       req.Narrative,
+		FilingReference: req.FilingReference,
+	}, userID)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	action := "case." + strings.ToLower(caseRecord.Status)
+	recordAudit(c, h.auditService, action, "case", caseID.String(), before, caseSnapshot(caseRecord))
+
+	return c.JSON(fiber.Map{
+		"message": "Case status updated successfully",
+		"data":    caseRecord,
+	})
+}
+
+// AddCaseNote adds an investigation note to a case
+func (h *CaseHandler) AddCaseNote(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	note, err := h.caseService.AddNote(caseID, userID, req.Body)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.note_add", "case", caseID.String(), nil, note)
+
+	return c.Status(fiber.StatusCreated).JSON(note)
+}
+
+// AddCaseTransactions links further transactions to a case
+func (h *CaseHandler) AddCaseTransactions(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		TransactionIDs []string `json:"transaction_ids"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	transactionIDs, err := parseUUIDs(req.TransactionIDs)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction_ids",
+		})
+	}
+
+	caseRecord, err := h.caseService.AddTransactions(caseID, transactionIDs, userID, role)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.transactions_add", "case", caseID.String(), nil, models.JSON{"transaction_ids": req.TransactionIDs})
+
+	return c.JSON(caseRecord)
+}
+
+// UploadEvidence attaches a file (multipart field "file") to a case
+func (h *CaseHandler) UploadEvidence(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing file upload",
+		})
+	}
+	if fileHeader.Size > services.MaxEvidenceSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Evidence file exceeds %d bytes", services.MaxEvidenceSize),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, services.MaxEvidenceSize+1))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read uploaded file",
+		})
+	}
+
+	contentType := fileHeader.Header.Get(fiber.HeaderContentType)
+	evidence, err := h.caseService.AddEvidence(caseID, userID, fileHeader.Filename, contentType, c.FormValue("description"), content)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.evidence_add", "case", caseID.String(), nil, evidence)
+
+	return c.Status(fiber.StatusCreated).JSON(evidence)
+}
+
+// DownloadEvidence returns an evidence file
+func (h *CaseHandler) DownloadEvidence(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	evidenceID, err := uuid.Parse(c.Params("evidenceId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid evidence ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	evidence, err := h.caseService.GetEvidence(caseID, evidenceID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Evidence not found",
+		})
+	}
+
+	contentType := evidence.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", evidence.FileName))
+	c.Set("X-Content-SHA256", evidence.SHA256)
+	return c.Send(evidence.Content)
+}
+
+// ExportSARReport downloads the SAR document for a case as plain text (default) or JSON
+func (h *CaseHandler) ExportSARReport(c *fiber.Ctx) error {
+	caseID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid case ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	format := c.Query("format", "txt")
+	if format != "txt" && format != "json" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported export format, use txt or json",
+		})
+	}
+
+	report, err := h.caseService.BuildSARReport(caseID, userID)
+	if err != nil {
+		return caseError(c, err)
+	}
+
+	recordAudit(c, h.auditService, "case.report_export", "case", caseID.String(), nil, models.JSON{"format": format})
+
+	filename := fmt.Sprintf("sar-%s.%s", report.CaseNumber, format)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		return c.JSON(report)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return writeSARText(c, report)
+}
+
+func writeSARText(w io.Writer, report *services.SARReport) error {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+	section := func(title string) {
+		line("")
+		line("%s", title)
+		line("%s", strings.Repeat("-", len(title)))
+	}
+	timestamp := func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	line("SUSPICIOUS ACTIVITY REPORT")
+	line("==========================")
+	line("Case number:      %s", report.CaseNumber)
+	line("Title:            %s", report.Title)
+	line("Status:           %s", report.Status)
+	line("Priority:         %s", report.Priority)
+	line("Opened:           %s", timestamp(report.OpenedAt))
+	if report.FiledAt != nil {
+		line("Filed:            %s", timestamp(*report.FiledAt))
+	}
+	if report.FilingReference != "" {
+		line("Filing reference: %s", report.FilingReference)
+	}
+	if report.Investigator != "" {
+		line("Investigator:     %s", report.Investigator)
+	}
+	line("Generated:        %s", timestamp(report.GeneratedAt))
+
+	if report.Alert != nil {
+		section("Originating alert")
+		line("%s [%s/%s] raised %s by %s", report.Alert.Title, report.Alert.Type, report.Alert.Severity, timestamp(report.Alert.RaisedAt), report.Alert.Source)
+		if report.Alert.Summary != "" {
+			line("%s", report.Alert.Summary)
+		}
+	}
+
+	section("Activity summary")
+	if report.Activity.From != nil && report.Activity.To != nil {
+		line("Period:       %s to %s", timestamp(*report.Activity.From), timestamp(*report.Activity.To))
+	}
+	line("Transactions: %d across %d portfolio(s)", report.Activity.TransactionCount, report.Activity.PortfolioCount)
+	line("Total amount: %s %s", report.Activity.TotalAmount.StringFixed(2), strings.Join(report.Activity.Currencies, "/"))
+
+	section("Subjects")
+	if len(report.Subjects) == 0 {
+		line("No counterparties recorded")
+	}
+	for _, subject := range report.Subjects {
+		details := subject.Country
+		if subject.LEI != "" {
+			details += ", LEI " + subject.LEI
+		}
+		if subject.RiskRating != "" {
+			details += fmt.Sprintf(", risk %s, KYC %s", subject.RiskRating, subject.KYCStatus)
+		}
+		line("- %s (%s)", subject.Name, details)
+	}
+
+	section("Transactions")
+	for _, t := range report.Transactions {
+		line("- %s  %s %s %s %s  %s  counterparty=%q risk=%d  [%s]",
+			timestamp(t.Date), t.Type, t.Symbol, t.Amount.StringFixed(2), t.Currency, t.Status, t.Counterparty, t.RiskScore, t.ID)
+	}
+
+	section("Description")
+	line("%s", report.Description)
+
+	section("Narrative")
+	line("%s", report.Narrative)
+
+	section("Investigation timeline")
+	for _, note := range report.Timeline {
+		line("- %s  %-13s %s: %s", timestamp(note.At), note.Kind, note.Author, note.Body)
+	}
+
+	section("Evidence")
+	if len(report.Evidence) == 0 {
+		line("No evidence attached")
+	}
+	for _, evidence := range report.Evidence {
+		line("- %s (%d bytes, sha256 %s, uploaded %s)", evidence.FileName, evidence.Size, evidence.SHA256, timestamp(evidence.UploadedAt))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// caseSnapshot captures a case's own fields for the audit log, without its relations
+func caseSnapshot(caseRecord *models.Case) models.JSON {
+	summary := *caseRecord
+	summary.Assignee = nil
+	summary.Alert = nil
+	summary.Transactions = nil
+	summary.Notes = nil
+	summary.Evidence = nil
+	return services.Snapshot(summary)
+}
+
+func (h *CaseHandler) caseSnapshot(caseID, userID uuid.UUID) interface{} {
+	caseRecord, err := h.caseService.GetCase(caseID, userID)
+	if err != nil {
+		return nil
+	}
+	return caseSnapshot(caseRecord)
+}
+
+// caseError maps case service errors onto HTTP responses
+func caseError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, services.ErrCaseNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, services.ErrCaseClosed), errors.Is(err, services.ErrInvalidCaseTransition):
+		status = fiber.StatusConflict
+	case err.Error() == "alert not found", err.Error() == "transaction not found", err.Error() == "assignee not found":
+		status = fiber.StatusNotFound
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseUUIDs(values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -1,16 +1,50 @@
 package handlers
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/screening"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
 )
 
 type ComplianceHandler struct {
-	// Add compliance service when implemented
+	screener        *screening.Screener
+	amlService      *services.AMLService
+	preTradeService *services.PreTradeService
+	accessService   *services.AccessService
 }
 
 func NewComplianceHandler() *ComplianceHandler {
-	return &ComplianceHandler{}
+	return &ComplianceHandler{
+		screener:        screening.GetScreener(),
+		amlService:      services.NewAMLService(),
+		preTradeService: services.NewPreTradeService(),
+		accessService:   services.NewAccessService(),
+	}
+}
+
+// PreTradeCheckRequest is a proposed order, with the fields of CreateTransactionRequest
+type PreTradeCheckRequest struct {
+	PortfolioID     string  `json:"portfolio_id" validate:"required"`
+	TransactionType string  `json:"transaction_type" validate:"required"` // BUY or SELL
+	Symbol          string  `json:"symbol" validate:"notblank"`
+	Quantity        float64 `json:"quantity" validate:"gt=0"`
+	Price           float64 `json:"price" validate:"gte=0"`
+	Currency        string  `json:"currency"`
+	AssetType       string  `json:"asset_type"`
+	CreditRating    string  `json:"credit_rating"`
+
+	CounterpartyID      string `json:"counterparty_id"`
+	CounterpartyName    string `json:"counterparty_name"`
+	CounterpartyCountry string `json:"counterparty_country"`
 }
 
 // CheckCompliance performs compliance checks for a portfolio
@@ -78,25 +112,315 @@ func (h *ComplianceHandler) CheckPositionLimits(c *fiber.Ctx) error {
 	})
 }
 
-// CheckAML performs AML check on a transaction
+// CheckAML performs AML checks and sanctions/PEP screening on a transaction
 func (h *ComplianceHandler) CheckAML(c *fiber.Ctx) error {
-	transactionID := c.Params("id")
-	if _, err := uuid.Parse(transactionID); err != nil {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid transaction ID",
 		})
 	}
 
-	// Placeholder implementation
+	var transaction models.Transaction
+	if err := database.GetDB().First(&transaction, transactionID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Transaction not found",
+		})
+	}
+
+	var screenedBy *uuid.UUID
+	if userID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		screenedBy = &userID
+	}
+
+	report, err := h.amlService.CheckTransaction(c.UserContext(), &transaction, screenedBy)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run AML checks",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"transaction_id": transactionID,
-		"status":         "PASSED",
-		"risk_score":     15,
+		"transaction_id": report.TransactionID,
+		"status":         report.Status,
+		"risk_score":     report.RiskScore,
+		"flags":          report.Flags,
 		"checks": []string{
 			"SANCTIONS_SCREENING",
 			"PEP_CHECK",
 			"TRANSACTION_MONITORING",
+			"COUNTERPARTY_CHECK",
 		},
-		"notes": "All AML checks passed successfully",
+		"screenings":   report.Screenings,
+		"counterparty": report.Counterparty,
+		"notes":        report.Notes,
 	})
 }
+
+// GetTransactionScreenings returns the screening history for a transaction
+func (h *ComplianceHandler) GetTransactionScreenings(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	results, err := h.screener.GetTransactionScreenings(transactionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve screening results",
+		})
+	}
+
+	return c.JSON(results)
+}
+
+// GetTransactionMonitoring returns the transaction monitoring evaluations of a transaction, with
+// the features, rules and anomaly score behind each
+func (h *ComplianceHandler) GetTransactionMonitoring(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	evaluations, err := h.amlService.GetEvaluations(transactionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve monitoring evaluations",
+		})
+	}
+
+	return c.JSON(evaluations)
+}
+
+// AMLSweep re-runs transaction monitoring over the last ?days of transactions (30 by default),
+// optionally of one ?portfolio_id in the user's tenant, and reports the transactions newly flagged
+// for review
+func (h *ComplianceHandler) AMLSweep(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var portfolioID *uuid.UUID
+	if id := c.Query("portfolio_id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid portfolio ID",
+			})
+		}
+		portfolioID = &parsed
+	}
+
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > services.MaxAMLSweepDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "days must be between 1 and " + strconv.Itoa(services.MaxAMLSweepDays),
+		})
+	}
+
+	result, err := h.amlService.Sweep(c.UserContext(), days, portfolioID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run AML sweep",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// ScreenName screens an arbitrary name against the sanctions and PEP lists
+func (h *ComplianceHandler) ScreenName(c *fiber.Ctx) error {
+	var req struct {
+		Name    string `json:"name" validate:"notblank,max=200"`
+		Country string `json:"country"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	var screenedBy *uuid.UUID
+	if userID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		screenedBy = &userID
+	}
+
+	result, err := h.screener.Screen(req.Name, req.Country, screening.SubjectAdHoc, nil, screenedBy)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to screen name",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// ImportSanctionsList imports an OFAC, EU, UN, PEP or internal list file
+func (h *ComplianceHandler) ImportSanctionsList(c *fiber.Ctx) error {
+	source := strings.ToUpper(c.FormValue("source"))
+	if source == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "List source is required",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "List file is required",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read list file",
+		})
+	}
+	defer file.Close()
+
+	entries, err := screening.ParseList(source, file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.screener.ImportList(source, entries); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import list",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "List imported successfully",
+		"source":   source,
+		"imported": len(entries),
+	})
+}
+
+// GetSanctionsEntries returns imported list entries
+func (h *ComplianceHandler) GetSanctionsEntries(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.screener.ListEntries(c.Query("source"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve sanctions entries",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// PreTradeCheck runs the KYC, sanctions, restricted and watch list and position limit checks on a proposed
+// order before it is routed. The response says whether the order passed and why not; a failed
+// check is still a 200, so order flows need only read "passed".
+func (h *ComplianceHandler) PreTradeCheck(c *fiber.Ctx) error {
+	var req PreTradeCheckRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	portfolioID, err := uuid.Parse(req.PortfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	order := models.Transaction{
+		PortfolioID:     portfolioID,
+		TransactionType: strings.ToUpper(req.TransactionType),
+		Symbol:          strings.ToUpper(req.Symbol),
+		Quantity:        decimal.NewFromFloat(req.Quantity),
+		Price:           decimal.NewFromFloat(req.Price),
+		Currency:        req.Currency,
+		AssetType:       strings.ToUpper(req.AssetType),
+		CreditRating:    strings.ToUpper(req.CreditRating),
+
+		CounterpartyName:    req.CounterpartyName,
+		CounterpartyCountry: req.CounterpartyCountry,
+	}
+	order.Amount = order.TradeAmount()
+	if order.Currency == "" {
+		order.Currency = "USD"
+	}
+
+	if req.CounterpartyID != "" {
+		counterpartyID, err := uuid.Parse(req.CounterpartyID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid counterparty ID",
+			})
+		}
+		order.CounterpartyID = &counterpartyID
+	}
+
+	if order.TransactionType != "BUY" && order.TransactionType != "SELL" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "transaction_type must be BUY or SELL",
+		})
+	}
+	if err := services.ValidateTransaction(&order); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	allowed, err := h.accessService.CanAccessPortfolio(userID, role, portfolioID)
+	if err != nil || !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Portfolio not found",
+		})
+	}
+
+	result, err := h.preTradeService.CheckOrder(c.UserContext(), &order, userID)
+	if err != nil {
+		if err.Error() == "counterparty not found" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Counterparty not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run pre-trade checks",
+		})
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type ComplianceRuleHandler struct {
+	ruleService  *services.ComplianceRuleService
+	auditService *services.AuditService
+}
+
+func NewComplianceRuleHandler() *ComplianceRuleHandler {
+	return &ComplianceRuleHandler{
+		ruleService:  services.NewComplianceRuleService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+type ComplianceRuleRequest struct {
+	Name        string   `json:"name" validate:"notblank"`
+	Description string   `json:"description"`
+	Scope       string   `json:"scope" validate:"required"`
+	Metric      string   `json:"metric" validate:"required"`
+	Parameter   string   `json:"parameter"`
+	Operator    string   `json:"operator" validate:"required"`
+	Threshold   *float64 `json:"threshold" validate:"required"`
+	Severity    string   `json:"severity" validate:"required"`
+	PortfolioID string   `json:"portfolio_id"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// GetRules returns all compliance rules
+func (h *ComplianceRuleHandler) GetRules(c *fiber.Ctx) error {
+	ruleList, err := h.ruleService.ListRules(c.Query("scope"), c.QueryBool("active_only", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve compliance rules",
+		})
+	}
+
+	return c.JSON(ruleList)
+}
+
+// GetRule returns a specific compliance rule
+func (h *ComplianceRuleHandler) GetRule(c *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	rule, err := h.ruleService.GetRule(ruleID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Rule not found",
+		})
+	}
+
+	return c.JSON(rule)
+}
+
+// CreateRule creates a new compliance rule
+func (h *ComplianceRuleHandler) CreateRule(c *fiber.Ctx) error {
+	var req ComplianceRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	if req.Threshold == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Threshold is required",
+		})
+	}
+
+	rule := models.ComplianceRule{IsActive: true}
+	if err := applyRuleRequest(&rule, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if userID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		rule.CreatedBy = &userID
+	}
+
+	if err := h.ruleService.CreateRule(&rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "compliance_rule.create", "compliance_rule", rule.ID.String(), nil, rule)
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateRule updates an existing compliance rule
+func (h *ComplianceRuleHandler) UpdateRule(c *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	var req ComplianceRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Partial(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	rule, err := h.ruleService.GetRule(ruleID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Rule not found",
+		})
+	}
+
+	before := services.Snapshot(rule)
+
+	if err := applyRuleRequest(rule, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.ruleService.UpdateRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "compliance_rule.update", "compliance_rule", rule.ID.String(), before, rule)
+
+	return c.JSON(fiber.Map{
+		"message": "Rule updated successfully",
+		"data":    rule,
+	})
+}
+
+// DeleteRule deletes a compliance rule
+func (h *ComplianceRuleHandler) DeleteRule(c *fiber.Ctx) error {
+	ruleID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	rule, err := h.ruleService.GetRule(ruleID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Rule not found",
+		})
+	}
+
+	if err := h.ruleService.DeleteRule(ruleID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Rule not found",
+		})
+	}
+
+	recordAudit(c, h.auditService, "compliance_rule.delete", "compliance_rule", ruleID.String(), rule, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Rule deleted successfully",
+	})
+}
+
+// EvaluateRules runs the rule engine immediately, optionally for a single portfolio
+func (h *ComplianceRuleHandler) EvaluateRules(c *fiber.Ctx) error {
+	var portfolioID *uuid.UUID
+	if id := c.Query("portfolio_id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid portfolio ID",
+			})
+		}
+		portfolioID = &parsed
+	}
+
+	result, err := h.ruleService.EvaluateAll(c.UserContext(), portfolioID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to evaluate compliance rules",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// applyRuleRequest copies the supplied request fields onto a rule
+func applyRuleRequest(rule *models.ComplianceRule, req ComplianceRuleRequest) error {
+	if req.Name != "" {
+		rule.Name = req.Name
+	}
+	if req.Description != "" {
+		rule.Description = req.Description
+	}
+	if req.Scope != "" {
+		rule.Scope = req.Scope
+	}
+	if req.Metric != "" {
+		rule.Metric = req.Metric
+	}
+	if req.Parameter != "" {
+		rule.Parameter = req.Parameter
+	}
+	if req.Operator != "" {
+		rule.Operator = req.Operator
+	}
+	if req.Threshold != nil {
+		rule.Threshold = decimal.NewFromFloat(*req.Threshold)
+	}
+	if req.Severity != "" {
+		rule.Severity = req.Severity
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if req.PortfolioID != "" {
+		portfolioID, err := uuid.Parse(req.PortfolioID)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid portfolio ID")
+		}
+		rule.PortfolioID = &portfolioID
+	}
+
+	return nil
+}
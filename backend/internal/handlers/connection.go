@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+	"github.com/Taf0711/financial-risk-monitor/internal/websocket"
+)
+
+// ConnectionHandler shows platform administrators the WebSocket and event stream clients connected
+// to the instance serving the request, and disconnects them. Each instance has its own clients.
+type ConnectionHandler struct {
+	hub           *websocket.Hub
+	instance      string
+	accessService *services.AccessService
+	auditService  *services.AuditService
+}
+
+func NewConnectionHandler(hub *websocket.Hub) *ConnectionHandler {
+	instance, _ := os.Hostname()
+	return &ConnectionHandler{
+		hub:           hub,
+		instance:      instance,
+		accessService: services.NewAccessService(),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+type DisconnectRequest struct {
+	Reason string `json:"reason" validate:"max=120"`
+}
+
+// GetConnections returns the clients connected to this instance, oldest first: who they are, where
+// they connect from, what they subscribed to and when they were last heard from. ?user_id= keeps
+// one user's.
+func (h *ConnectionHandler) GetConnections(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+
+	connections := h.hub.Connections()
+	if userID := c.Query("user_id"); userID != "" {
+		filtered := make([]websocket.ConnectionInfo, 0, len(connections))
+		for _, connection := range connections {
+			if connection.UserID == userID {
+				filtered = append(filtered, connection)
+			}
+		}
+		connections = filtered
+	}
+
+	return c.JSON(fiber.Map{
+		"instance":    h.instance,
+		"connections": connections,
+		"total":       len(connections),
+	})
+}
+
+// DisconnectConnection closes a client connected to this instance. A WebSocket client is sent the
+// reason in a policy violation close frame; it may reconnect unless its sessions are revoked too.
+func (h *ConnectionHandler) DisconnectConnection(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+	clientID := c.Params("id")
+
+	var req DisconnectRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.BadRequest("Invalid request body")
+		}
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+	if req.Reason == "" {
+		req.Reason = "disconnected by an administrator"
+	}
+
+	var before *websocket.ConnectionInfo
+	for _, connection := range h.hub.Connections() {
+		if connection.ClientID == clientID {
+			before = &connection
+			break
+		}
+	}
+	if before == nil || !h.hub.Disconnect(clientID, req.Reason) {
+		return apperror.NotFound("Connection not found on this instance")
+	}
+
+	recordAudit(c, h.auditService, "websocket.disconnect", "websocket_connection", clientID, before, fiber.Map{"reason": req.Reason})
+
+	return c.JSON(fiber.Map{
+		"message": "Connection closed",
+	})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type CorporateActionHandler struct {
+	actionService *services.CorporateActionService
+	auditService  *services.AuditService
+}
+
+func NewCorporateActionHandler() *CorporateActionHandler {
+	return &CorporateActionHandler{
+		actionService: services.NewCorporateActionService(),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// corporateActionListSpec lists the filters and sort fields the corporate action listing accepts
+var corporateActionListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"ex_date":    "ex_date",
+		"symbol":     "symbol",
+		"created_at": "created_at",
+	},
+	DefaultSort: "ex_date",
+	DateColumn:  "ex_date",
+	Filters: map[string]string{
+		"symbol":      "symbol",
+		"action_type": "action_type",
+		"status":      "status",
+	},
+}
+
+// GetCorporateActions returns a page of the corporate actions
+func (h *CorporateActionHandler) GetCorporateActions(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, corporateActionListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	query := database.GetDB().Model(&models.CorporateAction{})
+	actions, total, err := h.actionService.List(query, corporateActionListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve corporate actions", err)
+	}
+
+	return c.JSON(pagination.Response(actions, total, params))
+}
+
+// CreateCorporateAction records a split, dividend or symbol change as PENDING. It is applied by
+// the end-of-day batch once its ex-date is reached, or earlier on request.
+func (h *CorporateActionHandler) CreateCorporateAction(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	var req services.CorporateActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	action, err := h.actionService.Create(req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to create corporate action")
+	}
+
+	recordAudit(c, h.auditService, "corporate_action.create", "corporate_action", action.ID.String(), nil, action)
+
+	return c.Status(fiber.StatusCreated).JSON(action)
+}
+
+// GetCorporateAction returns a corporate action
+func (h *CorporateActionHandler) GetCorporateAction(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+
+	action, err := h.actionService.Get(actionID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve corporate action")
+	}
+
+	return c.JSON(action)
+}
+
+// GetCorporateActionAdjustments returns the positions, lots, orders, snapshots and dividends an
+// applied corporate action changed, with their values before and after
+func (h *CorporateActionHandler) GetCorporateActionAdjustments(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+	if _, err := h.actionService.Get(actionID); err != nil {
+		return serviceError(err, "Failed to retrieve corporate action")
+	}
+
+	adjustments, err := h.actionService.Adjustments(actionID)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve corporate action adjustments", err)
+	}
+
+	return c.JSON(fiber.Map{"adjustments": adjustments})
+}
+
+// PreviewCorporateAction returns the adjustments applying a pending corporate action would make,
+// without making them
+func (h *CorporateActionHandler) PreviewCorporateAction(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+
+	plan, err := h.actionService.Preview(actionID)
+	if err != nil {
+		return serviceError(err, "Failed to preview corporate action")
+	}
+
+	return c.JSON(plan)
+}
+
+// ApplyCorporateAction applies a pending corporate action whose ex-date has been reached to every
+// portfolio holding the symbol
+func (h *CorporateActionHandler) ApplyCorporateAction(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	before, err := h.actionService.Get(actionID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve corporate action")
+	}
+	plan, err := h.actionService.Apply(actionID, &userID)
+	if err != nil {
+		return serviceError(err, "Failed to apply corporate action")
+	}
+
+	recordAudit(c, h.auditService, "corporate_action.apply", "corporate_action", actionID.String(), before, fiber.Map{
+		"action":     plan.Action,
+		"portfolios": plan.Portfolios,
+		"counts":     plan.Counts,
+	})
+
+	return c.JSON(plan)
+}
+
+// RollbackCorporateAction undoes an applied corporate action
+func (h *CorporateActionHandler) RollbackCorporateAction(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	before, err := h.actionService.Get(actionID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve corporate action")
+	}
+	action, err := h.actionService.Rollback(actionID, userID)
+	if err != nil {
+		return serviceError(err, "Failed to roll back corporate action")
+	}
+
+	recordAudit(c, h.auditService, "corporate_action.rollback", "corporate_action", actionID.String(), before, action)
+
+	return c.JSON(action)
+}
+
+// DeleteCorporateAction removes a pending corporate action
+func (h *CorporateActionHandler) DeleteCorporateAction(c *fiber.Ctx) error {
+	actionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid corporate action ID")
+	}
+
+	before, err := h.actionService.Get(actionID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve corporate action")
+	}
+	if err := h.actionService.Delete(actionID); err != nil {
+		return serviceError(err, "Failed to delete corporate action")
+	}
+
+	recordAudit(c, h.auditService, "corporate_action.delete", "corporate_action", actionID.String(), before, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Corporate action deleted successfully",
+	})
+}
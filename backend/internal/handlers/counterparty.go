@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type CounterpartyHandler struct {
+	counterpartyService *services.CounterpartyService
+	auditService        *services.AuditService
+}
+
+func NewCounterpartyHandler() *CounterpartyHandler {
+	return &CounterpartyHandler{
+		counterpartyService: services.NewCounterpartyService(),
+		auditService:        services.NewAuditService(),
+	}
+}
+
+type CounterpartyRequest struct {
+	Name          string   `json:"name" validate:"notblank"`
+	LEI           string   `json:"lei"`
+	EntityType    string   `json:"entity_type"`
+	Jurisdiction  string   `json:"jurisdiction" validate:"notblank"`
+	RiskRating    string   `json:"risk_rating"`
+	KYCStatus     string   `json:"kyc_status"`
+	KYCExpiresAt  string   `json:"kyc_expires_at"` // RFC3339
+	ExposureLimit *float64 `json:"exposure_limit" validate:"omitnil,gte=0"`
+	IsActive      *bool    `json:"is_active"`
+	Notes         string   `json:"notes"`
+}
+
+// counterpartyListSpec lists the filters and sort fields GetCounterparties accepts
+var counterpartyListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"name":        "name",
+		"created_at":  "created_at",
+		"risk_rating": "risk_rating",
+	},
+	DefaultSort: "created_at",
+	Filters: map[string]string{
+		"jurisdiction": "jurisdiction",
+		"risk_rating":  "risk_rating",
+		"kyc_status":   "kyc_status",
+		"is_active":    "is_active",
+	},
+}
+
+// GetCounterparties returns a page of counterparties
+func (h *CounterpartyHandler) GetCounterparties(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, counterpartyListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	counterparties, total, err := h.counterpartyService.ListCounterparties(counterpartyListSpec, params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve counterparties",
+		})
+	}
+
+	return c.JSON(pagination.Response(counterparties, total, params))
+}
+
+// GetCounterparty returns a single counterparty
+func (h *CounterpartyHandler) GetCounterparty(c *fiber.Ctx) error {
+	counterpartyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid counterparty ID",
+		})
+	}
+
+	counterparty, err := h.counterpartyService.GetCounterparty(counterpartyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Counterparty not found",
+		})
+	}
+
+	return c.JSON(counterparty)
+}
+
+// CreateCounterparty creates a new counterparty
+func (h *CounterpartyHandler) CreateCounterparty(c *fiber.Ctx) error {
+	var req CounterpartyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	counterparty := models.Counterparty{IsActive: true}
+	if err := applyCounterpartyRequest(&counterparty, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.counterpartyService.CreateCounterparty(&counterparty); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "counterparty.create", "counterparty", counterparty.ID.String(), nil, counterparty)
+
+	return c.Status(fiber.StatusCreated).JSON(counterparty)
+}
+
+// UpdateCounterparty updates a counterparty
+func (h *CounterpartyHandler) UpdateCounterparty(c *fiber.Ctx) error {
+	counterpartyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid counterparty ID",
+		})
+	}
+
+	var req CounterpartyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Partial(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	counterparty, err := h.counterpartyService.GetCounterparty(counterpartyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Counterparty not found",
+		})
+	}
+
+	before := services.Snapshot(counterparty)
+
+	if err := applyCounterpartyRequest(counterparty, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.counterpartyService.UpdateCounterparty(counterparty); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "counterparty.update", "counterparty", counterparty.ID.String(), before, counterparty)
+
+	return c.JSON(fiber.Map{
+		"message": "Counterparty updated successfully",
+		"data":    counterparty,
+	})
+}
+
+// DeleteCounterparty deletes a counterparty that has no transactions
+func (h *CounterpartyHandler) DeleteCounterparty(c *fiber.Ctx) error {
+	counterpartyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid counterparty ID",
+		})
+	}
+
+	counterparty, err := h.counterpartyService.GetCounterparty(counterpartyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Counterparty not found",
+		})
+	}
+
+	if err := h.counterpartyService.DeleteCounterparty(counterpartyID); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "counterparty.delete", "counterparty", counterpartyID.String(), counterparty, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Counterparty deleted successfully",
+	})
+}
+
+// GetExposures returns the aggregated exposure of the user's tenant for every counterparty
+func (h *CounterpartyHandler) GetExposures(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	exposures, err := h.counterpartyService.GetExposures(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate counterparty exposure",
+		})
+	}
+
+	breached := 0
+	for _, exposure := range exposures {
+		if exposure.LimitBreached {
+			breached++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            exposures,
+		"total":           len(exposures),
+		"limits_breached": breached,
+		"calculated_at":   time.Now(),
+	})
+}
+
+// GetExposure returns the aggregated exposure of the user's tenant for one counterparty
+func (h *CounterpartyHandler) GetExposure(c *fiber.Ctx) error {
+	counterpartyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid counterparty ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	exposure, err := h.counterpartyService.GetExposure(counterpartyID, userID)
+	if err != nil {
+		if err.Error() == "counterparty not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Counterparty not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate counterparty exposure",
+		})
+	}
+
+	return c.JSON(exposure)
+}
+
+// applyCounterpartyRequest copies the supplied request fields onto a counterparty
+func applyCounterpartyRequest(counterparty *models.Counterparty, req CounterpartyRequest) error {
+	if req.Name != "" {
+		counterparty.Name = req.Name
+	}
+	if req.LEI != "" {
+		counterparty.LEI = req.LEI
+	}
+	if req.EntityType != "" {
+		counterparty.EntityType = req.EntityType
+	}
+	if req.Jurisdiction != "" {
+		counterparty.Jurisdiction = req.Jurisdiction
+	}
+	if req.RiskRating != "" {
+		counterparty.RiskRating = req.RiskRating
+	}
+	if req.KYCStatus != "" && req.KYCStatus != counterparty.KYCStatus {
+		counterparty.KYCStatus = req.KYCStatus
+		if counterparty.KYCStatus == models.KYCStatusVerified {
+			now := time.Now()
+			counterparty.KYCVerifiedAt = &now
+		}
+	}
+	if req.KYCExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.KYCExpiresAt)
+		if err != nil {
+			return errors.New("invalid kyc_expires_at, expected RFC3339")
+		}
+		counterparty.KYCExpiresAt = &expiresAt
+	}
+	if req.ExposureLimit != nil {
+		counterparty.ExposureLimit = decimal.NewFromFloat(*req.ExposureLimit)
+	}
+	if req.IsActive != nil {
+		counterparty.IsActive = *req.IsActive
+	}
+	if req.Notes != "" {
+		counterparty.Notes = req.Notes
+	}
+	return nil
+}
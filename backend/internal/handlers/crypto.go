@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type CryptoHandler struct {
+	crypto       *services.CryptoRiskService
+	auditService *services.AuditService
+}
+
+func NewCryptoHandler() *CryptoHandler {
+	return &CryptoHandler{
+		crypto:       services.NewCryptoRiskService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetAssets lists the crypto reference data that overrides the built-in chains and pegs
+func (h *CryptoHandler) GetAssets(c *fiber.Ctx) error {
+	assets, err := h.crypto.ListAssets()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve crypto assets",
+		})
+	}
+
+	return c.JSON(assets)
+}
+
+// UpsertAsset sets the chain of a crypto asset and, for a stablecoin, its peg
+func (h *CryptoHandler) UpsertAsset(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req services.CryptoAssetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	asset, err := h.crypto.UpsertAsset(c.Params("symbol"), req, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "crypto_asset.update", "crypto_asset", asset.Symbol, nil, asset)
+
+	return c.JSON(fiber.Map{
+		"message": "Crypto asset updated successfully",
+		"data":    asset,
+	})
+}
+
+// DeleteAsset removes the reference data of a crypto asset
+func (h *CryptoHandler) DeleteAsset(c *fiber.Ctx) error {
+	symbol := strings.ToUpper(c.Params("symbol"))
+	if err := h.crypto.DeleteAsset(symbol); err != nil {
+		if err.Error() == "crypto asset not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Crypto asset not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete crypto asset",
+		})
+	}
+
+	recordAudit(c, h.auditService, "crypto_asset.delete", "crypto_asset", symbol, nil, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Crypto asset deleted successfully",
+	})
+}
+
+// GetCustody lists the exchanges, custodians and wallets a portfolio keeps its crypto at
+func (h *CryptoHandler) GetCustody(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	custody, err := h.crypto.GetCustody(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve crypto custody",
+		})
+	}
+
+	return c.JSON(custody)
+}
+
+// SetCustody replaces the exchanges, custodians and wallets a portfolio keeps its crypto at
+func (h *CryptoHandler) SetCustody(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req struct {
+		Custody []services.CryptoCustodyInput `json:"custody"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before, err := h.crypto.GetCustody(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve crypto custody",
+		})
+	}
+
+	custody, err := h.crypto.SetCustody(portfolioID, req.Custody, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "crypto_custody.update", "portfolio", portfolioID.String(), before, custody)
+
+	return c.JSON(fiber.Map{
+		"message": "Crypto custody updated successfully",
+		"data":    custody,
+	})
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type EscalationHandler struct {
+	escalationService *services.EscalationService
+	auditService      *services.AuditService
+}
+
+func NewEscalationHandler() *EscalationHandler {
+	return &EscalationHandler{
+		escalationService: services.NewEscalationService(),
+		auditService:      services.NewAuditService(),
+	}
+}
+
+type TeamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type OnCallShiftRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+type EscalationPolicyRequest struct {
+	Name      string                  `json:"name"`
+	Severity  string                  `json:"severity"`
+	AlertType *string                 `json:"alert_type"` // Empty covers every alert type
+	Tiers     []models.EscalationTier `json:"tiers"`
+	IsActive  *bool                   `json:"is_active"`
+}
+
+// GetTeams returns all teams
+func (h *EscalationHandler) GetTeams(c *fiber.Ctx) error {
+	teams, err := h.escalationService.ListTeams()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve teams",
+		})
+	}
+
+	return c.JSON(teams)
+}
+
+// CreateTeam creates a team
+func (h *EscalationHandler) CreateTeam(c *fiber.Ctx) error {
+	var req TeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	team := models.Team{Name: req.Name, Description: req.Description}
+	if err := h.escalationService.CreateTeam(&team); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "team.create", "team", team.ID.String(), nil, team)
+
+	return c.Status(fiber.StatusCreated).JSON(team)
+}
+
+// GetTeam returns a team with who is on call now
+func (h *EscalationHandler) GetTeam(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	team, err := h.escalationService.GetTeam(teamID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Team not found",
+		})
+	}
+
+	onCall, err := h.escalationService.OnCall(teamID, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve on-call users",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"team":    team,
+		"on_call": onCall,
+	})
+}
+
+// UpdateTeam renames or redescribes a team
+func (h *EscalationHandler) UpdateTeam(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	var req TeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	team, err := h.escalationService.GetTeam(teamID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Team not found",
+		})
+	}
+
+	before := services.Snapshot(team)
+	team.Name = req.Name
+	team.Description = req.Description
+
+	if err := h.escalationService.UpdateTeam(team); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "team.update", "team", team.ID.String(), before, team)
+
+	return c.JSON(fiber.Map{
+		"message": "Team updated successfully",
+		"data":    team,
+	})
+}
+
+// DeleteTeam deletes a team and its rota
+func (h *EscalationHandler) DeleteTeam(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	if err := h.escalationService.DeleteTeam(teamID); err != nil {
+		status := fiber.StatusBadRequest
+		if err.Error() == "team not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "team.delete", "team", teamID.String(), nil, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Team deleted successfully",
+	})
+}
+
+// GetShifts returns a team's rota between the from and to query params (RFC3339), by default
+// the next two weeks
+func (h *EscalationHandler) GetShifts(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	from := time.Now()
+	to := from.Add(14 * 24 * time.Hour)
+	if param := c.Query("from"); param != "" {
+		if from, err = time.Parse(time.RFC3339, param); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "from must be an RFC3339 timestamp",
+			})
+		}
+	}
+	if param := c.Query("to"); param != "" {
+		if to, err = time.Parse(time.RFC3339, param); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must be an RFC3339 timestamp",
+			})
+		}
+	}
+
+	shifts, err := h.escalationService.ListShifts(teamID, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve shifts",
+		})
+	}
+
+	return c.JSON(shifts)
+}
+
+// CreateShift puts a user on call for a team
+func (h *EscalationHandler) CreateShift(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	var req OnCallShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	shift := models.OnCallShift{
+		TeamID:   teamID,
+		UserID:   req.UserID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	if err := h.escalationService.CreateShift(&shift); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "on_call_shift.create", "team", teamID.String(), nil, shift)
+
+	return c.Status(fiber.StatusCreated).JSON(shift)
+}
+
+// DeleteShift removes a shift from a team's rota
+func (h *EscalationHandler) DeleteShift(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+	shiftID, err := uuid.Parse(c.Params("shiftId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid shift ID",
+		})
+	}
+
+	if err := h.escalationService.DeleteShift(teamID, shiftID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Shift not found",
+		})
+	}
+
+	recordAudit(c, h.auditService, "on_call_shift.delete", "team", teamID.String(), fiber.Map{"shift_id": shiftID}, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Shift deleted successfully",
+	})
+}
+
+// GetPolicies returns all escalation policies
+func (h *EscalationHandler) GetPolicies(c *fiber.Ctx) error {
+	policies, err := h.escalationService.ListPolicies()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve escalation policies",
+		})
+	}
+
+	return c.JSON(policies)
+}
+
+// GetPolicy returns an escalation policy
+func (h *EscalationHandler) GetPolicy(c *fiber.Ctx) error {
+	policyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid escalation policy ID",
+		})
+	}
+
+	policy, err := h.escalationService.GetPolicy(policyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Escalation policy not found",
+		})
+	}
+
+	return c.JSON(policy)
+}
+
+// CreatePolicy creates an escalation policy
+func (h *EscalationHandler) CreatePolicy(c *fiber.Ctx) error {
+	var req EscalationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	policy := models.EscalationPolicy{Severity: "CRITICAL", IsActive: true}
+	applyEscalationPolicyRequest(&policy, req)
+
+	if err := h.escalationService.CreatePolicy(&policy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "escalation_policy.create", "escalation_policy", policy.ID.String(), nil, policy)
+
+	return c.Status(fiber.StatusCreated).JSON(policy)
+}
+
+// UpdatePolicy updates an escalation policy
+func (h *EscalationHandler) UpdatePolicy(c *fiber.Ctx) error {
+	policyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid escalation policy ID",
+		})
+	}
+
+	var req EscalationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	policy, err := h.escalationService.GetPolicy(policyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Escalation policy not found",
+		})
+	}
+
+	before := services.Snapshot(policy)
+	applyEscalationPolicyRequest(policy, req)
+
+	if err := h.escalationService.UpdatePolicy(policy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "escalation_policy.update", "escalation_policy", policy.ID.String(), before, policy)
+
+	return c.JSON(fiber.Map{
+		"message": "Escalation policy updated successfully",
+		"data":    policy,
+	})
+}
+
+// DeletePolicy deletes an escalation policy that has not escalated any alert
+func (h *EscalationHandler) DeletePolicy(c *fiber.Ctx) error {
+	policyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid escalation policy ID",
+		})
+	}
+
+	if err := h.escalationService.DeletePolicy(policyID); err != nil {
+		status := fiber.StatusBadRequest
+		if err.Error() == "escalation policy not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "escalation_policy.delete", "escalation_policy", policyID.String(), nil, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Escalation policy deleted successfully",
+	})
+}
+
+// applyEscalationPolicyRequest copies the fields present in the request onto the policy
+func applyEscalationPolicyRequest(policy *models.EscalationPolicy, req EscalationPolicyRequest) {
+	if req.Name != "" {
+		policy.Name = req.Name
+	}
+	if req.Severity != "" {
+		policy.Severity = req.Severity
+	}
+	if req.AlertType != nil {
+		policy.AlertType = *req.AlertType
+	}
+	if req.Tiers != nil {
+		policy.Tiers = req.Tiers
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/export"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// ExportHandler serves CSV and XLSX exports of the transaction, alert and risk history listings.
+// Each export accepts its listing's filters, date range and sort; limit and offset are ignored.
+type ExportHandler struct {
+	exportService      *services.ExportService
+	transactionService *services.TransactionService
+	accessService      *services.AccessService
+	auditService       *services.AuditService
+	cfg                *config.ExportConfig
+}
+
+func NewExportHandler(cfg *config.ExportConfig, riskCfg *config.RiskConfig) *ExportHandler {
+	return &ExportHandler{
+		exportService:      services.NewExportService(cfg),
+		transactionService: services.NewTransactionService(riskCfg),
+		accessService:      services.NewAccessService(),
+		auditService:       services.NewAuditService(),
+		cfg:                cfg,
+	}
+}
+
+// exportDownloadURL is where a completed export job's file is downloaded from
+func exportDownloadURL(id uuid.UUID) string {
+	return "/api/v1/exports/" + id.String() + "/download"
+}
+
+// ExportTransactions streams the transactions in portfolios the user can access as a CSV or XLSX
+// file (?format=), with the filters and ranges of SearchTransactions
+func (h *ExportHandler) ExportTransactions(c *fiber.Ctx) error {
+	req, err := h.transactionExport(c)
+	if err != nil {
+		return err
+	}
+	return h.stream(c, req)
+}
+
+// StartTransactionExport starts an export job for the transactions ExportTransactions would
+// stream, for exports too large to stream
+func (h *ExportHandler) StartTransactionExport(c *fiber.Ctx) error {
+	req, err := h.transactionExport(c)
+	if err != nil {
+		return err
+	}
+	return h.startJob(c, req)
+}
+
+// ExportAlerts streams the alerts for portfolios the user can access as a CSV or XLSX file
+// (?format=), with the filters of GetAlerts
+func (h *ExportHandler) ExportAlerts(c *fiber.Ctx) error {
+	req, err := h.alertExport(c)
+	if err != nil {
+		return err
+	}
+	return h.stream(c, req)
+}
+
+// StartAlertExport starts an export job for the alerts ExportAlerts would stream, for exports too
+// large to stream
+func (h *ExportHandler) StartAlertExport(c *fiber.Ctx) error {
+	req, err := h.alertExport(c)
+	if err != nil {
+		return err
+	}
+	return h.startJob(c, req)
+}
+
+// ExportRiskHistory streams a portfolio's risk history snapshots as a CSV or XLSX file (?format=),
+// with the filters of GetRiskHistory; access is checked by the PortfolioAccess middleware
+func (h *ExportHandler) ExportRiskHistory(c *fiber.Ctx) error {
+	req, err := h.riskHistoryExport(c)
+	if err != nil {
+		return err
+	}
+	return h.stream(c, req)
+}
+
+// StartRiskHistoryExport starts an export job for the snapshots ExportRiskHistory would stream,
+// for exports too large to stream; access is checked by the PortfolioAccess middleware
+func (h *ExportHandler) StartRiskHistoryExport(c *fiber.Ctx) error {
+	req, err := h.riskHistoryExport(c)
+	if err != nil {
+		return err
+	}
+	return h.startJob(c, req)
+}
+
+func (h *ExportHandler) transactionExport(c *fiber.Ctx) (services.ExportRequest, error) {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return services.ExportRequest{}, apperror.Unauthorized("Invalid user ID")
+	}
+
+	params, err := pagination.Parse(c, transactionSearchSpec)
+	if err != nil {
+		return services.ExportRequest{}, apperror.BadRequest(err.Error())
+	}
+	search, err := parseTransactionSearch(c)
+	if err != nil {
+		return services.ExportRequest{}, err
+	}
+
+	query := h.transactionService.SearchQuery(userID, role, search)
+	return exportRequest(c, models.ExportDatasetTransactions, query, transactionSearchSpec, params)
+}
+
+func (h *ExportHandler) alertExport(c *fiber.Ctx) (services.ExportRequest, error) {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return services.ExportRequest{}, apperror.Unauthorized("Invalid user ID")
+	}
+
+	params, err := pagination.Parse(c, alertListSpec)
+	if err != nil {
+		return services.ExportRequest{}, apperror.BadRequest(err.Error())
+	}
+
+	query := h.accessService.ScopeQuery(database.GetDB().Model(&models.Alert{}), "portfolio_id", userID, role)
+	return exportRequest(c, models.ExportDatasetAlerts, query, alertListSpec, params)
+}
+
+func (h *ExportHandler) riskHistoryExport(c *fiber.Ctx) (services.ExportRequest, error) {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return services.ExportRequest{}, apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	params, err := pagination.Parse(c, riskHistoryListSpec)
+	if err != nil {
+		return services.ExportRequest{}, apperror.BadRequest(err.Error())
+	}
+
+	query := database.GetDB().Model(&models.RiskHistory{}).Where("portfolio_id = ?", portfolioID)
+	return exportRequest(c, models.ExportDatasetRiskHistory, query, riskHistoryListSpec, params)
+}
+
+// exportRequest reads the format of an export and applies the listing's filters to its scoped query
+func exportRequest(c *fiber.Ctx, dataset string, query *gorm.DB, spec pagination.Spec, params pagination.Params) (services.ExportRequest, error) {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return services.ExportRequest{}, apperror.Unauthorized("Invalid user ID")
+	}
+
+	format := strings.ToLower(c.Query("format", export.FormatCSV))
+	if !export.Supported(format) {
+		return services.ExportRequest{}, apperror.BadRequest(export.ErrUnsupportedFormat.Error())
+	}
+
+	// Query values point into the request buffer, which is reused once the handler returns but
+	// before the export has been written
+	format = strings.Clone(format)
+	for column, value := range params.Filters {
+		params.Filters[column] = strings.Clone(value)
+	}
+
+	return services.ExportRequest{
+		Dataset:     dataset,
+		Format:      format,
+		Query:       params.Filter(query, spec),
+		Params:      params,
+		RequestedBy: userID,
+		Filters:     string(c.Request().URI().QueryString()),
+	}, nil
+}
+
+// count returns the rows of an export, rejecting exports over the configured or XLSX limits
+func (h *ExportHandler) count(c *fiber.Ctx, req services.ExportRequest) (int64, error) {
+	total, err := h.exportService.Count(c.UserContext(), req)
+	if err != nil {
+		return 0, apperror.Internal("Failed to count export rows", err)
+	}
+	if total > h.cfg.MaxRows {
+		return 0, apperror.BadRequest(fmt.Sprintf("Export of %d rows exceeds the limit of %d, narrow the filters", total, h.cfg.MaxRows))
+	}
+	if req.Format == export.FormatXLSX && total > export.MaxXLSXRows {
+		return 0, apperror.BadRequest(export.ErrTooManyRows.Error())
+	}
+	return total, nil
+}
+
+// stream writes an export to the response one row at a time
+func (h *ExportHandler) stream(c *fiber.Ctx, req services.ExportRequest) error {
+	total, err := h.count(c, req)
+	if err != nil {
+		return err
+	}
+	if total > h.cfg.SyncMaxRows {
+		return apperror.BadRequest(fmt.Sprintf("Export of %d rows is too large to stream, start an export job with POST instead", total))
+	}
+
+	recordAudit(c, h.auditService, "export.download", "export", req.Dataset, nil, fiber.Map{
+		"format": req.Format, "rows": total, "filters": req.Filters,
+	})
+
+	// The status and headers are sent before the first row, so a failure part way through can only
+	// be logged and the file is cut short
+	ctx := c.UserContext()
+	fileName := services.ExportFileName(req.Dataset, req.Format, c.Context().Time())
+	c.Set(fiber.HeaderContentType, export.ContentType(req.Format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := h.exportService.Write(ctx, w, req); err != nil {
+			logging.Component("export").ErrorContext(ctx, "Export stream failed", "dataset", req.Dataset, "error", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// startJob renders an export in the background; the job links to its file once it completes
+func (h *ExportHandler) startJob(c *fiber.Ctx, req services.ExportRequest) error {
+	if _, err := h.count(c, req); err != nil {
+		return err
+	}
+
+	job, err := h.exportService.StartJob(req)
+	if err != nil {
+		return apperror.Internal("Failed to start export", err)
+	}
+
+	recordAudit(c, h.auditService, "export.start", "export", job.ID.String(), nil, job)
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetExport returns an export job the user started, with its download link once it completes
+func (h *ExportHandler) GetExport(c *fiber.Ctx) error {
+	job, err := h.loadExport(c, false)
+	if err != nil {
+		return err
+	}
+
+	if job.Status == models.ExportStatusCompleted {
+		job.DownloadURL = exportDownloadURL(job.ID)
+	}
+	return c.JSON(job)
+}
+
+// DownloadExport returns the file of a completed export job the user started
+func (h *ExportHandler) DownloadExport(c *fiber.Ctx) error {
+	job, err := h.loadExport(c, true)
+	if err != nil {
+		return err
+	}
+	if job.Status != models.ExportStatusCompleted {
+		return apperror.Conflict("Export is " + strings.ToLower(job.Status))
+	}
+
+	c.Set(fiber.HeaderContentType, job.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", job.FileName))
+	return c.Send(job.Content)
+}
+
+// loadExport fetches the export job named by the route if the caller started it
+func (h *ExportHandler) loadExport(c *fiber.Ctx, withContent bool) (*models.ExportJob, error) {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest("Invalid export ID")
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return nil, apperror.Unauthorized("Invalid user ID")
+	}
+
+	job, err := h.exportService.GetJob(jobID, userID, withContent)
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		return nil, apperror.Internal("Failed to retrieve export", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type ExposureHandler struct {
+	exposure     *services.ExposureService
+	auditService *services.AuditService
+}
+
+func NewExposureHandler() *ExposureHandler {
+	return &ExposureHandler{
+		exposure:     services.NewExposureService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+// GetSymbolExposure returns the exposure to a symbol across the portfolios the user can access.
+// Positions in ?symbol= are summed in ?currency= (USD by default) by team and portfolio, only
+// over the portfolios of ?team_id= when given. Admins and compliance officers also get the firm's
+// exposure against its limit on the symbol.
+func (h *ExposureHandler) GetSymbolExposure(c *fiber.Ctx) error {
+	var teamID *uuid.UUID
+	if raw := c.Query("team_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return apperror.BadRequest("Invalid team ID")
+		}
+		teamID = &id
+	}
+
+	userID, role, _ := currentUser(c)
+	exposure, err := h.exposure.Exposure(c.UserContext(), c.Query("symbol"), teamID, c.Query("currency"), userID, role)
+	if err != nil {
+		return serviceError(err, "Failed to calculate exposure")
+	}
+
+	return c.JSON(exposure)
+}
+
+// GetExposureLimits lists the firm-wide exposure limits of the user's tenant
+func (h *ExposureHandler) GetExposureLimits(c *fiber.Ctx) error {
+	userID, _, _ := currentUser(c)
+	limits, err := h.exposure.ListLimits(userID)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve exposure limits", err)
+	}
+
+	return c.JSON(limits)
+}
+
+// SetExposureLimit sets the firm-wide limit on the gross exposure to a symbol across every
+// portfolio of the user's tenant
+func (h *ExposureHandler) SetExposureLimit(c *fiber.Ctx) error {
+	var req services.ExposureLimitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+
+	userID, _, _ := currentUser(c)
+	limit, previous, err := h.exposure.SetLimit(c.Params("symbol"), req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to set exposure limit")
+	}
+
+	var before interface{}
+	if previous != nil {
+		before = previous
+	}
+	recordAudit(c, h.auditService, "exposure_limit.update", "exposure_limit", limit.ID.String(), before, limit)
+
+	return c.JSON(limit)
+}
+
+// DeleteExposureLimit removes the firm-wide exposure limit on a symbol
+func (h *ExposureHandler) DeleteExposureLimit(c *fiber.Ctx) error {
+	userID, _, _ := currentUser(c)
+	limit, err := h.exposure.DeleteLimit(c.Params("symbol"), userID)
+	if err != nil {
+		return serviceError(err, "Failed to delete exposure limit")
+	}
+
+	recordAudit(c, h.auditService, "exposure_limit.delete", "exposure_limit", limit.ID.String(), limit, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Exposure limit deleted successfully",
+	})
+}
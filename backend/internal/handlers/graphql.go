@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/graphql"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+const (
+	// graphqlMaxDepth is the deepest nesting of fields a query may select
+	graphqlMaxDepth = 8
+
+	// graphqlMaxLimit caps the limit argument of list fields
+	graphqlMaxLimit = 100
+)
+
+var errInsufficientPermissions = errors.New("Insufficient permissions")
+
+// GraphQLHandler serves dashboard queries over portfolios, positions, transactions, risk metrics
+// and alerts in a single round trip
+type GraphQLHandler struct {
+	schema        *graphql.Schema
+	batch         *services.BatchReadService
+	accessService *services.AccessService
+	logger        *slog.Logger
+}
+
+func NewGraphQLHandler() *GraphQLHandler {
+	h := &GraphQLHandler{
+		batch:         services.NewBatchReadService(),
+		accessService: services.NewAccessService(),
+		logger:        logging.Component("graphql"),
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+// graphqlSession is the caller and the loaders of one request. Loaders cache for the request only
+// so that no result outlives the access check it was loaded under.
+type graphqlSession struct {
+	userID  uuid.UUID
+	role    string
+	allowed func(middleware.Permission) bool
+
+	portfolios   *graphql.Loader[uuid.UUID, *models.Portfolio]
+	users        *graphql.Loader[uuid.UUID, *models.User]
+	positions    *graphql.Loader[uuid.UUID, []models.Position]
+	transactions *graphql.Loader[portfolioPage, []models.Transaction]
+	riskMetrics  *graphql.Loader[uuid.UUID, []models.RiskMetric]
+	alerts       *graphql.Loader[portfolioPage, []models.Alert]
+}
+
+// portfolioPage keys a loader of the latest records of a portfolio
+type portfolioPage struct {
+	portfolioID uuid.UUID
+	status      string
+	limit       int
+}
+
+type graphqlSessionKey struct{}
+
+func sessionFrom(ctx context.Context) *graphqlSession {
+	return ctx.Value(graphqlSessionKey{}).(*graphqlSession)
+}
+
+// Query executes a GraphQL query. Invalid queries are rejected with 400; errors of individual
+// fields, such as fields the caller lacks the permission for, are reported alongside the data.
+func (h *GraphQLHandler) Query(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req graphql.Request
+	if err := c.BodyParser(&req); err != nil || req.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(graphql.Response{
+			Errors: []graphql.Error{{Message: "Request body must contain a query"}},
+		})
+	}
+
+	// Queries only read; keep them out of the audit trail of state-changing requests
+	c.Locals(middleware.AuditRecordedKey, true)
+
+	session := h.newSession(userID, role, func(perm middleware.Permission) bool {
+		return middleware.Allowed(c, perm)
+	})
+	ctx := context.WithValue(c.UserContext(), graphqlSessionKey{}, session)
+
+	resp, ok := h.schema.Execute(ctx, req)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(resp)
+	}
+	return c.JSON(resp)
+}
+
+func (h *GraphQLHandler) newSession(userID uuid.UUID, role string, allowed func(middleware.Permission) bool) *graphqlSession {
+	return &graphqlSession{
+		userID:  userID,
+		role:    role,
+		allowed: allowed,
+		portfolios: graphql.NewLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Portfolio, error) {
+			values, err := h.batch.Portfolios(ctx, ids)
+			return values, h.loadError(ctx, "portfolios", err)
+		}),
+		users: graphql.NewLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+			values, err := h.batch.Users(ctx, ids)
+			return values, h.loadError(ctx, "users", err)
+		}),
+		positions: graphql.NewLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Position, error) {
+			values, err := h.batch.Positions(ctx, ids)
+			return values, h.loadError(ctx, "positions", err)
+		}),
+		transactions: graphql.NewLoader(func(ctx context.Context, keys []portfolioPage) (map[portfolioPage][]models.Transaction, error) {
+			return loadPages(keys, func(ids []uuid.UUID, _ string, limit int) (map[uuid.UUID][]models.Transaction, error) {
+				values, err := h.batch.RecentTransactions(ctx, ids, limit)
+				return values, h.loadError(ctx, "transactions", err)
+			})
+		}),
+		riskMetrics: graphql.NewLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.RiskMetric, error) {
+			values, err := h.batch.LatestRiskMetrics(ctx, ids)
+			return values, h.loadError(ctx, "risk metrics", err)
+		}),
+		alerts: graphql.NewLoader(func(ctx context.Context, keys []portfolioPage) (map[portfolioPage][]models.Alert, error) {
+			return loadPages(keys, func(ids []uuid.UUID, status string, limit int) (map[uuid.UUID][]models.Alert, error) {
+				values, err := h.batch.RecentAlerts(ctx, ids, status, limit)
+				return values, h.loadError(ctx, "alerts", err)
+			})
+		}),
+	}
+}
+
+// loadError logs a failed load and replaces its error with one safe to return to clients
+func (h *GraphQLHandler) loadError(ctx context.Context, what string, err error) error {
+	if err == nil {
+		return nil
+	}
+	h.logger.ErrorContext(ctx, "Failed to load "+what, "error", err)
+	return errors.New("Failed to load " + what)
+}
+
+// loadPages fetches the pages of a batch of keys with one query per distinct status and limit
+func loadPages[V any](keys []portfolioPage, fetch func(ids []uuid.UUID, status string, limit int) (map[uuid.UUID][]V, error)) (map[portfolioPage][]V, error) {
+	type pageKind struct {
+		status string
+		limit  int
+	}
+	ids := make(map[pageKind][]uuid.UUID)
+	for _, key := range keys {
+		kind := pageKind{key.status, key.limit}
+		ids[kind] = append(ids[kind], key.portfolioID)
+	}
+
+	pages := make(map[portfolioPage][]V, len(keys))
+	for kind, portfolioIDs := range ids {
+		byPortfolio, err := fetch(portfolioIDs, kind.status, kind.limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range portfolioIDs {
+			pages[portfolioPage{id, kind.status, kind.limit}] = byPortfolio[id]
+		}
+	}
+	return pages, nil
+}
+
+// requires restricts a field to callers with a permission
+func requires(perm middleware.Permission, f *graphql.Field) *graphql.Field {
+	f.Authorize = func(ctx context.Context) error {
+		if !sessionFrom(ctx).allowed(perm) {
+			return errInsufficientPermissions
+		}
+		return nil
+	}
+	return f
+}
+
+// scalar is a field read from a source of type T
+func scalar[T any](get func(*T) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Resolve: func(_ context.Context, p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(*T)), nil
+		},
+	}
+}
+
+// clampLimit keeps a limit argument between 1 and graphqlMaxLimit
+func clampLimit(limit int) int {
+	return min(max(limit, 1), graphqlMaxLimit)
+}
+
+func (h *GraphQLHandler) buildSchema() *graphql.Schema {
+	portfolio := &graphql.Object{Name: "Portfolio"}
+	user := &graphql.Object{Name: "User"}
+	position := &graphql.Object{Name: "Position"}
+	transaction := &graphql.Object{Name: "Transaction"}
+	riskMetric := &graphql.Object{Name: "RiskMetric"}
+	alert := &graphql.Object{Name: "Alert"}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"portfolios": requires(middleware.PermPortfolioRead, &graphql.Field{
+			Type: portfolio,
+			List: true,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				session := sessionFrom(ctx)
+				portfolios, err := h.batch.AccessiblePortfolios(ctx, session.userID, session.role)
+				return portfolios, h.loadError(ctx, "portfolios", err)
+			},
+		}),
+		"portfolio": requires(middleware.PermPortfolioRead, &graphql.Field{
+			Type: portfolio,
+			Args: map[string]graphql.Arg{"id": {Kind: graphql.ArgID, Required: true}},
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				session := sessionFrom(ctx)
+				portfolioID, err := uuid.Parse(p.String("id"))
+				if err != nil {
+					return nil, errors.New("Invalid portfolio ID")
+				}
+				// Portfolios the caller cannot see are reported as missing so IDs cannot be probed
+				allowed, err := h.accessService.CanAccessPortfolio(session.userID, session.role, portfolioID)
+				if err != nil {
+					return nil, h.loadError(ctx, "portfolio", err)
+				}
+				if !allowed {
+					return nil, errors.New("Portfolio not found")
+				}
+				return session.portfolios.Load(ctx, portfolioID), nil
+			},
+		}),
+		"alerts": requires(middleware.PermAlertRead, &graphql.Field{
+			Type: alert,
+			List: true,
+			Args: map[string]graphql.Arg{
+				"status":   {Kind: graphql.ArgString},
+				"severity": {Kind: graphql.ArgString},
+				"limit":    {Kind: graphql.ArgInt, Default: 50},
+			},
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				session := sessionFrom(ctx)
+				alerts, err := h.batch.Alerts(ctx, session.userID, session.role, p.String("status"), p.String("severity"), clampLimit(p.Int("limit", 50)))
+				return alerts, h.loadError(ctx, "alerts", err)
+			},
+		}),
+	}}
+
+	portfolio.Fields = map[string]*graphql.Field{
+		"id":                    scalar(func(p *models.Portfolio) interface{} { return p.ID }),
+		"name":                  scalar(func(p *models.Portfolio) interface{} { return p.Name }),
+		"description":           scalar(func(p *models.Portfolio) interface{} { return p.Description }),
+		"currency":              scalar(func(p *models.Portfolio) interface{} { return p.Currency }),
+		"totalValue":            scalar(func(p *models.Portfolio) interface{} { return p.TotalValue }),
+		"cashBalance":           scalar(func(p *models.Portfolio) interface{} { return p.CashBalance }),
+		"marginLoan":            scalar(func(p *models.Portfolio) interface{} { return p.MarginLoan }),
+		"maintenanceMarginRate": scalar(func(p *models.Portfolio) interface{} { return p.MaintenanceMarginRate }),
+		"valueWithCash":         scalar(func(p *models.Portfolio) interface{} { return p.ValueWithCash() }),
+		"createdAt":             scalar(func(p *models.Portfolio) interface{} { return p.CreatedAt }),
+		"updatedAt":             scalar(func(p *models.Portfolio) interface{} { return p.UpdatedAt }),
+		"owner": {
+			Type: user,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				return sessionFrom(ctx).users.Load(ctx, p.Source.(*models.Portfolio).UserID), nil
+			},
+		},
+		"positions": {
+			Type: position,
+			List: true,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				return sessionFrom(ctx).positions.Load(ctx, p.Source.(*models.Portfolio).ID), nil
+			},
+		},
+		"transactions": requires(middleware.PermTransactionRead, &graphql.Field{
+			Type: transaction,
+			List: true,
+			Args: map[string]graphql.Arg{"limit": {Kind: graphql.ArgInt, Default: 20}},
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				key := portfolioPage{portfolioID: p.Source.(*models.Portfolio).ID, limit: clampLimit(p.Int("limit", 20))}
+				return sessionFrom(ctx).transactions.Load(ctx, key), nil
+			},
+		}),
+		"riskMetrics": requires(middleware.PermRiskRead, &graphql.Field{
+			Type: riskMetric,
+			List: true,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				return sessionFrom(ctx).riskMetrics.Load(ctx, p.Source.(*models.Portfolio).ID), nil
+			},
+		}),
+		"alerts": requires(middleware.PermAlertRead, &graphql.Field{
+			Type: alert,
+			List: true,
+			Args: map[string]graphql.Arg{
+				"status": {Kind: graphql.ArgString},
+				"limit":  {Kind: graphql.ArgInt, Default: 20},
+			},
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				key := portfolioPage{portfolioID: p.Source.(*models.Portfolio).ID, status: p.String("status"), limit: clampLimit(p.Int("limit", 20))}
+				return sessionFrom(ctx).alerts.Load(ctx, key), nil
+			},
+		}),
+	}
+
+	// Contact details and roles are for user administrators only
+	user.Fields = map[string]*graphql.Field{
+		"id":        scalar(func(u *models.User) interface{} { return u.ID }),
+		"firstName": scalar(func(u *models.User) interface{} { return u.FirstName }),
+		"lastName":  scalar(func(u *models.User) interface{} { return u.LastName }),
+		"email":     requires(middleware.PermUserManage, scalar(func(u *models.User) interface{} { return u.Email })),
+		"role":      requires(middleware.PermUserManage, scalar(func(u *models.User) interface{} { return u.Role })),
+	}
+
+	position.Fields = map[string]*graphql.Field{
+		"id":           scalar(func(p *models.Position) interface{} { return p.ID }),
+		"symbol":       scalar(func(p *models.Position) interface{} { return p.Symbol }),
+		"assetType":    scalar(func(p *models.Position) interface{} { return p.AssetType }),
+		"quantity":     scalar(func(p *models.Position) interface{} { return p.Quantity }),
+		"averagePrice": scalar(func(p *models.Position) interface{} { return p.AveragePrice }),
+		"currentPrice": scalar(func(p *models.Position) interface{} { return p.CurrentPrice }),
+		"marketValue":  scalar(func(p *models.Position) interface{} { return p.MarketValue }),
+		"pnl":          scalar(func(p *models.Position) interface{} { return p.PnL }),
+		"pnlPercent":   scalar(func(p *models.Position) interface{} { return p.PnLPercent }),
+		"weight":       scalar(func(p *models.Position) interface{} { return p.Weight }),
+		"liquidity":    scalar(func(p *models.Position) interface{} { return p.Liquidity }),
+		"currency":     scalar(func(p *models.Position) interface{} { return p.Currency }),
+		"fxRate":       scalar(func(p *models.Position) interface{} { return p.FXRate }),
+		"updatedAt":    scalar(func(p *models.Position) interface{} { return p.UpdatedAt }),
+	}
+
+	// Screening results and counterparties are compliance data
+	transaction.Fields = map[string]*graphql.Field{
+		"id":                  scalar(func(t *models.Transaction) interface{} { return t.ID }),
+		"portfolioId":         scalar(func(t *models.Transaction) interface{} { return t.PortfolioID }),
+		"type":                scalar(func(t *models.Transaction) interface{} { return t.TransactionType }),
+		"symbol":              scalar(func(t *models.Transaction) interface{} { return t.Symbol }),
+		"side":                scalar(func(t *models.Transaction) interface{} { return t.Side }),
+		"quantity":            scalar(func(t *models.Transaction) interface{} { return t.Quantity }),
+		"price":               scalar(func(t *models.Transaction) interface{} { return t.Price }),
+		"amount":              scalar(func(t *models.Transaction) interface{} { return t.Amount }),
+		"currency":            scalar(func(t *models.Transaction) interface{} { return t.Currency }),
+		"status":              scalar(func(t *models.Transaction) interface{} { return t.Status }),
+		"orderStatus":         scalar(func(t *models.Transaction) interface{} { return t.OrderStatus }),
+		"filledQuantity":      scalar(func(t *models.Transaction) interface{} { return t.FilledQuantity }),
+		"notes":               scalar(func(t *models.Transaction) interface{} { return t.Notes }),
+		"executedAt":          scalar(func(t *models.Transaction) interface{} { return t.ExecutedAt }),
+		"createdAt":           scalar(func(t *models.Transaction) interface{} { return t.CreatedAt }),
+		"counterpartyName":    requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.CounterpartyName })),
+		"counterpartyCountry": requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.CounterpartyCountry })),
+		"kycVerified":         requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.KYCVerified })),
+		"amlChecked":          requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.AMLChecked })),
+		"riskScore":           requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.RiskScore })),
+		"complianceNotes":     requires(middleware.PermComplianceRead, scalar(func(t *models.Transaction) interface{} { return t.ComplianceNotes })),
+	}
+
+	riskMetric.Fields = map[string]*graphql.Field{
+		"id":              scalar(func(m *models.RiskMetric) interface{} { return m.ID }),
+		"metricType":      scalar(func(m *models.RiskMetric) interface{} { return m.MetricType }),
+		"value":           scalar(func(m *models.RiskMetric) interface{} { return m.Value }),
+		"threshold":       scalar(func(m *models.RiskMetric) interface{} { return m.Threshold }),
+		"status":          scalar(func(m *models.RiskMetric) interface{} { return m.Status }),
+		"timeHorizon":     scalar(func(m *models.RiskMetric) interface{} { return m.TimeHorizon }),
+		"confidenceLevel": scalar(func(m *models.RiskMetric) interface{} { return m.ConfidenceLevel }),
+		"details":         scalar(func(m *models.RiskMetric) interface{} { return m.Details }),
+		"calculatedAt":    scalar(func(m *models.RiskMetric) interface{} { return m.CalculatedAt }),
+	}
+
+	alert.Fields = map[string]*graphql.Field{
+		"id":              scalar(func(a *models.Alert) interface{} { return a.ID }),
+		"alertType":       scalar(func(a *models.Alert) interface{} { return a.AlertType }),
+		"severity":        scalar(func(a *models.Alert) interface{} { return a.Severity }),
+		"title":           scalar(func(a *models.Alert) interface{} { return a.Title }),
+		"description":     scalar(func(a *models.Alert) interface{} { return a.Description }),
+		"source":          scalar(func(a *models.Alert) interface{} { return a.Source }),
+		"status":          scalar(func(a *models.Alert) interface{} { return a.Status }),
+		"escalationLevel": scalar(func(a *models.Alert) interface{} { return a.EscalationLevel }),
+		"acknowledgedAt":  scalar(func(a *models.Alert) interface{} { return a.AcknowledgedAt }),
+		"resolvedAt":      scalar(func(a *models.Alert) interface{} { return a.ResolvedAt }),
+		"createdAt":       scalar(func(a *models.Alert) interface{} { return a.CreatedAt }),
+		"portfolio": {
+			Type: portfolio,
+			Resolve: func(ctx context.Context, p graphql.ResolveParams) (interface{}, error) {
+				return sessionFrom(ctx).portfolios.Load(ctx, p.Source.(*models.Alert).PortfolioID), nil
+			},
+		},
+	}
+
+	return &graphql.Schema{Query: query, MaxDepth: graphqlMaxDepth}
+}
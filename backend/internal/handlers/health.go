@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct{}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// Check reports the status of the API and its dependencies. The API is degraded while an optional
+// dependency is down and unhealthy, with a 503, while a required one is.
+func (h *HealthHandler) Check(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	postgres := fiber.Map{"status": "up"}
+	postgresUp := true
+	if err := database.PingPostgres(ctx); err != nil {
+		postgresUp = false
+		postgres = fiber.Map{"status": "down", "error": err.Error()}
+	}
+
+	redis := database.GetRedisStatus()
+
+	status, code := "healthy", fiber.StatusOK
+	switch {
+	case !postgresUp || (redis.Status != "up" && redis.Required):
+		status, code = "unhealthy", fiber.StatusServiceUnavailable
+	case redis.Status != "up":
+		status = "degraded"
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status":  status,
+		"service": "Financial Risk Monitor API",
+		"dependencies": fiber.Map{
+			"postgres": postgres,
+			"redis":    redis,
+		},
+	})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type InvestmentGuidelineHandler struct {
+	guidelineService *services.InvestmentGuidelineService
+	auditService     *services.AuditService
+}
+
+func NewInvestmentGuidelineHandler() *InvestmentGuidelineHandler {
+	return &InvestmentGuidelineHandler{
+		guidelineService: services.NewInvestmentGuidelineService(),
+		auditService:     services.NewAuditService(),
+	}
+}
+
+// InvestmentGuidelineRequest is a portfolio's whole mandate; omitted limits are not enforced
+type InvestmentGuidelineRequest struct {
+	AllowedAssetTypes  []string `json:"allowed_asset_types"`
+	MaxCryptoPercent   *float64 `json:"max_crypto_percent"`
+	MinCreditRating    string   `json:"min_credit_rating"`
+	ESGExcludedSymbols []string `json:"esg_excluded_symbols"`
+	ESGExcludedSectors []string `json:"esg_excluded_sectors"`
+	IsActive           *bool    `json:"is_active"`
+}
+
+// GetGuideline returns a portfolio's investment guideline
+func (h *InvestmentGuidelineHandler) GetGuideline(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	guideline, err := h.guidelineService.GetGuideline(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guideline not found",
+		})
+	}
+
+	return c.JSON(guideline)
+}
+
+// SaveGuideline sets a portfolio's investment guideline, replacing any it had
+func (h *InvestmentGuidelineHandler) SaveGuideline(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req InvestmentGuidelineRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var before interface{}
+	guideline, err := h.guidelineService.GetGuideline(portfolioID)
+	if err != nil {
+		guideline = &models.InvestmentGuideline{PortfolioID: portfolioID, IsActive: true}
+	} else {
+		before = services.Snapshot(guideline)
+	}
+
+	guideline.AllowedAssetTypes = req.AllowedAssetTypes
+	guideline.MaxCryptoPercent = nil
+	if req.MaxCryptoPercent != nil {
+		maxCrypto := decimal.NewFromFloat(*req.MaxCryptoPercent)
+		guideline.MaxCryptoPercent = &maxCrypto
+	}
+	guideline.MinCreditRating = req.MinCreditRating
+	guideline.ESGExcludedSymbols = req.ESGExcludedSymbols
+	guideline.ESGExcludedSectors = req.ESGExcludedSectors
+	if req.IsActive != nil {
+		guideline.IsActive = *req.IsActive
+	}
+	if userID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		guideline.UpdatedBy = &userID
+	}
+
+	if err := h.guidelineService.SaveGuideline(guideline); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "investment_guideline.save", "investment_guideline", guideline.ID.String(), before, guideline)
+
+	return c.JSON(guideline)
+}
+
+// DeleteGuideline removes a portfolio's investment guideline
+func (h *InvestmentGuidelineHandler) DeleteGuideline(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	guideline, err := h.guidelineService.DeleteGuideline(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Guideline not found",
+		})
+	}
+
+	recordAudit(c, h.auditService, "investment_guideline.delete", "investment_guideline", guideline.ID.String(), guideline, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Guideline deleted successfully",
+	})
+}
+
+// EvaluateGuideline checks a portfolio's holdings against its guideline immediately, raising
+// alerts for the breaches
+func (h *InvestmentGuidelineHandler) EvaluateGuideline(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.guidelineService.EvaluateAll(c.UserContext(), &portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to evaluate investment guideline",
+		})
+	}
+
+	return c.JSON(result)
+}
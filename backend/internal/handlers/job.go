@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// JobHandler starts the background jobs for calculations too slow for a request, and reports
+// their progress and results to the user who started them
+type JobHandler struct {
+	jobService   *services.JobService
+	auditService *services.AuditService
+	riskConfig   *config.RiskConfig
+}
+
+func NewJobHandler(cfg *config.JobConfig, riskCfg *config.RiskConfig) *JobHandler {
+	return &JobHandler{
+		jobService:   services.NewJobService(cfg, riskCfg),
+		auditService: services.NewAuditService(),
+		riskConfig:   riskCfg,
+	}
+}
+
+// jobListSpec lists the filters and sort fields GetJobs accepts
+var jobListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"status":     "status",
+		"job_type":   "job_type",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"status":       "status",
+		"job_type":     "job_type",
+		"portfolio_id": "portfolio_id",
+	},
+}
+
+// StartVaRJob queues a VaR calculation with the parameters CalculateVAR accepts, for Monte Carlo
+// runs too slow to wait for. The stored risk metric is the job's result.
+func (h *JobHandler) StartVaRJob(c *fiber.Ctx) error {
+	portfolioID, userID, err := jobPortfolio(c)
+	if err != nil {
+		return err
+	}
+	params, err := parseVaRParams(c, h.riskConfig)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobService.StartVaRJob(c.UserContext(), portfolioID, params, userID)
+	if err != nil {
+		return apperror.Internal("Failed to start VaR job", err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// StartBacktestJob queues the VaR backtest GetBacktest would run with the same parameters
+func (h *JobHandler) StartBacktestJob(c *fiber.Ctx) error {
+	portfolioID, userID, err := jobPortfolio(c)
+	if err != nil {
+		return err
+	}
+	confidence, days, err := parseBacktestParams(c, h.riskConfig)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobService.StartBacktestJob(c.UserContext(), portfolioID, confidence, days, userID)
+	if err != nil {
+		return apperror.Internal("Failed to start backtest job", err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// StartReportJob queues the report GenerateReport would render with the same body; the job links
+// to the stored report once it completes
+func (h *JobHandler) StartReportJob(c *fiber.Ctx) error {
+	portfolioID, _, err := jobPortfolio(c)
+	if err != nil {
+		return err
+	}
+	req, err := parseGenerateReport(c, portfolioID)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobService.StartReportJob(c.UserContext(), req)
+	if err != nil {
+		return serviceError(err, "Failed to start report job")
+	}
+
+	recordAudit(c, h.auditService, "report.generate", "job", job.ID.String(), nil, job)
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetJobs returns a page of the jobs the user started, without their results
+func (h *JobHandler) GetJobs(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	params, err := pagination.Parse(c, jobListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	jobs, total, err := h.jobService.ListJobs(userID, jobListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve jobs", err)
+	}
+
+	return c.JSON(pagination.Response(jobs, total, params))
+}
+
+// GetJob returns the status, progress and, once it completes, the result of a job the user
+// started
+func (h *JobHandler) GetJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid job ID")
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	job, err := h.jobService.GetJob(jobID, userID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve job")
+	}
+
+	if job.JobType == models.JobTypeReport && job.Status == models.JobStatusCompleted {
+		if raw, ok := job.Result["report_id"].(string); ok {
+			if reportID, err := uuid.Parse(raw); err == nil {
+				job.Result["download_url"] = reportDownloadURL(reportID)
+			}
+		}
+	}
+	return c.JSON(job)
+}
+
+// jobPortfolio reads the portfolio a job is started for from the route, and who starts it
+func jobPortfolio(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, apperror.Unauthorized("Invalid user ID")
+	}
+	return portfolioID, userID, nil
+}
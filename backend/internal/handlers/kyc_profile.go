@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type KYCProfileHandler struct {
+	kycService   *services.KYCProfileService
+	auditService *services.AuditService
+}
+
+func NewKYCProfileHandler() *KYCProfileHandler {
+	return &KYCProfileHandler{
+		kycService:   services.NewKYCProfileService(),
+		auditService: services.NewAuditService(),
+	}
+}
+
+type KYCProfileRequest struct {
+	SubjectType string               `json:"subject_type"` // USER or COUNTERPARTY, on create only
+	SubjectID   string               `json:"subject_id"`
+	RiskRating  string               `json:"risk_rating"`
+	Documents   []models.KYCDocument `json:"documents"` // Replaces the profile's documents when given
+	Notes       string               `json:"notes"`
+}
+
+type KYCReviewRequest struct {
+	Decision   string `json:"decision" validate:"required"` // VERIFIED or REJECTED
+	RiskRating string `json:"risk_rating"`                  // Re-rates the profile before its next review is scheduled
+	Notes      string `json:"notes"`
+}
+
+// kycProfileListSpec lists the filters and sort fields GetProfiles accepts
+var kycProfileListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at":     "created_at",
+		"next_review_at": "next_review_at",
+		"risk_rating":    "risk_rating",
+	},
+	DefaultSort: "created_at",
+	Filters: map[string]string{
+		"subject_type": "subject_type",
+		"subject_id":   "subject_id",
+		"status":       "status",
+		"risk_rating":  "risk_rating",
+	},
+}
+
+func kycProfileNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "KYC profile not found",
+	})
+}
+
+// GetProfiles returns a page of KYC profiles
+func (h *KYCProfileHandler) GetProfiles(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, kycProfileListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	profiles, total, err := h.kycService.ListProfiles(kycProfileListSpec, params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve KYC profiles",
+		})
+	}
+
+	return c.JSON(pagination.Response(profiles, total, params))
+}
+
+// GetDueForReview returns the profiles awaiting review, and those due within ?within_days (30 by
+// default)
+func (h *KYCProfileHandler) GetDueForReview(c *fiber.Ctx) error {
+	days := c.QueryInt("within_days", 30)
+	if days < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "within_days cannot be negative",
+		})
+	}
+
+	profiles, err := h.kycService.DueForReview(time.Duration(days) * 24 * time.Hour)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve KYC profiles due for review",
+		})
+	}
+
+	return c.JSON(profiles)
+}
+
+// GetProfile returns a single KYC profile
+func (h *KYCProfileHandler) GetProfile(c *fiber.Ctx) error {
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid KYC profile ID",
+		})
+	}
+
+	profile, err := h.kycService.GetProfile(profileID)
+	if err != nil {
+		return kycProfileNotFound(c)
+	}
+
+	return c.JSON(profile)
+}
+
+// CreateProfile opens a KYC profile, pending review, for a user or counterparty
+func (h *KYCProfileHandler) CreateProfile(c *fiber.Ctx) error {
+	var req KYCProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid subject ID",
+		})
+	}
+
+	profile := &models.KYCProfile{
+		SubjectType: req.SubjectType,
+		SubjectID:   subjectID,
+		RiskRating:  req.RiskRating,
+		Documents:   req.Documents,
+		Notes:       req.Notes,
+	}
+	if err := h.kycService.CreateProfile(profile); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "kyc_profile.create", "kyc_profile", profile.ID.String(), nil, profile)
+
+	return c.Status(fiber.StatusCreated).JSON(profile)
+}
+
+// UpdateProfile changes a profile's documents, risk rating or notes
+func (h *KYCProfileHandler) UpdateProfile(c *fiber.Ctx) error {
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid KYC profile ID",
+		})
+	}
+
+	var req KYCProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	profile, err := h.kycService.GetProfile(profileID)
+	if err != nil {
+		return kycProfileNotFound(c)
+	}
+	before := services.Snapshot(profile)
+
+	if req.RiskRating != "" {
+		profile.RiskRating = req.RiskRating
+	}
+	if req.Documents != nil {
+		profile.Documents = req.Documents
+	}
+	if req.Notes != "" {
+		profile.Notes = req.Notes
+	}
+
+	if err := h.kycService.UpdateProfile(profile); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "kyc_profile.update", "kyc_profile", profileID.String(), before, profile)
+
+	return c.JSON(profile)
+}
+
+// ReviewProfile verifies or rejects a profile, such as when it is due for its periodic review
+func (h *KYCProfileHandler) ReviewProfile(c *fiber.Ctx) error {
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid KYC profile ID",
+		})
+	}
+
+	var req KYCReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	reviewerID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	profile, err := h.kycService.GetProfile(profileID)
+	if err != nil {
+		return kycProfileNotFound(c)
+	}
+	before := services.Snapshot(profile)
+
+	if req.RiskRating != "" {
+		profile.RiskRating = req.RiskRating
+	}
+	if req.Notes != "" {
+		profile.Notes = req.Notes
+	}
+
+	if err := h.kycService.ReviewProfile(profile, req.Decision, reviewerID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "kyc_profile.review", "kyc_profile", profileID.String(), before, profile)
+
+	return c.JSON(profile)
+}
+
+// DeleteProfile removes a KYC profile
+func (h *KYCProfileHandler) DeleteProfile(c *fiber.Ctx) error {
+	profileID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid KYC profile ID",
+		})
+	}
+
+	profile, err := h.kycService.DeleteProfile(profileID)
+	if err != nil {
+		return kycProfileNotFound(c)
+	}
+
+	recordAudit(c, h.auditService, "kyc_profile.delete", "kyc_profile", profileID.String(), profile, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "KYC profile deleted successfully",
+	})
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// limitWarningUtilization is the utilization, in percent, from which a limit is reported as WARNING
+const limitWarningUtilization = 75
+
+// Directions of a limit
+const (
+	limitMax = "max" // The value must stay at or below the limit
+	limitMin = "min" // The value must stay at or above the limit
+)
+
+// limitUtilization is a risk threshold with the portfolio's current value against it. Utilization
+// is the value as a percentage of a maximum, or the limit as a percentage of the value for a
+// minimum, so 100 is the limit either way. A limit of zero is not enforced and has no utilization.
+type limitUtilization struct {
+	Name               string           `json:"name"`
+	Threshold          string           `json:"threshold"` // Field of the portfolio's risk thresholds
+	Direction          string           `json:"direction"` // max, min
+	Value              decimal.Decimal  `json:"value"`
+	Limit              decimal.Decimal  `json:"limit"`
+	UtilizationPercent *decimal.Decimal `json:"utilization_percent"`
+	Status             string           `json:"status"` // SAFE, WARNING, CRITICAL, NOT_ENFORCED
+	Detail             string           `json:"detail,omitempty"`
+	CalculatedAt       time.Time        `json:"calculated_at"`
+}
+
+// GetLimitUtilization reports each risk threshold of a portfolio with its current value and how
+// much of the limit is used, so a dashboard can draw its gauges from one call. VaR, position,
+// sector, concentration and liquidity limits are calculated from the positions for the request;
+// leverage, FX, DV01, venue and loss limits are read from the latest metric their monitors stored.
+// A limit that cannot be measured is left out with the reason in errors. The stablecoin depeg and
+// stop loss distance thresholds are rules on single instruments and orders, not measures of the
+// portfolio, and are not reported.
+func (h *RiskHandler) GetLimitUtilization(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	ctx := c.UserContext()
+	var portfolio models.Portfolio
+	if err := database.GetDB().WithContext(ctx).Preload("Positions").First(&portfolio, portfolioUUID).Error; err != nil {
+		return apperror.NotFound("Portfolio not found")
+	}
+
+	thresholds, err := h.riskEngine.GetThresholds(portfolioUUID)
+	if err != nil {
+		return apperror.Internal("Failed to load risk thresholds", err)
+	}
+
+	report := newLimitReport()
+	h.measurePositionLimits(ctx, report, &portfolio, []uuid.UUID{portfolioUUID}, thresholds)
+	failures := report.Failures
+
+	latest := make(map[string]models.RiskMetric)
+	if metrics, err := h.dashboard.LatestRiskMetrics(ctx, portfolioUUID); err != nil {
+		for _, name := range []string{"leverage", "fx_exposure", "dv01", "venue_exposure", "daily_loss", "weekly_loss", "drawdown"} {
+			failures[name] = "Failed to load stored risk metrics"
+		}
+	} else {
+		for _, metric := range metrics {
+			latest[metric.MetricType] = metric
+		}
+	}
+	for _, stored := range []struct {
+		name       string
+		threshold  string
+		metricType string
+		detail     string // Detail the value is read from, or the metric value when empty
+		limit      decimal.Decimal
+	}{
+		{"leverage", "max_leverage", "LEVERAGE", "", thresholds.MaxLeverage},
+		{"fx_exposure", "max_fx_exposure", "FX_RISK", "", thresholds.MaxFXExposure},
+		{"dv01", "max_dv01", "DV01", "", thresholds.MaxDV01},
+		{"venue_exposure", "max_venue_exposure", "CRYPTO_VENUE_EXPOSURE", "", thresholds.MaxVenueExposure},
+		{"daily_loss", "max_daily_loss", "DRAWDOWN", "daily_loss", thresholds.MaxDailyLoss},
+		{"weekly_loss", "max_weekly_loss", "DRAWDOWN", "weekly_loss", thresholds.MaxWeeklyLoss},
+		{"drawdown", "max_drawdown", "DRAWDOWN", "", thresholds.MaxDrawdown},
+	} {
+		if _, failed := failures[stored.name]; failed {
+			continue
+		}
+		metric, ok := latest[stored.metricType]
+		if !ok {
+			failures[stored.name] = "Not calculated yet"
+			continue
+		}
+		value := metric.Value
+		if stored.detail != "" {
+			detail, ok := metric.Details[stored.detail].(float64)
+			if !ok {
+				failures[stored.name] = "Not calculated yet"
+				continue
+			}
+			value = decimal.NewFromFloat(detail).Round(4)
+		}
+		report.add(stored.name, stored.threshold, limitMax, value, stored.limit, "", metric.CalculatedAt)
+	}
+
+	response := fiber.Map{
+		"portfolio_id":  portfolioUUID,
+		"limits":        report.Limits,
+		"calculated_at": time.Now(),
+	}
+	if len(failures) > 0 {
+		response["errors"] = failures
+	}
+	return c.JSON(response)
+}
+
+// limitReport collects the limits measured for a portfolio and why the others could not be
+type limitReport struct {
+	Limits   []limitUtilization
+	Failures map[string]string
+}
+
+func newLimitReport() *limitReport {
+	return &limitReport{Limits: []limitUtilization{}, Failures: make(map[string]string)}
+}
+
+func (r *limitReport) add(name, threshold, direction string, value, limit decimal.Decimal, detail string, calculatedAt time.Time) {
+	r.Limits = append(r.Limits, newLimitUtilization(name, threshold, direction, value, limit, detail, calculatedAt))
+}
+
+// measurePositionLimits measures the VaR, position, sector, concentration and liquidity limits of
+// a portfolio loaded with its positions, drawing the price history for VaR from the snapshots of
+// the given portfolios. It returns the VaR metrics calculated, by limit name.
+func (h *RiskHandler) measurePositionLimits(ctx context.Context, report *limitReport, portfolio *models.Portfolio, portfolioIDs []uuid.UUID, thresholds *models.RiskThresholds) map[string]*models.RiskMetric {
+	failures := report.Failures
+	now := time.Now()
+	hasPositions := len(portfolio.Positions) > 0
+	vars := make(map[string]*models.RiskMetric)
+
+	for _, v := range []struct {
+		name       string
+		threshold  string
+		confidence float64
+		limit      decimal.Decimal
+	}{
+		{"var_95", "max_var_95", 0.95, thresholds.MaxVaR95},
+		{"var_99", "max_var_99", 0.99, thresholds.MaxVaR99},
+	} {
+		if !hasPositions {
+			failures[v.name] = "Portfolio has no positions"
+			continue
+		}
+		metric, share, err := h.varShare(ctx, portfolio, portfolioIDs, v.confidence)
+		if errors.Is(err, services.ErrInsufficientPriceHistory) {
+			failures[v.name] = "Not enough price history"
+		} else if err != nil {
+			failures[v.name] = "Failed to calculate VaR"
+		} else {
+			vars[v.name] = metric
+			report.add(v.name, v.threshold, limitMax, share, v.limit, "Historical one-day VaR as a share of portfolio value", now)
+		}
+	}
+
+	if !hasPositions {
+		for _, name := range []string{"position_size", "single_asset_exposure", "sector_exposure", "concentration"} {
+			failures[name] = "Portfolio has no positions"
+		}
+	} else {
+		result := h.concentration.CalculateConcentration(portfolio.Positions, calculator.ConcentrationLimits{
+			MaxHHI:            thresholds.MaxConcentration.InexactFloat64(),
+			MaxSingleAsset:    thresholds.MaxSingleAssetExposure.InexactFloat64(),
+			MaxSectorExposure: thresholds.MaxSectorExposure.InexactFloat64(),
+		})
+		if len(result.TopPositions) > 0 {
+			largest := result.TopPositions[0]
+			weight := decimal.NewFromFloat(largest.Weight).Round(4)
+			report.add("position_size", "max_position_size", limitMax, weight, thresholds.MaxPositionSize, largest.Symbol, result.Timestamp)
+			report.add("single_asset_exposure", "max_single_asset_exposure", limitMax, weight, thresholds.MaxSingleAssetExposure, largest.Symbol, result.Timestamp)
+		}
+		// Unclassified symbols are not a sector, as in the concentration breaches
+		for _, sector := range result.Sectors {
+			if sector.Name != calculator.UnclassifiedSector {
+				report.add("sector_exposure", "max_sector_exposure", limitMax, decimal.NewFromFloat(sector.Weight).Round(4),
+					thresholds.MaxSectorExposure, sector.Name, result.Timestamp)
+				break
+			}
+		}
+		report.add("concentration", "max_concentration", limitMax, decimal.NewFromFloat(result.HHI).Round(4),
+			thresholds.MaxConcentration, "Herfindahl index", result.Timestamp)
+	}
+
+	if !hasPositions && !portfolio.CashBalance.IsPositive() {
+		failures["liquidity"] = "Portfolio has no positions"
+	} else if result, err := h.liquidityMetric(portfolio); err != nil {
+		failures["liquidity"] = "Failed to calculate liquidity"
+	} else {
+		report.add("liquidity", "min_liquidity_ratio", limitMin, result.Metric.Value, thresholds.MinLiquidityRatio, "", now)
+	}
+	return vars
+}
+
+// varShare calculates a portfolio's historical one-day VaR at a confidence level, with its price
+// history drawn from the snapshots of the given portfolios, and the VaR as a share of the value
+// including cash, the unit of the VaR thresholds
+func (h *RiskHandler) varShare(ctx context.Context, portfolio *models.Portfolio, portfolioIDs []uuid.UUID, confidence float64) (*models.RiskMetric, decimal.Decimal, error) {
+	params := services.DefaultVaRParams(h.config)
+	params.Method = calculator.MethodHistorical
+	params.Confidence = confidence
+	params.Horizon = 1
+
+	metric, _, err := h.varService.CalculateRollup(ctx, portfolio, portfolioIDs, params)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	value := portfolio.ValueWithCash()
+	if !value.IsPositive() {
+		return nil, decimal.Zero, errors.New("portfolio has no value")
+	}
+	return metric, metric.Value.Div(value).Round(4), nil
+}
+
+// newLimitUtilization measures a value against a limit
+func newLimitUtilization(name, threshold, direction string, value, limit decimal.Decimal, detail string, calculatedAt time.Time) limitUtilization {
+	item := limitUtilization{
+		Name:         name,
+		Threshold:    threshold,
+		Direction:    direction,
+		Value:        value,
+		Limit:        limit,
+		Detail:       detail,
+		CalculatedAt: calculatedAt,
+	}
+	if !limit.IsPositive() {
+		item.Status = "NOT_ENFORCED"
+		return item
+	}
+
+	var utilization decimal.Decimal
+	if direction == limitMin {
+		if !value.IsPositive() {
+			item.Status = "CRITICAL"
+			return item
+		}
+		utilization = limit.Div(value)
+	} else {
+		utilization = value.Div(limit)
+	}
+	utilization = utilization.Mul(decimal.NewFromInt(100)).Round(2)
+	item.UtilizationPercent = &utilization
+
+	switch {
+	case utilization.GreaterThan(decimal.NewFromInt(100)):
+		item.Status = "CRITICAL"
+	case utilization.GreaterThanOrEqual(decimal.NewFromInt(limitWarningUtilization)):
+		item.Status = "WARNING"
+	default:
+		item.Status = "SAFE"
+	}
+	return item
+}
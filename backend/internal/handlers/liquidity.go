@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type LiquidityHandler struct {
+	liquidityService *services.LiquidityService
+	auditService     *services.AuditService
+}
+
+func NewLiquidityHandler() *LiquidityHandler {
+	return &LiquidityHandler{
+		liquidityService: services.NewLiquidityService(),
+		auditService:     services.NewAuditService(),
+	}
+}
+
+// GetMarketData lists the market data positions are classified from
+func (h *LiquidityHandler) GetMarketData(c *fiber.Ctx) error {
+	data, err := h.liquidityService.ListMarketData()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve market data",
+		})
+	}
+
+	return c.JSON(data)
+}
+
+// UpdateMarketData sets the average daily volume and bid-ask spread of a symbol
+func (h *LiquidityHandler) UpdateMarketData(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req services.MarketDataRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	data, err := h.liquidityService.UpsertMarketData(c.Params("symbol"), req, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "market_data.update", "symbol_market_data", data.Symbol, nil, data)
+
+	return c.JSON(fiber.Map{
+		"message": "Market data updated successfully",
+		"data":    data,
+	})
+}
+
+// ClassifyAll reclassifies every position now instead of waiting for the nightly run
+func (h *LiquidityHandler) ClassifyAll(c *fiber.Ctx) error {
+	run, err := h.liquidityService.ClassifyAll(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to classify positions",
+		})
+	}
+
+	return c.JSON(run)
+}
+
+// GetPortfolioLiquidity returns the positions of a portfolio with their liquidity classification
+func (h *LiquidityHandler) GetPortfolioLiquidity(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	positions, err := h.liquidityService.GetPortfolioLiquidity(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve position liquidity",
+		})
+	}
+
+	return c.JSON(positions)
+}
+
+// GetLiquidationSchedule simulates selling a portfolio day by day under normal and stressed
+// participation: what is sold each day, its market impact and the value left to sell. Each day
+// sells at most ?participation= (default 10%) or ?stressed_participation= (5%) of a symbol's
+// average daily volume, for up to ?max_days= (60) days.
+func (h *LiquidityHandler) GetLiquidationSchedule(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	schedule, err := h.liquidityService.LiquidationSchedule(c.UserContext(), portfolioID, services.LiquidationScheduleRequest{
+		Participation:         c.QueryFloat("participation"),
+		StressedParticipation: c.QueryFloat("stressed_participation"),
+		MaxDays:               c.QueryInt("max_days"),
+	})
+	if err != nil {
+		return serviceError(err, "Failed to simulate liquidation")
+	}
+
+	return c.JSON(schedule)
+}
+
+// ClassifyPortfolio reclassifies the positions of a portfolio
+func (h *LiquidityHandler) ClassifyPortfolio(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	run, err := h.liquidityService.ClassifyPortfolio(c.UserContext(), portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to classify positions",
+		})
+	}
+
+	return c.JSON(run)
+}
+
+// SetLiquidityOverride fixes the liquidity class of a position
+func (h *LiquidityHandler) SetLiquidityOverride(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	position, err := h.loadPosition(c)
+	if position == nil {
+		return err
+	}
+
+	var req struct {
+		Class  string `json:"class"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before, err := h.liquidityService.GetClassification(position.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve position liquidity",
+		})
+	}
+
+	classification, err := h.liquidityService.SetOverride(position.ID, req.Class, req.Reason, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "position_liquidity.override", "position", position.ID.String(),
+		liquiditySnapshot(position, before), liquiditySnapshot(position, classification))
+
+	return c.JSON(fiber.Map{
+		"message": "Liquidity override set successfully",
+		"data":    classification,
+	})
+}
+
+// ClearLiquidityOverride returns a position to its calculated liquidity class
+func (h *LiquidityHandler) ClearLiquidityOverride(c *fiber.Ctx) error {
+	position, err := h.loadPosition(c)
+	if position == nil {
+		return err
+	}
+
+	before, err := h.liquidityService.GetClassification(position.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve position liquidity",
+		})
+	}
+
+	classification, err := h.liquidityService.ClearOverride(position.ID)
+	if err != nil {
+		if err.Error() == "position has no liquidity override" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to clear liquidity override",
+		})
+	}
+
+	recordAudit(c, h.auditService, "position_liquidity.clear_override", "position", position.ID.String(),
+		liquiditySnapshot(position, before), liquiditySnapshot(position, classification))
+
+	return c.JSON(fiber.Map{
+		"message": "Liquidity override cleared successfully",
+		"data":    classification,
+	})
+}
+
+// loadPosition fetches the :positionId position of the :id portfolio. When it returns a nil
+// position the error response has already been written and err is the result of writing it.
+func (h *LiquidityHandler) loadPosition(c *fiber.Ctx) (*models.Position, error) {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+	positionID, err := uuid.Parse(c.Params("positionId"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid position ID",
+		})
+	}
+
+	position, err := h.liquidityService.GetPosition(portfolioID, positionID)
+	if err != nil {
+		if err.Error() == "position not found" {
+			return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Position not found",
+			})
+		}
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve position",
+		})
+	}
+	return position, nil
+}
+
+// liquiditySnapshot is the audit trail state of a position's liquidity
+func liquiditySnapshot(position *models.Position, classification *models.PositionLiquidity) fiber.Map {
+	snapshot := fiber.Map{
+		"symbol":    position.Symbol,
+		"liquidity": position.Liquidity,
+	}
+	if classification != nil {
+		snapshot["liquidity"] = classification.EffectiveClass()
+		if snapshot["liquidity"] == "" {
+			snapshot["liquidity"] = position.Liquidity
+		}
+		snapshot["calculated_class"] = classification.CalculatedClass
+		snapshot["override_class"] = classification.OverrideClass
+		snapshot["override_reason"] = classification.OverrideReason
+	}
+	return snapshot
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type LoggingHandler struct {
+	auditService *services.AuditService
+}
+
+func NewLoggingHandler() *LoggingHandler {
+	return &LoggingHandler{
+		auditService: services.NewAuditService(),
+	}
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// GetLogLevel returns the minimum level currently logged
+func (h *LoggingHandler) GetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"level": logging.Level()})
+}
+
+// SetLogLevel changes the minimum level logged without a restart. The change applies to this
+// instance only and lasts until it restarts.
+func (h *LoggingHandler) SetLogLevel(c *fiber.Ctx) error {
+	var req LogLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := logging.Level()
+	if err := logging.SetLevel(req.Level); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	after := logging.Level()
+
+	logging.Component("logging").InfoContext(c.UserContext(), "Log level changed", "from", before, "to", after)
+	recordAudit(c, h.auditService, "log_level.update", "log_level", "", fiber.Map{"level": before}, fiber.Map{"level": after})
+
+	return c.JSON(fiber.Map{"level": after})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// lotListSpec lists the filters and sort fields the lot listing accepts
+var lotListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"opened_at":  "opened_at",
+		"cost_price": "cost_price",
+		"symbol":     "symbol",
+	},
+	DefaultSort: "opened_at",
+	DateColumn:  "opened_at",
+	Filters: map[string]string{
+		"symbol": "symbol",
+	},
+}
+
+// realizedGainListSpec lists the filters and sort fields the realized gain listing accepts
+var realizedGainListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"closed_at":    "closed_at",
+		"realized_pnl": "realized_pnl",
+		"symbol":       "symbol",
+	},
+	DefaultSort: "closed_at",
+	DateColumn:  "closed_at",
+	Filters: map[string]string{
+		"symbol":       "symbol",
+		"holding_term": "holding_term",
+	},
+}
+
+// GetLots returns a page of a portfolio's tax lots, the open ones unless ?status=closed or all.
+// Access is checked by the PortfolioAccess middleware.
+func (h *PortfolioHandler) GetLots(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	params, err := pagination.Parse(c, lotListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	query := database.GetDB().Model(&models.PositionLot{}).Where("portfolio_id = ?", portfolioID)
+	switch c.Query("status", "open") {
+	case "open":
+		query = query.Where("remaining_quantity > 0")
+	case "closed":
+		query = query.Where("remaining_quantity = 0")
+	case "all":
+	default:
+		return apperror.BadRequest("status must be open, closed or all")
+	}
+
+	lots, total, err := h.lotService.ListLots(query, lotListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve lots", err)
+	}
+
+	return c.JSON(pagination.Response(lots, total, params))
+}
+
+// GetRealizedGains returns a page of the lots a portfolio's sales closed, with the cost, proceeds,
+// holding term and gain of each, for tax reporting; from and to select by the sale's execution time.
+// Access is checked by the PortfolioAccess middleware.
+func (h *PortfolioHandler) GetRealizedGains(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	params, err := pagination.Parse(c, realizedGainListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	query := database.GetDB().Model(&models.LotClosure{}).Where("portfolio_id = ?", portfolioID)
+	closures, total, err := h.lotService.ListClosures(query, realizedGainListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve realized gains", err)
+	}
+
+	return c.JSON(pagination.Response(closures, total, params))
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// MarketDepthHandler serves the order book snapshots captured from the market data feed, and the
+// ones a stored liquidity calculation used
+type MarketDepthHandler struct {
+	marketDepthService *services.MarketDepthService
+}
+
+func NewMarketDepthHandler() *MarketDepthHandler {
+	return &MarketDepthHandler{
+		marketDepthService: services.NewMarketDepthService(),
+	}
+}
+
+// marketDepthListSpec lists the filters and sort fields GetMarketDepth accepts
+var marketDepthListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"captured_at": "captured_at",
+		"spread":      "spread",
+	},
+	DefaultSort: "captured_at",
+	DateColumn:  "captured_at",
+	Filters: map[string]string{
+		"source": "source",
+	},
+}
+
+// GetMarketDepth returns a page of the order book snapshots of a symbol, newest first
+func (h *MarketDepthHandler) GetMarketDepth(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, marketDepthListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	snapshots, total, err := h.marketDepthService.ListSnapshots(c.Params("symbol"), marketDepthListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve market depth", err)
+	}
+
+	return c.JSON(pagination.Response(snapshots, total, params))
+}
+
+// GetLatestMarketDepth returns the most recent order book snapshot of a symbol
+func (h *MarketDepthHandler) GetLatestMarketDepth(c *fiber.Ctx) error {
+	snapshot, err := h.marketDepthService.LatestSnapshot(c.Params("symbol"))
+	if err != nil {
+		return serviceError(err, "Failed to retrieve market depth")
+	}
+
+	return c.JSON(snapshot)
+}
+
+// GetMetricMarketDepth returns the order book snapshots a stored VaR or liquidity metric of the
+// portfolio was calculated with, listing the symbols whose snapshot has since been purged
+func (h *MarketDepthHandler) GetMetricMarketDepth(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	metricID, err := uuid.Parse(c.Params("metricId"))
+	if err != nil {
+		return apperror.BadRequest("Invalid metric ID")
+	}
+
+	depth, err := h.marketDepthService.MetricSnapshots(portfolioID, metricID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve market depth")
+	}
+
+	return c.JSON(depth)
+}
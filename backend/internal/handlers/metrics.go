@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+type MetricsHandler struct {
+	token string
+}
+
+func NewMetricsHandler(cfg *config.MetricsConfig) *MetricsHandler {
+	return &MetricsHandler{token: cfg.Token}
+}
+
+// GetMetrics serves the metrics in the Prometheus text exposition format
+func (h *MetricsHandler) GetMetrics(c *fiber.Ctx) error {
+	if h.token != "" {
+		expected := "Bearer " + h.token
+		if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte(expected)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid metrics token",
+			})
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return metrics.WriteText(c.Response().BodyWriter())
+}
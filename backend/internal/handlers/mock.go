@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/mock"
+)
+
+// MockHandler controls the development mock data generator
+type MockHandler struct {
+	generator *mock.MockDataGenerator
+}
+
+func NewMockHandler(generator *mock.MockDataGenerator) *MockHandler {
+	return &MockHandler{generator: generator}
+}
+
+// GetMockStatus returns whether mock data is being generated, the profile it is generated with and
+// the universes and scenarios available
+func (h *MockHandler) GetMockStatus(c *fiber.Ctx) error {
+	return c.JSON(h.generator.Status())
+}
+
+// StartMock resumes mock data generation
+func (h *MockHandler) StartMock(c *fiber.Ctx) error {
+	h.generator.SetRunning(true)
+	return c.JSON(h.generator.Status())
+}
+
+// StopMock pauses mock data generation; scenarios can still be injected
+func (h *MockHandler) StopMock(c *fiber.Ctx) error {
+	h.generator.SetRunning(false)
+	return c.JSON(h.generator.Status())
+}
+
+// UpdateMockProfile changes the symbol universe, tick intervals, alert probability or AML
+// threshold of the generator. Only the settings given change.
+func (h *MockHandler) UpdateMockProfile(c *fiber.Ctx) error {
+	var req mock.MockProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if _, err := h.generator.UpdateProfile(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(h.generator.Status())
+}
+
+// InjectMockScenario produces a flash crash, AML structuring burst or alert storm at once
+func (h *MockHandler) InjectMockScenario(c *fiber.Ctx) error {
+	var req mock.MockScenarioRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	result, err := h.generator.Inject(c.UserContext(), req)
+	if err != nil {
+		switch {
+		case err.Error() == "portfolio not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		case errors.Is(err, mock.ErrNoPortfolios):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
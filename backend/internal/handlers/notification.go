@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// maskedSecret replaces webhook secrets in responses; sending it back keeps the stored secret
+const maskedSecret = "********"
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: services.NewNotificationService(),
+	}
+}
+
+type NotificationChannelRequest struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Target     string      `json:"target"`
+	Severities string      `json:"severities"`
+	Events     string      `json:"events"` // Comma-separated: alert.created, alert.resolved
+	Config     models.JSON `json:"config"` // A "secret" signs webhook bodies with HMAC-SHA256
+	IsActive   *bool       `json:"is_active"`
+}
+
+// GetChannels returns all notification channels
+func (h *NotificationHandler) GetChannels(c *fiber.Ctx) error {
+	channels, err := h.notificationService.ListChannels()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notification channels",
+		})
+	}
+
+	for i := range channels {
+		maskChannelSecret(&channels[i])
+	}
+	return c.JSON(channels)
+}
+
+// CreateChannel creates a new notification channel
+func (h *NotificationHandler) CreateChannel(c *fiber.Ctx) error {
+	var req NotificationChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	channel := models.NotificationChannel{IsActive: true}
+	applyChannelRequest(&channel, req)
+
+	if err := h.notificationService.CreateChannel(&channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	maskChannelSecret(&channel)
+	return c.Status(fiber.StatusCreated).JSON(channel)
+}
+
+// UpdateChannel updates a notification channel
+func (h *NotificationHandler) UpdateChannel(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid channel ID",
+		})
+	}
+
+	var req NotificationChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	channel, err := h.notificationService.GetChannel(channelID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notification channel not found",
+		})
+	}
+
+	applyChannelRequest(channel, req)
+
+	if err := h.notificationService.UpdateChannel(channel); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	maskChannelSecret(channel)
+	return c.JSON(fiber.Map{
+		"message": "Notification channel updated successfully",
+		"data":    channel,
+	})
+}
+
+// DeleteChannel deletes a notification channel
+func (h *NotificationHandler) DeleteChannel(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid channel ID",
+		})
+	}
+
+	if err := h.notificationService.DeleteChannel(channelID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notification channel not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification channel deleted successfully",
+	})
+}
+
+// TestChannel sends a test notification through a channel
+func (h *NotificationHandler) TestChannel(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid channel ID",
+		})
+	}
+
+	channel, err := h.notificationService.GetChannel(channelID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notification channel not found",
+		})
+	}
+
+	notifier := notifications.GetNotifier()
+	if notifier == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Notifications are not enabled",
+		})
+	}
+
+	if err := notifier.SendTest(channel); err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Test notification failed: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Test notification sent",
+	})
+}
+
+// GetDeliveries returns the notification delivery log
+func (h *NotificationHandler) GetDeliveries(c *fiber.Ctx) error {
+	var alertID *uuid.UUID
+	if id := c.Query("alert_id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid alert ID",
+			})
+		}
+		alertID = &parsed
+	}
+
+	deliveries, err := h.notificationService.ListDeliveries(alertID, c.Query("status"), c.Query("event"), c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve notification deliveries",
+		})
+	}
+
+	return c.JSON(deliveries)
+}
+
+// RetryDelivery immediately re-attempts a failed delivery
+func (h *NotificationHandler) RetryDelivery(c *fiber.Ctx) error {
+	deliveryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid delivery ID",
+		})
+	}
+
+	notifier := notifications.GetNotifier()
+	if notifier == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Notifications are not enabled",
+		})
+	}
+
+	delivery, err := notifier.Retry(deliveryID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(delivery)
+}
+
+// RedeliverDelivery sends a sent or failed delivery again as a new delivery with the same payload
+func (h *NotificationHandler) RedeliverDelivery(c *fiber.Ctx) error {
+	deliveryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid delivery ID",
+		})
+	}
+
+	notifier := notifications.GetNotifier()
+	if notifier == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Notifications are not enabled",
+		})
+	}
+
+	delivery, err := notifier.Redeliver(deliveryID)
+	if err != nil {
+		if err.Error() == "delivery not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Delivery not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(delivery)
+}
+
+// applyChannelRequest copies the supplied request fields onto a channel
+func applyChannelRequest(channel *models.NotificationChannel, req NotificationChannelRequest) {
+	if req.Name != "" {
+		channel.Name = req.Name
+	}
+	if req.Type != "" {
+		channel.Type = req.Type
+	}
+	if req.Target != "" {
+		channel.Target = req.Target
+	}
+	if req.Severities != "" {
+		channel.Severities = req.Severities
+	}
+	if req.Events != "" {
+		channel.Events = req.Events
+	}
+	if req.Config != nil {
+		if req.Config["secret"] == maskedSecret {
+			if secret, ok := channel.Config["secret"]; ok {
+				req.Config["secret"] = secret
+			} else {
+				delete(req.Config, "secret")
+			}
+		}
+		channel.Config = req.Config
+	}
+	if req.IsActive != nil {
+		channel.IsActive = *req.IsActive
+	}
+}
+
+// maskChannelSecret hides a channel's webhook secret before it is returned
+func maskChannelSecret(channel *models.NotificationChannel) {
+	if _, ok := channel.Config["secret"]; !ok {
+		return
+	}
+	masked := models.JSON{}
+	for k, v := range channel.Config {
+		masked[k] = v
+	}
+	masked["secret"] = maskedSecret
+	channel.Config = masked
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// NotificationPreferenceHandler manages the notification preferences of the user making the request
+type NotificationPreferenceHandler struct {
+	preferenceService *services.NotificationPreferenceService
+	auditService      *services.AuditService
+}
+
+func NewNotificationPreferenceHandler() *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		preferenceService: services.NewNotificationPreferenceService(),
+		auditService:      services.NewAuditService(),
+	}
+}
+
+// GetPreferences returns the user's notification preferences
+func (h *NotificationPreferenceHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	preference, err := h.preferenceService.Get(userID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve notification preferences")
+	}
+
+	return c.JSON(preference)
+}
+
+// UpdatePreferences sets which alert severities the user is emailed straight away or sent in the
+// daily digest, their quiet hours and whether they get the digest
+func (h *NotificationPreferenceHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	var req services.NotificationPreferenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+
+	before, _ := h.preferenceService.Get(userID)
+	preference, err := h.preferenceService.Update(userID, req)
+	if err != nil {
+		return serviceError(err, "Failed to update notification preferences")
+	}
+
+	recordAudit(c, h.auditService, "notification_preference.update", "user", userID.String(), before, preference)
+
+	return c.JSON(preference)
+}
+
+// DeletePreferences stops the user being emailed alerts and digests
+func (h *NotificationPreferenceHandler) DeletePreferences(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	before, err := h.preferenceService.Get(userID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve notification preferences")
+	}
+	if err := h.preferenceService.Delete(userID); err != nil {
+		return serviceError(err, "Failed to delete notification preferences")
+	}
+
+	recordAudit(c, h.auditService, "notification_preference.delete", "user", userID.String(), before, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Notification preferences deleted successfully",
+	})
+}
+
+// GetDigest returns what the user's next daily digest would report on so far
+func (h *NotificationPreferenceHandler) GetDigest(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	digest, err := h.preferenceService.Digest(userID)
+	if err != nil {
+		return serviceError(err, "Failed to build notification digest")
+	}
+
+	return c.JSON(digest)
+}
@@ -1,27 +1,69 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
 	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
 )
 
 type PortfolioHandler struct {
 	portfolioService *services.PortfolioService
+	accessService    *services.AccessService
+	pnlService       *services.PnLService
+	performance      *services.PerformanceService
+	cashService      *services.CashService
+	lotService       *services.LotService
+	activityService  *services.ActivityService
+	snapshots        *services.PortfolioSnapshotService
+	dashboard        *services.DashboardService
+	simulation       *services.SimulationService
+	hierarchy        *services.PortfolioHierarchyService
+	auditService     *services.AuditService
 }
 
 func NewPortfolioHandler() *PortfolioHandler {
 	return &PortfolioHandler{
 		portfolioService: services.NewPortfolioService(),
+		accessService:    services.NewAccessService(),
+		pnlService:       services.NewPnLService(),
+		performance:      services.NewPerformanceService(),
+		cashService:      services.NewCashService(),
+		lotService:       services.NewLotService(),
+		activityService:  services.NewActivityService(),
+		snapshots:        services.NewPortfolioSnapshotService(),
+		dashboard:        services.NewDashboardService(),
+		simulation:       services.NewSimulationService(),
+		hierarchy:        services.NewPortfolioHierarchyService(),
+		auditService:     services.NewAuditService(),
 	}
 }
 
-// GetPortfolios returns all portfolios for a user
+// GetPortfolios returns the portfolios a user owns, supervises or shares through a team (all of them
+// for global roles)
 func (h *PortfolioHandler) GetPortfolios(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
 
-	portfolios, err := h.portfolioService.GetUserPortfolios(uuid.MustParse(userID))
+	ids, all, err := h.accessService.AccessiblePortfolioIDs(userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch portfolios",
+		})
+	}
+
+	portfolios, err := h.portfolioService.GetPortfoliosByIDs(ids, all)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch portfolios",
@@ -31,27 +73,50 @@ func (h *PortfolioHandler) GetPortfolios(c *fiber.Ctx) error {
 	return c.JSON(portfolios)
 }
 
-// GetPortfolio returns a specific portfolio
+// GetPortfolio returns a specific portfolio; access is checked by the PortfolioAccess middleware
 func (h *PortfolioHandler) GetPortfolio(c *fiber.Ctx) error {
-	portfolioID := c.Params("id")
-	userID := c.Locals("user_id").(string)
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
 
-	portfolio, err := h.portfolioService.GetPortfolio(uuid.MustParse(portfolioID), uuid.MustParse(userID))
+	portfolio, err := h.portfolioService.GetPortfolioByID(portfolioID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Portfolio not found",
-		})
+		return err
 	}
 
+	setETag(c, portfolio.Version)
 	return c.JSON(portfolio)
 }
 
+// GetSummary returns a portfolio's value, latest risk metrics and active alert counts for dashboards
+func (h *PortfolioHandler) GetSummary(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	summary, err := h.dashboard.PortfolioSummary(c.UserContext(), portfolioID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return apperror.NotFound("Portfolio not found")
+		}
+		return apperror.Internal("Failed to retrieve portfolio summary", err)
+	}
+
+	return c.JSON(summary)
+}
+
 // CreatePortfolio creates a new portfolio
 func (h *PortfolioHandler) CreatePortfolio(c *fiber.Ctx) error {
 	var req struct {
-		Name        string `json:"name" validate:"required"`
-		Description string `json:"description"`
-		Currency    string `json:"currency"`
+		Name             string `json:"name" validate:"notblank,max=255"`
+		Description      string `json:"description"`
+		Currency         string `json:"currency"`
+		Benchmark        string `json:"benchmark"`
+		CustodianAccount string `json:"custodian_account" validate:"max=50"`
+		CostBasisMethod  string `json:"cost_basis_method" validate:"omitempty,oneof=FIFO LIFO HIFO"`
+		services.MarginAccountRequest
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -59,33 +124,99 @@ func (h *PortfolioHandler) CreatePortfolio(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+	if err := req.MarginAccountRequest.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	benchmark, err := services.NormalizeBenchmark(req.Benchmark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	userID := c.Locals("user_id").(string)
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
 
 	createReq := services.CreatePortfolioRequest{
-		Name:        req.Name,
-		Description: req.Description,
-		Currency:    req.Currency,
+		Name:                 req.Name,
+		Description:          req.Description,
+		Currency:             req.Currency,
+		Benchmark:            benchmark,
+		CustodianAccount:     req.CustodianAccount,
+		CostBasisMethod:      req.CostBasisMethod,
+		MarginAccountRequest: req.MarginAccountRequest,
 	}
 
-	portfolio, err := h.portfolioService.CreatePortfolio(uuid.MustParse(userID), createReq)
+	portfolio, err := h.portfolioService.CreatePortfolio(userID, createReq)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create portfolio",
-		})
+		return apperror.Internal("Failed to create portfolio", err)
 	}
 
+	recordAudit(c, h.auditService, "portfolio.create", "portfolio", portfolio.ID.String(), nil, portfolio)
+
 	return c.Status(fiber.StatusCreated).JSON(portfolio)
 }
 
-// UpdatePortfolio updates a portfolio
+// ClonePortfolio copies a portfolio the user can access into a new portfolio they own, as a
+// sandbox for what-if analysis or the start of a model portfolio
+func (h *PortfolioHandler) ClonePortfolio(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	// The body is optional; the copy is named after the source by default
+	var req services.PortfolioFromTemplateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.BadRequest("Invalid request body")
+		}
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	portfolio, err := h.portfolioService.ClonePortfolio(portfolioID, userID, req)
+	if err != nil {
+		return serviceError(err, "Failed to clone portfolio")
+	}
+
+	recordAudit(c, h.auditService, "portfolio.clone", "portfolio", portfolio.ID.String(), nil, portfolio)
+
+	return c.Status(fiber.StatusCreated).JSON(portfolio)
+}
+
+// UpdatePortfolio updates a portfolio. The update names the version it was made against in
+// If-Match or the version field, and is refused with the current portfolio if that has changed.
 func (h *PortfolioHandler) UpdatePortfolio(c *fiber.Ctx) error {
-	portfolioID := c.Params("id")
-	userID := c.Locals("user_id").(string)
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
 
 	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
+		Name             string  `json:"name" validate:"omitempty,notblank,max=255"`
+		Description      string  `json:"description"`
+		Benchmark        *string `json:"benchmark"`
+		CustodianAccount *string `json:"custodian_account" validate:"omitempty,max=50"`
+		CostBasisMethod  string  `json:"cost_basis_method" validate:"omitempty,oneof=FIFO LIFO HIFO"`
+		Version          *int64  `json:"version"` // Version the update was made against, when If-Match is not sent
+		services.MarginAccountRequest
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -93,24 +224,51 @@ func (h *PortfolioHandler) UpdatePortfolio(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+	if err := req.MarginAccountRequest.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	version, err := expectedVersion(c, req.Version)
+	if err != nil {
+		return err
+	}
+
+	if req.Benchmark != nil {
+		benchmark, err := services.NormalizeBenchmark(*req.Benchmark)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		req.Benchmark = &benchmark
+	}
 
 	updateReq := services.UpdatePortfolioRequest{
-		Name:        req.Name,
-		Description: req.Description,
+		Name:                 req.Name,
+		Description:          req.Description,
+		Benchmark:            req.Benchmark,
+		CustodianAccount:     req.CustodianAccount,
+		CostBasisMethod:      req.CostBasisMethod,
+		MarginAccountRequest: req.MarginAccountRequest,
 	}
 
-	portfolio, err := h.portfolioService.UpdatePortfolio(
-		uuid.MustParse(portfolioID),
-		uuid.MustParse(userID),
-		updateReq,
-	)
+	var before interface{}
+	if existing, err := h.portfolioService.GetPortfolioByID(portfolioID); err == nil {
+		before = services.Snapshot(existing)
+	}
 
+	portfolio, err := h.portfolioService.UpdatePortfolio(portfolioID, userID, version, updateReq)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update portfolio",
-		})
+		return serviceError(err, "Failed to update portfolio")
 	}
 
+	recordAudit(c, h.auditService, "portfolio.update", "portfolio", portfolioID.String(), before, portfolio)
+
+	setETag(c, portfolio.Version)
 	return c.JSON(fiber.Map{
 		"message": "Portfolio updated successfully",
 		"data":    portfolio,
@@ -119,38 +277,392 @@ func (h *PortfolioHandler) UpdatePortfolio(c *fiber.Ctx) error {
 
 // DeletePortfolio deletes a portfolio
 func (h *PortfolioHandler) DeletePortfolio(c *fiber.Ctx) error {
-	portfolioID := c.Params("id")
-	userID := c.Locals("user_id").(string)
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	var before interface{}
+	if existing, err := h.portfolioService.GetPortfolioByID(portfolioID); err == nil {
+		before = services.Snapshot(existing)
+	}
+
+	if err := h.portfolioService.DeletePortfolio(portfolioID, userID); err != nil {
+		return serviceError(err, "Failed to delete portfolio")
+	}
 
-	err := h.portfolioService.DeletePortfolio(
-		uuid.MustParse(portfolioID),
-		uuid.MustParse(userID),
-	)
+	recordAudit(c, h.auditService, "portfolio.delete", "portfolio", portfolioID.String(), before, nil)
 
+	return c.JSON(fiber.Map{
+		"message": "Portfolio deleted successfully",
+	})
+}
+
+// RestorePortfolio undeletes a soft deleted portfolio and the records deleted with it
+func (h *PortfolioHandler) RestorePortfolio(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete portfolio",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
 		})
 	}
 
+	portfolio, err := h.portfolioService.RestorePortfolio(portfolioID)
+	if err != nil {
+		return serviceError(err, "Failed to restore portfolio")
+	}
+
+	recordAudit(c, h.auditService, "portfolio.restore", "portfolio", portfolio.ID.String(), nil, portfolio)
+
 	return c.JSON(fiber.Map{
-		"message": "Portfolio deleted successfully",
+		"message": "Portfolio restored successfully",
+		"data":    portfolio,
 	})
 }
 
-// GetPositions returns all positions for a portfolio
+// GetPositions returns all positions for a portfolio; access is checked by the PortfolioAccess middleware
 func (h *PortfolioHandler) GetPositions(c *fiber.Ctx) error {
-	portfolioID := c.Params("id")
-	userID := c.Locals("user_id").(string)
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	portfolio, err := h.portfolioService.GetPortfolioByID(portfolioID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(portfolio.Positions)
+}
+
+// GetPnL returns unrealized P&L by position and P&L over ?period=1d|1w|1m (default 1d);
+// access is checked by the PortfolioAccess middleware
+func (h *PortfolioHandler) GetPnL(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	report, err := h.pnlService.GetPnL(portfolioID, c.Query("period", "1d"))
+	if err != nil {
+		if err.Error() == "invalid period, use 1d, 1w or 1m" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate P&L",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// GetPerformance compares the portfolio's returns over ?window=1m|3m|6m|ytd|1y (default 1y) with
+// its benchmark, or with ?benchmark= instead; access is checked by the PortfolioAccess middleware
+func (h *PortfolioHandler) GetPerformance(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	report, err := h.performance.GetPerformance(portfolioID, c.Query("window", "1y"), c.Query("benchmark"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		case errors.Is(err, services.ErrInvalidPerformanceWindow), errors.Is(err, services.ErrNoBenchmark),
+			errors.Is(err, services.ErrInvalidBenchmark):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate performance",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// Simulate runs a basket of hypothetical trades against the portfolio and returns the weights,
+// risk and compliance findings it would be left with, without booking anything
+func (h *PortfolioHandler) Simulate(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req struct {
+		Trades []services.SimulatedTrade `json:"trades"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	result, err := h.simulation.Simulate(c.UserContext(), portfolioID, req.Trades)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSimulation):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case err.Error() == "portfolio not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to simulate trades",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// cashLedgerSpec lists the filters and sort fields GetCashLedger accepts
+var cashLedgerSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"amount":     "amount",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"entry_type":     "entry_type",
+		"transaction_id": "transaction_id",
+	},
+}
+
+// GetCash returns a portfolio's cash balance and the part not committed to pending buys and
+// withdrawals; access is checked by the PortfolioAccess middleware
+func (h *PortfolioHandler) GetCash(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	summary, err := h.cashService.GetSummary(portfolioID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve cash balance",
+		})
+	}
+
+	return c.JSON(summary)
+}
+
+// GetCashLedger returns a page of a portfolio's cash movements; access is checked by the
+// PortfolioAccess middleware
+func (h *PortfolioHandler) GetCashLedger(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	params, err := pagination.Parse(c, cashLedgerSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	entries, total, err := h.cashService.ListLedger(portfolioID, cashLedgerSpec, params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve cash ledger",
+		})
+	}
 
-	positions, err := h.portfolioService.GetPortfolioPositions(uuid.MustParse(portfolioID), uuid.MustParse(userID))
+	return c.JSON(pagination.Response(entries, total, params))
+}
+
+// navHistorySpec lists the filters and sort fields GetNAVHistory accepts
+var navHistorySpec = pagination.Spec{
+	SortFields: map[string]string{
+		"date": "date",
+		"nav":  "nav",
+	},
+	DefaultSort:  "date",
+	DateColumn:   "date",
+	DefaultLimit: 365,
+}
+
+// GetNAVHistory returns a page of a portfolio's end-of-day NAV snapshots, newest first unless
+// ?sort=date; ?positions=true includes each day's positions and weights. Access is checked by the
+// PortfolioAccess middleware.
+func (h *PortfolioHandler) GetNAVHistory(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	params, err := pagination.Parse(c, navHistorySpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	snapshots, total, err := h.snapshots.ListHistory(portfolioID, navHistorySpec, params, c.QueryBool("positions"))
+	if err != nil {
+		return apperror.Internal("Failed to retrieve NAV history", err)
+	}
+
+	return c.JSON(pagination.Response(snapshots, total, params))
+}
+
+// GetSupervisors returns the users assigned to supervise a portfolio
+func (h *PortfolioHandler) GetSupervisors(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	supervisors, err := h.accessService.ListSupervisors(portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch supervisors",
+		})
+	}
+
+	return c.JSON(supervisors)
+}
+
+// AddSupervisor assigns a user to supervise a portfolio
+func (h *PortfolioHandler) AddSupervisor(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var assignedBy *uuid.UUID
+	if currentID, _, err := currentUser(c); err == nil {
+		assignedBy = &currentID
+	}
+
+	supervisor, err := h.accessService.AddSupervisor(portfolioID, userID, assignedBy)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "portfolio.supervisor_add", "portfolio", portfolioID.String(), nil, supervisor)
+
+	return c.Status(fiber.StatusCreated).JSON(supervisor)
+}
+
+// RemoveSupervisor removes a user's supervision of a portfolio
+func (h *PortfolioHandler) RemoveSupervisor(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := h.accessService.RemoveSupervisor(portfolioID, userID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "portfolio.supervisor_remove", "portfolio", portfolioID.String(), models.JSON{"user_id": userID.String()}, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Supervisor removed successfully",
+	})
+}
+
+// SetTeam shares a portfolio with a team's members, or stops sharing it when team_id is null
+func (h *PortfolioHandler) SetTeam(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	var req struct {
+		TeamID *uuid.UUID `json:"team_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before, err := h.portfolioService.GetPortfolioByID(portfolioID)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Portfolio not found or access denied",
+			"error": "Portfolio not found",
+		})
+	}
+
+	portfolio, err := h.accessService.SetPortfolioTeam(portfolioID, req.TeamID)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if err.Error() == "portfolio not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(positions)
+	recordAudit(c, h.auditService, "portfolio.team_set", "portfolio", portfolioID.String(),
+		models.JSON{"team_id": before.TeamID}, models.JSON{"team_id": portfolio.TeamID})
+
+	return c.JSON(portfolio)
 }
 
 // AddPosition adds a position to a portfolio
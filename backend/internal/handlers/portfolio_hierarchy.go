@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// SetParent places a portfolio under a parent portfolio, such as a strategy under a book, or at
+// the top of the hierarchy when parent_id is null. The user must be able to modify both; limits
+// the portfolio does not set itself are inherited from the new parent.
+func (h *PortfolioHandler) SetParent(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	var req services.ParentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+
+	before, err := h.portfolioService.GetPortfolioByID(portfolioID)
+	if err != nil {
+		return apperror.NotFound("Portfolio not found")
+	}
+
+	userID, role, _ := currentUser(c)
+	portfolio, err := h.hierarchy.SetParent(portfolioID, req.ParentID, userID, role)
+	if err != nil {
+		return serviceError(err, "Failed to move portfolio")
+	}
+
+	recordAudit(c, h.auditService, "portfolio.parent_set", "portfolio", portfolioID.String(),
+		models.JSON{"parent_id": before.ParentID}, models.JSON{"parent_id": portfolio.ParentID})
+
+	setETag(c, portfolio.Version)
+	return c.JSON(portfolio)
+}
+
+// GetHierarchy returns the portfolios above a portfolio, just their names for those the user cannot
+// access, and the tree of portfolios under it, each with its value rolled up from those under it
+func (h *PortfolioHandler) GetHierarchy(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	userID, role, _ := currentUser(c)
+	hierarchy, err := h.hierarchy.Get(portfolioID, userID, role)
+	if err != nil {
+		return serviceError(err, "Failed to load portfolio hierarchy")
+	}
+
+	return c.JSON(hierarchy)
+}
+
+// GetRollup rolls the positions of every portfolio under a portfolio up into it, in its currency,
+// and measures the VaR, position, sector, concentration and liquidity limits of the whole against
+// the portfolio's thresholds, as GetLimitUtilization does for a single portfolio. The limits read
+// from stored metrics are monitored per portfolio and not reported here.
+func (h *RiskHandler) GetRollup(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	ctx := c.UserContext()
+	rollup, portfolioIDs, err := h.hierarchy.Rollup(ctx, portfolioID)
+	if err != nil {
+		return serviceError(err, "Failed to roll up portfolio")
+	}
+
+	thresholds, err := h.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return apperror.Internal("Failed to load risk thresholds", err)
+	}
+
+	report := newLimitReport()
+	vars := h.measurePositionLimits(ctx, report, rollup, portfolioIDs, thresholds)
+
+	response := fiber.Map{
+		"portfolio_id":  portfolioID,
+		"portfolio_ids": portfolioIDs,
+		"currency":      rollup.Currency,
+		"total_value":   rollup.TotalValue,
+		"cash_balance":  rollup.CashBalance,
+		"margin_loan":   rollup.MarginLoan,
+		"positions":     rollup.Positions,
+		"var":           vars,
+		"limits":        report.Limits,
+		"calculated_at": time.Now(),
+	}
+	if len(report.Failures) > 0 {
+		response["errors"] = report.Failures
+	}
+	return c.JSON(response)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type PortfolioTemplateHandler struct {
+	templateService *services.PortfolioTemplateService
+	auditService    *services.AuditService
+}
+
+func NewPortfolioTemplateHandler() *PortfolioTemplateHandler {
+	return &PortfolioTemplateHandler{
+		templateService: services.NewPortfolioTemplateService(),
+		auditService:    services.NewAuditService(),
+	}
+}
+
+// portfolioTemplateListSpec lists the filters and sort fields the template listing accepts
+var portfolioTemplateListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultSort: "name",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"currency": "currency",
+	},
+}
+
+// GetPortfolioTemplates returns a page of the portfolio templates
+func (h *PortfolioTemplateHandler) GetPortfolioTemplates(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, portfolioTemplateListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	templates, total, err := h.templateService.List(portfolioTemplateListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve portfolio templates", err)
+	}
+
+	return c.JSON(pagination.Response(templates, total, params))
+}
+
+// GetPortfolioTemplate returns a portfolio template with its positions, thresholds and guideline
+func (h *PortfolioTemplateHandler) GetPortfolioTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio template ID")
+	}
+
+	template, err := h.templateService.Get(templateID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve portfolio template")
+	}
+
+	return c.JSON(template)
+}
+
+// CreatePortfolioTemplate saves a predefined set of positions, cash, risk thresholds and
+// investment guideline that portfolios can be created from
+func (h *PortfolioTemplateHandler) CreatePortfolioTemplate(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+	req, err := parsePortfolioTemplateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	template, err := h.templateService.Create(*req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to create portfolio template")
+	}
+
+	recordAudit(c, h.auditService, "portfolio_template.create", "portfolio_template", template.ID.String(), nil, template)
+
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+// UpdatePortfolioTemplate replaces a portfolio template; portfolios created from it keep what they
+// were created with
+func (h *PortfolioTemplateHandler) UpdatePortfolioTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio template ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+	req, err := parsePortfolioTemplateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.templateService.Get(templateID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve portfolio template")
+	}
+	template, err := h.templateService.Update(templateID, *req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to update portfolio template")
+	}
+
+	recordAudit(c, h.auditService, "portfolio_template.update", "portfolio_template", template.ID.String(), before, template)
+
+	return c.JSON(template)
+}
+
+// DeletePortfolioTemplate removes a portfolio template
+func (h *PortfolioTemplateHandler) DeletePortfolioTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio template ID")
+	}
+
+	template, err := h.templateService.Delete(templateID)
+	if err != nil {
+		return serviceError(err, "Failed to delete portfolio template")
+	}
+
+	recordAudit(c, h.auditService, "portfolio_template.delete", "portfolio_template", template.ID.String(), template, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Portfolio template deleted successfully",
+	})
+}
+
+// CreatePortfolioFromTemplate creates a portfolio owned by the user with the template's positions,
+// cash, risk thresholds and guideline
+func (h *PortfolioTemplateHandler) CreatePortfolioFromTemplate(c *fiber.Ctx) error {
+	templateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio template ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	// The body is optional; the portfolio is named after the template by default
+	var req services.PortfolioFromTemplateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.BadRequest("Invalid request body")
+		}
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	portfolio, err := h.templateService.CreatePortfolio(templateID, userID, req)
+	if err != nil {
+		return serviceError(err, "Failed to create portfolio from template")
+	}
+
+	recordAudit(c, h.auditService, "portfolio.create_from_template", "portfolio", portfolio.ID.String(), nil, portfolio)
+
+	return c.Status(fiber.StatusCreated).JSON(portfolio)
+}
+
+// parsePortfolioTemplateRequest reads and validates a template from the request body
+func parsePortfolioTemplateRequest(c *fiber.Ctx) (*services.PortfolioTemplateRequest, error) {
+	var req services.PortfolioTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, validationErrorResponse(c, err)
+	}
+	if req.Positions == nil {
+		req.Positions = []models.TemplatePosition{}
+	}
+	return &req, nil
+}
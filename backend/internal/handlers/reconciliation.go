@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService *services.ReconciliationService
+	auditService          *services.AuditService
+}
+
+func NewReconciliationHandler(cfg *config.ReconciliationConfig) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: services.NewReconciliationService(cfg),
+		auditService:          services.NewAuditService(),
+	}
+}
+
+// reconciliationRunListSpec lists the filters and sort fields the run listing accepts
+var reconciliationRunListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"as_of":      "as_of",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "as_of",
+	Filters: map[string]string{
+		"source": "source",
+		"status": "status",
+	},
+}
+
+// reconciliationBreakListSpec lists the filters and sort fields the break listing accepts
+var reconciliationBreakListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"symbol":     "symbol",
+		"status":     "status",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"run_id":       "run_id",
+		"portfolio_id": "portfolio_id",
+		"status":       "status",
+		"break_type":   "break_type",
+	},
+}
+
+// UploadPositions reconciles an uploaded custodian CSV position file (multipart field "file"),
+// as of the as_of form value (YYYY-MM-DD, today by default). A file that cannot be reconciled is
+// recorded as a failed run and reported with a 422.
+func (h *ReconciliationHandler) UploadPositions(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return apperror.BadRequest("Position file is required")
+	}
+
+	req := services.ReconcileRequest{
+		Source:    models.ReconciliationSourceUpload,
+		FileName:  fileHeader.Filename,
+		CreatedBy: &userID,
+	}
+	if value := c.FormValue("as_of"); value != "" {
+		if req.AsOf, err = time.Parse("2006-01-02", value); err != nil {
+			return apperror.BadRequest("as_of must be a date (YYYY-MM-DD)")
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return apperror.BadRequest("Failed to read position file")
+	}
+	defer file.Close()
+
+	run, err := h.reconciliationService.ReconcileFile(c.UserContext(), file, req)
+	if err != nil {
+		return apperror.Internal("Failed to reconcile positions", err)
+	}
+
+	recordAudit(c, h.auditService, "reconciliation.run", "reconciliation_run", run.ID.String(), nil, run)
+
+	if run.Status == models.ReconciliationStatusFailed {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(run)
+	}
+	return c.Status(fiber.StatusCreated).JSON(run)
+}
+
+// GetRuns returns a page of reconciliation runs, newest first by default
+func (h *ReconciliationHandler) GetRuns(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, reconciliationRunListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	runs, total, err := h.reconciliationService.ListRuns(reconciliationRunListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve reconciliation runs", err)
+	}
+
+	return c.JSON(pagination.Response(runs, total, params))
+}
+
+// GetRun returns a reconciliation run
+func (h *ReconciliationHandler) GetRun(c *fiber.Ctx) error {
+	runID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid reconciliation run ID")
+	}
+
+	run, err := h.reconciliationService.GetRun(runID)
+	if err != nil {
+		return reconciliationError(err, "Failed to retrieve reconciliation run")
+	}
+
+	return c.JSON(run)
+}
+
+// GetBreaks returns a page of reconciliation breaks, filtered by run, portfolio, status or type
+func (h *ReconciliationHandler) GetBreaks(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, reconciliationBreakListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	for _, filter := range []string{"run_id", "portfolio_id"} {
+		if value, ok := params.Filters[filter]; ok {
+			if _, err := uuid.Parse(value); err != nil {
+				return apperror.BadRequest("Invalid " + filter)
+			}
+		}
+	}
+
+	breaks, total, err := h.reconciliationService.ListBreaks(reconciliationBreakListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve reconciliation breaks", err)
+	}
+
+	return c.JSON(pagination.Response(breaks, total, params))
+}
+
+// UpdateBreak explains, resolves or reopens a reconciliation break
+func (h *ReconciliationHandler) UpdateBreak(c *fiber.Ctx) error {
+	breakID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid reconciliation break ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	var req services.UpdateBreakRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	b, err := h.reconciliationService.UpdateBreak(breakID, userID, req)
+	if err != nil {
+		return reconciliationError(err, "Failed to update reconciliation break")
+	}
+
+	recordAudit(c, h.auditService, "reconciliation_break.update", "reconciliation_break", b.ID.String(), nil, b)
+
+	return c.JSON(b)
+}
+
+// reconciliationError passes on the service's not found and bad request errors
+func reconciliationError(err error, message string) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Internal(message, err)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/reports"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+	accessService *services.AccessService
+	auditService  *services.AuditService
+}
+
+func NewReportHandler() *ReportHandler {
+	return &ReportHandler{
+		reportService: services.NewReportService(),
+		accessService: services.NewAccessService(),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// reportListSpec lists the filters and sort fields GetReports accepts
+var reportListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at":  "created_at",
+		"report_type": "report_type",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"portfolio_id": "portfolio_id",
+		"report_type":  "report_type",
+		"format":       "format",
+	},
+}
+
+// GenerateReport renders and stores a report for a portfolio; access is checked by the PortfolioAccess middleware
+func (h *ReportHandler) GenerateReport(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	generateReq, err := parseGenerateReport(c, portfolioID)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.reportService.GenerateReport(generateReq)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report.DownloadURL = reportDownloadURL(report.ID)
+	recordAudit(c, h.auditService, "report.generate", "report", report.ID.String(), nil, report)
+
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// parseGenerateReport reads the report GenerateReport is asked for from the body; the user who
+// asked generates it
+func parseGenerateReport(c *fiber.Ctx, portfolioID uuid.UUID) (services.GenerateReportRequest, error) {
+	var req struct {
+		Type   string `json:"type"`
+		Format string `json:"format"`
+		From   string `json:"from"`
+		To     string `json:"to"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return services.GenerateReportRequest{}, apperror.BadRequest("Invalid request body")
+	}
+	if req.Type == "" {
+		req.Type = models.ReportTypeDailyRisk
+	}
+	if req.Format == "" {
+		req.Format = reports.FormatPDF
+	}
+
+	generateReq := services.GenerateReportRequest{
+		PortfolioID: portfolioID,
+		ReportType:  req.Type,
+		Format:      req.Format,
+	}
+	var err error
+	if generateReq.From, err = parseReportTime(req.From, false); err != nil {
+		return generateReq, apperror.BadRequest("Invalid from, expected RFC3339 or YYYY-MM-DD")
+	}
+	if generateReq.To, err = parseReportTime(req.To, true); err != nil {
+		return generateReq, apperror.BadRequest("Invalid to, expected RFC3339 or YYYY-MM-DD")
+	}
+	if userID, _, err := currentUser(c); err == nil {
+		generateReq.GeneratedBy = &userID
+	}
+	return generateReq, nil
+}
+
+// GetReports returns a page of stored reports for portfolios the user can see
+func (h *ReportHandler) GetReports(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	params, err := pagination.Parse(c, reportListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	reportList, total, err := h.reportService.ListReports(userID, role, reportListSpec, params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve reports",
+		})
+	}
+
+	for i := range reportList {
+		reportList[i].DownloadURL = reportDownloadURL(reportList[i].ID)
+	}
+
+	return c.JSON(pagination.Response(reportList, total, params))
+}
+
+// GetReport returns a stored report's metadata
+func (h *ReportHandler) GetReport(c *fiber.Ctx) error {
+	report, err := h.loadReport(c, false)
+	if report == nil {
+		return err
+	}
+
+	report.DownloadURL = reportDownloadURL(report.ID)
+	return c.JSON(report)
+}
+
+// DownloadReport returns a stored report file
+func (h *ReportHandler) DownloadReport(c *fiber.Ctx) error {
+	report, err := h.loadReport(c, true)
+	if report == nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, report.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", report.FileName))
+	return c.Send(report.Content)
+}
+
+// loadReport fetches the report named by the route and checks the caller can see its portfolio.
+// It returns nil after writing the error response.
+func (h *ReportHandler) loadReport(c *fiber.Ctx, withContent bool) (*models.Report, error) {
+	reportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	report, err := h.reportService.GetReport(reportID, withContent)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found",
+		})
+	}
+
+	allowed, err := h.accessService.CanAccessPortfolio(userID, role, report.PortfolioID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check access",
+		})
+	}
+	if !allowed {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found",
+		})
+	}
+
+	return report, nil
+}
+
+func reportDownloadURL(reportID uuid.UUID) string {
+	return "/api/v1/reports/" + reportID.String() + "/download"
+}
+
+// parseReportTime accepts RFC3339 timestamps or YYYY-MM-DD dates; empty means unset. With endOfDay
+// a bare date covers the whole day.
+func parseReportTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, errors.New("invalid time")
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+	accessService    *services.AccessService
+	auditService     *services.AuditService
+}
+
+func NewRetentionHandler(cfg *config.RetentionConfig) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: services.NewRetentionService(cfg),
+		accessService:    services.NewAccessService(),
+		auditService:     services.NewAuditService(),
+	}
+}
+
+// deletedListSpec lists the filters and sort fields the deleted record listings accept
+var deletedListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"deleted_at": "deleted_at",
+		"created_at": "created_at",
+	},
+	DefaultSort: "deleted_at",
+	DateColumn:  "deleted_at",
+}
+
+// deletedChildListSpec is deletedListSpec for records that belong to a portfolio
+var deletedChildListSpec = pagination.Spec{
+	SortFields:  deletedListSpec.SortFields,
+	DefaultSort: deletedListSpec.DefaultSort,
+	DateColumn:  deletedListSpec.DateColumn,
+	Filters: map[string]string{
+		"portfolio_id": "portfolio_id",
+	},
+}
+
+// GetDeleted lists the soft deleted portfolios, transactions or alerts (:type) that can still be
+// restored
+func (h *RetentionHandler) GetDeleted(c *fiber.Ctx) error {
+	spec := deletedChildListSpec
+	if c.Params("type") == "portfolios" {
+		spec = deletedListSpec
+	}
+	params, err := pagination.Parse(c, spec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var records interface{}
+	var total int64
+	switch c.Params("type") {
+	case "portfolios":
+		records, total, err = h.retentionService.ListDeletedPortfolios(spec, params)
+	case "transactions":
+		records, total, err = h.retentionService.ListDeletedTransactions(spec, params)
+	case "alerts":
+		records, total, err = h.retentionService.ListDeletedAlerts(spec, params)
+	default:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown record type, use portfolios, transactions or alerts",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve deleted records",
+		})
+	}
+
+	return c.JSON(pagination.Response(records, total, params))
+}
+
+// GetRetentionPolicies lists how long alerts, transactions and audit entries are kept and after
+// how long closed user accounts are anonymized
+func (h *RetentionHandler) GetRetentionPolicies(c *fiber.Ctx) error {
+	policies, err := h.retentionService.Policies()
+	if err != nil {
+		return apperror.Internal("Failed to retrieve retention policies", err)
+	}
+
+	return c.JSON(fiber.Map{"policies": policies})
+}
+
+// UpdateRetentionPolicy changes the retention period of an entity, or turns its policy off. Policies
+// span tenants, so only platform administrators may change them.
+func (h *RetentionHandler) UpdateRetentionPolicy(c *fiber.Ctx) error {
+	userID, err := platformAdmin(c, h.accessService)
+	if err != nil {
+		return err
+	}
+
+	var req services.RetentionPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	policy, err := h.retentionService.UpdatePolicy(c.Params("entity"), req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to update retention policy")
+	}
+
+	recordAudit(c, h.auditService, "retention_policy.update", "retention_policy", policy.EntityType, nil, policy)
+
+	return c.JSON(policy)
+}
+
+// RunRetentionPolicies applies the retention policies now rather than at the next purge interval
+func (h *RetentionHandler) RunRetentionPolicies(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+	applied, err := h.retentionService.ApplyPolicies(c.UserContext())
+	if err != nil {
+		return apperror.Internal("Failed to apply retention policies", err)
+	}
+
+	recordAudit(c, h.auditService, "retention_policy.run", "retention_policy", "", nil, applied)
+
+	return c.JSON(fiber.Map{"applied": applied})
+}
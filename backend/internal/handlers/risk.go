@@ -1,29 +1,70 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/cache"
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
 )
 
 type RiskHandler struct {
-	config *config.RiskConfig
+	config        *config.RiskConfig
+	riskEngine    *services.RiskEngineService
+	backtest      *services.BacktestService
+	varService    *services.VaRService
+	leverage      *services.LeverageService
+	drawdown      *services.DrawdownService
+	currency      *services.CurrencyService
+	fixedIncome   *services.FixedIncomeService
+	crypto        *services.CryptoRiskService
+	dashboard     *services.DashboardService
+	history       *services.RiskHistoryService
+	hierarchy     *services.PortfolioHierarchyService
+	auditService  *services.AuditService
+	concentration *calculator.ConcentrationCalculator
 }
 
 func NewRiskHandler(cfg *config.RiskConfig) *RiskHandler {
 	return &RiskHandler{
-		config: cfg,
+		config:        cfg,
+		riskEngine:    services.NewRiskEngineService(),
+		backtest:      services.NewBacktestService(),
+		varService:    services.NewVaRService(cfg),
+		leverage:      services.NewLeverageService(),
+		drawdown:      services.NewDrawdownService(),
+		currency:      services.NewCurrencyService(),
+		fixedIncome:   services.NewFixedIncomeService(),
+		crypto:        services.NewCryptoRiskService(),
+		dashboard:     services.NewDashboardService(),
+		history:       services.NewRiskHistoryService(cfg),
+		hierarchy:     services.NewPortfolioHierarchyService(),
+		auditService:  services.NewAuditService(),
+		concentration: calculator.NewConcentrationCalculator(5),
 	}
 }
 
-// CalculateVAR calculates Value at Risk for a portfolio
+// CalculateVAR calculates Value at Risk for a portfolio with ?method=simplified (default),
+// historical, parametric or montecarlo, at ?confidence= over ?horizon= days (defaulting to the
+// configured ones). The return based methods take ?lookback= days of snapshots (default 250) and
+// montecarlo ?simulations= paths and a ?seed= for reproducible runs (defaulting to the configured
+// ones). The result is cached per portfolio and parameters until a trade, threshold change or
+// large price move; ?force_refresh=true recalculates it.
 func (h *RiskHandler) CalculateVAR(c *fiber.Ctx) error {
 	portfolioID := c.Params("id")
 	portfolioUUID, err := uuid.Parse(portfolioID)
@@ -33,66 +74,252 @@ func (h *RiskHandler) CalculateVAR(c *fiber.Ctx) error {
 		})
 	}
 
+	params, err := parseVaRParams(c, h.config)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("var:%s:%g:%d:%d:%d:%d", params.Method, params.Confidence, params.Horizon,
+		params.Lookback, params.Simulations, params.Seed)
+	result, cached, err := cache.GetPortfolioRisk(c.UserContext(), portfolioUUID, key, c.QueryBool("force_refresh"), func() (*VaRResponse, error) {
+		return h.calculateVaR(c, portfolioUUID, params)
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Cached = cached
+	return c.JSON(result)
+}
+
+// parseVaRParams reads the VaR method and parameters of CalculateVAR from the query, defaulting
+// to the configured ones
+func parseVaRParams(c *fiber.Ctx, cfg *config.RiskConfig) (services.VaRParams, error) {
+	var err error
+	params := services.DefaultVaRParams(cfg)
+	params.Method = strings.ToLower(c.Query("method", params.Method))
+	if raw := c.Query("confidence"); raw != "" {
+		if params.Confidence, err = strconv.ParseFloat(raw, 64); err != nil {
+			return params, apperror.BadRequest("confidence must be a number, e.g. 0.99")
+		}
+	}
+	params.Horizon = c.QueryInt("horizon", params.Horizon)
+	params.Lookback = c.QueryInt("lookback", params.Lookback)
+	params.Simulations = c.QueryInt("simulations", params.Simulations)
+	if raw := c.Query("seed"); raw != "" {
+		if params.Seed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return params, apperror.BadRequest("seed must be an integer")
+		}
+	}
+	if err := params.Validate(); err != nil {
+		return params, apperror.BadRequest(err.Error())
+	}
+	return params, nil
+}
+
+// VaRResponse is a portfolio's VaR as CalculateVAR returns and caches it
+type VaRResponse struct {
+	PortfolioID          uuid.UUID                        `json:"portfolio_id"`
+	VaRValue             decimal.Decimal                  `json:"var_value"`
+	VaRPercentage        decimal.Decimal                  `json:"var_percentage"`
+	ConfidenceLevel      float64                          `json:"confidence_level"`
+	TimeHorizon          int                              `json:"time_horizon"`
+	Method               string                           `json:"method"`
+	Details              models.JSON                      `json:"details"`
+	PortfolioValue       decimal.Decimal                  `json:"portfolio_value"`
+	PositionsValue       decimal.Decimal                  `json:"positions_value"`
+	CashBalance          decimal.Decimal                  `json:"cash_balance"`
+	Status               string                           `json:"status"`
+	Threshold            decimal.Decimal                  `json:"threshold"`
+	LiquidityAdjustedVaR *calculator.LiquidityAdjustedVaR `json:"liquidity_adjusted_var"`
+	CalculatedAt         time.Time                        `json:"calculated_at"`
+	Cached               bool                             `json:"cached"` // Served from the cache rather than calculated for this request
+}
+
+// calculateVaR calculates and stores the VaR of a portfolio
+func (h *RiskHandler) calculateVaR(c *fiber.Ctx, portfolioID uuid.UUID, params services.VaRParams) (*VaRResponse, error) {
+	ctx := c.UserContext()
+
 	// Get portfolio and positions
+	loadCtx, span := tracing.Start(ctx, "risk.var.load_portfolio", tracing.String("portfolio_id", portfolioID.String()))
 	var portfolio models.Portfolio
-	if err := database.GetDB().Preload("Positions").First(&portfolio, portfolioUUID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Portfolio not found",
-		})
+	err := database.GetDB().WithContext(loadCtx).Preload("Positions").First(&portfolio, portfolioID).Error
+	span.RecordError(err)
+	span.End()
+	if err != nil {
+		return nil, apperror.NotFound("Portfolio not found")
 	}
 
 	if len(portfolio.Positions) == 0 {
+		return nil, apperror.BadRequest("Portfolio has no positions")
+	}
+
+	riskMetric, lvar, err := h.varService.Calculate(ctx, &portfolio, params)
+	if errors.Is(err, services.ErrInsufficientPriceHistory) {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity, apperror.CodeBadRequest,
+			"Not enough NAV snapshots to calculate "+params.Method+" VaR; use method=simplified")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, apperror.New(fiber.StatusServiceUnavailable, apperror.CodeUnavailable,
+			"VaR calculation timed out; try fewer simulations or a shorter lookback")
+	}
+	if err != nil {
+		return nil, apperror.Internal("Failed to calculate VaR", err)
+	}
+
+	// Store the metric in database
+	persistCtx, span := tracing.Start(ctx, "risk.var.persist")
+	span.RecordError(database.GetDB().WithContext(persistCtx).Create(riskMetric).Error)
+	span.End()
+
+	return &VaRResponse{
+		PortfolioID:          portfolioID,
+		VaRValue:             riskMetric.Value,
+		VaRPercentage:        services.SimplifiedVaRPercent(&portfolio, riskMetric.Value),
+		ConfidenceLevel:      params.Confidence,
+		TimeHorizon:          params.Horizon,
+		Method:               params.Method,
+		Details:              riskMetric.Details,
+		PortfolioValue:       portfolio.ValueWithCash(),
+		PositionsValue:       portfolio.TotalValue,
+		CashBalance:          portfolio.CashBalance,
+		Status:               riskMetric.Status,
+		Threshold:            riskMetric.Threshold,
+		LiquidityAdjustedVaR: lvar,
+		CalculatedAt:         time.Now(),
+	}, nil
+}
+
+// CalculateLiquidityRisk calculates liquidity risk for a portfolio. The result is cached like
+// CalculateVAR's; ?force_refresh=true recalculates it.
+func (h *RiskHandler) CalculateLiquidityRisk(c *fiber.Ctx) error {
+	portfolioID := c.Params("id")
+	portfolioUUID, err := uuid.Parse(portfolioID)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Portfolio has no positions",
+			"error": "Invalid portfolio ID",
 		})
 	}
 
-	// Simple VaR calculation - 5% of portfolio value at 95% confidence
-	varPercentage := 0.05 // 5% VaR
-	varValue := portfolio.TotalValue.Mul(decimal.NewFromFloat(varPercentage))
-	threshold := portfolio.TotalValue.Mul(decimal.NewFromFloat(0.08)) // 8% threshold
+	result, cached, err := cache.GetPortfolioRisk(c.UserContext(), portfolioUUID, "liquidity", c.QueryBool("force_refresh"), func() (*LiquidityResponse, error) {
+		return h.calculateLiquidity(c, portfolioUUID)
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Cached = cached
+	return c.JSON(result)
+}
+
+// LiquidityResponse is a portfolio's liquidity as CalculateLiquidityRisk returns and caches it
+type LiquidityResponse struct {
+	PortfolioID          uuid.UUID                        `json:"portfolio_id"`
+	LiquidityRatio       decimal.Decimal                  `json:"liquidity_ratio"`
+	LiquidityScore       string                           `json:"liquidity_score"`
+	DaysToLiquidate      float64                          `json:"days_to_liquidate"`
+	RiskAssessment       string                           `json:"risk_assessment"`
+	Status               string                           `json:"status"`
+	CalculatedAt         time.Time                        `json:"calculated_at"`
+	LiquidityAdjustedVaR *calculator.LiquidityAdjustedVaR `json:"liquidity_adjusted_var"`
+	CashBalance          decimal.Decimal                  `json:"cash_balance"`
+	Breakdown            LiquidityBreakdown               `json:"breakdown"`
+	Cached               bool                             `json:"cached"` // Served from the cache rather than calculated for this request
+}
 
-	status := "SAFE"
-	if varValue.GreaterThan(threshold) {
-		status = "CRITICAL"
-	} else if varValue.GreaterThan(threshold.Mul(decimal.NewFromFloat(0.75))) {
-		status = "WARNING"
+// LiquidityBreakdown is the value of a portfolio in each liquidity tier
+type LiquidityBreakdown struct {
+	High   decimal.Decimal `json:"HIGH"` // Includes cash
+	Medium decimal.Decimal `json:"MEDIUM"`
+	Low    decimal.Decimal `json:"LOW"`
+}
+
+// calculateLiquidity calculates and stores the liquidity of a portfolio
+func (h *RiskHandler) calculateLiquidity(c *fiber.Ctx, portfolioID uuid.UUID) (*LiquidityResponse, error) {
+	db := database.GetDB().WithContext(c.UserContext())
+
+	// Get portfolio and positions
+	var portfolio models.Portfolio
+	if err := db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		return nil, apperror.NotFound("Portfolio not found")
+	}
+
+	// Cash is fully liquid, so a portfolio holding only cash still has a liquidity ratio
+	if len(portfolio.Positions) == 0 && !portfolio.CashBalance.IsPositive() {
+		return nil, apperror.BadRequest("Portfolio has no positions")
+	}
+
+	result, err := h.liquidityMetric(&portfolio)
+	if err != nil {
+		return nil, apperror.Internal("Failed to calculate liquidity-adjusted VaR", err)
 	}
 
 	// Store the metric in database
-	riskMetric := models.RiskMetric{
-		PortfolioID:     portfolioUUID,
-		MetricType:      "VAR",
-		Value:           varValue,
-		Threshold:       threshold,
-		Status:          status,
-		TimeHorizon:     h.config.VARTimeHorizon,
-		ConfidenceLevel: decimal.NewFromFloat(h.config.VARConfidenceLevel),
-		Details: models.JSON{
-			"method":          "simplified",
-			"portfolio_value": portfolio.TotalValue.InexactFloat64(),
-			"position_count":  len(portfolio.Positions),
+	db.Create(result.Metric)
+
+	return &LiquidityResponse{
+		PortfolioID:          portfolioID,
+		LiquidityRatio:       result.Metric.Value,
+		LiquidityScore:       result.Assessment,
+		DaysToLiquidate:      result.DaysToLiquidate,
+		RiskAssessment:       result.Assessment,
+		Status:               result.Metric.Status,
+		CalculatedAt:         time.Now(),
+		LiquidityAdjustedVaR: result.LVaR,
+		CashBalance:          result.Cash,
+		Breakdown: LiquidityBreakdown{
+			High:   result.High,
+			Medium: result.Medium,
+			Low:    result.Low,
 		},
+	}, nil
+}
+
+// liquidityResult is a portfolio's liquidity breakdown and the risk metric it is stored as
+type liquidityResult struct {
+	Metric          *models.RiskMetric
+	Assessment      string
+	DaysToLiquidate float64
+	Cash            decimal.Decimal
+	High            decimal.Decimal
+	Medium          decimal.Decimal
+	Low             decimal.Decimal
+	LVaR            *calculator.LiquidityAdjustedVaR
+}
+
+// liquidityMetric calculates the share of a portfolio, cash included, that is highly liquid
+func (h *RiskHandler) liquidityMetric(portfolio *models.Portfolio) (*liquidityResult, error) {
+	metric, breakdown, lvar, err := services.LiquidityMetric(portfolio, h.config.VARTimeHorizon)
+	if err != nil {
+		return nil, err
 	}
 
-	database.GetDB().Create(&riskMetric)
+	// Determine risk assessment
+	riskAssessment := "LOW_RISK"
+	daysToLiquidate := 1.0
+	switch metric.Status {
+	case "CRITICAL":
+		riskAssessment = "HIGH_RISK"
+		daysToLiquidate = 10.0
+	case "WARNING":
+		riskAssessment = "MEDIUM_RISK"
+		daysToLiquidate = 3.5
+	}
 
-	return c.JSON(fiber.Map{
-		"portfolio_id":     portfolioID,
-		"var_value":        varValue,
-		"var_percentage":   varPercentage * 100,
-		"confidence_level": h.config.VARConfidenceLevel,
-		"time_horizon":     h.config.VARTimeHorizon,
-		"method":           "simplified",
-		"portfolio_value":  portfolio.TotalValue,
-		"status":           status,
-		"threshold":        threshold,
-		"calculated_at":    time.Now(),
-	})
+	return &liquidityResult{
+		Metric:          metric,
+		Assessment:      riskAssessment,
+		DaysToLiquidate: daysToLiquidate,
+		Cash:            breakdown.Cash,
+		High:            breakdown.High,
+		Medium:          breakdown.Medium,
+		Low:             breakdown.Low,
+		LVaR:            lvar,
+	}, nil
 }
 
-// CalculateLiquidityRisk calculates liquidity risk for a portfolio
-func (h *RiskHandler) CalculateLiquidityRisk(c *fiber.Ctx) error {
+// CalculateConcentration reports position, sector and asset class concentration for a portfolio
+func (h *RiskHandler) CalculateConcentration(c *fiber.Ctx) error {
 	portfolioID := c.Params("id")
 	portfolioUUID, err := uuid.Parse(portfolioID)
 	if err != nil {
@@ -101,9 +328,11 @@ func (h *RiskHandler) CalculateLiquidityRisk(c *fiber.Ctx) error {
 		})
 	}
 
+	db := database.GetDB().WithContext(c.UserContext())
+
 	// Get portfolio and positions
 	var portfolio models.Portfolio
-	if err := database.GetDB().Preload("Positions").First(&portfolio, portfolioUUID).Error; err != nil {
+	if err := db.Preload("Positions").First(&portfolio, portfolioUUID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Portfolio not found",
 		})
@@ -115,85 +344,316 @@ func (h *RiskHandler) CalculateLiquidityRisk(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate liquidity breakdown
-	totalValue := decimal.Zero
-	highLiquid := decimal.Zero
-	mediumLiquid := decimal.Zero
-	lowLiquid := decimal.Zero
-
-	for _, position := range portfolio.Positions {
-		totalValue = totalValue.Add(position.MarketValue)
-		switch position.Liquidity {
-		case "HIGH":
-			highLiquid = highLiquid.Add(position.MarketValue)
-		case "MEDIUM":
-			mediumLiquid = mediumLiquid.Add(position.MarketValue)
-		case "LOW":
-			lowLiquid = lowLiquid.Add(position.MarketValue)
-		default:
-			highLiquid = highLiquid.Add(position.MarketValue) // Default to high
-		}
+	concentration := h.concentration
+	if top := c.QueryInt("top", 0); top > 0 {
+		concentration = calculator.NewConcentrationCalculator(top)
 	}
 
-	liquidityRatio := decimal.Zero
-	if !totalValue.IsZero() {
-		liquidityRatio = highLiquid.Div(totalValue)
+	riskMetric, result, err := h.concentrationMetric(&portfolio, concentration)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load risk thresholds",
+		})
 	}
 
-	// Determine risk assessment
-	riskAssessment := "LOW_RISK"
-	daysToLiquidate := 1.0
-	status := "SAFE"
-	ratio := liquidityRatio.InexactFloat64()
+	// Store the metric in database
+	db.Create(riskMetric)
 
-	if ratio < 0.3 {
-		riskAssessment = "HIGH_RISK"
-		daysToLiquidate = 10.0
-		status = "CRITICAL"
-	} else if ratio < 0.7 {
-		riskAssessment = "MEDIUM_RISK"
-		daysToLiquidate = 3.5
-		status = "WARNING"
+	return c.JSON(fiber.Map{
+		"portfolio_id":  portfolioID,
+		"concentration": result,
+		"calculated_at": result.Timestamp,
+	})
+}
+
+// concentrationMetric calculates position, sector and asset class concentration of a portfolio with
+// positions against its risk thresholds
+func (h *RiskHandler) concentrationMetric(portfolio *models.Portfolio, concentration *calculator.ConcentrationCalculator) (*models.RiskMetric, *calculator.ConcentrationResult, error) {
+	thresholds, err := h.riskEngine.GetThresholds(portfolio.ID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Store the metric in database
-	threshold := decimal.NewFromFloat(0.3) // 30% threshold
-	riskMetric := models.RiskMetric{
-		PortfolioID: portfolioUUID,
-		MetricType:  "LIQUIDITY_RATIO",
-		Value:       liquidityRatio,
-		Threshold:   threshold,
-		Status:      status,
+	result := concentration.CalculateConcentration(portfolio.Positions, calculator.ConcentrationLimits{
+		MaxHHI:            thresholds.MaxConcentration.InexactFloat64(),
+		MaxSingleAsset:    thresholds.MaxSingleAssetExposure.InexactFloat64(),
+		MaxSectorExposure: thresholds.MaxSectorExposure.InexactFloat64(),
+	})
+
+	return &models.RiskMetric{
+		PortfolioID: portfolio.ID,
+		MetricType:  "CONCENTRATION",
+		Value:       decimal.NewFromFloat(result.HHI),
+		Threshold:   thresholds.MaxConcentration,
+		Status:      result.Status,
 		Details: models.JSON{
-			"breakdown": fiber.Map{
-				"HIGH":   highLiquid.InexactFloat64(),
-				"MEDIUM": mediumLiquid.InexactFloat64(),
-				"LOW":    lowLiquid.InexactFloat64(),
-			},
-			"portfolio_value": totalValue.InexactFloat64(),
-			"position_count":  len(portfolio.Positions),
+			"top_n_weight":   result.TopNWeight,
+			"sector_count":   len(result.Sectors),
+			"breach_count":   len(result.Breaches),
+			"position_count": result.PositionCount,
 		},
+	}, result, nil
+}
+
+// GetLeverage reports gross and net leverage and the margin position of a portfolio, raising
+// alerts when the leverage limit or maintenance margin is breached
+func (h *RiskHandler) GetLeverage(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
 	}
 
-	database.GetDB().Create(&riskMetric)
+	result, err := h.leverage.CheckLeverage(c.UserContext(), portfolioUUID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate leverage",
+		})
+	}
 
 	return c.JSON(fiber.Map{
-		"portfolio_id":      portfolioID,
-		"liquidity_ratio":   liquidityRatio,
-		"liquidity_score":   riskAssessment,
-		"days_to_liquidate": daysToLiquidate,
-		"risk_assessment":   riskAssessment,
-		"status":            status,
-		"calculated_at":     time.Now(),
-		"breakdown": fiber.Map{
-			"HIGH":   highLiquid,
-			"MEDIUM": mediumLiquid,
-			"LOW":    lowLiquid,
-		},
+		"portfolio_id":  portfolioUUID,
+		"leverage":      result,
+		"calculated_at": result.Timestamp,
+	})
+}
+
+// GetDrawdown reports the daily and weekly losses and the drawdown from the NAV peak of a
+// portfolio, raising alerts when a loss limit is hit
+func (h *RiskHandler) GetDrawdown(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.drawdown.CheckDrawdown(c.UserContext(), portfolioUUID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate drawdown",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio_id":  portfolioUUID,
+		"drawdown":      result,
+		"calculated_at": result.Timestamp,
 	})
 }
 
-// GetRiskMetrics returns risk metrics for a portfolio
+// GetInterestRateRisk reports the duration, convexity, DV01 and credit exposure of a portfolio's
+// bonds, raising an alert when DV01 breaches the threshold
+func (h *RiskHandler) GetInterestRateRisk(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.fixedIncome.CheckInterestRateRisk(c.UserContext(), portfolioUUID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate interest rate risk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio_id":       portfolioUUID,
+		"interest_rate_risk": result,
+		"calculated_at":      result.Timestamp,
+	})
+}
+
+// GetCryptoRisk reports the volatility, chain liquidity, venue exposure and stablecoin pegs of a
+// portfolio's crypto assets, raising alerts on venue breaches and depegs
+func (h *RiskHandler) GetCryptoRisk(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.crypto.CheckCryptoRisk(c.UserContext(), portfolioUUID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate crypto risk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio_id":  portfolioUUID,
+		"crypto_risk":   result,
+		"calculated_at": result.Timestamp,
+	})
+}
+
+// GetCurrencyExposure reports a portfolio's exposure by currency in its base currency and its FX
+// risk, raising an alert when the foreign currency share breaches the threshold
+func (h *RiskHandler) GetCurrencyExposure(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	result, err := h.currency.CheckExposure(c.UserContext(), portfolioUUID)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate currency exposure",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio_id":      portfolioUUID,
+		"currency_exposure": result,
+		"calculated_at":     result.Timestamp,
+	})
+}
+
+// GetVaRContributions ranks the positions of a portfolio by their contribution to its VaR at
+// ?confidence= (default the configured VaR confidence level), with their marginal and
+// incremental VaR
+func (h *RiskHandler) GetVaRContributions(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	confidence := h.config.VARConfidenceLevel
+	if raw := c.Query("confidence"); raw != "" {
+		confidence, err = strconv.ParseFloat(raw, 64)
+		if err != nil || confidence <= 0 || confidence >= 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "confidence must be between 0 and 1, e.g. 0.99",
+			})
+		}
+	}
+
+	result, err := h.varService.Contributions(c.UserContext(), portfolioUUID, confidence)
+	if err != nil {
+		if err.Error() == "portfolio not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate VaR contributions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"portfolio_id":  portfolioUUID,
+		"var":           result,
+		"calculated_at": time.Now(),
+	})
+}
+
+// GetBacktest validates stored VaR forecasts against realized P&L over ?days= (default 250) at
+// ?confidence= (default the configured VaR confidence level)
+func (h *RiskHandler) GetBacktest(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	confidence, days, err := parseBacktestParams(c, h.config)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.backtest.BacktestVaR(portfolioUUID, confidence, days)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to backtest VaR",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// parseBacktestParams reads the confidence level and number of days of GetBacktest from the query
+func parseBacktestParams(c *fiber.Ctx, cfg *config.RiskConfig) (float64, int, error) {
+	days := c.QueryInt("days", 250)
+	if days <= 0 || days > 1000 {
+		return 0, 0, apperror.BadRequest("days must be between 1 and 1000")
+	}
+
+	confidence := cfg.VARConfidenceLevel
+	if raw := c.Query("confidence"); raw != "" {
+		var err error
+		confidence, err = strconv.ParseFloat(raw, 64)
+		if err != nil || confidence <= 0 || confidence >= 1 {
+			return 0, 0, apperror.BadRequest("confidence must be between 0 and 1, e.g. 0.99")
+		}
+	}
+	return confidence, days, nil
+}
+
+// riskMetricListSpec lists the filters and sort fields GetRiskMetrics accepts
+var riskMetricListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"calculated_at": "calculated_at",
+		"metric_type":   "metric_type",
+		"value":         "value",
+	},
+	DefaultSort: "calculated_at",
+	DateColumn:  "calculated_at",
+	Filters: map[string]string{
+		"metric_type": "metric_type",
+		"status":      "status",
+	},
+}
+
+// riskHistoryListSpec lists the filters and sort fields GetRiskHistory accepts
+var riskHistoryListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"recorded_at": "recorded_at",
+		"value":       "value",
+	},
+	DefaultSort:  "recorded_at",
+	DateColumn:   "recorded_at",
+	DefaultLimit: 30,
+	Filters: map[string]string{
+		"metric_type": "metric_type",
+		"resolution":  "resolution",
+	},
+}
+
+// GetRiskMetrics returns a page of risk metrics for a portfolio
 func (h *RiskHandler) GetRiskMetrics(c *fiber.Ctx) error {
 	portfolioID := c.Params("id")
 	portfolioUUID, err := uuid.Parse(portfolioID)
@@ -203,33 +663,57 @@ func (h *RiskHandler) GetRiskMetrics(c *fiber.Ctx) error {
 		})
 	}
 
-	var metrics []models.RiskMetric
-	if err := database.GetDB().Preload("Portfolio").Preload("Portfolio.User").
-		Where("portfolio_id = ?", portfolioUUID).
-		Order("calculated_at DESC").
-		Find(&metrics).Error; err != nil {
+	params, err := pagination.Parse(c, riskMetricListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page, err := h.dashboard.ListRiskMetrics(c.UserContext(), portfolioUUID, riskMetricListSpec, params,
+		string(c.Request().URI().QueryString()))
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve risk metrics",
 		})
 	}
 
-	if len(metrics) == 0 {
-		// Return instructions to calculate fresh metrics if none exist
-		return c.JSON([]fiber.Map{
-			{
-				"portfolio_id":       portfolioID,
-				"metric_type":        "NONE",
-				"message":            "No historical metrics found - calculate VaR and liquidity separately",
-				"var_endpoint":       "/api/v1/risk/portfolio/" + portfolioID + "/var",
-				"liquidity_endpoint": "/api/v1/risk/portfolio/" + portfolioID + "/liquidity",
-			},
+	response := pagination.Response(page.Metrics, page.Total, params)
+	if page.Total == 0 {
+		// Point the client at the endpoints that calculate fresh metrics
+		response["message"] = "No historical metrics found - calculate VaR and liquidity separately"
+		response["var_endpoint"] = "/api/v1/risk/portfolio/" + portfolioID + "/var"
+		response["liquidity_endpoint"] = "/api/v1/risk/portfolio/" + portfolioID + "/liquidity"
+	}
+
+	return c.JSON(response)
+}
+
+// GetLatestRiskMetrics returns the most recent metric of each type calculated for a portfolio
+func (h *RiskHandler) GetLatestRiskMetrics(c *fiber.Ctx) error {
+	portfolioUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid portfolio ID",
+		})
+	}
+
+	metrics, err := h.dashboard.LatestRiskMetrics(c.UserContext(), portfolioUUID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve risk metrics",
 		})
 	}
 
-	return c.JSON(metrics)
+	return c.JSON(fiber.Map{
+		"portfolio_id": portfolioUUID,
+		"metrics":      metrics,
+	})
 }
 
-// GetRiskHistory returns historical risk data for a portfolio
+// GetRiskHistory returns a page of historical risk data for a portfolio. With interval (1h, 1d or
+// 1w) it returns the history aggregated per metric into buckets of that interval instead, each
+// the max, avg or last value of the bucket as chosen by agg (default avg).
 func (h *RiskHandler) GetRiskHistory(c *fiber.Ctx) error {
 	portfolioID := c.Params("id")
 	portfolioUUID, err := uuid.Parse(portfolioID)
@@ -239,38 +723,48 @@ func (h *RiskHandler) GetRiskHistory(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get optional query parameters
-	metricType := c.Query("metric_type", "")
-	limitStr := c.Query("limit", "30")
-	limit, _ := strconv.Atoi(limitStr)
-	if limit <= 0 || limit > 1000 {
-		limit = 30
+	params, err := pagination.Parse(c, riskHistoryListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	var history []models.RiskHistory
-	query := database.GetDB().Where("portfolio_id = ?", portfolioUUID)
+	if interval := c.Query("interval"); interval != "" {
+		agg := c.Query("agg", "avg")
+		buckets, total, err := h.history.Aggregate(c.UserContext(), portfolioUUID, interval, agg, riskHistoryListSpec, params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidHistoryInterval) || errors.Is(err, services.ErrInvalidHistoryAggregate) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to aggregate risk history",
+			})
+		}
 
-	if metricType != "" {
-		query = query.Where("metric_type = ?", metricType)
+		response := pagination.Response(buckets, total, params)
+		response["interval"] = interval
+		response["agg"] = agg
+		return c.JSON(response)
 	}
 
-	if err := query.Order("recorded_at DESC").Limit(limit).Find(&history).Error; err != nil {
+	var history []models.RiskHistory
+	query := database.GetDB().WithContext(c.UserContext()).Model(&models.RiskHistory{}).Where("portfolio_id = ?", portfolioUUID)
+
+	total, err := pagination.Find(query, riskHistoryListSpec, params, &history)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve risk history",
 		})
 	}
 
-	if len(history) == 0 {
-		// Return sample history if none exists
-		return c.JSON([]fiber.Map{
-			{
-				"portfolio_id": portfolioID,
-				"metric_type":  "VAR",
-				"message":      "No historical data available",
-				"suggestion":   "Calculate some risk metrics first to build history",
-			},
-		})
+	response := pagination.Response(history, total, params)
+	if total == 0 {
+		response["message"] = "No historical data available"
+		response["suggestion"] = "Risk history is recorded on a schedule; check back after the next snapshot"
 	}
 
-	return c.JSON(history)
+	return c.JSON(response)
 }
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// summaryMetric is a risk metric as reported by the risk summary
+type summaryMetric struct {
+	Value        decimal.Decimal `json:"value"`
+	Threshold    decimal.Decimal `json:"threshold"`
+	Status       string          `json:"status"`
+	CalculatedAt time.Time       `json:"calculated_at"`
+	Recalculated bool            `json:"recalculated"` // Calculated for this request rather than stored
+}
+
+// portfolioRiskSummary is the latest VaR, liquidity, concentration and alert counts of a portfolio.
+// A metric that cannot be calculated is left out with the reason in Errors.
+type portfolioRiskSummary struct {
+	PortfolioID   uuid.UUID             `json:"portfolio_id"`
+	Name          string                `json:"name"`
+	Currency      string                `json:"currency"`
+	ValueWithCash decimal.Decimal       `json:"value_with_cash"`
+	VaR           *summaryMetric        `json:"var"`
+	Liquidity     *summaryMetric        `json:"liquidity"`
+	Concentration *summaryMetric        `json:"concentration"`
+	ActiveAlerts  *services.AlertCounts `json:"active_alerts"`
+	Errors        map[string]string     `json:"errors,omitempty"`
+}
+
+// GetSummary reports the risk of every portfolio the caller owns, alone or through a team, in one
+// call. Metrics stored within the configured maximum age are reused and the rest recalculated and
+// stored, with the portfolios spread over a bounded pool of workers.
+func (h *RiskHandler) GetSummary(c *fiber.Ctx) error {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	ctx := c.UserContext()
+	var portfolios []models.Portfolio
+	err = database.GetDB().WithContext(ctx).Preload("Positions").
+		Where("user_id = ? OR team_id IN (SELECT team_id FROM team_members WHERE user_id = ?)", userID, userID).
+		Order("name").Find(&portfolios).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch portfolios",
+		})
+	}
+
+	summaries := make([]*portfolioRiskSummary, len(portfolios))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(h.config.SummaryWorkers, 1), len(portfolios)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				summaries[i] = h.summarizePortfolio(ctx, &portfolios[i])
+			}
+		}()
+	}
+	for i := range portfolios {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return c.JSON(fiber.Map{
+		"portfolios":    summaries,
+		"count":         len(summaries),
+		"calculated_at": time.Now(),
+	})
+}
+
+// summarizePortfolio fetches or calculates the risk summary of a portfolio loaded with its positions
+func (h *RiskHandler) summarizePortfolio(ctx context.Context, portfolio *models.Portfolio) *portfolioRiskSummary {
+	summary := &portfolioRiskSummary{
+		PortfolioID:   portfolio.ID,
+		Name:          portfolio.Name,
+		Currency:      portfolio.Currency,
+		ValueWithCash: portfolio.ValueWithCash(),
+		Errors:        make(map[string]string),
+	}
+
+	latest := make(map[string]models.RiskMetric)
+	if metrics, err := h.dashboard.LatestRiskMetrics(ctx, portfolio.ID); err == nil {
+		for _, metric := range metrics {
+			latest[metric.MetricType] = metric
+		}
+	}
+	fresh := func(metricType string) *summaryMetric {
+		metric, ok := latest[metricType]
+		if !ok || time.Since(metric.CalculatedAt) > h.config.SummaryMaxAge {
+			return nil
+		}
+		return &summaryMetric{Value: metric.Value, Threshold: metric.Threshold, Status: metric.Status, CalculatedAt: metric.CalculatedAt}
+	}
+	calculated := func(metric *models.RiskMetric) *summaryMetric {
+		// The summary is still served if the metric cannot be stored
+		database.GetDB().WithContext(ctx).Create(metric)
+		return &summaryMetric{Value: metric.Value, Threshold: metric.Threshold, Status: metric.Status,
+			CalculatedAt: metric.CalculatedAt, Recalculated: true}
+	}
+	hasPositions := len(portfolio.Positions) > 0
+
+	if summary.VaR = fresh("VAR"); summary.VaR == nil {
+		if !hasPositions {
+			summary.Errors["var"] = "Portfolio has no positions"
+		} else if metric, _, err := services.VaRMetric(portfolio, h.config); err != nil {
+			summary.Errors["var"] = "Failed to calculate VaR"
+		} else {
+			summary.VaR = calculated(metric)
+		}
+	}
+
+	if summary.Liquidity = fresh("LIQUIDITY_RATIO"); summary.Liquidity == nil {
+		if !hasPositions && !portfolio.CashBalance.IsPositive() {
+			summary.Errors["liquidity"] = "Portfolio has no positions"
+		} else if result, err := h.liquidityMetric(portfolio); err != nil {
+			summary.Errors["liquidity"] = "Failed to calculate liquidity"
+		} else {
+			summary.Liquidity = calculated(result.Metric)
+		}
+	}
+
+	if summary.Concentration = fresh("CONCENTRATION"); summary.Concentration == nil {
+		if !hasPositions {
+			summary.Errors["concentration"] = "Portfolio has no positions"
+		} else if metric, _, err := h.concentrationMetric(portfolio, h.concentration); err != nil {
+			summary.Errors["concentration"] = "Failed to calculate concentration"
+		} else {
+			summary.Concentration = calculated(metric)
+		}
+	}
+
+	counts, err := h.dashboard.PortfolioAlertCounts(ctx, portfolio.ID)
+	if err != nil {
+		summary.Errors["active_alerts"] = "Failed to count alerts"
+	} else {
+		summary.ActiveAlerts = counts
+	}
+
+	if len(summary.Errors) == 0 {
+		summary.Errors = nil
+	}
+	return summary
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// GetThresholds returns a portfolio's effective risk thresholds, with the limits set on the
+// portfolio itself in overrides and their version as the ETag
+func (h *RiskHandler) GetThresholds(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	thresholds, err := h.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return apperror.Internal("Failed to load risk thresholds", err)
+	}
+
+	setETag(c, thresholds.Version)
+	return c.JSON(thresholds)
+}
+
+// UpdateThresholds changes some of the limits set on a portfolio, or with inherit takes them from
+// its parent again; portfolios under it inheriting a limit follow the change. The change names the
+// version it was made against in If-Match or the version field, and is refused with the current
+// thresholds if they have changed since.
+func (h *RiskHandler) UpdateThresholds(c *fiber.Ctx) error {
+	portfolioID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid portfolio ID")
+	}
+
+	var req services.ThresholdsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	version, err := expectedVersion(c, req.Version)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return apperror.Internal("Failed to load risk thresholds", err)
+	}
+	thresholds, err := h.riskEngine.UpdateThresholds(portfolioID, version, &req.ThresholdOverrides, req.Inherit)
+	if err != nil {
+		return serviceError(err, "Failed to update risk thresholds")
+	}
+
+	recordAudit(c, h.auditService, "risk_thresholds.update", "risk_thresholds", thresholds.ID.String(), before, thresholds)
+
+	setETag(c, thresholds.Version)
+	return c.JSON(thresholds)
+}
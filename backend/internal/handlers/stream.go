@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/websocket"
+)
+
+// StreamHandler serves the WebSocket gateway's events over Server-Sent Events, for clients behind
+// proxies that block WebSocket upgrades
+type StreamHandler struct {
+	hub *websocket.Hub
+}
+
+func NewStreamHandler(hub *websocket.Hub) *StreamHandler {
+	return &StreamHandler{hub: hub}
+}
+
+// Stream opens an event stream of the alerts, risk updates, order updates and prices the user may
+// see. ?portfolios=, ?severities= and ?symbols= take comma-separated subscription filters; the
+// Last-Event-ID header, or ?last_event_id= on the first connection, resumes after the events
+// already received.
+func (h *StreamHandler) Stream(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+	client := websocket.Client{UserID: userID, Role: role, IP: c.IP()}
+
+	filters := websocket.ClientMessage{
+		Portfolios: splitQueryList(c.Query("portfolios")),
+		Severities: splitQueryList(c.Query("severities")),
+		Symbols:    splitQueryList(c.Query("symbols")),
+	}
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	lastSeen := websocket.ParseLastEventID(lastEventID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.hub.ServeSSE(w, client, filters, lastSeen)
+	})
+	return nil
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty entries
+func splitQueryList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// Symbol lists, the :list route parameter
+const (
+	symbolListRestricted = "restricted"
+	symbolListWatch      = "watch"
+)
+
+type SymbolListHandler struct {
+	symbolListService *services.SymbolListService
+	auditService      *services.AuditService
+}
+
+func NewSymbolListHandler() *SymbolListHandler {
+	return &SymbolListHandler{
+		symbolListService: services.NewSymbolListService(),
+		auditService:      services.NewAuditService(),
+	}
+}
+
+type SymbolListRequest struct {
+	Symbol        string `json:"symbol" validate:"notblank"`
+	Side          string `json:"side"` // BUY, SELL or empty for both
+	Reason        string `json:"reason" validate:"notblank"`
+	EffectiveFrom string `json:"effective_from"` // RFC3339, defaults to now for new entries
+	EffectiveTo   string `json:"effective_to"`   // RFC3339, empty for no end
+}
+
+// symbolListSpec lists the filters and sort fields GetEntries accepts
+var symbolListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"symbol":         "symbol",
+		"effective_from": "effective_from",
+		"created_at":     "created_at",
+	},
+	DefaultSort: "created_at",
+	Filters: map[string]string{
+		"symbol": "symbol",
+		"side":   "side",
+	},
+}
+
+func unknownSymbolList(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "Unknown list, use restricted or watch",
+	})
+}
+
+func symbolListEntryNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "List entry not found",
+	})
+}
+
+// applySymbolListRequest copies a request onto a list entry. An entry keeps its start when the
+// request gives none.
+func applySymbolListRequest(entry *models.SymbolListEntry, req SymbolListRequest) error {
+	entry.Symbol = req.Symbol
+	entry.Side = req.Side
+	entry.Reason = req.Reason
+
+	if req.EffectiveFrom != "" {
+		effectiveFrom, err := time.Parse(time.RFC3339, req.EffectiveFrom)
+		if err != nil {
+			return errors.New("invalid effective_from, use RFC3339")
+		}
+		entry.EffectiveFrom = effectiveFrom
+	}
+	entry.EffectiveTo = nil
+	if req.EffectiveTo != "" {
+		effectiveTo, err := time.Parse(time.RFC3339, req.EffectiveTo)
+		if err != nil {
+			return errors.New("invalid effective_to, use RFC3339")
+		}
+		entry.EffectiveTo = &effectiveTo
+	}
+	return nil
+}
+
+// GetEntries returns a page of the restricted or watch list (:list), including entries that are
+// not in effect
+func (h *SymbolListHandler) GetEntries(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, symbolListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var entries interface{}
+	var total int64
+	switch c.Params("list") {
+	case symbolListRestricted:
+		entries, total, err = h.symbolListService.ListRestricted(symbolListSpec, params)
+	case symbolListWatch:
+		entries, total, err = h.symbolListService.ListWatched(symbolListSpec, params)
+	default:
+		return unknownSymbolList(c)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve list entries",
+		})
+	}
+
+	return c.JSON(pagination.Response(entries, total, params))
+}
+
+// GetEntry returns one entry of the restricted or watch list
+func (h *SymbolListHandler) GetEntry(c *fiber.Ctx) error {
+	entryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid list entry ID",
+		})
+	}
+
+	var entry interface{}
+	switch c.Params("list") {
+	case symbolListRestricted:
+		entry, err = h.symbolListService.GetRestricted(entryID)
+	case symbolListWatch:
+		entry, err = h.symbolListService.GetWatched(entryID)
+	default:
+		return unknownSymbolList(c)
+	}
+	if err != nil {
+		return symbolListEntryNotFound(c)
+	}
+
+	return c.JSON(entry)
+}
+
+// CreateEntry adds a symbol to the restricted or watch list
+func (h *SymbolListHandler) CreateEntry(c *fiber.Ctx) error {
+	var req SymbolListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	var fields models.SymbolListEntry
+	if err := applySymbolListRequest(&fields, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if userID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		fields.AddedBy = &userID
+	}
+
+	list := c.Params("list")
+	var entry interface{}
+	var err error
+	switch list {
+	case symbolListRestricted:
+		restricted := &models.RestrictedList{SymbolListEntry: fields}
+		err = h.symbolListService.SaveRestricted(restricted)
+		entry, fields = restricted, restricted.SymbolListEntry
+	case symbolListWatch:
+		watched := &models.WatchList{SymbolListEntry: fields}
+		err = h.symbolListService.SaveWatched(watched)
+		entry, fields = watched, watched.SymbolListEntry
+	default:
+		return unknownSymbolList(c)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "symbol_list.create", list+"_list", fields.ID.String(), nil, entry)
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// UpdateEntry changes an entry of the restricted or watch list, such as to end it
+func (h *SymbolListHandler) UpdateEntry(c *fiber.Ctx) error {
+	entryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid list entry ID",
+		})
+	}
+
+	var req SymbolListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	list := c.Params("list")
+	var entry, before interface{}
+	var fields *models.SymbolListEntry
+	var save func() error
+	switch list {
+	case symbolListRestricted:
+		restricted, err := h.symbolListService.GetRestricted(entryID)
+		if err != nil {
+			return symbolListEntryNotFound(c)
+		}
+		entry, before, fields = restricted, services.Snapshot(restricted), &restricted.SymbolListEntry
+		save = func() error { return h.symbolListService.SaveRestricted(restricted) }
+	case symbolListWatch:
+		watched, err := h.symbolListService.GetWatched(entryID)
+		if err != nil {
+			return symbolListEntryNotFound(c)
+		}
+		entry, before, fields = watched, services.Snapshot(watched), &watched.SymbolListEntry
+		save = func() error { return h.symbolListService.SaveWatched(watched) }
+	default:
+		return unknownSymbolList(c)
+	}
+
+	if err := applySymbolListRequest(fields, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err := save(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "symbol_list.update", list+"_list", entryID.String(), before, entry)
+
+	return c.JSON(entry)
+}
+
+// DeleteEntry removes an entry from the restricted or watch list. Ending an entry with
+// effective_to keeps its history instead.
+func (h *SymbolListHandler) DeleteEntry(c *fiber.Ctx) error {
+	entryID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid list entry ID",
+		})
+	}
+
+	list := c.Params("list")
+	var entry interface{}
+	switch list {
+	case symbolListRestricted:
+		entry, err = h.symbolListService.DeleteRestricted(entryID)
+	case symbolListWatch:
+		entry, err = h.symbolListService.DeleteWatched(entryID)
+	default:
+		return unknownSymbolList(c)
+	}
+	if err != nil {
+		return symbolListEntryNotFound(c)
+	}
+
+	recordAudit(c, h.auditService, "symbol_list.delete", list+"_list", entryID.String(), entry, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "List entry deleted successfully",
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// TeamHandler manages team memberships, which share the portfolios a team owns with its members
+type TeamHandler struct {
+	accessService *services.AccessService
+	auditService  *services.AuditService
+}
+
+func NewTeamHandler(accessService *services.AccessService) *TeamHandler {
+	return &TeamHandler{
+		accessService: accessService,
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// GetMembers returns the members of a team with their roles
+func (h *TeamHandler) GetMembers(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	members, err := h.accessService.ListTeamMembers(teamID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch team members",
+		})
+	}
+
+	return c.JSON(members)
+}
+
+// SetMember adds a user to a team as a VIEWER or EDITOR, or changes their role
+func (h *TeamHandler) SetMember(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Role string `json:"role"` // VIEWER (default) or EDITOR
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var addedBy *uuid.UUID
+	if currentID, _, err := currentUser(c); err == nil {
+		addedBy = &currentID
+	}
+
+	member, err := h.accessService.SetTeamMember(teamID, userID, req.Role, addedBy)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if err.Error() == "team not found" || err.Error() == "user not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "team.member_set", "team", teamID.String(), nil, member)
+
+	return c.JSON(member)
+}
+
+// RemoveMember removes a user from a team
+func (h *TeamHandler) RemoveMember(c *fiber.Ctx) error {
+	teamID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := h.accessService.RemoveTeamMember(teamID, userID); err != nil {
+		if err.Error() == "team member not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove team member",
+		})
+	}
+
+	recordAudit(c, h.auditService, "team.member_remove", "team", teamID.String(), models.JSON{"user_id": userID.String()}, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Team member removed successfully",
+	})
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// TenantHandler manages tenants. The platform operator's administrators create tenants and move
+// users between them; a tenant's own administrators may view and change its settings.
+type TenantHandler struct {
+	tenantService *services.TenantService
+	accessService *services.AccessService
+	auditService  *services.AuditService
+}
+
+func NewTenantHandler() *TenantHandler {
+	return &TenantHandler{
+		tenantService: services.NewTenantService(),
+		accessService: services.NewAccessService(),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// tenantListSpec lists the sort fields the tenant listing accepts
+var tenantListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+}
+
+// GetTenants returns a page of the tenants
+func (h *TenantHandler) GetTenants(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+	params, err := pagination.Parse(c, tenantListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	tenants, total, err := h.tenantService.List(tenantListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve tenants", err)
+	}
+
+	return c.JSON(pagination.Response(tenants, total, params))
+}
+
+// CreateTenant creates a tenant. With encrypt_pii set, the names of its users are stored encrypted
+// with a data key of its own, which needs TENANT_MASTER_KEY.
+func (h *TenantHandler) CreateTenant(c *fiber.Ctx) error {
+	userID, err := platformAdmin(c, h.accessService)
+	if err != nil {
+		return err
+	}
+
+	var req services.TenantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	tenant, err := h.tenantService.Create(req, userID)
+	if err != nil {
+		return serviceError(err, "Failed to create tenant")
+	}
+
+	recordAudit(c, h.auditService, "tenant.create", "tenant", tenant.ID.String(), nil, tenant)
+
+	return c.Status(fiber.StatusCreated).JSON(tenant)
+}
+
+// GetTenant returns a tenant to the platform operator's administrators or the tenant's own
+func (h *TenantHandler) GetTenant(c *fiber.Ctx) error {
+	tenant, err := h.accessibleTenant(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tenant)
+}
+
+// UpdateTenant renames a tenant, replaces its settings and turns PII encryption on or off,
+// re-encrypting or decrypting its users' names
+func (h *TenantHandler) UpdateTenant(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+	tenantID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid tenant ID")
+	}
+
+	var req services.TenantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	before, err := h.tenantService.Get(tenantID)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve tenant")
+	}
+	tenant, err := h.tenantService.Update(tenantID, req)
+	if err != nil {
+		return serviceError(err, "Failed to update tenant")
+	}
+
+	recordAudit(c, h.auditService, "tenant.update", "tenant", tenantID.String(), before, tenant)
+
+	return c.JSON(tenant)
+}
+
+// UpdateTenantSettings replaces the risk thresholds new portfolios of a tenant start with and the
+// AML monitoring settings its transactions are checked with
+func (h *TenantHandler) UpdateTenantSettings(c *fiber.Ctx) error {
+	before, err := h.accessibleTenant(c)
+	if err != nil {
+		return err
+	}
+
+	var settings models.TenantSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+
+	tenant, err := h.tenantService.UpdateSettings(before.ID, settings)
+	if err != nil {
+		return serviceError(err, "Failed to update tenant settings")
+	}
+
+	recordAudit(c, h.auditService, "tenant.settings_update", "tenant", tenant.ID.String(), before.Settings, tenant.Settings)
+
+	return c.JSON(tenant)
+}
+
+// AssignUserTenant moves a user, with the portfolios they own, into a tenant or, with a null
+// tenant_id, out of any
+func (h *TenantHandler) AssignUserTenant(c *fiber.Ctx) error {
+	if _, err := platformAdmin(c, h.accessService); err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid user ID")
+	}
+
+	var req services.TenantAssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+
+	before, err := h.accessService.TenantOf(userID)
+	if err != nil {
+		return apperror.NotFound("User not found")
+	}
+	user, err := h.tenantService.AssignUser(userID, req.TenantID)
+	if err != nil {
+		return serviceError(err, "Failed to assign user to tenant")
+	}
+
+	recordAudit(c, h.auditService, "user.tenant_assign", "user", userID.String(),
+		fiber.Map{"tenant_id": before}, fiber.Map{"tenant_id": user.TenantID})
+
+	return c.JSON(user)
+}
+
+// platformAdmin returns the user making the request if they are outside any tenant, for changes
+// that span tenants
+func platformAdmin(c *fiber.Ctx, accessService *services.AccessService) (uuid.UUID, error) {
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return uuid.Nil, apperror.Unauthorized("Invalid user ID")
+	}
+	tenantID, err := accessService.TenantOf(userID)
+	if err != nil {
+		return uuid.Nil, apperror.Internal("Failed to check access", err)
+	}
+	if tenantID != nil {
+		return uuid.Nil, apperror.Forbidden("Only platform administrators may make this change")
+	}
+	return userID, nil
+}
+
+// accessibleTenant loads the tenant in the id route parameter if the user making the request is
+// outside any tenant or in it, answering not found otherwise
+func (h *TenantHandler) accessibleTenant(c *fiber.Ctx) (*models.Tenant, error) {
+	tenantID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest("Invalid tenant ID")
+	}
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return nil, apperror.Unauthorized("Invalid user ID")
+	}
+
+	own, err := h.accessService.TenantOf(userID)
+	if err != nil {
+		return nil, apperror.Internal("Failed to check access", err)
+	}
+	if own != nil && *own != tenantID {
+		return nil, apperror.NotFound("Tenant not found")
+	}
+
+	tenant, err := h.tenantService.Get(tenantID)
+	if err != nil {
+		return nil, serviceError(err, "Failed to retrieve tenant")
+	}
+	return tenant, nil
+}
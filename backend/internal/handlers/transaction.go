@@ -1,53 +1,248 @@
 package handlers
 
 import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 
-	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/imports"
+	"github.com/Taf0711/financial-risk-monitor/internal/middleware"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
 )
 
 type TransactionHandler struct {
-	// Add transaction service when implemented
+	transactionService *services.TransactionService
+	orderService       *services.OrderService
+	approvalService    *services.TransactionApprovalService
+	amendmentService   *services.TransactionAmendmentService
+	importService      *services.TransactionImportService
+	lotService         *services.LotService
+	auditService       *services.AuditService
 }
 
-func NewTransactionHandler() *TransactionHandler {
-	return &TransactionHandler{}
+func NewTransactionHandler(cfg *config.RiskConfig) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: services.NewTransactionService(cfg),
+		orderService:       services.NewOrderService(),
+		approvalService:    services.NewTransactionApprovalService(),
+		amendmentService:   services.NewTransactionAmendmentService(),
+		importService:      services.NewTransactionImportService(cfg),
+		lotService:         services.NewLotService(),
+		auditService:       services.NewAuditService(),
+	}
 }
 
 type CreateTransactionRequest struct {
 	PortfolioID     string  `json:"portfolio_id" validate:"required"`
 	TransactionType string  `json:"transaction_type" validate:"required"`
 	Symbol          string  `json:"symbol"`
-	Quantity        float64 `json:"quantity"`
-	Price           float64 `json:"price"`
+	Quantity        float64 `json:"quantity" validate:"gte=0"`
+	Price           float64 `json:"price" validate:"gte=0"`
+	Amount          float64 `json:"amount" validate:"gte=0"` // DEPOSIT and WITHDRAWAL; trades are quantity times price
 	Currency        string  `json:"currency"`
 	ExecutedAt      string  `json:"executed_at"`
 	Notes           string  `json:"notes"`
+	AssetType       string  `json:"asset_type"`    // STOCK, BOND, CRYPTO, etc., checked against the investment guideline
+	CreditRating    string  `json:"credit_rating"` // Issue rating of a bond
+
+	CounterpartyID      string `json:"counterparty_id"`
+	CounterpartyName    string `json:"counterparty_name"`
+	CounterpartyCountry string `json:"counterparty_country"`
 }
 
 type UpdateTransactionStatusRequest struct {
 	Status string `json:"status" validate:"required"`
 }
 
-// GetTransactions returns all transactions
+type ApproveTransactionRequest struct {
+	Decision string `json:"decision" validate:"required"` // APPROVED or REJECTED
+	Comment  string `json:"comment"`
+}
+
+type UpdateOrderStatusRequest struct {
+	OrderStatus string `json:"order_status" validate:"required"` // CANCELLED or REJECTED
+	Reason      string `json:"reason"`
+}
+
+// transactionListSpec lists the filters and sort fields GetTransactions accepts
+var transactionListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at":  "transactions.created_at",
+		"executed_at": "transactions.executed_at",
+		"amount":      "transactions.amount",
+		"symbol":      "transactions.symbol",
+		"status":      "transactions.status",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "transactions.created_at",
+	Filters: map[string]string{
+		"portfolio_id":     "transactions.portfolio_id",
+		"status":           "transactions.status",
+		"symbol":           "transactions.symbol",
+		"transaction_type": "transactions.transaction_type",
+	},
+}
+
+// GetTransactions returns a page of the transactions in portfolios the user can access
 func (h *TransactionHandler) GetTransactions(c *fiber.Ctx) error {
-	var transactions []models.Transaction
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
 
-	if err := database.GetDB().Find(&transactions).Error; err != nil {
+	params, err := pagination.Parse(c, transactionListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	transactions, total, err := h.transactionService.ListTransactions(userID, role, transactionListSpec, params)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve transactions",
 		})
 	}
 
-	return c.JSON(transactions)
+	return c.JSON(pagination.Response(transactions, total, params))
+}
+
+// transactionSearchSpec lists the filters and sort fields SearchTransactions accepts. Its date range
+// is on the trade date, or when the transaction was recorded if it has none.
+var transactionSearchSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"trade_date":  "COALESCE(transactions.executed_at, transactions.created_at)",
+		"created_at":  "transactions.created_at",
+		"executed_at": "transactions.executed_at",
+		"amount":      "transactions.amount",
+		"risk_score":  "transactions.risk_score",
+		"symbol":      "transactions.symbol",
+		"status":      "transactions.status",
+	},
+	DefaultSort: "trade_date",
+	DateColumn:  "COALESCE(transactions.executed_at, transactions.created_at)",
+	Filters: map[string]string{
+		"portfolio_id":     "transactions.portfolio_id",
+		"status":           "transactions.status",
+		"symbol":           "transactions.symbol",
+		"transaction_type": "transactions.transaction_type",
+		"order_status":     "transactions.order_status",
+		"approval_status":  "transactions.approval_status",
+		"asset_type":       "transactions.asset_type",
+	},
 }
 
-// CreateTransaction creates a new transaction
+// SearchTransactions returns a page of the transactions in portfolios the user can access matching
+// the filters, amount (min_amount, max_amount) and risk score (min_risk_score, max_risk_score)
+// ranges and the aml_checked, kyc_verified and requires_review flags
+func (h *TransactionHandler) SearchTransactions(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return apperror.Unauthorized("Invalid user ID")
+	}
+
+	params, err := pagination.Parse(c, transactionSearchSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	search, err := parseTransactionSearch(c)
+	if err != nil {
+		return err
+	}
+
+	transactions, total, err := h.transactionService.SearchTransactions(userID, role, transactionSearchSpec, params, search)
+	if err != nil {
+		return apperror.Internal("Failed to search transactions", err)
+	}
+
+	return c.JSON(pagination.Response(transactions, total, params))
+}
+
+// parseTransactionSearch reads the range and flag query parameters of SearchTransactions
+func parseTransactionSearch(c *fiber.Ctx) (services.TransactionSearch, error) {
+	var search services.TransactionSearch
+	var err error
+
+	if search.MinAmount, err = queryDecimal("min_amount", c.Query("min_amount")); err != nil {
+		return search, err
+	}
+	if search.MaxAmount, err = queryDecimal("max_amount", c.Query("max_amount")); err != nil {
+		return search, err
+	}
+	if search.MinAmount != nil && search.MaxAmount != nil && search.MinAmount.GreaterThan(*search.MaxAmount) {
+		return search, apperror.BadRequest("min_amount must not exceed max_amount")
+	}
+
+	if search.MinRiskScore, err = queryRiskScore("min_risk_score", c.Query("min_risk_score")); err != nil {
+		return search, err
+	}
+	if search.MaxRiskScore, err = queryRiskScore("max_risk_score", c.Query("max_risk_score")); err != nil {
+		return search, err
+	}
+	if search.MinRiskScore != nil && search.MaxRiskScore != nil && *search.MinRiskScore > *search.MaxRiskScore {
+		return search, apperror.BadRequest("min_risk_score must not exceed max_risk_score")
+	}
+
+	if search.AMLChecked, err = queryBool("aml_checked", c.Query("aml_checked")); err != nil {
+		return search, err
+	}
+	if search.KYCVerified, err = queryBool("kyc_verified", c.Query("kyc_verified")); err != nil {
+		return search, err
+	}
+	if search.RequiresReview, err = queryBool("requires_review", c.Query("requires_review")); err != nil {
+		return search, err
+	}
+	return search, nil
+}
+
+func queryDecimal(name, value string) (*decimal.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, apperror.BadRequest("invalid " + name + ", expected a number")
+	}
+	return &d, nil
+}
+
+func queryRiskScore(name, value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	score, err := strconv.Atoi(value)
+	if err != nil || score < 0 || score > 100 {
+		return nil, apperror.BadRequest("invalid " + name + ", expected an integer from 0 to 100")
+	}
+	return &score, nil
+}
+
+func queryBool(name, value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, apperror.BadRequest("invalid " + name + ", expected true or false")
+	}
+	return &b, nil
+}
+
+// CreateTransaction creates a new transaction in a portfolio the user owns
 func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 	var req CreateTransactionRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -55,6 +250,9 @@ func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
 
 	portfolioID, err := uuid.Parse(req.PortfolioID)
 	if err != nil {
@@ -63,16 +261,32 @@ func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 		})
 	}
 
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	// A trade's amount is set from its quantity and price when it is saved
 	transaction := models.Transaction{
 		PortfolioID:     portfolioID,
 		TransactionType: req.TransactionType,
 		Symbol:          req.Symbol,
 		Quantity:        decimal.NewFromFloat(req.Quantity),
 		Price:           decimal.NewFromFloat(req.Price),
-		Amount:          decimal.NewFromFloat(req.Quantity * req.Price),
 		Currency:        req.Currency,
 		Status:          "PENDING",
 		Notes:           req.Notes,
+		AssetType:       strings.ToUpper(req.AssetType),
+		CreditRating:    strings.ToUpper(req.CreditRating),
+
+		CounterpartyName:    req.CounterpartyName,
+		CounterpartyCountry: req.CounterpartyCountry,
+	}
+
+	if isCashTransaction(req.TransactionType) {
+		transaction.Amount = decimal.NewFromFloat(req.Amount)
 	}
 
 	if req.ExecutedAt != "" {
@@ -81,66 +295,226 @@ func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 		}
 	}
 
-	if req.Currency == "" {
-		transaction.Currency = "USD"
+	if req.CounterpartyID != "" {
+		counterpartyID, err := uuid.Parse(req.CounterpartyID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid counterparty ID",
+			})
+		}
+		transaction.CounterpartyID = &counterpartyID
 	}
 
-	if err := database.GetDB().Create(&transaction).Error; err != nil {
+	if err := services.ValidateTransaction(&transaction); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.transactionService.CreateTransaction(c.UserContext(), userID, role, &transaction); err != nil {
+		var blocked *services.CounterpartyBlockedError
+		var restricted *services.RestrictedSymbolError
+		var kycBlocked *services.KYCBlockedError
+		var insufficient *services.InsufficientCashError
+		var riskRejected *services.RiskRejectedError
+		switch {
+		case errors.As(err, &riskRejected):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":      "Order rejected by pre-trade risk checks",
+				"risk_score": riskRejected.RiskScore,
+				"violations": riskRejected.Violations,
+			})
+		case errors.As(err, &blocked):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Counterparty may not be traded with",
+				"flags": blocked.Flags,
+			})
+		case errors.As(err, &restricted):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":  "Symbol is on the restricted list",
+				"symbol": restricted.Symbol,
+				"reason": restricted.Reason,
+			})
+		case errors.As(err, &kycBlocked):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":        "KYC profile is not current",
+				"subject_type": kycBlocked.SubjectType,
+				"subject_id":   kycBlocked.SubjectID,
+				"kyc_status":   kycBlocked.Status,
+			})
+		case errors.As(err, &insufficient):
+			return insufficientCashResponse(c, insufficient)
+		case err.Error() == "portfolio not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		case err.Error() == "counterparty not found":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Counterparty not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create transaction",
 		})
 	}
 
+	recordAudit(c, h.auditService, "transaction.create", "transaction", transaction.ID.String(), nil, transaction)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message":     "Transaction created successfully",
 		"transaction": transaction,
 	})
 }
 
-// GetTransaction returns a specific transaction
-func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
-	id := c.Params("id")
-	transactionID, err := uuid.Parse(id)
+// ImportTransactions loads trades from an uploaded CSV or FIX 4.4 drop-copy file. The format comes
+// from the "format" field or the file extension, and "portfolio_id" applies to rows without one.
+// Repeating a request with the same Idempotency-Key header returns the first import's result.
+func (h *TransactionHandler) ImportTransactions(c *fiber.Ctx) error {
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid transaction ID",
+			"error": "Import file is required",
 		})
 	}
 
-	var transaction models.Transaction
-	if err := database.GetDB().First(&transaction, transactionID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Transaction not found",
+	req := services.ImportRequest{
+		UserID:         userID,
+		Role:           role,
+		Format:         strings.ToLower(c.FormValue("format")),
+		FileName:       fileHeader.Filename,
+		IdempotencyKey: strings.TrimSpace(c.Get(middleware.IdempotencyKeyHeader)),
+	}
+	if req.Format == "" {
+		req.Format = importFormatFromName(fileHeader.Filename)
+	}
+	if len(req.IdempotencyKey) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Idempotency-Key must be at most 255 characters",
 		})
 	}
+	if value := c.FormValue("portfolio_id"); value != "" {
+		portfolioID, err := uuid.Parse(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid portfolio ID",
+			})
+		}
+		req.DefaultPortfolioID = &portfolioID
+	}
 
-	return c.JSON(transaction)
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read import file",
+		})
+	}
+	defer file.Close()
+
+	record, replayed, err := h.importService.ImportTransactions(c.UserContext(), file, req)
+	if err != nil {
+		if err.Error() == "unsupported import format, use csv or fix" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import transactions",
+		})
+	}
+
+	if replayed {
+		c.Set(middleware.IdempotentReplayedHeader, "true")
+		return c.JSON(record)
+	}
+
+	recordAudit(c, h.auditService, "transaction.import", "transaction_import", record.ID.String(), nil, fiber.Map{
+		"file_name":  record.FileName,
+		"format":     record.Format,
+		"status":     record.Status,
+		"total_rows": record.TotalRows,
+		"imported":   record.Imported,
+		"duplicates": record.Duplicates,
+		"failed":     record.Failed,
+	})
+
+	if record.Status == models.ImportStatusFailed {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(record)
+	}
+	return c.Status(fiber.StatusCreated).JSON(record)
 }
 
-// UpdateTransaction updates a transaction
-func (h *TransactionHandler) UpdateTransaction(c *fiber.Ctx) error {
-	id := c.Params("id")
-	transactionID, err := uuid.Parse(id)
+// GetImport returns the result of one of the user's imports
+func (h *TransactionHandler) GetImport(c *fiber.Ctx) error {
+	importID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid transaction ID",
+			"error": "Invalid import ID",
+		})
+	}
+
+	userID, _, err := currentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	record, err := h.importService.GetImport(importID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Import not found",
 		})
 	}
 
+	return c.JSON(record)
+}
+
+// importFormatFromName picks the import format from a file extension, defaulting to CSV
+func importFormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".fix", ".log", ".txt":
+		return imports.FormatFIX
+	default:
+		return imports.FormatCSV
+	}
+}
+
+// GetTransaction returns a specific transaction
+func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	return c.JSON(transaction)
+}
+
+// UpdateTransaction updates a transaction
+func (h *TransactionHandler) UpdateTransaction(c *fiber.Ctx) error {
 	var req CreateTransactionRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Partial(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
 
-	var transaction models.Transaction
-	if err := database.GetDB().First(&transaction, transactionID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Transaction not found",
-		})
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
 	}
 
+	before := services.Snapshot(transaction)
+
 	// Update fields
 	if req.Symbol != "" {
 		transaction.Symbol = req.Symbol
@@ -150,18 +524,46 @@ func (h *TransactionHandler) UpdateTransaction(c *fiber.Ctx) error {
 	}
 	if req.Price != 0 {
 		transaction.Price = decimal.NewFromFloat(req.Price)
-		transaction.Amount = transaction.Quantity.Mul(transaction.Price)
+	}
+	if isCashTransaction(transaction.TransactionType) && req.Amount != 0 {
+		transaction.Amount = decimal.NewFromFloat(req.Amount)
 	}
 	if req.Notes != "" {
 		transaction.Notes = req.Notes
 	}
 
-	if err := database.GetDB().Save(&transaction).Error; err != nil {
+	userID, role, _ := currentUser(c)
+	if err := h.transactionService.UpdateTransaction(userID, role, transaction); err != nil {
+		var insufficient *services.InsufficientCashError
+		if errors.As(err, &insufficient) {
+			return insufficientCashResponse(c, insufficient)
+		}
+		var held *services.ApprovalPendingError
+		if errors.As(err, &held) {
+			return approvalPendingResponse(c, held)
+		}
+		if errors.Is(err, services.ErrTransactionCompleted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		switch err.Error() {
+		case "transaction not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		case "only NEW orders can change quantity or price", "an approved transaction's quantity, price and amount cannot change":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update transaction",
 		})
 	}
 
+	recordAudit(c, h.auditService, "transaction.update", "transaction", transaction.ID.String(), before, transaction)
+
 	return c.JSON(fiber.Map{
 		"message":     "Transaction updated successfully",
 		"transaction": transaction,
@@ -170,70 +572,387 @@ func (h *TransactionHandler) UpdateTransaction(c *fiber.Ctx) error {
 
 // DeleteTransaction deletes a transaction
 func (h *TransactionHandler) DeleteTransaction(c *fiber.Ctx) error {
-	id := c.Params("id")
-	transactionID, err := uuid.Parse(id)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid transaction ID",
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, role, _ := currentUser(c)
+	if err := h.transactionService.DeleteTransaction(userID, role, transaction); err != nil {
+		var insufficient *services.InsufficientCashError
+		if errors.As(err, &insufficient) {
+			return insufficientCashResponse(c, insufficient)
+		}
+		if err.Error() == "transaction not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		if errors.Is(err, services.ErrTransactionCompleted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		// A purchase whose lots have been sold
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete transaction",
 		})
 	}
 
-	var transaction models.Transaction
-	if err := database.GetDB().First(&transaction, transactionID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Transaction not found",
+	recordAudit(c, h.auditService, "transaction.delete", "transaction", transaction.ID.String(), transaction, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Transaction deleted successfully",
+	})
+}
+
+// RestoreTransaction undeletes a soft deleted transaction, settling its cash again
+func (h *TransactionHandler) RestoreTransaction(c *fiber.Ctx) error {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
 		})
 	}
 
-	if err := database.GetDB().Delete(&transaction).Error; err != nil {
+	userID, _, _ := currentUser(c)
+	transaction, err := h.transactionService.RestoreTransaction(transactionID, userID)
+	if err != nil {
+		var insufficient *services.InsufficientCashError
+		if errors.As(err, &insufficient) {
+			return insufficientCashResponse(c, insufficient)
+		}
+		switch err.Error() {
+		case "deleted transaction not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Deleted transaction not found",
+			})
+		case "portfolio is deleted; restore the portfolio first":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete transaction",
+			"error": "Failed to restore transaction",
 		})
 	}
 
+	recordAudit(c, h.auditService, "transaction.restore", "transaction", transaction.ID.String(), nil, transaction)
+
 	return c.JSON(fiber.Map{
-		"message": "Transaction deleted successfully",
+		"message":     "Transaction restored successfully",
+		"transaction": transaction,
 	})
 }
 
 // UpdateTransactionStatus updates the status of a transaction
 func (h *TransactionHandler) UpdateTransactionStatus(c *fiber.Ctx) error {
-	id := c.Params("id")
-	transactionID, err := uuid.Parse(id)
-	if err != nil {
+	var req UpdateTransactionStatusRequest
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid transaction ID",
+			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
 
-	var req UpdateTransactionStatusRequest
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, _, _ := currentUser(c)
+	before := services.Snapshot(transaction)
+
+	if err := h.transactionService.UpdateStatus(c.UserContext(), transaction, req.Status, userID); err != nil {
+		var insufficient *services.InsufficientCashError
+		var transition *services.InvalidTransitionError
+		var held *services.ApprovalPendingError
+		switch {
+		case errors.As(err, &insufficient):
+			return insufficientCashResponse(c, insufficient)
+		case errors.As(err, &transition):
+			return invalidTransitionResponse(c, transition)
+		case errors.As(err, &held):
+			return approvalPendingResponse(c, held)
+		case errors.Is(err, services.ErrTransactionCompleted):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case strings.HasPrefix(err.Error(), "invalid status"):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update transaction status",
+		})
+	}
+
+	recordAudit(c, h.auditService, "transaction.status_update", "transaction", transaction.ID.String(), before, transaction)
+
+	return c.JSON(fiber.Map{
+		"message":     "Transaction status updated successfully",
+		"transaction": transaction,
+	})
+}
+
+// ApproveTransaction records the four-eyes decision on a transaction held for approval. The
+// reviewer must be someone other than the user who created the transaction.
+func (h *TransactionHandler) ApproveTransaction(c *fiber.Ctx) error {
+	var req ApproveTransactionRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
 
-	var transaction models.Transaction
-	if err := database.GetDB().First(&transaction, transactionID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Transaction not found",
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, _, _ := currentUser(c)
+	before := services.Snapshot(transaction)
+
+	decision := strings.ToUpper(req.Decision)
+	if err := h.approvalService.Decide(c.UserContext(), transaction, decision, req.Comment, userID); err != nil {
+		var selfApproval *services.SelfApprovalError
+		switch {
+		case errors.As(err, &selfApproval):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case err.Error() == "transaction is not awaiting approval":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":  err.Error(),
+				"status": transaction.Status,
+			})
+		case err.Error() == "transaction not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		case err.Error() == "decision must be APPROVED or REJECTED":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record approval decision",
 		})
 	}
 
-	transaction.Status = req.Status
-	if req.Status == "COMPLETED" {
-		now := time.Now()
-		transaction.ExecutedAt = &now
+	action := "transaction.approve"
+	if decision == models.ApprovalRejected {
+		action = "transaction.reject"
+	}
+	recordAudit(c, h.auditService, action, "transaction", transaction.ID.String(), before, transaction)
+
+	return c.JSON(fiber.Map{
+		"message":     "Approval decision recorded successfully",
+		"transaction": transaction,
+	})
+}
+
+// GetFills returns the executions recorded against an order
+func (h *TransactionHandler) GetFills(c *fiber.Ctx) error {
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
 	}
 
-	if err := database.GetDB().Save(&transaction).Error; err != nil {
+	fills, err := h.orderService.ListFills(transaction.ID)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update transaction status",
+			"error": "Failed to retrieve fills",
+		})
+	}
+
+	return c.JSON(fills)
+}
+
+// GetTransactionLots returns the tax lots a BUY opened, with how much of each is still held, and
+// the lots a SELL closed with the gain realized on each
+func (h *TransactionHandler) GetTransactionLots(c *fiber.Ctx) error {
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	lots, closures, err := h.lotService.TransactionLots(transaction.ID)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve lots", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"transaction_id": transaction.ID,
+		"realized_pnl":   transaction.RealizedPnL,
+		"lots":           lots,
+		"closed_lots":    closures,
+	})
+}
+
+// RecordFill records an execution against an order, moving it to PARTIALLY_FILLED or FILLED
+func (h *TransactionHandler) RecordFill(c *fiber.Ctx) error {
+	var req services.FillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, _, _ := currentUser(c)
+	before := services.Snapshot(transaction)
+
+	fill, err := h.orderService.RecordFill(c.UserContext(), transaction, req, userID)
+	if err != nil {
+		var insufficient *services.InsufficientCashError
+		var transition *services.InvalidTransitionError
+		var held *services.ApprovalPendingError
+		switch {
+		case errors.As(err, &insufficient):
+			return insufficientCashResponse(c, insufficient)
+		case errors.As(err, &transition):
+			return invalidTransitionResponse(c, transition)
+		case errors.As(err, &held):
+			return approvalPendingResponse(c, held)
+		case err.Error() == "transaction not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	recordAudit(c, h.auditService, "transaction.fill", "transaction", transaction.ID.String(), before, transaction)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     "Fill recorded successfully",
+		"fill":        fill,
+		"transaction": transaction,
+	})
+}
+
+// UpdateOrderStatus cancels or rejects an order
+func (h *TransactionHandler) UpdateOrderStatus(c *fiber.Ctx) error {
+	var req UpdateOrderStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, _, _ := currentUser(c)
+	before := services.Snapshot(transaction)
+
+	err = h.orderService.Transition(c.UserContext(), transaction, strings.ToUpper(req.OrderStatus), req.Reason, userID)
+	if err != nil {
+		var transition *services.InvalidTransitionError
+		var held *services.ApprovalPendingError
+		switch {
+		case errors.As(err, &transition):
+			return invalidTransitionResponse(c, transition)
+		case errors.As(err, &held):
+			return approvalPendingResponse(c, held)
+		case err.Error() == "transaction not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
+	recordAudit(c, h.auditService, "transaction.order_status_update", "transaction", transaction.ID.String(), before, transaction)
+
 	return c.JSON(fiber.Map{
-		"message":     "Transaction status updated successfully",
+		"message":     "Order status updated successfully",
 		"transaction": transaction,
 	})
 }
+
+// loadTransaction fetches the :id transaction through the caller's portfolio access. When it returns
+// a nil transaction the error response has already been written and err is the result of writing it.
+func (h *TransactionHandler) loadTransaction(c *fiber.Ctx) (*models.Transaction, error) {
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	userID, role, err := currentUser(c)
+	if err != nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	transaction, err := h.transactionService.GetTransaction(transactionID, userID, role)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Transaction not found",
+		})
+	}
+
+	return transaction, nil
+}
+
+// isCashTransaction reports whether a transaction type moves cash without a trade
+func isCashTransaction(transactionType string) bool {
+	return transactionType == "DEPOSIT" || transactionType == "WITHDRAWAL"
+}
+
+// insufficientCashResponse rejects a transaction the portfolio's available cash does not cover
+func insufficientCashResponse(c *fiber.Ctx, err *services.InsufficientCashError) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":     "Insufficient cash",
+		"available": err.Available,
+		"required":  err.Required,
+		"currency":  err.Currency,
+	})
+}
+
+// approvalPendingResponse rejects changes to a transaction held for four-eyes approval
+func approvalPendingResponse(c *fiber.Ctx, err *services.ApprovalPendingError) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":          err.Error(),
+		"transaction_id": err.TransactionID,
+	})
+}
+
+// invalidTransitionResponse rejects an order state change the order's lifecycle does not allow
+func invalidTransitionResponse(c *fiber.Ctx, err *services.InvalidTransitionError) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error": err.Error(),
+		"from":  err.From,
+		"to":    err.To,
+	})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// amendmentListSpec lists the filters and sort fields GetAmendments accepts
+var amendmentListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at":  "created_at",
+		"reviewed_at": "reviewed_at",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"portfolio_id":   "portfolio_id",
+		"transaction_id": "transaction_id",
+		"action":         "action",
+		"status":         "status",
+	},
+}
+
+// GetAmendments returns a page of the amendments of transactions in portfolios the user can
+// access; approvers list those awaiting review with ?status=PENDING
+func (h *TransactionHandler) GetAmendments(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, amendmentListSpec)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+
+	userID, role, _ := currentUser(c)
+	amendments, total, err := h.amendmentService.List(userID, role, amendmentListSpec, params)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve amendments", err)
+	}
+
+	return c.JSON(pagination.Response(amendments, total, params))
+}
+
+// GetAmendment returns an amendment with its old and new values
+func (h *TransactionHandler) GetAmendment(c *fiber.Ctx) error {
+	amendmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid amendment ID")
+	}
+
+	userID, role, _ := currentUser(c)
+	amendment, err := h.amendmentService.Get(amendmentID, userID, role)
+	if err != nil {
+		return serviceError(err, "Failed to retrieve amendment")
+	}
+
+	return c.JSON(amendment)
+}
+
+// GetTransactionAmendments returns the amendment trail of a transaction: the amendments requested
+// of it and, for a replacement, the one that booked it
+func (h *TransactionHandler) GetTransactionAmendments(c *fiber.Ctx) error {
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	amendments, err := h.amendmentService.ListForTransaction(transaction.ID)
+	if err != nil {
+		return apperror.Internal("Failed to retrieve amendments", err)
+	}
+
+	return c.JSON(amendments)
+}
+
+// RequestAmendment asks for a completed transaction to be corrected or cancelled. The request
+// waits for another user's approval; the transaction is unchanged until then.
+func (h *TransactionHandler) RequestAmendment(c *fiber.Ctx) error {
+	var req services.AmendmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	req.Action = strings.ToUpper(req.Action)
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	transaction, err := h.loadTransaction(c)
+	if transaction == nil {
+		return err
+	}
+
+	userID, role, _ := currentUser(c)
+	amendment, err := h.amendmentService.Request(c.UserContext(), transaction, req, userID, role)
+	if err != nil {
+		return serviceError(err, "Failed to request amendment")
+	}
+
+	recordAudit(c, h.auditService, "transaction_amendment.request", "transaction_amendment", amendment.ID.String(), nil, amendment)
+
+	return c.Status(fiber.StatusCreated).JSON(amendment)
+}
+
+// DecideAmendment approves or rejects a pending amendment. The reviewer must be someone other
+// than the user who requested it; approval supersedes the transaction at once.
+func (h *TransactionHandler) DecideAmendment(c *fiber.Ctx) error {
+	amendmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest("Invalid amendment ID")
+	}
+
+	var req services.AmendmentDecision
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.BadRequest("Invalid request body")
+	}
+	req.Decision = strings.ToUpper(req.Decision)
+	if err := validation.Struct(req); err != nil {
+		return validationErrorResponse(c, err)
+	}
+
+	userID, role, _ := currentUser(c)
+	amendment, err := h.amendmentService.Decide(c.UserContext(), amendmentID, req, userID, role)
+	if err != nil {
+		var insufficient *services.InsufficientCashError
+		if errors.As(err, &insufficient) {
+			return insufficientCashResponse(c, insufficient)
+		}
+		return serviceError(err, "Failed to record amendment decision")
+	}
+
+	action := "transaction_amendment.approve"
+	if amendment.Status == models.ApprovalRejected {
+		action = "transaction_amendment.reject"
+	}
+	recordAudit(c, h.auditService, action, "transaction_amendment", amendment.ID.String(), nil, amendment)
+
+	return c.JSON(amendment)
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+type UserHandler struct {
+	userService   *services.UserService
+	accessService *services.AccessService
+	auditService  *services.AuditService
+}
+
+func NewUserHandler(authService *services.AuthService) *UserHandler {
+	return &UserHandler{
+		userService:   services.NewUserService(authService),
+		accessService: services.NewAccessService(),
+		auditService:  services.NewAuditService(),
+	}
+}
+
+// userListSpec lists the filters and sort fields GetUsers accepts
+var userListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"created_at": "created_at",
+		"email":      "email",
+		"last_name":  "last_name",
+	},
+	DefaultSort: "created_at",
+	DateColumn:  "created_at",
+	Filters: map[string]string{
+		"role":      "role",
+		"is_active": "is_active",
+		"tenant_id": "tenant_id",
+	},
+}
+
+// GetUsers lists users, filtered by role, active status, registration date and ?search= on the
+// email or name. Administrators in a tenant see only its users.
+func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
+	params, err := pagination.Parse(c, userListSpec)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	tenantID, err := h.actorTenant(c)
+	if err != nil {
+		return userError(c, err, "Failed to retrieve users")
+	}
+
+	users, total, err := h.userService.ListUsers(userListSpec, params, c.Query("search"), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve users",
+		})
+	}
+
+	return c.JSON(pagination.Response(users, total, params))
+}
+
+// GetUser returns a single user
+func (h *UserHandler) GetUser(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := h.scopedUser(c, userID)
+	if err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+
+	return c.JSON(user)
+}
+
+// UpdateRole changes a user's role
+func (h *UserHandler) UpdateRole(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before, err := h.scopedUser(c, userID)
+	if err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+
+	user, err := h.userService.UpdateRole(userID, req.Role)
+	if err != nil {
+		return userError(c, err, "Failed to update user role")
+	}
+
+	recordAudit(c, h.auditService, "user.role_update", "user", userID.String(), before, user)
+
+	return c.JSON(fiber.Map{
+		"message": "User role updated successfully",
+		"data":    user,
+	})
+}
+
+// Deactivate disables a user account and signs the user out
+func (h *UserHandler) Deactivate(c *fiber.Ctx) error {
+	return h.setActive(c, false)
+}
+
+// Reactivate re-enables a user account
+func (h *UserHandler) Reactivate(c *fiber.Ctx) error {
+	return h.setActive(c, true)
+}
+
+func (h *UserHandler) setActive(c *fiber.Ctx, active bool) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	before, err := h.scopedUser(c, userID)
+	if err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+
+	user, err := h.userService.SetActive(userID, active)
+	if err != nil {
+		return userError(c, err, "Failed to update user status")
+	}
+
+	action := "user.deactivate"
+	if active {
+		action = "user.reactivate"
+	}
+	recordAudit(c, h.auditService, action, "user", userID.String(), before, user)
+
+	return c.JSON(fiber.Map{
+		"message": "User status updated successfully",
+		"data":    user,
+	})
+}
+
+// ForcePasswordReset sets a temporary password the user must change before logging in again. The
+// temporary password is returned once and never stored in plain text or audited.
+func (h *UserHandler) ForcePasswordReset(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if _, err := h.scopedUser(c, userID); err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+
+	user, temporary, err := h.userService.ForcePasswordReset(userID)
+	if err != nil {
+		return userError(c, err, "Failed to reset password")
+	}
+
+	recordAudit(c, h.auditService, "user.password_reset", "user", userID.String(), nil, user)
+
+	return c.JSON(fiber.Map{
+		"message":            "Password reset; the user must change the temporary password at next login",
+		"temporary_password": temporary,
+		"data":               user,
+	})
+}
+
+// ExportPersonalData returns, as a JSON download, the personal data held about a user, deleted
+// accounts included, for a data subject access request
+func (h *UserHandler) ExportPersonalData(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	export, err := h.userService.PersonalData(userID)
+	if err != nil {
+		return userError(c, err, "Failed to export personal data")
+	}
+	if err := h.checkTenant(c, &export.User); err != nil {
+		return userError(c, err, "Failed to export personal data")
+	}
+
+	recordAudit(c, h.auditService, "user.personal_data_export", "user", userID.String(), nil, nil)
+
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="personal-data-`+userID.String()+`.json"`)
+	return c.JSON(export)
+}
+
+// Anonymize replaces the name and email of a deactivated or deleted account with placeholders and
+// removes its sessions, KYC profile and API keys. It cannot be undone.
+func (h *UserHandler) Anonymize(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	before, err := h.userService.GetUserIncludingDeleted(userID)
+	if err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+	if err := h.checkTenant(c, before); err != nil {
+		return userError(c, err, "Failed to retrieve user")
+	}
+
+	user, err := h.userService.Anonymize(userID)
+	if err != nil {
+		return userError(c, err, "Failed to anonymize user")
+	}
+
+	// The personal data replaced is not copied into the audit log
+	recordAudit(c, h.auditService, "user.anonymize", "user", userID.String(), nil, user)
+
+	return c.JSON(fiber.Map{
+		"message": "User anonymized successfully",
+		"data":    user,
+	})
+}
+
+// actorTenant returns the tenant of the administrator making the request, or nil for the platform
+// operator's
+func (h *UserHandler) actorTenant(c *fiber.Ctx) (*uuid.UUID, error) {
+	actorID, _, err := currentUser(c)
+	if err != nil {
+		return nil, err
+	}
+	return h.accessService.TenantOf(actorID)
+}
+
+// scopedUser loads a user the administrator making the request may manage, answering not found
+// for users of another tenant
+func (h *UserHandler) scopedUser(c *fiber.Ctx, userID uuid.UUID) (*models.User, error) {
+	user, err := h.userService.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.checkTenant(c, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkTenant answers not found for a user outside the tenant of the administrator making the
+// request, if they are in one
+func (h *UserHandler) checkTenant(c *fiber.Ctx, user *models.User) error {
+	tenantID, err := h.actorTenant(c)
+	if err != nil {
+		return err
+	}
+	if tenantID != nil && (user.TenantID == nil || *user.TenantID != *tenantID) {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// userError maps user administration errors onto responses
+func userError(c *fiber.Ctx, err error, message string) error {
+	switch err.Error() {
+	case "user not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case "cannot remove the last active administrator", "role must be admin, analyst, trader or compliance_officer",
+		"anonymized users cannot be reactivated", "only deactivated or deleted users can be anonymized":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/validation"
+)
+
+// validationErrorResponse reports a request that failed its validate tags with a 422 listing every
+// failing field in the error details
+func validationErrorResponse(c *fiber.Ctx, err error) error {
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		return apperror.Validation("Validation failed", fields)
+	}
+	return apperror.BadRequest("Invalid request body")
+}
+
+// serviceError reports a failed service call: typed errors such as a missing record keep their
+// status, anything else is an internal error with message
+func serviceError(err error, message string) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Internal(message, err)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// expectedVersion returns the version of a record an update was made against: the If-Match header,
+// which is the ETag the record was read with, or else the version field of the body. An update
+// naming neither is refused, so that it cannot overwrite a change it has not seen; If-Match: *
+// applies it whatever the current version.
+func expectedVersion(c *fiber.Ctx, body *int64) (int64, error) {
+	if match := strings.TrimSpace(c.Get(fiber.HeaderIfMatch)); match != "" {
+		if match == "*" {
+			return models.AnyVersion, nil
+		}
+		version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(match, "W/"), `"`), 10, 64)
+		if err != nil || version < 1 {
+			return 0, apperror.BadRequest("If-Match must be the ETag the record was read with")
+		}
+		return version, nil
+	}
+	if body == nil {
+		return 0, apperror.PreconditionRequired("Send the version the update was made against in If-Match or the version field")
+	}
+	if *body < 1 {
+		return 0, apperror.BadRequest("version must be a version the record was read with")
+	}
+	return *body, nil
+}
+
+// setETag sends a record's version as its ETag, for the If-Match of a later update
+func setETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.FormatInt(version, 10)))
+}
@@ -0,0 +1,118 @@
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvColumns are the recognised header names
+var csvColumns = map[string]bool{
+	"external_id":          true,
+	"portfolio_id":         true,
+	"transaction_type":     true,
+	"symbol":               true,
+	"quantity":             true,
+	"price":                true,
+	"currency":             true,
+	"status":               true,
+	"executed_at":          true,
+	"notes":                true,
+	"counterparty_id":      true,
+	"counterparty_name":    true,
+	"counterparty_country": true,
+}
+
+// requiredCSVColumns must be present; symbol may be blank for cash movements
+var requiredCSVColumns = []string{"transaction_type", "quantity", "price"}
+
+// ParseCSV reads trades from a CSV file with a header row, one row at a time
+func ParseCSV(r io.Reader, handle RowHandler) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !csvColumns[name] {
+			return fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing required column %q", name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			if err := handle(Trade{Line: parseErr.Line}, parseErr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		trade, rowErr := csvTrade(line, get)
+		if err := handle(trade, rowErr); err != nil {
+			return err
+		}
+	}
+}
+
+func csvTrade(line int, get func(string) string) (Trade, error) {
+	trade := Trade{
+		Line:                line,
+		ExternalID:          get("external_id"),
+		PortfolioID:         get("portfolio_id"),
+		TransactionType:     strings.ToUpper(get("transaction_type")),
+		Symbol:              strings.ToUpper(get("symbol")),
+		Currency:            strings.ToUpper(get("currency")),
+		Status:              strings.ToUpper(get("status")),
+		Notes:               get("notes"),
+		CounterpartyID:      get("counterparty_id"),
+		CounterpartyName:    get("counterparty_name"),
+		CounterpartyCountry: get("counterparty_country"),
+	}
+
+	var err error
+	if trade.Quantity, err = parseDecimal("quantity", get("quantity")); err != nil {
+		return trade, err
+	}
+	if trade.Price, err = parseDecimal("price", get("price")); err != nil {
+		return trade, err
+	}
+	if trade.ExecutedAt, err = parseTime(get("executed_at")); err != nil {
+		return trade, err
+	}
+	return trade, nil
+}
@@ -0,0 +1,189 @@
+package imports
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FIX 4.4 tags read from execution reports
+const (
+	fixTagAccount      = "1"
+	fixTagCurrency     = "15"
+	fixTagExecID       = "17"
+	fixTagLastPx       = "31"
+	fixTagLastQty      = "32"
+	fixTagMsgType      = "35"
+	fixTagOrdStatus    = "39"
+	fixTagSide         = "54"
+	fixTagSymbol       = "55"
+	fixTagText         = "58"
+	fixTagTransactTime = "60"
+	fixTagExecType     = "150"
+	fixTagPartyID      = "448"
+	fixTagPartyRole    = "452"
+)
+
+const (
+	fixMsgTypeExecutionReport = "8"
+	fixExecTypeTrade          = "F"
+	fixPartyRoleContraFirm    = "17"
+	maxFIXLineSize            = 1 << 20
+)
+
+var fixSides = map[string]string{
+	"1": "BUY",
+	"2": "SELL",
+	"5": "SELL", // Sell short
+	"6": "SELL", // Sell short exempt
+}
+
+var fixTimeLayouts = []string{
+	"20060102-15:04:05.000",
+	"20060102-15:04:05",
+}
+
+// ParseFIX reads fills from a FIX 4.4 drop-copy log with one message per line. Fields may be
+// separated by SOH or '|', and anything before "8=FIX" on a line (such as a log timestamp) is
+// ignored. Only execution reports for trades are imported; other messages are skipped.
+func ParseFIX(r io.Reader, handle RowHandler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxFIXLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		start := strings.Index(text, "8=FIX")
+		if start < 0 {
+			continue
+		}
+
+		fields := splitFIX(text[start:])
+		if fields.get(fixTagMsgType) != fixMsgTypeExecutionReport {
+			continue
+		}
+
+		trade, ok, rowErr := fixTrade(line, fields)
+		if !ok {
+			continue
+		}
+		if err := handle(trade, rowErr); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("line %d is longer than %d bytes", line+1, maxFIXLineSize)
+		}
+		return err
+	}
+	return nil
+}
+
+type fixField struct {
+	tag   string
+	value string
+}
+
+type fixMessage []fixField
+
+// get returns the first value of a tag
+func (m fixMessage) get(tag string) string {
+	for _, field := range m {
+		if field.tag == tag {
+			return field.value
+		}
+	}
+	return ""
+}
+
+// contraFirm returns the PartyID of the contra firm in the parties group
+func (m fixMessage) contraFirm() string {
+	var partyID string
+	for _, field := range m {
+		switch field.tag {
+		case fixTagPartyID:
+			partyID = field.value
+		case fixTagPartyRole:
+			if field.value == fixPartyRoleContraFirm {
+				return partyID
+			}
+		}
+	}
+	return ""
+}
+
+func splitFIX(text string) fixMessage {
+	separator := "\x01"
+	if !strings.Contains(text, separator) {
+		separator = "|"
+	}
+
+	var message fixMessage
+	for _, pair := range strings.Split(text, separator) {
+		tag, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		message = append(message, fixField{tag: strings.TrimSpace(tag), value: value})
+	}
+	return message
+}
+
+// fixTrade converts an execution report into a trade; ok is false for reports that are not fills
+func fixTrade(line int, m fixMessage) (trade Trade, ok bool, rowErr error) {
+	trade = Trade{
+		Line:             line,
+		ExternalID:       m.get(fixTagExecID),
+		PortfolioID:      m.get(fixTagAccount),
+		Symbol:           strings.ToUpper(m.get(fixTagSymbol)),
+		Currency:         strings.ToUpper(m.get(fixTagCurrency)),
+		Notes:            m.get(fixTagText),
+		CounterpartyName: m.contraFirm(),
+	}
+
+	switch m.get(fixTagExecType) {
+	case fixExecTypeTrade:
+	case "":
+		// Pre-4.3 style reports carry the fill in OrdStatus (1 = partially filled, 2 = filled)
+		if status := m.get(fixTagOrdStatus); status != "1" && status != "2" {
+			return trade, false, nil
+		}
+	case "G", "H":
+		return trade, true, errors.New("trade corrections and cancels must be applied manually")
+	default:
+		return trade, false, nil
+	}
+
+	side, known := fixSides[m.get(fixTagSide)]
+	if !known {
+		return trade, true, fmt.Errorf("unsupported side %q", m.get(fixTagSide))
+	}
+	trade.TransactionType = side
+
+	var err error
+	if trade.Quantity, err = parseDecimal("LastQty(32)", m.get(fixTagLastQty)); err != nil {
+		return trade, true, err
+	}
+	if trade.Price, err = parseDecimal("LastPx(31)", m.get(fixTagLastPx)); err != nil {
+		return trade, true, err
+	}
+
+	if value := m.get(fixTagTransactTime); value != "" {
+		for _, layout := range fixTimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				trade.ExecutedAt = &t
+				break
+			}
+		}
+		if trade.ExecutedAt == nil {
+			return trade, true, fmt.Errorf("invalid TransactTime(60) %q", value)
+		}
+	}
+
+	return trade, true, nil
+}
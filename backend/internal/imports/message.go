@@ -0,0 +1,48 @@
+package imports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MessageTrade reads a trade from the fields of a streamed message, named as the CSV columns.
+// Values are strings, or numbers for quantity and price and times for executed_at; null and
+// unknown fields are ignored so message schemas can grow. Line identifies the message, such as
+// its offset in a topic partition.
+func MessageTrade(line int, fields map[string]any) (Trade, error) {
+	var fieldErr error
+	get := func(name string) string {
+		value, err := messageText(name, fields[name])
+		if err != nil && fieldErr == nil {
+			fieldErr = err
+		}
+		return strings.TrimSpace(value)
+	}
+
+	trade, err := csvTrade(line, get)
+	if fieldErr != nil {
+		return trade, fieldErr
+	}
+	return trade, err
+}
+
+func messageText(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case fmt.Stringer: // json.Number, decimal.Decimal
+		return v.String(), nil
+	case float64:
+		return decimal.NewFromFloat(v).String(), nil
+	case int64:
+		return decimal.NewFromInt(v).String(), nil
+	}
+	return "", fmt.Errorf("invalid %s: unsupported value of type %T", name, value)
+}
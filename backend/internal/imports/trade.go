@@ -0,0 +1,72 @@
+package imports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Supported file formats
+const (
+	FormatCSV   = "csv"
+	FormatFIX   = "fix"
+	FormatKafka = "kafka" // Trades consumed from a Kafka topic rather than uploaded
+)
+
+// Trade is one trade read from an import file. PortfolioID and CounterpartyID are left as
+// strings for the caller to resolve.
+type Trade struct {
+	Line                int
+	ExternalID          string
+	PortfolioID         string
+	TransactionType     string
+	Symbol              string
+	Quantity            decimal.Decimal
+	Price               decimal.Decimal
+	Currency            string
+	Status              string
+	ExecutedAt          *time.Time
+	Notes               string
+	CounterpartyID      string
+	CounterpartyName    string
+	CounterpartyCountry string
+}
+
+// RowHandler receives each trade in file order, or the error that made its row unreadable.
+// Returning an error stops the parse.
+type RowHandler func(trade Trade, rowErr error) error
+
+// timeLayouts are the executed_at formats accepted in CSV files
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid executed_at %q", value)
+}
+
+func parseDecimal(field, value string) (decimal.Decimal, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,301 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// Special offsets of ListOffsets
+const (
+	offsetLatest   int64 = -1
+	offsetEarliest int64 = -2
+)
+
+// Client is a connection pool to a Kafka cluster with a cache of its topic metadata. It speaks the
+// subset of the protocol used by Producer and Consumer.
+type Client struct {
+	dialer    *dialer
+	bootstrap []string
+
+	mu      sync.Mutex
+	conns   map[int32]*conn    // By broker node ID
+	brokers map[int32]string   // Address by node ID
+	leaders map[string][]int32 // Leader node ID of each partition by topic
+}
+
+// NewClient creates a client for the configured brokers. No connection is made until the first
+// request.
+func NewClient(cfg *config.KafkaConfig) (*Client, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka: no brokers configured")
+	}
+	switch cfg.SASLMechanism {
+	case "", SASLPlain, SASLScramSHA256, SASLScramSHA512:
+	default:
+		return nil, fmt.Errorf("kafka: unsupported SASL mechanism %q", cfg.SASLMechanism)
+	}
+
+	d := &dialer{
+		clientID:  cfg.ClientID,
+		mechanism: cfg.SASLMechanism,
+		username:  cfg.SASLUsername,
+		password:  cfg.SASLPassword,
+	}
+	if cfg.TLS {
+		d.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return &Client{
+		dialer:    d,
+		bootstrap: cfg.Brokers,
+		conns:     make(map[int32]*conn),
+		brokers:   make(map[int32]string),
+		leaders:   make(map[string][]int32),
+	}, nil
+}
+
+// Close closes every pooled connection
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, conn := range c.conns {
+		conn.close()
+		delete(c.conns, id)
+	}
+}
+
+// broker returns a pooled connection to a broker, dialing it when there is none or the last one
+// failed
+func (c *Client) broker(ctx context.Context, nodeID int32) (*conn, error) {
+	c.mu.Lock()
+	existing, ok := c.conns[nodeID]
+	addr, known := c.brokers[nodeID]
+	c.mu.Unlock()
+	if ok && !existing.isClosed() {
+		return existing, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("kafka: unknown broker %d", nodeID)
+	}
+
+	conn, err := c.dialer.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.conns[nodeID]; ok && existing != conn && !existing.isClosed() {
+		conn.close() // Another caller dialed first
+		return existing, nil
+	}
+	c.conns[nodeID] = conn
+	return conn, nil
+}
+
+// anyBroker sends a request to the first reachable broker, trying known brokers before the
+// bootstrap list
+func (c *Client) anyBroker(ctx context.Context, api int16, body []byte) ([]byte, error) {
+	c.mu.Lock()
+	ids := make([]int32, 0, len(c.brokers))
+	for id := range c.brokers {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+
+	var lastErr error
+	for _, id := range ids {
+		conn, err := c.broker(ctx, id)
+		if err == nil {
+			var response []byte
+			if response, err = conn.roundTrip(ctx, api, body, requestTimeout); err == nil {
+				return response, nil
+			}
+		}
+		lastErr = err
+	}
+	for _, addr := range c.bootstrap {
+		conn, err := c.dialer.dial(ctx, addr)
+		if err == nil {
+			var response []byte
+			response, err = conn.roundTrip(ctx, api, body, requestTimeout)
+			conn.close()
+			if err == nil {
+				return response, nil
+			}
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("kafka: no broker reachable: %w", lastErr)
+}
+
+// refreshMetadata reloads the brokers and the partition leaders of the given topics
+func (c *Client) refreshMetadata(ctx context.Context, topics ...string) error {
+	e := &encoder{}
+	e.strings(topics)
+	response, err := c.anyBroker(ctx, apiMetadata, e.buf)
+	if err != nil {
+		return err
+	}
+
+	r := &decoder{buf: response}
+	brokers := make(map[int32]string)
+	for range r.arrayLen() {
+		id := r.int32()
+		host := r.string()
+		port := r.int32()
+		r.string() // Rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.int32() // Controller ID
+
+	leaders := make(map[string][]int32)
+	var topicErr error
+	for range r.arrayLen() {
+		code := r.int16()
+		name := r.string()
+		r.bool() // Internal
+		partitions := make([]int32, r.arrayLen())
+		for range partitions {
+			r.int16() // Partition error; a missing leader is reported as -1
+			index := r.int32()
+			leader := r.int32()
+			r.int32s() // Replicas
+			r.int32s() // In-sync replicas
+			if index >= 0 && int(index) < len(partitions) {
+				partitions[index] = leader
+			}
+		}
+		if code != 0 {
+			topicErr = fmt.Errorf("topic %s: %w", name, Error(code))
+			continue
+		}
+		leaders[name] = partitions
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	c.mu.Lock()
+	c.brokers = brokers
+	for name, partitions := range leaders {
+		c.leaders[name] = partitions
+	}
+	c.mu.Unlock()
+	return topicErr
+}
+
+// Partitions returns the number of partitions of a topic
+func (c *Client) Partitions(ctx context.Context, topic string) (int, error) {
+	c.mu.Lock()
+	partitions, ok := c.leaders[topic]
+	c.mu.Unlock()
+	if ok {
+		return len(partitions), nil
+	}
+	if err := c.refreshMetadata(ctx, topic); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.leaders[topic]), nil
+}
+
+// leader returns a connection to the leader of a partition, loading metadata when the leader is
+// not known
+func (c *Client) leader(ctx context.Context, topic string, partition int32) (*conn, error) {
+	c.mu.Lock()
+	partitions, ok := c.leaders[topic]
+	c.mu.Unlock()
+	if !ok || int(partition) >= len(partitions) || partitions[partition] < 0 {
+		if err := c.refreshMetadata(ctx, topic); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		partitions = c.leaders[topic]
+		c.mu.Unlock()
+	}
+	if int(partition) >= len(partitions) || partitions[partition] < 0 {
+		return nil, ErrLeaderNotAvailable
+	}
+	return c.broker(ctx, partitions[partition])
+}
+
+// invalidate drops the cached leaders of a topic after a request failed because they changed
+func (c *Client) invalidate(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.leaders, topic)
+}
+
+// coordinator dials the group coordinator of a consumer group. The connection is not pooled, so
+// heartbeats do not queue behind fetches to the same broker.
+func (c *Client) coordinator(ctx context.Context, group string) (*conn, error) {
+	e := &encoder{}
+	e.string(group)
+	e.int8(0) // Key type: group
+	response, err := c.anyBroker(ctx, apiFindCoordinator, e.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &decoder{buf: response}
+	r.int32() // Throttle time
+	code := r.int16()
+	r.string() // Error message
+	r.int32()  // Node ID
+	host := r.string()
+	port := r.int32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if code != 0 {
+		return nil, Error(code)
+	}
+	return c.dialer.dial(ctx, net.JoinHostPort(host, strconv.Itoa(int(port))))
+}
+
+// listOffset returns the earliest or latest offset of a partition
+func (c *Client) listOffset(ctx context.Context, topic string, partition int32, which int64) (int64, error) {
+	conn, err := c.leader(ctx, topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	e := &encoder{}
+	e.int32(-1) // Replica ID
+	e.arrayLen(1)
+	e.string(topic)
+	e.arrayLen(1)
+	e.int32(partition)
+	e.int64(which)
+	response, err := conn.roundTrip(ctx, apiListOffsets, e.buf, requestTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	r := &decoder{buf: response}
+	r.arrayLen()
+	r.string()
+	r.arrayLen()
+	r.int32() // Partition
+	code := r.int16()
+	r.int64() // Timestamp
+	offset := r.int64()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if code != 0 {
+		if Error(code).staleMetadata() {
+			c.invalidate(topic)
+		}
+		return 0, Error(code)
+	}
+	return offset, nil
+}
@@ -0,0 +1,291 @@
+package kafka
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+const (
+	dialTimeout    = 10 * time.Second
+	requestTimeout = 30 * time.Second
+
+	// maxResponseSize caps a response so a corrupt size prefix cannot exhaust memory
+	maxResponseSize = 128 << 20
+)
+
+// dialer opens authenticated connections to brokers
+type dialer struct {
+	clientID  string
+	tls       *tls.Config
+	mechanism string
+	username  string
+	password  string
+}
+
+// conn is a connection to one broker. Requests are sent one at a time.
+type conn struct {
+	mu            sync.Mutex
+	nc            net.Conn
+	clientID      string
+	correlationID int32
+	closed        bool
+}
+
+func (d *dialer) dial(ctx context.Context, addr string) (*conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	var nc net.Conn
+	var err error
+	if d.tls != nil {
+		nc, err = (&tls.Dialer{Config: d.tls}).DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{nc: nc, clientID: d.clientID}
+	if d.mechanism != "" {
+		if err := d.authenticate(ctx, c); err != nil {
+			c.close()
+			return nil, fmt.Errorf("kafka: SASL authentication with %s: %w", addr, err)
+		}
+	}
+	return c, nil
+}
+
+// roundTrip sends a request and returns the response body after the correlation ID. The
+// connection is closed on any I/O error; timeout bounds the exchange when ctx has no earlier
+// deadline.
+func (c *conn) roundTrip(ctx context.Context, api int16, body []byte, timeout time.Duration) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, net.ErrClosed
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	// Unblock the exchange when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { c.nc.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	c.correlationID++
+	e := &encoder{buf: make([]byte, 4, 64+len(body))}
+	e.int16(api)
+	e.int16(apiVersions[api])
+	e.int32(c.correlationID)
+	e.string(c.clientID)
+	e.buf = append(e.buf, body...)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+
+	response, err := c.exchange(e.buf)
+	if err != nil {
+		c.closeLocked()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if id := int32(binary.BigEndian.Uint32(response)); id != c.correlationID {
+		c.closeLocked()
+		return nil, fmt.Errorf("kafka: response for request %d, expected %d", id, c.correlationID)
+	}
+	return response[4:], nil
+}
+
+func (c *conn) exchange(request []byte) ([]byte, error) {
+	if _, err := c.nc.Write(request); err != nil {
+		return nil, err
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(c.nc, size[:]); err != nil {
+		return nil, err
+	}
+	n := int32(binary.BigEndian.Uint32(size[:]))
+	if n < 4 || n > maxResponseSize {
+		return nil, fmt.Errorf("kafka: invalid response size %d", n)
+	}
+	response := make([]byte, n)
+	if _, err := io.ReadFull(c.nc, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *conn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *conn) closeLocked() {
+	if !c.closed {
+		c.closed = true
+		c.nc.Close()
+	}
+}
+
+func (c *conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// authenticate runs the SASL handshake and exchange for the configured mechanism
+func (d *dialer) authenticate(ctx context.Context, c *conn) error {
+	e := &encoder{}
+	e.string(d.mechanism)
+	response, err := c.roundTrip(ctx, apiSaslHandshake, e.buf, requestTimeout)
+	if err != nil {
+		return err
+	}
+	r := &decoder{buf: response}
+	code := r.int16()
+	mechanisms := r.strings()
+	if r.err != nil {
+		return r.err
+	}
+	if code != 0 {
+		return fmt.Errorf("%w (broker supports %s)", Error(code), strings.Join(mechanisms, ", "))
+	}
+
+	switch d.mechanism {
+	case SASLPlain:
+		_, err := d.saslAuthenticate(ctx, c, []byte("\x00"+d.username+"\x00"+d.password))
+		return err
+	case SASLScramSHA256:
+		return d.scram(ctx, c, sha256.New)
+	case SASLScramSHA512:
+		return d.scram(ctx, c, sha512.New)
+	}
+	return fmt.Errorf("unsupported SASL mechanism %q", d.mechanism)
+}
+
+func (d *dialer) saslAuthenticate(ctx context.Context, c *conn, auth []byte) ([]byte, error) {
+	e := &encoder{}
+	e.bytes(auth)
+	response, err := c.roundTrip(ctx, apiSaslAuthenticate, e.buf, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	r := &decoder{buf: response}
+	code := r.int16()
+	message := r.string()
+	reply := r.bytes()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if code != 0 {
+		if message != "" {
+			return nil, fmt.Errorf("%w: %s", Error(code), message)
+		}
+		return nil, Error(code)
+	}
+	return reply, nil
+}
+
+// scram authenticates with SCRAM (RFC 5802) without channel binding
+func (d *dialer) scram(ctx context.Context, c *conn, newHash func() hash.Hash) error {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	clientNonce := base64.RawStdEncoding.EncodeToString(nonce)
+	username := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(d.username)
+	clientFirstBare := "n=" + username + ",r=" + clientNonce
+
+	serverFirst, err := d.saslAuthenticate(ctx, c, []byte("n,,"+clientFirstBare))
+	if err != nil {
+		return err
+	}
+	attrs := scramAttributes(string(serverFirst))
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil {
+		return errors.New("invalid SCRAM salt")
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < 1 {
+		return errors.New("invalid SCRAM iteration count")
+	}
+	if !strings.HasPrefix(attrs["r"], clientNonce) {
+		return errors.New("SCRAM server nonce does not extend the client nonce")
+	}
+
+	saltedPassword, err := pbkdf2.Key(newHash, d.password, salt, iterations, newHash().Size())
+	if err != nil {
+		return err
+	}
+	clientFinalBare := "c=biws,r=" + attrs["r"]
+	authMessage := clientFirstBare + "," + string(serverFirst) + "," + clientFinalBare
+
+	clientKey := hmacSum(newHash, saltedPassword, "Client Key")
+	storedKey := newHash()
+	storedKey.Write(clientKey)
+	signature := hmacSum(newHash, storedKey.Sum(nil), authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+
+	serverFinal, err := d.saslAuthenticate(ctx, c, []byte(clientFinalBare+",p="+base64.StdEncoding.EncodeToString(proof)))
+	if err != nil {
+		return err
+	}
+	final := scramAttributes(string(serverFinal))
+	if final["e"] != "" {
+		return errors.New("SCRAM: " + final["e"])
+	}
+	serverSignature := hmacSum(newHash, hmacSum(newHash, saltedPassword, "Server Key"), authMessage)
+	if final["v"] != base64.StdEncoding.EncodeToString(serverSignature) {
+		return errors.New("SCRAM server signature mismatch")
+	}
+	return nil
+}
+
+func hmacSum(newHash func() hash.Hash, key []byte, message string) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// scramAttributes splits a SCRAM message into its k=v attributes
+func scramAttributes(message string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(message, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,615 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+const (
+	sessionTimeout    = 30 * time.Second
+	rebalanceTimeout  = 60 * time.Second
+	heartbeatInterval = 3 * time.Second
+
+	fetchMaxWait           = 500 * time.Millisecond
+	fetchMaxBytes          = 16 << 20
+	fetchPartitionMaxBytes = 1 << 20
+
+	retryDelay = 5 * time.Second
+
+	// rangeAssignor is the partition assignment strategy, the default of the Java consumer
+	rangeAssignor = "range"
+)
+
+// Start offset policies for partitions the group has no committed offset for
+const (
+	StartEarliest = "earliest"
+	StartLatest   = "latest"
+)
+
+// Handler processes records of one partition in offset order and returns how many it finished.
+// The offsets of finished records are committed; when it returns an error, the rest are fetched
+// again after a pause. ctx is cancelled when the group rebalances, and the handler should then
+// stop at the next record.
+type Handler func(ctx context.Context, records []Record) (int, error)
+
+// Consumer reads a topic as a member of a consumer group. The group coordinator spreads the
+// topic's partitions across the members, and the offset each member reaches is committed to the
+// group, so consumption resumes where it stopped after a restart or rebalance. Delivery is at
+// least once: records handled after the last commit are handled again.
+type Consumer struct {
+	client      *Client
+	group       string
+	topic       string
+	startOffset int64
+	logger      *slog.Logger
+
+	memberID string
+}
+
+func NewConsumer(client *Client, group, topic, startOffset string) (*Consumer, error) {
+	c := &Consumer{
+		client: client,
+		group:  group,
+		topic:  topic,
+		logger: logging.Component("kafka").With("group", group, "topic", topic),
+	}
+	switch startOffset {
+	case StartEarliest:
+		c.startOffset = offsetEarliest
+	case StartLatest:
+		c.startOffset = offsetLatest
+	default:
+		return nil, fmt.Errorf("kafka: invalid start offset %q, use earliest or latest", startOffset)
+	}
+	if group == "" {
+		return nil, errors.New("kafka: consumer group is required")
+	}
+	return c, nil
+}
+
+// Run consumes the topic until ctx is cancelled, rejoining the group after rebalances and
+// failures, and leaves the group when it returns
+func (c *Consumer) Run(ctx context.Context, handle Handler) {
+	c.logger.Info("Kafka consumer starting")
+	for ctx.Err() == nil {
+		err := c.generation(ctx, handle)
+		var kafkaErr Error
+		if err != nil && ctx.Err() == nil && !(errors.As(err, &kafkaErr) && kafkaErr.rejoin()) {
+			c.logger.Warn("Kafka consumer failed, rejoining", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+}
+
+// generation joins the group, consumes the assigned partitions until the group rebalances or a
+// request fails, and commits the offsets reached
+func (c *Consumer) generation(ctx context.Context, handle Handler) error {
+	coordinator, err := c.client.coordinator(ctx, c.group)
+	if err != nil {
+		return err
+	}
+	defer coordinator.close()
+
+	generationID, partitions, err := c.join(ctx, coordinator)
+	if err != nil {
+		var kafkaErr Error
+		if errors.As(err, &kafkaErr) && kafkaErr == ErrUnknownMemberID {
+			c.memberID = ""
+		}
+		return err
+	}
+	c.logger.Info("Joined consumer group", "generation", generationID, "member_id", c.memberID, "partitions", partitions)
+
+	session := &groupSession{
+		consumer:     c,
+		coordinator:  coordinator,
+		generationID: generationID,
+	}
+	defer func() {
+		if ctx.Err() != nil {
+			session.leave()
+		}
+	}()
+	if len(partitions) == 0 {
+		return session.heartbeatUntilRebalance(ctx)
+	}
+
+	offsets, err := session.committedOffsets(ctx, partitions)
+	if err != nil {
+		return err
+	}
+
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go session.heartbeat(sessionCtx, cancel)
+
+	err = session.consume(sessionCtx, offsets, handle)
+	if cause := context.Cause(sessionCtx); cause != nil && !errors.Is(cause, context.Canceled) {
+		return cause // The heartbeat ended the generation
+	}
+	return err
+}
+
+// join runs the JoinGroup and SyncGroup exchange and returns the generation and the partitions
+// assigned to this member. The member elected leader computes the assignment for everyone.
+func (c *Consumer) join(ctx context.Context, coordinator *conn) (int32, []int32, error) {
+	subscription := &encoder{}
+	subscription.int16(0) // Version
+	subscription.strings([]string{c.topic})
+	subscription.bytes(nil) // User data
+
+	e := &encoder{}
+	e.string(c.group)
+	e.int32(int32(sessionTimeout / time.Millisecond))
+	e.int32(int32(rebalanceTimeout / time.Millisecond))
+	e.string(c.memberID)
+	e.string("consumer")
+	e.arrayLen(1)
+	e.string(rangeAssignor)
+	e.bytes(subscription.buf)
+
+	response, err := coordinator.roundTrip(ctx, apiJoinGroup, e.buf, rebalanceTimeout+requestTimeout)
+	if err != nil {
+		return 0, nil, err
+	}
+	r := &decoder{buf: response}
+	r.int32() // Throttle time
+	code := r.int16()
+	generationID := r.int32()
+	protocol := r.string()
+	leader := r.string()
+	memberID := r.string()
+	members := make(map[string][]string)
+	for range r.arrayLen() {
+		id := r.string()
+		members[id] = decodeSubscription(r.bytes())
+	}
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+	if code != 0 {
+		return 0, nil, Error(code)
+	}
+	c.memberID = memberID
+	if protocol != rangeAssignor {
+		return 0, nil, fmt.Errorf("kafka: group uses assignment strategy %q, expected %q", protocol, rangeAssignor)
+	}
+
+	var assignments map[string][]int32
+	if leader == memberID {
+		if assignments, err = c.assign(ctx, members); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	e = &encoder{}
+	e.string(c.group)
+	e.int32(generationID)
+	e.string(memberID)
+	e.arrayLen(len(assignments))
+	for id, partitions := range assignments {
+		e.string(id)
+		e.bytes(c.encodeAssignment(partitions))
+	}
+	response, err = coordinator.roundTrip(ctx, apiSyncGroup, e.buf, rebalanceTimeout+requestTimeout)
+	if err != nil {
+		return 0, nil, err
+	}
+	r = &decoder{buf: response}
+	r.int32() // Throttle time
+	code = r.int16()
+	assignment := r.bytes()
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+	if code != 0 {
+		return 0, nil, Error(code)
+	}
+	return generationID, c.decodeAssignment(assignment), nil
+}
+
+// assign spreads the topic's partitions over the members subscribed to it in contiguous ranges,
+// as the Java range assignor does
+func (c *Consumer) assign(ctx context.Context, members map[string][]string) (map[string][]int32, error) {
+	// Reload partitions so a topic that grew is assigned in full
+	c.client.invalidate(c.topic)
+	count, err := c.client.Partitions(ctx, c.topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribed []string
+	assignments := make(map[string][]int32, len(members))
+	for id, topics := range members {
+		assignments[id] = nil
+		if slices.Contains(topics, c.topic) {
+			subscribed = append(subscribed, id)
+		}
+	}
+	if len(subscribed) == 0 {
+		return assignments, nil
+	}
+	sort.Strings(subscribed)
+
+	per, extra := count/len(subscribed), count%len(subscribed)
+	next := 0
+	for i, id := range subscribed {
+		n := per
+		if i < extra {
+			n++
+		}
+		for p := next; p < next+n; p++ {
+			assignments[id] = append(assignments[id], int32(p))
+		}
+		next += n
+	}
+	return assignments, nil
+}
+
+func (c *Consumer) encodeAssignment(partitions []int32) []byte {
+	e := &encoder{}
+	e.int16(0) // Version
+	e.arrayLen(1)
+	e.string(c.topic)
+	e.arrayLen(len(partitions))
+	for _, p := range partitions {
+		e.int32(p)
+	}
+	e.bytes(nil) // User data
+	return e.buf
+}
+
+// decodeAssignment returns the partitions of the consumer's topic in a member assignment
+func (c *Consumer) decodeAssignment(data []byte) []int32 {
+	r := &decoder{buf: data}
+	r.int16() // Version
+	var partitions []int32
+	for range r.arrayLen() {
+		topic := r.string()
+		assigned := r.int32s()
+		if topic == c.topic {
+			partitions = append(partitions, assigned...)
+		}
+	}
+	if r.err != nil {
+		return nil
+	}
+	return partitions
+}
+
+// decodeSubscription returns the topics of a member's subscription metadata
+func decodeSubscription(data []byte) []string {
+	r := &decoder{buf: data}
+	r.int16() // Version
+	topics := r.strings()
+	if r.err != nil {
+		return nil
+	}
+	return topics
+}
+
+// groupSession is this member's part in one generation of the group
+type groupSession struct {
+	consumer     *Consumer
+	coordinator  *conn
+	generationID int32
+}
+
+// heartbeat keeps the membership alive and cancels ctx with the error that ended the generation
+func (s *groupSession) heartbeat(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.sendHeartbeat(ctx); err != nil {
+				if ctx.Err() == nil {
+					cancel(err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// heartbeatUntilRebalance keeps a member without partitions in the group until the next rebalance,
+// which may hand it some
+func (s *groupSession) heartbeatUntilRebalance(ctx context.Context) error {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.sendHeartbeat(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *groupSession) sendHeartbeat(ctx context.Context) error {
+	e := &encoder{}
+	e.string(s.consumer.group)
+	e.int32(s.generationID)
+	e.string(s.consumer.memberID)
+	response, err := s.coordinator.roundTrip(ctx, apiHeartbeat, e.buf, requestTimeout)
+	if err != nil {
+		return err
+	}
+	r := &decoder{buf: response}
+	r.int32() // Throttle time
+	code := r.int16()
+	if r.err != nil {
+		return r.err
+	}
+	return errorCode(code)
+}
+
+// leave tells the coordinator this member is gone so its partitions are reassigned at once rather
+// than after the session timeout
+func (s *groupSession) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e := &encoder{}
+	e.string(s.consumer.group)
+	e.string(s.consumer.memberID)
+	if _, err := s.coordinator.roundTrip(ctx, apiLeaveGroup, e.buf, requestTimeout); err != nil {
+		s.consumer.logger.Debug("Failed to leave consumer group", "error", err)
+	}
+	s.consumer.memberID = ""
+}
+
+// committedOffsets returns the offset to resume each partition from: the group's committed offset,
+// or the start offset policy for partitions without one
+func (s *groupSession) committedOffsets(ctx context.Context, partitions []int32) (map[int32]int64, error) {
+	c := s.consumer
+	e := &encoder{}
+	e.string(c.group)
+	e.arrayLen(1)
+	e.string(c.topic)
+	e.arrayLen(len(partitions))
+	for _, p := range partitions {
+		e.int32(p)
+	}
+	response, err := s.coordinator.roundTrip(ctx, apiOffsetFetch, e.buf, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	r := &decoder{buf: response}
+	for range r.arrayLen() {
+		r.string()
+		for range r.arrayLen() {
+			partition := r.int32()
+			offset := r.int64()
+			r.string() // Metadata
+			if code := r.int16(); code != 0 {
+				return nil, Error(code)
+			}
+			offsets[partition] = offset
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for _, p := range partitions {
+		if offset, ok := offsets[p]; ok && offset >= 0 {
+			continue
+		}
+		offset, err := c.client.listOffset(ctx, c.topic, p, c.startOffset)
+		if err != nil {
+			return nil, err
+		}
+		offsets[p] = offset
+	}
+	return offsets, nil
+}
+
+// commit stores the offsets reached for the group. It runs on the consumer's context rather than
+// the session's, so the progress of a generation that is ending is still saved.
+func (s *groupSession) commit(ctx context.Context, offsets map[int32]int64) error {
+	c := s.consumer
+	e := &encoder{}
+	e.string(c.group)
+	e.int32(s.generationID)
+	e.string(c.memberID)
+	e.int64(-1) // Retention: the broker default
+	e.arrayLen(1)
+	e.string(c.topic)
+	e.arrayLen(len(offsets))
+	for p, offset := range offsets {
+		e.int32(p)
+		e.int64(offset)
+		e.nullableString(nil)
+	}
+	response, err := s.coordinator.roundTrip(ctx, apiOffsetCommit, e.buf, requestTimeout)
+	if err != nil {
+		return err
+	}
+
+	r := &decoder{buf: response}
+	for range r.arrayLen() {
+		r.string()
+		for range r.arrayLen() {
+			r.int32() // Partition
+			if code := r.int16(); code != 0 && r.err == nil {
+				return Error(code)
+			}
+		}
+	}
+	return r.err
+}
+
+// consume fetches the assigned partitions from their leaders and hands the records to the
+// handler, committing after every fetch that made progress
+func (s *groupSession) consume(ctx context.Context, offsets map[int32]int64, handle Handler) error {
+	c := s.consumer
+	parent := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		results, err := s.fetch(ctx, offsets)
+		if err != nil {
+			return err
+		}
+
+		progressed := make(map[int32]int64)
+		failed := false
+		for _, partition := range slices.Sorted(maps.Keys(results)) {
+			result := results[partition]
+
+			if len(result.records) > 0 {
+				done, err := handle(ctx, result.records)
+				if done > 0 {
+					offsets[partition] = result.records[done-1].Offset + 1
+					progressed[partition] = offsets[partition]
+				}
+				if err != nil {
+					c.logger.Error("Failed to handle Kafka records", "partition", partition,
+						"offset", offsets[partition], "error", err)
+					failed = true
+					continue
+				}
+				if done < len(result.records) {
+					continue // Stopped for a rebalance
+				}
+			}
+
+			var kafkaErr Error
+			switch {
+			case result.err == nil:
+				if result.next > offsets[partition] {
+					// Skipped control records and aborted transactions
+					offsets[partition] = result.next
+					progressed[partition] = result.next
+				}
+			case errors.As(result.err, &kafkaErr) && kafkaErr == ErrOffsetOutOfRange:
+				offset, err := c.client.listOffset(ctx, c.topic, partition, c.startOffset)
+				if err != nil {
+					return err
+				}
+				c.logger.Warn("Committed offset out of range, resetting", "partition", partition,
+					"from", offsets[partition], "to", offset)
+				offsets[partition] = offset
+				progressed[partition] = offset
+			case errors.As(result.err, &kafkaErr) && kafkaErr.staleMetadata():
+				c.client.invalidate(c.topic)
+			default:
+				// The partition stays at the batch that could not be read
+				c.logger.Error("Failed to read Kafka records", "partition", partition,
+					"offset", offsets[partition], "error", result.err)
+				failed = true
+			}
+		}
+
+		if len(progressed) > 0 {
+			if err := s.commit(parent, progressed); err != nil {
+				return err
+			}
+		}
+		if failed {
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+	return nil
+}
+
+// fetchResult holds the records of one partition in a fetch response, the offset to fetch next
+// and the partition's error
+type fetchResult struct {
+	records []Record
+	next    int64
+	err     error
+}
+
+// fetch reads each partition from its offset, one request per leader. Partition errors are
+// returned in the results; an error is returned when a broker could not be reached.
+func (s *groupSession) fetch(ctx context.Context, offsets map[int32]int64) (map[int32]fetchResult, error) {
+	c := s.consumer
+	results := make(map[int32]fetchResult, len(offsets))
+	byLeader := make(map[*conn][]int32)
+	for partition := range offsets {
+		conn, err := c.client.leader(ctx, c.topic, partition)
+		if err != nil {
+			results[partition] = fetchResult{err: err}
+			continue
+		}
+		byLeader[conn] = append(byLeader[conn], partition)
+	}
+
+	for conn, partitions := range byLeader {
+		if err := s.fetchFrom(ctx, conn, partitions, offsets, results); err != nil {
+			c.client.invalidate(c.topic)
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (s *groupSession) fetchFrom(ctx context.Context, conn *conn, partitions []int32, offsets map[int32]int64, results map[int32]fetchResult) error {
+	c := s.consumer
+	e := &encoder{}
+	e.int32(-1) // Replica ID
+	e.int32(int32(fetchMaxWait / time.Millisecond))
+	e.int32(1) // Min bytes
+	e.int32(fetchMaxBytes)
+	e.int8(1) // Isolation: read committed
+	e.arrayLen(1)
+	e.string(c.topic)
+	e.arrayLen(len(partitions))
+	for _, p := range partitions {
+		e.int32(p)
+		e.int64(offsets[p])
+		e.int32(fetchPartitionMaxBytes)
+	}
+	response, err := conn.roundTrip(ctx, apiFetch, e.buf, fetchMaxWait+requestTimeout)
+	if err != nil {
+		return err
+	}
+
+	r := &decoder{buf: response}
+	r.int32() // Throttle time
+	for range r.arrayLen() {
+		topic := r.string()
+		for range r.arrayLen() {
+			partition := r.int32()
+			code := r.int16()
+			r.int64() // High watermark
+			r.int64() // Last stable offset
+			var aborted []abortedTxn
+			for range r.arrayLen() {
+				aborted = append(aborted, abortedTxn{producerID: r.int64(), firstOffset: r.int64()})
+			}
+			data := r.bytes()
+			if r.err != nil {
+				return r.err
+			}
+			if code != 0 {
+				results[partition] = fetchResult{err: Error(code)}
+				continue
+			}
+			records, next, err := decodeBatches(topic, partition, data, offsets[partition], aborted)
+			results[partition] = fetchResult{records: records, next: next, err: err}
+		}
+	}
+	return r.err
+}
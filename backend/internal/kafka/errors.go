@@ -0,0 +1,82 @@
+package kafka
+
+import "fmt"
+
+// Error is an error code returned by a broker
+type Error int16
+
+// Broker error codes the client acts on
+const (
+	ErrOffsetOutOfRange        Error = 1
+	ErrCorruptMessage          Error = 2
+	ErrUnknownTopicOrPartition Error = 3
+	ErrLeaderNotAvailable      Error = 5
+	ErrNotLeader               Error = 6
+	ErrRequestTimedOut         Error = 7
+	ErrMessageTooLarge         Error = 10
+	ErrCoordinatorLoading      Error = 14
+	ErrCoordinatorNotAvailable Error = 15
+	ErrNotCoordinator          Error = 16
+	ErrNotEnoughReplicas       Error = 19
+	ErrIllegalGeneration       Error = 22
+	ErrUnknownMemberID         Error = 25
+	ErrRebalanceInProgress     Error = 27
+	ErrTopicAuthorization      Error = 29
+	ErrGroupAuthorization      Error = 30
+	ErrUnsupportedSASL         Error = 33
+	ErrUnsupportedVersion      Error = 35
+	ErrSASLAuthentication      Error = 58
+)
+
+var errorNames = map[Error]string{
+	ErrOffsetOutOfRange:        "offset out of range",
+	ErrCorruptMessage:          "corrupt message",
+	ErrUnknownTopicOrPartition: "unknown topic or partition",
+	ErrLeaderNotAvailable:      "leader not available",
+	ErrNotLeader:               "not leader for partition",
+	ErrRequestTimedOut:         "request timed out",
+	ErrMessageTooLarge:         "message too large",
+	ErrCoordinatorLoading:      "coordinator loading",
+	ErrCoordinatorNotAvailable: "coordinator not available",
+	ErrNotCoordinator:          "not coordinator",
+	ErrNotEnoughReplicas:       "not enough replicas",
+	ErrIllegalGeneration:       "illegal generation",
+	ErrUnknownMemberID:         "unknown member ID",
+	ErrRebalanceInProgress:     "rebalance in progress",
+	ErrTopicAuthorization:      "topic authorization failed",
+	ErrGroupAuthorization:      "group authorization failed",
+	ErrUnsupportedSASL:         "unsupported SASL mechanism",
+	ErrUnsupportedVersion:      "unsupported version",
+	ErrSASLAuthentication:      "SASL authentication failed",
+}
+
+func (e Error) Error() string {
+	if name, ok := errorNames[e]; ok {
+		return "kafka: " + name
+	}
+	return fmt.Sprintf("kafka: error code %d", int16(e))
+}
+
+// staleMetadata reports whether the error means the client's view of partition leaders or the
+// group coordinator is out of date and the request should be retried after a refresh
+func (e Error) staleMetadata() bool {
+	switch e {
+	case ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeader, ErrRequestTimedOut,
+		ErrCoordinatorLoading, ErrCoordinatorNotAvailable, ErrNotCoordinator, ErrNotEnoughReplicas:
+		return true
+	}
+	return false
+}
+
+// rejoin reports whether the error means the consumer's group membership is over
+func (e Error) rejoin() bool {
+	return e == ErrIllegalGeneration || e == ErrUnknownMemberID || e == ErrRebalanceInProgress
+}
+
+// errorCode converts a response error code into an error
+func errorCode(code int16) error {
+	if code == 0 {
+		return nil
+	}
+	return Error(code)
+}
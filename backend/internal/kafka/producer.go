@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	produceTimeout  = 30 * time.Second
+	produceAttempts = 3
+)
+
+// Producer writes records to topics and waits for all in-sync replicas to acknowledge them
+type Producer struct {
+	client      *Client
+	compression string
+}
+
+func NewProducer(client *Client, compression string) (*Producer, error) {
+	if code, ok := compressionCodes[compression]; !ok || code == compressionCodes[CompressionLZ4] {
+		return nil, fmt.Errorf("kafka: unsupported compression %q, use none, gzip, snappy or zstd", compression)
+	}
+	return &Producer{client: client, compression: compression}, nil
+}
+
+// Produce writes records to a topic. Records with the same key go to the same partition, chosen as
+// the Java client chooses it so that keyed records partition alike whichever producer wrote them;
+// records without a key go to a random partition. Order is kept within each partition. Requests
+// that fail because partition leadership moved are retried after reloading metadata.
+func (p *Producer) Produce(ctx context.Context, topic string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	count, err := p.client.Partitions(ctx, topic)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("kafka: topic %s has no partitions", topic)
+	}
+
+	byPartition := make(map[int32][]Record)
+	var order []int32
+	for _, r := range records {
+		partition := int32(rand.IntN(count))
+		if r.Key != nil {
+			partition = int32(murmur2(r.Key)&0x7fffffff) % int32(count)
+		}
+		if _, ok := byPartition[partition]; !ok {
+			order = append(order, partition)
+		}
+		byPartition[partition] = append(byPartition[partition], r)
+	}
+
+	for _, partition := range order {
+		if err := p.producePartition(ctx, topic, partition, byPartition[partition]); err != nil {
+			return fmt.Errorf("kafka: produce to %s/%d: %w", topic, partition, err)
+		}
+	}
+	return nil
+}
+
+func (p *Producer) producePartition(ctx context.Context, topic string, partition int32, records []Record) error {
+	batch, err := encodeBatch(records, p.compression)
+	if err != nil {
+		return err
+	}
+
+	e := &encoder{}
+	e.nullableString(nil) // Transactional ID
+	e.int16(-1)           // Acks: all in-sync replicas
+	e.int32(int32(produceTimeout / time.Millisecond))
+	e.arrayLen(1)
+	e.string(topic)
+	e.arrayLen(1)
+	e.int32(partition)
+	e.bytes(batch)
+
+	for attempt := 1; ; attempt++ {
+		err = p.send(ctx, topic, partition, e.buf)
+		var kafkaErr Error
+		retriable := !errors.As(err, &kafkaErr) || kafkaErr.staleMetadata()
+		if err == nil || !retriable || attempt == produceAttempts || ctx.Err() != nil {
+			return err
+		}
+		p.client.invalidate(topic)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 250 * time.Millisecond):
+		}
+	}
+}
+
+func (p *Producer) send(ctx context.Context, topic string, partition int32, request []byte) error {
+	conn, err := p.client.leader(ctx, topic, partition)
+	if err != nil {
+		return err
+	}
+	response, err := conn.roundTrip(ctx, apiProduce, request, produceTimeout+requestTimeout)
+	if err != nil {
+		return err
+	}
+
+	r := &decoder{buf: response}
+	var code int16
+	for range r.arrayLen() {
+		r.string()
+		for range r.arrayLen() {
+			r.int32() // Partition
+			code = r.int16()
+			r.int64() // Base offset
+			r.int64() // Log append time
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return errorCode(code)
+}
+
+// murmur2 is the hash the Java client's default partitioner applies to record keys
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
@@ -0,0 +1,202 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// API keys of the requests the client sends. Only non-flexible versions are used, which every
+// broker from Kafka 2.1 to 4.x accepts, so no tagged fields are encoded.
+const (
+	apiProduce          int16 = 0
+	apiFetch            int16 = 1
+	apiListOffsets      int16 = 2
+	apiMetadata         int16 = 3
+	apiOffsetCommit     int16 = 8
+	apiOffsetFetch      int16 = 9
+	apiFindCoordinator  int16 = 10
+	apiJoinGroup        int16 = 11
+	apiHeartbeat        int16 = 12
+	apiLeaveGroup       int16 = 13
+	apiSyncGroup        int16 = 14
+	apiSaslHandshake    int16 = 17
+	apiSaslAuthenticate int16 = 36
+)
+
+// apiVersions are the versions sent for each API key
+var apiVersions = map[int16]int16{
+	apiProduce:          3,
+	apiFetch:            4,
+	apiListOffsets:      1,
+	apiMetadata:         1,
+	apiOffsetCommit:     2,
+	apiOffsetFetch:      1,
+	apiFindCoordinator:  1,
+	apiJoinGroup:        2,
+	apiHeartbeat:        1,
+	apiLeaveGroup:       1,
+	apiSyncGroup:        1,
+	apiSaslHandshake:    1,
+	apiSaslAuthenticate: 0,
+}
+
+var errShortResponse = errors.New("kafka: truncated response")
+
+// encoder appends request fields in the Kafka wire format
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int8(v int8) {
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *encoder) int16(v int16) {
+	e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v))
+}
+
+func (e *encoder) int32(v int32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v))
+}
+
+func (e *encoder) int64(v int64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// nullableString writes -1 for nil
+func (e *encoder) nullableString(s *string) {
+	if s == nil {
+		e.int16(-1)
+		return
+	}
+	e.string(*s)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// arrayLen writes the length of an array, whose elements the caller writes next
+func (e *encoder) arrayLen(n int) {
+	e.int32(int32(n))
+}
+
+func (e *encoder) strings(values []string) {
+	e.arrayLen(len(values))
+	for _, s := range values {
+		e.string(s)
+	}
+}
+
+// decoder reads response fields. The first error sticks: later reads return zero values, so a
+// response can be decoded in full and checked once at the end.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errShortResponse
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *decoder) bool() bool {
+	return d.int8() != 0
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+// bytes returns nil for a null byte array
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// arrayLen reads the length of an array, treating null as empty. Lengths larger than the rest of
+// the response are rejected before the caller allocates for them.
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.buf) {
+		d.err = errShortResponse
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) strings() []string {
+	values := make([]string, d.arrayLen())
+	for i := range values {
+		values[i] = d.string()
+	}
+	return values
+}
+
+func (d *decoder) int32s() []int32 {
+	values := make([]int32, d.arrayLen())
+	for i := range values {
+		values[i] = d.int32()
+	}
+	return values
+}
@@ -0,0 +1,353 @@
+package kafka
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/klauspost/compress/snappy/xerial"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression codecs of record batches
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+	CompressionLZ4    = "lz4"
+	CompressionZstd   = "zstd"
+)
+
+var compressionCodes = map[string]int16{
+	CompressionNone:   0,
+	CompressionGzip:   1,
+	CompressionSnappy: 2,
+	CompressionLZ4:    3,
+	CompressionZstd:   4,
+}
+
+const (
+	batchHeaderSize  = 61 // Bytes from the base offset to the record count, inclusive
+	recordBatchMagic = 2
+
+	attrCompressionMask = 0x07
+	attrTransactional   = 0x10
+	attrControl         = 0x20
+
+	// maxDecompressedSize caps a decompressed batch so a corrupt or hostile batch cannot exhaust memory
+	maxDecompressedSize = 64 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+
+// Header is a record header
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a message read from or written to a topic partition
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+	Timestamp time.Time
+}
+
+// encodeBatch writes records as a single record batch (magic 2) with the given codec
+func encodeBatch(records []Record, compression string) ([]byte, error) {
+	code, ok := compressionCodes[compression]
+	if !ok || compression == CompressionLZ4 {
+		return nil, fmt.Errorf("kafka: unsupported compression %q", compression)
+	}
+
+	base := time.Now().UnixMilli()
+	maxTimestamp := base
+	var body []byte
+	for i, r := range records {
+		ts := base
+		if !r.Timestamp.IsZero() {
+			ts = r.Timestamp.UnixMilli()
+		}
+		if i == 0 {
+			base, maxTimestamp = ts, ts
+		}
+		maxTimestamp = max(maxTimestamp, ts)
+		body = appendRecord(body, r, int64(i), ts-base)
+	}
+
+	body, err := compress(code, body)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encoder{buf: make([]byte, 0, batchHeaderSize+len(body))}
+	e.int64(0)                                       // Base offset, assigned by the broker
+	e.int32(int32(batchHeaderSize - 12 + len(body))) // Batch length, after this field
+	e.int32(-1)                                      // Partition leader epoch
+	e.int8(recordBatchMagic)
+	e.int32(0) // CRC, filled in below
+	e.int16(code)
+	e.int32(int32(len(records) - 1)) // Last offset delta
+	e.int64(base)
+	e.int64(maxTimestamp)
+	e.int64(-1) // Producer ID
+	e.int16(-1) // Producer epoch
+	e.int32(-1) // Base sequence
+	e.int32(int32(len(records)))
+	e.buf = append(e.buf, body...)
+
+	binary.BigEndian.PutUint32(e.buf[17:21], crc32.Checksum(e.buf[21:], castagnoli))
+	return e.buf, nil
+}
+
+func appendRecord(buf []byte, r Record, offsetDelta, timestampDelta int64) []byte {
+	var rec []byte
+	rec = append(rec, 0) // Attributes
+	rec = binary.AppendVarint(rec, timestampDelta)
+	rec = binary.AppendVarint(rec, offsetDelta)
+	rec = appendVarBytes(rec, r.Key)
+	rec = appendVarBytes(rec, r.Value)
+	rec = binary.AppendVarint(rec, int64(len(r.Headers)))
+	for _, h := range r.Headers {
+		rec = appendVarBytes(rec, []byte(h.Key))
+		rec = appendVarBytes(rec, h.Value)
+	}
+
+	buf = binary.AppendVarint(buf, int64(len(rec)))
+	return append(buf, rec...)
+}
+
+// appendVarBytes writes a varint length and the bytes, with -1 for nil
+func appendVarBytes(buf, b []byte) []byte {
+	if b == nil {
+		return binary.AppendVarint(buf, -1)
+	}
+	buf = binary.AppendVarint(buf, int64(len(b)))
+	return append(buf, b...)
+}
+
+func compress(code int16, data []byte) ([]byte, error) {
+	switch code {
+	case 0:
+		return data, nil
+	case 1:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case 2:
+		return xerial.Encode(nil, data), nil
+	case 4:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer encoder.Close()
+		return encoder.EncodeAll(data, nil), nil
+	}
+	return nil, fmt.Errorf("kafka: unsupported compression code %d", code)
+}
+
+func decompress(code int16, data []byte) ([]byte, error) {
+	switch code {
+	case 0:
+		return data, nil
+	case 1:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+		if err == nil && len(out) > maxDecompressedSize {
+			err = errors.New("kafka: decompressed batch too large")
+		}
+		return out, err
+	case 2:
+		out, err := xerial.Decode(data)
+		if err == nil && len(out) > maxDecompressedSize {
+			err = errors.New("kafka: decompressed batch too large")
+		}
+		return out, err
+	case 3:
+		return nil, errors.New("kafka: lz4 compressed batches are not supported")
+	case 4:
+		return zstdDecoder.DecodeAll(data, nil)
+	}
+	return nil, fmt.Errorf("kafka: unknown compression code %d", code)
+}
+
+// abortedTxn is a transaction aborted in a fetched partition, from the fetch response
+type abortedTxn struct {
+	producerID  int64
+	firstOffset int64
+}
+
+// decodeBatches reads the records of the batches in a fetch response from offset onwards and
+// returns the offset to fetch next. Records of aborted transactions and control batches are
+// skipped but still move the next offset on. A batch cut off at the end of the response, as
+// brokers do when the fetch size is reached, is ignored. On error the next offset is that of the
+// batch that could not be read.
+func decodeBatches(topic string, partition int32, data []byte, offset int64, aborted []abortedTxn) ([]Record, int64, error) {
+	var records []Record
+	next := offset
+	abortedProducers := make(map[int64]bool)
+	slices.SortFunc(aborted, func(a, b abortedTxn) int { return cmp.Compare(a.firstOffset, b.firstOffset) })
+
+	for len(data) >= 17 {
+		baseOffset := int64(binary.BigEndian.Uint64(data[0:8]))
+		length := int(int32(binary.BigEndian.Uint32(data[8:12])))
+		if length < 0 || len(data) < 12+length {
+			break
+		}
+		batch := data[:12+length]
+		data = data[12+length:]
+
+		if magic := batch[16]; magic != recordBatchMagic {
+			return records, next, fmt.Errorf("kafka: unsupported message format version %d", magic)
+		}
+		if len(batch) < batchHeaderSize {
+			return records, next, ErrCorruptMessage
+		}
+		if crc32.Checksum(batch[21:], castagnoli) != binary.BigEndian.Uint32(batch[17:21]) {
+			return records, next, ErrCorruptMessage
+		}
+		batchEnd := baseOffset + int64(int32(binary.BigEndian.Uint32(batch[23:27]))) + 1
+
+		attributes := int16(binary.BigEndian.Uint16(batch[21:23]))
+		baseTimestamp := int64(binary.BigEndian.Uint64(batch[27:35]))
+		producerID := int64(binary.BigEndian.Uint64(batch[43:51]))
+
+		// A transaction is aborted from its first offset until its abort marker
+		for len(aborted) > 0 && aborted[0].firstOffset <= baseOffset {
+			abortedProducers[aborted[0].producerID] = true
+			aborted = aborted[1:]
+		}
+		if attributes&attrControl != 0 {
+			delete(abortedProducers, producerID)
+			next = max(next, batchEnd)
+			continue
+		}
+		if attributes&attrTransactional != 0 && abortedProducers[producerID] {
+			next = max(next, batchEnd)
+			continue
+		}
+
+		body, err := decompress(attributes&attrCompressionMask, batch[batchHeaderSize:])
+		if err != nil {
+			return records, next, err
+		}
+		batchRecords, err := decodeRecords(body, int(int32(binary.BigEndian.Uint32(batch[57:61]))))
+		if err != nil {
+			return records, next, err
+		}
+		for _, r := range batchRecords {
+			r.Offset += baseOffset
+			if r.Offset < offset {
+				continue // Batches are returned whole, from before the requested offset
+			}
+			r.Topic = topic
+			r.Partition = partition
+			r.Timestamp = time.UnixMilli(baseTimestamp + r.Timestamp.UnixMilli())
+			records = append(records, r)
+		}
+		next = max(next, batchEnd)
+	}
+	return records, next, nil
+}
+
+// decodeRecords reads the records of a decompressed batch body. Offsets and timestamps are left
+// as deltas from the batch's base.
+func decodeRecords(body []byte, count int) ([]Record, error) {
+	r := &varReader{buf: body}
+	records := make([]Record, 0, min(count, len(body)))
+	for range count {
+		length := r.varint()
+		if r.err != nil || length < 0 || int(length) > len(r.buf) {
+			return nil, ErrCorruptMessage
+		}
+		rec := &varReader{buf: r.buf[:length]}
+		r.buf = r.buf[length:]
+
+		rec.take(1) // Attributes
+		timestampDelta := rec.varint()
+		offsetDelta := rec.varint()
+		record := Record{
+			Offset:    offsetDelta,
+			Timestamp: time.UnixMilli(timestampDelta),
+			Key:       rec.varBytes(),
+			Value:     rec.varBytes(),
+		}
+		headers := rec.varint()
+		if headers < 0 || int(headers) > len(rec.buf) {
+			return nil, ErrCorruptMessage
+		}
+		for range headers {
+			key := rec.varBytes()
+			record.Headers = append(record.Headers, Header{Key: string(key), Value: rec.varBytes()})
+		}
+		if rec.err != nil {
+			return nil, ErrCorruptMessage
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// varReader reads the varint encoded fields of records
+type varReader struct {
+	buf []byte
+	err error
+}
+
+func (r *varReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = ErrCorruptMessage
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *varReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf) {
+		r.err = ErrCorruptMessage
+		return nil
+	}
+	b := r.buf[:n:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// varBytes reads a varint length and the bytes, returning nil for -1
+func (r *varReader) varBytes() []byte {
+	n := r.varint()
+	if n < 0 {
+		return nil
+	}
+	return r.take(int(n))
+}
@@ -0,0 +1,113 @@
+// Package lifecycle runs the server's background workers under a shared context and stops them
+// in order on shutdown
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// Manager owns the context of the background workers. Shutdown cancels it, waits for the
+// workers to return and then runs the shutdown hooks, such as flushing queued writes.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	running map[string]int
+	hooks   []hook
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logging.Component("lifecycle"),
+		running: make(map[string]int),
+	}
+}
+
+// Context is cancelled when shutdown starts
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs a worker in its own goroutine. The worker must return soon after ctx is cancelled;
+// work it has already started may finish on a context detached from ctx.
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+
+	m.wg.Go(func() {
+		defer func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+		}()
+		run(m.ctx)
+	})
+}
+
+// OnShutdown registers a hook run once the workers have stopped, in registration order
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown cancels the workers' context, waits for them to return and runs the shutdown hooks.
+// When ctx ends first, the workers still running are logged and abandoned, the hooks are run
+// with the expired ctx so they give up at once, and ctx's error is returned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+
+	var errs []error
+	select {
+	case <-stopped:
+		m.logger.Info("Background workers stopped")
+	case <-ctx.Done():
+		m.mu.Lock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		m.mu.Unlock()
+		slices.Sort(names)
+		m.logger.Warn("Background workers did not stop in time", "workers", names)
+		errs = append(errs, ctx.Err())
+	}
+
+	m.mu.Lock()
+	hooks := slices.Clone(m.hooks)
+	m.mu.Unlock()
+	for _, h := range hooks {
+		if err := h.fn(ctx); err != nil {
+			m.logger.Warn("Shutdown hook failed", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
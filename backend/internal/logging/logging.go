@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// Log formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type requestIDKey struct{}
+
+var (
+	level = new(slog.LevelVar)
+
+	defaultLogger atomic.Pointer[slog.Logger]
+)
+
+func init() {
+	defaultLogger.Store(slog.New(newHandler(os.Stdout, FormatText)))
+}
+
+// Init builds the shared logger from the configuration and routes the standard library logger and
+// slog's default through it
+func Init(cfg *config.LogConfig) (*slog.Logger, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+
+	format := strings.ToLower(cfg.Format)
+	if format != FormatJSON && format != FormatText {
+		return nil, fmt.Errorf("unknown log format %q, use json or text", cfg.Format)
+	}
+
+	logger := slog.New(newHandler(os.Stdout, format))
+	defaultLogger.Store(logger)
+	slog.SetDefault(logger)
+
+	return logger, nil
+}
+
+func newHandler(w io.Writer, format string) slog.Handler {
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return contextHandler{slog.NewJSONHandler(w, options)}
+	}
+	return contextHandler{slog.NewTextHandler(w, options)}
+}
+
+// Logger returns the shared logger
+func Logger() *slog.Logger {
+	return defaultLogger.Load()
+}
+
+// Component returns the shared logger tagged with the component writing to it
+func Component(name string) *slog.Logger {
+	return Logger().With("component", name)
+}
+
+// SetLevel changes the minimum level logged, at runtime; level is debug, info, warn or error
+func SetLevel(value string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("unknown log level %q, use debug, info, warn or error", value)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// Level returns the current minimum level
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// WithRequestID returns a context carrying the ID that correlates log lines, alerts and broadcasts
+// caused by one request or job
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// WithNewRequestID returns a context carrying a fresh request ID, for work started by a scheduler
+// rather than a request
+func WithNewRequestID(ctx context.Context) context.Context {
+	return WithRequestID(ctx, uuid.NewString())
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID carried by the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,85 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Book is the order book of a symbol from a depth source; levels may come in any order
+type Book struct {
+	Symbol    string              `json:"symbol"`
+	Bids      []models.DepthLevel `json:"bids"`
+	Asks      []models.DepthLevel `json:"asks"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+// DepthSource snapshots the order books of symbols with up to levels price levels a side
+type DepthSource interface {
+	Depth(ctx context.Context, symbols []string, levels int) ([]Book, error)
+}
+
+// NewDepthSource returns where the order books of the configured feed come from: the simulated
+// feed books around its own prices and the http feed reads PRICE_FEED_DEPTH_URL. It returns nil
+// when the feed has no order books.
+func NewDepthSource(cfg *config.PriceFeedConfig, feed Feed) DepthSource {
+	switch feed := feed.(type) {
+	case *SimulatedFeed:
+		return feed
+	case *HTTPFeed:
+		if cfg.DepthURL == "" {
+			return nil
+		}
+		return NewHTTPDepthSource(cfg.DepthURL, cfg.APIKey)
+	default:
+		return nil
+	}
+}
+
+// HTTPDepthSource reads order books with GET <url>?symbols=AAPL,MSFT&levels=10. The endpoint
+// returns a JSON array of {"symbol", "bids", "asks", "timestamp"} books, each level being
+// {"price", "quantity", "orders"}.
+type HTTPDepthSource struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPDepthSource(depthURL, apiKey string) *HTTPDepthSource {
+	return &HTTPDepthSource{
+		url:    depthURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *HTTPDepthSource) Depth(ctx context.Context, symbols []string, levels int) ([]Book, error) {
+	query := url.Values{}
+	query.Set("symbols", strings.Join(symbols, ","))
+	query.Set("levels", strconv.Itoa(levels))
+
+	body, err := fetch(ctx, s.client, s.url, s.apiKey, query)
+	if err != nil {
+		return nil, fmt.Errorf("market depth: %w", err)
+	}
+
+	var books []Book
+	if err := json.Unmarshal(body, &books); err != nil {
+		return nil, fmt.Errorf("invalid market depth response: %w", err)
+	}
+	now := time.Now()
+	for i := range books {
+		if books[i].Timestamp.IsZero() {
+			books[i].Timestamp = now
+		}
+	}
+	return books, nil
+}
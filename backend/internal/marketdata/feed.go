@@ -0,0 +1,50 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// Feed sources
+const (
+	SourceSimulated = "simulated"
+	SourceHTTP      = "http"
+	SourceNone      = "none"
+)
+
+// Quote is one price observation from a feed
+type Quote struct {
+	Symbol    string    `json:"symbol"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Holdings returns the symbols held in positions and the portfolio benchmarks with their last known
+// price, zero if none
+type Holdings func() (map[string]float64, error)
+
+// Feed streams quotes until ctx is cancelled or the feed fails
+type Feed interface {
+	Name() string
+	Run(ctx context.Context, out chan<- Quote) error
+}
+
+// NewFeed builds the feed selected by the configuration; it returns nil for the "none" source
+func NewFeed(cfg *config.PriceFeedConfig, holdings Holdings) (Feed, error) {
+	switch cfg.Source {
+	case SourceNone, "":
+		return nil, nil
+	case SourceSimulated:
+		return NewSimulatedFeed(cfg.PollInterval, holdings), nil
+	case SourceHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("PRICE_FEED_URL is required for the %s price feed", SourceHTTP)
+		}
+		return NewHTTPFeed(cfg.URL, cfg.APIKey, cfg.PollInterval, cfg.Symbols, holdings), nil
+	default:
+		return nil, fmt.Errorf("unknown price feed source %q", cfg.Source)
+	}
+}
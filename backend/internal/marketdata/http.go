@@ -0,0 +1,174 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// maxQuoteResponseSize caps a single poll response, of quotes or order books
+const maxQuoteResponseSize = 4 << 20
+
+// HTTPFeed polls a quote endpoint with GET <url>?symbols=AAPL,MSFT. The endpoint returns either a
+// JSON array of {"symbol", "price", "timestamp"} quotes or an object mapping symbols to prices.
+type HTTPFeed struct {
+	url      string
+	apiKey   string
+	interval time.Duration
+	symbols  []string
+	holdings Holdings
+	client   *http.Client
+}
+
+func NewHTTPFeed(feedURL, apiKey string, interval time.Duration, symbols []string, holdings Holdings) *HTTPFeed {
+	return &HTTPFeed{
+		url:      feedURL,
+		apiKey:   apiKey,
+		interval: interval,
+		symbols:  symbols,
+		holdings: holdings,
+		client:   &http.Client{Timeout: interval + 5*time.Second},
+	}
+}
+
+func (f *HTTPFeed) Name() string {
+	return SourceHTTP
+}
+
+func (f *HTTPFeed) Run(ctx context.Context, out chan<- Quote) error {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			symbols, err := f.currentSymbols()
+			if err != nil {
+				logging.Component("marketdata").Warn("Failed to load symbols for price feed", "error", err)
+				continue
+			}
+			if len(symbols) == 0 {
+				continue
+			}
+
+			quotes, err := f.poll(ctx, symbols)
+			if err != nil {
+				// A failed poll is retried on the next tick
+				logging.Component("marketdata").Warn("Price feed poll failed", "error", err)
+				continue
+			}
+
+			for _, quote := range quotes {
+				select {
+				case out <- quote:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// currentSymbols returns the configured symbols, or the held ones when none are configured
+func (f *HTTPFeed) currentSymbols() ([]string, error) {
+	if len(f.symbols) > 0 || f.holdings == nil {
+		return f.symbols, nil
+	}
+
+	held, err := f.holdings()
+	if err != nil {
+		return nil, err
+	}
+	return sortedSymbols(held), nil
+}
+
+// sortedSymbols returns the symbols of held in order
+func sortedSymbols(held map[string]float64) []string {
+	symbols := make([]string, 0, len(held))
+	for symbol := range held {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (f *HTTPFeed) poll(ctx context.Context, symbols []string) ([]Quote, error) {
+	query := url.Values{}
+	query.Set("symbols", strings.Join(symbols, ","))
+
+	body, err := fetch(ctx, f.client, f.url, f.apiKey, query)
+	if err != nil {
+		return nil, fmt.Errorf("price feed: %w", err)
+	}
+	return decodeQuotes(body, time.Now())
+}
+
+// fetch GETs a JSON endpoint of the feed with query added to its own query string
+func fetch(ctx context.Context, client *http.Client, rawURL, apiKey string, query url.Values) ([]byte, error) {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	params := endpoint.Query()
+	for key, values := range query {
+		params[key] = values
+	}
+	endpoint.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxQuoteResponseSize))
+}
+
+// decodeQuotes accepts a quote array or a symbol-to-price object; quotes without a timestamp are
+// stamped with receivedAt
+func decodeQuotes(body []byte, receivedAt time.Time) ([]Quote, error) {
+	var quotes []Quote
+	if err := json.Unmarshal(body, &quotes); err != nil {
+		var prices map[string]float64
+		if err := json.Unmarshal(body, &prices); err != nil {
+			return nil, fmt.Errorf("invalid price feed response: %w", err)
+		}
+		for symbol, price := range prices {
+			quotes = append(quotes, Quote{Symbol: symbol, Price: price})
+		}
+	}
+
+	valid := quotes[:0]
+	for _, quote := range quotes {
+		if quote.Symbol == "" || quote.Price <= 0 {
+			continue
+		}
+		if quote.Timestamp.IsZero() {
+			quote.Timestamp = receivedAt
+		}
+		valid = append(valid, quote)
+	}
+	return valid, nil
+}
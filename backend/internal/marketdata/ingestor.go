@@ -0,0 +1,370 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/cache"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+const (
+	minRestartDelay = 5 * time.Second
+	maxRestartDelay = time.Minute
+)
+
+// Ingestor consumes a price feed and, once per batch interval, writes the latest prices to Redis,
+// publishes them for WebSocket clients, revalues the positions holding them, checks the drift of
+// their portfolios' target allocations, invalidates the cached risk of portfolios whose holdings
+// moved and queues those that moved sharply for an intraday risk recalculation. Redis holds the
+// authoritative latest price of each symbol. It also snapshots the order books of the same symbols
+// when the feed has them.
+type Ingestor struct {
+	feed          Feed
+	depth         DepthSource
+	depthLevels   int
+	symbols       []string
+	redisClient   *redis.Client
+	db            *gorm.DB
+	pnlService    *services.PnLService
+	performance   *services.PerformanceService
+	crypto        *services.CryptoRiskService
+	allocation    *services.AllocationService
+	marketDepth   *services.MarketDepthService
+	batchInterval time.Duration
+	priceTTL      time.Duration
+	logger        *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string]Quote
+	last    map[string]Quote
+
+	riskMarks map[string]float64 // Price of each symbol when the risk of its holders was last invalidated
+}
+
+// NewIngestor creates an ingestor for the configured feed; it returns nil when the source is "none"
+func NewIngestor(cfg *config.PriceFeedConfig) (*Ingestor, error) {
+	ingestor := &Ingestor{
+		redisClient:   database.GetRedis(),
+		db:            database.GetDB(),
+		pnlService:    services.NewPnLService(),
+		performance:   services.NewPerformanceService(),
+		crypto:        services.NewCryptoRiskService(),
+		allocation:    services.NewAllocationService(),
+		marketDepth:   services.NewMarketDepthService(),
+		depthLevels:   cfg.DepthLevels,
+		symbols:       cfg.Symbols,
+		batchInterval: cfg.BatchInterval,
+		priceTTL:      cfg.PriceTTL,
+		logger:        logging.Component("marketdata"),
+		pending:       make(map[string]Quote),
+		last:          make(map[string]Quote),
+		riskMarks:     make(map[string]float64),
+	}
+
+	feed, err := NewFeed(cfg, ingestor.Holdings)
+	if err != nil || feed == nil {
+		return nil, err
+	}
+	ingestor.feed = feed
+	ingestor.depth = NewDepthSource(cfg, feed)
+	return ingestor, nil
+}
+
+// Run ingests prices until ctx is cancelled, restarting the feed with backoff when it fails
+func (i *Ingestor) Run(ctx context.Context) {
+	i.logger.Info("Starting price feed ingestion", "feed", i.feed.Name())
+
+	quotes := make(chan Quote, 1024)
+	go i.runFeed(ctx, quotes)
+
+	ticker := time.NewTicker(i.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case quote := <-quotes:
+			i.add(quote)
+		case <-ticker.C:
+			i.flush(ctx)
+		}
+	}
+}
+
+func (i *Ingestor) runFeed(ctx context.Context, quotes chan<- Quote) {
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := i.feed.Run(ctx, quotes)
+		if ctx.Err() != nil {
+			return
+		}
+		i.logger.Warn("Price feed stopped, restarting", "feed", i.feed.Name(), "error", err, "delay", delay.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		// Back off while the feed keeps failing quickly
+		if time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		} else {
+			delay *= 2
+			if delay > maxRestartDelay {
+				delay = maxRestartDelay
+			}
+		}
+	}
+}
+
+// add keeps the newest quote per symbol for the next batch, dropping out-of-order quotes
+func (i *Ingestor) add(quote Quote) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if last, ok := i.last[quote.Symbol]; ok && quote.Timestamp.Before(last.Timestamp) {
+		return
+	}
+	if pending, ok := i.pending[quote.Symbol]; ok && quote.Timestamp.Before(pending.Timestamp) {
+		return
+	}
+	i.pending[quote.Symbol] = quote
+}
+
+// flush writes the pending batch to Redis, publishes it, revalues positions, records benchmark
+// closes, invalidates cached risk after large moves and queues intraday risk recalculations
+func (i *Ingestor) flush(ctx context.Context) {
+	i.mu.Lock()
+	if len(i.pending) == 0 {
+		i.mu.Unlock()
+		return
+	}
+	batch := i.pending
+	i.pending = make(map[string]Quote, len(batch))
+
+	prices := make(map[string]float64, len(batch))
+	updates := make(map[string]interface{}, len(batch))
+	for symbol, quote := range batch {
+		change := 0.0
+		if last, ok := i.last[symbol]; ok && last.Price > 0 {
+			change = (quote.Price/last.Price - 1) * 100
+		}
+		i.last[symbol] = quote
+
+		prices[symbol] = quote.Price
+		updates[symbol] = map[string]interface{}{
+			"price":     quote.Price,
+			"change":    change,
+			"timestamp": quote.Timestamp.Unix(),
+		}
+	}
+	i.mu.Unlock()
+
+	_, err := i.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for symbol, price := range prices {
+			pipe.Set(ctx, database.PriceKey(symbol), price, i.priceTTL)
+		}
+		return nil
+	})
+	// While the Redis circuit is open the outage has already been logged once
+	if err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+		i.logger.Warn("Failed to store prices in Redis", "error", err)
+	}
+
+	// Every instance relays the batch to its WebSocket clients through the Redis bridge
+	if payload, err := json.Marshal(updates); err == nil {
+		if err := i.redisClient.Publish(ctx, database.PricesChannel, payload).Err(); err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+			i.logger.Warn("Failed to publish price updates", "error", err)
+		}
+	}
+
+	if err := i.pnlService.ApplyPrices(prices); err != nil {
+		i.logger.Warn("Failed to apply price updates to positions", "error", err)
+	}
+	if err := i.performance.ApplyPrices(prices); err != nil {
+		i.logger.Warn("Failed to record benchmark prices", "error", err)
+	}
+	if err := i.crypto.RecordPrices(prices); err != nil {
+		i.logger.Warn("Failed to record crypto price samples", "error", err)
+	}
+	if err := i.allocation.ApplyPrices(prices); err != nil {
+		i.logger.Warn("Failed to check allocation drift", "error", err)
+	}
+	if err := i.invalidateRisk(ctx, prices); err != nil {
+		i.logger.Warn("Failed to invalidate cached portfolio risk", "error", err)
+	}
+	if err := services.QueuePriceMoves(ctx, prices); err != nil {
+		i.logger.Warn("Failed to queue intraday risk recalculations", "error", err)
+	}
+}
+
+// invalidateRisk drops the cached VaR and liquidity of the portfolios holding a symbol whose price
+// has moved by CACHE_RISK_PRICE_MOVE percent or more since their risk was last invalidated for it.
+// A symbol's first price after startup invalidates its holders, since their cached risk may have
+// been calculated at any price.
+func (i *Ingestor) invalidateRisk(ctx context.Context, prices map[string]float64) error {
+	var moved []string
+	for symbol, price := range prices {
+		mark, ok := i.riskMarks[symbol]
+		if ok && mark > 0 && math.Abs(price/mark-1)*100 < cache.RiskPriceMove() {
+			continue
+		}
+		i.riskMarks[symbol] = price
+		moved = append(moved, symbol)
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	var portfolioIDs []uuid.UUID
+	err := i.db.WithContext(ctx).Model(&models.Position{}).
+		Where("symbol IN ?", moved).
+		Distinct().Pluck("portfolio_id", &portfolioIDs).Error
+	if err != nil {
+		return err
+	}
+	cache.InvalidatePortfolioRisk(ctx, portfolioIDs...)
+	return nil
+}
+
+// CaptureDepth stores a snapshot of the order book of each configured, or else held, symbol at a
+// fixed interval until ctx is cancelled. It does nothing when the feed has no order books.
+func (i *Ingestor) CaptureDepth(ctx context.Context, interval time.Duration) {
+	if i.depth == nil || interval <= 0 || i.depthLevels <= 0 {
+		i.logger.Info("Order book snapshots disabled", "feed", i.feed.Name())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if err := i.captureDepth(ctx); err != nil {
+			i.logger.WarnContext(ctx, "Order book snapshot failed", "error", err)
+		}
+	}
+}
+
+func (i *Ingestor) captureDepth(ctx context.Context) error {
+	symbols := i.symbols
+	if len(symbols) == 0 {
+		held, err := i.Holdings()
+		if err != nil {
+			return err
+		}
+		symbols = sortedSymbols(held)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	books, err := i.depth.Depth(ctx, symbols, i.depthLevels)
+	if err != nil {
+		return err
+	}
+	snapshots := make([]models.MarketDepthSnapshot, 0, len(books))
+	for _, book := range books {
+		snapshots = append(snapshots, models.MarketDepthSnapshot{
+			Symbol:     book.Symbol,
+			Source:     i.feed.Name(),
+			Bids:       book.Bids,
+			Asks:       book.Asks,
+			CapturedAt: book.Timestamp,
+		})
+	}
+
+	stored, err := i.marketDepth.RecordSnapshots(ctx, snapshots, i.depthLevels)
+	if err != nil {
+		return err
+	}
+	i.logger.DebugContext(ctx, "Captured order book snapshots", "symbols", len(symbols), "stored", stored)
+	return nil
+}
+
+// Holdings returns the held symbols and portfolio benchmarks priced from Redis, falling back to the
+// positions' last price and the benchmarks' last close
+func (i *Ingestor) Holdings() (map[string]float64, error) {
+	var rows []struct {
+		Symbol string
+		Price  float64
+	}
+	err := i.db.Raw(`SELECT symbol, COALESCE(MAX(current_price), 0) AS price
+		FROM positions WHERE quantity <> 0 AND deleted_at IS NULL GROUP BY symbol`).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := i.performance.BenchmarkPrices()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		held[row.Symbol] = row.Price
+	}
+	symbols := make([]string, 0, len(held))
+	for symbol := range held {
+		symbols = append(symbols, symbol)
+	}
+
+	latest, err := LatestPrices(context.Background(), i.redisClient, symbols)
+	if err != nil {
+		return held, nil
+	}
+	for symbol, price := range latest {
+		held[symbol] = price
+	}
+	return held, nil
+}
+
+// LatestPrices reads the latest stored prices of the given symbols; symbols without a price are omitted
+func LatestPrices(ctx context.Context, client *redis.Client, symbols []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(symbols))
+	if len(symbols) == 0 {
+		return prices, nil
+	}
+
+	keys := make([]string, len(symbols))
+	for idx, symbol := range symbols {
+		keys[idx] = database.PriceKey(symbol)
+	}
+
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for idx, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if price, err := strconv.ParseFloat(str, 64); err == nil && price > 0 {
+			prices[symbols[idx]] = price
+		}
+	}
+	return prices, nil
+}
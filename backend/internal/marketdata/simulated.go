@@ -0,0 +1,171 @@
+package marketdata
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// defaultPrices seeds the simulated feed for the demo symbols
+var defaultPrices = map[string]float64{
+	"AAPL":   150.00,
+	"GOOGL":  2800.00,
+	"MSFT":   300.00,
+	"AMZN":   3300.00,
+	"TSLA":   800.00,
+	"JPM":    140.00,
+	"BAC":    35.00,
+	"GS":     350.00,
+	"MS":     90.00,
+	"WFC":    45.00,
+	"BTC":    45000.00,
+	"ETH":    3000.00,
+	"GOLD":   1800.00,
+	"SILVER": 25.00,
+	"OIL":    75.00,
+}
+
+// Simulated order books quote a tight spread around the current price, with levels further out
+// holding more size
+const (
+	simulatedHalfSpread = 0.0005 // Of the price
+	simulatedLevelStep  = 0.0002 // Between levels, of the price
+	simulatedLevelValue = 250000 // Average value resting at the best level
+)
+
+// SimulatedFeed random-walks prices around a base for development and demos, and books simulated
+// order depth around them
+type SimulatedFeed struct {
+	interval time.Duration
+	holdings Holdings
+	base     map[string]float64
+
+	mu     sync.Mutex // Guards prices, which Depth reads while Run walks them
+	prices map[string]float64
+}
+
+func NewSimulatedFeed(interval time.Duration, holdings Holdings) *SimulatedFeed {
+	feed := &SimulatedFeed{
+		interval: interval,
+		holdings: holdings,
+		base:     make(map[string]float64, len(defaultPrices)),
+		prices:   make(map[string]float64, len(defaultPrices)),
+	}
+	for symbol, price := range defaultPrices {
+		feed.base[symbol] = price
+		feed.prices[symbol] = price
+	}
+	return feed
+}
+
+func (f *SimulatedFeed) Name() string {
+	return SourceSimulated
+}
+
+func (f *SimulatedFeed) Run(ctx context.Context, out chan<- Quote) error {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			f.addHeldSymbols()
+
+			for _, quote := range f.walk() {
+				select {
+				case out <- quote:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// walk moves every simulated price one step and returns the new quotes
+func (f *SimulatedFeed) walk() []Quote {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	quotes := make([]Quote, 0, len(f.prices))
+	for symbol, price := range f.prices {
+		// Random walk of up to ±1%, kept within 10% of the base price
+		newPrice := price * (1 + (rand.Float64()-0.5)*0.02)
+		base := f.base[symbol]
+		if newPrice > base*1.1 {
+			newPrice = base * 1.09
+		} else if newPrice < base*0.9 {
+			newPrice = base * 0.91
+		}
+		f.prices[symbol] = newPrice
+		quotes = append(quotes, Quote{Symbol: symbol, Price: newPrice, Timestamp: now})
+	}
+	return quotes
+}
+
+// Depth books levels a side around the current simulated price of each symbol; symbols without
+// one are skipped
+func (f *SimulatedFeed) Depth(ctx context.Context, symbols []string, levels int) ([]Book, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	books := make([]Book, 0, len(symbols))
+	for _, symbol := range symbols {
+		price, ok := f.prices[symbol]
+		if !ok {
+			continue
+		}
+
+		book := Book{
+			Symbol:    symbol,
+			Bids:      make([]models.DepthLevel, levels),
+			Asks:      make([]models.DepthLevel, levels),
+			Timestamp: now,
+		}
+		for level := 0; level < levels; level++ {
+			offset := simulatedHalfSpread + float64(level)*simulatedLevelStep
+			size := simulatedLevelValue * (1 + float64(level)*0.25) / price
+			book.Bids[level] = models.DepthLevel{
+				Price:    price * (1 - offset),
+				Quantity: size * (0.5 + rand.Float64()),
+				Orders:   1 + rand.Intn(20),
+			}
+			book.Asks[level] = models.DepthLevel{
+				Price:    price * (1 + offset),
+				Quantity: size * (0.5 + rand.Float64()),
+				Orders:   1 + rand.Intn(20),
+			}
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// addHeldSymbols starts quoting held symbols that have no simulated price yet from their last
+// known price; symbols that were never priced are skipped
+func (f *SimulatedFeed) addHeldSymbols() {
+	if f.holdings == nil {
+		return
+	}
+	held, err := f.holdings()
+	if err != nil {
+		logging.Component("marketdata").Warn("Failed to load holdings for simulated price feed", "error", err)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for symbol, price := range held {
+		if _, ok := f.prices[symbol]; !ok && price > 0 {
+			f.base[symbol] = price
+			f.prices[symbol] = price
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"io"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+)
+
+// namespace prefixes every metric exported by the API
+const namespace = "riskmonitor_"
+
+// HubGateway is the hub label of the WebSocket gateway's metrics
+const HubGateway = "gateway"
+
+var registry = NewRegistry()
+
+var (
+	HTTPRequests = registry.NewCounterVec(namespace+"http_requests_total",
+		"HTTP requests by method, route and status code.", "method", "route", "status")
+	HTTPRequestDuration = registry.NewHistogramVec(namespace+"http_request_duration_seconds",
+		"HTTP request latency by method and route.", DefaultDurationBuckets, "method", "route")
+
+	WebSocketConnections = registry.NewGaugeVec(namespace+"websocket_connections",
+		"Open WebSocket connections by hub.", "hub")
+	WebSocketMessagesDropped = registry.NewCounterVec(namespace+"websocket_messages_dropped_total",
+		"WebSocket messages dropped because a broadcast queue was full.", "hub")
+
+	AlertsCreated = registry.NewCounterVec(namespace+"alerts_created_total",
+		"Alerts created by type and severity.", "alert_type", "severity")
+	AlertsSuppressed = registry.NewCounterVec(namespace+"alerts_suppressed_total",
+		"Alerts raised inside a suppression window, by type and severity.", "alert_type", "severity")
+
+	RiskCalculationDuration = registry.NewHistogramVec(namespace+"risk_calculation_duration_seconds",
+		"Duration of risk calculations by calculation.", DefaultDurationBuckets, "calculation")
+	TradeEvaluations = registry.NewCounterVec(namespace+"risk_trade_evaluations_total",
+		"Pre-trade risk evaluations by outcome.", "outcome")
+
+	BatchJobRuns = registry.NewCounterVec(namespace+"batch_job_runs_total",
+		"Batch jobs finished by pipeline, job and status (SUCCEEDED, FAILED, SKIPPED).", "pipeline", "job", "status")
+
+	CacheRequests = registry.NewCounterVec(namespace+"cache_requests_total",
+		"Read-through cache lookups by namespace and result (hit, miss, refresh).", "namespace", "result")
+)
+
+func init() {
+	registerPostgresStats()
+	registerRedisStats()
+}
+
+// WriteText writes every metric in the Prometheus text exposition format
+func WriteText(w io.Writer) error {
+	return registry.WriteText(w)
+}
+
+func registerPostgresStats() {
+	stat := func(read func(open, inUse, idle, waitCount int64, waitSeconds float64) float64) func() (float64, bool) {
+		return func() (float64, bool) {
+			db := database.GetDB()
+			if db == nil {
+				return 0, false
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return 0, false
+			}
+			s := sqlDB.Stats()
+			return read(int64(s.OpenConnections), int64(s.InUse), int64(s.Idle), s.WaitCount, s.WaitDuration.Seconds()), true
+		}
+	}
+
+	registry.NewGaugeFunc(namespace+"db_open_connections", "Open PostgreSQL connections.",
+		stat(func(open, _, _, _ int64, _ float64) float64 { return float64(open) }))
+	registry.NewGaugeFunc(namespace+"db_in_use_connections", "PostgreSQL connections in use.",
+		stat(func(_, inUse, _, _ int64, _ float64) float64 { return float64(inUse) }))
+	registry.NewGaugeFunc(namespace+"db_idle_connections", "Idle PostgreSQL connections.",
+		stat(func(_, _, idle, _ int64, _ float64) float64 { return float64(idle) }))
+	registry.NewCounterFunc(namespace+"db_wait_count_total", "Times a query waited for a free PostgreSQL connection.",
+		stat(func(_, _, _, waitCount int64, _ float64) float64 { return float64(waitCount) }))
+	registry.NewCounterFunc(namespace+"db_wait_duration_seconds_total", "Time spent waiting for a free PostgreSQL connection.",
+		stat(func(_, _, _, _ int64, waitSeconds float64) float64 { return waitSeconds }))
+}
+
+func registerRedisStats() {
+	stat := func(read func(total, idle, hits, misses, timeouts uint32) float64) func() (float64, bool) {
+		return func() (float64, bool) {
+			client := database.GetRedis()
+			if client == nil {
+				return 0, false
+			}
+			s := client.PoolStats()
+			return read(s.TotalConns, s.IdleConns, s.Hits, s.Misses, s.Timeouts), true
+		}
+	}
+
+	registry.NewGaugeFunc(namespace+"redis_up", "Whether commands are being sent to Redis (1) or the circuit breaker is open (0).",
+		func() (float64, bool) {
+			if database.GetRedis() == nil {
+				return 0, false
+			}
+			if database.RedisAvailable() {
+				return 1, true
+			}
+			return 0, true
+		})
+	registry.NewGaugeFunc(namespace+"redis_pool_connections", "Connections in the Redis pool.",
+		stat(func(total, _, _, _, _ uint32) float64 { return float64(total) }))
+	registry.NewGaugeFunc(namespace+"redis_pool_idle_connections", "Idle connections in the Redis pool.",
+		stat(func(_, idle, _, _, _ uint32) float64 { return float64(idle) }))
+	registry.NewCounterFunc(namespace+"redis_pool_hits_total", "Times a free connection was found in the Redis pool.",
+		stat(func(_, _, hits, _, _ uint32) float64 { return float64(hits) }))
+	registry.NewCounterFunc(namespace+"redis_pool_misses_total", "Times no free connection was found in the Redis pool.",
+		stat(func(_, _, _, misses, _ uint32) float64 { return float64(misses) }))
+	registry.NewCounterFunc(namespace+"redis_pool_timeouts_total", "Times waiting for a Redis pool connection timed out.",
+		stat(func(_, _, _, _, timeouts uint32) float64 { return float64(timeouts) }))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// AuditRecordedKey is set in Locals by handlers that write their own, more detailed audit entry
+const AuditRecordedKey = "audit_recorded"
+
+// AuditActor builds the audit actor from the authenticated request
+func AuditActor(c *fiber.Ctx) services.AuditActor {
+	actor := services.AuditActor{
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}
+
+	if userID, ok := c.Locals("user_id").(string); ok {
+		if id, err := uuid.Parse(userID); err == nil {
+			actor.UserID = &id
+		}
+	}
+	actor.Email, _ = c.Locals("email").(string)
+	actor.Role, _ = c.Locals("role").(string)
+
+	return actor
+}
+
+// AuditTrail records every successful state-changing request that a handler did not audit itself
+func AuditTrail(auditService *services.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil || status >= 400 {
+			return err
+		}
+		if recorded, _ := c.Locals(AuditRecordedKey).(bool); recorded {
+			return err
+		}
+
+		after := map[string]interface{}{
+			"method": c.Method(),
+			"path":   c.Path(),
+			"status": status,
+		}
+		action := "request." + strings.ToLower(c.Method())
+		if auditErr := auditService.Record(AuditActor(c), action, "request", c.Path(), nil, after); auditErr != nil {
+			logging.Component("audit").ErrorContext(c.UserContext(), "Failed to record audit entry",
+				"method", c.Method(), "path", c.Path(), "error", auditErr)
+		}
+
+		return err
+	}
+}
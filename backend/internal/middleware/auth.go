@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
 	"github.com/Taf0711/financial-risk-monitor/internal/services"
 )
 
@@ -40,6 +44,135 @@ func JWTMiddleware(authService *services.AuthService) fiber.Handler {
 		c.Locals("user_id", (*claims)["user_id"])
 		c.Locals("email", (*claims)["email"])
 		c.Locals("role", (*claims)["role"])
+		c.Locals("jti", (*claims)["jti"])
+		c.Locals("token_exp", (*claims)["exp"])
+
+		return c.Next()
+	}
+}
+
+// APIKeyHeader carries the API key of a service-to-service request
+const APIKeyHeader = "X-API-Key"
+
+// Locals keys set for requests authenticated with an API key
+const (
+	APIKeyIDKey     = "api_key_id"
+	apiKeyScopesKey = "api_key_scopes"
+)
+
+// Authenticate accepts either an X-API-Key header or a Bearer token. A request with an API key is
+// authenticated by the key alone, even if it also carries a token.
+func Authenticate(authService *services.AuthService, apiKeyService *services.APIKeyService) fiber.Handler {
+	jwtAuth := JWTMiddleware(authService)
+	apiKeyAuth := APIKeyMiddleware(apiKeyService)
+
+	return func(c *fiber.Ctx) error {
+		if c.Get(APIKeyHeader) != "" {
+			return apiKeyAuth(c)
+		}
+		return jwtAuth(c)
+	}
+}
+
+// APIKeyMiddleware authenticates requests by X-API-Key and enforces the key's rate limit. The
+// request acts as the key's owner, limited to the key's scopes.
+func APIKeyMiddleware(apiKeyService *services.APIKeyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		key, err := apiKeyService.Authenticate(ctx, c.Get(APIKeyHeader))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Invalid or expired API key",
+				})
+			}
+			logging.Component("api_key").ErrorContext(ctx, "Failed to authenticate API key", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to authenticate API key",
+			})
+		}
+
+		limit, err := apiKeyService.Allow(ctx, key)
+		if err != nil {
+			logging.Component("api_key").ErrorContext(ctx, "Failed to check API key rate limit", "api_key_id", key.ID, "error", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Rate limit unavailable",
+			})
+		}
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+		if !limit.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(limit.RetryAfter.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "API key rate limit exceeded",
+			})
+		}
+
+		scopes := make(map[Permission]bool, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes[Permission(scope)] = true
+		}
+
+		c.Locals("user_id", key.UserID.String())
+		c.Locals("email", key.User.Email)
+		c.Locals("role", key.User.Role)
+		c.Locals(APIKeyIDKey, key.ID.String())
+		c.Locals(apiKeyScopesKey, scopes)
+
+		return c.Next()
+	}
+}
+
+// RoleMiddleware only allows requests from users holding one of the given roles
+func RoleMiddleware(roles ...string) fiber.Handler {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !allowed[role] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Insufficient permissions",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// AdminMiddleware only allows requests from administrators
+func AdminMiddleware() fiber.Handler {
+	return RoleMiddleware("admin")
+}
+
+// WebSocketAuthMiddleware authenticates WebSocket upgrade requests. Browsers cannot set
+// headers on the upgrade request, so the JWT may also be passed as the "token" query param.
+func WebSocketAuthMiddleware(authService *services.AuthService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			tokenString = strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		}
+
+		if tokenString == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing authentication token",
+			})
+		}
+
+		claims, err := authService.ValidateToken(tokenString)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired token",
+			})
+		}
+
+		// Store user info for the WebSocket handler
+		c.Locals("user_id", (*claims)["user_id"])
+		c.Locals("email", (*claims)["email"])
+		c.Locals("role", (*claims)["role"])
 
 		return c.Next()
 	}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// ErrorResponse is the body of every error response: the message, a machine-readable code, any
+// details such as the fields that failed validation, and the request ID to quote to support
+type ErrorResponse struct {
+	Error     string        `json:"error"`
+	Code      apperror.Code `json:"code"`
+	Details   interface{}   `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// ErrorHandler is the Fiber error handler. It reports errors returned by handlers, and panics
+// recovered from them, in the error envelope with the status and code of their apperror type;
+// internal errors are logged and answered with a generic message.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr := apperror.From(err)
+	if appErr.Status >= fiber.StatusInternalServerError && appErr.Err != nil {
+		logging.Component("http").ErrorContext(c.UserContext(), "Request failed", "path", c.Path(), "error", appErr.Err)
+	}
+
+	requestID, _ := c.Locals(RequestIDKey).(string)
+	return c.Status(appErr.Status).JSON(ErrorResponse{
+		Error:     appErr.Message,
+		Code:      appErr.Code,
+		Details:   appErr.Details,
+		RequestID: requestID,
+	})
+}
+
+// ErrorEnvelope brings the {"error": "..."} responses handlers write themselves into the error
+// envelope, adding the code for their status and the request ID
+func ErrorEnvelope() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusBadRequest ||
+			!strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(c.Response().Body(), &body); err != nil {
+			return nil
+		}
+		if _, ok := body["error"].(string); !ok {
+			return nil
+		}
+		if _, ok := body["code"]; ok {
+			return nil
+		}
+
+		body["code"] = apperror.CodeForStatus(status)
+		if requestID, ok := c.Locals(RequestIDKey).(string); ok {
+			body["request_id"] = requestID
+		}
+		return c.JSON(body)
+	}
+}
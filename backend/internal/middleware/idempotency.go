@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a retried request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	idempotencyLockTTL      = time.Minute
+)
+
+// idempotentResponse is the stored result of a request. An entry without a status is a request
+// still in progress.
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency makes a route safe to retry. When a request carries an Idempotency-Key header, the
+// first response is stored in Redis for the window and later requests from the same user with the
+// same key get that response back instead of running the handler again. Reusing a key with a
+// different body is rejected. Server errors are not stored, so the client can retry them. If
+// Redis is unavailable requests run normally.
+func Idempotency(client *redis.Client, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key must be at most 255 characters",
+			})
+		}
+
+		userID, _ := c.Locals("user_id").(string)
+		redisKey := "idempotency:" + userID + ":" + c.Method() + ":" + c.Path() + ":" + key
+		sum := sha256.Sum256(c.Body())
+		fingerprint := hex.EncodeToString(sum[:])
+
+		ctx := c.UserContext()
+		pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+		acquired, err := client.SetNX(ctx, redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			if !errors.Is(err, database.ErrRedisUnavailable) {
+				logging.Component("idempotency").WarnContext(ctx, "Idempotency check unavailable", "path", c.Path(), "error", err)
+			}
+			return c.Next()
+		}
+
+		if !acquired {
+			return replayIdempotent(c, client, redisKey, fingerprint)
+		}
+
+		if err := c.Next(); err != nil {
+			client.Del(ctx, redisKey)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			client.Del(ctx, redisKey)
+			return nil
+		}
+
+		stored, _ := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		})
+		if err := client.Set(ctx, redisKey, stored, window).Err(); err != nil {
+			logging.Component("idempotency").WarnContext(ctx, "Failed to store idempotent response", "path", c.Path(), "error", err)
+		}
+		return nil
+	}
+}
+
+// replayIdempotent answers a request whose key was already used
+func replayIdempotent(c *fiber.Ctx, client *redis.Client, redisKey, fingerprint string) error {
+	raw, err := client.Get(c.UserContext(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The earlier request failed and released the key between our checks
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A request with this Idempotency-Key just finished; retry it",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Idempotency check unavailable",
+		})
+	}
+
+	var previous idempotentResponse
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid stored idempotent response",
+		})
+	}
+
+	if previous.Fingerprint != fingerprint {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Idempotency-Key was already used with a different request body",
+		})
+	}
+	if previous.Status == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A request with this Idempotency-Key is still being processed",
+		})
+	}
+
+	// A replay changes nothing, so the audit trail should not record it again
+	c.Locals(AuditRecordedKey, true)
+	c.Set(IdempotentReplayedHeader, "true")
+	if previous.ContentType != "" {
+		c.Set(fiber.HeaderContentType, previous.ContentType)
+	}
+	return c.Status(previous.Status).Send(previous.Body)
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners cannot inflate the series count
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of requests by route pattern rather than path, so
+// /portfolios/:id is one series however many portfolios are requested
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			// The error handler has not set the status yet
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+				if fiberErr.Code == fiber.StatusNotFound {
+					route = unmatchedRoute
+				}
+			}
+		}
+
+		metrics.HTTPRequests.With(c.Method(), route, strconv.Itoa(status)).Inc()
+		metrics.HTTPRequestDuration.With(c.Method(), route).ObserveSince(start)
+
+		return err
+	}
+}
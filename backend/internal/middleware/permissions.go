@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// Permission is an action a role may perform
+type Permission string
+
+const (
+	PermPortfolioRead      Permission = "portfolio:read"
+	PermPortfolioWrite     Permission = "portfolio:write"
+	PermPortfolioAssign    Permission = "portfolio:assign" // Manage portfolio supervisors
+	PermTransactionRead    Permission = "transaction:read"
+	PermTransactionWrite   Permission = "transaction:write"
+	PermTransactionApprove Permission = "transaction:approve" // Change transaction status
+	PermTransactionDelete  Permission = "transaction:delete"
+	PermRiskRead           Permission = "risk:read"
+	PermLiquidityManage    Permission = "liquidity:manage" // Symbol market data, benchmark prices, bond reference data, corporate actions and position liquidity overrides
+	PermAlertRead          Permission = "alert:read"
+	PermAlertManage        Permission = "alert:manage" // Acknowledge and resolve
+	PermAlertDelete        Permission = "alert:delete"
+	PermEscalationManage   Permission = "escalation:manage" // Teams, on-call rotas and escalation policies
+	PermComplianceRead     Permission = "compliance:read"
+	PermComplianceScreen   Permission = "compliance:screen" // Run AML checks and screenings
+	PermComplianceManage   Permission = "compliance:manage" // Sanctions lists and rules
+	PermNotificationManage Permission = "notification:manage"
+	PermUserManage         Permission = "user:manage"
+	PermAuditRead          Permission = "audit:read"
+	PermCaseEscalate       Permission = "case:escalate" // Open SAR cases from alerts and transactions
+	PermCaseRead           Permission = "case:read"
+	PermCaseManage         Permission = "case:manage" // Investigate, assign, file and dismiss
+	PermReportRead         Permission = "report:read"
+	PermReportGenerate     Permission = "report:generate"
+	PermSystemManage       Permission = "system:manage" // Runtime settings such as the log level
+	PermAPIKeyManage       Permission = "api_key:manage"
+	PermRecordsRestore     Permission = "records:restore" // List and restore soft deleted records
+)
+
+// allPermissions is every permission, the scopes an API key may be given
+var allPermissions = permissionSet(
+	PermPortfolioRead, PermPortfolioWrite, PermPortfolioAssign,
+	PermTransactionRead, PermTransactionWrite, PermTransactionApprove, PermTransactionDelete,
+	PermRiskRead, PermLiquidityManage,
+	PermAlertRead, PermAlertManage, PermAlertDelete, PermEscalationManage,
+	PermComplianceRead, PermComplianceScreen, PermComplianceManage,
+	PermNotificationManage,
+	PermUserManage,
+	PermAuditRead,
+	PermCaseEscalate, PermCaseRead, PermCaseManage,
+	PermReportRead, PermReportGenerate,
+	PermSystemManage,
+	PermAPIKeyManage,
+	PermRecordsRestore,
+)
+
+var rolePermissions = map[string]map[Permission]bool{
+	models.RoleAnalyst: permissionSet(
+		PermPortfolioRead, PermPortfolioWrite,
+		PermTransactionRead, PermTransactionWrite,
+		PermRiskRead,
+		PermAlertRead, PermAlertManage,
+		PermComplianceRead,
+		PermCaseEscalate,
+		PermReportRead, PermReportGenerate,
+	),
+	models.RoleTrader: permissionSet(
+		PermPortfolioRead, PermPortfolioWrite,
+		PermTransactionRead, PermTransactionWrite,
+		PermRiskRead,
+		PermAlertRead,
+		PermComplianceRead,
+		PermReportRead,
+	),
+	models.RoleComplianceOfficer: permissionSet(
+		PermPortfolioRead,
+		PermTransactionRead, PermTransactionApprove,
+		PermRiskRead, PermLiquidityManage,
+		PermAlertRead, PermAlertManage,
+		PermComplianceRead, PermComplianceScreen, PermComplianceManage,
+		PermNotificationManage, PermEscalationManage,
+		PermAuditRead,
+		PermCaseEscalate, PermCaseRead, PermCaseManage,
+		PermReportRead, PermReportGenerate,
+	),
+}
+
+func permissionSet(perms ...Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(perms))
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set
+}
+
+// HasPermission reports whether a role grants a permission; admins hold every permission
+func HasPermission(role string, perm Permission) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	return rolePermissions[role][perm]
+}
+
+// IsPermission reports whether name is a known permission
+func IsPermission(name string) bool {
+	return allPermissions[Permission(name)]
+}
+
+// Allowed reports whether the request's user has a permission and, for requests made with an API
+// key, whether the key is scoped to it
+func Allowed(c *fiber.Ctx, perm Permission) bool {
+	role, _ := c.Locals("role").(string)
+	if scopes, ok := c.Locals(apiKeyScopesKey).(map[Permission]bool); ok && !scopes[perm] {
+		return false
+	}
+	return HasPermission(role, perm)
+}
+
+// RequirePermission only allows requests from users whose role grants the permission and, for
+// requests made with an API key, whose key is scoped to it
+func RequirePermission(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !Allowed(c, perm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Insufficient permissions",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// PortfolioAccess rejects requests for a portfolio (route param) the user cannot see
+func PortfolioAccess(accessService *services.AccessService, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		portfolioID, err := uuid.Parse(c.Params(param))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid portfolio ID",
+			})
+		}
+
+		return checkPortfolioAccess(c, accessService, portfolioID, "Portfolio not found")
+	}
+}
+
+// PortfolioModify rejects requests to change a portfolio (route param) the user cannot edit: 404 when
+// they cannot see it, 403 when they only view it as a supervisor, team viewer or compliance officer
+func PortfolioModify(accessService *services.AccessService, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		portfolioID, err := uuid.Parse(c.Params(param))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid portfolio ID",
+			})
+		}
+
+		userIDStr, _ := c.Locals("user_id").(string)
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		role, _ := c.Locals("role").(string)
+
+		allowed, err := accessService.CanModifyPortfolio(userID, role, portfolioID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check access",
+			})
+		}
+		if !allowed {
+			visible, err := accessService.CanAccessPortfolio(userID, role, portfolioID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to check access",
+				})
+			}
+			if visible {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Portfolio is read-only for this user",
+				})
+			}
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Portfolio not found",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// TransactionAccess rejects requests for a transaction (route param) in a portfolio the user cannot see
+func TransactionAccess(accessService *services.AccessService, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		transactionID, err := uuid.Parse(c.Params(param))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid transaction ID",
+			})
+		}
+
+		portfolioID, err := accessService.TransactionPortfolioID(transactionID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Transaction not found",
+			})
+		}
+
+		return checkPortfolioAccess(c, accessService, portfolioID, "Transaction not found")
+	}
+}
+
+// AlertAccess rejects requests for an alert (route param) in a portfolio the user cannot see
+func AlertAccess(accessService *services.AccessService, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alertID, err := uuid.Parse(c.Params(param))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid alert ID",
+			})
+		}
+
+		portfolioID, err := accessService.AlertPortfolioID(alertID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Alert not found",
+			})
+		}
+
+		return checkPortfolioAccess(c, accessService, portfolioID, "Alert not found")
+	}
+}
+
+// checkPortfolioAccess responds with notFound rather than 403 so object IDs cannot be probed
+func checkPortfolioAccess(c *fiber.Ctx, accessService *services.AccessService, portfolioID uuid.UUID, notFound string) error {
+	userIDStr, _ := c.Locals("user_id").(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	allowed, err := accessService.CanAccessPortfolio(userID, role, portfolioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check access",
+		})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": notFound,
+		})
+	}
+
+	return c.Next()
+}
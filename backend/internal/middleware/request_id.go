@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+const (
+	// RequestIDHeader carries the ID correlating a request with its log lines, alerts and broadcasts
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the Locals key holding the request ID
+	RequestIDKey = "request_id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID assigns every request an ID, keeping the caller's X-Request-ID when it is usable. The
+// ID is echoed in the response and carried by c.UserContext() so services log and publish it.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Locals(RequestIDKey, requestID)
+		c.Set(RequestIDHeader, requestID)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), requestID))
+
+		return c.Next()
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII, so a caller cannot inject into log lines
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestLogger logs one line per request, at warn level for client errors and error level for
+// server errors
+func RequestLogger() fiber.Handler {
+	logger := logging.Component("http")
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		if err != nil {
+			// Let the error handler set the status before it is logged
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+		}
+		if userID, ok := c.Locals("user_id").(string); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if traceID := tracing.TraceIDFromContext(c.UserContext()); traceID != "" {
+			attrs = append(attrs, "trace_id", traceID)
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		logger.Log(c.UserContext(), level, "Request handled", attrs...)
+
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// CORS lets the configured browser origins call the API with the request headers it reads. With no
+// origins configured no CORS headers are sent, so browsers only allow same-origin calls.
+func CORS(cfg *config.CORSConfig) fiber.Handler {
+	if len(cfg.AllowOrigins) == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowOrigins, ", "),
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Last-Event-ID, " + RequestIDHeader + ", " + tracing.TraceparentHeader + ", " + APIKeyHeader,
+		ExposeHeaders:    RequestIDHeader,
+		AllowMethods:     strings.Join(cfg.AllowMethods, ", "),
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
+
+// SecurityHeaders adds HSTS, Content-Security-Policy and the other configured security headers to
+// every response
+func SecurityHeaders(cfg *config.SecurityHeadersConfig) fiber.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if !cfg.Enabled {
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		if hsts != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		if cfg.ContentSecurityPolicy != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy)
+		}
+		if cfg.FrameOptions != "" {
+			c.Set(fiber.HeaderXFrameOptions, cfg.FrameOptions)
+		}
+		if cfg.ReferrerPolicy != "" {
+			c.Set(fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy)
+		}
+		return c.Next()
+	}
+}
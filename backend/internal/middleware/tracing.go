@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// Tracing records a server span per request, continuing the caller's trace when a W3C traceparent
+// header is sent. The span is carried by c.UserContext(), so GORM, Redis and service spans started
+// from it join the request trace. Requests are named by route pattern, as in the metrics.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !tracing.Enabled() {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		if parent, ok := tracing.ParseTraceparent(c.Get(tracing.TraceparentHeader)); ok {
+			ctx = tracing.ContextWithRemote(ctx, parent)
+		}
+		ctx, span := tracing.StartKind(ctx, c.Method(), tracing.KindServer,
+			tracing.String("http.method", c.Method()),
+			// Fiber reuses the path buffer once the request is done
+			tracing.String("http.target", strings.Clone(c.Path())),
+		)
+		c.SetUserContext(ctx)
+		defer span.End()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+				if fiberErr.Code == fiber.StatusNotFound {
+					route = unmatchedRoute
+				}
+			}
+		}
+
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			tracing.String("http.route", route),
+			tracing.Int("http.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			if err == nil {
+				err = errors.New(fiber.ErrInternalServerError.Message)
+			}
+			span.RecordError(err)
+		}
+		if id, ok := c.Locals(RequestIDKey).(string); ok {
+			span.SetAttributes(tracing.String("http.request_id", id))
+		}
+
+		return err
+	}
+}
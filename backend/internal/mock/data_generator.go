@@ -4,205 +4,275 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/marketdata"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
 	"github.com/Taf0711/financial-risk-monitor/internal/services"
 	"github.com/Taf0711/financial-risk-monitor/internal/websocket"
 )
 
+// MockDataGenerator publishes made-up transactions, risk metrics and alerts for development. Its
+// profile and whether it is running can be changed while it runs, and scenarios injected on demand.
 type MockDataGenerator struct {
 	hub          *websocket.Hub
-	simpleHub    interface{} // We'll use interface{} to avoid import cycle
 	redisClient  *redis.Client
 	riskService  *services.RiskEngineService
 	alertService *services.AlertService
-	symbols      []string
-	prices       map[string]float64
+	prices       map[string]float64 // Prices used before any price has been ingested
+	seed         int64
+	logger       *slog.Logger
+
+	mu      sync.Mutex
+	profile Profile
+	running bool
+	changed chan struct{} // Closed and replaced when the profile or running state changes
 }
 
-func NewMockDataGenerator(hub *websocket.Hub) *MockDataGenerator {
-	return &MockDataGenerator{
+// MockStatus is the generator's running state and profile, with the tick intervals in seconds
+type MockStatus struct {
+	Running                    bool     `json:"running"`
+	Universe                   string   `json:"universe"`
+	Symbols                    []string `json:"symbols"`
+	TransactionIntervalSeconds int      `json:"transaction_interval_seconds"`
+	RiskMetricIntervalSeconds  int      `json:"risk_metric_interval_seconds"`
+	AlertIntervalSeconds       int      `json:"alert_interval_seconds"`
+	AlertProbability           float64  `json:"alert_probability"`
+	AMLThreshold               float64  `json:"aml_threshold"`
+	Universes                  []string `json:"universes"`
+	Scenarios                  []string `json:"scenarios"`
+}
+
+// NewMockDataGenerator creates a generator that starts running with Run, with the default profile
+// or that of the configured demo scenario
+func NewMockDataGenerator(hub *websocket.Hub, cfg *config.MockConfig) (*MockDataGenerator, error) {
+	m := &MockDataGenerator{
 		hub:          hub,
 		redisClient:  database.GetRedis(),
 		riskService:  services.NewRiskEngineService(),
 		alertService: services.NewAlertService(),
-		symbols: []string{
-			"AAPL", "GOOGL", "MSFT", "AMZN", "TSLA",
-			"JPM", "BAC", "GS", "MS", "WFC",
-			"BTC", "ETH", "GOLD", "SILVER", "OIL",
-		},
-		prices: map[string]float64{
-			"AAPL":   150.00,
-			"GOOGL":  2800.00,
-			"MSFT":   300.00,
-			"AMZN":   3300.00,
-			"TSLA":   800.00,
-			"JPM":    140.00,
-			"BAC":    35.00,
-			"GS":     350.00,
-			"MS":     90.00,
-			"WFC":    45.00,
-			"BTC":    45000.00,
-			"ETH":    3000.00,
-			"GOLD":   1800.00,
-			"SILVER": 25.00,
-			"OIL":    75.00,
-		},
+		logger:       logging.Component("mock"),
+		prices:       seedPrices,
+		seed:         cfg.Seed,
+		profile:      DefaultProfile(),
+		running:      true,
+		changed:      make(chan struct{}),
+	}
+	if cfg.ScenarioFile == "" {
+		return m, nil
+	}
+
+	scenario, err := LoadDemoScenario(cfg.ScenarioFile)
+	if err != nil {
+		return nil, err
 	}
+	if m.seed == 0 {
+		m.seed = scenario.Seed
+	}
+	scenario.Seed = m.seed
+	m.prices = scenario.knownPrices()
+	if scenario.Profile != nil {
+		if m.profile, err = scenario.Profile.Apply(m.profile, m.prices); err != nil {
+			return nil, err
+		}
+	}
+	m.logger.Info("Mock data follows demo scenario", "scenario", scenario.Name, "seed", m.seed)
+	return m, nil
 }
 
-// SetSimpleHub sets the simple hub for broadcasting
-func (m *MockDataGenerator) SetSimpleHub(hub interface{}) {
-	m.simpleHub = hub
+// Status returns the generator's running state and profile
+func (m *MockDataGenerator) Status() MockStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.profile
+	return MockStatus{
+		Running:                    m.running,
+		Universe:                   p.Universe,
+		Symbols:                    p.Symbols,
+		TransactionIntervalSeconds: int(p.TransactionInterval.Seconds()),
+		RiskMetricIntervalSeconds:  int(p.RiskMetricInterval.Seconds()),
+		AlertIntervalSeconds:       int(p.AlertInterval.Seconds()),
+		AlertProbability:           p.AlertProbability,
+		AMLThreshold:               p.AMLThreshold,
+		Universes:                  UniverseNames(),
+		Scenarios:                  ScenarioNames(),
+	}
 }
 
-// broadcastMessage sends message to both hubs
-func (m *MockDataGenerator) broadcastMessage(message websocket.Message) {
-	// Try to broadcast to original hub
-	if m.hub != nil {
-		if err := m.hub.BroadcastToAll(message); err != nil {
-			log.Printf("Warning: Failed to broadcast to hub: %v", err)
-		}
+// SetRunning starts or pauses generation; scenarios can be injected either way
+func (m *MockDataGenerator) SetRunning(running bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running != running {
+		m.running = running
+		m.notifyChange()
+	}
+}
+
+// UpdateProfile applies a profile update, restarting the tick intervals it changes
+func (m *MockDataGenerator) UpdateProfile(update MockProfileRequest) (Profile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profile, err := update.Apply(m.profile, m.prices)
+	if err != nil {
+		return m.profile, err
 	}
+	m.profile = profile
+	m.notifyChange()
+	return profile, nil
+}
 
-	// Try to broadcast to simple hub using interface method
-	if m.simpleHub != nil {
-		// Type assertion to call BroadcastToAll
-		if simpleHub, ok := m.simpleHub.(interface{ BroadcastToAll(interface{}) error }); ok {
-			if err := simpleHub.BroadcastToAll(message); err != nil {
-				log.Printf("Warning: Failed to broadcast to simple hub: %v", err)
-			}
-		}
+// notifyChange wakes the generation loops to pick up the new state. The caller holds mu.
+func (m *MockDataGenerator) notifyChange() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *MockDataGenerator) state() (Profile, bool, <-chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.profile, m.running, m.changed
+}
+
+// broadcastMessage sends message to the clients the hub routes its topic to
+func (m *MockDataGenerator) broadcastMessage(ctx context.Context, topic websocket.Topic, message websocket.Message) {
+	message.RequestID = logging.RequestID(ctx)
+	if err := m.hub.Publish(topic, message); err != nil {
+		m.logger.WarnContext(ctx, "Failed to broadcast to hub", "type", message.Type, "error", err)
 	}
 }
 
-func (m *MockDataGenerator) Start() {
-	log.Println("Starting mock data generator...")
+// Run generates mock transactions, risk metrics and alerts until ctx is cancelled
+func (m *MockDataGenerator) Run(ctx context.Context) {
+	m.logger.Info("Starting mock data generator")
 
-	// Generate price updates
-	go m.generatePriceUpdates()
+	var wg sync.WaitGroup
 
 	// Generate transactions
-	go m.generateTransactions()
+	wg.Go(func() {
+		m.every(ctx, m.newRand(0), func(p Profile) time.Duration { return p.TransactionInterval }, m.generateTransaction)
+	})
 
 	// Generate risk metrics
-	go m.generateRiskMetrics()
+	wg.Go(func() {
+		m.every(ctx, m.newRand(1), func(p Profile) time.Duration { return p.RiskMetricInterval }, m.generateRiskMetric)
+	})
 
 	// Generate alerts
-	go m.generateAlerts()
+	wg.Go(func() {
+		m.every(ctx, m.newRand(2), func(p Profile) time.Duration { return p.AlertInterval }, m.generateAlert)
+	})
+
+	wg.Wait()
 }
 
-func (m *MockDataGenerator) generatePriceUpdates() {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+// newRand returns the random source of one of the generation loops. With a seed, each loop draws
+// the same sequence on every run, whatever the timing of the others.
+func (m *MockDataGenerator) newRand(loop int64) *rand.Rand {
+	if m.seed == 0 {
+		return rand.New(rand.NewSource(time.Now().UnixNano() + loop))
+	}
+	return rand.New(rand.NewSource(m.seed + loop))
+}
 
+// every calls generate at the profile's interval while the generator is running, until ctx is
+// cancelled. The ticker restarts whenever the profile or running state changes.
+func (m *MockDataGenerator) every(ctx context.Context, rng *rand.Rand, interval func(Profile) time.Duration, generate func(ctx context.Context, profile Profile, rng *rand.Rand)) {
 	for {
-		select {
-		case <-ticker.C:
-			updates := make(map[string]interface{})
-
-			for symbol, basePrice := range m.prices {
-				// Random walk with mean reversion
-				change := (rand.Float64() - 0.5) * 0.02 // ±1% change
-				newPrice := basePrice * (1 + change)
-
-				// Mean reversion
-				if newPrice > basePrice*1.1 {
-					newPrice = basePrice * 1.09
-				} else if newPrice < basePrice*0.9 {
-					newPrice = basePrice * 0.91
-				}
-
-				m.prices[symbol] = newPrice
-				updates[symbol] = map[string]interface{}{
-					"price":     newPrice,
-					"change":    change * 100,
-					"timestamp": time.Now().Unix(),
+		profile, running, changed := m.state()
+		ticker := time.NewTicker(interval(profile))
+
+	ticks:
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-changed:
+				break ticks
+			case <-ticker.C:
+				if running {
+					generate(logging.WithNewRequestID(context.WithoutCancel(ctx)), profile, rng)
 				}
 			}
-
-			// Broadcast price updates
-			log.Printf("Generated price updates: %+v", updates)
-			message := websocket.Message{
-				Type: "price_update",
-				Data: updates,
-			}
-
-			// Broadcast to all hubs
-			m.broadcastMessage(message)
-
-			// Store in Redis
-			ctx := context.Background()
-			for symbol, price := range m.prices {
-				key := fmt.Sprintf("price:%s", symbol)
-				m.redisClient.Set(ctx, key, price, 5*time.Minute)
-			}
 		}
+		ticker.Stop()
 	}
 }
 
-func (m *MockDataGenerator) generateTransactions() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+func (m *MockDataGenerator) generateTransaction(ctx context.Context, profile Profile, rng *rand.Rand) {
+	// Generate random transaction
+	transaction := m.createMockTransaction(ctx, profile.Symbols, rng)
 
-	for {
-		select {
-		case <-ticker.C:
-			// Generate random transaction
-			transaction := m.createMockTransaction()
-
-			// Skip if empty transaction (failed to get portfolio)
-			if transaction.ID == uuid.Nil {
-				continue
-			}
+	// Skip if empty transaction (failed to get portfolio)
+	if transaction.ID == uuid.Nil {
+		return
+	}
 
-			// Check if it triggers AML flags
-			if transaction.Amount.GreaterThan(decimal.NewFromInt(10000)) {
-				m.generateAMLAlert(transaction)
-			}
+	// Check if it triggers AML flags
+	if transaction.Amount.GreaterThan(decimal.NewFromFloat(profile.AMLThreshold)) {
+		m.generateAMLAlert(ctx, transaction)
+	}
 
-			// Broadcast transaction
-			log.Printf("Generated transaction: %s %s %s @ %s", transaction.TransactionType, transaction.Symbol, transaction.Quantity.String(), transaction.Price.String())
-			message := websocket.Message{
-				Type: "new_transaction",
-				Data: map[string]interface{}{
-					"transaction": transaction,
-					"timestamp":   time.Now().Unix(),
-				},
-			}
+	m.broadcastTransaction(ctx, transaction)
+}
+
+func (m *MockDataGenerator) broadcastTransaction(ctx context.Context, transaction models.Transaction) {
+	m.logger.DebugContext(ctx, "Generated transaction", "type", transaction.TransactionType, "symbol", transaction.Symbol,
+		"quantity", transaction.Quantity.String(), "price", transaction.Price.String())
+	message := websocket.Message{
+		Type: "new_transaction",
+		Data: map[string]interface{}{
+			"transaction": transaction,
+			"timestamp":   time.Now().Unix(),
+		},
+	}
+
+	m.broadcastMessage(ctx, websocket.Topic{PortfolioID: transaction.PortfolioID.String()}, message)
+}
 
-			// Broadcast to all hubs
-			m.broadcastMessage(message)
+// currentPrice reads the ingested price from Redis, falling back to the seed price
+func (m *MockDataGenerator) currentPrice(symbol string) float64 {
+	latest, err := marketdata.LatestPrices(context.Background(), m.redisClient, []string{symbol})
+	if err == nil {
+		if price, ok := latest[symbol]; ok {
+			return price
 		}
 	}
+	return m.prices[symbol]
 }
 
-func (m *MockDataGenerator) createMockTransaction() models.Transaction {
-	symbol := m.symbols[rand.Intn(len(m.symbols))]
-	quantity := decimal.NewFromFloat(rand.Float64() * 100)
-	price := decimal.NewFromFloat(m.prices[symbol])
+func (m *MockDataGenerator) createMockTransaction(ctx context.Context, symbols []string, rng *rand.Rand) models.Transaction {
+	symbol := symbols[rng.Intn(len(symbols))]
+	quantity := decimal.NewFromFloat(rng.Float64() * 100)
+	price := decimal.NewFromFloat(m.currentPrice(symbol))
 	amount := quantity.Mul(price)
 
 	transactionTypes := []string{"BUY", "SELL"}
-	transactionType := transactionTypes[rand.Intn(len(transactionTypes))]
+	transactionType := transactionTypes[rng.Intn(len(transactionTypes))]
 
 	// Get actual portfolio ID from database
 	var portfolios []models.Portfolio
-	if err := database.GetDB().Find(&portfolios).Error; err != nil || len(portfolios) == 0 {
+	if err := database.GetDB().Order("created_at").Find(&portfolios).Error; err != nil || len(portfolios) == 0 {
 		// Fallback to a default portfolio ID if database query fails
-		log.Printf("Warning: failed to fetch portfolios for transaction: %v", err)
+		m.logger.WarnContext(ctx, "Failed to fetch portfolios for transaction", "error", err)
 		return models.Transaction{} // Return empty transaction
 	}
 
-	selectedPortfolio := portfolios[rand.Intn(len(portfolios))]
+	selectedPortfolio := portfolios[rng.Intn(len(portfolios))]
 
 	return models.Transaction{
 		ID:              uuid.New(),
@@ -215,117 +285,115 @@ func (m *MockDataGenerator) createMockTransaction() models.Transaction {
 		Currency:        "USD",
 		Status:          "COMPLETED",
 		ExecutedAt:      &time.Time{},
-		KYCVerified:     rand.Float64() > 0.1, // 90% verified
-		AMLChecked:      rand.Float64() > 0.2, // 80% checked
-		RiskScore:       rand.Intn(100),
+		KYCVerified:     rng.Float64() > 0.1, // 90% verified
+		AMLChecked:      rng.Float64() > 0.2, // 80% checked
+		RiskScore:       rng.Intn(100),
 		CreatedAt:       time.Now(),
+
+		OrderStatus:      models.OrderStatusFilled,
+		FilledQuantity:   quantity,
+		AverageFillPrice: price,
 	}
 }
 
-func (m *MockDataGenerator) generateRiskMetrics() {
-	ticker := time.NewTicker(15 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			// Get existing portfolios to generate metrics for
-			var portfolios []models.Portfolio
-			if err := database.GetDB().Find(&portfolios).Error; err != nil {
-				log.Printf("Warning: failed to fetch portfolios: %v", err)
-				continue
-			}
+func (m *MockDataGenerator) generateRiskMetric(ctx context.Context, _ Profile, rng *rand.Rand) {
+	// Get existing portfolios to generate metrics for
+	var portfolios []models.Portfolio
+	if err := database.GetDB().Order("created_at").Find(&portfolios).Error; err != nil {
+		m.logger.WarnContext(ctx, "Failed to fetch portfolios", "error", err)
+		return
+	}
 
-			if len(portfolios) == 0 {
-				log.Println("No portfolios found, skipping risk metric generation")
-				continue
-			}
+	if len(portfolios) == 0 {
+		m.logger.DebugContext(ctx, "No portfolios found, skipping risk metric generation")
+		return
+	}
 
-			// Pick a random portfolio
-			portfolio := portfolios[rand.Intn(len(portfolios))]
+	// Pick a random portfolio
+	portfolio := portfolios[rng.Intn(len(portfolios))]
 
-			// Calculate actual VaR using RiskService
-			varReq := services.VaRCalculationRequest{
-				PortfolioID:     portfolio.ID,
-				TimeHorizon:     1,
-				ConfidenceLevel: 95.0,
-				Method:          "historical_simulation",
-			}
-			varMetric, err := m.riskService.CalculateVaR(varReq)
-			if err != nil {
-				log.Printf("Warning: failed to calculate VaR for portfolio %s: %v", portfolio.ID, err)
-			}
+	// Calculate actual VaR using RiskService
+	varReq := services.VaRCalculationRequest{
+		PortfolioID:     portfolio.ID,
+		TimeHorizon:     1,
+		ConfidenceLevel: 95.0,
+		Method:          "historical_simulation",
+	}
+	varMetric, err := m.riskService.CalculateVaR(varReq)
+	if err != nil {
+		m.logger.WarnContext(ctx, "Failed to calculate VaR", "portfolio_id", portfolio.ID, "error", err)
+	}
 
-			// Calculate actual Liquidity using RiskService
-			liquidityMetric, err := m.riskService.CalculateLiquidityRisk(portfolio.ID)
-			if err != nil {
-				log.Printf("Warning: failed to calculate liquidity for portfolio %s: %v", portfolio.ID, err)
-			}
+	// Calculate actual Liquidity using RiskService
+	liquidityMetric, err := m.riskService.CalculateLiquidityRisk(portfolio.ID)
+	if err != nil {
+		m.logger.WarnContext(ctx, "Failed to calculate liquidity", "portfolio_id", portfolio.ID, "error", err)
+	}
 
-			// Skip this iteration if both metrics are nil (empty portfolio)
-			if varMetric == nil && liquidityMetric == nil {
-				log.Printf("Skipping risk metric generation for empty portfolio %s", portfolio.ID)
-				continue
-			}
+	// Skip this iteration if both metrics are nil (empty portfolio)
+	if varMetric == nil && liquidityMetric == nil {
+		m.logger.DebugContext(ctx, "Skipping risk metric generation for empty portfolio", "portfolio_id", portfolio.ID)
+		return
+	}
 
-			// Check if we need to generate alerts for breaches
-			if varMetric != nil && varMetric.Status != "SAFE" {
-				err := m.alertService.CreateRiskBreachAlert(
-					portfolio.ID,
-					"VAR",
-					varMetric.VaRValue.InexactFloat64(),
-					varMetric.Threshold.InexactFloat64(),
-				)
-				if err != nil {
-					log.Printf("Warning: failed to create VaR alert: %v", err)
-				}
-			}
+	// Check if we need to generate alerts for breaches
+	if varMetric != nil && varMetric.Status != "SAFE" {
+		err := m.alertService.CreateRiskBreachAlert(
+			ctx,
+			portfolio.ID,
+			"VAR",
+			varMetric.VaRValue.InexactFloat64(),
+			varMetric.Threshold.InexactFloat64(),
+		)
+		if err != nil {
+			m.logger.WarnContext(ctx, "Failed to create VaR alert", "portfolio_id", portfolio.ID, "error", err)
+		}
+	}
 
-			if liquidityMetric != nil && liquidityMetric.RiskAssessment != "LOW_RISK" {
-				err := m.alertService.CreateRiskBreachAlert(
-					portfolio.ID,
-					"LIQUIDITY_RATIO",
-					liquidityMetric.LiquidityRatio.InexactFloat64(),
-					decimal.NewFromFloat(0.3).InexactFloat64(), // Default threshold
-				)
-				if err != nil {
-					log.Printf("Warning: failed to create liquidity alert: %v", err)
-				}
-			}
+	if liquidityMetric != nil && liquidityMetric.RiskAssessment != "LOW_RISK" {
+		err := m.alertService.CreateRiskBreachAlert(
+			ctx,
+			portfolio.ID,
+			"LIQUIDITY_RATIO",
+			liquidityMetric.LiquidityRatio.InexactFloat64(),
+			decimal.NewFromFloat(0.3).InexactFloat64(), // Default threshold
+		)
+		if err != nil {
+			m.logger.WarnContext(ctx, "Failed to create liquidity alert", "portfolio_id", portfolio.ID, "error", err)
+		}
+	}
 
-			// Broadcast risk metrics
-			var varStr, varStatus, liquidityStr, liquidityStatus string
-			if varMetric != nil {
-				varStr = varMetric.VaRValue.String()
-				varStatus = varMetric.Status
-			} else {
-				varStr = "N/A"
-				varStatus = "N/A"
-			}
-			if liquidityMetric != nil {
-				liquidityStr = liquidityMetric.LiquidityRatio.String()
-				liquidityStatus = liquidityMetric.RiskAssessment
-			} else {
-				liquidityStr = "N/A"
-				liquidityStatus = "N/A"
-			}
+	// Broadcast risk metrics
+	var varStr, varStatus, liquidityStr, liquidityStatus string
+	if varMetric != nil {
+		varStr = varMetric.VaRValue.String()
+		varStatus = varMetric.Status
+	} else {
+		varStr = "N/A"
+		varStatus = "N/A"
+	}
+	if liquidityMetric != nil {
+		liquidityStr = liquidityMetric.LiquidityRatio.String()
+		liquidityStatus = liquidityMetric.RiskAssessment
+	} else {
+		liquidityStr = "N/A"
+		liquidityStatus = "N/A"
+	}
 
-			log.Printf("Generated risk metrics for portfolio %s - VaR: %s (%s), Liquidity: %s (%s)",
-				portfolio.ID, varStr, varStatus, liquidityStr, liquidityStatus)
-
-			message := websocket.Message{
-				Type: "risk_update",
-				Data: map[string]interface{}{
-					"portfolio_id": portfolio.ID,
-					"var":          varMetric,
-					"liquidity":    liquidityMetric,
-					"timestamp":    time.Now().Unix(),
-				},
-			}
+	m.logger.DebugContext(ctx, "Generated risk metrics", "portfolio_id", portfolio.ID,
+		"var", varStr, "var_status", varStatus, "liquidity", liquidityStr, "liquidity_status", liquidityStatus)
 
-			m.broadcastMessage(message)
-		}
+	message := websocket.Message{
+		Type: "risk_update",
+		Data: map[string]interface{}{
+			"portfolio_id": portfolio.ID,
+			"var":          varMetric,
+			"liquidity":    liquidityMetric,
+			"timestamp":    time.Now().Unix(),
+		},
 	}
+
+	m.broadcastMessage(ctx, websocket.Topic{PortfolioID: portfolio.ID.String()}, message)
 }
 
 func (m *MockDataGenerator) getRiskStatus(value, threshold float64) string {
@@ -351,105 +419,100 @@ func (m *MockDataGenerator) getLiquidityStatus(ratio float64) string {
 	}
 }
 
-func (m *MockDataGenerator) generateAlerts() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+func (m *MockDataGenerator) generateAlert(ctx context.Context, profile Profile, rng *rand.Rand) {
+	// Get existing portfolios to generate alerts for
+	var portfolios []models.Portfolio
+	if err := database.GetDB().Order("created_at").Find(&portfolios).Error; err != nil {
+		m.logger.WarnContext(ctx, "Failed to fetch portfolios", "error", err)
+		return
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			// Get existing portfolios to generate alerts for
-			var portfolios []models.Portfolio
-			if err := database.GetDB().Find(&portfolios).Error; err != nil {
-				log.Printf("Warning: failed to fetch portfolios: %v", err)
-				continue
-			}
+	if len(portfolios) == 0 {
+		m.logger.DebugContext(ctx, "No portfolios found, skipping alert generation")
+		return
+	}
 
-			if len(portfolios) == 0 {
-				log.Println("No portfolios found, skipping alert generation")
-				continue
-			}
+	// Randomly generate an alert
+	if rng.Float64() < profile.AlertProbability {
+		portfolio := portfolios[rng.Intn(len(portfolios))]
 
-			// Randomly generate an alert (30% chance)
-			if rand.Float64() > 0.7 {
-				portfolio := portfolios[rand.Intn(len(portfolios))]
-
-				alertTypes := []struct {
-					Type        string
-					Severity    string
-					Title       string
-					Description string
-					Source      string
-				}{
-					{
-						Type:        "RISK_BREACH",
-						Severity:    "HIGH",
-						Title:       "VaR Limit Exceeded",
-						Description: "Portfolio Value at Risk exceeds threshold",
-						Source:      "VAR_CALCULATOR",
-					},
-					{
-						Type:        "COMPLIANCE_VIOLATION",
-						Severity:    "CRITICAL",
-						Title:       "Position Limit Breach",
-						Description: "Single position exceeds 25% of portfolio",
-						Source:      "POSITION_LIMIT_CHECKER",
-					},
-					{
-						Type:        "SUSPICIOUS_ACTIVITY",
-						Severity:    "MEDIUM",
-						Title:       "Unusual Trading Pattern",
-						Description: "High frequency trading detected",
-						Source:      "PATTERN_DETECTOR",
-					},
-				}
+		m.createAlert(ctx, portfolio, alertTemplates[rng.Intn(len(alertTemplates))], nil)
+	}
+}
 
-				alertType := alertTypes[rand.Intn(len(alertTypes))]
-
-				alert := &models.Alert{
-					PortfolioID: portfolio.ID,
-					AlertType:   alertType.Type,
-					Severity:    alertType.Severity,
-					Title:       alertType.Title,
-					Description: alertType.Description,
-					Source:      alertType.Source,
-					Status:      "ACTIVE",
-					TriggeredBy: models.JSON{
-						"mock_generated": true,
-						"portfolio_name": portfolio.Name,
-					},
-				}
+// alertTemplate is a kind of alert the generator raises
+type alertTemplate struct {
+	Type        string
+	Severity    string
+	Title       string
+	Description string
+	Source      string
+}
 
-				// Store alert in database using AlertService
-				err := m.alertService.CreateAlert(alert)
-				if err != nil {
-					log.Printf("Warning: failed to create alert: %v", err)
-					continue
-				}
+var alertTemplates = []alertTemplate{
+	{
+		Type:        "RISK_BREACH",
+		Severity:    "HIGH",
+		Title:       "VaR Limit Exceeded",
+		Description: "Portfolio Value at Risk exceeds threshold",
+		Source:      "VAR_CALCULATOR",
+	},
+	{
+		Type:        "COMPLIANCE_VIOLATION",
+		Severity:    "CRITICAL",
+		Title:       "Position Limit Breach",
+		Description: "Single position exceeds 25% of portfolio",
+		Source:      "POSITION_LIMIT_CHECKER",
+	},
+	{
+		Type:        "SUSPICIOUS_ACTIVITY",
+		Severity:    "MEDIUM",
+		Title:       "Unusual Trading Pattern",
+		Description: "High frequency trading detected",
+		Source:      "PATTERN_DETECTOR",
+	},
+}
 
-				// Broadcast alert
-				log.Printf("Generated alert for portfolio %s: %s - %s", portfolio.ID, alert.Severity, alert.Title)
-				message := websocket.Message{
-					Type: "new_alert",
-					Data: map[string]interface{}{
-						"alert":     alert,
-						"timestamp": time.Now().Unix(),
-					},
-				}
+// createAlert stores an alert of a template for a portfolio, with extra details in its trigger,
+// and reports whether it was stored
+func (m *MockDataGenerator) createAlert(ctx context.Context, portfolio models.Portfolio, alertType alertTemplate, details models.JSON) bool {
+	triggeredBy := models.JSON{
+		"mock_generated": true,
+		"portfolio_name": portfolio.Name,
+	}
+	for key, value := range details {
+		triggeredBy[key] = value
+	}
 
-				m.broadcastMessage(message)
+	alert := &models.Alert{
+		PortfolioID: portfolio.ID,
+		AlertType:   alertType.Type,
+		Severity:    alertType.Severity,
+		Title:       alertType.Title,
+		Description: alertType.Description,
+		Source:      alertType.Source,
+		Status:      "ACTIVE",
+		TriggeredBy: triggeredBy,
+	}
 
-				// Store in Redis for caching
-				ctx := context.Background()
-				alertJSON, _ := json.Marshal(alert)
-				key := fmt.Sprintf("alert:%s", alert.ID)
-				m.redisClient.Set(ctx, key, alertJSON, 24*time.Hour)
-			}
-		}
+	// Store alert in database using AlertService
+	err := m.alertService.CreateAlert(ctx, alert)
+	if err != nil {
+		m.logger.WarnContext(ctx, "Failed to create alert", "portfolio_id", portfolio.ID, "error", err)
+		return false
 	}
+
+	// The alert reaches WebSocket clients through the Redis bridge
+	m.logger.DebugContext(ctx, "Generated alert", "portfolio_id", portfolio.ID, "severity", alert.Severity, "title", alert.Title)
+
+	// Store in Redis for caching
+	alertJSON, _ := json.Marshal(alert)
+	key := fmt.Sprintf("alert:%s", alert.ID)
+	m.redisClient.Set(ctx, key, alertJSON, 24*time.Hour)
+	return true
 }
 
-func (m *MockDataGenerator) generateAMLAlert(transaction models.Transaction) {
+func (m *MockDataGenerator) generateAMLAlert(ctx context.Context, transaction models.Transaction) {
 	alert := &models.Alert{
 		PortfolioID: transaction.PortfolioID,
 		AlertType:   "SUSPICIOUS_ACTIVITY",
@@ -467,13 +530,13 @@ func (m *MockDataGenerator) generateAMLAlert(transaction models.Transaction) {
 	}
 
 	// Store alert in database using AlertService
-	err := m.alertService.CreateAlert(alert)
+	err := m.alertService.CreateAlert(ctx, alert)
 	if err != nil {
-		log.Printf("Warning: failed to create AML alert: %v", err)
+		m.logger.WarnContext(ctx, "Failed to create AML alert", "transaction_id", transaction.ID, "error", err)
 		return
 	}
 
-	log.Printf("Generated AML alert for transaction: %s %s", transaction.Amount.String(), transaction.Symbol)
+	m.logger.DebugContext(ctx, "Generated AML alert", "amount", transaction.Amount.String(), "symbol", transaction.Symbol)
 	message := websocket.Message{
 		Type: "aml_alert",
 		Data: map[string]interface{}{
@@ -483,5 +546,8 @@ func (m *MockDataGenerator) generateAMLAlert(transaction models.Transaction) {
 		},
 	}
 
-	m.broadcastMessage(message)
+	m.broadcastMessage(ctx, websocket.Topic{
+		PortfolioID: alert.PortfolioID.String(),
+		Severity:    alert.Severity,
+	}, message)
 }
@@ -0,0 +1,223 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDemoDays is the length of the price paths when a scenario does not set it
+const defaultDemoDays = 60
+
+// Metrics an expected breach can name, with the risk check that reports them
+const (
+	DemoMetricDrawdown = "DRAWDOWN"
+	DemoMetricVaR      = "VAR"
+	DemoMetricLeverage = "LEVERAGE"
+)
+
+// DemoScenario describes a demo environment: the users and portfolios seeded, the daily closes of
+// each symbol, and the breaches the seeded portfolios are expected to show. Everything random is
+// drawn from Seed, so the same file seeds the same data on every machine.
+type DemoScenario struct {
+	Name             string               `yaml:"name"`
+	Seed             int64                `yaml:"seed"`
+	Days             int                  `yaml:"days"`    // Length of the price paths, one end-of-day snapshot each
+	Profile          *MockProfileRequest  `yaml:"profile"` // Mock data generator settings; the default profile when unset
+	Users            []DemoUser           `yaml:"users"`
+	Prices           map[string]PricePath `yaml:"prices"`
+	ExpectedBreaches []ExpectedBreach     `yaml:"expected_breaches"`
+}
+
+type DemoUser struct {
+	Email      string          `yaml:"email"`
+	Password   string          `yaml:"password"`
+	FirstName  string          `yaml:"first_name"`
+	LastName   string          `yaml:"last_name"`
+	Role       string          `yaml:"role"`
+	Portfolios []DemoPortfolio `yaml:"portfolios"`
+}
+
+type DemoPortfolio struct {
+	Name         string         `yaml:"name"`
+	Description  string         `yaml:"description"`
+	Currency     string         `yaml:"currency"`
+	CashBalance  float64        `yaml:"cash_balance"`
+	MarginLoan   float64        `yaml:"margin_loan"`
+	Positions    []DemoPosition `yaml:"positions"`
+	Transactions int            `yaml:"transactions"` // Trades of its symbols drawn from the price paths
+}
+
+type DemoPosition struct {
+	Symbol       string  `yaml:"symbol"`
+	Quantity     float64 `yaml:"quantity"`
+	AveragePrice float64 `yaml:"average_price"`
+	AssetType    string  `yaml:"asset_type"`
+	Liquidity    string  `yaml:"liquidity"`
+}
+
+// PricePath is a symbol's daily closes, oldest first: given as Closes, or a geometric random walk
+// from Start with the given daily drift and volatility. Shocks then move every close from their
+// day on, e.g. a crash on the last day.
+type PricePath struct {
+	Closes     []float64    `yaml:"closes"`
+	Start      float64      `yaml:"start"`
+	Drift      float64      `yaml:"drift"`
+	Volatility float64      `yaml:"volatility"`
+	Shocks     []PriceShock `yaml:"shocks"`
+}
+
+type PriceShock struct {
+	Day    int     `yaml:"day"`    // 0-based day of the path
+	Change float64 `yaml:"change"` // Fractional move, e.g. -0.25
+}
+
+// ExpectedBreach is the status a risk check should report for a seeded portfolio
+type ExpectedBreach struct {
+	User      string `yaml:"user"` // Owner's email; needed only when portfolio names repeat
+	Portfolio string `yaml:"portfolio"`
+	Metric    string `yaml:"metric"` // DRAWDOWN, VAR or LEVERAGE
+	Status    string `yaml:"status"` // SAFE, WARNING or CRITICAL
+}
+
+// LoadDemoScenario reads and checks a YAML scenario file
+func LoadDemoScenario(path string) (*DemoScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario DemoScenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return &scenario, nil
+}
+
+func (s *DemoScenario) validate() error {
+	if s.Days == 0 {
+		s.Days = defaultDemoDays
+	}
+	if s.Days < 2 {
+		return errors.New("days must be at least 2")
+	}
+	for symbol, path := range s.Prices {
+		if len(path.Closes) == 0 && path.Start <= 0 {
+			return fmt.Errorf("prices of %s need closes or a positive start", symbol)
+		}
+		if len(path.Closes) > 0 && len(path.Closes) != s.Days {
+			return fmt.Errorf("prices of %s have %d closes, not one for each of the %d days", symbol, len(path.Closes), s.Days)
+		}
+		for _, shock := range path.Shocks {
+			if shock.Day < 0 || shock.Day >= s.Days || shock.Change <= -1 {
+				return fmt.Errorf("prices of %s have an invalid shock on day %d", symbol, shock.Day)
+			}
+		}
+	}
+	if s.Profile != nil {
+		if _, err := s.Profile.Apply(DefaultProfile(), s.knownPrices()); err != nil {
+			return fmt.Errorf("profile: %w", err)
+		}
+	}
+
+	owners := make(map[string][]string)
+	for _, user := range s.Users {
+		if user.Email == "" || user.Password == "" {
+			return errors.New("every user needs an email and a password")
+		}
+		for _, portfolio := range user.Portfolios {
+			if portfolio.Name == "" {
+				return fmt.Errorf("a portfolio of %s has no name", user.Email)
+			}
+			owners[portfolio.Name] = append(owners[portfolio.Name], user.Email)
+			for _, position := range portfolio.Positions {
+				if _, ok := s.Prices[position.Symbol]; !ok {
+					return fmt.Errorf("position %s of %s has no prices", position.Symbol, portfolio.Name)
+				}
+			}
+		}
+	}
+
+	for _, breach := range s.ExpectedBreaches {
+		switch breach.Metric {
+		case DemoMetricDrawdown, DemoMetricVaR, DemoMetricLeverage:
+		default:
+			return fmt.Errorf("expected breach metric must be %s, %s or %s", DemoMetricDrawdown, DemoMetricVaR, DemoMetricLeverage)
+		}
+		switch breach.Status {
+		case "SAFE", "WARNING", "CRITICAL":
+		default:
+			return errors.New("expected breach status must be SAFE, WARNING or CRITICAL")
+		}
+		emails := owners[breach.Portfolio]
+		switch {
+		case len(emails) == 0:
+			return fmt.Errorf("expected breach names unknown portfolio %q", breach.Portfolio)
+		case breach.User != "" && !slices.Contains(emails, breach.User):
+			return fmt.Errorf("expected breach names portfolio %q of %s, who has none by that name", breach.Portfolio, breach.User)
+		case breach.User == "" && len(emails) > 1:
+			return fmt.Errorf("expected breach on portfolio %q needs the user, as several have one by that name", breach.Portfolio)
+		}
+	}
+	return nil
+}
+
+// PricePaths returns the daily closes of every symbol, oldest first. Each symbol's random walk
+// has its own source derived from the seed, so adding a symbol does not change the others.
+func (s *DemoScenario) PricePaths() map[string][]float64 {
+	paths := make(map[string][]float64, len(s.Prices))
+	for symbol, path := range s.Prices {
+		closes := slices.Clone(path.Closes)
+		if len(closes) == 0 {
+			rng := rand.New(rand.NewSource(s.Seed ^ symbolSeed(symbol)))
+			closes = make([]float64, s.Days)
+			closes[0] = path.Start
+			for day := 1; day < s.Days; day++ {
+				closes[day] = closes[day-1] * math.Exp(path.Drift+path.Volatility*rng.NormFloat64())
+			}
+		}
+		for _, shock := range path.Shocks {
+			for day := shock.Day; day < len(closes); day++ {
+				closes[day] *= 1 + shock.Change
+			}
+		}
+		for day := range closes {
+			closes[day] = math.Round(closes[day]*100) / 100
+		}
+		paths[symbol] = closes
+	}
+	return paths
+}
+
+// LatestPrices returns the last close of every symbol
+func (s *DemoScenario) LatestPrices() map[string]float64 {
+	latest := make(map[string]float64, len(s.Prices))
+	for symbol, closes := range s.PricePaths() {
+		latest[symbol] = closes[len(closes)-1]
+	}
+	return latest
+}
+
+// knownPrices returns the generator's seed prices overridden by the scenario's last closes
+func (s *DemoScenario) knownPrices() map[string]float64 {
+	prices := maps.Clone(seedPrices)
+	maps.Copy(prices, s.LatestPrices())
+	return prices
+}
+
+func symbolSeed(symbol string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToUpper(symbol)))
+	return int64(h.Sum64())
+}
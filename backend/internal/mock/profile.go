@@ -0,0 +1,156 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Symbol universes the generator can trade
+var Universes = map[string][]string{
+	"mixed": {
+		"AAPL", "GOOGL", "MSFT", "AMZN", "TSLA",
+		"JPM", "BAC", "GS", "MS", "WFC",
+		"BTC", "ETH", "GOLD", "SILVER", "OIL",
+	},
+	"equities":    {"AAPL", "GOOGL", "MSFT", "AMZN", "TSLA", "JPM", "BAC", "GS", "MS", "WFC"},
+	"banks":       {"JPM", "BAC", "GS", "MS", "WFC"},
+	"crypto":      {"BTC", "ETH"},
+	"commodities": {"GOLD", "SILVER", "OIL"},
+}
+
+// seedPrices are the prices symbols trade at before any price has been ingested
+var seedPrices = map[string]float64{
+	"AAPL":   150.00,
+	"GOOGL":  2800.00,
+	"MSFT":   300.00,
+	"AMZN":   3300.00,
+	"TSLA":   800.00,
+	"JPM":    140.00,
+	"BAC":    35.00,
+	"GS":     350.00,
+	"MS":     90.00,
+	"WFC":    45.00,
+	"BTC":    45000.00,
+	"ETH":    3000.00,
+	"GOLD":   1800.00,
+	"SILVER": 25.00,
+	"OIL":    75.00,
+}
+
+// Bounds of the profile settings accepted from the control API
+const (
+	minTickInterval = time.Second
+	maxTickInterval = time.Hour
+)
+
+// Profile tunes what the generator produces and how often
+type Profile struct {
+	Universe            string        `json:"universe"`
+	Symbols             []string      `json:"symbols"`
+	TransactionInterval time.Duration `json:"-"`
+	RiskMetricInterval  time.Duration `json:"-"`
+	AlertInterval       time.Duration `json:"-"`
+	AlertProbability    float64       `json:"alert_probability"` // Chance of an alert on each alert tick
+	AMLThreshold        float64       `json:"aml_threshold"`     // Transaction amount that raises an AML alert
+}
+
+// DefaultProfile trades the mixed universe with the generator's original intervals
+func DefaultProfile() Profile {
+	return Profile{
+		Universe:            "mixed",
+		Symbols:             Universes["mixed"],
+		TransactionInterval: 10 * time.Second,
+		RiskMetricInterval:  15 * time.Second,
+		AlertInterval:       30 * time.Second,
+		AlertProbability:    0.3,
+		AMLThreshold:        10000,
+	}
+}
+
+// MockProfileRequest changes the settings it sets. Symbols replace the universe's symbols; a
+// universe alone selects all of its symbols.
+type MockProfileRequest struct {
+	Universe                   *string  `json:"universe" yaml:"universe"`
+	Symbols                    []string `json:"symbols" yaml:"symbols"`
+	TransactionIntervalSeconds *int     `json:"transaction_interval_seconds" yaml:"transaction_interval_seconds"`
+	RiskMetricIntervalSeconds  *int     `json:"risk_metric_interval_seconds" yaml:"risk_metric_interval_seconds"`
+	AlertIntervalSeconds       *int     `json:"alert_interval_seconds" yaml:"alert_interval_seconds"`
+	AlertProbability           *float64 `json:"alert_probability" yaml:"alert_probability"`
+	AMLThreshold               *float64 `json:"aml_threshold" yaml:"aml_threshold"`
+}
+
+// Apply returns the profile with the update's settings, or an error naming the first invalid one.
+// Symbols must be among those the generator has prices for.
+func (u MockProfileRequest) Apply(p Profile, prices map[string]float64) (Profile, error) {
+	if u.Universe != nil {
+		symbols, ok := Universes[*u.Universe]
+		if !ok {
+			return p, fmt.Errorf("universe must be one of %s", strings.Join(UniverseNames(), ", "))
+		}
+		p.Universe, p.Symbols = *u.Universe, symbols
+	}
+	if u.Symbols != nil {
+		symbols := make([]string, 0, len(u.Symbols))
+		for _, symbol := range u.Symbols {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			if _, ok := prices[symbol]; !ok {
+				return p, fmt.Errorf("unknown symbol %q", symbol)
+			}
+			if !slices.Contains(symbols, symbol) {
+				symbols = append(symbols, symbol)
+			}
+		}
+		if len(symbols) == 0 {
+			return p, errors.New("symbols must not be empty")
+		}
+		p.Universe, p.Symbols = "custom", symbols
+	}
+
+	intervals := []struct {
+		name    string
+		seconds *int
+		target  *time.Duration
+	}{
+		{"transaction_interval_seconds", u.TransactionIntervalSeconds, &p.TransactionInterval},
+		{"risk_metric_interval_seconds", u.RiskMetricIntervalSeconds, &p.RiskMetricInterval},
+		{"alert_interval_seconds", u.AlertIntervalSeconds, &p.AlertInterval},
+	}
+	for _, interval := range intervals {
+		if interval.seconds == nil {
+			continue
+		}
+		d := time.Duration(*interval.seconds) * time.Second
+		if d < minTickInterval || d > maxTickInterval {
+			return p, fmt.Errorf("%s must be between %d and %d", interval.name, int(minTickInterval.Seconds()), int(maxTickInterval.Seconds()))
+		}
+		*interval.target = d
+	}
+
+	if u.AlertProbability != nil {
+		if *u.AlertProbability < 0 || *u.AlertProbability > 1 {
+			return p, errors.New("alert_probability must be between 0 and 1")
+		}
+		p.AlertProbability = *u.AlertProbability
+	}
+	if u.AMLThreshold != nil {
+		if *u.AMLThreshold <= 0 {
+			return p, errors.New("aml_threshold must be positive")
+		}
+		p.AMLThreshold = *u.AMLThreshold
+	}
+	return p, nil
+}
+
+// UniverseNames returns the names of the symbol universes in order
+func UniverseNames() []string {
+	names := make([]string, 0, len(Universes))
+	for name := range Universes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,251 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Scenarios that can be injected into the generated data
+const (
+	ScenarioFlashCrash     = "flash_crash"     // Prices of the symbols drop at once
+	ScenarioAMLStructuring = "aml_structuring" // A burst of transactions just below the AML threshold
+	ScenarioAlertStorm     = "alert_storm"     // Many alerts across portfolios at once
+)
+
+// Scenario parameter defaults and bounds
+const (
+	defaultCrashDrop        = 0.2
+	defaultStructuringCount = 5
+	defaultAlertStormCount  = 50
+	maxStructuringCount     = 100
+	maxAlertStormCount      = 1000
+)
+
+// ErrNoPortfolios is returned when a scenario needs portfolios and there are none
+var ErrNoPortfolios = errors.New("no portfolios to generate data for")
+
+// MockScenarioRequest selects a scenario and its parameters; unset parameters take their defaults
+type MockScenarioRequest struct {
+	Scenario    string     `json:"scenario" validate:"required"`
+	PortfolioID *uuid.UUID `json:"portfolio_id"` // Structuring: the oldest portfolio when unset; alert storm: every portfolio
+	Symbols     []string   `json:"symbols"`      // Flash crash: the profile's symbols when unset
+	Drop        float64    `json:"drop"`         // Flash crash: fraction the prices fall by, 0.2 by default
+	Count       int        `json:"count"`        // Structuring: 5 transactions by default; alert storm: 50 alerts
+}
+
+// MockScenarioResult is what an injected scenario produced
+type MockScenarioResult struct {
+	Scenario     string             `json:"scenario"`
+	Prices       map[string]float64 `json:"prices,omitempty"`
+	Transactions int                `json:"transactions"`
+	Alerts       int                `json:"alerts"`
+}
+
+// ScenarioNames returns the scenarios that can be injected
+func ScenarioNames() []string {
+	return []string{ScenarioAlertStorm, ScenarioAMLStructuring, ScenarioFlashCrash}
+}
+
+// Inject produces a scenario's data at once, whether or not the generator is running. The same
+// request against the same portfolios and prices produces the same data.
+func (m *MockDataGenerator) Inject(ctx context.Context, req MockScenarioRequest) (*MockScenarioResult, error) {
+	profile, _, _ := m.state()
+
+	switch req.Scenario {
+	case ScenarioFlashCrash:
+		drop := req.Drop
+		if drop == 0 {
+			drop = defaultCrashDrop
+		}
+		if drop <= 0 || drop >= 1 {
+			return nil, errors.New("drop must be between 0 and 1")
+		}
+		symbols := profile.Symbols
+		if len(req.Symbols) > 0 {
+			update, err := MockProfileRequest{Symbols: req.Symbols}.Apply(profile, m.prices)
+			if err != nil {
+				return nil, err
+			}
+			symbols = update.Symbols
+		}
+		return m.flashCrash(ctx, symbols, drop)
+
+	case ScenarioAMLStructuring:
+		count, err := scenarioCount(req.Count, defaultStructuringCount, maxStructuringCount)
+		if err != nil {
+			return nil, err
+		}
+		portfolios, err := scenarioPortfolios(req.PortfolioID)
+		if err != nil {
+			return nil, err
+		}
+		return m.structuringBurst(ctx, portfolios[0], profile, count), nil
+
+	case ScenarioAlertStorm:
+		count, err := scenarioCount(req.Count, defaultAlertStormCount, maxAlertStormCount)
+		if err != nil {
+			return nil, err
+		}
+		portfolios, err := scenarioPortfolios(req.PortfolioID)
+		if err != nil {
+			return nil, err
+		}
+		return m.alertStorm(ctx, portfolios, count), nil
+
+	default:
+		return nil, fmt.Errorf("scenario must be one of %s", strings.Join(ScenarioNames(), ", "))
+	}
+}
+
+// flashCrash publishes the symbols' prices cut by drop and raises a CRITICAL alert for each
+// portfolio holding one of them
+func (m *MockDataGenerator) flashCrash(ctx context.Context, symbols []string, drop float64) (*MockScenarioResult, error) {
+	result := &MockScenarioResult{Scenario: ScenarioFlashCrash, Prices: make(map[string]float64, len(symbols))}
+	updates := make(map[string]interface{}, len(symbols))
+	now := time.Now().Unix()
+	for _, symbol := range symbols {
+		price := math.Round(m.currentPrice(symbol)*(1-drop)*100) / 100
+		result.Prices[symbol] = price
+		updates[symbol] = map[string]interface{}{
+			"price":     price,
+			"change":    -drop * 100,
+			"timestamp": now,
+		}
+	}
+	if err := m.hub.PublishPrices(updates); err != nil {
+		return nil, err
+	}
+
+	var portfolios []models.Portfolio
+	err := database.GetDB().WithContext(ctx).
+		Where("id IN (?)", database.GetDB().Model(&models.Position{}).Select("portfolio_id").Where("symbol IN ?", symbols)).
+		Order("created_at").Find(&portfolios).Error
+	if err != nil {
+		return nil, err
+	}
+
+	crash := alertTemplate{
+		Type:        "RISK_BREACH",
+		Severity:    "CRITICAL",
+		Title:       "Flash Crash",
+		Description: fmt.Sprintf("Prices of %s fell %.0f%% at once", strings.Join(symbols, ", "), drop*100),
+		Source:      "MARKET_MONITOR",
+	}
+	for _, portfolio := range portfolios {
+		if m.createAlert(ctx, portfolio, crash, models.JSON{"scenario": ScenarioFlashCrash, "drop": drop, "symbols": symbols}) {
+			result.Alerts++
+		}
+	}
+
+	m.logger.InfoContext(ctx, "Injected flash crash", "symbols", symbols, "drop", drop, "alerts", result.Alerts)
+	return result, nil
+}
+
+// structuringBurst publishes count buys of the profile's first symbol spread evenly from 90% to
+// just below the AML threshold, then the alert transaction monitoring would raise for them
+func (m *MockDataGenerator) structuringBurst(ctx context.Context, portfolio models.Portfolio, profile Profile, count int) *MockScenarioResult {
+	result := &MockScenarioResult{Scenario: ScenarioAMLStructuring}
+	symbol := profile.Symbols[0]
+	price := decimal.NewFromFloat(m.currentPrice(symbol))
+	now := time.Now()
+
+	amounts := make([]float64, count)
+	for i := range count {
+		share := 0.9
+		if count > 1 {
+			share += 0.09 * float64(i) / float64(count-1)
+		}
+		amount := decimal.NewFromFloat(profile.AMLThreshold * share).Round(2)
+		amounts[i] = amount.InexactFloat64()
+
+		executedAt := now
+		transaction := models.Transaction{
+			ID:               uuid.New(),
+			PortfolioID:      portfolio.ID,
+			TransactionType:  "BUY",
+			Symbol:           symbol,
+			Quantity:         amount.Div(price).Round(8),
+			Price:            price,
+			Amount:           amount,
+			Currency:         "USD",
+			Status:           "COMPLETED",
+			ExecutedAt:       &executedAt,
+			KYCVerified:      true,
+			AMLChecked:       true,
+			CreatedAt:        now,
+			OrderStatus:      models.OrderStatusFilled,
+			FilledQuantity:   amount.Div(price).Round(8),
+			AverageFillPrice: price,
+		}
+		m.broadcastTransaction(ctx, transaction)
+		result.Transactions++
+	}
+
+	structuring := alertTemplate{
+		Type:        "SUSPICIOUS_ACTIVITY",
+		Severity:    "HIGH",
+		Title:       "Possible Structuring",
+		Description: fmt.Sprintf("%d transactions just below %.2f", count, profile.AMLThreshold),
+		Source:      "AML_CHECKER",
+	}
+	if m.createAlert(ctx, portfolio, structuring, models.JSON{"scenario": ScenarioAMLStructuring, "amounts": amounts, "symbol": symbol}) {
+		result.Alerts++
+	}
+
+	m.logger.InfoContext(ctx, "Injected AML structuring burst", "portfolio_id", portfolio.ID, "transactions", result.Transactions)
+	return result
+}
+
+// alertStorm raises count alerts, cycling through the portfolios and the alert templates
+func (m *MockDataGenerator) alertStorm(ctx context.Context, portfolios []models.Portfolio, count int) *MockScenarioResult {
+	result := &MockScenarioResult{Scenario: ScenarioAlertStorm}
+	for i := range count {
+		portfolio := portfolios[i%len(portfolios)]
+		if m.createAlert(ctx, portfolio, alertTemplates[i%len(alertTemplates)], models.JSON{"scenario": ScenarioAlertStorm, "sequence": i + 1}) {
+			result.Alerts++
+		}
+	}
+
+	m.logger.InfoContext(ctx, "Injected alert storm", "portfolios", len(portfolios), "alerts", result.Alerts)
+	return result
+}
+
+// scenarioCount returns count, or the default when it is unset, within 1 and max
+func scenarioCount(count, defaultCount, maxCount int) (int, error) {
+	if count == 0 {
+		return defaultCount, nil
+	}
+	if count < 1 || count > maxCount {
+		return 0, fmt.Errorf("count must be between 1 and %d", maxCount)
+	}
+	return count, nil
+}
+
+// scenarioPortfolios returns the requested portfolio, or every portfolio oldest first
+func scenarioPortfolios(portfolioID *uuid.UUID) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	query := database.GetDB().Order("created_at")
+	if portfolioID != nil {
+		query = query.Where("id = ?", *portfolioID)
+	}
+	if err := query.Find(&portfolios).Error; err != nil {
+		return nil, err
+	}
+	if len(portfolios) == 0 {
+		if portfolioID != nil {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, ErrNoPortfolios
+	}
+	return portfolios, nil
+}
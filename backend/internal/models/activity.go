@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Portfolio activity types
+const (
+	ActivityTransaction     = "TRANSACTION"      // A transaction was recorded
+	ActivityAlert           = "ALERT"            // An alert was raised
+	ActivityThresholdChange = "THRESHOLD_CHANGE" // Risk limits were set or changed
+	ActivityStatusChange    = "STATUS_CHANGE"    // A transaction, order or alert changed status
+	ActivityAmendment       = "AMENDMENT"        // An amendment or cancellation of a transaction was requested or decided
+)
+
+// PortfolioActivity is an entry of a portfolio's timeline. Entries are written by database
+// triggers on transactions, alerts, risk thresholds and transaction amendments, never by the
+// application.
+type PortfolioActivity struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID  uuid.UUID `gorm:"type:uuid;not null" json:"portfolio_id"`
+	ActivityType string    `gorm:"type:varchar(30);not null" json:"activity_type"`
+	EntityType   string    `gorm:"type:varchar(30);not null" json:"entity_type"` // transaction, alert, risk_thresholds, transaction_amendment
+	EntityID     uuid.UUID `gorm:"type:uuid;not null" json:"entity_id"`
+	Summary      string    `gorm:"not null" json:"summary"`
+	Details      JSON      `gorm:"type:jsonb" json:"details"` // What was recorded, or the statuses or limits before and after
+	OccurredAt   time.Time `gorm:"not null" json:"occurred_at"`
+}
@@ -10,23 +10,32 @@ import (
 type Alert struct {
 	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
 	PortfolioID    uuid.UUID  `gorm:"type:uuid;not null" json:"portfolio_id"`
-	AlertType      string     `gorm:"not null" json:"alert_type"` // RISK_BREACH, COMPLIANCE_VIOLATION, SUSPICIOUS_ACTIVITY
-	Severity       string     `gorm:"not null" json:"severity"`   // LOW, MEDIUM, HIGH, CRITICAL
+	TenantID       *uuid.UUID `gorm:"type:uuid;->" json:"tenant_id,omitempty"` // Set by the database from the portfolio's tenant
+	AlertType      string     `gorm:"not null" json:"alert_type"`              // RISK_BREACH, COMPLIANCE_VIOLATION, SUSPICIOUS_ACTIVITY
+	Severity       string     `gorm:"not null" json:"severity"`                // LOW, MEDIUM, HIGH, CRITICAL
 	Title          string     `gorm:"not null" json:"title"`
 	Description    string     `json:"description"`
 	Source         string     `json:"source"`                         // VAR_CALCULATOR, POSITION_LIMIT_CHECKER, AML_CHECKER, etc.
-	Status         string     `gorm:"default:'ACTIVE'" json:"status"` // ACTIVE, ACKNOWLEDGED, RESOLVED, DISMISSED
+	Status         string     `gorm:"default:'ACTIVE'" json:"status"` // ACTIVE, SUPPRESSED, ACKNOWLEDGED, ESCALATED, RESOLVED, DISMISSED
 	TriggeredBy    JSON       `gorm:"type:jsonb" json:"triggered_by"` // Details of what triggered the alert
 	Resolution     string     `json:"resolution"`
 	AcknowledgedBy *uuid.UUID `gorm:"type:uuid" json:"acknowledged_by"`
 	AcknowledgedAt *time.Time `json:"acknowledged_at"`
 	ResolvedBy     *uuid.UUID `gorm:"type:uuid" json:"resolved_by"`
 	ResolvedAt     *time.Time `json:"resolved_at"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	// Escalation tiers reached so far while unacknowledged, under EscalationPolicyID
+	EscalationLevel    int        `gorm:"default:0" json:"escalation_level"`
+	EscalationPolicyID *uuid.UUID `gorm:"type:uuid" json:"escalation_policy_id"`
+	// Suppression window the alert was raised in, which tagged it SUPPRESSED instead of ACTIVE
+	SuppressionID *uuid.UUID     `gorm:"type:uuid" json:"suppression_id,omitempty"`
+	Version       int64          `gorm:"not null;default:1" json:"version"` // Raised by the database with each change
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	// Relations
-	Portfolio Portfolio `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty"`
+	Portfolio   Portfolio         `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty"`
+	Escalations []AlertEscalation `gorm:"foreignKey:AlertID;constraint:OnDelete:CASCADE" json:"escalations,omitempty"`
 }
 
 func (a *Alert) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertStatusSuppressed is the status of an alert raised inside a suppression window. It is kept
+// for the record but not notified, escalated or counted with the active alerts.
+const AlertStatusSuppressed = "SUPPRESSED"
+
+// AlertSuppression silences the alerts of a portfolio, a compliance rule or an alert source from
+// StartsAt until EndsAt, e.g. the expected breaches of a planned rebalance. Each set field narrows
+// the window, and a window without a portfolio covers every portfolio. Cancelling a window ends it
+// early and keeps it for the record.
+type AlertSuppression struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID *uuid.UUID `gorm:"type:uuid;index" json:"portfolio_id"`
+	RuleID      *uuid.UUID `gorm:"type:uuid" json:"rule_id"` // Compliance rule whose breach alerts are suppressed
+	Source      string     `gorm:"type:varchar(100)" json:"source,omitempty"`
+	StartsAt    time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt      time.Time  `gorm:"not null" json:"ends_at"`
+	Reason      string     `gorm:"not null" json:"reason"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CancelledBy *uuid.UUID `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (s *AlertSuppression) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
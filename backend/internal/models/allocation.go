@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// What a target allocation weight applies to
+const (
+	AllocationKindSymbol     = "SYMBOL"
+	AllocationKindAssetClass = "ASSET_CLASS"
+)
+
+// TargetAllocation is the weight a portfolio aims to hold in one symbol or asset class, as a
+// fraction of its positions and cash, and how far it may drift either side before rebalancing
+type TargetAllocation struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID  uuid.UUID       `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	Kind         string          `gorm:"type:varchar(20);not null" json:"kind"` // SYMBOL or ASSET_CLASS
+	Key          string          `gorm:"type:varchar(30);not null" json:"key"`  // Symbol, or asset class as in the concentration breakdown
+	TargetWeight decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"target_weight"`
+	Tolerance    decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"tolerance"` // Allowed drift either side of the target
+	UpdatedBy    *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+func (t *TargetAllocation) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+	return nil
+}
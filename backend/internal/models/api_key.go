@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates an external system such as an OMS or data pipeline. The key acts as its
+// owner, limited to its scopes, so it never holds more permissions than the owner's role grants.
+// Only a hash of the key is stored; the key itself is shown once when minted.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // Leading characters, to tell keys apart
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // Owner the key acts as
+	Scopes     []string   `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	RateLimit  int        `gorm:"not null" json:"rate_limit"` // Requests per minute
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	k.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable is returned when code attempts to modify a recorded audit entry
+var ErrAuditLogImmutable = errors.New("audit log entries are immutable")
+
+// AuditLog records who changed what, with snapshots of the entity before and after the change
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	UserEmail  string     `json:"user_email"`
+	UserRole   string     `json:"user_role"`
+	Action     string     `gorm:"not null;index" json:"action"`      // e.g. transaction.status_update, alert.acknowledge
+	EntityType string     `gorm:"not null;index" json:"entity_type"` // portfolio, transaction, alert, compliance_rule, request...
+	EntityID   string     `gorm:"index" json:"entity_id"`
+	Before     JSON       `gorm:"type:jsonb" json:"before"`
+	After      JSON       `gorm:"type:jsonb" json:"after"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Batch pipelines
+const (
+	BatchPipelineEOD = "eod" // End-of-day snapshots, risk calculations, compliance sweeps and reports
+)
+
+// What started a batch run
+const (
+	BatchSourceSchedule = "schedule"
+	BatchSourceManual   = "manual" // Started through the API
+)
+
+// Batch run and job statuses. A job is skipped when a job it depends on did not succeed, or the
+// run was stopped before it started.
+const (
+	BatchStatusPending   = "PENDING"
+	BatchStatusRunning   = "RUNNING"
+	BatchStatusSucceeded = "SUCCEEDED"
+	BatchStatusFailed    = "FAILED"
+	BatchStatusSkipped   = "SKIPPED"
+)
+
+// BatchRun is one execution of a batch pipeline. It fails if any of its jobs did not succeed.
+type BatchRun struct {
+	ID           uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	Pipeline     string        `gorm:"type:varchar(50);not null" json:"pipeline"`
+	BusinessDate time.Time     `gorm:"type:date;not null" json:"business_date"`
+	Source       string        `gorm:"type:varchar(20);not null" json:"source"` // schedule, manual
+	Status       string        `gorm:"type:varchar(20);not null" json:"status"` // RUNNING, SUCCEEDED, FAILED
+	Error        string        `json:"error,omitempty"`
+	TriggeredBy  *uuid.UUID    `gorm:"type:uuid" json:"triggered_by,omitempty"`
+	StartedAt    time.Time     `gorm:"not null;index" json:"started_at"`
+	FinishedAt   *time.Time    `json:"finished_at"`
+	Jobs         []BatchJobRun `gorm:"foreignKey:RunID" json:"jobs,omitempty"`
+}
+
+func (r *BatchRun) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
+
+// BatchJobRun is the outcome of one job in a batch run
+type BatchJobRun struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	RunID      uuid.UUID  `gorm:"type:uuid;not null" json:"run_id"`
+	Name       string     `gorm:"type:varchar(50);not null" json:"name"`
+	Position   int        `gorm:"not null" json:"position"` // Order the pipeline lists the job in
+	DependsOn  []string   `gorm:"type:jsonb;serializer:json" json:"depends_on"`
+	Status     string     `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts   int        `json:"attempts"`
+	Output     string     `json:"output,omitempty"` // Summary of what the job did
+	Error      string     `json:"error,omitempty"`  // Error of the last attempt
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (j *BatchJobRun) BeforeCreate(tx *gorm.DB) error {
+	j.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Sources of benchmark prices
+const (
+	BenchmarkSourceFeed   = "FEED"
+	BenchmarkSourceManual = "MANUAL"
+)
+
+// BenchmarkPrice is the daily closing level of a benchmark index or ETF. The row for the current
+// day is overwritten by each price feed batch, so it holds the latest level until the day ends.
+type BenchmarkPrice struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Symbol    string          `gorm:"type:varchar(20);not null;uniqueIndex:idx_benchmark_prices_symbol_date" json:"symbol"`
+	Date      time.Time       `gorm:"type:date;not null;uniqueIndex:idx_benchmark_prices_symbol_date" json:"date"`
+	Close     decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"close"`
+	Source    string          `gorm:"type:varchar(10);not null" json:"source"` // FEED or MANUAL
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (p *BenchmarkPrice) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CreditRatings is the rating scale from best to worst
+var CreditRatings = []string{
+	"AAA", "AA+", "AA", "AA-", "A+", "A", "A-",
+	"BBB+", "BBB", "BBB-", "BB+", "BB", "BB-", "B+", "B", "B-",
+	"CCC+", "CCC", "CCC-", "CC", "C", "D",
+}
+
+// CreditRatingRank is the position of a rating on the scale, 0 for AAA, or -1 for an unknown rating
+func CreditRatingRank(rating string) int {
+	return slices.Index(CreditRatings, strings.ToUpper(strings.TrimSpace(rating)))
+}
+
+// IsInvestmentGrade reports whether a rating is BBB- or better
+func IsInvestmentGrade(rating string) bool {
+	rank := CreditRatingRank(rating)
+	return rank >= 0 && rank <= CreditRatingRank("BBB-")
+}
+
+// BondReference is the reference data a bond held in positions is priced and analysed from. Prices
+// of the bond's positions are per unit of FaceValue, in the position currency.
+type BondReference struct {
+	Symbol          string          `gorm:"primaryKey;type:varchar(20)" json:"symbol"`
+	CouponRate      decimal.Decimal `gorm:"type:decimal(10,6);not null" json:"coupon_rate"` // Annual, as a fraction of face value
+	CouponFrequency int             `gorm:"not null;default:2" json:"coupon_frequency"`     // Payments per year: 0 (zero coupon), 1, 2, 4 or 12
+	MaturityDate    time.Time       `gorm:"type:date;not null" json:"maturity_date"`
+	FaceValue       decimal.Decimal `gorm:"type:decimal(20,8);not null;default:100" json:"face_value"` // Per unit held
+	CreditRating    string          `gorm:"type:varchar(4)" json:"credit_rating,omitempty"`
+	Issuer          string          `json:"issuer,omitempty"`
+	UpdatedBy       *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+func (BondReference) TableName() string {
+	return "bond_reference_data"
+}
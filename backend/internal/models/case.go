@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Case statuses. A case moves OPEN -> INVESTIGATING -> FILED or DISMISSED; FILED and DISMISSED are final.
+const (
+	CaseStatusOpen          = "OPEN"
+	CaseStatusInvestigating = "INVESTIGATING"
+	CaseStatusFiled         = "FILED"
+	CaseStatusDismissed     = "DISMISSED"
+)
+
+// Case timeline entry kinds
+const (
+	CaseNoteComment      = "NOTE"
+	CaseNoteEscalation   = "ESCALATION"
+	CaseNoteStatusChange = "STATUS_CHANGE"
+	CaseNoteAssignment   = "ASSIGNMENT"
+	CaseNoteEvidence     = "EVIDENCE"
+	CaseNoteTransaction  = "TRANSACTION"
+)
+
+// Case is a suspicious activity investigation that may end in a Suspicious Activity Report filing
+type Case struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	CaseNumber      string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"case_number"`
+	Title           string     `gorm:"not null" json:"title"`
+	Description     string     `json:"description"`
+	Status          string     `gorm:"type:varchar(20);default:'OPEN';index" json:"status"` // OPEN, INVESTIGATING, FILED, DISMISSED
+	Priority        string     `gorm:"type:varchar(20);default:'MEDIUM'" json:"priority"`   // LOW, MEDIUM, HIGH, CRITICAL
+	AlertID         *uuid.UUID `gorm:"type:uuid;index" json:"alert_id"`                     // Alert the case was escalated from
+	AssigneeID      *uuid.UUID `gorm:"type:uuid;index" json:"assignee_id"`
+	CreatedBy       *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	Narrative       string     `json:"narrative"`        // SAR narrative; required before filing
+	FilingReference string     `json:"filing_reference"` // Reference issued by the regulator on filing
+	FiledBy         *uuid.UUID `gorm:"type:uuid" json:"filed_by"`
+	FiledAt         *time.Time `json:"filed_at"`
+	DismissedBy     *uuid.UUID `gorm:"type:uuid" json:"dismissed_by"`
+	DismissedAt     *time.Time `json:"dismissed_at"`
+	DismissalReason string     `json:"dismissal_reason"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Assignee     *User          `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
+	Alert        *Alert         `gorm:"foreignKey:AlertID" json:"alert,omitempty"`
+	Transactions []Transaction  `gorm:"many2many:case_transactions" json:"transactions,omitempty"`
+	Notes        []CaseNote     `gorm:"foreignKey:CaseID" json:"notes,omitempty"`
+	Evidence     []CaseEvidence `gorm:"foreignKey:CaseID" json:"evidence,omitempty"`
+}
+
+func (c *Case) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+	return nil
+}
+
+// IsClosed reports whether the case has reached a final status
+func (c *Case) IsClosed() bool {
+	return c.Status == CaseStatusFiled || c.Status == CaseStatusDismissed
+}
+
+// CaseNote is an entry on a case timeline: an investigator's note or a system record of a change
+type CaseNote struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	CaseID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"case_id"`
+	AuthorID  *uuid.UUID `gorm:"type:uuid" json:"author_id"`
+	Kind      string     `gorm:"type:varchar(20);default:'NOTE'" json:"kind"` // NOTE, ESCALATION, STATUS_CHANGE, ASSIGNMENT, EVIDENCE, TRANSACTION
+	Body      string     `gorm:"not null" json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relations
+	Author *User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+}
+
+func (n *CaseNote) BeforeCreate(tx *gorm.DB) error {
+	n.ID = uuid.New()
+	return nil
+}
+
+// CaseEvidence is a file attached to a case. The content is stored with the record and only
+// returned through the download endpoint.
+type CaseEvidence struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	CaseID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"case_id"`
+	FileName    string     `gorm:"not null" json:"file_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	SHA256      string     `gorm:"type:varchar(64)" json:"sha256"`
+	Description string     `json:"description"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	UploadedBy  *uuid.UUID `gorm:"type:uuid" json:"uploaded_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (e *CaseEvidence) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
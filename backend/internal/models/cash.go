@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Cash ledger entry types
+const (
+	CashEntryDeposit    = "DEPOSIT"
+	CashEntryWithdrawal = "WITHDRAWAL"
+	CashEntryBuy        = "BUY"
+	CashEntrySell       = "SELL"
+	CashEntryDividend   = "DIVIDEND"   // Cash dividend paid by a corporate action
+	CashEntryAdjustment = "ADJUSTMENT" // Cash balance set directly on the portfolio
+	CashEntryReversal   = "REVERSAL"   // A completed transaction moved to another status
+)
+
+// CashLedgerEntry is one movement of a portfolio's cash balance. Entries are never changed, so the
+// ledger of a portfolio sums to its cash balance.
+type CashLedgerEntry struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	TransactionID *uuid.UUID      `gorm:"type:uuid;index" json:"transaction_id,omitempty"`
+	EntryType     string          `gorm:"type:varchar(20);not null" json:"entry_type"`
+	Amount        decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"amount"`        // Signed, in the portfolio currency
+	BalanceAfter  decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"balance_after"` // In the portfolio currency
+	Description   string          `json:"description,omitempty"`
+	CreatedBy     *uuid.UUID      `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+
+	// Relations
+	Portfolio *Portfolio `gorm:"foreignKey:PortfolioID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (CashLedgerEntry) TableName() string {
+	return "cash_ledger"
+}
+
+func (e *CashLedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ComplianceRule is an admin-defined rule evaluated against portfolios or transactions
+type ComplianceRule struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string          `gorm:"not null" json:"name"`
+	Description string          `json:"description"`
+	Scope       string          `gorm:"type:varchar(20);not null" json:"scope"`   // PORTFOLIO, TRANSACTION
+	Metric      string          `gorm:"type:varchar(50);not null" json:"metric"`  // MAX_POSITION_WEIGHT, AMOUNT, etc.
+	Parameter   string          `json:"parameter"`                                // Metric argument such as an asset type or symbol
+	Operator    string          `gorm:"type:varchar(5);not null" json:"operator"` // GT, GTE, LT, LTE, EQ, NEQ
+	Threshold   decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"threshold"`
+	Severity    string          `gorm:"type:varchar(20);not null" json:"severity"` // LOW, MEDIUM, HIGH, CRITICAL
+	PortfolioID *uuid.UUID      `gorm:"type:uuid" json:"portfolio_id"`             // Nil applies the rule to every portfolio
+	IsActive    bool            `gorm:"default:true" json:"is_active"`
+	CreatedBy   *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (r *ComplianceRule) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Corporate action types
+const (
+	CorporateActionSplit         = "SPLIT"          // RatioTo new shares for every RatioFrom held; a reverse split has RatioTo < RatioFrom
+	CorporateActionCashDividend  = "CASH_DIVIDEND"  // CashAmount per share held, paid in Currency
+	CorporateActionStockDividend = "STOCK_DIVIDEND" // RatioTo new shares for every RatioFrom held, on top of those held
+	CorporateActionSymbolChange  = "SYMBOL_CHANGE"  // Symbol becomes NewSymbol
+)
+
+// Corporate action statuses
+const (
+	CorporateActionPending    = "PENDING"
+	CorporateActionApplied    = "APPLIED"
+	CorporateActionRolledBack = "ROLLED_BACK"
+)
+
+// What a corporate action adjustment changed
+const (
+	AdjustmentPosition    = "POSITION"
+	AdjustmentLot         = "LOT"
+	AdjustmentLotClosure  = "LOT_CLOSURE" // A sale of a renamed symbol
+	AdjustmentTransaction = "TRANSACTION" // An open order resized by a split, or a trade renamed
+	AdjustmentSnapshot    = "SNAPSHOT"    // A NAV snapshot's positions, the price history of VaR
+	AdjustmentDividend    = "DIVIDEND"    // A DIVIDEND transaction paying a cash dividend
+)
+
+// CorporateAction is a split, dividend or symbol change of a security, applied to every portfolio
+// holding it on or after its ex-date
+type CorporateAction struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Symbol       string          `gorm:"type:varchar(10);not null;index" json:"symbol"`
+	ActionType   string          `gorm:"type:varchar(20);not null" json:"action_type"` // SPLIT, CASH_DIVIDEND, STOCK_DIVIDEND, SYMBOL_CHANGE
+	ExDate       time.Time       `gorm:"type:date;not null" json:"ex_date"`
+	PayDate      *time.Time      `gorm:"type:date" json:"pay_date,omitempty"`
+	RatioFrom    decimal.Decimal `gorm:"type:decimal(20,8)" json:"ratio_from,omitempty"`
+	RatioTo      decimal.Decimal `gorm:"type:decimal(20,8)" json:"ratio_to,omitempty"`
+	CashAmount   decimal.Decimal `gorm:"type:decimal(20,8)" json:"cash_amount,omitempty"` // Per share
+	Currency     string          `gorm:"type:varchar(3)" json:"currency,omitempty"`
+	NewSymbol    string          `gorm:"type:varchar(10)" json:"new_symbol,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Status       string          `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
+	AppliedAt    *time.Time      `json:"applied_at,omitempty"`
+	AppliedBy    *uuid.UUID      `gorm:"type:uuid" json:"applied_by,omitempty"` // Nil when applied by the end-of-day batch
+	RolledBackAt *time.Time      `json:"rolled_back_at,omitempty"`
+	RolledBackBy *uuid.UUID      `gorm:"type:uuid" json:"rolled_back_by,omitempty"`
+	CreatedBy    *uuid.UUID      `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+func (a *CorporateAction) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
+
+// Factor is what a split or stock dividend multiplies quantities by and divides prices by; one for
+// the other actions
+func (a *CorporateAction) Factor() decimal.Decimal {
+	switch a.ActionType {
+	case CorporateActionSplit:
+		return a.RatioTo.Div(a.RatioFrom)
+	case CorporateActionStockDividend:
+		return decimal.NewFromInt(1).Add(a.RatioTo.Div(a.RatioFrom))
+	}
+	return decimal.NewFromInt(1)
+}
+
+// CorporateActionAdjustment is one record a corporate action changed, with the values it had
+// before and after, so the action can be previewed and rolled back
+type CorporateActionAdjustment struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	ActionID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"action_id"`
+	PortfolioID *uuid.UUID       `gorm:"type:uuid" json:"portfolio_id,omitempty"`
+	EntityType  string           `gorm:"type:varchar(20);not null" json:"entity_type"` // POSITION, LOT, LOT_CLOSURE, TRANSACTION, SNAPSHOT, DIVIDEND
+	EntityID    uuid.UUID        `gorm:"type:uuid" json:"entity_id"`                   // Nil in a preview of a dividend not yet paid
+	Before      AdjustmentValues `gorm:"type:jsonb;serializer:json" json:"before"`
+	After       AdjustmentValues `gorm:"type:jsonb;serializer:json" json:"after"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+func (a *CorporateActionAdjustment) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
+
+// AdjustmentValues are the fields of a record a corporate action changes; unchanged fields are nil
+type AdjustmentValues struct {
+	Symbol            string             `json:"symbol,omitempty"`
+	Quantity          *decimal.Decimal   `json:"quantity,omitempty"`
+	RemainingQuantity *decimal.Decimal   `json:"remaining_quantity,omitempty"`
+	AveragePrice      *decimal.Decimal   `json:"average_price,omitempty"`
+	CurrentPrice      *decimal.Decimal   `json:"current_price,omitempty"`
+	CostPrice         *decimal.Decimal   `json:"cost_price,omitempty"`
+	Price             *decimal.Decimal   `json:"price,omitempty"`
+	FilledQuantity    *decimal.Decimal   `json:"filled_quantity,omitempty"`
+	AverageFillPrice  *decimal.Decimal   `json:"average_fill_price,omitempty"`
+	StopLoss          *decimal.Decimal   `json:"stop_loss,omitempty"`
+	TakeProfit        *decimal.Decimal   `json:"take_profit,omitempty"`
+	Amount            *decimal.Decimal   `json:"amount,omitempty"`
+	Currency          string             `json:"currency,omitempty"` // Of a dividend
+	Positions         []SnapshotPosition `json:"positions,omitempty"`
+}
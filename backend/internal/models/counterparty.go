@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Counterparty risk ratings
+const (
+	CounterpartyRiskLow        = "LOW"
+	CounterpartyRiskMedium     = "MEDIUM"
+	CounterpartyRiskHigh       = "HIGH"
+	CounterpartyRiskProhibited = "PROHIBITED"
+)
+
+// Counterparty KYC statuses
+const (
+	KYCStatusPending  = "PENDING"
+	KYCStatusVerified = "VERIFIED"
+	KYCStatusRejected = "REJECTED"
+	KYCStatusExpired  = "EXPIRED"
+)
+
+// Counterparty is a firm or individual the platform's portfolios trade with
+type Counterparty struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Name          string          `gorm:"not null;index" json:"name"`
+	LEI           string          `gorm:"type:varchar(20);index" json:"lei"`   // Legal Entity Identifier
+	EntityType    string          `gorm:"type:varchar(20)" json:"entity_type"` // INDIVIDUAL, ENTITY
+	Jurisdiction  string          `gorm:"not null" json:"jurisdiction"`
+	RiskRating    string          `gorm:"type:varchar(20);default:'MEDIUM'" json:"risk_rating"` // LOW, MEDIUM, HIGH, PROHIBITED
+	KYCStatus     string          `gorm:"type:varchar(20);default:'PENDING'" json:"kyc_status"` // PENDING, VERIFIED, REJECTED, EXPIRED
+	KYCVerifiedAt *time.Time      `json:"kyc_verified_at"`
+	KYCExpiresAt  *time.Time      `json:"kyc_expires_at"`
+	ExposureLimit decimal.Decimal `gorm:"type:decimal(20,2)" json:"exposure_limit"` // Zero means no limit
+	IsActive      bool            `gorm:"default:true" json:"is_active"`
+	Notes         string          `json:"notes"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (c *Counterparty) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+	return nil
+}
+
+// KYCCurrent reports whether the counterparty's KYC is verified and has not lapsed
+func (c *Counterparty) KYCCurrent(now time.Time) bool {
+	if c.KYCStatus != KYCStatusVerified {
+		return false
+	}
+	return c.KYCExpiresAt == nil || c.KYCExpiresAt.After(now)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Venues crypto holdings are kept at
+const (
+	CryptoVenueExchange   = "EXCHANGE"
+	CryptoVenueCustodian  = "CUSTODIAN"
+	CryptoVenueHotWallet  = "HOT_WALLET"
+	CryptoVenueColdWallet = "COLD_WALLET"
+)
+
+// CryptoAsset is the reference data of a crypto asset: the chain it settles on and, for a
+// stablecoin, the price it is pegged to in its peg currency
+type CryptoAsset struct {
+	Symbol       string          `gorm:"primaryKey;type:varchar(20)" json:"symbol"`
+	Chain        string          `gorm:"type:varchar(30);not null" json:"chain"` // BITCOIN, ETHEREUM, SOLANA, ...
+	IsStablecoin bool            `gorm:"default:false" json:"is_stablecoin"`
+	PegCurrency  string          `gorm:"type:varchar(3)" json:"peg_currency,omitempty"`
+	PegPrice     decimal.Decimal `gorm:"type:decimal(20,8)" json:"peg_price"` // Zero for assets without a peg
+	UpdatedBy    *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// CryptoCustody is the quantity of a crypto asset a portfolio keeps at one venue: an exchange or
+// custodian, linked to its counterparty record, or one of the firm's own wallets
+type CryptoCustody struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	Symbol         string          `gorm:"type:varchar(20);not null" json:"symbol"`
+	VenueType      string          `gorm:"type:varchar(20);not null" json:"venue_type"` // EXCHANGE, CUSTODIAN, HOT_WALLET, COLD_WALLET
+	VenueName      string          `gorm:"not null" json:"venue_name"`
+	CounterpartyID *uuid.UUID      `gorm:"type:uuid" json:"counterparty_id,omitempty"` // Exchange or custodian
+	WalletAddress  string          `json:"wallet_address,omitempty"`
+	Quantity       decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"quantity"`
+	UpdatedBy      *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	Counterparty *Counterparty `gorm:"foreignKey:CounterpartyID" json:"counterparty,omitempty"`
+}
+
+func (c *CryptoCustody) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+	return nil
+}
+
+// CryptoPriceSample is the last feed price of a crypto asset within an hour. Crypto trades around
+// the clock, so its volatility is measured from hourly samples rather than daily closes.
+type CryptoPriceSample struct {
+	Symbol string          `gorm:"primaryKey;type:varchar(20)" json:"symbol"`
+	Hour   time.Time       `gorm:"primaryKey" json:"hour"`
+	Price  decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"price"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Escalation target types
+const (
+	EscalationTargetTeam = "TEAM" // Whoever is on call for the team
+	EscalationTargetRole = "ROLE" // Every active user holding the role
+	EscalationTargetUser = "USER"
+)
+
+// Team is a group of users sharing an on-call rotation and, through its members, the portfolios it
+// owns
+type Team struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (t *Team) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+	return nil
+}
+
+// OnCallShift puts a user on call for a team from StartsAt until EndsAt. Overlapping shifts put
+// several users on call at once.
+type OnCallShift struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TeamID    uuid.UUID `gorm:"type:uuid;not null;index:idx_on_call_shifts_team_period" json:"team_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	StartsAt  time.Time `gorm:"not null;index:idx_on_call_shifts_team_period" json:"starts_at"`
+	EndsAt    time.Time `gorm:"not null;index:idx_on_call_shifts_team_period" json:"ends_at"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (s *OnCallShift) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
+
+// EscalationTier is a step of an escalation policy, reached when an alert is still unacknowledged
+// AfterMinutes after it was raised. TargetID is the team or user for TEAM and USER targets.
+type EscalationTier struct {
+	AfterMinutes int        `json:"after_minutes"`
+	TargetType   string     `json:"target_type"` // TEAM, ROLE, USER
+	TargetID     *uuid.UUID `json:"target_id,omitempty"`
+	Role         string     `json:"role,omitempty"`
+}
+
+// EscalationPolicy escalates unacknowledged alerts of a severity, and optionally of one alert
+// type, through its tiers in order
+type EscalationPolicy struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	Name      string           `gorm:"not null" json:"name"`
+	Severity  string           `gorm:"type:varchar(20);not null;default:'CRITICAL'" json:"severity"`
+	AlertType string           `gorm:"type:varchar(50)" json:"alert_type"` // Empty matches every type
+	Tiers     []EscalationTier `gorm:"type:jsonb;serializer:json;not null" json:"tiers"`
+	IsActive  bool             `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+func (p *EscalationPolicy) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
+
+// AlertEscalation records an alert reaching a tier of its escalation policy and who was notified
+type AlertEscalation struct {
+	ID         uuid.UUID   `gorm:"type:uuid;primary_key" json:"id"`
+	AlertID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"alert_id"`
+	PolicyID   uuid.UUID   `gorm:"type:uuid;not null" json:"policy_id"`
+	Tier       int         `gorm:"not null" json:"tier"` // 1-based
+	TargetType string      `gorm:"type:varchar(20);not null" json:"target_type"`
+	TargetID   *uuid.UUID  `gorm:"type:uuid" json:"target_id"`
+	Role       string      `json:"role,omitempty"`
+	Recipients []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"recipients"`
+	Note       string      `json:"note,omitempty"` // Why nobody was notified, e.g. an empty rota
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+func (e *AlertEscalation) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
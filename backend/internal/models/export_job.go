@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Export datasets
+const (
+	ExportDatasetTransactions = "transactions"
+	ExportDatasetAlerts       = "alerts"
+	ExportDatasetRiskHistory  = "risk_history"
+)
+
+// Export job statuses
+const (
+	ExportStatusProcessing = "PROCESSING"
+	ExportStatusCompleted  = "COMPLETED"
+	ExportStatusFailed     = "FAILED"
+)
+
+// ExportJob is an export too large to stream in the response. The file is rendered in the
+// background, stored with the record until ExpiresAt and only returned through the download
+// endpoint, to the user who requested it.
+type ExportJob struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null;index" json:"requested_by"`
+	Dataset     string     `gorm:"type:varchar(30);not null" json:"dataset"` // transactions, alerts, risk_history
+	Format      string     `gorm:"type:varchar(10);not null" json:"format"`  // csv, xlsx
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`  // PROCESSING, COMPLETED, FAILED
+	Filters     string     `json:"filters"`                                  // Query string the export was requested with
+	RowCount    int64      `json:"row_count"`
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	Message     string     `json:"message,omitempty"` // Why the export failed
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+}
+
+func (e *ExportJob) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
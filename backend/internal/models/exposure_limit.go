@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ExposureLimit caps the gross exposure to one symbol summed across every portfolio of a tenant,
+// or of the whole platform when the platform operator sets it without a tenant
+type ExposureLimit struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID    *uuid.UUID      `gorm:"type:uuid" json:"tenant_id,omitempty"`
+	Symbol      string          `gorm:"type:varchar(20);not null" json:"symbol"`
+	MaxExposure decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"max_exposure"`
+	Currency    string          `gorm:"type:varchar(3);not null;default:'USD'" json:"currency"` // Currency MaxExposure is in
+	Notes       string          `json:"notes,omitempty"`
+	UpdatedBy   *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (l *ExposureLimit) BeforeCreate(tx *gorm.DB) error {
+	l.ID = uuid.New()
+	return nil
+}
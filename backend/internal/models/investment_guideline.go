@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// InvestmentGuideline is a portfolio's mandate: what its manager may hold and trade. Each limit
+// is optional; empty lists and nil limits are not enforced.
+type InvestmentGuideline struct {
+	ID                 uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID        uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex" json:"portfolio_id"`
+	AllowedAssetTypes  []string         `gorm:"type:jsonb;serializer:json" json:"allowed_asset_types"` // STOCK, BOND, etc.; empty allows all
+	MaxCryptoPercent   *decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_crypto_percent"`          // % of portfolio in CRYPTO
+	MinCreditRating    string           `gorm:"type:varchar(4)" json:"min_credit_rating"`              // Lowest rating of bonds held, such as BBB-
+	ESGExcludedSymbols []string         `gorm:"column:esg_excluded_symbols;type:jsonb;serializer:json" json:"esg_excluded_symbols"`
+	ESGExcludedSectors []string         `gorm:"column:esg_excluded_sectors;type:jsonb;serializer:json" json:"esg_excluded_sectors"` // Sectors such as ENERGY
+	IsActive           bool             `gorm:"default:true" json:"is_active"`
+	UpdatedBy          *uuid.UUID       `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+}
+
+func (g *InvestmentGuideline) BeforeCreate(tx *gorm.DB) error {
+	g.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job types
+const (
+	JobTypeVaR      = "var"
+	JobTypeBacktest = "backtest"
+	JobTypeReport   = "report"
+)
+
+// Job statuses
+const (
+	JobStatusQueued    = "QUEUED"
+	JobStatusRunning   = "RUNNING"
+	JobStatusCompleted = "COMPLETED"
+	JobStatusFailed    = "FAILED"
+)
+
+// Job is a calculation too slow to run within a request, such as a Monte Carlo VaR or a report.
+// It is queued in Redis and run by a worker, which records its progress and result here until
+// ExpiresAt. Only the user who started a job can see it.
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	JobType     string     `gorm:"type:varchar(30);not null" json:"job_type"` // var, backtest, report
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`   // QUEUED, RUNNING, COMPLETED, FAILED
+	Progress    int        `gorm:"not null;default:0" json:"progress"`        // Percent complete
+	Message     string     `json:"message,omitempty"`                         // The current step, or why the job failed
+	Params      JSON       `gorm:"type:jsonb" json:"params"`
+	Result      JSON       `gorm:"type:jsonb" json:"result,omitempty"`
+	PortfolioID *uuid.UUID `gorm:"type:uuid;index" json:"portfolio_id,omitempty"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null;index" json:"requested_by"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	j.ID = uuid.New()
+	return nil
+}
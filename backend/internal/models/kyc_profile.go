@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// KYC profile subjects
+const (
+	KYCSubjectUser         = "USER" // A portfolio owner
+	KYCSubjectCounterparty = "COUNTERPARTY"
+)
+
+// KYCDocument describes an identity document seen during verification. The document itself is
+// kept outside the platform.
+type KYCDocument struct {
+	Type           string     `json:"type"`      // PASSPORT, NATIONAL_ID, DRIVERS_LICENSE, PROOF_OF_ADDRESS, INCORPORATION_CERTIFICATE
+	Reference      string     `json:"reference"` // Document number, masked to its last four characters
+	IssuingCountry string     `json:"issuing_country"`
+	IssuedAt       *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// KYCProfile is the know-your-customer record of a user or counterparty. A verified profile stays
+// current until its next review is due or one of its documents expires.
+type KYCProfile struct {
+	ID             uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	SubjectType    string        `gorm:"type:varchar(20);not null;uniqueIndex:idx_kyc_profiles_subject,priority:1" json:"subject_type"` // USER, COUNTERPARTY
+	SubjectID      uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_kyc_profiles_subject,priority:2" json:"subject_id"`
+	Status         string        `gorm:"type:varchar(20);default:'PENDING'" json:"status"`     // PENDING, VERIFIED, REJECTED, EXPIRED
+	RiskRating     string        `gorm:"type:varchar(20);default:'MEDIUM'" json:"risk_rating"` // LOW, MEDIUM, HIGH; sets the review cycle
+	Documents      []KYCDocument `gorm:"type:jsonb;serializer:json" json:"documents"`
+	VerifiedAt     *time.Time    `json:"verified_at"`
+	LastReviewedAt *time.Time    `json:"last_reviewed_at"`
+	LastReviewedBy *uuid.UUID    `gorm:"type:uuid" json:"last_reviewed_by"`
+	NextReviewAt   *time.Time    `gorm:"index" json:"next_review_at"`
+	Notes          string        `json:"notes"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+func (p *KYCProfile) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
+
+// DocumentsExpired reports whether any of the profile's documents has expired
+func (p *KYCProfile) DocumentsExpired(now time.Time) bool {
+	for _, doc := range p.Documents {
+		if doc.ExpiresAt != nil && !doc.ExpiresAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveStatus is the profile's status, with a verified profile that has lapsed reported as
+// EXPIRED
+func (p *KYCProfile) EffectiveStatus(now time.Time) string {
+	if p.Status == KYCStatusVerified &&
+		((p.NextReviewAt != nil && !p.NextReviewAt.After(now)) || p.DocumentsExpired(now)) {
+		return KYCStatusExpired
+	}
+	return p.Status
+}
+
+// Current reports whether the profile is verified and has not lapsed
+func (p *KYCProfile) Current(now time.Time) bool {
+	return p.EffectiveStatus(now) == KYCStatusVerified
+}
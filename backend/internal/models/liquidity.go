@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Position liquidity classes, from easiest to hardest to sell
+const (
+	LiquidityHigh   = "HIGH"
+	LiquidityMedium = "MEDIUM"
+	LiquidityLow    = "LOW"
+)
+
+// Where a position's liquidity classification came from
+const (
+	LiquiditySourceMarketData = "MARKET_DATA" // Average daily volume and spread of the symbol
+	LiquiditySourceAssetType  = "ASSET_TYPE"  // Cash-like asset types, liquid whatever the market data
+	LiquiditySourceNone       = "NONE"        // No market data for the symbol; the class is left as it was
+)
+
+// SymbolMarketData is the trading activity a symbol's liquidity is classified from
+type SymbolMarketData struct {
+	Symbol             string          `gorm:"primaryKey;type:varchar(20)" json:"symbol"`
+	AverageDailyVolume decimal.Decimal `gorm:"type:decimal(24,4);not null" json:"average_daily_volume"` // Units traded per day
+	BidAskSpread       decimal.Decimal `gorm:"type:decimal(10,6);not null" json:"bid_ask_spread"`       // As a fraction of price
+	UpdatedBy          *uuid.UUID      `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+func (SymbolMarketData) TableName() string {
+	return "symbol_market_data"
+}
+
+// PositionLiquidity is the liquidity classification of a position. The calculated class is
+// refreshed nightly; an override, when set, takes precedence and is what the position carries.
+type PositionLiquidity struct {
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PositionID         uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex" json:"position_id"`
+	Source             string          `gorm:"type:varchar(20);not null" json:"source"` // MARKET_DATA, ASSET_TYPE, NONE
+	CalculatedClass    string          `gorm:"type:varchar(10)" json:"calculated_class,omitempty"`
+	Score              decimal.Decimal `gorm:"type:decimal(5,2)" json:"score"` // 0-100, higher is more liquid
+	DaysToLiquidate    decimal.Decimal `gorm:"type:decimal(12,2)" json:"days_to_liquidate"`
+	AverageDailyVolume decimal.Decimal `gorm:"type:decimal(24,4)" json:"average_daily_volume"`
+	BidAskSpread       decimal.Decimal `gorm:"type:decimal(10,6)" json:"bid_ask_spread"`
+	CalculatedAt       *time.Time      `json:"calculated_at,omitempty"`
+
+	OverrideClass  *string    `gorm:"type:varchar(10)" json:"override_class,omitempty"`
+	OverrideReason string     `json:"override_reason,omitempty"`
+	OverriddenBy   *uuid.UUID `gorm:"type:uuid" json:"overridden_by,omitempty"`
+	OverriddenAt   *time.Time `json:"overridden_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Position *Position `gorm:"foreignKey:PositionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (PositionLiquidity) TableName() string {
+	return "position_liquidity"
+}
+
+func (p *PositionLiquidity) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
+
+// EffectiveClass is the override when one is set, otherwise the calculated class
+func (p *PositionLiquidity) EffectiveClass() string {
+	if p.OverrideClass != nil {
+		return *p.OverrideClass
+	}
+	return p.CalculatedClass
+}
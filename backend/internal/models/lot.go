@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Cost basis methods choosing which open lots a sale closes
+const (
+	CostBasisFIFO = "FIFO" // Oldest lots first
+	CostBasisLIFO = "LIFO" // Newest lots first
+	CostBasisHIFO = "HIFO" // Highest cost lots first, realizing the smallest gain
+)
+
+// Holding terms of a closed lot; a lot held for more than a year is long term
+const (
+	HoldingTermShort = "SHORT"
+	HoldingTermLong  = "LONG"
+)
+
+// PositionLot is the quantity of a symbol bought by one fill, at that fill's price. Sales consume
+// open lots in the order of the portfolio's cost basis method.
+type PositionLot struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID       uuid.UUID       `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	PositionID        *uuid.UUID      `gorm:"type:uuid" json:"position_id,omitempty"` // Position of the symbol when the lot was opened
+	Symbol            string          `gorm:"not null" json:"symbol"`
+	TransactionID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	FillID            uuid.UUID       `gorm:"type:uuid;not null" json:"fill_id"`
+	Currency          string          `gorm:"type:varchar(3)" json:"currency"`
+	Quantity          decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"quantity"`
+	RemainingQuantity decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"remaining_quantity"`
+	CostPrice         decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"cost_price"` // In Currency
+	OpenedAt          time.Time       `gorm:"not null" json:"opened_at"`
+	ClosedAt          *time.Time      `json:"closed_at,omitempty"` // When the last of the lot was sold
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+func (l *PositionLot) BeforeCreate(tx *gorm.DB) error {
+	l.ID = uuid.New()
+	return nil
+}
+
+// LotClosure is the part of a lot one sale fill closed and the gain it realized. A sale of more
+// than the open lots hold closes the rest without a lot, at the position's average price.
+type LotClosure struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	LotID         *uuid.UUID      `gorm:"type:uuid;index" json:"lot_id"` // Nil when no open lot covered the sale
+	PortfolioID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	Symbol        string          `gorm:"not null" json:"symbol"`
+	TransactionID uuid.UUID       `gorm:"type:uuid;not null;index" json:"transaction_id"` // The sale
+	FillID        uuid.UUID       `gorm:"type:uuid;not null" json:"fill_id"`
+	Method        string          `gorm:"type:varchar(10);not null" json:"method"` // FIFO, LIFO, HIFO
+	Quantity      decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"quantity"`
+	CostPrice     decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"cost_price"`
+	SalePrice     decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"sale_price"`
+	FXRate        decimal.Decimal `gorm:"type:decimal(20,10);default:1" json:"fx_rate"`    // Converts the sale currency into the portfolio currency
+	RealizedPnL   decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"realized_pnl"` // In the portfolio currency
+	HoldingTerm   string          `gorm:"type:varchar(10)" json:"holding_term,omitempty"`  // SHORT, LONG; empty without a lot
+	OpenedAt      *time.Time      `json:"opened_at,omitempty"`
+	ClosedAt      time.Time       `gorm:"not null" json:"closed_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func (c *LotClosure) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New()
+	return nil
+}
+
+// HoldingTermOf is the holding term of a lot opened and closed at the given times
+func HoldingTermOf(openedAt, closedAt time.Time) string {
+	if closedAt.After(openedAt.AddDate(1, 0, 0)) {
+		return HoldingTermLong
+	}
+	return HoldingTermShort
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DepthLevel is one price level of an order book
+type DepthLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Orders   int     `json:"orders"`
+}
+
+// MarketDepthSnapshot is the order book of a symbol as captured from the market data feed, kept so
+// that the depth a liquidity calculation used can be retrieved later. Bids are best first, highest
+// price first, and asks lowest price first. Snapshots are deleted under the market_depth_snapshots
+// retention policy.
+type MarketDepthSnapshot struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Symbol     string          `gorm:"type:varchar(20);not null;index" json:"symbol"`
+	Source     string          `gorm:"type:varchar(20);not null" json:"source"` // The feed it was captured from
+	Bids       []DepthLevel    `gorm:"type:jsonb;serializer:json;not null" json:"bids"`
+	Asks       []DepthLevel    `gorm:"type:jsonb;serializer:json;not null" json:"asks"`
+	BestBid    decimal.Decimal `gorm:"type:decimal(20,8);not null;default:0" json:"best_bid"`
+	BestAsk    decimal.Decimal `gorm:"type:decimal(20,8);not null;default:0" json:"best_ask"`
+	MidPrice   decimal.Decimal `gorm:"type:decimal(20,8);not null;default:0" json:"mid_price"`
+	Spread     decimal.Decimal `gorm:"type:decimal(10,6);not null;default:0" json:"spread"`    // As a fraction of the mid price; zero when a side is empty
+	BidDepth   decimal.Decimal `gorm:"type:decimal(24,4);not null;default:0" json:"bid_depth"` // Value of all bid levels
+	AskDepth   decimal.Decimal `gorm:"type:decimal(24,4);not null;default:0" json:"ask_depth"` // Value of all ask levels
+	CapturedAt time.Time       `gorm:"not null;index" json:"captured_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (s *MarketDepthSnapshot) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
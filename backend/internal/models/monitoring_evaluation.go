@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// What ran a transaction monitoring evaluation
+const (
+	MonitoringTriggerAMLCheck = "AML_CHECK"
+	MonitoringTriggerSweep    = "SWEEP" // A retrospective AML sweep
+)
+
+// MonitoringRuleHit is a transaction monitoring rule a transaction triggered
+type MonitoringRuleHit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// TransactionMonitoringEvaluation is the trail of one run of the transaction monitoring pipeline:
+// the features extracted from the transaction and its portfolio's history, the rules run and hit,
+// and the anomaly score when a scorer is configured
+type TransactionMonitoringEvaluation struct {
+	ID             uuid.UUID           `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID  uuid.UUID           `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	PortfolioID    uuid.UUID           `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	Features       map[string]float64  `gorm:"type:jsonb;serializer:json" json:"features"`
+	RulesEvaluated []string            `gorm:"type:jsonb;serializer:json" json:"rules_evaluated"`
+	Hits           []MonitoringRuleHit `gorm:"type:jsonb;serializer:json" json:"hits"`
+	AnomalyScorer  string              `gorm:"type:varchar(20)" json:"anomaly_scorer,omitempty"`
+	AnomalyScore   *float64            `json:"anomaly_score"`           // 0-1, nil without a scorer or when scoring failed
+	AnomalyError   string              `json:"anomaly_error,omitempty"` // Why scoring failed; the rules still apply
+	RiskScore      int                 `json:"risk_score"`              // Sum of the hit scores, capped at 100
+	RequiresReview bool                `gorm:"default:false" json:"requires_review"`
+	Trigger        string              `gorm:"type:varchar(20);default:'AML_CHECK'" json:"trigger"` // AML_CHECK, SWEEP
+	EvaluatedAt    time.Time           `gorm:"index" json:"evaluated_at"`
+}
+
+func (e *TransactionMonitoringEvaluation) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
+
+// Flags lists the rules the transaction hit
+func (e *TransactionMonitoringEvaluation) Flags() []string {
+	flags := make([]string, 0, len(e.Hits))
+	for _, hit := range e.Hits {
+		flags = append(flags, hit.Rule)
+	}
+	return flags
+}
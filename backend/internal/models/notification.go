@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationChannel is a destination that alerts of the configured severities are delivered to
+type NotificationChannel struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string    `gorm:"not null" json:"name"`
+	Type       string    `gorm:"type:varchar(20);not null" json:"type"`          // EMAIL, SLACK, WEBHOOK
+	Target     string    `gorm:"not null" json:"target"`                         // Comma-separated addresses or a webhook URL
+	Severities string    `gorm:"not null" json:"severities"`                     // Comma-separated, e.g. "HIGH,CRITICAL"
+	Events     string    `gorm:"not null;default:'alert.created'" json:"events"` // Comma-separated: alert.created, alert.resolved
+	Config     JSON      `gorm:"type:jsonb" json:"config"`                       // Channel-specific options such as webhook headers and secret
+	IsActive   bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (n *NotificationChannel) BeforeCreate(tx *gorm.DB) error {
+	n.ID = uuid.New()
+	return nil
+}
+
+// NotificationDelivery records each attempt to deliver an alert event to a channel. Payload keeps the
+// alert as it was when the event happened, so retries and redeliveries send what the first attempt
+// did; FAILED deliveries are the dead letters.
+type NotificationDelivery struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	AlertID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"alert_id"`
+	ChannelID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"channel_id"`
+	Event         string     `gorm:"type:varchar(40);not null;default:'alert.created'" json:"event"`
+	Payload       JSON       `gorm:"type:jsonb" json:"payload,omitempty"`
+	RedeliveryOf  *uuid.UUID `gorm:"type:uuid" json:"redelivery_of,omitempty"`
+	Status        string     `gorm:"type:varchar(20);not null;index" json:"status"` // PENDING, RETRYING, SENT, FAILED
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Channel NotificationChannel `gorm:"foreignKey:ChannelID" json:"channel,omitempty"`
+}
+
+func (n *NotificationDelivery) BeforeCreate(tx *gorm.DB) error {
+	n.ID = uuid.New()
+	return nil
+}
+
+// Channels a user may choose for the alerts of a severity
+const (
+	PreferenceChannelEmail  = "EMAIL"  // An email per alert
+	PreferenceChannelDigest = "DIGEST" // Listed in the daily digest
+)
+
+// NotificationPreference is what a user is told about alerts on the portfolios they can see. Users
+// without one are only reached through the notification channels and escalations.
+type NotificationPreference struct {
+	UserID          uuid.UUID           `gorm:"type:uuid;primary_key" json:"user_id"`
+	Channels        map[string][]string `gorm:"type:jsonb;serializer:json;not null" json:"channels"` // Severity -> EMAIL and/or DIGEST; none for silence
+	QuietHoursStart *string             `gorm:"type:varchar(5)" json:"quiet_hours_start"`            // HH:MM in Timezone; emails are held for the digest in between
+	QuietHoursEnd   *string             `gorm:"type:varchar(5)" json:"quiet_hours_end"`
+	Timezone        string              `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	DailyDigest     bool                `gorm:"not null" json:"daily_digest"`
+	LastDigestAt    *time.Time          `json:"last_digest_at"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
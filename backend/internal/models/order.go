@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Order lifecycle states of BUY and SELL transactions
+const (
+	OrderStatusNew             = "NEW"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusFilled          = "FILLED"
+	OrderStatusCancelled       = "CANCELLED"
+	OrderStatusRejected        = "REJECTED"
+)
+
+// orderTransitions lists the states each order state may move to. FILLED, CANCELLED and REJECTED
+// are final.
+var orderTransitions = map[string][]string{
+	OrderStatusNew:             {OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected},
+	OrderStatusPartiallyFilled: {OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCancelled},
+}
+
+// CanTransitionOrder reports whether an order may move from one state to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusForStatus is the order state matching a transaction status, for orders recorded
+// without going through the lifecycle such as imported trades
+func OrderStatusForStatus(status string) string {
+	switch status {
+	case "COMPLETED":
+		return OrderStatusFilled
+	case "FAILED":
+		return OrderStatusRejected
+	case "CANCELLED":
+		return OrderStatusCancelled
+	}
+	return OrderStatusNew
+}
+
+// Fill is an execution of part or all of an order
+type Fill struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID uuid.UUID       `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Quantity      decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"quantity"`
+	Price         decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"price"`
+	Amount        decimal.Decimal `gorm:"type:decimal(20,2);not null" json:"amount"`
+	ExecutionID   string          `gorm:"type:varchar(100)" json:"execution_id,omitempty"` // Venue or broker execution reference
+	ExecutedAt    time.Time       `gorm:"not null" json:"executed_at"`
+	CreatedBy     *uuid.UUID      `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+func (Fill) TableName() string {
+	return "transaction_fills"
+}
+
+func (f *Fill) BeforeCreate(tx *gorm.DB) error {
+	f.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PnLHistory is a daily profit and loss snapshot for a portfolio. The row for the current day is
+// overwritten on each price update, so it always holds the latest intraday figures.
+type PnLHistory struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID   uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_pnl_history_portfolio_date" json:"portfolio_id"`
+	Date          time.Time       `gorm:"type:date;not null;uniqueIndex:idx_pnl_history_portfolio_date" json:"date"`
+	MarketValue   decimal.Decimal `gorm:"type:decimal(20,2)" json:"market_value"`
+	CostBasis     decimal.Decimal `gorm:"type:decimal(20,2)" json:"cost_basis"`
+	UnrealizedPnL decimal.Decimal `gorm:"column:unrealized_pnl;type:decimal(20,2)" json:"unrealized_pnl"`
+	RealizedPnL   decimal.Decimal `gorm:"column:realized_pnl;type:decimal(20,2)" json:"realized_pnl"` // Realized during the day
+	DailyPnL      decimal.Decimal `gorm:"column:daily_pnl;type:decimal(20,2)" json:"daily_pnl"`       // Change in market value since the previous snapshot plus realized P&L
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (PnLHistory) TableName() string {
+	return "pnl_history"
+}
+
+func (p *PnLHistory) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
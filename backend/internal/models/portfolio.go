@@ -11,15 +11,36 @@ import (
 type Portfolio struct {
 	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
 	UserID      uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	TeamID      *uuid.UUID      `gorm:"type:uuid;index" json:"team_id,omitempty"`   // Team sharing the portfolio with its members
+	ParentID    *uuid.UUID      `gorm:"type:uuid;index" json:"parent_id,omitempty"` // Book or strategy the portfolio rolls up into
+	TenantID    *uuid.UUID      `gorm:"type:uuid;->" json:"tenant_id,omitempty"`    // Set by the database from the owner's tenant
 	Name        string          `gorm:"not null" json:"name"`
 	Description string          `json:"description"`
 	TotalValue  decimal.Decimal `gorm:"type:decimal(20,2)" json:"total_value"`
 	Currency    string          `gorm:"default:'USD'" json:"currency"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	Benchmark   string          `gorm:"type:varchar(20)" json:"benchmark,omitempty"` // Index or ETF symbol returns are compared with, such as SPX
+
+	// Account number the custodian reports the portfolio's positions under, for reconciliation
+	CustodianAccount string `gorm:"type:varchar(50);index" json:"custodian_account,omitempty"`
+
+	// Margin account. A negative cash balance is a debit; MarginLoan is borrowing against positions.
+	CashBalance           decimal.Decimal `gorm:"type:decimal(20,2);default:0" json:"cash_balance"`
+	MarginLoan            decimal.Decimal `gorm:"type:decimal(20,2);default:0" json:"margin_loan"`
+	MaintenanceMarginRate decimal.Decimal `gorm:"type:decimal(10,4);default:0.25" json:"maintenance_margin_rate"` // Equity required as a fraction of gross exposure
+
+	// Which open lots a sale closes: FIFO, LIFO or HIFO
+	CostBasisMethod string `gorm:"type:varchar(10);default:'FIFO'" json:"cost_basis_method"`
+
+	// Raised by the database with each change to the portfolio's settings or cash, not its value
+	Version int64 `gorm:"not null;default:1" json:"version"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // Soft deleted, kept until the retention purge
 
 	// Relations
 	User      User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Team      *Team      `gorm:"foreignKey:TeamID" json:"team,omitempty"`
 	Positions []Position `gorm:"foreignKey:PortfolioID" json:"positions,omitempty"`
 }
 
@@ -30,13 +51,21 @@ type Position struct {
 	Quantity     decimal.Decimal `gorm:"type:decimal(20,8)" json:"quantity"`
 	AveragePrice decimal.Decimal `gorm:"type:decimal(20,8)" json:"average_price"`
 	CurrentPrice decimal.Decimal `gorm:"type:decimal(20,8)" json:"current_price"`
-	MarketValue  decimal.Decimal `gorm:"type:decimal(20,2)" json:"market_value"`
-	PnL          decimal.Decimal `gorm:"type:decimal(20,2)" json:"pnl"`
+	MarketValue  decimal.Decimal `gorm:"type:decimal(20,2)" json:"market_value"` // In the portfolio currency
+	PnL          decimal.Decimal `gorm:"type:decimal(20,2)" json:"pnl"`          // In the portfolio currency
 	PnLPercent   decimal.Decimal `gorm:"type:decimal(10,4)" json:"pnl_percent"`
-	Weight       decimal.Decimal `gorm:"type:decimal(10,4)" json:"weight"` // Position weight in portfolio
-	AssetType    string          `gorm:"not null" json:"asset_type"`       // STOCK, BOND, COMMODITY, etc.
-	Liquidity    string          `gorm:"default:'HIGH'" json:"liquidity"`  // HIGH, MEDIUM, LOW
-	UpdatedAt    time.Time       `json:"updated_at"`
+	Weight       decimal.Decimal `gorm:"type:decimal(10,4)" json:"weight"`               // Position weight in portfolio
+	AssetType    string          `gorm:"not null" json:"asset_type"`                     // STOCK, BOND, COMMODITY, etc.
+	Liquidity    string          `gorm:"default:'HIGH'" json:"liquidity"`                // HIGH, MEDIUM, LOW
+	CreditRating string          `gorm:"type:varchar(4)" json:"credit_rating,omitempty"` // Issue rating of bonds, such as AA+
+
+	// Prices are quoted in Currency; FXRate converts them into the portfolio currency
+	Currency         string          `gorm:"type:varchar(3);default:'USD'" json:"currency"`
+	FXRate           decimal.Decimal `gorm:"type:decimal(20,10);default:1" json:"fx_rate"`
+	LocalMarketValue decimal.Decimal `gorm:"type:decimal(20,2)" json:"local_market_value"`
+
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 func (p *Portfolio) BeforeCreate(tx *gorm.DB) error {
@@ -44,6 +73,11 @@ func (p *Portfolio) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ValueWithCash is the value of the portfolio's positions plus its cash balance
+func (p *Portfolio) ValueWithCash() decimal.Decimal {
+	return p.TotalValue.Add(p.CashBalance)
+}
+
 func (p *Position) BeforeCreate(tx *gorm.DB) error {
 	p.ID = uuid.New()
 	return nil
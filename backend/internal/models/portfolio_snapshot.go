@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SnapshotPosition is a position as it stood when a portfolio snapshot was taken. Values are in
+// the portfolio currency and the weight is a percent of the positions' market value.
+type SnapshotPosition struct {
+	Symbol       string          `json:"symbol"`
+	AssetType    string          `json:"asset_type"`
+	Currency     string          `json:"currency"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price"`
+	Price        decimal.Decimal `json:"price"`
+	MarketValue  decimal.Decimal `json:"market_value"`
+	PnL          decimal.Decimal `json:"pnl"`
+	Weight       decimal.Decimal `json:"weight"`
+}
+
+// PortfolioSnapshot is a portfolio's end-of-day valuation. Portfolio.TotalValue is overwritten as
+// prices move, so these rows are the NAV history; a day's row is replaced if it is taken again.
+type PortfolioSnapshot struct {
+	ID          uuid.UUID          `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_portfolio_snapshots_portfolio_date" json:"portfolio_id"`
+	Date        time.Time          `gorm:"type:date;not null;uniqueIndex:idx_portfolio_snapshots_portfolio_date" json:"date"`
+	Currency    string             `gorm:"type:varchar(3);not null" json:"currency"`
+	NAV         decimal.Decimal    `gorm:"column:nav;type:decimal(20,2)" json:"nav"` // Market value plus cash less the margin loan
+	MarketValue decimal.Decimal    `gorm:"type:decimal(20,2)" json:"market_value"`
+	CashBalance decimal.Decimal    `gorm:"type:decimal(20,2)" json:"cash_balance"`
+	MarginLoan  decimal.Decimal    `gorm:"type:decimal(20,2)" json:"margin_loan"`
+	Positions   []SnapshotPosition `gorm:"type:jsonb;serializer:json" json:"positions,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+func (p *PortfolioSnapshot) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PortfolioSupervisor grants a user access to a portfolio they do not own
+type PortfolioSupervisor struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_portfolio_supervisor" json:"portfolio_id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_portfolio_supervisor;index" json:"user_id"`
+	AssignedBy  *uuid.UUID `gorm:"type:uuid" json:"assigned_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (p *PortfolioSupervisor) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
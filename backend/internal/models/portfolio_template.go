@@ -0,0 +1,185 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PortfolioTemplate is a predefined portfolio that new portfolios can be created from: a set of
+// positions and cash, risk thresholds and an investment guideline. Thresholds not set keep their
+// defaults, and a template without a guideline sets none.
+type PortfolioTemplate struct {
+	ID          uuid.UUID            `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string               `gorm:"uniqueIndex;not null" json:"name"`
+	Description string               `json:"description"`
+	Currency    string               `gorm:"type:varchar(3);not null" json:"currency"`
+	Benchmark   string               `gorm:"type:varchar(20)" json:"benchmark,omitempty"`
+	CashBalance decimal.Decimal      `gorm:"type:decimal(20,2);default:0" json:"cash_balance"`
+	Positions   []TemplatePosition   `gorm:"type:jsonb;serializer:json" json:"positions"`
+	Thresholds  *ThresholdOverrides  `gorm:"type:jsonb;serializer:json" json:"thresholds,omitempty"`
+	Guideline   *GuidelineDefinition `gorm:"type:jsonb;serializer:json" json:"guideline,omitempty"`
+	CreatedBy   *uuid.UUID           `gorm:"type:uuid" json:"created_by"`
+	UpdatedBy   *uuid.UUID           `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func (t *PortfolioTemplate) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+	return nil
+}
+
+// TemplatePosition is a holding of a portfolio template, bought at Price in Currency
+type TemplatePosition struct {
+	Symbol    string          `json:"symbol"`
+	AssetType string          `json:"asset_type"` // STOCK, BOND, CRYPTO, etc.
+	Quantity  decimal.Decimal `json:"quantity"`
+	Price     decimal.Decimal `json:"price"`
+	Currency  string          `json:"currency,omitempty"` // The portfolio currency when empty
+}
+
+// ThresholdOverrides are the risk thresholds a portfolio template, tenant or portfolio sets; nil
+// fields keep the limits inherited, from the defaults of GetDefaultThresholds at the top
+type ThresholdOverrides struct {
+	MaxVaR95               *decimal.Decimal `json:"max_var_95,omitempty"`
+	MaxVaR99               *decimal.Decimal `json:"max_var_99,omitempty"`
+	MaxPositionSize        *decimal.Decimal `json:"max_position_size,omitempty"`
+	MaxSingleAssetExposure *decimal.Decimal `json:"max_single_asset_exposure,omitempty"`
+	MaxSectorExposure      *decimal.Decimal `json:"max_sector_exposure,omitempty"`
+	MinLiquidityRatio      *decimal.Decimal `json:"min_liquidity_ratio,omitempty"`
+	MaxLeverage            *decimal.Decimal `json:"max_leverage,omitempty"`
+	MaxConcentration       *decimal.Decimal `json:"max_concentration,omitempty"`
+	MaxFXExposure          *decimal.Decimal `json:"max_fx_exposure,omitempty"`
+	MaxDV01                *decimal.Decimal `json:"max_dv01,omitempty"`
+	MaxVenueExposure       *decimal.Decimal `json:"max_venue_exposure,omitempty"`
+	MaxStablecoinDepeg     *decimal.Decimal `json:"max_stablecoin_depeg,omitempty"`
+	MaxDailyLoss           *decimal.Decimal `json:"max_daily_loss,omitempty"`
+	MaxWeeklyLoss          *decimal.Decimal `json:"max_weekly_loss,omitempty"`
+	MaxDrawdown            *decimal.Decimal `json:"max_drawdown,omitempty"`
+	RequireStopLoss        *bool            `json:"require_stop_loss,omitempty"`
+	MaxStopLossDistance    *decimal.Decimal `json:"max_stop_loss_distance,omitempty"`
+}
+
+// Apply sets the overridden thresholds
+func (o *ThresholdOverrides) Apply(t *RiskThresholds) {
+	for _, field := range []struct {
+		override *decimal.Decimal
+		target   *decimal.Decimal
+	}{
+		{o.MaxVaR95, &t.MaxVaR95},
+		{o.MaxVaR99, &t.MaxVaR99},
+		{o.MaxPositionSize, &t.MaxPositionSize},
+		{o.MaxSingleAssetExposure, &t.MaxSingleAssetExposure},
+		{o.MaxSectorExposure, &t.MaxSectorExposure},
+		{o.MinLiquidityRatio, &t.MinLiquidityRatio},
+		{o.MaxLeverage, &t.MaxLeverage},
+		{o.MaxConcentration, &t.MaxConcentration},
+		{o.MaxFXExposure, &t.MaxFXExposure},
+		{o.MaxDV01, &t.MaxDV01},
+		{o.MaxVenueExposure, &t.MaxVenueExposure},
+		{o.MaxStablecoinDepeg, &t.MaxStablecoinDepeg},
+		{o.MaxDailyLoss, &t.MaxDailyLoss},
+		{o.MaxWeeklyLoss, &t.MaxWeeklyLoss},
+		{o.MaxDrawdown, &t.MaxDrawdown},
+		{o.MaxStopLossDistance, &t.MaxStopLossDistance},
+	} {
+		if field.override != nil {
+			*field.target = *field.override
+		}
+	}
+	if o.RequireStopLoss != nil {
+		t.RequireStopLoss = *o.RequireStopLoss
+	}
+}
+
+// Merge sets the thresholds other overrides on top of these
+func (o *ThresholdOverrides) Merge(other *ThresholdOverrides) {
+	limits := o.limits()
+	for name, override := range other.limits() {
+		if *override != nil {
+			*limits[name] = *override
+		}
+	}
+	if other.RequireStopLoss != nil {
+		o.RequireStopLoss = other.RequireStopLoss
+	}
+}
+
+// Clear drops the overrides of the named thresholds, so that they are inherited again
+func (o *ThresholdOverrides) Clear(names []string) error {
+	limits := o.limits()
+	for _, name := range names {
+		if name == "require_stop_loss" {
+			o.RequireStopLoss = nil
+			continue
+		}
+		override, ok := limits[name]
+		if !ok {
+			return fmt.Errorf("%s is not a risk threshold", name)
+		}
+		*override = nil
+	}
+	return nil
+}
+
+// Equal reports whether both override the same thresholds with the same limits
+func (o *ThresholdOverrides) Equal(other *ThresholdOverrides) bool {
+	limits := other.limits()
+	for name, override := range o.limits() {
+		if (*override == nil) != (*limits[name] == nil) || (*override != nil && !(*override).Equal(**limits[name])) {
+			return false
+		}
+	}
+	if (o.RequireStopLoss == nil) != (other.RequireStopLoss == nil) {
+		return false
+	}
+	return o.RequireStopLoss == nil || *o.RequireStopLoss == *other.RequireStopLoss
+}
+
+// limits returns the overrides of the decimal thresholds by their JSON name
+func (o *ThresholdOverrides) limits() map[string]**decimal.Decimal {
+	return map[string]**decimal.Decimal{
+		"max_var_95":                &o.MaxVaR95,
+		"max_var_99":                &o.MaxVaR99,
+		"max_position_size":         &o.MaxPositionSize,
+		"max_single_asset_exposure": &o.MaxSingleAssetExposure,
+		"max_sector_exposure":       &o.MaxSectorExposure,
+		"min_liquidity_ratio":       &o.MinLiquidityRatio,
+		"max_leverage":              &o.MaxLeverage,
+		"max_concentration":         &o.MaxConcentration,
+		"max_fx_exposure":           &o.MaxFXExposure,
+		"max_dv01":                  &o.MaxDV01,
+		"max_venue_exposure":        &o.MaxVenueExposure,
+		"max_stablecoin_depeg":      &o.MaxStablecoinDepeg,
+		"max_daily_loss":            &o.MaxDailyLoss,
+		"max_weekly_loss":           &o.MaxWeeklyLoss,
+		"max_drawdown":              &o.MaxDrawdown,
+		"max_stop_loss_distance":    &o.MaxStopLossDistance,
+	}
+}
+
+// GuidelineDefinition is the mandate of an investment guideline, without the portfolio it applies to
+type GuidelineDefinition struct {
+	AllowedAssetTypes  []string         `json:"allowed_asset_types,omitempty"`
+	MaxCryptoPercent   *decimal.Decimal `json:"max_crypto_percent,omitempty"`
+	MinCreditRating    string           `json:"min_credit_rating,omitempty"`
+	ESGExcludedSymbols []string         `json:"esg_excluded_symbols,omitempty"`
+	ESGExcludedSectors []string         `json:"esg_excluded_sectors,omitempty"`
+}
+
+// For returns the definition as an active guideline of a portfolio
+func (d *GuidelineDefinition) For(portfolioID uuid.UUID) *InvestmentGuideline {
+	return &InvestmentGuideline{
+		PortfolioID:        portfolioID,
+		AllowedAssetTypes:  d.AllowedAssetTypes,
+		MaxCryptoPercent:   d.MaxCryptoPercent,
+		MinCreditRating:    d.MinCreditRating,
+		ESGExcludedSymbols: d.ESGExcludedSymbols,
+		ESGExcludedSectors: d.ESGExcludedSectors,
+		IsActive:           true,
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Sources of custodian position files
+const (
+	ReconciliationSourceUpload = "upload" // Uploaded through the API
+	ReconciliationSourceHTTP   = "http"   // Fetched from the custodian's API
+	ReconciliationSourceInbox  = "inbox"  // Picked up from the inbox directory, e.g. an SFTP drop
+)
+
+// Reconciliation run statuses
+const (
+	ReconciliationStatusCompleted = "COMPLETED"
+	ReconciliationStatusFailed    = "FAILED"
+)
+
+// Reconciliation break types
+const (
+	BreakTypeQuantity        = "QUANTITY"         // Both sides hold the symbol in different quantities
+	BreakTypePrice           = "PRICE"            // The custodian's price is outside the tolerance of ours
+	BreakTypeMissingInternal = "MISSING_INTERNAL" // The custodian holds a position we do not
+	BreakTypeMissingExternal = "MISSING_EXTERNAL" // We hold a position the custodian does not
+)
+
+// Reconciliation break statuses. Open breaks are the unexplained ones alerts are raised for.
+const (
+	BreakStatusOpen      = "OPEN"
+	BreakStatusExplained = "EXPLAINED" // Known cause, such as a trade awaiting settlement
+	BreakStatusResolved  = "RESOLVED"  // Corrected on either side
+)
+
+// ReconciliationRun is one comparison of a custodian position file with the positions of the
+// portfolios it covers
+type ReconciliationRun struct {
+	ID           uuid.UUID   `gorm:"type:uuid;primary_key" json:"id"`
+	Source       string      `gorm:"type:varchar(10);not null" json:"source"` // upload, http, inbox
+	FileName     string      `json:"file_name"`
+	AsOf         time.Time   `gorm:"type:date;not null" json:"as_of"`
+	Status       string      `gorm:"type:varchar(20);not null" json:"status"` // COMPLETED, FAILED
+	Portfolios   int         `json:"portfolios"`
+	PortfolioIDs []uuid.UUID `gorm:"type:jsonb;serializer:json" json:"portfolio_ids"` // Portfolios the file covered
+	Positions    int         `json:"positions"`                                       // Custodian positions read
+	Matched      int         `json:"matched"`
+	Breaks       int         `json:"breaks"`
+	Unexplained  int         `json:"unexplained"`
+	Message      string      `json:"message,omitempty"` // Why the file was rejected, or the unknown accounts skipped
+	CreatedBy    *uuid.UUID  `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt    time.Time   `gorm:"index" json:"created_at"`
+}
+
+func (r *ReconciliationRun) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
+
+// ReconciliationBreak is a difference between our position in a symbol and the custodian's.
+// Quantities missing on one side are zero, and QuantityDifference is the custodian's quantity
+// less ours.
+type ReconciliationBreak struct {
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	RunID              uuid.UUID       `gorm:"type:uuid;not null;index" json:"run_id"`
+	PortfolioID        uuid.UUID       `gorm:"type:uuid;not null;index:idx_reconciliation_breaks_portfolio_status,priority:1" json:"portfolio_id"`
+	Symbol             string          `gorm:"type:varchar(20);not null" json:"symbol"`
+	BreakType          string          `gorm:"type:varchar(20);not null" json:"break_type"` // QUANTITY, PRICE, MISSING_INTERNAL, MISSING_EXTERNAL
+	InternalQuantity   decimal.Decimal `gorm:"type:decimal(20,8)" json:"internal_quantity"`
+	ExternalQuantity   decimal.Decimal `gorm:"type:decimal(20,8)" json:"external_quantity"`
+	QuantityDifference decimal.Decimal `gorm:"type:decimal(20,8)" json:"quantity_difference"`
+	InternalPrice      decimal.Decimal `gorm:"type:decimal(20,8)" json:"internal_price"`
+	ExternalPrice      decimal.Decimal `gorm:"type:decimal(20,8)" json:"external_price"`
+	Status             string          `gorm:"type:varchar(20);not null;index:idx_reconciliation_breaks_portfolio_status,priority:2" json:"status"` // OPEN, EXPLAINED, RESOLVED
+	Explanation        string          `json:"explanation,omitempty"`
+	ExplainedBy        *uuid.UUID      `gorm:"type:uuid" json:"explained_by,omitempty"`
+	ExplainedAt        *time.Time      `json:"explained_at,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+func (b *ReconciliationBreak) BeforeCreate(tx *gorm.DB) error {
+	b.ID = uuid.New()
+	return nil
+}
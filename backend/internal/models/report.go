@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Report types
+const (
+	ReportTypeDailyRisk         = "DAILY_RISK"
+	ReportTypeComplianceSummary = "COMPLIANCE_SUMMARY"
+	ReportTypeAlertHistory      = "ALERT_HISTORY"
+)
+
+// Report is a generated regulatory or risk report. The rendered file is stored with the record and
+// only returned through the download endpoint.
+type Report struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	PortfolioID uuid.UUID  `gorm:"type:uuid;not null;index" json:"portfolio_id"`
+	ReportType  string     `gorm:"type:varchar(30);not null;index" json:"report_type"` // DAILY_RISK, COMPLIANCE_SUMMARY, ALERT_HISTORY
+	Format      string     `gorm:"type:varchar(10);not null" json:"format"`            // pdf, csv
+	Title       string     `gorm:"not null" json:"title"`
+	FileName    string     `gorm:"not null" json:"file_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	SHA256      string     `gorm:"type:varchar(64)" json:"sha256"`
+	PeriodFrom  time.Time  `json:"period_from"`
+	PeriodTo    time.Time  `json:"period_to"`
+	GeneratedBy *uuid.UUID `gorm:"type:uuid" json:"generated_by"`
+	Content     []byte     `gorm:"type:bytea" json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	DownloadURL string `gorm:"-" json:"download_url"`
+}
+
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
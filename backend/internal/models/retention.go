@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entities with a retention policy
+const (
+	RetentionAlerts       = "alerts"       // Resolved and dismissed alerts are deleted
+	RetentionTransactions = "transactions" // Settled transactions no open lot or case needs are deleted
+	RetentionAuditLogs    = "audit_logs"
+	RetentionClosedUsers  = "closed_users" // Deactivated and deleted accounts are anonymized
+	RetentionMarketDepth  = "market_depth_snapshots"
+)
+
+// RetentionPolicy sets how long records of an entity are kept, counted from their creation or,
+// for user accounts, from their closing
+type RetentionPolicy struct {
+	EntityType    string     `gorm:"type:varchar(50);primary_key" json:"entity_type"`
+	RetentionDays int        `gorm:"not null" json:"retention_days"`
+	Enabled       bool       `gorm:"not null;default:true" json:"enabled"`
+	UpdatedBy     *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
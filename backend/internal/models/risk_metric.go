@@ -37,6 +37,7 @@ type RiskHistory struct {
 	MetricType  string          `gorm:"type:varchar(50);not null" json:"metric_type"`
 	Value       decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"value"`
 	RecordedAt  time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"recorded_at"`
+	Resolution  string          `gorm:"type:varchar(10);not null;default:RAW" json:"resolution"` // RAW, HOURLY, DAILY
 
 	// Relationships
 	Portfolio Portfolio `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty"`
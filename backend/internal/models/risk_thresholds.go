@@ -27,6 +27,14 @@ type RiskThresholds struct {
 	MinLiquidityRatio decimal.Decimal `gorm:"type:decimal(10,4)" json:"min_liquidity_ratio"`
 	MaxLeverage       decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_leverage"`
 	MaxConcentration  decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_concentration"`
+	MaxFXExposure     decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_fx_exposure"` // Share of gross exposure outside the portfolio currency
+
+	// Interest Rate Limits
+	MaxDV01 decimal.Decimal `gorm:"column:max_dv01;type:decimal(10,6)" json:"max_dv01"` // Loss from a one basis point rise in rates, as a share of portfolio value
+
+	// Crypto Limits
+	MaxVenueExposure   decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_venue_exposure"`   // Share of crypto value one exchange or custodian may hold
+	MaxStablecoinDepeg decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_stablecoin_depeg"` // Deviation of a stablecoin from its peg treated as a depeg
 
 	// Loss Limits
 	MaxDailyLoss  decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_daily_loss"` // % of portfolio
@@ -37,6 +45,12 @@ type RiskThresholds struct {
 	RequireStopLoss     bool            `gorm:"default:true" json:"require_stop_loss"`
 	MaxStopLossDistance decimal.Decimal `gorm:"type:decimal(10,4)" json:"max_stop_loss_distance"` // Max % from entry
 
+	// Limits set on the portfolio itself. The others are inherited from its parent portfolio, or
+	// for a top-level portfolio from its tenant's settings and the defaults.
+	Overrides ThresholdOverrides `gorm:"type:jsonb;serializer:json;not null" json:"overrides"`
+
+	Version int64 `gorm:"not null;default:1" json:"version"` // Raised by the database with each change
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -49,6 +63,30 @@ func (rt *RiskThresholds) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// Limits returns a copy of every limit of the thresholds as overrides
+func (rt *RiskThresholds) Limits() *ThresholdOverrides {
+	limits := *rt
+	return &ThresholdOverrides{
+		MaxVaR95:               &limits.MaxVaR95,
+		MaxVaR99:               &limits.MaxVaR99,
+		MaxPositionSize:        &limits.MaxPositionSize,
+		MaxSingleAssetExposure: &limits.MaxSingleAssetExposure,
+		MaxSectorExposure:      &limits.MaxSectorExposure,
+		MinLiquidityRatio:      &limits.MinLiquidityRatio,
+		MaxLeverage:            &limits.MaxLeverage,
+		MaxConcentration:       &limits.MaxConcentration,
+		MaxFXExposure:          &limits.MaxFXExposure,
+		MaxDV01:                &limits.MaxDV01,
+		MaxVenueExposure:       &limits.MaxVenueExposure,
+		MaxStablecoinDepeg:     &limits.MaxStablecoinDepeg,
+		MaxDailyLoss:           &limits.MaxDailyLoss,
+		MaxWeeklyLoss:          &limits.MaxWeeklyLoss,
+		MaxDrawdown:            &limits.MaxDrawdown,
+		RequireStopLoss:        &limits.RequireStopLoss,
+		MaxStopLossDistance:    &limits.MaxStopLossDistance,
+	}
+}
+
 // GetDefaultThresholds returns default risk thresholds for a new portfolio
 func GetDefaultThresholds(portfolioID uuid.UUID) *RiskThresholds {
 	return &RiskThresholds{
@@ -61,10 +99,14 @@ func GetDefaultThresholds(portfolioID uuid.UUID) *RiskThresholds {
 		MinLiquidityRatio:      decimal.NewFromFloat(0.30), // 30% min liquidity
 		MaxLeverage:            decimal.NewFromFloat(2.0),  // 2x leverage max
 		MaxConcentration:       decimal.NewFromFloat(0.35), // 35% Herfindahl index
+		MaxFXExposure:          decimal.NewFromFloat(0.50), // 50% max in foreign currencies
 		MaxDailyLoss:           decimal.NewFromFloat(0.03), // 3% daily loss limit
 		MaxWeeklyLoss:          decimal.NewFromFloat(0.07), // 7% weekly loss limit
 		MaxDrawdown:            decimal.NewFromFloat(0.15), // 15% max drawdown
 		RequireStopLoss:        true,
-		MaxStopLossDistance:    decimal.NewFromFloat(0.05), // 5% max stop distance
+		MaxStopLossDistance:    decimal.NewFromFloat(0.05),  // 5% max stop distance
+		MaxDV01:                decimal.NewFromFloat(0.001), // 0.1% of portfolio value per basis point
+		MaxVenueExposure:       decimal.NewFromFloat(0.50),  // 50% of crypto value at one venue
+		MaxStablecoinDepeg:     decimal.NewFromFloat(0.02),  // 2% from the peg
 	}
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SanctionsEntry is a single record imported from a sanctions or PEP list
+type SanctionsEntry struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Source     string     `gorm:"type:varchar(20);not null;index" json:"source"` // OFAC, EU, UN, PEP, INTERNAL
+	ExternalID string     `gorm:"type:varchar(100);index" json:"external_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Aliases    string     `json:"aliases"`                             // Semicolon-separated alternate names
+	EntityType string     `gorm:"type:varchar(20)" json:"entity_type"` // INDIVIDUAL, ENTITY, VESSEL, AIRCRAFT
+	Country    string     `json:"country"`
+	Programs   string     `json:"programs"`
+	IsPEP      bool       `gorm:"default:false" json:"is_pep"`
+	Remarks    string     `json:"remarks"`
+	ListedAt   *time.Time `json:"listed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (s *SanctionsEntry) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
+
+// ScreeningResult records the outcome of screening one party of a transaction
+type ScreeningResult struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID *uuid.UUID `gorm:"type:uuid;index" json:"transaction_id"`
+	SubjectName   string     `gorm:"not null" json:"subject_name"`
+	SubjectType   string     `gorm:"type:varchar(20);not null" json:"subject_type"` // CUSTOMER, COUNTERPARTY, AD_HOC
+	Status        string     `gorm:"type:varchar(20);not null" json:"status"`       // CLEAR, POTENTIAL_MATCH, MATCH
+	SanctionsHit  bool       `gorm:"default:false" json:"sanctions_hit"`
+	PEPHit        bool       `gorm:"default:false" json:"pep_hit"`
+	TopScore      float64    `json:"top_score"`
+	Matches       JSON       `gorm:"type:jsonb" json:"matches"`
+	ScreenedBy    *uuid.UUID `gorm:"type:uuid" json:"screened_by"`
+	ScreenedAt    time.Time  `json:"screened_at"`
+}
+
+func (s *ScreeningResult) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
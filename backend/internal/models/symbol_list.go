@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SymbolListEntry is an entry of the restricted or watch list: a symbol, with the reason it is
+// listed, that is in effect from EffectiveFrom until EffectiveTo, or indefinitely when that is not
+// set. Side limits the entry to BUY or SELL orders.
+type SymbolListEntry struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Symbol        string     `gorm:"type:varchar(20);not null;index" json:"symbol"`
+	Side          string     `gorm:"type:varchar(10)" json:"side,omitempty"` // BUY, SELL or empty for both
+	Reason        string     `gorm:"not null" json:"reason"`
+	EffectiveFrom time.Time  `gorm:"not null" json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	AddedBy       *uuid.UUID `gorm:"type:uuid" json:"added_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (e *SymbolListEntry) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New()
+	return nil
+}
+
+// Applies reports whether the entry covers an order on the given side at a time
+func (e *SymbolListEntry) Applies(side string, at time.Time) bool {
+	if at.Before(e.EffectiveFrom) || (e.EffectiveTo != nil && !e.EffectiveTo.After(at)) {
+		return false
+	}
+	return e.Side == "" || e.Side == side
+}
+
+// RestrictedList entries block trading in a symbol, such as an issuer the firm holds inside
+// information on
+type RestrictedList struct {
+	SymbolListEntry
+}
+
+// WatchList entries let trading in a symbol go ahead but raise a compliance alert for each trade
+type WatchList struct {
+	SymbolListEntry
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Team membership roles. Members see the portfolios their team owns; editors may also change them.
+const (
+	TeamRoleViewer = "VIEWER"
+	TeamRoleEditor = "EDITOR"
+)
+
+// TeamMember makes a user a member of a team, with access to the portfolios the team owns
+type TeamMember struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TeamID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_team_member" json:"team_id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_team_member;index" json:"user_id"`
+	Role      string     `gorm:"type:varchar(20);not null" json:"role"` // VIEWER or EDITOR
+	AddedBy   *uuid.UUID `gorm:"type:uuid" json:"added_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (m *TeamMember) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New()
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tenant is an organisation whose users, portfolios, transactions and alerts are kept apart from
+// other tenants'. Users without a tenant belong to the platform operator.
+type Tenant struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Name       string         `gorm:"uniqueIndex;not null" json:"name"`
+	Settings   TenantSettings `gorm:"type:jsonb;serializer:json" json:"settings"`
+	EncryptPII bool           `gorm:"default:false" json:"encrypt_pii"` // Users' names are stored encrypted with the tenant's data key
+	DataKey    *string        `json:"-"`                                // Wrapped by TENANT_MASTER_KEY
+	CreatedBy  *uuid.UUID     `gorm:"type:uuid" json:"created_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+	return nil
+}
+
+// TenantSettings override the platform's defaults for a tenant's portfolios and transactions
+type TenantSettings struct {
+	RiskThresholds *ThresholdOverrides `json:"risk_thresholds,omitempty"` // Applied to the tenant's new portfolios
+	AML            *AMLOverrides       `json:"aml,omitempty"`
+}
+
+// AMLOverrides are the transaction monitoring settings a tenant sets; nil fields keep the
+// MONITORING_* configuration
+type AMLOverrides struct {
+	Rules            []string `json:"rules,omitempty"`
+	LargeAmount      *float64 `json:"large_amount,omitempty"`
+	VelocityLimit    *int     `json:"velocity_limit,omitempty"`
+	StructuringCount *int     `json:"structuring_count,omitempty"`
+	OutlierZScore    *float64 `json:"outlier_z_score,omitempty"`
+	ReviewScore      *int     `json:"review_score,omitempty"`
+}
+
+// IsZero reports whether the overrides change nothing
+func (o *AMLOverrides) IsZero() bool {
+	return o == nil || (len(o.Rules) == 0 && o.LargeAmount == nil && o.VelocityLimit == nil &&
+		o.StructuringCount == nil && o.OutlierZScore == nil && o.ReviewScore == nil)
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,36 +9,94 @@ import (
 	"gorm.io/gorm"
 )
 
+// TransactionStatusPendingApproval is the status of a transaction held for four-eyes approval
+const TransactionStatusPendingApproval = "PENDING_APPROVAL"
+
+// TransactionStatusAmended is the status of a completed transaction an approved amendment replaced
+const TransactionStatusAmended = "AMENDED"
+
+// Invariants every saved transaction keeps, whichever code path writes it
+var (
+	ErrNegativeQuantity = errors.New("quantity must not be negative")
+	ErrNegativePrice    = errors.New("price must not be negative")
+)
+
+// Four-eyes approval states of a transaction
+const (
+	ApprovalPending  = "PENDING"
+	ApprovalApproved = "APPROVED"
+	ApprovalRejected = "REJECTED"
+)
+
 type Transaction struct {
 	ID              uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
-	PortfolioID     uuid.UUID       `gorm:"type:uuid;not null" json:"portfolio_id"`
-	TransactionType string          `gorm:"not null" json:"transaction_type"` // BUY, SELL, DEPOSIT, WITHDRAWAL
+	PortfolioID     uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_transactions_portfolio_external_id,priority:1" json:"portfolio_id"`
+	TenantID        *uuid.UUID      `gorm:"type:uuid;->" json:"tenant_id,omitempty"` // Set by the database from the portfolio's tenant
+	TransactionType string          `gorm:"not null" json:"transaction_type"`        // BUY, SELL, DEPOSIT, WITHDRAWAL, DIVIDEND
 	Symbol          string          `json:"symbol"`
 	Quantity        decimal.Decimal `gorm:"type:decimal(20,8)" json:"quantity"`
 	Price           decimal.Decimal `gorm:"type:decimal(20,8)" json:"price"`
 	Amount          decimal.Decimal `gorm:"type:decimal(20,2)" json:"amount"`
 	Currency        string          `gorm:"default:'USD'" json:"currency"`
-	Status          string          `gorm:"default:'PENDING'" json:"status"` // PENDING, COMPLETED, FAILED, CANCELLED
+	Status          string          `gorm:"default:'PENDING'" json:"status"` // PENDING, PENDING_APPROVAL, COMPLETED, FAILED, CANCELLED, AMENDED
 	ExecutedAt      *time.Time      `json:"executed_at"`
 	Notes           string          `json:"notes"`
 
+	// Order lifecycle of BUY and SELL transactions, which Status follows: FILLED is COMPLETED, as
+	// is CANCELLED after a partial fill for the filled quantity, and REJECTED is FAILED.
+	OrderStatus       string           `gorm:"type:varchar(20)" json:"order_status,omitempty"` // NEW, PARTIALLY_FILLED, FILLED, CANCELLED, REJECTED
+	OrderStatusReason string           `json:"order_status_reason,omitempty"`
+	FilledQuantity    decimal.Decimal  `gorm:"type:decimal(20,8);default:0" json:"filled_quantity"`
+	AverageFillPrice  decimal.Decimal  `gorm:"type:decimal(20,8);default:0" json:"average_fill_price"`
+	RealizedPnL       *decimal.Decimal `gorm:"type:decimal(20,2)" json:"realized_pnl,omitempty"` // Gain a SELL realized on the lots it closed, in the portfolio currency
+
+	// Bulk import details. ExternalID is the source system's trade reference, or a hash of the
+	// row when the file has none, and makes re-importing the same trade a no-op.
+	ExternalID *string    `gorm:"type:varchar(100);uniqueIndex:idx_transactions_portfolio_external_id,priority:2" json:"external_id,omitempty"`
+	ImportID   *uuid.UUID `gorm:"type:uuid;index" json:"import_id,omitempty"`
+
+	// Counterparty details used for sanctions screening. When CounterpartyID is set the name and
+	// country are copied from the counterparty record at the time of the trade.
+	CounterpartyID      *uuid.UUID `gorm:"type:uuid;index" json:"counterparty_id"`
+	CounterpartyName    string     `json:"counterparty_name"`
+	CounterpartyCountry string     `json:"counterparty_country"`
+
 	// Compliance fields
 	KYCVerified     bool   `gorm:"default:false" json:"kyc_verified"`
 	AMLChecked      bool   `gorm:"default:false" json:"aml_checked"`
 	RiskScore       int    `json:"risk_score"` // 0-100
 	ComplianceNotes string `json:"compliance_notes"`
 
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// Four-eyes approval. A pending transaction flagged for review is held as PENDING_APPROVAL
+	// until a user other than CreatedBy approves or rejects it.
+	CreatedBy      *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	ApprovalStatus string     `gorm:"type:varchar(20)" json:"approval_status,omitempty"` // PENDING, APPROVED, REJECTED; empty when never held
+	ApprovalReason string     `json:"approval_reason,omitempty"`
+	ReviewedBy     *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ReviewComment  string     `json:"review_comment,omitempty"`
+
+	// Amendments. An approved amendment of a completed transaction marks it AMENDED and books a
+	// replacement amending it, or marks it CANCELLED; either way SupersededAt is set and what it
+	// traded is kept as it was.
+	AmendsID     *uuid.UUID `gorm:"type:uuid;index" json:"amends_id,omitempty"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	// Relations
-	Portfolio Portfolio `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty"`
+	Portfolio    Portfolio     `gorm:"foreignKey:PortfolioID" json:"portfolio,omitempty"`
+	Counterparty *Counterparty `gorm:"foreignKey:CounterpartyID" json:"counterparty,omitempty"`
+	Fills        []Fill        `gorm:"foreignKey:TransactionID;constraint:OnDelete:CASCADE" json:"fills,omitempty"`
 
 	// Risk Management Fields (add these)
-	Side       string          `json:"side"`       // BUY or SELL
-	AssetType  string          `json:"asset_type"` // STOCK, BOND, COMMODITY, CRYPTO
-	StopLoss   decimal.Decimal `gorm:"type:decimal(20,8)" json:"stop_loss"`
-	TakeProfit decimal.Decimal `gorm:"type:decimal(20,8)" json:"take_profit"`
+	Side         string          `json:"side"`                                           // BUY or SELL
+	AssetType    string          `json:"asset_type"`                                     // STOCK, BOND, COMMODITY, CRYPTO
+	CreditRating string          `gorm:"type:varchar(4)" json:"credit_rating,omitempty"` // Issue rating of a bond traded
+	StopLoss     decimal.Decimal `gorm:"type:decimal(20,8)" json:"stop_loss"`
+	TakeProfit   decimal.Decimal `gorm:"type:decimal(20,8)" json:"take_profit"`
 
 	// Risk Analysis Results
 	RiskApproved   bool `gorm:"default:false" json:"risk_approved"`
@@ -49,3 +108,40 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	t.ID = uuid.New()
 	return nil
 }
+
+// BeforeSave rejects a negative quantity or price and sets a trade's amount from them, so that the
+// amount cannot drift from what was traded. It applies to transactions created or saved whole;
+// updates of single columns leave the amount alone.
+func (t *Transaction) BeforeSave(tx *gorm.DB) error {
+	if t.Quantity.IsNegative() {
+		return ErrNegativeQuantity
+	}
+	if t.Price.IsNegative() {
+		return ErrNegativePrice
+	}
+	if t.IsTrade() {
+		t.Amount = t.TradeAmount()
+	}
+	return nil
+}
+
+// Executed reports whether the transaction completed, whether or not an amendment has since
+// superseded it. What an executed transaction traded only changes through amendments.
+func (t *Transaction) Executed() bool {
+	return t.Status == "COMPLETED" || t.SupersededAt != nil
+}
+
+// IsTrade reports whether the transaction is a BUY or SELL, whose amount follows its quantity and
+// price, rather than a cash movement with an amount of its own
+func (t *Transaction) IsTrade() bool {
+	return t.TransactionType == "BUY" || t.TransactionType == "SELL"
+}
+
+// TradeAmount is a trade's value: the filled quantity at the average fill price once it has
+// completed with fills, or else the quantity at the price
+func (t *Transaction) TradeAmount() decimal.Decimal {
+	if t.Status == "COMPLETED" && t.FilledQuantity.IsPositive() {
+		return t.FilledQuantity.Mul(t.AverageFillPrice).Round(2)
+	}
+	return t.Quantity.Mul(t.Price).Round(2)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Transaction amendment actions
+const (
+	AmendmentAmend  = "AMEND"  // Replace the transaction with one carrying the new values
+	AmendmentCancel = "CANCEL" // Cancel the transaction, reversing its cash and lots
+)
+
+// TransactionAmendment is a requested correction or cancellation of a completed transaction, with
+// the values it had and those asked for. It waits as PENDING until a user other than the one who
+// requested it approves or rejects it; the transaction itself is never edited.
+type TransactionAmendment struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	TransactionID uuid.UUID       `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	PortfolioID   uuid.UUID       `gorm:"type:uuid;not null" json:"portfolio_id"`
+	Action        string          `gorm:"type:varchar(10);not null" json:"action"`                   // AMEND, CANCEL
+	Status        string          `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, APPROVED, REJECTED
+	Reason        string          `gorm:"not null" json:"reason"`
+	OldValues     AmendmentValues `gorm:"type:jsonb;serializer:json" json:"old_values"`
+	NewValues     AmendmentValues `gorm:"type:jsonb;serializer:json" json:"new_values"`
+	ReplacementID *uuid.UUID      `gorm:"type:uuid" json:"replacement_id,omitempty"` // Transaction an approved AMEND booked
+	RequestedBy   uuid.UUID       `gorm:"type:uuid;not null" json:"requested_by"`
+	ReviewedBy    *uuid.UUID      `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewComment string          `json:"review_comment,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (a *TransactionAmendment) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
+
+// AmendmentValues are the fields of a transaction an amendment changes; unchanged fields are nil
+type AmendmentValues struct {
+	Symbol     *string          `json:"symbol,omitempty"`
+	Quantity   *decimal.Decimal `json:"quantity,omitempty"`
+	Price      *decimal.Decimal `json:"price,omitempty"`
+	Amount     *decimal.Decimal `json:"amount,omitempty"`
+	Currency   *string          `json:"currency,omitempty"`
+	ExecutedAt *time.Time       `json:"executed_at,omitempty"`
+	Status     string           `json:"status,omitempty"`
+}
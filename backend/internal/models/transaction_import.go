@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Transaction import statuses
+const (
+	ImportStatusProcessing = "PROCESSING"
+	ImportStatusCompleted  = "COMPLETED"
+	ImportStatusFailed     = "FAILED"
+)
+
+// ImportRowError is a rejected row of an import file
+type ImportRowError struct {
+	Line       int    `json:"line"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// TransactionImport records one bulk upload of trades. A repeated upload with the same
+// idempotency key from the same user returns this record instead of importing again.
+type TransactionImport struct {
+	ID              uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	ImportedBy      uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_transaction_imports_user_key,priority:1" json:"imported_by"`
+	IdempotencyKey  *string          `gorm:"type:varchar(255);uniqueIndex:idx_transaction_imports_user_key,priority:2" json:"idempotency_key,omitempty"`
+	Format          string           `gorm:"type:varchar(10);not null" json:"format"` // csv, fix
+	FileName        string           `json:"file_name"`
+	Status          string           `gorm:"type:varchar(20);not null" json:"status"` // PROCESSING, COMPLETED, FAILED
+	TotalRows       int              `json:"total_rows"`
+	Imported        int              `json:"imported"`
+	Duplicates      int              `json:"duplicates"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `gorm:"type:jsonb;serializer:json" json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+	Message         string           `json:"message,omitempty"` // Why the whole file was rejected
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+func (t *TransactionImport) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New()
+	return nil
+}
@@ -31,3 +31,7 @@ func (j *JSON) Scan(value interface{}) error {
 
 	return json.Unmarshal(bytes, j)
 }
+
+// AnyVersion stands for the version an update of a versioned record was made against when it
+// applies whatever the record's current version, as with If-Match: * or bulk changes
+const AnyVersion int64 = 0
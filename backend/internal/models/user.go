@@ -5,22 +5,73 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/tenancy"
+)
+
+// User roles
+const (
+	RoleAdmin             = "admin"
+	RoleAnalyst           = "analyst"
+	RoleTrader            = "trader"
+	RoleComplianceOfficer = "compliance_officer"
 )
 
 type User struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Email     string         `gorm:"unique;not null" json:"email"`
-	Password  string         `gorm:"not null" json:"-"`
-	FirstName string         `gorm:"not null" json:"first_name"`
-	LastName  string         `gorm:"not null" json:"last_name"`
-	Role      string         `gorm:"not null;default:'analyst'" json:"role"`
-	IsActive  bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                    uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Email                 string         `gorm:"unique;not null" json:"email"`
+	Password              string         `gorm:"not null" json:"-"`
+	FirstName             string         `gorm:"not null" json:"first_name"`
+	LastName              string         `gorm:"not null" json:"last_name"`
+	Role                  string         `gorm:"not null;default:'analyst'" json:"role"` // admin, analyst, trader, compliance_officer
+	IsActive              bool           `gorm:"default:true" json:"is_active"`
+	PasswordResetRequired bool           `gorm:"default:false" json:"password_reset_required"` // Login refused until the user changes the password
+	TenantID              *uuid.UUID     `gorm:"type:uuid;index" json:"tenant_id,omitempty"`   // Nil for the platform operator's users
+	AnonymizedAt          *time.Time     `json:"anonymized_at,omitempty"`                      // Personal data replaced after the account was closed
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	u.ID = uuid.New()
 	return nil
 }
+
+// BeforeSave encrypts the user's name when their tenant encrypts PII
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.TenantID == nil {
+		return nil
+	}
+	var err error
+	if u.FirstName, err = tenancy.Seal(*u.TenantID, u.FirstName); err != nil {
+		return err
+	}
+	u.LastName, err = tenancy.Seal(*u.TenantID, u.LastName)
+	return err
+}
+
+// AfterSave restores the plain name on the saved value
+func (u *User) AfterSave(tx *gorm.DB) error {
+	u.openNames()
+	return nil
+}
+
+// AfterFind decrypts the user's name. A name that cannot be decrypted is left sealed rather than
+// failing the query.
+func (u *User) AfterFind(tx *gorm.DB) error {
+	u.openNames()
+	return nil
+}
+
+func (u *User) openNames() {
+	if u.TenantID == nil {
+		return
+	}
+	if name, err := tenancy.Open(*u.TenantID, u.FirstName); err == nil {
+		u.FirstName = name
+	}
+	if name, err := tenancy.Open(*u.TenantID, u.LastName); err == nil {
+		u.LastName = name
+	}
+}
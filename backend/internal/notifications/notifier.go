@@ -0,0 +1,464 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Delivery statuses
+const (
+	StatusPending  = "PENDING"
+	StatusRetrying = "RETRYING"
+	StatusSent     = "SENT"
+	StatusFailed   = "FAILED"
+)
+
+// Alert events channels can subscribe to
+const (
+	EventAlertCreated  = "alert.created"
+	EventAlertResolved = "alert.resolved"
+)
+
+// Notification is an alert event delivered to a channel
+type Notification struct {
+	Event      string
+	DeliveryID uuid.UUID // Zero for test and escalation messages, which are not recorded
+	Alert      *models.Alert
+}
+
+// RecipientResolver returns the email addresses of the users whose notification preferences ask
+// for an alert straight away
+type RecipientResolver interface {
+	AlertRecipients(alert *models.Alert) ([]string, error)
+}
+
+// Notifier fans alerts out to the notification channels configured for their severity, and to the
+// users whose preferences ask for them
+type Notifier struct {
+	db       *gorm.DB
+	cfg      *config.NotificationConfig
+	senders  map[string]Sender
+	email    *EmailSender
+	resolver RecipientResolver // Nil until set, leaving users to the channels and digests
+	logger   *slog.Logger
+	pending  sync.WaitGroup // Background dispatches not yet finished
+}
+
+var (
+	defaultNotifier *Notifier
+	notifierMu      sync.RWMutex
+)
+
+// Init creates the shared notifier; alerts created before Init are not delivered
+func Init(cfg *config.NotificationConfig) *Notifier {
+	notifier := NewNotifier(cfg)
+
+	notifierMu.Lock()
+	defaultNotifier = notifier
+	notifierMu.Unlock()
+
+	return notifier
+}
+
+// GetNotifier returns the shared notifier, or nil if Init has not been called
+func GetNotifier() *Notifier {
+	notifierMu.RLock()
+	defer notifierMu.RUnlock()
+	return defaultNotifier
+}
+
+// Dispatch delivers a newly created alert through the shared notifier in the background
+func Dispatch(alert *models.Alert) {
+	DispatchEvent(EventAlertCreated, alert)
+}
+
+// DispatchEvent delivers an alert event through the shared notifier in the background
+func DispatchEvent(event string, alert *models.Alert) {
+	notifier := GetNotifier()
+	if notifier == nil {
+		return
+	}
+
+	alertCopy := *alert
+	notifier.pending.Go(func() {
+		notifier.NotifyEvent(event, &alertCopy)
+	})
+}
+
+// DispatchEscalation emails an escalated alert to the given addresses through the shared notifier
+// in the background
+func DispatchEscalation(alert *models.Alert, tier int, emails []string) {
+	notifier := GetNotifier()
+	if notifier == nil || len(emails) == 0 {
+		return
+	}
+
+	alertCopy := *alert
+	notifier.pending.Go(func() {
+		if err := notifier.NotifyEscalation(&alertCopy, tier, emails); err != nil {
+			notifier.logger.Error("Failed to send escalation email", "alert_id", alertCopy.ID, "tier", tier, "error", err)
+		}
+	})
+}
+
+// Drain waits for the background dispatches started so far to record and attempt their
+// deliveries, or for ctx to end
+func (n *Notifier) Drain(ctx context.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewNotifier creates a notifier with the built-in email, Slack and webhook senders
+func NewNotifier(cfg *config.NotificationConfig) *Notifier {
+	client := &http.Client{Timeout: cfg.HTTPTimeout}
+	email := NewEmailSender(cfg)
+
+	return &Notifier{
+		db:  database.GetDB(),
+		cfg: cfg,
+		senders: map[string]Sender{
+			ChannelEmail:   email,
+			ChannelSlack:   NewSlackSender(client),
+			ChannelWebhook: NewWebhookSender(client),
+		},
+		email:  email,
+		logger: logging.Component("notifications"),
+	}
+}
+
+// SetRecipientResolver sets who is emailed about new alerts under their own preferences; call it
+// before alerts are dispatched
+func (n *Notifier) SetRecipientResolver(resolver RecipientResolver) {
+	n.resolver = resolver
+}
+
+// ValidChannelType reports whether a channel type has a sender
+func ValidChannelType(channelType string) bool {
+	switch channelType {
+	case ChannelEmail, ChannelSlack, ChannelWebhook:
+		return true
+	}
+	return false
+}
+
+// ValidEvent reports whether channels can subscribe to an event
+func ValidEvent(event string) bool {
+	return event == EventAlertCreated || event == EventAlertResolved
+}
+
+// NotifyAlert delivers a newly created alert
+func (n *Notifier) NotifyAlert(alert *models.Alert) {
+	n.NotifyEvent(EventAlertCreated, alert)
+}
+
+// NotifyEvent records a delivery for each channel subscribed to the event and the alert's severity
+// and attempts it immediately. The delivery keeps a snapshot of the alert for retries. A new alert
+// is also emailed to the users whose preferences ask for it.
+func (n *Notifier) NotifyEvent(event string, alert *models.Alert) {
+	if event == EventAlertCreated && n.resolver != nil {
+		n.notifyUsers(alert)
+	}
+
+	var channels []models.NotificationChannel
+	if err := n.db.Where("is_active = ?", true).Find(&channels).Error; err != nil {
+		n.logger.Error("Failed to load notification channels", "alert_id", alert.ID, "error", err)
+		return
+	}
+
+	payload, err := snapshotAlert(alert)
+	if err != nil {
+		n.logger.Error("Failed to snapshot alert for notification", "alert_id", alert.ID, "error", err)
+		return
+	}
+
+	for i := range channels {
+		channel := &channels[i]
+		if !matchesList(channel.Severities, alert.Severity) || !matchesList(channel.Events, event) {
+			continue
+		}
+
+		delivery := &models.NotificationDelivery{
+			AlertID:   alert.ID,
+			ChannelID: channel.ID,
+			Event:     event,
+			Payload:   payload,
+			Status:    StatusPending,
+		}
+		if err := n.db.Create(delivery).Error; err != nil {
+			n.logger.Error("Failed to record notification delivery", "alert_id", alert.ID, "channel_id", channel.ID, "error", err)
+			continue
+		}
+
+		n.attempt(delivery, channel, alert)
+	}
+}
+
+// NotifyEscalation emails an escalated alert to the users it was escalated to. These emails go to
+// people rather than a configured channel, so no delivery is recorded.
+func (n *Notifier) NotifyEscalation(alert *models.Alert, tier int, emails []string) error {
+	channel := &models.NotificationChannel{
+		Name:   "escalation",
+		Type:   ChannelEmail,
+		Target: strings.Join(emails, ","),
+	}
+
+	escalated := *alert
+	escalated.Title = fmt.Sprintf("Escalated to tier %d: %s", tier, alert.Title)
+	return n.send(channel, &Notification{Event: EventAlertCreated, Alert: &escalated})
+}
+
+// notifyUsers emails a new alert to each user asking for it, one message per user so addresses are
+// not shared. Like escalations these go to people rather than a channel, so no delivery is recorded.
+func (n *Notifier) notifyUsers(alert *models.Alert) {
+	emails, err := n.resolver.AlertRecipients(alert)
+	if err != nil {
+		n.logger.Error("Failed to resolve alert recipients", "alert_id", alert.ID, "error", err)
+		return
+	}
+
+	for _, email := range emails {
+		channel := &models.NotificationChannel{Name: "preference", Type: ChannelEmail, Target: email}
+		if err := n.send(channel, &Notification{Event: EventAlertCreated, Alert: alert}); err != nil {
+			n.logger.Warn("Failed to email alert to user", "alert_id", alert.ID, "error", err)
+		}
+	}
+}
+
+// SendEmail emails a plain text message, such as a digest, to the given addresses
+func (n *Notifier) SendEmail(recipients []string, subject, text string) error {
+	return n.email.SendMessage(recipients, subject, text)
+}
+
+// SendTest sends a synthetic alert to a channel without recording a delivery
+func (n *Notifier) SendTest(channel *models.NotificationChannel) error {
+	alert := &models.Alert{
+		ID:          uuid.New(),
+		AlertType:   "TEST",
+		Severity:    "LOW",
+		Title:       "Test notification",
+		Description: fmt.Sprintf("Test message for notification channel %q", channel.Name),
+		Source:      "NOTIFIER",
+		Status:      "ACTIVE",
+		CreatedAt:   time.Now(),
+	}
+
+	return n.send(channel, &Notification{Event: EventAlertCreated, Alert: alert})
+}
+
+// Retry immediately re-attempts a delivery regardless of its schedule
+func (n *Notifier) Retry(deliveryID uuid.UUID) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	if err := n.db.First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("delivery not found")
+		}
+		return nil, err
+	}
+	if delivery.Status == StatusSent {
+		return nil, errors.New("delivery already sent")
+	}
+
+	if err := n.retry(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Redeliver sends a finished delivery again, sent or failed, as a new delivery of the same event and
+// alert snapshot so receivers can recover from their own outages. The original is left as it was.
+func (n *Notifier) Redeliver(deliveryID uuid.UUID) (*models.NotificationDelivery, error) {
+	var original models.NotificationDelivery
+	if err := n.db.First(&original, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("delivery not found")
+		}
+		return nil, err
+	}
+	if original.Status != StatusSent && original.Status != StatusFailed {
+		return nil, errors.New("delivery still in progress")
+	}
+
+	delivery := &models.NotificationDelivery{
+		AlertID:      original.AlertID,
+		ChannelID:    original.ChannelID,
+		Event:        original.Event,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+		Status:       StatusPending,
+	}
+	if err := n.db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	if err := n.retry(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// StartRetryWorker periodically re-attempts deliveries whose backoff has elapsed, until ctx is
+// cancelled
+func (n *Notifier) StartRetryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var due []models.NotificationDelivery
+		err := n.db.Where("status = ? AND next_attempt_at <= ?", StatusRetrying, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(100).
+			Find(&due).Error
+		if err != nil {
+			n.logger.Error("Failed to load pending notification retries", "error", err)
+			continue
+		}
+
+		for i := range due {
+			if err := n.retry(&due[i]); err != nil {
+				n.logger.Warn("Notification retry skipped", "delivery_id", due[i].ID, "error", err)
+			}
+		}
+	}
+}
+
+func (n *Notifier) retry(delivery *models.NotificationDelivery) error {
+	var channel models.NotificationChannel
+	if err := n.db.First(&channel, delivery.ChannelID).Error; err != nil {
+		n.markFailed(delivery, "notification channel no longer exists")
+		return fmt.Errorf("channel not found: %w", err)
+	}
+
+	alert, err := n.deliveryAlert(delivery)
+	if err != nil {
+		n.markFailed(delivery, "alert no longer exists")
+		return fmt.Errorf("alert not found: %w", err)
+	}
+
+	n.attempt(delivery, &channel, alert)
+	return nil
+}
+
+// deliveryAlert returns the alert snapshot taken when the event happened, falling back to the
+// current alert for deliveries recorded before snapshots were kept
+func (n *Notifier) deliveryAlert(delivery *models.NotificationDelivery) (*models.Alert, error) {
+	var alert models.Alert
+	if len(delivery.Payload) > 0 {
+		data, err := json.Marshal(delivery.Payload)
+		if err == nil {
+			if err = json.Unmarshal(data, &alert); err == nil {
+				return &alert, nil
+			}
+		}
+		n.logger.Warn("Unreadable notification payload, using the current alert", "delivery_id", delivery.ID, "error", err)
+	}
+
+	if err := n.db.First(&alert, delivery.AlertID).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// attempt sends once and records the outcome, scheduling a retry with exponential backoff on failure
+func (n *Notifier) attempt(delivery *models.NotificationDelivery, channel *models.NotificationChannel, alert *models.Alert) {
+	err := n.send(channel, &Notification{Event: delivery.Event, DeliveryID: delivery.ID, Alert: alert})
+	delivery.Attempts++
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = StatusSent
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= n.cfg.MaxAttempts {
+			delivery.Status = StatusFailed
+			delivery.NextAttemptAt = nil
+			n.logger.Error("Notification failed permanently",
+				"channel_type", channel.Type, "channel", channel.Name, "alert_id", alert.ID, "attempts", delivery.Attempts, "error", err)
+		} else {
+			backoff := n.cfg.RetryBaseDelay * time.Duration(1<<(delivery.Attempts-1))
+			next := now.Add(backoff)
+			delivery.Status = StatusRetrying
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := n.db.Save(delivery).Error; err != nil {
+		n.logger.Error("Failed to update notification delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (n *Notifier) send(channel *models.NotificationChannel, notification *Notification) error {
+	sender, ok := n.senders[channel.Type]
+	if !ok {
+		return fmt.Errorf("unsupported channel type: %s", channel.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.HTTPTimeout)
+	defer cancel()
+
+	return sender.Send(ctx, channel, notification)
+}
+
+func (n *Notifier) markFailed(delivery *models.NotificationDelivery, reason string) {
+	delivery.Status = StatusFailed
+	delivery.LastError = reason
+	delivery.NextAttemptAt = nil
+	n.db.Save(delivery)
+}
+
+// matchesList reports whether a comma-separated list, such as a channel's severities or events,
+// contains a value
+func matchesList(list, value string) bool {
+	for _, s := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(s), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotAlert converts an alert into the JSON kept with its deliveries
+func snapshotAlert(alert *models.Alert) (models.JSON, error) {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.JSON
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
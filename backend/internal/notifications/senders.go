@@ -0,0 +1,229 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Channel types
+const (
+	ChannelEmail   = "EMAIL"
+	ChannelSlack   = "SLACK"
+	ChannelWebhook = "WEBHOOK"
+)
+
+// Sender delivers an alert event to a single notification channel
+type Sender interface {
+	Send(ctx context.Context, channel *models.NotificationChannel, notification *Notification) error
+}
+
+// EmailSender delivers alerts over SMTP
+type EmailSender struct {
+	cfg *config.NotificationConfig
+}
+
+func NewEmailSender(cfg *config.NotificationConfig) *EmailSender {
+	return &EmailSender{cfg: cfg}
+}
+
+func (s *EmailSender) Send(ctx context.Context, channel *models.NotificationChannel, notification *Notification) error {
+	alert := notification.Alert
+
+	var recipients []string
+	for _, addr := range strings.Split(channel.Target, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+
+	subject := fmt.Sprintf("[%s] %s%s", alert.Severity, eventPrefix(notification.Event), alert.Title)
+	return s.SendMessage(recipients, subject, alertSummary(alert))
+}
+
+// SendMessage emails a plain text message to the given addresses
+func (s *EmailSender) SendMessage(recipients []string, subject, text string) error {
+	if s.cfg.SMTPHost == "" {
+		return errors.New("SMTP is not configured")
+	}
+	if len(recipients) == 0 {
+		return errors.New("no email recipients configured")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.cfg.SMTPFrom)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&body, "Subject: %s\r\n", headerSafe(subject))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(text)
+
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+
+	addr := fmt.Sprintf("%s:%s", s.cfg.SMTPHost, s.cfg.SMTPPort)
+	return smtp.SendMail(addr, auth, s.cfg.SMTPFrom, recipients, []byte(body.String()))
+}
+
+// SlackSender posts alerts to a Slack incoming webhook
+type SlackSender struct {
+	client *http.Client
+}
+
+func NewSlackSender(client *http.Client) *SlackSender {
+	return &SlackSender{client: client}
+}
+
+func (s *SlackSender) Send(ctx context.Context, channel *models.NotificationChannel, notification *Notification) error {
+	alert := notification.Alert
+	color := severityColor(alert.Severity)
+	if notification.Event == EventAlertResolved {
+		color = "#2e7d32"
+	}
+	payload := map[string]interface{}{
+		"text": fmt.Sprintf("*[%s] %s%s*", alert.Severity, eventPrefix(notification.Event), alert.Title),
+		"attachments": []map[string]interface{}{
+			{
+				"color": color,
+				"text":  alert.Description,
+				"fields": []map[string]interface{}{
+					{"title": "Type", "value": alert.AlertType, "short": true},
+					{"title": "Source", "value": alert.Source, "short": true},
+					{"title": "Portfolio", "value": alert.PortfolioID.String(), "short": true},
+					{"title": "Alert ID", "value": alert.ID.String(), "short": true},
+				},
+				"ts": alert.CreatedAt.Unix(),
+			},
+		},
+	}
+
+	return postJSON(ctx, s.client, channel.Target, payload, nil, "")
+}
+
+// WebhookSender posts alert events as JSON to an arbitrary HTTP endpoint. The event and delivery ID
+// are sent in the body and the X-Webhook-Event and X-Webhook-Delivery headers; the delivery ID stays
+// the same across retries so receivers can discard duplicates.
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender(client *http.Client) *WebhookSender {
+	return &WebhookSender{client: client}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, channel *models.NotificationChannel, notification *Notification) error {
+	payload := map[string]interface{}{
+		"event":     notification.Event,
+		"alert":     notification.Alert,
+		"timestamp": time.Now().Unix(),
+	}
+	if notification.DeliveryID != uuid.Nil {
+		payload["delivery_id"] = notification.DeliveryID
+	}
+
+	headers := map[string]string{}
+	if configured, ok := channel.Config["headers"].(map[string]interface{}); ok {
+		for k, v := range configured {
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+	headers["X-Webhook-Event"] = notification.Event
+	if notification.DeliveryID != uuid.Nil {
+		headers["X-Webhook-Delivery"] = notification.DeliveryID.String()
+	}
+
+	// Sign the body when a shared secret is configured so receivers can verify the sender
+	secret, _ := channel.Config["secret"].(string)
+
+	return postJSON(ctx, s.client, channel.Target, payload, headers, secret)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string, secret string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-SHA256", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+func alertSummary(alert *models.Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", alert.Title)
+	fmt.Fprintf(&b, "Severity:  %s\n", alert.Severity)
+	fmt.Fprintf(&b, "Type:      %s\n", alert.AlertType)
+	fmt.Fprintf(&b, "Source:    %s\n", alert.Source)
+	fmt.Fprintf(&b, "Portfolio: %s\n", alert.PortfolioID)
+	fmt.Fprintf(&b, "Alert ID:  %s\n", alert.ID)
+	fmt.Fprintf(&b, "Raised at: %s\n\n", alert.CreatedAt.Format(time.RFC1123))
+	b.WriteString(alert.Description)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// eventPrefix marks messages for events other than an alert being raised
+func eventPrefix(event string) string {
+	if event == EventAlertResolved {
+		return "Resolved: "
+	}
+	return ""
+}
+
+// headerSafe strips line breaks so alert text cannot inject extra mail headers
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case "CRITICAL":
+		return "#b71c1c"
+	case "HIGH":
+		return "#e65100"
+	case "MEDIUM":
+		return "#f9a825"
+	}
+	return "#607d8b"
+}
@@ -0,0 +1,178 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+)
+
+// Spec describes what an endpoint allows clients to filter and sort on. Map keys are query
+// parameter / sort names as clients send them; values are the database columns they map to.
+type Spec struct {
+	SortFields   map[string]string
+	DefaultSort  string // Key in SortFields
+	DateColumn   string // Column used by from/to; empty disables date ranges
+	Filters      map[string]string
+	DefaultLimit int
+}
+
+// Params is a parsed list request
+type Params struct {
+	Limit   int
+	Offset  int
+	Sort    string // Column to order by
+	Desc    bool
+	From    *time.Time
+	To      *time.Time
+	Filters map[string]string // Column -> value
+}
+
+// Parse reads limit, offset, sort (field or -field for descending), order (asc|desc), from, to
+// (RFC3339 or YYYY-MM-DD) and the spec's filters from the query string
+func Parse(c *fiber.Ctx, spec Spec) (Params, error) {
+	p := Params{
+		Limit:   c.QueryInt("limit", spec.defaultLimit()),
+		Offset:  c.QueryInt("offset", 0),
+		Desc:    true,
+		Filters: make(map[string]string),
+	}
+
+	if p.Limit <= 0 || p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+
+	sort := c.Query("sort", spec.DefaultSort)
+	if strings.HasPrefix(sort, "-") {
+		sort = strings.TrimPrefix(sort, "-")
+	} else if c.Query("sort") != "" {
+		p.Desc = false
+	}
+	switch strings.ToLower(c.Query("order")) {
+	case "asc":
+		p.Desc = false
+	case "desc":
+		p.Desc = true
+	}
+	if sort != "" {
+		column, ok := spec.SortFields[sort]
+		if !ok {
+			return p, errors.New("unsupported sort field: " + sort)
+		}
+		p.Sort = column
+	}
+
+	if spec.DateColumn != "" {
+		var err error
+		if p.From, err = parseDate(c.Query("from"), false); err != nil {
+			return p, errors.New("invalid from date, expected RFC3339 or YYYY-MM-DD")
+		}
+		if p.To, err = parseDate(c.Query("to"), true); err != nil {
+			return p, errors.New("invalid to date, expected RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	for param, column := range spec.Filters {
+		if value := c.Query(param); value != "" {
+			p.Filters[column] = value
+		}
+	}
+
+	return p, nil
+}
+
+// Filter applies the filters and date range to a query without ordering or paging it
+func (p Params) Filter(query *gorm.DB, spec Spec) *gorm.DB {
+	for column, value := range p.Filters {
+		query = query.Where(column+" = ?", value)
+	}
+	if p.From != nil {
+		query = query.Where(spec.DateColumn+" >= ?", *p.From)
+	}
+	if p.To != nil {
+		query = query.Where(spec.DateColumn+" <= ?", *p.To)
+	}
+	return query
+}
+
+// Sorted orders a query without paging it, for walking every match such as in an export
+func (p Params) Sorted(query *gorm.DB) *gorm.DB {
+	if p.Sort != "" {
+		direction := " ASC"
+		if p.Desc {
+			direction = " DESC"
+		}
+		query = query.Order(p.Sort + direction)
+	}
+	return query
+}
+
+// Page orders and pages a query
+func (p Params) Page(query *gorm.DB) *gorm.DB {
+	return p.Sorted(query).Limit(p.Limit).Offset(p.Offset)
+}
+
+// Find filters, counts and pages query into dest, returning the total number of matches.
+// Preloads are applied to the page query only, since they cannot be combined with a count.
+func Find[T any](query *gorm.DB, spec Spec, p Params, dest *[]T, preloads ...string) (int64, error) {
+	query = p.Filter(query, spec)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	page := p.Page(query)
+	for _, preload := range preloads {
+		page = page.Preload(preload)
+	}
+	if err := page.Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Response wraps a page of results with its paging metadata
+func Response(data interface{}, total int64, p Params) fiber.Map {
+	return fiber.Map{
+		"data":   data,
+		"total":  total,
+		"limit":  p.Limit,
+		"offset": p.Offset,
+	}
+}
+
+func (s Spec) defaultLimit() int {
+	if s.DefaultLimit > 0 {
+		return s.DefaultLimit
+	}
+	return DefaultLimit
+}
+
+// parseDate accepts RFC3339 or a bare date; a bare end date covers the whole day
+func parseDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -0,0 +1,119 @@
+package reconciliation
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Tolerances are how far the custodian's positions may differ from ours without a break. Price is
+// a fraction of our price.
+type Tolerances struct {
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+}
+
+// Compare returns the breaks between our positions in a portfolio and the custodian's holdings in
+// it, ordered by symbol, and the number of symbols that matched. Positions and holdings of the same
+// symbol, such as separate lots, are summed, and a zero total counts as not held. Prices are only
+// compared when the custodian reports one in the position's currency. The breaks' run, portfolio
+// and status are left for the caller.
+func Compare(positions []models.Position, holdings []Holding, tol Tolerances) (int, []models.ReconciliationBreak) {
+	ours := make(map[string]models.Position, len(positions))
+	for _, position := range positions {
+		symbol := strings.ToUpper(position.Symbol)
+		if held, ok := ours[symbol]; ok {
+			position.Quantity = position.Quantity.Add(held.Quantity)
+		}
+		ours[symbol] = position
+	}
+	for symbol, position := range ours {
+		if position.Quantity.IsZero() {
+			delete(ours, symbol)
+		}
+	}
+
+	theirs := make(map[string]Holding, len(holdings))
+	for _, holding := range holdings {
+		if held, ok := theirs[holding.Symbol]; ok {
+			holding.Quantity = holding.Quantity.Add(held.Quantity)
+			if holding.Price.IsZero() {
+				holding.Price, holding.Currency = held.Price, held.Currency
+			}
+		}
+		theirs[holding.Symbol] = holding
+	}
+	for symbol, holding := range theirs {
+		if holding.Quantity.IsZero() {
+			delete(theirs, symbol)
+		}
+	}
+
+	symbols := make([]string, 0, len(ours)+len(theirs))
+	for symbol := range ours {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range theirs {
+		if _, ok := ours[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	slices.Sort(symbols)
+
+	matched := 0
+	var breaks []models.ReconciliationBreak
+	for _, symbol := range symbols {
+		position, held := ours[symbol]
+		holding, reported := theirs[symbol]
+
+		b := models.ReconciliationBreak{
+			Symbol:             symbol,
+			InternalQuantity:   position.Quantity,
+			ExternalQuantity:   holding.Quantity,
+			QuantityDifference: holding.Quantity.Sub(position.Quantity),
+			InternalPrice:      position.CurrentPrice,
+			ExternalPrice:      holding.Price,
+		}
+		switch {
+		case !held:
+			b.BreakType = models.BreakTypeMissingInternal
+			breaks = append(breaks, b)
+			continue
+		case !reported:
+			b.BreakType = models.BreakTypeMissingExternal
+			breaks = append(breaks, b)
+			continue
+		}
+
+		clean := true
+		if b.QuantityDifference.Abs().GreaterThan(tol.Quantity) {
+			b.BreakType = models.BreakTypeQuantity
+			breaks = append(breaks, b)
+			clean = false
+		}
+		if priceBreak(position, holding, tol.Price) {
+			b.BreakType = models.BreakTypePrice
+			breaks = append(breaks, b)
+			clean = false
+		}
+		if clean {
+			matched++
+		}
+	}
+	return matched, breaks
+}
+
+// priceBreak reports whether the custodian's price is further from ours than the tolerance
+func priceBreak(position models.Position, holding Holding, tolerance decimal.Decimal) bool {
+	if !holding.Price.IsPositive() || !position.CurrentPrice.IsPositive() {
+		return false
+	}
+	if holding.Currency != "" && position.Currency != "" && !strings.EqualFold(holding.Currency, position.Currency) {
+		return false
+	}
+	deviation := holding.Price.Sub(position.CurrentPrice).Abs().Div(position.CurrentPrice)
+	return deviation.GreaterThan(tolerance)
+}
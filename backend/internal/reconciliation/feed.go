@@ -0,0 +1,163 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// SourceHTTP fetches positions from the custodian's API
+const SourceHTTP = "http"
+
+const (
+	maxFeedResponseSize = 64 << 20
+	httpFeedTimeout     = time.Minute
+
+	// inboxSettleTime is how long a file must go unmodified before it is read, so a file still
+	// being transferred is left for the next poll
+	inboxSettleTime = 30 * time.Second
+)
+
+// NewFeed builds the custodian feed selected by the configuration; nil when files are only
+// uploaded or dropped into the inbox
+func NewFeed(cfg *config.ReconciliationConfig) (*HTTPFeed, error) {
+	switch cfg.Source {
+	case "":
+		return nil, nil
+	case SourceHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("RECON_URL is required for the %s reconciliation source", SourceHTTP)
+		}
+		return NewHTTPFeed(cfg.URL, cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown reconciliation source %q", cfg.Source)
+	}
+}
+
+// HTTPFeed fetches the custodian's positions with GET <url>?as_of=YYYY-MM-DD, as JSON (see
+// ParseJSON) or, when the response is text/csv, as a CSV position file
+type HTTPFeed struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPFeed(feedURL, apiKey string) *HTTPFeed {
+	return &HTTPFeed{
+		url:    feedURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: httpFeedTimeout},
+	}
+}
+
+func (f *HTTPFeed) Fetch(ctx context.Context, asOf time.Time) ([]Holding, error) {
+	endpoint, err := url.Parse(f.url)
+	if err != nil {
+		return nil, err
+	}
+	query := endpoint.Query()
+	query.Set("as_of", asOf.Format("2006-01-02"))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/csv")
+	if f.apiKey != "" {
+		req.Header.Set("X-API-Key", f.apiKey)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("positions endpoint returned status %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, maxFeedResponseSize)
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/csv" {
+		return ParseCSV(body)
+	}
+	return ParseJSON(body)
+}
+
+// Inbox is a directory custodian CSV files are delivered to, such as the landing directory of an
+// SFTP transfer. Files are moved to its processed or failed subdirectory once reconciled.
+type Inbox struct {
+	dir string
+}
+
+func NewInbox(dir string) *Inbox {
+	return &Inbox{dir: dir}
+}
+
+// Pending returns the paths of the CSV files waiting, oldest first. Hidden files, the usual
+// names of transfers in progress, and files modified in the last 30 seconds are skipped.
+func (i *Inbox) Pending() ([]string, error) {
+	entries, err := os.ReadDir(i.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		path    string
+		modTime time.Time
+	}
+	var files []pending
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") || !strings.EqualFold(filepath.Ext(name), ".csv") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) < inboxSettleTime {
+			continue
+		}
+		files = append(files, pending{path: filepath.Join(i.dir, name), modTime: info.ModTime()})
+	}
+
+	slices.SortFunc(files, func(a, b pending) int {
+		return a.modTime.Compare(b.modTime)
+	})
+	paths := make([]string, len(files))
+	for n, file := range files {
+		paths[n] = file.path
+	}
+	return paths, nil
+}
+
+// Done moves a file out of the inbox, into the failed subdirectory if it was rejected. A file of
+// the same name already there is kept by prefixing the new one with the time.
+func (i *Inbox) Done(path string, failed bool) error {
+	subdir := "processed"
+	if failed {
+		subdir = "failed"
+	}
+	dir := filepath.Join(i.dir, subdir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z-")+filepath.Base(path))
+	}
+	return os.Rename(path, target)
+}
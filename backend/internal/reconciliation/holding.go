@@ -0,0 +1,176 @@
+// Package reconciliation reads custodian position files and compares them with our positions
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxHoldings caps the positions read from one file
+const MaxHoldings = 100000
+
+// Holding is a position as the custodian reports it. The portfolio is identified by its ID or by
+// the custodian account it is held under.
+type Holding struct {
+	Line        int             `json:"-"`
+	PortfolioID string          `json:"portfolio_id"`
+	Account     string          `json:"account"`
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"` // Zero when the custodian reports no price
+	Currency    string          `json:"currency"`
+}
+
+// csvColumns are the recognised header names
+var csvColumns = map[string]bool{
+	"portfolio_id": true,
+	"account":      true,
+	"symbol":       true,
+	"quantity":     true,
+	"price":        true,
+	"currency":     true,
+}
+
+// ParseCSV reads a custodian position file with a header row. An unreadable row rejects the
+// whole file, since reconciling without it would report the position as missing.
+func ParseCSV(r io.Reader) ([]Holding, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !csvColumns[name] {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"symbol", "quantity"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing required column %q", name)
+		}
+	}
+	_, hasPortfolio := columns["portfolio_id"]
+	_, hasAccount := columns["account"]
+	if !hasPortfolio && !hasAccount {
+		return nil, errors.New("missing column \"portfolio_id\" or \"account\"")
+	}
+
+	holdings := []Holding{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return holdings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(holdings) >= MaxHoldings {
+			return nil, fmt.Errorf("file has more than %d positions", MaxHoldings)
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		holding := Holding{
+			Line:        line,
+			PortfolioID: get("portfolio_id"),
+			Account:     get("account"),
+			Symbol:      get("symbol"),
+			Currency:    get("currency"),
+		}
+		if get("quantity") == "" {
+			return nil, fmt.Errorf("line %d: quantity is required", line)
+		}
+		if holding.Quantity, err = parseDecimal("quantity", get("quantity")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if holding.Price, err = parseDecimal("price", get("price")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := holding.normalize(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		holdings = append(holdings, holding)
+	}
+}
+
+// ParseJSON reads {"positions": [...]} or a bare array of positions, with the CSV column names as
+// fields. Line is the position's index, from 1.
+func ParseJSON(r io.Reader) ([]Holding, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Positions []Holding `json:"positions"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Positions == nil {
+		envelope.Positions = nil
+		if err := json.Unmarshal(body, &envelope.Positions); err != nil {
+			return nil, fmt.Errorf("invalid positions response: %w", err)
+		}
+	}
+	if len(envelope.Positions) > MaxHoldings {
+		return nil, fmt.Errorf("response has more than %d positions", MaxHoldings)
+	}
+
+	for i := range envelope.Positions {
+		holding := &envelope.Positions[i]
+		holding.Line = i + 1
+		if err := holding.normalize(); err != nil {
+			return nil, fmt.Errorf("position %d: %w", holding.Line, err)
+		}
+	}
+	return envelope.Positions, nil
+}
+
+func (h *Holding) normalize() error {
+	h.PortfolioID = strings.TrimSpace(h.PortfolioID)
+	h.Account = strings.TrimSpace(h.Account)
+	h.Symbol = strings.ToUpper(strings.TrimSpace(h.Symbol))
+	h.Currency = strings.ToUpper(strings.TrimSpace(h.Currency))
+
+	if h.PortfolioID == "" && h.Account == "" {
+		return errors.New("portfolio_id or account is required")
+	}
+	if h.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if h.Price.IsNegative() {
+		return errors.New("price must not be negative")
+	}
+	return nil
+}
+
+func parseDecimal(field, value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,38 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"time"
+)
+
+// RenderCSV writes the document as CSV. Each section starts with a "# heading" row; facts are
+// label,value rows and tables keep their header row so sections can be split out by consumers.
+func RenderCSV(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"# " + doc.Title},
+		{"subtitle", doc.Subtitle},
+		{"generated_at", doc.GeneratedAt.UTC().Format(time.RFC3339)},
+	}
+	for _, section := range doc.Sections {
+		rows = append(rows, []string{}, []string{"# " + section.Heading})
+		if section.Text != "" {
+			rows = append(rows, []string{section.Text})
+		}
+		for _, fact := range section.Facts {
+			rows = append(rows, []string{fact.Label, fact.Value})
+		}
+		if section.Table != nil {
+			rows = append(rows, section.Table.Headers)
+			rows = append(rows, section.Table.Rows...)
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
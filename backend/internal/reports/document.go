@@ -0,0 +1,66 @@
+package reports
+
+import (
+	"errors"
+	"time"
+)
+
+// Output formats
+const (
+	FormatPDF = "pdf"
+	FormatCSV = "csv"
+)
+
+// Document is a format-independent report: a title block followed by sections of key/value
+// facts and tables. Renderers turn it into PDF or CSV.
+type Document struct {
+	Title       string
+	Subtitle    string
+	GeneratedAt time.Time
+	Sections    []Section
+}
+
+// Section is a headed block of a report
+type Section struct {
+	Heading string
+	Text    string
+	Facts   []Fact
+	Table   *Table
+}
+
+// Fact is a labelled value shown in a section
+type Fact struct {
+	Label string
+	Value string
+}
+
+// Table is a grid of values with a header row
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// AddSection appends a section and returns it for further population
+func (d *Document) AddSection(heading string) *Section {
+	d.Sections = append(d.Sections, Section{Heading: heading})
+	return &d.Sections[len(d.Sections)-1]
+}
+
+// AddFact appends a labelled value to a section
+func (s *Section) AddFact(label, value string) {
+	s.Facts = append(s.Facts, Fact{Label: label, Value: value})
+}
+
+// Render produces the document in the requested format and returns the content type
+func Render(doc *Document, format string) ([]byte, string, error) {
+	switch format {
+	case FormatPDF:
+		data, err := RenderPDF(doc)
+		return data, "application/pdf", err
+	case FormatCSV:
+		data, err := RenderCSV(doc)
+		return data, "text/csv", err
+	default:
+		return nil, "", errors.New("unsupported report format, use pdf or csv")
+	}
+}
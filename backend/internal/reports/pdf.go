@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfMargin     = 15.0
+	pdfLineHeight = 6.0
+	pdfLabelWidth = 60.0
+)
+
+// RenderPDF lays the document out on A4 pages with a repeated footer
+func RenderPDF(doc *Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(doc.Title, true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, tr(doc.Title), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, "Page "+strconv.Itoa(pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(0, 8, tr(doc.Title), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(90, 90, 90)
+	if doc.Subtitle != "" {
+		pdf.MultiCell(0, pdfLineHeight, tr(doc.Subtitle), "", "L", false)
+	}
+	pdf.MultiCell(0, pdfLineHeight, "Generated "+doc.GeneratedAt.UTC().Format(time.RFC1123), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+
+	width, _ := pdf.GetPageSize()
+	contentWidth := width - 2*pdfMargin
+
+	for _, section := range doc.Sections {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, tr(section.Heading), "B", 1, "L", false, 0, "")
+		pdf.Ln(1)
+
+		if section.Text != "" {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.MultiCell(0, pdfLineHeight, tr(section.Text), "", "L", false)
+		}
+
+		for _, fact := range section.Facts {
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.CellFormat(pdfLabelWidth, pdfLineHeight, tr(fact.Label), "", 0, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.MultiCell(contentWidth-pdfLabelWidth, pdfLineHeight, tr(fact.Value), "", "L", false)
+		}
+
+		if section.Table != nil && len(section.Table.Headers) > 0 {
+			renderPDFTable(pdf, section.Table, contentWidth, tr)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderPDFTable(pdf *fpdf.Fpdf, table *Table, contentWidth float64, tr func(string) string) {
+	colWidth := contentWidth / float64(len(table.Headers))
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for _, h := range table.Headers {
+			pdf.CellFormat(colWidth, pdfLineHeight, tr(truncate(pdf, h, colWidth)), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	header()
+	pdf.SetFont("Helvetica", "", 8)
+	_, pageHeight := pdf.GetPageSize()
+	for _, row := range table.Rows {
+		if pdf.GetY()+pdfLineHeight > pageHeight-pdfMargin {
+			pdf.AddPage()
+			header()
+			pdf.SetFont("Helvetica", "", 8)
+		}
+		for i := range table.Headers {
+			value := ""
+			if i < len(row) {
+				value = row[i]
+			}
+			pdf.CellFormat(colWidth, pdfLineHeight, tr(truncate(pdf, value, colWidth)), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
+
+// truncate shortens a value so it fits a table cell
+func truncate(pdf *fpdf.Fpdf, value string, width float64) string {
+	limit := width - 2
+	if pdf.GetStringWidth(value) <= limit {
+		return value
+	}
+	runes := []rune(value)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > limit {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
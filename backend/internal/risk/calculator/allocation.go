@@ -0,0 +1,199 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// AllocationTarget is the weight a portfolio aims to hold in a symbol or asset class, as fractions
+// of its positions and cash
+type AllocationTarget struct {
+	Kind      string  `json:"kind"` // SYMBOL or ASSET_CLASS
+	Key       string  `json:"key"`
+	Weight    float64 `json:"weight"`
+	Tolerance float64 `json:"tolerance"`
+}
+
+// AllocationCalculator compares a portfolio's weights with its target allocation and suggests the
+// trades that would bring drifted targets back to their weight
+type AllocationCalculator struct{}
+
+func NewAllocationCalculator() *AllocationCalculator {
+	return &AllocationCalculator{}
+}
+
+// CalculateDrift weighs the positions and cash against each target. Cash counts towards the CASH
+// asset class. A target drifted beyond its tolerance is breached and gets rebalancing trades: a
+// symbol target trades that symbol, an asset class target trades the class's holdings pro rata to
+// their value, and the trades suggested for one symbol by several targets are netted.
+func (ac *AllocationCalculator) CalculateDrift(positions []models.Position, cash float64, targets []AllocationTarget) *AllocationDriftResult {
+	result := &AllocationDriftResult{
+		Timestamp: time.Now(),
+		Cash:      cash,
+		Targets:   []AllocationDrift{},
+		Trades:    []RebalanceTrade{},
+		Warnings:  []string{},
+		Breaches:  []string{},
+	}
+
+	type holding struct {
+		value     float64
+		unitValue float64
+		class     string
+	}
+	holdings := make(map[string]*holding)
+	symbols := []string{}
+	classValues := map[string]float64{"CASH": cash}
+	result.TotalValue = cash
+	for _, position := range positions {
+		value := position.MarketValue.InexactFloat64()
+		result.TotalValue += value
+
+		h, ok := holdings[position.Symbol]
+		if !ok {
+			h = &holding{class: ClassifySymbol(position.Symbol, position.AssetType).AssetClass}
+			holdings[position.Symbol] = h
+			symbols = append(symbols, position.Symbol)
+		}
+		h.value += value
+		if !position.Quantity.IsZero() {
+			h.unitValue = value / position.Quantity.InexactFloat64()
+		}
+		classValues[h.class] += value
+	}
+	sort.Strings(symbols)
+
+	adjustments := make(map[string]float64)
+	unheld := []RebalanceTrade{}
+	warningLevel := false
+	for _, target := range targets {
+		drift := AllocationDrift{
+			Kind:         target.Kind,
+			Key:          target.Key,
+			TargetWeight: target.Weight,
+			Tolerance:    target.Tolerance,
+			TargetValue:  target.Weight * result.TotalValue,
+		}
+		if target.Kind == models.AllocationKindSymbol {
+			if h, ok := holdings[target.Key]; ok {
+				drift.MarketValue = h.value
+			}
+		} else {
+			drift.MarketValue = classValues[target.Key]
+		}
+		if result.TotalValue > 0 {
+			drift.CurrentWeight = drift.MarketValue / result.TotalValue
+		}
+		drift.Drift = drift.CurrentWeight - drift.TargetWeight
+		result.MaxDrift = math.Max(result.MaxDrift, math.Abs(drift.Drift))
+
+		switch {
+		case math.Abs(drift.Drift) > target.Tolerance:
+			drift.Breached = true
+			result.Breaches = append(result.Breaches, fmt.Sprintf("%s %s is %.2f%% against a target of %.2f%% ± %.2f%%",
+				target.Kind, target.Key, drift.CurrentWeight*100, drift.TargetWeight*100, target.Tolerance*100))
+		case math.Abs(drift.Drift) >= target.Tolerance*0.8:
+			warningLevel = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s is close to its tolerance band", target.Kind, target.Key))
+		}
+		result.Targets = append(result.Targets, drift)
+		if !drift.Breached {
+			continue
+		}
+
+		change := drift.TargetValue - drift.MarketValue
+		switch {
+		case target.Kind == models.AllocationKindSymbol:
+			if _, ok := holdings[target.Key]; ok {
+				adjustments[target.Key] += change
+			} else {
+				unheld = append(unheld, RebalanceTrade{Symbol: target.Key, Side: "BUY", Value: change})
+			}
+		case target.Key == "CASH":
+			// Cash follows from the other trades rather than being traded itself
+		case drift.MarketValue > 0:
+			for _, symbol := range symbols {
+				if h := holdings[symbol]; h.class == target.Key && h.value > 0 {
+					adjustments[symbol] += change * h.value / drift.MarketValue
+				}
+			}
+		default:
+			unheld = append(unheld, RebalanceTrade{AssetClass: target.Key, Side: "BUY", Value: change})
+		}
+	}
+
+	for _, symbol := range symbols {
+		value, ok := adjustments[symbol]
+		if !ok || value == 0 {
+			continue
+		}
+		h := holdings[symbol]
+		trade := RebalanceTrade{Symbol: symbol, AssetClass: h.class, Side: "BUY", Value: math.Abs(value)}
+		if value < 0 {
+			trade.Side = "SELL"
+		}
+		if h.unitValue != 0 {
+			trade.Quantity = math.Abs(value / h.unitValue)
+		}
+		result.Trades = append(result.Trades, trade)
+	}
+	// Targets the portfolio holds nothing of can only be given a value to buy
+	for _, trade := range unheld {
+		if trade.Value > 0 {
+			result.Trades = append(result.Trades, trade)
+		}
+	}
+
+	switch {
+	case len(result.Breaches) > 0:
+		result.Status = "CRITICAL"
+	case warningLevel:
+		result.Status = "WARNING"
+	default:
+		result.Status = "SAFE"
+	}
+
+	return result
+}
+
+// AllocationDrift compares the weight held in a symbol or asset class with its target
+type AllocationDrift struct {
+	Kind          string  `json:"kind"`
+	Key           string  `json:"key"`
+	TargetWeight  float64 `json:"target_weight"`
+	CurrentWeight float64 `json:"current_weight"`
+	Drift         float64 `json:"drift"` // Current less target weight
+	Tolerance     float64 `json:"tolerance"`
+	MarketValue   float64 `json:"market_value"`
+	TargetValue   float64 `json:"target_value"`
+	Breached      bool    `json:"breached"`
+}
+
+// RebalanceTrade is a suggested trade in the portfolio currency. Quantity is zero when the
+// portfolio holds nothing to price the trade from; Symbol is empty for an asset class it does not
+// hold at all.
+type RebalanceTrade struct {
+	Symbol     string  `json:"symbol,omitempty"`
+	AssetClass string  `json:"asset_class,omitempty"`
+	Side       string  `json:"side"`
+	Quantity   float64 `json:"quantity"`
+	Value      float64 `json:"value"`
+}
+
+// AllocationDriftResult contains a portfolio's drift from its target allocation and the trades
+// suggested to rebalance the targets breached
+type AllocationDriftResult struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	TotalValue float64           `json:"total_value"` // Positions and cash
+	Cash       float64           `json:"cash"`
+	MaxDrift   float64           `json:"max_drift"`
+	Status     string            `json:"status"`
+	Targets    []AllocationDrift `json:"targets"`
+	Trades     []RebalanceTrade  `json:"trades"`
+	Warnings   []string          `json:"warnings"`
+	Breaches   []string          `json:"breaches"`
+}
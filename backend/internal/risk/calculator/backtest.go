@@ -0,0 +1,208 @@
+package calculator
+
+import (
+	"math"
+	"time"
+)
+
+// Basel traffic-light zones
+const (
+	TrafficLightGreen  = "GREEN"
+	TrafficLightYellow = "YELLOW"
+	TrafficLightRed    = "RED"
+)
+
+// significanceLevel is the p-value below which the coverage and independence tests reject the model
+const significanceLevel = 0.05
+
+// BacktestObservation pairs a VaR forecast with the P&L realized over the following period.
+// VaR is a positive loss amount; a loss larger than VaR is an exception.
+type BacktestObservation struct {
+	Date        time.Time `json:"date"`
+	VaR         float64   `json:"var"`
+	PnL         float64   `json:"pnl"`
+	IsException bool      `json:"is_exception"`
+}
+
+// BacktestCalculator validates a VaR model against realized P&L
+type BacktestCalculator struct {
+	confidence float64
+}
+
+// NewBacktestCalculator creates a calculator for VaR forecasts at the given confidence level
+func NewBacktestCalculator(confidence float64) *BacktestCalculator {
+	if confidence <= 0 || confidence >= 1 {
+		confidence = 0.99
+	}
+	return &BacktestCalculator{confidence: confidence}
+}
+
+// Backtest counts VaR exceptions and runs the Kupiec proportion-of-failures, Christoffersen
+// independence and conditional coverage tests, then rates the model with the Basel traffic light
+func (b *BacktestCalculator) Backtest(observations []BacktestObservation) *BacktestResult {
+	result := &BacktestResult{
+		Confidence:   b.confidence,
+		Observations: len(observations),
+		Series:       make([]BacktestObservation, 0, len(observations)),
+	}
+
+	exceptions := make([]bool, len(observations))
+	for i, obs := range observations {
+		obs.IsException = obs.PnL < -obs.VaR
+		exceptions[i] = obs.IsException
+		if obs.IsException {
+			result.Exceptions++
+		}
+		result.Series = append(result.Series, obs)
+	}
+
+	n := float64(result.Observations)
+	p := 1 - b.confidence
+	result.ExpectedExceptions = n * p
+	if n > 0 {
+		result.ExceptionRate = float64(result.Exceptions) / n
+	}
+
+	result.Kupiec = kupiecTest(result.Exceptions, result.Observations, p)
+	result.Christoffersen = christoffersenTest(exceptions)
+	ccStatistic := result.Kupiec.Statistic + result.Christoffersen.Statistic
+	result.ConditionalCoverage = newTestResult(ccStatistic, chiSquarePValue(ccStatistic, 2))
+
+	result.CumulativeProbability = binomialCDF(result.Exceptions, result.Observations, p)
+	result.TrafficLight = trafficLight(result.CumulativeProbability)
+	return result
+}
+
+// kupiecTest checks the exception rate matches the VaR confidence level (chi-square, 1 df)
+func kupiecTest(exceptions, observations int, p float64) TestResult {
+	if observations == 0 {
+		return newTestResult(0, 1)
+	}
+
+	x := float64(exceptions)
+	n := float64(observations)
+	observed := x / n
+
+	logNull := xlogy(n-x, 1-p) + xlogy(x, p)
+	logAlt := xlogy(n-x, 1-observed) + xlogy(x, observed)
+	statistic := math.Max(0, -2*(logNull-logAlt))
+	return newTestResult(statistic, chiSquarePValue(statistic, 1))
+}
+
+// christoffersenTest checks exceptions do not cluster: whether an exception today makes one
+// tomorrow more likely (chi-square, 1 df)
+func christoffersenTest(exceptions []bool) TestResult {
+	var n00, n01, n10, n11 float64
+	for i := 1; i < len(exceptions); i++ {
+		switch {
+		case !exceptions[i-1] && !exceptions[i]:
+			n00++
+		case !exceptions[i-1] && exceptions[i]:
+			n01++
+		case exceptions[i-1] && !exceptions[i]:
+			n10++
+		default:
+			n11++
+		}
+	}
+
+	total := n00 + n01 + n10 + n11
+	if total == 0 {
+		return newTestResult(0, 1)
+	}
+
+	pi := (n01 + n11) / total
+	pi0, pi1 := 0.0, 0.0
+	if n00+n01 > 0 {
+		pi0 = n01 / (n00 + n01)
+	}
+	if n10+n11 > 0 {
+		pi1 = n11 / (n10 + n11)
+	}
+
+	logNull := xlogy(n00+n10, 1-pi) + xlogy(n01+n11, pi)
+	logAlt := xlogy(n00, 1-pi0) + xlogy(n01, pi0) + xlogy(n10, 1-pi1) + xlogy(n11, pi1)
+	statistic := math.Max(0, -2*(logNull-logAlt))
+	return newTestResult(statistic, chiSquarePValue(statistic, 1))
+}
+
+// trafficLight applies the Basel zones: green while the exception count is within the 95th
+// percentile of the binomial distribution, red beyond the 99.99th
+func trafficLight(cumulativeProbability float64) string {
+	switch {
+	case cumulativeProbability < 0.95:
+		return TrafficLightGreen
+	case cumulativeProbability < 0.9999:
+		return TrafficLightYellow
+	default:
+		return TrafficLightRed
+	}
+}
+
+func newTestResult(statistic, pValue float64) TestResult {
+	return TestResult{
+		Statistic: statistic,
+		PValue:    pValue,
+		Rejected:  pValue < significanceLevel,
+	}
+}
+
+// chiSquarePValue is the upper tail probability of the chi-square distribution for 1 or 2 degrees of freedom
+func chiSquarePValue(statistic float64, df int) float64 {
+	if statistic <= 0 {
+		return 1
+	}
+	if df == 2 {
+		return math.Exp(-statistic / 2)
+	}
+	return math.Erfc(math.Sqrt(statistic / 2))
+}
+
+// binomialCDF is P(X <= k) for X ~ Binomial(n, p)
+func binomialCDF(k, n int, p float64) float64 {
+	if n == 0 {
+		return 1
+	}
+	sum := 0.0
+	for i := 0; i <= k && i <= n; i++ {
+		sum += math.Exp(logBinomialCoefficient(n, i) + xlogy(float64(i), p) + xlogy(float64(n-i), 1-p))
+	}
+	return math.Min(sum, 1)
+}
+
+func logBinomialCoefficient(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// xlogy returns x*log(y), treating 0*log(0) as 0
+func xlogy(x, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
+
+// BacktestResult contains the outcome of a VaR backtest
+type BacktestResult struct {
+	Confidence            float64               `json:"confidence"`
+	Observations          int                   `json:"observations"`
+	Exceptions            int                   `json:"exceptions"`
+	ExpectedExceptions    float64               `json:"expected_exceptions"`
+	ExceptionRate         float64               `json:"exception_rate"`
+	Kupiec                TestResult            `json:"kupiec"`
+	Christoffersen        TestResult            `json:"christoffersen"`
+	ConditionalCoverage   TestResult            `json:"conditional_coverage"`
+	CumulativeProbability float64               `json:"cumulative_probability"` // P(exceptions <= observed) if the model is correct
+	TrafficLight          string                `json:"traffic_light"`
+	Series                []BacktestObservation `json:"series"`
+}
+
+// TestResult is a likelihood-ratio test outcome
+type TestResult struct {
+	Statistic float64 `json:"statistic"`
+	PValue    float64 `json:"p_value"`
+	Rejected  bool    `json:"rejected"`
+}
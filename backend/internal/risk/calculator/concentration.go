@@ -0,0 +1,234 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// ConcentrationLimits are the thresholds a portfolio's concentration is checked against, as fractions
+type ConcentrationLimits struct {
+	MaxHHI            float64 `json:"max_hhi"`
+	MaxSingleAsset    float64 `json:"max_single_asset"`
+	MaxSectorExposure float64 `json:"max_sector_exposure"`
+}
+
+// ConcentrationCalculator measures how concentrated a portfolio is by position, sector and asset class
+type ConcentrationCalculator struct {
+	topN int
+}
+
+// NewConcentrationCalculator creates a calculator that reports the topN largest positions
+func NewConcentrationCalculator(topN int) *ConcentrationCalculator {
+	if topN <= 0 {
+		topN = 5
+	}
+	return &ConcentrationCalculator{topN: topN}
+}
+
+// CalculateConcentration computes the Herfindahl-Hirschman index, top-N weights and sector and
+// asset class breakdowns, and reports any limits breached
+func (cc *ConcentrationCalculator) CalculateConcentration(positions []models.Position, limits ConcentrationLimits) *ConcentrationResult {
+	result := &ConcentrationResult{
+		Timestamp:    time.Now(),
+		Limits:       limits,
+		TopPositions: []PositionWeight{},
+		Sectors:      []ExposureBreakdown{},
+		AssetClasses: []ExposureBreakdown{},
+		Breaches:     []ConcentrationBreach{},
+	}
+
+	// Aggregate by symbol first so split lots of the same asset count once
+	bySymbol := make(map[string]*PositionWeight)
+	for _, position := range positions {
+		value := position.MarketValue.InexactFloat64()
+		if value <= 0 {
+			continue
+		}
+		result.TotalValue += value
+
+		pw, ok := bySymbol[position.Symbol]
+		if !ok {
+			classification := ClassifySymbol(position.Symbol, position.AssetType)
+			pw = &PositionWeight{
+				Symbol:     position.Symbol,
+				Sector:     classification.Sector,
+				AssetClass: classification.AssetClass,
+			}
+			bySymbol[position.Symbol] = pw
+		}
+		pw.MarketValue += value
+	}
+
+	if result.TotalValue == 0 {
+		return result
+	}
+
+	weights := make([]PositionWeight, 0, len(bySymbol))
+	sectors := make(map[string]*ExposureBreakdown)
+	assetClasses := make(map[string]*ExposureBreakdown)
+
+	for _, pw := range bySymbol {
+		pw.Weight = pw.MarketValue / result.TotalValue
+		result.HHI += pw.Weight * pw.Weight
+		weights = append(weights, *pw)
+
+		addExposure(sectors, pw.Sector, *pw)
+		addExposure(assetClasses, pw.AssetClass, *pw)
+	}
+
+	sort.Slice(weights, func(i, j int) bool {
+		return weights[i].Weight > weights[j].Weight
+	})
+
+	result.PositionCount = len(weights)
+	result.EffectivePositions = 1 / result.HHI
+	result.TopN = cc.topN
+	for i := 0; i < len(weights) && i < cc.topN; i++ {
+		result.TopPositions = append(result.TopPositions, weights[i])
+		result.TopNWeight += weights[i].Weight
+	}
+
+	result.Sectors = sortedExposures(sectors, result.TotalValue)
+	result.AssetClasses = sortedExposures(assetClasses, result.TotalValue)
+
+	result.Breaches = cc.checkBreaches(result, weights)
+	result.Status = concentrationStatus(result.Breaches)
+
+	return result
+}
+
+func (cc *ConcentrationCalculator) checkBreaches(result *ConcentrationResult, weights []PositionWeight) []ConcentrationBreach {
+	breaches := []ConcentrationBreach{}
+	limits := result.Limits
+
+	if limits.MaxHHI > 0 && result.HHI > limits.MaxHHI {
+		breaches = append(breaches, newBreach("CONCENTRATION_INDEX", "PORTFOLIO",
+			fmt.Sprintf("Herfindahl index %.4f exceeds limit %.4f", result.HHI, limits.MaxHHI),
+			result.HHI, limits.MaxHHI))
+	}
+
+	if limits.MaxSingleAsset > 0 {
+		for _, pw := range weights {
+			if pw.Weight > limits.MaxSingleAsset {
+				breaches = append(breaches, newBreach("SINGLE_ASSET_EXPOSURE", pw.Symbol,
+					fmt.Sprintf("%s is %.2f%% of the portfolio, limit %.2f%%", pw.Symbol, pw.Weight*100, limits.MaxSingleAsset*100),
+					pw.Weight, limits.MaxSingleAsset))
+			}
+		}
+	}
+
+	if limits.MaxSectorExposure > 0 {
+		for _, sector := range result.Sectors {
+			if sector.Name == UnclassifiedSector {
+				continue
+			}
+			if sector.Weight > limits.MaxSectorExposure {
+				breaches = append(breaches, newBreach("SECTOR_EXPOSURE", sector.Name,
+					fmt.Sprintf("Sector %s is %.2f%% of the portfolio, limit %.2f%%", sector.Name, sector.Weight*100, limits.MaxSectorExposure*100),
+					sector.Weight, limits.MaxSectorExposure))
+			}
+		}
+	}
+
+	return breaches
+}
+
+// newBreach grades a breach CRITICAL once the value exceeds the limit by more than half again
+func newBreach(breachType, subject, message string, value, limit float64) ConcentrationBreach {
+	severity := "WARNING"
+	if value > limit*1.5 {
+		severity = "CRITICAL"
+	}
+	return ConcentrationBreach{
+		Type:      breachType,
+		Subject:   subject,
+		Severity:  severity,
+		Message:   message,
+		Value:     value,
+		Threshold: limit,
+	}
+}
+
+func concentrationStatus(breaches []ConcentrationBreach) string {
+	status := "SAFE"
+	for _, breach := range breaches {
+		if breach.Severity == "CRITICAL" {
+			return "CRITICAL"
+		}
+		status = "WARNING"
+	}
+	return status
+}
+
+func addExposure(exposures map[string]*ExposureBreakdown, name string, pw PositionWeight) {
+	exposure, ok := exposures[name]
+	if !ok {
+		exposure = &ExposureBreakdown{Name: name, Symbols: []string{}}
+		exposures[name] = exposure
+	}
+	exposure.MarketValue += pw.MarketValue
+	exposure.Symbols = append(exposure.Symbols, pw.Symbol)
+}
+
+func sortedExposures(exposures map[string]*ExposureBreakdown, totalValue float64) []ExposureBreakdown {
+	result := make([]ExposureBreakdown, 0, len(exposures))
+	for _, exposure := range exposures {
+		exposure.Weight = exposure.MarketValue / totalValue
+		sort.Strings(exposure.Symbols)
+		result = append(result, *exposure)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Weight > result[j].Weight
+	})
+	return result
+}
+
+// Result structures
+
+// ConcentrationResult contains the concentration analysis for a portfolio
+type ConcentrationResult struct {
+	Timestamp          time.Time             `json:"timestamp"`
+	TotalValue         float64               `json:"total_value"`
+	PositionCount      int                   `json:"position_count"`
+	HHI                float64               `json:"hhi"`
+	EffectivePositions float64               `json:"effective_positions"` // 1/HHI: equally weighted positions with the same concentration
+	TopN               int                   `json:"top_n"`
+	TopNWeight         float64               `json:"top_n_weight"`
+	TopPositions       []PositionWeight      `json:"top_positions"`
+	Sectors            []ExposureBreakdown   `json:"sectors"`
+	AssetClasses       []ExposureBreakdown   `json:"asset_classes"`
+	Limits             ConcentrationLimits   `json:"limits"`
+	Breaches           []ConcentrationBreach `json:"breaches"`
+	Status             string                `json:"status"`
+}
+
+// PositionWeight is a symbol's share of the portfolio
+type PositionWeight struct {
+	Symbol      string  `json:"symbol"`
+	Sector      string  `json:"sector"`
+	AssetClass  string  `json:"asset_class"`
+	MarketValue float64 `json:"market_value"`
+	Weight      float64 `json:"weight"`
+}
+
+// ExposureBreakdown is the portfolio's exposure to one sector or asset class
+type ExposureBreakdown struct {
+	Name        string   `json:"name"`
+	MarketValue float64  `json:"market_value"`
+	Weight      float64  `json:"weight"`
+	Symbols     []string `json:"symbols"`
+}
+
+// ConcentrationBreach is a concentration limit the portfolio exceeds
+type ConcentrationBreach struct {
+	Type      string  `json:"type"`
+	Subject   string  `json:"subject"`
+	Severity  string  `json:"severity"`
+	Message   string  `json:"message"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
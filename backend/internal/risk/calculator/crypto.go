@@ -0,0 +1,457 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// hoursPerYear annualizes hourly crypto return statistics. Crypto trades every hour of every day,
+// so there are no weekends or holidays to leave out as with tradingDaysPerYear.
+const hoursPerYear = 24 * 365
+
+// cryptoVolatilityWindows are the rolling windows crypto volatility is measured over
+var cryptoVolatilityWindows = []struct {
+	label  string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// CryptoAssetTerms are the terms of a crypto asset from its reference data
+type CryptoAssetTerms struct {
+	Chain      string
+	Stablecoin bool
+	PegPrice   float64 // Zero for assets without a peg
+}
+
+// defaultCryptoAssets covers the crypto assets traded by the platform and the mock generator
+var defaultCryptoAssets = map[string]CryptoAssetTerms{
+	"BTC":  {Chain: "BITCOIN"},
+	"ETH":  {Chain: "ETHEREUM"},
+	"SOL":  {Chain: "SOLANA"},
+	"USDT": {Chain: "ETHEREUM", Stablecoin: true, PegPrice: 1},
+	"USDC": {Chain: "ETHEREUM", Stablecoin: true, PegPrice: 1},
+	"DAI":  {Chain: "ETHEREUM", Stablecoin: true, PegPrice: 1},
+}
+
+// DefaultCryptoAssets returns the built-in crypto asset terms, keyed by symbol, which reference
+// data overrides
+func DefaultCryptoAssets() map[string]CryptoAssetTerms {
+	return maps.Clone(defaultCryptoAssets)
+}
+
+// chainProfiles are the markets assets settling on each chain are assumed to trade in. Assets on
+// other chains trade like the LOW liquidity tier.
+var chainProfiles = map[string]tierMarketProfile{
+	"BITCOIN":  {volumeMultiple: 50, spread: 0.0005, marketCap: 1e12},
+	"ETHEREUM": {volumeMultiple: 30, spread: 0.001, marketCap: 300e9},
+	"SOLANA":   {volumeMultiple: 5, spread: 0.003, marketCap: 50e9},
+}
+
+// stablecoinSpread is the spread of a stablecoin trading at its peg, whatever its chain
+const stablecoinSpread = 0.0002
+
+// IsCryptoPosition reports whether a position holds a crypto asset, by its asset type, its symbol's
+// classification or crypto reference data
+func IsCryptoPosition(position models.Position, assets map[string]CryptoAssetTerms) bool {
+	if _, ok := assets[position.Symbol]; ok {
+		return true
+	}
+	return ClassifySymbol(position.Symbol, position.AssetType).AssetClass == "CRYPTO"
+}
+
+// ChainMarketData is a MarketDataProvider that estimates the volume, spread and market cap of
+// crypto assets from the chain they settle on, and of every other symbol from its liquidity tier
+type ChainMarketData struct {
+	*TierMarketData
+	chains      map[string]string
+	stablecoins map[string]bool
+}
+
+// NewChainMarketData creates a provider for the symbols held in positions, with assets keyed by
+// symbol. Crypto positions without terms are treated as settling on an unknown chain.
+func NewChainMarketData(positions []models.Position, assets map[string]CryptoAssetTerms) *ChainMarketData {
+	m := &ChainMarketData{
+		TierMarketData: NewTierMarketData(positions),
+		chains:         make(map[string]string),
+		stablecoins:    make(map[string]bool),
+	}
+	for _, position := range positions {
+		if !IsCryptoPosition(position, assets) {
+			continue
+		}
+		terms := assets[position.Symbol]
+		m.chains[position.Symbol] = strings.ToUpper(terms.Chain)
+		m.stablecoins[position.Symbol] = terms.Stablecoin
+	}
+	return m
+}
+
+func (m *ChainMarketData) chainProfile(symbol string) (tierMarketProfile, bool) {
+	chain, ok := m.chains[symbol]
+	if !ok {
+		return tierMarketProfile{}, false
+	}
+	if profile, ok := chainProfiles[chain]; ok {
+		return profile, true
+	}
+	return tierProfiles["LOW"], true
+}
+
+func (m *ChainMarketData) GetAverageDailyVolume(symbol string) float64 {
+	if profile, ok := m.chainProfile(symbol); ok {
+		return m.quantities[symbol] * profile.volumeMultiple
+	}
+	return m.TierMarketData.GetAverageDailyVolume(symbol)
+}
+
+func (m *ChainMarketData) GetBidAskSpread(symbol string) float64 {
+	if profile, ok := m.chainProfile(symbol); ok {
+		if m.stablecoins[symbol] {
+			return stablecoinSpread
+		}
+		return profile.spread
+	}
+	return m.TierMarketData.GetBidAskSpread(symbol)
+}
+
+func (m *ChainMarketData) GetMarketCap(symbol string) float64 {
+	if profile, ok := m.chainProfile(symbol); ok {
+		return profile.marketCap
+	}
+	return m.TierMarketData.GetMarketCap(symbol)
+}
+
+// PriceSample is a price observed at a point in time
+type PriceSample struct {
+	Time  time.Time
+	Price float64
+}
+
+// RealizedVolatility is the annualized volatility of the log returns between consecutive samples,
+// sorted by time, within the window ending at asOf. Each return is scaled to one hour by the
+// square root of the hours it spans, so gaps in the samples neither inflate nor dampen the result.
+// It is nil with fewer than two returns in the window.
+func RealizedVolatility(samples []PriceSample, window time.Duration, asOf time.Time) *float64 {
+	start := asOf.Add(-window)
+	returns := []float64{}
+	var previous *PriceSample
+	for i := range samples {
+		sample := &samples[i]
+		if sample.Time.Before(start) || sample.Time.After(asOf) || sample.Price <= 0 {
+			continue
+		}
+		if previous != nil {
+			if hours := sample.Time.Sub(previous.Time).Hours(); hours > 0 {
+				returns = append(returns, math.Log(sample.Price/previous.Price)/math.Sqrt(hours))
+			}
+		}
+		previous = sample
+	}
+	if len(returns) < 2 {
+		return nil
+	}
+
+	_, variance := meanVariance(returns)
+	volatility := math.Sqrt(variance * hoursPerYear)
+	return &volatility
+}
+
+// CustodyHolding is a quantity of a crypto asset kept at one venue
+type CustodyHolding struct {
+	Symbol         string
+	VenueType      string // EXCHANGE, CUSTODIAN, HOT_WALLET or COLD_WALLET
+	VenueName      string
+	CounterpartyID *uuid.UUID
+	ExposureLimit  float64 // The counterparty's exposure limit; zero means no limit
+	Quantity       float64
+}
+
+// CryptoLimits are the crypto risk limits of a portfolio, as fractions. Zero disables a limit.
+type CryptoLimits struct {
+	MaxVenueExposure   float64 // Share of crypto value one exchange or custodian may hold
+	MaxStablecoinDepeg float64 // Deviation of a stablecoin from its peg treated as a depeg
+}
+
+// CryptoCalculator measures the market, liquidity and venue risk of a portfolio's crypto assets
+type CryptoCalculator struct{}
+
+func NewCryptoCalculator() *CryptoCalculator {
+	return &CryptoCalculator{}
+}
+
+// Calculate analyses the crypto positions among positions, with assets keyed by symbol and hourly
+// price samples keyed by symbol and sorted by time. Liquidity is scored by the LiquidityCalculator
+// from the chain each asset settles on. Custody holdings are valued at their position's price per
+// unit; crypto held at no recorded venue is reported as unallocated. A venue breaches the limits
+// when an exchange or custodian holds more than MaxVenueExposure of crypto value or more than its
+// counterparty's exposure limit, and a stablecoin when its price is MaxStablecoinDepeg or further
+// from its peg.
+func (cc *CryptoCalculator) Calculate(positions []models.Position, assets map[string]CryptoAssetTerms, samples map[string][]PriceSample,
+	custody []CustodyHolding, limits CryptoLimits, asOf time.Time) *CryptoRiskResult {
+	result := &CryptoRiskResult{
+		Timestamp:        asOf,
+		MaxVenueExposure: limits.MaxVenueExposure,
+		MaxDepeg:         limits.MaxStablecoinDepeg,
+		Positions:        []CryptoPositionRisk{},
+		Chains:           []ChainExposure{},
+		Venues:           []VenueExposure{},
+		Stablecoins:      []StablecoinPeg{},
+		Warnings:         []string{},
+		Breaches:         []string{},
+	}
+
+	crypto := []models.Position{}
+	for _, position := range positions {
+		if IsCryptoPosition(position, assets) {
+			crypto = append(crypto, position)
+		}
+	}
+	liquidity := NewLiquidityCalculator(NewChainMarketData(crypto, assets))
+
+	quantities := make(map[string]float64)
+	unitValues := make(map[string]float64)
+	byChain := make(map[string]*ChainExposure)
+	warningLevel := false
+	for _, position := range crypto {
+		terms := assets[position.Symbol]
+		chain := strings.ToUpper(terms.Chain)
+		if chain == "" {
+			chain = "UNKNOWN"
+		}
+		marketValue := position.MarketValue.InexactFloat64()
+		quantity := position.Quantity.InexactFloat64()
+		quantities[position.Symbol] += quantity
+		if quantity != 0 {
+			unitValues[position.Symbol] = marketValue / quantity
+		}
+
+		analysis := liquidity.analyzePositionLiquidity(position)
+		risk := CryptoPositionRisk{
+			PositionID:      position.ID,
+			Symbol:          position.Symbol,
+			Chain:           chain,
+			Stablecoin:      terms.Stablecoin,
+			Quantity:        quantity,
+			Price:           position.CurrentPrice.InexactFloat64(),
+			MarketValue:     marketValue,
+			Volatility:      make(map[string]*float64, len(cryptoVolatilityWindows)),
+			LiquidityScore:  analysis.LiquidityScore,
+			LiquidityClass:  analysis.LiquidityClass,
+			DaysToLiquidate: analysis.DaysToLiquidate,
+		}
+		for _, window := range cryptoVolatilityWindows {
+			risk.Volatility[window.label] = RealizedVolatility(samples[position.Symbol], window.length, asOf)
+		}
+		if risk.Volatility["24h"] == nil && !terms.Stablecoin {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: not enough price samples in the last 24 hours to measure volatility", position.Symbol))
+		}
+		result.Positions = append(result.Positions, risk)
+		result.CryptoValue += marketValue
+
+		exposure, ok := byChain[chain]
+		if !ok {
+			exposure = &ChainExposure{Chain: chain}
+			byChain[chain] = exposure
+		}
+		exposure.MarketValue += marketValue
+		exposure.Positions++
+
+		if terms.Stablecoin && terms.PegPrice > 0 && risk.Price > 0 {
+			peg := StablecoinPeg{
+				Symbol:    position.Symbol,
+				PegPrice:  terms.PegPrice,
+				Price:     risk.Price,
+				Deviation: risk.Price/terms.PegPrice - 1,
+			}
+			deviation := math.Abs(peg.Deviation)
+			if limits.MaxStablecoinDepeg > 0 && deviation >= limits.MaxStablecoinDepeg {
+				peg.Depegged = true
+				result.Breaches = append(result.Breaches, fmt.Sprintf("%s trades at %.4f, %.2f%% from its peg of %.4f",
+					peg.Symbol, peg.Price, peg.Deviation*100, peg.PegPrice))
+			} else if limits.MaxStablecoinDepeg > 0 && deviation >= limits.MaxStablecoinDepeg*0.8 {
+				warningLevel = true
+			}
+			result.Stablecoins = append(result.Stablecoins, peg)
+		}
+	}
+
+	if result.CryptoValue != 0 {
+		for i := range result.Positions {
+			result.Positions[i].Weight = result.Positions[i].MarketValue / result.CryptoValue
+		}
+		for _, exposure := range byChain {
+			exposure.Weight = exposure.MarketValue / result.CryptoValue
+		}
+	}
+	for _, exposure := range byChain {
+		result.Chains = append(result.Chains, *exposure)
+	}
+	sort.Slice(result.Chains, func(i, j int) bool { return result.Chains[i].MarketValue > result.Chains[j].MarketValue })
+
+	warningLevel = cc.venueExposure(result, custody, quantities, unitValues, limits) || warningLevel
+
+	switch {
+	case len(result.Breaches) > 0:
+		result.Status = "CRITICAL"
+	case warningLevel:
+		result.Status = "WARNING"
+	default:
+		result.Status = "SAFE"
+	}
+
+	return result
+}
+
+// venueExposure values the custody holdings by venue, checks exchanges and custodians against the
+// limits and reports crypto held at no recorded venue. It returns whether a venue is within 80% of
+// the limit.
+func (cc *CryptoCalculator) venueExposure(result *CryptoRiskResult, custody []CustodyHolding, quantities, unitValues map[string]float64, limits CryptoLimits) bool {
+	type venueKey struct{ venueType, name string }
+	byVenue := make(map[venueKey]*VenueExposure)
+	allocated := make(map[string]float64)
+	for _, holding := range custody {
+		if _, held := quantities[holding.Symbol]; !held {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s is recorded at %s but not held by the portfolio", holding.Symbol, holding.VenueName))
+			continue
+		}
+		allocated[holding.Symbol] += holding.Quantity
+
+		key := venueKey{holding.VenueType, holding.VenueName}
+		venue, ok := byVenue[key]
+		if !ok {
+			venue = &VenueExposure{
+				VenueType:      holding.VenueType,
+				VenueName:      holding.VenueName,
+				CounterpartyID: holding.CounterpartyID,
+				ExposureLimit:  holding.ExposureLimit,
+			}
+			byVenue[key] = venue
+		}
+		venue.Value += holding.Quantity * unitValues[holding.Symbol]
+		venue.Holdings++
+	}
+
+	symbols := make([]string, 0, len(quantities))
+	for symbol := range quantities {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		held, recorded := quantities[symbol], allocated[symbol]
+		switch {
+		case recorded > held:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: venues record %.8g but the portfolio holds %.8g", symbol, recorded, held))
+		case held > recorded:
+			result.UnallocatedValue += (held - recorded) * unitValues[symbol]
+		}
+	}
+	if result.UnallocatedValue > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Crypto worth %.2f has no recorded exchange or wallet", result.UnallocatedValue))
+	}
+
+	warningLevel := false
+	for _, venue := range byVenue {
+		if result.CryptoValue != 0 {
+			venue.Share = venue.Value / result.CryptoValue
+		}
+		if venue.VenueType == models.CryptoVenueExchange || venue.VenueType == models.CryptoVenueCustodian {
+			result.LargestVenueShare = math.Max(result.LargestVenueShare, venue.Share)
+			if limits.MaxVenueExposure > 0 && venue.Share > limits.MaxVenueExposure {
+				venue.Breached = true
+				result.Breaches = append(result.Breaches, fmt.Sprintf("%s holds %.2f%% of crypto value, above the limit of %.2f%%",
+					venue.VenueName, venue.Share*100, limits.MaxVenueExposure*100))
+			} else if limits.MaxVenueExposure > 0 && venue.Share >= limits.MaxVenueExposure*0.8 {
+				warningLevel = true
+			}
+			if venue.ExposureLimit > 0 && venue.Value > venue.ExposureLimit {
+				venue.Breached = true
+				result.Breaches = append(result.Breaches, fmt.Sprintf("%s holds %.2f of crypto, above its counterparty exposure limit of %.2f",
+					venue.VenueName, venue.Value, venue.ExposureLimit))
+			}
+			result.CounterpartyHeld += venue.Value
+		} else {
+			result.SelfCustodied += venue.Value
+		}
+		result.Venues = append(result.Venues, *venue)
+	}
+	sort.Slice(result.Venues, func(i, j int) bool { return result.Venues[i].Value > result.Venues[j].Value })
+
+	return warningLevel
+}
+
+// CryptoPositionRisk is the market and liquidity risk of one crypto position. Volatility is
+// annualized over 24/7 trading and keyed by window (24h, 7d, 30d); a window is null without
+// enough price samples.
+type CryptoPositionRisk struct {
+	PositionID      uuid.UUID           `json:"position_id"`
+	Symbol          string              `json:"symbol"`
+	Chain           string              `json:"chain"`
+	Stablecoin      bool                `json:"stablecoin"`
+	Quantity        float64             `json:"quantity"`
+	Price           float64             `json:"price"`
+	MarketValue     float64             `json:"market_value"`
+	Weight          float64             `json:"weight"` // Share of the portfolio's crypto value
+	Volatility      map[string]*float64 `json:"volatility"`
+	LiquidityScore  float64             `json:"liquidity_score"`
+	LiquidityClass  string              `json:"liquidity_class"`
+	DaysToLiquidate float64             `json:"days_to_liquidate"`
+}
+
+// ChainExposure is the crypto value settling on one chain; UNKNOWN for assets without reference data
+type ChainExposure struct {
+	Chain       string  `json:"chain"`
+	MarketValue float64 `json:"market_value"`
+	Weight      float64 `json:"weight"` // Share of the portfolio's crypto value
+	Positions   int     `json:"positions"`
+}
+
+// VenueExposure is the crypto value a portfolio keeps at one exchange, custodian or wallet
+type VenueExposure struct {
+	VenueType      string     `json:"venue_type"`
+	VenueName      string     `json:"venue_name"`
+	CounterpartyID *uuid.UUID `json:"counterparty_id,omitempty"`
+	Value          float64    `json:"value"`
+	Share          float64    `json:"share"` // Share of the portfolio's crypto value
+	ExposureLimit  float64    `json:"exposure_limit,omitempty"`
+	Holdings       int        `json:"holdings"`
+	Breached       bool       `json:"breached"`
+}
+
+// StablecoinPeg compares a stablecoin's price with its peg; Deviation is a signed fraction
+type StablecoinPeg struct {
+	Symbol    string  `json:"symbol"`
+	PegPrice  float64 `json:"peg_price"`
+	Price     float64 `json:"price"`
+	Deviation float64 `json:"deviation"`
+	Depegged  bool    `json:"depegged"`
+}
+
+// CryptoRiskResult contains the market, liquidity, venue and peg risk of a portfolio's crypto assets
+type CryptoRiskResult struct {
+	Timestamp         time.Time            `json:"timestamp"`
+	CryptoValue       float64              `json:"crypto_value"`
+	CounterpartyHeld  float64              `json:"counterparty_held"`   // Value kept at exchanges and custodians
+	SelfCustodied     float64              `json:"self_custodied"`      // Value kept in the firm's own wallets
+	UnallocatedValue  float64              `json:"unallocated_value"`   // Value at no recorded venue
+	LargestVenueShare float64              `json:"largest_venue_share"` // Largest share of crypto value at one exchange or custodian
+	MaxVenueExposure  float64              `json:"max_venue_exposure"`
+	MaxDepeg          float64              `json:"max_depeg"`
+	Status            string               `json:"status"`
+	Positions         []CryptoPositionRisk `json:"positions"`
+	Chains            []ChainExposure      `json:"chains"`
+	Venues            []VenueExposure      `json:"venues"`
+	Stablecoins       []StablecoinPeg      `json:"stablecoins"`
+	Warnings          []string             `json:"warnings"`
+	Breaches          []string             `json:"breaches"`
+}
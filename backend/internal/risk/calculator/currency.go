@@ -0,0 +1,137 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// CurrencyExposureCalculator breaks a portfolio's exposure down by currency and estimates the
+// loss a move in exchange rates could cause
+type CurrencyExposureCalculator struct {
+	annualVolatility float64 // Assumed annual volatility of every currency against the base
+}
+
+func NewCurrencyExposureCalculator(annualVolatility float64) *CurrencyExposureCalculator {
+	return &CurrencyExposureCalculator{annualVolatility: annualVolatility}
+}
+
+// CalculateExposure values each position in the base currency at rates, which map a currency to
+// base currency units per unit. A position whose currency has no rate keeps its last converted
+// market value and its currency is reported as stale. maxForeignExposure is the limit on the share
+// of gross exposure held outside the base currency; zero disables it.
+func (cc *CurrencyExposureCalculator) CalculateExposure(positions []models.Position, baseCurrency string, rates map[string]float64, maxForeignExposure float64) *CurrencyExposureResult {
+	baseCurrency = currencyCode(baseCurrency)
+	result := &CurrencyExposureResult{
+		Timestamp:          time.Now(),
+		BaseCurrency:       baseCurrency,
+		MaxForeignExposure: maxForeignExposure,
+		Currencies:         []CurrencyExposure{},
+		Breaches:           []string{},
+	}
+
+	byCurrency := make(map[string]*CurrencyExposure)
+	for _, position := range positions {
+		currency := currencyCode(position.Currency)
+		exposure, ok := byCurrency[currency]
+		if !ok {
+			exposure = &CurrencyExposure{Currency: currency}
+			byCurrency[currency] = exposure
+		}
+
+		local := position.LocalMarketValue.InexactFloat64()
+		if local == 0 && !position.MarketValue.IsZero() && position.FXRate.IsPositive() {
+			// Positions valued before multi-currency support only have a converted value
+			local = position.MarketValue.Div(position.FXRate).InexactFloat64()
+		}
+
+		exposure.LocalValue += local
+		exposure.Positions++
+		switch rate, ok := rates[currency]; {
+		case currency == baseCurrency:
+			exposure.BaseValue += local
+		case ok:
+			exposure.BaseValue += local * rate
+		default:
+			exposure.BaseValue += position.MarketValue.InexactFloat64()
+			exposure.Stale = true
+		}
+	}
+
+	for _, exposure := range byCurrency {
+		if exposure.LocalValue != 0 {
+			exposure.Rate = exposure.BaseValue / exposure.LocalValue
+		}
+		result.GrossExposure += math.Abs(exposure.BaseValue)
+		if exposure.Currency != baseCurrency {
+			result.ForeignExposure += math.Abs(exposure.BaseValue)
+		}
+		result.Currencies = append(result.Currencies, *exposure)
+	}
+
+	sort.Slice(result.Currencies, func(i, j int) bool {
+		return math.Abs(result.Currencies[i].BaseValue) > math.Abs(result.Currencies[j].BaseValue)
+	})
+
+	if result.GrossExposure > 0 {
+		for i := range result.Currencies {
+			result.Currencies[i].Weight = math.Abs(result.Currencies[i].BaseValue) / result.GrossExposure
+		}
+		result.ForeignExposureRatio = result.ForeignExposure / result.GrossExposure
+	}
+
+	// One-day 95% loss, assuming the foreign currencies all move together against the base
+	result.FXVaR95 = 1.645 * cc.annualVolatility / math.Sqrt(252) * result.ForeignExposure
+
+	if maxForeignExposure > 0 && result.ForeignExposureRatio > maxForeignExposure {
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Foreign currency exposure %.2f%% exceeds limit %.2f%%",
+			result.ForeignExposureRatio*100, maxForeignExposure*100))
+	}
+
+	switch {
+	case len(result.Breaches) > 0:
+		result.Status = "CRITICAL"
+	case maxForeignExposure > 0 && result.ForeignExposureRatio >= maxForeignExposure*0.8:
+		result.Status = "WARNING"
+	default:
+		result.Status = "SAFE"
+	}
+
+	return result
+}
+
+func currencyCode(currency string) string {
+	if currency = strings.ToUpper(strings.TrimSpace(currency)); currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// CurrencyExposure is the part of a portfolio held in one currency
+type CurrencyExposure struct {
+	Currency   string  `json:"currency"`
+	LocalValue float64 `json:"local_value"` // Market value in this currency
+	BaseValue  float64 `json:"base_value"`  // Market value in the base currency
+	Rate       float64 `json:"rate"`        // Base currency units per unit of this currency
+	Weight     float64 `json:"weight"`      // Share of gross exposure
+	Positions  int     `json:"positions"`
+	Stale      bool    `json:"stale,omitempty"` // No current rate; last converted values were used
+}
+
+// CurrencyExposureResult contains the currency breakdown and FX risk of a portfolio
+type CurrencyExposureResult struct {
+	Timestamp            time.Time          `json:"timestamp"`
+	BaseCurrency         string             `json:"base_currency"`
+	GrossExposure        float64            `json:"gross_exposure"`
+	ForeignExposure      float64            `json:"foreign_exposure"` // Gross exposure outside the base currency
+	ForeignExposureRatio float64            `json:"foreign_exposure_ratio"`
+	MaxForeignExposure   float64            `json:"max_foreign_exposure"`
+	FXVaR95              float64            `json:"fx_var_95"`
+	Status               string             `json:"status"`
+	Currencies           []CurrencyExposure `json:"currencies"`
+	Breaches             []string           `json:"breaches"`
+}
@@ -0,0 +1,137 @@
+package calculator
+
+import (
+	"fmt"
+	"time"
+)
+
+// NAVPoint is a portfolio's net asset value at the close of a day
+type NAVPoint struct {
+	Date time.Time `json:"date"`
+	NAV  float64   `json:"nav"`
+}
+
+// LossLimits are the loss limits of a portfolio as fractions of NAV; zero disables a limit
+type LossLimits struct {
+	MaxDailyLoss  float64 `json:"max_daily_loss"`
+	MaxWeeklyLoss float64 `json:"max_weekly_loss"`
+	MaxDrawdown   float64 `json:"max_drawdown"`
+}
+
+// DrawdownCalculator measures a portfolio's rolling losses and drawdown from its end-of-day NAV
+// history and current NAV
+type DrawdownCalculator struct{}
+
+func NewDrawdownCalculator() *DrawdownCalculator {
+	return &DrawdownCalculator{}
+}
+
+// CalculateDrawdown compares the current NAV with the last close before today (daily loss), the
+// last close at least a week ago or the oldest close within the week (weekly loss), and the
+// highest NAV of the history (drawdown). history must be in date order. Losses and drawdowns are
+// fractions of the reference NAV, positive for a loss.
+func (dc *DrawdownCalculator) CalculateDrawdown(history []NAVPoint, currentNAV float64, now time.Time, limits LossLimits) *DrawdownResult {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekAgo := today.AddDate(0, 0, -7)
+
+	result := &DrawdownResult{
+		Timestamp:  now,
+		CurrentNAV: currentNAV,
+		PeakNAV:    currentNAV,
+		Limits:     limits,
+		Snapshots:  len(history),
+		Breaches:   []string{},
+	}
+
+	var previous, weekStart *NAVPoint
+	peak := 0.0
+	for i := range history {
+		point := &history[i]
+		if point.Date.Before(today) {
+			previous = point
+		}
+		if !point.Date.After(weekAgo) || weekStart == nil {
+			weekStart = point
+		}
+
+		// The worst peak-to-trough decline of the closes
+		if point.NAV > peak {
+			peak = point.NAV
+		} else if peak > 0 {
+			result.MaxDrawdown = max(result.MaxDrawdown, (peak-point.NAV)/peak)
+		}
+	}
+	if weekStart != nil && !weekStart.Date.Before(today) {
+		weekStart = nil
+	}
+
+	if previous != nil && previous.NAV > 0 {
+		result.PreviousClose = previous
+		result.DailyLoss = (previous.NAV - currentNAV) / previous.NAV
+	}
+	if weekStart != nil && weekStart.NAV > 0 {
+		result.WeekStart = weekStart
+		result.WeeklyLoss = (weekStart.NAV - currentNAV) / weekStart.NAV
+	}
+	if peak > result.PeakNAV {
+		result.PeakNAV = peak
+	}
+	if result.PeakNAV > 0 {
+		result.CurrentDrawdown = max(0, (result.PeakNAV-currentNAV)/result.PeakNAV)
+	}
+	result.MaxDrawdown = max(result.MaxDrawdown, result.CurrentDrawdown)
+
+	if limits.MaxDailyLoss > 0 && result.DailyLoss > limits.MaxDailyLoss {
+		result.DailyLimitHit = true
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Daily loss of %.2f%% exceeds limit %.2f%%", result.DailyLoss*100, limits.MaxDailyLoss*100))
+	}
+	if limits.MaxWeeklyLoss > 0 && result.WeeklyLoss > limits.MaxWeeklyLoss {
+		result.WeeklyLimitHit = true
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Weekly loss of %.2f%% exceeds limit %.2f%%", result.WeeklyLoss*100, limits.MaxWeeklyLoss*100))
+	}
+	if limits.MaxDrawdown > 0 && result.CurrentDrawdown > limits.MaxDrawdown {
+		result.DrawdownLimitHit = true
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Drawdown of %.2f%% from the peak exceeds limit %.2f%%", result.CurrentDrawdown*100, limits.MaxDrawdown*100))
+	}
+
+	result.Status = dc.status(result)
+	return result
+}
+
+// status is CRITICAL when a limit is hit, WARNING from 80% of any limit
+func (dc *DrawdownCalculator) status(result *DrawdownResult) string {
+	near := func(value, limit float64) bool {
+		return limit > 0 && value >= limit*0.8
+	}
+	switch {
+	case len(result.Breaches) > 0:
+		return "CRITICAL"
+	case near(result.DailyLoss, result.Limits.MaxDailyLoss),
+		near(result.WeeklyLoss, result.Limits.MaxWeeklyLoss),
+		near(result.CurrentDrawdown, result.Limits.MaxDrawdown):
+		return "WARNING"
+	default:
+		return "SAFE"
+	}
+}
+
+// DrawdownResult contains a portfolio's rolling losses and drawdown against its loss limits
+type DrawdownResult struct {
+	Timestamp        time.Time  `json:"timestamp"`
+	CurrentNAV       float64    `json:"current_nav"`
+	PreviousClose    *NAVPoint  `json:"previous_close,omitempty"` // Last close before today
+	WeekStart        *NAVPoint  `json:"week_start,omitempty"`     // Close the weekly loss is measured from
+	PeakNAV          float64    `json:"peak_nav"`
+	DailyLoss        float64    `json:"daily_loss"`
+	WeeklyLoss       float64    `json:"weekly_loss"`
+	CurrentDrawdown  float64    `json:"current_drawdown"` // Decline of the current NAV from the peak
+	MaxDrawdown      float64    `json:"max_drawdown"`     // Worst decline over the history
+	Limits           LossLimits `json:"limits"`
+	DailyLimitHit    bool       `json:"daily_limit_hit"`
+	WeeklyLimitHit   bool       `json:"weekly_limit_hit"`
+	DrawdownLimitHit bool       `json:"drawdown_limit_hit"`
+	Snapshots        int        `json:"snapshots"` // End-of-day snapshots the figures are based on
+	Status           string     `json:"status"`    // SAFE, WARNING, CRITICAL
+	Breaches         []string   `json:"breaches"`
+}
@@ -0,0 +1,323 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// basisPoint is the rate move DV01 measures
+const basisPoint = 0.0001
+
+// daysPerYear converts days to the years cash flows are discounted over
+const daysPerYear = 365.25
+
+// maturityBuckets group bond positions by years to maturity
+var maturityBuckets = []struct {
+	label string
+	upTo  float64
+}{
+	{"0-2Y", 2},
+	{"2-5Y", 5},
+	{"5-10Y", 10},
+	{"10-30Y", 30},
+	{"30Y+", math.Inf(1)},
+}
+
+// BondTerms are the terms of a bond from its reference data
+type BondTerms struct {
+	CouponRate      float64 // Annual, as a fraction of face value
+	CouponFrequency int     // Payments per year; 0 for a zero-coupon bond
+	Maturity        time.Time
+	FaceValue       float64 // Per unit held
+	CreditRating    string
+}
+
+// FixedIncomeCalculator measures the interest rate and credit risk of a portfolio's bonds
+type FixedIncomeCalculator struct{}
+
+func NewFixedIncomeCalculator() *FixedIncomeCalculator {
+	return &FixedIncomeCalculator{}
+}
+
+// Calculate analyses the positions with terms, keyed by symbol, at their current prices. Prices
+// are taken to include accrued interest. A position's yield is solved from its price, and its
+// duration, convexity and DV01 follow from that yield; portfolio figures weight positions by
+// market value. maxDV01 limits DV01 as a share of portfolioValue; zero disables it. BOND positions
+// without terms are reported as missing reference data.
+func (fc *FixedIncomeCalculator) Calculate(positions []models.Position, terms map[string]BondTerms, portfolioValue, maxDV01 float64, asOf time.Time) *FixedIncomeResult {
+	result := &FixedIncomeResult{
+		Timestamp:            asOf,
+		PortfolioValue:       portfolioValue,
+		MaxDV01:              maxDV01,
+		Positions:            []BondAnalytics{},
+		CreditExposure:       []CreditExposure{},
+		MaturityBuckets:      make([]MaturityBucket, len(maturityBuckets)),
+		MissingReferenceData: []string{},
+		Warnings:             []string{},
+		Breaches:             []string{},
+	}
+	for i, bucket := range maturityBuckets {
+		result.MaturityBuckets[i].Bucket = bucket.label
+	}
+
+	byRating := make(map[string]*CreditExposure)
+	durationSum, convexitySum := 0.0, 0.0
+	for _, position := range positions {
+		bond, ok := terms[position.Symbol]
+		if !ok {
+			if position.AssetType == "BOND" && !slices.Contains(result.MissingReferenceData, position.Symbol) {
+				result.MissingReferenceData = append(result.MissingReferenceData, position.Symbol)
+			}
+			continue
+		}
+
+		analytics, err := analyseBond(position, bond, asOf)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", position.Symbol, err))
+			continue
+		}
+
+		result.BondMarketValue += analytics.MarketValue
+		result.DV01 += analytics.DV01
+		durationSum += analytics.ModifiedDuration * analytics.MarketValue
+		convexitySum += analytics.Convexity * analytics.MarketValue
+
+		rating := analytics.CreditRating
+		if models.CreditRatingRank(rating) < 0 {
+			rating = "NR"
+		}
+		exposure, ok := byRating[rating]
+		if !ok {
+			exposure = &CreditExposure{Rating: rating}
+			byRating[rating] = exposure
+		}
+		exposure.MarketValue += analytics.MarketValue
+		exposure.Positions++
+		switch {
+		case rating == "NR":
+			result.Unrated += analytics.MarketValue
+		case models.IsInvestmentGrade(rating):
+			result.InvestmentGrade += analytics.MarketValue
+		default:
+			result.HighYield += analytics.MarketValue
+		}
+
+		for i, bucket := range maturityBuckets {
+			if analytics.YearsToMaturity <= bucket.upTo {
+				result.MaturityBuckets[i].MarketValue += analytics.MarketValue
+				result.MaturityBuckets[i].DV01 += analytics.DV01
+				result.MaturityBuckets[i].Positions++
+				break
+			}
+		}
+
+		result.Positions = append(result.Positions, *analytics)
+	}
+
+	if result.BondMarketValue != 0 {
+		result.ModifiedDuration = durationSum / result.BondMarketValue
+		result.Convexity = convexitySum / result.BondMarketValue
+		for i := range result.Positions {
+			result.Positions[i].Weight = result.Positions[i].MarketValue / result.BondMarketValue
+		}
+		for _, exposure := range byRating {
+			exposure.Weight = exposure.MarketValue / result.BondMarketValue
+		}
+	}
+	for _, exposure := range byRating {
+		result.CreditExposure = append(result.CreditExposure, *exposure)
+	}
+	sort.Slice(result.CreditExposure, func(i, j int) bool {
+		return ratingOrder(result.CreditExposure[i].Rating) < ratingOrder(result.CreditExposure[j].Rating)
+	})
+
+	if portfolioValue > 0 {
+		result.DV01Ratio = math.Abs(result.DV01) / portfolioValue
+	}
+	if maxDV01 > 0 && result.DV01Ratio > maxDV01 {
+		result.Breaches = append(result.Breaches, fmt.Sprintf("DV01 of %.2f is %.4f%% of portfolio value, above the limit of %.4f%%",
+			result.DV01, result.DV01Ratio*100, maxDV01*100))
+	}
+
+	switch {
+	case len(result.Breaches) > 0:
+		result.Status = "CRITICAL"
+	case maxDV01 > 0 && result.DV01Ratio >= maxDV01*0.8:
+		result.Status = "WARNING"
+	default:
+		result.Status = "SAFE"
+	}
+
+	return result
+}
+
+// analyseBond solves a position's yield from its price and derives its duration, convexity and DV01
+func analyseBond(position models.Position, bond BondTerms, asOf time.Time) (*BondAnalytics, error) {
+	years := bond.Maturity.Sub(asOf).Hours() / 24 / daysPerYear
+	if years <= 0 {
+		return nil, fmt.Errorf("matured on %s", bond.Maturity.Format("2006-01-02"))
+	}
+	price := position.CurrentPrice.InexactFloat64()
+	if price <= 0 || bond.FaceValue <= 0 {
+		return nil, fmt.Errorf("no price to analyse")
+	}
+
+	flows := bondCashFlows(bond, asOf)
+	frequency := float64(bond.CouponFrequency)
+	if frequency == 0 {
+		frequency = 1
+	}
+
+	periodYield, ok := solvePeriodYield(flows, frequency, price)
+	if !ok {
+		return nil, fmt.Errorf("no yield matches price %.4f", price)
+	}
+
+	value, weightedTime, weightedConvexity := 0.0, 0.0, 0.0
+	for _, flow := range flows {
+		pv := flow.amount / math.Pow(1+periodYield, frequency*flow.years)
+		value += pv
+		weightedTime += flow.years * pv
+		weightedConvexity += flow.years * (flow.years + 1/frequency) * pv
+	}
+
+	macaulay := weightedTime / value
+	modified := macaulay / (1 + periodYield)
+	marketValue := position.MarketValue.InexactFloat64()
+	return &BondAnalytics{
+		PositionID:       position.ID,
+		Symbol:           position.Symbol,
+		MarketValue:      marketValue,
+		CreditRating:     bond.CreditRating,
+		YearsToMaturity:  years,
+		YieldToMaturity:  periodYield * frequency,
+		MacaulayDuration: macaulay,
+		ModifiedDuration: modified,
+		Convexity:        weightedConvexity / (value * (1 + periodYield) * (1 + periodYield)),
+		DV01:             modified * marketValue * basisPoint,
+	}, nil
+}
+
+type cashFlow struct {
+	years  float64
+	amount float64
+}
+
+// bondCashFlows lists the coupons and redemption still to be paid per unit held, stepping back
+// from maturity by the coupon period
+func bondCashFlows(bond BondTerms, asOf time.Time) []cashFlow {
+	if bond.CouponFrequency <= 0 {
+		return []cashFlow{{years: bond.Maturity.Sub(asOf).Hours() / 24 / daysPerYear, amount: bond.FaceValue}}
+	}
+
+	coupon := bond.FaceValue * bond.CouponRate / float64(bond.CouponFrequency)
+	months := 12 / bond.CouponFrequency
+	flows := []cashFlow{}
+	for i := 0; ; i++ {
+		date := bond.Maturity.AddDate(0, -i*months, 0)
+		if !date.After(asOf) {
+			break
+		}
+		amount := coupon
+		if i == 0 {
+			amount += bond.FaceValue
+		}
+		flows = append(flows, cashFlow{years: date.Sub(asOf).Hours() / 24 / daysPerYear, amount: amount})
+	}
+	return flows
+}
+
+// solvePeriodYield finds by bisection the yield per coupon period at which the cash flows are
+// worth the price
+func solvePeriodYield(flows []cashFlow, frequency, price float64) (float64, bool) {
+	presentValue := func(periodYield float64) float64 {
+		value := 0.0
+		for _, flow := range flows {
+			value += flow.amount / math.Pow(1+periodYield, frequency*flow.years)
+		}
+		return value
+	}
+
+	low, high := -0.5, 5.0
+	if presentValue(low) < price || presentValue(high) > price {
+		return 0, false
+	}
+	for i := 0; i < 200 && high-low > 1e-12; i++ {
+		mid := (low + high) / 2
+		if presentValue(mid) > price {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// ratingOrder sorts ratings best first, with unrated bonds last
+func ratingOrder(rating string) int {
+	if rank := models.CreditRatingRank(rating); rank >= 0 {
+		return rank
+	}
+	return len(models.CreditRatings)
+}
+
+// BondAnalytics is the interest rate risk of one bond position. Market value and DV01 are in the
+// portfolio currency; DV01 is the loss from a one basis point rise in yield.
+type BondAnalytics struct {
+	PositionID       uuid.UUID `json:"position_id"`
+	Symbol           string    `json:"symbol"`
+	MarketValue      float64   `json:"market_value"`
+	Weight           float64   `json:"weight"` // Share of the portfolio's bond market value
+	CreditRating     string    `json:"credit_rating,omitempty"`
+	YearsToMaturity  float64   `json:"years_to_maturity"`
+	YieldToMaturity  float64   `json:"yield_to_maturity"` // Annual, compounded at the coupon frequency
+	MacaulayDuration float64   `json:"macaulay_duration"`
+	ModifiedDuration float64   `json:"modified_duration"`
+	Convexity        float64   `json:"convexity"`
+	DV01             float64   `json:"dv01"`
+}
+
+// CreditExposure is the bond market value held at one credit rating; NR for unrated bonds
+type CreditExposure struct {
+	Rating      string  `json:"rating"`
+	MarketValue float64 `json:"market_value"`
+	Weight      float64 `json:"weight"` // Share of the portfolio's bond market value
+	Positions   int     `json:"positions"`
+}
+
+// MaturityBucket is the bond market value and DV01 maturing within a range of years
+type MaturityBucket struct {
+	Bucket      string  `json:"bucket"`
+	MarketValue float64 `json:"market_value"`
+	DV01        float64 `json:"dv01"`
+	Positions   int     `json:"positions"`
+}
+
+// FixedIncomeResult contains the interest rate and credit risk of a portfolio's bonds
+type FixedIncomeResult struct {
+	Timestamp            time.Time        `json:"timestamp"`
+	PortfolioValue       float64          `json:"portfolio_value"`
+	BondMarketValue      float64          `json:"bond_market_value"`
+	ModifiedDuration     float64          `json:"modified_duration"`
+	Convexity            float64          `json:"convexity"`
+	DV01                 float64          `json:"dv01"`
+	DV01Ratio            float64          `json:"dv01_ratio"` // DV01 as a share of portfolio value
+	MaxDV01              float64          `json:"max_dv01"`
+	InvestmentGrade      float64          `json:"investment_grade"` // Market value rated BBB- or better
+	HighYield            float64          `json:"high_yield"`
+	Unrated              float64          `json:"unrated"`
+	Status               string           `json:"status"`
+	Positions            []BondAnalytics  `json:"positions"`
+	CreditExposure       []CreditExposure `json:"credit_exposure"`
+	MaturityBuckets      []MaturityBucket `json:"maturity_buckets"`
+	MissingReferenceData []string         `json:"missing_reference_data"` // BOND positions without reference data
+	Warnings             []string         `json:"warnings"`
+	Breaches             []string         `json:"breaches"`
+}
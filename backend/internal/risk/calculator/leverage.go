@@ -0,0 +1,107 @@
+package calculator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// MarginAccount is the cash and borrowing side of a portfolio
+type MarginAccount struct {
+	CashBalance           float64 `json:"cash_balance"`
+	MarginLoan            float64 `json:"margin_loan"`
+	MaintenanceMarginRate float64 `json:"maintenance_margin_rate"` // Equity required as a fraction of gross exposure
+}
+
+// LeverageCalculator measures a portfolio's gross and net leverage and its margin position
+type LeverageCalculator struct{}
+
+func NewLeverageCalculator() *LeverageCalculator {
+	return &LeverageCalculator{}
+}
+
+// CalculateLeverage computes exposure against equity, where equity is the long market value less
+// the short market value plus cash less the margin loan. Gross leverage counts shorts at their
+// absolute value; net leverage nets them against longs. maxLeverage is the gross limit.
+func (lc *LeverageCalculator) CalculateLeverage(positions []models.Position, account MarginAccount, maxLeverage float64) *LeverageResult {
+	result := &LeverageResult{
+		Timestamp:   time.Now(),
+		Account:     account,
+		MaxLeverage: maxLeverage,
+		Breaches:    []string{},
+	}
+
+	for _, position := range positions {
+		value := position.MarketValue.InexactFloat64()
+		if value >= 0 {
+			result.LongExposure += value
+		} else {
+			result.ShortExposure -= value
+		}
+	}
+
+	result.GrossExposure = result.LongExposure + result.ShortExposure
+	result.NetExposure = result.LongExposure - result.ShortExposure
+	result.Equity = result.NetExposure + account.CashBalance - account.MarginLoan
+	result.MaintenanceRequirement = result.GrossExposure * account.MaintenanceMarginRate
+	result.ExcessLiquidity = result.Equity - result.MaintenanceRequirement
+	if result.LongExposure > 0 {
+		result.MarginUtilization = account.MarginLoan / result.LongExposure
+	}
+
+	if result.Equity <= 0 {
+		if result.GrossExposure > 0 {
+			result.Breaches = append(result.Breaches, fmt.Sprintf("Equity %.2f is not positive against gross exposure %.2f", result.Equity, result.GrossExposure))
+		}
+		result.MarginCall = result.GrossExposure > 0
+		result.Status = lc.status(result)
+		return result
+	}
+
+	result.GrossLeverage = result.GrossExposure / result.Equity
+	result.NetLeverage = result.NetExposure / result.Equity
+
+	if maxLeverage > 0 && result.GrossLeverage > maxLeverage {
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Gross leverage %.2fx exceeds limit %.2fx", result.GrossLeverage, maxLeverage))
+	}
+	if result.ExcessLiquidity < 0 {
+		result.MarginCall = true
+		result.Breaches = append(result.Breaches, fmt.Sprintf("Equity %.2f is below the maintenance requirement %.2f", result.Equity, result.MaintenanceRequirement))
+	}
+
+	result.Status = lc.status(result)
+	return result
+}
+
+// status is CRITICAL on a margin call or a leverage breach, WARNING from 80% of the limit
+func (lc *LeverageCalculator) status(result *LeverageResult) string {
+	switch {
+	case result.MarginCall, len(result.Breaches) > 0:
+		return "CRITICAL"
+	case result.MaxLeverage > 0 && result.GrossLeverage >= result.MaxLeverage*0.8:
+		return "WARNING"
+	default:
+		return "SAFE"
+	}
+}
+
+// LeverageResult contains the leverage and margin analysis for a portfolio
+type LeverageResult struct {
+	Timestamp              time.Time     `json:"timestamp"`
+	Account                MarginAccount `json:"account"`
+	LongExposure           float64       `json:"long_exposure"`
+	ShortExposure          float64       `json:"short_exposure"`
+	GrossExposure          float64       `json:"gross_exposure"`
+	NetExposure            float64       `json:"net_exposure"`
+	Equity                 float64       `json:"equity"`
+	GrossLeverage          float64       `json:"gross_leverage"` // Zero when equity is not positive
+	NetLeverage            float64       `json:"net_leverage"`
+	MaxLeverage            float64       `json:"max_leverage"`
+	MarginUtilization      float64       `json:"margin_utilization"` // Margin loan as a fraction of long exposure
+	MaintenanceRequirement float64       `json:"maintenance_requirement"`
+	ExcessLiquidity        float64       `json:"excess_liquidity"` // Equity above the maintenance requirement
+	MarginCall             bool          `json:"margin_call"`
+	Status                 string        `json:"status"`
+	Breaches               []string      `json:"breaches"`
+}
@@ -0,0 +1,173 @@
+package calculator
+
+import "math"
+
+// Participation rates the liquidation waterfall sells into by default, as calculateLiquidationTime
+// assumes for normal and stressed markets
+const (
+	NormalParticipationRate   = 0.1
+	StressedParticipationRate = 0.05
+)
+
+// LiquidationInput is a position to be sold with the market it trades in. Short positions are
+// bought back, so quantities and values are taken as absolute.
+type LiquidationInput struct {
+	Symbol             string
+	Quantity           float64
+	Price              float64
+	AverageDailyVolume float64 // Units traded per day; 0 means the position cannot be sold
+	BidAskSpread       float64 // As a fraction of price
+	Immediate          bool    // Cash-like positions, sold in full on the first day without impact
+}
+
+// LiquidationSale is what is sold of one position on a day of a liquidation
+type LiquidationSale struct {
+	Symbol       string  `json:"symbol"`
+	Quantity     float64 `json:"quantity"`
+	Value        float64 `json:"value"`         // At the current price
+	MarketImpact float64 `json:"market_impact"` // Cost of the price moving against the sale
+}
+
+// LiquidationDay is one day of a liquidation waterfall: what is sold, what it costs and what is
+// left to sell afterwards
+type LiquidationDay struct {
+	Day                    int               `json:"day"`
+	SoldValue              float64           `json:"sold_value"`
+	MarketImpact           float64           `json:"market_impact"`
+	NetProceeds            float64           `json:"net_proceeds"`
+	CumulativeSold         float64           `json:"cumulative_sold"`
+	CumulativeMarketImpact float64           `json:"cumulative_market_impact"`
+	RemainingValue         float64           `json:"remaining_value"`
+	PercentLiquidated      float64           `json:"percent_liquidated"`
+	Sales                  []LiquidationSale `json:"sales"`
+}
+
+// LiquidationRemainder is what is left of a position once the waterfall stops
+type LiquidationRemainder struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+	Value    float64 `json:"value"`
+}
+
+// LiquidationWaterfall is a day-by-day liquidation of positions at one participation rate
+type LiquidationWaterfall struct {
+	ParticipationRate  float64                `json:"participation_rate"`
+	TotalValue         float64                `json:"total_value"`
+	DaysToLiquidate    int                    `json:"days_to_liquidate"` // Days until everything is sold, 0 when it is not sold within the horizon
+	FullyLiquidated    bool                   `json:"fully_liquidated"`
+	TotalMarketImpact  float64                `json:"total_market_impact"`
+	MarketImpactPct    float64                `json:"market_impact_pct"` // Of the value sold
+	Days               []LiquidationDay       `json:"days"`
+	Unliquidated       []LiquidationRemainder `json:"unliquidated"`
+	UnliquidatedValue  float64                `json:"unliquidated_value"`
+	LiquidationHorizon int                    `json:"liquidation_horizon"` // Days simulated at most
+}
+
+// SimulateLiquidation sells each position every day at most participationRate of its average daily
+// volume, until everything is sold or maxDays have passed. The market impact of a day's sale of a
+// position follows the square-root model of calculateMarketImpact on the quantity sold that day,
+// plus half the spread.
+func SimulateLiquidation(positions []LiquidationInput, participationRate float64, maxDays int) LiquidationWaterfall {
+	waterfall := LiquidationWaterfall{
+		ParticipationRate:  participationRate,
+		LiquidationHorizon: maxDays,
+		Days:               []LiquidationDay{},
+		Unliquidated:       []LiquidationRemainder{},
+	}
+
+	remaining := make([]float64, len(positions))
+	for i, position := range positions {
+		remaining[i] = math.Abs(position.Quantity)
+		waterfall.TotalValue += remaining[i] * position.Price
+	}
+
+	left := waterfall.TotalValue
+	var sold, impact float64
+	for day := 1; day <= maxDays && left > 0.005; day++ {
+		today := LiquidationDay{Day: day, Sales: []LiquidationSale{}}
+		for i, position := range positions {
+			if remaining[i] <= 0 {
+				continue
+			}
+
+			quantity := remaining[i]
+			rate := 0.0
+			if !position.Immediate {
+				if position.AverageDailyVolume <= 0 {
+					continue
+				}
+				quantity = math.Min(quantity, position.AverageDailyVolume*participationRate)
+				rate = marketImpact(quantity, position.AverageDailyVolume, position.BidAskSpread)
+			}
+			remaining[i] -= quantity
+			if remaining[i] < 1e-9 {
+				// Rounding must not leave a sliver of a position to sell the next day
+				remaining[i] = 0
+			}
+
+			value := quantity * position.Price
+			sale := LiquidationSale{
+				Symbol:       position.Symbol,
+				Quantity:     quantity,
+				Value:        roundCents(value),
+				MarketImpact: roundCents(value * rate),
+			}
+			today.Sales = append(today.Sales, sale)
+			today.SoldValue += value
+			today.MarketImpact += value * rate
+		}
+		if len(today.Sales) == 0 {
+			// Nothing left can be sold; later days would be the same
+			break
+		}
+
+		sold += today.SoldValue
+		impact += today.MarketImpact
+		left = math.Max(waterfall.TotalValue-sold, 0)
+
+		today.NetProceeds = roundCents(today.SoldValue - today.MarketImpact)
+		today.SoldValue = roundCents(today.SoldValue)
+		today.MarketImpact = roundCents(today.MarketImpact)
+		today.CumulativeSold = roundCents(sold)
+		today.CumulativeMarketImpact = roundCents(impact)
+		today.RemainingValue = roundCents(left)
+		today.PercentLiquidated = percentOf(sold, waterfall.TotalValue)
+		waterfall.Days = append(waterfall.Days, today)
+	}
+
+	for i, position := range positions {
+		if remaining[i] > 0 {
+			value := remaining[i] * position.Price
+			waterfall.Unliquidated = append(waterfall.Unliquidated, LiquidationRemainder{
+				Symbol:   position.Symbol,
+				Quantity: remaining[i],
+				Value:    roundCents(value),
+			})
+			waterfall.UnliquidatedValue += value
+		}
+	}
+	waterfall.UnliquidatedValue = roundCents(waterfall.UnliquidatedValue)
+	waterfall.FullyLiquidated = len(waterfall.Unliquidated) == 0
+	if waterfall.FullyLiquidated {
+		waterfall.DaysToLiquidate = len(waterfall.Days)
+	}
+
+	waterfall.TotalValue = roundCents(waterfall.TotalValue)
+	waterfall.TotalMarketImpact = roundCents(impact)
+	if sold > 0 {
+		waterfall.MarketImpactPct = math.Round(impact/sold*10000) / 100
+	}
+	return waterfall
+}
+
+// percentOf is part as a percentage of total, to two decimal places
+func percentOf(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(part/total*10000) / 100
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -86,9 +86,6 @@ func (l *LiquidityCalculator) CalculateLiquidity(positions []models.Position, po
 	result.StressedMarketDays = l.calculateLiquidationTime(positions, "STRESSED")
 	result.CrisisMarketDays = l.calculateLiquidationTime(positions, "CRISIS")
 
-	// Calculate liquidity-adjusted VaR
-	result.LiquidityAdjustedVaR = l.calculateLiquidityAdjustedVaR(result)
-
 	// Determine overall liquidity health
 	result.LiquidityHealth = l.assessLiquidityHealth(result)
 
@@ -271,6 +268,11 @@ func (l *LiquidityCalculator) calculateDaysToLiquidate(quantity, avgDailyVolume,
 
 // calculateMarketImpact estimates price impact of liquidating position
 func (l *LiquidityCalculator) calculateMarketImpact(quantity, avgDailyVolume, spread float64) float64 {
+	return marketImpact(quantity, avgDailyVolume, spread)
+}
+
+// marketImpact is the price impact of selling a quantity in a day, as a fraction of its value
+func marketImpact(quantity, avgDailyVolume, spread float64) float64 {
 	if avgDailyVolume == 0 {
 		return 0.5 // 50% impact for illiquid assets
 	}
@@ -338,22 +340,85 @@ func (l *LiquidityCalculator) calculateLiquidationTime(positions []models.Positi
 	return maxDays
 }
 
-// calculateLiquidityAdjustedVaR adjusts VaR for liquidity risk
-func (l *LiquidityCalculator) calculateLiquidityAdjustedVaR(result *LiquidityResult) float64 {
-	// Simple adjustment: multiply by liquidity factor
-	liquidityFactor := 1.0
+// IncludeCash adds a portfolio's cash balance to an analysed portfolio. Positive cash is immediately
+// liquid, so it counts in full as liquid value and the ratios and health are restated against the
+// portfolio value including it; a debit balance is a liability and leaves the result unchanged.
+// Cash carries no market risk and takes no share of the VaR in AdjustVaR.
+func (l *LiquidityCalculator) IncludeCash(result *LiquidityResult, cash float64) {
+	if cash <= 0 {
+		return
+	}
 
-	if result.LiquidityRatio < 0.3 {
-		liquidityFactor = 1.5 // 50% increase for low liquidity
-	} else if result.LiquidityRatio < 0.5 {
-		liquidityFactor = 1.3 // 30% increase
-	} else if result.LiquidityRatio < 0.7 {
-		liquidityFactor = 1.15 // 15% increase
+	positionsValue := result.PortfolioValue
+	totalValue := positionsValue + cash
+	liquidValue := cash
+	illiquidValue := 0.0
+	weightedScore := 100 * cash
+	if positionsValue != 0 {
+		liquidValue += result.LiquidityRatio * positionsValue
+		illiquidValue = result.IlliquidityRatio * positionsValue
+		weightedScore += result.WeightedLiquidityScore * positionsValue
 	}
 
-	// This would typically use the VaR from VaR calculator
-	// For now, returning a placeholder
-	return result.PortfolioValue * 0.05 * liquidityFactor
+	result.CashBalance = cash
+	result.PortfolioValue = totalValue
+	result.LiquidityRatio = liquidValue / totalValue
+	result.IlliquidityRatio = illiquidValue / totalValue
+	result.WeightedLiquidityScore = weightedScore / totalValue
+	result.LiquidityHealth = l.assessLiquidityHealth(result)
+	result.Alerts = l.checkLiquidityAlerts(result)
+}
+
+// AdjustVaR computes the liquidity-adjusted VaR of an analysed portfolio from its VaR over
+// timeHorizon days and stores it on result.
+//
+// The VaR is allocated to positions by gross market value. A position that cannot be sold within the
+// VaR horizon stays exposed for its whole liquidation horizon, so its share is scaled by the
+// square root of liquidation days over horizon days. Selling also crosses half the bid-ask
+// spread, which is added as an exogenous cost.
+func (l *LiquidityCalculator) AdjustVaR(result *LiquidityResult, varValue float64, timeHorizon int) *LiquidityAdjustedVaR {
+	if timeHorizon < 1 {
+		timeHorizon = 1
+	}
+	lvar := &LiquidityAdjustedVaR{
+		VaR:         varValue,
+		TimeHorizon: timeHorizon,
+		Positions:   make([]PositionLiquidityVaR, 0, len(result.Positions)),
+	}
+
+	grossValue := 0.0
+	for _, pos := range result.Positions {
+		grossValue += math.Abs(pos.MarketValue)
+	}
+
+	adjustedVaR := 0.0
+	for _, pos := range result.Positions {
+		share := 0.0
+		if grossValue > 0 {
+			share = varValue * math.Abs(pos.MarketValue) / grossValue
+		}
+		liquidationDays := math.Max(pos.DaysToLiquidate, float64(timeHorizon))
+
+		pl := PositionLiquidityVaR{
+			Symbol:          pos.Symbol,
+			LiquidationDays: liquidationDays,
+			VaRContribution: share,
+			AdjustedVaR:     share * math.Sqrt(liquidationDays/float64(timeHorizon)),
+			SpreadCost:      0.5 * pos.BidAskSpread * math.Abs(pos.MarketValue),
+		}
+		adjustedVaR += pl.AdjustedVaR
+		lvar.SpreadCost += pl.SpreadCost
+		lvar.Positions = append(lvar.Positions, pl)
+	}
+
+	lvar.HorizonCost = math.Max(adjustedVaR-varValue, 0)
+	lvar.Value = adjustedVaR + lvar.SpreadCost
+	if varValue > 0 {
+		lvar.AdjustmentRatio = lvar.Value / varValue
+	}
+
+	result.LiquidityAdjustedVaR = lvar
+	return lvar
 }
 
 // assessLiquidityHealth determines overall liquidity health status
@@ -423,18 +488,19 @@ func (l *LiquidityCalculator) checkLiquidityAlerts(result *LiquidityResult) []Li
 
 // LiquidityResult contains comprehensive liquidity analysis
 type LiquidityResult struct {
-	Timestamp              time.Time           `json:"timestamp"`
-	PortfolioValue         float64             `json:"portfolio_value"`
-	LiquidityRatio         float64             `json:"liquidity_ratio"`
-	IlliquidityRatio       float64             `json:"illiquidity_ratio"`
-	WeightedLiquidityScore float64             `json:"weighted_liquidity_score"`
-	NormalMarketDays       float64             `json:"normal_market_days"`
-	StressedMarketDays     float64             `json:"stressed_market_days"`
-	CrisisMarketDays       float64             `json:"crisis_market_days"`
-	LiquidityAdjustedVaR   float64             `json:"liquidity_adjusted_var"`
-	LiquidityHealth        string              `json:"liquidity_health"`
-	Positions              []PositionLiquidity `json:"positions"`
-	Alerts                 []LiquidityAlert    `json:"alerts"`
+	Timestamp              time.Time             `json:"timestamp"`
+	PortfolioValue         float64               `json:"portfolio_value"`
+	CashBalance            float64               `json:"cash_balance"` // Set by IncludeCash
+	LiquidityRatio         float64               `json:"liquidity_ratio"`
+	IlliquidityRatio       float64               `json:"illiquidity_ratio"`
+	WeightedLiquidityScore float64               `json:"weighted_liquidity_score"`
+	NormalMarketDays       float64               `json:"normal_market_days"`
+	StressedMarketDays     float64               `json:"stressed_market_days"`
+	CrisisMarketDays       float64               `json:"crisis_market_days"`
+	LiquidityAdjustedVaR   *LiquidityAdjustedVaR `json:"liquidity_adjusted_var,omitempty"` // Set by AdjustVaR
+	LiquidityHealth        string                `json:"liquidity_health"`
+	Positions              []PositionLiquidity   `json:"positions"`
+	Alerts                 []LiquidityAlert      `json:"alerts"`
 }
 
 // PositionLiquidity contains liquidity metrics for a single position
@@ -453,6 +519,26 @@ type PositionLiquidity struct {
 	OrdedlyLiquidationValue   float64 `json:"orderly_liquidation_value"`
 }
 
+// LiquidityAdjustedVaR is a VaR extended by the time and cost of liquidating the positions
+type LiquidityAdjustedVaR struct {
+	VaR             float64                `json:"var"`
+	TimeHorizon     int                    `json:"time_horizon"`
+	HorizonCost     float64                `json:"horizon_cost"` // Added by liquidation horizons longer than the VaR horizon
+	SpreadCost      float64                `json:"spread_cost"`
+	Value           float64                `json:"value"`
+	AdjustmentRatio float64                `json:"adjustment_ratio"` // Value / VaR
+	Positions       []PositionLiquidityVaR `json:"positions"`
+}
+
+// PositionLiquidityVaR is one position's part of the liquidity-adjusted VaR
+type PositionLiquidityVaR struct {
+	Symbol          string  `json:"symbol"`
+	LiquidationDays float64 `json:"liquidation_days"`
+	VaRContribution float64 `json:"var_contribution"`
+	AdjustedVaR     float64 `json:"adjusted_var"`
+	SpreadCost      float64 `json:"spread_cost"`
+}
+
 // LiquidityAlert represents a liquidity-related alert
 type LiquidityAlert struct {
 	Type      string  `json:"type"`
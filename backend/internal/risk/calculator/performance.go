@@ -0,0 +1,102 @@
+package calculator
+
+import (
+	"math"
+	"time"
+)
+
+// tradingDaysPerYear annualizes daily return statistics
+const tradingDaysPerYear = 252
+
+// PerformanceObservation pairs a day's portfolio return with the benchmark return over the same
+// day, both as fractions
+type PerformanceObservation struct {
+	Date            time.Time `json:"date"`
+	PortfolioReturn float64   `json:"portfolio_return"`
+	BenchmarkReturn float64   `json:"benchmark_return"`
+	ExcessReturn    float64   `json:"excess_return"`
+}
+
+// PerformanceResult compares portfolio returns with a benchmark. Returns are compounded over the
+// observations; tracking error and the information ratio are annualized from daily figures. Beta,
+// tracking error and the information ratio need at least two observations and are nil otherwise,
+// as is beta when the benchmark did not move.
+type PerformanceResult struct {
+	Observations     int                      `json:"observations"`
+	PortfolioReturn  float64                  `json:"portfolio_return"`
+	BenchmarkReturn  float64                  `json:"benchmark_return"`
+	ExcessReturn     float64                  `json:"excess_return"`
+	TrackingError    *float64                 `json:"tracking_error"`
+	Beta             *float64                 `json:"beta"`
+	InformationRatio *float64                 `json:"information_ratio"`
+	Series           []PerformanceObservation `json:"series"`
+}
+
+// ComparePerformance measures daily portfolio returns against the benchmark
+func ComparePerformance(observations []PerformanceObservation) *PerformanceResult {
+	result := &PerformanceResult{
+		Observations: len(observations),
+		Series:       make([]PerformanceObservation, 0, len(observations)),
+	}
+
+	portfolioGrowth, benchmarkGrowth := 1.0, 1.0
+	portfolio := make([]float64, len(observations))
+	benchmark := make([]float64, len(observations))
+	excess := make([]float64, len(observations))
+	for i, obs := range observations {
+		obs.ExcessReturn = obs.PortfolioReturn - obs.BenchmarkReturn
+		portfolioGrowth *= 1 + obs.PortfolioReturn
+		benchmarkGrowth *= 1 + obs.BenchmarkReturn
+		portfolio[i], benchmark[i], excess[i] = obs.PortfolioReturn, obs.BenchmarkReturn, obs.ExcessReturn
+		result.Series = append(result.Series, obs)
+	}
+	result.PortfolioReturn = portfolioGrowth - 1
+	result.BenchmarkReturn = benchmarkGrowth - 1
+	result.ExcessReturn = result.PortfolioReturn - result.BenchmarkReturn
+
+	if len(observations) < 2 {
+		return result
+	}
+
+	excessMean, excessVariance := meanVariance(excess)
+	trackingError := math.Sqrt(excessVariance * tradingDaysPerYear)
+	result.TrackingError = &trackingError
+	if trackingError > 0 {
+		informationRatio := excessMean * tradingDaysPerYear / trackingError
+		result.InformationRatio = &informationRatio
+	}
+
+	_, benchmarkVariance := meanVariance(benchmark)
+	if benchmarkVariance > 0 {
+		beta := covariance(portfolio, benchmark) / benchmarkVariance
+		result.Beta = &beta
+	}
+	return result
+}
+
+// meanVariance returns the mean and sample variance of a series of at least two values
+func meanVariance(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, squares / float64(len(values)-1)
+}
+
+// covariance is the sample covariance of two series of the same length, at least two
+func covariance(a, b []float64) float64 {
+	meanA, _ := meanVariance(a)
+	meanB, _ := meanVariance(b)
+
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return sum / float64(len(a)-1)
+}
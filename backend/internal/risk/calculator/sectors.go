@@ -0,0 +1,106 @@
+package calculator
+
+import "strings"
+
+// UnclassifiedSector is reported for symbols with no known sector
+const UnclassifiedSector = "UNCLASSIFIED"
+
+// SymbolClassification is the sector and asset class a symbol belongs to
+type SymbolClassification struct {
+	Sector     string `json:"sector"`
+	AssetClass string `json:"asset_class"`
+}
+
+// defaultClassifications covers the symbols traded by the platform and the mock generator
+var defaultClassifications = map[string]SymbolClassification{
+	// Technology
+	"AAPL":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"MSFT":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"GOOGL": {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"GOOG":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"META":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"NVDA":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"AMD":   {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"INTC":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"ORCL":  {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+	"CRM":   {Sector: "TECHNOLOGY", AssetClass: "EQUITY"},
+
+	// Consumer discretionary
+	"AMZN": {Sector: "CONSUMER_DISCRETIONARY", AssetClass: "EQUITY"},
+	"TSLA": {Sector: "CONSUMER_DISCRETIONARY", AssetClass: "EQUITY"},
+	"NKE":  {Sector: "CONSUMER_DISCRETIONARY", AssetClass: "EQUITY"},
+	"HD":   {Sector: "CONSUMER_DISCRETIONARY", AssetClass: "EQUITY"},
+
+	// Financials
+	"JPM": {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"BAC": {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"GS":  {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"MS":  {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"WFC": {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"C":   {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"V":   {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+	"MA":  {Sector: "FINANCIALS", AssetClass: "EQUITY"},
+
+	// Health care
+	"JNJ":  {Sector: "HEALTH_CARE", AssetClass: "EQUITY"},
+	"PFE":  {Sector: "HEALTH_CARE", AssetClass: "EQUITY"},
+	"UNH":  {Sector: "HEALTH_CARE", AssetClass: "EQUITY"},
+	"MRK":  {Sector: "HEALTH_CARE", AssetClass: "EQUITY"},
+	"ABBV": {Sector: "HEALTH_CARE", AssetClass: "EQUITY"},
+
+	// Energy
+	"XOM": {Sector: "ENERGY", AssetClass: "EQUITY"},
+	"CVX": {Sector: "ENERGY", AssetClass: "EQUITY"},
+
+	// Digital assets
+	"BTC":  {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+	"ETH":  {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+	"SOL":  {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+	"USDT": {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+	"USDC": {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+	"DAI":  {Sector: "DIGITAL_ASSETS", AssetClass: "CRYPTO"},
+
+	// Commodities
+	"GOLD":   {Sector: "PRECIOUS_METALS", AssetClass: "COMMODITY"},
+	"SILVER": {Sector: "PRECIOUS_METALS", AssetClass: "COMMODITY"},
+	"OIL":    {Sector: "ENERGY", AssetClass: "COMMODITY"},
+	"NATGAS": {Sector: "ENERGY", AssetClass: "COMMODITY"},
+}
+
+// assetClassByType maps position asset types onto the broader asset classes
+var assetClassByType = map[string]string{
+	"STOCK":           "EQUITY",
+	"EQUITY":          "EQUITY",
+	"ETF":             "EQUITY",
+	"BOND":            "FIXED_INCOME",
+	"GOVERNMENT_BOND": "FIXED_INCOME",
+	"CORPORATE_BOND":  "FIXED_INCOME",
+	"CRYPTO":          "CRYPTO",
+	"COMMODITY":       "COMMODITY",
+	"CASH":            "CASH",
+	"MONEY_MARKET":    "CASH",
+	"FOREX":           "CURRENCY",
+}
+
+// ClassifySymbol returns the sector and asset class for a symbol. The position's asset type takes
+// precedence for the asset class; unknown symbols are reported as UNCLASSIFIED.
+func ClassifySymbol(symbol, assetType string) SymbolClassification {
+	classification, ok := defaultClassifications[strings.ToUpper(symbol)]
+	if !ok {
+		classification = SymbolClassification{Sector: UnclassifiedSector, AssetClass: UnclassifiedSector}
+	}
+
+	if class, ok := assetClassByType[strings.ToUpper(assetType)]; ok {
+		classification.AssetClass = class
+	}
+
+	// Cash and fixed income have no equity sector of their own
+	if classification.Sector == UnclassifiedSector {
+		switch classification.AssetClass {
+		case "CASH", "FIXED_INCOME", "CURRENCY":
+			classification.Sector = classification.AssetClass
+		}
+	}
+
+	return classification
+}
@@ -0,0 +1,67 @@
+package calculator
+
+import (
+	"strings"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// tierMarketProfile is the market a liquidity tier is assumed to trade in
+type tierMarketProfile struct {
+	volumeMultiple float64 // Average daily volume as a multiple of the position size
+	spread         float64
+	marketCap      float64
+}
+
+// tierProfiles are calibrated so a HIGH position liquidates within a day at 10% participation,
+// a MEDIUM one in about five days and a LOW one in about twenty
+var tierProfiles = map[string]tierMarketProfile{
+	"HIGH":   {volumeMultiple: 50, spread: 0.0005, marketCap: 50e9},
+	"MEDIUM": {volumeMultiple: 2, spread: 0.005, marketCap: 5e9},
+	"LOW":    {volumeMultiple: 0.5, spread: 0.02, marketCap: 500e6},
+}
+
+// TierMarketData is a MarketDataProvider that estimates volume, spread and market cap from the
+// liquidity tier (HIGH, MEDIUM, LOW) recorded on each position, for deployments without a market
+// data vendor. It has no order book data.
+type TierMarketData struct {
+	quantities map[string]float64
+	tiers      map[string]string
+}
+
+// NewTierMarketData creates a provider for the symbols held in positions
+func NewTierMarketData(positions []models.Position) *TierMarketData {
+	m := &TierMarketData{
+		quantities: make(map[string]float64, len(positions)),
+		tiers:      make(map[string]string, len(positions)),
+	}
+	for _, position := range positions {
+		m.quantities[position.Symbol] += position.Quantity.Abs().InexactFloat64()
+		m.tiers[position.Symbol] = strings.ToUpper(position.Liquidity)
+	}
+	return m
+}
+
+func (m *TierMarketData) profile(symbol string) tierMarketProfile {
+	if profile, ok := tierProfiles[m.tiers[symbol]]; ok {
+		return profile
+	}
+	// Positions default to HIGH liquidity
+	return tierProfiles["HIGH"]
+}
+
+func (m *TierMarketData) GetAverageDailyVolume(symbol string) float64 {
+	return m.quantities[symbol] * m.profile(symbol).volumeMultiple
+}
+
+func (m *TierMarketData) GetBidAskSpread(symbol string) float64 {
+	return m.profile(symbol).spread
+}
+
+func (m *TierMarketData) GetMarketDepth(symbol string) *MarketDepth {
+	return nil
+}
+
+func (m *TierMarketData) GetMarketCap(symbol string) float64 {
+	return m.profile(symbol).marketCap
+}
@@ -1,18 +1,39 @@
 package calculator
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"math/rand"
+	"runtime"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// VaR methods CalculateMethod accepts
+const (
+	MethodHistorical = "historical"
+	MethodParametric = "parametric"
+	MethodMonteCarlo = "montecarlo"
+)
+
+// MonteCarloOptions controls a Monte Carlo VaR simulation
+type MonteCarloOptions struct {
+	Simulations int
+	Workers     int   // Goroutines the simulations are split across; 0 uses GOMAXPROCS
+	Seed        int64 // Non-zero makes the simulation reproducible for the same number of workers
+	// Progress is called from the workers with the simulations run so far, every
+	// monteCarloCheckEvery simulations of each worker; it must be safe for concurrent use
+	Progress func(completed, total int)
 }
 
+// monteCarloCheckEvery is how many simulations a worker runs between checks for cancellation
+const monteCarloCheckEvery = 1000
+
 // VaRCalculator handles Value at Risk calculations
 type VaRCalculator struct {
 	portfolioValue   float64
@@ -63,6 +84,34 @@ func (v *VaRCalculator) CalculateVaR(positions []models.Position, priceHistory m
 	return result, nil
 }
 
+// CalculateMethod calculates the VaR of the positions with a single method at any confidence level,
+// scaled from daily returns to the time horizon by the square root of time. The price series must
+// cover the same days. monteCarlo is only used by the Monte Carlo method, which stops with the
+// context's error when it is cancelled.
+func (v *VaRCalculator) CalculateMethod(ctx context.Context, method string, positions []models.Position, priceHistory map[string][]float64, confidence float64, timeHorizon int, monteCarlo MonteCarloOptions) (float64, error) {
+	at := &VaRCalculator{
+		portfolioValue:   v.portfolioValue,
+		confidenceLevels: []float64{confidence},
+	}
+
+	var daily map[float64]float64
+	switch method {
+	case MethodHistorical:
+		daily = at.historicalVaR(at.calculatePortfolioReturns(positions, priceHistory))
+	case MethodParametric:
+		daily = at.parametricVaR(at.calculatePortfolioReturns(positions, priceHistory))
+	case MethodMonteCarlo:
+		var err error
+		if daily, err = at.simulateMonteCarloVaR(ctx, positions, priceHistory, monteCarlo); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("unknown VaR method %q", method)
+	}
+
+	return daily[confidence] * math.Sqrt(float64(max(timeHorizon, 1))), nil
+}
+
 // calculatePortfolioReturns calculates historical returns for the portfolio
 func (v *VaRCalculator) calculatePortfolioReturns(positions []models.Position, priceHistory map[string][]float64) []float64 {
 	if len(priceHistory) == 0 {
@@ -143,14 +192,8 @@ func (v *VaRCalculator) parametricVaR(returns []float64) map[float64]float64 {
 
 	result := make(map[float64]float64)
 
-	// Z-scores for confidence levels
-	zScores := map[float64]float64{
-		0.95: 1.645,
-		0.99: 2.326,
-	}
-
-	for confidence, z := range zScores {
-		varReturn := mean - z*stdDev
+	for _, confidence := range v.confidenceLevels {
+		varReturn := mean - zScore(confidence)*stdDev
 		result[confidence] = -varReturn * v.portfolioValue
 	}
 
@@ -159,51 +202,86 @@ func (v *VaRCalculator) parametricVaR(returns []float64) map[float64]float64 {
 
 // monteCarloVaR calculates VaR using Monte Carlo simulation
 func (v *VaRCalculator) monteCarloVaR(positions []models.Position, priceHistory map[string][]float64, numSimulations int) map[float64]float64 {
-	if len(positions) == 0 || len(priceHistory) == 0 {
-		return map[float64]float64{0.95: 0, 0.99: 0}
+	result, _ := v.simulateMonteCarloVaR(context.Background(), positions, priceHistory, MonteCarloOptions{Simulations: numSimulations})
+	return result
+}
+
+// simulateMonteCarloVaR draws normal returns for each position from the mean and standard
+// deviation of its history, splitting the simulations across workers that each have their own
+// random source
+func (v *VaRCalculator) simulateMonteCarloVaR(ctx context.Context, positions []models.Position, priceHistory map[string][]float64, opts MonteCarloOptions) (map[float64]float64, error) {
+	if len(positions) == 0 || len(priceHistory) == 0 || opts.Simulations <= 0 {
+		return v.historicalVaR(nil), nil
 	}
 
 	// Calculate returns for each asset
-	assetReturns := make(map[string][]float64)
 	assetStats := make(map[string]struct{ mean, stdDev float64 })
-
 	for symbol, prices := range priceHistory {
 		returns := v.calculateReturns(prices)
 		if len(returns) > 0 {
-			assetReturns[symbol] = returns
 			mean := v.calculateMean(returns)
 			stdDev := v.calculateStdDev(returns, mean)
 			assetStats[symbol] = struct{ mean, stdDev float64 }{mean, stdDev}
 		}
 	}
 
-	// Run Monte Carlo simulations
-	simulatedPortfolioReturns := make([]float64, numSimulations)
-
-	for i := 0; i < numSimulations; i++ {
-		portfolioReturn := 0.0
-		totalValue := 0.0
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, opts.Simulations)
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
 
-		for _, position := range positions {
-			stats, exists := assetStats[position.Symbol]
-			if !exists {
-				continue
+	// Each worker fills its own range of the results, so a seeded run does not depend on scheduling
+	simulatedPortfolioReturns := make([]float64, opts.Simulations)
+	chunk := (opts.Simulations + workers - 1) / workers
+	var completed atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start, end := w*chunk, min((w+1)*chunk, opts.Simulations)
+		random := rand.New(rand.NewSource(seed + int64(w)))
+		wg.Go(func() {
+			for i := start; i < end; i++ {
+				if (i-start)%monteCarloCheckEvery == 0 {
+					if ctx.Err() != nil {
+						return
+					}
+					if opts.Progress != nil && i > start {
+						opts.Progress(int(completed.Add(monteCarloCheckEvery)), opts.Simulations)
+					}
+				}
+
+				portfolioReturn := 0.0
+				totalValue := 0.0
+				for _, position := range positions {
+					stats, exists := assetStats[position.Symbol]
+					if !exists {
+						continue
+					}
+
+					// Generate random return based on historical mean and std dev
+					randomReturn := v.generateRandomReturn(random, stats.mean, stats.stdDev)
+					positionValue := position.Quantity.InexactFloat64() * position.CurrentPrice.InexactFloat64()
+					portfolioReturn += randomReturn * positionValue
+					totalValue += positionValue
+				}
+
+				if totalValue > 0 {
+					simulatedPortfolioReturns[i] = portfolioReturn / totalValue
+				}
 			}
-
-			// Generate random return based on historical mean and std dev
-			randomReturn := v.generateRandomReturn(stats.mean, stats.stdDev)
-			positionValue := position.Quantity.InexactFloat64() * position.CurrentPrice.InexactFloat64()
-			portfolioReturn += randomReturn * positionValue
-			totalValue += positionValue
-		}
-
-		if totalValue > 0 {
-			simulatedPortfolioReturns[i] = portfolioReturn / totalValue
-		}
+		})
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// Calculate VaR from simulated returns
-	return v.historicalVaR(simulatedPortfolioReturns)
+	return v.historicalVaR(simulatedPortfolioReturns), nil
 }
 
 // calculateExpectedShortfall calculates the expected loss beyond VaR
@@ -263,7 +341,105 @@ func (v *VaRCalculator) calculateMaxDrawdown(returns []float64) float64 {
 	return maxDrawdown * v.portfolioValue
 }
 
+// CalculateContributions breaks the parametric VaR of the positions down per position from the
+// covariance of their returns. Each price series must end on the same day; series are aligned on
+// their most recent prices. Positions without at least two prices are listed as excluded.
+//
+// The marginal VaR of a position is the change in VaR per unit of its value, its component VaR
+// the marginal VaR times its value (the components add up to the VaR), and its incremental VaR
+// the VaR removed by closing it.
+func (v *VaRCalculator) CalculateContributions(positions []models.Position, priceHistory map[string][]float64, confidence float64, timeHorizon int) *VaRContributionResult {
+	result := &VaRContributionResult{
+		ConfidenceLevel: confidence,
+		TimeHorizon:     timeHorizon,
+		Contributions:   []PositionVaRContribution{},
+		Excluded:        []string{},
+	}
+
+	var included []models.Position
+	length := math.MaxInt32
+	for _, position := range positions {
+		prices := priceHistory[position.Symbol]
+		if len(prices) < 2 {
+			result.Excluded = append(result.Excluded, position.Symbol)
+			continue
+		}
+		included = append(included, position)
+		length = min(length, len(prices))
+	}
+	if len(included) == 0 {
+		return result
+	}
+	result.Observations = length - 1
+
+	n := len(included)
+	exposures := make([]float64, n)
+	returns := make([][]float64, n)
+	for i, position := range included {
+		prices := priceHistory[position.Symbol]
+		exposures[i] = position.MarketValue.InexactFloat64()
+		returns[i] = v.calculateReturns(prices[len(prices)-length:])
+	}
+
+	// Covariance of the position returns, and its product with the exposures
+	means := make([]float64, n)
+	for i := range returns {
+		means[i] = v.calculateMean(returns[i])
+	}
+	covariance := make([][]float64, n)
+	for i := range covariance {
+		covariance[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			covariance[i][j] = v.calculateCovariance(returns[i], returns[j], means[i], means[j])
+			covariance[j][i] = covariance[i][j]
+		}
+	}
+	weighted := make([]float64, n)
+	variance := 0.0
+	for i := range covariance {
+		for j := range covariance[i] {
+			weighted[i] += covariance[i][j] * exposures[j]
+		}
+		variance += exposures[i] * weighted[i]
+	}
+
+	scale := zScore(confidence) * math.Sqrt(float64(max(timeHorizon, 1)))
+	stdDev := math.Sqrt(math.Max(variance, 0))
+	result.VaR = scale * stdDev
+
+	for i, position := range included {
+		contribution := PositionVaRContribution{
+			Symbol:      position.Symbol,
+			MarketValue: exposures[i],
+		}
+		if stdDev > 0 {
+			contribution.MarginalVaR = scale * weighted[i] / stdDev
+			contribution.ComponentVaR = contribution.MarginalVaR * exposures[i]
+			contribution.Contribution = contribution.ComponentVaR / result.VaR
+		}
+		// Variance of the portfolio without the position
+		without := variance - 2*exposures[i]*weighted[i] + exposures[i]*exposures[i]*covariance[i][i]
+		contribution.IncrementalVaR = result.VaR - scale*math.Sqrt(math.Max(without, 0))
+		result.Contributions = append(result.Contributions, contribution)
+	}
+
+	sort.SliceStable(result.Contributions, func(i, j int) bool {
+		return result.Contributions[i].ComponentVaR > result.Contributions[j].ComponentVaR
+	})
+	for i := range result.Contributions {
+		result.Contributions[i].Rank = i + 1
+	}
+
+	return result
+}
+
 // Helper functions
+
+// zScore is the standard normal quantile of a confidence level, 1.645 at 95%
+func zScore(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*confidence-1)
+}
+
 func (v *VaRCalculator) calculateReturns(prices []float64) []float64 {
 	if len(prices) < 2 {
 		return []float64{}
@@ -305,10 +481,22 @@ func (v *VaRCalculator) calculateStdDev(data []float64, mean float64) float64 {
 	return math.Sqrt(variance)
 }
 
-func (v *VaRCalculator) generateRandomReturn(mean, stdDev float64) float64 {
+func (v *VaRCalculator) calculateCovariance(x, y []float64, meanX, meanY float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return 0
+	}
+
+	covariance := 0.0
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+	}
+	return covariance / float64(len(x)-1)
+}
+
+func (v *VaRCalculator) generateRandomReturn(random *rand.Rand, mean, stdDev float64) float64 {
 	// Box-Muller transform for normal distribution
-	u1 := math.Max(1e-10, rand.Float64())
-	u2 := rand.Float64()
+	u1 := math.Max(1e-10, random.Float64())
+	u2 := random.Float64()
 
 	z := math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
 	return mean + z*stdDev
@@ -329,3 +517,24 @@ type VaRResult struct {
 	ExpectedShortfall99 float64 `json:"expected_shortfall_99"`
 	MaxDrawdown         float64 `json:"max_drawdown"`
 }
+
+// PositionVaRContribution is one position's share of the portfolio VaR
+type PositionVaRContribution struct {
+	Rank           int     `json:"rank"` // By component VaR, largest first
+	Symbol         string  `json:"symbol"`
+	MarketValue    float64 `json:"market_value"`
+	MarginalVaR    float64 `json:"marginal_var"`    // Change in VaR per unit of market value
+	ComponentVaR   float64 `json:"component_var"`   // Marginal VaR times market value; components add up to the VaR
+	Contribution   float64 `json:"contribution"`    // Component VaR as a fraction of the VaR
+	IncrementalVaR float64 `json:"incremental_var"` // VaR removed by closing the position
+}
+
+// VaRContributionResult contains the parametric VaR of a portfolio broken down per position
+type VaRContributionResult struct {
+	ConfidenceLevel float64                   `json:"confidence_level"`
+	TimeHorizon     int                       `json:"time_horizon"`
+	Observations    int                       `json:"observations"` // Daily returns the covariance is estimated from
+	VaR             float64                   `json:"var"`
+	Contributions   []PositionVaRContribution `json:"contributions"`
+	Excluded        []string                  `json:"excluded"` // Symbols without enough price history
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// AccessService answers object-level questions: which portfolios a user may see and change. A user
+// sees the portfolios they own, supervise or that a team they belong to owns, and changes those they
+// own or edit as a team editor. A user in a tenant, whatever their role, never sees another tenant's
+// portfolios or the transactions and alerts in them.
+type AccessService struct {
+	db *gorm.DB
+}
+
+func NewAccessService() *AccessService {
+	return &AccessService{
+		db: database.GetDB(),
+	}
+}
+
+// HasGlobalScope reports whether a role can see every portfolio
+func HasGlobalScope(role string) bool {
+	return role == models.RoleAdmin || role == models.RoleComplianceOfficer
+}
+
+// accessibleSubquery selects the IDs of portfolios a user owns, supervises or shares through a team
+func (s *AccessService) accessibleSubquery(userID uuid.UUID) *gorm.DB {
+	return s.db.Raw(
+		`SELECT id FROM (
+			SELECT id FROM portfolios WHERE user_id = ? AND deleted_at IS NULL
+			UNION SELECT portfolio_id FROM portfolio_supervisors WHERE user_id = ?
+			UNION SELECT portfolios.id FROM portfolios JOIN team_members ON team_members.team_id = portfolios.team_id
+				WHERE team_members.user_id = ? AND portfolios.deleted_at IS NULL
+		) AS accessible WHERE `+tenantCondition("accessible.id"),
+		userID, userID, userID, userID, userID,
+	)
+}
+
+// tenantCondition is a condition on a portfolio column keeping the portfolios in the tenant of the
+// user given twice as its arguments; a user outside any tenant is not restricted by it
+func tenantCondition(column string) string {
+	return "((SELECT tenant_id FROM users WHERE id = ?) IS NULL OR " + column +
+		" IN (SELECT id FROM portfolios WHERE tenant_id = (SELECT tenant_id FROM users WHERE id = ?)))"
+}
+
+// inTenantOf restricts a query on a table with the given portfolio column to the user's tenant
+func inTenantOf(query *gorm.DB, column string, userID uuid.UUID) *gorm.DB {
+	return query.Where(tenantCondition(column), userID, userID)
+}
+
+// actedInTenantOf restricts a query on a table with the given user column to users in the tenant
+// of the user; a user outside any tenant is not restricted by it
+func actedInTenantOf(query *gorm.DB, column string, userID uuid.UUID) *gorm.DB {
+	return query.Where("((SELECT tenant_id FROM users WHERE id = ?) IS NULL OR "+column+
+		" IN (SELECT id FROM users WHERE tenant_id = (SELECT tenant_id FROM users WHERE id = ?)))", userID, userID)
+}
+
+// casesInTenantOf restricts a query on cases to those with a transaction, or escalated from an
+// alert, in the user's tenant; a user outside any tenant is not restricted by it
+func casesInTenantOf(query *gorm.DB, userID uuid.UUID) *gorm.DB {
+	const tenant = "(SELECT tenant_id FROM users WHERE id = ?)"
+	return query.Where("("+tenant+` IS NULL
+		OR cases.id IN (SELECT case_transactions.case_id FROM case_transactions
+			JOIN transactions ON transactions.id = case_transactions.transaction_id
+			WHERE transactions.tenant_id = `+tenant+`)
+		OR cases.alert_id IN (SELECT id FROM alerts WHERE tenant_id = `+tenant+"))",
+		userID, userID, userID)
+}
+
+// editableBy restricts a query on portfolios to those a user owns or edits as a team editor
+func editableBy(query *gorm.DB, userID uuid.UUID) *gorm.DB {
+	query = query.Where("(user_id = ? OR team_id IN (SELECT team_id FROM team_members WHERE user_id = ? AND role = ?))",
+		userID, userID, models.TeamRoleEditor)
+	return inTenantOf(query, "id", userID)
+}
+
+// ScopeQuery restricts a query on a table with the given portfolio column to the user's portfolios
+func (s *AccessService) ScopeQuery(query *gorm.DB, column string, userID uuid.UUID, role string) *gorm.DB {
+	if HasGlobalScope(role) {
+		return inTenantOf(query, column, userID)
+	}
+	return query.Where(column+" IN (?)", s.accessibleSubquery(userID))
+}
+
+// AccessiblePortfolioIDs returns the portfolios a user may see; all is true for global roles outside
+// any tenant
+func (s *AccessService) AccessiblePortfolioIDs(userID uuid.UUID, role string) (ids []uuid.UUID, all bool, err error) {
+	if HasGlobalScope(role) {
+		tenantID, err := s.TenantOf(userID)
+		if err != nil || tenantID == nil {
+			return nil, err == nil, err
+		}
+		err = s.db.Model(&models.Portfolio{}).Where("tenant_id = ?", *tenantID).Pluck("id", &ids).Error
+		return ids, false, err
+	}
+
+	err = s.db.Raw("SELECT id FROM (?) AS accessible", s.accessibleSubquery(userID)).Scan(&ids).Error
+	return ids, false, err
+}
+
+// TenantOf returns the tenant a user belongs to, or nil for the platform operator's users
+func (s *AccessService) TenantOf(userID uuid.UUID) (*uuid.UUID, error) {
+	var user models.User
+	if err := s.db.Select("id", "tenant_id").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return user.TenantID, nil
+}
+
+// CanAccessPortfolio reports whether a user owns, supervises, shares through a team or globally
+// oversees a portfolio
+func (s *AccessService) CanAccessPortfolio(userID uuid.UUID, role string, portfolioID uuid.UUID) (bool, error) {
+	if HasGlobalScope(role) {
+		return s.inUserTenant(userID, portfolioID)
+	}
+
+	var count int64
+	err := s.db.Model(&models.Portfolio{}).
+		Where("id = ? AND id IN (?)", portfolioID, s.accessibleSubquery(userID)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CanModifyPortfolio reports whether a user may change a portfolio's contents: its owner, an editor
+// of its team or an admin. Supervisors, team viewers and compliance officers can see a portfolio but
+// not trade in it.
+func (s *AccessService) CanModifyPortfolio(userID uuid.UUID, role string, portfolioID uuid.UUID) (bool, error) {
+	if role == models.RoleAdmin {
+		return s.inUserTenant(userID, portfolioID)
+	}
+
+	var count int64
+	err := editableBy(s.db.Model(&models.Portfolio{}).Where("id = ?", portfolioID), userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// inUserTenant reports whether a portfolio is in the user's tenant; every portfolio is for a user
+// outside any tenant
+func (s *AccessService) inUserTenant(userID, portfolioID uuid.UUID) (bool, error) {
+	tenantID, err := s.TenantOf(userID)
+	if err != nil || tenantID == nil {
+		return err == nil, err
+	}
+
+	var count int64
+	err = s.db.Model(&models.Portfolio{}).Where("id = ? AND tenant_id = ?", portfolioID, *tenantID).Count(&count).Error
+	return count > 0, err
+}
+
+// TransactionPortfolioID returns the portfolio a transaction belongs to
+func (s *AccessService) TransactionPortfolioID(transactionID uuid.UUID) (uuid.UUID, error) {
+	var transaction models.Transaction
+	if err := s.db.Select("portfolio_id").First(&transaction, transactionID).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return transaction.PortfolioID, nil
+}
+
+// AlertPortfolioID returns the portfolio an alert belongs to
+func (s *AccessService) AlertPortfolioID(alertID uuid.UUID) (uuid.UUID, error) {
+	var alert models.Alert
+	if err := s.db.Select("portfolio_id").First(&alert, alertID).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return alert.PortfolioID, nil
+}
+
+// ListSupervisors returns the users assigned to supervise a portfolio
+func (s *AccessService) ListSupervisors(portfolioID uuid.UUID) ([]models.PortfolioSupervisor, error) {
+	var supervisors []models.PortfolioSupervisor
+	err := s.db.Preload("User").Where("portfolio_id = ?", portfolioID).Find(&supervisors).Error
+	return supervisors, err
+}
+
+// AddSupervisor grants a user access to a portfolio
+func (s *AccessService) AddSupervisor(portfolioID, userID uuid.UUID, assignedBy *uuid.UUID) (*models.PortfolioSupervisor, error) {
+	var portfolio models.Portfolio
+	if err := s.db.First(&portfolio, portfolioID).Error; err != nil {
+		return nil, errors.New("portfolio not found")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	supervisor := &models.PortfolioSupervisor{
+		PortfolioID: portfolioID,
+		UserID:      userID,
+		AssignedBy:  assignedBy,
+	}
+	if err := s.db.Create(supervisor).Error; err != nil {
+		return nil, errors.New("user already supervises this portfolio")
+	}
+
+	supervisor.User = user
+	return supervisor, nil
+}
+
+// RemoveSupervisor revokes a user's supervision of a portfolio
+func (s *AccessService) RemoveSupervisor(portfolioID, userID uuid.UUID) error {
+	result := s.db.Where("portfolio_id = ? AND user_id = ?", portfolioID, userID).
+		Delete(&models.PortfolioSupervisor{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("supervisor assignment not found")
+	}
+	return nil
+}
+
+// ListTeamMembers returns the members of a team with their roles
+func (s *AccessService) ListTeamMembers(teamID uuid.UUID) ([]models.TeamMember, error) {
+	members := []models.TeamMember{}
+	err := s.db.Preload("User").Where("team_id = ?", teamID).Order("created_at ASC").Find(&members).Error
+	return members, err
+}
+
+// SetTeamMember adds a user to a team or changes their role in it
+func (s *AccessService) SetTeamMember(teamID, userID uuid.UUID, role string, addedBy *uuid.UUID) (*models.TeamMember, error) {
+	role = strings.ToUpper(strings.TrimSpace(role))
+	if role == "" {
+		role = models.TeamRoleViewer
+	}
+	if role != models.TeamRoleViewer && role != models.TeamRoleEditor {
+		return nil, errors.New("role must be VIEWER or EDITOR")
+	}
+
+	var team models.Team
+	if err := s.db.First(&team, teamID).Error; err != nil {
+		return nil, errors.New("team not found")
+	}
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var member models.TeamMember
+	err := s.db.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		member = models.TeamMember{TeamID: teamID, UserID: userID, Role: role, AddedBy: addedBy}
+		if err := s.db.Create(&member).Error; err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if err := s.db.Model(&member).Update("role", role).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	member.User = user
+	return &member, nil
+}
+
+// RemoveTeamMember removes a user from a team, and with it their access to the team's portfolios
+func (s *AccessService) RemoveTeamMember(teamID, userID uuid.UUID) error {
+	result := s.db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("team member not found")
+	}
+	return nil
+}
+
+// SetPortfolioTeam shares a portfolio with a team, or stops sharing it when teamID is nil. The
+// portfolio keeps its individual owner.
+func (s *AccessService) SetPortfolioTeam(portfolioID uuid.UUID, teamID *uuid.UUID) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	if err := s.db.First(&portfolio, portfolioID).Error; err != nil {
+		return nil, errors.New("portfolio not found")
+	}
+	if teamID != nil {
+		var team models.Team
+		if err := s.db.First(&team, *teamID).Error; err != nil {
+			return nil, errors.New("team not found")
+		}
+		portfolio.Team = &team
+	}
+
+	if err := s.db.Model(&portfolio).Update("team_id", teamID).Error; err != nil {
+		return nil, err
+	}
+	portfolio.TeamID = teamID
+	return &portfolio, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// ActivityService reads the portfolio timelines the database keeps of transactions, alerts,
+// threshold changes and status transitions
+type ActivityService struct {
+	db *gorm.DB
+}
+
+func NewActivityService() *ActivityService {
+	return &ActivityService{
+		db: database.GetDB(),
+	}
+}
+
+// List returns a page of a portfolio's activity
+func (s *ActivityService) List(portfolioID uuid.UUID, spec pagination.Spec, params pagination.Params) ([]models.PortfolioActivity, int64, error) {
+	activities := []models.PortfolioActivity{}
+	query := s.db.Model(&models.PortfolioActivity{}).Where("portfolio_id = ?", portfolioID)
+	total, err := pagination.Find(query, spec, params, &activities)
+	return activities, total, err
+}
@@ -1,29 +1,82 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
 )
 
 type AlertService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
 func NewAlertService() *AlertService {
 	return &AlertService{
-		db: database.GetDB(),
+		db:     database.GetDB(),
+		logger: logging.Component("alerts"),
 	}
 }
 
-// CreateAlert creates a new alert
-func (s *AlertService) CreateAlert(alert *models.Alert) error {
-	return s.db.Create(alert).Error
+// publishedAlert is an alert as published for WebSocket delivery, tagged with the ID of the
+// request or job that raised it
+type publishedAlert struct {
+	*models.Alert
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// CreateAlert creates a new alert, publishes it for WebSocket delivery and sends notifications. An
+// alert raised inside a suppression window covering it is stored as SUPPRESSED and not notified.
+func (s *AlertService) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	if alert.Status == "" || alert.Status == "ACTIVE" {
+		suppression, err := matchingSuppression(s.db, alert)
+		if err != nil {
+			// Raising the alert matters more than honouring the window
+			s.logger.ErrorContext(ctx, "Failed to check alert suppression windows", "portfolio_id", alert.PortfolioID, "error", err)
+		} else if suppression != nil {
+			alert.Status = models.AlertStatusSuppressed
+			alert.SuppressionID = &suppression.ID
+		}
+	}
+
+	if err := s.db.Create(alert).Error; err != nil {
+		return err
+	}
+
+	metrics.AlertsCreated.With(alert.AlertType, alert.Severity).Inc()
+	if alert.Status == models.AlertStatusSuppressed {
+		metrics.AlertsSuppressed.With(alert.AlertType, alert.Severity).Inc()
+		s.logger.InfoContext(ctx, "Alert suppressed", "alert_id", alert.ID, "portfolio_id", alert.PortfolioID,
+			"alert_type", alert.AlertType, "severity", alert.Severity, "suppression_id", alert.SuppressionID)
+	} else {
+		s.logger.InfoContext(ctx, "Alert created", "alert_id", alert.ID, "portfolio_id", alert.PortfolioID,
+			"alert_type", alert.AlertType, "severity", alert.Severity)
+		notifications.Dispatch(alert)
+	}
+
+	if database.GetRedis() != nil {
+		alertJSON, err := json.Marshal(publishedAlert{Alert: alert, RequestID: logging.RequestID(ctx)})
+		if err == nil {
+			err = database.PublishEvent(ctx, database.AlertsChannel, alertJSON)
+		}
+		if err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+			s.logger.ErrorContext(ctx, "Failed to publish alert", "alert_id", alert.ID, "error", err)
+		}
+	}
+
+	return nil
 }
 
 // GetAlerts returns all alerts with optional filtering
@@ -45,11 +98,13 @@ func (s *AlertService) GetAlerts(status string, severity string, limit int) ([]m
 	return alerts, err
 }
 
-// GetAlertByID returns a specific alert by ID
+// GetAlertByID returns a specific alert by ID with its escalation timeline
 func (s *AlertService) GetAlertByID(alertID uuid.UUID) (*models.Alert, error) {
 	var alert models.Alert
 	err := s.db.Preload("Portfolio", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id, user_id, name, description, total_value, currency, created_at, updated_at")
+	}).Preload("Escalations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
 	}).First(&alert, alertID).Error
 	if err != nil {
 		return nil, err
@@ -69,22 +124,56 @@ func (s *AlertService) AcknowledgeAlert(alertID uuid.UUID, userID uuid.UUID) err
 
 // ResolveAlert resolves an alert
 func (s *AlertService) ResolveAlert(alertID uuid.UUID, userID uuid.UUID, resolution string) error {
-	return s.db.Model(&models.Alert{}).Where("id = ?", alertID).Updates(map[string]interface{}{
+	err := s.db.Model(&models.Alert{}).Where("id = ?", alertID).Updates(map[string]interface{}{
 		"status":      "RESOLVED",
 		"resolved_by": userID,
 		"resolved_at": time.Now(),
 		"resolution":  resolution,
 		"updated_at":  time.Now(),
 	}).Error
+	if err != nil {
+		return err
+	}
+	dispatchResolved(s.db, alertID)
+	return nil
+}
+
+// dispatchResolved notifies the channels subscribed to resolutions once an alert has been resolved
+func dispatchResolved(db *gorm.DB, alertID uuid.UUID) {
+	var alert models.Alert
+	if err := db.First(&alert, alertID).Error; err == nil {
+		notifications.DispatchEvent(notifications.EventAlertResolved, &alert)
+	}
 }
 
-// DeleteAlert deletes an alert
+// DeleteAlert soft deletes an alert
 func (s *AlertService) DeleteAlert(alertID uuid.UUID) error {
 	return s.db.Delete(&models.Alert{}, alertID).Error
 }
 
+// RestoreAlert undeletes a soft deleted alert whose portfolio has not been deleted
+func (s *AlertService) RestoreAlert(alertID uuid.UUID) (*models.Alert, error) {
+	var alert models.Alert
+	err := s.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", alertID).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("deleted alert not found")
+		}
+		return nil, err
+	}
+
+	if err := requireLivePortfolio(s.db, alert.PortfolioID); err != nil {
+		return nil, err
+	}
+	if err := s.db.Unscoped().Model(&alert).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	alert.DeletedAt = gorm.DeletedAt{}
+	return &alert, nil
+}
+
 // CreateRiskBreachAlert creates an alert for risk threshold breach
-func (s *AlertService) CreateRiskBreachAlert(portfolioID uuid.UUID, metricType string, currentValue, threshold float64) error {
+func (s *AlertService) CreateRiskBreachAlert(ctx context.Context, portfolioID uuid.UUID, metricType string, currentValue, threshold float64) error {
 	var severity string
 	breachRatio := currentValue / threshold
 
@@ -113,11 +202,11 @@ func (s *AlertService) CreateRiskBreachAlert(portfolioID uuid.UUID, metricType s
 		},
 	}
 
-	return s.CreateAlert(alert)
+	return s.CreateAlert(ctx, alert)
 }
 
 // CreateComplianceAlert creates an alert for compliance violations
-func (s *AlertService) CreateComplianceAlert(portfolioID uuid.UUID, violationType string, details map[string]interface{}) error {
+func (s *AlertService) CreateComplianceAlert(ctx context.Context, portfolioID uuid.UUID, violationType string, details map[string]interface{}) error {
 	var severity, title, description string
 
 	switch violationType {
@@ -129,6 +218,26 @@ func (s *AlertService) CreateComplianceAlert(portfolioID uuid.UUID, violationTyp
 		severity = "CRITICAL"
 		title = "KYC/AML Violation"
 		description = "Suspicious transaction activity detected"
+	case "SANCTIONS":
+		severity = "CRITICAL"
+		title = "Sanctions Screening Match"
+		description = "Transaction party matches a sanctions list entry"
+	case "COUNTERPARTY":
+		severity = "HIGH"
+		title = "Counterparty Compliance Violation"
+		description = "Transaction counterparty is blocked, unverified or over its exposure limit"
+	case "WATCH_LIST":
+		severity = "MEDIUM"
+		title = "Watch List Trade"
+		description = "Transaction is in a symbol on the compliance watch list"
+	case "APPROVAL_REQUIRED":
+		severity = "MEDIUM"
+		title = "Transaction Awaiting Approval"
+		description = "Transaction was flagged for review and needs a second user's approval"
+	case "AMENDMENT_REQUESTED":
+		severity = "MEDIUM"
+		title = "Transaction Amendment Awaiting Approval"
+		description = "A correction or cancellation of a completed transaction needs a second user's approval"
 	case "LIQUIDITY_RISK":
 		severity = "MEDIUM"
 		title = "Liquidity Risk Alert"
@@ -150,11 +259,11 @@ func (s *AlertService) CreateComplianceAlert(portfolioID uuid.UUID, violationTyp
 		TriggeredBy: models.JSON(details),
 	}
 
-	return s.CreateAlert(alert)
+	return s.CreateAlert(ctx, alert)
 }
 
 // CreateSuspiciousActivityAlert creates an alert for suspicious trading activity
-func (s *AlertService) CreateSuspiciousActivityAlert(portfolioID uuid.UUID, activityType string, details map[string]interface{}) error {
+func (s *AlertService) CreateSuspiciousActivityAlert(ctx context.Context, portfolioID uuid.UUID, activityType string, details map[string]interface{}) error {
 	alert := &models.Alert{
 		PortfolioID: portfolioID,
 		AlertType:   "SUSPICIOUS_ACTIVITY",
@@ -166,7 +275,7 @@ func (s *AlertService) CreateSuspiciousActivityAlert(portfolioID uuid.UUID, acti
 		TriggeredBy: models.JSON(details),
 	}
 
-	return s.CreateAlert(alert)
+	return s.CreateAlert(ctx, alert)
 }
 
 // GetActiveAlerts returns all active alerts
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,13 +13,17 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
 )
 
 type AlertGeneratorService struct {
 	db          *gorm.DB
 	redisClient *redis.Client
 	riskService *RiskEngineService
+	logger      *slog.Logger
 }
 
 func NewAlertGeneratorService() *AlertGeneratorService {
@@ -26,6 +31,7 @@ func NewAlertGeneratorService() *AlertGeneratorService {
 		db:          database.GetDB(),
 		redisClient: database.GetRedis(),
 		riskService: NewRiskEngineService(),
+		logger:      logging.Component("alert_generator"),
 	}
 }
 
@@ -329,6 +335,9 @@ func (a *AlertGeneratorService) storeAndBroadcastAlert(alert models.Alert) {
 	if err := a.db.Create(&alert).Error; err != nil {
 		return
 	}
+	metrics.AlertsCreated.With(alert.AlertType, alert.Severity).Inc()
+
+	notifications.Dispatch(&alert)
 
 	// Cache in Redis
 	ctx := context.Background()
@@ -338,8 +347,8 @@ func (a *AlertGeneratorService) storeAndBroadcastAlert(alert models.Alert) {
 	a.redisClient.SAdd(ctx, "active_alerts", alert.ID.String())
 
 	// Broadcast via WebSocket (publish to Redis channel)
-	a.redisClient.Publish(ctx, "alerts_channel", alertJSON)
+	database.PublishEvent(ctx, database.AlertsChannel, alertJSON)
 
-	fmt.Printf("🚨 Alert Generated: %s - %s (Severity: %s)\n",
-		alert.AlertType, alert.Title, alert.Severity)
+	a.logger.Info("Alert generated", "alert_id", alert.ID, "alert_type", alert.AlertType,
+		"title", alert.Title, "severity", alert.Severity)
 }
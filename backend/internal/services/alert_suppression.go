@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// maxSuppressionWindow bounds how long a suppression window may last, so a forgotten window does
+// not silence a portfolio indefinitely
+const maxSuppressionWindow = 7 * 24 * time.Hour
+
+type AlertSuppressionService struct {
+	db *gorm.DB
+}
+
+func NewAlertSuppressionService() *AlertSuppressionService {
+	return &AlertSuppressionService{
+		db: database.GetDB(),
+	}
+}
+
+// AlertSuppressionRequest opens a suppression window. At least one of the portfolio, rule and
+// source must be given.
+type AlertSuppressionRequest struct {
+	PortfolioID *uuid.UUID `json:"portfolio_id"`
+	RuleID      *uuid.UUID `json:"rule_id"`                   // Compliance rule
+	Source      string     `json:"source" validate:"max=100"` // Alert source, e.g. DRAWDOWN_MONITOR
+	StartsAt    *time.Time `json:"starts_at"`                 // Now when omitted
+	EndsAt      time.Time  `json:"ends_at" validate:"required"`
+	Reason      string     `json:"reason" validate:"required,max=2000"`
+}
+
+// Create opens a suppression window
+func (s *AlertSuppressionService) Create(req AlertSuppressionRequest, userID uuid.UUID) (*models.AlertSuppression, error) {
+	if req.PortfolioID == nil && req.RuleID == nil && req.Source == "" {
+		return nil, apperror.BadRequest("A suppression window needs a portfolio, rule or source")
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) || !req.EndsAt.After(now) {
+		return nil, apperror.BadRequest("ends_at must be after starts_at and in the future")
+	}
+	if req.EndsAt.Sub(startsAt) > maxSuppressionWindow {
+		return nil, apperror.BadRequest("A suppression window may last at most 7 days")
+	}
+
+	if req.PortfolioID != nil {
+		if err := s.db.Select("id").First(&models.Portfolio{}, *req.PortfolioID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apperror.NotFound("Portfolio not found")
+			}
+			return nil, err
+		}
+	}
+	if req.RuleID != nil {
+		if err := s.db.Select("id").First(&models.ComplianceRule{}, *req.RuleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apperror.NotFound("Compliance rule not found")
+			}
+			return nil, err
+		}
+	}
+
+	suppression := &models.AlertSuppression{
+		PortfolioID: req.PortfolioID,
+		RuleID:      req.RuleID,
+		Source:      req.Source,
+		StartsAt:    startsAt,
+		EndsAt:      req.EndsAt,
+		Reason:      req.Reason,
+		CreatedBy:   &userID,
+	}
+	if err := s.db.Create(suppression).Error; err != nil {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+// List returns a page of the suppression windows matching query
+func (s *AlertSuppressionService) List(query *gorm.DB, spec pagination.Spec, params pagination.Params) ([]models.AlertSuppression, int64, error) {
+	suppressions := []models.AlertSuppression{}
+	total, err := pagination.Find(query, spec, params, &suppressions)
+	return suppressions, total, err
+}
+
+// Get returns a suppression window
+func (s *AlertSuppressionService) Get(id uuid.UUID) (*models.AlertSuppression, error) {
+	var suppression models.AlertSuppression
+	if err := s.db.First(&suppression, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Suppression window not found")
+		}
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// Cancel ends a suppression window now, or before it starts. Alerts already suppressed stay so.
+func (s *AlertSuppressionService) Cancel(id, userID uuid.UUID) (*models.AlertSuppression, error) {
+	suppression, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if suppression.CancelledAt != nil || !suppression.EndsAt.After(now) {
+		return nil, apperror.Conflict("Suppression window has already ended")
+	}
+
+	updates := map[string]interface{}{
+		"cancelled_by": userID,
+		"cancelled_at": now,
+		"updated_at":   now,
+	}
+	// A window that has started ends now; one that has not keeps its times for the record
+	if suppression.StartsAt.Before(now) {
+		updates["ends_at"] = now
+	}
+	if err := s.db.Model(suppression).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// matchingSuppression returns the open suppression window covering an alert raised now, or nil.
+// A compliance rule breach alert carries its rule in triggered_by.
+func matchingSuppression(db *gorm.DB, alert *models.Alert) (*models.AlertSuppression, error) {
+	ruleID, _ := alert.TriggeredBy["rule_id"].(string)
+	now := time.Now()
+
+	var suppression models.AlertSuppression
+	err := db.Where("starts_at <= ? AND ends_at > ? AND cancelled_at IS NULL", now, now).
+		Where("portfolio_id IS NULL OR portfolio_id = ?", alert.PortfolioID).
+		Where("source IS NULL OR source = '' OR source = ?", alert.Source).
+		Where("rule_id IS NULL OR rule_id::text = ?", ruleID).
+		Order("starts_at").First(&suppression).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &suppression, nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// allocationDriftAlertSource is used to avoid raising a drift alert while one is still active
+const allocationDriftAlertSource = "ALLOCATION_DRIFT_MONITOR"
+
+// defaultAllocationTolerance applies to targets set without a tolerance
+var defaultAllocationTolerance = decimal.NewFromFloat(0.05)
+
+// TargetAllocationInput is the weight a portfolio aims to hold in one symbol or asset class
+type TargetAllocationInput struct {
+	Kind         string          `json:"kind"` // SYMBOL or ASSET_CLASS
+	Key          string          `json:"key"`
+	TargetWeight decimal.Decimal `json:"target_weight"`
+	Tolerance    decimal.Decimal `json:"tolerance"` // Defaults to 0.05
+}
+
+// AllocationService keeps portfolios' target allocations, measures how far their weights have
+// drifted and raises an alert with rebalancing trades when a target drifts beyond its tolerance
+type AllocationService struct {
+	db           *gorm.DB
+	alertService *AlertService
+	calculator   *calculator.AllocationCalculator
+	logger       *slog.Logger
+}
+
+func NewAllocationService() *AllocationService {
+	return &AllocationService{
+		db:           database.GetDB(),
+		alertService: NewAlertService(),
+		calculator:   calculator.NewAllocationCalculator(),
+		logger:       logging.Component("allocation"),
+	}
+}
+
+// GetTargets lists a portfolio's target allocation
+func (s *AllocationService) GetTargets(portfolioID uuid.UUID) ([]models.TargetAllocation, error) {
+	targets := []models.TargetAllocation{}
+	err := s.db.Where("portfolio_id = ?", portfolioID).Order("kind ASC, key ASC").Find(&targets).Error
+	return targets, err
+}
+
+// SetTargets replaces a portfolio's target allocation. The symbol targets and the asset class
+// targets may each add up to at most 100%.
+func (s *AllocationService) SetTargets(portfolioID uuid.UUID, inputs []TargetAllocationInput, userID uuid.UUID) ([]models.TargetAllocation, error) {
+	var count int64
+	if err := s.db.Model(&models.Portfolio{}).Where("id = ?", portfolioID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("portfolio not found")
+	}
+
+	one := decimal.NewFromInt(1)
+	totals := make(map[string]decimal.Decimal)
+	seen := make(map[string]bool)
+	rows := make([]models.TargetAllocation, 0, len(inputs))
+	for i, input := range inputs {
+		row := models.TargetAllocation{
+			PortfolioID:  portfolioID,
+			Kind:         strings.ToUpper(strings.TrimSpace(input.Kind)),
+			Key:          strings.ToUpper(strings.TrimSpace(input.Key)),
+			TargetWeight: input.TargetWeight,
+			Tolerance:    input.Tolerance,
+			UpdatedBy:    &userID,
+		}
+		if row.Kind != models.AllocationKindSymbol && row.Kind != models.AllocationKindAssetClass {
+			return nil, fmt.Errorf("targets[%d]: kind must be SYMBOL or ASSET_CLASS", i)
+		}
+		if row.Key == "" || len(row.Key) > 30 {
+			return nil, fmt.Errorf("targets[%d]: key is required and must be at most 30 characters", i)
+		}
+		if seen[row.Kind+":"+row.Key] {
+			return nil, fmt.Errorf("targets[%d]: duplicate target for %s", i, row.Key)
+		}
+		seen[row.Kind+":"+row.Key] = true
+		if row.TargetWeight.IsNegative() || row.TargetWeight.GreaterThan(one) {
+			return nil, fmt.Errorf("targets[%d]: target_weight must be between 0 and 1", i)
+		}
+		if row.Tolerance.IsZero() {
+			row.Tolerance = defaultAllocationTolerance
+		}
+		if !row.Tolerance.IsPositive() || row.Tolerance.GreaterThan(one) {
+			return nil, fmt.Errorf("targets[%d]: tolerance must be between 0 and 1", i)
+		}
+		totals[row.Kind] = totals[row.Kind].Add(row.TargetWeight)
+		if totals[row.Kind].GreaterThan(one) {
+			return nil, fmt.Errorf("%s target weights add up to more than 1", strings.ToLower(row.Kind))
+		}
+		rows = append(rows, row)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("portfolio_id = ?", portfolioID).Delete(&models.TargetAllocation{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTargets(portfolioID)
+}
+
+// GetDrift compares a portfolio's current weights with its target allocation
+func (s *AllocationService) GetDrift(ctx context.Context, portfolioID uuid.UUID) (*calculator.AllocationDriftResult, error) {
+	defer metrics.RiskCalculationDuration.With("allocation_drift").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.allocation_drift", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	rows, err := s.GetTargets(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]calculator.AllocationTarget, 0, len(rows))
+	for _, row := range rows {
+		targets = append(targets, calculator.AllocationTarget{
+			Kind:      row.Kind,
+			Key:       row.Key,
+			Weight:    row.TargetWeight.InexactFloat64(),
+			Tolerance: row.Tolerance.InexactFloat64(),
+		})
+	}
+
+	return s.calculator.CalculateDrift(portfolio.Positions, portfolio.CashBalance.InexactFloat64(), targets), nil
+}
+
+// CheckDrift measures a portfolio's drift and raises an alert with the suggested rebalancing trades
+// when a target has drifted beyond its tolerance
+func (s *AllocationService) CheckDrift(ctx context.Context, portfolioID uuid.UUID) (*calculator.AllocationDriftResult, error) {
+	result, err := s.GetDrift(ctx, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	breached := []string{}
+	for _, target := range result.Targets {
+		if target.Breached {
+			breached = append(breached, target.Key)
+		}
+	}
+	if len(breached) == 0 || s.hasActiveAlert(portfolioID) {
+		return result, nil
+	}
+
+	alert := &models.Alert{
+		PortfolioID: portfolioID,
+		AlertType:   "RISK_BREACH",
+		Severity:    "MEDIUM",
+		Title:       "Allocation Drift",
+		Description: fmt.Sprintf("Allocation has drifted beyond tolerance for %s", strings.Join(breached, ", ")),
+		Source:      allocationDriftAlertSource,
+		Status:      "ACTIVE",
+		TriggeredBy: models.JSON{
+			"metric_type": "ALLOCATION_DRIFT",
+			"max_drift":   result.MaxDrift,
+			"targets":     result.Targets,
+			"trades":      result.Trades,
+		},
+	}
+	if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create allocation drift alert", "portfolio_id", portfolioID, "error", err)
+	}
+	return result, nil
+}
+
+// ApplyPrices checks the drift of every portfolio with a target allocation that holds a symbol
+// among a batch of feed prices. It runs after the positions have been revalued.
+func (s *AllocationService) ApplyPrices(prices map[string]float64) error {
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	var portfolioIDs []uuid.UUID
+	err := s.db.Model(&models.TargetAllocation{}).
+		Joins("JOIN positions ON positions.portfolio_id = target_allocations.portfolio_id AND positions.deleted_at IS NULL").
+		Where("positions.symbol IN ?", symbols).
+		Distinct("target_allocations.portfolio_id").
+		Pluck("target_allocations.portfolio_id", &portfolioIDs).Error
+	if err != nil {
+		return err
+	}
+
+	ctx := logging.WithNewRequestID(context.Background())
+	for _, portfolioID := range portfolioIDs {
+		if _, err := s.CheckDrift(ctx, portfolioID); err != nil {
+			s.logger.ErrorContext(ctx, "Allocation drift check failed", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *AllocationService) hasActiveAlert(portfolioID uuid.UUID) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, allocationDriftAlertSource, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/monitoring"
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/screening"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// AML check outcomes
+const (
+	AMLStatusPassed         = "PASSED"
+	AMLStatusReviewRequired = "REVIEW_REQUIRED"
+	AMLStatusBlocked        = "BLOCKED"
+)
+
+// AMLCheckReport is the combined outcome of transaction monitoring, sanctions/PEP screening and
+// the counterparty checks for one transaction
+type AMLCheckReport struct {
+	TransactionID uuid.UUID                               `json:"transaction_id"`
+	Status        string                                  `json:"status"`
+	RiskScore     int                                     `json:"risk_score"`
+	Flags         []string                                `json:"flags"`
+	Monitoring    *models.TransactionMonitoringEvaluation `json:"monitoring"`
+	Screenings    []models.ScreeningResult                `json:"screenings"`
+	Counterparty  *rules.CounterpartyCheckResult          `json:"counterparty"`
+	Notes         string                                  `json:"notes"`
+}
+
+// AMLService runs the AML checks on transactions and raises compliance alerts for failures
+type AMLService struct {
+	db                  *gorm.DB
+	screener            *screening.Screener
+	approvals           *TransactionApprovalService
+	pipeline            *monitoring.Pipeline
+	logger              *slog.Logger
+	alertService        *AlertService
+	counterpartyService *CounterpartyService
+}
+
+func NewAMLService() *AMLService {
+	return &AMLService{
+		db:                  database.GetDB(),
+		screener:            screening.GetScreener(),
+		approvals:           NewTransactionApprovalService(),
+		pipeline:            monitoring.GetPipeline(),
+		logger:              logging.Component("aml"),
+		alertService:        NewAlertService(),
+		counterpartyService: NewCounterpartyService(),
+	}
+}
+
+// CheckTransaction checks a transaction, records the result on it and, unless it passed, raises a
+// compliance alert and holds it for approval if it is still pending
+func (s *AMLService) CheckTransaction(ctx context.Context, transaction *models.Transaction, screenedBy *uuid.UUID) (*AMLCheckReport, error) {
+	// Transaction monitoring against the portfolio's history, with its trail kept for review
+	evaluation, err := s.Monitor(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sanctions and PEP screening of the customer and counterparty
+	screenings, err := s.screener.ScreenTransaction(transaction, screenedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AMLCheckReport{
+		TransactionID: transaction.ID,
+		Status:        AMLStatusPassed,
+		RiskScore:     evaluation.RiskScore,
+		Flags:         evaluation.Flags(),
+		Monitoring:    evaluation,
+		Screenings:    screenings,
+	}
+
+	for _, result := range screenings {
+		switch result.Status {
+		case screening.StatusMatch:
+			report.Flags = append(report.Flags, "SANCTIONS_MATCH:"+result.SubjectType)
+			report.RiskScore = 100
+		case screening.StatusPotentialMatch:
+			report.Flags = append(report.Flags, "SANCTIONS_POTENTIAL_MATCH:"+result.SubjectType)
+			report.RiskScore += 40
+		}
+		if result.PEPHit {
+			report.Flags = append(report.Flags, "PEP:"+result.SubjectType)
+			report.RiskScore += 20
+		}
+	}
+
+	// Status, KYC and exposure limit of the linked counterparty
+	counterpartyResult, err := s.counterpartyService.CheckTransaction(transaction)
+	if err != nil {
+		return nil, err
+	}
+	if counterpartyResult != nil {
+		report.Counterparty = counterpartyResult
+		report.Flags = append(report.Flags, counterpartyResult.Flags...)
+		report.RiskScore += counterpartyResult.RiskScore
+	}
+
+	if report.RiskScore > 100 {
+		report.RiskScore = 100
+	}
+
+	sanctioned := hasFlagPrefix(report.Flags, "SANCTIONS_MATCH")
+	switch {
+	case sanctioned, counterpartyResult != nil && counterpartyResult.Blocked:
+		report.Status = AMLStatusBlocked
+	case evaluation.RequiresReview || report.RiskScore >= 50:
+		report.Status = AMLStatusReviewRequired
+	}
+
+	report.Notes = "All AML checks passed successfully"
+	if len(report.Flags) > 0 {
+		report.Notes = "AML flags: " + strings.Join(report.Flags, ", ")
+	}
+
+	s.db.Model(transaction).Updates(map[string]interface{}{
+		"aml_checked":      true,
+		"risk_score":       report.RiskScore,
+		"compliance_notes": report.Notes,
+	})
+
+	if report.Status != AMLStatusPassed {
+		violationType := "KYC_AML"
+		switch {
+		case sanctioned:
+			violationType = "SANCTIONS"
+		case report.Status == AMLStatusBlocked:
+			violationType = "COUNTERPARTY"
+		}
+		s.alertService.CreateComplianceAlert(ctx, transaction.PortfolioID, violationType, map[string]interface{}{
+			"transaction_id": transaction.ID,
+			"flags":          report.Flags,
+			"risk_score":     report.RiskScore,
+		})
+
+		// Pending transactions stay on hold until a second user has reviewed the flags
+		if _, err := s.approvals.Hold(ctx, transaction, report.Notes); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// Monitor runs the transaction monitoring pipeline over a transaction and the history of its
+// portfolio up to when it was recorded, and saves the evaluation trail
+func (s *AMLService) Monitor(ctx context.Context, transaction *models.Transaction) (*models.TransactionMonitoringEvaluation, error) {
+	if s.pipeline == nil {
+		return nil, errors.New("transaction monitoring is not configured")
+	}
+
+	at := transaction.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var history []models.Transaction
+	if err := s.db.
+		Where("portfolio_id = ? AND id <> ? AND created_at > ? AND created_at <= ?",
+			transaction.PortfolioID, transaction.ID, at.Add(-s.pipeline.HistoryWindow()), at).
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return s.evaluate(ctx, transaction, history, models.MonitoringTriggerAMLCheck)
+}
+
+// evaluate runs the pipeline, with the AML settings of the portfolio's tenant, and saves the
+// evaluation trail
+func (s *AMLService) evaluate(ctx context.Context, transaction *models.Transaction, history []models.Transaction, trigger string) (*models.TransactionMonitoringEvaluation, error) {
+	pipeline := s.pipeline
+	settings, err := tenantSettingsOf(s.db, "portfolios", transaction.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && !settings.AML.IsZero() {
+		if pipeline, err = pipeline.WithOverrides(settings.AML); err != nil {
+			return nil, err
+		}
+	}
+
+	evaluation := pipeline.Evaluate(ctx, transaction, history)
+	evaluation.Trigger = trigger
+	if err := s.db.Create(evaluation).Error; err != nil {
+		return nil, err
+	}
+	return evaluation, nil
+}
+
+// GetEvaluations returns the monitoring evaluations of a transaction, latest first
+func (s *AMLService) GetEvaluations(transactionID uuid.UUID) ([]models.TransactionMonitoringEvaluation, error) {
+	var evaluations []models.TransactionMonitoringEvaluation
+	err := s.db.Where("transaction_id = ?", transactionID).Order("evaluated_at DESC").Find(&evaluations).Error
+	return evaluations, err
+}
+
+func hasFlagPrefix(flags []string, prefix string) bool {
+	for _, flag := range flags {
+		if strings.HasPrefix(flag, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// MaxAMLSweepDays bounds how far back a sweep reaches
+const MaxAMLSweepDays = 366
+
+// AMLSweepItem is a transaction a sweep flagged for review that was not flagged when last
+// evaluated
+type AMLSweepItem struct {
+	TransactionID     uuid.UUID       `json:"transaction_id"`
+	PortfolioID       uuid.UUID       `json:"portfolio_id"`
+	TransactionType   string          `json:"transaction_type"`
+	Symbol            string          `json:"symbol"`
+	Amount            decimal.Decimal `json:"amount"`
+	CreatedAt         time.Time       `json:"created_at"`
+	RiskScore         int             `json:"risk_score"`
+	PreviousRiskScore *int            `json:"previous_risk_score"` // Nil when it was never evaluated
+	Flags             []string        `json:"flags"`
+	NewFlags          []string        `json:"new_flags"` // Flags the previous evaluation did not raise
+	EvaluationID      uuid.UUID       `json:"evaluation_id"`
+}
+
+// AMLSweepResult summarizes a retrospective sweep of transaction monitoring over past transactions
+type AMLSweepResult struct {
+	Days                  int            `json:"days"`
+	PortfolioID           *uuid.UUID     `json:"portfolio_id,omitempty"`
+	Rules                 []string       `json:"rules"`
+	PortfoliosEvaluated   int            `json:"portfolios_evaluated"`
+	TransactionsEvaluated int            `json:"transactions_evaluated"`
+	Flagged               int            `json:"flagged"` // Transactions requiring review, newly or not
+	Cleared               int            `json:"cleared"` // Transactions that required review and no longer do
+	NewlyFlagged          []AMLSweepItem `json:"newly_flagged"`
+	StartedAt             time.Time      `json:"started_at"`
+	CompletedAt           time.Time      `json:"completed_at"`
+}
+
+// Sweep re-runs transaction monitoring with the current rules over the last days of transactions,
+// optionally of one portfolio, as each transaction was first seen. A sweep run by a user covers the
+// portfolios of the user's tenant, and one with uuid.Nil covers every tenant. Transactions that now
+// require review but did not when last evaluated are reported and raise KYC_AML alerts. The
+// transactions themselves are left as they are; a fresh AML check updates them.
+func (s *AMLService) Sweep(ctx context.Context, days int, portfolioID *uuid.UUID, userID uuid.UUID) (*AMLSweepResult, error) {
+	if s.pipeline == nil {
+		return nil, errors.New("transaction monitoring is not configured")
+	}
+	if days < 1 || days > MaxAMLSweepDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxAMLSweepDays)
+	}
+
+	result := &AMLSweepResult{
+		Days:         days,
+		PortfolioID:  portfolioID,
+		Rules:        s.pipeline.RuleNames(),
+		NewlyFlagged: []AMLSweepItem{},
+		StartedAt:    time.Now(),
+	}
+	since := result.StartedAt.AddDate(0, 0, -days)
+
+	var portfolioIDs []uuid.UUID
+	query := s.db.Model(&models.Transaction{}).
+		Joins("JOIN portfolios ON portfolios.id = transactions.portfolio_id AND portfolios.deleted_at IS NULL").
+		Where("transactions.created_at > ?", since)
+	if portfolioID != nil {
+		query = query.Where("transactions.portfolio_id = ?", *portfolioID)
+	}
+	if userID != uuid.Nil {
+		query = inTenantOf(query, "transactions.portfolio_id", userID)
+	}
+	if err := query.Distinct().Pluck("transactions.portfolio_id", &portfolioIDs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range portfolioIDs {
+		if err := s.sweepPortfolio(ctx, id, since, result); err != nil {
+			return nil, err
+		}
+		result.PortfoliosEvaluated++
+	}
+
+	result.CompletedAt = time.Now()
+	return result, nil
+}
+
+// sweepPortfolio re-evaluates a portfolio's transactions since the given time, loading its history
+// once for all of them
+func (s *AMLService) sweepPortfolio(ctx context.Context, portfolioID uuid.UUID, since time.Time, result *AMLSweepResult) error {
+	var transactions []models.Transaction
+	if err := s.db.
+		Where("portfolio_id = ? AND created_at > ?", portfolioID, since.Add(-s.pipeline.HistoryWindow())).
+		Order("created_at").
+		Find(&transactions).Error; err != nil {
+		return err
+	}
+
+	var sweptIDs []uuid.UUID
+	for _, tx := range transactions {
+		if tx.CreatedAt.After(since) {
+			sweptIDs = append(sweptIDs, tx.ID)
+		}
+	}
+
+	previous, err := s.latestEvaluations(sweptIDs, result.StartedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		transaction := &transactions[i]
+		if !transaction.CreatedAt.After(since) {
+			continue
+		}
+
+		evaluation, err := s.evaluate(ctx, transaction, transactions, models.MonitoringTriggerSweep)
+		if err != nil {
+			return err
+		}
+		result.TransactionsEvaluated++
+
+		before, evaluated := previous[transaction.ID]
+		switch {
+		case evaluation.RequiresReview:
+			result.Flagged++
+		case evaluated && before.RequiresReview:
+			result.Cleared++
+		}
+		if !evaluation.RequiresReview || (evaluated && before.RequiresReview) {
+			continue
+		}
+
+		item := AMLSweepItem{
+			TransactionID:   transaction.ID,
+			PortfolioID:     portfolioID,
+			TransactionType: transaction.TransactionType,
+			Symbol:          transaction.Symbol,
+			Amount:          transaction.Amount,
+			CreatedAt:       transaction.CreatedAt,
+			RiskScore:       evaluation.RiskScore,
+			Flags:           evaluation.Flags(),
+			NewFlags:        []string{},
+			EvaluationID:    evaluation.ID,
+		}
+		var beforeFlags []string
+		if evaluated {
+			item.PreviousRiskScore = &before.RiskScore
+			beforeFlags = before.Flags()
+		}
+		for _, flag := range item.Flags {
+			if !slices.Contains(beforeFlags, flag) {
+				item.NewFlags = append(item.NewFlags, flag)
+			}
+		}
+		result.NewlyFlagged = append(result.NewlyFlagged, item)
+
+		s.alertService.CreateComplianceAlert(ctx, portfolioID, "KYC_AML", map[string]interface{}{
+			"transaction_id": transaction.ID,
+			"flags":          item.Flags,
+			"risk_score":     item.RiskScore,
+			"aml_sweep":      true,
+		})
+	}
+
+	return nil
+}
+
+// latestEvaluations returns the most recent evaluation of each transaction made before the given
+// time
+func (s *AMLService) latestEvaluations(transactionIDs []uuid.UUID, before time.Time) (map[uuid.UUID]models.TransactionMonitoringEvaluation, error) {
+	latest := make(map[uuid.UUID]models.TransactionMonitoringEvaluation, len(transactionIDs))
+	if len(transactionIDs) == 0 {
+		return latest, nil
+	}
+
+	for chunk := range slices.Chunk(transactionIDs, 1000) {
+		var evaluations []models.TransactionMonitoringEvaluation
+		if err := s.db.
+			Where("transaction_id IN ? AND evaluated_at < ?", chunk, before).
+			Order("evaluated_at").
+			Find(&evaluations).Error; err != nil {
+			return nil, err
+		}
+		for _, evaluation := range evaluations {
+			latest[evaluation.TransactionID] = evaluation
+		}
+	}
+	return latest, nil
+}
+
+// StartSweepScheduler sweeps the last days of transactions at a fixed interval until ctx is cancelled
+func (s *AMLService) StartSweepScheduler(ctx context.Context, interval time.Duration, days int) {
+	if interval <= 0 {
+		s.logger.Info("Scheduled AML sweeps disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		result, err := s.Sweep(ctx, days, nil, uuid.Nil)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduled AML sweep failed", "error", err)
+			continue
+		}
+
+		if len(result.NewlyFlagged) > 0 {
+			s.logger.InfoContext(ctx, "AML sweep flagged transactions", "newly_flagged", len(result.NewlyFlagged),
+				"transactions", result.TransactionsEvaluated, "cleared", result.Cleared)
+		}
+	}
+}
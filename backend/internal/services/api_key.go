@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+const (
+	// apiKeyTokenPrefix marks the keys this API mints, so leaked keys are easy to find in code scans
+	apiKeyTokenPrefix = "frm_"
+	// apiKeyDisplayLength is how much of a key is kept in clear to tell keys apart
+	apiKeyDisplayLength = 12
+
+	// apiKeyRateKey counts the requests of a key in a one minute window
+	apiKeyRateKey = "api_key:rate:%s:%d"
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key
+	apiKeyTouchInterval = time.Minute
+)
+
+// ErrInvalidAPIKey is returned for unknown, revoked and expired keys alike, so callers cannot
+// probe which keys exist
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+type APIKeyService struct {
+	db               *gorm.DB
+	redisClient      *redis.Client
+	defaultRateLimit int
+	logger           *slog.Logger
+}
+
+func NewAPIKeyService(cfg *config.APIKeyConfig) *APIKeyService {
+	return &APIKeyService{
+		db:               database.GetDB(),
+		redisClient:      database.GetRedis(),
+		defaultRateLimit: cfg.DefaultRateLimit,
+		logger:           logging.Component("api_key"),
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	UserID    *uuid.UUID `json:"user_id"` // Owner the key acts as; defaults to the caller
+	Scopes    []string   `json:"scopes"`
+	RateLimit int        `json:"rate_limit"` // Requests per minute; defaults to API_KEY_DEFAULT_RATE_LIMIT
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries the key itself, which cannot be retrieved again
+type CreateAPIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey models.APIKey `json:"api_key"`
+}
+
+// GetOwner returns the active user a key would act as
+func (s *APIKeyService) GetOwner(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("user is deactivated")
+	}
+	return &user, nil
+}
+
+// CreateKey mints a key for owner. Scopes must already be checked against the owner's role.
+func (s *APIKeyService) CreateKey(req CreateAPIKeyRequest, owner *models.User, createdBy uuid.UUID) (*CreateAPIKeyResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	if req.RateLimit < 0 {
+		return nil, errors.New("rate_limit must not be negative")
+	}
+	if req.RateLimit == 0 {
+		req.RateLimit = s.defaultRateLimit
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := models.APIKey{
+		Name:      req.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashToken(key),
+		UserID:    owner.ID,
+		Scopes:    req.Scopes,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+	}
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+
+	return &CreateAPIKeyResponse{Key: key, APIKey: apiKey}, nil
+}
+
+// ListKeys returns all keys, optionally only those acting as one user
+func (s *APIKeyService) ListKeys(userID *uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	query := s.db.Order("created_at DESC")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Find(&keys).Error
+	return keys, err
+}
+
+// GetKey returns a key by ID
+func (s *APIKeyService) GetKey(keyID uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.First(&key, keyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key not found")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeKey stops a key from authenticating; revoking a revoked key is a no-op
+func (s *APIKeyService) RevokeKey(keyID uuid.UUID) (*models.APIKey, error) {
+	key, err := s.GetKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(key).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	key.RevokedAt = &now
+	return key, nil
+}
+
+// Authenticate returns the key matching rawKey, with its owner loaded, if it may be used
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyTokenPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	err := s.db.WithContext(ctx).Preload("User").Where("key_hash = ?", hashToken(rawKey)).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, ErrInvalidAPIKey
+	}
+	// Keys stop working with their owner; the preload skips soft-deleted users
+	if key.User.ID == uuid.Nil || !key.User.IsActive {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.db.WithContext(ctx).Model(&key).UpdateColumn("last_used_at", now).Error; err != nil {
+			s.logger.WarnContext(ctx, "Failed to record API key use", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	return &key, nil
+}
+
+// RateLimitResult is the outcome of counting a request against a key's rate limit
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // Until the window resets
+}
+
+// Allow counts a request against the key's per-minute limit in a fixed window shared by every
+// instance. When Redis is optional and down requests are allowed.
+func (s *APIKeyService) Allow(ctx context.Context, key *models.APIKey) (*RateLimitResult, error) {
+	now := time.Now()
+	window := now.Unix() / 60
+	result := &RateLimitResult{
+		Allowed:    true,
+		Limit:      key.RateLimit,
+		Remaining:  key.RateLimit,
+		RetryAfter: time.Unix((window+1)*60, 0).Sub(now),
+	}
+
+	redisKey := fmt.Sprintf(apiKeyRateKey, key.ID, window)
+	var count *redis.IntCmd
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, redisKey)
+		pipe.Expire(ctx, redisKey, 2*time.Minute)
+		return nil
+	})
+	if database.IsRedisDegraded(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check API key rate limit: %w", err)
+	}
+
+	used := int(count.Val())
+	result.Allowed = used <= key.RateLimit
+	result.Remaining = max(key.RateLimit-used, 0)
+	return result, nil
+}
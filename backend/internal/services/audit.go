@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// AuditActor identifies who performed an audited operation
+type AuditActor struct {
+	UserID    *uuid.UUID
+	Email     string
+	Role      string
+	IPAddress string
+	UserAgent string
+}
+
+// AuditFilter narrows an audit log query; zero values are ignored
+type AuditFilter struct {
+	UserID     *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditService writes and queries the append-only audit trail
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService() *AuditService {
+	return &AuditService{
+		db: database.GetDB(),
+	}
+}
+
+// Record appends an audit entry; before and after are stored as JSON snapshots
+func (s *AuditService) Record(actor AuditActor, action, entityType, entityID string, before, after interface{}) error {
+	entry := &models.AuditLog{
+		UserID:     actor.UserID,
+		UserEmail:  actor.Email,
+		UserRole:   actor.Role,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     Snapshot(before),
+		After:      Snapshot(after),
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+	}
+
+	return s.db.Create(entry).Error
+}
+
+// List returns the audit entries matching the filter that were made by users in the viewer's
+// tenant, newest first, with the total match count
+func (s *AuditService) List(viewerID uuid.UUID, filter AuditFilter) ([]models.AuditLog, int64, error) {
+	query := actedInTenantOf(s.db.Model(&models.AuditLog{}), "user_id", viewerID)
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&logs).Error
+
+	return logs, total, err
+}
+
+// Snapshot converts an entity to a JSON map so later mutations do not change the recorded state
+func Snapshot(v interface{}) models.JSON {
+	if v == nil {
+		return nil
+	}
+	if snapshot, ok := v.(models.JSON); ok {
+		return snapshot
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot models.JSON
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return models.JSON{"value": string(data)}
+	}
+	return snapshot
+}
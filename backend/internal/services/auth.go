@@ -1,42 +1,87 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
 )
 
 type AuthService struct {
-	db        *gorm.DB
-	jwtSecret string
-	jwtExpiry time.Duration
+	db            *gorm.DB
+	redisClient   *redis.Client
+	jwtSecret     string
+	jwtExpiry     time.Duration
+	refreshExpiry time.Duration
+	logger        *slog.Logger
 }
 
 func NewAuthService(cfg *config.JWTConfig) *AuthService {
 	return &AuthService{
-		db:        database.GetDB(),
-		jwtSecret: cfg.Secret,
-		jwtExpiry: cfg.Expiry,
+		db:            database.GetDB(),
+		redisClient:   database.GetRedis(),
+		jwtSecret:     cfg.Secret,
+		jwtExpiry:     cfg.Expiry,
+		refreshExpiry: cfg.RefreshExpiry,
+		logger:        logging.Component("auth"),
 	}
 }
 
+// Redis keys for the access token deny-list
+const (
+	revokedTokenKey    = "auth:revoked_jti:%s"
+	userTokensAfterKey = "auth:tokens_valid_after_ms:%s"
+)
+
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
 }
 
 type LoginResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    int64       `json:"expires_in"` // Access token lifetime in seconds
+	User         models.User `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ClientInfo identifies the client a refresh token was issued to
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// ChangePasswordRequest replaces a user's password, including a temporary one set by an administrator
+type ChangePasswordRequest struct {
+	Email           string `json:"email" validate:"required,email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
 }
 
+// minPasswordLength is the shortest password a user may set
+const minPasswordLength = 6
+
 type RegisterRequest struct {
 	Email     string `json:"email" validate:"required,email"`
 	Password  string `json:"password" validate:"required,min=6"`
@@ -75,8 +120,8 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 	return &user, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(req LoginRequest) (*LoginResponse, error) {
+// Login authenticates a user and returns an access token and refresh token
+func (s *AuthService) Login(req LoginRequest, client ClientInfo) (*LoginResponse, error) {
 	var user models.User
 
 	// Find user
@@ -96,26 +141,219 @@ func (s *AuthService) Login(req LoginRequest) (*LoginResponse, error) {
 	if !user.IsActive {
 		return nil, errors.New("account is disabled")
 	}
+	if user.PasswordResetRequired {
+		return nil, errors.New("password reset required")
+	}
+
+	return s.issueTokens(s.db, &user, client)
+}
+
+// ChangePassword replaces a user's password after checking the current one, clearing a reset
+// required by an administrator, and signs the user out of every other session
+func (s *AuthService) ChangePassword(req ChangePasswordRequest) error {
+	var user models.User
+	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid credentials")
+		}
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return errors.New("invalid credentials")
+	}
+	if !user.IsActive {
+		return errors.New("account is disabled")
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		return fmt.Errorf("new password must be at least %d characters", minPasswordLength)
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("new password must differ from the current password")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"password":                string(hashedPassword),
+		"password_reset_required": false,
+	}).Error; err != nil {
+		return err
+	}
+
+	return s.RevokeUserSessions(user.ID)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token is rotated;
+// presenting an already rotated token revokes every session of the user.
+func (s *AuthService) Refresh(refreshToken string, client ClientInfo) (*LoginResponse, error) {
+	var response *LoginResponse
+	var reused *models.RefreshToken
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error; err != nil {
+			return errors.New("invalid refresh token")
+		}
+
+		if stored.RevokedAt != nil {
+			reused = &stored
+			return errors.New("refresh token has been revoked")
+		}
+		if time.Now().After(stored.ExpiresAt) {
+			return errors.New("refresh token has expired")
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return errors.New("invalid refresh token")
+		}
+		if !user.IsActive {
+			return errors.New("account is disabled")
+		}
+
+		issued, err := s.issueTokens(tx, &user, client)
+		if err != nil {
+			return err
+		}
+
+		var replacement models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(issued.RefreshToken)).First(&replacement).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&stored).Updates(map[string]interface{}{
+			"revoked_at":  now,
+			"replaced_by": replacement.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		response = issued
+		return nil
+	})
+
+	// A rotated token being replayed means it was likely stolen
+	if reused != nil && reused.ReplacedBy != nil {
+		s.RevokeUserSessions(reused.UserID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Logout revokes the caller's access token and, if supplied, their refresh token
+func (s *AuthService) Logout(jti string, expiresAt time.Time, refreshToken string) error {
+	if jti != "" {
+		if ttl := time.Until(expiresAt); ttl > 0 {
+			ctx := context.Background()
+			err := s.redisClient.Set(ctx, fmt.Sprintf(revokedTokenKey, jti), 1, ttl).Err()
+			if database.IsRedisDegraded(err) {
+				// The access token stays valid until it expires; the refresh token is still revoked
+				s.logger.Warn("Redis unavailable, access token not revoked", "jti", jti)
+			} else if err != nil {
+				return fmt.Errorf("failed to revoke access token: %w", err)
+			}
+		}
+	}
+
+	if refreshToken != "" {
+		now := time.Now()
+		err := s.db.Model(&models.RefreshToken{}).
+			Where("token_hash = ? AND revoked_at IS NULL", hashToken(refreshToken)).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeUserSessions invalidates every access and refresh token issued to a user so far
+func (s *AuthService) RevokeUserSessions(userID uuid.UUID) error {
+	ctx := context.Background()
+	key := fmt.Sprintf(userTokensAfterKey, userID)
+	err := s.redisClient.Set(ctx, key, time.Now().UnixMilli(), s.jwtExpiry).Err()
+	if database.IsRedisDegraded(err) {
+		s.logger.Warn("Redis unavailable, access tokens stay valid until they expire", "user_id", userID)
+	} else if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// SetUserActive enables or disables a user; disabling locks them out immediately
+func (s *AuthService) SetUserActive(userID uuid.UUID, active bool) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&user).Update("is_active", active).Error; err != nil {
+		return nil, err
+	}
+
+	if !active {
+		if err := s.RevokeUserSessions(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// issueTokens creates a signed access token and stores a new refresh token using db
+func (s *AuthService) issueTokens(db *gorm.DB, user *models.User, client ClientInfo) (*LoginResponse, error) {
+	token, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate JWT token
-	token, err := s.generateToken(&user)
+	refreshToken, err := generateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshExpiry),
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+	}
+	if err := db.Create(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
 	return &LoginResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwtExpiry.Seconds()),
+		User:         *user,
 	}, nil
 }
 
 // generateToken creates a JWT token for a user
 func (s *AuthService) generateToken(user *models.User) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID.String(),
 		"email":   user.Email,
 		"role":    user.Role,
-		"exp":     time.Now().Add(s.jwtExpiry).Unix(),
+		"jti":     uuid.New().String(),
+		"iat":     float64(now.UnixMilli()) / 1000, // To the millisecond, to compare with when sessions were revoked
+		"exp":     now.Add(s.jwtExpiry).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -135,9 +373,63 @@ func (s *AuthService) ValidateToken(tokenString string) (*jwt.MapClaims, error)
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if err := s.checkRevoked(claims); err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("invalid token")
+	return &claims, nil
+}
+
+// checkRevoked rejects tokens on the deny-list or issued before the user's sessions were revoked.
+// When Redis is optional and down the deny-list cannot be read and tokens are accepted.
+func (s *AuthService) checkRevoked(claims jwt.MapClaims) error {
+	ctx := context.Background()
+
+	if jti, _ := claims["jti"].(string); jti != "" {
+		exists, err := s.redisClient.Exists(ctx, fmt.Sprintf(revokedTokenKey, jti)).Result()
+		if database.IsRedisDegraded(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if exists > 0 {
+			return errors.New("token has been revoked")
+		}
+	}
+
+	userID, _ := claims["user_id"].(string)
+	validAfter, err := s.redisClient.Get(ctx, fmt.Sprintf(userTokensAfterKey, userID)).Int64()
+	if database.IsRedisDegraded(err) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if err == nil {
+		issuedAt, _ := claims["iat"].(float64)
+		if int64(math.Round(issuedAt*1000)) <= validAfter {
+			return errors.New("token has been revoked")
+		}
+	}
+
+	return nil
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+const (
+	// regulatoryBacktestDays is the one-year window of daily observations regulators expect
+	regulatoryBacktestDays = 250
+	// maxBacktestGapDays skips observations whose next forecast is further away than a long weekend
+	maxBacktestGapDays = 4
+)
+
+// dailyForecast is the last VaR forecast of a day with the portfolio value it was made on
+type dailyForecast struct {
+	Day            time.Time
+	Value          float64
+	TimeHorizon    int
+	PortfolioValue float64
+}
+
+// BacktestReport is a VaR backtest for one portfolio
+type BacktestReport struct {
+	PortfolioID uuid.UUID                  `json:"portfolio_id"`
+	From        time.Time                  `json:"from"`
+	To          time.Time                  `json:"to"`
+	Result      *calculator.BacktestResult `json:"result"`
+	Warnings    []string                   `json:"warnings"`
+}
+
+type BacktestService struct {
+	db *gorm.DB
+}
+
+func NewBacktestService() *BacktestService {
+	return &BacktestService{
+		db: database.GetDB(),
+	}
+}
+
+// BacktestVaR compares the stored daily VaR forecasts at a confidence level with the change in
+// portfolio value over the following day. Forecasts for longer horizons are scaled to one day by
+// the square root of time. The value change includes any cash flows in the portfolio.
+func (s *BacktestService) BacktestVaR(portfolioID uuid.UUID, confidence float64, days int) (*BacktestReport, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+
+	var forecasts []dailyForecast
+	err := s.db.Raw(`SELECT DISTINCT ON (date_trunc('day', calculated_at))
+			date_trunc('day', calculated_at) AS day,
+			value,
+			time_horizon,
+			COALESCE((details->>'portfolio_value')::numeric, 0) AS portfolio_value
+		FROM risk_metrics
+		WHERE portfolio_id = ? AND metric_type = 'VAR' AND calculated_at BETWEEN ? AND ?
+			AND ROUND(confidence_level, 4) = ROUND(?::numeric, 4)
+		ORDER BY date_trunc('day', calculated_at), calculated_at DESC`,
+		portfolioID, from, to, confidence).Scan(&forecasts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	observations := make([]calculator.BacktestObservation, 0, len(forecasts))
+	skipped := 0
+	for i := 0; i+1 < len(forecasts); i++ {
+		current, next := forecasts[i], forecasts[i+1]
+		if current.PortfolioValue <= 0 || next.PortfolioValue <= 0 {
+			skipped++
+			continue
+		}
+		if next.Day.Sub(current.Day) > maxBacktestGapDays*24*time.Hour {
+			skipped++
+			continue
+		}
+
+		horizon := current.TimeHorizon
+		if horizon < 1 {
+			horizon = 1
+		}
+		observations = append(observations, calculator.BacktestObservation{
+			Date: current.Day,
+			VaR:  current.Value / math.Sqrt(float64(horizon)),
+			PnL:  next.PortfolioValue - current.PortfolioValue,
+		})
+	}
+
+	report := &BacktestReport{
+		PortfolioID: portfolioID,
+		From:        from,
+		To:          to,
+		Result:      calculator.NewBacktestCalculator(confidence).Backtest(observations),
+		Warnings:    []string{},
+	}
+	if len(observations) < regulatoryBacktestDays {
+		report.Warnings = append(report.Warnings, "Fewer than 250 daily observations; regulatory backtests use a full year of data")
+	}
+	if skipped > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d day(s) skipped because of missing portfolio values or gaps between forecasts", skipped))
+	}
+	return report, nil
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/batch"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// batchAlertSource is used to avoid raising another batch failure alert for a portfolio while one
+// is still active
+const batchAlertSource = "BATCH_ORCHESTRATOR"
+
+// End-of-day batch jobs
+const (
+	eodJobCorporate   = "corporate_actions"
+	eodJobLiquidity   = "liquidity_classification"
+	eodJobNAV         = "nav_snapshots"
+	eodJobRisk        = "risk_snapshots"
+	eodJobDrawdown    = "drawdown_checks"
+	eodJobRules       = "compliance_rules"
+	eodJobGuidelines  = "investment_guidelines"
+	eodJobAMLSweep    = "aml_sweep"
+	eodJobDailyReport = "daily_reports"
+)
+
+// BatchService runs the end-of-day batch pipeline, records the outcome of each run and its jobs,
+// and raises an alert on every portfolio when a run fails
+type BatchService struct {
+	db            *gorm.DB
+	cfg           *config.BatchConfig
+	riskCfg       *config.RiskConfig
+	complianceCfg *config.ComplianceConfig
+	alertService  *AlertService
+	logger        *slog.Logger
+}
+
+func NewBatchService(cfg *config.BatchConfig, riskCfg *config.RiskConfig, complianceCfg *config.ComplianceConfig) *BatchService {
+	return &BatchService{
+		db:            database.GetDB(),
+		cfg:           cfg,
+		riskCfg:       riskCfg,
+		complianceCfg: complianceCfg,
+		alertService:  NewAlertService(),
+		logger:        logging.Component("batch"),
+	}
+}
+
+// eodPipeline builds the end-of-day pipeline for a business date. Corporate actions going ex are
+// applied first, then valuations, the risk calculations and compliance checks that read them, and
+// the daily reports last.
+func (s *BatchService) eodPipeline(businessDate time.Time) (*batch.Pipeline, error) {
+	return batch.NewPipeline(models.BatchPipelineEOD,
+		batch.Job{
+			Name: eodJobCorporate,
+			Run: func(ctx context.Context) (string, error) {
+				applied, err := NewCorporateActionService().ApplyDue(ctx, businessDate)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d corporate actions applied", applied), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobLiquidity,
+			DependsOn: []string{eodJobCorporate},
+			Run: func(ctx context.Context) (string, error) {
+				run, err := NewLiquidityService().ClassifyAll(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d positions classified, %d changed, %d unclassified", run.Classified, run.Changed, run.Unclassified), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobNAV,
+			DependsOn: []string{eodJobCorporate},
+			Run: func(ctx context.Context) (string, error) {
+				recorded, err := NewPortfolioSnapshotService().SnapshotAll(ctx, businessDate)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d portfolios snapshotted", recorded), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobRisk,
+			DependsOn: []string{eodJobLiquidity, eodJobNAV},
+			Run: func(ctx context.Context) (string, error) {
+				recorded, err := NewRiskHistoryService(s.riskCfg).Snapshot(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d portfolios recorded", recorded), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobDrawdown,
+			DependsOn: []string{eodJobNAV},
+			Run: func(ctx context.Context) (string, error) {
+				checked, err := NewDrawdownService().CheckAll(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d portfolios checked", checked), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobRules,
+			DependsOn: []string{eodJobNAV},
+			Run: func(ctx context.Context) (string, error) {
+				result, err := NewComplianceRuleService().EvaluateAll(ctx, nil, businessDate)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d rules evaluated, %d breaches", result.RulesEvaluated, len(result.Breaches)), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobGuidelines,
+			DependsOn: []string{eodJobNAV},
+			Run: func(ctx context.Context) (string, error) {
+				result, err := NewInvestmentGuidelineService().EvaluateAll(ctx, nil)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d portfolios evaluated, %d in breach", result.PortfoliosEvaluated, len(result.Portfolios)), nil
+			},
+		},
+		batch.Job{
+			Name: eodJobAMLSweep,
+			Run: func(ctx context.Context) (string, error) {
+				result, err := NewAMLService().Sweep(ctx, s.complianceCfg.AMLSweepDays, nil, uuid.Nil)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d transactions evaluated, %d newly flagged", result.TransactionsEvaluated, len(result.NewlyFlagged)), nil
+			},
+		},
+		batch.Job{
+			Name:      eodJobDailyReport,
+			DependsOn: []string{eodJobRisk, eodJobDrawdown, eodJobRules, eodJobGuidelines, eodJobAMLSweep},
+			Run:       s.dailyReports(),
+		},
+	)
+}
+
+// dailyReports returns the job generating every portfolio's daily risk report. A retry only
+// generates the reports that failed before.
+func (s *BatchService) dailyReports() func(ctx context.Context) (string, error) {
+	generated := make(map[uuid.UUID]bool)
+	return func(ctx context.Context) (string, error) {
+		var portfolioIDs []uuid.UUID
+		if err := s.db.WithContext(ctx).Model(&models.Portfolio{}).Order("created_at").Pluck("id", &portfolioIDs).Error; err != nil {
+			return "", err
+		}
+
+		reportService := NewReportService()
+		failed := 0
+		for _, portfolioID := range portfolioIDs {
+			if generated[portfolioID] {
+				continue
+			}
+			_, err := reportService.GenerateReport(GenerateReportRequest{
+				PortfolioID: portfolioID,
+				ReportType:  models.ReportTypeDailyRisk,
+				Format:      s.cfg.ReportFormat,
+			})
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Daily risk report failed", "portfolio_id", portfolioID, "error", err)
+				failed++
+				continue
+			}
+			generated[portfolioID] = true
+		}
+
+		if failed > 0 {
+			return "", fmt.Errorf("%d of %d daily risk reports failed", failed, len(portfolioIDs))
+		}
+		return fmt.Sprintf("%d reports generated", len(generated)), nil
+	}
+}
+
+// StartScheduler runs the end-of-day batch once a day at the given UTC hour until ctx is
+// cancelled
+func (s *BatchService) StartScheduler(ctx context.Context, hour int) {
+	for {
+		if !sleepUntil(ctx, nextDailyRun(time.Now(), hour)) {
+			return
+		}
+
+		runCtx := logging.WithNewRequestID(ctx)
+		if _, err := s.RunEOD(runCtx, models.BatchSourceSchedule, nil); err != nil {
+			s.logger.ErrorContext(runCtx, "End-of-day batch could not start", "error", err)
+		}
+	}
+}
+
+// StartEOD starts the end-of-day batch in the background and returns the run, RUNNING; poll it
+// with GetRun until it finishes
+func (s *BatchService) StartEOD(userID uuid.UUID) (*models.BatchRun, error) {
+	ctx := logging.WithNewRequestID(context.Background())
+	run, pipeline, err := s.createRun(ctx, models.BatchSourceManual, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run goes on changing in the background
+	started := *run
+	started.Jobs = slices.Clone(run.Jobs)
+
+	go s.execute(ctx, run, pipeline)
+	return &started, nil
+}
+
+// RunEOD runs the end-of-day batch and returns the finished run. Cancelling ctx skips the jobs
+// that have not started yet.
+func (s *BatchService) RunEOD(ctx context.Context, source string, userID *uuid.UUID) (*models.BatchRun, error) {
+	run, pipeline, err := s.createRun(ctx, source, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.execute(ctx, run, pipeline)
+	return run, nil
+}
+
+// createRun records a RUNNING run of the end-of-day pipeline for today (UTC) with its jobs
+// PENDING, unless a run is already in progress
+func (s *BatchService) createRun(ctx context.Context, source string, userID *uuid.UUID) (*models.BatchRun, *batch.Pipeline, error) {
+	now := time.Now().UTC()
+	businessDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	pipeline, err := s.eodPipeline(businessDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var running int64
+	if err := s.db.WithContext(ctx).Model(&models.BatchRun{}).
+		Where("pipeline = ? AND status = ?", pipeline.Name(), models.BatchStatusRunning).
+		Count(&running).Error; err != nil {
+		return nil, nil, err
+	}
+	if running > 0 {
+		return nil, nil, apperror.Conflict("An end-of-day batch is already running")
+	}
+
+	run := &models.BatchRun{
+		Pipeline:     pipeline.Name(),
+		BusinessDate: businessDate,
+		Source:       source,
+		Status:       models.BatchStatusRunning,
+		TriggeredBy:  userID,
+		StartedAt:    now,
+	}
+	for i, job := range pipeline.Jobs() {
+		run.Jobs = append(run.Jobs, models.BatchJobRun{
+			Name:      job.Name,
+			Position:  i,
+			DependsOn: append([]string{}, job.DependsOn...),
+			Status:    models.BatchStatusPending,
+		})
+	}
+	if err := s.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, nil, err
+	}
+	return run, pipeline, nil
+}
+
+// execute runs the pipeline, saving each job's progress as it goes, then records the run's
+// outcome and alerts on failure
+func (s *BatchService) execute(ctx context.Context, run *models.BatchRun, pipeline *batch.Pipeline) {
+	s.logger.InfoContext(ctx, "Batch run started", "run_id", run.ID, "pipeline", run.Pipeline, "source", run.Source)
+
+	jobIDs := make(map[string]uuid.UUID, len(run.Jobs))
+	for _, job := range run.Jobs {
+		jobIDs[job.Name] = job.ID
+	}
+
+	// Progress is saved even after ctx is cancelled, so a stopped run records where it stopped
+	saveCtx := context.WithoutCancel(ctx)
+	results := pipeline.Run(ctx, batch.Options{
+		Retries:    s.cfg.JobRetries,
+		RetryDelay: s.cfg.RetryDelay,
+		JobTimeout: s.cfg.JobTimeout,
+	}, func(result batch.Result) {
+		updates := map[string]interface{}{
+			"status":      result.Status,
+			"attempts":    result.Attempts,
+			"output":      result.Output,
+			"error":       result.Error,
+			"started_at":  result.StartedAt,
+			"finished_at": result.FinishedAt,
+		}
+		if err := s.db.WithContext(saveCtx).Model(&models.BatchJobRun{}).Where("id = ?", jobIDs[result.Name]).Updates(updates).Error; err != nil {
+			s.logger.ErrorContext(saveCtx, "Failed to save batch job progress", "run_id", run.ID, "job", result.Name, "error", err)
+		}
+
+		switch result.Status {
+		case models.BatchStatusSucceeded:
+			metrics.BatchJobRuns.With(run.Pipeline, result.Name, result.Status).Inc()
+			s.logger.InfoContext(saveCtx, "Batch job succeeded", "run_id", run.ID, "job", result.Name,
+				"attempts", result.Attempts, "output", result.Output)
+		case models.BatchStatusFailed, models.BatchStatusSkipped:
+			metrics.BatchJobRuns.With(run.Pipeline, result.Name, result.Status).Inc()
+			s.logger.ErrorContext(saveCtx, "Batch job did not succeed", "run_id", run.ID, "job", result.Name,
+				"status", result.Status, "attempts", result.Attempts, "error", result.Error)
+		}
+	})
+
+	var unsuccessful []string
+	for i, result := range results {
+		run.Jobs[i] = models.BatchJobRun{
+			ID:         jobIDs[result.Name],
+			RunID:      run.ID,
+			Name:       result.Name,
+			Position:   i,
+			DependsOn:  run.Jobs[i].DependsOn,
+			Status:     result.Status,
+			Attempts:   result.Attempts,
+			Output:     result.Output,
+			Error:      result.Error,
+			StartedAt:  result.StartedAt,
+			FinishedAt: result.FinishedAt,
+		}
+		if result.Status != models.BatchStatusSucceeded {
+			unsuccessful = append(unsuccessful, result.Name)
+		}
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.BatchStatusSucceeded
+	if len(unsuccessful) > 0 {
+		run.Status = models.BatchStatusFailed
+		run.Error = "Jobs did not succeed: " + strings.Join(unsuccessful, ", ")
+	}
+	err := s.db.WithContext(saveCtx).Model(&models.BatchRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error
+	if err != nil {
+		s.logger.ErrorContext(saveCtx, "Failed to save batch run", "run_id", run.ID, "error", err)
+	}
+
+	if run.Status == models.BatchStatusFailed {
+		s.logger.ErrorContext(saveCtx, "Batch run failed", "run_id", run.ID, "pipeline", run.Pipeline,
+			"jobs", unsuccessful, "duration", now.Sub(run.StartedAt))
+		s.raiseFailureAlerts(saveCtx, run, unsuccessful)
+		return
+	}
+	s.logger.InfoContext(saveCtx, "Batch run succeeded", "run_id", run.ID, "pipeline", run.Pipeline,
+		"duration", now.Sub(run.StartedAt))
+}
+
+// raiseFailureAlerts raises an alert on every portfolio whose end-of-day figures the failed run
+// left missing or stale, unless one from an earlier run is still active
+func (s *BatchService) raiseFailureAlerts(ctx context.Context, run *models.BatchRun, unsuccessful []string) {
+	var portfolioIDs []uuid.UUID
+	err := s.db.WithContext(ctx).Model(&models.Portfolio{}).
+		Where("id NOT IN (?)", s.db.Model(&models.Alert{}).Select("portfolio_id").
+			Where("source = ? AND status = ?", batchAlertSource, "ACTIVE")).
+		Pluck("id", &portfolioIDs).Error
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load portfolios to alert of the failed batch", "run_id", run.ID, "error", err)
+		return
+	}
+
+	for _, portfolioID := range portfolioIDs {
+		alert := &models.Alert{
+			PortfolioID: portfolioID,
+			AlertType:   "BATCH_FAILURE",
+			Severity:    "HIGH",
+			Title:       "End-of-Day Batch Failed",
+			Description: fmt.Sprintf("End-of-day processing for %s did not complete (%s); snapshots, risk figures or reports may be missing",
+				run.BusinessDate.Format("2006-01-02"), strings.Join(unsuccessful, ", ")),
+			Source: batchAlertSource,
+			Status: "ACTIVE",
+			TriggeredBy: models.JSON{
+				"run_id":        run.ID,
+				"pipeline":      run.Pipeline,
+				"business_date": run.BusinessDate.Format("2006-01-02"),
+				"jobs":          unsuccessful,
+			},
+		}
+		if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create batch failure alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+}
+
+// FailInterrupted marks the runs a previous process left RUNNING as failed, with their
+// unfinished jobs, so that a new run can start. It returns how many runs were marked.
+func (s *BatchService) FailInterrupted(ctx context.Context) (int64, error) {
+	var runIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.BatchRun{}).Where("status = ?", models.BatchStatusRunning).Pluck("id", &runIDs).Error; err != nil {
+		return 0, err
+	}
+	if len(runIDs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		jobs := tx.Model(&models.BatchJobRun{}).Where("run_id IN ?", runIDs)
+		if err := jobs.Session(&gorm.Session{}).Where("status = ?", models.BatchStatusRunning).
+			Updates(map[string]interface{}{"status": models.BatchStatusFailed, "error": "interrupted by a restart", "finished_at": now}).Error; err != nil {
+			return err
+		}
+		if err := jobs.Session(&gorm.Session{}).Where("status = ?", models.BatchStatusPending).
+			Updates(map[string]interface{}{"status": models.BatchStatusSkipped, "error": "run stopped before the job started", "finished_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.BatchRun{}).Where("id IN ?", runIDs).
+			Updates(map[string]interface{}{"status": models.BatchStatusFailed, "error": "Interrupted by a restart", "finished_at": now}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(runIDs)), nil
+}
+
+// ListRuns returns a page of batch runs, without their jobs, and the total match count
+func (s *BatchService) ListRuns(spec pagination.Spec, params pagination.Params) ([]models.BatchRun, int64, error) {
+	var runs []models.BatchRun
+	total, err := pagination.Find(s.db.Model(&models.BatchRun{}), spec, params, &runs)
+	return runs, total, err
+}
+
+// GetRun returns a batch run with its jobs in pipeline order
+func (s *BatchService) GetRun(runID uuid.UUID) (*models.BatchRun, error) {
+	var run models.BatchRun
+	err := s.db.Preload("Jobs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).First(&run, "id = ?", runID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Batch run not found")
+		}
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// BatchReadService loads the records of many portfolios in one query each, keyed by portfolio,
+// for the GraphQL API's loaders. Callers must verify access to the portfolios.
+type BatchReadService struct {
+	db            *gorm.DB
+	accessService *AccessService
+}
+
+func NewBatchReadService() *BatchReadService {
+	return &BatchReadService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+	}
+}
+
+// AccessiblePortfolios returns the portfolios a user owns, supervises or shares through a team, or all
+// of them for global roles
+func (s *BatchReadService) AccessiblePortfolios(ctx context.Context, userID uuid.UUID, role string) ([]models.Portfolio, error) {
+	portfolios := []models.Portfolio{}
+	query := s.accessService.ScopeQuery(s.db.WithContext(ctx), "id", userID, role)
+	err := query.Order("created_at ASC").Find(&portfolios).Error
+	return portfolios, err
+}
+
+// Alerts returns the latest alerts of the portfolios a user can see, optionally filtered by status and severity
+func (s *BatchReadService) Alerts(ctx context.Context, userID uuid.UUID, role, status, severity string, limit int) ([]models.Alert, error) {
+	alerts := []models.Alert{}
+	query := s.accessService.ScopeQuery(s.db.WithContext(ctx), "portfolio_id", userID, role)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&alerts).Error
+	return alerts, err
+}
+
+// Portfolios returns portfolios by ID
+func (s *BatchReadService) Portfolios(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&portfolios).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.Portfolio, len(portfolios))
+	for i := range portfolios {
+		byID[portfolios[i].ID] = &portfolios[i]
+	}
+	return byID, nil
+}
+
+// Users returns users by ID
+func (s *BatchReadService) Users(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+	var users []models.User
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	return byID, nil
+}
+
+// Positions returns the positions of each portfolio, largest first
+func (s *BatchReadService) Positions(ctx context.Context, portfolioIDs []uuid.UUID) (map[uuid.UUID][]models.Position, error) {
+	var positions []models.Position
+	err := s.db.WithContext(ctx).Where("portfolio_id IN ?", portfolioIDs).
+		Order("market_value DESC").
+		Find(&positions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPortfolio := make(map[uuid.UUID][]models.Position, len(portfolioIDs))
+	for _, position := range positions {
+		byPortfolio[position.PortfolioID] = append(byPortfolio[position.PortfolioID], position)
+	}
+	return byPortfolio, nil
+}
+
+// RecentTransactions returns up to limit of the latest transactions of each portfolio
+func (s *BatchReadService) RecentTransactions(ctx context.Context, portfolioIDs []uuid.UUID, limit int) (map[uuid.UUID][]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := s.db.WithContext(ctx).Raw(`SELECT * FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY portfolio_id ORDER BY created_at DESC) AS row_num
+			FROM transactions WHERE portfolio_id IN ? AND deleted_at IS NULL
+		) ranked WHERE row_num <= ? ORDER BY created_at DESC`, portfolioIDs, limit).
+		Scan(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPortfolio := make(map[uuid.UUID][]models.Transaction, len(portfolioIDs))
+	for _, transaction := range transactions {
+		byPortfolio[transaction.PortfolioID] = append(byPortfolio[transaction.PortfolioID], transaction)
+	}
+	return byPortfolio, nil
+}
+
+// LatestRiskMetrics returns the most recent metric of each type calculated for each portfolio
+func (s *BatchReadService) LatestRiskMetrics(ctx context.Context, portfolioIDs []uuid.UUID) (map[uuid.UUID][]models.RiskMetric, error) {
+	var metrics []models.RiskMetric
+	err := s.db.WithContext(ctx).Raw(`SELECT DISTINCT ON (portfolio_id, metric_type) * FROM risk_metrics
+		WHERE portfolio_id IN ? ORDER BY portfolio_id, metric_type, calculated_at DESC`, portfolioIDs).
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPortfolio := make(map[uuid.UUID][]models.RiskMetric, len(portfolioIDs))
+	for _, metric := range metrics {
+		byPortfolio[metric.PortfolioID] = append(byPortfolio[metric.PortfolioID], metric)
+	}
+	return byPortfolio, nil
+}
+
+// RecentAlerts returns up to limit of the latest alerts of each portfolio, optionally with the given status
+func (s *BatchReadService) RecentAlerts(ctx context.Context, portfolioIDs []uuid.UUID, status string, limit int) (map[uuid.UUID][]models.Alert, error) {
+	var alerts []models.Alert
+	err := s.db.WithContext(ctx).Raw(`SELECT * FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY portfolio_id ORDER BY created_at DESC) AS row_num
+			FROM alerts WHERE portfolio_id IN ? AND (? = '' OR status = ?) AND deleted_at IS NULL
+		) ranked WHERE row_num <= ? ORDER BY created_at DESC`, portfolioIDs, status, status, limit).
+		Scan(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPortfolio := make(map[uuid.UUID][]models.Alert, len(portfolioIDs))
+	for _, alert := range alerts {
+		byPortfolio[alert.PortfolioID] = append(byPortfolio[alert.PortfolioID], alert)
+	}
+	return byPortfolio, nil
+}
@@ -0,0 +1,752 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// MaxEvidenceSize caps evidence uploads; it matches Fiber's default request body limit
+const MaxEvidenceSize = 4 * 1024 * 1024
+
+var (
+	ErrCaseNotFound          = errors.New("case not found")
+	ErrCaseClosed            = errors.New("case is closed")
+	ErrInvalidCaseTransition = errors.New("invalid case status transition")
+)
+
+// caseTransitions lists the statuses each case status may move to
+var caseTransitions = map[string][]string{
+	models.CaseStatusOpen:          {models.CaseStatusInvestigating, models.CaseStatusDismissed},
+	models.CaseStatusInvestigating: {models.CaseStatusFiled, models.CaseStatusDismissed},
+}
+
+var casePriorities = map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true, "CRITICAL": true}
+
+// EscalateCaseRequest opens a case from an alert, a set of transactions, or both
+type EscalateCaseRequest struct {
+	Title          string
+	Description    string
+	Priority       string
+	AlertID        *uuid.UUID
+	TransactionIDs []uuid.UUID
+	AssigneeID     *uuid.UUID
+}
+
+// CaseUpdate holds editable case fields; nil fields are left unchanged
+type CaseUpdate struct {
+	Title       *string
+	Description *string
+	Priority    *string
+	Narrative   *string
+}
+
+// CaseTransition moves a case to a new status
+type CaseTransition struct {
+	Status          string
+	Reason          string // Required to dismiss
+	Narrative       string // Replaces the stored narrative when set
+	FilingReference string
+}
+
+type CaseService struct {
+	db            *gorm.DB
+	accessService *AccessService
+}
+
+func NewCaseService() *CaseService {
+	return &CaseService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+	}
+}
+
+// CanInvestigate reports whether a role may be assigned cases
+func CanInvestigate(role string) bool {
+	return role == models.RoleAdmin || role == models.RoleComplianceOfficer
+}
+
+// ListCases returns a page of the cases in the user's tenant and the total match count
+func (s *CaseService) ListCases(userID uuid.UUID, spec pagination.Spec, params pagination.Params) ([]models.Case, int64, error) {
+	var cases []models.Case
+	query := casesInTenantOf(s.db.Model(&models.Case{}), userID)
+	total, err := pagination.Find(query, spec, params, &cases, "Assignee")
+	return cases, total, err
+}
+
+// GetCase returns a case in the user's tenant with its transactions, timeline and evidence metadata
+func (s *CaseService) GetCase(caseID, userID uuid.UUID) (*models.Case, error) {
+	var c models.Case
+	err := casesInTenantOf(s.db, userID).
+		Preload("Assignee").
+		Preload("Alert").
+		Preload("Transactions", func(db *gorm.DB) *gorm.DB { return db.Order("transactions.created_at ASC") }).
+		Preload("Transactions.Counterparty").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB { return db.Order("case_notes.created_at ASC") }).
+		Preload("Notes.Author").
+		Preload("Evidence", func(db *gorm.DB) *gorm.DB { return db.Omit("content").Order("case_evidences.created_at ASC") }).
+		First(&c, caseID).Error
+	if err != nil {
+		return nil, ErrCaseNotFound
+	}
+	return &c, nil
+}
+
+// EscalateCase opens a case for flagged transactions. Escalating an alert pulls in the transaction
+// that triggered it and marks the alert ESCALATED.
+func (s *CaseService) EscalateCase(req EscalateCaseRequest, userID uuid.UUID, role string) (*models.Case, error) {
+	var alert *models.Alert
+	transactionIDs := req.TransactionIDs
+
+	if req.AlertID != nil {
+		alert = &models.Alert{}
+		if err := s.db.First(alert, *req.AlertID).Error; err != nil {
+			return nil, errors.New("alert not found")
+		}
+		if err := s.checkPortfolioAccess(userID, role, alert.PortfolioID, "alert not found"); err != nil {
+			return nil, err
+		}
+		if id, ok := alertTransactionID(alert); ok && !containsUUID(transactionIDs, id) {
+			transactionIDs = append(transactionIDs, id)
+		}
+	}
+
+	if alert == nil && len(transactionIDs) == 0 {
+		return nil, errors.New("an alert_id or at least one transaction_id is required")
+	}
+
+	transactions, err := s.loadTransactions(transactionIDs, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := strings.ToUpper(req.Priority)
+	if priority == "" {
+		priority = "MEDIUM"
+		if alert != nil && casePriorities[alert.Severity] {
+			priority = alert.Severity
+		}
+	}
+	if !casePriorities[priority] {
+		return nil, errors.New("invalid priority, use LOW, MEDIUM, HIGH or CRITICAL")
+	}
+
+	if req.AssigneeID != nil {
+		if _, err := s.loadInvestigator(*req.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		if alert != nil {
+			title = alert.Title
+		} else {
+			title = fmt.Sprintf("Suspicious activity: %d transaction(s)", len(transactions))
+		}
+	}
+
+	c := &models.Case{
+		CaseNumber:  newCaseNumber(),
+		Title:       title,
+		Description: req.Description,
+		Status:      models.CaseStatusOpen,
+		Priority:    priority,
+		AlertID:     req.AlertID,
+		AssigneeID:  req.AssigneeID,
+		CreatedBy:   &userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		if err := linkCaseTransactions(tx, c.ID, transactions); err != nil {
+			return err
+		}
+
+		body := fmt.Sprintf("Case opened with %d transaction(s)", len(transactions))
+		if alert != nil {
+			body = fmt.Sprintf("Escalated from alert %q (%s)", alert.Title, alert.ID)
+		}
+		if err := addCaseNote(tx, c.ID, &userID, models.CaseNoteEscalation, body); err != nil {
+			return err
+		}
+
+		if alert != nil {
+			return tx.Model(&models.Alert{}).Where("id = ?", alert.ID).Updates(map[string]interface{}{
+				"status":     "ESCALATED",
+				"updated_at": time.Now(),
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCase(c.ID, userID)
+}
+
+// UpdateCase edits an open case's details and SAR narrative
+func (s *CaseService) UpdateCase(caseID, userID uuid.UUID, update CaseUpdate) (*models.Case, error) {
+	c, err := s.openCase(caseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Title != nil {
+		if strings.TrimSpace(*update.Title) == "" {
+			return nil, errors.New("title cannot be empty")
+		}
+		c.Title = *update.Title
+	}
+	if update.Description != nil {
+		c.Description = *update.Description
+	}
+	if update.Priority != nil {
+		priority := strings.ToUpper(*update.Priority)
+		if !casePriorities[priority] {
+			return nil, errors.New("invalid priority, use LOW, MEDIUM, HIGH or CRITICAL")
+		}
+		c.Priority = priority
+	}
+	if update.Narrative != nil {
+		c.Narrative = *update.Narrative
+	}
+
+	if err := s.db.Save(c).Error; err != nil {
+		return nil, err
+	}
+	return s.GetCase(caseID, userID)
+}
+
+// AssignCase hands an open case to an investigator
+func (s *CaseService) AssignCase(caseID, assigneeID, userID uuid.UUID) (*models.Case, error) {
+	c, err := s.openCase(caseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignee, err := s.loadInvestigator(assigneeID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(c).Updates(map[string]interface{}{
+			"assignee_id": assigneeID,
+			"updated_at":  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		body := fmt.Sprintf("Assigned to %s %s (%s)", assignee.FirstName, assignee.LastName, assignee.Email)
+		return addCaseNote(tx, caseID, &userID, models.CaseNoteAssignment, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCase(caseID, userID)
+}
+
+// TransitionCase moves a case along its workflow. Starting an investigation assigns the case to the
+// caller if nobody has it; filing needs a narrative; dismissing needs a reason. Closing a case
+// resolves the alert it was escalated from.
+func (s *CaseService) TransitionCase(caseID uuid.UUID, transition CaseTransition, userID uuid.UUID) (*models.Case, error) {
+	c, err := s.openCase(caseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := strings.ToUpper(transition.Status)
+	if !canTransitionCase(c.Status, status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidCaseTransition, c.Status, status)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}
+	body := fmt.Sprintf("Status changed from %s to %s", c.Status, status)
+	var alertResolution string
+
+	switch status {
+	case models.CaseStatusInvestigating:
+		if c.AssigneeID == nil {
+			updates["assignee_id"] = userID
+		}
+	case models.CaseStatusFiled:
+		narrative := c.Narrative
+		if transition.Narrative != "" {
+			narrative = transition.Narrative
+			updates["narrative"] = narrative
+		}
+		if strings.TrimSpace(narrative) == "" {
+			return nil, errors.New("a narrative is required to file a SAR")
+		}
+		updates["filing_reference"] = transition.FilingReference
+		updates["filed_by"] = userID
+		updates["filed_at"] = now
+		if transition.FilingReference != "" {
+			body += fmt.Sprintf(" (filing reference %s)", transition.FilingReference)
+		}
+		alertResolution = fmt.Sprintf("SAR filed under case %s", c.CaseNumber)
+	case models.CaseStatusDismissed:
+		if strings.TrimSpace(transition.Reason) == "" {
+			return nil, errors.New("a reason is required to dismiss a case")
+		}
+		updates["dismissal_reason"] = transition.Reason
+		updates["dismissed_by"] = userID
+		updates["dismissed_at"] = now
+		body += ": " + transition.Reason
+		alertResolution = fmt.Sprintf("Case %s dismissed: %s", c.CaseNumber, transition.Reason)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(c).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := addCaseNote(tx, caseID, &userID, models.CaseNoteStatusChange, body); err != nil {
+			return err
+		}
+		if alertResolution != "" && c.AlertID != nil {
+			return tx.Model(&models.Alert{}).Where("id = ?", *c.AlertID).Updates(map[string]interface{}{
+				"status":      "RESOLVED",
+				"resolved_by": userID,
+				"resolved_at": now,
+				"resolution":  alertResolution,
+				"updated_at":  now,
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if alertResolution != "" && c.AlertID != nil {
+		dispatchResolved(s.db, *c.AlertID)
+	}
+	return s.GetCase(caseID, userID)
+}
+
+// AddNote adds an investigation note to an open case
+func (s *CaseService) AddNote(caseID, userID uuid.UUID, body string) (*models.CaseNote, error) {
+	if _, err := s.openCase(caseID, userID); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, errors.New("note body is required")
+	}
+
+	note := &models.CaseNote{
+		CaseID:   caseID,
+		AuthorID: &userID,
+		Kind:     models.CaseNoteComment,
+		Body:     body,
+	}
+	if err := s.db.Create(note).Error; err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// AddTransactions links more transactions to an open case
+func (s *CaseService) AddTransactions(caseID uuid.UUID, transactionIDs []uuid.UUID, userID uuid.UUID, role string) (*models.Case, error) {
+	c, err := s.openCase(caseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactionIDs) == 0 {
+		return nil, errors.New("at least one transaction_id is required")
+	}
+
+	transactions, err := s.loadTransactions(transactionIDs, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := linkCaseTransactions(tx, c.ID, transactions); err != nil {
+			return err
+		}
+		body := fmt.Sprintf("Linked %d transaction(s)", len(transactions))
+		return addCaseNote(tx, caseID, &userID, models.CaseNoteTransaction, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCase(caseID, userID)
+}
+
+// AddEvidence attaches a file to an open case, recording its SHA-256 digest for chain of custody
+func (s *CaseService) AddEvidence(caseID, userID uuid.UUID, fileName, contentType, description string, content []byte) (*models.CaseEvidence, error) {
+	if _, err := s.openCase(caseID, userID); err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, errors.New("evidence file is empty")
+	}
+	if len(content) > MaxEvidenceSize {
+		return nil, fmt.Errorf("evidence file exceeds %d bytes", MaxEvidenceSize)
+	}
+
+	digest := sha256.Sum256(content)
+	evidence := &models.CaseEvidence{
+		CaseID:      caseID,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(digest[:]),
+		Description: description,
+		Content:     content,
+		UploadedBy:  &userID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(evidence).Error; err != nil {
+			return err
+		}
+		body := fmt.Sprintf("Attached %s (sha256 %s)", fileName, evidence.SHA256)
+		return addCaseNote(tx, caseID, &userID, models.CaseNoteEvidence, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
+
+// GetEvidence returns an evidence record of a case in the user's tenant including its content
+func (s *CaseService) GetEvidence(caseID, evidenceID, userID uuid.UUID) (*models.CaseEvidence, error) {
+	var evidence models.CaseEvidence
+	cases := casesInTenantOf(s.db.Model(&models.Case{}).Select("id"), userID)
+	err := s.db.Where("id = ? AND case_id = ? AND case_id IN (?)", evidenceID, caseID, cases).First(&evidence).Error
+	if err != nil {
+		return nil, errors.New("evidence not found")
+	}
+	return &evidence, nil
+}
+
+// openCase loads a case in the user's tenant that can still be changed
+func (s *CaseService) openCase(caseID, userID uuid.UUID) (*models.Case, error) {
+	var c models.Case
+	if err := casesInTenantOf(s.db, userID).First(&c, caseID).Error; err != nil {
+		return nil, ErrCaseNotFound
+	}
+	if c.IsClosed() {
+		return nil, ErrCaseClosed
+	}
+	return &c, nil
+}
+
+// loadTransactions fetches transactions by ID, rejecting any the user cannot see
+func (s *CaseService) loadTransactions(ids []uuid.UUID, userID uuid.UUID, role string) ([]models.Transaction, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var transactions []models.Transaction
+	if err := s.db.Where("id IN ?", ids).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	if len(transactions) != len(uniqueUUIDs(ids)) {
+		return nil, errors.New("transaction not found")
+	}
+
+	for _, transaction := range transactions {
+		if err := s.checkPortfolioAccess(userID, role, transaction.PortfolioID, "transaction not found"); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// checkPortfolioAccess reports inaccessible objects as not found, like the access middleware
+func (s *CaseService) checkPortfolioAccess(userID uuid.UUID, role string, portfolioID uuid.UUID, notFound string) error {
+	allowed, err := s.accessService.CanAccessPortfolio(userID, role, portfolioID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New(notFound)
+	}
+	return nil
+}
+
+// loadInvestigator returns an active user who may be assigned cases
+func (s *CaseService) loadInvestigator(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("assignee not found")
+	}
+	if !user.IsActive || !CanInvestigate(user.Role) {
+		return nil, errors.New("assignee must be an active compliance officer or admin")
+	}
+	return &user, nil
+}
+
+func addCaseNote(tx *gorm.DB, caseID uuid.UUID, authorID *uuid.UUID, kind, body string) error {
+	return tx.Create(&models.CaseNote{
+		CaseID:   caseID,
+		AuthorID: authorID,
+		Kind:     kind,
+		Body:     body,
+	}).Error
+}
+
+// linkCaseTransactions writes case_transactions rows directly so the transactions themselves are not re-saved
+func linkCaseTransactions(tx *gorm.DB, caseID uuid.UUID, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	rows := make([]map[string]interface{}, 0, len(transactions))
+	for _, transaction := range transactions {
+		rows = append(rows, map[string]interface{}{
+			"case_id":        caseID,
+			"transaction_id": transaction.ID,
+		})
+	}
+	return tx.Table("case_transactions").Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func canTransitionCase(from, to string) bool {
+	for _, next := range caseTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// alertTransactionID extracts the transaction an alert was raised for, if any
+func alertTransactionID(alert *models.Alert) (uuid.UUID, bool) {
+	raw, ok := alert.TriggeredBy["transaction_id"]
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(fmt.Sprint(raw))
+	return id, err == nil
+}
+
+func newCaseNumber() string {
+	return fmt.Sprintf("CASE-%s-%s", time.Now().UTC().Format("20060102"), strings.ToUpper(uuid.New().String()[:8]))
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !containsUUID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// SARReport is the exportable Suspicious Activity Report assembled from a case
+type SARReport struct {
+	CaseNumber      string           `json:"case_number"`
+	Title           string           `json:"title"`
+	Status          string           `json:"status"`
+	Priority        string           `json:"priority"`
+	FilingReference string           `json:"filing_reference,omitempty"`
+	FiledAt         *time.Time       `json:"filed_at,omitempty"`
+	OpenedAt        time.Time        `json:"opened_at"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+	Investigator    string           `json:"investigator,omitempty"`
+	Description     string           `json:"description"`
+	Narrative       string           `json:"narrative"`
+	Alert           *SARAlert        `json:"alert,omitempty"`
+	Activity        SARActivity      `json:"activity"`
+	Subjects        []SARSubject     `json:"subjects"`
+	Transactions    []SARTransaction `json:"transactions"`
+	Timeline        []SARNote        `json:"timeline"`
+	Evidence        []SAREvidence    `json:"evidence"`
+}
+
+type SARAlert struct {
+	ID       uuid.UUID `json:"id"`
+	Type     string    `json:"type"`
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	RaisedAt time.Time `json:"raised_at"`
+	Source   string    `json:"source"`
+	Summary  string    `json:"summary"`
+}
+
+// SARActivity summarises the suspicious activity window and volume
+type SARActivity struct {
+	From             *time.Time      `json:"from"`
+	To               *time.Time      `json:"to"`
+	TransactionCount int             `json:"transaction_count"`
+	TotalAmount      decimal.Decimal `json:"total_amount"`
+	Currencies       []string        `json:"currencies"`
+	PortfolioCount   int             `json:"portfolio_count"`
+}
+
+// SARSubject is a party to the reported transactions
+type SARSubject struct {
+	Name           string     `json:"name"`
+	Country        string     `json:"country"`
+	CounterpartyID *uuid.UUID `json:"counterparty_id,omitempty"`
+	LEI            string     `json:"lei,omitempty"`
+	RiskRating     string     `json:"risk_rating,omitempty"`
+	KYCStatus      string     `json:"kyc_status,omitempty"`
+}
+
+type SARTransaction struct {
+	ID           uuid.UUID       `json:"id"`
+	Date         time.Time       `json:"date"`
+	PortfolioID  uuid.UUID       `json:"portfolio_id"`
+	Type         string          `json:"type"`
+	Symbol       string          `json:"symbol"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+	Status       string          `json:"status"`
+	Counterparty string          `json:"counterparty"`
+	RiskScore    int             `json:"risk_score"`
+}
+
+type SARNote struct {
+	At     time.Time `json:"at"`
+	Kind   string    `json:"kind"`
+	Author string    `json:"author"`
+	Body   string    `json:"body"`
+}
+
+type SAREvidence struct {
+	FileName   string    `json:"file_name"`
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// BuildSARReport assembles the SAR document for a case in the user's tenant
+func (s *CaseService) BuildSARReport(caseID, userID uuid.UUID) (*SARReport, error) {
+	c, err := s.GetCase(caseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SARReport{
+		CaseNumber:      c.CaseNumber,
+		Title:           c.Title,
+		Status:          c.Status,
+		Priority:        c.Priority,
+		FilingReference: c.FilingReference,
+		FiledAt:         c.FiledAt,
+		OpenedAt:        c.CreatedAt,
+		GeneratedAt:     time.Now(),
+		Description:     c.Description,
+		Narrative:       c.Narrative,
+		Subjects:        []SARSubject{},
+		Transactions:    []SARTransaction{},
+		Timeline:        []SARNote{},
+		Evidence:        []SAREvidence{},
+	}
+	if c.Assignee != nil {
+		report.Investigator = fmt.Sprintf("%s %s (%s)", c.Assignee.FirstName, c.Assignee.LastName, c.Assignee.Email)
+	}
+	if c.Alert != nil {
+		report.Alert = &SARAlert{
+			ID:       c.Alert.ID,
+			Type:     c.Alert.AlertType,
+			Severity: c.Alert.Severity,
+			Title:    c.Alert.Title,
+			RaisedAt: c.Alert.CreatedAt,
+			Source:   c.Alert.Source,
+			Summary:  c.Alert.Description,
+		}
+	}
+
+	total := decimal.Zero
+	currencies := map[string]bool{}
+	portfolios := map[uuid.UUID]bool{}
+	subjects := map[string]bool{}
+	for _, t := range c.Transactions {
+		date := t.CreatedAt
+		if t.ExecutedAt != nil {
+			date = *t.ExecutedAt
+		}
+		if report.Activity.From == nil || date.Before(*report.Activity.From) {
+			d := date
+			report.Activity.From = &d
+		}
+		if report.Activity.To == nil || date.After(*report.Activity.To) {
+			d := date
+			report.Activity.To = &d
+		}
+		total = total.Add(t.Amount.Abs())
+		if !currencies[t.Currency] {
+			currencies[t.Currency] = true
+			report.Activity.Currencies = append(report.Activity.Currencies, t.Currency)
+		}
+		portfolios[t.PortfolioID] = true
+
+		report.Transactions = append(report.Transactions, SARTransaction{
+			ID:           t.ID,
+			Date:         date,
+			PortfolioID:  t.PortfolioID,
+			Type:         t.TransactionType,
+			Symbol:       t.Symbol,
+			Amount:       t.Amount,
+			Currency:     t.Currency,
+			Status:       t.Status,
+			Counterparty: t.CounterpartyName,
+			RiskScore:    t.RiskScore,
+		})
+
+		if t.CounterpartyName == "" {
+			continue
+		}
+		key := strings.ToLower(t.CounterpartyName + "|" + t.CounterpartyCountry)
+		if subjects[key] {
+			continue
+		}
+		subjects[key] = true
+		subject := SARSubject{Name: t.CounterpartyName, Country: t.CounterpartyCountry}
+		if t.Counterparty != nil {
+			subject.CounterpartyID = &t.Counterparty.ID
+			subject.LEI = t.Counterparty.LEI
+			subject.RiskRating = t.Counterparty.RiskRating
+			subject.KYCStatus = t.Counterparty.KYCStatus
+		}
+		report.Subjects = append(report.Subjects, subject)
+	}
+	report.Activity.TransactionCount = len(c.Transactions)
+	report.Activity.TotalAmount = total
+	report.Activity.PortfolioCount = len(portfolios)
+
+	for _, n := range c.Notes {
+		author := "system"
+		if n.Author != nil {
+			author = n.Author.Email
+		}
+		report.Timeline = append(report.Timeline, SARNote{At: n.CreatedAt, Kind: n.Kind, Author: author, Body: n.Body})
+	}
+	for _, e := range c.Evidence {
+		report.Evidence = append(report.Evidence, SAREvidence{FileName: e.FileName, SHA256: e.SHA256, Size: e.Size, UploadedAt: e.CreatedAt})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// cashDebitTypes are the transaction types that take cash out of a portfolio
+var cashDebitTypes = []string{"BUY", "WITHDRAWAL"}
+
+// InsufficientCashError is returned when a withdrawal, or a BUY when buys must be covered by cash,
+// is larger than the portfolio's available cash
+type InsufficientCashError struct {
+	Available decimal.Decimal
+	Required  decimal.Decimal
+	Currency  string
+}
+
+func (e *InsufficientCashError) Error() string {
+	return fmt.Sprintf("insufficient cash: %s %s available, %s required",
+		e.Available.StringFixed(2), e.Currency, e.Required.StringFixed(2))
+}
+
+// CashSummary is a portfolio's cash balance and how much of it is free to spend
+type CashSummary struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	Currency      string          `json:"currency"`
+	Balance       decimal.Decimal `json:"balance"`
+	PendingDebits decimal.Decimal `json:"pending_debits"` // Pending BUY and WITHDRAWAL amounts
+	Available     decimal.Decimal `json:"available"`
+}
+
+// CashService keeps each portfolio's cash balance in step with its completed transactions and
+// records every movement in the cash ledger
+type CashService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCashService() *CashService {
+	return &CashService{
+		db:     database.GetDB(),
+		logger: logging.Component("cash"),
+	}
+}
+
+// cashFlowSign is the direction a completed transaction moves cash: 1 in, -1 out and 0 for types
+// without a cash leg
+func cashFlowSign(transactionType string) int64 {
+	switch transactionType {
+	case "DEPOSIT", "SELL", "DIVIDEND":
+		return 1
+	case "WITHDRAWAL", "BUY":
+		return -1
+	}
+	return 0
+}
+
+// portfolioAmount converts a transaction's amount into the portfolio currency
+func portfolioAmount(transaction *models.Transaction, currency string) (decimal.Decimal, error) {
+	rate, err := fxRate(transaction.Currency, currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return transaction.Amount.Abs().Mul(rate).Round(2), nil
+}
+
+// GetSummary returns a portfolio's cash balance and the part not committed to pending debits
+func (s *CashService) GetSummary(portfolioID uuid.UUID) (*CashSummary, error) {
+	var portfolio models.Portfolio
+	if err := s.db.Where("id = ?", portfolioID).First(&portfolio).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	pending, err := s.pendingDebits(s.db, &portfolio, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CashSummary{
+		PortfolioID:   portfolio.ID,
+		Currency:      portfolio.Currency,
+		Balance:       portfolio.CashBalance,
+		PendingDebits: pending,
+		Available:     portfolio.CashBalance.Sub(pending),
+	}, nil
+}
+
+// ListLedger returns a page of a portfolio's cash ledger and the total match count
+func (s *CashService) ListLedger(portfolioID uuid.UUID, spec pagination.Spec, params pagination.Params) ([]models.CashLedgerEntry, int64, error) {
+	var entries []models.CashLedgerEntry
+	query := s.db.Model(&models.CashLedgerEntry{}).Where("portfolio_id = ?", portfolioID)
+	total, err := pagination.Find(query, spec, params, &entries)
+	return entries, total, err
+}
+
+// pendingDebits sums the pending BUY and WITHDRAWAL amounts of a portfolio in its currency,
+// including those awaiting approval and leaving out the excluded transaction
+func (s *CashService) pendingDebits(db *gorm.DB, portfolio *models.Portfolio, excludeID uuid.UUID) (decimal.Decimal, error) {
+	var rows []struct {
+		Currency string
+		Amount   decimal.Decimal
+	}
+	err := db.Model(&models.Transaction{}).
+		Select("currency, COALESCE(SUM(ABS(amount)), 0) AS amount").
+		Where("portfolio_id = ? AND status IN ? AND transaction_type IN ? AND id <> ?",
+			portfolio.ID, []string{"PENDING", models.TransactionStatusPendingApproval}, cashDebitTypes, excludeID).
+		Group("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	for _, row := range rows {
+		rate, err := fxRate(row.Currency, portfolio.Currency)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(row.Amount.Mul(rate))
+	}
+	return total.Round(2), nil
+}
+
+// CheckFunds rejects a withdrawal, or a BUY when includeBuys is set, that is larger than the
+// portfolio's cash less what its other pending buys and withdrawals already commit. It locks the
+// portfolio, so run it in the transaction that stores the order.
+func (s *CashService) CheckFunds(tx *gorm.DB, transaction *models.Transaction, includeBuys bool) error {
+	switch transaction.TransactionType {
+	case "WITHDRAWAL":
+	case "BUY":
+		if !includeBuys {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	portfolio, err := lockPortfolio(tx, transaction.PortfolioID)
+	if err != nil {
+		return err
+	}
+	required, err := portfolioAmount(transaction, portfolio.Currency)
+	if err != nil {
+		return err
+	}
+	pending, err := s.pendingDebits(tx, portfolio, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	available := portfolio.CashBalance.Sub(pending)
+	if required.GreaterThan(available) {
+		return &InsufficientCashError{
+			Available: decimal.Max(available, decimal.Zero),
+			Required:  required,
+			Currency:  portfolio.Currency,
+		}
+	}
+	return nil
+}
+
+// Settle brings a transaction's cash postings in line with its status. A completed deposit,
+// withdrawal, buy or sell moves the cash balance once, however often it is settled, and a
+// transaction that leaves COMPLETED has its postings reversed. A withdrawal may not take the
+// balance below zero.
+func (s *CashService) Settle(tx *gorm.DB, transaction *models.Transaction, userID *uuid.UUID) error {
+	return s.settle(tx, transaction, transaction.Status == "COMPLETED", userID)
+}
+
+// Reverse undoes a transaction's cash postings whatever its status, for transactions that are
+// deleted or superseded by an amendment
+func (s *CashService) Reverse(tx *gorm.DB, transaction *models.Transaction, userID *uuid.UUID) error {
+	return s.settle(tx, transaction, false, userID)
+}
+
+func (s *CashService) settle(tx *gorm.DB, transaction *models.Transaction, completed bool, userID *uuid.UUID) error {
+	sign := cashFlowSign(transaction.TransactionType)
+	if sign == 0 {
+		return nil
+	}
+
+	portfolio, err := lockPortfolio(tx, transaction.PortfolioID)
+	if err != nil {
+		return err
+	}
+
+	var posted decimal.Decimal
+	err = tx.Model(&models.CashLedgerEntry{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("transaction_id = ?", transaction.ID).
+		Scan(&posted).Error
+	if err != nil {
+		return err
+	}
+
+	description := transaction.TransactionType
+	if transaction.Symbol != "" {
+		description += " " + transaction.Symbol
+	}
+	entry := models.CashLedgerEntry{
+		TransactionID: &transaction.ID,
+		CreatedBy:     userID,
+	}
+	switch {
+	case completed && posted.IsZero():
+		amount, err := portfolioAmount(transaction, portfolio.Currency)
+		if err != nil {
+			return err
+		}
+		if amount.IsZero() {
+			return nil
+		}
+		entry.EntryType = transaction.TransactionType
+		entry.Amount = amount.Mul(decimal.NewFromInt(sign))
+		entry.Description = description
+	case !completed && !posted.IsZero():
+		entry.EntryType = models.CashEntryReversal
+		entry.Amount = posted.Neg()
+		entry.Description = "Reversal of " + description
+	default:
+		return nil
+	}
+
+	if transaction.TransactionType == "WITHDRAWAL" && entry.Amount.IsNegative() &&
+		portfolio.CashBalance.Add(entry.Amount).IsNegative() {
+		return &InsufficientCashError{
+			Available: decimal.Max(portfolio.CashBalance, decimal.Zero),
+			Required:  entry.Amount.Neg(),
+			Currency:  portfolio.Currency,
+		}
+	}
+
+	return s.post(tx, portfolio, &entry)
+}
+
+// Adjust records a cash balance set directly on a locked portfolio as an ADJUSTMENT entry for the
+// difference from its current balance
+func (s *CashService) Adjust(tx *gorm.DB, portfolio *models.Portfolio, balance decimal.Decimal, description string, userID *uuid.UUID) error {
+	delta := balance.Sub(portfolio.CashBalance)
+	if delta.IsZero() {
+		return nil
+	}
+	return s.post(tx, portfolio, &models.CashLedgerEntry{
+		EntryType:   models.CashEntryAdjustment,
+		Amount:      delta,
+		Description: description,
+		CreatedBy:   userID,
+	})
+}
+
+// post moves a locked portfolio's cash balance by the entry's amount and records the entry
+func (s *CashService) post(tx *gorm.DB, portfolio *models.Portfolio, entry *models.CashLedgerEntry) error {
+	portfolio.CashBalance = portfolio.CashBalance.Add(entry.Amount)
+	if err := tx.Model(portfolio).Update("cash_balance", portfolio.CashBalance).Error; err != nil {
+		return err
+	}
+
+	entry.PortfolioID = portfolio.ID
+	entry.BalanceAfter = portfolio.CashBalance
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	s.logger.Info("Cash posted", "portfolio_id", portfolio.ID, "entry_type", entry.EntryType,
+		"amount", entry.Amount.String(), "balance", portfolio.CashBalance.String())
+	return nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+type ComplianceRuleService struct {
+	db           *gorm.DB
+	alertService *AlertService
+	lastRun      time.Time
+	logger       *slog.Logger
+}
+
+func NewComplianceRuleService() *ComplianceRuleService {
+	return &ComplianceRuleService{
+		db:           database.GetDB(),
+		alertService: NewAlertService(),
+		logger:       logging.Component("compliance_rules"),
+	}
+}
+
+// RuleBreach describes a rule that evaluated as breached
+type RuleBreach struct {
+	RuleID        uuid.UUID       `json:"rule_id"`
+	RuleName      string          `json:"rule_name"`
+	Scope         string          `json:"scope"`
+	Metric        string          `json:"metric"`
+	Parameter     string          `json:"parameter,omitempty"`
+	Operator      string          `json:"operator"`
+	Threshold     decimal.Decimal `json:"threshold"`
+	Value         decimal.Decimal `json:"value"`
+	Severity      string          `json:"severity"`
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty"`
+}
+
+// RuleEvaluationResult summarizes a rule engine run
+type RuleEvaluationResult struct {
+	RulesEvaluated        int          `json:"rules_evaluated"`
+	PortfoliosEvaluated   int          `json:"portfolios_evaluated"`
+	TransactionsEvaluated int          `json:"transactions_evaluated"`
+	Breaches              []RuleBreach `json:"breaches"`
+	EvaluatedAt           time.Time    `json:"evaluated_at"`
+}
+
+// ListRules returns rules, optionally filtered by scope and active state
+func (s *ComplianceRuleService) ListRules(scope string, activeOnly bool) ([]models.ComplianceRule, error) {
+	var ruleList []models.ComplianceRule
+	query := s.db.Model(&models.ComplianceRule{})
+
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	err := query.Order("created_at ASC").Find(&ruleList).Error
+	return ruleList, err
+}
+
+// GetRule returns a rule by ID
+func (s *ComplianceRuleService) GetRule(ruleID uuid.UUID) (*models.ComplianceRule, error) {
+	var rule models.ComplianceRule
+	if err := s.db.First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("rule not found")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule validates and stores a new rule
+func (s *ComplianceRuleService) CreateRule(rule *models.ComplianceRule) error {
+	if err := rules.ValidateRule(rule); err != nil {
+		return err
+	}
+	return s.db.Create(rule).Error
+}
+
+// UpdateRule validates and saves changes to an existing rule
+func (s *ComplianceRuleService) UpdateRule(rule *models.ComplianceRule) error {
+	if err := rules.ValidateRule(rule); err != nil {
+		return err
+	}
+	return s.db.Save(rule).Error
+}
+
+// DeleteRule removes a rule
+func (s *ComplianceRuleService) DeleteRule(ruleID uuid.UUID) error {
+	result := s.db.Delete(&models.ComplianceRule{}, ruleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("rule not found")
+	}
+	return nil
+}
+
+// SeedDefaultRules creates the built-in rules when the rule table is empty
+func (s *ComplianceRuleService) SeedDefaultRules() error {
+	var count int64
+	if err := s.db.Model(&models.ComplianceRule{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	defaults := rules.DefaultRules()
+	return s.db.Create(&defaults).Error
+}
+
+// EvaluatePortfolio evaluates all active portfolio-scoped rules against one portfolio
+func (s *ComplianceRuleService) EvaluatePortfolio(portfolioID uuid.UUID) ([]RuleBreach, error) {
+	var portfolio models.Portfolio
+	if err := s.db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		return nil, fmt.Errorf("portfolio not found: %w", err)
+	}
+
+	ruleList, err := s.rulesFor(rules.ScopePortfolio, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	breaches := []RuleBreach{}
+	for _, rule := range ruleList {
+		value := rules.PortfolioMetric(rule.Metric, rule.Parameter, portfolio.Positions)
+		if rules.Compare(value, rule.Operator, rule.Threshold) {
+			breaches = append(breaches, newRuleBreach(rule, value, portfolioID, nil))
+		}
+	}
+
+	return breaches, nil
+}
+
+// EvaluateTransaction evaluates all active transaction-scoped rules against one transaction
+func (s *ComplianceRuleService) EvaluateTransaction(tx *models.Transaction) ([]RuleBreach, error) {
+	ruleList, err := s.rulesFor(rules.ScopeTransaction, tx.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []models.Transaction
+	s.db.Where("portfolio_id = ? AND created_at > ?", tx.PortfolioID, time.Now().Add(-24*time.Hour)).
+		Find(&recent)
+
+	breaches := []RuleBreach{}
+	for _, rule := range ruleList {
+		value := rules.TransactionMetric(rule.Metric, tx, recent)
+		if rules.Compare(value, rule.Operator, rule.Threshold) {
+			txID := tx.ID
+			breaches = append(breaches, newRuleBreach(rule, value, tx.PortfolioID, &txID))
+		}
+	}
+
+	return breaches, nil
+}
+
+// EvaluateAll evaluates portfolio rules against every portfolio (or just one when
+// portfolioID is set) and transaction rules against transactions created since `since`.
+// Breaches are raised as COMPLIANCE_VIOLATION alerts.
+func (s *ComplianceRuleService) EvaluateAll(ctx context.Context, portfolioID *uuid.UUID, since time.Time) (*RuleEvaluationResult, error) {
+	result := &RuleEvaluationResult{
+		Breaches:    []RuleBreach{},
+		EvaluatedAt: time.Now(),
+	}
+
+	var ruleCount int64
+	s.db.Model(&models.ComplianceRule{}).Where("is_active = ?", true).Count(&ruleCount)
+	result.RulesEvaluated = int(ruleCount)
+
+	var portfolioIDs []uuid.UUID
+	portfolioQuery := s.db.Model(&models.Portfolio{})
+	if portfolioID != nil {
+		portfolioQuery = portfolioQuery.Where("id = ?", *portfolioID)
+	}
+	if err := portfolioQuery.Pluck("id", &portfolioIDs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range portfolioIDs {
+		breaches, err := s.EvaluatePortfolio(id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Rule evaluation failed for portfolio", "portfolio_id", id, "error", err)
+			continue
+		}
+		result.PortfoliosEvaluated++
+		result.Breaches = append(result.Breaches, breaches...)
+	}
+
+	var transactions []models.Transaction
+	txQuery := s.db.Where("created_at > ?", since)
+	if portfolioID != nil {
+		txQuery = txQuery.Where("portfolio_id = ?", *portfolioID)
+	}
+	if err := txQuery.Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range transactions {
+		breaches, err := s.EvaluateTransaction(&transactions[i])
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Rule evaluation failed for transaction", "transaction_id", transactions[i].ID, "error", err)
+			continue
+		}
+		result.TransactionsEvaluated++
+		result.Breaches = append(result.Breaches, breaches...)
+	}
+
+	for _, breach := range result.Breaches {
+		s.raiseBreachAlert(ctx, breach)
+	}
+
+	return result, nil
+}
+
+// StartScheduler evaluates all rules at a fixed interval until ctx is cancelled
+func (s *ComplianceRuleService) StartScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.lastRun = time.Now().Add(-interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		runStart := time.Now()
+		result, err := s.EvaluateAll(ctx, nil, s.lastRun)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduled compliance rule evaluation failed", "error", err)
+			continue
+		}
+		s.lastRun = runStart
+
+		if len(result.Breaches) > 0 {
+			s.logger.InfoContext(ctx, "Compliance rules breached", "breaches", len(result.Breaches),
+				"portfolios", result.PortfoliosEvaluated, "transactions", result.TransactionsEvaluated)
+		}
+	}
+}
+
+// rulesFor returns active rules of a scope that apply to the given portfolio
+func (s *ComplianceRuleService) rulesFor(scope string, portfolioID uuid.UUID) ([]models.ComplianceRule, error) {
+	var ruleList []models.ComplianceRule
+	err := s.db.Where("scope = ? AND is_active = ? AND (portfolio_id IS NULL OR portfolio_id = ?)",
+		scope, true, portfolioID).
+		Find(&ruleList).Error
+	return ruleList, err
+}
+
+// raiseBreachAlert creates an alert for a breach unless one is already active for the same rule
+func (s *ComplianceRuleService) raiseBreachAlert(ctx context.Context, breach RuleBreach) {
+	query := s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ? AND triggered_by->>'rule_id' = ?",
+			breach.PortfolioID, "RULE_ENGINE", "ACTIVE", breach.RuleID.String())
+	if breach.TransactionID != nil {
+		query = query.Where("triggered_by->>'transaction_id' = ?", breach.TransactionID.String())
+	}
+
+	var count int64
+	query.Count(&count)
+	if count > 0 {
+		return
+	}
+
+	triggeredBy := models.JSON{
+		"rule_id":   breach.RuleID.String(),
+		"rule_name": breach.RuleName,
+		"metric":    breach.Metric,
+		"operator":  breach.Operator,
+		"threshold": breach.Threshold.InexactFloat64(),
+		"value":     breach.Value.InexactFloat64(),
+	}
+	if breach.Parameter != "" {
+		triggeredBy["parameter"] = breach.Parameter
+	}
+	if breach.TransactionID != nil {
+		triggeredBy["transaction_id"] = breach.TransactionID.String()
+	}
+
+	alert := &models.Alert{
+		PortfolioID: breach.PortfolioID,
+		AlertType:   "COMPLIANCE_VIOLATION",
+		Severity:    breach.Severity,
+		Title:       fmt.Sprintf("Compliance Rule Breached: %s", breach.RuleName),
+		Description: fmt.Sprintf("%s of %s is %s threshold %s", breach.Metric, breach.Value.StringFixed(2),
+			operatorDescription(breach.Operator), breach.Threshold.String()),
+		Source:      "RULE_ENGINE",
+		Status:      "ACTIVE",
+		TriggeredBy: triggeredBy,
+	}
+
+	if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create rule breach alert", "rule_id", breach.RuleID, "error", err)
+	}
+}
+
+func newRuleBreach(rule models.ComplianceRule, value decimal.Decimal, portfolioID uuid.UUID, transactionID *uuid.UUID) RuleBreach {
+	return RuleBreach{
+		RuleID:        rule.ID,
+		RuleName:      rule.Name,
+		Scope:         rule.Scope,
+		Metric:        rule.Metric,
+		Parameter:     rule.Parameter,
+		Operator:      rule.Operator,
+		Threshold:     rule.Threshold,
+		Value:         value,
+		Severity:      rule.Severity,
+		PortfolioID:   portfolioID,
+		TransactionID: transactionID,
+	}
+}
+
+func operatorDescription(operator string) string {
+	switch operator {
+	case "GT":
+		return "above"
+	case "GTE":
+		return "at or above"
+	case "LT":
+		return "below"
+	case "LTE":
+		return "at or below"
+	case "EQ":
+		return "equal to"
+	case "NEQ":
+		return "not equal to"
+	}
+	return operator
+}
@@ -0,0 +1,723 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// adjustmentTables is the table each kind of adjustment changes; dividends create transactions
+// instead
+var adjustmentTables = map[string]string{
+	models.AdjustmentPosition:    "positions",
+	models.AdjustmentLot:         "position_lots",
+	models.AdjustmentLotClosure:  "lot_closures",
+	models.AdjustmentTransaction: "transactions",
+	models.AdjustmentSnapshot:    "portfolio_snapshots",
+}
+
+// CorporateActionService records splits, dividends and symbol changes and applies them to the
+// positions, open lots, open orders and snapshot price history of every portfolio holding the
+// symbol. Each change is kept as an adjustment so an action can be previewed before it is applied
+// and rolled back after.
+type CorporateActionService struct {
+	db          *gorm.DB
+	cashService *CashService
+	logger      *slog.Logger
+}
+
+func NewCorporateActionService() *CorporateActionService {
+	return &CorporateActionService{
+		db:          database.GetDB(),
+		cashService: NewCashService(),
+		logger:      logging.Component("corporate_action"),
+	}
+}
+
+// CorporateActionRequest announces a corporate action. Splits and stock dividends need both
+// ratios, cash dividends a cash amount per share and symbol changes the new symbol.
+type CorporateActionRequest struct {
+	Symbol      string          `json:"symbol" validate:"required,max=10"`
+	ActionType  string          `json:"action_type" validate:"required,oneof=SPLIT CASH_DIVIDEND STOCK_DIVIDEND SYMBOL_CHANGE"`
+	ExDate      string          `json:"ex_date" validate:"required"` // YYYY-MM-DD
+	PayDate     string          `json:"pay_date"`                    // YYYY-MM-DD; cash dividends are dated on it when given
+	RatioFrom   decimal.Decimal `json:"ratio_from"`
+	RatioTo     decimal.Decimal `json:"ratio_to"`
+	CashAmount  decimal.Decimal `json:"cash_amount"`
+	Currency    string          `json:"currency" validate:"omitempty,len=3"` // Of a cash dividend; the position's currency when omitted
+	NewSymbol   string          `json:"new_symbol" validate:"max=10"`
+	Description string          `json:"description" validate:"max=2000"`
+}
+
+// CorporateActionPlan is what applying an action changes, or changed
+type CorporateActionPlan struct {
+	Action      *models.CorporateAction            `json:"action"`
+	Adjustments []models.CorporateActionAdjustment `json:"adjustments"`
+	Portfolios  int                                `json:"portfolios"` // Portfolios affected
+	Counts      map[string]int                     `json:"counts"`     // Adjustments by entity type
+}
+
+// List returns a page of the corporate actions matching query
+func (s *CorporateActionService) List(query *gorm.DB, spec pagination.Spec, params pagination.Params) ([]models.CorporateAction, int64, error) {
+	actions := []models.CorporateAction{}
+	total, err := pagination.Find(query, spec, params, &actions)
+	return actions, total, err
+}
+
+// Get returns a corporate action
+func (s *CorporateActionService) Get(id uuid.UUID) (*models.CorporateAction, error) {
+	var action models.CorporateAction
+	if err := s.db.First(&action, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Corporate action not found")
+		}
+		return nil, err
+	}
+	return &action, nil
+}
+
+// Create records a PENDING corporate action
+func (s *CorporateActionService) Create(req CorporateActionRequest, userID uuid.UUID) (*models.CorporateAction, error) {
+	action := &models.CorporateAction{
+		Symbol:      strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		ActionType:  req.ActionType,
+		Description: strings.TrimSpace(req.Description),
+		Status:      models.CorporateActionPending,
+		CreatedBy:   &userID,
+	}
+	if action.Symbol == "" {
+		return nil, apperror.BadRequest("symbol is required")
+	}
+
+	exDate, err := time.Parse("2006-01-02", req.ExDate)
+	if err != nil {
+		return nil, apperror.BadRequest("ex_date must be a YYYY-MM-DD date")
+	}
+	action.ExDate = exDate
+	if req.PayDate != "" {
+		payDate, err := time.Parse("2006-01-02", req.PayDate)
+		if err != nil {
+			return nil, apperror.BadRequest("pay_date must be a YYYY-MM-DD date")
+		}
+		if payDate.Before(exDate) {
+			return nil, apperror.BadRequest("pay_date may not be before ex_date")
+		}
+		action.PayDate = &payDate
+	}
+
+	switch req.ActionType {
+	case models.CorporateActionSplit, models.CorporateActionStockDividend:
+		if !req.RatioFrom.IsPositive() || !req.RatioTo.IsPositive() {
+			return nil, apperror.BadRequest("ratio_from and ratio_to must be positive")
+		}
+		if req.ActionType == models.CorporateActionSplit && req.RatioFrom.Equal(req.RatioTo) {
+			return nil, apperror.BadRequest("A split must change the number of shares")
+		}
+		action.RatioFrom = req.RatioFrom
+		action.RatioTo = req.RatioTo
+	case models.CorporateActionCashDividend:
+		if !req.CashAmount.IsPositive() {
+			return nil, apperror.BadRequest("cash_amount must be positive")
+		}
+		action.CashAmount = req.CashAmount
+		action.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	case models.CorporateActionSymbolChange:
+		action.NewSymbol = strings.ToUpper(strings.TrimSpace(req.NewSymbol))
+		if action.NewSymbol == "" || action.NewSymbol == action.Symbol {
+			return nil, apperror.BadRequest("new_symbol must be given and differ from symbol")
+		}
+	}
+
+	if err := s.db.Create(action).Error; err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// Delete removes a pending corporate action
+func (s *CorporateActionService) Delete(id uuid.UUID) error {
+	action, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if action.Status != models.CorporateActionPending {
+		return apperror.Conflict("Only a pending corporate action can be deleted; roll back an applied one")
+	}
+	return s.db.Delete(action).Error
+}
+
+// Adjustments returns the changes an applied or rolled back action made
+func (s *CorporateActionService) Adjustments(id uuid.UUID) ([]models.CorporateActionAdjustment, error) {
+	adjustments := []models.CorporateActionAdjustment{}
+	err := s.db.Where("action_id = ?", id).Order("created_at").Find(&adjustments).Error
+	return adjustments, err
+}
+
+// Preview returns what applying a pending action would change, without changing anything
+func (s *CorporateActionService) Preview(id uuid.UUID) (*CorporateActionPlan, error) {
+	action, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if action.Status != models.CorporateActionPending {
+		return nil, apperror.Conflict("Only a pending corporate action can be previewed")
+	}
+	adjustments, err := s.plan(s.db, action)
+	if err != nil {
+		return nil, err
+	}
+	return newCorporateActionPlan(action, adjustments), nil
+}
+
+// Apply applies a pending action whose ex-date has been reached, with the portfolios holding the
+// symbol locked so that price updates and trades wait for it. userID is nil when the end-of-day
+// batch applies it.
+func (s *CorporateActionService) Apply(id uuid.UUID, userID *uuid.UUID) (*CorporateActionPlan, error) {
+	var action models.CorporateAction
+	var adjustments []models.CorporateActionAdjustment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&action, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.NotFound("Corporate action not found")
+			}
+			return err
+		}
+		if action.Status != models.CorporateActionPending {
+			return apperror.Conflict("Only a pending corporate action can be applied")
+		}
+		if action.ExDate.After(time.Now()) {
+			return apperror.Conflict("Corporate action may not be applied before its ex-date")
+		}
+		// A rename also checks the holders of the new symbol, so they are locked too
+		symbols := []string{action.Symbol}
+		if action.NewSymbol != "" {
+			symbols = append(symbols, action.NewSymbol)
+		}
+		if err := lockPortfoliosHolding(tx, symbols); err != nil {
+			return err
+		}
+
+		var err error
+		adjustments, err = s.plan(tx, &action)
+		if err != nil {
+			return err
+		}
+		for i := range adjustments {
+			if err := s.write(tx, &action, &adjustments[i], userID); err != nil {
+				return err
+			}
+		}
+		if len(adjustments) > 0 {
+			if err := tx.CreateInBatches(&adjustments, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		action.Status = models.CorporateActionApplied
+		action.AppliedAt = &now
+		action.AppliedBy = userID
+		return tx.Model(&action).Updates(map[string]interface{}{
+			"status":     action.Status,
+			"applied_at": now,
+			"applied_by": userID,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Corporate action applied", "action_id", action.ID, "symbol", action.Symbol,
+		"action_type", action.ActionType, "adjustments", len(adjustments))
+	return newCorporateActionPlan(&action, adjustments), nil
+}
+
+// Rollback undoes an applied action: positions, lots, orders and snapshots get their values back,
+// with positions kept at their current market price, and dividends paid are reversed. It is
+// refused once later fills or corporate actions of the symbol have built on the adjusted values.
+func (s *CorporateActionService) Rollback(id uuid.UUID, userID uuid.UUID) (*models.CorporateAction, error) {
+	var action models.CorporateAction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&action, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.NotFound("Corporate action not found")
+			}
+			return err
+		}
+		if action.Status != models.CorporateActionApplied || action.AppliedAt == nil {
+			return apperror.Conflict("Only an applied corporate action can be rolled back")
+		}
+
+		symbols := []string{action.Symbol}
+		if action.NewSymbol != "" {
+			symbols = append(symbols, action.NewSymbol)
+		}
+		if err := lockPortfoliosHolding(tx, symbols); err != nil {
+			return err
+		}
+
+		var later int64
+		err := tx.Model(&models.CorporateAction{}).
+			Where("id <> ? AND status = ? AND applied_at > ?", action.ID, models.CorporateActionApplied, *action.AppliedAt).
+			Where("symbol IN ? OR new_symbol IN ?", symbols, symbols).
+			Count(&later).Error
+		if err != nil {
+			return err
+		}
+		if later > 0 {
+			return apperror.Conflict("A later corporate action of the symbol has been applied; roll it back first")
+		}
+		if action.ActionType != models.CorporateActionCashDividend {
+			var fills int64
+			err := tx.Model(&models.Fill{}).
+				Joins("JOIN transactions ON transactions.id = transaction_fills.transaction_id").
+				Where("transactions.symbol IN ? AND transaction_fills.created_at > ?", symbols, *action.AppliedAt).
+				Count(&fills).Error
+			if err != nil {
+				return err
+			}
+			if fills > 0 {
+				return apperror.Conflict("Trades of the symbol have been filled since the corporate action was applied")
+			}
+		}
+
+		var adjustments []models.CorporateActionAdjustment
+		if err := tx.Where("action_id = ?", action.ID).Find(&adjustments).Error; err != nil {
+			return err
+		}
+		for i := range adjustments {
+			if err := s.restore(tx, &action, &adjustments[i], &userID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		action.Status = models.CorporateActionRolledBack
+		action.RolledBackAt = &now
+		action.RolledBackBy = &userID
+		return tx.Model(&action).Updates(map[string]interface{}{
+			"status":         action.Status,
+			"rolled_back_at": now,
+			"rolled_back_by": userID,
+			"updated_at":     now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Corporate action rolled back", "action_id", action.ID, "symbol", action.Symbol, "action_type", action.ActionType)
+	return &action, nil
+}
+
+// ApplyDue applies the pending actions whose ex-date is on or before businessDate, oldest first,
+// and returns how many were applied. An action that fails is left pending for the next run.
+func (s *CorporateActionService) ApplyDue(ctx context.Context, businessDate time.Time) (int, error) {
+	var ids []uuid.UUID
+	err := s.db.WithContext(ctx).Model(&models.CorporateAction{}).
+		Where("status = ? AND ex_date <= ?", models.CorporateActionPending, businessDate).
+		Order("ex_date, created_at").Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	applied, failed := 0, 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return applied, ctx.Err()
+		}
+		if _, err := s.Apply(id, nil); err != nil {
+			s.logger.ErrorContext(ctx, "Corporate action could not be applied", "action_id", id, "error", err)
+			failed++
+			continue
+		}
+		applied++
+	}
+	if failed > 0 {
+		return applied, fmt.Errorf("%d of %d corporate actions could not be applied", failed, len(ids))
+	}
+	return applied, nil
+}
+
+// plan works out the adjustments applying an action makes, without making them
+func (s *CorporateActionService) plan(db *gorm.DB, action *models.CorporateAction) ([]models.CorporateActionAdjustment, error) {
+	switch action.ActionType {
+	case models.CorporateActionSplit, models.CorporateActionStockDividend:
+		return s.planResize(db, action)
+	case models.CorporateActionCashDividend:
+		return s.planDividend(db, action)
+	case models.CorporateActionSymbolChange:
+		return s.planRename(db, action)
+	}
+	return nil, apperror.BadRequest("Unknown corporate action type " + action.ActionType)
+}
+
+// planResize multiplies the quantities and divides the prices of the symbol's positions, open
+// lots and open orders by the action's factor, and does the same to the snapshots before the
+// ex-date so the price history has no jump
+func (s *CorporateActionService) planResize(db *gorm.DB, action *models.CorporateAction) ([]models.CorporateActionAdjustment, error) {
+	factor := action.Factor()
+	scale := func(d decimal.Decimal) *decimal.Decimal {
+		v := d.Mul(factor).Round(8)
+		return &v
+	}
+	unscale := func(d decimal.Decimal) *decimal.Decimal {
+		v := d.Div(factor).Round(8)
+		return &v
+	}
+	var adjustments []models.CorporateActionAdjustment
+
+	var positions []models.Position
+	if err := db.Where("symbol = ?", action.Symbol).Order("portfolio_id").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+	for _, position := range positions {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentPosition, position.PortfolioID, position.ID,
+			models.AdjustmentValues{Quantity: decimalPtr(position.Quantity), AveragePrice: decimalPtr(position.AveragePrice), CurrentPrice: decimalPtr(position.CurrentPrice)},
+			models.AdjustmentValues{Quantity: scale(position.Quantity), AveragePrice: unscale(position.AveragePrice), CurrentPrice: unscale(position.CurrentPrice)}))
+	}
+
+	var lots []models.PositionLot
+	if err := db.Where("symbol = ? AND remaining_quantity > 0", action.Symbol).Order("portfolio_id, opened_at").Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	for _, lot := range lots {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentLot, lot.PortfolioID, lot.ID,
+			models.AdjustmentValues{Quantity: decimalPtr(lot.Quantity), RemainingQuantity: decimalPtr(lot.RemainingQuantity), CostPrice: decimalPtr(lot.CostPrice)},
+			models.AdjustmentValues{Quantity: scale(lot.Quantity), RemainingQuantity: scale(lot.RemainingQuantity), CostPrice: unscale(lot.CostPrice)}))
+	}
+
+	var orders []models.Transaction
+	err := db.Where("symbol = ? AND order_status IN ?", action.Symbol, []string{models.OrderStatusNew, models.OrderStatusPartiallyFilled}).
+		Order("portfolio_id, created_at").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		before := models.AdjustmentValues{
+			Quantity:         decimalPtr(order.Quantity),
+			Price:            decimalPtr(order.Price),
+			FilledQuantity:   decimalPtr(order.FilledQuantity),
+			AverageFillPrice: decimalPtr(order.AverageFillPrice),
+		}
+		after := models.AdjustmentValues{
+			Quantity:         scale(order.Quantity),
+			Price:            unscale(order.Price),
+			FilledQuantity:   scale(order.FilledQuantity),
+			AverageFillPrice: unscale(order.AverageFillPrice),
+		}
+		if order.StopLoss.IsPositive() {
+			before.StopLoss, after.StopLoss = decimalPtr(order.StopLoss), unscale(order.StopLoss)
+		}
+		if order.TakeProfit.IsPositive() {
+			before.TakeProfit, after.TakeProfit = decimalPtr(order.TakeProfit), unscale(order.TakeProfit)
+		}
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentTransaction, order.PortfolioID, order.ID, before, after))
+	}
+
+	snapshots, err := snapshotsHolding(db, action.Symbol, &action.ExDate)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		resized := make([]models.SnapshotPosition, len(snapshot.Positions))
+		for i, position := range snapshot.Positions {
+			if position.Symbol == action.Symbol {
+				position.Quantity = *scale(position.Quantity)
+				position.AveragePrice = *unscale(position.AveragePrice)
+				position.Price = *unscale(position.Price)
+			}
+			resized[i] = position
+		}
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentSnapshot, snapshot.PortfolioID, snapshot.ID,
+			models.AdjustmentValues{Positions: snapshot.Positions}, models.AdjustmentValues{Positions: resized}))
+	}
+	return adjustments, nil
+}
+
+// planDividend pays each portfolio the cash amount per share it was entitled to: what it held at
+// the close before the ex-date, as recorded in its last snapshot dated before it. Trades booked
+// since, which the action may be applied well after, do not change the entitlement.
+func (s *CorporateActionService) planDividend(db *gorm.DB, action *models.CorporateAction) ([]models.CorporateActionAdjustment, error) {
+	holding, err := json.Marshal([]map[string]string{{"symbol": action.Symbol}})
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []models.PortfolioSnapshot
+	err = db.Select("DISTINCT ON (portfolio_id) *").
+		Where("date < ?", action.ExDate).
+		Where("portfolio_id IN (?)", db.Model(&models.PortfolioSnapshot{}).Select("portfolio_id").
+			Where("positions @> ?::jsonb AND date < ?", string(holding), action.ExDate)).
+		Order("portfolio_id, date DESC").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []models.CorporateActionAdjustment
+	for _, snapshot := range snapshots {
+		quantity := decimal.Zero
+		currency := action.Currency
+		for _, position := range snapshot.Positions {
+			if position.Symbol != action.Symbol {
+				continue
+			}
+			quantity = quantity.Add(position.Quantity)
+			if currency == "" {
+				currency = position.Currency
+			}
+		}
+		if !quantity.IsPositive() {
+			continue
+		}
+
+		amount := quantity.Mul(action.CashAmount).Round(2)
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentDividend, snapshot.PortfolioID, uuid.Nil,
+			models.AdjustmentValues{},
+			models.AdjustmentValues{Currency: currency, Quantity: &quantity, Price: decimalPtr(action.CashAmount), Amount: &amount}))
+	}
+	return adjustments, nil
+}
+
+// planRename moves the symbol's positions, lots, sales, transactions and snapshot history to the
+// new symbol. A portfolio already holding the new symbol has to be merged by hand first.
+func (s *CorporateActionService) planRename(db *gorm.DB, action *models.CorporateAction) ([]models.CorporateActionAdjustment, error) {
+	var clashes []uuid.UUID
+	err := db.Model(&models.Position{}).Where("symbol = ?", action.NewSymbol).
+		Where("portfolio_id IN (?)", db.Model(&models.Position{}).Select("portfolio_id").Where("symbol = ?", action.Symbol)).
+		Distinct().Pluck("portfolio_id", &clashes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(clashes) > 0 {
+		return nil, apperror.Conflict(fmt.Sprintf("%d portfolios already hold %s alongside %s", len(clashes), action.NewSymbol, action.Symbol))
+	}
+
+	before := models.AdjustmentValues{Symbol: action.Symbol}
+	after := models.AdjustmentValues{Symbol: action.NewSymbol}
+	var adjustments []models.CorporateActionAdjustment
+
+	var positions []models.Position
+	if err := db.Where("symbol = ?", action.Symbol).Order("portfolio_id").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+	for _, position := range positions {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentPosition, position.PortfolioID, position.ID, before, after))
+	}
+
+	var lots []models.PositionLot
+	if err := db.Where("symbol = ?", action.Symbol).Order("portfolio_id, opened_at").Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	for _, lot := range lots {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentLot, lot.PortfolioID, lot.ID, before, after))
+	}
+
+	var closures []models.LotClosure
+	if err := db.Where("symbol = ?", action.Symbol).Order("portfolio_id, closed_at").Find(&closures).Error; err != nil {
+		return nil, err
+	}
+	for _, closure := range closures {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentLotClosure, closure.PortfolioID, closure.ID, before, after))
+	}
+
+	// Deleted transactions are renamed too, so that restoring one finds its position
+	var transactions []models.Transaction
+	if err := db.Unscoped().Where("symbol = ?", action.Symbol).Order("portfolio_id, created_at").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentTransaction, transaction.PortfolioID, transaction.ID, before, after))
+	}
+
+	snapshots, err := snapshotsHolding(db, action.Symbol, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		renamed := make([]models.SnapshotPosition, len(snapshot.Positions))
+		for i, position := range snapshot.Positions {
+			if position.Symbol == action.Symbol {
+				position.Symbol = action.NewSymbol
+			}
+			renamed[i] = position
+		}
+		adjustments = append(adjustments, newAdjustment(action, models.AdjustmentSnapshot, snapshot.PortfolioID, snapshot.ID,
+			models.AdjustmentValues{Positions: snapshot.Positions}, models.AdjustmentValues{Positions: renamed}))
+	}
+	return adjustments, nil
+}
+
+// write makes one planned adjustment
+func (s *CorporateActionService) write(tx *gorm.DB, action *models.CorporateAction, adjustment *models.CorporateActionAdjustment, userID *uuid.UUID) error {
+	if adjustment.EntityType != models.AdjustmentDividend {
+		return updateAdjusted(tx, adjustment.EntityType, adjustment.EntityID, adjustment.After)
+	}
+
+	paidAt := action.ExDate
+	if action.PayDate != nil {
+		paidAt = *action.PayDate
+	}
+	currency := adjustment.After.Currency
+	dividend := &models.Transaction{
+		PortfolioID:     *adjustment.PortfolioID,
+		TransactionType: "DIVIDEND",
+		Symbol:          action.Symbol,
+		Quantity:        *adjustment.After.Quantity,
+		Price:           *adjustment.After.Price,
+		Amount:          *adjustment.After.Amount,
+		Currency:        currency,
+		Status:          "COMPLETED",
+		ExecutedAt:      &paidAt,
+		Notes:           fmt.Sprintf("Cash dividend of %s %s per share", action.CashAmount.String(), currency),
+		CreatedBy:       userID,
+	}
+	if err := tx.Create(dividend).Error; err != nil {
+		return err
+	}
+	adjustment.EntityID = dividend.ID
+	return s.cashService.Settle(tx, dividend, userID)
+}
+
+// restore undoes one adjustment
+func (s *CorporateActionService) restore(tx *gorm.DB, action *models.CorporateAction, adjustment *models.CorporateActionAdjustment, userID *uuid.UUID) error {
+	switch adjustment.EntityType {
+	case models.AdjustmentDividend:
+		var dividend models.Transaction
+		if err := tx.First(&dividend, adjustment.EntityID).Error; err != nil {
+			// Deleting the dividend already reversed its cash
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := s.cashService.Reverse(tx, &dividend, userID); err != nil {
+			return err
+		}
+		return tx.Delete(&dividend).Error
+	case models.AdjustmentPosition:
+		values := adjustment.Before
+		if values.CurrentPrice != nil {
+			// The position keeps the market price it has now, in the old shares
+			var position models.Position
+			if err := tx.First(&position, adjustment.EntityID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			values.CurrentPrice = decimalPtr(position.CurrentPrice.Mul(action.Factor()).Round(8))
+		}
+		return updateAdjusted(tx, adjustment.EntityType, adjustment.EntityID, values)
+	}
+	return updateAdjusted(tx, adjustment.EntityType, adjustment.EntityID, adjustment.Before)
+}
+
+// updateAdjusted sets the values of an adjusted record
+func updateAdjusted(tx *gorm.DB, entityType string, id uuid.UUID, values models.AdjustmentValues) error {
+	table, ok := adjustmentTables[entityType]
+	if !ok {
+		return fmt.Errorf("unknown adjustment type %s", entityType)
+	}
+
+	columns := make(map[string]interface{})
+	if values.Symbol != "" {
+		columns["symbol"] = values.Symbol
+	}
+	for column, value := range map[string]*decimal.Decimal{
+		"quantity":           values.Quantity,
+		"remaining_quantity": values.RemainingQuantity,
+		"average_price":      values.AveragePrice,
+		"current_price":      values.CurrentPrice,
+		"cost_price":         values.CostPrice,
+		"price":              values.Price,
+		"filled_quantity":    values.FilledQuantity,
+		"average_fill_price": values.AverageFillPrice,
+		"stop_loss":          values.StopLoss,
+		"take_profit":        values.TakeProfit,
+	} {
+		if value != nil {
+			columns[column] = *value
+		}
+	}
+	if values.Positions != nil {
+		positions, err := json.Marshal(values.Positions)
+		if err != nil {
+			return err
+		}
+		columns["positions"] = gorm.Expr("?::jsonb", string(positions))
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	switch entityType {
+	case models.AdjustmentPosition, models.AdjustmentTransaction, models.AdjustmentSnapshot:
+		columns["updated_at"] = time.Now()
+	}
+	return tx.Table(table).Where("id = ?", id).Updates(columns).Error
+}
+
+// snapshotsHolding returns the snapshots with a position in symbol, taken before a date when given
+func snapshotsHolding(db *gorm.DB, symbol string, before *time.Time) ([]models.PortfolioSnapshot, error) {
+	holding, err := json.Marshal([]map[string]string{{"symbol": symbol}})
+	if err != nil {
+		return nil, err
+	}
+	query := db.Where("positions @> ?::jsonb", string(holding))
+	if before != nil {
+		query = query.Where("date < ?", *before)
+	}
+	var snapshots []models.PortfolioSnapshot
+	err = query.Order("portfolio_id, date").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func newAdjustment(action *models.CorporateAction, entityType string, portfolioID, entityID uuid.UUID, before, after models.AdjustmentValues) models.CorporateActionAdjustment {
+	return models.CorporateActionAdjustment{
+		ActionID:    action.ID,
+		PortfolioID: &portfolioID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Before:      before,
+		After:       after,
+	}
+}
+
+func newCorporateActionPlan(action *models.CorporateAction, adjustments []models.CorporateActionAdjustment) *CorporateActionPlan {
+	plan := &CorporateActionPlan{
+		Action:      action,
+		Adjustments: adjustments,
+		Counts:      make(map[string]int),
+	}
+	if plan.Adjustments == nil {
+		plan.Adjustments = []models.CorporateActionAdjustment{}
+	}
+	portfolios := make(map[uuid.UUID]bool)
+	for _, adjustment := range adjustments {
+		plan.Counts[adjustment.EntityType]++
+		if adjustment.PortfolioID != nil {
+			portfolios[*adjustment.PortfolioID] = true
+		}
+	}
+	plan.Portfolios = len(portfolios)
+	return plan
+}
+
+func decimalPtr(d decimal.Decimal) *decimal.Decimal {
+	return &d
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// openTransactionStatuses are the statuses that count towards counterparty exposure
+var openTransactionStatuses = []string{"PENDING", models.TransactionStatusPendingApproval, "COMPLETED"}
+
+// CounterpartyExposure aggregates a counterparty's trading across all portfolios
+type CounterpartyExposure struct {
+	CounterpartyID    uuid.UUID       `json:"counterparty_id"`
+	Name              string          `json:"name"`
+	Jurisdiction      string          `json:"jurisdiction"`
+	RiskRating        string          `json:"risk_rating"`
+	KYCStatus         string          `json:"kyc_status"`
+	GrossExposure     decimal.Decimal `json:"gross_exposure"`
+	BuyVolume         decimal.Decimal `json:"buy_volume"`
+	SellVolume        decimal.Decimal `json:"sell_volume"`
+	NetExposure       decimal.Decimal `json:"net_exposure"`
+	TransactionCount  int64           `json:"transaction_count"`
+	PortfolioCount    int64           `json:"portfolio_count"`
+	ExposureLimit     decimal.Decimal `json:"exposure_limit"`
+	Utilization       float64         `json:"utilization"` // Gross exposure / limit; zero when there is no limit
+	LimitBreached     bool            `json:"limit_breached"`
+	LastTransactionAt *time.Time      `json:"last_transaction_at"`
+}
+
+type exposureRow struct {
+	CounterpartyID    uuid.UUID
+	GrossExposure     decimal.Decimal
+	BuyVolume         decimal.Decimal
+	SellVolume        decimal.Decimal
+	TransactionCount  int64
+	PortfolioCount    int64
+	LastTransactionAt *time.Time
+}
+
+type CounterpartyService struct {
+	db      *gorm.DB
+	checker *rules.CounterpartyChecker
+}
+
+func NewCounterpartyService() *CounterpartyService {
+	return &CounterpartyService{
+		db:      database.GetDB(),
+		checker: rules.NewCounterpartyChecker(),
+	}
+}
+
+// ListCounterparties returns a page of counterparties and the total match count
+func (s *CounterpartyService) ListCounterparties(spec pagination.Spec, params pagination.Params) ([]models.Counterparty, int64, error) {
+	var counterparties []models.Counterparty
+	total, err := pagination.Find(s.db.Model(&models.Counterparty{}), spec, params, &counterparties)
+	return counterparties, total, err
+}
+
+// GetCounterparty returns a single counterparty
+func (s *CounterpartyService) GetCounterparty(counterpartyID uuid.UUID) (*models.Counterparty, error) {
+	var counterparty models.Counterparty
+	if err := s.db.First(&counterparty, counterpartyID).Error; err != nil {
+		return nil, errors.New("counterparty not found")
+	}
+	return &counterparty, nil
+}
+
+// CreateCounterparty validates and stores a new counterparty
+func (s *CounterpartyService) CreateCounterparty(counterparty *models.Counterparty) error {
+	if err := rules.ValidateCounterparty(counterparty); err != nil {
+		return err
+	}
+	// Select all columns so an explicit is_active=false is not replaced by the column default
+	return s.db.Select("*").Create(counterparty).Error
+}
+
+// UpdateCounterparty validates and saves changes to a counterparty
+func (s *CounterpartyService) UpdateCounterparty(counterparty *models.Counterparty) error {
+	if err := rules.ValidateCounterparty(counterparty); err != nil {
+		return err
+	}
+	return s.db.Save(counterparty).Error
+}
+
+// DeleteCounterparty removes a counterparty that has never been traded with; others must be deactivated
+func (s *CounterpartyService) DeleteCounterparty(counterpartyID uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&models.Transaction{}).Where("counterparty_id = ?", counterpartyID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("counterparty has transactions, deactivate it instead")
+	}
+
+	result := s.db.Delete(&models.Counterparty{}, counterpartyID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("counterparty not found")
+	}
+	return nil
+}
+
+// GetExposures aggregates the open exposure of the user's tenant for every counterparty, largest
+// first
+func (s *CounterpartyService) GetExposures(userID uuid.UUID) ([]CounterpartyExposure, error) {
+	var counterparties []models.Counterparty
+	if err := s.db.Order("name").Find(&counterparties).Error; err != nil {
+		return nil, err
+	}
+
+	rows, err := s.exposureRows(nil, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exposures := make([]CounterpartyExposure, 0, len(counterparties))
+	for i := range counterparties {
+		exposures = append(exposures, buildExposure(&counterparties[i], rows[counterparties[i].ID]))
+	}
+
+	sort.SliceStable(exposures, func(i, j int) bool {
+		return exposures[i].GrossExposure.GreaterThan(exposures[j].GrossExposure)
+	})
+	return exposures, nil
+}
+
+// GetExposure aggregates the open exposure of the user's tenant for one counterparty
+func (s *CounterpartyService) GetExposure(counterpartyID, userID uuid.UUID) (*CounterpartyExposure, error) {
+	counterparty, err := s.GetCounterparty(counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.exposureRows(&counterpartyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := buildExposure(counterparty, rows[counterpartyID])
+	return &exposure, nil
+}
+
+// CheckTransaction runs the counterparty checks for a transaction. It returns nil when the
+// transaction has no linked counterparty.
+func (s *CounterpartyService) CheckTransaction(tx *models.Transaction) (*rules.CounterpartyCheckResult, error) {
+	if tx.CounterpartyID == nil {
+		return nil, nil
+	}
+
+	counterparty, err := s.GetCounterparty(*tx.CounterpartyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exclude the transaction itself so re-checking a stored trade does not count it twice
+	var current decimal.Decimal
+	err = s.db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("counterparty_id = ? AND status IN ? AND id <> ?", counterparty.ID, openTransactionStatuses, tx.ID).
+		Scan(&current).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.checker.CheckTransaction(counterparty, tx.Amount, current)
+	return &result, nil
+}
+
+func (s *CounterpartyService) exposureRows(counterpartyID *uuid.UUID, userID uuid.UUID) (map[uuid.UUID]exposureRow, error) {
+	query := s.db.Model(&models.Transaction{}).
+		Select(`counterparty_id,
+			COALESCE(SUM(amount), 0) AS gross_exposure,
+			COALESCE(SUM(CASE WHEN transaction_type = 'BUY' THEN amount ELSE 0 END), 0) AS buy_volume,
+			COALESCE(SUM(CASE WHEN transaction_type = 'SELL' THEN amount ELSE 0 END), 0) AS sell_volume,
+			COUNT(*) AS transaction_count,
+			COUNT(DISTINCT portfolio_id) AS portfolio_count,
+			MAX(created_at) AS last_transaction_at`).
+		Where("counterparty_id IS NOT NULL AND status IN ?", openTransactionStatuses).
+		Group("counterparty_id")
+	if counterpartyID != nil {
+		query = query.Where("counterparty_id = ?", *counterpartyID)
+	}
+	query = inTenantOf(query, "portfolio_id", userID)
+
+	var rows []exposureRow
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]exposureRow, len(rows))
+	for _, row := range rows {
+		byID[row.CounterpartyID] = row
+	}
+	return byID, nil
+}
+
+func buildExposure(counterparty *models.Counterparty, row exposureRow) CounterpartyExposure {
+	exposure := CounterpartyExposure{
+		CounterpartyID:    counterparty.ID,
+		Name:              counterparty.Name,
+		Jurisdiction:      counterparty.Jurisdiction,
+		RiskRating:        counterparty.RiskRating,
+		KYCStatus:         counterparty.KYCStatus,
+		GrossExposure:     row.GrossExposure,
+		BuyVolume:         row.BuyVolume,
+		SellVolume:        row.SellVolume,
+		NetExposure:       row.BuyVolume.Sub(row.SellVolume),
+		TransactionCount:  row.TransactionCount,
+		PortfolioCount:    row.PortfolioCount,
+		ExposureLimit:     counterparty.ExposureLimit,
+		LastTransactionAt: row.LastTransactionAt,
+	}
+
+	if counterparty.ExposureLimit.IsPositive() {
+		exposure.Utilization = row.GrossExposure.Div(counterparty.ExposureLimit).InexactFloat64()
+		exposure.LimitBreached = row.GrossExposure.GreaterThan(counterparty.ExposureLimit)
+	}
+
+	return exposure
+}
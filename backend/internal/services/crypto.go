@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// Alert sources used to avoid raising the same crypto alert while one is still active
+const (
+	cryptoVenueAlertSource = "CRYPTO_VENUE_MONITOR"
+	depegAlertSource       = "DEPEG_MONITOR"
+)
+
+// cryptoSampleRetention covers the longest crypto volatility window
+const cryptoSampleRetention = 31 * 24 * time.Hour
+
+// CryptoAssetRequest sets the reference data of a crypto asset
+type CryptoAssetRequest struct {
+	Chain        string          `json:"chain"`
+	IsStablecoin bool            `json:"is_stablecoin"`
+	PegCurrency  string          `json:"peg_currency"` // Defaults to USD for a stablecoin
+	PegPrice     decimal.Decimal `json:"peg_price"`    // Defaults to 1 for a stablecoin
+}
+
+// CryptoCustodyInput is the quantity of a crypto asset a portfolio keeps at one venue
+type CryptoCustodyInput struct {
+	Symbol         string          `json:"symbol"`
+	VenueType      string          `json:"venue_type"` // EXCHANGE, CUSTODIAN, HOT_WALLET or COLD_WALLET
+	VenueName      string          `json:"venue_name"`
+	CounterpartyID *uuid.UUID      `json:"counterparty_id"`
+	WalletAddress  string          `json:"wallet_address"`
+	Quantity       decimal.Decimal `json:"quantity"`
+}
+
+// CryptoRiskService keeps crypto reference data, where portfolios custody their crypto and hourly
+// crypto prices, measures crypto volatility, venue exposure and stablecoin pegs, records the
+// largest venue exposure as a risk metric and raises alerts on venue breaches and depegs
+type CryptoRiskService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	calculator   *calculator.CryptoCalculator
+	logger       *slog.Logger
+}
+
+func NewCryptoRiskService() *CryptoRiskService {
+	return &CryptoRiskService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		calculator:   calculator.NewCryptoCalculator(),
+		logger:       logging.Component("crypto_risk"),
+	}
+}
+
+// cryptoAssets returns the built-in crypto asset terms overridden by reference data, keyed by symbol
+func cryptoAssets(db *gorm.DB) (map[string]calculator.CryptoAssetTerms, error) {
+	assets := calculator.DefaultCryptoAssets()
+	var rows []models.CryptoAsset
+	if err := db.Find(&rows).Error; err != nil {
+		return assets, err
+	}
+	for _, row := range rows {
+		assets[row.Symbol] = calculator.CryptoAssetTerms{
+			Chain:      row.Chain,
+			Stablecoin: row.IsStablecoin,
+			PegPrice:   row.PegPrice.InexactFloat64(),
+		}
+	}
+	return assets, nil
+}
+
+// ListAssets returns the reference data of every crypto asset
+func (s *CryptoRiskService) ListAssets() ([]models.CryptoAsset, error) {
+	var assets []models.CryptoAsset
+	err := s.db.Order("symbol").Find(&assets).Error
+	return assets, err
+}
+
+// UpsertAsset sets the reference data of a crypto asset
+func (s *CryptoRiskService) UpsertAsset(symbol string, req CryptoAssetRequest, userID uuid.UUID) (*models.CryptoAsset, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	chain := strings.ToUpper(strings.TrimSpace(req.Chain))
+	if chain == "" {
+		return nil, errors.New("chain is required")
+	}
+	if len(chain) > 30 {
+		return nil, errors.New("chain must be at most 30 characters")
+	}
+
+	asset := models.CryptoAsset{
+		Symbol:       symbol,
+		Chain:        chain,
+		IsStablecoin: req.IsStablecoin,
+		UpdatedBy:    &userID,
+	}
+	if req.IsStablecoin {
+		asset.PegCurrency = strings.ToUpper(strings.TrimSpace(req.PegCurrency))
+		if asset.PegCurrency == "" {
+			asset.PegCurrency = "USD"
+		}
+		if len(asset.PegCurrency) != 3 {
+			return nil, errors.New("peg_currency must be a three-letter currency code")
+		}
+		asset.PegPrice = req.PegPrice
+		if asset.PegPrice.IsZero() {
+			asset.PegPrice = decimal.NewFromInt(1)
+		}
+		if asset.PegPrice.IsNegative() {
+			return nil, errors.New("peg_price must be positive")
+		}
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chain", "is_stablecoin", "peg_currency", "peg_price", "updated_by", "updated_at"}),
+	}).Create(&asset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// DeleteAsset removes the reference data of a crypto asset; built-in assets fall back to their defaults
+func (s *CryptoRiskService) DeleteAsset(symbol string) error {
+	result := s.db.Delete(&models.CryptoAsset{}, "symbol = ?", strings.ToUpper(strings.TrimSpace(symbol)))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("crypto asset not found")
+	}
+	return nil
+}
+
+// GetCustody returns where a portfolio keeps its crypto, with the exchanges' and custodians'
+// counterparty records
+func (s *CryptoRiskService) GetCustody(portfolioID uuid.UUID) ([]models.CryptoCustody, error) {
+	var custody []models.CryptoCustody
+	err := s.db.Preload("Counterparty").Where("portfolio_id = ?", portfolioID).
+		Order("symbol, venue_type, venue_name").Find(&custody).Error
+	return custody, err
+}
+
+// SetCustody replaces the venues a portfolio keeps its crypto at. Exchanges and custodians may be
+// linked to their counterparty record, whose exposure limit then applies to the crypto they hold.
+func (s *CryptoRiskService) SetCustody(portfolioID uuid.UUID, inputs []CryptoCustodyInput, userID uuid.UUID) ([]models.CryptoCustody, error) {
+	if err := requireLivePortfolio(s.db, portfolioID); err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.CryptoCustody, 0, len(inputs))
+	for i, input := range inputs {
+		row := models.CryptoCustody{
+			PortfolioID:    portfolioID,
+			Symbol:         strings.ToUpper(strings.TrimSpace(input.Symbol)),
+			VenueType:      strings.ToUpper(strings.TrimSpace(input.VenueType)),
+			VenueName:      strings.TrimSpace(input.VenueName),
+			CounterpartyID: input.CounterpartyID,
+			WalletAddress:  strings.TrimSpace(input.WalletAddress),
+			Quantity:       input.Quantity,
+			UpdatedBy:      &userID,
+		}
+		if row.Symbol == "" {
+			return nil, fmt.Errorf("custody[%d]: symbol is required", i)
+		}
+		switch row.VenueType {
+		case models.CryptoVenueExchange, models.CryptoVenueCustodian, models.CryptoVenueHotWallet, models.CryptoVenueColdWallet:
+		default:
+			return nil, fmt.Errorf("custody[%d]: venue_type must be EXCHANGE, CUSTODIAN, HOT_WALLET or COLD_WALLET", i)
+		}
+		if row.VenueName == "" {
+			return nil, fmt.Errorf("custody[%d]: venue_name is required", i)
+		}
+		if !row.Quantity.IsPositive() {
+			return nil, fmt.Errorf("custody[%d]: quantity must be positive", i)
+		}
+		if row.CounterpartyID != nil {
+			var count int64
+			if err := s.db.Model(&models.Counterparty{}).Where("id = ?", *row.CounterpartyID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("custody[%d]: counterparty not found", i)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("portfolio_id = ?", portfolioID).Delete(&models.CryptoCustody{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCustody(portfolioID)
+}
+
+// RecordPrices keeps the latest price of the crypto assets among a batch of feed prices as the
+// sample for the current hour
+func (s *CryptoRiskService) RecordPrices(prices map[string]float64) error {
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	assets, err := cryptoAssets(s.db)
+	if err != nil {
+		return err
+	}
+	var held []string
+	if err := s.db.Model(&models.Position{}).Where("asset_type = ? AND symbol IN ?", "CRYPTO", symbols).Distinct().Pluck("symbol", &held).Error; err != nil {
+		return err
+	}
+	for _, symbol := range held {
+		if _, ok := assets[symbol]; !ok {
+			assets[symbol] = calculator.CryptoAssetTerms{}
+		}
+	}
+
+	hour := time.Now().UTC().Truncate(time.Hour)
+	rows := []models.CryptoPriceSample{}
+	for _, symbol := range symbols {
+		_, known := assets[symbol]
+		if prices[symbol] <= 0 || !known && calculator.ClassifySymbol(symbol, "").AssetClass != "CRYPTO" {
+			continue
+		}
+		rows = append(rows, models.CryptoPriceSample{Symbol: symbol, Hour: hour, Price: decimal.NewFromFloat(prices[symbol])})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "hour"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price"}),
+	}).Create(&rows).Error
+}
+
+// CheckCryptoRisk analyses a portfolio's crypto assets and checks where they are held against its
+// MaxVenueExposure threshold and its stablecoins against MaxStablecoinDepeg
+func (s *CryptoRiskService) CheckCryptoRisk(ctx context.Context, portfolioID uuid.UUID) (*calculator.CryptoRiskResult, error) {
+	defer metrics.RiskCalculationDuration.With("crypto").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.crypto", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := cryptoAssets(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	samples, err := s.priceSamples(portfolio.Positions, assets, now.Add(-cryptoSampleRetention))
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.custodyHoldings(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.calculator.Calculate(portfolio.Positions, assets, samples, holdings, calculator.CryptoLimits{
+		MaxVenueExposure:   thresholds.MaxVenueExposure.InexactFloat64(),
+		MaxStablecoinDepeg: thresholds.MaxStablecoinDepeg.InexactFloat64(),
+	}, now)
+	if len(result.Positions) == 0 {
+		return result, nil
+	}
+
+	depegged := []string{}
+	for _, peg := range result.Stablecoins {
+		if peg.Depegged {
+			depegged = append(depegged, peg.Symbol)
+		}
+	}
+	metric := models.RiskMetric{
+		PortfolioID: portfolioID,
+		MetricType:  "CRYPTO_VENUE_EXPOSURE",
+		Value:       decimal.NewFromFloat(result.LargestVenueShare).Round(6),
+		Threshold:   thresholds.MaxVenueExposure,
+		Status:      result.Status,
+		Details: models.JSON{
+			"crypto_value":      result.CryptoValue,
+			"counterparty_held": result.CounterpartyHeld,
+			"self_custodied":    result.SelfCustodied,
+			"unallocated_value": result.UnallocatedValue,
+			"depegged":          depegged,
+		},
+	}
+	if err := s.db.Create(&metric).Error; err != nil {
+		return nil, err
+	}
+
+	s.raiseAlerts(ctx, portfolioID, result)
+	return result, nil
+}
+
+// priceSamples loads the hourly samples since a time of the crypto assets held in positions
+func (s *CryptoRiskService) priceSamples(positions []models.Position, assets map[string]calculator.CryptoAssetTerms, since time.Time) (map[string][]calculator.PriceSample, error) {
+	symbols := []string{}
+	for _, position := range positions {
+		if calculator.IsCryptoPosition(position, assets) {
+			symbols = append(symbols, position.Symbol)
+		}
+	}
+
+	samples := make(map[string][]calculator.PriceSample)
+	if len(symbols) == 0 {
+		return samples, nil
+	}
+
+	var rows []models.CryptoPriceSample
+	if err := s.db.Where("symbol IN ? AND hour >= ?", symbols, since).Order("hour ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		samples[row.Symbol] = append(samples[row.Symbol], calculator.PriceSample{Time: row.Hour, Price: row.Price.InexactFloat64()})
+	}
+	return samples, nil
+}
+
+// custodyHoldings loads where a portfolio keeps its crypto, with the exposure limits of the
+// counterparties holding it
+func (s *CryptoRiskService) custodyHoldings(portfolioID uuid.UUID) ([]calculator.CustodyHolding, error) {
+	custody, err := s.GetCustody(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := make([]calculator.CustodyHolding, 0, len(custody))
+	for _, row := range custody {
+		holding := calculator.CustodyHolding{
+			Symbol:         row.Symbol,
+			VenueType:      row.VenueType,
+			VenueName:      row.VenueName,
+			CounterpartyID: row.CounterpartyID,
+			Quantity:       row.Quantity.InexactFloat64(),
+		}
+		if row.Counterparty != nil {
+			holding.ExposureLimit = row.Counterparty.ExposureLimit.InexactFloat64()
+		}
+		holdings = append(holdings, holding)
+	}
+	return holdings, nil
+}
+
+// raiseAlerts raises venue exposure and depeg alerts unless the same alert is still active
+func (s *CryptoRiskService) raiseAlerts(ctx context.Context, portfolioID uuid.UUID, result *calculator.CryptoRiskResult) {
+	venues := []string{}
+	for _, venue := range result.Venues {
+		if venue.Breached {
+			venues = append(venues, venue.VenueName)
+		}
+	}
+	if len(venues) > 0 && !s.hasActiveAlert(portfolioID, cryptoVenueAlertSource) {
+		alert := &models.Alert{
+			PortfolioID: portfolioID,
+			AlertType:   "RISK_BREACH",
+			Severity:    "HIGH",
+			Title:       "Crypto Venue Exposure Breached",
+			Description: fmt.Sprintf("Too much crypto is held at %s", strings.Join(venues, ", ")),
+			Source:      cryptoVenueAlertSource,
+			Status:      "ACTIVE",
+			TriggeredBy: models.JSON{
+				"metric_type":         "CRYPTO_VENUE_EXPOSURE",
+				"venues":              venues,
+				"largest_venue_share": result.LargestVenueShare,
+				"max_venue_exposure":  result.MaxVenueExposure,
+				"crypto_value":        result.CryptoValue,
+			},
+		}
+		if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create crypto venue alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+
+	depegged := []string{}
+	severity := "HIGH"
+	for _, peg := range result.Stablecoins {
+		if !peg.Depegged {
+			continue
+		}
+		depegged = append(depegged, fmt.Sprintf("%s at %.4f (%+.2f%%)", peg.Symbol, peg.Price, peg.Deviation*100))
+		if result.MaxDepeg > 0 && math.Abs(peg.Deviation) >= 2*result.MaxDepeg {
+			severity = "CRITICAL"
+		}
+	}
+	if len(depegged) > 0 && !s.hasActiveAlert(portfolioID, depegAlertSource) {
+		alert := &models.Alert{
+			PortfolioID: portfolioID,
+			AlertType:   "RISK_BREACH",
+			Severity:    severity,
+			Title:       "Stablecoin Depeg",
+			Description: fmt.Sprintf("Stablecoins trading away from their peg: %s", strings.Join(depegged, ", ")),
+			Source:      depegAlertSource,
+			Status:      "ACTIVE",
+			TriggeredBy: models.JSON{
+				"metric_type":          "STABLECOIN_DEPEG",
+				"stablecoins":          result.Stablecoins,
+				"max_stablecoin_depeg": result.MaxDepeg,
+			},
+		}
+		if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create depeg alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+}
+
+func (s *CryptoRiskService) hasActiveAlert(portfolioID uuid.UUID, source string) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, source, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// StartMonitor checks the crypto risk of every portfolio holding crypto at a fixed interval, until
+// ctx is cancelled, and prunes price samples older than the longest volatility window
+func (s *CryptoRiskService) StartMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Crypto risk monitor disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if err := s.db.Where("hour < ?", time.Now().Add(-cryptoSampleRetention)).Delete(&models.CryptoPriceSample{}).Error; err != nil {
+			s.logger.ErrorContext(ctx, "Failed to prune crypto price samples", "error", err)
+		}
+
+		assets, err := cryptoAssets(s.db)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Crypto risk monitor failed to load crypto assets", "error", err)
+			continue
+		}
+		symbols := make([]string, 0, len(assets))
+		for symbol := range assets {
+			symbols = append(symbols, symbol)
+		}
+
+		var portfolioIDs []uuid.UUID
+		err = s.db.Model(&models.Position{}).
+			Where("asset_type = ? OR symbol IN ?", "CRYPTO", symbols).
+			Distinct("portfolio_id").
+			Pluck("portfolio_id", &portfolioIDs).Error
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Crypto risk monitor failed to load portfolios", "error", err)
+			continue
+		}
+
+		for _, portfolioID := range portfolioIDs {
+			if _, err := s.CheckCryptoRisk(ctx, portfolioID); err != nil {
+				s.logger.ErrorContext(ctx, "Crypto risk check failed", "portfolio_id", portfolioID, "error", err)
+			}
+		}
+	}
+}
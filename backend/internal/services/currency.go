@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/fx"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// fxAnnualVolatility is the assumed annual volatility of a currency pair, used for FX VaR
+const fxAnnualVolatility = 0.10
+
+// fxRate returns the rate converting one currency into another through the shared FX converter
+func fxRate(from, to string) (decimal.Decimal, error) {
+	if fx.Normalize(from) == fx.Normalize(to) {
+		return decimal.NewFromInt(1), nil
+	}
+	converter := fx.GetConverter()
+	if converter == nil {
+		return decimal.Zero, fmt.Errorf("no FX converter to convert %s into %s", from, to)
+	}
+	return converter.Rate(context.Background(), from, to)
+}
+
+// CurrencyService reports a portfolio's exposure by currency, records its FX risk as a risk metric
+// and keeps foreign positions marked at current exchange rates
+type CurrencyService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	pnlService   *PnLService
+	calculator   *calculator.CurrencyExposureCalculator
+	logger       *slog.Logger
+}
+
+func NewCurrencyService() *CurrencyService {
+	return &CurrencyService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		pnlService:   NewPnLService(),
+		calculator:   calculator.NewCurrencyExposureCalculator(fxAnnualVolatility),
+		logger:       logging.Component("currency"),
+	}
+}
+
+// CheckExposure values a portfolio's positions by currency at current rates and checks the
+// foreign currency share against its MaxFXExposure threshold
+func (s *CurrencyService) CheckExposure(ctx context.Context, portfolioID uuid.UUID) (*calculator.CurrencyExposureResult, error) {
+	defer metrics.RiskCalculationDuration.With("fx_exposure").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.fx_exposure", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.calculator.CalculateExposure(portfolio.Positions, portfolio.Currency,
+		s.currentRates(ctx, &portfolio), thresholds.MaxFXExposure.InexactFloat64())
+
+	metric := models.RiskMetric{
+		PortfolioID: portfolioID,
+		MetricType:  "FX_RISK",
+		Value:       decimal.NewFromFloat(result.ForeignExposureRatio).Round(4),
+		Threshold:   thresholds.MaxFXExposure,
+		Status:      result.Status,
+		Details: models.JSON{
+			"base_currency":    result.BaseCurrency,
+			"foreign_exposure": result.ForeignExposure,
+			"gross_exposure":   result.GrossExposure,
+			"fx_var_95":        result.FXVaR95,
+			"currency_count":   len(result.Currencies),
+		},
+	}
+	if err := s.db.Create(&metric).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Breaches) > 0 && !s.hasActiveAlert(portfolioID) {
+		if err := s.alertService.CreateRiskBreachAlert(ctx, portfolioID, "FX_RISK", result.ForeignExposureRatio, result.MaxForeignExposure); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create FX risk alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// currentRates returns the rate into the portfolio currency of each currency its positions are
+// held in. Currencies without a rate are left out and the calculator reports them as stale.
+func (s *CurrencyService) currentRates(ctx context.Context, portfolio *models.Portfolio) map[string]float64 {
+	rates := make(map[string]float64)
+	looked := make(map[string]bool)
+	for _, position := range portfolio.Positions {
+		currency := fx.Normalize(position.Currency)
+		if looked[currency] {
+			continue
+		}
+		looked[currency] = true
+
+		rate, err := fxRate(currency, portfolio.Currency)
+		if err != nil {
+			s.logger.WarnContext(ctx, "No exchange rate for position currency", "portfolio_id", portfolio.ID, "currency", currency, "error", err)
+			continue
+		}
+		rates[currency] = rate.InexactFloat64()
+	}
+	return rates
+}
+
+func (s *CurrencyService) hasActiveAlert(portfolioID uuid.UUID) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, "FX_RISK_CALCULATOR", "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// StartMonitor re-marks foreign positions at the latest rates and checks the FX exposure of every
+// portfolio holding them at a fixed interval until ctx is cancelled
+func (s *CurrencyService) StartMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if err := s.pnlService.RevalueFX(); err != nil {
+			s.logger.ErrorContext(ctx, "FX revaluation failed", "error", err)
+		}
+
+		var portfolioIDs []uuid.UUID
+		err := s.db.Model(&models.Position{}).
+			Joins("JOIN portfolios ON portfolios.id = positions.portfolio_id").
+			Where("COALESCE(positions.currency, 'USD') <> COALESCE(portfolios.currency, 'USD')").
+			Distinct("positions.portfolio_id").
+			Pluck("positions.portfolio_id", &portfolioIDs).Error
+		if err != nil {
+			s.logger.ErrorContext(ctx, "FX monitor failed to load portfolios", "error", err)
+			continue
+		}
+
+		for _, portfolioID := range portfolioIDs {
+			if _, err := s.CheckExposure(ctx, portfolioID); err != nil {
+				s.logger.ErrorContext(ctx, "FX exposure check failed", "portfolio_id", portfolioID, "error", err)
+			}
+		}
+	}
+}
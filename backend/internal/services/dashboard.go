@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/cache"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// AlertCounts is the number of active alerts, in total and by severity
+type AlertCounts struct {
+	Total      int64            `json:"total"`
+	BySeverity map[string]int64 `json:"by_severity"`
+}
+
+// PortfolioSummary is the dashboard view of a portfolio: its value, latest risk metrics and
+// active alerts
+type PortfolioSummary struct {
+	PortfolioID   uuid.UUID           `json:"portfolio_id"`
+	Name          string              `json:"name"`
+	Currency      string              `json:"currency"`
+	TotalValue    decimal.Decimal     `json:"total_value"`
+	CashBalance   decimal.Decimal     `json:"cash_balance"`
+	ValueWithCash decimal.Decimal     `json:"value_with_cash"`
+	PositionCount int64               `json:"position_count"`
+	LatestMetrics []models.RiskMetric `json:"latest_metrics"`
+	ActiveAlerts  AlertCounts         `json:"active_alerts"`
+}
+
+// RiskMetricPage is a page of a portfolio's risk metrics and the total match count
+type RiskMetricPage struct {
+	Metrics []models.RiskMetric `json:"metrics"`
+	Total   int64               `json:"total"`
+}
+
+// DashboardService serves the reads dashboards refresh constantly through the read-through cache
+type DashboardService struct {
+	db            *gorm.DB
+	accessService *AccessService
+}
+
+func NewDashboardService() *DashboardService {
+	return &DashboardService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+	}
+}
+
+// ListRiskMetrics returns a page of a portfolio's risk metrics with their portfolio and its owner.
+// query identifies the page in the cache and should be the request's query string.
+func (s *DashboardService) ListRiskMetrics(ctx context.Context, portfolioID uuid.UUID, spec pagination.Spec, params pagination.Params, query string) (*RiskMetricPage, error) {
+	return cache.Get(ctx, cache.RiskMetrics, "list:"+portfolioID.String()+"?"+query, func() (*RiskMetricPage, error) {
+		page := &RiskMetricPage{}
+		scoped := s.db.WithContext(ctx).Model(&models.RiskMetric{}).Where("portfolio_id = ?", portfolioID)
+		total, err := pagination.Find(scoped, spec, params, &page.Metrics, "Portfolio", "Portfolio.User")
+		page.Total = total
+		return page, err
+	})
+}
+
+// LatestRiskMetrics returns the most recent metric of each type calculated for a portfolio
+func (s *DashboardService) LatestRiskMetrics(ctx context.Context, portfolioID uuid.UUID) ([]models.RiskMetric, error) {
+	return cache.Get(ctx, cache.RiskMetrics, "latest:"+portfolioID.String(), func() ([]models.RiskMetric, error) {
+		metrics := []models.RiskMetric{}
+		err := s.db.WithContext(ctx).Raw(`SELECT DISTINCT ON (metric_type) * FROM risk_metrics
+			WHERE portfolio_id = ? ORDER BY metric_type, calculated_at DESC`, portfolioID).
+			Scan(&metrics).Error
+		return metrics, err
+	})
+}
+
+// PortfolioSummary returns the dashboard summary of a portfolio
+func (s *DashboardService) PortfolioSummary(ctx context.Context, portfolioID uuid.UUID) (*PortfolioSummary, error) {
+	return cache.Get(ctx, cache.Portfolios, "summary:"+portfolioID.String(), func() (*PortfolioSummary, error) {
+		var portfolio models.Portfolio
+		if err := s.db.WithContext(ctx).First(&portfolio, portfolioID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("portfolio not found")
+			}
+			return nil, err
+		}
+
+		summary := &PortfolioSummary{
+			PortfolioID:   portfolio.ID,
+			Name:          portfolio.Name,
+			Currency:      portfolio.Currency,
+			TotalValue:    portfolio.TotalValue,
+			CashBalance:   portfolio.CashBalance,
+			ValueWithCash: portfolio.ValueWithCash(),
+		}
+		err := s.db.WithContext(ctx).Model(&models.Position{}).
+			Where("portfolio_id = ?", portfolioID).
+			Count(&summary.PositionCount).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if summary.LatestMetrics, err = s.LatestRiskMetrics(ctx, portfolioID); err != nil {
+			return nil, err
+		}
+		counts, err := s.PortfolioAlertCounts(ctx, portfolioID)
+		if err != nil {
+			return nil, err
+		}
+		summary.ActiveAlerts = *counts
+		return summary, nil
+	})
+}
+
+// PortfolioAlertCounts returns the active alerts of a portfolio
+func (s *DashboardService) PortfolioAlertCounts(ctx context.Context, portfolioID uuid.UUID) (*AlertCounts, error) {
+	return cache.Get(ctx, cache.Alerts, "active_counts:portfolio:"+portfolioID.String(), func() (*AlertCounts, error) {
+		return s.countActiveAlerts(s.db.WithContext(ctx).Model(&models.Alert{}).Where("portfolio_id = ?", portfolioID))
+	})
+}
+
+// ActiveAlertCounts returns the active alerts across the portfolios a user can see
+func (s *DashboardService) ActiveAlertCounts(ctx context.Context, userID uuid.UUID, role string) (*AlertCounts, error) {
+	scope := "all"
+	if !HasGlobalScope(role) {
+		scope = "user:" + userID.String()
+	} else {
+		tenantID, err := s.accessService.TenantOf(userID)
+		if err != nil {
+			return nil, err
+		}
+		if tenantID != nil {
+			scope = "tenant:" + tenantID.String()
+		}
+	}
+	return cache.Get(ctx, cache.Alerts, "active_counts:"+scope, func() (*AlertCounts, error) {
+		query := s.accessService.ScopeQuery(s.db.WithContext(ctx).Model(&models.Alert{}), "portfolio_id", userID, role)
+		return s.countActiveAlerts(query)
+	})
+}
+
+func (s *DashboardService) countActiveAlerts(query *gorm.DB) (*AlertCounts, error) {
+	var rows []struct {
+		Severity string
+		Count    int64
+	}
+	err := query.Select("severity, COUNT(*) AS count").Where("status = ?", "ACTIVE").Group("severity").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &AlertCounts{BySeverity: make(map[string]int64, len(rows))}
+	for _, row := range rows {
+		counts.BySeverity[row.Severity] = row.Count
+		counts.Total += row.Count
+	}
+	return counts, nil
+}
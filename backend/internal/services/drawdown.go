@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// drawdownLookbackDays is how far back the NAV history is searched for the peak of a drawdown
+const drawdownLookbackDays = 365
+
+// Alert sources used to avoid raising the same loss limit alert while one is still active
+const (
+	dailyLossAlertSource  = "DAILY_LOSS_MONITOR"
+	weeklyLossAlertSource = "WEEKLY_LOSS_MONITOR"
+	drawdownAlertSource   = "DRAWDOWN_MONITOR"
+)
+
+// DrawdownService tracks each portfolio's daily and weekly losses and its drawdown from the peak
+// against its loss limits, using the end-of-day NAV snapshots and the current NAV
+type DrawdownService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	calculator   *calculator.DrawdownCalculator
+	logger       *slog.Logger
+}
+
+func NewDrawdownService() *DrawdownService {
+	return &DrawdownService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		calculator:   calculator.NewDrawdownCalculator(),
+		logger:       logging.Component("drawdown"),
+	}
+}
+
+// CheckDrawdown calculates and records a portfolio's losses and drawdown against its MaxDailyLoss,
+// MaxWeeklyLoss and MaxDrawdown thresholds, raising a CRITICAL alert for each limit hit
+func (s *DrawdownService) CheckDrawdown(ctx context.Context, portfolioID uuid.UUID) (*calculator.DrawdownResult, error) {
+	defer metrics.RiskCalculationDuration.With("drawdown").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.drawdown", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var snapshots []models.PortfolioSnapshot
+	err = s.db.WithContext(ctx).Select("date", "nav").
+		Where("portfolio_id = ? AND date >= ?", portfolioID, now.UTC().AddDate(0, 0, -drawdownLookbackDays).Format("2006-01-02")).
+		Order("date").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	history := make([]calculator.NAVPoint, len(snapshots))
+	for i, snapshot := range snapshots {
+		history[i] = calculator.NAVPoint{Date: snapshot.Date, NAV: snapshot.NAV.InexactFloat64()}
+	}
+
+	_, nav := portfolioNAV(&portfolio)
+	result := s.calculator.CalculateDrawdown(history, nav.InexactFloat64(), now, calculator.LossLimits{
+		MaxDailyLoss:  thresholds.MaxDailyLoss.InexactFloat64(),
+		MaxWeeklyLoss: thresholds.MaxWeeklyLoss.InexactFloat64(),
+		MaxDrawdown:   thresholds.MaxDrawdown.InexactFloat64(),
+	})
+
+	metric := models.RiskMetric{
+		PortfolioID: portfolioID,
+		MetricType:  "DRAWDOWN",
+		Value:       decimal.NewFromFloat(result.CurrentDrawdown).Round(4),
+		Threshold:   thresholds.MaxDrawdown,
+		Status:      result.Status,
+		Details: models.JSON{
+			"current_nav":  result.CurrentNAV,
+			"peak_nav":     result.PeakNAV,
+			"daily_loss":   result.DailyLoss,
+			"weekly_loss":  result.WeeklyLoss,
+			"max_drawdown": result.MaxDrawdown,
+		},
+	}
+	if err := s.db.Create(&metric).Error; err != nil {
+		return nil, err
+	}
+
+	s.raiseAlerts(ctx, portfolioID, result)
+	return result, nil
+}
+
+// raiseAlerts raises a CRITICAL alert for each loss limit hit unless the same alert is still active
+func (s *DrawdownService) raiseAlerts(ctx context.Context, portfolioID uuid.UUID, result *calculator.DrawdownResult) {
+	limits := []struct {
+		hit       bool
+		source    string
+		title     string
+		value     float64
+		limit     float64
+		reference *calculator.NAVPoint
+	}{
+		{result.DailyLimitHit, dailyLossAlertSource, "Daily Loss Limit Hit", result.DailyLoss, result.Limits.MaxDailyLoss, result.PreviousClose},
+		{result.WeeklyLimitHit, weeklyLossAlertSource, "Weekly Loss Limit Hit", result.WeeklyLoss, result.Limits.MaxWeeklyLoss, result.WeekStart},
+		{result.DrawdownLimitHit, drawdownAlertSource, "Maximum Drawdown Hit", result.CurrentDrawdown, result.Limits.MaxDrawdown, nil},
+	}
+
+	for _, l := range limits {
+		if !l.hit || s.hasActiveAlert(portfolioID, l.source) {
+			continue
+		}
+
+		referenceNAV := result.PeakNAV
+		triggeredBy := models.JSON{
+			"metric_type": "DRAWDOWN",
+			"current_nav": result.CurrentNAV,
+			"loss":        l.value,
+			"limit":       l.limit,
+		}
+		if l.reference != nil {
+			referenceNAV = l.reference.NAV
+			triggeredBy["reference_date"] = l.reference.Date.Format("2006-01-02")
+		}
+		triggeredBy["reference_nav"] = referenceNAV
+
+		alert := &models.Alert{
+			PortfolioID: portfolioID,
+			AlertType:   "RISK_BREACH",
+			Severity:    "CRITICAL",
+			Title:       l.title,
+			Description: fmt.Sprintf("NAV of %.2f is %.2f%% below %.2f, over the %.2f%% limit",
+				result.CurrentNAV, l.value*100, referenceNAV, l.limit*100),
+			Source:      l.source,
+			Status:      "ACTIVE",
+			TriggeredBy: triggeredBy,
+		}
+		if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create loss limit alert", "portfolio_id", portfolioID, "source", l.source, "error", err)
+		}
+	}
+}
+
+func (s *DrawdownService) hasActiveAlert(portfolioID uuid.UUID, source string) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, source, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// StartMonitor checks the losses and drawdown of every portfolio with NAV history at a fixed
+// interval until ctx is cancelled
+func (s *DrawdownService) StartMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Drawdown monitor disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.CheckAll(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Drawdown monitor failed to load portfolios", "error", err)
+		}
+	}
+}
+
+// CheckAll checks every portfolio with NAV history and returns how many were checked. A portfolio
+// that fails is logged and skipped.
+func (s *DrawdownService) CheckAll(ctx context.Context) (int, error) {
+	var portfolioIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.PortfolioSnapshot{}).Distinct("portfolio_id").Pluck("portfolio_id", &portfolioIDs).Error; err != nil {
+		return 0, err
+	}
+
+	checked := 0
+	for _, portfolioID := range portfolioIDs {
+		if _, err := s.CheckDrawdown(ctx, portfolioID); err != nil {
+			s.logger.ErrorContext(ctx, "Drawdown check failed", "portfolio_id", portfolioID, "error", err)
+			continue
+		}
+		checked++
+	}
+	return checked, nil
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
+)
+
+// escalationRoles are the roles a tier may escalate to
+var escalationRoles = map[string]bool{
+	models.RoleAdmin:             true,
+	models.RoleAnalyst:           true,
+	models.RoleTrader:            true,
+	models.RoleComplianceOfficer: true,
+}
+
+type EscalationService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewEscalationService() *EscalationService {
+	return &EscalationService{
+		db:     database.GetDB(),
+		logger: logging.Component("escalation"),
+	}
+}
+
+// ListTeams returns all teams by name
+func (s *EscalationService) ListTeams() ([]models.Team, error) {
+	var teams []models.Team
+	err := s.db.Order("name").Find(&teams).Error
+	return teams, err
+}
+
+// GetTeam returns a team by ID
+func (s *EscalationService) GetTeam(teamID uuid.UUID) (*models.Team, error) {
+	var team models.Team
+	if err := s.db.First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("team not found")
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam validates and stores a new team
+func (s *EscalationService) CreateTeam(team *models.Team) error {
+	if team.Name = strings.TrimSpace(team.Name); team.Name == "" {
+		return errors.New("team name is required")
+	}
+	return s.db.Create(team).Error
+}
+
+// UpdateTeam validates and saves changes to a team
+func (s *EscalationService) UpdateTeam(team *models.Team) error {
+	if team.Name = strings.TrimSpace(team.Name); team.Name == "" {
+		return errors.New("team name is required")
+	}
+	return s.db.Save(team).Error
+}
+
+// DeleteTeam removes a team with its rota and members unless an escalation policy still escalates
+// to it or it owns portfolios
+func (s *EscalationService) DeleteTeam(teamID uuid.UUID) error {
+	var count int64
+	err := s.db.Model(&models.EscalationPolicy{}).
+		Where("tiers @> ?", fmt.Sprintf(`[{"target_type":%q,"target_id":%q}]`, models.EscalationTargetTeam, teamID)).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("team is used by an escalation policy")
+	}
+	if err := s.db.Model(&models.Portfolio{}).Where("team_id = ?", teamID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("team owns portfolios")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.OnCallShift{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", teamID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Team{}, teamID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("team not found")
+		}
+		return nil
+	})
+}
+
+// ListShifts returns a team's shifts overlapping [from, to), earliest first
+func (s *EscalationService) ListShifts(teamID uuid.UUID, from, to time.Time) ([]models.OnCallShift, error) {
+	var shifts []models.OnCallShift
+	err := s.db.Preload("User").
+		Where("team_id = ? AND starts_at < ? AND ends_at > ?", teamID, to, from).
+		Order("starts_at").
+		Find(&shifts).Error
+	return shifts, err
+}
+
+// CreateShift puts an active user on call for a team
+func (s *EscalationService) CreateShift(shift *models.OnCallShift) error {
+	if !shift.EndsAt.After(shift.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if _, err := s.GetTeam(shift.TeamID); err != nil {
+		return err
+	}
+	if _, err := s.activeUser(shift.UserID); err != nil {
+		return err
+	}
+	return s.db.Create(shift).Error
+}
+
+// DeleteShift removes a shift from a team's rota
+func (s *EscalationService) DeleteShift(teamID, shiftID uuid.UUID) error {
+	result := s.db.Where("team_id = ?", teamID).Delete(&models.OnCallShift{}, shiftID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("shift not found")
+	}
+	return nil
+}
+
+// OnCall returns the active users on call for a team at a point in time
+func (s *EscalationService) OnCall(teamID uuid.UUID, at time.Time) ([]models.User, error) {
+	var users []models.User
+	err := s.db.
+		Where("id IN (?)", s.db.Model(&models.OnCallShift{}).
+			Select("user_id").
+			Where("team_id = ? AND starts_at <= ? AND ends_at > ?", teamID, at, at)).
+		Where("is_active = ?", true).
+		Order("email").
+		Find(&users).Error
+	return users, err
+}
+
+// ListPolicies returns all escalation policies
+func (s *EscalationService) ListPolicies() ([]models.EscalationPolicy, error) {
+	var policies []models.EscalationPolicy
+	err := s.db.Order("created_at").Find(&policies).Error
+	return policies, err
+}
+
+// GetPolicy returns an escalation policy by ID
+func (s *EscalationService) GetPolicy(policyID uuid.UUID) (*models.EscalationPolicy, error) {
+	var policy models.EscalationPolicy
+	if err := s.db.First(&policy, policyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("escalation policy not found")
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// CreatePolicy validates and stores a new escalation policy
+func (s *EscalationService) CreatePolicy(policy *models.EscalationPolicy) error {
+	if err := s.validatePolicy(policy); err != nil {
+		return err
+	}
+	// Select all columns so an explicit is_active=false is not replaced by the column default
+	return s.db.Select("*").Create(policy).Error
+}
+
+// UpdatePolicy validates and saves changes to an escalation policy. Alerts already escalating
+// under it continue from the tier they reached.
+func (s *EscalationService) UpdatePolicy(policy *models.EscalationPolicy) error {
+	if err := s.validatePolicy(policy); err != nil {
+		return err
+	}
+	return s.db.Save(policy).Error
+}
+
+// DeletePolicy removes an escalation policy that has never escalated an alert; others must be
+// deactivated so the alert timelines stay complete
+func (s *EscalationService) DeletePolicy(policyID uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&models.AlertEscalation{}).Where("policy_id = ?", policyID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("escalation policy has escalated alerts, deactivate it instead")
+	}
+
+	result := s.db.Delete(&models.EscalationPolicy{}, policyID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("escalation policy not found")
+	}
+	return nil
+}
+
+func (s *EscalationService) validatePolicy(policy *models.EscalationPolicy) error {
+	policy.Severity = strings.ToUpper(strings.TrimSpace(policy.Severity))
+	policy.AlertType = strings.ToUpper(strings.TrimSpace(policy.AlertType))
+
+	if strings.TrimSpace(policy.Name) == "" {
+		return errors.New("policy name is required")
+	}
+	switch policy.Severity {
+	case "LOW", "MEDIUM", "HIGH", "CRITICAL":
+	default:
+		return errors.New("unsupported severity: " + policy.Severity)
+	}
+	if len(policy.Tiers) == 0 {
+		return errors.New("at least one tier is required")
+	}
+
+	previous := 0
+	for i := range policy.Tiers {
+		tier := &policy.Tiers[i]
+		if tier.AfterMinutes <= previous {
+			return fmt.Errorf("tier %d: after_minutes must be greater than %d", i+1, previous)
+		}
+		previous = tier.AfterMinutes
+
+		tier.TargetType = strings.ToUpper(tier.TargetType)
+		switch tier.TargetType {
+		case models.EscalationTargetTeam:
+			if tier.TargetID == nil {
+				return fmt.Errorf("tier %d: target_id is required", i+1)
+			}
+			if _, err := s.GetTeam(*tier.TargetID); err != nil {
+				return fmt.Errorf("tier %d: %w", i+1, err)
+			}
+			tier.Role = ""
+		case models.EscalationTargetUser:
+			if tier.TargetID == nil {
+				return fmt.Errorf("tier %d: target_id is required", i+1)
+			}
+			if _, err := s.activeUser(*tier.TargetID); err != nil {
+				return fmt.Errorf("tier %d: %w", i+1, err)
+			}
+			tier.Role = ""
+		case models.EscalationTargetRole:
+			if !escalationRoles[tier.Role] {
+				return fmt.Errorf("tier %d: unsupported role %q", i+1, tier.Role)
+			}
+			tier.TargetID = nil
+		default:
+			return fmt.Errorf("tier %d: target_type must be TEAM, ROLE or USER", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (s *EscalationService) activeUser(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("user is deactivated")
+	}
+	return &user, nil
+}
+
+// StartScheduler escalates overdue alerts at a fixed interval until ctx is cancelled
+func (s *EscalationService) StartScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.EscalateDue(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Alert escalation failed", "error", err)
+		}
+	}
+}
+
+// EscalateDue moves every unacknowledged alert covered by a policy through the tiers it is due
+// for, and returns the number of escalations made. An alert keeps the policy it first escalated
+// under; otherwise a policy for its alert type wins over one for any type.
+func (s *EscalationService) EscalateDue(ctx context.Context) (int, error) {
+	var policies []models.EscalationPolicy
+	if err := s.db.WithContext(ctx).Where("is_active = ?", true).Order("created_at").Find(&policies).Error; err != nil {
+		return 0, err
+	}
+	if len(policies) == 0 {
+		return 0, nil
+	}
+
+	severities := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		severities = append(severities, policy.Severity)
+	}
+
+	var alerts []models.Alert
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND acknowledged_at IS NULL AND severity IN ?", "ACTIVE", severities).
+		Find(&alerts).Error
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	escalated := 0
+	for i := range alerts {
+		alert := &alerts[i]
+		policy := matchPolicy(policies, alert)
+		if policy == nil {
+			continue
+		}
+
+		elapsed := now.Sub(alert.CreatedAt)
+		for alert.EscalationLevel < len(policy.Tiers) &&
+			elapsed >= time.Duration(policy.Tiers[alert.EscalationLevel].AfterMinutes)*time.Minute {
+			ok, err := s.escalate(ctx, alert, policy, now)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to escalate alert", "alert_id", alert.ID, "error", err)
+				break
+			}
+			if !ok {
+				// Acknowledged, resolved or escalated by another instance meanwhile
+				break
+			}
+			escalated++
+		}
+	}
+
+	return escalated, nil
+}
+
+// matchPolicy returns the policy governing an alert, or nil if none covers it
+func matchPolicy(policies []models.EscalationPolicy, alert *models.Alert) *models.EscalationPolicy {
+	var fallback *models.EscalationPolicy
+	for i := range policies {
+		policy := &policies[i]
+		if alert.EscalationPolicyID != nil {
+			if policy.ID == *alert.EscalationPolicyID {
+				return policy
+			}
+			continue
+		}
+		if policy.Severity != alert.Severity {
+			continue
+		}
+		if policy.AlertType == alert.AlertType {
+			return policy
+		}
+		if policy.AlertType == "" && fallback == nil {
+			fallback = policy
+		}
+	}
+	return fallback
+}
+
+// escalate moves an alert to its next tier and notifies that tier. It reports false when the
+// alert changed since it was loaded, so each tier is escalated once across instances.
+func (s *EscalationService) escalate(ctx context.Context, alert *models.Alert, policy *models.EscalationPolicy, now time.Time) (bool, error) {
+	tier := policy.Tiers[alert.EscalationLevel]
+	level := alert.EscalationLevel + 1
+
+	recipients, note, err := s.recipients(tier, alert.TenantID, now)
+	if err != nil {
+		return false, err
+	}
+
+	escalation := models.AlertEscalation{
+		AlertID:    alert.ID,
+		PolicyID:   policy.ID,
+		Tier:       level,
+		TargetType: tier.TargetType,
+		TargetID:   tier.TargetID,
+		Role:       tier.Role,
+		Recipients: make([]uuid.UUID, 0, len(recipients)),
+		Note:       note,
+	}
+	emails := make([]string, 0, len(recipients))
+	for _, user := range recipients {
+		escalation.Recipients = append(escalation.Recipients, user.ID)
+		emails = append(emails, user.Email)
+	}
+
+	claimed := false
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Alert{}).
+			Where("id = ? AND status = ? AND acknowledged_at IS NULL AND escalation_level = ?", alert.ID, "ACTIVE", alert.EscalationLevel).
+			Updates(map[string]interface{}{
+				"escalation_level":     level,
+				"escalation_policy_id": policy.ID,
+				"updated_at":           now,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+		return tx.Create(&escalation).Error
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	alert.EscalationLevel = level
+	alert.EscalationPolicyID = &policy.ID
+
+	s.logger.WarnContext(ctx, "Alert escalated", "alert_id", alert.ID, "severity", alert.Severity, "policy_id", policy.ID,
+		"tier", level, "target_type", tier.TargetType, "recipients", len(recipients))
+	if note != "" {
+		s.logger.WarnContext(ctx, "Escalation tier has nobody to notify", "alert_id", alert.ID, "tier", level, "note", note)
+	}
+	notifications.DispatchEscalation(alert, level, emails)
+
+	return true, nil
+}
+
+// recipients resolves the active users a tier escalates to at a point in time, with a note when
+// there are none. A role reaches its holders in the alert's tenant and the platform operator's.
+func (s *EscalationService) recipients(tier models.EscalationTier, tenantID *uuid.UUID, now time.Time) ([]models.User, string, error) {
+	switch tier.TargetType {
+	case models.EscalationTargetTeam:
+		users, err := s.OnCall(*tier.TargetID, now)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(users) == 0 {
+			return nil, "nobody is on call for the team", nil
+		}
+		return users, "", nil
+
+	case models.EscalationTargetRole:
+		var users []models.User
+		query := s.db.Where("role = ? AND is_active = ?", tier.Role, true)
+		if tenantID != nil {
+			query = query.Where("tenant_id IS NULL OR tenant_id = ?", *tenantID)
+		} else {
+			query = query.Where("tenant_id IS NULL")
+		}
+		if err := query.Order("email").Find(&users).Error; err != nil {
+			return nil, "", err
+		}
+		if len(users) == 0 {
+			return nil, "no active users hold the role", nil
+		}
+		return users, "", nil
+
+	case models.EscalationTargetUser:
+		var users []models.User
+		if err := s.db.Where("id = ? AND is_active = ?", *tier.TargetID, true).Find(&users).Error; err != nil {
+			return nil, "", err
+		}
+		if len(users) == 0 {
+			return nil, "the user is deactivated or deleted", nil
+		}
+		return users, "", nil
+	}
+
+	return nil, "", fmt.Errorf("unsupported escalation target %q", tier.TargetType)
+}
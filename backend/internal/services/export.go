@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/export"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// exportDataset is a table that can be exported: its columns and how a scanned row becomes cells
+type exportDataset struct {
+	sheet   string
+	columns []export.Column
+	scan    func(db *gorm.DB, rows *sql.Rows) ([]string, error)
+}
+
+// datasetOf scans each row into a T before formatting it with row
+func datasetOf[T any](sheet string, columns []export.Column, row func(*T) []string) exportDataset {
+	return exportDataset{
+		sheet:   sheet,
+		columns: columns,
+		scan: func(db *gorm.DB, rows *sql.Rows) ([]string, error) {
+			var record T
+			if err := db.ScanRows(rows, &record); err != nil {
+				return nil, err
+			}
+			return row(&record), nil
+		},
+	}
+}
+
+var exportDatasets = map[string]exportDataset{
+	models.ExportDatasetTransactions: datasetOf("Transactions", []export.Column{
+		{Header: "ID"}, {Header: "Portfolio ID"}, {Header: "Type"}, {Header: "Symbol"}, {Header: "Asset Type"},
+		{Header: "Quantity", Numeric: true}, {Header: "Price", Numeric: true}, {Header: "Amount", Numeric: true},
+		{Header: "Realized P&L", Numeric: true}, {Header: "Currency"}, {Header: "Status"}, {Header: "Order Status"}, {Header: "Approval Status"},
+		{Header: "Counterparty"}, {Header: "Counterparty Country"}, {Header: "Risk Score", Numeric: true},
+		{Header: "AML Checked"}, {Header: "KYC Verified"}, {Header: "Requires Review"},
+		{Header: "Executed At"}, {Header: "Created At"}, {Header: "Notes"},
+	}, func(t *models.Transaction) []string {
+		return []string{
+			t.ID.String(), t.PortfolioID.String(), t.TransactionType, t.Symbol, t.AssetType,
+			t.Quantity.String(), t.Price.String(), t.Amount.StringFixed(2),
+			exportDecimal(t.RealizedPnL), t.Currency, t.Status, t.OrderStatus, t.ApprovalStatus,
+			t.CounterpartyName, t.CounterpartyCountry, strconv.Itoa(t.RiskScore),
+			yesNo(t.AMLChecked), yesNo(t.KYCVerified), yesNo(t.RequiresReview),
+			exportTime(t.ExecutedAt), exportTime(&t.CreatedAt), t.Notes,
+		}
+	}),
+	models.ExportDatasetAlerts: datasetOf("Alerts", []export.Column{
+		{Header: "ID"}, {Header: "Portfolio ID"}, {Header: "Type"}, {Header: "Severity"}, {Header: "Title"},
+		{Header: "Description"}, {Header: "Source"}, {Header: "Status"}, {Header: "Escalation Level", Numeric: true},
+		{Header: "Created At"}, {Header: "Acknowledged At"}, {Header: "Resolved At"}, {Header: "Resolution"},
+	}, func(a *models.Alert) []string {
+		return []string{
+			a.ID.String(), a.PortfolioID.String(), a.AlertType, a.Severity, a.Title,
+			a.Description, a.Source, a.Status, strconv.Itoa(a.EscalationLevel),
+			exportTime(&a.CreatedAt), exportTime(a.AcknowledgedAt), exportTime(a.ResolvedAt), a.Resolution,
+		}
+	}),
+	models.ExportDatasetRiskHistory: datasetOf("Risk History", []export.Column{
+		{Header: "Recorded At"}, {Header: "Portfolio ID"}, {Header: "Metric"}, {Header: "Value", Numeric: true},
+		{Header: "Resolution"},
+	}, func(r *models.RiskHistory) []string {
+		return []string{
+			exportTime(&r.RecordedAt), r.PortfolioID.String(), r.MetricType, r.Value.String(), r.Resolution,
+		}
+	}),
+}
+
+// exportDecimal formats an optional amount for an export, leaving an unset one blank
+func exportDecimal(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.StringFixed(2)
+}
+
+// exportTime formats a timestamp for an export, leaving an unset one blank
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportRequest describes an export of the rows a filtered query selects. Query must already
+// carry the caller's access scope and filters; Params only orders it.
+type ExportRequest struct {
+	Dataset     string
+	Format      string
+	Query       *gorm.DB
+	Params      pagination.Params
+	RequestedBy uuid.UUID
+	Filters     string // Raw query string, recorded on export jobs
+}
+
+// ExportService streams datasets as CSV or XLSX, and renders exports too large to stream into
+// stored files that expire after the configured TTL
+type ExportService struct {
+	db     *gorm.DB
+	cfg    *config.ExportConfig
+	logger *slog.Logger
+}
+
+func NewExportService(cfg *config.ExportConfig) *ExportService {
+	return &ExportService{
+		db:     database.GetDB(),
+		cfg:    cfg,
+		logger: logging.Component("export"),
+	}
+}
+
+// ExportFileName names an export file after its dataset and the time it was requested
+func ExportFileName(dataset, format string, now time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", dataset, now.UTC().Format("20060102-150405"), format)
+}
+
+// Count returns how many rows an export would contain
+func (s *ExportService) Count(ctx context.Context, req ExportRequest) (int64, error) {
+	var total int64
+	err := req.Query.Session(&gorm.Session{}).WithContext(ctx).Count(&total).Error
+	return total, err
+}
+
+// Write streams the rows of an export to w one at a time and returns how many were written
+func (s *ExportService) Write(ctx context.Context, w io.Writer, req ExportRequest) (int64, error) {
+	dataset, ok := exportDatasets[req.Dataset]
+	if !ok {
+		return 0, fmt.Errorf("unknown export dataset %q", req.Dataset)
+	}
+
+	writer, err := export.NewWriter(w, req.Format, dataset.sheet, dataset.columns)
+	if err != nil {
+		return 0, err
+	}
+
+	query := req.Params.Sorted(req.Query.Session(&gorm.Session{}).WithContext(ctx))
+	rows, err := query.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var written int64
+	for rows.Next() {
+		row, err := dataset.scan(query, rows)
+		if err != nil {
+			return written, err
+		}
+		if err := writer.Write(row); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if err := rows.Err(); err != nil {
+		return written, err
+	}
+	return written, writer.Close()
+}
+
+// StartJob records an export job and renders its file in the background. The returned job is
+// PROCESSING; poll it with GetJob until it completes or fails.
+func (s *ExportService) StartJob(req ExportRequest) (*models.ExportJob, error) {
+	if _, ok := exportDatasets[req.Dataset]; !ok {
+		return nil, fmt.Errorf("unknown export dataset %q", req.Dataset)
+	}
+
+	now := time.Now()
+	job := &models.ExportJob{
+		RequestedBy: req.RequestedBy,
+		Dataset:     req.Dataset,
+		Format:      req.Format,
+		Status:      models.ExportStatusProcessing,
+		Filters:     req.Filters,
+		FileName:    ExportFileName(req.Dataset, req.Format, now),
+		ContentType: export.ContentType(req.Format),
+		ExpiresAt:   now.Add(s.cfg.TTL),
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	go s.runJob(logging.WithNewRequestID(context.Background()), job.ID, req)
+	return job, nil
+}
+
+// runJob renders an export job's file and stores it, or records why it failed
+func (s *ExportService) runJob(ctx context.Context, jobID uuid.UUID, req ExportRequest) {
+	var buf bytes.Buffer
+	written, err := s.Write(ctx, &buf, req)
+
+	now := time.Now()
+	updates := map[string]interface{}{"completed_at": now, "row_count": written}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Export job failed", "export_id", jobID, "dataset", req.Dataset, "error", err)
+		updates["status"] = models.ExportStatusFailed
+		updates["message"] = "Export failed"
+		if errors.Is(err, export.ErrTooManyRows) {
+			updates["message"] = err.Error()
+		}
+	} else {
+		updates["status"] = models.ExportStatusCompleted
+		updates["content"] = buf.Bytes()
+		updates["size"] = int64(buf.Len())
+		s.logger.InfoContext(ctx, "Export job completed", "export_id", jobID, "dataset", req.Dataset,
+			"rows", written, "bytes", buf.Len())
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.ExportJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save export job", "export_id", jobID, "error", err)
+	}
+}
+
+// GetJob returns an unexpired export job requested by the user; the file is only loaded when
+// withContent is set
+func (s *ExportService) GetJob(jobID, userID uuid.UUID, withContent bool) (*models.ExportJob, error) {
+	query := s.db.Where("id = ? AND requested_by = ? AND expires_at > ?", jobID, userID, time.Now())
+	if !withContent {
+		query = query.Omit("content")
+	}
+
+	var job models.ExportJob
+	if err := query.First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Export not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StartPurgeJob deletes expired export files at a fixed interval until ctx is cancelled
+func (s *ExportService) StartPurgeJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.PurgeExpired(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Purge of expired exports failed", "error", err)
+		}
+	}
+}
+
+// PurgeExpired deletes the export jobs past their expiry and returns how many were removed
+func (s *ExportService) PurgeExpired(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.ExportJob{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.logger.InfoContext(ctx, "Purged expired exports", "count", result.RowsAffected)
+	}
+	return result.RowsAffected, nil
+}
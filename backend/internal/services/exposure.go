@@ -0,0 +1,540 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/fx"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// firmExposureAlertSource is the source of firm exposure limit alerts, used with the limit to
+// avoid raising the same alert while one is still active
+const firmExposureAlertSource = "FIRM_EXPOSURE_MONITOR"
+
+// exposureWarningUtilization is the share of a firm limit, in percent, at which exposure is WARNING
+const exposureWarningUtilization = 75
+
+// exposureTopPortfolios is how many of the largest holders a firm exposure alert lists
+const exposureTopPortfolios = 5
+
+// ExposureTotals is the exposure to a symbol summed over a set of portfolios. Short positions
+// count against the net exposure and towards the gross.
+type ExposureTotals struct {
+	Quantity       decimal.Decimal `json:"quantity"`
+	LongExposure   decimal.Decimal `json:"long_exposure"`
+	ShortExposure  decimal.Decimal `json:"short_exposure"` // Market value of short positions, as a positive amount
+	NetExposure    decimal.Decimal `json:"net_exposure"`
+	GrossExposure  decimal.Decimal `json:"gross_exposure"`
+	PortfolioCount int             `json:"portfolio_count"`
+}
+
+func (t *ExposureTotals) add(h *symbolHolding) {
+	t.Quantity = t.Quantity.Add(h.Quantity)
+	if h.MarketValue.IsNegative() {
+		t.ShortExposure = t.ShortExposure.Sub(h.MarketValue)
+	} else {
+		t.LongExposure = t.LongExposure.Add(h.MarketValue)
+	}
+	t.NetExposure = t.NetExposure.Add(h.MarketValue)
+	t.GrossExposure = t.GrossExposure.Add(h.MarketValue.Abs())
+	t.PortfolioCount++
+}
+
+// PortfolioExposure is one portfolio's holding of a symbol, valued in the report currency
+type PortfolioExposure struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Name        string          `json:"name"`
+	TeamID      *uuid.UUID      `json:"team_id,omitempty"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	MarketValue decimal.Decimal `json:"market_value"`
+}
+
+// TeamExposure is the exposure of the portfolios shared with a team; portfolios without a team
+// are grouped under a null team_id
+type TeamExposure struct {
+	TeamID   *uuid.UUID `json:"team_id"`
+	TeamName string     `json:"team_name,omitempty"`
+	ExposureTotals
+}
+
+// FirmExposure measures the exposure of every portfolio in the firm against its limit on the
+// symbol, in the limit's currency
+type FirmExposure struct {
+	Limit              *models.ExposureLimit `json:"limit,omitempty"`
+	Currency           string                `json:"currency"`
+	GrossExposure      decimal.Decimal       `json:"gross_exposure"`
+	PortfolioCount     int                   `json:"portfolio_count"`
+	UtilizationPercent *decimal.Decimal      `json:"utilization_percent,omitempty"`
+	Status             string                `json:"status"` // SAFE, WARNING, CRITICAL or NO_LIMIT
+}
+
+// SymbolExposure is the exposure to a symbol across the portfolios a user can access
+type SymbolExposure struct {
+	Symbol   string     `json:"symbol"`
+	Currency string     `json:"currency"`
+	TeamID   *uuid.UUID `json:"team_id,omitempty"`
+	ExposureTotals
+	Teams        []TeamExposure      `json:"teams"`
+	Portfolios   []PortfolioExposure `json:"portfolios"`
+	Firm         *FirmExposure       `json:"firm,omitempty"` // Only for roles that see the whole firm
+	CalculatedAt time.Time           `json:"calculated_at"`
+}
+
+// ExposureLimitRequest sets the firm-wide limit on a symbol
+type ExposureLimitRequest struct {
+	MaxExposure decimal.Decimal `json:"max_exposure"`
+	Currency    string          `json:"currency"`
+	Notes       string          `json:"notes"`
+}
+
+// symbolHolding is a live portfolio's position in a symbol, valued in the portfolio currency
+type symbolHolding struct {
+	PortfolioID uuid.UUID
+	Name        string
+	TeamID      *uuid.UUID
+	Currency    string
+	Quantity    decimal.Decimal
+	MarketValue decimal.Decimal
+}
+
+// ExposureService aggregates the exposure to a symbol across portfolios and teams, and keeps and
+// monitors the firm-wide limits on it
+type ExposureService struct {
+	db            *gorm.DB
+	accessService *AccessService
+	alertService  *AlertService
+	logger        *slog.Logger
+}
+
+func NewExposureService() *ExposureService {
+	return &ExposureService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+		alertService:  NewAlertService(),
+		logger:        logging.Component("exposure"),
+	}
+}
+
+// normalizeSymbol trims and upper-cases a symbol, rejecting empty and overlong ones
+func normalizeSymbol(symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" || len(symbol) > 20 {
+		return "", apperror.BadRequest("symbol is required and must be at most 20 characters")
+	}
+	return symbol, nil
+}
+
+// holdings returns the positions in a symbol of the live portfolios query selects
+func holdings(query *gorm.DB, symbol string) ([]symbolHolding, error) {
+	var rows []symbolHolding
+	err := query.Table("positions").
+		Select("positions.portfolio_id, portfolios.name, portfolios.team_id, portfolios.currency, positions.quantity, positions.market_value").
+		Joins("JOIN portfolios ON portfolios.id = positions.portfolio_id AND portfolios.deleted_at IS NULL").
+		Where("positions.symbol = ? AND positions.deleted_at IS NULL AND positions.quantity <> 0", symbol).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// convertHoldings values holdings in currency, in place, and sorts them by absolute value
+func convertHoldings(rows []symbolHolding, currency string) error {
+	rates := map[string]decimal.Decimal{}
+	for i := range rows {
+		from := fx.Normalize(rows[i].Currency)
+		rate, ok := rates[from]
+		if !ok {
+			var err error
+			if rate, err = fxRate(from, currency); err != nil {
+				return apperror.BadRequest(fmt.Sprintf("No exchange rate from %s to %s", from, currency))
+			}
+			rates[from] = rate
+		}
+		rows[i].MarketValue = rows[i].MarketValue.Mul(rate).Round(2)
+		rows[i].Currency = currency
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].MarketValue.Abs().GreaterThan(rows[j].MarketValue.Abs())
+	})
+	return nil
+}
+
+// Exposure sums the positions in a symbol of the portfolios a user can access, optionally only
+// those of a team, in currency, broken down by team and portfolio. Admins and compliance officers
+// also get the exposure of the whole firm measured against its limit on the symbol.
+func (s *ExposureService) Exposure(ctx context.Context, symbol string, teamID *uuid.UUID, currency string, userID uuid.UUID, role string) (*SymbolExposure, error) {
+	symbol, err := normalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	currency = fx.Normalize(currency)
+
+	query := s.accessService.ScopeQuery(s.db.WithContext(ctx), "positions.portfolio_id", userID, role)
+	if teamID != nil {
+		query = query.Where("portfolios.team_id = ?", *teamID)
+	}
+	rows, err := holdings(query, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := convertHoldings(rows, currency); err != nil {
+		return nil, err
+	}
+
+	exposure := &SymbolExposure{
+		Symbol:       symbol,
+		Currency:     currency,
+		TeamID:       teamID,
+		Teams:        []TeamExposure{},
+		Portfolios:   make([]PortfolioExposure, 0, len(rows)),
+		CalculatedAt: time.Now(),
+	}
+	teams := map[uuid.UUID]*TeamExposure{}
+	var unassigned *TeamExposure
+	for i := range rows {
+		row := &rows[i]
+		exposure.add(row)
+		exposure.Portfolios = append(exposure.Portfolios, PortfolioExposure{
+			PortfolioID: row.PortfolioID,
+			Name:        row.Name,
+			TeamID:      row.TeamID,
+			Quantity:    row.Quantity,
+			MarketValue: row.MarketValue,
+		})
+
+		var team *TeamExposure
+		switch {
+		case row.TeamID == nil:
+			if unassigned == nil {
+				unassigned = &TeamExposure{}
+			}
+			team = unassigned
+		case teams[*row.TeamID] == nil:
+			team = &TeamExposure{TeamID: row.TeamID}
+			teams[*row.TeamID] = team
+		default:
+			team = teams[*row.TeamID]
+		}
+		team.add(row)
+	}
+	if err := s.collectTeams(exposure, teams, unassigned); err != nil {
+		return nil, err
+	}
+
+	if HasGlobalScope(role) {
+		tenantID, err := s.accessService.TenantOf(userID)
+		if err != nil {
+			return nil, err
+		}
+		if exposure.Firm, err = s.firmExposure(ctx, symbol, tenantID); err != nil {
+			return nil, err
+		}
+	}
+	return exposure, nil
+}
+
+// collectTeams names the teams of an exposure and lists them by gross exposure, largest first
+func (s *ExposureService) collectTeams(exposure *SymbolExposure, teams map[uuid.UUID]*TeamExposure, unassigned *TeamExposure) error {
+	if len(teams) > 0 {
+		ids := make([]uuid.UUID, 0, len(teams))
+		for id := range teams {
+			ids = append(ids, id)
+		}
+		var named []models.Team
+		if err := s.db.Select("id", "name").Where("id IN ?", ids).Find(&named).Error; err != nil {
+			return err
+		}
+		for _, team := range named {
+			teams[team.ID].TeamName = team.Name
+		}
+		for _, team := range teams {
+			exposure.Teams = append(exposure.Teams, *team)
+		}
+	}
+	if unassigned != nil {
+		exposure.Teams = append(exposure.Teams, *unassigned)
+	}
+	sort.SliceStable(exposure.Teams, func(i, j int) bool {
+		return exposure.Teams[i].GrossExposure.GreaterThan(exposure.Teams[j].GrossExposure)
+	})
+	return nil
+}
+
+// firmHoldings returns the holdings of a symbol across a tenant, or every portfolio for the
+// platform operator, valued in currency and largest first
+func (s *ExposureService) firmHoldings(ctx context.Context, symbol string, tenantID *uuid.UUID, currency string) ([]symbolHolding, error) {
+	query := s.db.WithContext(ctx)
+	if tenantID != nil {
+		query = query.Where("portfolios.tenant_id = ?", *tenantID)
+	}
+	rows, err := holdings(query, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return rows, convertHoldings(rows, currency)
+}
+
+// firmExposure measures the firm's exposure to a symbol against its limit, in the limit's
+// currency, or in USD with a NO_LIMIT status when the symbol has none
+func (s *ExposureService) firmExposure(ctx context.Context, symbol string, tenantID *uuid.UUID) (*FirmExposure, error) {
+	limit, err := s.findLimit(s.db.WithContext(ctx), symbol, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	firm := &FirmExposure{Limit: limit, Currency: "USD", Status: "NO_LIMIT"}
+	if limit != nil {
+		firm.Currency = limit.Currency
+	}
+	rows, err := s.firmHoldings(ctx, symbol, tenantID, firm.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var totals ExposureTotals
+	for i := range rows {
+		totals.add(&rows[i])
+	}
+	firm.GrossExposure = totals.GrossExposure
+	firm.PortfolioCount = totals.PortfolioCount
+
+	if limit != nil {
+		utilization := totals.GrossExposure.Div(limit.MaxExposure).Mul(decimal.NewFromInt(100)).Round(2)
+		firm.UtilizationPercent = &utilization
+		firm.Status = exposureStatus(utilization)
+	}
+	return firm, nil
+}
+
+// exposureStatus grades the utilization of a firm limit in percent
+func exposureStatus(utilization decimal.Decimal) string {
+	switch {
+	case utilization.GreaterThan(decimal.NewFromInt(100)):
+		return "CRITICAL"
+	case utilization.GreaterThanOrEqual(decimal.NewFromInt(exposureWarningUtilization)):
+		return "WARNING"
+	default:
+		return "SAFE"
+	}
+}
+
+// findLimit returns the limit of a tenant, or of the platform operator when tenantID is nil, on a
+// symbol, or nil when there is none
+func (s *ExposureService) findLimit(db *gorm.DB, symbol string, tenantID *uuid.UUID) (*models.ExposureLimit, error) {
+	query := db.Where("symbol = ?", symbol)
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	} else {
+		query = query.Where("tenant_id IS NULL")
+	}
+
+	var limit models.ExposureLimit
+	if err := query.First(&limit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// ListLimits returns the firm exposure limits of the user's tenant by symbol
+func (s *ExposureService) ListLimits(userID uuid.UUID) ([]models.ExposureLimit, error) {
+	tenantID, err := s.accessService.TenantOf(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Order("symbol")
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	} else {
+		query = query.Where("tenant_id IS NULL")
+	}
+	limits := []models.ExposureLimit{}
+	return limits, query.Find(&limits).Error
+}
+
+// SetLimit creates or replaces the firm exposure limit of the user's tenant on a symbol and
+// returns the limit it replaced, if any
+func (s *ExposureService) SetLimit(symbol string, req ExposureLimitRequest, userID uuid.UUID) (limit, previous *models.ExposureLimit, err error) {
+	if symbol, err = normalizeSymbol(symbol); err != nil {
+		return nil, nil, err
+	}
+	if !req.MaxExposure.IsPositive() {
+		return nil, nil, apperror.BadRequest("max_exposure must be positive")
+	}
+	currency := fx.Normalize(req.Currency)
+	if len(currency) != 3 {
+		return nil, nil, apperror.BadRequest("currency must be a 3-letter ISO code")
+	}
+
+	tenantID, err := s.accessService.TenantOf(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := s.findLimit(tx, symbol, tenantID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			snapshot := *existing
+			previous = &snapshot
+			limit = existing
+		} else {
+			limit = &models.ExposureLimit{TenantID: tenantID, Symbol: symbol}
+		}
+		limit.MaxExposure = req.MaxExposure
+		limit.Currency = currency
+		limit.Notes = strings.TrimSpace(req.Notes)
+		limit.UpdatedBy = &userID
+		return tx.Save(limit).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return limit, previous, nil
+}
+
+// DeleteLimit removes the firm exposure limit of the user's tenant on a symbol and returns it
+func (s *ExposureService) DeleteLimit(symbol string, userID uuid.UUID) (*models.ExposureLimit, error) {
+	symbol, err := normalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := s.accessService.TenantOf(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := s.findLimit(s.db, symbol, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil {
+		return nil, apperror.NotFound("Exposure limit not found")
+	}
+	if err := s.db.Delete(limit).Error; err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+// StartMonitor checks every firm exposure limit at a fixed interval until ctx is cancelled
+func (s *ExposureService) StartMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Firm exposure monitor disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.CheckLimits(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Firm exposure monitor failed to load limits", "error", err)
+		}
+	}
+}
+
+// CheckLimits measures the firm's exposure against every limit and returns how many are
+// breached. A limit that fails is logged and skipped.
+func (s *ExposureService) CheckLimits(ctx context.Context) (int, error) {
+	var limits []models.ExposureLimit
+	if err := s.db.WithContext(ctx).Find(&limits).Error; err != nil {
+		return 0, err
+	}
+
+	breached := 0
+	for i := range limits {
+		limit := &limits[i]
+		rows, err := s.firmHoldings(ctx, limit.Symbol, limit.TenantID, limit.Currency)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Firm exposure check failed", "symbol", limit.Symbol, "tenant_id", limit.TenantID, "error", err)
+			continue
+		}
+		var totals ExposureTotals
+		for j := range rows {
+			totals.add(&rows[j])
+		}
+		if !totals.GrossExposure.GreaterThan(limit.MaxExposure) {
+			continue
+		}
+		breached++
+		s.raiseAlert(ctx, limit, totals, rows)
+	}
+	return breached, nil
+}
+
+// raiseAlert raises an alert on the largest holder of a symbol whose firm limit is breached,
+// unless an alert on the same limit is still active
+func (s *ExposureService) raiseAlert(ctx context.Context, limit *models.ExposureLimit, totals ExposureTotals, rows []symbolHolding) {
+	if len(rows) == 0 || s.hasActiveAlert(limit) {
+		return
+	}
+
+	ratio := totals.GrossExposure.Div(limit.MaxExposure)
+	severity := "MEDIUM"
+	if ratio.GreaterThanOrEqual(decimal.NewFromInt(2)) {
+		severity = "CRITICAL"
+	} else if ratio.GreaterThanOrEqual(decimal.NewFromFloat(1.2)) {
+		severity = "HIGH"
+	}
+
+	top := make([]models.JSON, 0, exposureTopPortfolios)
+	for _, row := range rows[:min(len(rows), exposureTopPortfolios)] {
+		top = append(top, models.JSON{"portfolio_id": row.PortfolioID, "name": row.Name, "market_value": row.MarketValue})
+	}
+
+	alert := &models.Alert{
+		PortfolioID: rows[0].PortfolioID,
+		AlertType:   "RISK_BREACH",
+		Severity:    severity,
+		Title:       fmt.Sprintf("Firm Exposure Limit Breached: %s", limit.Symbol),
+		Description: fmt.Sprintf("Gross exposure to %s of %s %s across %d portfolios exceeds the firm limit of %s %s",
+			limit.Symbol, totals.GrossExposure.StringFixed(2), limit.Currency, totals.PortfolioCount,
+			limit.MaxExposure.StringFixed(2), limit.Currency),
+		Source: firmExposureAlertSource,
+		Status: "ACTIVE",
+		TriggeredBy: models.JSON{
+			"limit_id":        limit.ID,
+			"symbol":          limit.Symbol,
+			"gross_exposure":  totals.GrossExposure,
+			"net_exposure":    totals.NetExposure,
+			"max_exposure":    limit.MaxExposure,
+			"currency":        limit.Currency,
+			"portfolio_count": totals.PortfolioCount,
+			"top_portfolios":  top,
+		},
+	}
+	if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create firm exposure alert", "symbol", limit.Symbol, "error", err)
+	}
+}
+
+func (s *ExposureService) hasActiveAlert(limit *models.ExposureLimit) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("source = ? AND status = ? AND triggered_by->>'limit_id' = ?", firmExposureAlertSource, "ACTIVE", limit.ID.String()).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// BondReferenceRequest sets the terms of a bond
+type BondReferenceRequest struct {
+	CouponRate      decimal.Decimal `json:"coupon_rate"`
+	CouponFrequency *int            `json:"coupon_frequency"` // Defaults to 2, semi-annual
+	MaturityDate    string          `json:"maturity_date"`    // YYYY-MM-DD
+	FaceValue       decimal.Decimal `json:"face_value"`       // Defaults to 100
+	CreditRating    string          `json:"credit_rating"`
+	Issuer          string          `json:"issuer"`
+}
+
+// FixedIncomeService keeps bond reference data, measures the duration, DV01 and credit exposure of
+// portfolios' bonds, records DV01 as a risk metric and raises an alert when it breaches MaxDV01
+type FixedIncomeService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	calculator   *calculator.FixedIncomeCalculator
+	logger       *slog.Logger
+}
+
+func NewFixedIncomeService() *FixedIncomeService {
+	return &FixedIncomeService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		calculator:   calculator.NewFixedIncomeCalculator(),
+		logger:       logging.Component("fixed_income"),
+	}
+}
+
+// ListBonds returns the reference data of every bond
+func (s *FixedIncomeService) ListBonds() ([]models.BondReference, error) {
+	var bonds []models.BondReference
+	err := s.db.Order("symbol").Find(&bonds).Error
+	return bonds, err
+}
+
+// UpsertBond sets the reference data of a bond. A credit rating is copied onto the positions
+// holding the bond, which investment guidelines check.
+func (s *FixedIncomeService) UpsertBond(symbol string, req BondReferenceRequest, userID uuid.UUID) (*models.BondReference, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	if req.CouponRate.IsNegative() || req.CouponRate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.New("coupon_rate must be an annual fraction of face value between 0 and 1")
+	}
+	frequency := 2
+	if req.CouponFrequency != nil {
+		frequency = *req.CouponFrequency
+	}
+	switch frequency {
+	case 0, 1, 2, 4, 12:
+	default:
+		return nil, errors.New("coupon_frequency must be 0, 1, 2, 4 or 12")
+	}
+	maturity, err := time.Parse("2006-01-02", req.MaturityDate)
+	if err != nil {
+		return nil, errors.New("maturity_date must be a YYYY-MM-DD date")
+	}
+	face := req.FaceValue
+	if face.IsZero() {
+		face = decimal.NewFromInt(100)
+	}
+	if face.IsNegative() {
+		return nil, errors.New("face_value must be positive")
+	}
+	rating := strings.ToUpper(strings.TrimSpace(req.CreditRating))
+	if rating != "" && models.CreditRatingRank(rating) < 0 {
+		return nil, errors.New("unsupported credit rating " + rating + ", use AAA to D")
+	}
+
+	bond := models.BondReference{
+		Symbol:          symbol,
+		CouponRate:      req.CouponRate,
+		CouponFrequency: frequency,
+		MaturityDate:    maturity,
+		FaceValue:       face,
+		CreditRating:    rating,
+		Issuer:          strings.TrimSpace(req.Issuer),
+		UpdatedBy:       &userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "symbol"}},
+			DoUpdates: clause.AssignmentColumns([]string{"coupon_rate", "coupon_frequency", "maturity_date", "face_value",
+				"credit_rating", "issuer", "updated_by", "updated_at"}),
+		}).Create(&bond).Error
+		if err != nil {
+			return err
+		}
+		if rating == "" {
+			return nil
+		}
+		return tx.Model(&models.Position{}).Where("symbol = ?", symbol).Update("credit_rating", rating).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bond, nil
+}
+
+// DeleteBond removes the reference data of a bond
+func (s *FixedIncomeService) DeleteBond(symbol string) error {
+	result := s.db.Delete(&models.BondReference{}, "symbol = ?", strings.ToUpper(strings.TrimSpace(symbol)))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("bond not found")
+	}
+	return nil
+}
+
+// CheckInterestRateRisk analyses a portfolio's bonds and checks their DV01, as a share of the
+// portfolio's value with cash, against its MaxDV01 threshold
+func (s *FixedIncomeService) CheckInterestRateRisk(ctx context.Context, portfolioID uuid.UUID) (*calculator.FixedIncomeResult, error) {
+	defer metrics.RiskCalculationDuration.With("dv01").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.dv01", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := s.bondTerms(portfolio.Positions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.calculator.Calculate(portfolio.Positions, terms, portfolio.ValueWithCash().InexactFloat64(),
+		thresholds.MaxDV01.InexactFloat64(), time.Now())
+
+	metric := models.RiskMetric{
+		PortfolioID: portfolioID,
+		MetricType:  "DV01",
+		Value:       decimal.NewFromFloat(result.DV01Ratio).Round(6),
+		Threshold:   thresholds.MaxDV01,
+		Status:      result.Status,
+		Details: models.JSON{
+			"dv01":              result.DV01,
+			"modified_duration": result.ModifiedDuration,
+			"convexity":         result.Convexity,
+			"bond_market_value": result.BondMarketValue,
+			"high_yield":        result.HighYield,
+			"unrated":           result.Unrated,
+		},
+	}
+	if err := s.db.Create(&metric).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Breaches) > 0 && !s.hasActiveAlert(portfolioID) {
+		if err := s.alertService.CreateRiskBreachAlert(ctx, portfolioID, "DV01", result.DV01Ratio, result.MaxDV01); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create DV01 alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// bondTerms loads the reference data of the symbols held in positions
+func (s *FixedIncomeService) bondTerms(positions []models.Position) (map[string]calculator.BondTerms, error) {
+	symbols := make([]string, 0, len(positions))
+	for _, position := range positions {
+		symbols = append(symbols, position.Symbol)
+	}
+
+	terms := make(map[string]calculator.BondTerms)
+	if len(symbols) == 0 {
+		return terms, nil
+	}
+
+	var bonds []models.BondReference
+	if err := s.db.Where("symbol IN ?", symbols).Find(&bonds).Error; err != nil {
+		return nil, err
+	}
+	for _, bond := range bonds {
+		terms[bond.Symbol] = calculator.BondTerms{
+			CouponRate:      bond.CouponRate.InexactFloat64(),
+			CouponFrequency: bond.CouponFrequency,
+			Maturity:        bond.MaturityDate,
+			FaceValue:       bond.FaceValue.InexactFloat64(),
+			CreditRating:    bond.CreditRating,
+		}
+	}
+	return terms, nil
+}
+
+func (s *FixedIncomeService) hasActiveAlert(portfolioID uuid.UUID) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, "DV01_CALCULATOR", "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// StartMonitor checks the interest rate risk of every portfolio holding bonds at a fixed interval
+// until ctx is cancelled
+func (s *FixedIncomeService) StartMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Interest rate risk monitor disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		var portfolioIDs []uuid.UUID
+		err := s.db.Model(&models.Position{}).
+			Where("asset_type = ? OR symbol IN (?)", "BOND", s.db.Model(&models.BondReference{}).Select("symbol")).
+			Distinct("portfolio_id").
+			Pluck("portfolio_id", &portfolioIDs).Error
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Interest rate risk monitor failed to load portfolios", "error", err)
+			continue
+		}
+
+		for _, portfolioID := range portfolioIDs {
+			if _, err := s.CheckInterestRateRisk(ctx, portfolioID); err != nil {
+				s.logger.ErrorContext(ctx, "Interest rate risk check failed", "portfolio_id", portfolioID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/cache"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// What queued an intraday risk recalculation
+const (
+	RecalcTriggerPriceMove  = "PRICE_MOVE"
+	RecalcTriggerLargeTrade = "LARGE_TRADE"
+)
+
+// recalcPollInterval is how often the queue is checked for portfolios that are due
+const recalcPollInterval = time.Second
+
+// Price moves and large trades queue the portfolios they affect in intradayRecalcs rather than
+// recalculating them at once, so that a burst of ticks or fills in the same portfolio costs one
+// recalculation. The queue belongs to the instance and only accepts triggers while
+// IntradayRiskService.StartRecalculator runs in it.
+
+// recalcRequest is a queued recalculation of a portfolio and what has triggered it since it was
+// queued
+type recalcRequest struct {
+	firstQueued time.Time
+	lastQueued  time.Time
+	triggers    map[string]bool
+	symbols     map[string]bool
+}
+
+// recalcQueue debounces the intraday risk recalculations of the instance
+type recalcQueue struct {
+	mu           sync.Mutex
+	running      bool
+	priceMove    float64
+	tradePercent float64
+	pending      map[uuid.UUID]*recalcRequest
+	marks        map[string]float64 // Price of each symbol when its holders were last queued for it
+	logger       *slog.Logger
+}
+
+var intradayRecalcs = &recalcQueue{
+	pending: make(map[uuid.UUID]*recalcRequest),
+	marks:   make(map[string]float64),
+	logger:  logging.Component("intraday_risk"),
+}
+
+// start makes the queue accept triggers under the configured thresholds
+func (q *recalcQueue) start(cfg *config.RiskConfig) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running = true
+	q.priceMove = cfg.RecalcPriceMove
+	q.tradePercent = cfg.RecalcTradePercent
+}
+
+// stop drops the queued recalculations and ignores triggers until the queue is started again
+func (q *recalcQueue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running = false
+	q.pending = make(map[uuid.UUID]*recalcRequest)
+	q.marks = make(map[string]float64)
+}
+
+// add queues portfolios for a recalculation, or adds the trigger to their queued one
+func (q *recalcQueue) add(trigger string, symbols []string, portfolioIDs ...uuid.UUID) {
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.running {
+		return
+	}
+
+	for _, portfolioID := range portfolioIDs {
+		request, ok := q.pending[portfolioID]
+		if !ok {
+			request = &recalcRequest{firstQueued: now, triggers: make(map[string]bool), symbols: make(map[string]bool)}
+			q.pending[portfolioID] = request
+		}
+		request.lastQueued = now
+		request.triggers[trigger] = true
+		for _, symbol := range symbols {
+			request.symbols[symbol] = true
+		}
+	}
+}
+
+// due removes and returns the queued recalculations that have had no trigger for the debounce
+// period, or have waited the maximum delay
+func (q *recalcQueue) due(now time.Time, debounce, maxDelay time.Duration) map[uuid.UUID]*recalcRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := make(map[uuid.UUID]*recalcRequest)
+	for portfolioID, request := range q.pending {
+		if now.Sub(request.lastQueued) >= debounce || now.Sub(request.firstQueued) >= maxDelay {
+			due[portfolioID] = request
+			delete(q.pending, portfolioID)
+		}
+	}
+	return due
+}
+
+// moved returns the symbols whose price has moved by the configured percent or more since their
+// holders were last queued for them. A symbol's first price only marks it, so that a restart does
+// not queue every portfolio.
+func (q *recalcQueue) moved(prices map[string]float64) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.running || q.priceMove <= 0 {
+		return nil
+	}
+
+	var moved []string
+	for symbol, price := range prices {
+		mark, ok := q.marks[symbol]
+		if ok && mark > 0 && math.Abs(price/mark-1)*100 < q.priceMove {
+			continue
+		}
+		q.marks[symbol] = price
+		if ok && mark > 0 {
+			moved = append(moved, symbol)
+		}
+	}
+	return moved
+}
+
+// QueuePriceMoves queues the portfolios holding a symbol whose price has moved by
+// RISK_RECALC_PRICE_MOVE percent or more since they were last queued for it
+func QueuePriceMoves(ctx context.Context, prices map[string]float64) error {
+	moved := intradayRecalcs.moved(prices)
+	if len(moved) == 0 {
+		return nil
+	}
+
+	var portfolioIDs []uuid.UUID
+	err := database.GetDB().WithContext(ctx).Model(&models.Position{}).
+		Where("symbol IN ? AND quantity <> 0", moved).
+		Distinct().Pluck("portfolio_id", &portfolioIDs).Error
+	if err != nil {
+		return err
+	}
+	intradayRecalcs.add(RecalcTriggerPriceMove, moved, portfolioIDs...)
+	return nil
+}
+
+// QueueLargeTrade queues the portfolio of a fill worth RISK_RECALC_TRADE_PERCENT percent or more
+// of its value including cash. It is called once the fill has been committed.
+func QueueLargeTrade(ctx context.Context, transaction *models.Transaction, fill *models.Fill) {
+	q := intradayRecalcs
+	q.mu.Lock()
+	tradePercent := q.tradePercent
+	if !q.running {
+		tradePercent = 0
+	}
+	q.mu.Unlock()
+	if tradePercent <= 0 {
+		return
+	}
+
+	var portfolio models.Portfolio
+	err := database.GetDB().WithContext(ctx).Select("id", "currency", "total_value", "cash_balance").
+		First(&portfolio, transaction.PortfolioID).Error
+	if err != nil {
+		q.logger.WarnContext(ctx, "Failed to load portfolio of fill", "portfolio_id", transaction.PortfolioID, "error", err)
+		return
+	}
+	rate, err := fxRate(transaction.Currency, portfolio.Currency)
+	if err != nil {
+		q.logger.WarnContext(ctx, "Failed to value fill", "transaction_id", transaction.ID, "error", err)
+		return
+	}
+
+	// Any trade is large in a portfolio without value
+	value := portfolio.ValueWithCash()
+	amount := fill.Amount.Abs().Mul(rate)
+	if value.IsPositive() && amount.Div(value).Mul(decimal.NewFromInt(100)).LessThan(decimal.NewFromFloat(tradePercent)) {
+		return
+	}
+	q.add(RecalcTriggerLargeTrade, []string{transaction.Symbol}, transaction.PortfolioID)
+}
+
+// IntradayRiskService recalculates the VaR and liquidity of the portfolios queued by price moves
+// and large trades, storing them as risk metrics, alerting on breached thresholds and publishing
+// them as risk updates
+type IntradayRiskService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	config       *config.RiskConfig
+	logger       *slog.Logger
+}
+
+func NewIntradayRiskService(cfg *config.RiskConfig) *IntradayRiskService {
+	return &IntradayRiskService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		config:       cfg,
+		logger:       logging.Component("intraday_risk"),
+	}
+}
+
+// StartRecalculator accepts triggers and recalculates the queued portfolios as they become due,
+// up to RISK_RECALC_WORKERS at a time, until ctx is cancelled
+func (s *IntradayRiskService) StartRecalculator(ctx context.Context) {
+	if s.config.RecalcDebounce <= 0 {
+		s.logger.Info("Intraday risk recalculation disabled")
+		return
+	}
+
+	intradayRecalcs.start(s.config)
+	defer intradayRecalcs.stop()
+
+	ticker := time.NewTicker(recalcPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		due := intradayRecalcs.due(time.Now(), s.config.RecalcDebounce, s.config.RecalcMaxDelay)
+		if len(due) == 0 {
+			continue
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		jobs := make(chan uuid.UUID)
+		var wg sync.WaitGroup
+		for w := 0; w < min(max(s.config.RecalcWorkers, 1), len(due)); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for portfolioID := range jobs {
+					if err := s.Recalculate(ctx, portfolioID, due[portfolioID]); err != nil {
+						s.logger.ErrorContext(ctx, "Intraday risk recalculation failed", "portfolio_id", portfolioID, "error", err)
+					}
+				}
+			}()
+		}
+		for portfolioID := range due {
+			jobs <- portfolioID
+		}
+		close(jobs)
+		wg.Wait()
+	}
+}
+
+// Recalculate stores a portfolio's VaR and liquidity ratio as risk metrics recording what
+// triggered them, drops its cached risk, raises a breach alert for a threshold crossed unless one
+// is still active and publishes the new figures as a risk update
+func (s *IntradayRiskService) Recalculate(ctx context.Context, portfolioID uuid.UUID, request *recalcRequest) error {
+	defer metrics.RiskCalculationDuration.With("intraday").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.intraday", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted since it was queued
+			return nil
+		}
+		return err
+	}
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return err
+	}
+
+	trigger := models.JSON{
+		"triggers":  sortedKeys(request.triggers),
+		"symbols":   sortedKeys(request.symbols),
+		"queued_at": request.firstQueued,
+	}
+
+	varMetric, lvar, err := VaRMetric(&portfolio, s.config)
+	if err != nil {
+		return err
+	}
+	liquidity, _, _, err := LiquidityMetric(&portfolio, s.config.VARTimeHorizon)
+	if err != nil {
+		return err
+	}
+	varMetric.Details["trigger"] = trigger
+	liquidity.Details["trigger"] = trigger
+	if err := s.db.WithContext(ctx).Create([]*models.RiskMetric{varMetric, liquidity}).Error; err != nil {
+		return err
+	}
+	cache.InvalidatePortfolioRisk(ctx, portfolioID)
+
+	// The VaR thresholds are a share of the value including cash
+	if value := portfolio.ValueWithCash(); value.IsPositive() && thresholds.MaxVaR95.IsPositive() {
+		share := varMetric.Value.Div(value)
+		if share.GreaterThan(thresholds.MaxVaR95) && !s.hasActiveAlert(portfolioID, "VAR_CALCULATOR") {
+			if err := s.alertService.CreateRiskBreachAlert(ctx, portfolioID, "VAR", share.InexactFloat64(), thresholds.MaxVaR95.InexactFloat64()); err != nil {
+				s.logger.ErrorContext(ctx, "Failed to create VaR breach alert", "portfolio_id", portfolioID, "error", err)
+			}
+		}
+	}
+	if liquidity.Value.LessThan(thresholds.MinLiquidityRatio) && !s.hasActiveAlert(portfolioID, "LIQUIDITY_CALCULATOR") {
+		if err := s.alertService.CreateRiskBreachAlert(ctx, portfolioID, "LIQUIDITY", liquidity.Value.InexactFloat64(), thresholds.MinLiquidityRatio.InexactFloat64()); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create liquidity breach alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+
+	update := map[string]interface{}{
+		"portfolio_id":           portfolioID,
+		"var":                    varMetric.Value.InexactFloat64(),
+		"liquidity_adjusted_var": lvar.Value,
+		"liquidity":              liquidity.Value.InexactFloat64(),
+		"trigger":                trigger,
+		"timestamp":              time.Now().Unix(),
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		update["request_id"] = requestID
+	}
+	updateJSON, _ := json.Marshal(update)
+	database.PublishEvent(ctx, database.RiskUpdatesChannel, updateJSON)
+	return nil
+}
+
+func (s *IntradayRiskService) hasActiveAlert(portfolioID uuid.UUID, source string) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, source, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// sortedKeys lists the members of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
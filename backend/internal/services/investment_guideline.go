@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// InvestmentGuidelineService keeps each portfolio's investment guideline and checks holdings and
+// trades against it, raising a COMPLIANCE_VIOLATION alert for every guideline breached
+type InvestmentGuidelineService struct {
+	db           *gorm.DB
+	alertService *AlertService
+	logger       *slog.Logger
+}
+
+func NewInvestmentGuidelineService() *InvestmentGuidelineService {
+	return &InvestmentGuidelineService{
+		db:           database.GetDB(),
+		alertService: NewAlertService(),
+		logger:       logging.Component("investment_guidelines"),
+	}
+}
+
+// PortfolioGuidelineBreaches are the guideline breaches found in one portfolio
+type PortfolioGuidelineBreaches struct {
+	PortfolioID uuid.UUID               `json:"portfolio_id"`
+	Breaches    []rules.GuidelineBreach `json:"breaches"`
+}
+
+// GuidelineEvaluationResult summarizes a guideline evaluation run; only portfolios with breaches
+// are listed
+type GuidelineEvaluationResult struct {
+	PortfoliosEvaluated int                          `json:"portfolios_evaluated"`
+	Portfolios          []PortfolioGuidelineBreaches `json:"portfolios"`
+	EvaluatedAt         time.Time                    `json:"evaluated_at"`
+}
+
+// GetGuideline returns a portfolio's guideline
+func (s *InvestmentGuidelineService) GetGuideline(portfolioID uuid.UUID) (*models.InvestmentGuideline, error) {
+	var guideline models.InvestmentGuideline
+	if err := s.db.Where("portfolio_id = ?", portfolioID).First(&guideline).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("guideline not found")
+		}
+		return nil, err
+	}
+	return &guideline, nil
+}
+
+// SaveGuideline validates and creates or updates a portfolio's guideline
+func (s *InvestmentGuidelineService) SaveGuideline(guideline *models.InvestmentGuideline) error {
+	if err := rules.ValidateGuideline(guideline); err != nil {
+		return err
+	}
+	return s.db.Save(guideline).Error
+}
+
+// DeleteGuideline removes a portfolio's guideline and returns it
+func (s *InvestmentGuidelineService) DeleteGuideline(portfolioID uuid.UUID) (*models.InvestmentGuideline, error) {
+	guideline, err := s.GetGuideline(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(guideline).Error; err != nil {
+		return nil, err
+	}
+	return guideline, nil
+}
+
+// activeGuideline returns the portfolio's guideline, or nil when it has none or it is inactive
+func (s *InvestmentGuidelineService) activeGuideline(portfolioID uuid.UUID) (*models.InvestmentGuideline, error) {
+	guideline, err := s.GetGuideline(portfolioID)
+	if err != nil {
+		if err.Error() == "guideline not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !guideline.IsActive {
+		return nil, nil
+	}
+	return guideline, nil
+}
+
+func (s *InvestmentGuidelineService) positions(portfolioID uuid.UUID) ([]models.Position, error) {
+	var positions []models.Position
+	err := s.db.Where("portfolio_id = ?", portfolioID).Find(&positions).Error
+	return positions, err
+}
+
+// EvaluatePortfolio checks a portfolio's positions against its active guideline
+func (s *InvestmentGuidelineService) EvaluatePortfolio(portfolioID uuid.UUID) ([]rules.GuidelineBreach, error) {
+	guideline, err := s.activeGuideline(portfolioID)
+	if err != nil || guideline == nil {
+		return []rules.GuidelineBreach{}, err
+	}
+	positions, err := s.positions(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	return rules.EvaluateGuideline(guideline, positions), nil
+}
+
+// CheckTrade checks a trade against its portfolio's active guideline without raising alerts
+func (s *InvestmentGuidelineService) CheckTrade(trade *models.Transaction) ([]rules.GuidelineBreach, error) {
+	if !isOrder(trade) {
+		return []rules.GuidelineBreach{}, nil
+	}
+	guideline, err := s.activeGuideline(trade.PortfolioID)
+	if err != nil || guideline == nil {
+		return []rules.GuidelineBreach{}, err
+	}
+	positions, err := s.positions(trade.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	return rules.EvaluateTrade(guideline, trade, positions), nil
+}
+
+// AlertTrade checks a stored trade against its portfolio's guideline and raises an alert for each
+// guideline it breaches
+func (s *InvestmentGuidelineService) AlertTrade(ctx context.Context, trade *models.Transaction) {
+	breaches, err := s.CheckTrade(trade)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Guideline check failed for transaction", "transaction_id", trade.ID, "error", err)
+		return
+	}
+	for _, breach := range breaches {
+		s.raiseBreachAlert(ctx, trade.PortfolioID, &trade.ID, breach)
+	}
+}
+
+// EvaluateAll checks every portfolio with an active guideline, or just one when portfolioID is
+// set, and raises alerts for the breaches
+func (s *InvestmentGuidelineService) EvaluateAll(ctx context.Context, portfolioID *uuid.UUID) (*GuidelineEvaluationResult, error) {
+	result := &GuidelineEvaluationResult{
+		Portfolios:  []PortfolioGuidelineBreaches{},
+		EvaluatedAt: time.Now(),
+	}
+
+	var portfolioIDs []uuid.UUID
+	query := s.db.Model(&models.InvestmentGuideline{}).
+		Joins("JOIN portfolios ON portfolios.id = investment_guidelines.portfolio_id AND portfolios.deleted_at IS NULL").
+		Where("investment_guidelines.is_active = ?", true)
+	if portfolioID != nil {
+		query = query.Where("investment_guidelines.portfolio_id = ?", *portfolioID)
+	}
+	if err := query.Pluck("investment_guidelines.portfolio_id", &portfolioIDs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range portfolioIDs {
+		breaches, err := s.EvaluatePortfolio(id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Guideline evaluation failed for portfolio", "portfolio_id", id, "error", err)
+			continue
+		}
+		result.PortfoliosEvaluated++
+		if len(breaches) == 0 {
+			continue
+		}
+		result.Portfolios = append(result.Portfolios, PortfolioGuidelineBreaches{PortfolioID: id, Breaches: breaches})
+		for _, breach := range breaches {
+			s.raiseBreachAlert(ctx, id, nil, breach)
+		}
+	}
+
+	return result, nil
+}
+
+// StartScheduler evaluates every portfolio's guideline at a fixed interval until ctx is cancelled
+func (s *InvestmentGuidelineService) StartScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		result, err := s.EvaluateAll(ctx, nil)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduled guideline evaluation failed", "error", err)
+			continue
+		}
+		if len(result.Portfolios) > 0 {
+			s.logger.InfoContext(ctx, "Investment guidelines breached", "portfolios", len(result.Portfolios),
+				"evaluated", result.PortfoliosEvaluated)
+		}
+	}
+}
+
+// raiseBreachAlert creates an alert naming the guideline breached. A portfolio breach is not
+// raised again while an alert for the same guideline and symbol is active.
+func (s *InvestmentGuidelineService) raiseBreachAlert(ctx context.Context, portfolioID uuid.UUID, transactionID *uuid.UUID, breach rules.GuidelineBreach) {
+	if transactionID == nil {
+		var count int64
+		s.db.Model(&models.Alert{}).
+			Where("portfolio_id = ? AND source = ? AND status = ? AND triggered_by->>'guideline' = ? AND COALESCE(triggered_by->>'symbol', '') = ?",
+				portfolioID, "GUIDELINE_ENGINE", "ACTIVE", breach.Guideline, breach.Symbol).
+			Where("triggered_by->>'transaction_id' IS NULL").
+			Count(&count)
+		if count > 0 {
+			return
+		}
+	}
+
+	triggeredBy := models.JSON{
+		"guideline": breach.Guideline,
+	}
+	if breach.Symbol != "" {
+		triggeredBy["symbol"] = breach.Symbol
+	}
+	if breach.Value != "" {
+		triggeredBy["value"] = breach.Value
+	}
+	if breach.Limit != "" {
+		triggeredBy["limit"] = breach.Limit
+	}
+	if transactionID != nil {
+		triggeredBy["transaction_id"] = transactionID.String()
+	}
+
+	alert := &models.Alert{
+		PortfolioID: portfolioID,
+		AlertType:   "COMPLIANCE_VIOLATION",
+		Severity:    "HIGH",
+		Title:       fmt.Sprintf("Investment Guideline Breached: %s", breach.Guideline),
+		Description: breach.Description,
+		Source:      "GUIDELINE_ENGINE",
+		Status:      "ACTIVE",
+		TriggeredBy: triggeredBy,
+	}
+
+	if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create guideline breach alert", "portfolio_id", portfolioID,
+			"guideline", breach.Guideline, "error", err)
+	}
+}
@@ -0,0 +1,610 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+const (
+	// jobQueueKey is the Redis list the IDs of queued jobs are pushed onto; the workers of every
+	// instance pop from it
+	jobQueueKey = "jobs:queue"
+	// jobPopTimeout is how long a worker waits for a job before checking for shutdown
+	jobPopTimeout = 5 * time.Second
+	// jobProgressInterval is the least time between two progress updates of a job
+	jobProgressInterval = time.Second
+)
+
+// jobRunner runs a claimed job and returns its result, reporting how far it has got on the way
+type jobRunner func(ctx context.Context, job *models.Job, progress *jobProgress) (models.JSON, error)
+
+// varJobParams are the VaR parameters a VaR job was started with
+type varJobParams struct {
+	Method      string  `json:"method"`
+	Confidence  float64 `json:"confidence"`
+	Horizon     int     `json:"horizon"`
+	Lookback    int     `json:"lookback"`
+	Simulations int     `json:"simulations"`
+	Seed        int64   `json:"seed,string,omitempty"` // A string so that large seeds survive JSONB
+}
+
+// backtestJobParams are the parameters a backtest job was started with
+type backtestJobParams struct {
+	Confidence float64 `json:"confidence"`
+	Days       int     `json:"days"`
+}
+
+// reportJobParams are the parameters a report job was started with
+type reportJobParams struct {
+	ReportType string     `json:"report_type"`
+	Format     string     `json:"format"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+}
+
+// JobService queues calculations too slow to run within a request on a Redis list and runs them
+// on background workers, recording their progress and results and publishing each change to the
+// user who started them
+type JobService struct {
+	db            *gorm.DB
+	cfg           *config.JobConfig
+	varService    *VaRService
+	backtest      *BacktestService
+	reportService *ReportService
+	runners       map[string]jobRunner
+	logger        *slog.Logger
+}
+
+func NewJobService(cfg *config.JobConfig, riskCfg *config.RiskConfig) *JobService {
+	// A job is bounded by the job timeout rather than the one meant for requests
+	varCfg := *riskCfg
+	varCfg.VaRTimeout = 0
+
+	s := &JobService{
+		db:            database.GetDB(),
+		cfg:           cfg,
+		varService:    NewVaRService(&varCfg),
+		backtest:      NewBacktestService(),
+		reportService: NewReportService(),
+		logger:        logging.Component("jobs"),
+	}
+	s.runners = map[string]jobRunner{
+		models.JobTypeVaR:      s.runVaR,
+		models.JobTypeBacktest: s.runBacktest,
+		models.JobTypeReport:   s.runReport,
+	}
+	return s
+}
+
+// StartVaRJob queues a VaR calculation of a portfolio, e.g. a Monte Carlo run with many paths.
+// The stored risk metric and its liquidity-adjusted VaR are the job's result.
+func (s *JobService) StartVaRJob(ctx context.Context, portfolioID uuid.UUID, params VaRParams, userID uuid.UUID) (*models.Job, error) {
+	return s.enqueue(ctx, models.JobTypeVaR, &portfolioID, varJobParams{
+		Method:      params.Method,
+		Confidence:  params.Confidence,
+		Horizon:     params.Horizon,
+		Lookback:    params.Lookback,
+		Simulations: params.Simulations,
+		Seed:        params.Seed,
+	}, userID)
+}
+
+// StartBacktestJob queues a VaR backtest of a portfolio over the last days
+func (s *JobService) StartBacktestJob(ctx context.Context, portfolioID uuid.UUID, confidence float64, days int, userID uuid.UUID) (*models.Job, error) {
+	return s.enqueue(ctx, models.JobTypeBacktest, &portfolioID, backtestJobParams{
+		Confidence: confidence,
+		Days:       days,
+	}, userID)
+}
+
+// StartReportJob queues the generation of a report for the user who is its GeneratedBy. The
+// stored report's ID is the job's result.
+func (s *JobService) StartReportJob(ctx context.Context, req GenerateReportRequest) (*models.Job, error) {
+	if req.GeneratedBy == nil {
+		return nil, errors.New("report job has no user")
+	}
+	if _, ok := reportTitles[req.ReportType]; !ok {
+		return nil, apperror.BadRequest("unsupported report type, use DAILY_RISK, COMPLIANCE_SUMMARY or ALERT_HISTORY")
+	}
+	return s.enqueue(ctx, models.JobTypeReport, &req.PortfolioID, reportJobParams{
+		ReportType: req.ReportType,
+		Format:     req.Format,
+		From:       req.From,
+		To:         req.To,
+	}, *req.GeneratedBy)
+}
+
+// enqueue records a job and pushes it onto the queue. A job whose push fails stays QUEUED and is
+// pushed again by the next sweep.
+func (s *JobService) enqueue(ctx context.Context, jobType string, portfolioID *uuid.UUID, params interface{}, userID uuid.UUID) (*models.Job, error) {
+	encoded, err := jobJSON(params)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		JobType:     jobType,
+		Status:      models.JobStatusQueued,
+		Params:      encoded,
+		PortfolioID: portfolioID,
+		RequestedBy: userID,
+		ExpiresAt:   time.Now().Add(s.cfg.TTL),
+	}
+	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.push(ctx, job.ID); err != nil {
+		s.logger.WarnContext(ctx, "Failed to queue job, leaving it for the sweep", "job_id", job.ID, "error", err)
+	}
+	s.publish(ctx, job)
+	return job, nil
+}
+
+// push adds a job to the queue
+func (s *JobService) push(ctx context.Context, jobID uuid.UUID) error {
+	return database.GetRedis().LPush(ctx, jobQueueKey, jobID.String()).Err()
+}
+
+// GetJob returns an unexpired job started by the user
+func (s *JobService) GetJob(jobID, userID uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	err := s.db.Where("id = ? AND requested_by = ? AND expires_at > ?", jobID, userID, time.Now()).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Job not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs returns a page of the unexpired jobs started by the user, without their results
+func (s *JobService) ListJobs(userID uuid.UUID, spec pagination.Spec, params pagination.Params) ([]models.Job, int64, error) {
+	query := s.db.Model(&models.Job{}).Omit("result").
+		Where("requested_by = ? AND expires_at > ?", userID, time.Now())
+
+	var jobs []models.Job
+	total, err := pagination.Find(query, spec, params, &jobs)
+	return jobs, total, err
+}
+
+// StartWorkers runs the configured number of workers on the queue, and sweeps lost and stalled
+// jobs at the sweep interval, until ctx is cancelled. A job interrupted by the shutdown is queued
+// again for another instance.
+func (s *JobService) StartWorkers(ctx context.Context) {
+	if s.cfg.Workers <= 0 {
+		s.logger.Info("Job workers disabled")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		wg.Go(func() { s.work(ctx) })
+	}
+	if s.cfg.SweepInterval > 0 {
+		wg.Go(func() { s.sweepLoop(ctx) })
+	}
+	s.logger.Info("Job workers started", "workers", s.cfg.Workers)
+	wg.Wait()
+}
+
+// work pops jobs off the queue and runs them one at a time
+func (s *JobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		popped, err := database.GetRedis().BRPop(ctx, jobPopTimeout, jobQueueKey).Result()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.Nil) {
+				continue
+			}
+			if !errors.Is(err, database.ErrRedisUnavailable) {
+				s.logger.Error("Failed to pop job", "error", err)
+			}
+			// Redis is down; wait rather than spin until it is back
+			select {
+			case <-ctx.Done():
+			case <-time.After(jobPopTimeout):
+			}
+			continue
+		}
+
+		jobID, err := uuid.Parse(popped[1])
+		if err != nil {
+			s.logger.Warn("Dropping invalid job ID from the queue", "job_id", popped[1])
+			continue
+		}
+		s.run(ctx, jobID)
+	}
+}
+
+// run claims a queued job and runs it to completion or failure. Another worker may have claimed
+// it already, as a job can be queued more than once.
+func (s *JobService) run(workerCtx context.Context, jobID uuid.UUID) {
+	ctx := logging.WithNewRequestID(context.WithoutCancel(workerCtx))
+	now := time.Now()
+	claim := s.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ?", jobID, models.JobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusRunning,
+			"started_at": now,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"message":    "",
+		})
+	if claim.Error != nil {
+		s.logger.ErrorContext(ctx, "Failed to claim job", "job_id", jobID, "error", claim.Error)
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	var job models.Job
+	if err := s.db.WithContext(ctx).First(&job, jobID).Error; err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load job", "job_id", jobID, "error", err)
+		return
+	}
+	s.publish(ctx, &job)
+
+	runner, ok := s.runners[job.JobType]
+	if !ok {
+		s.finish(ctx, &job, nil, fmt.Errorf("unknown job type %q", job.JobType))
+		return
+	}
+
+	// Shutting down cancels the job as well as the timeout does
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(workerCtx, cancel)
+	defer stop()
+	if s.cfg.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(runCtx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	s.logger.InfoContext(ctx, "Job started", "job_id", job.ID, "job_type", job.JobType, "attempt", job.Attempts)
+	result, err := runJob(runCtx, runner, &job, &jobProgress{service: s, job: &job})
+	if err != nil && workerCtx.Err() != nil {
+		s.requeue(ctx, &job)
+		return
+	}
+	s.finish(ctx, &job, result, err)
+}
+
+// runJob runs a job, turning a panic into an error
+func runJob(ctx context.Context, runner jobRunner, job *models.Job, progress *jobProgress) (result models.JSON, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return runner(ctx, job, progress)
+}
+
+// finish records the result of a job, or why it failed
+func (s *JobService) finish(ctx context.Context, job *models.Job, result models.JSON, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{"completed_at": now}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Job failed", "job_id", job.ID, "job_type", job.JobType, "error", err)
+		job.Status = models.JobStatusFailed
+		job.Message = jobErrorMessage(err)
+	} else {
+		s.logger.InfoContext(ctx, "Job completed", "job_id", job.ID, "job_type", job.JobType,
+			"duration", now.Sub(*job.StartedAt))
+		job.Status = models.JobStatusCompleted
+		job.Progress = 100
+		job.Message = ""
+		job.Result = result
+		updates["result"] = result
+	}
+	job.CompletedAt = &now
+	updates["status"] = job.Status
+	updates["progress"] = job.Progress
+	updates["message"] = job.Message
+
+	if err := s.db.WithContext(ctx).Model(&models.Job{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save job", "job_id", job.ID, "error", err)
+		return
+	}
+	s.publish(ctx, job)
+}
+
+// requeue puts a job interrupted by a shutdown back on the queue
+func (s *JobService) requeue(ctx context.Context, job *models.Job) {
+	job.Status = models.JobStatusQueued
+	job.Progress = 0
+	job.Message = "Interrupted by a restart, queued again"
+	err := s.db.WithContext(ctx).Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"progress":   job.Progress,
+		"message":    job.Message,
+		"started_at": nil,
+	}).Error
+	if err == nil {
+		err = s.push(ctx, job.ID)
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to queue interrupted job again, leaving it for the sweep", "job_id", job.ID, "error", err)
+	}
+	s.publish(ctx, job)
+}
+
+// jobErrorMessage is what the user is told about a failed job
+func jobErrorMessage(err error) string {
+	var appErr *apperror.Error
+	switch {
+	case errors.As(err, &appErr):
+		return appErr.Message
+	case errors.Is(err, ErrInsufficientPriceHistory):
+		return "Not enough NAV snapshots to calculate VaR; use method=simplified"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Job timed out"
+	}
+	return "Job failed"
+}
+
+// sweepLoop sweeps the jobs at the sweep interval until ctx is cancelled
+func (s *JobService) sweepLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if err := s.Sweep(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Job sweep failed", "error", err)
+		}
+	}
+}
+
+// Sweep queues again the jobs that have waited longer than the sweep interval, in case their
+// queue entry was lost, fails the jobs running past the timeout whose worker must have stopped and
+// deletes expired jobs
+func (s *JobService) Sweep(ctx context.Context) error {
+	now := time.Now()
+	db := s.db.WithContext(ctx)
+
+	var waiting []uuid.UUID
+	err := db.Model(&models.Job{}).Where("status = ? AND updated_at < ?", models.JobStatusQueued, now.Add(-s.cfg.SweepInterval)).
+		Pluck("id", &waiting).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range waiting {
+		if err := s.push(ctx, id); err != nil {
+			return err
+		}
+		// Touched so that a job is pushed again at most once per sweep interval
+		db.Model(&models.Job{}).Where("id = ?", id).Update("updated_at", now)
+	}
+
+	if s.cfg.Timeout > 0 {
+		var stalled []models.Job
+		err := db.Where("status = ? AND started_at < ?", models.JobStatusRunning, now.Add(-s.cfg.Timeout-s.cfg.SweepInterval)).
+			Find(&stalled).Error
+		if err != nil {
+			return err
+		}
+		for i := range stalled {
+			s.finish(ctx, &stalled[i], nil, apperror.Internal("The worker running the job stopped", nil))
+		}
+	}
+
+	purged := db.Where("expires_at <= ?", now).Delete(&models.Job{})
+	if purged.Error != nil {
+		return purged.Error
+	}
+	if len(waiting) > 0 || purged.RowsAffected > 0 {
+		s.logger.InfoContext(ctx, "Swept jobs", "requeued", len(waiting), "purged", purged.RowsAffected)
+	}
+	return nil
+}
+
+// publish tells the user who started a job that its status or progress changed
+func (s *JobService) publish(ctx context.Context, job *models.Job) {
+	event := map[string]interface{}{
+		"job_id":       job.ID,
+		"job_type":     job.JobType,
+		"status":       job.Status,
+		"progress":     job.Progress,
+		"requested_by": job.RequestedBy,
+		"timestamp":    time.Now().Unix(),
+	}
+	if job.PortfolioID != nil {
+		event["portfolio_id"] = job.PortfolioID
+	}
+	if job.Message != "" {
+		event["message"] = job.Message
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		event["request_id"] = requestID
+	}
+
+	payload, err := json.Marshal(event)
+	if err == nil {
+		err = database.GetRedis().Publish(ctx, database.JobsChannel, payload).Err()
+	}
+	if err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+		s.logger.ErrorContext(ctx, "Failed to publish job update", "job_id", job.ID, "error", err)
+	}
+}
+
+// jobProgress records how far a running job has got, at most once per jobProgressInterval
+// unless the step changes. It is safe for concurrent use.
+type jobProgress struct {
+	service *JobService
+	job     *models.Job
+
+	mu         sync.Mutex
+	reportedAt time.Time
+}
+
+// Report records the percent of a job done and the step it is on
+func (p *jobProgress) Report(ctx context.Context, percent int, step string) {
+	percent = min(max(percent, 0), 99)
+
+	p.mu.Lock()
+	if percent < p.job.Progress || (step == p.job.Message &&
+		(percent == p.job.Progress || time.Since(p.reportedAt) < jobProgressInterval)) {
+		p.mu.Unlock()
+		return
+	}
+	p.job.Progress = percent
+	p.job.Message = step
+	p.reportedAt = time.Now()
+	snapshot := *p.job
+	p.mu.Unlock()
+
+	err := p.service.db.WithContext(ctx).Model(&models.Job{}).Where("id = ?", snapshot.ID).
+		Updates(map[string]interface{}{"progress": snapshot.Progress, "message": snapshot.Message}).Error
+	if err != nil {
+		p.service.logger.WarnContext(ctx, "Failed to save job progress", "job_id", snapshot.ID, "error", err)
+	}
+	p.service.publish(ctx, &snapshot)
+}
+
+// runVaR calculates and stores the VaR of the job's portfolio
+func (s *JobService) runVaR(ctx context.Context, job *models.Job, progress *jobProgress) (models.JSON, error) {
+	var params varJobParams
+	if err := decodeJobParams(job, &params); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, 0, "Loading portfolio")
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, job.PortfolioID).Error; err != nil {
+		return nil, apperror.NotFound("Portfolio not found")
+	}
+	if len(portfolio.Positions) == 0 {
+		return nil, apperror.BadRequest("Portfolio has no positions")
+	}
+
+	progress.Report(ctx, 5, "Calculating "+params.Method+" VaR")
+	metric, lvar, err := s.varService.Calculate(ctx, &portfolio, VaRParams{
+		Method:      params.Method,
+		Confidence:  params.Confidence,
+		Horizon:     params.Horizon,
+		Lookback:    params.Lookback,
+		Simulations: params.Simulations,
+		Seed:        params.Seed,
+		Progress: func(completed, total int) {
+			progress.Report(ctx, 5+90*completed/total, "Simulating paths")
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, 95, "Storing the result")
+	if err := s.db.WithContext(ctx).Create(metric).Error; err != nil {
+		return nil, err
+	}
+
+	return models.JSON{
+		"metric_id":              metric.ID,
+		"var_value":              metric.Value,
+		"var_percentage":         SimplifiedVaRPercent(&portfolio, metric.Value),
+		"confidence_level":       params.Confidence,
+		"time_horizon":           params.Horizon,
+		"method":                 params.Method,
+		"details":                metric.Details,
+		"portfolio_value":        portfolio.ValueWithCash(),
+		"status":                 metric.Status,
+		"threshold":              metric.Threshold,
+		"liquidity_adjusted_var": lvar,
+		"calculated_at":          time.Now(),
+	}, nil
+}
+
+// runBacktest backtests the stored VaR forecasts of the job's portfolio
+func (s *JobService) runBacktest(ctx context.Context, job *models.Job, progress *jobProgress) (models.JSON, error) {
+	var params backtestJobParams
+	if err := decodeJobParams(job, &params); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, 10, "Backtesting VaR forecasts")
+	report, err := s.backtest.BacktestVaR(*job.PortfolioID, params.Confidence, params.Days)
+	if err != nil {
+		return nil, err
+	}
+	return jobJSON(report)
+}
+
+// runReport generates and stores a report for the job's portfolio
+func (s *JobService) runReport(ctx context.Context, job *models.Job, progress *jobProgress) (models.JSON, error) {
+	var params reportJobParams
+	if err := decodeJobParams(job, &params); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, 10, "Generating report")
+	report, err := s.reportService.GenerateReport(GenerateReportRequest{
+		PortfolioID: *job.PortfolioID,
+		ReportType:  params.ReportType,
+		Format:      params.Format,
+		From:        params.From,
+		To:          params.To,
+		GeneratedBy: &job.RequestedBy,
+	})
+	if err != nil {
+		// GenerateReport's errors describe what was wrong with the request
+		if err.Error() == "portfolio not found" {
+			return nil, apperror.NotFound("Portfolio not found")
+		}
+		return nil, apperror.BadRequest(err.Error())
+	}
+
+	return models.JSON{
+		"report_id":    report.ID,
+		"report_type":  report.ReportType,
+		"format":       report.Format,
+		"title":        report.Title,
+		"file_name":    report.FileName,
+		"size":         report.Size,
+		"period_from":  report.PeriodFrom,
+		"period_to":    report.PeriodTo,
+		"generated_at": report.CreatedAt,
+	}, nil
+}
+
+// jobJSON converts a value into the JSON object a job's parameters or result are stored as
+func jobJSON(v interface{}) (models.JSON, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out models.JSON
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+// decodeJobParams reads a job's parameters into the struct they were stored from
+func decodeJobParams(job *models.Job, params interface{}) error {
+	raw, err := json.Marshal(job.Params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, params)
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// kycReviewCycle is how long a verified profile stays current before it must be reviewed again,
+// by risk rating
+var kycReviewCycle = map[string]time.Duration{
+	"LOW":    3 * 365 * 24 * time.Hour,
+	"MEDIUM": 2 * 365 * 24 * time.Hour,
+	"HIGH":   365 * 24 * time.Hour,
+}
+
+var kycDocumentTypes = map[string]bool{
+	"PASSPORT": true, "NATIONAL_ID": true, "DRIVERS_LICENSE": true,
+	"PROOF_OF_ADDRESS": true, "INCORPORATION_CERTIFICATE": true,
+}
+
+// KYCBlockedError is returned when a transaction's portfolio owner or counterparty has a KYC
+// profile that is not verified or has lapsed
+type KYCBlockedError struct {
+	SubjectType string
+	SubjectID   uuid.UUID
+	Status      string
+}
+
+func (e *KYCBlockedError) Error() string {
+	subject := "portfolio owner"
+	if e.SubjectType == models.KYCSubjectCounterparty {
+		subject = "counterparty"
+	}
+	return fmt.Sprintf("%s's KYC profile is %s", subject, strings.ToLower(e.Status))
+}
+
+// KYCProfileService keeps the KYC profiles of users and counterparties and blocks transactions
+// whose owner or counterparty profile is not current. Subjects without a profile are not blocked.
+type KYCProfileService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewKYCProfileService() *KYCProfileService {
+	return &KYCProfileService{
+		db:     database.GetDB(),
+		logger: logging.Component("kyc"),
+	}
+}
+
+// validateKYCProfile normalises and checks a profile before it is stored. Document references
+// are masked so only their last four characters are kept.
+func validateKYCProfile(profile *models.KYCProfile) error {
+	profile.SubjectType = strings.ToUpper(profile.SubjectType)
+	profile.RiskRating = strings.ToUpper(profile.RiskRating)
+
+	if profile.SubjectType != models.KYCSubjectUser && profile.SubjectType != models.KYCSubjectCounterparty {
+		return fmt.Errorf("unsupported subject type %q, use USER or COUNTERPARTY", profile.SubjectType)
+	}
+	if profile.RiskRating == "" {
+		profile.RiskRating = "MEDIUM"
+	}
+	if _, ok := kycReviewCycle[profile.RiskRating]; !ok {
+		return fmt.Errorf("unsupported risk rating: %s", profile.RiskRating)
+	}
+	if profile.Status == "" {
+		profile.Status = models.KYCStatusPending
+	}
+
+	for i := range profile.Documents {
+		doc := &profile.Documents[i]
+		doc.Type = strings.ToUpper(strings.TrimSpace(doc.Type))
+		doc.IssuingCountry = strings.ToUpper(strings.TrimSpace(doc.IssuingCountry))
+		doc.Reference = maskReference(doc.Reference)
+		if !kycDocumentTypes[doc.Type] {
+			return fmt.Errorf("unsupported document type: %s", doc.Type)
+		}
+		if doc.IssuedAt != nil && doc.ExpiresAt != nil && !doc.ExpiresAt.After(*doc.IssuedAt) {
+			return fmt.Errorf("%s expires_at must be after issued_at", strings.ToLower(doc.Type))
+		}
+	}
+	return nil
+}
+
+// maskReference keeps the last four characters of a document number. References that are already
+// masked are left as they are.
+func maskReference(reference string) string {
+	reference = strings.TrimSpace(reference)
+	if strings.HasPrefix(reference, "*") || len(reference) <= 4 {
+		return reference
+	}
+	return strings.Repeat("*", len(reference)-4) + reference[len(reference)-4:]
+}
+
+// ListProfiles returns a page of KYC profiles and the total match count
+func (s *KYCProfileService) ListProfiles(spec pagination.Spec, params pagination.Params) ([]models.KYCProfile, int64, error) {
+	var profiles []models.KYCProfile
+	total, err := pagination.Find(s.db.Model(&models.KYCProfile{}), spec, params, &profiles)
+	return profiles, total, err
+}
+
+// GetProfile returns a KYC profile by ID
+func (s *KYCProfileService) GetProfile(profileID uuid.UUID) (*models.KYCProfile, error) {
+	var profile models.KYCProfile
+	if err := s.db.First(&profile, profileID).Error; err != nil {
+		return nil, errors.New("KYC profile not found")
+	}
+	return &profile, nil
+}
+
+// profileFor returns the profile of a subject, or nil when it has none
+func (s *KYCProfileService) profileFor(subjectType string, subjectID uuid.UUID) (*models.KYCProfile, error) {
+	var profile models.KYCProfile
+	err := s.db.Where("subject_type = ? AND subject_id = ?", subjectType, subjectID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// CreateProfile validates and stores a new profile, pending review, for a user or counterparty
+// that has none
+func (s *KYCProfileService) CreateProfile(profile *models.KYCProfile) error {
+	profile.Status = models.KYCStatusPending
+	if err := validateKYCProfile(profile); err != nil {
+		return err
+	}
+
+	var subject interface{} = &models.User{}
+	if profile.SubjectType == models.KYCSubjectCounterparty {
+		subject = &models.Counterparty{}
+	}
+	if err := s.db.Select("id").First(subject, "id = ?", profile.SubjectID).Error; err != nil {
+		return fmt.Errorf("%s not found", strings.ToLower(profile.SubjectType))
+	}
+
+	existing, err := s.profileFor(profile.SubjectType, profile.SubjectID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("subject already has a KYC profile")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(profile).Error; err != nil {
+			return err
+		}
+		return syncCounterpartyKYC(tx, profile)
+	})
+}
+
+// UpdateProfile validates and saves changes to a profile's documents, risk rating and notes. Its
+// status only changes through a review.
+func (s *KYCProfileService) UpdateProfile(profile *models.KYCProfile) error {
+	if err := validateKYCProfile(profile); err != nil {
+		return err
+	}
+	return s.db.Save(profile).Error
+}
+
+// ReviewProfile records a review that verifies or rejects a profile. A verified profile is due
+// for review again after the cycle of its risk rating; it needs documents, none of them expired.
+func (s *KYCProfileService) ReviewProfile(profile *models.KYCProfile, decision string, reviewerID uuid.UUID) error {
+	if err := validateKYCProfile(profile); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch strings.ToUpper(decision) {
+	case models.KYCStatusVerified:
+		if len(profile.Documents) == 0 {
+			return errors.New("a profile needs identity documents to be verified")
+		}
+		if profile.DocumentsExpired(now) {
+			return errors.New("a profile with expired documents cannot be verified")
+		}
+		nextReview := now.Add(kycReviewCycle[profile.RiskRating])
+		profile.Status = models.KYCStatusVerified
+		profile.VerifiedAt = &now
+		profile.NextReviewAt = &nextReview
+	case models.KYCStatusRejected:
+		profile.Status = models.KYCStatusRejected
+		profile.NextReviewAt = nil
+	default:
+		return fmt.Errorf("unsupported review decision %q, use VERIFIED or REJECTED", decision)
+	}
+	profile.LastReviewedAt = &now
+	profile.LastReviewedBy = &reviewerID
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(profile).Error; err != nil {
+			return err
+		}
+		return syncCounterpartyKYC(tx, profile)
+	})
+}
+
+// DeleteProfile removes a profile and returns it
+func (s *KYCProfileService) DeleteProfile(profileID uuid.UUID) (*models.KYCProfile, error) {
+	profile, err := s.GetProfile(profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(profile).Error; err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// DueForReview returns the profiles awaiting a first review or expired, and the verified ones due
+// for review within the given period, soonest first
+func (s *KYCProfileService) DueForReview(within time.Duration) ([]models.KYCProfile, error) {
+	var profiles []models.KYCProfile
+	err := s.db.Where("status IN ? OR (status = ? AND next_review_at <= ?)",
+		[]string{models.KYCStatusPending, models.KYCStatusExpired}, models.KYCStatusVerified, time.Now().Add(within)).
+		Order("next_review_at ASC NULLS FIRST").
+		Find(&profiles).Error
+	return profiles, err
+}
+
+// syncCounterpartyKYC copies a counterparty profile's status onto the counterparty record, which
+// the counterparty checks read
+func syncCounterpartyKYC(tx *gorm.DB, profile *models.KYCProfile) error {
+	if profile.SubjectType != models.KYCSubjectCounterparty {
+		return nil
+	}
+	return tx.Model(&models.Counterparty{}).Where("id = ?", profile.SubjectID).Updates(map[string]interface{}{
+		"kyc_status":      profile.Status,
+		"kyc_verified_at": profile.VerifiedAt,
+		"kyc_expires_at":  profile.NextReviewAt,
+	}).Error
+}
+
+// Check returns why a transaction's portfolio owner or counterparty fails KYC, and sets the
+// transaction's KYCVerified when both, or the owner of a trade without a counterparty, have a
+// current profile
+func (s *KYCProfileService) Check(transaction *models.Transaction) ([]*KYCBlockedError, error) {
+	var portfolio models.Portfolio
+	if err := s.db.Select("id", "user_id").First(&portfolio, "id = ?", transaction.PortfolioID).Error; err != nil {
+		return nil, errors.New("portfolio not found")
+	}
+
+	subjects := map[string]uuid.UUID{models.KYCSubjectUser: portfolio.UserID}
+	if transaction.CounterpartyID != nil {
+		subjects[models.KYCSubjectCounterparty] = *transaction.CounterpartyID
+	}
+
+	now := time.Now()
+	blocks := []*KYCBlockedError{}
+	verified := true
+	for _, subjectType := range []string{models.KYCSubjectUser, models.KYCSubjectCounterparty} {
+		subjectID, ok := subjects[subjectType]
+		if !ok {
+			continue
+		}
+		profile, err := s.profileFor(subjectType, subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			verified = false
+			continue
+		}
+		if !profile.Current(now) {
+			verified = false
+			blocks = append(blocks, &KYCBlockedError{SubjectType: subjectType, SubjectID: subjectID, Status: profile.EffectiveStatus(now)})
+		}
+	}
+
+	transaction.KYCVerified = verified
+	return blocks, nil
+}
+
+// Enforce returns a KYCBlockedError when a transaction's portfolio owner or counterparty has a
+// profile that is not current
+func (s *KYCProfileService) Enforce(transaction *models.Transaction) error {
+	blocks, err := s.Check(transaction)
+	if err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		return blocks[0]
+	}
+	return nil
+}
+
+// ExpireLapsed marks verified profiles past their review date or with an expired document as
+// EXPIRED and returns how many were expired
+func (s *KYCProfileService) ExpireLapsed(ctx context.Context) (int, error) {
+	var profiles []models.KYCProfile
+	if err := s.db.Where("status = ?", models.KYCStatusVerified).Find(&profiles).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	expired := 0
+	for i := range profiles {
+		profile := &profiles[i]
+		if profile.Current(now) {
+			continue
+		}
+		profile.Status = models.KYCStatusExpired
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(profile).Update("status", profile.Status).Error; err != nil {
+				return err
+			}
+			return syncCounterpartyKYC(tx, profile)
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to expire KYC profile", "profile_id", profile.ID, "error", err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// StartExpiryJob expires lapsed profiles at a fixed interval until ctx is cancelled
+func (s *KYCProfileService) StartExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		expired, err := s.ExpireLapsed(ctx)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "KYC profile expiry failed", "error", err)
+			continue
+		}
+		if expired > 0 {
+			s.logger.InfoContext(ctx, "Expired lapsed KYC profiles", "expired", expired)
+		}
+	}
+}
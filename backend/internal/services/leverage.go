@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// Alert sources used to avoid raising the same leverage alert while one is still active
+const (
+	leverageAlertSource   = "LEVERAGE_CALCULATOR"
+	marginCallAlertSource = "MARGIN_MONITOR"
+)
+
+// LeverageService computes portfolio leverage, records it as a risk metric and raises alerts
+// when the leverage limit is breached or equity falls below the maintenance margin
+type LeverageService struct {
+	db           *gorm.DB
+	riskEngine   *RiskEngineService
+	alertService *AlertService
+	calculator   *calculator.LeverageCalculator
+	logger       *slog.Logger
+}
+
+func NewLeverageService() *LeverageService {
+	return &LeverageService{
+		db:           database.GetDB(),
+		riskEngine:   NewRiskEngineService(),
+		alertService: NewAlertService(),
+		calculator:   calculator.NewLeverageCalculator(),
+		logger:       logging.Component("leverage"),
+	}
+}
+
+// CheckLeverage calculates and records a portfolio's leverage against its MaxLeverage threshold
+func (s *LeverageService) CheckLeverage(ctx context.Context, portfolioID uuid.UUID) (*calculator.LeverageResult, error) {
+	defer metrics.RiskCalculationDuration.With("leverage").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.leverage", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.calculate(&portfolio, thresholds)
+
+	metric := models.RiskMetric{
+		PortfolioID: portfolioID,
+		MetricType:  "LEVERAGE",
+		Value:       decimal.NewFromFloat(result.GrossLeverage).Round(4),
+		Threshold:   thresholds.MaxLeverage,
+		Status:      result.Status,
+		Details: models.JSON{
+			"net_leverage":     result.NetLeverage,
+			"gross_exposure":   result.GrossExposure,
+			"net_exposure":     result.NetExposure,
+			"equity":           result.Equity,
+			"excess_liquidity": result.ExcessLiquidity,
+			"margin_call":      result.MarginCall,
+		},
+	}
+	if err := s.db.Create(&metric).Error; err != nil {
+		return nil, err
+	}
+
+	s.raiseAlerts(ctx, portfolioID, result)
+	return result, nil
+}
+
+// calculate returns the leverage and margin position of a portfolio loaded with its positions
+func (s *LeverageService) calculate(portfolio *models.Portfolio, thresholds *models.RiskThresholds) *calculator.LeverageResult {
+	return s.calculator.CalculateLeverage(portfolio.Positions, calculator.MarginAccount{
+		CashBalance:           portfolio.CashBalance.InexactFloat64(),
+		MarginLoan:            portfolio.MarginLoan.InexactFloat64(),
+		MaintenanceMarginRate: portfolio.MaintenanceMarginRate.InexactFloat64(),
+	}, thresholds.MaxLeverage.InexactFloat64())
+}
+
+// raiseAlerts raises leverage and margin call alerts unless the same alert is still active
+func (s *LeverageService) raiseAlerts(ctx context.Context, portfolioID uuid.UUID, result *calculator.LeverageResult) {
+	if result.MaxLeverage > 0 && result.GrossLeverage > result.MaxLeverage && !s.hasActiveAlert(portfolioID, leverageAlertSource) {
+		if err := s.alertService.CreateRiskBreachAlert(ctx, portfolioID, "LEVERAGE", result.GrossLeverage, result.MaxLeverage); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create leverage alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+
+	if result.MarginCall && !s.hasActiveAlert(portfolioID, marginCallAlertSource) {
+		alert := &models.Alert{
+			PortfolioID: portfolioID,
+			AlertType:   "RISK_BREACH",
+			Severity:    "CRITICAL",
+			Title:       "Margin Call",
+			Description: fmt.Sprintf("Equity of %.2f is below the maintenance requirement of %.2f", result.Equity, result.MaintenanceRequirement),
+			Source:      marginCallAlertSource,
+			Status:      "ACTIVE",
+			TriggeredBy: models.JSON{
+				"metric_type":             "LEVERAGE",
+				"equity":                  result.Equity,
+				"maintenance_requirement": result.MaintenanceRequirement,
+				"gross_exposure":          result.GrossExposure,
+				"excess_liquidity":        result.ExcessLiquidity,
+			},
+		}
+		if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create margin call alert", "portfolio_id", portfolioID, "error", err)
+		}
+	}
+}
+
+func (s *LeverageService) hasActiveAlert(portfolioID uuid.UUID, source string) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, source, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// StartMonitor checks the leverage of every portfolio with positions at a fixed interval until ctx is cancelled
+func (s *LeverageService) StartMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		var portfolioIDs []uuid.UUID
+		if err := s.db.Model(&models.Position{}).Distinct("portfolio_id").Pluck("portfolio_id", &portfolioIDs).Error; err != nil {
+			s.logger.ErrorContext(ctx, "Leverage monitor failed to load portfolios", "error", err)
+			continue
+		}
+
+		for _, portfolioID := range portfolioIDs {
+			if _, err := s.CheckLeverage(ctx, portfolioID); err != nil {
+				s.logger.ErrorContext(ctx, "Leverage check failed", "portfolio_id", portfolioID, "error", err)
+			}
+		}
+	}
+}
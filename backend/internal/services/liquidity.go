@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// LiquidityService classifies positions as HIGH, MEDIUM or LOW liquidity from the market data of
+// their symbols and keeps the classification, and any manual override, on the position
+type LiquidityService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewLiquidityService() *LiquidityService {
+	return &LiquidityService{
+		db:     database.GetDB(),
+		logger: logging.Component("liquidity"),
+	}
+}
+
+// MarketDataRequest sets the trading activity of a symbol
+type MarketDataRequest struct {
+	AverageDailyVolume decimal.Decimal `json:"average_daily_volume"`
+	BidAskSpread       decimal.Decimal `json:"bid_ask_spread"`
+}
+
+// ClassificationRun summarizes a reclassification of positions
+type ClassificationRun struct {
+	Classified   int `json:"classified"`   // Positions with a calculated class
+	Changed      int `json:"changed"`      // Positions whose effective class changed
+	Unclassified int `json:"unclassified"` // Positions whose symbol has no market data
+}
+
+// PositionLiquidityView is a position with its liquidity classification, if it has one
+type PositionLiquidityView struct {
+	PositionID     uuid.UUID                 `json:"position_id"`
+	Symbol         string                    `json:"symbol"`
+	AssetType      string                    `json:"asset_type"`
+	Quantity       decimal.Decimal           `json:"quantity"`
+	MarketValue    decimal.Decimal           `json:"market_value"`
+	Liquidity      string                    `json:"liquidity"`
+	Classification *models.PositionLiquidity `json:"classification"`
+}
+
+// ListMarketData returns the market data of every symbol
+func (s *LiquidityService) ListMarketData() ([]models.SymbolMarketData, error) {
+	var data []models.SymbolMarketData
+	err := s.db.Order("symbol").Find(&data).Error
+	return data, err
+}
+
+// UpsertMarketData sets the market data of a symbol
+func (s *LiquidityService) UpsertMarketData(symbol string, req MarketDataRequest, userID uuid.UUID) (*models.SymbolMarketData, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	if req.AverageDailyVolume.IsNegative() {
+		return nil, errors.New("average_daily_volume must not be negative")
+	}
+	if req.BidAskSpread.IsNegative() || req.BidAskSpread.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.New("bid_ask_spread must be a fraction of price between 0 and 1")
+	}
+
+	data := models.SymbolMarketData{
+		Symbol:             symbol,
+		AverageDailyVolume: req.AverageDailyVolume,
+		BidAskSpread:       req.BidAskSpread,
+		UpdatedBy:          &userID,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"average_daily_volume", "bid_ask_spread", "updated_by", "updated_at"}),
+	}).Create(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetPortfolioLiquidity returns the positions of a portfolio with their liquidity classification
+func (s *LiquidityService) GetPortfolioLiquidity(portfolioID uuid.UUID) ([]PositionLiquidityView, error) {
+	var positions []models.Position
+	if err := s.db.Where("portfolio_id = ?", portfolioID).Order("symbol").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	classifications, err := s.classificationsFor(s.db, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]PositionLiquidityView, 0, len(positions))
+	for _, position := range positions {
+		view := PositionLiquidityView{
+			PositionID:  position.ID,
+			Symbol:      position.Symbol,
+			AssetType:   position.AssetType,
+			Quantity:    position.Quantity,
+			MarketValue: position.MarketValue,
+			Liquidity:   position.Liquidity,
+		}
+		if classification, ok := classifications[position.ID]; ok {
+			view.Classification = &classification
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// ClassifyPortfolio reclassifies the positions of one portfolio
+func (s *LiquidityService) ClassifyPortfolio(ctx context.Context, portfolioID uuid.UUID) (*ClassificationRun, error) {
+	var positions []models.Position
+	if err := s.db.WithContext(ctx).Where("portfolio_id = ?", portfolioID).Find(&positions).Error; err != nil {
+		return nil, err
+	}
+	return s.classify(ctx, positions)
+}
+
+// ClassifyAll reclassifies every position
+func (s *LiquidityService) ClassifyAll(ctx context.Context) (*ClassificationRun, error) {
+	var positions []models.Position
+	if err := s.db.WithContext(ctx).Find(&positions).Error; err != nil {
+		return nil, err
+	}
+	return s.classify(ctx, positions)
+}
+
+// classify recalculates the class of each position from its symbol's market data and stores the
+// effective class, the override if there is one, on the position
+func (s *LiquidityService) classify(ctx context.Context, positions []models.Position) (*ClassificationRun, error) {
+	ctx, span := tracing.Start(ctx, "risk.liquidity.classify", tracing.Int("position_count", len(positions)))
+	defer span.End()
+
+	run := &ClassificationRun{}
+	if len(positions) == 0 {
+		return run, nil
+	}
+
+	db := s.db.WithContext(ctx)
+	symbols := make([]string, 0, len(positions))
+	for _, position := range positions {
+		symbols = append(symbols, position.Symbol)
+	}
+	var data []models.SymbolMarketData
+	if err := db.Where("symbol IN ?", symbols).Find(&data).Error; err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	marketData := make(map[string]models.SymbolMarketData, len(data))
+	for _, d := range data {
+		marketData[d.Symbol] = d
+	}
+
+	classifications, err := s.classificationsFor(db, positions)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, position := range positions {
+		classification, exists := classifications[position.ID]
+		classification.PositionID = position.ID
+		classification.CalculatedAt = &now
+
+		d, hasData := marketData[position.Symbol]
+		switch {
+		case calculator.IsCashLike(position.AssetType):
+			classification.Source = models.LiquiditySourceAssetType
+		case hasData:
+			classification.Source = models.LiquiditySourceMarketData
+		default:
+			classification.Source = models.LiquiditySourceNone
+		}
+
+		if classification.Source == models.LiquiditySourceNone {
+			classification.CalculatedClass = ""
+			classification.Score = decimal.Zero
+			classification.DaysToLiquidate = decimal.Zero
+			classification.AverageDailyVolume = decimal.Zero
+			classification.BidAskSpread = decimal.Zero
+			run.Unclassified++
+		} else {
+			result := calculator.ClassifyLiquidity(calculator.LiquidityClassInput{
+				AssetType:          position.AssetType,
+				Quantity:           position.Quantity.InexactFloat64(),
+				AverageDailyVolume: d.AverageDailyVolume.InexactFloat64(),
+				BidAskSpread:       d.BidAskSpread.InexactFloat64(),
+			})
+			classification.CalculatedClass = result.Class
+			classification.Score = decimal.NewFromFloat(result.Score)
+			classification.DaysToLiquidate = decimal.NewFromFloat(result.DaysToLiquidate).Round(2)
+			classification.AverageDailyVolume = d.AverageDailyVolume
+			classification.BidAskSpread = d.BidAskSpread
+			run.Classified++
+		}
+
+		if exists {
+			err = db.Model(&classification).Select("source", "calculated_class", "score", "days_to_liquidate",
+				"average_daily_volume", "bid_ask_spread", "calculated_at").Updates(&classification).Error
+		} else {
+			err = db.Create(&classification).Error
+		}
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+
+		// Without market data or an override the position keeps the class it has
+		effective := classification.EffectiveClass()
+		if effective != "" && effective != position.Liquidity {
+			if err := db.Model(&position).UpdateColumn("liquidity", effective).Error; err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+			run.Changed++
+		}
+	}
+
+	span.SetAttributes(tracing.Int("liquidity.changed", run.Changed))
+	return run, nil
+}
+
+// SetOverride fixes a position's liquidity class whatever its market data says
+func (s *LiquidityService) SetOverride(positionID uuid.UUID, class, reason string, userID uuid.UUID) (*models.PositionLiquidity, error) {
+	class = strings.ToUpper(class)
+	if class != models.LiquidityHigh && class != models.LiquidityMedium && class != models.LiquidityLow {
+		return nil, errors.New("class must be HIGH, MEDIUM or LOW")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	var classification models.PositionLiquidity
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.loadOrInit(tx, positionID, &classification); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		classification.OverrideClass = &class
+		classification.OverrideReason = strings.TrimSpace(reason)
+		classification.OverriddenBy = &userID
+		classification.OverriddenAt = &now
+		if err := tx.Save(&classification).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Position{}).Where("id = ?", positionID).UpdateColumn("liquidity", class).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &classification, nil
+}
+
+// ClearOverride returns a position to its calculated class. A position that was never classified
+// keeps its current class.
+func (s *LiquidityService) ClearOverride(positionID uuid.UUID) (*models.PositionLiquidity, error) {
+	var classification models.PositionLiquidity
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("position_id = ?", positionID).First(&classification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("position has no liquidity override")
+			}
+			return err
+		}
+		if classification.OverrideClass == nil {
+			return errors.New("position has no liquidity override")
+		}
+
+		classification.OverrideClass = nil
+		classification.OverrideReason = ""
+		classification.OverriddenBy = nil
+		classification.OverriddenAt = nil
+		if err := tx.Save(&classification).Error; err != nil {
+			return err
+		}
+		if classification.CalculatedClass == "" {
+			return nil
+		}
+		return tx.Model(&models.Position{}).Where("id = ?", positionID).UpdateColumn("liquidity", classification.CalculatedClass).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &classification, nil
+}
+
+// GetPosition returns a position of a portfolio
+func (s *LiquidityService) GetPosition(portfolioID, positionID uuid.UUID) (*models.Position, error) {
+	var position models.Position
+	if err := s.db.Where("id = ? AND portfolio_id = ?", positionID, portfolioID).First(&position).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("position not found")
+		}
+		return nil, err
+	}
+	return &position, nil
+}
+
+// GetClassification returns the liquidity classification of a position, if it has one
+func (s *LiquidityService) GetClassification(positionID uuid.UUID) (*models.PositionLiquidity, error) {
+	var classification models.PositionLiquidity
+	if err := s.db.Where("position_id = ?", positionID).First(&classification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &classification, nil
+}
+
+// loadOrInit loads a position's classification, or prepares a new one if it was never classified
+func (s *LiquidityService) loadOrInit(tx *gorm.DB, positionID uuid.UUID, classification *models.PositionLiquidity) error {
+	err := tx.Where("position_id = ?", positionID).First(classification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		*classification = models.PositionLiquidity{
+			ID:         uuid.New(),
+			PositionID: positionID,
+			Source:     models.LiquiditySourceNone,
+		}
+		return nil
+	}
+	return err
+}
+
+// classificationsFor returns the stored classifications of positions by position ID
+func (s *LiquidityService) classificationsFor(db *gorm.DB, positions []models.Position) (map[uuid.UUID]models.PositionLiquidity, error) {
+	ids := make([]uuid.UUID, 0, len(positions))
+	for _, position := range positions {
+		ids = append(ids, position.ID)
+	}
+
+	var rows []models.PositionLiquidity
+	if len(ids) > 0 {
+		if err := db.Where("position_id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	classifications := make(map[uuid.UUID]models.PositionLiquidity, len(rows))
+	for _, row := range rows {
+		classifications[row.PositionID] = row
+	}
+	return classifications, nil
+}
+
+// StartScheduler reclassifies every position once a day at the given hour (UTC) until ctx is
+// cancelled
+func (s *LiquidityService) StartScheduler(ctx context.Context, hour int) {
+	for {
+		if !sleepUntil(ctx, nextDailyRun(time.Now(), hour)) {
+			return
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		run, err := s.ClassifyAll(ctx)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Nightly liquidity classification failed", "error", err)
+			continue
+		}
+		s.logger.InfoContext(ctx, "Position liquidity reclassified",
+			"classified", run.Classified, "changed", run.Changed, "unclassified", run.Unclassified)
+	}
+}
+
+// nextDailyRun returns the next time after now at which the UTC clock reads hour:00
+func nextDailyRun(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// sleepUntil waits until t and reports false if ctx was cancelled first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
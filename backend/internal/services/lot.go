@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// lotOrder is the order each cost basis method consumes open lots in
+var lotOrder = map[string]string{
+	models.CostBasisFIFO: "opened_at ASC, created_at ASC",
+	models.CostBasisLIFO: "opened_at DESC, created_at DESC",
+	models.CostBasisHIFO: "cost_price DESC, opened_at ASC",
+}
+
+// ValidCostBasisMethod reports whether a cost basis method is supported
+func ValidCostBasisMethod(method string) bool {
+	_, ok := lotOrder[method]
+	return ok
+}
+
+// LotService keeps the tax lots of each portfolio: every BUY fill opens a lot and every SELL fill
+// closes open lots of the symbol in the order of the portfolio's cost basis method, recording the
+// gain realized on each
+type LotService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewLotService() *LotService {
+	return &LotService{
+		db:     database.GetDB(),
+		logger: logging.Component("lot"),
+	}
+}
+
+// Book opens a lot for a BUY fill, or closes lots for a SELL fill and adds the gain realized to
+// the transaction's realized P&L. It runs in the transaction recording the fill.
+func (s *LotService) Book(tx *gorm.DB, transaction *models.Transaction, fill *models.Fill) error {
+	if !isOrder(transaction) {
+		return nil
+	}
+	portfolio, err := lockPortfolio(tx, transaction.PortfolioID)
+	if err != nil {
+		return err
+	}
+
+	var position models.Position
+	err = tx.Where("portfolio_id = ? AND symbol = ?", transaction.PortfolioID, transaction.Symbol).
+		Order("quantity DESC").First(&position).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	hasPosition := err == nil
+
+	if transaction.TransactionType == "BUY" {
+		lot := &models.PositionLot{
+			PortfolioID:       transaction.PortfolioID,
+			Symbol:            transaction.Symbol,
+			TransactionID:     transaction.ID,
+			FillID:            fill.ID,
+			Currency:          transaction.Currency,
+			Quantity:          fill.Quantity,
+			RemainingQuantity: fill.Quantity,
+			CostPrice:         fill.Price,
+			OpenedAt:          fill.ExecutedAt,
+		}
+		if hasPosition {
+			lot.PositionID = &position.ID
+		}
+		return tx.Create(lot).Error
+	}
+
+	method := portfolio.CostBasisMethod
+	if !ValidCostBasisMethod(method) {
+		method = models.CostBasisFIFO
+	}
+	rate, err := fxRate(transaction.Currency, portfolio.Currency)
+	if err != nil {
+		return err
+	}
+
+	var lots []models.PositionLot
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("portfolio_id = ? AND symbol = ? AND remaining_quantity > 0", transaction.PortfolioID, transaction.Symbol).
+		Order(lotOrder[method]).Find(&lots).Error
+	if err != nil {
+		return err
+	}
+
+	realized := decimal.Zero
+	remaining := fill.Quantity
+	closure := func(quantity, costPrice decimal.Decimal) models.LotClosure {
+		pnl := fill.Price.Sub(costPrice).Mul(quantity).Mul(rate).Round(2)
+		realized = realized.Add(pnl)
+		return models.LotClosure{
+			PortfolioID:   transaction.PortfolioID,
+			Symbol:        transaction.Symbol,
+			TransactionID: transaction.ID,
+			FillID:        fill.ID,
+			Method:        method,
+			Quantity:      quantity,
+			CostPrice:     costPrice,
+			SalePrice:     fill.Price,
+			FXRate:        rate,
+			RealizedPnL:   pnl,
+			ClosedAt:      fill.ExecutedAt,
+		}
+	}
+
+	for i := range lots {
+		if !remaining.IsPositive() {
+			break
+		}
+		lot := &lots[i]
+		quantity := decimal.Min(remaining, lot.RemainingQuantity)
+		remaining = remaining.Sub(quantity)
+
+		closed := closure(quantity, lot.CostPrice)
+		closed.LotID = &lot.ID
+		closed.OpenedAt = &lot.OpenedAt
+		closed.HoldingTerm = models.HoldingTermOf(lot.OpenedAt, fill.ExecutedAt)
+		if err := tx.Create(&closed).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"remaining_quantity": lot.RemainingQuantity.Sub(quantity)}
+		if quantity.Equal(lot.RemainingQuantity) {
+			updates["closed_at"] = fill.ExecutedAt
+		}
+		if err := tx.Model(lot).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	// Holdings bought before lots were kept, or never bought through an order, have no lots; the
+	// rest of the sale is costed at the position's average price
+	if remaining.IsPositive() {
+		costPrice := fill.Price
+		if hasPosition {
+			costPrice = position.AveragePrice
+		}
+		unmatched := closure(remaining, costPrice)
+		if err := tx.Create(&unmatched).Error; err != nil {
+			return err
+		}
+		s.logger.Warn("Sale not covered by open lots", "transaction_id", transaction.ID,
+			"symbol", transaction.Symbol, "quantity", remaining.String())
+	}
+
+	total := realized
+	if transaction.RealizedPnL != nil {
+		total = total.Add(*transaction.RealizedPnL)
+	}
+	transaction.RealizedPnL = &total
+	return tx.Model(transaction).UpdateColumn("realized_pnl", total).Error
+}
+
+// Rebook books each fill of a transaction again, for a transaction restored after deletion
+func (s *LotService) Rebook(tx *gorm.DB, transaction *models.Transaction) error {
+	if !isOrder(transaction) {
+		return nil
+	}
+	var fills []models.Fill
+	if err := tx.Where("transaction_id = ?", transaction.ID).Order("executed_at ASC, created_at ASC").Find(&fills).Error; err != nil {
+		return err
+	}
+	for i := range fills {
+		if err := s.Book(tx, transaction, &fills[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unbook undoes a transaction's lots for a transaction being deleted or superseded by an amendment:
+// a sale's closed quantity goes back to the lots it closed, and a purchase's lots are removed. A
+// purchase whose lots have been partly sold cannot be unbooked until those sales are cancelled.
+func (s *LotService) Unbook(tx *gorm.DB, transaction *models.Transaction) error {
+	if !isOrder(transaction) {
+		return nil
+	}
+
+	if transaction.TransactionType == "BUY" {
+		var sold int64
+		err := tx.Model(&models.PositionLot{}).
+			Where("transaction_id = ? AND remaining_quantity < quantity", transaction.ID).Count(&sold).Error
+		if err != nil {
+			return err
+		}
+		if sold > 0 {
+			return apperror.Conflict("Lots opened by this purchase have been sold; cancel those sales first")
+		}
+		return tx.Where("transaction_id = ?", transaction.ID).Delete(&models.PositionLot{}).Error
+	}
+
+	var closures []models.LotClosure
+	if err := tx.Where("transaction_id = ?", transaction.ID).Find(&closures).Error; err != nil {
+		return err
+	}
+	for _, closed := range closures {
+		if closed.LotID == nil {
+			continue
+		}
+		err := tx.Model(&models.PositionLot{}).Where("id = ?", *closed.LotID).Updates(map[string]interface{}{
+			"remaining_quantity": gorm.Expr("remaining_quantity + ?", closed.Quantity),
+			"closed_at":          nil,
+			"updated_at":         time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("transaction_id = ?", transaction.ID).Delete(&models.LotClosure{}).Error; err != nil {
+		return err
+	}
+	transaction.RealizedPnL = nil
+	return tx.Model(transaction).UpdateColumn("realized_pnl", nil).Error
+}
+
+// ListLots returns a page of the lots matching query
+func (s *LotService) ListLots(query *gorm.DB, spec pagination.Spec, params pagination.Params) ([]models.PositionLot, int64, error) {
+	lots := []models.PositionLot{}
+	total, err := pagination.Find(query, spec, params, &lots)
+	return lots, total, err
+}
+
+// ListClosures returns a page of the lot closures matching query
+func (s *LotService) ListClosures(query *gorm.DB, spec pagination.Spec, params pagination.Params) ([]models.LotClosure, int64, error) {
+	closures := []models.LotClosure{}
+	total, err := pagination.Find(query, spec, params, &closures)
+	return closures, total, err
+}
+
+// TransactionLots returns the lots a purchase opened and the lot closures of a sale
+func (s *LotService) TransactionLots(transactionID uuid.UUID) ([]models.PositionLot, []models.LotClosure, error) {
+	lots := []models.PositionLot{}
+	if err := s.db.Where("transaction_id = ?", transactionID).Order("opened_at ASC").Find(&lots).Error; err != nil {
+		return nil, nil, err
+	}
+	closures := []models.LotClosure{}
+	if err := s.db.Where("transaction_id = ?", transactionID).Order("closed_at ASC, opened_at ASC").Find(&closures).Error; err != nil {
+		return nil, nil, err
+	}
+	return lots, closures, nil
+}
+
+// ClosuresBetween returns the lot closures of a portfolio in a window, oldest first
+func (s *LotService) ClosuresBetween(portfolioID uuid.UUID, from, to time.Time) ([]models.LotClosure, error) {
+	closures := []models.LotClosure{}
+	err := s.db.Where("portfolio_id = ? AND closed_at BETWEEN ? AND ?", portfolioID, from, to).
+		Order("closed_at ASC").Find(&closures).Error
+	return closures, err
+}
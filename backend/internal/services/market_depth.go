@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+// maxMarketDepthAge is how old a symbol's latest order book snapshot may be for a liquidity
+// calculation to use it; older books no longer describe the market
+const maxMarketDepthAge = 15 * time.Minute
+
+// marketDepthDetail is the risk metric detail that maps each symbol to the order book snapshot
+// its liquidity was calculated with
+const marketDepthDetail = "market_depth_snapshots"
+
+// MarketDepthService stores the order book snapshots captured from the market data feed and
+// returns the depth a liquidity calculation used
+type MarketDepthService struct {
+	db *gorm.DB
+}
+
+func NewMarketDepthService() *MarketDepthService {
+	return &MarketDepthService{db: database.GetDB()}
+}
+
+// MetricMarketDepth is the order book depth a stored risk metric was calculated with
+type MetricMarketDepth struct {
+	MetricID     uuid.UUID                    `json:"metric_id"`
+	PortfolioID  uuid.UUID                    `json:"portfolio_id"`
+	MetricType   string                       `json:"metric_type"`
+	CalculatedAt time.Time                    `json:"calculated_at"`
+	Snapshots    []models.MarketDepthSnapshot `json:"snapshots"`
+	Purged       []string                     `json:"purged"` // Symbols whose snapshot has since been deleted under its retention policy
+}
+
+// RecordSnapshots stores captured order books. Levels without a positive price and quantity are
+// dropped, each side is sorted best price first and cut to levels, and books left empty are
+// skipped. It returns how many snapshots were stored.
+func (s *MarketDepthService) RecordSnapshots(ctx context.Context, snapshots []models.MarketDepthSnapshot, levels int) (int, error) {
+	valid := make([]models.MarketDepthSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		snapshot.Symbol = strings.ToUpper(strings.TrimSpace(snapshot.Symbol))
+		snapshot.Bids = depthLevels(snapshot.Bids, levels, func(a, b float64) bool { return a > b })
+		snapshot.Asks = depthLevels(snapshot.Asks, levels, func(a, b float64) bool { return a < b })
+		if snapshot.Symbol == "" || (len(snapshot.Bids) == 0 && len(snapshot.Asks) == 0) {
+			continue
+		}
+		if snapshot.CapturedAt.IsZero() {
+			snapshot.CapturedAt = time.Now()
+		}
+		summarizeDepth(&snapshot)
+		valid = append(valid, snapshot)
+	}
+	if len(valid) == 0 {
+		return 0, nil
+	}
+
+	if err := s.db.WithContext(ctx).CreateInBatches(&valid, 100).Error; err != nil {
+		return 0, err
+	}
+	return len(valid), nil
+}
+
+// depthLevels keeps the levels with a positive price and quantity, best first by better, up to
+// limit of them when limit is positive
+func depthLevels(levels []models.DepthLevel, limit int, better func(a, b float64) bool) []models.DepthLevel {
+	kept := make([]models.DepthLevel, 0, len(levels))
+	for _, level := range levels {
+		if level.Price > 0 && level.Quantity > 0 {
+			kept = append(kept, level)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return better(kept[i].Price, kept[j].Price) })
+	if limit > 0 && len(kept) > limit {
+		kept = kept[:limit]
+	}
+	return kept
+}
+
+// summarizeDepth sets the top of book, spread and value on each side of a snapshot whose levels
+// are sorted
+func summarizeDepth(snapshot *models.MarketDepthSnapshot) {
+	var bidDepth, askDepth float64
+	for _, level := range snapshot.Bids {
+		bidDepth += level.Price * level.Quantity
+	}
+	for _, level := range snapshot.Asks {
+		askDepth += level.Price * level.Quantity
+	}
+	snapshot.BidDepth = decimal.NewFromFloat(bidDepth).Round(4)
+	snapshot.AskDepth = decimal.NewFromFloat(askDepth).Round(4)
+
+	if len(snapshot.Bids) > 0 {
+		snapshot.BestBid = decimal.NewFromFloat(snapshot.Bids[0].Price)
+	}
+	if len(snapshot.Asks) > 0 {
+		snapshot.BestAsk = decimal.NewFromFloat(snapshot.Asks[0].Price)
+	}
+	if len(snapshot.Bids) > 0 && len(snapshot.Asks) > 0 {
+		bid, ask := snapshot.Bids[0].Price, snapshot.Asks[0].Price
+		mid := (bid + ask) / 2
+		snapshot.MidPrice = decimal.NewFromFloat(mid).Round(8)
+		snapshot.Spread = decimal.NewFromFloat((ask - bid) / mid).Round(6)
+	}
+}
+
+// ListSnapshots returns a page of a symbol's order book snapshots
+func (s *MarketDepthService) ListSnapshots(symbol string, spec pagination.Spec, params pagination.Params) ([]models.MarketDepthSnapshot, int64, error) {
+	query := s.db.Model(&models.MarketDepthSnapshot{}).Where("symbol = ?", strings.ToUpper(symbol))
+
+	var snapshots []models.MarketDepthSnapshot
+	total, err := pagination.Find(query, spec, params, &snapshots)
+	return snapshots, total, err
+}
+
+// LatestSnapshot returns the most recent order book snapshot of a symbol
+func (s *MarketDepthService) LatestSnapshot(symbol string) (*models.MarketDepthSnapshot, error) {
+	var snapshot models.MarketDepthSnapshot
+	err := s.db.Where("symbol = ?", strings.ToUpper(symbol)).Order("captured_at DESC").First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("No market depth captured for the symbol")
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// MetricSnapshots returns the order book snapshots a portfolio's stored VaR or liquidity metric was
+// calculated with. A metric calculated without any depth has no snapshots.
+func (s *MarketDepthService) MetricSnapshots(portfolioID, metricID uuid.UUID) (*MetricMarketDepth, error) {
+	var metric models.RiskMetric
+	if err := s.db.Where("id = ? AND portfolio_id = ?", metricID, portfolioID).First(&metric).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Risk metric not found")
+		}
+		return nil, err
+	}
+
+	used := map[string]uuid.UUID{}
+	if recorded, ok := metric.Details[marketDepthDetail].(map[string]interface{}); ok {
+		for symbol, raw := range recorded {
+			if str, ok := raw.(string); ok {
+				if id, err := uuid.Parse(str); err == nil {
+					used[symbol] = id
+				}
+			}
+		}
+	}
+
+	result := &MetricMarketDepth{
+		MetricID:     metric.ID,
+		PortfolioID:  metric.PortfolioID,
+		MetricType:   metric.MetricType,
+		CalculatedAt: metric.CalculatedAt,
+		Snapshots:    []models.MarketDepthSnapshot{},
+		Purged:       []string{},
+	}
+	if len(used) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(used))
+	for _, id := range used {
+		ids = append(ids, id)
+	}
+	if err := s.db.Where("id IN ?", ids).Order("symbol").Find(&result.Snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uuid.UUID]bool, len(result.Snapshots))
+	for _, snapshot := range result.Snapshots {
+		found[snapshot.ID] = true
+	}
+	for symbol, id := range used {
+		if !found[id] {
+			result.Purged = append(result.Purged, symbol)
+		}
+	}
+	sort.Strings(result.Purged)
+	return result, nil
+}
+
+// latestMarketDepth loads the newest snapshot of each symbol captured within maxMarketDepthAge
+func latestMarketDepth(db *gorm.DB, symbols []string) (map[string]models.MarketDepthSnapshot, error) {
+	latest := make(map[string]models.MarketDepthSnapshot, len(symbols))
+	if len(symbols) == 0 {
+		return latest, nil
+	}
+
+	var snapshots []models.MarketDepthSnapshot
+	err := db.Raw(`SELECT DISTINCT ON (symbol) * FROM market_depth_snapshots
+		WHERE symbol IN ? AND captured_at > ? ORDER BY symbol, captured_at DESC`,
+		symbols, time.Now().Add(-maxMarketDepthAge)).Scan(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		latest[snapshot.Symbol] = snapshot
+	}
+	return latest, nil
+}
+
+// depthMarketData is a MarketDataProvider that adds the latest captured order books to the
+// estimated market data, and remembers which snapshots it served
+type depthMarketData struct {
+	calculator.MarketDataProvider
+	snapshots map[string]models.MarketDepthSnapshot
+}
+
+// newDepthMarketData adds the recent order books of the held symbols to estimates. Without stored
+// depth, or when it cannot be loaded, the calculation goes on without order book data.
+func newDepthMarketData(db *gorm.DB, positions []models.Position, estimates calculator.MarketDataProvider) *depthMarketData {
+	symbols := make([]string, 0, len(positions))
+	for _, position := range positions {
+		symbols = append(symbols, position.Symbol)
+	}
+	snapshots, err := latestMarketDepth(db, symbols)
+	if err != nil {
+		logging.Component("market_depth").Warn("Failed to load market depth for liquidity", "error", err)
+		snapshots = map[string]models.MarketDepthSnapshot{}
+	}
+	return &depthMarketData{MarketDataProvider: estimates, snapshots: snapshots}
+}
+
+func (m *depthMarketData) GetMarketDepth(symbol string) *calculator.MarketDepth {
+	snapshot, ok := m.snapshots[symbol]
+	if !ok {
+		return m.MarketDataProvider.GetMarketDepth(symbol)
+	}
+	return &calculator.MarketDepth{
+		BidLevels: priceLevels(snapshot.Bids),
+		AskLevels: priceLevels(snapshot.Asks),
+		Timestamp: snapshot.CapturedAt,
+	}
+}
+
+// used maps each symbol with a recent order book to the ID of its snapshot, as recorded in the
+// details of a risk metric
+func (m *depthMarketData) used() map[string]string {
+	used := make(map[string]string, len(m.snapshots))
+	for symbol, snapshot := range m.snapshots {
+		used[symbol] = snapshot.ID.String()
+	}
+	return used
+}
+
+func priceLevels(levels []models.DepthLevel) []calculator.PriceLevel {
+	converted := make([]calculator.PriceLevel, len(levels))
+	for i, level := range levels {
+		converted[i] = calculator.PriceLevel{Price: level.Price, Quantity: level.Quantity, Orders: level.Orders}
+	}
+	return converted
+}
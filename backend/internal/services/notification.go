@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
+)
+
+type NotificationService struct {
+	db *gorm.DB
+}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{
+		db: database.GetDB(),
+	}
+}
+
+// ListChannels returns all notification channels
+func (s *NotificationService) ListChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := s.db.Order("created_at ASC").Find(&channels).Error
+	return channels, err
+}
+
+// GetChannel returns a notification channel by ID
+func (s *NotificationService) GetChannel(channelID uuid.UUID) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := s.db.First(&channel, channelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("notification channel not found")
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// CreateChannel validates and stores a new notification channel
+func (s *NotificationService) CreateChannel(channel *models.NotificationChannel) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+	return s.db.Create(channel).Error
+}
+
+// UpdateChannel validates and saves changes to a notification channel
+func (s *NotificationService) UpdateChannel(channel *models.NotificationChannel) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+	return s.db.Save(channel).Error
+}
+
+// DeleteChannel removes a notification channel
+func (s *NotificationService) DeleteChannel(channelID uuid.UUID) error {
+	result := s.db.Delete(&models.NotificationChannel{}, channelID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("notification channel not found")
+	}
+	return nil
+}
+
+// ListDeliveries returns the delivery log, optionally filtered by alert, status and event. FAILED
+// deliveries are the dead letters that can be redelivered.
+func (s *NotificationService) ListDeliveries(alertID *uuid.UUID, status, event string, limit int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	query := s.db.Preload("Channel")
+
+	if alertID != nil {
+		query = query.Where("alert_id = ?", *alertID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", strings.ToUpper(status))
+	}
+	if event != "" {
+		query = query.Where("event = ?", strings.ToLower(event))
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func validateChannel(channel *models.NotificationChannel) error {
+	channel.Type = strings.ToUpper(channel.Type)
+	channel.Severities = strings.ToUpper(strings.ReplaceAll(channel.Severities, " ", ""))
+	channel.Events = strings.ToLower(strings.ReplaceAll(channel.Events, " ", ""))
+	if channel.Events == "" {
+		channel.Events = notifications.EventAlertCreated
+	}
+
+	if strings.TrimSpace(channel.Name) == "" {
+		return errors.New("channel name is required")
+	}
+	if !notifications.ValidChannelType(channel.Type) {
+		return errors.New("channel type must be EMAIL, SLACK or WEBHOOK")
+	}
+	if strings.TrimSpace(channel.Target) == "" {
+		return errors.New("channel target is required")
+	}
+	if channel.Type != notifications.ChannelEmail &&
+		!strings.HasPrefix(channel.Target, "https://") && !strings.HasPrefix(channel.Target, "http://") {
+		return errors.New("webhook target must be an http(s) URL")
+	}
+	if channel.Severities == "" {
+		return errors.New("at least one severity is required")
+	}
+	for _, severity := range strings.Split(channel.Severities, ",") {
+		switch severity {
+		case "LOW", "MEDIUM", "HIGH", "CRITICAL":
+		default:
+			return errors.New("unsupported severity: " + severity)
+		}
+	}
+	for _, event := range strings.Split(channel.Events, ",") {
+		if !notifications.ValidEvent(event) {
+			return errors.New("unsupported event: " + event)
+		}
+	}
+	if secret, ok := channel.Config["secret"]; ok {
+		if _, isString := secret.(string); !isString {
+			return errors.New("webhook secret must be a string")
+		}
+	}
+
+	return nil
+}
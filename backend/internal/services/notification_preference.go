@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/notifications"
+)
+
+// digestWindow is the longest period a digest reports on, so that turning the digest back on does
+// not send everything since the last one
+const digestWindow = 24 * time.Hour
+
+// digestListLimit is how many alerts and risk changes a digest email lists before summarizing the
+// rest
+const digestListLimit = 50
+
+// defaultPreferenceChannels emails high and critical alerts straight away and leaves the rest to the
+// digest
+var defaultPreferenceChannels = map[string][]string{
+	"CRITICAL": {models.PreferenceChannelEmail},
+	"HIGH":     {models.PreferenceChannelEmail},
+	"MEDIUM":   {models.PreferenceChannelDigest},
+	"LOW":      {models.PreferenceChannelDigest},
+}
+
+// NotificationPreferenceService keeps what each user is told about alerts on the portfolios they can
+// see, emails alerts to the users asking for them and sends the daily digests
+type NotificationPreferenceService struct {
+	db     *gorm.DB
+	access *AccessService
+	logger *slog.Logger
+}
+
+func NewNotificationPreferenceService() *NotificationPreferenceService {
+	return &NotificationPreferenceService{
+		db:     database.GetDB(),
+		access: NewAccessService(),
+		logger: logging.Component("notification_digest"),
+	}
+}
+
+// NotificationPreferenceRequest replaces a user's notification preferences. Without channels, high
+// and critical alerts are emailed and the rest left to the digest; the digest defaults to on.
+type NotificationPreferenceRequest struct {
+	Channels        map[string][]string `json:"channels"`
+	QuietHoursStart *string             `json:"quiet_hours_start"`
+	QuietHoursEnd   *string             `json:"quiet_hours_end"`
+	Timezone        string              `json:"timezone"`
+	DailyDigest     *bool               `json:"daily_digest"`
+}
+
+// NotificationDigest is what a user's digest reports on: the alerts raised in the period that were
+// not emailed one by one, the risk metrics whose status changed and the risk limits changed
+type NotificationDigest struct {
+	Since            time.Time                  `json:"since"`
+	Until            time.Time                  `json:"until"`
+	Alerts           []models.Alert             `json:"alerts"`
+	RiskChanges      []RiskStatusChange         `json:"risk_changes"`
+	ThresholdChanges []models.PortfolioActivity `json:"threshold_changes"`
+}
+
+// RiskStatusChange is a risk metric of a portfolio whose latest status differs from the one it had
+// at the start of a digest's period
+type RiskStatusChange struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	PortfolioName string          `json:"portfolio_name"`
+	MetricType    string          `json:"metric_type"`
+	FromStatus    *string         `json:"from_status"` // Nil when first calculated in the period
+	ToStatus      string          `json:"to_status"`
+	Value         decimal.Decimal `json:"value"`
+	CalculatedAt  time.Time       `json:"calculated_at"`
+}
+
+// IsEmpty reports whether nothing happened in the digest's period
+func (d *NotificationDigest) IsEmpty() bool {
+	return len(d.Alerts) == 0 && len(d.RiskChanges) == 0 && len(d.ThresholdChanges) == 0
+}
+
+// Get returns a user's notification preferences
+func (s *NotificationPreferenceService) Get(userID uuid.UUID) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	if err := s.db.First(&preference, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Notification preferences not set")
+		}
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Update validates and stores a user's notification preferences, keeping when their last digest
+// was sent
+func (s *NotificationPreferenceService) Update(userID uuid.UUID, req NotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	preference := &models.NotificationPreference{
+		UserID:          userID,
+		Channels:        req.Channels,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		Timezone:        strings.TrimSpace(req.Timezone),
+		DailyDigest:     req.DailyDigest == nil || *req.DailyDigest,
+	}
+	if err := validatePreference(preference); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channels", "quiet_hours_start", "quiet_hours_end", "timezone", "daily_digest", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(userID)
+}
+
+// Delete removes a user's notification preferences, so they are no longer emailed alerts or digests
+func (s *NotificationPreferenceService) Delete(userID uuid.UUID) error {
+	result := s.db.Delete(&models.NotificationPreference{}, "user_id = ?", userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("Notification preferences not set")
+	}
+	return nil
+}
+
+// validatePreference normalizes severities and channels, fills in the defaults and rejects quiet
+// hours and time zones that cannot be read
+func validatePreference(preference *models.NotificationPreference) error {
+	if preference.Channels == nil {
+		preference.Channels = defaultPreferenceChannels
+	}
+	channels := make(map[string][]string, len(preference.Channels))
+	for severity, chosen := range preference.Channels {
+		severity = strings.ToUpper(strings.TrimSpace(severity))
+		switch severity {
+		case "LOW", "MEDIUM", "HIGH", "CRITICAL":
+		default:
+			return apperror.BadRequest("unsupported severity: " + severity)
+		}
+		normalized := []string{}
+		for _, channel := range chosen {
+			channel = strings.ToUpper(strings.TrimSpace(channel))
+			if channel != models.PreferenceChannelEmail && channel != models.PreferenceChannelDigest {
+				return apperror.BadRequest("channel must be EMAIL or DIGEST: " + channel)
+			}
+			if !slices.Contains(normalized, channel) {
+				normalized = append(normalized, channel)
+			}
+		}
+		channels[severity] = normalized
+	}
+	preference.Channels = channels
+
+	if preference.Timezone == "" {
+		preference.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(preference.Timezone); err != nil {
+		return apperror.BadRequest("unknown timezone: " + preference.Timezone)
+	}
+
+	start, end := preference.QuietHoursStart, preference.QuietHoursEnd
+	if (start == nil) != (end == nil) {
+		return apperror.BadRequest("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if start != nil {
+		from, err := time.Parse("15:04", *start)
+		if err != nil {
+			return apperror.BadRequest("quiet_hours_start must be HH:MM")
+		}
+		to, err := time.Parse("15:04", *end)
+		if err != nil {
+			return apperror.BadRequest("quiet_hours_end must be HH:MM")
+		}
+		if from.Equal(to) {
+			return apperror.BadRequest("quiet hours must not start and end at the same time")
+		}
+	}
+	return nil
+}
+
+// wants reports whether a preference sends the alerts of a severity through a channel
+func wants(preference *models.NotificationPreference, severity, channel string) bool {
+	return slices.Contains(preference.Channels[severity], channel)
+}
+
+// inQuietHours reports whether a point in time falls in a user's quiet hours, which may run past
+// midnight
+func inQuietHours(preference *models.NotificationPreference, at time.Time) bool {
+	if preference.QuietHoursStart == nil || preference.QuietHoursEnd == nil {
+		return false
+	}
+	location, err := time.LoadLocation(preference.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	from, errFrom := time.Parse("15:04", *preference.QuietHoursStart)
+	to, errTo := time.Parse("15:04", *preference.QuietHoursEnd)
+	if errFrom != nil || errTo != nil {
+		return false
+	}
+
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	start, end := from.Hour()*60+from.Minute(), to.Hour()*60+to.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// emailedAtOnce reports whether an alert was emailed to a user when raised: its severity is emailed
+// and it was critical or raised outside their quiet hours
+func emailedAtOnce(preference *models.NotificationPreference, alert *models.Alert) bool {
+	return wants(preference, alert.Severity, models.PreferenceChannelEmail) &&
+		(alert.Severity == "CRITICAL" || !inQuietHours(preference, alert.CreatedAt))
+}
+
+// activeUsersWithPreferences returns the active users with notification preferences matching a
+// condition, keyed by ID
+func (s *NotificationPreferenceService) activeUsersWithPreferences(query string, args ...interface{}) ([]models.NotificationPreference, map[uuid.UUID]models.User, error) {
+	var preferences []models.NotificationPreference
+	err := s.db.Where(query, args...).
+		Where("user_id IN (SELECT id FROM users WHERE is_active = ? AND deleted_at IS NULL)", true).
+		Find(&preferences).Error
+	if err != nil || len(preferences) == 0 {
+		return nil, nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(preferences))
+	for _, preference := range preferences {
+		ids = append(ids, preference.UserID)
+	}
+	var users []models.User
+	if err := s.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uuid.UUID]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	return preferences, byID, nil
+}
+
+// AlertRecipients returns the addresses of the active users who can see an alert's portfolio and
+// have its severity emailed. Outside critical alerts, users in their quiet hours are left to the
+// digest.
+func (s *NotificationPreferenceService) AlertRecipients(alert *models.Alert) ([]string, error) {
+	preferences, users, err := s.activeUsersWithPreferences("channels -> CAST(? AS text) @> CAST(? AS jsonb)",
+		alert.Severity, fmt.Sprintf("[%q]", models.PreferenceChannelEmail))
+	if err != nil {
+		return nil, err
+	}
+
+	var emails []string
+	for i := range preferences {
+		preference := &preferences[i]
+		user, ok := users[preference.UserID]
+		if !ok || !emailedAtOnce(preference, alert) {
+			continue
+		}
+		visible, err := s.access.CanAccessPortfolio(user.ID, user.Role, alert.PortfolioID)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			emails = append(emails, user.Email)
+		}
+	}
+	return emails, nil
+}
+
+// Digest gathers what a user's next digest would report on, up to now
+func (s *NotificationPreferenceService) Digest(userID uuid.UUID) (*NotificationDigest, error) {
+	preference, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return s.digest(preference, &user, time.Now())
+}
+
+// digest gathers the alerts, risk status changes and limit changes on the portfolios a user can
+// see since their last digest, or for the last day
+func (s *NotificationPreferenceService) digest(preference *models.NotificationPreference, user *models.User, until time.Time) (*NotificationDigest, error) {
+	since := until.Add(-digestWindow)
+	if preference.LastDigestAt != nil && preference.LastDigestAt.After(since) {
+		since = *preference.LastDigestAt
+	}
+	digest := &NotificationDigest{
+		Since:            since,
+		Until:            until,
+		Alerts:           []models.Alert{},
+		RiskChanges:      []RiskStatusChange{},
+		ThresholdChanges: []models.PortfolioActivity{},
+	}
+
+	var alerts []models.Alert
+	query := s.db.Model(&models.Alert{}).Where("created_at >= ? AND created_at < ?", since, until)
+	err := s.access.ScopeQuery(query, "portfolio_id", user.ID, user.Role).Order("created_at").Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, alert := range alerts {
+		if emailedAtOnce(preference, &alert) {
+			continue
+		}
+		if wants(preference, alert.Severity, models.PreferenceChannelDigest) ||
+			wants(preference, alert.Severity, models.PreferenceChannelEmail) {
+			digest.Alerts = append(digest.Alerts, alert)
+		}
+	}
+
+	portfolios := s.access.ScopeQuery(s.db.Model(&models.Portfolio{}).Select("id"), "id", user.ID, user.Role)
+	err = s.db.Raw(`SELECT latest.portfolio_id, portfolios.name AS portfolio_name, latest.metric_type,
+			earlier.status AS from_status, latest.status AS to_status, latest.value, latest.calculated_at
+		FROM (
+			SELECT DISTINCT ON (portfolio_id, metric_type) portfolio_id, metric_type, status, value, calculated_at
+			FROM risk_metrics WHERE portfolio_id IN (?) AND calculated_at >= ? AND calculated_at < ?
+			ORDER BY portfolio_id, metric_type, calculated_at DESC
+		) AS latest
+		LEFT JOIN (
+			SELECT DISTINCT ON (portfolio_id, metric_type) portfolio_id, metric_type, status
+			FROM risk_metrics WHERE portfolio_id IN (?) AND calculated_at < ?
+			ORDER BY portfolio_id, metric_type, calculated_at DESC
+		) AS earlier ON earlier.portfolio_id = latest.portfolio_id AND earlier.metric_type = latest.metric_type
+		JOIN portfolios ON portfolios.id = latest.portfolio_id
+		WHERE earlier.status IS DISTINCT FROM latest.status
+		ORDER BY portfolios.name, latest.metric_type`,
+		portfolios, since, until, portfolios, since).Scan(&digest.RiskChanges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Where("portfolio_id IN (?) AND activity_type = ? AND occurred_at >= ? AND occurred_at < ?",
+		portfolios, models.ActivityThresholdChange, since, until).
+		Order("occurred_at").Find(&digest.ThresholdChanges).Error
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// StartDigestJob emails the daily digests once a day at the given UTC hour until ctx is cancelled
+func (s *NotificationPreferenceService) StartDigestJob(ctx context.Context, hour int) {
+	for {
+		if !sleepUntil(ctx, nextDailyRun(time.Now(), hour)) {
+			return
+		}
+
+		runCtx := logging.WithNewRequestID(ctx)
+		sent, err := s.SendDigests(runCtx)
+		if err != nil {
+			s.logger.ErrorContext(runCtx, "Notification digests failed", "error", err)
+			continue
+		}
+		s.logger.InfoContext(runCtx, "Notification digests sent", "sent", sent)
+	}
+}
+
+// SendDigests emails each active user with the digest on a summary of what happened since their
+// last one, skipping users with nothing to report, and returns the number sent. Each digest is
+// claimed first so that instances running the job together send it once; a digest that fails to
+// send is released to be covered by the next.
+func (s *NotificationPreferenceService) SendDigests(ctx context.Context) (int, error) {
+	notifier := notifications.GetNotifier()
+	if notifier == nil {
+		return 0, errors.New("notifier is not running")
+	}
+
+	preferences, users, err := s.activeUsersWithPreferences("daily_digest = ?", true)
+	if err != nil {
+		return 0, err
+	}
+
+	// Postgres keeps microseconds, and the release below matches on the claimed time
+	now := time.Now().Truncate(time.Microsecond)
+	sent := 0
+	for i := range preferences {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		preference := &preferences[i]
+		user, ok := users[preference.UserID]
+		if !ok {
+			continue
+		}
+
+		claim := s.db.WithContext(ctx).Model(&models.NotificationPreference{}).Where("user_id = ?", user.ID)
+		if preference.LastDigestAt != nil {
+			claim = claim.Where("last_digest_at = ?", *preference.LastDigestAt)
+		} else {
+			claim = claim.Where("last_digest_at IS NULL")
+		}
+		result := claim.UpdateColumn("last_digest_at", now)
+		if result.Error != nil {
+			return sent, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		digest, err := s.digest(preference, &user, now)
+		if err == nil && !digest.IsEmpty() {
+			err = notifier.SendEmail([]string{user.Email}, digestSubject(digest), digestText(digest))
+			if err == nil {
+				sent++
+			}
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to send notification digest", "user_id", user.ID, "error", err)
+			s.db.Model(&models.NotificationPreference{}).
+				Where("user_id = ? AND last_digest_at = ?", user.ID, now).
+				UpdateColumn("last_digest_at", preference.LastDigestAt)
+		}
+	}
+	return sent, nil
+}
+
+func digestSubject(digest *NotificationDigest) string {
+	return fmt.Sprintf("Risk monitor digest: %d alerts, %d risk changes",
+		len(digest.Alerts), len(digest.RiskChanges)+len(digest.ThresholdChanges))
+}
+
+// digestText lays a digest out as the plain text body of its email
+func digestText(digest *NotificationDigest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "What happened on your portfolios from %s to %s.\n",
+		digest.Since.UTC().Format(time.RFC1123), digest.Until.UTC().Format(time.RFC1123))
+
+	fmt.Fprintf(&b, "\nAlerts (%d)\n", len(digest.Alerts))
+	for i, alert := range digest.Alerts {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "  ...and %d more\n", len(digest.Alerts)-digestListLimit)
+			break
+		}
+		fmt.Fprintf(&b, "  [%s] %s (%s, portfolio %s)\n", alert.Severity, alert.Title,
+			alert.CreatedAt.UTC().Format("Jan 2 15:04"), alert.PortfolioID)
+	}
+
+	fmt.Fprintf(&b, "\nRisk metric status changes (%d)\n", len(digest.RiskChanges))
+	for i, change := range digest.RiskChanges {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "  ...and %d more\n", len(digest.RiskChanges)-digestListLimit)
+			break
+		}
+		from := "new"
+		if change.FromStatus != nil {
+			from = *change.FromStatus
+		}
+		fmt.Fprintf(&b, "  %s %s: %s to %s (%s)\n", change.PortfolioName, change.MetricType, from, change.ToStatus,
+			change.Value.StringFixed(4))
+	}
+
+	fmt.Fprintf(&b, "\nRisk limit changes (%d)\n", len(digest.ThresholdChanges))
+	for i, change := range digest.ThresholdChanges {
+		if i == digestListLimit {
+			fmt.Fprintf(&b, "  ...and %d more\n", len(digest.ThresholdChanges)-digestListLimit)
+			break
+		}
+		fmt.Fprintf(&b, "  %s (portfolio %s)\n", change.Summary, change.PortfolioID)
+	}
+	return b.String()
+}
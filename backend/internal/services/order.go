@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// InvalidTransitionError is returned when an order is moved to a state its current state cannot reach
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order cannot move from %s to %s", e.From, e.To)
+}
+
+// FillRequest records an execution against an order
+type FillRequest struct {
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	ExecutionID string          `json:"execution_id"`
+	ExecutedAt  *time.Time      `json:"executed_at"` // Defaults to now
+}
+
+// OrderService moves BUY and SELL transactions through the order lifecycle. Fills take an order
+// from NEW through PARTIALLY_FILLED to FILLED; it can be cancelled until it is filled and rejected
+// before any fill. Each change is published on the order updates channel for WebSocket clients.
+type OrderService struct {
+	db          *gorm.DB
+	cashService *CashService
+	lotService  *LotService
+	logger      *slog.Logger
+}
+
+func NewOrderService() *OrderService {
+	return &OrderService{
+		db:          database.GetDB(),
+		cashService: NewCashService(),
+		lotService:  NewLotService(),
+		logger:      logging.Component("order"),
+	}
+}
+
+// isOrder reports whether a transaction goes through the order lifecycle
+func isOrder(transaction *models.Transaction) bool {
+	return transaction.IsTrade()
+}
+
+// ListFills returns the fills of an order, oldest first
+func (s *OrderService) ListFills(transactionID uuid.UUID) ([]models.Fill, error) {
+	var fills []models.Fill
+	err := s.db.Where("transaction_id = ?", transactionID).Order("executed_at ASC, created_at ASC").Find(&fills).Error
+	return fills, err
+}
+
+// RecordFill adds an execution to an order, updating its filled quantity and average fill price.
+// The order becomes FILLED, and settles against the portfolio's cash, once the whole quantity has
+// been filled.
+func (s *OrderService) RecordFill(ctx context.Context, transaction *models.Transaction, req FillRequest, userID uuid.UUID) (*models.Fill, error) {
+	if !isOrder(transaction) {
+		return nil, errors.New("only BUY and SELL orders can be filled")
+	}
+	if !req.Quantity.IsPositive() {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if !req.Price.IsPositive() {
+		return nil, errors.New("price must be greater than zero")
+	}
+	if len(req.ExecutionID) > 100 {
+		return nil, errors.New("execution_id must be at most 100 characters")
+	}
+	executedAt := time.Now()
+	if req.ExecutedAt != nil {
+		if req.ExecutedAt.After(executedAt) {
+			return nil, errors.New("executed_at is in the future")
+		}
+		executedAt = *req.ExecutedAt
+	}
+
+	var from string
+	fill := &models.Fill{
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Amount:      req.Quantity.Mul(req.Price).Round(2),
+		ExecutionID: req.ExecutionID,
+		ExecutedAt:  executedAt,
+		CreatedBy:   &userID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, transaction); err != nil {
+			return err
+		}
+		if err := requireNotHeld(transaction); err != nil {
+			return err
+		}
+		from = transaction.OrderStatus
+
+		filled := transaction.FilledQuantity.Add(req.Quantity)
+		to := models.OrderStatusPartiallyFilled
+		if filled.Equal(transaction.Quantity) {
+			to = models.OrderStatusFilled
+		}
+		if !models.CanTransitionOrder(from, to) {
+			return &InvalidTransitionError{From: from, To: to}
+		}
+		if filled.GreaterThan(transaction.Quantity) {
+			return fmt.Errorf("fill quantity exceeds the remaining order quantity of %s",
+				transaction.Quantity.Sub(transaction.FilledQuantity).String())
+		}
+
+		fill.TransactionID = transaction.ID
+		if err := tx.Create(fill).Error; err != nil {
+			return err
+		}
+		if err := s.lotService.Book(tx, transaction, fill); err != nil {
+			return err
+		}
+
+		filledValue := transaction.FilledQuantity.Mul(transaction.AverageFillPrice).Add(req.Quantity.Mul(req.Price))
+		transaction.FilledQuantity = filled
+		transaction.AverageFillPrice = filledValue.Div(filled).Round(8)
+		transaction.ExecutedAt = &executedAt
+		return s.apply(tx, transaction, to, "", userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, transaction, from, fill)
+	QueueLargeTrade(ctx, transaction, fill)
+	return fill, nil
+}
+
+// FillRemaining fills whatever is left of an order at its order price, for orders completed
+// without individual executions being reported
+func (s *OrderService) FillRemaining(ctx context.Context, transaction *models.Transaction, userID uuid.UUID) error {
+	remaining := transaction.Quantity.Sub(transaction.FilledQuantity)
+	if !remaining.IsPositive() {
+		return &InvalidTransitionError{From: transaction.OrderStatus, To: models.OrderStatusFilled}
+	}
+	_, err := s.RecordFill(ctx, transaction, FillRequest{Quantity: remaining, Price: transaction.Price}, userID)
+	return err
+}
+
+// Transition cancels or rejects an order. Filling goes through RecordFill. An order cancelled after
+// a partial fill is completed for the quantity filled.
+func (s *OrderService) Transition(ctx context.Context, transaction *models.Transaction, to, reason string, userID uuid.UUID) error {
+	if !isOrder(transaction) {
+		return errors.New("only BUY and SELL orders have an order status")
+	}
+	if to != models.OrderStatusCancelled && to != models.OrderStatusRejected {
+		return errors.New("order_status must be CANCELLED or REJECTED; orders are filled by recording fills")
+	}
+
+	var from string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, transaction); err != nil {
+			return err
+		}
+		if err := requireNotHeld(transaction); err != nil {
+			return err
+		}
+		from = transaction.OrderStatus
+		if !models.CanTransitionOrder(from, to) {
+			return &InvalidTransitionError{From: from, To: to}
+		}
+		return s.apply(tx, transaction, to, reason, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, transaction, from, nil)
+	return nil
+}
+
+// PublishCreated announces a new order
+func (s *OrderService) PublishCreated(ctx context.Context, transaction *models.Transaction) {
+	if isOrder(transaction) {
+		s.publish(ctx, transaction, "", nil)
+	}
+}
+
+// fullFill is the single execution of an order recorded as already filled, such as an imported trade
+func fullFill(transaction *models.Transaction, userID *uuid.UUID) *models.Fill {
+	executedAt := time.Now()
+	if transaction.ExecutedAt != nil {
+		executedAt = *transaction.ExecutedAt
+	}
+	fill := &models.Fill{
+		TransactionID: transaction.ID,
+		Quantity:      transaction.Quantity,
+		Price:         transaction.Price,
+		Amount:        transaction.Amount,
+		ExecutedAt:    executedAt,
+		CreatedBy:     userID,
+	}
+	if transaction.ExternalID != nil {
+		fill.ExecutionID = *transaction.ExternalID
+	}
+	return fill
+}
+
+// lockOrder reloads an order for update so fills and transitions against it apply one at a time
+func lockOrder(tx *gorm.DB, transaction *models.Transaction) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transaction.ID).First(transaction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("transaction not found")
+	}
+	return err
+}
+
+// apply moves a locked order to a new state, brings its transaction status in line and settles
+// its cash once it completes
+func (s *OrderService) apply(tx *gorm.DB, transaction *models.Transaction, to, reason string, userID uuid.UUID) error {
+	transaction.OrderStatus = to
+	transaction.OrderStatusReason = reason
+
+	switch {
+	case to == models.OrderStatusFilled,
+		to == models.OrderStatusCancelled && transaction.FilledQuantity.IsPositive():
+		// Saving sets the amount to what was filled
+		transaction.Status = "COMPLETED"
+	case to == models.OrderStatusCancelled:
+		transaction.Status = "CANCELLED"
+	case to == models.OrderStatusRejected:
+		transaction.Status = "FAILED"
+	default:
+		transaction.Status = "PENDING"
+	}
+
+	if err := tx.Save(transaction).Error; err != nil {
+		return err
+	}
+	return s.cashService.Settle(tx, transaction, &userID)
+}
+
+// publish sends an order state change to WebSocket clients through Redis
+func (s *OrderService) publish(ctx context.Context, transaction *models.Transaction, from string, fill *models.Fill) {
+	s.logger.InfoContext(ctx, "Order status changed", "transaction_id", transaction.ID,
+		"from", from, "to", transaction.OrderStatus)
+
+	redisClient := database.GetRedis()
+	if redisClient == nil {
+		return
+	}
+
+	event := map[string]interface{}{
+		"transaction_id":     transaction.ID,
+		"portfolio_id":       transaction.PortfolioID,
+		"symbol":             transaction.Symbol,
+		"side":               transaction.TransactionType,
+		"previous_status":    from,
+		"order_status":       transaction.OrderStatus,
+		"status":             transaction.Status,
+		"quantity":           transaction.Quantity,
+		"filled_quantity":    transaction.FilledQuantity,
+		"average_fill_price": transaction.AverageFillPrice,
+		"timestamp":          time.Now().Unix(),
+	}
+	if transaction.OrderStatusReason != "" {
+		event["reason"] = transaction.OrderStatusReason
+	}
+	if fill != nil {
+		event["fill"] = fill
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		event["request_id"] = requestID
+	}
+
+	payload, err := json.Marshal(event)
+	if err == nil {
+		err = redisClient.Publish(ctx, database.OrdersChannel, payload).Err()
+	}
+	if err != nil && !errors.Is(err, database.ErrRedisUnavailable) {
+		s.logger.ErrorContext(ctx, "Failed to publish order update", "transaction_id", transaction.ID, "error", err)
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+// maxBenchmarkPrices bounds the prices loaded in one request
+const maxBenchmarkPrices = 5000
+
+var (
+	ErrInvalidPerformanceWindow = errors.New("invalid window, use 1m, 3m, 6m, ytd or 1y")
+	ErrNoBenchmark              = errors.New("portfolio has no benchmark; assign one or pass ?benchmark=")
+	ErrInvalidBenchmark         = errors.New("benchmark must be at most 20 characters")
+)
+
+var performanceWindows = map[string]func(time.Time) time.Time{
+	"1m":  func(t time.Time) time.Time { return t.AddDate(0, -1, 0) },
+	"3m":  func(t time.Time) time.Time { return t.AddDate(0, -3, 0) },
+	"6m":  func(t time.Time) time.Time { return t.AddDate(0, -6, 0) },
+	"ytd": func(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC) },
+	"1y":  func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) },
+}
+
+// BenchmarkPriceInput is one daily close of a benchmark, dated YYYY-MM-DD
+type BenchmarkPriceInput struct {
+	Date  string          `json:"date"`
+	Close decimal.Decimal `json:"close"`
+}
+
+// PerformanceReport compares a portfolio's daily returns, from its P&L history, with its benchmark
+type PerformanceReport struct {
+	PortfolioID uuid.UUID                     `json:"portfolio_id"`
+	Benchmark   string                        `json:"benchmark"`
+	Window      string                        `json:"window"`
+	From        time.Time                     `json:"from"`
+	To          time.Time                     `json:"to"`
+	Result      *calculator.PerformanceResult `json:"result"`
+	Warnings    []string                      `json:"warnings"`
+}
+
+// PerformanceService keeps benchmark prices and measures portfolios against their benchmarks
+type PerformanceService struct {
+	db *gorm.DB
+}
+
+func NewPerformanceService() *PerformanceService {
+	return &PerformanceService{
+		db: database.GetDB(),
+	}
+}
+
+// NormalizeBenchmark upper-cases a benchmark symbol; an empty symbol clears the benchmark
+func NormalizeBenchmark(symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if len(symbol) > 20 {
+		return "", ErrInvalidBenchmark
+	}
+	return symbol, nil
+}
+
+// BenchmarkPrices returns the latest close of every benchmark assigned to a portfolio, zero for
+// benchmarks that have no prices yet
+func (s *PerformanceService) BenchmarkPrices() (map[string]float64, error) {
+	var rows []struct {
+		Symbol string
+		Price  float64
+	}
+	err := s.db.Raw(`SELECT p.benchmark AS symbol, COALESCE(
+			(SELECT b.close FROM benchmark_prices b WHERE b.symbol = p.benchmark ORDER BY b.date DESC LIMIT 1), 0) AS price
+		FROM (SELECT DISTINCT benchmark FROM portfolios WHERE COALESCE(benchmark, '') <> '' AND deleted_at IS NULL) p`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		prices[row.Symbol] = row.Price
+	}
+	return prices, nil
+}
+
+// ApplyPrices records today's close of the benchmarks among a batch of feed prices
+func (s *PerformanceService) ApplyPrices(prices map[string]float64) error {
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	var benchmarks []string
+	if err := s.db.Model(&models.Portfolio{}).Where("benchmark IN ?", symbols).Distinct().Pluck("benchmark", &benchmarks).Error; err != nil {
+		return err
+	}
+	if len(benchmarks) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	rows := make([]models.BenchmarkPrice, 0, len(benchmarks))
+	for _, symbol := range benchmarks {
+		if prices[symbol] <= 0 {
+			continue
+		}
+		rows = append(rows, models.BenchmarkPrice{
+			Symbol: symbol,
+			Date:   today,
+			Close:  decimal.NewFromFloat(prices[symbol]),
+			Source: models.BenchmarkSourceFeed,
+		})
+	}
+	return s.upsertPrices(rows)
+}
+
+// RecordPrices loads historical closes of a benchmark, replacing those already stored for the
+// same dates, and returns them oldest first
+func (s *PerformanceService) RecordPrices(symbol string, inputs []BenchmarkPriceInput) ([]models.BenchmarkPrice, error) {
+	symbol, err := NormalizeBenchmark(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("prices are required")
+	}
+	if len(inputs) > maxBenchmarkPrices {
+		return nil, fmt.Errorf("at most %d prices can be loaded at once", maxBenchmarkPrices)
+	}
+
+	byDate := make(map[time.Time]int, len(inputs))
+	rows := make([]models.BenchmarkPrice, 0, len(inputs))
+	for i, input := range inputs {
+		date, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			return nil, fmt.Errorf("prices[%d]: date must be YYYY-MM-DD", i)
+		}
+		if !input.Close.IsPositive() {
+			return nil, fmt.Errorf("prices[%d]: close must be positive", i)
+		}
+
+		row := models.BenchmarkPrice{Symbol: symbol, Date: date, Close: input.Close, Source: models.BenchmarkSourceManual}
+		// A date listed twice keeps its last close, as a single upsert cannot touch a row twice
+		if idx, ok := byDate[date]; ok {
+			rows[idx] = row
+			continue
+		}
+		byDate[date] = len(rows)
+		rows = append(rows, row)
+	}
+
+	slices.SortFunc(rows, func(a, b models.BenchmarkPrice) int { return a.Date.Compare(b.Date) })
+
+	if err := s.upsertPrices(rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s *PerformanceService) upsertPrices(rows []models.BenchmarkPrice) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"close", "source", "updated_at"}),
+	}).CreateInBatches(&rows, 500).Error
+}
+
+// GetPrices returns the stored closes of a benchmark between two dates, oldest first
+func (s *PerformanceService) GetPrices(symbol string, from, to time.Time) ([]models.BenchmarkPrice, error) {
+	symbol, err := NormalizeBenchmark(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []models.BenchmarkPrice
+	err = s.db.Where("symbol = ? AND date >= ? AND date <= ?", symbol, from, to).Order("date ASC").Find(&prices).Error
+	return prices, err
+}
+
+// GetPerformance compares a portfolio's daily returns over a window of 1m, 3m, 6m, ytd or 1y with
+// its benchmark, or with another benchmark when one is given. A day's return is its P&L over the
+// previous snapshot's market value, so it includes cash flows into and out of positions like the
+// P&L report does. The benchmark return of a day runs between the latest closes on or before the
+// two snapshot dates.
+func (s *PerformanceService) GetPerformance(portfolioID uuid.UUID, window, benchmark string) (*PerformanceReport, error) {
+	start, ok := performanceWindows[window]
+	if !ok {
+		return nil, ErrInvalidPerformanceWindow
+	}
+
+	var portfolio models.Portfolio
+	if err := s.db.Select("id", "benchmark").First(&portfolio, "id = ?", portfolioID).Error; err != nil {
+		return nil, err
+	}
+	benchmark, err := NormalizeBenchmark(benchmark)
+	if err != nil {
+		return nil, err
+	}
+	if benchmark == "" {
+		benchmark = portfolio.Benchmark
+	}
+	if benchmark == "" {
+		return nil, ErrNoBenchmark
+	}
+
+	now := time.Now()
+	report := &PerformanceReport{
+		PortfolioID: portfolioID,
+		Benchmark:   benchmark,
+		Window:      window,
+		From:        start(now),
+		To:          now,
+		Warnings:    []string{},
+	}
+	fromDate := report.From.UTC().Truncate(24 * time.Hour)
+
+	// The first return of the window is measured from the last snapshot taken before it
+	var history []models.PnLHistory
+	if err := s.db.Where("portfolio_id = ? AND date >= ?", portfolioID, fromDate).Order("date ASC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	var opening models.PnLHistory
+	err = s.db.Where("portfolio_id = ? AND date < ?", portfolioID, fromDate).Order("date DESC").First(&opening).Error
+	if err == nil {
+		history = append([]models.PnLHistory{opening}, history...)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var closes []models.BenchmarkPrice
+	if len(history) > 0 {
+		var first models.BenchmarkPrice
+		err := s.db.Where("symbol = ? AND date <= ?", benchmark, history[0].Date).Order("date DESC").First(&first).Error
+		if err == nil {
+			closes = append(closes, first)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		var rest []models.BenchmarkPrice
+		if err := s.db.Where("symbol = ? AND date > ?", benchmark, history[0].Date).Order("date ASC").Find(&rest).Error; err != nil {
+			return nil, err
+		}
+		closes = append(closes, rest...)
+	}
+
+	observations := make([]calculator.PerformanceObservation, 0, len(history))
+	skipped := 0
+	for i := 1; i < len(history); i++ {
+		previous, current := history[i-1], history[i]
+		startClose, hasStart := closeOn(closes, previous.Date)
+		endClose, hasEnd := closeOn(closes, current.Date)
+		if !previous.MarketValue.IsPositive() || !hasStart || !hasEnd {
+			skipped++
+			continue
+		}
+
+		observations = append(observations, calculator.PerformanceObservation{
+			Date:            current.Date,
+			PortfolioReturn: current.DailyPnL.Div(previous.MarketValue).InexactFloat64(),
+			BenchmarkReturn: endClose.Div(startClose).Sub(decimal.NewFromInt(1)).InexactFloat64(),
+		})
+	}
+
+	report.Result = calculator.ComparePerformance(observations)
+	if len(observations) < 2 {
+		report.Warnings = append(report.Warnings, "Fewer than two daily returns in the window; tracking error, beta and the information ratio need more history")
+	}
+	if skipped > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d day(s) skipped because of a missing portfolio value or benchmark price", skipped))
+	}
+	return report, nil
+}
+
+// closeOn returns the latest close on or before a date from closes sorted by date
+func closeOn(closes []models.BenchmarkPrice, date time.Time) (decimal.Decimal, bool) {
+	found := false
+	var latest decimal.Decimal
+	for _, price := range closes {
+		if price.Date.After(date) {
+			break
+		}
+		latest, found = price.Close, true
+	}
+	return latest, found
+}
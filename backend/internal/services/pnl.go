@@ -0,0 +1,403 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+var pnlPeriods = map[string]func(time.Time) time.Time{
+	"1d": func(t time.Time) time.Time { return t.Add(-24 * time.Hour) },
+	"1w": func(t time.Time) time.Time { return t.AddDate(0, 0, -7) },
+	"1m": func(t time.Time) time.Time { return t.AddDate(0, -1, 0) },
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// PositionPnL is the unrealized P&L of one position. Prices are in the position currency; values
+// and P&L are in the portfolio currency.
+type PositionPnL struct {
+	PositionID    uuid.UUID       `json:"position_id"`
+	Symbol        string          `json:"symbol"`
+	Currency      string          `json:"currency"`
+	FXRate        decimal.Decimal `json:"fx_rate"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	AveragePrice  decimal.Decimal `json:"average_price"`
+	CurrentPrice  decimal.Decimal `json:"current_price"`
+	MarketValue   decimal.Decimal `json:"market_value"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	PnLPercent    decimal.Decimal `json:"pnl_percent"`
+}
+
+// PnLReport is a portfolio's P&L now and over a period
+type PnLReport struct {
+	PortfolioID   uuid.UUID           `json:"portfolio_id"`
+	Period        string              `json:"period"`
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	MarketValue   decimal.Decimal     `json:"market_value"`
+	CostBasis     decimal.Decimal     `json:"cost_basis"`
+	UnrealizedPnL decimal.Decimal     `json:"unrealized_pnl"`
+	RealizedPnL   decimal.Decimal     `json:"realized_pnl"`  // Realized during the period
+	RealizedLots  []models.LotClosure `json:"realized_lots"` // Lots closed during the period
+	PeriodPnL     decimal.Decimal     `json:"period_pnl"`    // Market value change over the period plus realized P&L
+	PeriodReturn  decimal.Decimal     `json:"period_return"` // Percent of the starting market value
+	Positions     []PositionPnL       `json:"positions"`
+	History       []models.PnLHistory `json:"history"`
+}
+
+// PnLService revalues positions as prices move and keeps daily P&L snapshots
+type PnLService struct {
+	db     *gorm.DB
+	lots   *LotService
+	logger *slog.Logger
+}
+
+func NewPnLService() *PnLService {
+	return &PnLService{
+		db:     database.GetDB(),
+		lots:   NewLotService(),
+		logger: logging.Component("pnl"),
+	}
+}
+
+// ApplyPrices revalues every position in the given symbols, then refreshes the totals, weights and
+// today's P&L snapshot of each affected portfolio
+func (s *PnLService) ApplyPrices(prices map[string]float64) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(prices))
+	for symbol := range prices {
+		symbols = append(symbols, symbol)
+	}
+
+	var positions []models.Position
+	if err := s.db.Where("symbol IN ?", symbols).Find(&positions).Error; err != nil {
+		return err
+	}
+
+	return s.revalue(positions, func(position *models.Position) decimal.Decimal {
+		return decimal.NewFromFloat(prices[position.Symbol])
+	})
+}
+
+// RevalueFX re-marks positions held in a currency other than their portfolio's at the latest
+// exchange rates, keeping their current prices
+func (s *PnLService) RevalueFX() error {
+	var positions []models.Position
+	err := s.db.Joins("JOIN portfolios ON portfolios.id = positions.portfolio_id").
+		Where("COALESCE(positions.currency, 'USD') <> COALESCE(portfolios.currency, 'USD')").
+		Find(&positions).Error
+	if err != nil {
+		return err
+	}
+
+	return s.revalue(positions, func(position *models.Position) decimal.Decimal {
+		return position.CurrentPrice
+	})
+}
+
+// revalue marks positions to the price returned for each, converting into the portfolio currency,
+// then refreshes every affected portfolio. Positions without a price or exchange rate are skipped.
+// Each portfolio is revalued in its own transaction under its row lock, from its positions as they
+// are once locked, so that a trade or corporate action changing them in the meantime is kept.
+func (s *PnLService) revalue(positions []models.Position, priceOf func(*models.Position) decimal.Decimal) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	baseCurrencies, err := s.portfolioCurrencies(positions)
+	if err != nil {
+		return err
+	}
+
+	byPortfolio := make(map[uuid.UUID][]uuid.UUID)
+	for _, position := range positions {
+		byPortfolio[position.PortfolioID] = append(byPortfolio[position.PortfolioID], position.ID)
+	}
+
+	for _, portfolioID := range sortedPortfolioIDs(byPortfolio) {
+		err := portfolioUpdates.serialize(portfolioID, func() error {
+			return s.revaluePortfolio(portfolioID, byPortfolio[portfolioID], baseCurrencies[portfolioID], priceOf)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revaluePortfolio marks the positions of one portfolio and refreshes its value and P&L snapshot
+func (s *PnLService) revaluePortfolio(portfolioID uuid.UUID, positionIDs []uuid.UUID, baseCurrency string,
+	priceOf func(*models.Position) decimal.Decimal) error {
+	var positions []models.Position
+	var totalValue decimal.Decimal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockPortfolio(tx, portfolioID); err != nil {
+			return err
+		}
+
+		var current []models.Position
+		if err := tx.Where("id IN ?", positionIDs).Find(&current).Error; err != nil {
+			return err
+		}
+		for i := range current {
+			position := &current[i]
+			price := priceOf(position)
+			if price.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+
+			rate, err := fxRate(position.Currency, baseCurrency)
+			if err != nil {
+				s.logger.Warn("Skipping revaluation of position", "symbol", position.Symbol, "portfolio_id", position.PortfolioID, "error", err)
+				continue
+			}
+
+			revaluePosition(position, price, rate)
+			err = tx.Model(position).Updates(map[string]interface{}{
+				"current_price":      position.CurrentPrice,
+				"local_market_value": position.LocalMarketValue,
+				"fx_rate":            position.FXRate,
+				"market_value":       position.MarketValue,
+				"pn_l":               position.PnL,
+				"pn_l_percent":       position.PnLPercent,
+				"updated_at":         time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		positions, totalValue, err = refreshPortfolioValue(tx, portfolioID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.snapshot(portfolioID, positions, totalValue)
+}
+
+// portfolioCurrencies returns the currency of each portfolio holding the positions
+func (s *PnLService) portfolioCurrencies(positions []models.Position) (map[uuid.UUID]string, error) {
+	ids := make([]uuid.UUID, 0, len(positions))
+	seen := make(map[uuid.UUID]bool)
+	for _, position := range positions {
+		if !seen[position.PortfolioID] {
+			seen[position.PortfolioID] = true
+			ids = append(ids, position.PortfolioID)
+		}
+	}
+
+	var portfolios []models.Portfolio
+	if err := s.db.Select("id", "currency").Where("id IN ?", ids).Find(&portfolios).Error; err != nil {
+		return nil, err
+	}
+
+	currencies := make(map[uuid.UUID]string, len(portfolios))
+	for _, portfolio := range portfolios {
+		currencies[portfolio.ID] = portfolio.Currency
+	}
+	return currencies, nil
+}
+
+// RefreshPortfolio recomputes position weights and the portfolio total value, then writes today's P&L snapshot
+func (s *PnLService) RefreshPortfolio(portfolioID uuid.UUID) error {
+	return portfolioUpdates.serialize(portfolioID, func() error {
+		var positions []models.Position
+		var totalValue decimal.Decimal
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if _, err := lockPortfolio(tx, portfolioID); err != nil {
+				return err
+			}
+			var err error
+			positions, totalValue, err = refreshPortfolioValue(tx, portfolioID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.snapshot(portfolioID, positions, totalValue)
+	})
+}
+
+// snapshot upserts today's P&L row for a portfolio
+func (s *PnLService) snapshot(portfolioID uuid.UUID, positions []models.Position, totalValue decimal.Decimal) error {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	costBasis, unrealized := decimal.Zero, decimal.Zero
+	for _, position := range positions {
+		costBasis = costBasis.Add(positionCostBasis(position))
+		unrealized = unrealized.Add(position.PnL)
+	}
+
+	realized, err := s.RealizedPnL(portfolioID, today, now)
+	if err != nil {
+		return err
+	}
+
+	daily := realized
+	var previous models.PnLHistory
+	err = s.db.Where("portfolio_id = ? AND date < ?", portfolioID, today).Order("date DESC").First(&previous).Error
+	if err == nil {
+		daily = daily.Add(totalValue.Sub(previous.MarketValue))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	row := models.PnLHistory{
+		PortfolioID:   portfolioID,
+		Date:          today,
+		MarketValue:   totalValue.Round(2),
+		CostBasis:     costBasis.Round(2),
+		UnrealizedPnL: unrealized.Round(2),
+		RealizedPnL:   realized.Round(2),
+		DailyPnL:      daily.Round(2),
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "portfolio_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"market_value", "cost_basis", "unrealized_pnl", "realized_pnl", "daily_pnl", "updated_at"}),
+	}).Create(&row).Error
+}
+
+// RealizedPnL is the P&L realized by sales in a window: the gains recorded on the lots each sale
+// fill closed. Completed sells from before lots were kept are estimated with the position's current
+// average price as their cost, converted into the portfolio currency at the position's current rate.
+func (s *PnLService) RealizedPnL(portfolioID uuid.UUID, from, to time.Time) (decimal.Decimal, error) {
+	var booked decimal.Decimal
+	err := s.db.Model(&models.LotClosure{}).Select("COALESCE(SUM(realized_pnl), 0)").
+		Where("portfolio_id = ? AND closed_at BETWEEN ? AND ?", portfolioID, from, to).
+		Where("transaction_id IN (SELECT id FROM transactions WHERE deleted_at IS NULL)").
+		Scan(&booked).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	var estimated decimal.Decimal
+	err = s.db.Raw(`SELECT COALESCE(SUM((t.price - cost.average_price) * t.quantity * cost.fx_rate), 0)
+		FROM transactions t
+		JOIN (
+			SELECT symbol, SUM(quantity * average_price) / NULLIF(SUM(quantity), 0) AS average_price,
+				MAX(COALESCE(fx_rate, 1)) AS fx_rate
+			FROM positions WHERE portfolio_id = ? AND deleted_at IS NULL GROUP BY symbol
+		) cost ON cost.symbol = t.symbol
+		WHERE t.portfolio_id = ? AND t.deleted_at IS NULL AND t.status = 'COMPLETED'
+			AND (t.transaction_type = 'SELL' OR t.side = 'SELL')
+			AND COALESCE(t.executed_at, t.created_at) BETWEEN ? AND ?
+			AND NOT EXISTS (SELECT 1 FROM lot_closures c WHERE c.transaction_id = t.id)`,
+		portfolioID, portfolioID, from, to).Scan(&estimated).Error
+	return booked.Add(estimated), err
+}
+
+// GetPnL reports current P&L by position and P&L over a period of 1d, 1w or 1m
+func (s *PnLService) GetPnL(portfolioID uuid.UUID, period string) (*PnLReport, error) {
+	start, ok := pnlPeriods[period]
+	if !ok {
+		return nil, errors.New("invalid period, use 1d, 1w or 1m")
+	}
+
+	var positions []models.Position
+	if err := s.db.Where("portfolio_id = ?", portfolioID).Order("symbol").Find(&positions).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &PnLReport{
+		PortfolioID: portfolioID,
+		Period:      period,
+		From:        start(now),
+		To:          now,
+		Positions:   make([]PositionPnL, 0, len(positions)),
+	}
+
+	for _, position := range positions {
+		report.MarketValue = report.MarketValue.Add(position.MarketValue)
+		report.CostBasis = report.CostBasis.Add(positionCostBasis(position))
+		report.UnrealizedPnL = report.UnrealizedPnL.Add(position.PnL)
+		report.Positions = append(report.Positions, PositionPnL{
+			PositionID:    position.ID,
+			Symbol:        position.Symbol,
+			Currency:      position.Currency,
+			FXRate:        position.FXRate,
+			Quantity:      position.Quantity,
+			AveragePrice:  position.AveragePrice,
+			CurrentPrice:  position.CurrentPrice,
+			MarketValue:   position.MarketValue,
+			UnrealizedPnL: position.PnL,
+			PnLPercent:    position.PnLPercent,
+		})
+	}
+
+	realized, err := s.RealizedPnL(portfolioID, report.From, report.To)
+	if err != nil {
+		return nil, err
+	}
+	report.RealizedPnL = realized
+
+	if report.RealizedLots, err = s.lots.ClosuresBetween(portfolioID, report.From, report.To); err != nil {
+		return nil, err
+	}
+
+	fromDate := report.From.UTC().Truncate(24 * time.Hour)
+	if err := s.db.Where("portfolio_id = ? AND date >= ?", portfolioID, fromDate).Order("date ASC").Find(&report.History).Error; err != nil {
+		return nil, err
+	}
+
+	// The period starts from the last snapshot taken before it, or the first one inside it
+	var opening models.PnLHistory
+	err = s.db.Where("portfolio_id = ? AND date < ?", portfolioID, fromDate).Order("date DESC").First(&opening).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && len(report.History) > 0 {
+		opening, err = report.History[0], nil
+	}
+	if err == nil {
+		report.PeriodPnL = report.MarketValue.Sub(opening.MarketValue).Add(realized)
+		if opening.MarketValue.IsPositive() {
+			report.PeriodReturn = report.PeriodPnL.Div(opening.MarketValue).Mul(hundred).Round(4)
+		}
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		report.PeriodPnL = realized
+	} else {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// revaluePosition marks a position to a new price and exchange rate. P&L is converted at the current
+// rate and the P&L percent is the return in the position currency.
+func revaluePosition(position *models.Position, price, fxRate decimal.Decimal) {
+	position.CurrentPrice = price
+	position.FXRate = fxRate
+	position.LocalMarketValue = position.Quantity.Mul(price).Round(2)
+	position.MarketValue = position.Quantity.Mul(price).Mul(fxRate).Round(2)
+	position.PnL = price.Sub(position.AveragePrice).Mul(position.Quantity).Mul(fxRate).Round(2)
+	if position.AveragePrice.IsPositive() {
+		position.PnLPercent = price.Div(position.AveragePrice).Sub(decimal.NewFromInt(1)).Mul(hundred).Round(4)
+	} else {
+		position.PnLPercent = decimal.Zero
+	}
+}
+
+// positionCostBasis is what a position cost, in the portfolio currency at its current rate
+func positionCostBasis(position models.Position) decimal.Decimal {
+	rate := position.FXRate
+	if !rate.IsPositive() {
+		rate = decimal.NewFromInt(1)
+	}
+	return position.Quantity.Mul(position.AveragePrice).Mul(rate)
+}
@@ -2,54 +2,130 @@ package services
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
 )
 
 type PortfolioService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	cashService *CashService
 }
 
 func NewPortfolioService() *PortfolioService {
 	return &PortfolioService{
-		db: database.GetDB(),
+		db:          database.GetDB(),
+		cashService: NewCashService(),
 	}
 }
 
 type CreatePortfolioRequest struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description"`
-	Currency    string `json:"currency"`
+	Name             string `json:"name" validate:"notblank,max=255"`
+	Description      string `json:"description"`
+	Currency         string `json:"currency"`
+	Benchmark        string `json:"benchmark"`
+	CustodianAccount string `json:"custodian_account" validate:"max=50"`
+	CostBasisMethod  string `json:"cost_basis_method" validate:"omitempty,oneof=FIFO LIFO HIFO"` // FIFO when empty
+	MarginAccountRequest
 }
 
 type UpdatePortfolioRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name             string  `json:"name" validate:"omitempty,notblank,max=255"`
+	Description      string  `json:"description"`
+	Benchmark        *string `json:"benchmark"`                                                   // Nil leaves the benchmark unchanged, empty clears it
+	CustodianAccount *string `json:"custodian_account" validate:"omitempty,max=50"`               // Nil leaves the account unchanged, empty clears it
+	CostBasisMethod  string  `json:"cost_basis_method" validate:"omitempty,oneof=FIFO LIFO HIFO"` // Applies to later sales
+	MarginAccountRequest
 }
 
-// GetUserPortfolios returns all portfolios for a specific user
+// MarginAccountRequest sets a portfolio's margin account; nil fields are left unchanged. A cash
+// balance set here is recorded in the cash ledger as an adjustment.
+type MarginAccountRequest struct {
+	CashBalance           *decimal.Decimal `json:"cash_balance"`
+	MarginLoan            *decimal.Decimal `json:"margin_loan"`
+	MaintenanceMarginRate *decimal.Decimal `json:"maintenance_margin_rate"`
+}
+
+// Validate rejects a negative margin loan or a maintenance rate outside 0 to 1
+func (r MarginAccountRequest) Validate() error {
+	if r.MarginLoan != nil && r.MarginLoan.IsNegative() {
+		return errors.New("margin_loan must not be negative")
+	}
+	if r.MaintenanceMarginRate != nil &&
+		(r.MaintenanceMarginRate.IsNegative() || r.MaintenanceMarginRate.GreaterThan(decimal.NewFromInt(1))) {
+		return errors.New("maintenance_margin_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// apply sets the margin loan and maintenance rate; the cash balance goes through the cash ledger
+func (r MarginAccountRequest) apply(portfolio *models.Portfolio) {
+	if r.MarginLoan != nil {
+		portfolio.MarginLoan = *r.MarginLoan
+	}
+	if r.MaintenanceMarginRate != nil {
+		portfolio.MaintenanceMarginRate = *r.MaintenanceMarginRate
+	}
+}
+
+// GetUserPortfolios returns all portfolios a user owns or edits as a team editor
 func (s *PortfolioService) GetUserPortfolios(userID uuid.UUID) ([]models.Portfolio, error) {
 	var portfolios []models.Portfolio
-	err := s.db.Preload("User").Where("user_id = ?", userID).Find(&portfolios).Error
+	err := editableBy(s.db.Preload("User"), userID).Find(&portfolios).Error
 	return portfolios, err
 }
 
-// GetPortfolio returns a specific portfolio by ID, ensuring it belongs to the user
+// GetPortfolio returns a specific portfolio by ID, ensuring the user owns or edits it
 func (s *PortfolioService) GetPortfolio(portfolioID, userID uuid.UUID) (*models.Portfolio, error) {
 	var portfolio models.Portfolio
-	err := s.db.Where("id = ? AND user_id = ?", portfolioID, userID).
+	err := editableBy(s.db.Where("id = ?", portfolioID), userID).
+		Preload("Positions").
+		Preload("User").
+		First(&portfolio).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("portfolio not found")
+		}
+		return nil, err
+	}
+
+	return &portfolio, nil
+}
+
+// GetPortfoliosByIDs returns the given portfolios, or every portfolio when all is set
+func (s *PortfolioService) GetPortfoliosByIDs(ids []uuid.UUID, all bool) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	query := s.db.Preload("User")
+	if !all {
+		if len(ids) == 0 {
+			return portfolios, nil
+		}
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Order("created_at ASC").Find(&portfolios).Error
+	return portfolios, err
+}
+
+// GetPortfolioByID returns a portfolio without an ownership check; callers must verify access
+func (s *PortfolioService) GetPortfolioByID(portfolioID uuid.UUID) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := s.db.Where("id = ?", portfolioID).
 		Preload("Positions").
 		Preload("User").
 		First(&portfolio).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("portfolio not found")
+			return nil, apperror.NotFound("portfolio not found")
 		}
 		return nil, err
 	}
@@ -60,18 +136,33 @@ func (s *PortfolioService) GetPortfolio(portfolioID, userID uuid.UUID) (*models.
 // CreatePortfolio creates a new portfolio for a user
 func (s *PortfolioService) CreatePortfolio(userID uuid.UUID, req CreatePortfolioRequest) (*models.Portfolio, error) {
 	portfolio := models.Portfolio{
-		UserID:      userID,
-		Name:        req.Name,
-		Description: req.Description,
-		Currency:    req.Currency,
-		TotalValue:  decimal.Zero,
+		UserID:           userID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Currency:         req.Currency,
+		Benchmark:        req.Benchmark,
+		TotalValue:       decimal.Zero,
+		CustodianAccount: strings.TrimSpace(req.CustodianAccount),
+		CostBasisMethod:  req.CostBasisMethod,
 	}
 
 	if portfolio.Currency == "" {
 		portfolio.Currency = "USD"
 	}
+	if portfolio.CostBasisMethod == "" {
+		portfolio.CostBasisMethod = models.CostBasisFIFO
+	}
+	req.MarginAccountRequest.apply(&portfolio)
 
-	err := s.db.Create(&portfolio).Error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&portfolio).Error; err != nil {
+			return err
+		}
+		if req.CashBalance == nil {
+			return nil
+		}
+		return s.cashService.Adjust(tx, &portfolio, *req.CashBalance, "Opening balance", &userID)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -79,28 +170,115 @@ func (s *PortfolioService) CreatePortfolio(userID uuid.UUID, req CreatePortfolio
 	return &portfolio, nil
 }
 
-// UpdatePortfolio updates an existing portfolio
-func (s *PortfolioService) UpdatePortfolio(portfolioID, userID uuid.UUID, req UpdatePortfolioRequest) (*models.Portfolio, error) {
-	var portfolio models.Portfolio
-
-	// Check if portfolio exists and belongs to user
-	err := s.db.Where("id = ? AND user_id = ?", portfolioID, userID).First(&portfolio).Error
+// ClonePortfolio copies a portfolio into a new one owned by the user, for what-if analysis without
+// touching the original. The copy starts with the source's positions at their current prices, cash
+// balance, margin settings, risk thresholds, guideline and target allocations; its transactions,
+// alerts, team, supervisors and custodian account are not copied. Callers must verify access.
+func (s *PortfolioService) ClonePortfolio(portfolioID, userID uuid.UUID, req PortfolioFromTemplateRequest) (*models.Portfolio, error) {
+	source, err := s.GetPortfolioByID(portfolioID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("portfolio not found")
-		}
 		return nil, err
 	}
 
-	// Update fields
+	seed := portfolioSeed{
+		positions:       source.Positions,
+		cashBalance:     source.CashBalance,
+		cashDescription: "Cloned from portfolio " + source.Name,
+	}
+	var thresholds models.RiskThresholds
+	if err := s.db.Where("portfolio_id = ?", portfolioID).First(&thresholds).Error; err == nil {
+		// The clone is a top-level portfolio, so the limits the source inherits are set on it
+		thresholds.Overrides = *thresholds.Limits()
+		seed.thresholds = &thresholds
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var guideline models.InvestmentGuideline
+	if err := s.db.Where("portfolio_id = ?", portfolioID).First(&guideline).Error; err == nil {
+		guideline.UpdatedBy = &userID
+		seed.guideline = &guideline
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := s.db.Where("portfolio_id = ?", portfolioID).Find(&seed.targets).Error; err != nil {
+		return nil, err
+	}
+
+	clone := models.Portfolio{
+		UserID:                userID,
+		Name:                  source.Name + " (copy)",
+		Description:           source.Description,
+		Currency:              source.Currency,
+		Benchmark:             source.Benchmark,
+		MarginLoan:            source.MarginLoan,
+		MaintenanceMarginRate: source.MaintenanceMarginRate,
+		CostBasisMethod:       source.CostBasisMethod,
+	}
 	if req.Name != "" {
-		portfolio.Name = req.Name
+		clone.Name = req.Name
 	}
 	if req.Description != "" {
-		portfolio.Description = req.Description
+		clone.Description = req.Description
 	}
 
-	err = s.db.Save(&portfolio).Error
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return seedPortfolio(tx, s.cashService, &clone, seed, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// UpdatePortfolio updates an existing portfolio, if it is still at the version the change was made
+// against
+func (s *PortfolioService) UpdatePortfolio(portfolioID, userID uuid.UUID, version int64, req UpdatePortfolioRequest) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Check if portfolio exists and the user owns or edits it. The row stays locked so the cash
+		// balance cannot move under a settling transaction between reading and saving it.
+		err := editableBy(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", portfolioID), userID).
+			First(&portfolio).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.NotFound("portfolio not found")
+			}
+			return err
+		}
+		if err := checkVersion("Portfolio", &portfolio, portfolio.Version, version); err != nil {
+			return err
+		}
+
+		// Update fields
+		if req.Name != "" {
+			portfolio.Name = req.Name
+		}
+		if req.Description != "" {
+			portfolio.Description = req.Description
+		}
+		if req.Benchmark != nil {
+			portfolio.Benchmark = *req.Benchmark
+		}
+		if req.CustodianAccount != nil {
+			portfolio.CustodianAccount = strings.TrimSpace(*req.CustodianAccount)
+		}
+		if req.CostBasisMethod != "" {
+			portfolio.CostBasisMethod = req.CostBasisMethod
+		}
+		req.MarginAccountRequest.apply(&portfolio)
+
+		if req.CashBalance != nil {
+			if err := s.cashService.Adjust(tx, &portfolio, *req.CashBalance, "Cash balance set on portfolio", &userID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(&portfolio).Error; err != nil {
+			return err
+		}
+		// The database raised the version with the changes
+		return tx.Model(&models.Portfolio{}).Where("id = ?", portfolio.ID).Select("version").Scan(&portfolio.Version).Error
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -108,37 +286,81 @@ func (s *PortfolioService) UpdatePortfolio(portfolioID, userID uuid.UUID, req Up
 	return &portfolio, nil
 }
 
-// DeletePortfolio deletes a portfolio and all its positions
+// softDeletedWithPortfolio are the records deleted and restored along with their portfolio
+var softDeletedWithPortfolio = []interface{}{&models.Position{}, &models.Transaction{}, &models.Alert{}}
+
+// DeletePortfolio soft deletes a portfolio with its positions, transactions and alerts. They all
+// share the portfolio's deletion time so that restoring it brings back exactly what was deleted
+// with it, and not records deleted on their own before.
 func (s *PortfolioService) DeletePortfolio(portfolioID, userID uuid.UUID) error {
-	// Check if portfolio exists and belongs to user
+	// Check if portfolio exists and the user owns or edits it
 	var portfolio models.Portfolio
-	err := s.db.Where("id = ? AND user_id = ?", portfolioID, userID).First(&portfolio).Error
+	err := editableBy(s.db.Where("id = ?", portfolioID), userID).First(&portfolio).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("portfolio not found")
+			return apperror.NotFound("portfolio not found")
 		}
 		return err
 	}
 
-	// Delete all positions first (cascade delete)
-	err = s.db.Where("portfolio_id = ?", portfolioID).Delete(&models.Position{}).Error
-	if err != nil {
+	// Portfolios under it would roll up into a deleted portfolio
+	var children int64
+	if err := s.db.Model(&models.Portfolio{}).Where("parent_id = ?", portfolioID).Count(&children).Error; err != nil {
 		return err
 	}
+	if children > 0 {
+		return apperror.Conflict("portfolio has sub-portfolios; move or delete them first")
+	}
 
-	// Delete the portfolio
-	err = s.db.Delete(&portfolio).Error
-	return err
+	deletedAt := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range softDeletedWithPortfolio {
+			if err := tx.Model(model).Where("portfolio_id = ?", portfolioID).Update("deleted_at", deletedAt).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&portfolio).Update("deleted_at", deletedAt).Error
+	})
+}
+
+// RestorePortfolio undeletes a soft deleted portfolio along with the records deleted with it
+func (s *PortfolioService) RestorePortfolio(portfolioID uuid.UUID) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := s.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", portfolioID).First(&portfolio).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("deleted portfolio not found")
+		}
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range softDeletedWithPortfolio {
+			err := tx.Unscoped().Model(model).
+				Where("portfolio_id = ? AND deleted_at = ?", portfolioID, portfolio.DeletedAt.Time).
+				Update("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Model(&portfolio).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio.DeletedAt = gorm.DeletedAt{}
+	return &portfolio, nil
 }
 
 // GetPortfolioPositions returns all positions for a portfolio
 func (s *PortfolioService) GetPortfolioPositions(portfolioID, userID uuid.UUID) ([]models.Position, error) {
-	// First verify the portfolio belongs to the user
+	// First verify the user owns or edits the portfolio
 	var portfolio models.Portfolio
-	err := s.db.Where("id = ? AND user_id = ?", portfolioID, userID).First(&portfolio).Error
+	err := editableBy(s.db.Where("id = ?", portfolioID), userID).First(&portfolio).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("portfolio not found")
+			return nil, apperror.NotFound("portfolio not found")
 		}
 		return nil, err
 	}
@@ -148,23 +370,16 @@ func (s *PortfolioService) GetPortfolioPositions(portfolioID, userID uuid.UUID)
 	return positions, err
 }
 
-// CalculatePortfolioValue recalculates the total value of a portfolio
+// CalculatePortfolioValue recalculates the total value of a portfolio and its position weights
+// under the portfolio's row lock
 func (s *PortfolioService) CalculatePortfolioValue(portfolioID uuid.UUID) error {
-	var positions []models.Position
-	err := s.db.Where("portfolio_id = ?", portfolioID).Find(&positions).Error
-	if err != nil {
-		return err
-	}
-
-	totalValue := decimal.Zero
-	for _, position := range positions {
-		totalValue = totalValue.Add(position.MarketValue)
-	}
-
-	// Update portfolio total value
-	err = s.db.Model(&models.Portfolio{}).
-		Where("id = ?", portfolioID).
-		Update("total_value", totalValue).Error
-
-	return err
+	return portfolioUpdates.serialize(portfolioID, func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if _, err := lockPortfolio(tx, portfolioID); err != nil {
+				return err
+			}
+			_, _, err := refreshPortfolioValue(tx, portfolioID)
+			return err
+		})
+	})
 }
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// hierarchyLockID is the Postgres advisory lock held while a portfolio moves in the hierarchy or
+// its thresholds change, so that concurrent moves cannot make the hierarchy circular and limits
+// are passed down in the order they were set
+const hierarchyLockID = 7_311_402_560
+
+// lockHierarchy holds the hierarchy lock until the transaction ends
+func lockHierarchy(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", hierarchyLockID).Error
+}
+
+// PortfolioHierarchyService arranges portfolios into books, strategies and sub-portfolios, and
+// rolls the positions of the portfolios under each one up into it
+type PortfolioHierarchyService struct {
+	db            *gorm.DB
+	accessService *AccessService
+	riskEngine    *RiskEngineService
+}
+
+func NewPortfolioHierarchyService() *PortfolioHierarchyService {
+	return &PortfolioHierarchyService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+		riskEngine:    NewRiskEngineService(),
+	}
+}
+
+// ParentRequest places a portfolio under a parent portfolio, or at the top of the hierarchy when
+// parent_id is null
+type ParentRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// HierarchyNode is a portfolio with the portfolios under it
+type HierarchyNode struct {
+	ID          uuid.UUID       `json:"id"`
+	ParentID    *uuid.UUID      `json:"parent_id,omitempty"`
+	Name        string          `json:"name"`
+	Currency    string          `json:"currency"`
+	TotalValue  decimal.Decimal `json:"total_value"`
+	CashBalance decimal.Decimal `json:"cash_balance"`
+	// Value with cash of the portfolio and every portfolio under it, in its currency
+	RollupValue decimal.Decimal `json:"rollup_value"`
+	Children    []HierarchyNode `json:"children"`
+}
+
+// HierarchyAncestor is a portfolio above another. Only its ID and name are given when the user
+// cannot access it.
+type HierarchyAncestor struct {
+	ID          uuid.UUID        `json:"id"`
+	ParentID    *uuid.UUID       `json:"parent_id,omitempty"`
+	Name        string           `json:"name"`
+	Currency    string           `json:"currency,omitempty"`
+	TotalValue  *decimal.Decimal `json:"total_value,omitempty"`
+	CashBalance *decimal.Decimal `json:"cash_balance,omitempty"`
+}
+
+// Hierarchy is a portfolio's place in the hierarchy: the portfolios above it and the tree under it
+type Hierarchy struct {
+	Ancestors []HierarchyAncestor `json:"ancestors"` // Top-level portfolio first
+	Tree      HierarchyNode       `json:"tree"`
+}
+
+// SetParent moves a portfolio under a parent, or to the top of the hierarchy when parentID is nil.
+// The user must be able to modify the parent, which must belong to the same tenant. The limits the
+// portfolio and those under it inherit are taken from the new parent.
+func (s *PortfolioHierarchyService) SetParent(portfolioID uuid.UUID, parentID *uuid.UUID, userID uuid.UUID, role string) (*models.Portfolio, error) {
+	if parentID != nil && *parentID == portfolioID {
+		return nil, apperror.BadRequest("A portfolio cannot roll up into itself")
+	}
+
+	var portfolio models.Portfolio
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockHierarchy(tx); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&portfolio, portfolioID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.NotFound("portfolio not found")
+			}
+			return err
+		}
+
+		if parentID != nil {
+			var parent models.Portfolio
+			if err := tx.First(&parent, *parentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return apperror.NotFound("parent portfolio not found")
+				}
+				return err
+			}
+			allowed, err := s.accessService.CanModifyPortfolio(userID, role, parent.ID)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return apperror.Forbidden("You cannot modify the parent portfolio")
+			}
+			if !sameTenant(portfolio.TenantID, parent.TenantID) {
+				return apperror.BadRequest("A portfolio can only roll up into a portfolio of the same tenant")
+			}
+			ancestors, err := ancestorIDs(tx, parent.ID)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(ancestors, portfolioID) {
+				return apperror.Conflict("The parent portfolio is under this portfolio; moving it there would make the hierarchy circular")
+			}
+		}
+
+		if err := tx.Model(&portfolio).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		portfolio.ParentID = parentID
+
+		thresholds, err := s.riskEngine.thresholdsIn(tx, portfolioID)
+		if err != nil {
+			return err
+		}
+		changed, err := s.riskEngine.resolveThresholds(tx, thresholds)
+		if err != nil {
+			return err
+		}
+		if changed {
+			if err := tx.Omit("Portfolio").Save(thresholds).Error; err != nil {
+				return err
+			}
+		}
+		if err := s.riskEngine.passDownThresholds(tx, portfolioID); err != nil {
+			return err
+		}
+		// The database raised the version with the move
+		return tx.Model(&models.Portfolio{}).Where("id = ?", portfolio.ID).Select("version").Scan(&portfolio.Version).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &portfolio, nil
+}
+
+// Get returns the portfolios above a portfolio and the tree of live portfolios under it. The
+// ancestors the user cannot access are named without their figures.
+func (s *PortfolioHierarchyService) Get(portfolioID, userID uuid.UUID, role string) (*Hierarchy, error) {
+	ids, err := subtreeIDs(s.db, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, apperror.NotFound("portfolio not found")
+	}
+	var portfolios []models.Portfolio
+	if err := s.db.Where("id IN ?", ids).Order("name").Find(&portfolios).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[uuid.UUID][]models.Portfolio)
+	var root models.Portfolio
+	for _, portfolio := range portfolios {
+		if portfolio.ID == portfolioID {
+			root = portfolio
+		} else if portfolio.ParentID != nil {
+			children[*portfolio.ParentID] = append(children[*portfolio.ParentID], portfolio)
+		}
+	}
+	tree, err := hierarchyTree(root, children)
+	if err != nil {
+		return nil, err
+	}
+
+	hierarchy := &Hierarchy{Ancestors: []HierarchyAncestor{}, Tree: *tree}
+	ancestors, err := ancestorIDs(s.db, portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors) > 1 {
+		var above []models.Portfolio
+		if err := s.db.Where("id IN ?", ancestors[1:]).Find(&above).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]models.Portfolio, len(above))
+		for _, portfolio := range above {
+			byID[portfolio.ID] = portfolio
+		}
+		// Nearest first from the query; the response starts at the top
+		for i := len(ancestors) - 1; i >= 1; i-- {
+			portfolio, ok := byID[ancestors[i]]
+			if !ok {
+				continue
+			}
+			allowed, err := s.accessService.CanAccessPortfolio(userID, role, portfolio.ID)
+			if err != nil {
+				return nil, err
+			}
+			ancestor := HierarchyAncestor{ID: portfolio.ID, Name: portfolio.Name}
+			if allowed {
+				ancestor.ParentID = portfolio.ParentID
+				ancestor.Currency = portfolio.Currency
+				ancestor.TotalValue = &portfolio.TotalValue
+				ancestor.CashBalance = &portfolio.CashBalance
+			}
+			hierarchy.Ancestors = append(hierarchy.Ancestors, ancestor)
+		}
+	}
+	return hierarchy, nil
+}
+
+// Rollup returns a portfolio with the positions, cash and margin loan of every live portfolio
+// under it added to its own, in its currency, and the IDs of the portfolios rolled up. Positions
+// in the same symbol are combined into one.
+func (s *PortfolioHierarchyService) Rollup(ctx context.Context, portfolioID uuid.UUID) (*models.Portfolio, []uuid.UUID, error) {
+	ids, err := subtreeIDs(s.db.WithContext(ctx), portfolioID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil, apperror.NotFound("portfolio not found")
+	}
+	var members []models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").Where("id IN ?", ids).Find(&members).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rollup models.Portfolio
+	for _, member := range members {
+		if member.ID == portfolioID {
+			rollup = member
+		}
+	}
+	rollup.TotalValue, rollup.CashBalance, rollup.MarginLoan = decimal.Zero, decimal.Zero, decimal.Zero
+	rollup.Positions = nil
+
+	bySymbol := make(map[string]*models.Position)
+	costs := make(map[string]decimal.Decimal) // Local cost of each symbol's quantity
+	for _, member := range members {
+		rate, err := fxRate(member.Currency, rollup.Currency)
+		if err != nil {
+			return nil, nil, apperror.BadRequest(fmt.Sprintf("No exchange rate from %s to %s for portfolio %s", member.Currency, rollup.Currency, member.Name))
+		}
+		rollup.CashBalance = rollup.CashBalance.Add(member.CashBalance.Mul(rate))
+		rollup.MarginLoan = rollup.MarginLoan.Add(member.MarginLoan.Mul(rate))
+
+		for _, position := range member.Positions {
+			combined, ok := bySymbol[position.Symbol]
+			if !ok {
+				combined = &models.Position{
+					PortfolioID:  rollup.ID,
+					Symbol:       position.Symbol,
+					CurrentPrice: position.CurrentPrice,
+					AssetType:    position.AssetType,
+					Liquidity:    position.Liquidity,
+					CreditRating: position.CreditRating,
+					Currency:     position.Currency,
+					FXRate:       position.FXRate.Mul(rate),
+					UpdatedAt:    position.UpdatedAt,
+				}
+				bySymbol[position.Symbol] = combined
+			}
+			combined.Quantity = combined.Quantity.Add(position.Quantity)
+			combined.MarketValue = combined.MarketValue.Add(position.MarketValue.Mul(rate))
+			combined.LocalMarketValue = combined.LocalMarketValue.Add(position.LocalMarketValue)
+			combined.PnL = combined.PnL.Add(position.PnL.Mul(rate))
+			costs[position.Symbol] = costs[position.Symbol].Add(position.Quantity.Mul(position.AveragePrice))
+			if position.UpdatedAt.After(combined.UpdatedAt) {
+				combined.CurrentPrice = position.CurrentPrice
+				combined.UpdatedAt = position.UpdatedAt
+			}
+		}
+	}
+
+	for symbol, position := range bySymbol {
+		position.MarketValue = position.MarketValue.Round(2)
+		position.PnL = position.PnL.Round(2)
+		if !position.Quantity.IsZero() {
+			position.AveragePrice = costs[symbol].Div(position.Quantity).Round(8)
+		}
+		if cost := position.MarketValue.Sub(position.PnL); !cost.IsZero() {
+			position.PnLPercent = position.PnL.Div(cost).Mul(hundred).Round(4)
+		}
+		rollup.TotalValue = rollup.TotalValue.Add(position.MarketValue)
+		rollup.Positions = append(rollup.Positions, *position)
+	}
+	for i := range rollup.Positions {
+		if rollup.TotalValue.IsPositive() {
+			rollup.Positions[i].Weight = rollup.Positions[i].MarketValue.Div(rollup.TotalValue).Mul(hundred).Round(4)
+		}
+	}
+	sort.Slice(rollup.Positions, func(i, j int) bool {
+		return rollup.Positions[i].MarketValue.GreaterThan(rollup.Positions[j].MarketValue)
+	})
+	rollup.CashBalance = rollup.CashBalance.Round(2)
+	rollup.MarginLoan = rollup.MarginLoan.Round(2)
+
+	return &rollup, ids, nil
+}
+
+// hierarchyTree builds the node of a portfolio with the nodes of the portfolios under it
+func hierarchyTree(portfolio models.Portfolio, children map[uuid.UUID][]models.Portfolio) (*HierarchyNode, error) {
+	node := newHierarchyNode(portfolio)
+	for _, child := range children[portfolio.ID] {
+		childNode, err := hierarchyTree(child, children)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := fxRate(child.Currency, portfolio.Currency)
+		if err != nil {
+			return nil, apperror.BadRequest(fmt.Sprintf("No exchange rate from %s to %s for portfolio %s", child.Currency, portfolio.Currency, child.Name))
+		}
+		node.RollupValue = node.RollupValue.Add(childNode.RollupValue.Mul(rate))
+		node.Children = append(node.Children, *childNode)
+	}
+	node.RollupValue = node.RollupValue.Round(2)
+	return &node, nil
+}
+
+func newHierarchyNode(portfolio models.Portfolio) HierarchyNode {
+	return HierarchyNode{
+		ID:          portfolio.ID,
+		ParentID:    portfolio.ParentID,
+		Name:        portfolio.Name,
+		Currency:    portfolio.Currency,
+		TotalValue:  portfolio.TotalValue,
+		CashBalance: portfolio.CashBalance,
+		RollupValue: portfolio.ValueWithCash(),
+		Children:    []HierarchyNode{},
+	}
+}
+
+// subtreeIDs returns the IDs of a live portfolio and of every live portfolio under it, none when
+// the portfolio does not exist
+func subtreeIDs(db *gorm.DB, portfolioID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Raw(`WITH RECURSIVE subtree AS (
+			SELECT id FROM portfolios WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT p.id FROM portfolios p JOIN subtree s ON p.parent_id = s.id WHERE p.deleted_at IS NULL
+		)
+		SELECT id FROM subtree`, portfolioID).Scan(&ids).Error
+	return ids, err
+}
+
+// ancestorIDs returns the ID of a portfolio followed by those of the portfolios above it, nearest
+// first. Deleted portfolios are included, as their children still roll up into them once restored.
+func ancestorIDs(db *gorm.DB, portfolioID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Raw(`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM portfolios WHERE id = ?
+			UNION
+			SELECT p.id, p.parent_id, a.depth + 1 FROM portfolios p JOIN ancestors a ON p.id = a.parent_id
+		)
+		SELECT id FROM ancestors ORDER BY depth`, portfolioID).Scan(&ids).Error
+	return ids, err
+}
+
+// sameTenant reports whether two portfolios belong to the same tenant, or both to none
+func sameTenant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Changes to a portfolio's positions, cash and value take its row FOR UPDATE before reading what
+// they change, so that price updates, trades, cash postings and corporate actions arriving together
+// apply one after another instead of overwriting each other. Within an instance, updates of a
+// portfolio also queue in portfolioUpdates, so that a burst of them waits in order rather than
+// holding database connections while blocked on the lock.
+
+// portfolioQueue serializes the updates of each portfolio within the instance
+type portfolioQueue struct {
+	mu    sync.Mutex
+	lanes map[uuid.UUID]*portfolioLane
+}
+
+type portfolioLane struct {
+	mu      sync.Mutex
+	waiting int // Updates running or queued, so that an idle lane can be dropped
+}
+
+var portfolioUpdates = &portfolioQueue{lanes: make(map[uuid.UUID]*portfolioLane)}
+
+// serialize runs fn once the updates of the portfolio queued before it have finished. fn must not
+// queue another update of the same portfolio.
+func (q *portfolioQueue) serialize(portfolioID uuid.UUID, fn func() error) error {
+	q.mu.Lock()
+	lane, ok := q.lanes[portfolioID]
+	if !ok {
+		lane = &portfolioLane{}
+		q.lanes[portfolioID] = lane
+	}
+	lane.waiting++
+	q.mu.Unlock()
+
+	lane.mu.Lock()
+	defer func() {
+		lane.mu.Unlock()
+		q.mu.Lock()
+		if lane.waiting--; lane.waiting == 0 {
+			delete(q.lanes, portfolioID)
+		}
+		q.mu.Unlock()
+	}()
+	return fn()
+}
+
+// lockPortfolio loads a portfolio for update so that changes to it apply one at a time
+func lockPortfolio(tx *gorm.DB, portfolioID uuid.UUID) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", portfolioID).First(&portfolio).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+	return &portfolio, nil
+}
+
+// lockPortfoliosHolding locks every portfolio with a position in one of the symbols, in ID order
+// so that transactions locking several portfolios cannot deadlock each other
+func lockPortfoliosHolding(tx *gorm.DB, symbols []string) error {
+	var ids []uuid.UUID
+	return tx.Model(&models.Portfolio{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN (?)", tx.Model(&models.Position{}).Select("portfolio_id").Where("symbol IN ?", symbols)).
+		Order("id").
+		Pluck("id", &ids).Error
+}
+
+// refreshPortfolioValue recomputes the weights of a locked portfolio's positions and its total
+// value, returning the positions and the value
+func refreshPortfolioValue(tx *gorm.DB, portfolioID uuid.UUID) ([]models.Position, decimal.Decimal, error) {
+	var positions []models.Position
+	if err := tx.Where("portfolio_id = ?", portfolioID).Find(&positions).Error; err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	totalValue := decimal.Zero
+	for _, position := range positions {
+		totalValue = totalValue.Add(position.MarketValue)
+	}
+
+	for i := range positions {
+		weight := decimal.Zero
+		if totalValue.IsPositive() {
+			weight = positions[i].MarketValue.Div(totalValue).Mul(hundred).Round(4)
+		}
+		if err := tx.Model(&models.Position{}).Where("id = ?", positions[i].ID).Update("weight", weight).Error; err != nil {
+			return nil, decimal.Zero, err
+		}
+		positions[i].Weight = weight
+	}
+	err := tx.Model(&models.Portfolio{}).Where("id = ?", portfolioID).Updates(map[string]interface{}{
+		"total_value": totalValue,
+		"updated_at":  time.Now(),
+	}).Error
+	return positions, totalValue, err
+}
+
+// sortedPortfolioIDs returns the keys of a set of portfolios in ID order
+func sortedPortfolioIDs(set map[uuid.UUID][]uuid.UUID) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
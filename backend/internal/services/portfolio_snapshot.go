@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// PortfolioSnapshotService records each portfolio's end-of-day NAV, cash and positions, the
+// history behind NAV charts and reports
+type PortfolioSnapshotService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewPortfolioSnapshotService() *PortfolioSnapshotService {
+	return &PortfolioSnapshotService{
+		db:     database.GetDB(),
+		logger: logging.Component("portfolio_snapshot"),
+	}
+}
+
+// StartScheduler snapshots every portfolio once a day at the given UTC hour until ctx is cancelled
+func (s *PortfolioSnapshotService) StartScheduler(ctx context.Context, hour int) {
+	for {
+		if !sleepUntil(ctx, nextDailyRun(time.Now(), hour)) {
+			return
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		recorded, err := s.SnapshotAll(ctx, time.Now())
+		if err != nil {
+			s.logger.ErrorContext(ctx, "End-of-day portfolio snapshot failed", "error", err)
+			continue
+		}
+		s.logger.InfoContext(ctx, "Portfolio NAV snapshots recorded", "portfolios", recorded)
+	}
+}
+
+// SnapshotAll records the snapshot of every portfolio for the UTC day of at and returns how many
+// were recorded. A portfolio that fails is logged and skipped.
+func (s *PortfolioSnapshotService) SnapshotAll(ctx context.Context, at time.Time) (int, error) {
+	recorded := 0
+	var batch []models.Portfolio
+	err := s.db.WithContext(ctx).Preload("Positions").FindInBatches(&batch, snapshotBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if _, err := s.Snapshot(ctx, &batch[i], at); err != nil {
+				s.logger.ErrorContext(ctx, "Snapshot of portfolio failed", "portfolio_id", batch[i].ID, "error", err)
+				continue
+			}
+			recorded++
+		}
+		return nil
+	}).Error
+	return recorded, err
+}
+
+// Snapshot records a portfolio, loaded with its positions, as of the UTC day of at, replacing a
+// snapshot already taken that day
+func (s *PortfolioSnapshotService) Snapshot(ctx context.Context, portfolio *models.Portfolio, at time.Time) (*models.PortfolioSnapshot, error) {
+	at = at.UTC()
+	snapshot := &models.PortfolioSnapshot{
+		PortfolioID: portfolio.ID,
+		Date:        time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC),
+		Currency:    portfolio.Currency,
+		CashBalance: portfolio.CashBalance.Round(2),
+		MarginLoan:  portfolio.MarginLoan.Round(2),
+		Positions:   make([]models.SnapshotPosition, 0, len(portfolio.Positions)),
+	}
+
+	marketValue, nav := portfolioNAV(portfolio)
+	for _, position := range portfolio.Positions {
+		weight := decimal.Zero
+		if marketValue.IsPositive() {
+			weight = position.MarketValue.Div(marketValue).Mul(hundred).Round(4)
+		}
+		snapshot.Positions = append(snapshot.Positions, models.SnapshotPosition{
+			Symbol:       position.Symbol,
+			AssetType:    position.AssetType,
+			Currency:     position.Currency,
+			Quantity:     position.Quantity,
+			AveragePrice: position.AveragePrice,
+			Price:        position.CurrentPrice,
+			MarketValue:  position.MarketValue,
+			PnL:          position.PnL,
+			Weight:       weight,
+		})
+	}
+	sort.Slice(snapshot.Positions, func(i, j int) bool {
+		return snapshot.Positions[i].MarketValue.GreaterThan(snapshot.Positions[j].MarketValue)
+	})
+
+	snapshot.MarketValue = marketValue.Round(2)
+	snapshot.NAV = nav.Round(2)
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "portfolio_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"currency", "nav", "market_value", "cash_balance", "margin_loan", "positions", "updated_at"}),
+	}).Create(snapshot).Error
+	return snapshot, err
+}
+
+// portfolioNAV returns the market value of a portfolio's loaded positions and its net asset value,
+// the market value plus cash less the margin loan
+func portfolioNAV(portfolio *models.Portfolio) (decimal.Decimal, decimal.Decimal) {
+	marketValue := decimal.Zero
+	for _, position := range portfolio.Positions {
+		marketValue = marketValue.Add(position.MarketValue)
+	}
+	return marketValue, marketValue.Add(portfolio.CashBalance).Sub(portfolio.MarginLoan)
+}
+
+// ListHistory returns a page of a portfolio's snapshots and the total match count. Positions are
+// left out unless withPositions is set, since charts only need the totals.
+func (s *PortfolioSnapshotService) ListHistory(portfolioID uuid.UUID, spec pagination.Spec, params pagination.Params, withPositions bool) ([]models.PortfolioSnapshot, int64, error) {
+	query := s.db.Model(&models.PortfolioSnapshot{}).Where("portfolio_id = ?", portfolioID)
+	if !withPositions {
+		query = query.Omit("positions")
+	}
+
+	var snapshots []models.PortfolioSnapshot
+	total, err := pagination.Find(query, spec, params, &snapshots)
+	return snapshots, total, err
+}
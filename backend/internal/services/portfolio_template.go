@@ -0,0 +1,357 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// PortfolioTemplateService keeps the predefined portfolios risk teams create model and sandbox
+// portfolios from
+type PortfolioTemplateService struct {
+	db          *gorm.DB
+	cashService *CashService
+}
+
+func NewPortfolioTemplateService() *PortfolioTemplateService {
+	return &PortfolioTemplateService{
+		db:          database.GetDB(),
+		cashService: NewCashService(),
+	}
+}
+
+// PortfolioTemplateRequest creates or replaces a portfolio template
+type PortfolioTemplateRequest struct {
+	Name        string                      `json:"name" validate:"notblank,max=255"`
+	Description string                      `json:"description"`
+	Currency    string                      `json:"currency"` // USD when empty
+	Benchmark   string                      `json:"benchmark"`
+	CashBalance decimal.Decimal             `json:"cash_balance"`
+	Positions   []models.TemplatePosition   `json:"positions"`
+	Thresholds  *models.ThresholdOverrides  `json:"thresholds"`
+	Guideline   *models.GuidelineDefinition `json:"guideline"`
+}
+
+// PortfolioFromTemplateRequest names a portfolio created from a template or cloned from another
+// portfolio; the template's or source's name and description are used when empty
+type PortfolioFromTemplateRequest struct {
+	Name        string `json:"name" validate:"omitempty,notblank,max=255"`
+	Description string `json:"description"`
+}
+
+// List returns a page of the templates
+func (s *PortfolioTemplateService) List(spec pagination.Spec, params pagination.Params) ([]models.PortfolioTemplate, int64, error) {
+	templates := []models.PortfolioTemplate{}
+	total, err := pagination.Find(s.db.Model(&models.PortfolioTemplate{}), spec, params, &templates)
+	return templates, total, err
+}
+
+// Get returns a template
+func (s *PortfolioTemplateService) Get(id uuid.UUID) (*models.PortfolioTemplate, error) {
+	var template models.PortfolioTemplate
+	if err := s.db.First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Portfolio template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Create validates and saves a new template
+func (s *PortfolioTemplateService) Create(req PortfolioTemplateRequest, userID uuid.UUID) (*models.PortfolioTemplate, error) {
+	template := &models.PortfolioTemplate{CreatedBy: &userID}
+	if err := s.apply(template, req, userID); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(template).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Update replaces a template's contents. Portfolios already created from it are not changed.
+func (s *PortfolioTemplateService) Update(id uuid.UUID, req PortfolioTemplateRequest, userID uuid.UUID) (*models.PortfolioTemplate, error) {
+	template, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(template, req, userID); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(template).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Delete removes a template and returns it
+func (s *PortfolioTemplateService) Delete(id uuid.UUID) (*models.PortfolioTemplate, error) {
+	template, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(template).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// apply validates a request, normalising its symbols and currencies, and sets it on the template
+func (s *PortfolioTemplateService) apply(template *models.PortfolioTemplate, req PortfolioTemplateRequest, userID uuid.UUID) error {
+	name := strings.TrimSpace(req.Name)
+	var taken int64
+	if err := s.db.Model(&models.PortfolioTemplate{}).Where("name = ? AND id <> ?", name, template.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperror.Conflict("A portfolio template named " + name + " already exists")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = "USD"
+	}
+	benchmark, err := NormalizeBenchmark(req.Benchmark)
+	if err != nil {
+		return apperror.BadRequest(err.Error())
+	}
+	if req.CashBalance.IsNegative() {
+		return apperror.BadRequest("cash_balance must not be negative")
+	}
+
+	positions := make([]models.TemplatePosition, 0, len(req.Positions))
+	for i, position := range req.Positions {
+		position.Symbol = strings.ToUpper(strings.TrimSpace(position.Symbol))
+		position.AssetType = strings.ToUpper(strings.TrimSpace(position.AssetType))
+		position.Currency = strings.ToUpper(strings.TrimSpace(position.Currency))
+		if position.Symbol == "" || position.AssetType == "" {
+			return apperror.BadRequest(fmt.Sprintf("positions[%d] needs a symbol and asset_type", i))
+		}
+		if !position.Quantity.IsPositive() || !position.Price.IsPositive() {
+			return apperror.BadRequest(fmt.Sprintf("positions[%d] needs a positive quantity and price", i))
+		}
+		positions = append(positions, position)
+	}
+
+	if req.Thresholds != nil {
+		if err := validateThresholdOverrides(req.Thresholds); err != nil {
+			return apperror.BadRequest(err.Error())
+		}
+	}
+	if req.Guideline != nil {
+		guideline := req.Guideline.For(uuid.Nil)
+		if err := rules.ValidateGuideline(guideline); err != nil {
+			return apperror.BadRequest(err.Error())
+		}
+		req.Guideline = &models.GuidelineDefinition{
+			AllowedAssetTypes:  guideline.AllowedAssetTypes,
+			MaxCryptoPercent:   guideline.MaxCryptoPercent,
+			MinCreditRating:    guideline.MinCreditRating,
+			ESGExcludedSymbols: guideline.ESGExcludedSymbols,
+			ESGExcludedSectors: guideline.ESGExcludedSectors,
+		}
+	}
+
+	template.Name = name
+	template.Description = req.Description
+	template.Currency = currency
+	template.Benchmark = benchmark
+	template.CashBalance = req.CashBalance
+	template.Positions = positions
+	template.Thresholds = req.Thresholds
+	template.Guideline = req.Guideline
+	template.UpdatedBy = &userID
+	return nil
+}
+
+// validateThresholdOverrides rejects negative limits
+func validateThresholdOverrides(o *models.ThresholdOverrides) error {
+	// Applying the overrides to zero thresholds leaves exactly the overridden limits set
+	var thresholds models.RiskThresholds
+	o.Apply(&thresholds)
+	for name, limit := range map[string]decimal.Decimal{
+		"max_var_95":                thresholds.MaxVaR95,
+		"max_var_99":                thresholds.MaxVaR99,
+		"max_position_size":         thresholds.MaxPositionSize,
+		"max_single_asset_exposure": thresholds.MaxSingleAssetExposure,
+		"max_sector_exposure":       thresholds.MaxSectorExposure,
+		"min_liquidity_ratio":       thresholds.MinLiquidityRatio,
+		"max_leverage":              thresholds.MaxLeverage,
+		"max_concentration":         thresholds.MaxConcentration,
+		"max_fx_exposure":           thresholds.MaxFXExposure,
+		"max_dv01":                  thresholds.MaxDV01,
+		"max_venue_exposure":        thresholds.MaxVenueExposure,
+		"max_stablecoin_depeg":      thresholds.MaxStablecoinDepeg,
+		"max_daily_loss":            thresholds.MaxDailyLoss,
+		"max_weekly_loss":           thresholds.MaxWeeklyLoss,
+		"max_drawdown":              thresholds.MaxDrawdown,
+		"max_stop_loss_distance":    thresholds.MaxStopLossDistance,
+	} {
+		if limit.IsNegative() {
+			return fmt.Errorf("thresholds.%s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// CreatePortfolio creates a portfolio owned by the user from a template. Positions are opened at
+// the template's prices, converted at current exchange rates, and no transactions are recorded for
+// them; the cash balance is posted to the cash ledger as the opening balance. The template's risk
+// thresholds override those of the user's tenant.
+func (s *PortfolioTemplateService) CreatePortfolio(templateID, userID uuid.UUID, req PortfolioFromTemplateRequest) (*models.Portfolio, error) {
+	template, err := s.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := models.Portfolio{
+		UserID:      userID,
+		Name:        template.Name,
+		Description: template.Description,
+		Currency:    template.Currency,
+		Benchmark:   template.Benchmark,
+	}
+	if req.Name != "" {
+		portfolio.Name = req.Name
+	}
+	if req.Description != "" {
+		portfolio.Description = req.Description
+	}
+
+	positions := make([]models.Position, 0, len(template.Positions))
+	for _, held := range template.Positions {
+		position := models.Position{
+			Symbol:       held.Symbol,
+			AssetType:    held.AssetType,
+			Quantity:     held.Quantity,
+			AveragePrice: held.Price,
+			Currency:     held.Currency,
+		}
+		if position.Currency == "" {
+			position.Currency = portfolio.Currency
+		}
+		rate, err := fxRate(position.Currency, portfolio.Currency)
+		if err != nil {
+			return nil, apperror.BadRequest(fmt.Sprintf("No exchange rate from %s to %s for %s", position.Currency, portfolio.Currency, held.Symbol))
+		}
+		revaluePosition(&position, held.Price, rate)
+		positions = append(positions, position)
+	}
+
+	thresholds := models.GetDefaultThresholds(uuid.Nil)
+	settings, err := tenantSettingsOf(s.db, "users", userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && settings.RiskThresholds != nil {
+		settings.RiskThresholds.Apply(thresholds)
+	}
+	if template.Thresholds != nil {
+		template.Thresholds.Apply(thresholds)
+		thresholds.Overrides = *template.Thresholds
+	}
+	var guideline *models.InvestmentGuideline
+	if template.Guideline != nil {
+		guideline = template.Guideline.For(uuid.Nil)
+		guideline.UpdatedBy = &userID
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		return seedPortfolio(tx, s.cashService, &portfolio, portfolioSeed{
+			positions:       positions,
+			cashBalance:     template.CashBalance,
+			cashDescription: "Opening balance from template " + template.Name,
+			thresholds:      thresholds,
+			guideline:       guideline,
+		}, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &portfolio, nil
+}
+
+// portfolioSeed is what a new portfolio starts with when created from a template or a clone
+type portfolioSeed struct {
+	positions       []models.Position
+	cashBalance     decimal.Decimal
+	cashDescription string
+	thresholds      *models.RiskThresholds
+	guideline       *models.InvestmentGuideline
+	targets         []models.TargetAllocation
+}
+
+// seedPortfolio creates a portfolio with its positions, weighted by market value, cash balance,
+// risk thresholds, guideline and target allocations
+func seedPortfolio(tx *gorm.DB, cashService *CashService, portfolio *models.Portfolio, seed portfolioSeed, userID uuid.UUID) error {
+	totalValue := decimal.Zero
+	for _, position := range seed.positions {
+		totalValue = totalValue.Add(position.MarketValue)
+	}
+	portfolio.TotalValue = totalValue
+	portfolio.CashBalance = decimal.Zero
+	if err := tx.Create(portfolio).Error; err != nil {
+		return err
+	}
+
+	for i := range seed.positions {
+		position := &seed.positions[i]
+		position.ID = uuid.Nil
+		position.PortfolioID = portfolio.ID
+		position.UpdatedAt = time.Time{}
+		position.Weight = decimal.Zero
+		if totalValue.IsPositive() {
+			position.Weight = position.MarketValue.Div(totalValue).Mul(hundred).Round(4)
+		}
+		if err := tx.Create(position).Error; err != nil {
+			return err
+		}
+	}
+	portfolio.Positions = seed.positions
+
+	if err := cashService.Adjust(tx, portfolio, seed.cashBalance, seed.cashDescription, &userID); err != nil {
+		return err
+	}
+
+	// Every column is inserted so that a disabled stop loss rule or an inactive guideline is not
+	// replaced by the column default
+	if seed.thresholds != nil {
+		seed.thresholds.ID = uuid.Nil
+		seed.thresholds.PortfolioID = portfolio.ID
+		seed.thresholds.Portfolio = models.Portfolio{}
+		seed.thresholds.CreatedAt, seed.thresholds.UpdatedAt = time.Time{}, time.Time{}
+		if err := tx.Select("*").Omit("Portfolio").Create(seed.thresholds).Error; err != nil {
+			return err
+		}
+	}
+	if seed.guideline != nil {
+		seed.guideline.ID = uuid.Nil
+		seed.guideline.PortfolioID = portfolio.ID
+		seed.guideline.CreatedAt, seed.guideline.UpdatedAt = time.Time{}, time.Time{}
+		if err := tx.Select("*").Create(seed.guideline).Error; err != nil {
+			return err
+		}
+	}
+	for _, target := range seed.targets {
+		target.ID = uuid.Nil
+		target.PortfolioID = portfolio.ID
+		target.CreatedAt, target.UpdatedAt = time.Time{}, time.Time{}
+		target.UpdatedBy = &userID
+		if err := tx.Create(&target).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/screening"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// Pre-trade checks
+const (
+	PreTradeCheckKYC            = "KYC"
+	PreTradeCheckSanctions      = "SANCTIONS"
+	PreTradeCheckRestrictedList = "RESTRICTED_LIST"
+	PreTradeCheckWatchList      = "WATCH_LIST"
+	PreTradeCheckPositionLimits = "POSITION_LIMITS"
+	PreTradeCheckGuidelines     = "INVESTMENT_GUIDELINES"
+)
+
+// Pre-trade check outcomes
+const (
+	PreTradeStatusPassed  = "PASSED"
+	PreTradeStatusWarning = "WARNING" // Only non-blocking reasons
+	PreTradeStatusFailed  = "FAILED"
+	PreTradeStatusSkipped = "SKIPPED" // Not applicable to the order
+)
+
+// PreTradeReason is one finding of a check. A blocking reason fails the order; the others should
+// be reviewed but do not stop it.
+type PreTradeReason struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Blocking bool   `json:"blocking"`
+}
+
+// PreTradeCheck is the outcome of one check with its findings
+type PreTradeCheck struct {
+	Check   string           `json:"check"`
+	Status  string           `json:"status"`
+	Reasons []PreTradeReason `json:"reasons"`
+}
+
+func (c *PreTradeCheck) add(code, message string, blocking bool) {
+	c.Reasons = append(c.Reasons, PreTradeReason{Code: code, Message: message, Blocking: blocking})
+	switch {
+	case blocking:
+		c.Status = PreTradeStatusFailed
+	case c.Status != PreTradeStatusFailed:
+		c.Status = PreTradeStatusWarning
+	}
+}
+
+// PreTradeResult is the outcome of the pre-trade compliance checks on a proposed order. Passed is
+// false when any check failed.
+type PreTradeResult struct {
+	Passed      bool            `json:"passed"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Side        string          `json:"side"`
+	Symbol      string          `json:"symbol"`
+	Checks      []PreTradeCheck `json:"checks"`
+	CheckedAt   time.Time       `json:"checked_at"`
+}
+
+// counterpartyFlagMessages describes the counterparty check flags; the blocking ones fail an order
+var counterpartyFlagMessages = map[string]struct {
+	message  string
+	blocking bool
+}{
+	"COUNTERPARTY_PROHIBITED":     {"Counterparty is rated prohibited", true},
+	"COUNTERPARTY_INACTIVE":       {"Counterparty is deactivated", true},
+	"COUNTERPARTY_KYC_REJECTED":   {"Counterparty KYC was rejected", true},
+	"COUNTERPARTY_KYC_EXPIRED":    {"Counterparty KYC has expired", false},
+	"COUNTERPARTY_KYC_PENDING":    {"Counterparty KYC is not verified", false},
+	"COUNTERPARTY_HIGH_RISK":      {"Counterparty is rated high risk", false},
+	"HIGH_RISK_JURISDICTION":      {"Counterparty is in a high risk jurisdiction", false},
+	"COUNTERPARTY_EXPOSURE_LIMIT": {"Order would take the counterparty over its exposure limit", true},
+}
+
+// PreTradeService runs the compliance checks an order must pass before it is routed. Checks record
+// nothing but the sanctions screenings, which are kept for audit without a transaction.
+type PreTradeService struct {
+	screener            *screening.Screener
+	counterpartyService *CounterpartyService
+	riskEngine          *RiskEngineService
+	symbolListService   *SymbolListService
+	guidelineService    *InvestmentGuidelineService
+	kycService          *KYCProfileService
+	logger              *slog.Logger
+}
+
+func NewPreTradeService() *PreTradeService {
+	return &PreTradeService{
+		screener:            screening.GetScreener(),
+		counterpartyService: NewCounterpartyService(),
+		riskEngine:          NewRiskEngineService(),
+		symbolListService:   NewSymbolListService(),
+		guidelineService:    NewInvestmentGuidelineService(),
+		kycService:          NewKYCProfileService(),
+		logger:              logging.Component("pre_trade"),
+	}
+}
+
+// CheckOrder runs the KYC, sanctions, restricted and watch list, position limit and investment
+// guideline checks on a proposed BUY or SELL order, given as a transaction that has not been stored
+func (s *PreTradeService) CheckOrder(ctx context.Context, order *models.Transaction, checkedBy uuid.UUID) (*PreTradeResult, error) {
+	if !isOrder(order) {
+		return nil, errors.New("only BUY and SELL orders can be checked")
+	}
+
+	kyc, err := s.checkKYC(order)
+	if err != nil {
+		return nil, err
+	}
+	sanctions, err := s.checkSanctions(order, checkedBy)
+	if err != nil {
+		return nil, err
+	}
+	restricted, watched, err := s.checkSymbolLists(order)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := s.checkPositionLimits(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	guidelines, err := s.checkGuidelines(order)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PreTradeResult{
+		Passed:      true,
+		PortfolioID: order.PortfolioID,
+		Side:        order.TransactionType,
+		Symbol:      order.Symbol,
+		Checks:      []PreTradeCheck{*kyc, *sanctions, *restricted, *watched, *limits, *guidelines},
+		CheckedAt:   time.Now(),
+	}
+	for _, check := range result.Checks {
+		if check.Status == PreTradeStatusFailed {
+			result.Passed = false
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Pre-trade check", "portfolio_id", order.PortfolioID, "symbol", order.Symbol,
+		"side", order.TransactionType, "passed", result.Passed)
+	return result, nil
+}
+
+func newPreTradeCheck(name string) *PreTradeCheck {
+	return &PreTradeCheck{Check: name, Status: PreTradeStatusPassed, Reasons: []PreTradeReason{}}
+}
+
+// checkKYC fails orders whose portfolio owner or counterparty has a KYC profile that is not
+// current, then checks the status, KYC and exposure limit of the order's counterparty and copies
+// its name and jurisdiction onto the order for screening
+func (s *PreTradeService) checkKYC(order *models.Transaction) (*PreTradeCheck, error) {
+	check := newPreTradeCheck(PreTradeCheckKYC)
+
+	blocks, err := s.kycService.Check(order)
+	if err != nil {
+		return nil, err
+	}
+	for _, blocked := range blocks {
+		check.add("KYC_PROFILE_"+blocked.Status+":"+blocked.SubjectType, blocked.Error(), true)
+	}
+	if order.CounterpartyID == nil {
+		return check, nil
+	}
+
+	counterparty, err := s.counterpartyService.GetCounterparty(*order.CounterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	order.CounterpartyName = counterparty.Name
+	order.CounterpartyCountry = counterparty.Jurisdiction
+
+	result, err := s.counterpartyService.CheckTransaction(order)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range result.Flags {
+		described, ok := counterpartyFlagMessages[flag]
+		if !ok {
+			described.message = flag
+		}
+		check.add(flag, described.message, described.blocking)
+	}
+	return check, nil
+}
+
+// checkSanctions screens the portfolio owner and the counterparty. A sanctions match fails the
+// order; potential and PEP matches are left for review.
+func (s *PreTradeService) checkSanctions(order *models.Transaction, checkedBy uuid.UUID) (*PreTradeCheck, error) {
+	check := newPreTradeCheck(PreTradeCheckSanctions)
+
+	results, err := s.screener.ScreenTransaction(order, &checkedBy)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		subject := strings.ToLower(result.SubjectType)
+		switch result.Status {
+		case screening.StatusMatch:
+			check.add("SANCTIONS_MATCH:"+result.SubjectType,
+				fmt.Sprintf("The %s %q matches a sanctions list entry", subject, result.SubjectName), true)
+		case screening.StatusPotentialMatch:
+			check.add("SANCTIONS_POTENTIAL_MATCH:"+result.SubjectType,
+				fmt.Sprintf("The %s %q may match a sanctions or PEP list entry", subject, result.SubjectName), false)
+		}
+		if result.PEPHit {
+			check.add("PEP:"+result.SubjectType,
+				fmt.Sprintf("The %s %q is a politically exposed person", subject, result.SubjectName), false)
+		}
+	}
+	return check, nil
+}
+
+// checkSymbolLists fails orders in a symbol on the restricted list and notes those on the watch
+// list, which are alerted on once the trade is recorded
+func (s *PreTradeService) checkSymbolLists(order *models.Transaction) (*PreTradeCheck, *PreTradeCheck, error) {
+	restrictedCheck := newPreTradeCheck(PreTradeCheckRestrictedList)
+	watchCheck := newPreTradeCheck(PreTradeCheckWatchList)
+
+	restricted, watched, err := s.symbolListService.Lookup(order.Symbol, order.TransactionType, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range restricted {
+		restrictedCheck.add("RESTRICTED_INSTRUMENT", fmt.Sprintf("%s is on the restricted list: %s", entry.Symbol, entry.Reason), true)
+	}
+	for _, entry := range watched {
+		watchCheck.add("WATCH_LIST", fmt.Sprintf("%s is on the watch list: %s", entry.Symbol, entry.Reason), false)
+	}
+	return restrictedCheck, watchCheck, nil
+}
+
+// checkPositionLimits runs the risk engine's pre-trade checks. Limit breaches fail the order;
+// warnings, such as a missing stop loss, do not.
+func (s *PreTradeService) checkPositionLimits(ctx context.Context, order *models.Transaction) (*PreTradeCheck, error) {
+	check := newPreTradeCheck(PreTradeCheckPositionLimits)
+
+	analysis, err := s.riskEngine.AssessTrade(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	for _, violation := range analysis.Violations {
+		check.add(violation.Type, violation.Description, violation.Severity != "WARNING")
+	}
+	return check, nil
+}
+
+// checkGuidelines fails orders that would breach the portfolio's investment guideline. Portfolios
+// without an active guideline skip it.
+func (s *PreTradeService) checkGuidelines(order *models.Transaction) (*PreTradeCheck, error) {
+	check := newPreTradeCheck(PreTradeCheckGuidelines)
+
+	guideline, err := s.guidelineService.activeGuideline(order.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if guideline == nil {
+		check.Status = PreTradeStatusSkipped
+		return check, nil
+	}
+
+	breaches, err := s.guidelineService.CheckTrade(order)
+	if err != nil {
+		return nil, err
+	}
+	for _, breach := range breaches {
+		check.add(breach.Guideline, breach.Description, true)
+	}
+	return check, nil
+}
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/reconciliation"
+)
+
+// reconciliationAlertSource is used to avoid raising another break alert for a portfolio while
+// one is still active
+const reconciliationAlertSource = "RECONCILIATION"
+
+// ReconciliationService compares custodian position files with the positions of the portfolios
+// they cover, records the breaks and raises an alert for each portfolio with unexplained ones
+type ReconciliationService struct {
+	db           *gorm.DB
+	cfg          *config.ReconciliationConfig
+	alertService *AlertService
+	logger       *slog.Logger
+}
+
+func NewReconciliationService(cfg *config.ReconciliationConfig) *ReconciliationService {
+	return &ReconciliationService{
+		db:           database.GetDB(),
+		cfg:          cfg,
+		alertService: NewAlertService(),
+		logger:       logging.Component("reconciliation"),
+	}
+}
+
+// ReconcileRequest describes where a custodian position file came from
+type ReconcileRequest struct {
+	Source    string // upload, http, inbox
+	FileName  string
+	AsOf      time.Time // Date the custodian's positions are as of; today when zero
+	CreatedBy *uuid.UUID
+}
+
+// UpdateBreakRequest explains a break or marks it resolved
+type UpdateBreakRequest struct {
+	Status      string `json:"status" validate:"required,oneof=OPEN EXPLAINED RESOLVED"`
+	Explanation string `json:"explanation" validate:"max=2000"` // Required to explain or resolve
+}
+
+// ReconcileFile reads a CSV position file and reconciles it. A file that cannot be read is
+// recorded as a failed run.
+func (s *ReconciliationService) ReconcileFile(ctx context.Context, r io.Reader, req ReconcileRequest) (*models.ReconciliationRun, error) {
+	holdings, err := reconciliation.ParseCSV(r)
+	if err != nil {
+		return s.failRun(ctx, req, err)
+	}
+	return s.Reconcile(ctx, holdings, req)
+}
+
+// Reconcile compares the custodian's holdings with our positions in each portfolio they name. Only
+// those portfolios are compared; holdings of accounts no portfolio is mapped to are skipped and
+// listed in the run's message. A break the portfolio's previous run had already explained, with
+// the same quantity difference, is carried over as explained. Only database failures are returned
+// as errors; problems with the file fail the run.
+func (s *ReconciliationService) Reconcile(ctx context.Context, holdings []reconciliation.Holding, req ReconcileRequest) (*models.ReconciliationRun, error) {
+	byPortfolio, skipped, fileErr, err := s.groupHoldings(holdings)
+	if err != nil {
+		return nil, err
+	}
+	if fileErr != nil {
+		return s.failRun(ctx, req, fileErr)
+	}
+
+	run := newReconciliationRun(req, models.ReconciliationStatusCompleted)
+	run.Positions = len(holdings)
+	run.PortfolioIDs = make([]uuid.UUID, 0, len(byPortfolio))
+	for portfolioID := range byPortfolio {
+		run.PortfolioIDs = append(run.PortfolioIDs, portfolioID)
+	}
+	slices.SortFunc(run.PortfolioIDs, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	run.Portfolios = len(run.PortfolioIDs)
+	if len(skipped) > 0 {
+		run.Message = "Skipped positions of unknown accounts: " + strings.Join(skipped, ", ")
+	}
+
+	tolerances := reconciliation.Tolerances{
+		Quantity: decimal.NewFromFloat(s.cfg.QuantityTolerance),
+		Price:    decimal.NewFromFloat(s.cfg.PriceTolerance),
+	}
+	unexplained := make(map[uuid.UUID][]models.ReconciliationBreak)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+
+		for _, portfolioID := range run.PortfolioIDs {
+			var positions []models.Position
+			if err := tx.Where("portfolio_id = ?", portfolioID).Find(&positions).Error; err != nil {
+				return err
+			}
+
+			matched, breaks := reconciliation.Compare(positions, byPortfolio[portfolioID], tolerances)
+			run.Matched += matched
+			if len(breaks) == 0 {
+				continue
+			}
+
+			for i := range breaks {
+				breaks[i].RunID = run.ID
+				breaks[i].PortfolioID = portfolioID
+				breaks[i].Status = models.BreakStatusOpen
+			}
+			if err := s.carryExplanations(tx, run, portfolioID, breaks); err != nil {
+				return err
+			}
+			if err := tx.Create(&breaks).Error; err != nil {
+				return err
+			}
+
+			run.Breaks += len(breaks)
+			for _, b := range breaks {
+				if b.Status == models.BreakStatusOpen {
+					unexplained[portfolioID] = append(unexplained[portfolioID], b)
+				}
+			}
+		}
+
+		for _, breaks := range unexplained {
+			run.Unexplained += len(breaks)
+		}
+		return tx.Save(run).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, portfolioID := range run.PortfolioIDs {
+		if breaks := unexplained[portfolioID]; len(breaks) > 0 {
+			s.raiseAlert(ctx, run, portfolioID, breaks)
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Reconciliation finished", "run_id", run.ID, "source", run.Source, "file", run.FileName,
+		"portfolios", run.Portfolios, "positions", run.Positions, "matched", run.Matched,
+		"breaks", run.Breaks, "unexplained", run.Unexplained)
+	return run, nil
+}
+
+func newReconciliationRun(req ReconcileRequest, status string) *models.ReconciliationRun {
+	asOf := req.AsOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	asOf = asOf.UTC()
+	return &models.ReconciliationRun{
+		Source:       req.Source,
+		FileName:     req.FileName,
+		AsOf:         time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC),
+		Status:       status,
+		PortfolioIDs: []uuid.UUID{},
+		CreatedBy:    req.CreatedBy,
+	}
+}
+
+// failRun records a run whose file was rejected
+func (s *ReconciliationService) failRun(ctx context.Context, req ReconcileRequest, cause error) (*models.ReconciliationRun, error) {
+	run := newReconciliationRun(req, models.ReconciliationStatusFailed)
+	run.Message = cause.Error()
+	if err := s.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, err
+	}
+	s.logger.WarnContext(ctx, "Reconciliation file rejected", "run_id", run.ID, "source", run.Source, "file", run.FileName, "error", cause)
+	return run, nil
+}
+
+// groupHoldings resolves the portfolio of each holding by ID or custodian account. An unknown
+// portfolio ID or an account set on several portfolios rejects the file; holdings of accounts no
+// portfolio is mapped to are left out and their accounts returned as skipped.
+func (s *ReconciliationService) groupHoldings(holdings []reconciliation.Holding) (byPortfolio map[uuid.UUID][]reconciliation.Holding, skipped []string, fileErr, err error) {
+	ids := make(map[uuid.UUID]bool)
+	accounts := make(map[string]bool)
+	for _, holding := range holdings {
+		if holding.PortfolioID == "" {
+			accounts[holding.Account] = true
+			continue
+		}
+		id, err := uuid.Parse(holding.PortfolioID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: invalid portfolio_id %q", holding.Line, holding.PortfolioID), nil
+		}
+		ids[id] = true
+	}
+
+	var portfolios []models.Portfolio
+	query := s.db.Select("id", "custodian_account").Where("id IN ?", mapKeys(ids))
+	if len(accounts) > 0 {
+		query = query.Or("custodian_account IN ?", mapKeys(accounts))
+	}
+	if err := query.Find(&portfolios).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	known := make(map[uuid.UUID]bool, len(portfolios))
+	byAccount := make(map[string]uuid.UUID, len(portfolios))
+	for _, portfolio := range portfolios {
+		known[portfolio.ID] = true
+		if !accounts[portfolio.CustodianAccount] {
+			continue
+		}
+		if _, ok := byAccount[portfolio.CustodianAccount]; ok {
+			return nil, nil, fmt.Errorf("account %s is set on several portfolios", portfolio.CustodianAccount), nil
+		}
+		byAccount[portfolio.CustodianAccount] = portfolio.ID
+	}
+
+	byPortfolio = make(map[uuid.UUID][]reconciliation.Holding)
+	unknown := make(map[string]bool)
+	for _, holding := range holdings {
+		var portfolioID uuid.UUID
+		if holding.PortfolioID != "" {
+			portfolioID = uuid.MustParse(holding.PortfolioID)
+			if !known[portfolioID] {
+				return nil, nil, fmt.Errorf("line %d: portfolio %s not found", holding.Line, holding.PortfolioID), nil
+			}
+		} else {
+			var ok bool
+			if portfolioID, ok = byAccount[holding.Account]; !ok {
+				unknown[holding.Account] = true
+				continue
+			}
+		}
+		byPortfolio[portfolioID] = append(byPortfolio[portfolioID], holding)
+	}
+
+	skipped = mapKeys(unknown)
+	slices.Sort(skipped)
+	return byPortfolio, skipped, nil, nil
+}
+
+func mapKeys[K comparable](m map[K]bool) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// carryExplanations marks breaks explained when the portfolio's previous completed run found the
+// same break, by symbol, type and quantity difference, and it had been explained. A difference
+// explained once, such as a trade settling over several days, is not reported again each day.
+func (s *ReconciliationService) carryExplanations(tx *gorm.DB, run *models.ReconciliationRun, portfolioID uuid.UUID, breaks []models.ReconciliationBreak) error {
+	covered, err := json.Marshal([]uuid.UUID{portfolioID})
+	if err != nil {
+		return err
+	}
+
+	var previous models.ReconciliationRun
+	err = tx.Select("id").
+		Where("id <> ? AND status = ? AND portfolio_ids @> ?::jsonb", run.ID, models.ReconciliationStatusCompleted, string(covered)).
+		Order("as_of DESC, created_at DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var explained []models.ReconciliationBreak
+	err = tx.Where("run_id = ? AND portfolio_id = ? AND status = ?", previous.ID, portfolioID, models.BreakStatusExplained).
+		Find(&explained).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range breaks {
+		for _, prior := range explained {
+			if prior.Symbol == breaks[i].Symbol && prior.BreakType == breaks[i].BreakType &&
+				prior.QuantityDifference.Equal(breaks[i].QuantityDifference) {
+				breaks[i].Status = models.BreakStatusExplained
+				breaks[i].Explanation = prior.Explanation
+				breaks[i].ExplainedBy = prior.ExplainedBy
+				breaks[i].ExplainedAt = prior.ExplainedAt
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// raiseAlert raises one alert listing a portfolio's unexplained breaks, HIGH when a quantity is
+// off and MEDIUM for price differences alone
+func (s *ReconciliationService) raiseAlert(ctx context.Context, run *models.ReconciliationRun, portfolioID uuid.UUID, breaks []models.ReconciliationBreak) {
+	if s.hasActiveAlert(portfolioID) {
+		return
+	}
+
+	severity := "MEDIUM"
+	symbols := make([]string, 0, len(breaks))
+	for _, b := range breaks {
+		if b.BreakType != models.BreakTypePrice {
+			severity = "HIGH"
+		}
+		if !slices.Contains(symbols, b.Symbol) {
+			symbols = append(symbols, b.Symbol)
+		}
+	}
+
+	alert := &models.Alert{
+		PortfolioID: portfolioID,
+		AlertType:   "COMPLIANCE_VIOLATION",
+		Severity:    severity,
+		Title:       "Unexplained Reconciliation Breaks",
+		Description: fmt.Sprintf("%d positions differ from the custodian's as of %s: %s",
+			len(breaks), run.AsOf.Format("2006-01-02"), strings.Join(symbols, ", ")),
+		Source: reconciliationAlertSource,
+		Status: "ACTIVE",
+		TriggeredBy: models.JSON{
+			"run_id":  run.ID,
+			"breaks":  len(breaks),
+			"symbols": symbols,
+		},
+	}
+	if err := s.alertService.CreateAlert(ctx, alert); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create reconciliation alert", "portfolio_id", portfolioID, "error", err)
+	}
+}
+
+func (s *ReconciliationService) hasActiveAlert(portfolioID uuid.UUID) bool {
+	var count int64
+	s.db.Model(&models.Alert{}).
+		Where("portfolio_id = ? AND source = ? AND status = ?", portfolioID, reconciliationAlertSource, "ACTIVE").
+		Count(&count)
+	return count > 0
+}
+
+// ListRuns returns a page of runs and the total match count
+func (s *ReconciliationService) ListRuns(spec pagination.Spec, params pagination.Params) ([]models.ReconciliationRun, int64, error) {
+	var runs []models.ReconciliationRun
+	total, err := pagination.Find(s.db.Model(&models.ReconciliationRun{}), spec, params, &runs)
+	return runs, total, err
+}
+
+// GetRun returns a run
+func (s *ReconciliationService) GetRun(runID uuid.UUID) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	if err := s.db.First(&run, "id = ?", runID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("reconciliation run not found")
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListBreaks returns a page of breaks and the total match count
+func (s *ReconciliationService) ListBreaks(spec pagination.Spec, params pagination.Params) ([]models.ReconciliationBreak, int64, error) {
+	var breaks []models.ReconciliationBreak
+	total, err := pagination.Find(s.db.Model(&models.ReconciliationBreak{}), spec, params, &breaks)
+	return breaks, total, err
+}
+
+// UpdateBreak explains, resolves or reopens a break. Explaining or resolving it needs an
+// explanation; reopening clears it.
+func (s *ReconciliationService) UpdateBreak(breakID, userID uuid.UUID, req UpdateBreakRequest) (*models.ReconciliationBreak, error) {
+	var b models.ReconciliationBreak
+	if err := s.db.First(&b, "id = ?", breakID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("reconciliation break not found")
+		}
+		return nil, err
+	}
+
+	explanation := strings.TrimSpace(req.Explanation)
+	if req.Status == models.BreakStatusOpen {
+		b.Status, b.Explanation, b.ExplainedBy, b.ExplainedAt = req.Status, "", nil, nil
+	} else {
+		if explanation == "" {
+			return nil, apperror.BadRequest("explanation is required")
+		}
+		now := time.Now()
+		b.Status, b.Explanation, b.ExplainedBy, b.ExplainedAt = req.Status, explanation, &userID, &now
+	}
+
+	if err := s.db.Save(&b).Error; err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// StartFetcher reconciles the custodian's positions fetched from the feed every day at hour (UTC),
+// as of the previous day, until ctx is cancelled
+func (s *ReconciliationService) StartFetcher(ctx context.Context, feed *reconciliation.HTTPFeed, hour int) {
+	for {
+		if !sleepUntil(ctx, nextDailyRun(time.Now(), hour)) {
+			return
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		req := ReconcileRequest{
+			Source:   models.ReconciliationSourceHTTP,
+			FileName: s.cfg.URL,
+			AsOf:     time.Now().UTC().AddDate(0, 0, -1),
+		}
+		holdings, err := feed.Fetch(ctx, req.AsOf)
+		if err != nil {
+			_, err = s.failRun(ctx, req, err)
+		} else {
+			_, err = s.Reconcile(ctx, holdings, req)
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Custodian reconciliation failed", "error", err)
+		}
+	}
+}
+
+// StartInboxWatcher reconciles the files delivered to the inbox every interval until ctx is
+// cancelled. Files are reconciled as of the day they were picked up.
+func (s *ReconciliationService) StartInboxWatcher(ctx context.Context, inbox *reconciliation.Inbox, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Reconciliation inbox watcher disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		paths, err := inbox.Pending()
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to list the reconciliation inbox", "error", err)
+			continue
+		}
+
+		for _, path := range paths {
+			run, err := s.reconcileInboxFile(ctx, path)
+			if err != nil {
+				// Left in the inbox to be retried on the next poll
+				s.logger.ErrorContext(ctx, "Inbox reconciliation failed", "file", path, "error", err)
+				continue
+			}
+			if err := inbox.Done(path, run.Status == models.ReconciliationStatusFailed); err != nil {
+				s.logger.ErrorContext(ctx, "Failed to move reconciled file out of the inbox", "file", path, "error", err)
+			}
+		}
+	}
+}
+
+func (s *ReconciliationService) reconcileInboxFile(ctx context.Context, path string) (*models.ReconciliationRun, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return s.ReconcileFile(ctx, file, ReconcileRequest{
+		Source:   models.ReconciliationSourceInbox,
+		FileName: filepath.Base(path),
+	})
+}
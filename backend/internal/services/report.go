@@ -0,0 +1,396 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/reports"
+)
+
+// flaggedRiskScore is the transaction risk score from which a trade is listed in compliance reports
+const flaggedRiskScore = 50
+
+var reportTitles = map[string]string{
+	models.ReportTypeDailyRisk:         "Daily Risk Report",
+	models.ReportTypeComplianceSummary: "Compliance Summary",
+	models.ReportTypeAlertHistory:      "Alert History",
+}
+
+// GenerateReportRequest describes a report to render for one portfolio
+type GenerateReportRequest struct {
+	PortfolioID uuid.UUID
+	ReportType  string
+	Format      string
+	From        *time.Time
+	To          *time.Time
+	GeneratedBy *uuid.UUID
+}
+
+type ReportService struct {
+	db            *gorm.DB
+	accessService *AccessService
+	riskEngine    *RiskEngineService
+}
+
+func NewReportService() *ReportService {
+	return &ReportService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+		riskEngine:    NewRiskEngineService(),
+	}
+}
+
+// ReportPeriod returns the default reporting window for a report type ending at now
+func ReportPeriod(reportType string, now time.Time) (time.Time, time.Time) {
+	if reportType == models.ReportTypeDailyRisk {
+		return now.Add(-24 * time.Hour), now
+	}
+	return now.AddDate(0, 0, -30), now
+}
+
+// GenerateReport renders a report, stores it and returns the stored record
+func (s *ReportService) GenerateReport(req GenerateReportRequest) (*models.Report, error) {
+	title, ok := reportTitles[req.ReportType]
+	if !ok {
+		return nil, errors.New("unsupported report type, use DAILY_RISK, COMPLIANCE_SUMMARY or ALERT_HISTORY")
+	}
+
+	var portfolio models.Portfolio
+	if err := s.db.Preload("Positions").First(&portfolio, req.PortfolioID).Error; err != nil {
+		return nil, errors.New("portfolio not found")
+	}
+
+	now := time.Now()
+	from, to := ReportPeriod(req.ReportType, now)
+	if req.From != nil {
+		from = *req.From
+	}
+	if req.To != nil {
+		to = *req.To
+	}
+	if !from.Before(to) {
+		return nil, errors.New("report period start must be before its end")
+	}
+
+	doc := &reports.Document{
+		Title:       fmt.Sprintf("%s - %s", title, portfolio.Name),
+		Subtitle:    fmt.Sprintf("Portfolio %s, period %s to %s", portfolio.ID, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339)),
+		GeneratedAt: now,
+	}
+
+	var err error
+	switch req.ReportType {
+	case models.ReportTypeDailyRisk:
+		err = s.buildDailyRisk(doc, &portfolio, from, to)
+	case models.ReportTypeComplianceSummary:
+		err = s.buildComplianceSummary(doc, &portfolio, from, to)
+	case models.ReportTypeAlertHistory:
+		err = s.buildAlertHistory(doc, &portfolio, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, contentType, err := reports.Render(doc, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(content)
+	report := &models.Report{
+		PortfolioID: portfolio.ID,
+		ReportType:  req.ReportType,
+		Format:      req.Format,
+		Title:       doc.Title,
+		FileName:    fmt.Sprintf("%s-%s-%s.%s", strings.ToLower(strings.ReplaceAll(req.ReportType, "_", "-")), portfolio.ID.String()[:8], now.UTC().Format("20060102-150405"), req.Format),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(digest[:]),
+		PeriodFrom:  from,
+		PeriodTo:    to,
+		GeneratedBy: req.GeneratedBy,
+		Content:     content,
+	}
+	if err := s.db.Create(report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns a page of stored reports for portfolios the user can see, without content
+func (s *ReportService) ListReports(userID uuid.UUID, role string, spec pagination.Spec, params pagination.Params) ([]models.Report, int64, error) {
+	query := s.accessService.ScopeQuery(s.db.Model(&models.Report{}).Omit("content"), "portfolio_id", userID, role)
+
+	var reportList []models.Report
+	total, err := pagination.Find(query, spec, params, &reportList)
+	return reportList, total, err
+}
+
+// GetReport returns a stored report; content is only loaded when withContent is set
+func (s *ReportService) GetReport(reportID uuid.UUID, withContent bool) (*models.Report, error) {
+	query := s.db
+	if !withContent {
+		query = query.Omit("content")
+	}
+
+	var report models.Report
+	if err := query.First(&report, reportID).Error; err != nil {
+		return nil, errors.New("report not found")
+	}
+	return &report, nil
+}
+
+func (s *ReportService) buildDailyRisk(doc *reports.Document, portfolio *models.Portfolio, from, to time.Time) error {
+	summary := doc.AddSection("Portfolio summary")
+	summary.AddFact("Name", portfolio.Name)
+	summary.AddFact("Currency", portfolio.Currency)
+	summary.AddFact("Total value", portfolio.TotalValue.StringFixed(2))
+	summary.AddFact("Positions", fmt.Sprint(len(portfolio.Positions)))
+
+	totalPnL := decimal.Zero
+	positions := &reports.Table{Headers: []string{"Symbol", "Asset type", "Quantity", "Price", "Market value", "Weight %", "P&L", "Liquidity"}}
+	for _, p := range portfolio.Positions {
+		totalPnL = totalPnL.Add(p.PnL)
+		positions.Rows = append(positions.Rows, []string{
+			p.Symbol, p.AssetType, p.Quantity.String(), p.CurrentPrice.StringFixed(2),
+			p.MarketValue.StringFixed(2), p.Weight.StringFixed(2), p.PnL.StringFixed(2), p.Liquidity,
+		})
+	}
+	summary.AddFact("Unrealized P&L", totalPnL.StringFixed(2))
+	doc.AddSection("Positions").Table = positions
+
+	var latest []models.RiskMetric
+	err := s.db.Raw(`SELECT DISTINCT ON (metric_type) * FROM risk_metrics
+		WHERE portfolio_id = ? AND calculated_at <= ?
+		ORDER BY metric_type, calculated_at DESC`, portfolio.ID, to).Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+	metrics := &reports.Table{Headers: []string{"Metric", "Value", "Threshold", "Status", "Calculated at"}}
+	for _, m := range latest {
+		metrics.Rows = append(metrics.Rows, []string{
+			m.MetricType, m.Value.StringFixed(4), m.Threshold.StringFixed(4), m.Status, m.CalculatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	section := doc.AddSection("Latest risk metrics")
+	if len(latest) == 0 {
+		section.Text = "No risk metrics have been calculated for this portfolio."
+	}
+	section.Table = metrics
+
+	var history []struct {
+		MetricType string
+		Samples    int64
+		MinValue   decimal.Decimal
+		MaxValue   decimal.Decimal
+		AvgValue   decimal.Decimal
+	}
+	err = s.db.Model(&models.RiskHistory{}).
+		Select("metric_type, COUNT(*) AS samples, MIN(value) AS min_value, MAX(value) AS max_value, AVG(value) AS avg_value").
+		Where("portfolio_id = ? AND recorded_at BETWEEN ? AND ?", portfolio.ID, from, to).
+		Group("metric_type").Order("metric_type").
+		Scan(&history).Error
+	if err != nil {
+		return err
+	}
+	trend := &reports.Table{Headers: []string{"Metric", "Samples", "Min", "Max", "Average"}}
+	for _, h := range history {
+		trend.Rows = append(trend.Rows, []string{h.MetricType, fmt.Sprint(h.Samples), h.MinValue.StringFixed(4), h.MaxValue.StringFixed(4), h.AvgValue.StringFixed(4)})
+	}
+	doc.AddSection("Risk history over the period").Table = trend
+
+	var snapshots []models.PortfolioSnapshot
+	err = s.db.Omit("positions").
+		Where("portfolio_id = ? AND date BETWEEN ? AND ?", portfolio.ID, from.UTC().Format("2006-01-02"), to.UTC().Format("2006-01-02")).
+		Order("date").Find(&snapshots).Error
+	if err != nil {
+		return err
+	}
+	nav := &reports.Table{Headers: []string{"Date", "NAV", "Market value", "Cash", "Margin loan"}}
+	for _, snapshot := range snapshots {
+		nav.Rows = append(nav.Rows, []string{
+			snapshot.Date.Format("2006-01-02"), snapshot.NAV.StringFixed(2), snapshot.MarketValue.StringFixed(2),
+			snapshot.CashBalance.StringFixed(2), snapshot.MarginLoan.StringFixed(2),
+		})
+	}
+	navSection := doc.AddSection("End-of-day NAV")
+	if len(snapshots) == 0 {
+		navSection.Text = "No end-of-day snapshots were recorded over the period."
+	}
+	navSection.Table = nav
+
+	if thresholds, err := s.riskEngine.GetThresholds(portfolio.ID); err == nil {
+		limits := doc.AddSection("Risk limits")
+		limits.AddFact("Max VaR 95", thresholds.MaxVaR95.StringFixed(2))
+		limits.AddFact("Max VaR 99", thresholds.MaxVaR99.StringFixed(2))
+		limits.AddFact("Max position size %", thresholds.MaxPositionSize.StringFixed(2))
+		limits.AddFact("Max sector exposure %", thresholds.MaxSectorExposure.StringFixed(2))
+		limits.AddFact("Min liquidity ratio", thresholds.MinLiquidityRatio.StringFixed(2))
+		limits.AddFact("Max daily loss %", thresholds.MaxDailyLoss.StringFixed(2))
+	}
+
+	return s.addAlertCounts(doc, portfolio.ID, from, to)
+}
+
+func (s *ReportService) buildComplianceSummary(doc *reports.Document, portfolio *models.Portfolio, from, to time.Time) error {
+	var totals struct {
+		Total          int64
+		AMLChecked     int64
+		RequiresReview int64
+		Flagged        int64
+		Volume         decimal.Decimal
+	}
+	err := s.db.Model(&models.Transaction{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE aml_checked) AS aml_checked,
+			COUNT(*) FILTER (WHERE requires_review) AS requires_review,
+			COUNT(*) FILTER (WHERE risk_score >= ?) AS flagged,
+			COALESCE(SUM(ABS(amount)), 0) AS volume`, flaggedRiskScore).
+		Where("portfolio_id = ? AND created_at BETWEEN ? AND ?", portfolio.ID, from, to).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+
+	activity := doc.AddSection("Transaction activity")
+	activity.AddFact("Transactions", fmt.Sprint(totals.Total))
+	activity.AddFact("Gross volume", totals.Volume.StringFixed(2)+" "+portfolio.Currency)
+	activity.AddFact("AML checked", fmt.Sprint(totals.AMLChecked))
+	activity.AddFact("Not AML checked", fmt.Sprint(totals.Total-totals.AMLChecked))
+	activity.AddFact("Requiring review", fmt.Sprint(totals.RequiresReview))
+	activity.AddFact(fmt.Sprintf("Risk score >= %d", flaggedRiskScore), fmt.Sprint(totals.Flagged))
+
+	var flagged []models.Transaction
+	err = s.db.Where("portfolio_id = ? AND created_at BETWEEN ? AND ? AND (risk_score >= ? OR requires_review)", portfolio.ID, from, to, flaggedRiskScore).
+		Order("risk_score DESC, created_at DESC").Limit(200).Find(&flagged).Error
+	if err != nil {
+		return err
+	}
+	flaggedTable := &reports.Table{Headers: []string{"Date", "Type", "Symbol", "Amount", "Counterparty", "Country", "Risk score", "Status"}}
+	for _, t := range flagged {
+		flaggedTable.Rows = append(flaggedTable.Rows, []string{
+			t.CreatedAt.UTC().Format("2006-01-02 15:04"), t.TransactionType, t.Symbol, t.Amount.StringFixed(2),
+			t.CounterpartyName, t.CounterpartyCountry, fmt.Sprint(t.RiskScore), t.Status,
+		})
+	}
+	doc.AddSection("Flagged transactions").Table = flaggedTable
+
+	var screenings []models.ScreeningResult
+	err = s.db.Joins("JOIN transactions ON transactions.id = screening_results.transaction_id").
+		Where("transactions.portfolio_id = ? AND screening_results.screened_at BETWEEN ? AND ? AND screening_results.status <> ?", portfolio.ID, from, to, "CLEAR").
+		Order("screening_results.screened_at DESC").Find(&screenings).Error
+	if err != nil {
+		return err
+	}
+	screeningTable := &reports.Table{Headers: []string{"Screened at", "Subject", "Type", "Status", "Sanctions", "PEP", "Top score"}}
+	for _, r := range screenings {
+		screeningTable.Rows = append(screeningTable.Rows, []string{
+			r.ScreenedAt.UTC().Format("2006-01-02 15:04"), r.SubjectName, r.SubjectType, r.Status,
+			yesNo(r.SanctionsHit), yesNo(r.PEPHit), fmt.Sprintf("%.2f", r.TopScore),
+		})
+	}
+	section := doc.AddSection("Sanctions and PEP screening hits")
+	if len(screenings) == 0 {
+		section.Text = "No potential or confirmed matches in the period."
+	}
+	section.Table = screeningTable
+
+	var caseCounts []struct {
+		Status string
+		Count  int64
+	}
+	err = s.db.Model(&models.Case{}).
+		Select("cases.status, COUNT(DISTINCT cases.id) AS count").
+		Joins("JOIN case_transactions ON case_transactions.case_id = cases.id").
+		Joins("JOIN transactions ON transactions.id = case_transactions.transaction_id").
+		Where("transactions.portfolio_id = ? AND cases.created_at BETWEEN ? AND ?", portfolio.ID, from, to).
+		Group("cases.status").Scan(&caseCounts).Error
+	if err != nil {
+		return err
+	}
+	cases := doc.AddSection("Suspicious activity cases")
+	if len(caseCounts) == 0 {
+		cases.Text = "No cases were opened for this portfolio in the period."
+	}
+	for _, c := range caseCounts {
+		cases.AddFact(c.Status, fmt.Sprint(c.Count))
+	}
+
+	return s.addAlertCounts(doc, portfolio.ID, from, to)
+}
+
+func (s *ReportService) buildAlertHistory(doc *reports.Document, portfolio *models.Portfolio, from, to time.Time) error {
+	if err := s.addAlertCounts(doc, portfolio.ID, from, to); err != nil {
+		return err
+	}
+
+	var alerts []models.Alert
+	err := s.db.Where("portfolio_id = ? AND created_at BETWEEN ? AND ?", portfolio.ID, from, to).
+		Order("created_at DESC").Find(&alerts).Error
+	if err != nil {
+		return err
+	}
+
+	table := &reports.Table{Headers: []string{"Raised at", "Type", "Severity", "Title", "Status", "Resolved at", "Resolution"}}
+	for _, a := range alerts {
+		resolvedAt := ""
+		if a.ResolvedAt != nil {
+			resolvedAt = a.ResolvedAt.UTC().Format("2006-01-02 15:04")
+		}
+		table.Rows = append(table.Rows, []string{
+			a.CreatedAt.UTC().Format("2006-01-02 15:04"), a.AlertType, a.Severity, a.Title, a.Status, resolvedAt, a.Resolution,
+		})
+	}
+	section := doc.AddSection("Alerts")
+	if len(alerts) == 0 {
+		section.Text = "No alerts were raised in the period."
+	}
+	section.Table = table
+	return nil
+}
+
+// addAlertCounts adds a section counting the period's alerts by severity and status
+func (s *ReportService) addAlertCounts(doc *reports.Document, portfolioID uuid.UUID, from, to time.Time) error {
+	var counts []struct {
+		Severity string
+		Status   string
+		Count    int64
+	}
+	err := s.db.Model(&models.Alert{}).
+		Select("severity, status, COUNT(*) AS count").
+		Where("portfolio_id = ? AND created_at BETWEEN ? AND ?", portfolioID, from, to).
+		Group("severity, status").Order("severity, status").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+
+	table := &reports.Table{Headers: []string{"Severity", "Status", "Count"}}
+	var total int64
+	for _, c := range counts {
+		total += c.Count
+		table.Rows = append(table.Rows, []string{c.Severity, c.Status, fmt.Sprint(c.Count)})
+	}
+	section := doc.AddSection("Alert summary")
+	section.AddFact("Alerts raised", fmt.Sprint(total))
+	section.Table = table
+	return nil
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
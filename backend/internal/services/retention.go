@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// purgeOrder lists the soft deleted tables children first, so that a record is purged on its own
+// schedule rather than through its parent's foreign key cascade
+var purgeOrder = []struct {
+	table string
+	model interface{}
+}{
+	{"alerts", &models.Alert{}},
+	{"transactions", &models.Transaction{}},
+	{"positions", &models.Position{}},
+	{"portfolios", &models.Portfolio{}},
+}
+
+// retentionStatuses are the statuses after which records of an entity may be deleted under its
+// retention policy
+var retentionStatuses = map[string][]string{
+	models.RetentionAlerts:       {"RESOLVED", "DISMISSED"},
+	models.RetentionTransactions: {"COMPLETED", "FAILED", "CANCELLED"},
+}
+
+// RetentionPolicyRequest changes a retention policy
+type RetentionPolicyRequest struct {
+	RetentionDays int   `json:"retention_days" validate:"min=1"`
+	Enabled       *bool `json:"enabled"`
+}
+
+// RetentionService lists soft deleted records for restoring and purges them once they are older
+// than the retention period, and applies the per-entity retention policies: deleting old alerts,
+// transactions, audit entries and market depth snapshots and anonymizing closed user accounts
+type RetentionService struct {
+	db            *gorm.DB
+	retentionDays int
+	logger        *slog.Logger
+}
+
+func NewRetentionService(cfg *config.RetentionConfig) *RetentionService {
+	return &RetentionService{
+		db:            database.GetDB(),
+		retentionDays: cfg.Days,
+		logger:        logging.Component("retention"),
+	}
+}
+
+// listDeleted returns a page of the soft deleted records of a model and the total match count
+func listDeleted[T any](db *gorm.DB, spec pagination.Spec, params pagination.Params) ([]T, int64, error) {
+	var records []T
+	query := db.Unscoped().Model(new(T)).Where("deleted_at IS NOT NULL")
+	total, err := pagination.Find(query, spec, params, &records)
+	return records, total, err
+}
+
+// ListDeletedPortfolios returns a page of soft deleted portfolios
+func (s *RetentionService) ListDeletedPortfolios(spec pagination.Spec, params pagination.Params) ([]models.Portfolio, int64, error) {
+	return listDeleted[models.Portfolio](s.db, spec, params)
+}
+
+// ListDeletedTransactions returns a page of soft deleted transactions
+func (s *RetentionService) ListDeletedTransactions(spec pagination.Spec, params pagination.Params) ([]models.Transaction, int64, error) {
+	return listDeleted[models.Transaction](s.db, spec, params)
+}
+
+// ListDeletedAlerts returns a page of soft deleted alerts
+func (s *RetentionService) ListDeletedAlerts(spec pagination.Spec, params pagination.Params) ([]models.Alert, int64, error) {
+	return listDeleted[models.Alert](s.db, spec, params)
+}
+
+// StartPurgeJob purges expired soft deleted records and applies the retention policies at a fixed
+// interval until ctx is cancelled
+func (s *RetentionService) StartPurgeJob(ctx context.Context, interval time.Duration) {
+	if s.retentionDays <= 0 {
+		s.logger.Info("Soft deleted records are kept indefinitely; only retention policies apply")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.Purge(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Purge of soft deleted records failed", "error", err)
+		}
+		if _, err := s.ApplyPolicies(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Retention policies failed", "error", err)
+		}
+	}
+}
+
+// Purge permanently deletes the records soft deleted more than the retention period ago and
+// returns how many were removed from each table. Rows that reference a purged portfolio, such as
+// its cash ledger, go with it through their foreign keys.
+func (s *RetentionService) Purge(ctx context.Context) (map[string]int64, error) {
+	purged := make(map[string]int64, len(purgeOrder))
+	if s.retentionDays <= 0 {
+		return purged, nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -s.retentionDays)
+	for _, entry := range purgeOrder {
+		result := s.db.WithContext(ctx).Unscoped().Where("deleted_at < ?", cutoff).Delete(entry.model)
+		if result.Error != nil {
+			return purged, fmt.Errorf("purge %s: %w", entry.table, result.Error)
+		}
+		purged[entry.table] = result.RowsAffected
+	}
+
+	s.logger.InfoContext(ctx, "Purged soft deleted records", "cutoff", cutoff,
+		"alerts", purged["alerts"], "transactions", purged["transactions"],
+		"positions", purged["positions"], "portfolios", purged["portfolios"])
+	return purged, nil
+}
+
+// Policies returns the retention policies
+func (s *RetentionService) Policies() ([]models.RetentionPolicy, error) {
+	policies := []models.RetentionPolicy{}
+	err := s.db.Order("entity_type").Find(&policies).Error
+	return policies, err
+}
+
+// UpdatePolicy changes how long records of an entity are kept, and whether they are removed at all
+func (s *RetentionService) UpdatePolicy(entityType string, req RetentionPolicyRequest, userID uuid.UUID) (*models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	if err := s.db.First(&policy, "entity_type = ?", entityType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Retention policy not found")
+		}
+		return nil, err
+	}
+
+	policy.RetentionDays = req.RetentionDays
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	policy.UpdatedBy = &userID
+	if err := s.db.Select("*").Save(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ApplyPolicies deletes the alerts, transactions, audit entries and market depth snapshots past
+// their enabled retention policies and anonymizes the user accounts closed for longer than
+// theirs, returning how many records each policy removed or anonymized
+func (s *RetentionService) ApplyPolicies(ctx context.Context) (map[string]int64, error) {
+	policies, err := s.Policies()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]int64, len(policies))
+	db := s.db.WithContext(ctx)
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -policy.RetentionDays)
+
+		var count int64
+		switch policy.EntityType {
+		case models.RetentionAlerts:
+			result := db.Unscoped().
+				Where("created_at < ? AND status IN ?", cutoff, retentionStatuses[policy.EntityType]).
+				Delete(&models.Alert{})
+			count, err = result.RowsAffected, result.Error
+
+		case models.RetentionTransactions:
+			// Open lots still carry cost basis, case evidence is kept with the case, and amended
+			// transactions are kept with their amendment chain
+			result := db.Unscoped().
+				Where("created_at < ? AND status IN ?", cutoff, retentionStatuses[policy.EntityType]).
+				Where("superseded_at IS NULL AND amends_id IS NULL").
+				Where("NOT EXISTS (SELECT 1 FROM position_lots WHERE position_lots.transaction_id = transactions.id AND position_lots.remaining_quantity > 0)").
+				Where("NOT EXISTS (SELECT 1 FROM case_transactions WHERE case_transactions.transaction_id = transactions.id)").
+				Where(`NOT EXISTS (SELECT 1 FROM transaction_amendments
+					WHERE transaction_amendments.transaction_id = transactions.id OR transaction_amendments.replacement_id = transactions.id)`).
+				Delete(&models.Transaction{})
+			count, err = result.RowsAffected, result.Error
+
+		case models.RetentionAuditLogs:
+			err = db.Transaction(func(tx *gorm.DB) error {
+				// Lifts the append-only trigger for this transaction alone
+				if err := tx.Exec("SET LOCAL app.retention_purge = 'on'").Error; err != nil {
+					return err
+				}
+				result := tx.Exec("DELETE FROM audit_logs WHERE created_at < ?", cutoff)
+				count = result.RowsAffected
+				return result.Error
+			})
+
+		case models.RetentionClosedUsers:
+			count, err = s.anonymizeClosedUsers(db, cutoff)
+
+		case models.RetentionMarketDepth:
+			result := db.Where("captured_at < ?", cutoff).Delete(&models.MarketDepthSnapshot{})
+			count, err = result.RowsAffected, result.Error
+
+		default:
+			s.logger.WarnContext(ctx, "Unknown retention policy skipped", "entity_type", policy.EntityType)
+			continue
+		}
+		if err != nil {
+			return applied, fmt.Errorf("retention of %s: %w", policy.EntityType, err)
+		}
+		applied[policy.EntityType] = count
+	}
+
+	s.logger.InfoContext(ctx, "Applied retention policies",
+		"alerts", applied[models.RetentionAlerts], "transactions", applied[models.RetentionTransactions],
+		"audit_logs", applied[models.RetentionAuditLogs], "closed_users", applied[models.RetentionClosedUsers],
+		"market_depth_snapshots", applied[models.RetentionMarketDepth])
+	return applied, nil
+}
+
+// anonymizeClosedUsers anonymizes the accounts deactivated or deleted before the cutoff. An
+// account's last update stands for when it was deactivated.
+func (s *RetentionService) anonymizeClosedUsers(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var users []models.User
+	err := db.Unscoped().
+		Where("(is_active = ? OR deleted_at IS NOT NULL) AND anonymized_at IS NULL", false).
+		Where("COALESCE(deleted_at, updated_at) < ?", cutoff).
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range users {
+		if err := anonymizeUser(db, &users[i]); err != nil {
+			return int64(i), err
+		}
+	}
+	return int64(len(users)), nil
+}
+
+// anonymizeUser replaces a closed account's name and email with placeholders, so that records
+// referencing the user no longer identify them, and removes the personal data kept with it: its
+// sessions, with their IP addresses, its KYC profile, its notification preferences and its API
+// keys. Audit entries are kept unchanged until their own retention policy removes them.
+func anonymizeUser(db *gorm.DB, user *models.User) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(user).UpdateColumns(map[string]interface{}{
+			"email":         "anonymized-" + user.ID.String() + "@anonymized.invalid",
+			"first_name":    "Anonymized",
+			"last_name":     "User",
+			"password":      "!",
+			"is_active":     false,
+			"anonymized_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_type = ? AND subject_id = ?", models.KYCSubjectUser, user.ID).
+			Delete(&models.KYCProfile{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.APIKey{}).Error
+	})
+}
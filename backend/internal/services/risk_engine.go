@@ -4,34 +4,57 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
 	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
 )
 
 type RiskEngineService struct {
 	db            *gorm.DB
 	alertService  *AlertService
+	approvals     *TransactionApprovalService
 	varCalculator *calculator.VaRCalculator
-	liquidityCalc *calculator.LiquidityCalculator
+	logger        *slog.Logger
 }
 
 func NewRiskEngineService() *RiskEngineService {
 	return &RiskEngineService{
 		db:            database.GetDB(),
 		alertService:  NewAlertService(),
-		varCalculator: calculator.NewVaRCalculator(100000),    // Default portfolio value
-		liquidityCalc: calculator.NewLiquidityCalculator(nil), // Will need mock provider
+		approvals:     NewTransactionApprovalService(),
+		varCalculator: calculator.NewVaRCalculator(100000), // Default portfolio value
+		logger:        logging.Component("risk_engine"),
 	}
 }
 
+// liquidityCalculator analyses positions with market data estimated from their liquidity tiers,
+// and for crypto assets from the chain they settle on, and with the latest captured order books
+func liquidityCalculator(positions []models.Position) *calculator.LiquidityCalculator {
+	return calculator.NewLiquidityCalculator(liquidityMarketData(positions))
+}
+
+// liquidityMarketData is the market data liquidityCalculator analyses positions with
+func liquidityMarketData(positions []models.Position) *depthMarketData {
+	db := database.GetDB()
+	// Crypto assets fall back to their built-in terms when reference data cannot be loaded
+	assets, _ := cryptoAssets(db)
+	return newDepthMarketData(db, positions, calculator.NewChainMarketData(positions, assets))
+}
+
 // TradeRiskAnalysis represents the risk assessment for a trade
 type TradeRiskAnalysis struct {
 	TradeID  uuid.UUID       `json:"trade_id"`
@@ -68,11 +91,78 @@ type RiskViolation struct {
 	Impact       decimal.Decimal `json:"impact"`
 }
 
+// Pre-trade enforcement modes, deciding what happens to new orders the risk engine rejects
+const (
+	EnforcementOff    = "OFF"    // Recorded with the violations
+	EnforcementHold   = "HOLD"   // Held for four-eyes approval like orders flagged for review
+	EnforcementReject = "REJECT" // Refused with a RiskRejectedError
+)
+
+// RiskRejectedError is returned when a new order is refused because the pre-trade risk checks
+// rejected it
+type RiskRejectedError struct {
+	RiskScore  decimal.Decimal
+	Violations []RiskViolation
+}
+
+func (e *RiskRejectedError) Error() string {
+	return fmt.Sprintf("order rejected by pre-trade risk checks: score %s with %d violations", e.RiskScore.StringFixed(0), len(e.Violations))
+}
+
 // EvaluateTransaction performs pre-trade risk assessment
-func (res *RiskEngineService) EvaluateTransaction(tx *models.Transaction) (*TradeRiskAnalysis, error) {
+func (res *RiskEngineService) EvaluateTransaction(ctx context.Context, tx *models.Transaction) (*TradeRiskAnalysis, error) {
+	defer metrics.RiskCalculationDuration.With("pre_trade").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.pre_trade",
+		tracing.String("portfolio_id", tx.PortfolioID.String()), tracing.String("symbol", tx.Symbol))
+	defer span.End()
+
+	analysis, err := res.AssessTrade(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.Int64("risk.score", analysis.RiskScore.IntPart()), tracing.String("risk.outcome", tradeOutcome(analysis)))
+	res.RecordAssessment(ctx, tx, analysis, false)
+	return analysis, nil
+}
+
+// RecordAssessment stores the outcome of AssessTrade on a saved transaction, raises alerts for its
+// violations and holds it for approval when flagged for review, or when rejected and holdRejected
+// is set
+func (res *RiskEngineService) RecordAssessment(ctx context.Context, tx *models.Transaction, analysis *TradeRiskAnalysis, holdRejected bool) {
+	// 9. Update transaction with risk analysis
+	res.updateTransactionRiskStatus(tx, analysis)
+
+	metrics.TradeEvaluations.With(tradeOutcome(analysis)).Inc()
+	res.logger.InfoContext(ctx, "Transaction evaluated", "transaction_id", tx.ID, "portfolio_id", tx.PortfolioID,
+		"risk_score", analysis.RiskScore.IntPart(), "violations", len(analysis.Violations), "approved", analysis.Approved)
+
+	// 10. Create alerts for critical violations
+	if !analysis.Approved && len(analysis.Violations) > 0 {
+		res.createRiskAlerts(ctx, tx, analysis)
+	}
+
+	// 11. Hold pending trades flagged for review until a second user approves them
+	reason := ""
+	switch {
+	case analysis.RequiresReview:
+		reason = fmt.Sprintf("Risk review: score %s with %d violations", analysis.RiskScore.StringFixed(0), len(analysis.Violations))
+	case !analysis.Approved && holdRejected:
+		reason = fmt.Sprintf("Rejected by risk checks: score %s with %d violations", analysis.RiskScore.StringFixed(0), len(analysis.Violations))
+	}
+	if reason != "" {
+		if _, err := res.approvals.Hold(ctx, tx, reason); err != nil {
+			res.logger.ErrorContext(ctx, "Failed to hold transaction for approval", "transaction_id", tx.ID, "error", err)
+		}
+	}
+}
+
+// AssessTrade runs the pre-trade risk checks on a trade, which need not be stored yet, without
+// recording the outcome or raising alerts
+func (res *RiskEngineService) AssessTrade(ctx context.Context, tx *models.Transaction) (*TradeRiskAnalysis, error) {
 	// Get portfolio and positions
 	var portfolio models.Portfolio
-	if err := res.db.Preload("Positions").First(&portfolio, tx.PortfolioID).Error; err != nil {
+	if err := res.db.WithContext(ctx).Preload("Positions").First(&portfolio, tx.PortfolioID).Error; err != nil {
 		return nil, fmt.Errorf("portfolio not found: %w", err)
 	}
 
@@ -92,7 +182,7 @@ func (res *RiskEngineService) EvaluateTransaction(tx *models.Transaction) (*Trad
 	}
 
 	// 1. Check Position Size Limit
-	if violation := res.checkPositionSizeLimit(tx, &portfolio, thresholds); violation != nil {
+	if violation := res.checkPositionSizeLimit(ctx, tx, &portfolio, thresholds); violation != nil {
 		analysis.Violations = append(analysis.Violations, *violation)
 	}
 
@@ -106,7 +196,7 @@ func (res *RiskEngineService) EvaluateTransaction(tx *models.Transaction) (*Trad
 	}
 
 	// 3. Check Concentration Risk
-	concentrationImpact := res.checkConcentrationRisk(tx, &portfolio, thresholds)
+	concentrationImpact := res.checkConcentrationRisk(ctx, tx, &portfolio, thresholds)
 	analysis.ConcentrationImpact = concentrationImpact.Impact
 	if concentrationImpact.Violation != nil {
 		analysis.Violations = append(analysis.Violations, *concentrationImpact.Violation)
@@ -140,27 +230,106 @@ func (res *RiskEngineService) EvaluateTransaction(tx *models.Transaction) (*Trad
 		res.generateRecommendations(analysis, tx)
 	}
 
-	// 9. Update transaction with risk analysis
-	res.updateTransactionRiskStatus(tx, analysis)
+	return analysis, nil
+}
 
-	// 10. Create alerts for critical violations
-	if !analysis.Approved && len(analysis.Violations) > 0 {
-		res.createRiskAlerts(tx, analysis)
+// tradeOutcome labels an evaluation for the trade evaluation metric
+func tradeOutcome(analysis *TradeRiskAnalysis) string {
+	switch {
+	case analysis.Approved:
+		return "approved"
+	case analysis.RequiresReview:
+		return "review"
 	}
+	return "rejected"
+}
 
-	return analysis, nil
+// GetThresholds returns a portfolio's risk thresholds, creating them on first use with the limits
+// it inherits
+func (res *RiskEngineService) GetThresholds(portfolioID uuid.UUID) (*models.RiskThresholds, error) {
+	return res.getOrCreateThresholds(portfolioID)
+}
+
+// ThresholdsRequest changes some of the limits set on a portfolio; limits left out are unchanged
+// and those named in inherit are taken from the parent portfolio again
+type ThresholdsRequest struct {
+	models.ThresholdOverrides
+	Inherit []string `json:"inherit"` // JSON names of thresholds no longer set on the portfolio itself
+	Version *int64   `json:"version"` // Version the change was made against, when If-Match is not sent
+}
+
+// UpdateThresholds changes the limits set on a portfolio, if its thresholds are still at the
+// version the change was made against, and passes the new limits down to the portfolios under it
+// that inherit them
+func (res *RiskEngineService) UpdateThresholds(portfolioID uuid.UUID, version int64, overrides *models.ThresholdOverrides, inherit []string) (*models.RiskThresholds, error) {
+	if err := validateThresholdOverrides(overrides); err != nil {
+		return nil, apperror.BadRequest(err.Error())
+	}
+	if err := new(models.ThresholdOverrides).Clear(inherit); err != nil {
+		return nil, apperror.BadRequest(err.Error())
+	}
+	// A portfolio whose thresholds were never read has the limits it inherits, at version 1
+	if _, err := res.getOrCreateThresholds(portfolioID); err != nil {
+		return nil, err
+	}
+
+	var thresholds models.RiskThresholds
+	err := res.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockHierarchy(tx); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("portfolio_id = ?", portfolioID).First(&thresholds).Error
+		if err != nil {
+			return err
+		}
+		if err := checkVersion("Risk thresholds", &thresholds, thresholds.Version, version); err != nil {
+			return err
+		}
+
+		thresholds.Overrides.Merge(overrides)
+		if err := thresholds.Overrides.Clear(inherit); err != nil {
+			return err
+		}
+		if _, err := res.resolveThresholds(tx, &thresholds); err != nil {
+			return err
+		}
+		if err := tx.Omit("Portfolio").Save(&thresholds).Error; err != nil {
+			return err
+		}
+		// The database raised the version with the changes
+		err = tx.Model(&models.RiskThresholds{}).Where("id = ?", thresholds.ID).Select("version").Scan(&thresholds.Version).Error
+		if err != nil {
+			return err
+		}
+		return res.passDownThresholds(tx, portfolioID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &thresholds, nil
 }
 
 // Helper methods
 
 func (res *RiskEngineService) getOrCreateThresholds(portfolioID uuid.UUID) (*models.RiskThresholds, error) {
+	return res.thresholdsIn(res.db, portfolioID)
+}
+
+// thresholdsIn returns a portfolio's risk thresholds, creating them on first use with the limits it
+// inherits and none set on the portfolio itself
+func (res *RiskEngineService) thresholdsIn(db *gorm.DB, portfolioID uuid.UUID) (*models.RiskThresholds, error) {
 	var thresholds models.RiskThresholds
-	err := res.db.Where("portfolio_id = ?", portfolioID).First(&thresholds).Error
+	err := db.Where("portfolio_id = ?", portfolioID).First(&thresholds).Error
 
 	if err == gorm.ErrRecordNotFound {
-		// Create default thresholds
-		thresholds = *models.GetDefaultThresholds(portfolioID)
-		if err := res.db.Create(&thresholds).Error; err != nil {
+		inherited, err := res.inheritedThresholds(db, portfolioID)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = *inherited
+		// Every column is inserted so that a tenant disabling the stop loss rule is not replaced by
+		// the column default
+		if err := db.Select("*").Omit("Portfolio").Create(&thresholds).Error; err != nil {
 			return nil, err
 		}
 	} else if err != nil {
@@ -170,8 +339,98 @@ func (res *RiskEngineService) getOrCreateThresholds(portfolioID uuid.UUID) (*mod
 	return &thresholds, nil
 }
 
-func (res *RiskEngineService) checkPositionSizeLimit(tx *models.Transaction, portfolio *models.Portfolio, thresholds *models.RiskThresholds) *RiskViolation {
-	tradeValue := tx.Quantity.Mul(tx.Price)
+// inheritedThresholds returns the limits of a portfolio setting none itself: those of its parent,
+// or for a top-level portfolio the defaults as its tenant sets them. A portfolio under a deleted
+// parent still inherits from it, as it rolls up into the parent again once that is restored.
+func (res *RiskEngineService) inheritedThresholds(db *gorm.DB, portfolioID uuid.UUID) (*models.RiskThresholds, error) {
+	var portfolio models.Portfolio
+	if err := db.Unscoped().Select("id", "parent_id").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("portfolio not found")
+		}
+		return nil, err
+	}
+
+	thresholds := models.GetDefaultThresholds(portfolioID)
+	if portfolio.ParentID != nil {
+		parent, err := res.thresholdsIn(db, *portfolio.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		parent.Limits().Apply(thresholds)
+		return thresholds, nil
+	}
+
+	settings, err := tenantSettingsOf(db, "portfolios", portfolioID)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && settings.RiskThresholds != nil {
+		settings.RiskThresholds.Apply(thresholds)
+	}
+	return thresholds, nil
+}
+
+// resolveThresholds sets the effective limits of a portfolio's thresholds from those it inherits
+// and those set on it, reporting whether any changed
+func (res *RiskEngineService) resolveThresholds(db *gorm.DB, thresholds *models.RiskThresholds) (bool, error) {
+	effective, err := res.inheritedThresholds(db, thresholds.PortfolioID)
+	if err != nil {
+		return false, err
+	}
+	thresholds.Overrides.Apply(effective)
+
+	limits := effective.Limits()
+	changed := !limits.Equal(thresholds.Limits())
+	limits.Apply(thresholds)
+	return changed, nil
+}
+
+// passDownThresholds resolves the thresholds of every portfolio under a portfolio again, parents
+// before their children, saving those whose limits changed. Portfolios whose thresholds were never
+// read get them from their parent on first use.
+func (res *RiskEngineService) passDownThresholds(tx *gorm.DB, portfolioID uuid.UUID) error {
+	var childIDs []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Portfolio{}).Where("parent_id = ?", portfolioID).Pluck("id", &childIDs).Error; err != nil {
+		return err
+	}
+
+	for _, childID := range childIDs {
+		var thresholds models.RiskThresholds
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("portfolio_id = ?", childID).First(&thresholds).Error
+		if err == nil {
+			changed, err := res.resolveThresholds(tx, &thresholds)
+			if err != nil {
+				return err
+			}
+			if changed {
+				if err := tx.Omit("Portfolio").Save(&thresholds).Error; err != nil {
+					return err
+				}
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := res.passDownThresholds(tx, childID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tradeValue is the value of a trade in the portfolio currency
+func (res *RiskEngineService) tradeValue(ctx context.Context, tx *models.Transaction, portfolio *models.Portfolio) decimal.Decimal {
+	value := tx.Quantity.Mul(tx.Price)
+	rate, err := fxRate(tx.Currency, portfolio.Currency)
+	if err != nil {
+		res.logger.WarnContext(ctx, "Valuing trade unconverted", "transaction_id", tx.ID, "currency", tx.Currency, "error", err)
+		return value
+	}
+	return value.Mul(rate)
+}
+
+func (res *RiskEngineService) checkPositionSizeLimit(ctx context.Context, tx *models.Transaction, portfolio *models.Portfolio, thresholds *models.RiskThresholds) *RiskViolation {
+	tradeValue := res.tradeValue(ctx, tx, portfolio)
 
 	if portfolio.TotalValue.IsZero() {
 		return nil
@@ -236,7 +495,7 @@ type ConcentrationResult struct {
 	Violation *RiskViolation
 }
 
-func (res *RiskEngineService) checkConcentrationRisk(tx *models.Transaction, portfolio *models.Portfolio, thresholds *models.RiskThresholds) *ConcentrationResult {
+func (res *RiskEngineService) checkConcentrationRisk(ctx context.Context, tx *models.Transaction, portfolio *models.Portfolio, thresholds *models.RiskThresholds) *ConcentrationResult {
 	// Calculate Herfindahl index
 	totalValue := portfolio.TotalValue
 	if totalValue.IsZero() {
@@ -250,7 +509,7 @@ func (res *RiskEngineService) checkConcentrationRisk(tx *models.Transaction, por
 	}
 
 	// Add new position impact
-	newPositionValue := tx.Quantity.Mul(tx.Price)
+	newPositionValue := res.tradeValue(ctx, tx, portfolio)
 	newTotalValue := totalValue.Add(newPositionValue)
 	newWeight := newPositionValue.Div(newTotalValue)
 	newHHI := hhi.Add(newWeight.Mul(newWeight))
@@ -308,13 +567,15 @@ type PositionViolation struct {
 
 func (res *RiskEngineService) checkLiquidityImpact(tx *models.Transaction, portfolio *models.Portfolio, thresholds *models.RiskThresholds) *LiquidityResult {
 	// Get current liquidity using the calculator
-	liquidityResult, err := res.liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
+	liquidityCalc := liquidityCalculator(portfolio.Positions)
+	liquidityResult, err := liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
 	if err != nil {
 		// Return simplified result if calculation fails
 		return &LiquidityResult{
 			Impact: decimal.NewFromFloat(0.05),
 		}
 	}
+	liquidityCalc.IncludeCash(liquidityResult, portfolio.CashBalance.InexactFloat64())
 
 	liquidityRatio := decimal.NewFromFloat(liquidityResult.LiquidityRatio)
 
@@ -428,7 +689,7 @@ func (res *RiskEngineService) updateTransactionRiskStatus(tx *models.Transaction
 	res.db.Model(tx).Updates(updates)
 }
 
-func (res *RiskEngineService) createRiskAlerts(tx *models.Transaction, analysis *TradeRiskAnalysis) {
+func (res *RiskEngineService) createRiskAlerts(ctx context.Context, tx *models.Transaction, analysis *TradeRiskAnalysis) {
 	for _, violation := range analysis.Violations {
 		if violation.Severity == "CRITICAL" || violation.Severity == "VIOLATION" {
 			alert := &models.Alert{
@@ -446,16 +707,22 @@ func (res *RiskEngineService) createRiskAlerts(tx *models.Transaction, analysis
 				},
 			}
 
-			res.alertService.CreateAlert(alert)
+			if err := res.alertService.CreateAlert(ctx, alert); err != nil {
+				res.logger.ErrorContext(ctx, "Failed to create risk violation alert", "transaction_id", tx.ID, "error", err)
+			}
 		}
 	}
 }
 
 // MonitorPortfolioRisk continuously monitors portfolio risk metrics
-func (res *RiskEngineService) MonitorPortfolioRisk(portfolioID uuid.UUID) error {
+func (res *RiskEngineService) MonitorPortfolioRisk(ctx context.Context, portfolioID uuid.UUID) error {
+	defer metrics.RiskCalculationDuration.With("monitor").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.monitor", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
 	// Get portfolio
 	var portfolio models.Portfolio
-	if err := res.db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+	if err := res.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
 		return fmt.Errorf("portfolio not found: %w", err)
 	}
 
@@ -467,7 +734,10 @@ func (res *RiskEngineService) MonitorPortfolioRisk(portfolioID uuid.UUID) error
 
 	// Calculate current VaR
 	priceHistory := make(map[string][]float64) // Mock price history
+	_, varSpan := tracing.Start(ctx, "risk.var.calculate", tracing.Int("position_count", len(portfolio.Positions)))
 	varResult, err := res.varCalculator.CalculateVaR(portfolio.Positions, priceHistory, 1)
+	varSpan.RecordError(err)
+	varSpan.End()
 	if err != nil {
 		return err
 	}
@@ -477,6 +747,7 @@ func (res *RiskEngineService) MonitorPortfolioRisk(portfolioID uuid.UUID) error
 	// Check VaR against thresholds
 	if varValue.GreaterThan(thresholds.MaxVaR95) {
 		res.alertService.CreateRiskBreachAlert(
+			ctx,
 			portfolioID,
 			"VAR",
 			varValue.InexactFloat64(),
@@ -485,16 +756,20 @@ func (res *RiskEngineService) MonitorPortfolioRisk(portfolioID uuid.UUID) error
 	}
 
 	// Calculate liquidity
-	liquidityResult, err := res.liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
+	liquidityCalc := liquidityCalculator(portfolio.Positions)
+	liquidityResult, err := liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
 	if err != nil {
 		return err
 	}
+	lvar := liquidityCalc.AdjustVaR(liquidityResult, varResult.VaR95, varResult.TimeHorizon)
+	liquidityCalc.IncludeCash(liquidityResult, portfolio.CashBalance.InexactFloat64())
 
 	liquidityValue := decimal.NewFromFloat(liquidityResult.LiquidityRatio)
 
 	// Check liquidity against thresholds
 	if liquidityValue.LessThan(thresholds.MinLiquidityRatio) {
 		res.alertService.CreateRiskBreachAlert(
+			ctx,
 			portfolioID,
 			"LIQUIDITY",
 			liquidityValue.InexactFloat64(),
@@ -503,16 +778,19 @@ func (res *RiskEngineService) MonitorPortfolioRisk(portfolioID uuid.UUID) error
 	}
 
 	// Broadcast updates via Redis
-	ctx := context.Background()
 	update := map[string]interface{}{
-		"portfolio_id": portfolioID,
-		"var":          varValue.InexactFloat64(),
-		"liquidity":    liquidityValue.InexactFloat64(),
-		"timestamp":    time.Now().Unix(),
+		"portfolio_id":           portfolioID,
+		"var":                    varValue.InexactFloat64(),
+		"liquidity_adjusted_var": lvar.Value,
+		"liquidity":              liquidityValue.InexactFloat64(),
+		"timestamp":              time.Now().Unix(),
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		update["request_id"] = requestID
 	}
 
 	updateJSON, _ := json.Marshal(update)
-	database.GetRedis().Publish(ctx, "risk_updates", updateJSON)
+	database.PublishEvent(ctx, database.RiskUpdatesChannel, updateJSON)
 
 	return nil
 }
@@ -541,6 +819,8 @@ type VaRResult struct {
 
 // CalculateVaR calculates Value at Risk for a portfolio
 func (res *RiskEngineService) CalculateVaR(req VaRCalculationRequest) (*VaRResult, error) {
+	defer metrics.RiskCalculationDuration.With("var").ObserveSince(time.Now())
+
 	// Get portfolio and positions
 	var portfolio models.Portfolio
 	if err := res.db.Preload("Positions").First(&portfolio, req.PortfolioID).Error; err != nil {
@@ -554,9 +834,11 @@ func (res *RiskEngineService) CalculateVaR(req VaRCalculationRequest) (*VaRResul
 		return nil, err
 	}
 
-	// Convert to service result format
+	// Convert to service result format. Cash adds to the value the VaR is measured against but
+	// carries no market risk of its own.
 	varValue := decimal.NewFromFloat(calcResult.VaR95)
-	threshold := portfolio.TotalValue.Mul(decimal.NewFromFloat(0.08))
+	portfolioValue := portfolio.ValueWithCash()
+	threshold := decimal.Max(portfolioValue, decimal.Zero).Mul(decimal.NewFromFloat(0.08))
 
 	status := "SAFE"
 	if varValue.GreaterThan(threshold) {
@@ -568,19 +850,29 @@ func (res *RiskEngineService) CalculateVaR(req VaRCalculationRequest) (*VaRResul
 	return &VaRResult{
 		PortfolioID:     req.PortfolioID,
 		VaRValue:        varValue,
-		VaRPercentage:   varValue.Div(portfolio.TotalValue).Mul(decimal.NewFromInt(100)),
+		VaRPercentage:   varPercentage(varValue, portfolioValue),
 		ConfidenceLevel: decimal.NewFromFloat(req.ConfidenceLevel),
 		TimeHorizon:     req.TimeHorizon,
 		Method:          req.Method,
-		PortfolioValue:  portfolio.TotalValue,
+		PortfolioValue:  portfolioValue,
 		CalculatedAt:    time.Now(),
 		Status:          status,
 		Threshold:       threshold,
 	}, nil
 }
 
+// varPercentage is VaR as a percentage of portfolio value, zero when the portfolio has no value
+func varPercentage(varValue, portfolioValue decimal.Decimal) decimal.Decimal {
+	if !portfolioValue.IsPositive() {
+		return decimal.Zero
+	}
+	return varValue.Div(portfolioValue).Mul(decimal.NewFromInt(100))
+}
+
 // CalculateLiquidityRisk calculates liquidity risk for a portfolio
 func (res *RiskEngineService) CalculateLiquidityRisk(portfolioID uuid.UUID) (*LiquidityResult, error) {
+	defer metrics.RiskCalculationDuration.With("liquidity").ObserveSince(time.Now())
+
 	// Get portfolio and positions
 	var portfolio models.Portfolio
 	if err := res.db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
@@ -588,10 +880,12 @@ func (res *RiskEngineService) CalculateLiquidityRisk(portfolioID uuid.UUID) (*Li
 	}
 
 	// Use the calculator
-	calcResult, err := res.liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
+	liquidityCalc := liquidityCalculator(portfolio.Positions)
+	calcResult, err := liquidityCalc.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
 	if err != nil {
 		return nil, err
 	}
+	liquidityCalc.IncludeCash(calcResult, portfolio.CashBalance.InexactFloat64())
 
 	// Convert to service result format
 	liquidityRatio := decimal.NewFromFloat(calcResult.LiquidityRatio)
@@ -615,6 +909,8 @@ func (res *RiskEngineService) CalculateLiquidityRisk(portfolioID uuid.UUID) (*Li
 
 // CheckPositionLimits checks position size limits
 func (res *RiskEngineService) CheckPositionLimits(portfolioID uuid.UUID, maxLimitPercent float64) (*PositionLimitResult, error) {
+	defer metrics.RiskCalculationDuration.With("position_limits").ObserveSince(time.Now())
+
 	// Get portfolio and positions
 	var portfolio models.Portfolio
 	if err := res.db.Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
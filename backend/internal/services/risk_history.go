@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+// Resolutions of risk history rows. Snapshots are recorded RAW and averaged into coarser buckets
+// as they age.
+const (
+	HistoryRaw    = "RAW"
+	HistoryHourly = "HOURLY"
+	HistoryDaily  = "DAILY"
+)
+
+var (
+	ErrInvalidHistoryInterval  = errors.New("unsupported interval, expected 1h, 1d or 1w")
+	ErrInvalidHistoryAggregate = errors.New("unsupported agg, expected max, avg or last")
+)
+
+// historyIntervals maps the aggregation intervals clients send to date_trunc units
+var historyIntervals = map[string]string{
+	"1h": "hour",
+	"1d": "day",
+	"1w": "week",
+}
+
+// historyViews maps the aggregation intervals to the TimescaleDB continuous aggregates holding them
+var historyViews = map[string]string{
+	"1h": database.RiskHistoryHourlyView,
+	"1d": database.RiskHistoryDailyView,
+}
+
+// historyAggregates maps the aggregate functions clients send to the windowed columns holding them
+var historyAggregates = map[string]string{
+	"max":  "max_value",
+	"avg":  "avg_value",
+	"last": "last_value",
+}
+
+// HistoryBucket is one metric's risk history aggregated over an interval
+type HistoryBucket struct {
+	MetricType string          `json:"metric_type"`
+	Bucket     time.Time       `json:"bucket"`
+	Value      decimal.Decimal `json:"value"`
+	Samples    int64           `json:"samples"`
+}
+
+// snapshotBatchSize is the number of portfolios loaded with their positions at a time
+const snapshotBatchSize = 100
+
+// RiskHistoryService records the risk metrics of every portfolio into the risk history at a fixed
+// cadence and downsamples old snapshots so that long retention stays compact
+type RiskHistoryService struct {
+	db            *gorm.DB
+	riskEngine    *RiskEngineService
+	leverage      *LeverageService
+	concentration *calculator.ConcentrationCalculator
+	hourlyAfter   time.Duration
+	dailyAfter    time.Duration
+	logger        *slog.Logger
+}
+
+func NewRiskHistoryService(cfg *config.RiskConfig) *RiskHistoryService {
+	return &RiskHistoryService{
+		db:            database.GetDB(),
+		riskEngine:    NewRiskEngineService(),
+		leverage:      NewLeverageService(),
+		concentration: calculator.NewConcentrationCalculator(5),
+		hourlyAfter:   cfg.HistoryHourlyAfter,
+		dailyAfter:    cfg.HistoryDailyAfter,
+		logger:        logging.Component("risk_history"),
+	}
+}
+
+// StartSnapshotter snapshots every portfolio and downsamples the history at a fixed interval until
+// ctx is cancelled
+func (s *RiskHistoryService) StartSnapshotter(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		s.logger.Info("Risk history snapshots disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx := logging.WithNewRequestID(context.WithoutCancel(ctx))
+		if _, err := s.Snapshot(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Risk history snapshot failed", "error", err)
+		}
+		if _, err := s.Downsample(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Risk history downsampling failed", "error", err)
+		}
+	}
+}
+
+// Snapshot records the VaR, liquidity ratio, leverage and concentration of every portfolio and
+// returns how many portfolios were recorded. A portfolio that fails is logged and skipped.
+func (s *RiskHistoryService) Snapshot(ctx context.Context) (int, error) {
+	defer metrics.RiskCalculationDuration.With("history_snapshot").ObserveSince(time.Now())
+
+	recordedAt := time.Now()
+	recorded := 0
+	var batch []models.Portfolio
+	err := s.db.WithContext(ctx).Preload("Positions").FindInBatches(&batch, snapshotBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := s.snapshotPortfolio(ctx, &batch[i], recordedAt); err != nil {
+				s.logger.ErrorContext(ctx, "Risk history snapshot of portfolio failed", "portfolio_id", batch[i].ID, "error", err)
+				continue
+			}
+			recorded++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return recorded, err
+	}
+
+	s.logger.InfoContext(ctx, "Recorded risk history snapshot", "portfolios", recorded)
+	return recorded, nil
+}
+
+// snapshotPortfolio records the risk metrics of a portfolio loaded with its positions
+func (s *RiskHistoryService) snapshotPortfolio(ctx context.Context, portfolio *models.Portfolio, recordedAt time.Time) error {
+	thresholds, err := s.riskEngine.GetThresholds(portfolio.ID)
+	if err != nil {
+		return err
+	}
+
+	varValue, _ := SimplifiedVaR(portfolio)
+	leverage := s.leverage.calculate(portfolio, thresholds)
+	values := map[string]decimal.Decimal{
+		"VAR":             varValue,
+		"LIQUIDITY_RATIO": LiquidityTiers(portfolio).Ratio(),
+		"LEVERAGE":        decimal.NewFromFloat(leverage.GrossLeverage).Round(4),
+	}
+	if len(portfolio.Positions) > 0 {
+		// Concentration is undefined for a portfolio holding only cash
+		result := s.concentration.CalculateConcentration(portfolio.Positions, calculator.ConcentrationLimits{
+			MaxHHI:            thresholds.MaxConcentration.InexactFloat64(),
+			MaxSingleAsset:    thresholds.MaxSingleAssetExposure.InexactFloat64(),
+			MaxSectorExposure: thresholds.MaxSectorExposure.InexactFloat64(),
+		})
+		values["CONCENTRATION"] = decimal.NewFromFloat(result.HHI)
+	}
+
+	rows := make([]models.RiskHistory, 0, len(values))
+	for metricType, value := range values {
+		rows = append(rows, models.RiskHistory{
+			PortfolioID: portfolio.ID,
+			MetricType:  metricType,
+			Value:       value,
+			RecordedAt:  recordedAt,
+			Resolution:  HistoryRaw,
+		})
+	}
+	return s.db.WithContext(ctx).Create(&rows).Error
+}
+
+// Downsample averages raw snapshots older than the hourly threshold into one row per hour, and
+// hourly rows older than the daily threshold into one row per day, returning how many rows each
+// step replaced. A zero threshold keeps that resolution indefinitely. With TimescaleDB the
+// history is compressed and aggregated by continuous aggregates instead, so nothing is replaced.
+func (s *RiskHistoryService) Downsample(ctx context.Context) (map[string]int64, error) {
+	replaced := make(map[string]int64, 2)
+	if database.TimescaleEnabled() {
+		return replaced, nil
+	}
+	steps := []struct {
+		from, to, unit string
+		after          time.Duration
+	}{
+		{HistoryRaw, HistoryHourly, "hour", s.hourlyAfter},
+		{HistoryHourly, HistoryDaily, "day", s.dailyAfter},
+	}
+
+	for _, step := range steps {
+		if step.after <= 0 {
+			continue
+		}
+		count, err := s.downsample(ctx, step.from, step.to, step.unit, time.Now().Add(-step.after))
+		if err != nil {
+			return replaced, fmt.Errorf("downsample %s to %s: %w", step.from, step.to, err)
+		}
+		replaced[step.from] = count
+	}
+
+	s.logger.InfoContext(ctx, "Downsampled risk history", "raw", replaced[HistoryRaw], "hourly", replaced[HistoryHourly])
+	return replaced, nil
+}
+
+// downsample replaces the rows of a resolution recorded before the cutoff with their average per
+// unit bucket. Only buckets that end before the cutoff are replaced, so a bucket is averaged once
+// all of its rows are in.
+func (s *RiskHistoryService) downsample(ctx context.Context, from, to, unit string, cutoff time.Time) (int64, error) {
+	var replaced int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO risk_histories (id, portfolio_id, metric_type, value, recorded_at, resolution)
+			SELECT uuid_generate_v4(), portfolio_id, metric_type, AVG(value), date_trunc(?, recorded_at), ?
+			FROM risk_histories
+			WHERE resolution = ? AND recorded_at < date_trunc(?, ?::timestamptz)
+			GROUP BY 2, 3, 5`, unit, to, from, unit, cutoff).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("resolution = ? AND recorded_at < date_trunc(?, ?::timestamptz)", from, unit, cutoff).
+			Delete(&models.RiskHistory{})
+		replaced = result.RowsAffected
+		return result.Error
+	})
+	return replaced, err
+}
+
+// Aggregate returns a page of a portfolio's risk history aggregated per metric into interval
+// buckets, and the total number of buckets. The history filters and date range of params apply
+// to the rows before they are aggregated; its sort direction orders the buckets by time. With
+// TimescaleDB, hourly and daily buckets are read from the continuous aggregates instead.
+func (s *RiskHistoryService) Aggregate(ctx context.Context, portfolioID uuid.UUID, interval, agg string, spec pagination.Spec, params pagination.Params) ([]HistoryBucket, int64, error) {
+	unit, ok := historyIntervals[interval]
+	if !ok {
+		return nil, 0, ErrInvalidHistoryInterval
+	}
+	column, ok := historyAggregates[agg]
+	if !ok {
+		return nil, 0, ErrInvalidHistoryAggregate
+	}
+
+	db := s.db.WithContext(ctx)
+	if view, ok := historyViews[interval]; ok && database.TimescaleEnabled() && params.Filters["resolution"] == "" {
+		return s.aggregateView(db, view, column, portfolioID, spec, params)
+	}
+	rows := params.Filter(db.Model(&models.RiskHistory{}).Where("portfolio_id = ?", portfolioID), spec).
+		Select("metric_type, value, recorded_at, date_trunc(?, recorded_at) AS bucket", unit)
+	// Every row carries its bucket's aggregates and its rank from the latest, so the latest row of
+	// each bucket stands for it
+	buckets := db.Raw(`SELECT metric_type, bucket, `+column+` AS value, samples FROM (
+			SELECT metric_type, bucket,
+				MAX(value) OVER (PARTITION BY metric_type, bucket) AS max_value,
+				AVG(value) OVER (PARTITION BY metric_type, bucket) AS avg_value,
+				FIRST_VALUE(value) OVER (PARTITION BY metric_type, bucket ORDER BY recorded_at DESC) AS last_value,
+				COUNT(*) OVER (PARTITION BY metric_type, bucket) AS samples,
+				ROW_NUMBER() OVER (PARTITION BY metric_type, bucket ORDER BY recorded_at DESC) AS latest_rank
+			FROM (?) AS h
+		) AS b WHERE latest_rank = 1`, rows)
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM (?) AS counted", buckets).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if params.Desc {
+		direction = "DESC"
+	}
+	var result []HistoryBucket
+	err := db.Raw("SELECT * FROM (?) AS page ORDER BY bucket "+direction+", metric_type LIMIT ? OFFSET ?",
+		buckets, params.Limit, params.Offset).
+		Scan(&result).Error
+	return result, total, err
+}
+
+// aggregateView reads a page of buckets from a TimescaleDB continuous aggregate. The date range
+// applies to the start of each bucket.
+func (s *RiskHistoryService) aggregateView(db *gorm.DB, view, column string, portfolioID uuid.UUID, spec pagination.Spec, params pagination.Params) ([]HistoryBucket, int64, error) {
+	viewSpec := spec
+	viewSpec.DateColumn = "bucket"
+	query := params.Filter(db.Table(view).Where("portfolio_id = ?", portfolioID), viewSpec)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction := " ASC"
+	if params.Desc {
+		direction = " DESC"
+	}
+	var result []HistoryBucket
+	err := query.Select("metric_type, bucket, " + column + " AS value, samples").
+		Order("bucket" + direction + ", metric_type").
+		Limit(params.Limit).Offset(params.Offset).
+		Scan(&result).Error
+	return result, total, err
+}
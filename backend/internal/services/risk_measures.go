@@ -0,0 +1,169 @@
+package services
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+)
+
+// simplifiedVaRPercentage is the share of position value the simplified method reports as VaR
+const simplifiedVaRPercentage = 0.05
+
+// SimplifiedVaR returns the VaR of a portfolio at 95% confidence, 5% of its position value, and the
+// threshold it is checked against, 8% of its value including cash. Cash carries no market risk
+// but cushions losses on the positions.
+func SimplifiedVaR(portfolio *models.Portfolio) (varValue, threshold decimal.Decimal) {
+	varValue = portfolio.TotalValue.Mul(decimal.NewFromFloat(simplifiedVaRPercentage))
+	threshold = decimal.Max(portfolio.ValueWithCash(), decimal.Zero).Mul(decimal.NewFromFloat(0.08))
+	return varValue, threshold
+}
+
+// SimplifiedVaRPercent is a portfolio's VaR as a percentage of its value including cash
+func SimplifiedVaRPercent(portfolio *models.Portfolio, varValue decimal.Decimal) decimal.Decimal {
+	value := portfolio.ValueWithCash()
+	if !value.IsPositive() {
+		return decimal.NewFromFloat(simplifiedVaRPercentage * 100)
+	}
+	return varValue.Div(value).Mul(decimal.NewFromInt(100)).Round(4)
+}
+
+// VaRMetric calculates the simplified VaR of a portfolio with positions, and its liquidity-adjusted
+// VaR, as the risk metric it is stored as
+func VaRMetric(portfolio *models.Portfolio, cfg *config.RiskConfig) (*models.RiskMetric, *calculator.LiquidityAdjustedVaR, error) {
+	varValue, threshold := SimplifiedVaR(portfolio)
+
+	lvar, depth, err := LiquidityAdjustedVaR(portfolio, varValue, cfg.VARTimeHorizon)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.RiskMetric{
+		PortfolioID:     portfolio.ID,
+		MetricType:      "VAR",
+		Value:           varValue,
+		Threshold:       threshold,
+		Status:          varStatus(varValue, threshold),
+		TimeHorizon:     cfg.VARTimeHorizon,
+		ConfidenceLevel: decimal.NewFromFloat(cfg.VARConfidenceLevel),
+		Details: models.JSON{
+			"method":                 VaRMethodSimplified,
+			"confidence_level":       cfg.VARConfidenceLevel,
+			"time_horizon":           cfg.VARTimeHorizon,
+			"portfolio_value":        portfolio.TotalValue.InexactFloat64(),
+			"cash_balance":           portfolio.CashBalance.InexactFloat64(),
+			"position_count":         len(portfolio.Positions),
+			"liquidity_adjusted_var": lvar.Value,
+			marketDepthDetail:        depth,
+		},
+	}, lvar, nil
+}
+
+// varStatus is CRITICAL when VaR exceeds its threshold and WARNING from 75% of it
+func varStatus(varValue, threshold decimal.Decimal) string {
+	if varValue.GreaterThan(threshold) {
+		return "CRITICAL"
+	} else if varValue.GreaterThan(threshold.Mul(decimal.NewFromFloat(0.75))) {
+		return "WARNING"
+	}
+	return "SAFE"
+}
+
+// LiquidityAdjustedVaR extends a portfolio's VaR by the time and cost of liquidating its positions.
+// It also returns the order book snapshot each symbol was analysed with, by symbol, for the details
+// of the metric it is stored with.
+func LiquidityAdjustedVaR(portfolio *models.Portfolio, varValue decimal.Decimal, timeHorizon int) (*calculator.LiquidityAdjustedVaR, map[string]string, error) {
+	marketData := liquidityMarketData(portfolio.Positions)
+	liquidity := calculator.NewLiquidityCalculator(marketData)
+	result, err := liquidity.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
+	if err != nil {
+		return nil, nil, err
+	}
+	return liquidity.AdjustVaR(result, varValue.InexactFloat64(), timeHorizon), marketData.used(), nil
+}
+
+// LiquidityBreakdown is a portfolio's value split by the liquidity tier of its positions, with
+// cash counted as highly liquid
+type LiquidityBreakdown struct {
+	Cash   decimal.Decimal
+	High   decimal.Decimal
+	Medium decimal.Decimal
+	Low    decimal.Decimal
+	Total  decimal.Decimal
+}
+
+// LiquidityTiers splits a portfolio loaded with its positions by liquidity tier. Positions not
+// yet classified count as highly liquid.
+func LiquidityTiers(portfolio *models.Portfolio) *LiquidityBreakdown {
+	cash := decimal.Max(portfolio.CashBalance, decimal.Zero)
+	breakdown := &LiquidityBreakdown{Cash: cash, High: cash, Total: cash}
+
+	for _, position := range portfolio.Positions {
+		breakdown.Total = breakdown.Total.Add(position.MarketValue)
+		switch position.Liquidity {
+		case "MEDIUM":
+			breakdown.Medium = breakdown.Medium.Add(position.MarketValue)
+		case "LOW":
+			breakdown.Low = breakdown.Low.Add(position.MarketValue)
+		default:
+			breakdown.High = breakdown.High.Add(position.MarketValue)
+		}
+	}
+	return breakdown
+}
+
+// Ratio is the highly liquid share of the portfolio, zero when it has no value
+func (b *LiquidityBreakdown) Ratio() decimal.Decimal {
+	if b.Total.IsZero() {
+		return decimal.Zero
+	}
+	return b.High.Div(b.Total)
+}
+
+// liquidityRatioThreshold is the highly liquid share below which a portfolio's liquidity is CRITICAL
+const liquidityRatioThreshold = 0.3
+
+// LiquidityMetric calculates the share of a portfolio loaded with its positions, cash included,
+// that is highly liquid, as the risk metric it is stored as, with the breakdown it is taken from
+// and the portfolio's liquidity-adjusted VaR
+func LiquidityMetric(portfolio *models.Portfolio, timeHorizon int) (*models.RiskMetric, *LiquidityBreakdown, *calculator.LiquidityAdjustedVaR, error) {
+	breakdown := LiquidityTiers(portfolio)
+	liquidityRatio := breakdown.Ratio()
+
+	varValue, _ := SimplifiedVaR(portfolio)
+	lvar, depth, err := LiquidityAdjustedVaR(portfolio, varValue, timeHorizon)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &models.RiskMetric{
+		PortfolioID: portfolio.ID,
+		MetricType:  "LIQUIDITY_RATIO",
+		Value:       liquidityRatio,
+		Threshold:   decimal.NewFromFloat(liquidityRatioThreshold),
+		Status:      liquidityStatus(liquidityRatio),
+		Details: models.JSON{
+			"breakdown": map[string]float64{
+				"HIGH":   breakdown.High.InexactFloat64(),
+				"MEDIUM": breakdown.Medium.InexactFloat64(),
+				"LOW":    breakdown.Low.InexactFloat64(),
+			},
+			"cash_balance":           breakdown.Cash.InexactFloat64(),
+			"portfolio_value":        breakdown.Total.InexactFloat64(),
+			"position_count":         len(portfolio.Positions),
+			"liquidity_adjusted_var": lvar.Value,
+			marketDepthDetail:        depth,
+		},
+	}, breakdown, lvar, nil
+}
+
+// liquidityStatus is CRITICAL when under 30% of a portfolio is highly liquid and WARNING under 70%
+func liquidityStatus(ratio decimal.Decimal) string {
+	if ratio.LessThan(decimal.NewFromFloat(liquidityRatioThreshold)) {
+		return "CRITICAL"
+	} else if ratio.LessThan(decimal.NewFromFloat(0.7)) {
+		return "WARNING"
+	}
+	return "SAFE"
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/rules"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// maxSimulatedTrades bounds the trades simulated in one request
+const maxSimulatedTrades = 100
+
+// ErrInvalidSimulation wraps the reasons a basket of trades cannot be simulated
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// SimulatedTrade is a hypothetical BUY or SELL order. Prices are in the currency of the position
+// traded; a trade in a symbol the portfolio does not hold opens a position in Currency.
+type SimulatedTrade struct {
+	Symbol    string          `json:"symbol"`
+	Side      string          `json:"side"` // BUY or SELL
+	Quantity  decimal.Decimal `json:"quantity"`
+	Price     decimal.Decimal `json:"price"`      // Defaults to the held position's current price
+	AssetType string          `json:"asset_type"` // For a new position; defaults to STOCK
+	Currency  string          `json:"currency"`   // For a new position; defaults to the portfolio currency
+	Value     decimal.Decimal `json:"value"`      // In the portfolio currency, filled in by the simulation
+}
+
+// SimulatedWeight is a symbol's share of the positions' value, in percent like Position.Weight
+type SimulatedWeight struct {
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	MarketValue decimal.Decimal `json:"market_value"`
+	Weight      decimal.Decimal `json:"weight"`
+}
+
+// PortfolioRiskProfile is the VaR, liquidity and concentration of a portfolio's positions and cash
+type PortfolioRiskProfile struct {
+	TotalValue     decimal.Decimal                 `json:"total_value"` // Positions only, as on the portfolio
+	CashBalance    decimal.Decimal                 `json:"cash_balance"`
+	VaR            decimal.Decimal                 `json:"var_95"`
+	VaRLimit       decimal.Decimal                 `json:"var_limit"`
+	VaRPercent     decimal.Decimal                 `json:"var_percent"`
+	LiquidityRatio decimal.Decimal                 `json:"liquidity_ratio"`
+	Concentration  *calculator.ConcentrationResult `json:"concentration"`
+	Weights        []SimulatedWeight               `json:"weights"`
+}
+
+// SimulationResult compares a portfolio before and after a basket of hypothetical trades. Passed
+// is false when the portfolio would breach a risk limit or a compliance check would fail.
+type SimulationResult struct {
+	PortfolioID uuid.UUID             `json:"portfolio_id"`
+	Passed      bool                  `json:"passed"`
+	Trades      []SimulatedTrade      `json:"trades"`
+	Before      *PortfolioRiskProfile `json:"before"`
+	After       *PortfolioRiskProfile `json:"after"`
+	Violations  []RiskViolation       `json:"violations"`
+	Compliance  []PreTradeCheck       `json:"compliance"`
+	SimulatedAt time.Time             `json:"simulated_at"`
+}
+
+// SimulationService runs a basket of hypothetical trades against a copy of a portfolio and reports
+// the risk profile, limit breaches and compliance findings it would be left with. It is the
+// pre-trade risk check generalized to several trades at once, and stores nothing: no transaction,
+// risk metric or alert.
+type SimulationService struct {
+	db                *gorm.DB
+	riskEngine        *RiskEngineService
+	symbolListService *SymbolListService
+	guidelineService  *InvestmentGuidelineService
+	concentration     *calculator.ConcentrationCalculator
+}
+
+func NewSimulationService() *SimulationService {
+	return &SimulationService{
+		db:                database.GetDB(),
+		riskEngine:        NewRiskEngineService(),
+		symbolListService: NewSymbolListService(),
+		guidelineService:  NewInvestmentGuidelineService(),
+		concentration:     calculator.NewConcentrationCalculator(5),
+	}
+}
+
+// Simulate applies trades in order to a copy of a portfolio's positions and cash. A BUY adds to a
+// position at the trade price, a SELL reduces it and may take it short; positions stay marked at
+// their current price, and new positions at the trade price. The result is checked against the
+// portfolio's risk thresholds, its investment guideline and the restricted and watch lists.
+func (s *SimulationService) Simulate(ctx context.Context, portfolioID uuid.UUID, trades []SimulatedTrade) (*SimulationResult, error) {
+	defer metrics.RiskCalculationDuration.With("simulation").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.simulation", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	if len(trades) == 0 {
+		return nil, fmt.Errorf("%w: trades are required", ErrInvalidSimulation)
+	}
+	if len(trades) > maxSimulatedTrades {
+		return nil, fmt.Errorf("%w: at most %d trades can be simulated at once", ErrInvalidSimulation, maxSimulatedTrades)
+	}
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+	thresholds, err := s.riskEngine.GetThresholds(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulationResult{
+		PortfolioID: portfolioID,
+		Trades:      make([]SimulatedTrade, 0, len(trades)),
+		Violations:  []RiskViolation{},
+		SimulatedAt: time.Now(),
+	}
+	if result.Before, err = s.profile(&portfolio, thresholds); err != nil {
+		return nil, err
+	}
+
+	simulated := portfolio
+	simulated.Positions = append([]models.Position(nil), portfolio.Positions...)
+	for i, trade := range trades {
+		applied, err := applySimulatedTrade(&simulated, trade)
+		if err != nil {
+			return nil, fmt.Errorf("%w: trades[%d]: %w", ErrInvalidSimulation, i, err)
+		}
+		result.Trades = append(result.Trades, *applied)
+	}
+
+	if result.After, err = s.profile(&simulated, thresholds); err != nil {
+		return nil, err
+	}
+	result.Violations = simulationViolations(result, &simulated, thresholds)
+	if result.Compliance, err = s.complianceChecks(&simulated, result.Trades); err != nil {
+		return nil, err
+	}
+
+	result.Passed = true
+	for _, violation := range result.Violations {
+		if violation.Severity != "WARNING" {
+			result.Passed = false
+		}
+	}
+	for _, check := range result.Compliance {
+		if check.Status == PreTradeStatusFailed {
+			result.Passed = false
+		}
+	}
+	return result, nil
+}
+
+// applySimulatedTrade validates a trade and applies it to the portfolio's positions and cash,
+// returning the trade with its defaults and value filled in
+func applySimulatedTrade(portfolio *models.Portfolio, trade SimulatedTrade) (*SimulatedTrade, error) {
+	trade.Symbol = strings.ToUpper(strings.TrimSpace(trade.Symbol))
+	trade.Side = strings.ToUpper(strings.TrimSpace(trade.Side))
+	if trade.Symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+	if trade.Side != "BUY" && trade.Side != "SELL" {
+		return nil, errors.New("side must be BUY or SELL")
+	}
+	if !trade.Quantity.IsPositive() {
+		return nil, errors.New("quantity must be positive")
+	}
+	if trade.Price.IsNegative() {
+		return nil, errors.New("price must be positive")
+	}
+
+	index := -1
+	for i, position := range portfolio.Positions {
+		if position.Symbol == trade.Symbol {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		if trade.Price.IsZero() {
+			return nil, fmt.Errorf("price is required for %s, which the portfolio does not hold", trade.Symbol)
+		}
+		if trade.AssetType == "" {
+			trade.AssetType = "STOCK"
+		}
+		if trade.Currency == "" {
+			trade.Currency = portfolio.Currency
+		}
+		rate, err := fxRate(trade.Currency, portfolio.Currency)
+		if err != nil {
+			return nil, err
+		}
+		portfolio.Positions = append(portfolio.Positions, models.Position{
+			PortfolioID:  portfolio.ID,
+			Symbol:       trade.Symbol,
+			AveragePrice: trade.Price,
+			CurrentPrice: trade.Price,
+			AssetType:    strings.ToUpper(trade.AssetType),
+			Liquidity:    "HIGH",
+			Currency:     trade.Currency,
+			FXRate:       rate,
+		})
+		index = len(portfolio.Positions) - 1
+	}
+
+	position := &portfolio.Positions[index]
+	trade.AssetType = position.AssetType
+	trade.Currency = position.Currency
+	if trade.Price.IsZero() {
+		trade.Price = position.CurrentPrice
+	}
+	rate := position.FXRate
+	if !rate.IsPositive() {
+		rate = decimal.NewFromInt(1)
+	}
+	trade.Value = trade.Quantity.Mul(trade.Price).Mul(rate).Round(2)
+
+	quantity := trade.Quantity
+	if trade.Side == "SELL" {
+		quantity = quantity.Neg()
+		portfolio.CashBalance = portfolio.CashBalance.Add(trade.Value)
+	} else {
+		portfolio.CashBalance = portfolio.CashBalance.Sub(trade.Value)
+	}
+
+	// Adding to a position in the same direction averages in the trade price; reducing it keeps
+	// the average, and crossing through zero starts again at the trade price
+	held := position.Quantity
+	next := held.Add(quantity)
+	switch {
+	case held.IsZero() || held.Sign() == quantity.Sign():
+		position.AveragePrice = held.Mul(position.AveragePrice).Add(quantity.Mul(trade.Price)).Div(next)
+	case next.Sign() != 0 && next.Sign() != held.Sign():
+		position.AveragePrice = trade.Price
+	}
+	position.Quantity = next
+	revaluePosition(position, position.CurrentPrice, rate)
+
+	if position.Quantity.IsZero() {
+		portfolio.Positions = append(portfolio.Positions[:index], portfolio.Positions[index+1:]...)
+	}
+
+	portfolio.TotalValue = decimal.Zero
+	for _, position := range portfolio.Positions {
+		portfolio.TotalValue = portfolio.TotalValue.Add(position.MarketValue)
+	}
+	return &trade, nil
+}
+
+// profile measures a portfolio's VaR, liquidity and concentration the way the risk endpoints do
+func (s *SimulationService) profile(portfolio *models.Portfolio, thresholds *models.RiskThresholds) (*PortfolioRiskProfile, error) {
+	varValue, varLimit := SimplifiedVaR(portfolio)
+
+	liquidity := liquidityCalculator(portfolio.Positions)
+	liquidityResult, err := liquidity.CalculateLiquidity(portfolio.Positions, portfolio.TotalValue.InexactFloat64())
+	if err != nil {
+		return nil, err
+	}
+	liquidity.IncludeCash(liquidityResult, portfolio.CashBalance.InexactFloat64())
+
+	profile := &PortfolioRiskProfile{
+		TotalValue:     portfolio.TotalValue,
+		CashBalance:    portfolio.CashBalance,
+		VaR:            varValue,
+		VaRLimit:       varLimit,
+		VaRPercent:     SimplifiedVaRPercent(portfolio, varValue),
+		LiquidityRatio: decimal.NewFromFloat(liquidityResult.LiquidityRatio).Round(4),
+		Concentration: s.concentration.CalculateConcentration(portfolio.Positions, calculator.ConcentrationLimits{
+			MaxHHI:            thresholds.MaxConcentration.InexactFloat64(),
+			MaxSingleAsset:    thresholds.MaxSingleAssetExposure.InexactFloat64(),
+			MaxSectorExposure: thresholds.MaxSectorExposure.InexactFloat64(),
+		}),
+		Weights: []SimulatedWeight{},
+	}
+
+	bySymbol := make(map[string]*SimulatedWeight)
+	for _, position := range portfolio.Positions {
+		weight, ok := bySymbol[position.Symbol]
+		if !ok {
+			weight = &SimulatedWeight{Symbol: position.Symbol}
+			bySymbol[position.Symbol] = weight
+		}
+		weight.Quantity = weight.Quantity.Add(position.Quantity)
+		weight.MarketValue = weight.MarketValue.Add(position.MarketValue)
+	}
+	for _, weight := range bySymbol {
+		if portfolio.TotalValue.IsPositive() {
+			weight.Weight = weight.MarketValue.Div(portfolio.TotalValue).Mul(hundred).Round(4)
+		}
+		profile.Weights = append(profile.Weights, *weight)
+	}
+	sort.Slice(profile.Weights, func(i, j int) bool {
+		return profile.Weights[i].MarketValue.GreaterThan(profile.Weights[j].MarketValue)
+	})
+	return profile, nil
+}
+
+// simulationViolations checks the simulated portfolio against its risk thresholds: the size of the
+// positions traded, VaR, the liquidity ratio, concentration and cash
+func simulationViolations(result *SimulationResult, portfolio *models.Portfolio, thresholds *models.RiskThresholds) []RiskViolation {
+	violations := []RiskViolation{}
+	after := result.After
+
+	traded := make(map[string]bool, len(result.Trades))
+	for _, trade := range result.Trades {
+		traded[trade.Symbol] = true
+	}
+	for _, weight := range after.Weights {
+		share := weight.Weight.Div(hundred)
+		if !traded[weight.Symbol] || !thresholds.MaxPositionSize.IsPositive() || !share.GreaterThan(thresholds.MaxPositionSize) {
+			continue
+		}
+		violations = append(violations, RiskViolation{
+			Type:         "POSITION_SIZE",
+			Severity:     "VIOLATION",
+			Description:  fmt.Sprintf("%s would be %s%% of the portfolio, above the maximum", weight.Symbol, weight.Weight.StringFixed(2)),
+			CurrentValue: share,
+			Limit:        thresholds.MaxPositionSize,
+			Impact:       share.Sub(thresholds.MaxPositionSize).Div(thresholds.MaxPositionSize),
+		})
+	}
+
+	if after.VaRLimit.IsPositive() && after.VaR.GreaterThan(after.VaRLimit) {
+		violations = append(violations, RiskViolation{
+			Type:         "VAR_LIMIT",
+			Severity:     "CRITICAL",
+			Description:  "Trades would increase VaR beyond limit",
+			CurrentValue: after.VaR,
+			Limit:        after.VaRLimit,
+			Impact:       after.VaR.Sub(after.VaRLimit).Div(after.VaRLimit),
+		})
+	}
+
+	if thresholds.MinLiquidityRatio.IsPositive() && after.LiquidityRatio.LessThan(thresholds.MinLiquidityRatio) {
+		violations = append(violations, RiskViolation{
+			Type:         "LIQUIDITY_RATIO",
+			Severity:     "WARNING",
+			Description:  "Trades would reduce liquidity below minimum",
+			CurrentValue: after.LiquidityRatio,
+			Limit:        thresholds.MinLiquidityRatio,
+			Impact:       thresholds.MinLiquidityRatio.Sub(after.LiquidityRatio).Div(thresholds.MinLiquidityRatio),
+		})
+	}
+
+	for _, breach := range after.Concentration.Breaches {
+		violations = append(violations, RiskViolation{
+			Type:         breach.Type,
+			Severity:     breach.Severity,
+			Description:  breach.Message,
+			CurrentValue: decimal.NewFromFloat(breach.Value),
+			Limit:        decimal.NewFromFloat(breach.Threshold),
+			Impact:       decimal.NewFromFloat((breach.Value - breach.Threshold) / breach.Threshold),
+		})
+	}
+
+	if portfolio.CashBalance.IsNegative() && !result.Before.CashBalance.IsNegative() {
+		violations = append(violations, RiskViolation{
+			Type:         "INSUFFICIENT_CASH",
+			Severity:     "VIOLATION",
+			Description:  fmt.Sprintf("Trades would overdraw cash by %s", portfolio.CashBalance.Neg().StringFixed(2)),
+			CurrentValue: portfolio.CashBalance,
+		})
+	}
+
+	for _, position := range portfolio.Positions {
+		if position.Quantity.IsNegative() && traded[position.Symbol] {
+			violations = append(violations, RiskViolation{
+				Type:         "SHORT_POSITION",
+				Severity:     "WARNING",
+				Description:  fmt.Sprintf("Trades would leave a short position of %s %s", position.Quantity.Neg().String(), position.Symbol),
+				CurrentValue: position.Quantity,
+			})
+		}
+	}
+	return violations
+}
+
+// complianceChecks runs the trades against the restricted and watch lists and the simulated
+// portfolio against its investment guideline
+func (s *SimulationService) complianceChecks(portfolio *models.Portfolio, trades []SimulatedTrade) ([]PreTradeCheck, error) {
+	restrictedCheck := newPreTradeCheck(PreTradeCheckRestrictedList)
+	watchCheck := newPreTradeCheck(PreTradeCheckWatchList)
+	now := time.Now()
+	for _, trade := range trades {
+		restricted, watched, err := s.symbolListService.Lookup(trade.Symbol, trade.Side, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range restricted {
+			restrictedCheck.add("RESTRICTED_INSTRUMENT", fmt.Sprintf("%s is on the restricted list: %s", entry.Symbol, entry.Reason), true)
+		}
+		for _, entry := range watched {
+			watchCheck.add("WATCH_LIST", fmt.Sprintf("%s is on the watch list: %s", entry.Symbol, entry.Reason), false)
+		}
+	}
+
+	guidelineCheck := newPreTradeCheck(PreTradeCheckGuidelines)
+	guideline, err := s.guidelineService.activeGuideline(portfolio.ID)
+	if err != nil {
+		return nil, err
+	}
+	if guideline == nil {
+		guidelineCheck.Status = PreTradeStatusSkipped
+	} else {
+		for _, breach := range rules.EvaluateGuideline(guideline, portfolio.Positions) {
+			guidelineCheck.add(breach.Guideline, breach.Description, true)
+		}
+	}
+
+	return []PreTradeCheck{*restrictedCheck, *watchCheck, *guidelineCheck}, nil
+}
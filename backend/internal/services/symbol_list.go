@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// RestrictedSymbolError is returned when a trade is in a symbol on the restricted list
+type RestrictedSymbolError struct {
+	Symbol string
+	Reason string
+}
+
+func (e *RestrictedSymbolError) Error() string {
+	return fmt.Sprintf("%s is on the restricted list: %s", e.Symbol, e.Reason)
+}
+
+// symbolList is the restricted or watch list
+type symbolList interface {
+	models.RestrictedList | models.WatchList
+}
+
+// SymbolListService keeps the restricted and watch lists and enforces them on trades: trades in a
+// restricted symbol are rejected, and trades in a watched one raise a compliance alert
+type SymbolListService struct {
+	db           *gorm.DB
+	alertService *AlertService
+	logger       *slog.Logger
+}
+
+func NewSymbolListService() *SymbolListService {
+	return &SymbolListService{
+		db:           database.GetDB(),
+		alertService: NewAlertService(),
+		logger:       logging.Component("symbol_list"),
+	}
+}
+
+// validateListEntry normalises and checks an entry before it is stored. Entries take effect
+// immediately unless given a start.
+func validateListEntry(entry *models.SymbolListEntry) error {
+	entry.Symbol = strings.ToUpper(strings.TrimSpace(entry.Symbol))
+	entry.Side = strings.ToUpper(entry.Side)
+	entry.Reason = strings.TrimSpace(entry.Reason)
+
+	if entry.Symbol == "" || len(entry.Symbol) > 20 {
+		return errors.New("symbol is required and must be at most 20 characters")
+	}
+	if entry.Side != "" && entry.Side != "BUY" && entry.Side != "SELL" {
+		return fmt.Errorf("invalid side %q, use BUY, SELL or leave it empty for both", entry.Side)
+	}
+	if entry.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if entry.EffectiveFrom.IsZero() {
+		entry.EffectiveFrom = time.Now()
+	}
+	if entry.EffectiveTo != nil && !entry.EffectiveTo.After(entry.EffectiveFrom) {
+		return errors.New("effective_to must be after effective_from")
+	}
+	return nil
+}
+
+func listEntries[T symbolList](db *gorm.DB, spec pagination.Spec, params pagination.Params) ([]T, int64, error) {
+	var entries []T
+	total, err := pagination.Find(db.Model(new(T)), spec, params, &entries)
+	return entries, total, err
+}
+
+func getEntry[T symbolList](db *gorm.DB, id uuid.UUID) (*T, error) {
+	var entry T
+	if err := db.First(&entry, id).Error; err != nil {
+		return nil, errors.New("list entry not found")
+	}
+	return &entry, nil
+}
+
+func deleteEntry[T symbolList](db *gorm.DB, id uuid.UUID) (*T, error) {
+	entry, err := getEntry[T](db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Delete(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// inEffect returns the entries of a list covering a trade on side at a time
+func inEffect[T symbolList](db *gorm.DB, symbol, side string, at time.Time) ([]T, error) {
+	var entries []T
+	err := db.Where("symbol = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", strings.ToUpper(symbol), at, at).
+		Where("side IS NULL OR side = '' OR side = ?", side).
+		Order("effective_from ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// ListRestricted returns a page of the restricted list, including entries not in effect
+func (s *SymbolListService) ListRestricted(spec pagination.Spec, params pagination.Params) ([]models.RestrictedList, int64, error) {
+	return listEntries[models.RestrictedList](s.db, spec, params)
+}
+
+// GetRestricted returns a restricted list entry
+func (s *SymbolListService) GetRestricted(id uuid.UUID) (*models.RestrictedList, error) {
+	return getEntry[models.RestrictedList](s.db, id)
+}
+
+// SaveRestricted validates and creates or updates a restricted list entry
+func (s *SymbolListService) SaveRestricted(entry *models.RestrictedList) error {
+	if err := validateListEntry(&entry.SymbolListEntry); err != nil {
+		return err
+	}
+	return s.db.Save(entry).Error
+}
+
+// DeleteRestricted removes a restricted list entry and returns it
+func (s *SymbolListService) DeleteRestricted(id uuid.UUID) (*models.RestrictedList, error) {
+	return deleteEntry[models.RestrictedList](s.db, id)
+}
+
+// ListWatched returns a page of the watch list, including entries not in effect
+func (s *SymbolListService) ListWatched(spec pagination.Spec, params pagination.Params) ([]models.WatchList, int64, error) {
+	return listEntries[models.WatchList](s.db, spec, params)
+}
+
+// GetWatched returns a watch list entry
+func (s *SymbolListService) GetWatched(id uuid.UUID) (*models.WatchList, error) {
+	return getEntry[models.WatchList](s.db, id)
+}
+
+// SaveWatched validates and creates or updates a watch list entry
+func (s *SymbolListService) SaveWatched(entry *models.WatchList) error {
+	if err := validateListEntry(&entry.SymbolListEntry); err != nil {
+		return err
+	}
+	return s.db.Save(entry).Error
+}
+
+// DeleteWatched removes a watch list entry and returns it
+func (s *SymbolListService) DeleteWatched(id uuid.UUID) (*models.WatchList, error) {
+	return deleteEntry[models.WatchList](s.db, id)
+}
+
+// Lookup returns the restricted and watch list entries covering a trade in symbol on side at a time
+func (s *SymbolListService) Lookup(symbol, side string, at time.Time) ([]models.RestrictedList, []models.WatchList, error) {
+	restricted, err := inEffect[models.RestrictedList](s.db, symbol, side, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	watched, err := inEffect[models.WatchList](s.db, symbol, side, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	return restricted, watched, nil
+}
+
+// Enforce checks a BUY or SELL transaction against the lists as of its execution time, or now. It
+// returns a RestrictedSymbolError for a restricted symbol and otherwise the watch list entries to
+// alert on once the transaction is stored.
+func (s *SymbolListService) Enforce(transaction *models.Transaction) ([]models.WatchList, error) {
+	if !isOrder(transaction) {
+		return nil, nil
+	}
+	at := time.Now()
+	if transaction.ExecutedAt != nil {
+		at = *transaction.ExecutedAt
+	}
+
+	restricted, watched, err := s.Lookup(transaction.Symbol, transaction.TransactionType, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(restricted) > 0 {
+		return nil, &RestrictedSymbolError{Symbol: restricted[0].Symbol, Reason: restricted[0].Reason}
+	}
+	return watched, nil
+}
+
+// AlertWatched raises a compliance alert for a stored transaction in a watched symbol
+func (s *SymbolListService) AlertWatched(ctx context.Context, transaction *models.Transaction, watched []models.WatchList) {
+	if len(watched) == 0 {
+		return
+	}
+	reasons := make([]string, 0, len(watched))
+	for _, entry := range watched {
+		reasons = append(reasons, entry.Reason)
+	}
+
+	err := s.alertService.CreateComplianceAlert(ctx, transaction.PortfolioID, "WATCH_LIST", map[string]interface{}{
+		"transaction_id": transaction.ID,
+		"symbol":         transaction.Symbol,
+		"side":           transaction.TransactionType,
+		"reasons":        reasons,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to raise watch list alert", "transaction_id", transaction.ID, "error", err)
+	}
+}
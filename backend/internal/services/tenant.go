@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/compliance/monitoring"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/tenancy"
+)
+
+// TenantService keeps the tenants, their settings and which users belong to them. A user's
+// portfolios, and their transactions and alerts, follow the user into a tenant.
+type TenantService struct {
+	db *gorm.DB
+}
+
+func NewTenantService() *TenantService {
+	return &TenantService{
+		db: database.GetDB(),
+	}
+}
+
+// TenantRequest creates or changes a tenant
+type TenantRequest struct {
+	Name       string                `json:"name" validate:"notblank,max=255"`
+	Settings   models.TenantSettings `json:"settings"`
+	EncryptPII bool                  `json:"encrypt_pii"`
+}
+
+// TenantAssignmentRequest moves a user into a tenant, or out of any when TenantID is nil
+type TenantAssignmentRequest struct {
+	TenantID *uuid.UUID `json:"tenant_id"`
+}
+
+// List returns a page of the tenants
+func (s *TenantService) List(spec pagination.Spec, params pagination.Params) ([]models.Tenant, int64, error) {
+	tenants := []models.Tenant{}
+	total, err := pagination.Find(s.db.Model(&models.Tenant{}), spec, params, &tenants)
+	return tenants, total, err
+}
+
+// Get returns a tenant
+func (s *TenantService) Get(id uuid.UUID) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := s.db.First(&tenant, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Tenant not found")
+		}
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// Create validates and saves a new tenant
+func (s *TenantService) Create(req TenantRequest, userID uuid.UUID) (*models.Tenant, error) {
+	tenant := &models.Tenant{CreatedBy: &userID}
+	if err := s.apply(tenant, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(tenant).Error; err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// Update changes a tenant's name, settings and PII encryption. Turning encryption on or off
+// re-encrypts or decrypts the names of the tenant's users with the change.
+func (s *TenantService) Update(id uuid.UUID, req TenantRequest) (*models.Tenant, error) {
+	tenant, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	wasEncrypted := tenant.EncryptPII
+	if err := s.apply(tenant, req); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(tenant).Error; err != nil {
+			return err
+		}
+		if tenant.EncryptPII != wasEncrypted {
+			return s.rewriteNames(tx, tenant)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if keyring := tenancy.GetKeyring(); keyring != nil {
+		keyring.Forget(tenant.ID)
+	}
+	return tenant, nil
+}
+
+// UpdateSettings replaces a tenant's risk threshold and AML settings, as its own administrators may
+func (s *TenantService) UpdateSettings(id uuid.UUID, settings models.TenantSettings) (*models.Tenant, error) {
+	tenant, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTenantSettings(&settings); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(tenant).Update("settings", settings).Error; err != nil {
+		return nil, err
+	}
+	tenant.Settings = settings
+	return tenant, nil
+}
+
+// AssignUser moves a user into a tenant, or out of any, with the portfolios they own. The user's
+// name is re-encrypted for the tenant they join.
+func (s *TenantService) AssignUser(userID uuid.UUID, tenantID *uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("User not found")
+		}
+		return nil, err
+	}
+	if tenantID != nil {
+		if _, err := s.Get(*tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Tenants are managed by the platform operator's administrators, so one must remain
+	if user.Role == models.RoleAdmin && user.IsActive && user.TenantID == nil && tenantID != nil {
+		var others int64
+		err := s.db.Model(&models.User{}).
+			Where("role = ? AND is_active = ? AND tenant_id IS NULL AND id <> ?", models.RoleAdmin, true, userID).
+			Count(&others).Error
+		if err != nil {
+			return nil, err
+		}
+		if others == 0 {
+			return nil, apperror.BadRequest("Cannot move the last platform administrator into a tenant")
+		}
+	}
+
+	user.TenantID = tenantID
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Select("TenantID", "FirstName", "LastName").Updates(&user).Error; err != nil {
+			return err
+		}
+		// Touching the owner moves each portfolio, its transactions and alerts to the owner's tenant
+		return tx.Exec("UPDATE portfolios SET user_id = user_id WHERE user_id = ?", userID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// apply validates a request and sets it on the tenant, generating a data key the first time
+// encryption is turned on
+func (s *TenantService) apply(tenant *models.Tenant, req TenantRequest) error {
+	name := strings.TrimSpace(req.Name)
+	var taken int64
+	if err := s.db.Model(&models.Tenant{}).Where("name = ? AND id <> ?", name, tenant.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperror.Conflict("A tenant named " + name + " already exists")
+	}
+	if err := validateTenantSettings(&req.Settings); err != nil {
+		return err
+	}
+
+	if req.EncryptPII && tenant.DataKey == nil {
+		keyring := tenancy.GetKeyring()
+		if keyring == nil {
+			return apperror.BadRequest(tenancy.ErrNoMasterKey.Error())
+		}
+		dataKey, err := keyring.NewDataKey()
+		if errors.Is(err, tenancy.ErrNoMasterKey) {
+			return apperror.BadRequest(err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		tenant.DataKey = &dataKey
+	}
+
+	tenant.Name = name
+	tenant.Settings = req.Settings
+	tenant.EncryptPII = req.EncryptPII
+	return nil
+}
+
+// rewriteNames stores the names of a tenant's users encrypted or in plain text to match the
+// tenant's setting. The data key is kept when encryption is turned off so that names it sealed can
+// still be read.
+func (s *TenantService) rewriteNames(tx *gorm.DB, tenant *models.Tenant) error {
+	var users []models.User
+	if err := tx.Unscoped().Where("tenant_id = ?", tenant.ID).Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		firstName, lastName := user.FirstName, user.LastName
+		if tenant.EncryptPII {
+			var err error
+			if firstName, err = tenancy.GetKeyring().SealWith(*tenant.DataKey, firstName); err != nil {
+				return err
+			}
+			if lastName, err = tenancy.GetKeyring().SealWith(*tenant.DataKey, lastName); err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(&user).UpdateColumns(map[string]interface{}{
+			"first_name": firstName,
+			"last_name":  lastName,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTenantSettings rejects negative risk limits and AML settings the monitoring pipeline
+// cannot run
+func validateTenantSettings(settings *models.TenantSettings) error {
+	if settings.RiskThresholds != nil {
+		if err := validateThresholdOverrides(settings.RiskThresholds); err != nil {
+			return apperror.BadRequest("risk_" + err.Error())
+		}
+	}
+	if pipeline := monitoring.GetPipeline(); pipeline != nil && !settings.AML.IsZero() {
+		if _, err := pipeline.WithOverrides(settings.AML); err != nil {
+			return apperror.BadRequest("aml: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// tenantSettingsOf returns the settings of the tenant a row of portfolios or users belongs to, or
+// nil outside any tenant
+func tenantSettingsOf(db *gorm.DB, table string, id uuid.UUID) (*models.TenantSettings, error) {
+	var tenants []models.Tenant
+	err := db.Model(&models.Tenant{}).
+		Joins("JOIN "+table+" ON "+table+".tenant_id = tenants.id").
+		Where(table+".id = ?", id).
+		Limit(1).Find(&tenants).Error
+	if err != nil || len(tenants) == 0 {
+		return nil, err
+	}
+	return &tenants[0].Settings, nil
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+var transactionTypes = map[string]bool{
+	"BUY":        true,
+	"SELL":       true,
+	"DEPOSIT":    true,
+	"WITHDRAWAL": true,
+}
+
+var transactionStatuses = map[string]bool{
+	"PENDING":   true,
+	"COMPLETED": true,
+	"FAILED":    true,
+	"CANCELLED": true,
+}
+
+// ErrTransactionCompleted is returned when an update, status change or deletion would change what a
+// completed transaction traded or settled; completed transactions are corrected and cancelled
+// through amendments instead
+var ErrTransactionCompleted = errors.New("a completed transaction cannot be changed or deleted; request an amendment or cancellation instead")
+
+// CounterpartyBlockedError is returned when a transaction's counterparty may not be traded with
+type CounterpartyBlockedError struct {
+	Flags []string
+}
+
+func (e *CounterpartyBlockedError) Error() string {
+	return "counterparty blocked: " + strings.Join(e.Flags, ", ")
+}
+
+// TransactionService reads and writes transactions through the caller's portfolio access
+type TransactionService struct {
+	db                  *gorm.DB
+	accessService       *AccessService
+	counterpartyService *CounterpartyService
+	cashService         *CashService
+	lotService          *LotService
+	orderService        *OrderService
+	symbolListService   *SymbolListService
+	guidelineService    *InvestmentGuidelineService
+	kycService          *KYCProfileService
+	riskEngine          *RiskEngineService
+	rejectBuysOverCash  bool
+	enforcement         string
+	logger              *slog.Logger
+}
+
+func NewTransactionService(cfg *config.RiskConfig) *TransactionService {
+	return &TransactionService{
+		db:                  database.GetDB(),
+		accessService:       NewAccessService(),
+		counterpartyService: NewCounterpartyService(),
+		cashService:         NewCashService(),
+		lotService:          NewLotService(),
+		orderService:        NewOrderService(),
+		symbolListService:   NewSymbolListService(),
+		guidelineService:    NewInvestmentGuidelineService(),
+		kycService:          NewKYCProfileService(),
+		riskEngine:          NewRiskEngineService(),
+		rejectBuysOverCash:  cfg.RejectBuysExceedingCash,
+		enforcement:         cfg.PreTradeEnforcement,
+		logger:              logging.Component("transaction"),
+	}
+}
+
+// ValidateTransaction checks a new transaction's type, that no quantity or price is negative and
+// that trades carry a symbol, quantity and price and cash movements a positive amount
+func ValidateTransaction(transaction *models.Transaction) error {
+	if !transactionTypes[transaction.TransactionType] {
+		return fmt.Errorf("invalid transaction_type %q", transaction.TransactionType)
+	}
+	if transaction.Quantity.IsNegative() {
+		return models.ErrNegativeQuantity
+	}
+	if transaction.Price.IsNegative() {
+		return models.ErrNegativePrice
+	}
+	switch transaction.TransactionType {
+	case "BUY", "SELL":
+		if transaction.Symbol == "" {
+			return errors.New("symbol is required for BUY and SELL")
+		}
+		if !transaction.Quantity.IsPositive() {
+			return errors.New("quantity must be greater than zero")
+		}
+		if !transaction.Price.IsPositive() {
+			return errors.New("price must be greater than zero")
+		}
+	default:
+		if !transaction.Amount.IsPositive() {
+			return errors.New("amount must be greater than zero")
+		}
+	}
+	return nil
+}
+
+// scoped joins transactions to their portfolio and restricts them to portfolios the user can access.
+// Columns must be qualified with the table name because portfolios shares several of them.
+func (s *TransactionService) scoped(userID uuid.UUID, role string) *gorm.DB {
+	query := s.db.Model(&models.Transaction{}).
+		Joins("JOIN portfolios ON portfolios.id = transactions.portfolio_id")
+	return s.accessService.ScopeQuery(query, "transactions.portfolio_id", userID, role)
+}
+
+// ListTransactions returns a page of the transactions visible to the user and the total match count
+func (s *TransactionService) ListTransactions(userID uuid.UUID, role string, spec pagination.Spec, params pagination.Params) ([]models.Transaction, int64, error) {
+	var transactions []models.Transaction
+	total, err := pagination.Find(s.scoped(userID, role), spec, params, &transactions)
+	return transactions, total, err
+}
+
+// TransactionSearch narrows a transaction search beyond the equality filters and date range of
+// its pagination spec; nil bounds and flags are ignored
+type TransactionSearch struct {
+	MinAmount      *decimal.Decimal
+	MaxAmount      *decimal.Decimal
+	MinRiskScore   *int
+	MaxRiskScore   *int
+	AMLChecked     *bool
+	KYCVerified    *bool
+	RequiresReview *bool
+}
+
+// SearchTransactions returns a page of the transactions visible to the user that match the
+// search, and the total match count
+func (s *TransactionService) SearchTransactions(userID uuid.UUID, role string, spec pagination.Spec, params pagination.Params, search TransactionSearch) ([]models.Transaction, int64, error) {
+	var transactions []models.Transaction
+	total, err := pagination.Find(s.SearchQuery(userID, role, search), spec, params, &transactions)
+	return transactions, total, err
+}
+
+// SearchQuery selects the transactions visible to the user that match the search's ranges and
+// flags, before pagination filters are applied
+func (s *TransactionService) SearchQuery(userID uuid.UUID, role string, search TransactionSearch) *gorm.DB {
+	query := s.scoped(userID, role)
+	if search.MinAmount != nil {
+		query = query.Where("transactions.amount >= ?", *search.MinAmount)
+	}
+	if search.MaxAmount != nil {
+		query = query.Where("transactions.amount <= ?", *search.MaxAmount)
+	}
+	if search.MinRiskScore != nil {
+		query = query.Where("transactions.risk_score >= ?", *search.MinRiskScore)
+	}
+	if search.MaxRiskScore != nil {
+		query = query.Where("transactions.risk_score <= ?", *search.MaxRiskScore)
+	}
+	if search.AMLChecked != nil {
+		query = query.Where("transactions.aml_checked = ?", *search.AMLChecked)
+	}
+	if search.KYCVerified != nil {
+		query = query.Where("transactions.kyc_verified = ?", *search.KYCVerified)
+	}
+	if search.RequiresReview != nil {
+		query = query.Where("transactions.requires_review = ?", *search.RequiresReview)
+	}
+	return query
+}
+
+// GetTransaction returns a transaction if it belongs to a portfolio the user can access
+func (s *TransactionService) GetTransaction(transactionID, userID uuid.UUID, role string) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := s.scoped(userID, role).Where("transactions.id = ?", transactionID).First(&transaction).Error; err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	return &transaction, nil
+}
+
+// CreateTransaction records a transaction against a portfolio the user owns. Withdrawals, and
+// BUYs when configured, are rejected with an InsufficientCashError when the portfolio's available
+// cash does not cover them. BUY and SELL orders start their lifecycle as NEW; those in a restricted
+// symbol are rejected with a RestrictedSymbolError and those in a watched one raise an alert. New
+// orders go through the pre-trade risk checks and are held for approval when flagged for review.
+// Under HOLD enforcement orders the checks reject are held too, and under REJECT they are refused
+// with a RiskRejectedError before anything is saved.
+func (s *TransactionService) CreateTransaction(ctx context.Context, userID uuid.UUID, role string, transaction *models.Transaction) error {
+	if err := s.requireOwnership(userID, role, transaction.PortfolioID); err != nil {
+		return err
+	}
+	transaction.CreatedBy = &userID
+
+	if transaction.Currency == "" {
+		transaction.Currency = "USD"
+	}
+	if transaction.Status == "" {
+		transaction.Status = "PENDING"
+	}
+	if isOrder(transaction) {
+		initOrder(transaction)
+		// The checks below see the amount saving would set
+		transaction.Amount = transaction.TradeAmount()
+	}
+
+	watched, err := s.symbolListService.Enforce(transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := s.kycService.Enforce(transaction); err != nil {
+		return err
+	}
+
+	if err := s.applyCounterparty(transaction); err != nil {
+		return err
+	}
+
+	var analysis *TradeRiskAnalysis
+	if transaction.OrderStatus == models.OrderStatusNew && s.enforcement != EnforcementOff {
+		if analysis, err = s.assessOrder(ctx, transaction); err != nil {
+			return err
+		}
+	}
+
+	var fill *models.Fill
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.cashService.CheckFunds(tx, transaction, s.rejectBuysOverCash); err != nil {
+			return err
+		}
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		if transaction.OrderStatus == models.OrderStatusFilled {
+			fill = fullFill(transaction, &userID)
+			if err := tx.Create(fill).Error; err != nil {
+				return err
+			}
+			if err := s.lotService.Book(tx, transaction, fill); err != nil {
+				return err
+			}
+		}
+		return s.cashService.Settle(tx, transaction, &userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.orderService.PublishCreated(ctx, transaction)
+	if fill != nil {
+		QueueLargeTrade(ctx, transaction, fill)
+	}
+	s.symbolListService.AlertWatched(ctx, transaction, watched)
+	s.guidelineService.AlertTrade(ctx, transaction)
+
+	switch {
+	case analysis != nil:
+		s.riskEngine.RecordAssessment(ctx, transaction, analysis, s.enforcement == EnforcementHold)
+	case transaction.OrderStatus == models.OrderStatusNew:
+		if _, err := s.riskEngine.EvaluateTransaction(ctx, transaction); err != nil {
+			s.logger.WarnContext(ctx, "Risk evaluation failed for new order", "transaction_id", transaction.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// assessOrder runs the pre-trade risk checks on a new order before it is saved, refusing it under
+// REJECT enforcement when the checks reject it. An order that cannot be assessed is not accepted.
+func (s *TransactionService) assessOrder(ctx context.Context, transaction *models.Transaction) (*TradeRiskAnalysis, error) {
+	defer metrics.RiskCalculationDuration.With("pre_trade").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.pre_trade",
+		tracing.String("portfolio_id", transaction.PortfolioID.String()), tracing.String("symbol", transaction.Symbol),
+		tracing.String("risk.enforcement", s.enforcement))
+	defer span.End()
+
+	analysis, err := s.riskEngine.AssessTrade(ctx, transaction)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("pre-trade risk checks failed: %w", err)
+	}
+	span.SetAttributes(tracing.Int64("risk.score", analysis.RiskScore.IntPart()), tracing.String("risk.outcome", tradeOutcome(analysis)))
+
+	if s.enforcement == EnforcementReject && !analysis.Approved && !analysis.RequiresReview {
+		metrics.TradeEvaluations.With(tradeOutcome(analysis)).Inc()
+		s.logger.WarnContext(ctx, "Order refused by pre-trade risk checks", "portfolio_id", transaction.PortfolioID,
+			"symbol", transaction.Symbol, "risk_score", analysis.RiskScore.IntPart(), "violations", len(analysis.Violations))
+		return nil, &RiskRejectedError{RiskScore: analysis.RiskScore, Violations: analysis.Violations}
+	}
+	return analysis, nil
+}
+
+// initOrder sets the order state of an order recorded with a transaction status. One recorded as
+// COMPLETED was filled in full at its price.
+func initOrder(transaction *models.Transaction) {
+	transaction.OrderStatus = models.OrderStatusForStatus(transaction.Status)
+	if transaction.OrderStatus == models.OrderStatusFilled {
+		transaction.FilledQuantity = transaction.Quantity
+		transaction.AverageFillPrice = transaction.Price
+	}
+}
+
+// applyCounterparty copies the linked counterparty's details onto the transaction and runs the
+// counterparty checks, rejecting trades with blocked counterparties and flagging the rest
+func (s *TransactionService) applyCounterparty(transaction *models.Transaction) error {
+	if transaction.CounterpartyID == nil {
+		return nil
+	}
+
+	counterparty, err := s.counterpartyService.GetCounterparty(*transaction.CounterpartyID)
+	if err != nil {
+		return err
+	}
+	transaction.CounterpartyName = counterparty.Name
+	transaction.CounterpartyCountry = counterparty.Jurisdiction
+
+	result, err := s.counterpartyService.CheckTransaction(transaction)
+	if err != nil {
+		return err
+	}
+	if result.Blocked {
+		return &CounterpartyBlockedError{Flags: result.Flags}
+	}
+
+	if len(result.Flags) > 0 {
+		transaction.RiskScore = result.RiskScore
+		transaction.ComplianceNotes = "Counterparty flags: " + strings.Join(result.Flags, ", ")
+	}
+	return nil
+}
+
+// UpdateTransaction saves changes to a transaction in a portfolio the user owns. What an executed
+// transaction traded cannot change, only its notes, and an order's quantity and price can only
+// change while it is NEW. Saving sets a trade's amount from its quantity and price.
+func (s *TransactionService) UpdateTransaction(userID uuid.UUID, role string, transaction *models.Transaction) error {
+	if err := s.requireOwnership(userID, role, transaction.PortfolioID); err != nil {
+		return errors.New("transaction not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transaction.ID).First(&stored).Error; err != nil {
+			return err
+		}
+		if err := requireNotHeld(&stored); err != nil {
+			return err
+		}
+		if stored.Executed() && tradeChanged(&stored, transaction) {
+			return ErrTransactionCompleted
+		}
+		if stored.ApprovalStatus == models.ApprovalApproved &&
+			(!stored.Quantity.Equal(transaction.Quantity) || !stored.Price.Equal(transaction.Price) || !stored.Amount.Equal(transaction.Amount)) {
+			return errors.New("an approved transaction's quantity, price and amount cannot change")
+		}
+		if isOrder(&stored) && stored.OrderStatus != models.OrderStatusNew &&
+			(!stored.Quantity.Equal(transaction.Quantity) || !stored.Price.Equal(transaction.Price)) {
+			return errors.New("only NEW orders can change quantity or price")
+		}
+		// Lifecycle fields only change through fills and transitions
+		transaction.OrderStatus = stored.OrderStatus
+		transaction.OrderStatusReason = stored.OrderStatusReason
+		transaction.FilledQuantity = stored.FilledQuantity
+		transaction.AverageFillPrice = stored.AverageFillPrice
+		transaction.RealizedPnL = stored.RealizedPnL
+		transaction.Status = stored.Status
+		transaction.CreatedBy = stored.CreatedBy
+		transaction.ApprovalStatus = stored.ApprovalStatus
+		transaction.ApprovalReason = stored.ApprovalReason
+		transaction.ReviewedBy = stored.ReviewedBy
+		transaction.ReviewedAt = stored.ReviewedAt
+		transaction.ReviewComment = stored.ReviewComment
+		transaction.AmendsID = stored.AmendsID
+		transaction.SupersededAt = stored.SupersededAt
+		return tx.Save(transaction).Error
+	})
+}
+
+// tradeChanged reports whether an update changes what a transaction traded: its type, symbol,
+// quantity, price, amount or currency
+func tradeChanged(stored, updated *models.Transaction) bool {
+	return stored.TransactionType != updated.TransactionType || stored.Symbol != updated.Symbol ||
+		!stored.Quantity.Equal(updated.Quantity) || !stored.Price.Equal(updated.Price) ||
+		!stored.Amount.Equal(updated.Amount) || stored.Currency != updated.Currency
+}
+
+// DeleteTransaction soft deletes a transaction from a portfolio the user owns, reversing its cash
+// postings and its lots. Executed transactions are cancelled through amendments instead.
+func (s *TransactionService) DeleteTransaction(userID uuid.UUID, role string, transaction *models.Transaction) error {
+	if err := s.requireOwnership(userID, role, transaction.PortfolioID); err != nil {
+		return errors.New("transaction not found")
+	}
+	if transaction.Executed() {
+		return ErrTransactionCompleted
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.cashService.Reverse(tx, transaction, &userID); err != nil {
+			return err
+		}
+		if err := s.lotService.Unbook(tx, transaction); err != nil {
+			return err
+		}
+		return tx.Delete(transaction).Error
+	})
+}
+
+// RestoreTransaction undeletes a soft deleted transaction and settles its cash and books its lots
+// again. Its portfolio must not itself be deleted; restoring the portfolio brings back the
+// transactions deleted with it.
+func (s *TransactionService) RestoreTransaction(transactionID, userID uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := s.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", transactionID).First(&transaction).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("deleted transaction not found")
+		}
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireLivePortfolio(tx, transaction.PortfolioID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&transaction).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		transaction.DeletedAt = gorm.DeletedAt{}
+		if err := s.lotService.Rebook(tx, &transaction); err != nil {
+			return err
+		}
+		return s.cashService.Settle(tx, &transaction, &userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// requireLivePortfolio rejects restoring a record into a portfolio that is itself soft deleted
+func requireLivePortfolio(db *gorm.DB, portfolioID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.Portfolio{}).Where("id = ?", portfolioID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("portfolio is deleted; restore the portfolio first")
+	}
+	return nil
+}
+
+// UpdateStatus changes a transaction's status and settles its cash: completing it moves the
+// portfolio's cash balance. Orders go through their lifecycle instead: COMPLETED fills the rest of
+// the order at its price, CANCELLED cancels it and FAILED rejects it. An executed transaction keeps
+// its status; it is cancelled through an amendment. Callers are gated by the approve permission,
+// so read access suffices.
+func (s *TransactionService) UpdateStatus(ctx context.Context, transaction *models.Transaction, status string, userID uuid.UUID) error {
+	if !transactionStatuses[status] {
+		return fmt.Errorf("invalid status %q", status)
+	}
+	if err := requireNotHeld(transaction); err != nil {
+		return err
+	}
+	if transaction.Executed() && status != transaction.Status {
+		return ErrTransactionCompleted
+	}
+
+	if isOrder(transaction) {
+		switch status {
+		case "COMPLETED":
+			return s.orderService.FillRemaining(ctx, transaction, userID)
+		case "CANCELLED":
+			return s.orderService.Transition(ctx, transaction, models.OrderStatusCancelled, "", userID)
+		case "FAILED":
+			return s.orderService.Transition(ctx, transaction, models.OrderStatusRejected, "", userID)
+		}
+		if transaction.OrderStatus != models.OrderStatusNew {
+			return &InvalidTransitionError{From: transaction.OrderStatus, To: models.OrderStatusNew}
+		}
+		return nil
+	}
+
+	transaction.Status = status
+	if status == "COMPLETED" {
+		now := time.Now()
+		transaction.ExecutedAt = &now
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(transaction).Error; err != nil {
+			return err
+		}
+		return s.cashService.Settle(tx, transaction, &userID)
+	})
+}
+
+func (s *TransactionService) requireOwnership(userID uuid.UUID, role string, portfolioID uuid.UUID) error {
+	owns, err := s.accessService.CanModifyPortfolio(userID, role, portfolioID)
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return errors.New("portfolio not found")
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// AmendmentRequest asks for a completed transaction to be corrected or cancelled. Fields left out
+// of an AMEND keep the transaction's values; a CANCEL takes none.
+type AmendmentRequest struct {
+	Action     string           `json:"action" validate:"required,oneof=AMEND CANCEL"`
+	Reason     string           `json:"reason" validate:"notblank,max=1000"`
+	Symbol     *string          `json:"symbol"`
+	Quantity   *decimal.Decimal `json:"quantity"`
+	Price      *decimal.Decimal `json:"price"`
+	Amount     *decimal.Decimal `json:"amount"` // DEPOSIT and WITHDRAWAL; trades are quantity times price
+	Currency   *string          `json:"currency"`
+	ExecutedAt *time.Time       `json:"executed_at"`
+}
+
+// AmendmentDecision approves or rejects a pending amendment
+type AmendmentDecision struct {
+	Decision string `json:"decision" validate:"required,oneof=APPROVED REJECTED"`
+	Comment  string `json:"comment"`
+}
+
+// TransactionAmendmentService corrects and cancels completed transactions under four-eyes review.
+// A request records the values the transaction has and those asked for; once another user approves
+// it the original's cash postings and lots are reversed and it is marked AMENDED, with a replacement
+// booked and settled in its place, or CANCELLED. The original's trade fields are never changed.
+type TransactionAmendmentService struct {
+	db            *gorm.DB
+	accessService *AccessService
+	cashService   *CashService
+	lotService    *LotService
+	alertService  *AlertService
+	logger        *slog.Logger
+}
+
+func NewTransactionAmendmentService() *TransactionAmendmentService {
+	return &TransactionAmendmentService{
+		db:            database.GetDB(),
+		accessService: NewAccessService(),
+		cashService:   NewCashService(),
+		lotService:    NewLotService(),
+		alertService:  NewAlertService(),
+		logger:        logging.Component("amendment"),
+	}
+}
+
+// Request records an amendment or cancellation of a completed transaction in a portfolio the user
+// can modify and raises an alert for approvers. A transaction has one pending amendment at a time.
+func (s *TransactionAmendmentService) Request(ctx context.Context, transaction *models.Transaction, req AmendmentRequest, userID uuid.UUID, role string) (*models.TransactionAmendment, error) {
+	owns, err := s.accessService.CanModifyPortfolio(userID, role, transaction.PortfolioID)
+	if err != nil {
+		return nil, apperror.Internal("Failed to check access", err)
+	}
+	if !owns {
+		return nil, apperror.NotFound("Transaction not found")
+	}
+
+	amendment := &models.TransactionAmendment{
+		TransactionID: transaction.ID,
+		PortfolioID:   transaction.PortfolioID,
+		Action:        req.Action,
+		Status:        models.ApprovalPending,
+		Reason:        strings.TrimSpace(req.Reason),
+		RequestedBy:   userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, transaction); err != nil {
+			return apperror.NotFound("Transaction not found")
+		}
+		if err := requireAmendable(transaction); err != nil {
+			return err
+		}
+
+		var pending int64
+		err := tx.Model(&models.TransactionAmendment{}).
+			Where("transaction_id = ? AND status = ?", transaction.ID, models.ApprovalPending).Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return apperror.Conflict("An amendment of this transaction is already awaiting approval")
+		}
+
+		if req.Action == models.AmendmentCancel {
+			if req.changes() {
+				return apperror.BadRequest("A cancellation takes no new values")
+			}
+			amendment.OldValues.Status = transaction.Status
+			amendment.NewValues.Status = "CANCELLED"
+		} else if amendment.OldValues, amendment.NewValues, err = amendedValues(transaction, req); err != nil {
+			return err
+		}
+		return tx.Create(amendment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Transaction amendment requested", "amendment_id", amendment.ID,
+		"transaction_id", transaction.ID, "action", amendment.Action)
+	s.alertService.CreateComplianceAlert(ctx, transaction.PortfolioID, "AMENDMENT_REQUESTED", map[string]interface{}{
+		"amendment_id":   amendment.ID,
+		"transaction_id": transaction.ID,
+		"action":         amendment.Action,
+		"reason":         amendment.Reason,
+	})
+	return amendment, nil
+}
+
+// requireAmendable rejects amending a transaction that has not completed or has already been
+// amended or cancelled; corrections of a replacement amend the replacement
+func requireAmendable(transaction *models.Transaction) error {
+	if transaction.SupersededAt != nil {
+		return apperror.Conflict("Transaction has already been " + strings.ToLower(transaction.Status) + "; amend its replacement instead")
+	}
+	if transaction.Status != "COMPLETED" {
+		return apperror.Conflict("Only completed transactions are amended; edit or cancel it directly")
+	}
+	return nil
+}
+
+// changes reports whether the request carries any new values
+func (r AmendmentRequest) changes() bool {
+	return r.Symbol != nil || r.Quantity != nil || r.Price != nil || r.Amount != nil || r.Currency != nil || r.ExecutedAt != nil
+}
+
+// amendedValues checks an AMEND against the transaction and returns the values it changes, before
+// and after. A trade's values are what it executed: its filled quantity at the average fill price.
+// Its amount follows them; only cash movements take one.
+func amendedValues(transaction *models.Transaction, req AmendmentRequest) (before, after models.AmendmentValues, err error) {
+	if transaction.IsTrade() && req.Amount != nil {
+		return before, after, apperror.BadRequest("A trade's amount is its quantity times its price; amend those instead")
+	}
+	if !transaction.IsTrade() && (req.Symbol != nil || req.Quantity != nil || req.Price != nil) {
+		return before, after, apperror.BadRequest("Symbol, quantity and price only apply to BUY and SELL")
+	}
+
+	after = models.AmendmentValues{
+		Symbol:     req.Symbol,
+		Quantity:   req.Quantity,
+		Price:      req.Price,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+		ExecutedAt: req.ExecutedAt,
+	}
+	if after.Currency != nil {
+		currency := strings.ToUpper(*after.Currency)
+		if _, err := fxRate(currency, transaction.Currency); err != nil {
+			return before, after, apperror.BadRequest(err.Error())
+		}
+		after.Currency = &currency
+	}
+	if after.ExecutedAt != nil && after.ExecutedAt.After(time.Now()) {
+		return before, after, apperror.BadRequest("executed_at is in the future")
+	}
+
+	executed := replacement(transaction, models.AmendmentValues{}, uuid.Nil)
+	amended := replacement(transaction, after, uuid.Nil)
+	if err := ValidateTransaction(amended); err != nil {
+		return before, after, apperror.BadRequest(err.Error())
+	}
+
+	after = models.AmendmentValues{}
+	if amended.Symbol != executed.Symbol {
+		before.Symbol, after.Symbol = &executed.Symbol, &amended.Symbol
+	}
+	if !amended.Quantity.Equal(executed.Quantity) {
+		before.Quantity, after.Quantity = &executed.Quantity, &amended.Quantity
+	}
+	if !amended.Price.Equal(executed.Price) {
+		before.Price, after.Price = &executed.Price, &amended.Price
+	}
+	if !amended.Amount.Equal(executed.Amount) {
+		before.Amount, after.Amount = &executed.Amount, &amended.Amount
+	}
+	if amended.Currency != executed.Currency {
+		before.Currency, after.Currency = &executed.Currency, &amended.Currency
+	}
+	if amended.ExecutedAt != nil && (executed.ExecutedAt == nil || !amended.ExecutedAt.Equal(*executed.ExecutedAt)) {
+		before.ExecutedAt, after.ExecutedAt = executed.ExecutedAt, amended.ExecutedAt
+	}
+	if after == (models.AmendmentValues{}) {
+		return before, after, apperror.BadRequest("An amendment must change the symbol, quantity, price, amount, currency or executed_at")
+	}
+	return before, after, nil
+}
+
+// replacement is the transaction an amendment books in place of the original: a completed copy of
+// what the original executed carrying the new values, filled in full at its price when it is a
+// trade, with its amount set from them
+func replacement(original *models.Transaction, values models.AmendmentValues, userID uuid.UUID) *models.Transaction {
+	transaction := &models.Transaction{
+		PortfolioID:         original.PortfolioID,
+		TransactionType:     original.TransactionType,
+		Symbol:              original.Symbol,
+		Quantity:            original.Quantity,
+		Price:               original.Price,
+		Amount:              original.Amount,
+		Currency:            original.Currency,
+		Status:              "COMPLETED",
+		ExecutedAt:          original.ExecutedAt,
+		Notes:               original.Notes,
+		CounterpartyID:      original.CounterpartyID,
+		CounterpartyName:    original.CounterpartyName,
+		CounterpartyCountry: original.CounterpartyCountry,
+		KYCVerified:         original.KYCVerified,
+		AMLChecked:          original.AMLChecked,
+		RiskScore:           original.RiskScore,
+		ComplianceNotes:     original.ComplianceNotes,
+		Side:                original.Side,
+		AssetType:           original.AssetType,
+		CreditRating:        original.CreditRating,
+		StopLoss:            original.StopLoss,
+		TakeProfit:          original.TakeProfit,
+		AmendsID:            &original.ID,
+		CreatedBy:           &userID,
+	}
+	if original.IsTrade() && original.FilledQuantity.IsPositive() {
+		transaction.Quantity = original.FilledQuantity
+		transaction.Price = original.AverageFillPrice
+	}
+	if values.Symbol != nil {
+		transaction.Symbol = *values.Symbol
+	}
+	if values.Quantity != nil {
+		transaction.Quantity = *values.Quantity
+	}
+	if values.Price != nil {
+		transaction.Price = *values.Price
+	}
+	if values.Amount != nil {
+		transaction.Amount = *values.Amount
+	}
+	if values.Currency != nil {
+		transaction.Currency = *values.Currency
+	}
+	if values.ExecutedAt != nil {
+		transaction.ExecutedAt = values.ExecutedAt
+	}
+	if isOrder(transaction) {
+		initOrder(transaction)
+		transaction.Amount = transaction.TradeAmount()
+	}
+	return transaction
+}
+
+// Decide approves or rejects a pending amendment of a transaction the reviewer can access. The
+// reviewer cannot be the user who requested it. Approval applies it in one database transaction:
+// the original's cash is reversed and its lots unbooked, then it is marked CANCELLED, or AMENDED
+// with its replacement booked and settled.
+func (s *TransactionAmendmentService) Decide(ctx context.Context, amendmentID uuid.UUID, decision AmendmentDecision, reviewerID uuid.UUID, role string) (*models.TransactionAmendment, error) {
+	amendment, err := s.Get(amendmentID, reviewerID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", amendment.ID).First(amendment).Error; err != nil {
+			return err
+		}
+		if amendment.Status != models.ApprovalPending {
+			return apperror.Conflict("Amendment has already been " + strings.ToLower(amendment.Status))
+		}
+		if amendment.RequestedBy == reviewerID {
+			return apperror.Forbidden("An amendment must be approved by a user other than the one who requested it")
+		}
+
+		now := time.Now()
+		amendment.Status = decision.Decision
+		amendment.ReviewedBy = &reviewerID
+		amendment.ReviewedAt = &now
+		amendment.ReviewComment = decision.Comment
+		if decision.Decision == models.ApprovalApproved {
+			if err := s.apply(tx, amendment, reviewerID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(amendment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Transaction amendment decided", "amendment_id", amendment.ID,
+		"transaction_id", amendment.TransactionID, "action", amendment.Action, "status", amendment.Status)
+	return amendment, nil
+}
+
+// apply supersedes the original transaction of an approved amendment
+func (s *TransactionAmendmentService) apply(tx *gorm.DB, amendment *models.TransactionAmendment, reviewerID uuid.UUID) error {
+	original := &models.Transaction{ID: amendment.TransactionID}
+	if err := lockOrder(tx, original); err != nil {
+		return apperror.NotFound("Transaction not found")
+	}
+	if err := requireAmendable(original); err != nil {
+		return err
+	}
+
+	if err := s.cashService.Reverse(tx, original, &reviewerID); err != nil {
+		return err
+	}
+	if err := s.lotService.Unbook(tx, original); err != nil {
+		return err
+	}
+
+	status := "CANCELLED"
+	if amendment.Action == models.AmendmentAmend {
+		status = models.TransactionStatusAmended
+		booked := replacement(original, amendment.NewValues, amendment.RequestedBy)
+		if err := tx.Create(booked).Error; err != nil {
+			return err
+		}
+		if isOrder(booked) {
+			fill := fullFill(booked, &reviewerID)
+			if err := tx.Create(fill).Error; err != nil {
+				return err
+			}
+			if err := s.lotService.Book(tx, booked, fill); err != nil {
+				return err
+			}
+		}
+		if err := s.cashService.Settle(tx, booked, &reviewerID); err != nil {
+			return err
+		}
+		amendment.ReplacementID = &booked.ID
+	}
+
+	return tx.Model(original).Updates(map[string]interface{}{
+		"status":        status,
+		"superseded_at": time.Now(),
+	}).Error
+}
+
+// Get returns an amendment of a transaction in a portfolio the user can access
+func (s *TransactionAmendmentService) Get(amendmentID, userID uuid.UUID, role string) (*models.TransactionAmendment, error) {
+	var amendment models.TransactionAmendment
+	query := s.accessService.ScopeQuery(s.db.Model(&models.TransactionAmendment{}), "portfolio_id", userID, role)
+	if err := query.Where("id = ?", amendmentID).First(&amendment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("Amendment not found")
+		}
+		return nil, err
+	}
+	return &amendment, nil
+}
+
+// ListForTransaction returns the amendments of a transaction and the one that booked it, if it
+// is a replacement, oldest first
+func (s *TransactionAmendmentService) ListForTransaction(transactionID uuid.UUID) ([]models.TransactionAmendment, error) {
+	amendments := []models.TransactionAmendment{}
+	err := s.db.Where("transaction_id = ? OR replacement_id = ?", transactionID, transactionID).
+		Order("created_at ASC").Find(&amendments).Error
+	return amendments, err
+}
+
+// List returns a page of the amendments of transactions in portfolios the user can access and the
+// total match count
+func (s *TransactionAmendmentService) List(userID uuid.UUID, role string, spec pagination.Spec, params pagination.Params) ([]models.TransactionAmendment, int64, error) {
+	amendments := []models.TransactionAmendment{}
+	query := s.accessService.ScopeQuery(s.db.Model(&models.TransactionAmendment{}), "portfolio_id", userID, role)
+	total, err := pagination.Find(query, spec, params, &amendments)
+	return amendments, total, err
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// ApprovalPendingError is returned when a transaction held for approval is filled, settled or
+// edited before it has been approved
+type ApprovalPendingError struct {
+	TransactionID uuid.UUID
+}
+
+func (e *ApprovalPendingError) Error() string {
+	return "transaction is awaiting four-eyes approval"
+}
+
+// SelfApprovalError is returned when the user who created a transaction tries to approve or reject it
+type SelfApprovalError struct{}
+
+func (e *SelfApprovalError) Error() string {
+	return "a transaction must be approved by a user other than the one who created it"
+}
+
+// TransactionApprovalService holds pending transactions flagged for review by the risk engine or
+// the AML checks until a second user approves or rejects them
+type TransactionApprovalService struct {
+	db           *gorm.DB
+	alertService *AlertService
+	orderService *OrderService
+	logger       *slog.Logger
+}
+
+func NewTransactionApprovalService() *TransactionApprovalService {
+	return &TransactionApprovalService{
+		db:           database.GetDB(),
+		alertService: NewAlertService(),
+		orderService: NewOrderService(),
+		logger:       logging.Component("approval"),
+	}
+}
+
+// requireNotHeld rejects changes to a transaction awaiting approval
+func requireNotHeld(transaction *models.Transaction) error {
+	if transaction.Status == models.TransactionStatusPendingApproval {
+		return &ApprovalPendingError{TransactionID: transaction.ID}
+	}
+	return nil
+}
+
+// Hold moves a flagged transaction to PENDING_APPROVAL and raises an alert for approvers. Only
+// pending transactions with nothing filled are held; completed ones have already settled and those
+// approved once stay approved. It reports whether the transaction was held.
+func (s *TransactionApprovalService) Hold(ctx context.Context, transaction *models.Transaction, reason string) (bool, error) {
+	if transaction.Status != "PENDING" || transaction.FilledQuantity.IsPositive() ||
+		transaction.ApprovalStatus == models.ApprovalApproved {
+		return false, nil
+	}
+
+	result := s.db.Model(&models.Transaction{}).
+		Where("id = ? AND status = ? AND COALESCE(filled_quantity, 0) = 0 AND COALESCE(approval_status, '') <> ?",
+			transaction.ID, "PENDING", models.ApprovalApproved).
+		Updates(map[string]interface{}{
+			"status":          models.TransactionStatusPendingApproval,
+			"approval_status": models.ApprovalPending,
+			"approval_reason": reason,
+			"requires_review": true,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	transaction.Status = models.TransactionStatusPendingApproval
+	transaction.ApprovalStatus = models.ApprovalPending
+	transaction.ApprovalReason = reason
+	transaction.RequiresReview = true
+
+	s.logger.InfoContext(ctx, "Transaction held for approval", "transaction_id", transaction.ID,
+		"portfolio_id", transaction.PortfolioID, "reason", reason)
+	s.alertService.CreateComplianceAlert(ctx, transaction.PortfolioID, "APPROVAL_REQUIRED", map[string]interface{}{
+		"transaction_id": transaction.ID,
+		"reason":         reason,
+	})
+	return true, nil
+}
+
+// Decide approves or rejects a held transaction. An approved transaction goes back to PENDING to
+// be filled or completed; a rejected one FAILS, rejecting its order. The reviewer cannot be the
+// user who created the transaction.
+func (s *TransactionApprovalService) Decide(ctx context.Context, transaction *models.Transaction, decision, comment string, reviewerID uuid.UUID) error {
+	if decision != models.ApprovalApproved && decision != models.ApprovalRejected {
+		return errors.New("decision must be APPROVED or REJECTED")
+	}
+
+	var from string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, transaction); err != nil {
+			return err
+		}
+		if transaction.Status != models.TransactionStatusPendingApproval {
+			return errors.New("transaction is not awaiting approval")
+		}
+		if transaction.CreatedBy != nil && *transaction.CreatedBy == reviewerID {
+			return &SelfApprovalError{}
+		}
+
+		now := time.Now()
+		transaction.ApprovalStatus = decision
+		transaction.ReviewedBy = &reviewerID
+		transaction.ReviewedAt = &now
+		transaction.ReviewComment = comment
+
+		from = transaction.OrderStatus
+		if decision == models.ApprovalApproved {
+			transaction.Status = "PENDING"
+		} else {
+			transaction.Status = "FAILED"
+			if isOrder(transaction) {
+				transaction.OrderStatus = models.OrderStatusRejected
+				transaction.OrderStatusReason = "Rejected in four-eyes review"
+				if comment != "" {
+					transaction.OrderStatusReason += ": " + comment
+				}
+			}
+		}
+		return tx.Save(transaction).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if isOrder(transaction) && transaction.OrderStatus != from {
+		s.orderService.publish(ctx, transaction, from, nil)
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/imports"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+const (
+	// MaxImportRows caps the trades read from one file
+	MaxImportRows = 50000
+	// maxImportRowErrors caps the row errors stored on an import; the failed count stays exact
+	maxImportRowErrors  = 500
+	maxExternalIDLength = 100
+)
+
+// ImportRequest describes an uploaded trade file
+type ImportRequest struct {
+	UserID             uuid.UUID
+	Role               string
+	Format             string // csv, fix, kafka
+	FileName           string
+	IdempotencyKey     string
+	DefaultPortfolioID *uuid.UUID // Used for rows without a portfolio
+}
+
+// TransactionImportService loads historical trades from CSV and FIX drop-copy files. Each row is
+// validated and stored on its own so one bad row does not reject the file, and every imported
+// trade goes through the risk engine and the AML checks.
+type TransactionImportService struct {
+	db                 *gorm.DB
+	accessService      *AccessService
+	transactionService *TransactionService
+	cashService        *CashService
+	lotService         *LotService
+	riskEngine         *RiskEngineService
+	amlService         *AMLService
+	logger             *slog.Logger
+}
+
+func NewTransactionImportService(cfg *config.RiskConfig) *TransactionImportService {
+	return &TransactionImportService{
+		db:                 database.GetDB(),
+		accessService:      NewAccessService(),
+		transactionService: NewTransactionService(cfg),
+		cashService:        NewCashService(),
+		lotService:         NewLotService(),
+		riskEngine:         NewRiskEngineService(),
+		amlService:         NewAMLService(),
+		logger:             logging.Component("transaction_import"),
+	}
+}
+
+// importRun holds the state of one import while its file is read
+type importRun struct {
+	record     *models.TransactionImport
+	req        ImportRequest
+	modifiable map[uuid.UUID]bool
+}
+
+// ImportTransactions reads trades from r and returns the import record. When the user already
+// made an import with the same idempotency key, that import is returned with replayed set and
+// the file is not read.
+func (s *TransactionImportService) ImportTransactions(ctx context.Context, r io.Reader, req ImportRequest) (record *models.TransactionImport, replayed bool, err error) {
+	var parse func(io.Reader, imports.RowHandler) error
+	switch req.Format {
+	case imports.FormatCSV:
+		parse = imports.ParseCSV
+	case imports.FormatFIX:
+		parse = imports.ParseFIX
+	default:
+		return nil, false, errors.New("unsupported import format, use csv or fix")
+	}
+
+	if req.IdempotencyKey != "" {
+		if existing, err := s.findByKey(req.UserID, req.IdempotencyKey); err == nil {
+			return existing, true, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	record = &models.TransactionImport{
+		ImportedBy: req.UserID,
+		Format:     req.Format,
+		FileName:   req.FileName,
+		Status:     models.ImportStatusProcessing,
+		Errors:     []models.ImportRowError{},
+	}
+	if req.IdempotencyKey != "" {
+		record.IdempotencyKey = &req.IdempotencyKey
+	}
+
+	// A concurrent upload with the same key wins the insert; return its record
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		existing, err := s.findByKey(req.UserID, req.IdempotencyKey)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, true, nil
+	}
+
+	if _, err := s.run(ctx, record, req, func(handle imports.RowHandler) error { return parse(r, handle) }); err != nil {
+		return nil, false, err
+	}
+	return record, false, nil
+}
+
+// ImportStream imports trades read from a message stream, such as a Kafka topic, as one import
+// named after source. parse passes each trade to its handler in order. The error that stopped
+// parse is returned as well as recorded, so the caller can deliver the trades from the one that
+// failed again; the trades before it stay imported.
+func (s *TransactionImportService) ImportStream(ctx context.Context, req ImportRequest, source string, parse func(imports.RowHandler) error) (*models.TransactionImport, error) {
+	record := &models.TransactionImport{
+		ImportedBy: req.UserID,
+		Format:     req.Format,
+		FileName:   source,
+		Status:     models.ImportStatusProcessing,
+		Errors:     []models.ImportRowError{},
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	parseErr, err := s.run(ctx, record, req, parse)
+	if err != nil {
+		return nil, err
+	}
+	return record, parseErr
+}
+
+// run reads the trades of an import, stores the outcome on its record and returns the error
+// that stopped parsing, if any. The returned error is the failure to save the record.
+func (s *TransactionImportService) run(ctx context.Context, record *models.TransactionImport, req ImportRequest, parse func(imports.RowHandler) error) (parseErr, err error) {
+	run := &importRun{
+		record:     record,
+		req:        req,
+		modifiable: make(map[uuid.UUID]bool),
+	}
+
+	parseErr = parse(func(trade imports.Trade, rowErr error) error {
+		if record.TotalRows >= MaxImportRows {
+			return fmt.Errorf("file has more than %d rows; split it into smaller files", MaxImportRows)
+		}
+		record.TotalRows++
+
+		if rowErr != nil {
+			run.fail(trade, rowErr)
+			return nil
+		}
+		return s.importTrade(ctx, run, trade)
+	})
+
+	record.Status = models.ImportStatusCompleted
+	if parseErr != nil {
+		record.Status = models.ImportStatusFailed
+		record.Message = parseErr.Error()
+	}
+	if err := s.db.Save(record).Error; err != nil {
+		return parseErr, err
+	}
+
+	s.logger.InfoContext(ctx, "Transaction import finished", "import_id", record.ID, "format", record.Format,
+		"status", record.Status, "rows", record.TotalRows, "imported", record.Imported,
+		"duplicates", record.Duplicates, "failed", record.Failed)
+
+	return parseErr, nil
+}
+
+// GetImport returns an import made by the user
+func (s *TransactionImportService) GetImport(importID, userID uuid.UUID) (*models.TransactionImport, error) {
+	var record models.TransactionImport
+	if err := s.db.Where("id = ? AND imported_by = ?", importID, userID).First(&record).Error; err != nil {
+		return nil, errors.New("import not found")
+	}
+	return &record, nil
+}
+
+func (s *TransactionImportService) findByKey(userID uuid.UUID, key string) (*models.TransactionImport, error) {
+	var record models.TransactionImport
+	err := s.db.Where("imported_by = ? AND idempotency_key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// importTrade validates and stores one trade, then runs the risk and AML checks on it. Row
+// problems are recorded on the import; only database failures are returned and stop the import.
+func (s *TransactionImportService) importTrade(ctx context.Context, run *importRun, trade imports.Trade) error {
+	transaction, err := s.buildTransaction(run, trade)
+	if err != nil {
+		run.fail(trade, err)
+		return nil
+	}
+
+	// Trades are checked against the restricted and watch lists as of their execution time
+	watched, err := s.transactionService.symbolListService.Enforce(transaction)
+	if err != nil {
+		var restricted *RestrictedSymbolError
+		if errors.As(err, &restricted) {
+			run.fail(trade, err)
+			return nil
+		}
+		return err
+	}
+
+	if err := s.transactionService.kycService.Enforce(transaction); err != nil {
+		var blocked *KYCBlockedError
+		if errors.As(err, &blocked) {
+			run.fail(trade, err)
+			return nil
+		}
+		return err
+	}
+
+	if err := s.transactionService.applyCounterparty(transaction); err != nil {
+		var blocked *CounterpartyBlockedError
+		if errors.As(err, &blocked) || err.Error() == "counterparty not found" {
+			run.fail(trade, err)
+			return nil
+		}
+		return err
+	}
+
+	// The unique (portfolio_id, external_id) index turns a repeated trade into a no-op. Completed
+	// trades settle against the portfolio's cash as they are stored.
+	var inserted bool
+	var fill *models.Fill
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(transaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		inserted = true
+		if transaction.OrderStatus == models.OrderStatusFilled {
+			fill = fullFill(transaction, &run.req.UserID)
+			if err := tx.Create(fill).Error; err != nil {
+				return err
+			}
+			if err := s.lotService.Book(tx, transaction, fill); err != nil {
+				return err
+			}
+		}
+		return s.cashService.Settle(tx, transaction, &run.req.UserID)
+	})
+	if err != nil {
+		var insufficient *InsufficientCashError
+		if errors.As(err, &insufficient) {
+			run.fail(trade, err)
+			return nil
+		}
+		return err
+	}
+	if !inserted {
+		run.record.Duplicates++
+		return nil
+	}
+	run.record.Imported++
+	if fill != nil {
+		QueueLargeTrade(ctx, transaction, fill)
+	}
+	s.transactionService.symbolListService.AlertWatched(ctx, transaction, watched)
+	s.transactionService.guidelineService.AlertTrade(ctx, transaction)
+
+	if _, err := s.riskEngine.EvaluateTransaction(ctx, transaction); err != nil {
+		s.logger.WarnContext(ctx, "Risk evaluation failed for imported transaction", "transaction_id", transaction.ID, "error", err)
+	}
+	if _, err := s.amlService.CheckTransaction(ctx, transaction, &run.req.UserID); err != nil {
+		s.logger.WarnContext(ctx, "AML check failed for imported transaction", "transaction_id", transaction.ID, "error", err)
+	}
+	return nil
+}
+
+func (s *TransactionImportService) buildTransaction(run *importRun, trade imports.Trade) (*models.Transaction, error) {
+	portfolioID, err := s.resolvePortfolio(run, trade.PortfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !transactionTypes[trade.TransactionType] {
+		return nil, fmt.Errorf("invalid transaction_type %q", trade.TransactionType)
+	}
+	isTrade := trade.TransactionType == "BUY" || trade.TransactionType == "SELL"
+	if isTrade && trade.Symbol == "" {
+		return nil, errors.New("symbol is required for BUY and SELL")
+	}
+	if !trade.Quantity.IsPositive() {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if trade.Price.IsNegative() || (isTrade && trade.Price.IsZero()) {
+		return nil, errors.New("price must be greater than zero")
+	}
+	if trade.ExecutedAt != nil && trade.ExecutedAt.After(time.Now()) {
+		return nil, errors.New("executed_at is in the future")
+	}
+
+	status := trade.Status
+	if status == "" {
+		status = "COMPLETED"
+	}
+	if !transactionStatuses[status] {
+		return nil, fmt.Errorf("invalid status %q", trade.Status)
+	}
+
+	currency := trade.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	externalID := trade.ExternalID
+	if externalID == "" {
+		externalID = rowFingerprint(portfolioID, trade, currency)
+	}
+	if len(externalID) > maxExternalIDLength {
+		return nil, fmt.Errorf("external_id is longer than %d characters", maxExternalIDLength)
+	}
+
+	transaction := &models.Transaction{
+		PortfolioID:     portfolioID,
+		TransactionType: trade.TransactionType,
+		Symbol:          trade.Symbol,
+		Quantity:        trade.Quantity,
+		Price:           trade.Price,
+		Amount:          trade.Quantity.Mul(trade.Price).Round(2),
+		Currency:        currency,
+		Status:          status,
+		ExecutedAt:      trade.ExecutedAt,
+		Notes:           trade.Notes,
+		ExternalID:      &externalID,
+		ImportID:        &run.record.ID,
+		CreatedBy:       &run.req.UserID,
+
+		CounterpartyName:    trade.CounterpartyName,
+		CounterpartyCountry: trade.CounterpartyCountry,
+	}
+	if isTrade {
+		transaction.Side = trade.TransactionType
+		initOrder(transaction)
+	}
+
+	if trade.CounterpartyID != "" {
+		counterpartyID, err := uuid.Parse(trade.CounterpartyID)
+		if err != nil {
+			return nil, errors.New("invalid counterparty_id")
+		}
+		transaction.CounterpartyID = &counterpartyID
+	}
+
+	return transaction, nil
+}
+
+// resolvePortfolio parses the row's portfolio, falling back to the request default, and checks the
+// user may trade in it. Results are cached for the run.
+func (s *TransactionImportService) resolvePortfolio(run *importRun, value string) (uuid.UUID, error) {
+	var portfolioID uuid.UUID
+	switch {
+	case value != "":
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return uuid.Nil, errors.New("invalid portfolio_id")
+		}
+		portfolioID = id
+	case run.req.DefaultPortfolioID != nil:
+		portfolioID = *run.req.DefaultPortfolioID
+	default:
+		return uuid.Nil, errors.New("portfolio_id is required")
+	}
+
+	allowed, cached := run.modifiable[portfolioID]
+	if !cached {
+		var err error
+		allowed, err = s.accessService.CanModifyPortfolio(run.req.UserID, run.req.Role, portfolioID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		run.modifiable[portfolioID] = allowed
+	}
+	if !allowed {
+		return uuid.Nil, errors.New("portfolio not found")
+	}
+	return portfolioID, nil
+}
+
+func (run *importRun) fail(trade imports.Trade, err error) {
+	run.record.Failed++
+	if len(run.record.Errors) >= maxImportRowErrors {
+		run.record.ErrorsTruncated = true
+		return
+	}
+	run.record.Errors = append(run.record.Errors, models.ImportRowError{
+		Line:       trade.Line,
+		ExternalID: trade.ExternalID,
+		Error:      err.Error(),
+	})
+}
+
+// rowFingerprint identifies a trade without a source reference by its economic details, so the
+// same row uploaded twice is imported once
+func rowFingerprint(portfolioID uuid.UUID, trade imports.Trade, currency string) string {
+	executedAt := ""
+	if trade.ExecutedAt != nil {
+		executedAt = trade.ExecutedAt.UTC().Format(time.RFC3339Nano)
+	}
+	fields := []string{
+		portfolioID.String(),
+		trade.TransactionType,
+		trade.Symbol,
+		trade.Quantity.String(),
+		trade.Price.String(),
+		currency,
+		executedAt,
+		trade.CounterpartyID,
+		trade.CounterpartyName,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "|")))
+	return "row-" + hex.EncodeToString(sum[:16])
+}
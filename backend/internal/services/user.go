@@ -0,0 +1,249 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/pagination"
+)
+
+// validRoles are the roles a user may be given
+var validRoles = map[string]bool{
+	models.RoleAdmin:             true,
+	models.RoleAnalyst:           true,
+	models.RoleTrader:            true,
+	models.RoleComplianceOfficer: true,
+}
+
+// UserService lets administrators list users, change their roles, deactivate and reactivate them,
+// force a password reset, export a user's personal data and anonymize closed accounts. Changes that
+// affect what a user's tokens allow sign them out.
+type UserService struct {
+	db          *gorm.DB
+	authService *AuthService
+}
+
+func NewUserService(authService *AuthService) *UserService {
+	return &UserService{
+		db:          database.GetDB(),
+		authService: authService,
+	}
+}
+
+// ListUsers lists users matching the filters, only those of a tenant when tenantID is set; search
+// matches the email or name, though not names the tenant stores encrypted
+func (s *UserService) ListUsers(spec pagination.Spec, params pagination.Params, search string, tenantID *uuid.UUID) ([]models.User, int64, error) {
+	var users []models.User
+	query := s.db.Model(&models.User{})
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("email ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?", pattern, pattern, pattern)
+	}
+	total, err := pagination.Find(query, spec, params, &users)
+	return users, total, err
+}
+
+// GetUser returns a user by ID
+func (s *UserService) GetUser(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserIncludingDeleted returns a user by ID, deleted or not
+func (s *UserService) GetUserIncludingDeleted(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.db.Unscoped().First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateRole changes a user's role and signs them out so their tokens carry the new role
+func (s *UserService) UpdateRole(userID uuid.UUID, role string) (*models.User, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !validRoles[role] {
+		return nil, errors.New("role must be admin, analyst, trader or compliance_officer")
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	if user.Role == models.RoleAdmin {
+		if err := s.ensureOtherAdmin(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Model(user).Update("role", role).Error; err != nil {
+		return nil, err
+	}
+	if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetActive deactivates or reactivates a user; deactivating signs them out
+func (s *UserService) SetActive(userID uuid.UUID, active bool) (*models.User, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if active && user.AnonymizedAt != nil {
+		return nil, errors.New("anonymized users cannot be reactivated")
+	}
+	if !active && user.IsActive && user.Role == models.RoleAdmin {
+		if err := s.ensureOtherAdmin(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err = s.authService.SetUserActive(userID, active)
+	if err != nil {
+		return nil, err
+	}
+	user.IsActive = active
+	return user, nil
+}
+
+// ForcePasswordReset replaces a user's password with a temporary one, returned only here, and signs
+// them out. The user cannot log in until they change it.
+func (s *UserService) ForcePasswordReset(userID uuid.UUID) (*models.User, string, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	temporary := base64.RawURLEncoding.EncodeToString(b)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(temporary), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"password":                string(hashedPassword),
+		"password_reset_required": true,
+	}).Error; err != nil {
+		return nil, "", err
+	}
+	if err := s.authService.RevokeUserSessions(user.ID); err != nil {
+		return nil, "", err
+	}
+	return user, temporary, nil
+}
+
+// PersonalDataExport bundles the personal data held about a user, for a data subject access
+// request
+type PersonalDataExport struct {
+	GeneratedAt             time.Time                       `json:"generated_at"`
+	User                    models.User                     `json:"user"`
+	KYCProfile              *models.KYCProfile              `json:"kyc_profile"`
+	Sessions                []models.RefreshToken           `json:"sessions"`
+	APIKeys                 []models.APIKey                 `json:"api_keys"`
+	TeamMemberships         []models.TeamMember             `json:"team_memberships"`
+	NotificationPreferences []models.NotificationPreference `json:"notification_preferences"`
+	Supervisions            []models.PortfolioSupervisor    `json:"supervisions"`
+	Portfolios              []models.Portfolio              `json:"portfolios"`   // Owned, including deleted ones
+	Transactions            []models.Transaction            `json:"transactions"` // In the owned portfolios
+	AuditTrail              []models.AuditLog               `json:"audit_trail"`  // Actions the user took
+}
+
+// PersonalData gathers the personal data held about a user, deleted accounts included
+func (s *UserService) PersonalData(userID uuid.UUID) (*PersonalDataExport, error) {
+	user, err := s.GetUserIncludingDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &PersonalDataExport{GeneratedAt: time.Now(), User: *user}
+
+	var profiles []models.KYCProfile
+	if err := s.db.Where("subject_type = ? AND subject_id = ?", models.KYCSubjectUser, userID).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	if len(profiles) > 0 {
+		export.KYCProfile = &profiles[0]
+	}
+
+	for _, query := range []struct {
+		db   *gorm.DB
+		dest interface{}
+	}{
+		{s.db.Where("user_id = ?", userID).Order("created_at"), &export.Sessions},
+		{s.db.Where("user_id = ?", userID).Order("created_at"), &export.APIKeys},
+		{s.db.Where("user_id = ?", userID).Order("created_at"), &export.TeamMemberships},
+		{s.db.Where("user_id = ?", userID), &export.NotificationPreferences},
+		{s.db.Where("user_id = ?", userID).Order("created_at"), &export.Supervisions},
+		{s.db.Unscoped().Where("user_id = ?", userID).Order("created_at"), &export.Portfolios},
+		{s.db.Unscoped().Where("portfolio_id IN (SELECT id FROM portfolios WHERE user_id = ?)", userID).Order("created_at"), &export.Transactions},
+		{s.db.Where("user_id = ?", userID).Order("created_at"), &export.AuditTrail},
+	} {
+		if err := query.db.Find(query.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+// Anonymize replaces a closed account's personal data with placeholders straight away, rather
+// than once the closed_users retention policy comes due
+func (s *UserService) Anonymize(userID uuid.UUID) (*models.User, error) {
+	user, err := s.GetUserIncludingDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsActive && !user.DeletedAt.Valid {
+		return nil, errors.New("only deactivated or deleted users can be anonymized")
+	}
+	if user.AnonymizedAt != nil {
+		return user, nil
+	}
+
+	if err := anonymizeUser(s.db, user); err != nil {
+		return nil, err
+	}
+	return s.GetUserIncludingDeleted(userID)
+}
+
+// ensureOtherAdmin refuses to demote or deactivate the last active administrator
+func (s *UserService) ensureOtherAdmin(userID uuid.UUID) error {
+	var count int64
+	err := s.db.Model(&models.User{}).
+		Where("role = ? AND is_active = ? AND id <> ?", models.RoleAdmin, true, userID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("cannot remove the last active administrator")
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/tracing"
+)
+
+// VaRMethodSimplified is the VaR method of VaRMetric, a fixed share of position value that needs
+// no price history. The other methods are those of calculator.VaRCalculator.CalculateMethod.
+const VaRMethodSimplified = "simplified"
+
+// Bounds of the VaR parameters accepted from the API
+const (
+	minVaRConfidence  = 0.9
+	maxVaRConfidence  = 0.999
+	maxVaRHorizon     = 30
+	maxVaRLookback    = 1000
+	minVaRSimulations = 1000
+	maxVaRSimulations = 100000
+)
+
+// minContributionPrices is the price history a position needs to be part of the VaR breakdown, so
+// a recent purchase does not shorten the covariance window of every other position
+const minContributionPrices = 20
+
+// ErrInsufficientPriceHistory is returned when no position has enough recorded prices for a
+// method based on returns
+var ErrInsufficientPriceHistory = errors.New("not enough price history")
+
+// VaRParams selects how a portfolio's VaR is calculated
+type VaRParams struct {
+	Method      string  // simplified, historical, parametric or montecarlo
+	Confidence  float64 // e.g. 0.95 or 0.99
+	Horizon     int     // Days; daily VaR is scaled by the square root of time
+	Lookback    int     // End-of-day snapshots the returns are taken from
+	Simulations int     // Monte Carlo paths
+	Seed        int64   // Non-zero makes a Monte Carlo run reproducible
+	// Progress, when set, is called as Monte Carlo paths complete; see calculator.MonteCarloOptions
+	Progress func(completed, total int)
+}
+
+// DefaultVaRParams are the simplified method at the configured confidence level, horizon and
+// Monte Carlo settings
+func DefaultVaRParams(cfg *config.RiskConfig) VaRParams {
+	return VaRParams{
+		Method:      VaRMethodSimplified,
+		Confidence:  cfg.VARConfidenceLevel,
+		Horizon:     cfg.VARTimeHorizon,
+		Lookback:    regulatoryBacktestDays,
+		Simulations: cfg.MonteCarloSimulations,
+		Seed:        int64(cfg.MonteCarloSeed),
+	}
+}
+
+// Validate checks the parameters against the bounds the API accepts
+func (p VaRParams) Validate() error {
+	switch p.Method {
+	case VaRMethodSimplified, calculator.MethodHistorical, calculator.MethodParametric, calculator.MethodMonteCarlo:
+	default:
+		return errors.New("method must be simplified, historical, parametric or montecarlo")
+	}
+	if p.Confidence < minVaRConfidence || p.Confidence > maxVaRConfidence {
+		return fmt.Errorf("confidence must be between %g and %g, e.g. 0.99", minVaRConfidence, maxVaRConfidence)
+	}
+	if p.Horizon < 1 || p.Horizon > maxVaRHorizon {
+		return fmt.Errorf("horizon must be between 1 and %d days", maxVaRHorizon)
+	}
+	if p.Lookback < minContributionPrices || p.Lookback > maxVaRLookback {
+		return fmt.Errorf("lookback must be between %d and %d days", minContributionPrices, maxVaRLookback)
+	}
+	if p.Simulations < minVaRSimulations || p.Simulations > maxVaRSimulations {
+		return fmt.Errorf("simulations must be between %d and %d", minVaRSimulations, maxVaRSimulations)
+	}
+	return nil
+}
+
+// VaRService calculates a portfolio's VaR with a chosen method and breaks it down per position,
+// using the prices recorded in its end-of-day snapshots
+type VaRService struct {
+	db     *gorm.DB
+	config *config.RiskConfig
+}
+
+func NewVaRService(cfg *config.RiskConfig) *VaRService {
+	return &VaRService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// Calculate calculates the VaR of a portfolio loaded with its positions, and its liquidity-adjusted
+// VaR, as the risk metric it is stored as. The parameters used are recorded in the metric details.
+// Methods other than simplified return ErrInsufficientPriceHistory when no position has enough
+// snapshots, and the calculation fails with context.DeadlineExceeded after the configured VaR
+// timeout.
+func (s *VaRService) Calculate(ctx context.Context, portfolio *models.Portfolio, params VaRParams) (*models.RiskMetric, *calculator.LiquidityAdjustedVaR, error) {
+	return s.CalculateRollup(ctx, portfolio, []uuid.UUID{portfolio.ID}, params)
+}
+
+// CalculateRollup calculates the VaR of a portfolio rolled up from several, as Calculate does,
+// drawing the price history of its positions from the snapshots of all of them
+func (s *VaRService) CalculateRollup(ctx context.Context, portfolio *models.Portfolio, portfolioIDs []uuid.UUID, params VaRParams) (*models.RiskMetric, *calculator.LiquidityAdjustedVaR, error) {
+	defer metrics.RiskCalculationDuration.With("var").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.var.calculate",
+		tracing.String("portfolio_id", portfolio.ID.String()), tracing.String("risk.var_method", params.Method))
+	defer span.End()
+
+	if s.config.VaRTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.VaRTimeout)
+		defer cancel()
+	}
+
+	if params.Method == VaRMethodSimplified {
+		cfg := *s.config
+		cfg.VARConfidenceLevel = params.Confidence
+		cfg.VARTimeHorizon = params.Horizon
+		return VaRMetric(portfolio, &cfg)
+	}
+
+	priceHistory, err := s.priceHistory(ctx, portfolioIDs, portfolio.Positions, params.Lookback)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(priceHistory) == 0 {
+		return nil, nil, ErrInsufficientPriceHistory
+	}
+
+	// A seeded run is only reproducible with the same number of workers, so it is fixed here
+	workers := s.config.MonteCarloWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	varCalculator := calculator.NewVaRCalculator(portfolio.TotalValue.InexactFloat64())
+	value, err := varCalculator.CalculateMethod(ctx, params.Method, portfolio.Positions, priceHistory, params.Confidence, params.Horizon, calculator.MonteCarloOptions{
+		Simulations: params.Simulations,
+		Workers:     workers,
+		Seed:        params.Seed,
+		Progress:    params.Progress,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+	varValue := decimal.NewFromFloat(max(value, 0)).Round(2)
+	_, threshold := SimplifiedVaR(portfolio)
+
+	lvar, depth, err := LiquidityAdjustedVaR(portfolio, varValue, params.Horizon)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details := models.JSON{
+		"method":                 params.Method,
+		"confidence_level":       params.Confidence,
+		"time_horizon":           params.Horizon,
+		"lookback_days":          params.Lookback,
+		"portfolio_value":        portfolio.TotalValue.InexactFloat64(),
+		"cash_balance":           portfolio.CashBalance.InexactFloat64(),
+		"position_count":         len(portfolio.Positions),
+		"positions_priced":       len(priceHistory),
+		"liquidity_adjusted_var": lvar.Value,
+		marketDepthDetail:        depth,
+	}
+	for _, prices := range priceHistory {
+		details["observations"] = len(prices) - 1
+		break
+	}
+	if params.Method == calculator.MethodMonteCarlo {
+		details["simulations"] = params.Simulations
+		if params.Seed != 0 {
+			details["seed"] = params.Seed
+			details["workers"] = workers
+		}
+	}
+
+	return &models.RiskMetric{
+		PortfolioID:     portfolio.ID,
+		MetricType:      "VAR",
+		Value:           varValue,
+		Threshold:       threshold,
+		Status:          varStatus(varValue, threshold),
+		TimeHorizon:     params.Horizon,
+		ConfidenceLevel: decimal.NewFromFloat(params.Confidence),
+		Details:         details,
+	}, lvar, nil
+}
+
+// Contributions calculates the marginal, component and incremental VaR of each position of a
+// portfolio at a confidence level, ranked by component VaR
+func (s *VaRService) Contributions(ctx context.Context, portfolioID uuid.UUID, confidence float64) (*calculator.VaRContributionResult, error) {
+	defer metrics.RiskCalculationDuration.With("var_contributions").ObserveSince(time.Now())
+	ctx, span := tracing.Start(ctx, "risk.var_contributions", tracing.String("portfolio_id", portfolioID.String()))
+	defer span.End()
+
+	var portfolio models.Portfolio
+	if err := s.db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("portfolio not found")
+		}
+		return nil, err
+	}
+
+	priceHistory, err := s.priceHistory(ctx, []uuid.UUID{portfolio.ID}, portfolio.Positions, regulatoryBacktestDays)
+	if err != nil {
+		return nil, err
+	}
+
+	varCalculator := calculator.NewVaRCalculator(portfolio.TotalValue.InexactFloat64())
+	return varCalculator.CalculateContributions(portfolio.Positions, priceHistory, confidence, s.config.VARTimeHorizon), nil
+}
+
+// priceHistory returns the daily closing prices of each position from up to lookback days of the
+// portfolios' snapshots before today, oldest first and ending with the current price. A symbol's
+// series stops at the most recent day no snapshot holds it, symbols with fewer than
+// minContributionPrices prices are left out and the rest are cut to the same days.
+func (s *VaRService) priceHistory(ctx context.Context, portfolioIDs []uuid.UUID, positions []models.Position, lookback int) (map[string][]float64, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour).Format("2006-01-02")
+
+	var dates []time.Time
+	err := s.db.WithContext(ctx).Model(&models.PortfolioSnapshot{}).
+		Where("portfolio_id IN ? AND date < ?", portfolioIDs, today).
+		Distinct("date").Order("date DESC").Limit(lookback).Pluck("date", &dates).Error
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []models.PortfolioSnapshot
+	if len(dates) > 0 {
+		err = s.db.WithContext(ctx).Select("date", "positions").
+			Where("portfolio_id IN ? AND date >= ? AND date < ?", portfolioIDs, dates[len(dates)-1], today).
+			Find(&snapshots).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each day's prices by symbol, most recent day first; portfolios holding the same symbol on a
+	// day recorded the same closing price
+	days := make([]map[string]decimal.Decimal, len(dates))
+	dayIndex := make(map[string]int, len(dates))
+	for i, date := range dates {
+		days[i] = make(map[string]decimal.Decimal)
+		dayIndex[date.Format("2006-01-02")] = i
+	}
+	for _, snapshot := range snapshots {
+		i, ok := dayIndex[snapshot.Date.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		for _, held := range snapshot.Positions {
+			days[i][held.Symbol] = held.Price
+		}
+	}
+
+	priceHistory := make(map[string][]float64, len(positions))
+	length := len(days) + 1
+	for _, position := range positions {
+		prices := []float64{position.CurrentPrice.InexactFloat64()}
+		for _, day := range days {
+			price, ok := day[position.Symbol]
+			if !ok {
+				break
+			}
+			prices = append(prices, price.InexactFloat64())
+		}
+		if len(prices) < minContributionPrices {
+			continue
+		}
+		priceHistory[position.Symbol] = prices
+		length = min(length, len(prices))
+	}
+
+	for symbol, prices := range priceHistory {
+		prices = prices[:length]
+		for i, j := 0, len(prices)-1; i < j; i, j = i+1, j-1 {
+			prices[i], prices[j] = prices[j], prices[i]
+		}
+		priceHistory[symbol] = prices
+	}
+	return priceHistory, nil
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/apperror"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+)
+
+// checkVersion refuses an update made against a version of a record other than its current one,
+// returning the current state of the record with the conflict
+func checkVersion(entity string, current interface{}, currentVersion, expected int64) error {
+	if expected == models.AnyVersion || expected == currentVersion {
+		return nil
+	}
+	return apperror.VersionConflict(
+		fmt.Sprintf("%s was changed since version %d; reapply the change to version %d", entity, expected, currentVersion),
+		current)
+}
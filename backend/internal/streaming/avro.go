@@ -0,0 +1,391 @@
+package streaming
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var errAvroTruncated = errors.New("avro: truncated value")
+
+// avroSchema is a parsed Avro schema. Values decode to nil, bool, int64 (int and long), float64
+// (float and double), []byte, string (also enums), map[string]any (records and maps) and []any,
+// with timestamp-millis/micros as time.Time and decimal as decimal.Decimal.
+type avroSchema struct {
+	Type        string // A primitive type, record, enum, array, map, fixed or union
+	Name        string
+	Fields      []avroField   // record
+	Symbols     []string      // enum
+	Items       *avroSchema   // array
+	Values      *avroSchema   // map
+	Size        int           // fixed
+	Branches    []*avroSchema // union
+	LogicalType string
+	Scale       int32 // decimal
+}
+
+type avroField struct {
+	Name   string
+	Schema *avroSchema
+}
+
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parseAvroSchema parses a schema in its JSON form
+func parseAvroSchema(text string) (*avroSchema, error) {
+	var raw any
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	return (&avroParser{named: make(map[string]*avroSchema)}).parse(raw, "")
+}
+
+// avroParser resolves references to named types defined earlier in the schema
+type avroParser struct {
+	named map[string]*avroSchema
+}
+
+func (p *avroParser) parse(raw any, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		if avroPrimitives[v] {
+			return &avroSchema{Type: v}, nil
+		}
+		if s, ok := p.named[qualify(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("avro: unknown type %q", v)
+
+	case []any:
+		union := &avroSchema{Type: "union"}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union.Branches = append(union.Branches, s)
+		}
+		return union, nil
+
+	case map[string]any:
+		typ, _ := v["type"].(string)
+		s := &avroSchema{Type: typ}
+		s.LogicalType, _ = v["logicalType"].(string)
+		if scale, ok := v["scale"].(float64); ok {
+			s.Scale = int32(scale)
+		}
+
+		if ns, ok := v["namespace"].(string); ok {
+			namespace = ns
+		}
+		if name, ok := v["name"].(string); ok {
+			s.Name = qualify(name, namespace)
+		}
+
+		switch typ {
+		case "record", "error":
+			s.Type = "record"
+			p.named[s.Name] = s // Records may refer to themselves
+			fields, _ := v["fields"].([]any)
+			for _, f := range fields {
+				field, _ := f.(map[string]any)
+				name, _ := field["name"].(string)
+				fs, err := p.parse(field["type"], namespace)
+				if err != nil {
+					return nil, fmt.Errorf("avro: field %s: %w", name, err)
+				}
+				s.Fields = append(s.Fields, avroField{Name: name, Schema: fs})
+			}
+		case "enum":
+			p.named[s.Name] = s
+			symbols, _ := v["symbols"].([]any)
+			for _, sym := range symbols {
+				name, _ := sym.(string)
+				s.Symbols = append(s.Symbols, name)
+			}
+		case "fixed":
+			p.named[s.Name] = s
+			size, _ := v["size"].(float64)
+			s.Size = int(size)
+		case "array":
+			items, err := p.parse(v["items"], namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Items = items
+		case "map":
+			values, err := p.parse(v["values"], namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Values = values
+		default:
+			if !avroPrimitives[typ] {
+				// {"type": "SomeNamedType"} or a nested type definition
+				return p.parse(v["type"], namespace)
+			}
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("avro: invalid schema element %v", raw)
+}
+
+func qualify(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroDecoder reads values in the Avro binary encoding
+type avroDecoder struct {
+	buf []byte
+}
+
+func (d *avroDecoder) long() (int64, error) {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		return 0, errAvroTruncated
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *avroDecoder) take(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(d.buf)) {
+		return nil, errAvroTruncated
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+func (d *avroDecoder) decode(s *avroSchema) (any, error) {
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int", "long":
+		v, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		switch s.LogicalType {
+		case "timestamp-millis":
+			return time.UnixMilli(v).UTC(), nil
+		case "timestamp-micros":
+			return time.UnixMicro(v).UTC(), nil
+		}
+		return v, nil
+	case "float":
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case "double":
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes", "fixed":
+		n := int64(s.Size)
+		if s.Type == "bytes" {
+			var err error
+			if n, err = d.long(); err != nil {
+				return nil, err
+			}
+		}
+		b, err := d.take(n)
+		if err != nil {
+			return nil, err
+		}
+		if s.LogicalType == "decimal" {
+			return decimal.NewFromBigInt(twosComplement(b), -s.Scale), nil
+		}
+		return b, nil
+	case "string":
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.take(n)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "enum":
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("avro: enum index %d out of range", i)
+		}
+		return s.Symbols[i], nil
+	case "union":
+		i, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(s.Branches)) {
+			return nil, fmt.Errorf("avro: union index %d out of range", i)
+		}
+		return d.decode(s.Branches[i])
+	case "record":
+		record := make(map[string]any, len(s.Fields))
+		for _, f := range s.Fields {
+			v, err := d.decode(f.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("avro: field %s: %w", f.Name, err)
+			}
+			record[f.Name] = v
+		}
+		return record, nil
+	case "array", "map":
+		var items []any
+		values := make(map[string]any)
+		for {
+			count, err := d.long()
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				break
+			}
+			if count < 0 {
+				count = -count
+				if _, err := d.long(); err != nil { // Block size in bytes
+					return nil, err
+				}
+			}
+			if count > int64(len(d.buf)) {
+				return nil, errAvroTruncated
+			}
+			for range count {
+				if s.Type == "array" {
+					v, err := d.decode(s.Items)
+					if err != nil {
+						return nil, err
+					}
+					items = append(items, v)
+					continue
+				}
+				key, err := d.decode(&avroSchema{Type: "string"})
+				if err != nil {
+					return nil, err
+				}
+				v, err := d.decode(s.Values)
+				if err != nil {
+					return nil, err
+				}
+				values[key.(string)] = v
+			}
+		}
+		if s.Type == "array" {
+			return items, nil
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("avro: unsupported type %q", s.Type)
+}
+
+// twosComplement reads a big-endian two's complement integer
+func twosComplement(b []byte) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+	return v
+}
+
+// avroEncode appends a value in the Avro binary encoding. It supports the types of the event
+// schemas this package publishes: records given as map[string]any, strings, longs, doubles,
+// booleans and unions with null.
+func avroEncode(buf []byte, s *avroSchema, value any) ([]byte, error) {
+	switch s.Type {
+	case "null":
+		if value != nil {
+			return nil, fmt.Errorf("avro: expected null, got %T", value)
+		}
+		return buf, nil
+	case "boolean":
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("avro: expected boolean, got %T", value)
+		}
+		if v {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case "int", "long":
+		switch v := value.(type) {
+		case int64:
+			return binary.AppendVarint(buf, v), nil
+		case int:
+			return binary.AppendVarint(buf, int64(v)), nil
+		case time.Time:
+			if s.LogicalType == "timestamp-micros" {
+				return binary.AppendVarint(buf, v.UnixMicro()), nil
+			}
+			return binary.AppendVarint(buf, v.UnixMilli()), nil
+		}
+		return nil, fmt.Errorf("avro: expected long, got %T", value)
+	case "double":
+		v, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("avro: expected double, got %T", value)
+		}
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v)), nil
+	case "string":
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("avro: expected string, got %T", value)
+		}
+		buf = binary.AppendVarint(buf, int64(len(v)))
+		return append(buf, v...), nil
+	case "union":
+		for i, branch := range s.Branches {
+			if (value == nil) != (branch.Type == "null") {
+				continue
+			}
+			out, err := avroEncode(binary.AppendVarint(buf, int64(i)), branch, value)
+			if err == nil {
+				return out, nil
+			}
+		}
+		return nil, fmt.Errorf("avro: no union branch for %T", value)
+	case "record":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("avro: expected record, got %T", value)
+		}
+		var err error
+		for _, f := range s.Fields {
+			if buf, err = avroEncode(buf, f.Schema, fields[f.Name]); err != nil {
+				return nil, fmt.Errorf("avro: field %s: %w", f.Name, err)
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("avro: encoding %q is not supported", s.Type)
+}
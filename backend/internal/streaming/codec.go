@@ -0,0 +1,134 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message formats
+const (
+	FormatJSON = "json"
+	FormatAvro = "avro"
+)
+
+var (
+	//go:embed schemas/alert.avsc
+	alertSchemaText string
+	//go:embed schemas/risk_update.avsc
+	riskUpdateSchemaText string
+)
+
+// eventSchema is the Avro schema of an event published to Kafka. JSON messages carry the same
+// fields.
+type eventSchema struct {
+	text   string
+	schema *avroSchema
+}
+
+func mustEventSchema(text string) *eventSchema {
+	schema, err := parseAvroSchema(text)
+	if err != nil {
+		panic(err)
+	}
+	return &eventSchema{text: text, schema: schema}
+}
+
+var (
+	alertEventSchema      = mustEventSchema(alertSchemaText)
+	riskUpdateEventSchema = mustEventSchema(riskUpdateSchemaText)
+)
+
+// codec serializes published events and deserializes consumed messages into their fields
+type codec interface {
+	encode(ctx context.Context, topic string, schema *eventSchema, fields map[string]any) ([]byte, error)
+	decode(ctx context.Context, value []byte) (map[string]any, error)
+}
+
+func newCodec(format, registryURL string) (codec, error) {
+	switch format {
+	case FormatJSON:
+		return jsonCodec{}, nil
+	case FormatAvro:
+		registry, err := newSchemaRegistry(registryURL)
+		if err != nil {
+			return nil, err
+		}
+		return &avroCodec{registry: registry, ids: make(map[string]int32)}, nil
+	}
+	return nil, fmt.Errorf("unsupported Kafka format %q, use json or avro", format)
+}
+
+// jsonCodec writes events as JSON objects and reads messages that are JSON objects
+type jsonCodec struct{}
+
+func (jsonCodec) encode(_ context.Context, _ string, _ *eventSchema, fields map[string]any) ([]byte, error) {
+	return json.Marshal(fields)
+}
+
+func (jsonCodec) decode(_ context.Context, value []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	if fields == nil {
+		return nil, errors.New("message is not a JSON object")
+	}
+	return fields, nil
+}
+
+// avroCodec uses the schema registry wire format: a zero byte, the big-endian schema ID and the
+// Avro binary encoding. Event schemas are registered under the <topic>-value subject on first
+// use; consumed messages are decoded with the writer schema their ID names.
+type avroCodec struct {
+	registry *schemaRegistry
+
+	mu  sync.Mutex
+	ids map[string]int32 // Schema IDs by subject
+}
+
+func (c *avroCodec) encode(ctx context.Context, topic string, schema *eventSchema, fields map[string]any) ([]byte, error) {
+	subject := topic + "-value"
+	c.mu.Lock()
+	id, ok := c.ids[subject]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		if id, err = c.registry.register(ctx, subject, schema.text); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.ids[subject] = id
+		c.mu.Unlock()
+	}
+
+	buf := make([]byte, 5, 256)
+	binary.BigEndian.PutUint32(buf[1:], uint32(id))
+	return avroEncode(buf, schema.schema, fields)
+}
+
+func (c *avroCodec) decode(ctx context.Context, value []byte) (map[string]any, error) {
+	if len(value) < 5 || value[0] != 0 {
+		return nil, errors.New("message is not in the schema registry Avro format")
+	}
+	schema, err := c.registry.schema(ctx, int32(binary.BigEndian.Uint32(value[1:5])))
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != "record" {
+		return nil, fmt.Errorf("message schema is %s, not a record", schema.Type)
+	}
+
+	decoded, err := (&avroDecoder{buf: value[5:]}).decode(schema)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.(map[string]any), nil
+}
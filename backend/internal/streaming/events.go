@@ -0,0 +1,86 @@
+package streaming
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// alertPayload is an alert as published to the alerts channel
+type alertPayload struct {
+	ID          string          `json:"id"`
+	PortfolioID string          `json:"portfolio_id"`
+	AlertType   string          `json:"alert_type"`
+	Severity    string          `json:"severity"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Source      string          `json:"source"`
+	Status      string          `json:"status"`
+	TriggeredBy json.RawMessage `json:"triggered_by"`
+	RequestID   string          `json:"request_id"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// alertEvent converts an alert event into the fields of an AlertEvent message, keyed by portfolio
+// so each portfolio's alerts stay in order
+func alertEvent(seq int64, payload string) (string, map[string]any, error) {
+	var alert alertPayload
+	if err := json.Unmarshal([]byte(payload), &alert); err != nil {
+		return "", nil, err
+	}
+
+	var triggeredBy any
+	if len(alert.TriggeredBy) > 0 && !bytes.Equal(alert.TriggeredBy, []byte("null")) {
+		triggeredBy = string(alert.TriggeredBy)
+	}
+	return alert.PortfolioID, map[string]any{
+		"seq":          seq,
+		"id":           alert.ID,
+		"portfolio_id": alert.PortfolioID,
+		"alert_type":   alert.AlertType,
+		"severity":     alert.Severity,
+		"title":        alert.Title,
+		"description":  alert.Description,
+		"source":       alert.Source,
+		"status":       alert.Status,
+		"triggered_by": triggeredBy,
+		"request_id":   optional(alert.RequestID),
+		"created_at":   alert.CreatedAt,
+	}, nil
+}
+
+// riskUpdatePayload is a risk update as published to the risk updates channel
+type riskUpdatePayload struct {
+	PortfolioID          string  `json:"portfolio_id"`
+	VaR                  float64 `json:"var"`
+	LiquidityAdjustedVaR float64 `json:"liquidity_adjusted_var"`
+	Liquidity            float64 `json:"liquidity"`
+	Timestamp            int64   `json:"timestamp"` // Unix seconds
+	RequestID            string  `json:"request_id"`
+}
+
+// riskUpdateEvent converts a risk update into the fields of a RiskUpdateEvent message, keyed by
+// portfolio
+func riskUpdateEvent(seq int64, payload string) (string, map[string]any, error) {
+	var update riskUpdatePayload
+	if err := json.Unmarshal([]byte(payload), &update); err != nil {
+		return "", nil, err
+	}
+	return update.PortfolioID, map[string]any{
+		"seq":                    seq,
+		"portfolio_id":           update.PortfolioID,
+		"var":                    update.VaR,
+		"liquidity_adjusted_var": update.LiquidityAdjustedVaR,
+		"liquidity":              update.Liquidity,
+		"calculated_at":          time.Unix(update.Timestamp, 0).UTC(),
+		"request_id":             optional(update.RequestID),
+	}, nil
+}
+
+// optional maps an empty string to null
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
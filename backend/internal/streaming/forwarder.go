@@ -0,0 +1,173 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/kafka"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+const (
+	forwardInterval   = 500 * time.Millisecond
+	forwardBatchSize  = 500
+	forwarderLease    = 15 * time.Second
+	forwarderLeaseKey = "kafka:forwarder:lease"
+)
+
+// holdLease takes the lease when it is free and extends it when this instance already holds it
+var holdLease = redis.NewScript(`
+local owner = redis.call('GET', KEYS[1])
+if owner == ARGV[1] then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+  return 1
+end
+if not owner then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+  return 1
+end
+return 0
+`)
+
+// forwardedStream is a replayable Redis channel whose events are produced to a Kafka topic
+type forwardedStream struct {
+	channel string
+	topic   string
+	schema  *eventSchema
+	convert func(seq int64, payload string) (key string, fields map[string]any, err error)
+}
+
+// forwarder produces the alert and risk update events buffered in Redis for WebSocket replay to
+// Kafka. Its position in each channel's sequence is kept in Redis, so events raised while it was
+// stopped are forwarded when it starts again, as far back as the buffer reaches. One instance of
+// the deployment forwards at a time, holding a lease in Redis.
+type forwarder struct {
+	producer   *kafka.Producer
+	codec      codec
+	streams    []forwardedStream
+	instanceID string
+	logger     *slog.Logger
+}
+
+func newForwarder(producer *kafka.Producer, c codec, alertsTopic, riskUpdatesTopic string) *forwarder {
+	return &forwarder{
+		producer: producer,
+		codec:    c,
+		streams: []forwardedStream{
+			{channel: database.AlertsChannel, topic: alertsTopic, schema: alertEventSchema, convert: alertEvent},
+			{channel: database.RiskUpdatesChannel, topic: riskUpdatesTopic, schema: riskUpdateEventSchema, convert: riskUpdateEvent},
+		},
+		instanceID: uuid.NewString(),
+		logger:     logging.Component("kafka_forwarder"),
+	}
+}
+
+func positionKey(channel string) string {
+	return "kafka:forwarded:" + channel
+}
+
+// Run forwards events until ctx is cancelled
+func (f *forwarder) Run(ctx context.Context) {
+	ticker := time.NewTicker(forwardInterval)
+	defer ticker.Stop()
+
+	leader := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client := database.GetRedis()
+		if client == nil || !database.RedisAvailable() {
+			continue
+		}
+		held, err := holdLease.Run(ctx, client, []string{forwarderLeaseKey}, f.instanceID, forwarderLease.Milliseconds()).Int()
+		if err != nil {
+			f.logger.Warn("Failed to renew Kafka forwarder lease", "error", err)
+			continue
+		}
+		if (held == 1) != leader {
+			leader = held == 1
+			f.logger.Info("Kafka forwarder lease changed", "held", leader)
+		}
+		if !leader {
+			continue
+		}
+
+		for _, stream := range f.streams {
+			if err := f.forward(ctx, client, stream); err != nil && ctx.Err() == nil {
+				f.logger.Error("Failed to forward events to Kafka", "channel", stream.channel, "topic", stream.topic, "error", err)
+			}
+		}
+	}
+}
+
+// forward produces the events of a channel numbered after the stored position and moves the
+// position past them
+func (f *forwarder) forward(ctx context.Context, client *redis.Client, stream forwardedStream) error {
+	key := positionKey(stream.channel)
+	position, err := client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		// First run: start with the events raised from now on rather than the whole buffer
+		_, latest, _, err := database.ReplayEvents(ctx, stream.channel, math.MaxInt64, 0)
+		if err != nil {
+			return err
+		}
+		return client.Set(ctx, key, latest, 0).Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	events, latest, truncated, err := database.ReplayEvents(ctx, stream.channel, position, forwardBatchSize)
+	if err != nil {
+		return err
+	}
+	if latest < position {
+		f.logger.Warn("Event sequence restarted, forwarding from the start", "channel", stream.channel,
+			"position", position, "latest", latest)
+		return client.Set(ctx, key, 0, 0).Err()
+	}
+	if truncated {
+		resumeAt := latest
+		if len(events) > 0 {
+			resumeAt = events[0].Seq - 1
+		}
+		f.logger.Warn("Events dropped from the buffer before they were forwarded", "channel", stream.channel,
+			"lost", resumeAt-position)
+		position = resumeAt
+	}
+	if len(events) == 0 {
+		return client.Set(ctx, key, position, 0).Err()
+	}
+
+	records := make([]kafka.Record, 0, len(events))
+	for _, event := range events {
+		partitionKey, fields, err := stream.convert(event.Seq, event.Payload)
+		if err == nil {
+			var value []byte
+			if value, err = f.codec.encode(ctx, stream.topic, stream.schema, fields); err == nil {
+				records = append(records, kafka.Record{Key: []byte(partitionKey), Value: value})
+				continue
+			}
+		}
+		if errors.Is(err, errRegistryUnavailable) {
+			return err
+		}
+		f.logger.Warn("Skipping event that cannot be forwarded", "channel", stream.channel, "seq", event.Seq, "error", err)
+	}
+
+	if err := f.producer.Produce(ctx, stream.topic, records); err != nil {
+		return err
+	}
+	return client.Set(ctx, key, events[len(events)-1].Seq, 0).Err()
+}
@@ -0,0 +1,100 @@
+// Package streaming connects the platform to Kafka: trades consumed from the transactions topic
+// are imported like uploaded files, and the alerts and risk updates published for WebSocket
+// clients are produced to their own topics.
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/imports"
+	"github.com/Taf0711/financial-risk-monitor/internal/kafka"
+	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// Streamer runs the Kafka forwarder and, when an ingest user is configured, the transaction
+// consumer
+type Streamer struct {
+	client    *kafka.Client
+	forwarder *forwarder
+	consumer  *kafka.Consumer
+	ingester  *transactionIngester
+}
+
+// NewStreamer connects to the configured cluster; it returns nil when Kafka is disabled
+func NewStreamer(cfg *config.KafkaConfig, riskCfg *config.RiskConfig) (*Streamer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	c, err := newCodec(cfg.Format, cfg.SchemaRegistryURL)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kafka.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := kafka.NewProducer(client, cfg.Compression)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	s := &Streamer{
+		client:    client,
+		forwarder: newForwarder(producer, c, cfg.TopicPrefix+cfg.AlertsTopic, cfg.TopicPrefix+cfg.RiskUpdatesTopic),
+	}
+	if cfg.IngestUserID == "" {
+		return s, nil
+	}
+
+	user, err := ingestUser(cfg.IngestUserID)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	if s.consumer, err = kafka.NewConsumer(client, cfg.ConsumerGroup, cfg.TopicPrefix+cfg.TransactionsTopic, cfg.StartOffset); err != nil {
+		client.Close()
+		return nil, err
+	}
+	s.ingester = &transactionIngester{
+		importService: services.NewTransactionImportService(riskCfg),
+		codec:         c,
+		req:           services.ImportRequest{UserID: user.ID, Role: user.Role, Format: imports.FormatKafka},
+	}
+	return s, nil
+}
+
+// ingestUser loads the active user transactions are imported for
+func ingestUser(id string) (*models.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KAFKA_INGEST_USER_ID: %w", err)
+	}
+	var user models.User
+	if err := database.GetDB().First(&user, "id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("ingest user %s: %w", userID, err)
+	}
+	if !user.IsActive {
+		return nil, fmt.Errorf("ingest user %s is deactivated", userID)
+	}
+	return &user, nil
+}
+
+// Run streams until ctx is cancelled, then closes the connections to the cluster
+func (s *Streamer) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() { s.forwarder.Run(ctx) })
+	if s.consumer != nil {
+		wg.Go(func() { s.consumer.Run(ctx, s.ingester.handle) })
+	}
+	wg.Wait()
+	s.client.Close()
+}
@@ -0,0 +1,66 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/imports"
+	"github.com/Taf0711/financial-risk-monitor/internal/kafka"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
+)
+
+// importChunkSize is the most records recorded as one transaction import, well under the
+// import row limit
+const importChunkSize = 1000
+
+// transactionIngester imports the trades consumed from the transactions topic. Each message holds
+// one trade with the fields of a CSV import row. Trades are imported as the ingest user, and a
+// trade delivered again is a duplicate of its external_id rather than a second transaction.
+type transactionIngester struct {
+	importService *services.TransactionImportService
+	codec         codec
+	req           services.ImportRequest
+}
+
+// handle is the consumer handler. Messages that do not hold a valid trade are recorded as failed
+// rows; schema registry and database failures stop it so the rest are delivered again.
+func (t *transactionIngester) handle(ctx context.Context, records []kafka.Record) (int, error) {
+	// Imports in progress finish when the group rebalances; ctx only stops the next record
+	store := context.WithoutCancel(ctx)
+
+	done := 0
+	for done < len(records) && ctx.Err() == nil {
+		chunk := records[done:min(done+importChunkSize, len(records))]
+		source := fmt.Sprintf("%s/%d@%d-%d", chunk[0].Topic, chunk[0].Partition, chunk[0].Offset, chunk[len(chunk)-1].Offset)
+
+		handled := 0
+		_, err := t.importService.ImportStream(store, t.req, source, func(handle imports.RowHandler) error {
+			for _, record := range chunk {
+				if ctx.Err() != nil {
+					return nil
+				}
+				fields, err := t.codec.decode(store, record.Value)
+				if errors.Is(err, errRegistryUnavailable) {
+					return err
+				}
+				var trade imports.Trade
+				if err == nil {
+					trade, err = imports.MessageTrade(int(record.Offset), fields)
+				} else {
+					trade.Line = int(record.Offset)
+				}
+				if err := handle(trade, err); err != nil {
+					return err
+				}
+				handled++
+			}
+			return nil
+		})
+		done += handled
+		if err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,258 @@
+package tenancy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+)
+
+// sealedPrefix marks a column value encrypted with a tenant's data key, so that values written
+// before a tenant turned encryption on still read as they are
+const sealedPrefix = "enc:v1:"
+
+// keyTTL is how long a tenant's key and encryption setting are cached, so that every instance
+// picks up a change within it
+const keyTTL = time.Minute
+
+// ErrNoMasterKey is returned when a data key is needed without TENANT_MASTER_KEY configured
+var ErrNoMasterKey = errors.New("TENANT_MASTER_KEY is not configured")
+
+// Keyring encrypts the PII columns of tenants that turn encryption on. Each tenant has its own
+// AES-256 data key, stored in the tenants table wrapped by the master key.
+type Keyring struct {
+	master cipher.AEAD // Nil without a master key
+	db     *gorm.DB
+
+	mu   sync.RWMutex
+	keys map[uuid.UUID]tenantKey
+}
+
+type tenantKey struct {
+	aead     cipher.AEAD // Nil when the tenant has no data key
+	encrypt  bool
+	loadedAt time.Time
+}
+
+var (
+	defaultKeyring *Keyring
+	keyringMu      sync.RWMutex
+)
+
+func NewKeyring(cfg *config.TenancyConfig) (*Keyring, error) {
+	keyring := &Keyring{
+		db:   database.GetDB(),
+		keys: make(map[uuid.UUID]tenantKey),
+	}
+	if cfg.MasterKey == "" {
+		return keyring, nil
+	}
+
+	master, err := base64.StdEncoding.DecodeString(cfg.MasterKey)
+	if err != nil || len(master) != 32 {
+		return nil, errors.New("TENANT_MASTER_KEY must be 32 bytes, base64 encoded")
+	}
+	if keyring.master, err = newAEAD(master); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// Init creates the shared keyring
+func Init(cfg *config.TenancyConfig) (*Keyring, error) {
+	keyring, err := NewKeyring(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	keyringMu.Lock()
+	defaultKeyring = keyring
+	keyringMu.Unlock()
+
+	return keyring, nil
+}
+
+// GetKeyring returns the shared keyring, or nil if Init has not been called
+func GetKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	return defaultKeyring
+}
+
+// NewDataKey generates a data key for a tenant and returns it wrapped by the master key
+func (k *Keyring) NewDataKey() (string, error) {
+	if k.master == nil {
+		return "", ErrNoMasterKey
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return seal(k.master, key)
+}
+
+// Seal encrypts a value for a tenant that encrypts PII, and returns it unchanged otherwise
+func (k *Keyring) Seal(tenantID uuid.UUID, value string) (string, error) {
+	key, err := k.key(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if !key.encrypt {
+		return value, nil
+	}
+	return k.sealWith(key, value)
+}
+
+// SealWith encrypts a value with a wrapped data key, for writes made before the tenant's setting
+// is committed
+func (k *Keyring) SealWith(dataKey string, value string) (string, error) {
+	aead, err := k.unwrap(dataKey)
+	if err != nil {
+		return "", err
+	}
+	return k.sealWith(tenantKey{aead: aead, encrypt: true}, value)
+}
+
+func (k *Keyring) sealWith(key tenantKey, value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	if key.aead == nil {
+		return "", errors.New("tenant has no data key")
+	}
+	sealed, err := seal(key.aead, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + sealed, nil
+}
+
+// Open decrypts a value sealed with a tenant's data key; other values are returned unchanged
+func (k *Keyring) Open(tenantID uuid.UUID, value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	key, err := k.key(tenantID)
+	if err != nil {
+		return "", err
+	}
+	if key.aead == nil {
+		return "", errors.New("tenant has no data key")
+	}
+	plaintext, err := open(key.aead, strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Forget drops a tenant's cached key and setting after they change
+func (k *Keyring) Forget(tenantID uuid.UUID) {
+	k.mu.Lock()
+	delete(k.keys, tenantID)
+	k.mu.Unlock()
+}
+
+// key returns a tenant's cached data key and setting, loading them when missing or stale
+func (k *Keyring) key(tenantID uuid.UUID) (tenantKey, error) {
+	k.mu.RLock()
+	key, ok := k.keys[tenantID]
+	k.mu.RUnlock()
+	if ok && time.Since(key.loadedAt) < keyTTL {
+		return key, nil
+	}
+
+	var row struct {
+		EncryptPII bool
+		DataKey    *string
+	}
+	if err := k.db.Raw("SELECT encrypt_pii, data_key FROM tenants WHERE id = ?", tenantID).Scan(&row).Error; err != nil {
+		return tenantKey{}, err
+	}
+	key = tenantKey{encrypt: row.EncryptPII, loadedAt: time.Now()}
+	if row.DataKey != nil && *row.DataKey != "" {
+		aead, err := k.unwrap(*row.DataKey)
+		if err != nil {
+			return tenantKey{}, fmt.Errorf("tenant %s data key: %w", tenantID, err)
+		}
+		key.aead = aead
+	}
+
+	k.mu.Lock()
+	k.keys[tenantID] = key
+	k.mu.Unlock()
+	return key, nil
+}
+
+// unwrap decrypts a data key with the master key
+func (k *Keyring) unwrap(dataKey string) (cipher.AEAD, error) {
+	if k.master == nil {
+		return nil, ErrNoMasterKey
+	}
+	key, err := open(k.master, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+// Seal encrypts a value for a tenant with the shared keyring; without one, as in the scripts,
+// values are left as they are
+func Seal(tenantID uuid.UUID, value string) (string, error) {
+	keyring := GetKeyring()
+	if keyring == nil {
+		return value, nil
+	}
+	return keyring.Seal(tenantID, value)
+}
+
+// Open decrypts a value of a tenant with the shared keyring
+func Open(tenantID uuid.UUID, value string) (string, error) {
+	if !strings.HasPrefix(value, sealedPrefix) {
+		return value, nil
+	}
+	keyring := GetKeyring()
+	if keyring == nil {
+		return "", errors.New("tenant keyring is not configured")
+	}
+	return keyring.Open(tenantID, value)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, returning base64 of the nonce and ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func open(aead cipher.AEAD, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed value is too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
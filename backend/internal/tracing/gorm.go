@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// registrar is the callback registration GORM returns from Before and After
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// GormPlugin records a client span for every statement GORM runs within a trace. Queries join the
+// trace when run with db.WithContext(ctx).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	register := func(operation string, before, after registrar) error {
+		if err := before.Register("tracing:before_"+operation, startGormSpan(operation)); err != nil {
+			return err
+		}
+		return after.Register("tracing:after_"+operation, endGormSpan(operation))
+	}
+
+	if err := register("create", callbacks.Create().Before("*"), callbacks.Create().After("*")); err != nil {
+		return err
+	}
+	if err := register("query", callbacks.Query().Before("*"), callbacks.Query().After("*")); err != nil {
+		return err
+	}
+	if err := register("update", callbacks.Update().Before("*"), callbacks.Update().After("*")); err != nil {
+		return err
+	}
+	if err := register("delete", callbacks.Delete().Before("*"), callbacks.Delete().After("*")); err != nil {
+		return err
+	}
+	if err := register("row", callbacks.Row().Before("*"), callbacks.Row().After("*")); err != nil {
+		return err
+	}
+	return register("raw", callbacks.Raw().Before("*"), callbacks.Raw().After("*"))
+}
+
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := startChild(db.Statement.Context, "db."+operation,
+			String("db.system", "postgresql"),
+			String("db.operation", operation),
+		)
+		if span != nil {
+			db.InstanceSet(gormSpanKey, span)
+		}
+	}
+}
+
+func endGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span := value.(*Span)
+
+		// The table is only known once GORM has parsed the model
+		if table := db.Statement.Table; table != "" {
+			span.SetName("db." + operation + " " + table)
+			span.SetAttributes(String("db.sql.table", table))
+		}
+		span.SetAttributes(
+			String("db.statement", db.Statement.SQL.String()),
+			Int64("db.rows_affected", db.RowsAffected),
+		)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(db.Error)
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook records a client span for every Redis command and pipeline run within a trace
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startChild(ctx, "redis."+cmd.Name(),
+			String("db.system", "redis"),
+			String("db.operation", cmd.Name()),
+		)
+		err := next(ctx, cmd)
+		if !errors.Is(err, redis.Nil) {
+			span.RecordError(err)
+		}
+		span.End()
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startChild(ctx, "redis.pipeline",
+			String("db.system", "redis"),
+			Int("db.redis.commands", len(cmds)),
+		)
+		err := next(ctx, cmds)
+		if !errors.Is(err, redis.Nil) {
+			span.RecordError(err)
+		}
+		span.End()
+		return err
+	}
+}
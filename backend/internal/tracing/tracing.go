@@ -0,0 +1,288 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+)
+
+// SpanKind says how a span relates to the rest of a trace, as in OTLP
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Span status codes, as in OTLP
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// Tracer samples traces and hands finished spans to the exporter
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+	exporter    *exporter
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// Init creates the shared tracer; while tracing is disabled spans are not recorded and cost next
+// to nothing
+func Init(cfg *config.TracingConfig) (*Tracer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := &Tracer{
+		serviceName: cfg.ServiceName,
+		sampleRatio: cfg.SampleRatio,
+		exporter:    exporter,
+	}
+	defaultTracer.Store(tracer)
+	return tracer, nil
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	return defaultTracer.Load() != nil
+}
+
+// Shutdown exports the spans still queued
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	defaultTracer.CompareAndSwap(t, nil)
+	return t.exporter.shutdown(ctx)
+}
+
+// SpanContext identifies a span within its trace
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+type spanContextKey struct{}
+
+// ContextWithRemote returns a context whose next span continues a trace started by a caller
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// FromContext returns the span context carried by ctx
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Span is one timed operation in a trace. A nil span, returned while tracing is disabled, accepts
+// every call and records nothing.
+type Span struct {
+	tracer   *Tracer
+	sc       SpanContext
+	parentID SpanID
+	name     string
+	kind     SpanKind
+	start    time.Time
+
+	mu            sync.Mutex
+	end           time.Time
+	attrs         []Attr
+	statusCode    int
+	statusMessage string
+	ended         bool
+}
+
+// Start starts an internal span as a child of the span carried by ctx
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind starts a span of the given kind as a child of the span carried by ctx
+func StartKind(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	tracer := defaultTracer.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	sc := SpanContext{SpanID: newSpanID()}
+	var parentID SpanID
+	if parent, ok := FromContext(ctx); ok {
+		// Children follow the sampling decision of their parent, so traces are whole or absent
+		sc.TraceID, sc.Sampled, parentID = parent.TraceID, parent.Sampled, parent.SpanID
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = tracer.sample(sc.TraceID)
+	}
+
+	ctx = context.WithValue(ctx, spanContextKey{}, sc)
+	if !sc.Sampled {
+		return ctx, nil
+	}
+
+	return ctx, &Span{
+		tracer:   tracer,
+		sc:       sc,
+		parentID: parentID,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+		attrs:    attrs,
+	}
+}
+
+// startChild starts a client span only when ctx already carries a span, so driver calls made
+// outside a request or traced job, such as health check pings, do not each become a trace
+func startChild(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if _, ok := FromContext(ctx); !ok {
+		return ctx, nil
+	}
+	return StartKind(ctx, name, KindClient, attrs...)
+}
+
+// sample keeps a trace when its ID falls within the sample ratio, so every service sampling at
+// the same ratio keeps the same traces
+func (t *Tracer) sample(id TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetName renames the span, for names only known once the work is done such as a matched route
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// RecordError marks the span as failed; a nil error is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = statusError
+	s.statusMessage = err.Error()
+}
+
+// End finishes the span and queues it for export; later calls are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.exporter.enqueue(s)
+}
+
+// TraceID is the 16 byte ID shared by every span of a trace
+type TraceID [16]byte
+
+// SpanID is the 8 byte ID of a span
+type SpanID [8]byte
+
+func (id TraceID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+func (id SpanID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// Attr is a span attribute; the value is a string, bool, int64 or float64
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attr {
+	return Attr{key, value}
+}
+
+func Int(key string, value int) Attr {
+	return Attr{key, int64(value)}
+}
+
+func Int64(key string, value int64) Attr {
+	return Attr{key, value}
+}
+
+func Float(key string, value float64) Attr {
+	return Attr{key, value}
+}
+
+func Bool(key string, value bool) Attr {
+	return Attr{key, value}
+}
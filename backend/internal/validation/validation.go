@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is a request field that failed one of its validate tags
+type FieldError struct {
+	Field   string `json:"field"` // JSON name, dotted for nested fields
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Errors lists every field of a request that failed validation
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// embeddedPrefix marks the names of embedded structs, which have no JSON name of their own
+const embeddedPrefix = "~"
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if field.Anonymous {
+			return embeddedPrefix + field.Name
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	// notblank is required for strings that must hold more than whitespace
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		if field.Kind() == reflect.String {
+			return strings.TrimSpace(field.String()) != ""
+		}
+		return !field.IsZero()
+	})
+	return v
+}
+
+// Struct checks a request against its validate tags, returning Errors listing every failing field
+func Struct(s interface{}) error {
+	return fieldErrors(validate.Struct(s))
+}
+
+// Partial checks the validate tags of the top-level fields a partial update sets, leaving out the
+// fields left at their zero value
+func Partial(s interface{}) error {
+	val := reflect.Indirect(reflect.ValueOf(s))
+	if val.Kind() != reflect.Struct {
+		return Struct(s)
+	}
+
+	var fields []string
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.IsExported() && !field.Anonymous && !val.Field(i).IsZero() {
+			fields = append(fields, field.Name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fieldErrors(validate.StructPartial(s, fields...))
+}
+
+func fieldErrors(err error) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+
+	result := make(Errors, 0, len(invalid))
+	for _, fe := range invalid {
+		field := fieldPath(fe.Namespace())
+		result = append(result, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(field, fe),
+		})
+	}
+	return result
+}
+
+// fieldPath turns a validator namespace into the field's JSON path, without the request type and
+// embedded structs
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments[1:] {
+		if !strings.HasPrefix(segment, embeddedPrefix) {
+			path = append(path, segment)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+func message(field string, fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required", "notblank":
+		return field + " is required"
+	case "email":
+		return field + " must be a valid email address"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, fe.Param())
+	case "uuid", "uuid4":
+		return field + " must be a UUID"
+	}
+	return fmt.Sprintf("%s is invalid (%s)", field, fe.Tag())
+}
@@ -1,113 +0,0 @@
-package websocket
-
-import (
-	"time"
-
-	"github.com/gorilla/websocket"
-)
-
-const (
-	// Time allowed to write a message to the peer
-	writeWait = 10 * time.Second
-
-	// Time allowed to read the next pong message from the peer
-	pongWait = 60 * time.Second
-
-	// Send pings to peer with this period. Must be less than pongWait
-	pingPeriod = (pongWait * 9) / 10
-
-	// Maximum message size allowed from peer
-	maxMessageSize = 512
-)
-
-// Client represents a websocket client connection
-type Client struct {
-	// The websocket connection
-	conn *websocket.Conn
-
-	// Buffered channel of outbound messages
-	send chan []byte
-
-	// Client unique identifier
-	id string
-
-	// User ID associated with this client
-	userID string
-
-	// Hub reference
-	hub *Hub
-}
-
-// NewClient creates a new websocket client
-func NewClient(conn *websocket.Conn, hub *Hub, userID string, clientID string) *Client {
-	return &Client{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		id:     clientID,
-		userID: userID,
-		hub:    hub,
-	}
-}
-
-// ReadPump handles reading messages from the websocket connection
-func (c *Client) ReadPump() {
-	defer func() {
-		c.hub.unregister <- c
-		c.conn.Close()
-	}()
-
-	c.conn.SetReadLimit(maxMessageSize)
-	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(pongWait))
-		return nil
-	})
-
-	for {
-		_, message, err := c.conn.ReadMessage()
-		if err != nil {
-			break
-		}
-
-		// Handle the message - you can add custom message processing here
-		c.handleMessage(message)
-	}
-}
-
-// WritePump handles writing messages to the websocket connection
-func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
-	defer func() {
-		ticker.Stop()
-		c.conn.Close()
-	}()
-
-	for {
-		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				return
-			}
-
-		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				return
-			}
-		}
-	}
-}
-
-// handleMessage processes incoming messages from the client
-func (c *Client) handleMessage(message []byte) {
-	// You can add custom message handling logic here
-	// For now, we'll just broadcast the message to all clients
-	c.hub.broadcast <- message
-}
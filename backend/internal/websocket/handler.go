@@ -1,45 +0,0 @@
-package websocket
-
-import (
-	"log"
-	"net/http"
-
-	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
-)
-
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow connections from any origin for now
-		// In production, you should validate the origin
-		return true
-	},
-}
-
-// HandleWebSocket handles websocket connection upgrades
-func HandleWebSocket(hub *Hub, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("Failed to upgrade connection: %v", err)
-		return
-	}
-
-	// Get user ID from query params or JWT token
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		userID = "anonymous"
-	}
-
-	// Generate unique client ID
-	clientID := uuid.New().String()
-
-	// Create new client
-	client := NewClient(conn, hub, userID, clientID)
-
-	// Register client with hub
-	hub.register <- client
-
-	// Start goroutines for reading and writing
-	go client.WritePump()
-	go client.ReadPump()
-}
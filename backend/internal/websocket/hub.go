@@ -1,123 +1,488 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/config"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+// Transport is a client connection the hub writes to. Both the Fiber and the Gorilla WebSocket
+// connections implement it.
+type Transport interface {
+	SetWriteDeadline(t time.Time) error
+	WriteMessage(messageType int, data []byte) error
+	Close() error
+}
+
+// textMessage is the WebSocket text frame type, the same in every WebSocket library
+const textMessage = 1
+
+// Message is an event or reply sent to WebSocket clients
+type Message struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+	// RequestID is the ID of the request or job that caused the event
+	RequestID string `json:"request_id,omitempty"`
+	// Topic and Seq number the events of replay topics, so a reconnecting client can ask for the
+	// events after the last one it saw
+	Topic string `json:"topic,omitempty"`
+	Seq   int64  `json:"seq,omitempty"`
+}
+
+// Transports a client can be connected over
+const (
+	TransportWebSocket = "websocket"
+	TransportSSE       = "sse"
 )
 
+// Client identifies a connection: its ID, the user it was authenticated as and where from
+type Client struct {
+	ID        string
+	UserID    string
+	Role      string
+	IP        string
+	Transport string
+}
+
+// ConnectionInfo describes a connected client for operators
+type ConnectionInfo struct {
+	ClientID       string                 `json:"client_id"`
+	UserID         string                 `json:"user_id"`
+	Role           string                 `json:"role"`
+	IPAddress      string                 `json:"ip_address"`
+	Transport      string                 `json:"transport"`
+	ConnectedAt    time.Time              `json:"connected_at"`
+	LastSeenAt     time.Time              `json:"last_seen_at"` // Last pong or message received, or heartbeat sent on an event stream
+	Subscriptions  map[string]interface{} `json:"subscriptions"`
+	QueuedMessages int                    `json:"queued_messages"`
+}
+
+// PortfolioLister returns the IDs of the portfolios a user may see; all is true for roles that see every portfolio
+type PortfolioLister func(userID, role string) (ids []string, all bool, err error)
+
+// subscriber is an authenticated client connection and its filters. Everything sent to
+// the client goes through its send queue, drained by its own writer goroutine.
+type subscriber struct {
+	conn        Transport
+	id          string
+	userID      string
+	role        string
+	ip          string
+	transport   string
+	connectedAt time.Time
+	lastSeen    atomic.Int64 // Unix nanoseconds
+	subs        *Subscriptions
+
+	send         chan []byte
+	dropOldest   bool          // When the queue is full, discard its oldest message instead of disconnecting
+	writeTimeout time.Duration // Deadline for each write to the client
+	pingInterval time.Duration // How often a WebSocket client is pinged; 0 for never
+	pongTimeout  time.Duration // How long a WebSocket client may stay silent before its read fails
+	done         chan struct{} // Closed to stop the writer
+	stopOnce     sync.Once
+	closing      chan struct{} // Closed to flush the queue and send a close frame before stopping
+	closeOnce    sync.Once
+	closeCode    int           // Close frame status, set before closing is closed
+	closeReason  string        // Close frame reason, set before closing is closed
+	stopped      chan struct{} // Closed once the writer has returned
+	logger       *slog.Logger
+
+	ownedMu sync.RWMutex
+	owned   map[string]bool
+	all     bool
+
+	// Live events are held while the client's missed events are replayed, then delivered unless
+	// the replay already did
+	replayMu  sync.Mutex
+	replaying bool
+	held      []outbound
+	lastSeq   map[string]int64 // Latest sequence number delivered per replay topic
+}
+
+// outbound is a queued event; prices is set for price updates, which are filtered per symbol.
+// Events of replay topics carry their replay topic and sequence number.
+type outbound struct {
+	topic  Topic
+	data   []byte
+	prices map[string]interface{}
+	replay string
+	seq    int64
+}
+
+// Hub is the WebSocket gateway: it routes events to the authenticated clients they are meant for,
+// by user, portfolio access and subscriptions. Broadcasting only queues events for each client,
+// so a client that stops reading cannot hold up the others.
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
-	mu         sync.RWMutex
+	connections    map[Transport]*subscriber
+	broadcast      chan outbound
+	listPortfolios PortfolioLister
+	queueSize      int
+	writeTimeout   time.Duration
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+	dropOldest     bool
+	closed         bool // Set on shutdown; clients registering afterwards are disconnected at once
+	mu             sync.RWMutex
+	logger         *slog.Logger
 }
 
-func NewHub() *Hub {
+// NewHub creates the WebSocket gateway
+func NewHub(cfg *config.WebSocketConfig) *Hub {
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		connections:  make(map[Transport]*subscriber),
+		broadcast:    make(chan outbound, 256),
+		queueSize:    max(cfg.SendQueueSize, 1),
+		writeTimeout: cfg.WriteTimeout,
+		pingInterval: cfg.PingInterval,
+		pongTimeout:  cfg.PongTimeout,
+		dropOldest:   cfg.SlowClientPolicy == config.SlowClientDropOldest,
+		logger:       logging.Component("websocket"),
 	}
 }
 
-func (h *Hub) Run() {
+// SetPortfolioLister sets the lookup used to restrict portfolio events to users with access
+func (h *Hub) SetPortfolioLister(lister PortfolioLister) {
+	h.listPortfolios = lister
+}
+
+// Run routes events until ctx is cancelled. Each event is queued for the clients it is meant for;
+// a client whose queue is full is disconnected, unless the hub drops its oldest messages instead.
+func (h *Hub) Run(ctx context.Context) {
 	for {
+		var out outbound
 		select {
-		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-
-			log.Printf("Client registered: %s", client.id)
-
-			// Send welcome message
-			welcome := Message{
-				Type: "welcome",
-				Data: map[string]interface{}{
-					"message":   "Connected to Risk Monitor",
-					"client_id": client.id,
-				},
-			}
+		case <-ctx.Done():
+			return
+		case out = <-h.broadcast:
+		}
+
+		h.mu.RLock()
+		subs := make([]*subscriber, 0, len(h.connections))
+		for _, sub := range h.connections {
+			subs = append(subs, sub)
+		}
+		h.mu.RUnlock()
 
-			if data, err := json.Marshal(welcome); err == nil {
-				client.send <- data
+		for _, sub := range subs {
+			data := out.data
+			if out.prices != nil {
+				data = sub.filterPrices(out.prices)
+				if data == nil {
+					continue
+				}
+			} else if !sub.accepts(out.topic) {
+				continue
 			}
 
-		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				h.mu.Unlock()
-				log.Printf("Client unregistered: %s", client.id)
-			} else {
-				h.mu.Unlock()
+			if !sub.deliver(out, data) {
+				h.logger.Warn("WebSocket client too slow, disconnecting", "user_id", sub.userID, "queue_size", h.queueSize)
+				sub.stop()
 			}
+		}
+	}
+}
 
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					// Client's send channel is full, close it
-					close(client.send)
-					delete(h.clients, client)
-				}
+// RegisterConnection registers an authenticated client connection. A WebSocket client is pinged
+// every ping interval from then on and disconnected when it has not answered within the pong
+// timeout.
+func (h *Hub) RegisterConnection(conn Transport, client Client) {
+	sub := &subscriber{
+		conn:         conn,
+		id:           client.ID,
+		userID:       client.UserID,
+		role:         client.Role,
+		ip:           client.IP,
+		transport:    client.Transport,
+		connectedAt:  time.Now(),
+		subs:         NewSubscriptions(),
+		send:         make(chan []byte, h.queueSize),
+		dropOldest:   h.dropOldest,
+		writeTimeout: h.writeTimeout,
+		pingInterval: h.pingInterval,
+		pongTimeout:  h.pongTimeout,
+		done:         make(chan struct{}),
+		closing:      make(chan struct{}),
+		stopped:      make(chan struct{}),
+		logger:       h.logger,
+		lastSeq:      make(map[string]int64),
+	}
+	h.refreshOwned(sub)
+	sub.keepAlive()
+	go sub.writePump()
+
+	h.mu.Lock()
+	h.connections[conn] = sub
+	total := len(h.connections)
+	closed := h.closed
+	h.mu.Unlock()
+	metrics.WebSocketConnections.With(metrics.HubGateway).Set(float64(total))
+
+	if closed {
+		sub.closeGracefully()
+	}
+	h.logger.Info("WebSocket client registered", "user_id", client.UserID, "client_id", client.ID,
+		"transport", client.Transport, "connections", total)
+}
+
+// Connections describes the clients connected to this instance, oldest first
+func (h *Hub) Connections() []ConnectionInfo {
+	h.mu.RLock()
+	infos := make([]ConnectionInfo, 0, len(h.connections))
+	for _, sub := range h.connections {
+		infos = append(infos, ConnectionInfo{
+			ClientID:       sub.id,
+			UserID:         sub.userID,
+			Role:           sub.role,
+			IPAddress:      sub.ip,
+			Transport:      sub.transport,
+			ConnectedAt:    sub.connectedAt,
+			LastSeenAt:     time.Unix(0, sub.lastSeen.Load()),
+			Subscriptions:  sub.subs.Snapshot(),
+			QueuedMessages: len(sub.send),
+		})
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ConnectedAt.Before(infos[j].ConnectedAt) })
+	return infos
+}
+
+// Disconnect closes a client connected to this instance, sending a WebSocket client its queued
+// messages and a policy violation close frame with the reason first. It reports false when no
+// such client is connected here.
+func (h *Hub) Disconnect(clientID, reason string) bool {
+	h.mu.RLock()
+	var target *subscriber
+	for _, sub := range h.connections {
+		if sub.id == clientID {
+			target = sub
+			break
+		}
+	}
+	h.mu.RUnlock()
+	if target == nil {
+		return false
+	}
+
+	h.logger.Warn("Disconnecting WebSocket client", "user_id", target.userID, "client_id", clientID, "reason", reason)
+	target.closeWith(closePolicyViolation, reason)
+	return true
+}
+
+// Shutdown disconnects every client once it has been sent its queued messages and, over
+// WebSocket, a going away close frame. Clients connecting afterwards are disconnected the same
+// way. When ctx ends before every client is done, the rest are closed at once and ctx's error is
+// returned.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	subs := make([]*subscriber, 0, len(h.connections))
+	for _, sub := range h.connections {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	h.logger.Info("Closing WebSocket clients", "connections", len(subs))
+	for _, sub := range subs {
+		sub.closeGracefully()
+	}
+	for _, sub := range subs {
+		select {
+		case <-sub.stopped:
+		case <-ctx.Done():
+			for _, sub := range subs {
+				sub.stop()
 			}
-			h.mu.RUnlock()
+			return ctx.Err()
 		}
 	}
+	return nil
 }
 
-// Register adds a client to the hub
-func (h *Hub) Register(client *Client) {
-	h.register <- client
+// UnregisterConnection unregisters a WebSocket connection and waits for its writer to return,
+// since the connection must not be written to once its handler has returned
+func (h *Hub) UnregisterConnection(conn Transport) {
+	h.mu.Lock()
+	sub, ok := h.connections[conn]
+	delete(h.connections, conn)
+	total := len(h.connections)
+	h.mu.Unlock()
+	if ok {
+		sub.stop()
+		<-sub.stopped
+	}
+	metrics.WebSocketConnections.With(metrics.HubGateway).Set(float64(total))
+
+	h.logger.Info("WebSocket client unregistered", "connections", total)
+}
+
+// HandleClientMessage processes a subscribe, unsubscribe or ping message and replies to the client
+func (h *Hub) HandleClientMessage(conn Transport, raw []byte) {
+	h.mu.RLock()
+	sub, ok := h.connections[conn]
+	h.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	sub.alive()
+
+	var msg ClientMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		sub.writeJSON(Message{Type: "error", Data: map[string]interface{}{"message": "Invalid message format"}})
+		return
+	}
+
+	h.handle(sub, msg)
 }
 
-// Unregister removes a client from the hub
-func (h *Hub) Unregister(client *Client) {
-	h.unregister <- client
+// handle carries out a client message; replies go through the client's send queue
+func (h *Hub) handle(sub *subscriber, msg ClientMessage) {
+	switch msg.Action {
+	case ActionPing:
+		sub.writeJSON(Message{Type: "pong", Data: map[string]interface{}{}})
+
+	case ActionSubscribe, ActionUnsubscribe:
+		var rejected []string
+		if msg.Action == ActionSubscribe && len(msg.Portfolios) > 0 {
+			// Pick up portfolios created since the client connected
+			h.refreshOwned(sub)
+
+			allowed := make([]string, 0, len(msg.Portfolios))
+			for _, id := range msg.Portfolios {
+				if sub.canSee(id) {
+					allowed = append(allowed, id)
+				} else {
+					rejected = append(rejected, id)
+				}
+			}
+			msg.Portfolios = allowed
+		}
+
+		sub.subs.Apply(msg)
+
+		data := sub.subs.Snapshot()
+		if len(rejected) > 0 {
+			data["rejected_portfolios"] = rejected
+		}
+		sub.writeJSON(Message{Type: msg.Action + "d", Data: data})
+
+	case ActionReplay:
+		h.replay(sub, msg.LastSeenSeq)
+
+	default:
+		sub.writeJSON(Message{Type: "error", Data: map[string]interface{}{"message": "Unknown action: " + msg.Action}})
+	}
 }
 
-// BroadcastToAll sends a message to all connected clients
+// BroadcastToAll broadcasts a message to all connected clients, ignoring subscriptions
 func (h *Hub) BroadcastToAll(message interface{}) error {
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	h.broadcast <- data
+	h.enqueue(outbound{data: data})
 	return nil
 }
 
-// BroadcastToUser sends a message to a specific user
-func (h *Hub) BroadcastToUser(userID string, message interface{}) error {
+// Publish sends a message to the clients whose permissions and subscriptions match the topic
+func (h *Hub) Publish(topic Topic, message interface{}) error {
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	for client := range h.clients {
-		if client.userID == userID {
-			select {
-			case client.send <- data:
-			default:
-				// Client's send channel is full
-			}
-		}
+	out := outbound{topic: topic, data: data}
+	if m, ok := message.(Message); ok && m.Seq > 0 {
+		out.replay, out.seq = m.Topic, m.Seq
 	}
+	h.enqueue(out)
+	return nil
+}
+
+// SendToUser sends a message to every connection of a user
+func (h *Hub) SendToUser(userID string, message interface{}) error {
+	return h.Publish(Topic{UserID: userID}, message)
+}
 
+// PublishPrices sends a price_update to every client, trimmed to the symbols each one subscribed to
+func (h *Hub) PublishPrices(updates map[string]interface{}) error {
+	h.enqueue(outbound{prices: updates})
 	return nil
 }
 
-type Message struct {
-	Type string                 `json:"type"`
-	Data map[string]interface{} `json:"data"`
+func (h *Hub) enqueue(out outbound) {
+	select {
+	case h.broadcast <- out:
+	default:
+		metrics.WebSocketMessagesDropped.With(metrics.HubGateway).Inc()
+		h.logger.Warn("Broadcast channel full, dropping message")
+	}
+}
+
+func (h *Hub) refreshOwned(sub *subscriber) {
+	if h.listPortfolios == nil {
+		return
+	}
+
+	ids, all, err := h.listPortfolios(sub.userID, sub.role)
+	if err != nil {
+		h.logger.Error("Failed to load portfolios for WebSocket user", "user_id", sub.userID, "error", err)
+		return
+	}
+
+	owned := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		owned[id] = true
+	}
+
+	sub.ownedMu.Lock()
+	sub.owned = owned
+	sub.all = all
+	sub.ownedMu.Unlock()
+}
+
+// canSee reports whether the user may receive events for a portfolio
+func (s *subscriber) canSee(portfolioID string) bool {
+	s.ownedMu.RLock()
+	defer s.ownedMu.RUnlock()
+	return s.all || s.owned[portfolioID]
+}
+
+func (s *subscriber) accepts(topic Topic) bool {
+	if topic.UserID != "" && topic.UserID != s.userID {
+		return false
+	}
+	if topic.PortfolioID != "" && !s.canSee(topic.PortfolioID) {
+		return false
+	}
+	return s.subs.Matches(topic)
+}
+
+// filterPrices returns the marshalled price_update for the client's symbols, or nil if none apply
+func (s *subscriber) filterPrices(prices map[string]interface{}) []byte {
+	filtered := make(map[string]interface{}, len(prices))
+	for symbol, update := range prices {
+		if s.subs.WantsSymbol(symbol) {
+			filtered[symbol] = update
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(Message{Type: "price_update", Data: filtered})
+	if err != nil {
+		return nil
+	}
+	return data
 }
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/logging"
+)
+
+// RedisBridge relays events published to Redis by any instance to the local WebSocket hub
+type RedisBridge struct {
+	client *redis.Client
+	hub    *Hub
+	logger *slog.Logger
+}
+
+// NewRedisBridge creates a bridge from Redis pub/sub to the given hub
+func NewRedisBridge(client *redis.Client, hub *Hub) *RedisBridge {
+	return &RedisBridge{
+		client: client,
+		hub:    hub,
+		logger: logging.Component("redis_bridge"),
+	}
+}
+
+// Run subscribes to the alert, risk, order, job and price channels and relays messages until ctx
+// is cancelled
+func (b *RedisBridge) Run(ctx context.Context) {
+	channels := []string{database.AlertsChannel, database.RiskUpdatesChannel, database.OrdersChannel,
+		database.JobsChannel, database.PricesChannel}
+	pubsub := b.client.Subscribe(ctx, channels...)
+	defer pubsub.Close()
+
+	b.logger.Info("Redis bridge subscribed", "channels", channels)
+
+	// The channel is closed when pubsub is closed; go-redis reconnects on its own
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			b.relay(msg.Channel, msg.Payload)
+		}
+	}
+}
+
+// eventEnvelope is how events of replayable channels are published: the event and its sequence
+// number on the channel
+type eventEnvelope struct {
+	Seq   int64           `json:"seq"`
+	Event json.RawMessage `json:"event"`
+}
+
+// relay converts a Redis payload into the WebSocket message format and broadcasts it
+func (b *RedisBridge) relay(channel, payload string) {
+	var seq int64
+	if database.IsReplayable(channel) {
+		var envelope eventEnvelope
+		if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+			b.logger.Warn("Invalid event envelope", "channel", channel, "error", err)
+			return
+		}
+		seq = envelope.Seq
+		payload = string(envelope.Event)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &data); err != nil {
+		b.logger.Warn("Invalid payload", "channel", channel, "error", err)
+		return
+	}
+
+	if channel == database.PricesChannel {
+		b.relayPrices(data)
+		return
+	}
+
+	message, topic, ok := eventMessage(channel, data)
+	if !ok {
+		return
+	}
+	message.Seq = seq
+
+	if err := b.hub.Publish(topic, message); err != nil {
+		ctx := logging.WithRequestID(context.Background(), message.RequestID)
+		b.logger.ErrorContext(ctx, "Failed to broadcast to hub", "type", message.Type, "error", err)
+	}
+}
+
+// eventMessage converts an alert, risk update, order update or job update event into its WebSocket
+// message and the topic it is routed by
+func eventMessage(channel string, data map[string]interface{}) (Message, Topic, bool) {
+	topic := Topic{PortfolioID: stringField(data, "portfolio_id")}
+	requestID := stringField(data, "request_id")
+
+	switch channel {
+	case database.AlertsChannel:
+		topic.Severity = stringField(data, "severity")
+		return Message{
+			Type: "new_alert",
+			Data: map[string]interface{}{
+				"alert":     data,
+				"timestamp": time.Now().Unix(),
+			},
+			RequestID: requestID,
+			Topic:     TopicAlerts,
+		}, topic, true
+	case database.RiskUpdatesChannel:
+		return Message{
+			Type:      "risk_update",
+			Data:      data,
+			RequestID: requestID,
+			Topic:     TopicRiskUpdates,
+		}, topic, true
+	case database.OrdersChannel:
+		return Message{
+			Type:      "order_update",
+			Data:      data,
+			RequestID: requestID,
+		}, topic, true
+	case database.JobsChannel:
+		// Only the user who started a job hears about it, whichever portfolios they follow
+		return Message{
+			Type:      "job_update",
+			Data:      data,
+			RequestID: requestID,
+		}, Topic{UserID: stringField(data, "requested_by")}, true
+	}
+	return Message{}, topic, false
+}
+
+// relayPrices broadcasts a batch of price updates keyed by symbol
+func (b *RedisBridge) relayPrices(updates map[string]interface{}) {
+	if err := b.hub.PublishPrices(updates); err != nil {
+		b.logger.Error("Failed to broadcast prices to hub", "error", err)
+	}
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	if v, ok := data[key].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+const (
+	// maxReplayEvents is the most events replayed per topic in one request; a client further
+	// behind replays again from the last event it received
+	maxReplayEvents = 500
+
+	// maxHeldEvents is the most live events held for a client while its replay runs
+	maxHeldEvents = 256
+
+	replayTimeout = 10 * time.Second
+)
+
+// replay sends a client the events of each requested topic numbered after the last one it saw,
+// as permitted by its portfolio access and subscriptions, then a replay_complete report, before
+// resuming live delivery. Live events arriving meanwhile are held and delivered afterwards unless
+// the replay already sent them.
+func (h *Hub) replay(sub *subscriber, lastSeen map[string]int64) {
+	if len(lastSeen) == 0 {
+		sub.writeJSON(Message{Type: "error", Data: map[string]interface{}{"message": "last_seen_seq is required"}})
+		return
+	}
+	for topic := range lastSeen {
+		if _, ok := replayTopics[topic]; !ok {
+			sub.writeJSON(Message{Type: "error", Data: map[string]interface{}{"message": "Unknown replay topic: " + topic}})
+			return
+		}
+	}
+
+	sub.replayMu.Lock()
+	if sub.replaying {
+		sub.replayMu.Unlock()
+		sub.writeJSON(Message{Type: "error", Data: map[string]interface{}{"message": "Replay already in progress"}})
+		return
+	}
+	sub.replaying = true
+	sub.replayMu.Unlock()
+	defer sub.resume()
+
+	ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+	defer cancel()
+
+	report := make(map[string]interface{}, len(lastSeen))
+	for topic, after := range lastSeen {
+		channel := replayTopics[topic]
+		events, latest, truncated, err := database.ReplayEvents(ctx, channel, after, maxReplayEvents)
+		if err != nil {
+			h.logger.Warn("Failed to replay WebSocket events", "user_id", sub.userID, "topic", topic, "error", err)
+			report[topic] = map[string]interface{}{"error": "Replay unavailable"}
+			continue
+		}
+
+		replayed := 0
+		lastReplayed := after
+		for _, event := range events {
+			lastReplayed = event.Seq
+
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+				continue
+			}
+			message, eventTopic, ok := eventMessage(channel, data)
+			if !ok || !sub.accepts(eventTopic) {
+				continue
+			}
+			message.Seq = event.Seq
+
+			payload, err := json.Marshal(message)
+			if err != nil {
+				continue
+			}
+			if err := sub.enqueueWait(ctx, payload); err != nil {
+				return
+			}
+			replayed++
+		}
+		sub.markDelivered(topic, lastReplayed)
+
+		report[topic] = map[string]interface{}{
+			"replayed":     replayed,
+			"last_seq":     lastReplayed,
+			"latest_seq":   latest,
+			"truncated":    truncated,             // Events after last_seen_seq were trimmed from the buffer
+			"more_pending": lastReplayed < latest, // Replay again from last_seq for the rest
+			"reset":        latest < after,        // The sequence restarted, e.g. Redis lost its data
+		}
+	}
+
+	sub.writeJSON(Message{Type: "replay_complete", Data: report})
+}
+
+// deliver queues a live event for the client, or holds it while a replay runs. Events of replay
+// topics the client already received are skipped. It reports false when the client's queue is
+// full and it should be disconnected.
+func (s *subscriber) deliver(out outbound, data []byte) bool {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	if s.replaying {
+		if len(s.held) >= maxHeldEvents {
+			metrics.WebSocketMessagesDropped.With(metrics.HubGateway).Inc()
+			return true
+		}
+		out.data = data
+		s.held = append(s.held, out)
+		return true
+	}
+	return s.queueLive(out, data)
+}
+
+// resume delivers the events held during a replay and returns the client to live delivery
+func (s *subscriber) resume() {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	for _, out := range s.held {
+		if !s.queueLive(out, out.data) {
+			s.stop()
+			break
+		}
+	}
+	s.held = nil
+	s.replaying = false
+}
+
+// markDelivered records that the events of a replay topic up to seq have been sent
+func (s *subscriber) markDelivered(topic string, seq int64) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	if seq > s.lastSeq[topic] {
+		s.lastSeq[topic] = seq
+	}
+}
+
+// queueLive queues an event unless it is a replay topic event at or before the last one sent.
+// The caller holds replayMu.
+func (s *subscriber) queueLive(out outbound, data []byte) bool {
+	if out.seq > 0 {
+		if out.seq <= s.lastSeq[out.replay] {
+			return true
+		}
+		s.lastSeq[out.replay] = out.seq
+	}
+	return s.enqueue(data)
+}
@@ -0,0 +1,217 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/metrics"
+)
+
+var errClientGone = errors.New("websocket client disconnected")
+
+// WebSocket close and ping frame types, the going away status code sent to clients on shutdown
+// and the policy violation one sent to clients disconnected by an operator, the same in every
+// WebSocket library
+const (
+	closeMessage         = 8
+	pingMessage          = 9
+	closeGoingAway       = 1001
+	closePolicyViolation = 1008
+)
+
+// closeFrameTimeout bounds the close frame write when the hub has no write timeout
+const closeFrameTimeout = time.Second
+
+// controlWriter is implemented by transports that can send control frames, such as the WebSocket
+// connections; the event stream has none
+type controlWriter interface {
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+}
+
+// pongReader is implemented by transports whose reads can time out and that are answered with
+// pongs, such as the WebSocket connections
+type pongReader interface {
+	SetReadDeadline(t time.Time) error
+	SetPongHandler(handler func(appData string) error)
+}
+
+// writePump writes the client's queued messages and pings until it is stopped or a write fails
+// or times out. When the client is closed gracefully, it first writes what is still queued and a
+// close frame. It is the only goroutine writing to the connection once the client is registered.
+func (s *subscriber) writePump() {
+	defer close(s.stopped)
+
+	var ping <-chan time.Time
+	if _, ok := s.conn.(controlWriter); ok && s.pingInterval > 0 {
+		ticker := time.NewTicker(s.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.closing:
+			s.flush()
+			s.stop()
+			return
+		case data := <-s.send:
+			if err := s.write(data); err != nil {
+				s.stop()
+				return
+			}
+		case <-ping:
+			if err := s.conn.(controlWriter).WriteControl(pingMessage, nil, time.Now().Add(s.controlTimeout())); err != nil {
+				s.logger.Debug("Failed to ping WebSocket client", "user_id", s.userID, "error", err)
+				s.stop()
+				return
+			}
+		}
+	}
+}
+
+// keepAlive has a WebSocket client's read fail once it has been silent for the pong timeout, so
+// that a client gone without closing its connection is unregistered. Pongs and messages from the
+// client extend it.
+func (s *subscriber) keepAlive() {
+	s.lastSeen.Store(time.Now().UnixNano())
+
+	conn, ok := s.conn.(pongReader)
+	if !ok || s.pongTimeout <= 0 {
+		return
+	}
+	conn.SetReadDeadline(time.Now().Add(s.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		s.alive()
+		return nil
+	})
+}
+
+// alive records that the client was heard from and extends its read deadline
+func (s *subscriber) alive() {
+	s.lastSeen.Store(time.Now().UnixNano())
+	if conn, ok := s.conn.(pongReader); ok && s.pongTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(s.pongTimeout))
+	}
+}
+
+// controlTimeout is the deadline for writing a control frame
+func (s *subscriber) controlTimeout() time.Duration {
+	if s.writeTimeout > 0 {
+		return s.writeTimeout
+	}
+	return closeFrameTimeout
+}
+
+func (s *subscriber) write(data []byte) error {
+	if s.writeTimeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	err := s.conn.WriteMessage(textMessage, data)
+	if err != nil {
+		s.logger.Debug("Failed to write to WebSocket client", "user_id", s.userID, "error", err)
+	}
+	return err
+}
+
+// flush writes the messages still queued, then tells a WebSocket client why it is being closed
+func (s *subscriber) flush() {
+queued:
+	for {
+		select {
+		case data := <-s.send:
+			if err := s.write(data); err != nil {
+				return
+			}
+		default:
+			break queued
+		}
+	}
+
+	conn, ok := s.conn.(controlWriter)
+	if !ok {
+		return
+	}
+	payload := append([]byte{byte(s.closeCode >> 8), byte(s.closeCode & 0xff)}, s.closeReason...)
+	if err := conn.WriteControl(closeMessage, payload, time.Now().Add(s.controlTimeout())); err != nil {
+		s.logger.Debug("Failed to send close frame to WebSocket client", "user_id", s.userID, "error", err)
+	}
+}
+
+// closeGracefully has the writer send the queued messages and a going away close frame before it
+// stops the client
+func (s *subscriber) closeGracefully() {
+	s.closeWith(closeGoingAway, "server shutting down")
+}
+
+// closeWith has the writer send the queued messages and a close frame with the given status and
+// reason before it stops the client; only the first close counts
+func (s *subscriber) closeWith(code int, reason string) {
+	s.closeOnce.Do(func() {
+		s.closeCode, s.closeReason = code, reason
+		close(s.closing)
+	})
+}
+
+// stop stops the writer and closes the connection, which unblocks a stalled write and ends the
+// connection's read loop so that its handler unregisters it
+func (s *subscriber) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// enqueue queues a message for the client without blocking. It reports false when the queue is
+// full and the client should be disconnected; with the drop oldest policy it discards the oldest
+// queued message to make room instead.
+func (s *subscriber) enqueue(data []byte) bool {
+	select {
+	case <-s.done:
+		return true
+	case s.send <- data:
+		return true
+	default:
+	}
+
+	metrics.WebSocketMessagesDropped.With(metrics.HubGateway).Inc()
+	if !s.dropOldest {
+		return false
+	}
+	select {
+	case <-s.send:
+	default:
+	}
+	select {
+	case s.send <- data:
+	default:
+		// The queue filled up again meanwhile; this message is the one dropped
+	}
+	return true
+}
+
+// enqueueWait queues a message for the client, waiting for room in the queue
+func (s *subscriber) enqueueWait(ctx context.Context, data []byte) error {
+	select {
+	case s.send <- data:
+		return nil
+	case <-s.done:
+		return errClientGone
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeJSON queues a reply to the client
+func (s *subscriber) writeJSON(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	if !s.enqueue(data) {
+		s.stop()
+	}
+}
@@ -0,0 +1,204 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sseHeartbeatInterval is how often an idle event stream gets a comment, so proxies keep it open
+// and a disconnected client is noticed
+const sseHeartbeatInterval = 15 * time.Second
+
+var errStreamClosed = errors.New("event stream closed")
+
+// sseTransport writes hub messages to a Server-Sent Events stream. The ID of each event of a
+// replay topic carries the last sequence number sent per topic, e.g. "alerts:12,risk_updates:40",
+// so the Last-Event-ID a client reconnects with resumes every topic.
+type sseTransport struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	cursor map[string]int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newSSETransport(w *bufio.Writer, lastSeen map[string]int64) *sseTransport {
+	cursor := make(map[string]int64, len(lastSeen))
+	for topic, seq := range lastSeen {
+		cursor[topic] = seq
+	}
+	return &sseTransport{
+		w:      w,
+		cursor: cursor,
+		done:   make(chan struct{}),
+	}
+}
+
+// SetWriteDeadline is a no-op: the stream has no per-write deadline, so a stalled client is left
+// to the server's write timeout
+func (t *sseTransport) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// WriteMessage writes a hub message as an event named after its type
+func (t *sseTransport) WriteMessage(_ int, data []byte) error {
+	var msg struct {
+		Type  string `json:"type"`
+		Topic string `json:"topic"`
+		Seq   int64  `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := ""
+	if msg.Topic != "" && msg.Seq > 0 {
+		t.cursor[msg.Topic] = msg.Seq
+		id = FormatLastEventID(t.cursor)
+	}
+	return t.write(msg.Type, id, data)
+}
+
+// Close ends the stream
+func (t *sseTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.done) })
+	return nil
+}
+
+// heartbeat writes a comment line, which clients ignore
+func (t *sseTransport) heartbeat() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return errStreamClosed
+	default:
+	}
+	if _, err := t.w.WriteString(": heartbeat\n\n"); err != nil {
+		return err
+	}
+	return t.w.Flush()
+}
+
+// write writes and flushes one event. The caller holds mu. Hub messages are single-line JSON.
+func (t *sseTransport) write(event, id string, data []byte) error {
+	select {
+	case <-t.done:
+		return errStreamClosed
+	default:
+	}
+
+	if id != "" {
+		fmt.Fprintf(t.w, "id: %s\n", id)
+	}
+	fmt.Fprintf(t.w, "event: %s\ndata: %s\n\n", event, data)
+	return t.w.Flush()
+}
+
+// ParseLastEventID reads the per-topic sequence numbers of an event ID; unknown topics and
+// malformed entries are ignored
+func ParseLastEventID(id string) map[string]int64 {
+	lastSeen := make(map[string]int64)
+	for _, part := range strings.Split(id, ",") {
+		topic, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		if _, known := replayTopics[topic]; !known {
+			continue
+		}
+		seq, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seq < 0 {
+			continue
+		}
+		lastSeen[topic] = seq
+	}
+	return lastSeen
+}
+
+// FormatLastEventID writes per-topic sequence numbers as an event ID
+func FormatLastEventID(cursor map[string]int64) string {
+	topics := make([]string, 0, len(cursor))
+	for topic := range cursor {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	parts := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		parts = append(parts, topic+":"+strconv.FormatInt(cursor[topic], 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ServeSSE streams the hub's events to an authenticated client over Server-Sent Events until it
+// disconnects. Clients cannot send messages on the stream, so their subscription filters are given
+// up front; with lastSeen, the events of each replay topic after it are replayed first, as with
+// the replay action. It returns once the client has gone.
+func (h *Hub) ServeSSE(w *bufio.Writer, client Client, filters ClientMessage, lastSeen map[string]int64) {
+	conn := newSSETransport(w, lastSeen)
+	client.ID = uuid.New().String()
+	client.Transport = TransportSSE
+	logger := h.logger.With("transport", TransportSSE, "user_id", client.UserID, "client_id", client.ID)
+
+	welcome, _ := json.Marshal(map[string]interface{}{
+		"type":      "welcome",
+		"message":   "Connected to Financial Risk Monitor event stream",
+		"user_id":   client.UserID,
+		"client_id": client.ID,
+		"timestamp": time.Now().Unix(),
+	})
+	conn.mu.Lock()
+	fmt.Fprint(w, "retry: 3000\n")
+	err := conn.write("welcome", "", welcome)
+	conn.mu.Unlock()
+	if err != nil {
+		logger.Debug("Failed to send event stream welcome", "error", err)
+		return
+	}
+
+	h.RegisterConnection(conn, client)
+	defer h.UnregisterConnection(conn)
+
+	h.mu.RLock()
+	sub := h.connections[conn]
+	h.mu.RUnlock()
+
+	if len(filters.Portfolios) > 0 || len(filters.Severities) > 0 || len(filters.Symbols) > 0 {
+		filters.Action = ActionSubscribe
+		h.handle(sub, filters)
+	}
+	if len(lastSeen) > 0 {
+		h.handle(sub, ClientMessage{Action: ActionReplay, LastSeenSeq: lastSeen})
+	}
+
+	ticker := time.NewTicker(sseHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-conn.done:
+			logger.Debug("Event stream closed")
+			return
+		case <-ticker.C:
+			if err := conn.heartbeat(); err != nil {
+				logger.Debug("Event stream client disconnected", "error", err)
+				return
+			}
+			sub.lastSeen.Store(time.Now().UnixNano())
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Taf0711/financial-risk-monitor/internal/database"
+)
+
+// Client message actions
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionPing        = "ping"
+	ActionReplay      = "replay"
+)
+
+// Replay topics: events of these topics carry a per-topic sequence number and can be replayed
+// after a reconnect
+const (
+	TopicAlerts      = "alerts"
+	TopicRiskUpdates = "risk_updates"
+)
+
+// replayTopics maps the replay topics to the Redis channels their events are buffered for
+var replayTopics = map[string]string{
+	TopicAlerts:      database.AlertsChannel,
+	TopicRiskUpdates: database.RiskUpdatesChannel,
+}
+
+// ClientMessage is a control message sent by a client over the socket
+type ClientMessage struct {
+	Action     string   `json:"action"`
+	Portfolios []string `json:"portfolios"`
+	Severities []string `json:"severities"`
+	Symbols    []string `json:"symbols"`
+	// LastSeenSeq is the sequence number of the last event the client received per replay topic
+	LastSeenSeq map[string]int64 `json:"last_seen_seq"`
+}
+
+// Topic describes what an outbound event is about so it can be routed to subscribers.
+// Empty fields are not used for filtering.
+type Topic struct {
+	UserID      string // Deliver only to this user's connections
+	PortfolioID string
+	Severity    string
+	Symbol      string
+}
+
+// Subscriptions holds the filters a client has asked for. An empty set means
+// "no filter" for that dimension.
+type Subscriptions struct {
+	mu         sync.RWMutex
+	portfolios map[string]bool
+	severities map[string]bool
+	symbols    map[string]bool
+}
+
+// NewSubscriptions creates an empty subscription set
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		portfolios: make(map[string]bool),
+		severities: make(map[string]bool),
+		symbols:    make(map[string]bool),
+	}
+}
+
+// Apply adds or removes the filters carried by a client message
+func (s *Subscriptions) Apply(msg ClientMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscribe := msg.Action == ActionSubscribe
+	update := func(set map[string]bool, values []string, upper bool) {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if upper {
+				v = strings.ToUpper(v)
+			}
+			if v == "" {
+				continue
+			}
+			if subscribe {
+				set[v] = true
+			} else {
+				delete(set, v)
+			}
+		}
+	}
+
+	update(s.portfolios, msg.Portfolios, false)
+	update(s.severities, msg.Severities, true)
+	update(s.symbols, msg.Symbols, true)
+}
+
+// Snapshot returns the current filters for reporting back to the client
+func (s *Subscriptions) Snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return map[string]interface{}{
+		"portfolios": setKeys(s.portfolios),
+		"severities": setKeys(s.severities),
+		"symbols":    setKeys(s.symbols),
+	}
+}
+
+// HasPortfolios reports whether the client narrowed events to specific portfolios
+func (s *Subscriptions) HasPortfolios() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.portfolios) > 0
+}
+
+// Matches reports whether an event with the given topic passes the client's filters
+func (s *Subscriptions) Matches(topic Topic) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if topic.PortfolioID != "" && len(s.portfolios) > 0 && !s.portfolios[topic.PortfolioID] {
+		return false
+	}
+	if topic.Severity != "" && len(s.severities) > 0 && !s.severities[strings.ToUpper(topic.Severity)] {
+		return false
+	}
+	if topic.Symbol != "" && len(s.symbols) > 0 && !s.symbols[strings.ToUpper(topic.Symbol)] {
+		return false
+	}
+	return true
+}
+
+// WantsSymbol reports whether price updates for a symbol should be delivered
+func (s *Subscriptions) WantsSymbol(symbol string) bool {
+	return s.Matches(Topic{Symbol: symbol})
+}
+
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	return keys
+}
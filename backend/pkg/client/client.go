@@ -0,0 +1,230 @@
+// Package client is a typed Go client for the Financial Risk Monitor REST API. The request,
+// response and operation code in the _gen.go files is generated by cmd/openapi from the same
+// routes and handlers as the OpenAPI document served at /api/v1/docs; this file holds the
+// transport they share.
+//
+//	api := client.New("http://localhost:8080")
+//	login, err := api.Login(ctx, client.LoginRequest{Email: email, Password: password})
+//	if err != nil {
+//		return err
+//	}
+//	api.SetToken(login.Token)
+//	portfolios, err := api.GetPortfolios(ctx)
+//
+// Failed calls return an *Error carrying the status, message and code of the API's error envelope.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Client calls the API on behalf of a user with a Bearer token, or an integration with an API key
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with a custom http.Client, such as one with a timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates requests with a Bearer access token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithAPIKey authenticates requests with an API key in the X-API-Key header
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// New returns a client for the API at baseURL, such as http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetToken replaces the Bearer access token, such as after logging in or refreshing the session
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Error is an error response from the API
+type Error struct {
+	StatusCode int             `json:"-"`
+	Message    string          `json:"error"`
+	Code       string          `json:"code"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api: %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// request is an API call under construction
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        interface{} // Encoded as JSON
+	rawBody     io.Reader
+	contentType string
+}
+
+// newRequest fills the {param} segments of a path template with args, in order
+func newRequest(method, template string, args ...interface{}) *request {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(args) > 0 {
+			segments[i] = url.PathEscape(fmt.Sprint(args[0]))
+			args = args[1:]
+		}
+	}
+	return &request{
+		method: method,
+		path:   strings.Join(segments, "/"),
+		query:  make(url.Values),
+		header: make(http.Header),
+	}
+}
+
+// setQuery sets a query parameter unless value is its type's zero value
+func (r *request) setQuery(name string, value interface{}) {
+	if !isZero(value) {
+		r.query.Set(name, fmt.Sprint(value))
+	}
+}
+
+// setHeader sets a header unless value is its type's zero value
+func (r *request) setHeader(name string, value interface{}) {
+	if !isZero(value) {
+		r.header.Set(name, fmt.Sprint(value))
+	}
+}
+
+// setRawBody sends body as is, such as a multipart form
+func (r *request) setRawBody(body io.Reader, contentType string) {
+	r.rawBody = body
+	r.contentType = contentType
+}
+
+func isZero(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
+
+// do sends a request and decodes a JSON response into out, unless out is nil
+func (c *Client) do(ctx context.Context, r *request, out interface{}) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// stream sends a request and returns the response body unread, for downloads and event streams.
+// The caller closes it.
+func (c *Client) stream(ctx context.Context, r *request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send sends a request and turns an error response into an *Error
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+	body, contentType := r.rawBody, r.contentType
+	if r.body != nil {
+		encoded, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = bytes.NewReader(encoded), "application/json"
+	}
+
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return nil, apiErr
+}
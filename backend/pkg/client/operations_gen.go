@@ -0,0 +1,5526 @@
+// Code generated by cmd/openapi. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Check reports the status of the API and its dependencies. The API is degraded while an optional
+// dependency is down and unhealthy, with a 503, while a required one is.
+//
+// GET /health
+func (c *Client) Check(ctx context.Context) (*CheckResponse, error) {
+	r := newRequest(http.MethodGet, "/health")
+	var out CheckResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMetrics serves the metrics in the Prometheus text exposition format
+//
+// GET /metrics
+func (c *Client) GetMetrics(ctx context.Context) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/metrics")
+	return c.stream(ctx, r)
+}
+
+// GetSpec serves the OpenAPI document describing the API, generated by cmd/openapi
+//
+// GET /api/v1/docs
+func (c *Client) GetSpec(ctx context.Context) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/docs")
+	return c.stream(ctx, r)
+}
+
+// Register handles user registration
+//
+// POST /api/v1/auth/register
+func (c *Client) Register(ctx context.Context, body RegisterRequest) (*RegisterResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/auth/register")
+	r.body = body
+	var out RegisterResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login handles user authentication
+//
+// POST /api/v1/auth/login
+func (c *Client) Login(ctx context.Context, body LoginRequest) (*LoginResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/auth/login")
+	r.body = body
+	var out LoginResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+//
+// POST /api/v1/auth/refresh
+func (c *Client) Refresh(ctx context.Context, body RefreshRequest) (*LoginResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/auth/refresh")
+	r.body = body
+	var out LoginResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChangePassword replaces the password of the user whose current password is supplied
+//
+// POST /api/v1/auth/change-password
+func (c *Client) ChangePassword(ctx context.Context, body ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/auth/change-password")
+	r.body = body
+	var out ChangePasswordResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Stream opens an event stream of the alerts, risk updates, order updates and prices the user may
+// see. ?portfolios=, ?severities= and ?symbols= take comma-separated subscription filters; the
+// Last-Event-ID header, or ?last_event_id= on the first connection, resumes after the events
+// already received.
+//
+// GET /api/v1/stream
+func (c *Client) Stream(ctx context.Context, params *StreamParams) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/stream")
+	if params != nil {
+		params.apply(r)
+	}
+	return c.stream(ctx, r)
+}
+
+// StreamParams are the optional parameters of Stream
+type StreamParams struct {
+	Portfolios  string
+	Severities  string
+	Symbols     string
+	LastEventID string
+	// Access token, for clients that cannot set the Authorization header
+	Token string
+}
+
+func (p *StreamParams) apply(r *request) {
+	r.setQuery("portfolios", p.Portfolios)
+	r.setQuery("severities", p.Severities)
+	r.setQuery("symbols", p.Symbols)
+	r.setQuery("last_event_id", p.LastEventID)
+	r.setQuery("token", p.Token)
+}
+
+// Logout revokes the current access token and the supplied refresh token
+//
+// POST /api/v1/auth/logout
+func (c *Client) Logout(ctx context.Context, body RefreshRequest) (*LogoutResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/auth/logout")
+	r.body = body
+	var out LogoutResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetUserStatus enables or disables a user account
+//
+// Requires the user:manage permission.
+//
+// PUT /api/v1/users/{id}/status
+func (c *Client) SetUserStatus(ctx context.Context, id uuid.UUID, body SetUserStatusRequest) (*SetUserStatusResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/users/{id}/status", id)
+	r.body = body
+	var out SetUserStatusResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeUserSessions signs a user out of every session
+//
+// Requires the user:manage permission.
+//
+// POST /api/v1/users/{id}/revoke-sessions
+func (c *Client) RevokeUserSessions(ctx context.Context, id uuid.UUID) (*RevokeUserSessionsResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/users/{id}/revoke-sessions", id)
+	var out RevokeUserSessionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAPIKeys lists API keys, optionally only those acting as one user (user_id query param)
+//
+// Requires the api_key:manage permission.
+//
+// GET /api/v1/api-keys
+func (c *Client) GetAPIKeys(ctx context.Context, params *GetAPIKeysParams) ([]APIKey, error) {
+	r := newRequest(http.MethodGet, "/api/v1/api-keys")
+	if params != nil {
+		params.apply(r)
+	}
+	var out []APIKey
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetAPIKeysParams are the optional parameters of GetAPIKeys
+type GetAPIKeysParams struct {
+	UserID string
+}
+
+func (p *GetAPIKeysParams) apply(r *request) {
+	r.setQuery("user_id", p.UserID)
+}
+
+// CreateAPIKey mints an API key. The key is only returned in this response.
+//
+// Requires the api_key:manage permission.
+//
+// POST /api/v1/api-keys
+func (c *Client) CreateAPIKey(ctx context.Context, body CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/api-keys")
+	r.body = body
+	var out CreateAPIKeyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RevokeAPIKey stops an API key from authenticating
+//
+// Requires the api_key:manage permission.
+//
+// DELETE /api/v1/api-keys/{id}
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*RevokeAPIKeyResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/api-keys/{id}", id)
+	var out RevokeAPIKeyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDeleted lists the soft deleted portfolios, transactions or alerts (:type) that can still be
+// restored
+//
+// Requires the records:restore permission.
+//
+// GET /api/v1/deleted/{type}
+func (c *Client) GetDeleted(ctx context.Context, typeParam string) (*GetDeletedResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/deleted/{type}", typeParam)
+	var out GetDeletedResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Query executes a GraphQL query. Invalid queries are rejected with 400; errors of individual
+// fields, such as fields the caller lacks the permission for, are reported alongside the data.
+//
+// POST /api/v1/graphql
+func (c *Client) Query(ctx context.Context, body GraphqlRequest) (*GraphqlResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/graphql")
+	r.body = body
+	var out GraphqlResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPortfolios returns the portfolios a user owns, supervises or shares through a team (all of
+// them for global roles)
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios
+func (c *Client) GetPortfolios(ctx context.Context) ([]Portfolio, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios")
+	var out []Portfolio
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetPortfolio returns a specific portfolio; access is checked by the PortfolioAccess middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}
+func (c *Client) GetPortfolio(ctx context.Context, id uuid.UUID) (*Portfolio, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}", id)
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePortfolio creates a new portfolio
+//
+// Requires the portfolio:write permission.
+//
+// POST /api/v1/portfolios
+func (c *Client) CreatePortfolio(ctx context.Context, body CreatePortfolioRequest, params *CreatePortfolioParams) (*Portfolio, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios")
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePortfolioParams are the optional parameters of CreatePortfolio
+type CreatePortfolioParams struct {
+	// Makes the request safe to retry: a repeated key returns the stored response
+	IdempotencyKey string
+}
+
+func (p *CreatePortfolioParams) apply(r *request) {
+	r.setHeader("Idempotency-Key", p.IdempotencyKey)
+}
+
+// UpdatePortfolio updates a portfolio. The update names the version it was made against in If-Match
+// or the version field, and is refused with the current portfolio if that has changed.
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}
+func (c *Client) UpdatePortfolio(ctx context.Context, id uuid.UUID, body UpdatePortfolioRequest, params *UpdatePortfolioParams) (*UpdatePortfolioResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out UpdatePortfolioResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePortfolioParams are the optional parameters of UpdatePortfolio
+type UpdatePortfolioParams struct {
+	// ETag of the record the update was made against; * applies it to any version
+	IfMatch string
+}
+
+func (p *UpdatePortfolioParams) apply(r *request) {
+	r.setHeader("If-Match", p.IfMatch)
+}
+
+// DeletePortfolio deletes a portfolio
+//
+// Requires the portfolio:write permission.
+//
+// DELETE /api/v1/portfolios/{id}
+func (c *Client) DeletePortfolio(ctx context.Context, id uuid.UUID) (*DeletePortfolioResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/portfolios/{id}", id)
+	var out DeletePortfolioResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RestorePortfolio undeletes a soft deleted portfolio and the records deleted with it
+//
+// Requires the records:restore permission.
+//
+// POST /api/v1/portfolios/{id}/restore
+func (c *Client) RestorePortfolio(ctx context.Context, id uuid.UUID) (*RestorePortfolioResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios/{id}/restore", id)
+	var out RestorePortfolioResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClonePortfolio copies a portfolio the user can access into a new portfolio they own, as a sandbox
+// for what-if analysis or the start of a model portfolio
+//
+// Requires the portfolio:write permission.
+//
+// POST /api/v1/portfolios/{id}/clone
+func (c *Client) ClonePortfolio(ctx context.Context, id uuid.UUID, body PortfolioFromTemplateRequest, params *ClonePortfolioParams) (*Portfolio, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios/{id}/clone", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClonePortfolioParams are the optional parameters of ClonePortfolio
+type ClonePortfolioParams struct {
+	// Makes the request safe to retry: a repeated key returns the stored response
+	IdempotencyKey string
+}
+
+func (p *ClonePortfolioParams) apply(r *request) {
+	r.setHeader("Idempotency-Key", p.IdempotencyKey)
+}
+
+// GetPositions returns all positions for a portfolio; access is checked by the PortfolioAccess
+// middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/positions
+func (c *Client) GetPositions(ctx context.Context, id uuid.UUID) ([]Position, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/positions", id)
+	var out []Position
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// PortfolioGetSummary returns a portfolio's value, latest risk metrics and active alert counts for
+// dashboards
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/summary
+func (c *Client) PortfolioGetSummary(ctx context.Context, id uuid.UUID) (*PortfolioSummary, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/summary", id)
+	var out PortfolioSummary
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPnL returns unrealized P&L by position and P&L over ?period=1d|1w|1m (default 1d); access is
+// checked by the PortfolioAccess middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/pnl
+func (c *Client) GetPnL(ctx context.Context, id uuid.UUID, params *GetPnLParams) (*PnLReport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/pnl", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out PnLReport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPnLParams are the optional parameters of GetPnL
+type GetPnLParams struct {
+	Period string
+}
+
+func (p *GetPnLParams) apply(r *request) {
+	r.setQuery("period", p.Period)
+}
+
+// GetNAVHistory returns a page of a portfolio's end-of-day NAV snapshots, newest first unless
+// ?sort=date; ?positions=true includes each day's positions and weights. Access is checked by the
+// PortfolioAccess middleware.
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/nav-history
+func (c *Client) GetNAVHistory(ctx context.Context, id uuid.UUID, params *GetNAVHistoryParams) (*GetNAVHistoryResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/nav-history", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetNAVHistoryResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNAVHistoryParams are the optional parameters of GetNAVHistory
+type GetNAVHistoryParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of date, nav; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To        string
+	Positions bool
+}
+
+func (p *GetNAVHistoryParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("positions", p.Positions)
+}
+
+// GetPerformance compares the portfolio's returns over ?window=1m|3m|6m|ytd|1y (default 1y) with
+// its benchmark, or with ?benchmark= instead; access is checked by the PortfolioAccess middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/performance
+func (c *Client) GetPerformance(ctx context.Context, id uuid.UUID, params *GetPerformanceParams) (*PerformanceReport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/performance", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out PerformanceReport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPerformanceParams are the optional parameters of GetPerformance
+type GetPerformanceParams struct {
+	Window    string
+	Benchmark string
+}
+
+func (p *GetPerformanceParams) apply(r *request) {
+	r.setQuery("window", p.Window)
+	r.setQuery("benchmark", p.Benchmark)
+}
+
+// GetCash returns a portfolio's cash balance and the part not committed to pending buys and
+// withdrawals; access is checked by the PortfolioAccess middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/cash
+func (c *Client) GetCash(ctx context.Context, id uuid.UUID) (*CashSummary, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/cash", id)
+	var out CashSummary
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCashLedger returns a page of a portfolio's cash movements; access is checked by the
+// PortfolioAccess middleware
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/cash/ledger
+func (c *Client) GetCashLedger(ctx context.Context, id uuid.UUID, params *GetCashLedgerParams) (*GetCashLedgerResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/cash/ledger", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetCashLedgerResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCashLedgerParams are the optional parameters of GetCashLedger
+type GetCashLedgerParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, amount; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To            string
+	EntryType     string
+	TransactionID string
+}
+
+func (p *GetCashLedgerParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("entry_type", p.EntryType)
+	r.setQuery("transaction_id", p.TransactionID)
+}
+
+// GetLots returns a page of a portfolio's tax lots, the open ones unless ?status=closed or all.
+// Access is checked by the PortfolioAccess middleware.
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/lots
+func (c *Client) GetLots(ctx context.Context, id uuid.UUID, params *GetLotsParams) (*GetLotsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/lots", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetLotsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLotsParams are the optional parameters of GetLots
+type GetLotsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of opened_at, cost_price, symbol; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To     string
+	Symbol string
+	Status string
+}
+
+func (p *GetLotsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("status", p.Status)
+}
+
+// GetRealizedGains returns a page of the lots a portfolio's sales closed, with the cost, proceeds,
+// holding term and gain of each, for tax reporting; from and to select by the sale's execution
+// time. Access is checked by the PortfolioAccess middleware.
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/realized-gains
+func (c *Client) GetRealizedGains(ctx context.Context, id uuid.UUID, params *GetRealizedGainsParams) (*GetRealizedGainsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/realized-gains", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetRealizedGainsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRealizedGainsParams are the optional parameters of GetRealizedGains
+type GetRealizedGainsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of closed_at, realized_pnl, symbol; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	Symbol      string
+	HoldingTerm string
+}
+
+func (p *GetRealizedGainsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("holding_term", p.HoldingTerm)
+}
+
+// GetActivity returns a page of a portfolio's timeline, latest first: transactions recorded, alerts
+// raised, risk threshold changes and transaction, order and alert status transitions. Access is
+// checked by the PortfolioAccess middleware.
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/activity
+func (c *Client) GetActivity(ctx context.Context, id uuid.UUID, params *GetActivityParams) (*GetActivityResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/activity", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetActivityResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetActivityParams are the optional parameters of GetActivity
+type GetActivityParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of occurred_at; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To           string
+	ActivityType string
+	EntityType   string
+	EntityID     string
+}
+
+func (p *GetActivityParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("activity_type", p.ActivityType)
+	r.setQuery("entity_type", p.EntityType)
+	r.setQuery("entity_id", p.EntityID)
+}
+
+// Simulate runs a basket of hypothetical trades against the portfolio and returns the weights, risk
+// and compliance findings it would be left with, without booking anything
+//
+// Requires the portfolio:read permission.
+//
+// POST /api/v1/portfolios/{id}/simulate
+func (c *Client) Simulate(ctx context.Context, id uuid.UUID, body SimulateRequest) (*SimulationResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios/{id}/simulate", id)
+	r.body = body
+	var out SimulationResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddPosition adds a position to a portfolio
+//
+// Requires the portfolio:write permission.
+//
+// POST /api/v1/portfolios/{id}/positions
+func (c *Client) AddPosition(ctx context.Context, id string) error {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios/{id}/positions", id)
+	return c.do(ctx, r, nil)
+}
+
+// UpdatePosition updates a position in a portfolio
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}/positions/{positionId}
+func (c *Client) UpdatePosition(ctx context.Context, id string, positionID string) error {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/positions/{positionId}", id, positionID)
+	return c.do(ctx, r, nil)
+}
+
+// DeletePosition deletes a position from a portfolio
+//
+// Requires the portfolio:write permission.
+//
+// DELETE /api/v1/portfolios/{id}/positions/{positionId}
+func (c *Client) DeletePosition(ctx context.Context, id string, positionID string) error {
+	r := newRequest(http.MethodDelete, "/api/v1/portfolios/{id}/positions/{positionId}", id, positionID)
+	return c.do(ctx, r, nil)
+}
+
+// SetLiquidityOverride fixes the liquidity class of a position
+//
+// Requires the liquidity:manage permission.
+//
+// PUT /api/v1/portfolios/{id}/positions/{positionId}/liquidity
+func (c *Client) SetLiquidityOverride(ctx context.Context, id uuid.UUID, positionID uuid.UUID, body SetLiquidityOverrideRequest) (*SetLiquidityOverrideResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/positions/{positionId}/liquidity", id, positionID)
+	r.body = body
+	var out SetLiquidityOverrideResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClearLiquidityOverride returns a position to its calculated liquidity class
+//
+// Requires the liquidity:manage permission.
+//
+// DELETE /api/v1/portfolios/{id}/positions/{positionId}/liquidity
+func (c *Client) ClearLiquidityOverride(ctx context.Context, id uuid.UUID, positionID uuid.UUID) (*ClearLiquidityOverrideResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/portfolios/{id}/positions/{positionId}/liquidity", id, positionID)
+	var out ClearLiquidityOverrideResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCustody lists the exchanges, custodians and wallets a portfolio keeps its crypto at
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/crypto-custody
+func (c *Client) GetCustody(ctx context.Context, id uuid.UUID) ([]CryptoCustody, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/crypto-custody", id)
+	var out []CryptoCustody
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// SetCustody replaces the exchanges, custodians and wallets a portfolio keeps its crypto at
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}/crypto-custody
+func (c *Client) SetCustody(ctx context.Context, id uuid.UUID, body SetCustodyRequest) (*SetCustodyResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/crypto-custody", id)
+	r.body = body
+	var out SetCustodyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTargets lists the weights a portfolio aims to hold by symbol and asset class
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/target-allocation
+func (c *Client) GetTargets(ctx context.Context, id uuid.UUID) ([]TargetAllocation, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/target-allocation", id)
+	var out []TargetAllocation
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// SetTargets replaces a portfolio's target allocation
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}/target-allocation
+func (c *Client) SetTargets(ctx context.Context, id uuid.UUID, body SetTargetsRequest) (*SetTargetsResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/target-allocation", id)
+	r.body = body
+	var out SetTargetsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDrift compares a portfolio's current weights with its target allocation and suggests the
+// trades that would rebalance the targets drifted beyond tolerance
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/allocation-drift
+func (c *Client) GetDrift(ctx context.Context, id uuid.UUID) (*AllocationDriftResult, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/allocation-drift", id)
+	var out AllocationDriftResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetThresholds returns a portfolio's effective risk thresholds, with the limits set on the
+// portfolio itself in overrides and their version as the ETag
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/risk-thresholds
+func (c *Client) GetThresholds(ctx context.Context, id uuid.UUID) (*RiskThresholds, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/risk-thresholds", id)
+	var out RiskThresholds
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateThresholds changes some of the limits set on a portfolio, or with inherit takes them from
+// its parent again; portfolios under it inheriting a limit follow the change. The change names the
+// version it was made against in If-Match or the version field, and is refused with the current
+// thresholds if they have changed since.
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}/risk-thresholds
+func (c *Client) UpdateThresholds(ctx context.Context, id uuid.UUID, body ThresholdsRequest, params *UpdateThresholdsParams) (*RiskThresholds, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/risk-thresholds", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out RiskThresholds
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateThresholdsParams are the optional parameters of UpdateThresholds
+type UpdateThresholdsParams struct {
+	// ETag of the record the update was made against; * applies it to any version
+	IfMatch string
+}
+
+func (p *UpdateThresholdsParams) apply(r *request) {
+	r.setHeader("If-Match", p.IfMatch)
+}
+
+// GetSupervisors returns the users assigned to supervise a portfolio
+//
+// Requires the portfolio:assign permission.
+//
+// GET /api/v1/portfolios/{id}/supervisors
+func (c *Client) GetSupervisors(ctx context.Context, id uuid.UUID) ([]PortfolioSupervisor, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/supervisors", id)
+	var out []PortfolioSupervisor
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// AddSupervisor assigns a user to supervise a portfolio
+//
+// Requires the portfolio:assign permission.
+//
+// POST /api/v1/portfolios/{id}/supervisors
+func (c *Client) AddSupervisor(ctx context.Context, id uuid.UUID, body AddSupervisorRequest) (*PortfolioSupervisor, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolios/{id}/supervisors", id)
+	r.body = body
+	var out PortfolioSupervisor
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveSupervisor removes a user's supervision of a portfolio
+//
+// Requires the portfolio:assign permission.
+//
+// DELETE /api/v1/portfolios/{id}/supervisors/{userId}
+func (c *Client) RemoveSupervisor(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*RemoveSupervisorResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/portfolios/{id}/supervisors/{userId}", id, userID)
+	var out RemoveSupervisorResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetTeam shares a portfolio with a team's members, or stops sharing it when team_id is null
+//
+// Requires the portfolio:assign permission.
+//
+// PUT /api/v1/portfolios/{id}/team
+func (c *Client) SetTeam(ctx context.Context, id uuid.UUID, body SetTeamRequest) (*Portfolio, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/team", id)
+	r.body = body
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetHierarchy returns the portfolios above a portfolio, just their names for those the user cannot
+// access, and the tree of portfolios under it, each with its value rolled up from those under it
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolios/{id}/hierarchy
+func (c *Client) GetHierarchy(ctx context.Context, id uuid.UUID) (*Hierarchy, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolios/{id}/hierarchy", id)
+	var out Hierarchy
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetParent places a portfolio under a parent portfolio, such as a strategy under a book, or at the
+// top of the hierarchy when parent_id is null. The user must be able to modify both; limits the
+// portfolio does not set itself are inherited from the new parent.
+//
+// Requires the portfolio:write permission.
+//
+// PUT /api/v1/portfolios/{id}/parent
+func (c *Client) SetParent(ctx context.Context, id uuid.UUID, body ParentRequest) (*Portfolio, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolios/{id}/parent", id)
+	r.body = body
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTransactions returns a page of the transactions in portfolios the user can access
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions
+func (c *Client) GetTransactions(ctx context.Context, params *GetTransactionsParams) (*GetTransactionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetTransactionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTransactionsParams are the optional parameters of GetTransactions
+type GetTransactionsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, executed_at, amount, symbol, status; prefix it with - to
+	// sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To              string
+	PortfolioID     string
+	Status          string
+	Symbol          string
+	TransactionType string
+}
+
+func (p *GetTransactionsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("transaction_type", p.TransactionType)
+}
+
+// SearchTransactions returns a page of the transactions in portfolios the user can access matching
+// the filters, amount (min_amount, max_amount) and risk score (min_risk_score, max_risk_score)
+// ranges and the aml_checked, kyc_verified and requires_review flags
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/search
+func (c *Client) SearchTransactions(ctx context.Context, params *SearchTransactionsParams) (*SearchTransactionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/search")
+	if params != nil {
+		params.apply(r)
+	}
+	var out SearchTransactionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchTransactionsParams are the optional parameters of SearchTransactions
+type SearchTransactionsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of trade_date, created_at, executed_at, amount, risk_score, symbol,
+	// status; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To              string
+	PortfolioID     string
+	Status          string
+	Symbol          string
+	TransactionType string
+	OrderStatus     string
+	ApprovalStatus  string
+	AssetType       string
+	MinAmount       string
+	MaxAmount       string
+	MinRiskScore    string
+	MaxRiskScore    string
+	AMLChecked      string
+	KYCVerified     string
+	RequiresReview  string
+}
+
+func (p *SearchTransactionsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("transaction_type", p.TransactionType)
+	r.setQuery("order_status", p.OrderStatus)
+	r.setQuery("approval_status", p.ApprovalStatus)
+	r.setQuery("asset_type", p.AssetType)
+	r.setQuery("min_amount", p.MinAmount)
+	r.setQuery("max_amount", p.MaxAmount)
+	r.setQuery("min_risk_score", p.MinRiskScore)
+	r.setQuery("max_risk_score", p.MaxRiskScore)
+	r.setQuery("aml_checked", p.AMLChecked)
+	r.setQuery("kyc_verified", p.KYCVerified)
+	r.setQuery("requires_review", p.RequiresReview)
+}
+
+// ExportTransactions streams the transactions in portfolios the user can access as a CSV or XLSX
+// file (?format=), with the filters and ranges of SearchTransactions
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/export
+func (c *Client) ExportTransactions(ctx context.Context, params *ExportTransactionsParams) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/export")
+	if params != nil {
+		params.apply(r)
+	}
+	return c.stream(ctx, r)
+}
+
+// ExportTransactionsParams are the optional parameters of ExportTransactions
+type ExportTransactionsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of trade_date, created_at, executed_at, amount, risk_score, symbol,
+	// status; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To              string
+	PortfolioID     string
+	Status          string
+	Symbol          string
+	TransactionType string
+	OrderStatus     string
+	ApprovalStatus  string
+	AssetType       string
+	MinAmount       string
+	MaxAmount       string
+	MinRiskScore    string
+	MaxRiskScore    string
+	AMLChecked      string
+	KYCVerified     string
+	RequiresReview  string
+	Format          string
+}
+
+func (p *ExportTransactionsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("transaction_type", p.TransactionType)
+	r.setQuery("order_status", p.OrderStatus)
+	r.setQuery("approval_status", p.ApprovalStatus)
+	r.setQuery("asset_type", p.AssetType)
+	r.setQuery("min_amount", p.MinAmount)
+	r.setQuery("max_amount", p.MaxAmount)
+	r.setQuery("min_risk_score", p.MinRiskScore)
+	r.setQuery("max_risk_score", p.MaxRiskScore)
+	r.setQuery("aml_checked", p.AMLChecked)
+	r.setQuery("kyc_verified", p.KYCVerified)
+	r.setQuery("requires_review", p.RequiresReview)
+	r.setQuery("format", p.Format)
+}
+
+// StartTransactionExport starts an export job for the transactions ExportTransactions would stream,
+// for exports too large to stream
+//
+// Requires the transaction:read permission.
+//
+// POST /api/v1/transactions/export
+func (c *Client) StartTransactionExport(ctx context.Context, params *StartTransactionExportParams) (*ExportJob, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/export")
+	if params != nil {
+		params.apply(r)
+	}
+	var out ExportJob
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartTransactionExportParams are the optional parameters of StartTransactionExport
+type StartTransactionExportParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of trade_date, created_at, executed_at, amount, risk_score, symbol,
+	// status; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To              string
+	PortfolioID     string
+	Status          string
+	Symbol          string
+	TransactionType string
+	OrderStatus     string
+	ApprovalStatus  string
+	AssetType       string
+	MinAmount       string
+	MaxAmount       string
+	MinRiskScore    string
+	MaxRiskScore    string
+	AMLChecked      string
+	KYCVerified     string
+	RequiresReview  string
+	Format          string
+}
+
+func (p *StartTransactionExportParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("transaction_type", p.TransactionType)
+	r.setQuery("order_status", p.OrderStatus)
+	r.setQuery("approval_status", p.ApprovalStatus)
+	r.setQuery("asset_type", p.AssetType)
+	r.setQuery("min_amount", p.MinAmount)
+	r.setQuery("max_amount", p.MaxAmount)
+	r.setQuery("min_risk_score", p.MinRiskScore)
+	r.setQuery("max_risk_score", p.MaxRiskScore)
+	r.setQuery("aml_checked", p.AMLChecked)
+	r.setQuery("kyc_verified", p.KYCVerified)
+	r.setQuery("requires_review", p.RequiresReview)
+	r.setQuery("format", p.Format)
+}
+
+// ImportTransactions loads trades from an uploaded CSV or FIX 4.4 drop-copy file. The format comes
+// from the "format" field or the file extension, and "portfolio_id" applies to rows without one.
+// Repeating a request with the same Idempotency-Key header returns the first import's result.
+//
+// Requires the transaction:write permission.
+//
+// POST /api/v1/transactions/import
+func (c *Client) ImportTransactions(ctx context.Context, body io.Reader, contentType string) (*TransactionImport, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/import")
+	r.setRawBody(body, contentType)
+	var out TransactionImport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetImport returns the result of one of the user's imports
+//
+// Requires the transaction:write permission.
+//
+// GET /api/v1/transactions/imports/{id}
+func (c *Client) GetImport(ctx context.Context, id uuid.UUID) (*TransactionImport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/imports/{id}", id)
+	var out TransactionImport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAmendments returns a page of the amendments of transactions in portfolios the user can access;
+// approvers list those awaiting review with ?status=PENDING
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/amendments
+func (c *Client) GetAmendments(ctx context.Context, params *GetAmendmentsParams) (*GetAmendmentsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/amendments")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetAmendmentsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAmendmentsParams are the optional parameters of GetAmendments
+type GetAmendmentsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, reviewed_at; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To            string
+	PortfolioID   string
+	TransactionID string
+	Action        string
+	Status        string
+}
+
+func (p *GetAmendmentsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("transaction_id", p.TransactionID)
+	r.setQuery("action", p.Action)
+	r.setQuery("status", p.Status)
+}
+
+// GetAmendment returns an amendment with its old and new values
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/amendments/{id}
+func (c *Client) GetAmendment(ctx context.Context, id uuid.UUID) (*TransactionAmendment, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/amendments/{id}", id)
+	var out TransactionAmendment
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DecideAmendment approves or rejects a pending amendment. The reviewer must be someone other than
+// the user who requested it; approval supersedes the transaction at once.
+//
+// Requires the transaction:approve permission.
+//
+// POST /api/v1/transactions/amendments/{id}/approve
+func (c *Client) DecideAmendment(ctx context.Context, id uuid.UUID, body AmendmentDecision) (*TransactionAmendment, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/amendments/{id}/approve", id)
+	r.body = body
+	var out TransactionAmendment
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTransaction returns a specific transaction
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/{id}
+func (c *Client) GetTransaction(ctx context.Context, id uuid.UUID) (*Transaction, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/{id}", id)
+	var out Transaction
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTransaction creates a new transaction in a portfolio the user owns
+//
+// Requires the transaction:write permission.
+//
+// POST /api/v1/transactions
+func (c *Client) CreateTransaction(ctx context.Context, body CreateTransactionRequest, params *CreateTransactionParams) (*CreateTransactionResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions")
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out CreateTransactionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTransactionParams are the optional parameters of CreateTransaction
+type CreateTransactionParams struct {
+	// Makes the request safe to retry: a repeated key returns the stored response
+	IdempotencyKey string
+}
+
+func (p *CreateTransactionParams) apply(r *request) {
+	r.setHeader("Idempotency-Key", p.IdempotencyKey)
+}
+
+// UpdateTransaction updates a transaction
+//
+// Requires the transaction:write permission.
+//
+// PUT /api/v1/transactions/{id}
+func (c *Client) UpdateTransaction(ctx context.Context, id uuid.UUID, body CreateTransactionRequest) (*UpdateTransactionResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/transactions/{id}", id)
+	r.body = body
+	var out UpdateTransactionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTransactionStatus updates the status of a transaction
+//
+// Requires the transaction:approve permission.
+//
+// PUT /api/v1/transactions/{id}/status
+func (c *Client) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, body UpdateTransactionStatusRequest) (*UpdateTransactionStatusResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/transactions/{id}/status", id)
+	r.body = body
+	var out UpdateTransactionStatusResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ApproveTransaction records the four-eyes decision on a transaction held for approval. The
+// reviewer must be someone other than the user who created the transaction.
+//
+// Requires the transaction:approve permission.
+//
+// POST /api/v1/transactions/{id}/approve
+func (c *Client) ApproveTransaction(ctx context.Context, id uuid.UUID, body ApproveTransactionRequest) (*ApproveTransactionResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/{id}/approve", id)
+	r.body = body
+	var out ApproveTransactionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOrderStatus cancels or rejects an order
+//
+// Requires the transaction:approve permission.
+//
+// PUT /api/v1/transactions/{id}/order-status
+func (c *Client) UpdateOrderStatus(ctx context.Context, id uuid.UUID, body UpdateOrderStatusRequest) (*UpdateOrderStatusResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/transactions/{id}/order-status", id)
+	r.body = body
+	var out UpdateOrderStatusResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFills returns the executions recorded against an order
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/{id}/fills
+func (c *Client) GetFills(ctx context.Context, id uuid.UUID) ([]Fill, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/{id}/fills", id)
+	var out []Fill
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetTransactionLots returns the tax lots a BUY opened, with how much of each is still held, and
+// the lots a SELL closed with the gain realized on each
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/{id}/lots
+func (c *Client) GetTransactionLots(ctx context.Context, id uuid.UUID) (*GetTransactionLotsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/{id}/lots", id)
+	var out GetTransactionLotsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RecordFill records an execution against an order, moving it to PARTIALLY_FILLED or FILLED
+//
+// Requires the transaction:approve permission.
+//
+// POST /api/v1/transactions/{id}/fills
+func (c *Client) RecordFill(ctx context.Context, id uuid.UUID, body FillRequest, params *RecordFillParams) (*RecordFillResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/{id}/fills", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out RecordFillResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RecordFillParams are the optional parameters of RecordFill
+type RecordFillParams struct {
+	// Makes the request safe to retry: a repeated key returns the stored response
+	IdempotencyKey string
+}
+
+func (p *RecordFillParams) apply(r *request) {
+	r.setHeader("Idempotency-Key", p.IdempotencyKey)
+}
+
+// GetTransactionAmendments returns the amendment trail of a transaction: the amendments requested
+// of it and, for a replacement, the one that booked it
+//
+// Requires the transaction:read permission.
+//
+// GET /api/v1/transactions/{id}/amendments
+func (c *Client) GetTransactionAmendments(ctx context.Context, id uuid.UUID) ([]TransactionAmendment, error) {
+	r := newRequest(http.MethodGet, "/api/v1/transactions/{id}/amendments", id)
+	var out []TransactionAmendment
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// RequestAmendment asks for a completed transaction to be corrected or cancelled. The request waits
+// for another user's approval; the transaction is unchanged until then.
+//
+// Requires the transaction:write permission.
+//
+// POST /api/v1/transactions/{id}/amendments
+func (c *Client) RequestAmendment(ctx context.Context, id uuid.UUID, body AmendmentRequest) (*TransactionAmendment, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/{id}/amendments", id)
+	r.body = body
+	var out TransactionAmendment
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTransaction deletes a transaction
+//
+// Requires the transaction:delete permission.
+//
+// DELETE /api/v1/transactions/{id}
+func (c *Client) DeleteTransaction(ctx context.Context, id uuid.UUID) (*DeleteTransactionResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/transactions/{id}", id)
+	var out DeleteTransactionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RestoreTransaction undeletes a soft deleted transaction, settling its cash again
+//
+// Requires the records:restore permission.
+//
+// POST /api/v1/transactions/{id}/restore
+func (c *Client) RestoreTransaction(ctx context.Context, id uuid.UUID) (*RestoreTransactionResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/transactions/{id}/restore", id)
+	var out RestoreTransactionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RiskGetSummary reports the risk of every portfolio the caller owns, alone or through a team, in
+// one call. Metrics stored within the configured maximum age are reused and the rest recalculated
+// and stored, with the portfolios spread over a bounded pool of workers.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/summary
+func (c *Client) RiskGetSummary(ctx context.Context) (*RiskGetSummaryResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/summary")
+	var out RiskGetSummaryResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRiskMetrics returns a page of risk metrics for a portfolio
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/metrics
+func (c *Client) GetRiskMetrics(ctx context.Context, id string, params *GetRiskMetricsParams) (map[string]interface{}, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/metrics", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out map[string]interface{}
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetRiskMetricsParams are the optional parameters of GetRiskMetrics
+type GetRiskMetricsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of calculated_at, metric_type, value; prefix it with - to sort in
+	// descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	MetricType string
+	Status     string
+}
+
+func (p *GetRiskMetricsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("metric_type", p.MetricType)
+	r.setQuery("status", p.Status)
+}
+
+// GetLatestRiskMetrics returns the most recent metric of each type calculated for a portfolio
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/metrics/latest
+func (c *Client) GetLatestRiskMetrics(ctx context.Context, id uuid.UUID) (*GetLatestRiskMetricsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/metrics/latest", id)
+	var out GetLatestRiskMetricsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMetricMarketDepth returns the order book snapshots a stored VaR or liquidity metric of the
+// portfolio was calculated with, listing the symbols whose snapshot has since been purged
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/metrics/{metricId}/market-depth
+func (c *Client) GetMetricMarketDepth(ctx context.Context, id uuid.UUID, metricID uuid.UUID) (*MetricMarketDepth, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/metrics/{metricId}/market-depth", id, metricID)
+	var out MetricMarketDepth
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CalculateVAR calculates Value at Risk for a portfolio with ?method=simplified (default),
+// historical, parametric or montecarlo, at ?confidence= over ?horizon= days (defaulting to the
+// configured ones). The return based methods take ?lookback= days of snapshots (default 250) and
+// montecarlo ?simulations= paths and a ?seed= for reproducible runs (defaulting to the configured
+// ones). The result is cached per portfolio and parameters until a trade, threshold change or large
+// price move; ?force_refresh=true recalculates it.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/var
+func (c *Client) CalculateVAR(ctx context.Context, id string, params *CalculateVARParams) (*VaRResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/var", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out VaRResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CalculateVARParams are the optional parameters of CalculateVAR
+type CalculateVARParams struct {
+	Method       string
+	Confidence   string
+	Horizon      int
+	Lookback     int
+	Simulations  int
+	Seed         string
+	ForceRefresh bool
+}
+
+func (p *CalculateVARParams) apply(r *request) {
+	r.setQuery("method", p.Method)
+	r.setQuery("confidence", p.Confidence)
+	r.setQuery("horizon", p.Horizon)
+	r.setQuery("lookback", p.Lookback)
+	r.setQuery("simulations", p.Simulations)
+	r.setQuery("seed", p.Seed)
+	r.setQuery("force_refresh", p.ForceRefresh)
+}
+
+// StartVaRJob queues a VaR calculation with the parameters CalculateVAR accepts, for Monte Carlo
+// runs too slow to wait for. The stored risk metric is the job's result.
+//
+// Requires the risk:read permission.
+//
+// POST /api/v1/risk/portfolio/{id}/var
+func (c *Client) StartVaRJob(ctx context.Context, id uuid.UUID, params *StartVaRJobParams) (*Job, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/portfolio/{id}/var", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out Job
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartVaRJobParams are the optional parameters of StartVaRJob
+type StartVaRJobParams struct {
+	Method      string
+	Confidence  string
+	Horizon     int
+	Lookback    int
+	Simulations int
+	Seed        string
+}
+
+func (p *StartVaRJobParams) apply(r *request) {
+	r.setQuery("method", p.Method)
+	r.setQuery("confidence", p.Confidence)
+	r.setQuery("horizon", p.Horizon)
+	r.setQuery("lookback", p.Lookback)
+	r.setQuery("simulations", p.Simulations)
+	r.setQuery("seed", p.Seed)
+}
+
+// GetVaRContributions ranks the positions of a portfolio by their contribution to its VaR at
+// ?confidence= (default the configured VaR confidence level), with their marginal and incremental
+// VaR
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/var/contributions
+func (c *Client) GetVaRContributions(ctx context.Context, id uuid.UUID, params *GetVaRContributionsParams) (*GetVaRContributionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/var/contributions", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetVaRContributionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetVaRContributionsParams are the optional parameters of GetVaRContributions
+type GetVaRContributionsParams struct {
+	Confidence string
+}
+
+func (p *GetVaRContributionsParams) apply(r *request) {
+	r.setQuery("confidence", p.Confidence)
+}
+
+// CalculateLiquidityRisk calculates liquidity risk for a portfolio. The result is cached like
+// CalculateVAR's; ?force_refresh=true recalculates it.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/liquidity
+func (c *Client) CalculateLiquidityRisk(ctx context.Context, id string, params *CalculateLiquidityRiskParams) (*LiquidityResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/liquidity", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out LiquidityResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CalculateLiquidityRiskParams are the optional parameters of CalculateLiquidityRisk
+type CalculateLiquidityRiskParams struct {
+	ForceRefresh bool
+}
+
+func (p *CalculateLiquidityRiskParams) apply(r *request) {
+	r.setQuery("force_refresh", p.ForceRefresh)
+}
+
+// GetPortfolioLiquidity returns the positions of a portfolio with their liquidity classification
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/liquidity/positions
+func (c *Client) GetPortfolioLiquidity(ctx context.Context, id uuid.UUID) ([]PositionLiquidityView, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/liquidity/positions", id)
+	var out []PositionLiquidityView
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetLiquidationSchedule simulates selling a portfolio day by day under normal and stressed
+// participation: what is sold each day, its market impact and the value left to sell. Each day
+// sells at most ?participation= (default 10%) or ?stressed_participation= (5%) of a symbol's
+// average daily volume, for up to ?max_days= (60) days.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/liquidation-schedule
+func (c *Client) GetLiquidationSchedule(ctx context.Context, id uuid.UUID, params *GetLiquidationScheduleParams) (*LiquidationSchedule, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/liquidation-schedule", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out LiquidationSchedule
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLiquidationScheduleParams are the optional parameters of GetLiquidationSchedule
+type GetLiquidationScheduleParams struct {
+	Participation         float64
+	StressedParticipation float64
+	MaxDays               int
+}
+
+func (p *GetLiquidationScheduleParams) apply(r *request) {
+	r.setQuery("participation", p.Participation)
+	r.setQuery("stressed_participation", p.StressedParticipation)
+	r.setQuery("max_days", p.MaxDays)
+}
+
+// ClassifyPortfolio reclassifies the positions of a portfolio
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// POST /api/v1/risk/portfolio/{id}/liquidity/classify
+func (c *Client) ClassifyPortfolio(ctx context.Context, id uuid.UUID) (*ClassificationRun, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/portfolio/{id}/liquidity/classify", id)
+	var out ClassificationRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMarketData lists the market data positions are classified from
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/market-data
+func (c *Client) GetMarketData(ctx context.Context) ([]SymbolMarketData, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/market-data")
+	var out []SymbolMarketData
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// UpdateMarketData sets the average daily volume and bid-ask spread of a symbol
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// PUT /api/v1/risk/market-data/{symbol}
+func (c *Client) UpdateMarketData(ctx context.Context, symbol string, body MarketDataRequest) (*UpdateMarketDataResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/risk/market-data/{symbol}", symbol)
+	r.body = body
+	var out UpdateMarketDataResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMarketDepth returns a page of the order book snapshots of a symbol, newest first
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/market-depth/{symbol}
+func (c *Client) GetMarketDepth(ctx context.Context, symbol string, params *GetMarketDepthParams) (*GetMarketDepthResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/market-depth/{symbol}", symbol)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetMarketDepthResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMarketDepthParams are the optional parameters of GetMarketDepth
+type GetMarketDepthParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of captured_at, spread; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To     string
+	Source string
+}
+
+func (p *GetMarketDepthParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("source", p.Source)
+}
+
+// GetLatestMarketDepth returns the most recent order book snapshot of a symbol
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/market-depth/{symbol}/latest
+func (c *Client) GetLatestMarketDepth(ctx context.Context, symbol string) (*MarketDepthSnapshot, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/market-depth/{symbol}/latest", symbol)
+	var out MarketDepthSnapshot
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPrices returns the daily closes of a benchmark between ?from= and ?to= (YYYY-MM-DD), the last
+// year by default
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/benchmarks/{symbol}/prices
+func (c *Client) GetPrices(ctx context.Context, symbol string, params *GetPricesParams) ([]BenchmarkPrice, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/benchmarks/{symbol}/prices", symbol)
+	if params != nil {
+		params.apply(r)
+	}
+	var out []BenchmarkPrice
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetPricesParams are the optional parameters of GetPrices
+type GetPricesParams struct {
+	From string
+	To   string
+}
+
+func (p *GetPricesParams) apply(r *request) {
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+}
+
+// RecordPrices loads daily closes of a benchmark, such as its history before the price feed started
+// quoting it
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// POST /api/v1/risk/benchmarks/{symbol}/prices
+func (c *Client) RecordPrices(ctx context.Context, symbol string, body RecordPricesRequest) (*RecordPricesResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/benchmarks/{symbol}/prices", symbol)
+	r.body = body
+	var out RecordPricesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBonds lists the bond reference data interest rate risk is measured from
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/bonds
+func (c *Client) GetBonds(ctx context.Context) ([]BondReference, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/bonds")
+	var out []BondReference
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// UpsertBond sets the coupon, maturity, face value and credit rating of a bond
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// PUT /api/v1/risk/bonds/{symbol}
+func (c *Client) UpsertBond(ctx context.Context, symbol string, body BondReferenceRequest) (*UpsertBondResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/risk/bonds/{symbol}", symbol)
+	r.body = body
+	var out UpsertBondResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteBond removes the reference data of a bond
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// DELETE /api/v1/risk/bonds/{symbol}
+func (c *Client) DeleteBond(ctx context.Context, symbol string) (*DeleteBondResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/risk/bonds/{symbol}", symbol)
+	var out DeleteBondResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAssets lists the crypto reference data that overrides the built-in chains and pegs
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/crypto-assets
+func (c *Client) GetAssets(ctx context.Context) ([]CryptoAsset, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/crypto-assets")
+	var out []CryptoAsset
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// UpsertAsset sets the chain of a crypto asset and, for a stablecoin, its peg
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// PUT /api/v1/risk/crypto-assets/{symbol}
+func (c *Client) UpsertAsset(ctx context.Context, symbol string, body CryptoAssetRequest) (*UpsertAssetResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/risk/crypto-assets/{symbol}", symbol)
+	r.body = body
+	var out UpsertAssetResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAsset removes the reference data of a crypto asset
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// DELETE /api/v1/risk/crypto-assets/{symbol}
+func (c *Client) DeleteAsset(ctx context.Context, symbol string) (*DeleteAssetResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/risk/crypto-assets/{symbol}", symbol)
+	var out DeleteAssetResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClassifyAll reclassifies every position now instead of waiting for the nightly run
+//
+// Requires the risk:read and liquidity:manage permissions.
+//
+// POST /api/v1/risk/liquidity/classify
+func (c *Client) ClassifyAll(ctx context.Context) (*ClassificationRun, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/liquidity/classify")
+	var out ClassificationRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CalculateConcentration reports position, sector and asset class concentration for a portfolio
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/concentration
+func (c *Client) CalculateConcentration(ctx context.Context, id string, params *CalculateConcentrationParams) (*CalculateConcentrationResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/concentration", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out CalculateConcentrationResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CalculateConcentrationParams are the optional parameters of CalculateConcentration
+type CalculateConcentrationParams struct {
+	Top int
+}
+
+func (p *CalculateConcentrationParams) apply(r *request) {
+	r.setQuery("top", p.Top)
+}
+
+// GetRiskHistory returns a page of historical risk data for a portfolio. With interval (1h, 1d or
+// 1w) it returns the history aggregated per metric into buckets of that interval instead, each the
+// max, avg or last value of the bucket as chosen by agg (default avg).
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/history
+func (c *Client) GetRiskHistory(ctx context.Context, id string, params *GetRiskHistoryParams) (map[string]interface{}, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/history", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out map[string]interface{}
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetRiskHistoryParams are the optional parameters of GetRiskHistory
+type GetRiskHistoryParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of recorded_at, value; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	MetricType string
+	Resolution string
+	Interval   string
+	Agg        string
+}
+
+func (p *GetRiskHistoryParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("metric_type", p.MetricType)
+	r.setQuery("resolution", p.Resolution)
+	r.setQuery("interval", p.Interval)
+	r.setQuery("agg", p.Agg)
+}
+
+// ExportRiskHistory streams a portfolio's risk history snapshots as a CSV or XLSX file (?format=),
+// with the filters of GetRiskHistory; access is checked by the PortfolioAccess middleware
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/history/export
+func (c *Client) ExportRiskHistory(ctx context.Context, id uuid.UUID, params *ExportRiskHistoryParams) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/history/export", id)
+	if params != nil {
+		params.apply(r)
+	}
+	return c.stream(ctx, r)
+}
+
+// ExportRiskHistoryParams are the optional parameters of ExportRiskHistory
+type ExportRiskHistoryParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of recorded_at, value; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	MetricType string
+	Resolution string
+	Format     string
+}
+
+func (p *ExportRiskHistoryParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("metric_type", p.MetricType)
+	r.setQuery("resolution", p.Resolution)
+	r.setQuery("format", p.Format)
+}
+
+// StartRiskHistoryExport starts an export job for the snapshots ExportRiskHistory would stream, for
+// exports too large to stream; access is checked by the PortfolioAccess middleware
+//
+// Requires the risk:read permission.
+//
+// POST /api/v1/risk/portfolio/{id}/history/export
+func (c *Client) StartRiskHistoryExport(ctx context.Context, id uuid.UUID, params *StartRiskHistoryExportParams) (*ExportJob, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/portfolio/{id}/history/export", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out ExportJob
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartRiskHistoryExportParams are the optional parameters of StartRiskHistoryExport
+type StartRiskHistoryExportParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of recorded_at, value; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	MetricType string
+	Resolution string
+	Format     string
+}
+
+func (p *StartRiskHistoryExportParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("metric_type", p.MetricType)
+	r.setQuery("resolution", p.Resolution)
+	r.setQuery("format", p.Format)
+}
+
+// GetBacktest validates stored VaR forecasts against realized P&L over ?days= (default 250) at
+// ?confidence= (default the configured VaR confidence level)
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/backtest
+func (c *Client) GetBacktest(ctx context.Context, id uuid.UUID, params *GetBacktestParams) (*BacktestReport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/backtest", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out BacktestReport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBacktestParams are the optional parameters of GetBacktest
+type GetBacktestParams struct {
+	Days       int
+	Confidence string
+}
+
+func (p *GetBacktestParams) apply(r *request) {
+	r.setQuery("days", p.Days)
+	r.setQuery("confidence", p.Confidence)
+}
+
+// StartBacktestJob queues the VaR backtest GetBacktest would run with the same parameters
+//
+// Requires the risk:read permission.
+//
+// POST /api/v1/risk/portfolio/{id}/backtest
+func (c *Client) StartBacktestJob(ctx context.Context, id uuid.UUID, params *StartBacktestJobParams) (*Job, error) {
+	r := newRequest(http.MethodPost, "/api/v1/risk/portfolio/{id}/backtest", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out Job
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartBacktestJobParams are the optional parameters of StartBacktestJob
+type StartBacktestJobParams struct {
+	Days       int
+	Confidence string
+}
+
+func (p *StartBacktestJobParams) apply(r *request) {
+	r.setQuery("days", p.Days)
+	r.setQuery("confidence", p.Confidence)
+}
+
+// GetLeverage reports gross and net leverage and the margin position of a portfolio, raising alerts
+// when the leverage limit or maintenance margin is breached
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/leverage
+func (c *Client) GetLeverage(ctx context.Context, id uuid.UUID) (*GetLeverageResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/leverage", id)
+	var out GetLeverageResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDrawdown reports the daily and weekly losses and the drawdown from the NAV peak of a
+// portfolio, raising alerts when a loss limit is hit
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/drawdown
+func (c *Client) GetDrawdown(ctx context.Context, id uuid.UUID) (*GetDrawdownResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/drawdown", id)
+	var out GetDrawdownResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCurrencyExposure reports a portfolio's exposure by currency in its base currency and its FX
+// risk, raising an alert when the foreign currency share breaches the threshold
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/currency-exposure
+func (c *Client) GetCurrencyExposure(ctx context.Context, id uuid.UUID) (*GetCurrencyExposureResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/currency-exposure", id)
+	var out GetCurrencyExposureResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetInterestRateRisk reports the duration, convexity, DV01 and credit exposure of a portfolio's
+// bonds, raising an alert when DV01 breaches the threshold
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/interest-rate-risk
+func (c *Client) GetInterestRateRisk(ctx context.Context, id uuid.UUID) (*GetInterestRateRiskResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/interest-rate-risk", id)
+	var out GetInterestRateRiskResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCryptoRisk reports the volatility, chain liquidity, venue exposure and stablecoin pegs of a
+// portfolio's crypto assets, raising alerts on venue breaches and depegs
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/crypto-risk
+func (c *Client) GetCryptoRisk(ctx context.Context, id uuid.UUID) (*GetCryptoRiskResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/crypto-risk", id)
+	var out GetCryptoRiskResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLimitUtilization reports each risk threshold of a portfolio with its current value and how
+// much of the limit is used, so a dashboard can draw its gauges from one call. VaR, position,
+// sector, concentration and liquidity limits are calculated from the positions for the request;
+// leverage, FX, DV01, venue and loss limits are read from the latest metric their monitors stored.
+// A limit that cannot be measured is left out with the reason in errors. The stablecoin depeg and
+// stop loss distance thresholds are rules on single instruments and orders, not measures of the
+// portfolio, and are not reported.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/limit-utilization
+func (c *Client) GetLimitUtilization(ctx context.Context, id uuid.UUID) (*GetLimitUtilizationResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/limit-utilization", id)
+	var out GetLimitUtilizationResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRollup rolls the positions of every portfolio under a portfolio up into it, in its currency,
+// and measures the VaR, position, sector, concentration and liquidity limits of the whole against
+// the portfolio's thresholds, as GetLimitUtilization does for a single portfolio. The limits read
+// from stored metrics are monitored per portfolio and not reported here.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/portfolio/{id}/rollup
+func (c *Client) GetRollup(ctx context.Context, id uuid.UUID) (*GetRollupResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/portfolio/{id}/rollup", id)
+	var out GetRollupResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSymbolExposure returns the exposure to a symbol across the portfolios the user can access.
+// Positions in ?symbol= are summed in ?currency= (USD by default) by team and portfolio, only over
+// the portfolios of ?team_id= when given. Admins and compliance officers also get the firm's
+// exposure against its limit on the symbol.
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/exposure
+func (c *Client) GetSymbolExposure(ctx context.Context, params *GetSymbolExposureParams) (*SymbolExposure, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/exposure")
+	if params != nil {
+		params.apply(r)
+	}
+	var out SymbolExposure
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSymbolExposureParams are the optional parameters of GetSymbolExposure
+type GetSymbolExposureParams struct {
+	TeamID   string
+	Symbol   string
+	Currency string
+}
+
+func (p *GetSymbolExposureParams) apply(r *request) {
+	r.setQuery("team_id", p.TeamID)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("currency", p.Currency)
+}
+
+// GetExposureLimits lists the firm-wide exposure limits of the user's tenant
+//
+// Requires the risk:read permission.
+//
+// GET /api/v1/risk/exposure-limits
+func (c *Client) GetExposureLimits(ctx context.Context) ([]ExposureLimit, error) {
+	r := newRequest(http.MethodGet, "/api/v1/risk/exposure-limits")
+	var out []ExposureLimit
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// SetExposureLimit sets the firm-wide limit on the gross exposure to a symbol across every
+// portfolio of the user's tenant
+//
+// Requires the risk:read and compliance:manage permissions.
+//
+// PUT /api/v1/risk/exposure-limits/{symbol}
+func (c *Client) SetExposureLimit(ctx context.Context, symbol string, body ExposureLimitRequest) (*ExposureLimit, error) {
+	r := newRequest(http.MethodPut, "/api/v1/risk/exposure-limits/{symbol}", symbol)
+	r.body = body
+	var out ExposureLimit
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteExposureLimit removes the firm-wide exposure limit on a symbol
+//
+// Requires the risk:read and compliance:manage permissions.
+//
+// DELETE /api/v1/risk/exposure-limits/{symbol}
+func (c *Client) DeleteExposureLimit(ctx context.Context, symbol string) (*DeleteExposureLimitResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/risk/exposure-limits/{symbol}", symbol)
+	var out DeleteExposureLimitResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAlerts returns a page of the alerts for portfolios the user can access
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts
+func (c *Client) GetAlerts(ctx context.Context, params *GetAlertsParams) (*GetAlertsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetAlertsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAlertsParams are the optional parameters of GetAlerts
+type GetAlertsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, severity, status; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	Status      string
+	Severity    string
+	AlertType   string
+	Source      string
+}
+
+func (p *GetAlertsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("severity", p.Severity)
+	r.setQuery("alert_type", p.AlertType)
+	r.setQuery("source", p.Source)
+}
+
+// GetActiveAlerts returns a page of the active alerts for portfolios the user can access
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/active
+func (c *Client) GetActiveAlerts(ctx context.Context, params *GetActiveAlertsParams) (*GetActiveAlertsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/active")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetActiveAlertsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetActiveAlertsParams are the optional parameters of GetActiveAlerts
+type GetActiveAlertsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, severity, status; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	Status      string
+	Severity    string
+	AlertType   string
+	Source      string
+}
+
+func (p *GetActiveAlertsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("severity", p.Severity)
+	r.setQuery("alert_type", p.AlertType)
+	r.setQuery("source", p.Source)
+}
+
+// GetAlertCounts returns the number of active alerts by severity across the user's portfolios
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/counts
+func (c *Client) GetAlertCounts(ctx context.Context) (*AlertCounts, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/counts")
+	var out AlertCounts
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportAlerts streams the alerts for portfolios the user can access as a CSV or XLSX file
+// (?format=), with the filters of GetAlerts
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/export
+func (c *Client) ExportAlerts(ctx context.Context, params *ExportAlertsParams) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/export")
+	if params != nil {
+		params.apply(r)
+	}
+	return c.stream(ctx, r)
+}
+
+// ExportAlertsParams are the optional parameters of ExportAlerts
+type ExportAlertsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, severity, status; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	Status      string
+	Severity    string
+	AlertType   string
+	Source      string
+	Format      string
+}
+
+func (p *ExportAlertsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("severity", p.Severity)
+	r.setQuery("alert_type", p.AlertType)
+	r.setQuery("source", p.Source)
+	r.setQuery("format", p.Format)
+}
+
+// StartAlertExport starts an export job for the alerts ExportAlerts would stream, for exports too
+// large to stream
+//
+// Requires the alert:read permission.
+//
+// POST /api/v1/alerts/export
+func (c *Client) StartAlertExport(ctx context.Context, params *StartAlertExportParams) (*ExportJob, error) {
+	r := newRequest(http.MethodPost, "/api/v1/alerts/export")
+	if params != nil {
+		params.apply(r)
+	}
+	var out ExportJob
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartAlertExportParams are the optional parameters of StartAlertExport
+type StartAlertExportParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, severity, status; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	Status      string
+	Severity    string
+	AlertType   string
+	Source      string
+	Format      string
+}
+
+func (p *StartAlertExportParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("severity", p.Severity)
+	r.setQuery("alert_type", p.AlertType)
+	r.setQuery("source", p.Source)
+	r.setQuery("format", p.Format)
+}
+
+// BulkAlerts acknowledges, resolves or dismisses a list of alerts. Each alert succeeds or fails on
+// its own; alerts the user cannot see are reported as not found, an alert without a version fails
+// as PRECONDITION_REQUIRED unless If-Match is *, and one changed since its version fails with the
+// conflict.
+//
+// Requires the alert:manage permission.
+//
+// POST /api/v1/alerts/bulk
+func (c *Client) BulkAlerts(ctx context.Context, body BulkAlertRequest, params *BulkAlertsParams) (*BulkAlertsResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/alerts/bulk")
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out BulkAlertsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BulkAlertsParams are the optional parameters of BulkAlerts
+type BulkAlertsParams struct {
+	// ETag of the record the update was made against; * applies it to any version
+	IfMatch string
+}
+
+func (p *BulkAlertsParams) apply(r *request) {
+	r.setHeader("If-Match", p.IfMatch)
+}
+
+// AcknowledgePortfolioAlerts acknowledges every active alert of a portfolio
+//
+// Requires the alert:manage permission.
+//
+// POST /api/v1/alerts/portfolio/{id}/acknowledge
+func (c *Client) AcknowledgePortfolioAlerts(ctx context.Context, id uuid.UUID) (*AcknowledgePortfolioAlertsResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/alerts/portfolio/{id}/acknowledge", id)
+	var out AcknowledgePortfolioAlertsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSuppressions returns a page of the alert suppression windows covering the user's portfolios,
+// including those covering every portfolio; ?active=true keeps the windows open now
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/suppressions
+func (c *Client) GetSuppressions(ctx context.Context, params *GetSuppressionsParams) (*GetSuppressionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/suppressions")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetSuppressionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSuppressionsParams are the optional parameters of GetSuppressions
+type GetSuppressionsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of starts_at, ends_at, created_at; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	RuleID      string
+	Source      string
+	Active      bool
+}
+
+func (p *GetSuppressionsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("rule_id", p.RuleID)
+	r.setQuery("source", p.Source)
+	r.setQuery("active", p.Active)
+}
+
+// CreateSuppression opens a window during which alerts of a portfolio, compliance rule or alert
+// source are raised as SUPPRESSED instead of ACTIVE. Only global roles may open a window without a
+// portfolio, which covers every portfolio.
+//
+// Requires the alert:manage permission.
+//
+// POST /api/v1/alerts/suppressions
+func (c *Client) CreateSuppression(ctx context.Context, body AlertSuppressionRequest) (*AlertSuppression, error) {
+	r := newRequest(http.MethodPost, "/api/v1/alerts/suppressions")
+	r.body = body
+	var out AlertSuppression
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSuppression returns an alert suppression window
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/suppressions/{id}
+func (c *Client) GetSuppression(ctx context.Context, id uuid.UUID) (*AlertSuppression, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/suppressions/{id}", id)
+	var out AlertSuppression
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelSuppression ends an alert suppression window early, or calls off one that has not started.
+// Alerts it already suppressed keep their status.
+//
+// Requires the alert:manage permission.
+//
+// DELETE /api/v1/alerts/suppressions/{id}
+func (c *Client) CancelSuppression(ctx context.Context, id uuid.UUID) (*AlertSuppression, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/alerts/suppressions/{id}", id)
+	var out AlertSuppression
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAlert returns a specific alert with its escalation timeline
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/alerts/{id}
+func (c *Client) GetAlert(ctx context.Context, id string) (*Alert, error) {
+	r := newRequest(http.MethodGet, "/api/v1/alerts/{id}", id)
+	var out Alert
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcknowledgeAlert acknowledges an alert. The change names the version it was made against in
+// If-Match or the version field, and is refused with the current alert if that has changed.
+//
+// Requires the alert:manage permission.
+//
+// PUT /api/v1/alerts/{id}/acknowledge
+func (c *Client) AcknowledgeAlert(ctx context.Context, id string, body AlertChangeRequest, params *AcknowledgeAlertParams) (*AcknowledgeAlertResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/alerts/{id}/acknowledge", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out AcknowledgeAlertResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcknowledgeAlertParams are the optional parameters of AcknowledgeAlert
+type AcknowledgeAlertParams struct {
+	// ETag of the record the update was made against; * applies it to any version
+	IfMatch string
+}
+
+func (p *AcknowledgeAlertParams) apply(r *request) {
+	r.setHeader("If-Match", p.IfMatch)
+}
+
+// ResolveAlert resolves an alert. The change names the version it was made against in If-Match or
+// the version field, and is refused with the current alert if that has changed.
+//
+// Requires the alert:manage permission.
+//
+// PUT /api/v1/alerts/{id}/resolve
+func (c *Client) ResolveAlert(ctx context.Context, id string, body AlertChangeRequest, params *ResolveAlertParams) (*ResolveAlertResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/alerts/{id}/resolve", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out ResolveAlertResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResolveAlertParams are the optional parameters of ResolveAlert
+type ResolveAlertParams struct {
+	// ETag of the record the update was made against; * applies it to any version
+	IfMatch string
+}
+
+func (p *ResolveAlertParams) apply(r *request) {
+	r.setHeader("If-Match", p.IfMatch)
+}
+
+// DeleteAlert deletes an alert
+//
+// Requires the alert:delete permission.
+//
+// DELETE /api/v1/alerts/{id}
+func (c *Client) DeleteAlert(ctx context.Context, id string) (*DeleteAlertResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/alerts/{id}", id)
+	var out DeleteAlertResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RestoreAlert undeletes a soft deleted alert
+//
+// Requires the records:restore permission.
+//
+// POST /api/v1/alerts/{id}/restore
+func (c *Client) RestoreAlert(ctx context.Context, id uuid.UUID) (*RestoreAlertResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/alerts/{id}/restore", id)
+	var out RestoreAlertResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTeams returns all teams
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/teams
+func (c *Client) GetTeams(ctx context.Context) ([]Team, error) {
+	r := newRequest(http.MethodGet, "/api/v1/teams")
+	var out []Team
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// CreateTeam creates a team
+//
+// Requires the escalation:manage permission.
+//
+// POST /api/v1/teams
+func (c *Client) CreateTeam(ctx context.Context, body TeamRequest) (*Team, error) {
+	r := newRequest(http.MethodPost, "/api/v1/teams")
+	r.body = body
+	var out Team
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTeam returns a team with who is on call now
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/teams/{id}
+func (c *Client) GetTeam(ctx context.Context, id uuid.UUID) (*GetTeamResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/teams/{id}", id)
+	var out GetTeamResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTeam renames or redescribes a team
+//
+// Requires the escalation:manage permission.
+//
+// PUT /api/v1/teams/{id}
+func (c *Client) UpdateTeam(ctx context.Context, id uuid.UUID, body TeamRequest) (*UpdateTeamResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/teams/{id}", id)
+	r.body = body
+	var out UpdateTeamResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTeam deletes a team and its rota
+//
+// Requires the escalation:manage permission.
+//
+// DELETE /api/v1/teams/{id}
+func (c *Client) DeleteTeam(ctx context.Context, id uuid.UUID) (*DeleteTeamResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/teams/{id}", id)
+	var out DeleteTeamResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetShifts returns a team's rota between the from and to query params (RFC3339), by default the
+// next two weeks
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/teams/{id}/shifts
+func (c *Client) GetShifts(ctx context.Context, id uuid.UUID, params *GetShiftsParams) ([]OnCallShift, error) {
+	r := newRequest(http.MethodGet, "/api/v1/teams/{id}/shifts", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out []OnCallShift
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetShiftsParams are the optional parameters of GetShifts
+type GetShiftsParams struct {
+	From string
+	To   string
+}
+
+func (p *GetShiftsParams) apply(r *request) {
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+}
+
+// CreateShift puts a user on call for a team
+//
+// Requires the escalation:manage permission.
+//
+// POST /api/v1/teams/{id}/shifts
+func (c *Client) CreateShift(ctx context.Context, id uuid.UUID, body OnCallShiftRequest) (*OnCallShift, error) {
+	r := newRequest(http.MethodPost, "/api/v1/teams/{id}/shifts", id)
+	r.body = body
+	var out OnCallShift
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShift removes a shift from a team's rota
+//
+// Requires the escalation:manage permission.
+//
+// DELETE /api/v1/teams/{id}/shifts/{shiftId}
+func (c *Client) DeleteShift(ctx context.Context, id uuid.UUID, shiftID uuid.UUID) (*DeleteShiftResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/teams/{id}/shifts/{shiftId}", id, shiftID)
+	var out DeleteShiftResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMembers returns the members of a team with their roles
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/teams/{id}/members
+func (c *Client) GetMembers(ctx context.Context, id uuid.UUID) ([]TeamMember, error) {
+	r := newRequest(http.MethodGet, "/api/v1/teams/{id}/members", id)
+	var out []TeamMember
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// SetMember adds a user to a team as a VIEWER or EDITOR, or changes their role
+//
+// Requires the portfolio:assign permission.
+//
+// PUT /api/v1/teams/{id}/members/{userId}
+func (c *Client) SetMember(ctx context.Context, id uuid.UUID, userID uuid.UUID, body SetMemberRequest) (*TeamMember, error) {
+	r := newRequest(http.MethodPut, "/api/v1/teams/{id}/members/{userId}", id, userID)
+	r.body = body
+	var out TeamMember
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveMember removes a user from a team
+//
+// Requires the portfolio:assign permission.
+//
+// DELETE /api/v1/teams/{id}/members/{userId}
+func (c *Client) RemoveMember(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*RemoveMemberResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/teams/{id}/members/{userId}", id, userID)
+	var out RemoveMemberResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPolicies returns all escalation policies
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/escalation-policies
+func (c *Client) GetPolicies(ctx context.Context) ([]EscalationPolicy, error) {
+	r := newRequest(http.MethodGet, "/api/v1/escalation-policies")
+	var out []EscalationPolicy
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// CreatePolicy creates an escalation policy
+//
+// Requires the escalation:manage permission.
+//
+// POST /api/v1/escalation-policies
+func (c *Client) CreatePolicy(ctx context.Context, body EscalationPolicyRequest) (*EscalationPolicy, error) {
+	r := newRequest(http.MethodPost, "/api/v1/escalation-policies")
+	r.body = body
+	var out EscalationPolicy
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPolicy returns an escalation policy
+//
+// Requires the alert:read permission.
+//
+// GET /api/v1/escalation-policies/{id}
+func (c *Client) GetPolicy(ctx context.Context, id uuid.UUID) (*EscalationPolicy, error) {
+	r := newRequest(http.MethodGet, "/api/v1/escalation-policies/{id}", id)
+	var out EscalationPolicy
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePolicy updates an escalation policy
+//
+// Requires the escalation:manage permission.
+//
+// PUT /api/v1/escalation-policies/{id}
+func (c *Client) UpdatePolicy(ctx context.Context, id uuid.UUID, body EscalationPolicyRequest) (*UpdatePolicyResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/escalation-policies/{id}", id)
+	r.body = body
+	var out UpdatePolicyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePolicy deletes an escalation policy that has not escalated any alert
+//
+// Requires the escalation:manage permission.
+//
+// DELETE /api/v1/escalation-policies/{id}
+func (c *Client) DeletePolicy(ctx context.Context, id uuid.UUID) (*DeletePolicyResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/escalation-policies/{id}", id)
+	var out DeletePolicyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckCompliance performs compliance checks for a portfolio
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/portfolio/{id}/check
+func (c *Client) CheckCompliance(ctx context.Context, id string) (*CheckComplianceResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/portfolio/{id}/check", id)
+	var out CheckComplianceResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckPositionLimits checks position limits for a portfolio
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/portfolio/{id}/position-limits
+func (c *Client) CheckPositionLimits(ctx context.Context, id string) (*CheckPositionLimitsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/portfolio/{id}/position-limits", id)
+	var out CheckPositionLimitsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckAML performs AML checks and sanctions/PEP screening on a transaction
+//
+// Requires the compliance:screen permission.
+//
+// POST /api/v1/compliance/transaction/{id}/aml-check
+func (c *Client) CheckAML(ctx context.Context, id uuid.UUID) (*CheckAMLResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/transaction/{id}/aml-check", id)
+	var out CheckAMLResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTransactionScreenings returns the screening history for a transaction
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/transaction/{id}/screenings
+func (c *Client) GetTransactionScreenings(ctx context.Context, id uuid.UUID) ([]ScreeningResult, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/transaction/{id}/screenings", id)
+	var out []ScreeningResult
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetTransactionMonitoring returns the transaction monitoring evaluations of a transaction, with
+// the features, rules and anomaly score behind each
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/transaction/{id}/monitoring
+func (c *Client) GetTransactionMonitoring(ctx context.Context, id uuid.UUID) ([]TransactionMonitoringEvaluation, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/transaction/{id}/monitoring", id)
+	var out []TransactionMonitoringEvaluation
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// AMLSweep re-runs transaction monitoring over the last ?days of transactions (30 by default),
+// optionally of one ?portfolio_id in the user's tenant, and reports the transactions newly flagged
+// for review
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/aml-sweep
+func (c *Client) AMLSweep(ctx context.Context, params *AMLSweepParams) (*AMLSweepResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/aml-sweep")
+	if params != nil {
+		params.apply(r)
+	}
+	var out AMLSweepResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AMLSweepParams are the optional parameters of AMLSweep
+type AMLSweepParams struct {
+	PortfolioID string
+	Days        int
+}
+
+func (p *AMLSweepParams) apply(r *request) {
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("days", p.Days)
+}
+
+// ScreenName screens an arbitrary name against the sanctions and PEP lists
+//
+// Requires the compliance:screen permission.
+//
+// POST /api/v1/compliance/screen
+func (c *Client) ScreenName(ctx context.Context, body ScreenNameRequest) (*ScreeningResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/screen")
+	r.body = body
+	var out ScreeningResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSanctionsEntries returns imported list entries
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/sanctions
+func (c *Client) GetSanctionsEntries(ctx context.Context, params *GetSanctionsEntriesParams) (*GetSanctionsEntriesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/sanctions")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetSanctionsEntriesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSanctionsEntriesParams are the optional parameters of GetSanctionsEntries
+type GetSanctionsEntriesParams struct {
+	Limit  string
+	Offset string
+	Source string
+}
+
+func (p *GetSanctionsEntriesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("source", p.Source)
+}
+
+// ImportSanctionsList imports an OFAC, EU, UN, PEP or internal list file
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/sanctions/import
+func (c *Client) ImportSanctionsList(ctx context.Context, body io.Reader, contentType string) (*ImportSanctionsListResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/sanctions/import")
+	r.setRawBody(body, contentType)
+	var out ImportSanctionsListResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PreTradeCheck runs the KYC, sanctions, restricted and watch list and position limit checks on a
+// proposed order before it is routed. The response says whether the order passed and why not; a
+// failed check is still a 200, so order flows need only read "passed".
+//
+// Requires the compliance:read permission.
+//
+// POST /api/v1/compliance/pre-trade-check
+func (c *Client) PreTradeCheck(ctx context.Context, body PreTradeCheckRequest) (*PreTradeResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/pre-trade-check")
+	r.body = body
+	var out PreTradeResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEntries returns a page of the restricted or watch list (:list), including entries that are not
+// in effect
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/symbol-lists/{list}
+func (c *Client) GetEntries(ctx context.Context, list string, params *GetEntriesParams) (*GetEntriesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/symbol-lists/{list}", list)
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetEntriesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEntriesParams are the optional parameters of GetEntries
+type GetEntriesParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of symbol, effective_from, created_at; prefix it with - to sort in
+	// descending order
+	Sort   string
+	Order  string
+	Symbol string
+	Side   string
+}
+
+func (p *GetEntriesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("side", p.Side)
+}
+
+// CreateEntry adds a symbol to the restricted or watch list
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/symbol-lists/{list}
+func (c *Client) CreateEntry(ctx context.Context, list string, body SymbolListRequest) (json.RawMessage, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/symbol-lists/{list}", list)
+	r.body = body
+	var out json.RawMessage
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetEntry returns one entry of the restricted or watch list
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/symbol-lists/{list}/{id}
+func (c *Client) GetEntry(ctx context.Context, list string, id uuid.UUID) (json.RawMessage, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/symbol-lists/{list}/{id}", list, id)
+	var out json.RawMessage
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// UpdateEntry changes an entry of the restricted or watch list, such as to end it
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/compliance/symbol-lists/{list}/{id}
+func (c *Client) UpdateEntry(ctx context.Context, list string, id uuid.UUID, body SymbolListRequest) (json.RawMessage, error) {
+	r := newRequest(http.MethodPut, "/api/v1/compliance/symbol-lists/{list}/{id}", list, id)
+	r.body = body
+	var out json.RawMessage
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// DeleteEntry removes an entry from the restricted or watch list. Ending an entry with effective_to
+// keeps its history instead.
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/compliance/symbol-lists/{list}/{id}
+func (c *Client) DeleteEntry(ctx context.Context, list string, id uuid.UUID) (*DeleteEntryResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/compliance/symbol-lists/{list}/{id}", list, id)
+	var out DeleteEntryResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGuideline returns a portfolio's investment guideline
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/portfolio/{id}/guideline
+func (c *Client) GetGuideline(ctx context.Context, id uuid.UUID) (*InvestmentGuideline, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/portfolio/{id}/guideline", id)
+	var out InvestmentGuideline
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SaveGuideline sets a portfolio's investment guideline, replacing any it had
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/compliance/portfolio/{id}/guideline
+func (c *Client) SaveGuideline(ctx context.Context, id uuid.UUID, body InvestmentGuidelineRequest) (*InvestmentGuideline, error) {
+	r := newRequest(http.MethodPut, "/api/v1/compliance/portfolio/{id}/guideline", id)
+	r.body = body
+	var out InvestmentGuideline
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGuideline removes a portfolio's investment guideline
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/compliance/portfolio/{id}/guideline
+func (c *Client) DeleteGuideline(ctx context.Context, id uuid.UUID) (*DeleteGuidelineResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/compliance/portfolio/{id}/guideline", id)
+	var out DeleteGuidelineResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EvaluateGuideline checks a portfolio's holdings against its guideline immediately, raising alerts
+// for the breaches
+//
+// Requires the compliance:screen permission.
+//
+// POST /api/v1/compliance/portfolio/{id}/guideline/evaluate
+func (c *Client) EvaluateGuideline(ctx context.Context, id uuid.UUID) (*GuidelineEvaluationResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/portfolio/{id}/guideline/evaluate", id)
+	var out GuidelineEvaluationResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPortfolioTemplates returns a page of the portfolio templates
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolio-templates
+func (c *Client) GetPortfolioTemplates(ctx context.Context, params *GetPortfolioTemplatesParams) (*GetPortfolioTemplatesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolio-templates")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetPortfolioTemplatesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPortfolioTemplatesParams are the optional parameters of GetPortfolioTemplates
+type GetPortfolioTemplatesParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of name, created_at, updated_at; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To       string
+	Currency string
+}
+
+func (p *GetPortfolioTemplatesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("currency", p.Currency)
+}
+
+// CreatePortfolioTemplate saves a predefined set of positions, cash, risk thresholds and investment
+// guideline that portfolios can be created from
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/portfolio-templates
+func (c *Client) CreatePortfolioTemplate(ctx context.Context, body PortfolioTemplateRequest) (*PortfolioTemplate, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolio-templates")
+	r.body = body
+	var out PortfolioTemplate
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPortfolioTemplate returns a portfolio template with its positions, thresholds and guideline
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/portfolio-templates/{id}
+func (c *Client) GetPortfolioTemplate(ctx context.Context, id uuid.UUID) (*PortfolioTemplate, error) {
+	r := newRequest(http.MethodGet, "/api/v1/portfolio-templates/{id}", id)
+	var out PortfolioTemplate
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePortfolioTemplate replaces a portfolio template; portfolios created from it keep what they
+// were created with
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/portfolio-templates/{id}
+func (c *Client) UpdatePortfolioTemplate(ctx context.Context, id uuid.UUID, body PortfolioTemplateRequest) (*PortfolioTemplate, error) {
+	r := newRequest(http.MethodPut, "/api/v1/portfolio-templates/{id}", id)
+	r.body = body
+	var out PortfolioTemplate
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePortfolioTemplate removes a portfolio template
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/portfolio-templates/{id}
+func (c *Client) DeletePortfolioTemplate(ctx context.Context, id uuid.UUID) (*DeletePortfolioTemplateResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/portfolio-templates/{id}", id)
+	var out DeletePortfolioTemplateResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePortfolioFromTemplate creates a portfolio owned by the user with the template's positions,
+// cash, risk thresholds and guideline
+//
+// Requires the portfolio:write permission.
+//
+// POST /api/v1/portfolio-templates/{id}/portfolios
+func (c *Client) CreatePortfolioFromTemplate(ctx context.Context, id uuid.UUID, body PortfolioFromTemplateRequest, params *CreatePortfolioFromTemplateParams) (*Portfolio, error) {
+	r := newRequest(http.MethodPost, "/api/v1/portfolio-templates/{id}/portfolios", id)
+	r.body = body
+	if params != nil {
+		params.apply(r)
+	}
+	var out Portfolio
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePortfolioFromTemplateParams are the optional parameters of CreatePortfolioFromTemplate
+type CreatePortfolioFromTemplateParams struct {
+	// Makes the request safe to retry: a repeated key returns the stored response
+	IdempotencyKey string
+}
+
+func (p *CreatePortfolioFromTemplateParams) apply(r *request) {
+	r.setHeader("Idempotency-Key", p.IdempotencyKey)
+}
+
+// GetCorporateActions returns a page of the corporate actions
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/corporate-actions
+func (c *Client) GetCorporateActions(ctx context.Context, params *GetCorporateActionsParams) (*GetCorporateActionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/corporate-actions")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetCorporateActionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCorporateActionsParams are the optional parameters of GetCorporateActions
+type GetCorporateActionsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of ex_date, symbol, created_at; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	Symbol     string
+	ActionType string
+	Status     string
+}
+
+func (p *GetCorporateActionsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("symbol", p.Symbol)
+	r.setQuery("action_type", p.ActionType)
+	r.setQuery("status", p.Status)
+}
+
+// CreateCorporateAction records a split, dividend or symbol change as PENDING. It is applied by the
+// end-of-day batch once its ex-date is reached, or earlier on request.
+//
+// Requires the liquidity:manage permission.
+//
+// POST /api/v1/corporate-actions
+func (c *Client) CreateCorporateAction(ctx context.Context, body CorporateActionRequest) (*CorporateAction, error) {
+	r := newRequest(http.MethodPost, "/api/v1/corporate-actions")
+	r.body = body
+	var out CorporateAction
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCorporateAction returns a corporate action
+//
+// Requires the portfolio:read permission.
+//
+// GET /api/v1/corporate-actions/{id}
+func (c *Client) GetCorporateAction(ctx context.Context, id uuid.UUID) (*CorporateAction, error) {
+	r := newRequest(http.MethodGet, "/api/v1/corporate-actions/{id}", id)
+	var out CorporateAction
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteCorporateAction removes a pending corporate action
+//
+// Requires the liquidity:manage permission.
+//
+// DELETE /api/v1/corporate-actions/{id}
+func (c *Client) DeleteCorporateAction(ctx context.Context, id uuid.UUID) (*DeleteCorporateActionResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/corporate-actions/{id}", id)
+	var out DeleteCorporateActionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PreviewCorporateAction returns the adjustments applying a pending corporate action would make,
+// without making them
+//
+// Requires the liquidity:manage permission.
+//
+// GET /api/v1/corporate-actions/{id}/preview
+func (c *Client) PreviewCorporateAction(ctx context.Context, id uuid.UUID) (*CorporateActionPlan, error) {
+	r := newRequest(http.MethodGet, "/api/v1/corporate-actions/{id}/preview", id)
+	var out CorporateActionPlan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCorporateActionAdjustments returns the positions, lots, orders, snapshots and dividends an
+// applied corporate action changed, with their values before and after
+//
+// Requires the liquidity:manage permission.
+//
+// GET /api/v1/corporate-actions/{id}/adjustments
+func (c *Client) GetCorporateActionAdjustments(ctx context.Context, id uuid.UUID) (*GetCorporateActionAdjustmentsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/corporate-actions/{id}/adjustments", id)
+	var out GetCorporateActionAdjustmentsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ApplyCorporateAction applies a pending corporate action whose ex-date has been reached to every
+// portfolio holding the symbol
+//
+// Requires the liquidity:manage permission.
+//
+// POST /api/v1/corporate-actions/{id}/apply
+func (c *Client) ApplyCorporateAction(ctx context.Context, id uuid.UUID) (*CorporateActionPlan, error) {
+	r := newRequest(http.MethodPost, "/api/v1/corporate-actions/{id}/apply", id)
+	var out CorporateActionPlan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RollbackCorporateAction undoes an applied corporate action
+//
+// Requires the liquidity:manage permission.
+//
+// POST /api/v1/corporate-actions/{id}/rollback
+func (c *Client) RollbackCorporateAction(ctx context.Context, id uuid.UUID) (*CorporateAction, error) {
+	r := newRequest(http.MethodPost, "/api/v1/corporate-actions/{id}/rollback", id)
+	var out CorporateAction
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetProfiles returns a page of KYC profiles
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/kyc-profiles
+func (c *Client) GetProfiles(ctx context.Context, params *GetProfilesParams) (*GetProfilesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/kyc-profiles")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetProfilesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetProfilesParams are the optional parameters of GetProfiles
+type GetProfilesParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, next_review_at, risk_rating; prefix it with - to sort in
+	// descending order
+	Sort        string
+	Order       string
+	SubjectType string
+	SubjectID   string
+	Status      string
+	RiskRating  string
+}
+
+func (p *GetProfilesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("subject_type", p.SubjectType)
+	r.setQuery("subject_id", p.SubjectID)
+	r.setQuery("status", p.Status)
+	r.setQuery("risk_rating", p.RiskRating)
+}
+
+// CreateProfile opens a KYC profile, pending review, for a user or counterparty
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/kyc-profiles
+func (c *Client) CreateProfile(ctx context.Context, body KYCProfileRequest) (*KYCProfile, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/kyc-profiles")
+	r.body = body
+	var out KYCProfile
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDueForReview returns the profiles awaiting review, and those due within ?within_days (30 by
+// default)
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/kyc-profiles/due
+func (c *Client) GetDueForReview(ctx context.Context, params *GetDueForReviewParams) ([]KYCProfile, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/kyc-profiles/due")
+	if params != nil {
+		params.apply(r)
+	}
+	var out []KYCProfile
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetDueForReviewParams are the optional parameters of GetDueForReview
+type GetDueForReviewParams struct {
+	WithinDays int
+}
+
+func (p *GetDueForReviewParams) apply(r *request) {
+	r.setQuery("within_days", p.WithinDays)
+}
+
+// GetProfile returns a single KYC profile
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/kyc-profiles/{id}
+func (c *Client) GetProfile(ctx context.Context, id uuid.UUID) (*KYCProfile, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/kyc-profiles/{id}", id)
+	var out KYCProfile
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile changes a profile's documents, risk rating or notes
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/compliance/kyc-profiles/{id}
+func (c *Client) UpdateProfile(ctx context.Context, id uuid.UUID, body KYCProfileRequest) (*KYCProfile, error) {
+	r := newRequest(http.MethodPut, "/api/v1/compliance/kyc-profiles/{id}", id)
+	r.body = body
+	var out KYCProfile
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReviewProfile verifies or rejects a profile, such as when it is due for its periodic review
+//
+// Requires the compliance:screen permission.
+//
+// POST /api/v1/compliance/kyc-profiles/{id}/review
+func (c *Client) ReviewProfile(ctx context.Context, id uuid.UUID, body KYCReviewRequest) (*KYCProfile, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/kyc-profiles/{id}/review", id)
+	r.body = body
+	var out KYCProfile
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteProfile removes a KYC profile
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/compliance/kyc-profiles/{id}
+func (c *Client) DeleteProfile(ctx context.Context, id uuid.UUID) (*DeleteProfileResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/compliance/kyc-profiles/{id}", id)
+	var out DeleteProfileResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRules returns all compliance rules
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/rules
+func (c *Client) GetRules(ctx context.Context, params *GetRulesParams) ([]ComplianceRule, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/rules")
+	if params != nil {
+		params.apply(r)
+	}
+	var out []ComplianceRule
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetRulesParams are the optional parameters of GetRules
+type GetRulesParams struct {
+	Scope      string
+	ActiveOnly bool
+}
+
+func (p *GetRulesParams) apply(r *request) {
+	r.setQuery("scope", p.Scope)
+	r.setQuery("active_only", p.ActiveOnly)
+}
+
+// CreateRule creates a new compliance rule
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/rules
+func (c *Client) CreateRule(ctx context.Context, body ComplianceRuleRequest) (*ComplianceRule, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/rules")
+	r.body = body
+	var out ComplianceRule
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EvaluateRules runs the rule engine immediately, optionally for a single portfolio
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/rules/evaluate
+func (c *Client) EvaluateRules(ctx context.Context, params *EvaluateRulesParams) (*RuleEvaluationResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/rules/evaluate")
+	if params != nil {
+		params.apply(r)
+	}
+	var out RuleEvaluationResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EvaluateRulesParams are the optional parameters of EvaluateRules
+type EvaluateRulesParams struct {
+	PortfolioID string
+}
+
+func (p *EvaluateRulesParams) apply(r *request) {
+	r.setQuery("portfolio_id", p.PortfolioID)
+}
+
+// GetRule returns a specific compliance rule
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/rules/{id}
+func (c *Client) GetRule(ctx context.Context, id uuid.UUID) (*ComplianceRule, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/rules/{id}", id)
+	var out ComplianceRule
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRule updates an existing compliance rule
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/compliance/rules/{id}
+func (c *Client) UpdateRule(ctx context.Context, id uuid.UUID, body ComplianceRuleRequest) (*UpdateRuleResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/compliance/rules/{id}", id)
+	r.body = body
+	var out UpdateRuleResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRule deletes a compliance rule
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/compliance/rules/{id}
+func (c *Client) DeleteRule(ctx context.Context, id uuid.UUID) (*DeleteRuleResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/compliance/rules/{id}", id)
+	var out DeleteRuleResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCounterparties returns a page of counterparties
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/counterparties
+func (c *Client) GetCounterparties(ctx context.Context, params *GetCounterpartiesParams) (*GetCounterpartiesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/counterparties")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetCounterpartiesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCounterpartiesParams are the optional parameters of GetCounterparties
+type GetCounterpartiesParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of name, created_at, risk_rating; prefix it with - to sort in descending
+	// order
+	Sort         string
+	Order        string
+	Jurisdiction string
+	RiskRating   string
+	KYCStatus    string
+	IsActive     string
+}
+
+func (p *GetCounterpartiesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("jurisdiction", p.Jurisdiction)
+	r.setQuery("risk_rating", p.RiskRating)
+	r.setQuery("kyc_status", p.KYCStatus)
+	r.setQuery("is_active", p.IsActive)
+}
+
+// CreateCounterparty creates a new counterparty
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/compliance/counterparties
+func (c *Client) CreateCounterparty(ctx context.Context, body CounterpartyRequest) (*Counterparty, error) {
+	r := newRequest(http.MethodPost, "/api/v1/compliance/counterparties")
+	r.body = body
+	var out Counterparty
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExposures returns the aggregated exposure of the user's tenant for every counterparty
+//
+// Requires the compliance:screen permission.
+//
+// GET /api/v1/compliance/counterparties/exposure
+func (c *Client) GetExposures(ctx context.Context) (*GetExposuresResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/counterparties/exposure")
+	var out GetExposuresResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCounterparty returns a single counterparty
+//
+// Requires the compliance:read permission.
+//
+// GET /api/v1/compliance/counterparties/{id}
+func (c *Client) GetCounterparty(ctx context.Context, id uuid.UUID) (*Counterparty, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/counterparties/{id}", id)
+	var out Counterparty
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCounterparty updates a counterparty
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/compliance/counterparties/{id}
+func (c *Client) UpdateCounterparty(ctx context.Context, id uuid.UUID, body CounterpartyRequest) (*UpdateCounterpartyResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/compliance/counterparties/{id}", id)
+	r.body = body
+	var out UpdateCounterpartyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteCounterparty deletes a counterparty that has no transactions
+//
+// Requires the compliance:manage permission.
+//
+// DELETE /api/v1/compliance/counterparties/{id}
+func (c *Client) DeleteCounterparty(ctx context.Context, id uuid.UUID) (*DeleteCounterpartyResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/compliance/counterparties/{id}", id)
+	var out DeleteCounterpartyResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExposure returns the aggregated exposure of the user's tenant for one counterparty
+//
+// Requires the compliance:screen permission.
+//
+// GET /api/v1/compliance/counterparties/{id}/exposure
+func (c *Client) GetExposure(ctx context.Context, id uuid.UUID) (*CounterpartyExposure, error) {
+	r := newRequest(http.MethodGet, "/api/v1/compliance/counterparties/{id}/exposure", id)
+	var out CounterpartyExposure
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRuns returns a page of reconciliation runs, newest first by default
+//
+// Requires the compliance:manage permission.
+//
+// GET /api/v1/reconciliation/runs
+func (c *Client) GetRuns(ctx context.Context, params *GetRunsParams) (*GetRunsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reconciliation/runs")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetRunsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRunsParams are the optional parameters of GetRuns
+type GetRunsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, as_of; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To     string
+	Source string
+	Status string
+}
+
+func (p *GetRunsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("source", p.Source)
+	r.setQuery("status", p.Status)
+}
+
+// UploadPositions reconciles an uploaded custodian CSV position file (multipart field "file"), as
+// of the as_of form value (YYYY-MM-DD, today by default). A file that cannot be reconciled is
+// recorded as a failed run and reported with a 422.
+//
+// Requires the compliance:manage permission.
+//
+// POST /api/v1/reconciliation/runs
+func (c *Client) UploadPositions(ctx context.Context, body io.Reader, contentType string) (*ReconciliationRun, error) {
+	r := newRequest(http.MethodPost, "/api/v1/reconciliation/runs")
+	r.setRawBody(body, contentType)
+	var out ReconciliationRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRun returns a reconciliation run
+//
+// Requires the compliance:manage permission.
+//
+// GET /api/v1/reconciliation/runs/{id}
+func (c *Client) GetRun(ctx context.Context, id uuid.UUID) (*ReconciliationRun, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reconciliation/runs/{id}", id)
+	var out ReconciliationRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBreaks returns a page of reconciliation breaks, filtered by run, portfolio, status or type
+//
+// Requires the compliance:manage permission.
+//
+// GET /api/v1/reconciliation/breaks
+func (c *Client) GetBreaks(ctx context.Context, params *GetBreaksParams) (*GetBreaksResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reconciliation/breaks")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetBreaksResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBreaksParams are the optional parameters of GetBreaks
+type GetBreaksParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, symbol, status; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	RunID       string
+	PortfolioID string
+	Status      string
+	BreakType   string
+}
+
+func (p *GetBreaksParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("run_id", p.RunID)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("status", p.Status)
+	r.setQuery("break_type", p.BreakType)
+}
+
+// UpdateBreak explains, resolves or reopens a reconciliation break
+//
+// Requires the compliance:manage permission.
+//
+// PUT /api/v1/reconciliation/breaks/{id}
+func (c *Client) UpdateBreak(ctx context.Context, id uuid.UUID, body UpdateBreakRequest) (*ReconciliationBreak, error) {
+	r := newRequest(http.MethodPut, "/api/v1/reconciliation/breaks/{id}", id)
+	r.body = body
+	var out ReconciliationBreak
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCases returns a page of cases
+//
+// Requires the case:read permission.
+//
+// GET /api/v1/cases
+func (c *Client) GetCases(ctx context.Context, params *GetCasesParams) (*GetCasesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/cases")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetCasesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCasesParams are the optional parameters of GetCases
+type GetCasesParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, updated_at, priority, status; prefix it with - to sort in
+	// descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To         string
+	Status     string
+	Priority   string
+	AssigneeID string
+	AlertID    string
+}
+
+func (p *GetCasesParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("status", p.Status)
+	r.setQuery("priority", p.Priority)
+	r.setQuery("assignee_id", p.AssigneeID)
+	r.setQuery("alert_id", p.AlertID)
+}
+
+// EscalateCase opens a case from an alert and/or flagged transactions
+//
+// Requires the case:escalate permission.
+//
+// POST /api/v1/cases
+func (c *Client) EscalateCase(ctx context.Context, body EscalateCaseRequest) (*Case, error) {
+	r := newRequest(http.MethodPost, "/api/v1/cases")
+	r.body = body
+	var out Case
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCase returns a case with its transactions, timeline and evidence
+//
+// Requires the case:read permission.
+//
+// GET /api/v1/cases/{id}
+func (c *Client) GetCase(ctx context.Context, id uuid.UUID) (*Case, error) {
+	r := newRequest(http.MethodGet, "/api/v1/cases/{id}", id)
+	var out Case
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCase edits a case's details and SAR narrative
+//
+// Requires the case:manage permission.
+//
+// PUT /api/v1/cases/{id}
+func (c *Client) UpdateCase(ctx context.Context, id uuid.UUID, body UpdateCaseRequest) (*UpdateCaseResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/cases/{id}", id)
+	r.body = body
+	var out UpdateCaseResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AssignCase assigns a case to an investigator
+//
+// Requires the case:manage permission.
+//
+// PUT /api/v1/cases/{id}/assign
+func (c *Client) AssignCase(ctx context.Context, id uuid.UUID, body AssignCaseRequest) (*AssignCaseResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/cases/{id}/assign", id)
+	r.body = body
+	var out AssignCaseResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCaseStatus moves a case through OPEN -> INVESTIGATING -> FILED/DISMISSED
+//
+// Requires the case:manage permission.
+//
+// PUT /api/v1/cases/{id}/status
+func (c *Client) UpdateCaseStatus(ctx context.Context, id uuid.UUID, body UpdateCaseStatusRequest) (*UpdateCaseStatusResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/cases/{id}/status", id)
+	r.body = body
+	var out UpdateCaseStatusResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddCaseNote adds an investigation note to a case
+//
+// Requires the case:manage permission.
+//
+// POST /api/v1/cases/{id}/notes
+func (c *Client) AddCaseNote(ctx context.Context, id uuid.UUID, body AddCaseNoteRequest) (*CaseNote, error) {
+	r := newRequest(http.MethodPost, "/api/v1/cases/{id}/notes", id)
+	r.body = body
+	var out CaseNote
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddCaseTransactions links further transactions to a case
+//
+// Requires the case:manage permission.
+//
+// POST /api/v1/cases/{id}/transactions
+func (c *Client) AddCaseTransactions(ctx context.Context, id uuid.UUID, body AddCaseTransactionsRequest) (*Case, error) {
+	r := newRequest(http.MethodPost, "/api/v1/cases/{id}/transactions", id)
+	r.body = body
+	var out Case
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadEvidence attaches a file (multipart field "file") to a case
+//
+// Requires the case:manage permission.
+//
+// POST /api/v1/cases/{id}/evidence
+func (c *Client) UploadEvidence(ctx context.Context, id uuid.UUID, body io.Reader, contentType string) (*CaseEvidence, error) {
+	r := newRequest(http.MethodPost, "/api/v1/cases/{id}/evidence", id)
+	r.setRawBody(body, contentType)
+	var out CaseEvidence
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadEvidence returns an evidence file
+//
+// Requires the case:read permission.
+//
+// GET /api/v1/cases/{id}/evidence/{evidenceId}
+func (c *Client) DownloadEvidence(ctx context.Context, id uuid.UUID, evidenceID uuid.UUID) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/cases/{id}/evidence/{evidenceId}", id, evidenceID)
+	return c.stream(ctx, r)
+}
+
+// ExportSARReport downloads the SAR document for a case as plain text (default) or JSON
+//
+// Requires the case:read permission.
+//
+// GET /api/v1/cases/{id}/report
+func (c *Client) ExportSARReport(ctx context.Context, id uuid.UUID, params *ExportSARReportParams) (*SARReport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/cases/{id}/report", id)
+	if params != nil {
+		params.apply(r)
+	}
+	var out SARReport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportSARReportParams are the optional parameters of ExportSARReport
+type ExportSARReportParams struct {
+	Format string
+}
+
+func (p *ExportSARReportParams) apply(r *request) {
+	r.setQuery("format", p.Format)
+}
+
+// GetReports returns a page of stored reports for portfolios the user can see
+//
+// Requires the report:read permission.
+//
+// GET /api/v1/reports
+func (c *Client) GetReports(ctx context.Context, params *GetReportsParams) (*GetReportsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reports")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetReportsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetReportsParams are the optional parameters of GetReports
+type GetReportsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, report_type; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	PortfolioID string
+	ReportType  string
+	Format      string
+}
+
+func (p *GetReportsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("portfolio_id", p.PortfolioID)
+	r.setQuery("report_type", p.ReportType)
+	r.setQuery("format", p.Format)
+}
+
+// GenerateReport renders and stores a report for a portfolio; access is checked by the
+// PortfolioAccess middleware
+//
+// Requires the report:generate permission.
+//
+// POST /api/v1/reports/portfolio/{id}
+func (c *Client) GenerateReport(ctx context.Context, id uuid.UUID, body GenerateReportRequest) (*Report, error) {
+	r := newRequest(http.MethodPost, "/api/v1/reports/portfolio/{id}", id)
+	r.body = body
+	var out Report
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartReportJob queues the report GenerateReport would render with the same body; the job links to
+// the stored report once it completes
+//
+// Requires the report:generate permission.
+//
+// POST /api/v1/reports/portfolio/{id}/jobs
+func (c *Client) StartReportJob(ctx context.Context, id uuid.UUID, body StartReportJobRequest) (*Job, error) {
+	r := newRequest(http.MethodPost, "/api/v1/reports/portfolio/{id}/jobs", id)
+	r.body = body
+	var out Job
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetReport returns a stored report's metadata
+//
+// Requires the report:read permission.
+//
+// GET /api/v1/reports/{id}
+func (c *Client) GetReport(ctx context.Context, id uuid.UUID) (*Report, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reports/{id}", id)
+	var out Report
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadReport returns a stored report file
+//
+// Requires the report:read permission.
+//
+// GET /api/v1/reports/{id}/download
+func (c *Client) DownloadReport(ctx context.Context, id uuid.UUID) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/reports/{id}/download", id)
+	return c.stream(ctx, r)
+}
+
+// GetExport returns an export job the user started, with its download link once it completes
+//
+// GET /api/v1/exports/{id}
+func (c *Client) GetExport(ctx context.Context, id uuid.UUID) (*ExportJob, error) {
+	r := newRequest(http.MethodGet, "/api/v1/exports/{id}", id)
+	var out ExportJob
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadExport returns the file of a completed export job the user started
+//
+// GET /api/v1/exports/{id}/download
+func (c *Client) DownloadExport(ctx context.Context, id uuid.UUID) (io.ReadCloser, error) {
+	r := newRequest(http.MethodGet, "/api/v1/exports/{id}/download", id)
+	return c.stream(ctx, r)
+}
+
+// GetJobs returns a page of the jobs the user started, without their results
+//
+// GET /api/v1/jobs
+func (c *Client) GetJobs(ctx context.Context, params *GetJobsParams) (*GetJobsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/jobs")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetJobsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJobsParams are the optional parameters of GetJobs
+type GetJobsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, status, job_type; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To          string
+	Status      string
+	JobType     string
+	PortfolioID string
+}
+
+func (p *GetJobsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("status", p.Status)
+	r.setQuery("job_type", p.JobType)
+	r.setQuery("portfolio_id", p.PortfolioID)
+}
+
+// GetJob returns the status, progress and, once it completes, the result of a job the user started
+//
+// GET /api/v1/jobs/{id}
+func (c *Client) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	r := newRequest(http.MethodGet, "/api/v1/jobs/{id}", id)
+	var out Job
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAuditLogs returns audit entries matching the query filters
+//
+// Requires the audit:read permission.
+//
+// GET /api/v1/audit
+func (c *Client) GetAuditLogs(ctx context.Context, params *GetAuditLogsParams) (*GetAuditLogsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/audit")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetAuditLogsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAuditLogsParams are the optional parameters of GetAuditLogs
+type GetAuditLogsParams struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	UserID     string
+	From       string
+	To         string
+	Limit      int
+	Offset     int
+}
+
+func (p *GetAuditLogsParams) apply(r *request) {
+	r.setQuery("action", p.Action)
+	r.setQuery("entity_type", p.EntityType)
+	r.setQuery("entity_id", p.EntityID)
+	r.setQuery("user_id", p.UserID)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+}
+
+// ExportAuditLogs downloads audit entries matching the query filters as CSV (default) or JSON
+//
+// Requires the audit:read permission.
+//
+// GET /api/v1/audit/export
+func (c *Client) ExportAuditLogs(ctx context.Context, params *ExportAuditLogsParams) ([]AuditLog, error) {
+	r := newRequest(http.MethodGet, "/api/v1/audit/export")
+	if params != nil {
+		params.apply(r)
+	}
+	var out []AuditLog
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// ExportAuditLogsParams are the optional parameters of ExportAuditLogs
+type ExportAuditLogsParams struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	UserID     string
+	From       string
+	To         string
+	Format     string
+}
+
+func (p *ExportAuditLogsParams) apply(r *request) {
+	r.setQuery("action", p.Action)
+	r.setQuery("entity_type", p.EntityType)
+	r.setQuery("entity_id", p.EntityID)
+	r.setQuery("user_id", p.UserID)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("format", p.Format)
+}
+
+// GetChannels returns all notification channels
+//
+// Requires the notification:manage permission.
+//
+// GET /api/v1/notifications/channels
+func (c *Client) GetChannels(ctx context.Context) ([]NotificationChannel, error) {
+	r := newRequest(http.MethodGet, "/api/v1/notifications/channels")
+	var out []NotificationChannel
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// CreateChannel creates a new notification channel
+//
+// Requires the notification:manage permission.
+//
+// POST /api/v1/notifications/channels
+func (c *Client) CreateChannel(ctx context.Context, body NotificationChannelRequest) (*NotificationChannel, error) {
+	r := newRequest(http.MethodPost, "/api/v1/notifications/channels")
+	r.body = body
+	var out NotificationChannel
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateChannel updates a notification channel
+//
+// Requires the notification:manage permission.
+//
+// PUT /api/v1/notifications/channels/{id}
+func (c *Client) UpdateChannel(ctx context.Context, id uuid.UUID, body NotificationChannelRequest) (*UpdateChannelResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/notifications/channels/{id}", id)
+	r.body = body
+	var out UpdateChannelResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteChannel deletes a notification channel
+//
+// Requires the notification:manage permission.
+//
+// DELETE /api/v1/notifications/channels/{id}
+func (c *Client) DeleteChannel(ctx context.Context, id uuid.UUID) (*DeleteChannelResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/notifications/channels/{id}", id)
+	var out DeleteChannelResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TestChannel sends a test notification through a channel
+//
+// Requires the notification:manage permission.
+//
+// POST /api/v1/notifications/channels/{id}/test
+func (c *Client) TestChannel(ctx context.Context, id uuid.UUID) (*TestChannelResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/notifications/channels/{id}/test", id)
+	var out TestChannelResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDeliveries returns the notification delivery log
+//
+// Requires the notification:manage permission.
+//
+// GET /api/v1/notifications/deliveries
+func (c *Client) GetDeliveries(ctx context.Context, params *GetDeliveriesParams) ([]NotificationDelivery, error) {
+	r := newRequest(http.MethodGet, "/api/v1/notifications/deliveries")
+	if params != nil {
+		params.apply(r)
+	}
+	var out []NotificationDelivery
+	err := c.do(ctx, r, &out)
+	return out, err
+}
+
+// GetDeliveriesParams are the optional parameters of GetDeliveries
+type GetDeliveriesParams struct {
+	AlertID string
+	Status  string
+	Event   string
+	Limit   int
+}
+
+func (p *GetDeliveriesParams) apply(r *request) {
+	r.setQuery("alert_id", p.AlertID)
+	r.setQuery("status", p.Status)
+	r.setQuery("event", p.Event)
+	r.setQuery("limit", p.Limit)
+}
+
+// RetryDelivery immediately re-attempts a failed delivery
+//
+// Requires the notification:manage permission.
+//
+// POST /api/v1/notifications/deliveries/{id}/retry
+func (c *Client) RetryDelivery(ctx context.Context, id uuid.UUID) (*NotificationDelivery, error) {
+	r := newRequest(http.MethodPost, "/api/v1/notifications/deliveries/{id}/retry", id)
+	var out NotificationDelivery
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RedeliverDelivery sends a sent or failed delivery again as a new delivery with the same payload
+//
+// Requires the notification:manage permission.
+//
+// POST /api/v1/notifications/deliveries/{id}/redeliver
+func (c *Client) RedeliverDelivery(ctx context.Context, id uuid.UUID) (*NotificationDelivery, error) {
+	r := newRequest(http.MethodPost, "/api/v1/notifications/deliveries/{id}/redeliver", id)
+	var out NotificationDelivery
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPreferences returns the user's notification preferences
+//
+// GET /api/v1/notification-preferences
+func (c *Client) GetPreferences(ctx context.Context) (*NotificationPreference, error) {
+	r := newRequest(http.MethodGet, "/api/v1/notification-preferences")
+	var out NotificationPreference
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePreferences sets which alert severities the user is emailed straight away or sent in the
+// daily digest, their quiet hours and whether they get the digest
+//
+// PUT /api/v1/notification-preferences
+func (c *Client) UpdatePreferences(ctx context.Context, body NotificationPreferenceRequest) (*NotificationPreference, error) {
+	r := newRequest(http.MethodPut, "/api/v1/notification-preferences")
+	r.body = body
+	var out NotificationPreference
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePreferences stops the user being emailed alerts and digests
+//
+// DELETE /api/v1/notification-preferences
+func (c *Client) DeletePreferences(ctx context.Context) (*DeletePreferencesResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/notification-preferences")
+	var out DeletePreferencesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDigest returns what the user's next daily digest would report on so far
+//
+// GET /api/v1/notification-preferences/digest
+func (c *Client) GetDigest(ctx context.Context) (*NotificationDigest, error) {
+	r := newRequest(http.MethodGet, "/api/v1/notification-preferences/digest")
+	var out NotificationDigest
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBatchRuns returns a page of batch runs, newest first by default
+//
+// Requires the system:manage permission.
+//
+// GET /api/v1/batch/runs
+func (c *Client) GetBatchRuns(ctx context.Context, params *GetBatchRunsParams) (*GetBatchRunsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/batch/runs")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetBatchRunsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBatchRunsParams are the optional parameters of GetBatchRuns
+type GetBatchRunsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of started_at, business_date; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To       string
+	Pipeline string
+	Status   string
+	Source   string
+}
+
+func (p *GetBatchRunsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("pipeline", p.Pipeline)
+	r.setQuery("status", p.Status)
+	r.setQuery("source", p.Source)
+}
+
+// StartBatchRun starts the end-of-day batch and returns the run with its jobs pending; poll
+// GetBatchRun for progress. Only one run may be in progress at a time.
+//
+// Requires the system:manage permission.
+//
+// POST /api/v1/batch/runs
+func (c *Client) StartBatchRun(ctx context.Context) (*BatchRun, error) {
+	r := newRequest(http.MethodPost, "/api/v1/batch/runs")
+	var out BatchRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBatchRun returns a batch run with the status, attempts and output of each of its jobs
+//
+// Requires the system:manage permission.
+//
+// GET /api/v1/batch/runs/{id}
+func (c *Client) GetBatchRun(ctx context.Context, id uuid.UUID) (*BatchRun, error) {
+	r := newRequest(http.MethodGet, "/api/v1/batch/runs/{id}", id)
+	var out BatchRun
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLogLevel returns the minimum level currently logged
+//
+// Requires the system:manage permission.
+//
+// GET /api/v1/admin/log-level
+func (c *Client) GetLogLevel(ctx context.Context) (*GetLogLevelResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/log-level")
+	var out GetLogLevelResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetLogLevel changes the minimum level logged without a restart. The change applies to this
+// instance only and lasts until it restarts.
+//
+// Requires the system:manage permission.
+//
+// PUT /api/v1/admin/log-level
+func (c *Client) SetLogLevel(ctx context.Context, body LogLevelRequest) (*SetLogLevelResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/log-level")
+	r.body = body
+	var out SetLogLevelResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMockStatus returns whether mock data is being generated, the profile it is generated with and
+// the universes and scenarios available
+//
+// Requires the system:manage permission.
+//
+// GET /api/v1/dev/mock
+func (c *Client) GetMockStatus(ctx context.Context) (*MockStatus, error) {
+	r := newRequest(http.MethodGet, "/api/v1/dev/mock")
+	var out MockStatus
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartMock resumes mock data generation
+//
+// Requires the system:manage permission.
+//
+// POST /api/v1/dev/mock/start
+func (c *Client) StartMock(ctx context.Context) (*MockStatus, error) {
+	r := newRequest(http.MethodPost, "/api/v1/dev/mock/start")
+	var out MockStatus
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StopMock pauses mock data generation; scenarios can still be injected
+//
+// Requires the system:manage permission.
+//
+// POST /api/v1/dev/mock/stop
+func (c *Client) StopMock(ctx context.Context) (*MockStatus, error) {
+	r := newRequest(http.MethodPost, "/api/v1/dev/mock/stop")
+	var out MockStatus
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateMockProfile changes the symbol universe, tick intervals, alert probability or AML threshold
+// of the generator. Only the settings given change.
+//
+// Requires the system:manage permission.
+//
+// PUT /api/v1/dev/mock/profile
+func (c *Client) UpdateMockProfile(ctx context.Context, body MockProfileRequest) (*MockStatus, error) {
+	r := newRequest(http.MethodPut, "/api/v1/dev/mock/profile")
+	r.body = body
+	var out MockStatus
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InjectMockScenario produces a flash crash, AML structuring burst or alert storm at once
+//
+// Requires the system:manage permission.
+//
+// POST /api/v1/dev/mock/scenarios
+func (c *Client) InjectMockScenario(ctx context.Context, body MockScenarioRequest) (*MockScenarioResult, error) {
+	r := newRequest(http.MethodPost, "/api/v1/dev/mock/scenarios")
+	r.body = body
+	var out MockScenarioResult
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsers lists users, filtered by role, active status, registration date and ?search= on the
+// email or name. Administrators in a tenant see only its users.
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// GET /api/v1/admin/users
+func (c *Client) GetUsers(ctx context.Context, params *GetUsersParams) (*GetUsersResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/users")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetUsersResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsersParams are the optional parameters of GetUsers
+type GetUsersParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of created_at, email, last_name; prefix it with - to sort in descending
+	// order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To       string
+	Role     string
+	IsActive string
+	TenantID string
+	Search   string
+}
+
+func (p *GetUsersParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+	r.setQuery("role", p.Role)
+	r.setQuery("is_active", p.IsActive)
+	r.setQuery("tenant_id", p.TenantID)
+	r.setQuery("search", p.Search)
+}
+
+// GetUser returns a single user
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// GET /api/v1/admin/users/{id}
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/users/{id}", id)
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRole changes a user's role
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// PUT /api/v1/admin/users/{id}/role
+func (c *Client) UpdateRole(ctx context.Context, id uuid.UUID, body UpdateRoleRequest) (*UpdateRoleResponse, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/users/{id}/role", id)
+	r.body = body
+	var out UpdateRoleResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Deactivate disables a user account and signs the user out
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// POST /api/v1/admin/users/{id}/deactivate
+func (c *Client) Deactivate(ctx context.Context, id uuid.UUID) (*DeactivateResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/users/{id}/deactivate", id)
+	var out DeactivateResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Reactivate re-enables a user account
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// POST /api/v1/admin/users/{id}/reactivate
+func (c *Client) Reactivate(ctx context.Context, id uuid.UUID) (*ReactivateResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/users/{id}/reactivate", id)
+	var out ReactivateResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ForcePasswordReset sets a temporary password the user must change before logging in again. The
+// temporary password is returned once and never stored in plain text or audited.
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// POST /api/v1/admin/users/{id}/reset-password
+func (c *Client) ForcePasswordReset(ctx context.Context, id uuid.UUID) (*ForcePasswordResetResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/users/{id}/reset-password", id)
+	var out ForcePasswordResetResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AssignUserTenant moves a user, with the portfolios they own, into a tenant or, with a null
+// tenant_id, out of any
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// PUT /api/v1/admin/users/{id}/tenant
+func (c *Client) AssignUserTenant(ctx context.Context, id uuid.UUID, body TenantAssignmentRequest) (*User, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/users/{id}/tenant", id)
+	r.body = body
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportPersonalData returns, as a JSON download, the personal data held about a user, deleted
+// accounts included, for a data subject access request
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// GET /api/v1/admin/users/{id}/personal-data
+func (c *Client) ExportPersonalData(ctx context.Context, id uuid.UUID) (*PersonalDataExport, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/users/{id}/personal-data", id)
+	var out PersonalDataExport
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Anonymize replaces the name and email of a deactivated or deleted account with placeholders and
+// removes its sessions, KYC profile and API keys. It cannot be undone.
+//
+// Requires the system:manage and user:manage permissions. Administrators only.
+//
+// POST /api/v1/admin/users/{id}/anonymize
+func (c *Client) Anonymize(ctx context.Context, id uuid.UUID) (*AnonymizeResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/users/{id}/anonymize", id)
+	var out AnonymizeResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRetentionPolicies lists how long alerts, transactions and audit entries are kept and after how
+// long closed user accounts are anonymized
+//
+// Requires the system:manage permission. Administrators only.
+//
+// GET /api/v1/admin/retention/policies
+func (c *Client) GetRetentionPolicies(ctx context.Context) (*GetRetentionPoliciesResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/retention/policies")
+	var out GetRetentionPoliciesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRetentionPolicy changes the retention period of an entity, or turns its policy off.
+// Policies span tenants, so only platform administrators may change them.
+//
+// Requires the system:manage permission. Administrators only.
+//
+// PUT /api/v1/admin/retention/policies/{entity}
+func (c *Client) UpdateRetentionPolicy(ctx context.Context, entity string, body RetentionPolicyRequest) (*RetentionPolicy, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/retention/policies/{entity}", entity)
+	r.body = body
+	var out RetentionPolicy
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RunRetentionPolicies applies the retention policies now rather than at the next purge interval
+//
+// Requires the system:manage permission. Administrators only.
+//
+// POST /api/v1/admin/retention/run
+func (c *Client) RunRetentionPolicies(ctx context.Context) (*RunRetentionPoliciesResponse, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/retention/run")
+	var out RunRetentionPoliciesResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenants returns a page of the tenants
+//
+// Requires the system:manage permission. Administrators only.
+//
+// GET /api/v1/admin/tenants
+func (c *Client) GetTenants(ctx context.Context, params *GetTenantsParams) (*GetTenantsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/tenants")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetTenantsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenantsParams are the optional parameters of GetTenants
+type GetTenantsParams struct {
+	// Page size, at most 1000
+	Limit int
+	// Number of results to skip
+	Offset int
+	// Field to sort by, one of name, created_at; prefix it with - to sort in descending order
+	Sort  string
+	Order string
+	// RFC3339 time or YYYY-MM-DD date
+	From string
+	// RFC3339 time or YYYY-MM-DD date
+	To string
+}
+
+func (p *GetTenantsParams) apply(r *request) {
+	r.setQuery("limit", p.Limit)
+	r.setQuery("offset", p.Offset)
+	r.setQuery("sort", p.Sort)
+	r.setQuery("order", p.Order)
+	r.setQuery("from", p.From)
+	r.setQuery("to", p.To)
+}
+
+// CreateTenant creates a tenant. With encrypt_pii set, the names of its users are stored encrypted
+// with a data key of its own, which needs TENANT_MASTER_KEY.
+//
+// Requires the system:manage permission. Administrators only.
+//
+// POST /api/v1/admin/tenants
+func (c *Client) CreateTenant(ctx context.Context, body TenantRequest) (*Tenant, error) {
+	r := newRequest(http.MethodPost, "/api/v1/admin/tenants")
+	r.body = body
+	var out Tenant
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTenant returns a tenant to the platform operator's administrators or the tenant's own
+//
+// Requires the system:manage permission. Administrators only.
+//
+// GET /api/v1/admin/tenants/{id}
+func (c *Client) GetTenant(ctx context.Context, id uuid.UUID) (*Tenant, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/tenants/{id}", id)
+	var out Tenant
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTenant renames a tenant, replaces its settings and turns PII encryption on or off,
+// re-encrypting or decrypting its users' names
+//
+// Requires the system:manage permission. Administrators only.
+//
+// PUT /api/v1/admin/tenants/{id}
+func (c *Client) UpdateTenant(ctx context.Context, id uuid.UUID, body TenantRequest) (*Tenant, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/tenants/{id}", id)
+	r.body = body
+	var out Tenant
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTenantSettings replaces the risk thresholds new portfolios of a tenant start with and the
+// AML monitoring settings its transactions are checked with
+//
+// Requires the system:manage permission. Administrators only.
+//
+// PUT /api/v1/admin/tenants/{id}/settings
+func (c *Client) UpdateTenantSettings(ctx context.Context, id uuid.UUID, body TenantSettings) (*Tenant, error) {
+	r := newRequest(http.MethodPut, "/api/v1/admin/tenants/{id}/settings", id)
+	r.body = body
+	var out Tenant
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConnections returns the clients connected to this instance, oldest first: who they are, where
+// they connect from, what they subscribed to and when they were last heard from. ?user_id= keeps
+// one user's.
+//
+// Requires the system:manage permission. Administrators only.
+//
+// GET /api/v1/admin/ws/connections
+func (c *Client) GetConnections(ctx context.Context, params *GetConnectionsParams) (*GetConnectionsResponse, error) {
+	r := newRequest(http.MethodGet, "/api/v1/admin/ws/connections")
+	if params != nil {
+		params.apply(r)
+	}
+	var out GetConnectionsResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConnectionsParams are the optional parameters of GetConnections
+type GetConnectionsParams struct {
+	UserID string
+}
+
+func (p *GetConnectionsParams) apply(r *request) {
+	r.setQuery("user_id", p.UserID)
+}
+
+// DisconnectConnection closes a client connected to this instance. A WebSocket client is sent the
+// reason in a policy violation close frame; it may reconnect unless its sessions are revoked too.
+//
+// Requires the system:manage permission. Administrators only.
+//
+// DELETE /api/v1/admin/ws/connections/{id}
+func (c *Client) DisconnectConnection(ctx context.Context, id string, body DisconnectRequest) (*DisconnectConnectionResponse, error) {
+	r := newRequest(http.MethodDelete, "/api/v1/admin/ws/connections/{id}", id)
+	r.body = body
+	var out DisconnectConnectionResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,3844 @@
+// Code generated by cmd/openapi. DO NOT EDIT.
+
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// AMLOverrides are the transaction monitoring settings a tenant sets; nil fields keep the
+// MONITORING_* configuration
+type AMLOverrides struct {
+	Rules            []string `json:"rules,omitempty"`
+	LargeAmount      *float64 `json:"large_amount,omitempty"`
+	VelocityLimit    *int     `json:"velocity_limit,omitempty"`
+	StructuringCount *int     `json:"structuring_count,omitempty"`
+	OutlierZScore    *float64 `json:"outlier_z_score,omitempty"`
+	ReviewScore      *int     `json:"review_score,omitempty"`
+}
+
+// AMLSweepItem is a transaction a sweep flagged for review that was not flagged when last evaluated
+type AMLSweepItem struct {
+	TransactionID   uuid.UUID       `json:"transaction_id,omitempty"`
+	PortfolioID     uuid.UUID       `json:"portfolio_id,omitempty"`
+	TransactionType string          `json:"transaction_type,omitempty"`
+	Symbol          string          `json:"symbol,omitempty"`
+	Amount          decimal.Decimal `json:"amount,omitempty"`
+	CreatedAt       time.Time       `json:"created_at,omitempty"`
+	RiskScore       int             `json:"risk_score,omitempty"`
+	// Nil when it was never evaluated
+	PreviousRiskScore *int     `json:"previous_risk_score,omitempty"`
+	Flags             []string `json:"flags,omitempty"`
+	// Flags the previous evaluation did not raise
+	NewFlags     []string  `json:"new_flags,omitempty"`
+	EvaluationID uuid.UUID `json:"evaluation_id,omitempty"`
+}
+
+// AMLSweepResult summarizes a retrospective sweep of transaction monitoring over past transactions
+type AMLSweepResult struct {
+	Days                  int        `json:"days,omitempty"`
+	PortfolioID           *uuid.UUID `json:"portfolio_id,omitempty"`
+	Rules                 []string   `json:"rules,omitempty"`
+	PortfoliosEvaluated   int        `json:"portfolios_evaluated,omitempty"`
+	TransactionsEvaluated int        `json:"transactions_evaluated,omitempty"`
+	// Transactions requiring review, newly or not
+	Flagged int `json:"flagged,omitempty"`
+	// Transactions that required review and no longer do
+	Cleared      int            `json:"cleared,omitempty"`
+	NewlyFlagged []AMLSweepItem `json:"newly_flagged,omitempty"`
+	StartedAt    time.Time      `json:"started_at,omitempty"`
+	CompletedAt  time.Time      `json:"completed_at,omitempty"`
+}
+
+// APIKey authenticates an external system such as an OMS or data pipeline. The key acts as its
+// owner, limited to its scopes, so it never holds more permissions than the owner's role grants.
+// Only a hash of the key is stored; the key itself is shown once when minted.
+type APIKey struct {
+	ID   uuid.UUID `json:"id,omitempty"`
+	Name string    `json:"name,omitempty"`
+	// Leading characters, to tell keys apart
+	Prefix string `json:"prefix,omitempty"`
+	// Owner the key acts as
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	Scopes []string  `json:"scopes,omitempty"`
+	// Requests per minute
+	RateLimit  int        `json:"rate_limit,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+type AcknowledgeAlertResponse struct {
+	Message string `json:"message"`
+	Data    Alert  `json:"data"`
+}
+
+type AcknowledgePortfolioAlertsResponse struct {
+	Message      string      `json:"message"`
+	Acknowledged int         `json:"acknowledged"`
+	AlertIDs     []uuid.UUID `json:"alert_ids"`
+}
+
+type AddCaseNoteRequest struct {
+	Body string `json:"body,omitempty"`
+}
+
+type AddCaseTransactionsRequest struct {
+	TransactionIDs []string `json:"transaction_ids,omitempty"`
+}
+
+type AddSupervisorRequest struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// AdjustmentValues are the fields of a record a corporate action changes; unchanged fields are nil
+type AdjustmentValues struct {
+	Symbol            string           `json:"symbol,omitempty"`
+	Quantity          *decimal.Decimal `json:"quantity,omitempty"`
+	RemainingQuantity *decimal.Decimal `json:"remaining_quantity,omitempty"`
+	AveragePrice      *decimal.Decimal `json:"average_price,omitempty"`
+	CurrentPrice      *decimal.Decimal `json:"current_price,omitempty"`
+	CostPrice         *decimal.Decimal `json:"cost_price,omitempty"`
+	Price             *decimal.Decimal `json:"price,omitempty"`
+	FilledQuantity    *decimal.Decimal `json:"filled_quantity,omitempty"`
+	AverageFillPrice  *decimal.Decimal `json:"average_fill_price,omitempty"`
+	StopLoss          *decimal.Decimal `json:"stop_loss,omitempty"`
+	TakeProfit        *decimal.Decimal `json:"take_profit,omitempty"`
+	Amount            *decimal.Decimal `json:"amount,omitempty"`
+	// Of a dividend
+	Currency  string             `json:"currency,omitempty"`
+	Positions []SnapshotPosition `json:"positions,omitempty"`
+}
+
+type Alert struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// Set by the database from the portfolio's tenant
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+	// RISK_BREACH, COMPLIANCE_VIOLATION, SUSPICIOUS_ACTIVITY
+	AlertType string `json:"alert_type,omitempty"`
+	// LOW, MEDIUM, HIGH, CRITICAL
+	Severity    string `json:"severity,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// VAR_CALCULATOR, POSITION_LIMIT_CHECKER, AML_CHECKER, etc.
+	Source string `json:"source,omitempty"`
+	// ACTIVE, SUPPRESSED, ACKNOWLEDGED, ESCALATED, RESOLVED, DISMISSED
+	Status string `json:"status,omitempty"`
+	// Details of what triggered the alert
+	TriggeredBy    map[string]interface{} `json:"triggered_by,omitempty"`
+	Resolution     string                 `json:"resolution,omitempty"`
+	AcknowledgedBy *uuid.UUID             `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time             `json:"acknowledged_at,omitempty"`
+	ResolvedBy     *uuid.UUID             `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+	// Escalation tiers reached so far while unacknowledged, under EscalationPolicyID
+	EscalationLevel    int        `json:"escalation_level,omitempty"`
+	EscalationPolicyID *uuid.UUID `json:"escalation_policy_id,omitempty"`
+	// Suppression window the alert was raised in, which tagged it SUPPRESSED instead of ACTIVE
+	SuppressionID *uuid.UUID `json:"suppression_id,omitempty"`
+	// Raised by the database with each change
+	Version     int64             `json:"version,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+	DeletedAt   *time.Time        `json:"deleted_at,omitempty"`
+	Portfolio   *Portfolio        `json:"portfolio,omitempty"`
+	Escalations []AlertEscalation `json:"escalations,omitempty"`
+}
+
+// AlertChangeRequest names the version of an alert a change was made against, when If-Match is not
+// sent, with the resolution of an alert being resolved
+type AlertChangeRequest struct {
+	Version    *int64 `json:"version,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// AlertCounts is the number of active alerts, in total and by severity
+type AlertCounts struct {
+	Total      int64            `json:"total,omitempty"`
+	BySeverity map[string]int64 `json:"by_severity,omitempty"`
+}
+
+// AlertEscalation records an alert reaching a tier of its escalation policy and who was notified
+type AlertEscalation struct {
+	ID       uuid.UUID `json:"id,omitempty"`
+	AlertID  uuid.UUID `json:"alert_id,omitempty"`
+	PolicyID uuid.UUID `json:"policy_id,omitempty"`
+	// 1-based
+	Tier       int         `json:"tier,omitempty"`
+	TargetType string      `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID  `json:"target_id,omitempty"`
+	Role       string      `json:"role,omitempty"`
+	Recipients []uuid.UUID `json:"recipients,omitempty"`
+	// Why nobody was notified, e.g. an empty rota
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// AlertSuppression silences the alerts of a portfolio, a compliance rule or an alert source from
+// StartsAt until EndsAt, e.g. the expected breaches of a planned rebalance. Each set field narrows
+// the window, and a window without a portfolio covers every portfolio. Cancelling a window ends it
+// early and keeps it for the record.
+type AlertSuppression struct {
+	ID          uuid.UUID  `json:"id,omitempty"`
+	PortfolioID *uuid.UUID `json:"portfolio_id,omitempty"`
+	// Compliance rule whose breach alerts are suppressed
+	RuleID      *uuid.UUID `json:"rule_id,omitempty"`
+	Source      string     `json:"source,omitempty"`
+	StartsAt    time.Time  `json:"starts_at,omitempty"`
+	EndsAt      time.Time  `json:"ends_at,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CancelledBy *uuid.UUID `json:"cancelled_by,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+// AlertSuppressionRequest opens a suppression window. At least one of the portfolio, rule and
+// source must be given.
+type AlertSuppressionRequest struct {
+	PortfolioID *uuid.UUID `json:"portfolio_id,omitempty"`
+	// Compliance rule
+	RuleID *uuid.UUID `json:"rule_id,omitempty"`
+	// Alert source, e.g. DRAWDOWN_MONITOR
+	Source string `json:"source,omitempty"`
+	// Now when omitted
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   time.Time  `json:"ends_at"`
+	Reason   string     `json:"reason"`
+}
+
+// AllocationDrift compares the weight held in a symbol or asset class with its target
+type AllocationDrift struct {
+	Kind          string  `json:"kind,omitempty"`
+	Key           string  `json:"key,omitempty"`
+	TargetWeight  float64 `json:"target_weight,omitempty"`
+	CurrentWeight float64 `json:"current_weight,omitempty"`
+	// Current less target weight
+	Drift       float64 `json:"drift,omitempty"`
+	Tolerance   float64 `json:"tolerance,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	TargetValue float64 `json:"target_value,omitempty"`
+	Breached    bool    `json:"breached,omitempty"`
+}
+
+// AllocationDriftResult contains a portfolio's drift from its target allocation and the trades
+// suggested to rebalance the targets breached
+type AllocationDriftResult struct {
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	// Positions and cash
+	TotalValue float64           `json:"total_value,omitempty"`
+	Cash       float64           `json:"cash,omitempty"`
+	MaxDrift   float64           `json:"max_drift,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Targets    []AllocationDrift `json:"targets,omitempty"`
+	Trades     []RebalanceTrade  `json:"trades,omitempty"`
+	Warnings   []string          `json:"warnings,omitempty"`
+	Breaches   []string          `json:"breaches,omitempty"`
+}
+
+// AmendmentDecision approves or rejects a pending amendment
+type AmendmentDecision struct {
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// AmendmentRequest asks for a completed transaction to be corrected or cancelled. Fields left out
+// of an AMEND keep the transaction's values; a CANCEL takes none.
+type AmendmentRequest struct {
+	Action   string           `json:"action"`
+	Reason   string           `json:"reason"`
+	Symbol   *string          `json:"symbol,omitempty"`
+	Quantity *decimal.Decimal `json:"quantity,omitempty"`
+	Price    *decimal.Decimal `json:"price,omitempty"`
+	// DEPOSIT and WITHDRAWAL; trades are quantity times price
+	Amount     *decimal.Decimal `json:"amount,omitempty"`
+	Currency   *string          `json:"currency,omitempty"`
+	ExecutedAt *time.Time       `json:"executed_at,omitempty"`
+}
+
+// AmendmentValues are the fields of a transaction an amendment changes; unchanged fields are nil
+type AmendmentValues struct {
+	Symbol     *string          `json:"symbol,omitempty"`
+	Quantity   *decimal.Decimal `json:"quantity,omitempty"`
+	Price      *decimal.Decimal `json:"price,omitempty"`
+	Amount     *decimal.Decimal `json:"amount,omitempty"`
+	Currency   *string          `json:"currency,omitempty"`
+	ExecutedAt *time.Time       `json:"executed_at,omitempty"`
+	Status     string           `json:"status,omitempty"`
+}
+
+type AnonymizeResponse struct {
+	Message string `json:"message"`
+	Data    User   `json:"data"`
+}
+
+type ApproveTransactionRequest struct {
+	// APPROVED or REJECTED
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type ApproveTransactionResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type AssignCaseRequest struct {
+	AssigneeID string `json:"assignee_id,omitempty"`
+}
+
+type AssignCaseResponse struct {
+	Message string `json:"message"`
+	Data    Case   `json:"data"`
+}
+
+// AuditLog records who changed what, with snapshots of the entity before and after the change
+type AuditLog struct {
+	ID        uuid.UUID  `json:"id,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	UserEmail string     `json:"user_email,omitempty"`
+	UserRole  string     `json:"user_role,omitempty"`
+	// e.g. transaction.status_update, alert.acknowledge
+	Action string `json:"action,omitempty"`
+	// portfolio, transaction, alert, compliance_rule, request...
+	EntityType string                 `json:"entity_type,omitempty"`
+	EntityID   string                 `json:"entity_id,omitempty"`
+	Before     map[string]interface{} `json:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	CreatedAt  time.Time              `json:"created_at,omitempty"`
+}
+
+// BacktestObservation pairs a VaR forecast with the P&L realized over the following period. VaR is
+// a positive loss amount; a loss larger than VaR is an exception.
+type BacktestObservation struct {
+	Date        time.Time `json:"date,omitempty"`
+	VaR         float64   `json:"var,omitempty"`
+	PnL         float64   `json:"pnl,omitempty"`
+	IsException bool      `json:"is_exception,omitempty"`
+}
+
+// BacktestReport is a VaR backtest for one portfolio
+type BacktestReport struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id,omitempty"`
+	From        time.Time       `json:"from,omitempty"`
+	To          time.Time       `json:"to,omitempty"`
+	Result      *BacktestResult `json:"result,omitempty"`
+	Warnings    []string        `json:"warnings,omitempty"`
+}
+
+// BacktestResult contains the outcome of a VaR backtest
+type BacktestResult struct {
+	Confidence          float64     `json:"confidence,omitempty"`
+	Observations        int         `json:"observations,omitempty"`
+	Exceptions          int         `json:"exceptions,omitempty"`
+	ExpectedExceptions  float64     `json:"expected_exceptions,omitempty"`
+	ExceptionRate       float64     `json:"exception_rate,omitempty"`
+	Kupiec              *TestResult `json:"kupiec,omitempty"`
+	Christoffersen      *TestResult `json:"christoffersen,omitempty"`
+	ConditionalCoverage *TestResult `json:"conditional_coverage,omitempty"`
+	// P(exceptions <= observed) if the model is correct
+	CumulativeProbability float64               `json:"cumulative_probability,omitempty"`
+	TrafficLight          string                `json:"traffic_light,omitempty"`
+	Series                []BacktestObservation `json:"series,omitempty"`
+}
+
+// BatchJobRun is the outcome of one job in a batch run
+type BatchJobRun struct {
+	ID    uuid.UUID `json:"id,omitempty"`
+	RunID uuid.UUID `json:"run_id,omitempty"`
+	Name  string    `json:"name,omitempty"`
+	// Order the pipeline lists the job in
+	Position  int      `json:"position,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Attempts  int      `json:"attempts,omitempty"`
+	// Summary of what the job did
+	Output string `json:"output,omitempty"`
+	// Error of the last attempt
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BatchRun is one execution of a batch pipeline. It fails if any of its jobs did not succeed.
+type BatchRun struct {
+	ID           uuid.UUID `json:"id,omitempty"`
+	Pipeline     string    `json:"pipeline,omitempty"`
+	BusinessDate time.Time `json:"business_date,omitempty"`
+	// schedule, manual
+	Source string `json:"source,omitempty"`
+	// RUNNING, SUCCEEDED, FAILED
+	Status      string        `json:"status,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	TriggeredBy *uuid.UUID    `json:"triggered_by,omitempty"`
+	StartedAt   time.Time     `json:"started_at,omitempty"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+	Jobs        []BatchJobRun `json:"jobs,omitempty"`
+}
+
+// BenchmarkPrice is the daily closing level of a benchmark index or ETF. The row for the current
+// day is overwritten by each price feed batch, so it holds the latest level until the day ends.
+type BenchmarkPrice struct {
+	ID     uuid.UUID       `json:"id,omitempty"`
+	Symbol string          `json:"symbol,omitempty"`
+	Date   time.Time       `json:"date,omitempty"`
+	Close  decimal.Decimal `json:"close,omitempty"`
+	// FEED or MANUAL
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// BenchmarkPriceInput is one daily close of a benchmark, dated YYYY-MM-DD
+type BenchmarkPriceInput struct {
+	Date  string          `json:"date,omitempty"`
+	Close decimal.Decimal `json:"close,omitempty"`
+}
+
+// BondAnalytics is the interest rate risk of one bond position. Market value and DV01 are in the
+// portfolio currency; DV01 is the loss from a one basis point rise in yield.
+type BondAnalytics struct {
+	PositionID  uuid.UUID `json:"position_id,omitempty"`
+	Symbol      string    `json:"symbol,omitempty"`
+	MarketValue float64   `json:"market_value,omitempty"`
+	// Share of the portfolio's bond market value
+	Weight          float64 `json:"weight,omitempty"`
+	CreditRating    string  `json:"credit_rating,omitempty"`
+	YearsToMaturity float64 `json:"years_to_maturity,omitempty"`
+	// Annual, compounded at the coupon frequency
+	YieldToMaturity  float64 `json:"yield_to_maturity,omitempty"`
+	MacaulayDuration float64 `json:"macaulay_duration,omitempty"`
+	ModifiedDuration float64 `json:"modified_duration,omitempty"`
+	Convexity        float64 `json:"convexity,omitempty"`
+	DV01             float64 `json:"dv01,omitempty"`
+}
+
+// BondReference is the reference data a bond held in positions is priced and analysed from. Prices
+// of the bond's positions are per unit of FaceValue, in the position currency.
+type BondReference struct {
+	Symbol string `json:"symbol,omitempty"`
+	// Annual, as a fraction of face value
+	CouponRate decimal.Decimal `json:"coupon_rate,omitempty"`
+	// Payments per year: 0 (zero coupon), 1, 2, 4 or 12
+	CouponFrequency int       `json:"coupon_frequency,omitempty"`
+	MaturityDate    time.Time `json:"maturity_date,omitempty"`
+	// Per unit held
+	FaceValue    decimal.Decimal `json:"face_value,omitempty"`
+	CreditRating string          `json:"credit_rating,omitempty"`
+	Issuer       string          `json:"issuer,omitempty"`
+	UpdatedBy    *uuid.UUID      `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time       `json:"updated_at,omitempty"`
+}
+
+// BondReferenceRequest sets the terms of a bond
+type BondReferenceRequest struct {
+	CouponRate decimal.Decimal `json:"coupon_rate,omitempty"`
+	// Defaults to 2, semi-annual
+	CouponFrequency *int `json:"coupon_frequency,omitempty"`
+	// YYYY-MM-DD
+	MaturityDate string `json:"maturity_date,omitempty"`
+	// Defaults to 100
+	FaceValue    decimal.Decimal `json:"face_value,omitempty"`
+	CreditRating string          `json:"credit_rating,omitempty"`
+	Issuer       string          `json:"issuer,omitempty"`
+}
+
+// BulkAlertRequest applies one action to many alerts
+type BulkAlertRequest struct {
+	// acknowledge, resolve or dismiss
+	Action   string   `json:"action,omitempty"`
+	AlertIDs []string `json:"alert_ids,omitempty"`
+	// Resolution or dismissal reason
+	Resolution string `json:"resolution,omitempty"`
+	// Versions the alerts were read at, by alert ID. Every alert needs one unless the request sends
+	// If-Match: *, which changes the alerts left out whatever their version.
+	Versions map[string]int64 `json:"versions,omitempty"`
+}
+
+// BulkAlertResult is the outcome of a bulk action for one alert
+type BulkAlertResult struct {
+	AlertID string `json:"alert_id,omitempty"`
+	Success bool   `json:"success,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// PRECONDITION_REQUIRED or VERSION_CONFLICT for version failures
+	Code string `json:"code,omitempty"`
+}
+
+type BulkAlertsResponse struct {
+	Action    string            `json:"action"`
+	Results   []BulkAlertResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+type CalculateConcentrationResponse struct {
+	PortfolioID   string              `json:"portfolio_id"`
+	Concentration ConcentrationResult `json:"concentration"`
+	CalculatedAt  time.Time           `json:"calculated_at"`
+}
+
+// Case is a suspicious activity investigation that may end in a Suspicious Activity Report filing
+type Case struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	CaseNumber  string    `json:"case_number,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	// OPEN, INVESTIGATING, FILED, DISMISSED
+	Status string `json:"status,omitempty"`
+	// LOW, MEDIUM, HIGH, CRITICAL
+	Priority string `json:"priority,omitempty"`
+	// Alert the case was escalated from
+	AlertID    *uuid.UUID `json:"alert_id,omitempty"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	// SAR narrative; required before filing
+	Narrative string `json:"narrative,omitempty"`
+	// Reference issued by the regulator on filing
+	FilingReference string         `json:"filing_reference,omitempty"`
+	FiledBy         *uuid.UUID     `json:"filed_by,omitempty"`
+	FiledAt         *time.Time     `json:"filed_at,omitempty"`
+	DismissedBy     *uuid.UUID     `json:"dismissed_by,omitempty"`
+	DismissedAt     *time.Time     `json:"dismissed_at,omitempty"`
+	DismissalReason string         `json:"dismissal_reason,omitempty"`
+	CreatedAt       time.Time      `json:"created_at,omitempty"`
+	UpdatedAt       time.Time      `json:"updated_at,omitempty"`
+	Assignee        *User          `json:"assignee,omitempty"`
+	Alert           *Alert         `json:"alert,omitempty"`
+	Transactions    []Transaction  `json:"transactions,omitempty"`
+	Notes           []CaseNote     `json:"notes,omitempty"`
+	Evidence        []CaseEvidence `json:"evidence,omitempty"`
+}
+
+// CaseEvidence is a file attached to a case. The content is stored with the record and only
+// returned through the download endpoint.
+type CaseEvidence struct {
+	ID          uuid.UUID  `json:"id,omitempty"`
+	CaseID      uuid.UUID  `json:"case_id,omitempty"`
+	FileName    string     `json:"file_name,omitempty"`
+	ContentType string     `json:"content_type,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	Sha256      string     `json:"sha256,omitempty"`
+	Description string     `json:"description,omitempty"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+}
+
+// CaseNote is an entry on a case timeline: an investigator's note or a system record of a change
+type CaseNote struct {
+	ID       uuid.UUID  `json:"id,omitempty"`
+	CaseID   uuid.UUID  `json:"case_id,omitempty"`
+	AuthorID *uuid.UUID `json:"author_id,omitempty"`
+	// NOTE, ESCALATION, STATUS_CHANGE, ASSIGNMENT, EVIDENCE, TRANSACTION
+	Kind      string    `json:"kind,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Author    *User     `json:"author,omitempty"`
+}
+
+// CashLedgerEntry is one movement of a portfolio's cash balance. Entries are never changed, so the
+// ledger of a portfolio sums to its cash balance.
+type CashLedgerEntry struct {
+	ID            uuid.UUID  `json:"id,omitempty"`
+	PortfolioID   uuid.UUID  `json:"portfolio_id,omitempty"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	EntryType     string     `json:"entry_type,omitempty"`
+	// Signed, in the portfolio currency
+	Amount decimal.Decimal `json:"amount,omitempty"`
+	// In the portfolio currency
+	BalanceAfter decimal.Decimal `json:"balance_after,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	CreatedBy    *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt    time.Time       `json:"created_at,omitempty"`
+}
+
+// CashSummary is a portfolio's cash balance and how much of it is free to spend
+type CashSummary struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+	Balance     decimal.Decimal `json:"balance,omitempty"`
+	// Pending BUY and WITHDRAWAL amounts
+	PendingDebits decimal.Decimal `json:"pending_debits,omitempty"`
+	Available     decimal.Decimal `json:"available,omitempty"`
+}
+
+// ChainExposure is the crypto value settling on one chain; UNKNOWN for assets without reference
+// data
+type ChainExposure struct {
+	Chain       string  `json:"chain,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	// Share of the portfolio's crypto value
+	Weight    float64 `json:"weight,omitempty"`
+	Positions int     `json:"positions,omitempty"`
+}
+
+// ChangePasswordRequest replaces a user's password, including a temporary one set by an
+// administrator
+type ChangePasswordRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ChangePasswordResponse struct {
+	Message string `json:"message"`
+}
+
+type CheckAMLResponse struct {
+	TransactionID uuid.UUID               `json:"transaction_id"`
+	Status        string                  `json:"status"`
+	RiskScore     int                     `json:"risk_score"`
+	Flags         []string                `json:"flags"`
+	Checks        []string                `json:"checks"`
+	Screenings    []ScreeningResult       `json:"screenings"`
+	Counterparty  CounterpartyCheckResult `json:"counterparty"`
+	Notes         string                  `json:"notes"`
+}
+
+type CheckComplianceResponse struct {
+	PortfolioID     string                   `json:"portfolio_id"`
+	ComplianceScore int                      `json:"compliance_score"`
+	Status          string                   `json:"status"`
+	Checks          []map[string]interface{} `json:"checks"`
+}
+
+type CheckPositionLimitsResponse struct {
+	PortfolioID    string                   `json:"portfolio_id"`
+	Status         string                   `json:"status"`
+	LimitThreshold float64                  `json:"limit_threshold"`
+	Positions      []map[string]interface{} `json:"positions"`
+}
+
+type CheckResponse struct {
+	Status       string                    `json:"status"`
+	Service      string                    `json:"service"`
+	Dependencies CheckResponseDependencies `json:"dependencies"`
+}
+
+type CheckResponseDependencies struct {
+	Postgres map[string]interface{} `json:"postgres"`
+	Redis    RedisStatus            `json:"redis"`
+}
+
+// ClassificationRun summarizes a reclassification of positions
+type ClassificationRun struct {
+	// Positions with a calculated class
+	Classified int `json:"classified,omitempty"`
+	// Positions whose effective class changed
+	Changed int `json:"changed,omitempty"`
+	// Positions whose symbol has no market data
+	Unclassified int `json:"unclassified,omitempty"`
+}
+
+type ClearLiquidityOverrideResponse struct {
+	Message string            `json:"message"`
+	Data    PositionLiquidity `json:"data"`
+}
+
+// ComplianceRule is an admin-defined rule evaluated against portfolios or transactions
+type ComplianceRule struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	// PORTFOLIO, TRANSACTION
+	Scope string `json:"scope,omitempty"`
+	// MAX_POSITION_WEIGHT, AMOUNT, etc.
+	Metric string `json:"metric,omitempty"`
+	// Metric argument such as an asset type or symbol
+	Parameter string `json:"parameter,omitempty"`
+	// GT, GTE, LT, LTE, EQ, NEQ
+	Operator  string          `json:"operator,omitempty"`
+	Threshold decimal.Decimal `json:"threshold,omitempty"`
+	// LOW, MEDIUM, HIGH, CRITICAL
+	Severity string `json:"severity,omitempty"`
+	// Nil applies the rule to every portfolio
+	PortfolioID *uuid.UUID `json:"portfolio_id,omitempty"`
+	IsActive    bool       `json:"is_active,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+type ComplianceRuleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Scope       string   `json:"scope"`
+	Metric      string   `json:"metric"`
+	Parameter   string   `json:"parameter,omitempty"`
+	Operator    string   `json:"operator"`
+	Threshold   *float64 `json:"threshold"`
+	Severity    string   `json:"severity"`
+	PortfolioID string   `json:"portfolio_id,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// ConcentrationBreach is a concentration limit the portfolio exceeds
+type ConcentrationBreach struct {
+	Type      string  `json:"type,omitempty"`
+	Subject   string  `json:"subject,omitempty"`
+	Severity  string  `json:"severity,omitempty"`
+	Message   string  `json:"message,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// ConcentrationLimits are the thresholds a portfolio's concentration is checked against, as
+// fractions
+type ConcentrationLimits struct {
+	MaxHhi            float64 `json:"max_hhi,omitempty"`
+	MaxSingleAsset    float64 `json:"max_single_asset,omitempty"`
+	MaxSectorExposure float64 `json:"max_sector_exposure,omitempty"`
+}
+
+// ConcentrationResult contains the concentration analysis for a portfolio
+type ConcentrationResult struct {
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	TotalValue    float64   `json:"total_value,omitempty"`
+	PositionCount int       `json:"position_count,omitempty"`
+	Hhi           float64   `json:"hhi,omitempty"`
+	// 1/HHI: equally weighted positions with the same concentration
+	EffectivePositions float64               `json:"effective_positions,omitempty"`
+	TopN               int                   `json:"top_n,omitempty"`
+	TopNWeight         float64               `json:"top_n_weight,omitempty"`
+	TopPositions       []PositionWeight      `json:"top_positions,omitempty"`
+	Sectors            []ExposureBreakdown   `json:"sectors,omitempty"`
+	AssetClasses       []ExposureBreakdown   `json:"asset_classes,omitempty"`
+	Limits             *ConcentrationLimits  `json:"limits,omitempty"`
+	Breaches           []ConcentrationBreach `json:"breaches,omitempty"`
+	Status             string                `json:"status,omitempty"`
+}
+
+// ConnectionInfo describes a connected client for operators
+type ConnectionInfo struct {
+	ClientID    string    `json:"client_id,omitempty"`
+	UserID      string    `json:"user_id,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	IPAddress   string    `json:"ip_address,omitempty"`
+	Transport   string    `json:"transport,omitempty"`
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+	// Last pong or message received, or heartbeat sent on an event stream
+	LastSeenAt     time.Time              `json:"last_seen_at,omitempty"`
+	Subscriptions  map[string]interface{} `json:"subscriptions,omitempty"`
+	QueuedMessages int                    `json:"queued_messages,omitempty"`
+}
+
+// CorporateAction is a split, dividend or symbol change of a security, applied to every portfolio
+// holding it on or after its ex-date
+type CorporateAction struct {
+	ID     uuid.UUID `json:"id,omitempty"`
+	Symbol string    `json:"symbol,omitempty"`
+	// SPLIT, CASH_DIVIDEND, STOCK_DIVIDEND, SYMBOL_CHANGE
+	ActionType string          `json:"action_type,omitempty"`
+	ExDate     time.Time       `json:"ex_date,omitempty"`
+	PayDate    *time.Time      `json:"pay_date,omitempty"`
+	RatioFrom  decimal.Decimal `json:"ratio_from,omitempty"`
+	RatioTo    decimal.Decimal `json:"ratio_to,omitempty"`
+	// Per share
+	CashAmount  decimal.Decimal `json:"cash_amount,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+	NewSymbol   string          `json:"new_symbol,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	AppliedAt   *time.Time      `json:"applied_at,omitempty"`
+	// Nil when applied by the end-of-day batch
+	AppliedBy    *uuid.UUID `json:"applied_by,omitempty"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+	RolledBackBy *uuid.UUID `json:"rolled_back_by,omitempty"`
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at,omitempty"`
+}
+
+// CorporateActionAdjustment is one record a corporate action changed, with the values it had before
+// and after, so the action can be previewed and rolled back
+type CorporateActionAdjustment struct {
+	ID          uuid.UUID  `json:"id,omitempty"`
+	ActionID    uuid.UUID  `json:"action_id,omitempty"`
+	PortfolioID *uuid.UUID `json:"portfolio_id,omitempty"`
+	// POSITION, LOT, LOT_CLOSURE, TRANSACTION, SNAPSHOT, DIVIDEND
+	EntityType string `json:"entity_type,omitempty"`
+	// Nil in a preview of a dividend not yet paid
+	EntityID  uuid.UUID         `json:"entity_id,omitempty"`
+	Before    *AdjustmentValues `json:"before,omitempty"`
+	After     *AdjustmentValues `json:"after,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitempty"`
+}
+
+// CorporateActionPlan is what applying an action changes, or changed
+type CorporateActionPlan struct {
+	Action      *CorporateAction            `json:"action,omitempty"`
+	Adjustments []CorporateActionAdjustment `json:"adjustments,omitempty"`
+	// Portfolios affected
+	Portfolios int `json:"portfolios,omitempty"`
+	// Adjustments by entity type
+	Counts map[string]int `json:"counts,omitempty"`
+}
+
+// CorporateActionRequest announces a corporate action. Splits and stock dividends need both ratios,
+// cash dividends a cash amount per share and symbol changes the new symbol.
+type CorporateActionRequest struct {
+	Symbol     string `json:"symbol"`
+	ActionType string `json:"action_type"`
+	// YYYY-MM-DD
+	ExDate string `json:"ex_date"`
+	// YYYY-MM-DD; cash dividends are dated on it when given
+	PayDate    string          `json:"pay_date,omitempty"`
+	RatioFrom  decimal.Decimal `json:"ratio_from,omitempty"`
+	RatioTo    decimal.Decimal `json:"ratio_to,omitempty"`
+	CashAmount decimal.Decimal `json:"cash_amount,omitempty"`
+	// Of a cash dividend; the position's currency when omitted
+	Currency    string `json:"currency,omitempty"`
+	NewSymbol   string `json:"new_symbol,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Counterparty is a firm or individual the platform's portfolios trade with
+type Counterparty struct {
+	ID   uuid.UUID `json:"id,omitempty"`
+	Name string    `json:"name,omitempty"`
+	// Legal Entity Identifier
+	LEI string `json:"lei,omitempty"`
+	// INDIVIDUAL, ENTITY
+	EntityType   string `json:"entity_type,omitempty"`
+	Jurisdiction string `json:"jurisdiction,omitempty"`
+	// LOW, MEDIUM, HIGH, PROHIBITED
+	RiskRating string `json:"risk_rating,omitempty"`
+	// PENDING, VERIFIED, REJECTED, EXPIRED
+	KYCStatus     string     `json:"kyc_status,omitempty"`
+	KYCVerifiedAt *time.Time `json:"kyc_verified_at,omitempty"`
+	KYCExpiresAt  *time.Time `json:"kyc_expires_at,omitempty"`
+	// Zero means no limit
+	ExposureLimit decimal.Decimal `json:"exposure_limit,omitempty"`
+	IsActive      bool            `json:"is_active,omitempty"`
+	Notes         string          `json:"notes,omitempty"`
+	CreatedAt     time.Time       `json:"created_at,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at,omitempty"`
+}
+
+type CounterpartyCheckResult struct {
+	CounterpartyID    string          `json:"counterparty_id,omitempty"`
+	Passed            bool            `json:"passed,omitempty"`
+	Blocked           bool            `json:"blocked,omitempty"`
+	RequiresReview    bool            `json:"requires_review,omitempty"`
+	RiskScore         int             `json:"risk_score,omitempty"`
+	ProjectedExposure decimal.Decimal `json:"projected_exposure,omitempty"`
+	Flags             []string        `json:"flags,omitempty"`
+}
+
+// CounterpartyExposure aggregates a counterparty's trading across all portfolios
+type CounterpartyExposure struct {
+	CounterpartyID   uuid.UUID       `json:"counterparty_id,omitempty"`
+	Name             string          `json:"name,omitempty"`
+	Jurisdiction     string          `json:"jurisdiction,omitempty"`
+	RiskRating       string          `json:"risk_rating,omitempty"`
+	KYCStatus        string          `json:"kyc_status,omitempty"`
+	GrossExposure    decimal.Decimal `json:"gross_exposure,omitempty"`
+	BuyVolume        decimal.Decimal `json:"buy_volume,omitempty"`
+	SellVolume       decimal.Decimal `json:"sell_volume,omitempty"`
+	NetExposure      decimal.Decimal `json:"net_exposure,omitempty"`
+	TransactionCount int64           `json:"transaction_count,omitempty"`
+	PortfolioCount   int64           `json:"portfolio_count,omitempty"`
+	ExposureLimit    decimal.Decimal `json:"exposure_limit,omitempty"`
+	// Gross exposure / limit; zero when there is no limit
+	Utilization       float64    `json:"utilization,omitempty"`
+	LimitBreached     bool       `json:"limit_breached,omitempty"`
+	LastTransactionAt *time.Time `json:"last_transaction_at,omitempty"`
+}
+
+type CounterpartyRequest struct {
+	Name         string `json:"name"`
+	LEI          string `json:"lei,omitempty"`
+	EntityType   string `json:"entity_type,omitempty"`
+	Jurisdiction string `json:"jurisdiction"`
+	RiskRating   string `json:"risk_rating,omitempty"`
+	KYCStatus    string `json:"kyc_status,omitempty"`
+	// RFC3339
+	KYCExpiresAt  string   `json:"kyc_expires_at,omitempty"`
+	ExposureLimit *float64 `json:"exposure_limit,omitempty"`
+	IsActive      *bool    `json:"is_active,omitempty"`
+	Notes         string   `json:"notes,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name,omitempty"`
+	// Owner the key acts as; defaults to the caller
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Scopes []string   `json:"scopes,omitempty"`
+	// Requests per minute; defaults to API_KEY_DEFAULT_RATE_LIMIT
+	RateLimit int        `json:"rate_limit,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse carries the key itself, which cannot be retrieved again
+type CreateAPIKeyResponse struct {
+	Key    string  `json:"key,omitempty"`
+	APIKey *APIKey `json:"api_key,omitempty"`
+}
+
+type CreatePortfolioRequest struct {
+	Name                  string           `json:"name"`
+	Description           string           `json:"description,omitempty"`
+	Currency              string           `json:"currency,omitempty"`
+	Benchmark             string           `json:"benchmark,omitempty"`
+	CustodianAccount      string           `json:"custodian_account,omitempty"`
+	CostBasisMethod       string           `json:"cost_basis_method,omitempty"`
+	CashBalance           *decimal.Decimal `json:"cash_balance,omitempty"`
+	MarginLoan            *decimal.Decimal `json:"margin_loan,omitempty"`
+	MaintenanceMarginRate *decimal.Decimal `json:"maintenance_margin_rate,omitempty"`
+}
+
+type CreateTransactionRequest struct {
+	PortfolioID     string  `json:"portfolio_id"`
+	TransactionType string  `json:"transaction_type"`
+	Symbol          string  `json:"symbol,omitempty"`
+	Quantity        float64 `json:"quantity,omitempty"`
+	Price           float64 `json:"price,omitempty"`
+	// DEPOSIT and WITHDRAWAL; trades are quantity times price
+	Amount     float64 `json:"amount,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	ExecutedAt string  `json:"executed_at,omitempty"`
+	Notes      string  `json:"notes,omitempty"`
+	// STOCK, BOND, CRYPTO, etc., checked against the investment guideline
+	AssetType string `json:"asset_type,omitempty"`
+	// Issue rating of a bond
+	CreditRating        string `json:"credit_rating,omitempty"`
+	CounterpartyID      string `json:"counterparty_id,omitempty"`
+	CounterpartyName    string `json:"counterparty_name,omitempty"`
+	CounterpartyCountry string `json:"counterparty_country,omitempty"`
+}
+
+type CreateTransactionResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// CreditExposure is the bond market value held at one credit rating; NR for unrated bonds
+type CreditExposure struct {
+	Rating      string  `json:"rating,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	// Share of the portfolio's bond market value
+	Weight    float64 `json:"weight,omitempty"`
+	Positions int     `json:"positions,omitempty"`
+}
+
+// CryptoAsset is the reference data of a crypto asset: the chain it settles on and, for a
+// stablecoin, the price it is pegged to in its peg currency
+type CryptoAsset struct {
+	Symbol string `json:"symbol,omitempty"`
+	// BITCOIN, ETHEREUM, SOLANA, ...
+	Chain        string `json:"chain,omitempty"`
+	IsStablecoin bool   `json:"is_stablecoin,omitempty"`
+	PegCurrency  string `json:"peg_currency,omitempty"`
+	// Zero for assets without a peg
+	PegPrice  decimal.Decimal `json:"peg_price,omitempty"`
+	UpdatedBy *uuid.UUID      `json:"updated_by,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at,omitempty"`
+}
+
+// CryptoAssetRequest sets the reference data of a crypto asset
+type CryptoAssetRequest struct {
+	Chain        string `json:"chain,omitempty"`
+	IsStablecoin bool   `json:"is_stablecoin,omitempty"`
+	// Defaults to USD for a stablecoin
+	PegCurrency string `json:"peg_currency,omitempty"`
+	// Defaults to 1 for a stablecoin
+	PegPrice decimal.Decimal `json:"peg_price,omitempty"`
+}
+
+// CryptoCustody is the quantity of a crypto asset a portfolio keeps at one venue: an exchange or
+// custodian, linked to its counterparty record, or one of the firm's own wallets
+type CryptoCustody struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	Symbol      string    `json:"symbol,omitempty"`
+	// EXCHANGE, CUSTODIAN, HOT_WALLET, COLD_WALLET
+	VenueType string `json:"venue_type,omitempty"`
+	VenueName string `json:"venue_name,omitempty"`
+	// Exchange or custodian
+	CounterpartyID *uuid.UUID      `json:"counterparty_id,omitempty"`
+	WalletAddress  string          `json:"wallet_address,omitempty"`
+	Quantity       decimal.Decimal `json:"quantity,omitempty"`
+	UpdatedBy      *uuid.UUID      `json:"updated_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at,omitempty"`
+	UpdatedAt      time.Time       `json:"updated_at,omitempty"`
+	Counterparty   *Counterparty   `json:"counterparty,omitempty"`
+}
+
+// CryptoCustodyInput is the quantity of a crypto asset a portfolio keeps at one venue
+type CryptoCustodyInput struct {
+	Symbol string `json:"symbol,omitempty"`
+	// EXCHANGE, CUSTODIAN, HOT_WALLET or COLD_WALLET
+	VenueType      string          `json:"venue_type,omitempty"`
+	VenueName      string          `json:"venue_name,omitempty"`
+	CounterpartyID *uuid.UUID      `json:"counterparty_id,omitempty"`
+	WalletAddress  string          `json:"wallet_address,omitempty"`
+	Quantity       decimal.Decimal `json:"quantity,omitempty"`
+}
+
+// CryptoPositionRisk is the market and liquidity risk of one crypto position. Volatility is
+// annualized over 24/7 trading and keyed by window (24h, 7d, 30d); a window is null without enough
+// price samples.
+type CryptoPositionRisk struct {
+	PositionID  uuid.UUID `json:"position_id,omitempty"`
+	Symbol      string    `json:"symbol,omitempty"`
+	Chain       string    `json:"chain,omitempty"`
+	Stablecoin  bool      `json:"stablecoin,omitempty"`
+	Quantity    float64   `json:"quantity,omitempty"`
+	Price       float64   `json:"price,omitempty"`
+	MarketValue float64   `json:"market_value,omitempty"`
+	// Share of the portfolio's crypto value
+	Weight          float64             `json:"weight,omitempty"`
+	Volatility      map[string]*float64 `json:"volatility,omitempty"`
+	LiquidityScore  float64             `json:"liquidity_score,omitempty"`
+	LiquidityClass  string              `json:"liquidity_class,omitempty"`
+	DaysToLiquidate float64             `json:"days_to_liquidate,omitempty"`
+}
+
+// CryptoRiskResult contains the market, liquidity, venue and peg risk of a portfolio's crypto
+// assets
+type CryptoRiskResult struct {
+	Timestamp   time.Time `json:"timestamp,omitempty"`
+	CryptoValue float64   `json:"crypto_value,omitempty"`
+	// Value kept at exchanges and custodians
+	CounterpartyHeld float64 `json:"counterparty_held,omitempty"`
+	// Value kept in the firm's own wallets
+	SelfCustodied float64 `json:"self_custodied,omitempty"`
+	// Value at no recorded venue
+	UnallocatedValue float64 `json:"unallocated_value,omitempty"`
+	// Largest share of crypto value at one exchange or custodian
+	LargestVenueShare float64              `json:"largest_venue_share,omitempty"`
+	MaxVenueExposure  float64              `json:"max_venue_exposure,omitempty"`
+	MaxDepeg          float64              `json:"max_depeg,omitempty"`
+	Status            string               `json:"status,omitempty"`
+	Positions         []CryptoPositionRisk `json:"positions,omitempty"`
+	Chains            []ChainExposure      `json:"chains,omitempty"`
+	Venues            []VenueExposure      `json:"venues,omitempty"`
+	Stablecoins       []StablecoinPeg      `json:"stablecoins,omitempty"`
+	Warnings          []string             `json:"warnings,omitempty"`
+	Breaches          []string             `json:"breaches,omitempty"`
+}
+
+// CurrencyExposure is the part of a portfolio held in one currency
+type CurrencyExposure struct {
+	Currency string `json:"currency,omitempty"`
+	// Market value in this currency
+	LocalValue float64 `json:"local_value,omitempty"`
+	// Market value in the base currency
+	BaseValue float64 `json:"base_value,omitempty"`
+	// Base currency units per unit of this currency
+	Rate float64 `json:"rate,omitempty"`
+	// Share of gross exposure
+	Weight    float64 `json:"weight,omitempty"`
+	Positions int     `json:"positions,omitempty"`
+	// No current rate; last converted values were used
+	Stale bool `json:"stale,omitempty"`
+}
+
+// CurrencyExposureResult contains the currency breakdown and FX risk of a portfolio
+type CurrencyExposureResult struct {
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	BaseCurrency  string    `json:"base_currency,omitempty"`
+	GrossExposure float64   `json:"gross_exposure,omitempty"`
+	// Gross exposure outside the base currency
+	ForeignExposure      float64            `json:"foreign_exposure,omitempty"`
+	ForeignExposureRatio float64            `json:"foreign_exposure_ratio,omitempty"`
+	MaxForeignExposure   float64            `json:"max_foreign_exposure,omitempty"`
+	FXVaR95              float64            `json:"fx_var_95,omitempty"`
+	Status               string             `json:"status,omitempty"`
+	Currencies           []CurrencyExposure `json:"currencies,omitempty"`
+	Breaches             []string           `json:"breaches,omitempty"`
+}
+
+type DeactivateResponse struct {
+	Message string `json:"message"`
+	Data    User   `json:"data"`
+}
+
+type DeleteAlertResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteAssetResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteBondResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteChannelResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteCorporateActionResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteCounterpartyResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteEntryResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteExposureLimitResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteGuidelineResponse struct {
+	Message string `json:"message"`
+}
+
+type DeletePolicyResponse struct {
+	Message string `json:"message"`
+}
+
+type DeletePortfolioResponse struct {
+	Message string `json:"message"`
+}
+
+type DeletePortfolioTemplateResponse struct {
+	Message string `json:"message"`
+}
+
+type DeletePreferencesResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteProfileResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteRuleResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteShiftResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteTeamResponse struct {
+	Message string `json:"message"`
+}
+
+type DeleteTransactionResponse struct {
+	Message string `json:"message"`
+}
+
+// DepthLevel is one price level of an order book
+type DepthLevel struct {
+	Price    float64 `json:"price,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Orders   int     `json:"orders,omitempty"`
+}
+
+type DisconnectConnectionResponse struct {
+	Message string `json:"message"`
+}
+
+type DisconnectRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// DrawdownResult contains a portfolio's rolling losses and drawdown against its loss limits
+type DrawdownResult struct {
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	CurrentNav    float64   `json:"current_nav,omitempty"`
+	PreviousClose *NAVPoint `json:"previous_close,omitempty"`
+	WeekStart     *NAVPoint `json:"week_start,omitempty"`
+	PeakNav       float64   `json:"peak_nav,omitempty"`
+	DailyLoss     float64   `json:"daily_loss,omitempty"`
+	WeeklyLoss    float64   `json:"weekly_loss,omitempty"`
+	// Decline of the current NAV from the peak
+	CurrentDrawdown float64 `json:"current_drawdown,omitempty"`
+	// Worst decline over the history
+	MaxDrawdown      float64     `json:"max_drawdown,omitempty"`
+	Limits           *LossLimits `json:"limits,omitempty"`
+	DailyLimitHit    bool        `json:"daily_limit_hit,omitempty"`
+	WeeklyLimitHit   bool        `json:"weekly_limit_hit,omitempty"`
+	DrawdownLimitHit bool        `json:"drawdown_limit_hit,omitempty"`
+	// End-of-day snapshots the figures are based on
+	Snapshots int `json:"snapshots,omitempty"`
+	// SAFE, WARNING, CRITICAL
+	Status   string   `json:"status,omitempty"`
+	Breaches []string `json:"breaches,omitempty"`
+}
+
+// Error is an error of a request, with the path of the field it occurred at
+type Error2 struct {
+	Message string            `json:"message,omitempty"`
+	Path    []json.RawMessage `json:"path,omitempty"`
+}
+
+// ErrorResponse is the body of every error response: the message, a machine-readable code, any
+// details such as the fields that failed validation, and the request ID to quote to support
+type ErrorResponse struct {
+	Error     string          `json:"error,omitempty"`
+	Code      string          `json:"code,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+type EscalateCaseRequest struct {
+	Title          string   `json:"title,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Priority       string   `json:"priority,omitempty"`
+	AlertID        string   `json:"alert_id,omitempty"`
+	TransactionIDs []string `json:"transaction_ids,omitempty"`
+	AssigneeID     string   `json:"assignee_id,omitempty"`
+}
+
+// EscalationPolicy escalates unacknowledged alerts of a severity, and optionally of one alert type,
+// through its tiers in order
+type EscalationPolicy struct {
+	ID       uuid.UUID `json:"id,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	// Empty matches every type
+	AlertType string           `json:"alert_type,omitempty"`
+	Tiers     []EscalationTier `json:"tiers,omitempty"`
+	IsActive  bool             `json:"is_active,omitempty"`
+	CreatedAt time.Time        `json:"created_at,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at,omitempty"`
+}
+
+type EscalationPolicyRequest struct {
+	Name     string `json:"name,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	// Empty covers every alert type
+	AlertType *string          `json:"alert_type,omitempty"`
+	Tiers     []EscalationTier `json:"tiers,omitempty"`
+	IsActive  *bool            `json:"is_active,omitempty"`
+}
+
+// EscalationTier is a step of an escalation policy, reached when an alert is still unacknowledged
+// AfterMinutes after it was raised. TargetID is the team or user for TEAM and USER targets.
+type EscalationTier struct {
+	AfterMinutes int `json:"after_minutes,omitempty"`
+	// TEAM, ROLE, USER
+	TargetType string     `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID `json:"target_id,omitempty"`
+	Role       string     `json:"role,omitempty"`
+}
+
+// ExportJob is an export too large to stream in the response. The file is rendered in the
+// background, stored with the record until ExpiresAt and only returned through the download
+// endpoint, to the user who requested it.
+type ExportJob struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	RequestedBy uuid.UUID `json:"requested_by,omitempty"`
+	// transactions, alerts, risk_history
+	Dataset string `json:"dataset,omitempty"`
+	// csv, xlsx
+	Format string `json:"format,omitempty"`
+	// PROCESSING, COMPLETED, FAILED
+	Status string `json:"status,omitempty"`
+	// Query string the export was requested with
+	Filters     string `json:"filters,omitempty"`
+	RowCount    int64  `json:"row_count,omitempty"`
+	FileName    string `json:"file_name,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	// Why the export failed
+	Message     string     `json:"message,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// ExposureBreakdown is the portfolio's exposure to one sector or asset class
+type ExposureBreakdown struct {
+	Name        string   `json:"name,omitempty"`
+	MarketValue float64  `json:"market_value,omitempty"`
+	Weight      float64  `json:"weight,omitempty"`
+	Symbols     []string `json:"symbols,omitempty"`
+}
+
+// ExposureLimit caps the gross exposure to one symbol summed across every portfolio of a tenant, or
+// of the whole platform when the platform operator sets it without a tenant
+type ExposureLimit struct {
+	ID          uuid.UUID       `json:"id,omitempty"`
+	TenantID    *uuid.UUID      `json:"tenant_id,omitempty"`
+	Symbol      string          `json:"symbol,omitempty"`
+	MaxExposure decimal.Decimal `json:"max_exposure,omitempty"`
+	// Currency MaxExposure is in
+	Currency  string     `json:"currency,omitempty"`
+	Notes     string     `json:"notes,omitempty"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+}
+
+// ExposureLimitRequest sets the firm-wide limit on a symbol
+type ExposureLimitRequest struct {
+	MaxExposure decimal.Decimal `json:"max_exposure,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+	Notes       string          `json:"notes,omitempty"`
+}
+
+// Fill is an execution of part or all of an order
+type Fill struct {
+	ID            uuid.UUID       `json:"id,omitempty"`
+	TransactionID uuid.UUID       `json:"transaction_id,omitempty"`
+	Quantity      decimal.Decimal `json:"quantity,omitempty"`
+	Price         decimal.Decimal `json:"price,omitempty"`
+	Amount        decimal.Decimal `json:"amount,omitempty"`
+	// Venue or broker execution reference
+	ExecutionID string     `json:"execution_id,omitempty"`
+	ExecutedAt  time.Time  `json:"executed_at,omitempty"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+}
+
+// FillRequest records an execution against an order
+type FillRequest struct {
+	Quantity    decimal.Decimal `json:"quantity,omitempty"`
+	Price       decimal.Decimal `json:"price,omitempty"`
+	ExecutionID string          `json:"execution_id,omitempty"`
+	// Defaults to now
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+}
+
+// FirmExposure measures the exposure of every portfolio in the firm against its limit on the
+// symbol, in the limit's currency
+type FirmExposure struct {
+	Limit              *ExposureLimit   `json:"limit,omitempty"`
+	Currency           string           `json:"currency,omitempty"`
+	GrossExposure      decimal.Decimal  `json:"gross_exposure,omitempty"`
+	PortfolioCount     int              `json:"portfolio_count,omitempty"`
+	UtilizationPercent *decimal.Decimal `json:"utilization_percent,omitempty"`
+	// SAFE, WARNING, CRITICAL or NO_LIMIT
+	Status string `json:"status,omitempty"`
+}
+
+// FixedIncomeResult contains the interest rate and credit risk of a portfolio's bonds
+type FixedIncomeResult struct {
+	Timestamp        time.Time `json:"timestamp,omitempty"`
+	PortfolioValue   float64   `json:"portfolio_value,omitempty"`
+	BondMarketValue  float64   `json:"bond_market_value,omitempty"`
+	ModifiedDuration float64   `json:"modified_duration,omitempty"`
+	Convexity        float64   `json:"convexity,omitempty"`
+	DV01             float64   `json:"dv01,omitempty"`
+	// DV01 as a share of portfolio value
+	DV01Ratio float64 `json:"dv01_ratio,omitempty"`
+	MaxDV01   float64 `json:"max_dv01,omitempty"`
+	// Market value rated BBB- or better
+	InvestmentGrade float64          `json:"investment_grade,omitempty"`
+	HighYield       float64          `json:"high_yield,omitempty"`
+	Unrated         float64          `json:"unrated,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	Positions       []BondAnalytics  `json:"positions,omitempty"`
+	CreditExposure  []CreditExposure `json:"credit_exposure,omitempty"`
+	MaturityBuckets []MaturityBucket `json:"maturity_buckets,omitempty"`
+	// BOND positions without reference data
+	MissingReferenceData []string `json:"missing_reference_data,omitempty"`
+	Warnings             []string `json:"warnings,omitempty"`
+	Breaches             []string `json:"breaches,omitempty"`
+}
+
+type ForcePasswordResetResponse struct {
+	Message           string `json:"message"`
+	TemporaryPassword string `json:"temporary_password"`
+	Data              User   `json:"data"`
+}
+
+type GenerateReportRequest struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+type GetActiveAlertsResponse struct {
+	Data []Alert `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetActivityResponse struct {
+	Data []PortfolioActivity `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetAlertsResponse struct {
+	Data []Alert `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetAmendmentsResponse struct {
+	Data []TransactionAmendment `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetAuditLogsResponse struct {
+	Data   []AuditLog `json:"data"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+type GetBatchRunsResponse struct {
+	Data []BatchRun `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetBreaksResponse struct {
+	Data []ReconciliationBreak `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetCasesResponse struct {
+	Data []Case `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetCashLedgerResponse struct {
+	Data []CashLedgerEntry `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetConnectionsResponse struct {
+	Instance    string           `json:"instance"`
+	Connections []ConnectionInfo `json:"connections"`
+	Total       int              `json:"total"`
+}
+
+type GetCorporateActionAdjustmentsResponse struct {
+	Adjustments []CorporateActionAdjustment `json:"adjustments"`
+}
+
+type GetCorporateActionsResponse struct {
+	Data []CorporateAction `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetCounterpartiesResponse struct {
+	Data []Counterparty `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetCryptoRiskResponse struct {
+	PortfolioID  uuid.UUID        `json:"portfolio_id"`
+	CryptoRisk   CryptoRiskResult `json:"crypto_risk"`
+	CalculatedAt time.Time        `json:"calculated_at"`
+}
+
+type GetCurrencyExposureResponse struct {
+	PortfolioID      uuid.UUID              `json:"portfolio_id"`
+	CurrencyExposure CurrencyExposureResult `json:"currency_exposure"`
+	CalculatedAt     time.Time              `json:"calculated_at"`
+}
+
+type GetDeletedResponse struct {
+	Data json.RawMessage `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetDrawdownResponse struct {
+	PortfolioID  uuid.UUID      `json:"portfolio_id"`
+	Drawdown     DrawdownResult `json:"drawdown"`
+	CalculatedAt time.Time      `json:"calculated_at"`
+}
+
+type GetEntriesResponse struct {
+	Data json.RawMessage `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetExposuresResponse struct {
+	Data           []CounterpartyExposure `json:"data"`
+	Total          int                    `json:"total"`
+	LimitsBreached int                    `json:"limits_breached"`
+	CalculatedAt   time.Time              `json:"calculated_at"`
+}
+
+type GetInterestRateRiskResponse struct {
+	PortfolioID      uuid.UUID         `json:"portfolio_id"`
+	InterestRateRisk FixedIncomeResult `json:"interest_rate_risk"`
+	CalculatedAt     time.Time         `json:"calculated_at"`
+}
+
+type GetJobsResponse struct {
+	Data []Job `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetLatestRiskMetricsResponse struct {
+	PortfolioID uuid.UUID    `json:"portfolio_id"`
+	Metrics     []RiskMetric `json:"metrics"`
+}
+
+type GetLeverageResponse struct {
+	PortfolioID  uuid.UUID      `json:"portfolio_id"`
+	Leverage     LeverageResult `json:"leverage"`
+	CalculatedAt time.Time      `json:"calculated_at"`
+}
+
+type GetLimitUtilizationResponse struct {
+	PortfolioID  uuid.UUID          `json:"portfolio_id"`
+	Limits       []LimitUtilization `json:"limits"`
+	CalculatedAt time.Time          `json:"calculated_at"`
+	Errors       map[string]string  `json:"errors,omitempty"`
+}
+
+type GetLogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+type GetLotsResponse struct {
+	Data []PositionLot `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetMarketDepthResponse struct {
+	Data []MarketDepthSnapshot `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetNAVHistoryResponse struct {
+	Data []PortfolioSnapshot `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetPortfolioTemplatesResponse struct {
+	Data []PortfolioTemplate `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetProfilesResponse struct {
+	Data []KYCProfile `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetRealizedGainsResponse struct {
+	Data []LotClosure `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetReportsResponse struct {
+	Data []Report `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetRetentionPoliciesResponse struct {
+	Policies []RetentionPolicy `json:"policies"`
+}
+
+type GetRollupResponse struct {
+	PortfolioID  uuid.UUID              `json:"portfolio_id"`
+	PortfolioIDs []uuid.UUID            `json:"portfolio_ids"`
+	Currency     string                 `json:"currency"`
+	TotalValue   decimal.Decimal        `json:"total_value"`
+	CashBalance  decimal.Decimal        `json:"cash_balance"`
+	MarginLoan   decimal.Decimal        `json:"margin_loan"`
+	Positions    []Position             `json:"positions"`
+	VaR          map[string]interface{} `json:"var"`
+	Limits       []LimitUtilization     `json:"limits"`
+	CalculatedAt time.Time              `json:"calculated_at"`
+	Errors       map[string]string      `json:"errors,omitempty"`
+}
+
+type GetRunsResponse struct {
+	Data []ReconciliationRun `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetSanctionsEntriesResponse struct {
+	Entries []SanctionsEntry `json:"entries"`
+	Total   int64            `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+}
+
+type GetSuppressionsResponse struct {
+	Data []AlertSuppression `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetTeamResponse struct {
+	Team   Team   `json:"team"`
+	OnCall []User `json:"on_call"`
+}
+
+type GetTenantsResponse struct {
+	Data []Tenant `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetTransactionLotsResponse struct {
+	TransactionID uuid.UUID        `json:"transaction_id"`
+	RealizedPnL   *decimal.Decimal `json:"realized_pnl"`
+	Lots          []PositionLot    `json:"lots"`
+	ClosedLots    []LotClosure     `json:"closed_lots"`
+}
+
+type GetTransactionsResponse struct {
+	Data []Transaction `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetUsersResponse struct {
+	Data []User `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type GetVaRContributionsResponse struct {
+	PortfolioID  uuid.UUID             `json:"portfolio_id"`
+	VaR          VaRContributionResult `json:"var"`
+	CalculatedAt time.Time             `json:"calculated_at"`
+}
+
+// Request is a GraphQL request as clients post it
+type GraphqlRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Fields that failed are null in Data and have an entry in
+// Errors.
+type GraphqlResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []Error2        `json:"errors,omitempty"`
+}
+
+// GuidelineBreach is a holding or trade that breaches one guideline of a portfolio's mandate
+type GuidelineBreach struct {
+	Guideline string `json:"guideline,omitempty"`
+	// Empty for portfolio-wide limits
+	Symbol      string `json:"symbol,omitempty"`
+	Description string `json:"description,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Limit       string `json:"limit,omitempty"`
+}
+
+// GuidelineDefinition is the mandate of an investment guideline, without the portfolio it applies
+// to
+type GuidelineDefinition struct {
+	AllowedAssetTypes  []string         `json:"allowed_asset_types,omitempty"`
+	MaxCryptoPercent   *decimal.Decimal `json:"max_crypto_percent,omitempty"`
+	MinCreditRating    string           `json:"min_credit_rating,omitempty"`
+	EsgExcludedSymbols []string         `json:"esg_excluded_symbols,omitempty"`
+	EsgExcludedSectors []string         `json:"esg_excluded_sectors,omitempty"`
+}
+
+// GuidelineEvaluationResult summarizes a guideline evaluation run; only portfolios with breaches
+// are listed
+type GuidelineEvaluationResult struct {
+	PortfoliosEvaluated int                          `json:"portfolios_evaluated,omitempty"`
+	Portfolios          []PortfolioGuidelineBreaches `json:"portfolios,omitempty"`
+	EvaluatedAt         time.Time                    `json:"evaluated_at,omitempty"`
+}
+
+// Hierarchy is a portfolio's place in the hierarchy: the portfolios above it and the tree under it
+type Hierarchy struct {
+	// Top-level portfolio first
+	Ancestors []HierarchyAncestor `json:"ancestors,omitempty"`
+	Tree      *HierarchyNode      `json:"tree,omitempty"`
+}
+
+// HierarchyAncestor is a portfolio above another. Only its ID and name are given when the user
+// cannot access it.
+type HierarchyAncestor struct {
+	ID          uuid.UUID        `json:"id,omitempty"`
+	ParentID    *uuid.UUID       `json:"parent_id,omitempty"`
+	Name        string           `json:"name,omitempty"`
+	Currency    string           `json:"currency,omitempty"`
+	TotalValue  *decimal.Decimal `json:"total_value,omitempty"`
+	CashBalance *decimal.Decimal `json:"cash_balance,omitempty"`
+}
+
+// HierarchyNode is a portfolio with the portfolios under it
+type HierarchyNode struct {
+	ID          uuid.UUID       `json:"id,omitempty"`
+	ParentID    *uuid.UUID      `json:"parent_id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+	TotalValue  decimal.Decimal `json:"total_value,omitempty"`
+	CashBalance decimal.Decimal `json:"cash_balance,omitempty"`
+	// Value with cash of the portfolio and every portfolio under it, in its currency
+	RollupValue decimal.Decimal `json:"rollup_value,omitempty"`
+	Children    []HierarchyNode `json:"children,omitempty"`
+}
+
+// ImportRowError is a rejected row of an import file
+type ImportRowError struct {
+	Line       int    `json:"line,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type ImportSanctionsListResponse struct {
+	Message  string `json:"message"`
+	Source   string `json:"source"`
+	Imported int    `json:"imported"`
+}
+
+// InvestmentGuideline is a portfolio's mandate: what its manager may hold and trade. Each limit is
+// optional; empty lists and nil limits are not enforced.
+type InvestmentGuideline struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// STOCK, BOND, etc.; empty allows all
+	AllowedAssetTypes []string `json:"allowed_asset_types,omitempty"`
+	// % of portfolio in CRYPTO
+	MaxCryptoPercent *decimal.Decimal `json:"max_crypto_percent,omitempty"`
+	// Lowest rating of bonds held, such as BBB-
+	MinCreditRating    string   `json:"min_credit_rating,omitempty"`
+	EsgExcludedSymbols []string `json:"esg_excluded_symbols,omitempty"`
+	// Sectors such as ENERGY
+	EsgExcludedSectors []string   `json:"esg_excluded_sectors,omitempty"`
+	IsActive           bool       `json:"is_active,omitempty"`
+	UpdatedBy          *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt          time.Time  `json:"created_at,omitempty"`
+	UpdatedAt          time.Time  `json:"updated_at,omitempty"`
+}
+
+// InvestmentGuidelineRequest is a portfolio's whole mandate; omitted limits are not enforced
+type InvestmentGuidelineRequest struct {
+	AllowedAssetTypes  []string `json:"allowed_asset_types,omitempty"`
+	MaxCryptoPercent   *float64 `json:"max_crypto_percent,omitempty"`
+	MinCreditRating    string   `json:"min_credit_rating,omitempty"`
+	EsgExcludedSymbols []string `json:"esg_excluded_symbols,omitempty"`
+	EsgExcludedSectors []string `json:"esg_excluded_sectors,omitempty"`
+	IsActive           *bool    `json:"is_active,omitempty"`
+}
+
+// Job is a calculation too slow to run within a request, such as a Monte Carlo VaR or a report. It
+// is queued in Redis and run by a worker, which records its progress and result here until
+// ExpiresAt. Only the user who started a job can see it.
+type Job struct {
+	ID uuid.UUID `json:"id,omitempty"`
+	// var, backtest, report
+	JobType string `json:"job_type,omitempty"`
+	// QUEUED, RUNNING, COMPLETED, FAILED
+	Status string `json:"status,omitempty"`
+	// Percent complete
+	Progress int `json:"progress,omitempty"`
+	// The current step, or why the job failed
+	Message     string                 `json:"message,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	PortfolioID *uuid.UUID             `json:"portfolio_id,omitempty"`
+	RequestedBy uuid.UUID              `json:"requested_by,omitempty"`
+	Attempts    int                    `json:"attempts,omitempty"`
+	CreatedAt   time.Time              `json:"created_at,omitempty"`
+	UpdatedAt   time.Time              `json:"updated_at,omitempty"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time              `json:"expires_at,omitempty"`
+}
+
+// KYCDocument describes an identity document seen during verification. The document itself is kept
+// outside the platform.
+type KYCDocument struct {
+	// PASSPORT, NATIONAL_ID, DRIVERS_LICENSE, PROOF_OF_ADDRESS, INCORPORATION_CERTIFICATE
+	Type string `json:"type,omitempty"`
+	// Document number, masked to its last four characters
+	Reference      string     `json:"reference,omitempty"`
+	IssuingCountry string     `json:"issuing_country,omitempty"`
+	IssuedAt       *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// KYCProfile is the know-your-customer record of a user or counterparty. A verified profile stays
+// current until its next review is due or one of its documents expires.
+type KYCProfile struct {
+	ID uuid.UUID `json:"id,omitempty"`
+	// USER, COUNTERPARTY
+	SubjectType string    `json:"subject_type,omitempty"`
+	SubjectID   uuid.UUID `json:"subject_id,omitempty"`
+	// PENDING, VERIFIED, REJECTED, EXPIRED
+	Status string `json:"status,omitempty"`
+	// LOW, MEDIUM, HIGH; sets the review cycle
+	RiskRating     string        `json:"risk_rating,omitempty"`
+	Documents      []KYCDocument `json:"documents,omitempty"`
+	VerifiedAt     *time.Time    `json:"verified_at,omitempty"`
+	LastReviewedAt *time.Time    `json:"last_reviewed_at,omitempty"`
+	LastReviewedBy *uuid.UUID    `json:"last_reviewed_by,omitempty"`
+	NextReviewAt   *time.Time    `json:"next_review_at,omitempty"`
+	Notes          string        `json:"notes,omitempty"`
+	CreatedAt      time.Time     `json:"created_at,omitempty"`
+	UpdatedAt      time.Time     `json:"updated_at,omitempty"`
+}
+
+type KYCProfileRequest struct {
+	// USER or COUNTERPARTY, on create only
+	SubjectType string `json:"subject_type,omitempty"`
+	SubjectID   string `json:"subject_id,omitempty"`
+	RiskRating  string `json:"risk_rating,omitempty"`
+	// Replaces the profile's documents when given
+	Documents []KYCDocument `json:"documents,omitempty"`
+	Notes     string        `json:"notes,omitempty"`
+}
+
+type KYCReviewRequest struct {
+	// VERIFIED or REJECTED
+	Decision string `json:"decision"`
+	// Re-rates the profile before its next review is scheduled
+	RiskRating string `json:"risk_rating,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// LeverageResult contains the leverage and margin analysis for a portfolio
+type LeverageResult struct {
+	Timestamp     time.Time      `json:"timestamp,omitempty"`
+	Account       *MarginAccount `json:"account,omitempty"`
+	LongExposure  float64        `json:"long_exposure,omitempty"`
+	ShortExposure float64        `json:"short_exposure,omitempty"`
+	GrossExposure float64        `json:"gross_exposure,omitempty"`
+	NetExposure   float64        `json:"net_exposure,omitempty"`
+	Equity        float64        `json:"equity,omitempty"`
+	// Zero when equity is not positive
+	GrossLeverage float64 `json:"gross_leverage,omitempty"`
+	NetLeverage   float64 `json:"net_leverage,omitempty"`
+	MaxLeverage   float64 `json:"max_leverage,omitempty"`
+	// Margin loan as a fraction of long exposure
+	MarginUtilization      float64 `json:"margin_utilization,omitempty"`
+	MaintenanceRequirement float64 `json:"maintenance_requirement,omitempty"`
+	// Equity above the maintenance requirement
+	ExcessLiquidity float64  `json:"excess_liquidity,omitempty"`
+	MarginCall      bool     `json:"margin_call,omitempty"`
+	Status          string   `json:"status,omitempty"`
+	Breaches        []string `json:"breaches,omitempty"`
+}
+
+// LiquidationDay is one day of a liquidation waterfall: what is sold, what it costs and what is
+// left to sell afterwards
+type LiquidationDay struct {
+	Day                    int               `json:"day,omitempty"`
+	SoldValue              float64           `json:"sold_value,omitempty"`
+	MarketImpact           float64           `json:"market_impact,omitempty"`
+	NetProceeds            float64           `json:"net_proceeds,omitempty"`
+	CumulativeSold         float64           `json:"cumulative_sold,omitempty"`
+	CumulativeMarketImpact float64           `json:"cumulative_market_impact,omitempty"`
+	RemainingValue         float64           `json:"remaining_value,omitempty"`
+	PercentLiquidated      float64           `json:"percent_liquidated,omitempty"`
+	Sales                  []LiquidationSale `json:"sales,omitempty"`
+}
+
+// LiquidationPosition is a position in a liquidation schedule with the market it is sold into
+type LiquidationPosition struct {
+	Symbol             string          `json:"symbol,omitempty"`
+	AssetType          string          `json:"asset_type,omitempty"`
+	Quantity           decimal.Decimal `json:"quantity,omitempty"`
+	MarketValue        decimal.Decimal `json:"market_value,omitempty"`
+	AverageDailyVolume float64         `json:"average_daily_volume,omitempty"`
+	BidAskSpread       float64         `json:"bid_ask_spread,omitempty"`
+	// MARKET_DATA, ASSET_TYPE or TIER_ESTIMATE
+	Source string `json:"source,omitempty"`
+}
+
+// LiquidationRemainder is what is left of a position once the waterfall stops
+type LiquidationRemainder struct {
+	Symbol   string  `json:"symbol,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Value    float64 `json:"value,omitempty"`
+}
+
+// LiquidationSale is what is sold of one position on a day of a liquidation
+type LiquidationSale struct {
+	Symbol   string  `json:"symbol,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	// At the current price
+	Value float64 `json:"value,omitempty"`
+	// Cost of the price moving against the sale
+	MarketImpact float64 `json:"market_impact,omitempty"`
+}
+
+// LiquidationSchedule is a portfolio's day-by-day liquidation under normal and stressed
+// participation. Cash needs no selling and is reported alongside.
+type LiquidationSchedule struct {
+	PortfolioID  uuid.UUID             `json:"portfolio_id,omitempty"`
+	Currency     string                `json:"currency,omitempty"`
+	CashBalance  decimal.Decimal       `json:"cash_balance,omitempty"`
+	Positions    []LiquidationPosition `json:"positions,omitempty"`
+	Normal       *LiquidationWaterfall `json:"normal,omitempty"`
+	Stressed     *LiquidationWaterfall `json:"stressed,omitempty"`
+	CalculatedAt time.Time             `json:"calculated_at,omitempty"`
+}
+
+// LiquidationWaterfall is a day-by-day liquidation of positions at one participation rate
+type LiquidationWaterfall struct {
+	ParticipationRate float64 `json:"participation_rate,omitempty"`
+	TotalValue        float64 `json:"total_value,omitempty"`
+	// Days until everything is sold, 0 when it is not sold within the horizon
+	DaysToLiquidate   int     `json:"days_to_liquidate,omitempty"`
+	FullyLiquidated   bool    `json:"fully_liquidated,omitempty"`
+	TotalMarketImpact float64 `json:"total_market_impact,omitempty"`
+	// Of the value sold
+	MarketImpactPct   float64                `json:"market_impact_pct,omitempty"`
+	Days              []LiquidationDay       `json:"days,omitempty"`
+	Unliquidated      []LiquidationRemainder `json:"unliquidated,omitempty"`
+	UnliquidatedValue float64                `json:"unliquidated_value,omitempty"`
+	// Days simulated at most
+	LiquidationHorizon int `json:"liquidation_horizon,omitempty"`
+}
+
+// LiquidityAdjustedVaR is a VaR extended by the time and cost of liquidating the positions
+type LiquidityAdjustedVaR struct {
+	VaR         float64 `json:"var,omitempty"`
+	TimeHorizon int     `json:"time_horizon,omitempty"`
+	// Added by liquidation horizons longer than the VaR horizon
+	HorizonCost float64 `json:"horizon_cost,omitempty"`
+	SpreadCost  float64 `json:"spread_cost,omitempty"`
+	Value       float64 `json:"value,omitempty"`
+	// Value / VaR
+	AdjustmentRatio float64                `json:"adjustment_ratio,omitempty"`
+	Positions       []PositionLiquidityVaR `json:"positions,omitempty"`
+}
+
+// LiquidityBreakdown is the value of a portfolio in each liquidity tier
+type LiquidityBreakdown struct {
+	// Includes cash
+	HIGH   decimal.Decimal `json:"HIGH,omitempty"`
+	MEDIUM decimal.Decimal `json:"MEDIUM,omitempty"`
+	LOW    decimal.Decimal `json:"LOW,omitempty"`
+}
+
+// LiquidityResponse is a portfolio's liquidity as CalculateLiquidityRisk returns and caches it
+type LiquidityResponse struct {
+	PortfolioID          uuid.UUID             `json:"portfolio_id,omitempty"`
+	LiquidityRatio       decimal.Decimal       `json:"liquidity_ratio,omitempty"`
+	LiquidityScore       string                `json:"liquidity_score,omitempty"`
+	DaysToLiquidate      float64               `json:"days_to_liquidate,omitempty"`
+	RiskAssessment       string                `json:"risk_assessment,omitempty"`
+	Status               string                `json:"status,omitempty"`
+	CalculatedAt         time.Time             `json:"calculated_at,omitempty"`
+	LiquidityAdjustedVaR *LiquidityAdjustedVaR `json:"liquidity_adjusted_var,omitempty"`
+	CashBalance          decimal.Decimal       `json:"cash_balance,omitempty"`
+	Breakdown            *LiquidityBreakdown   `json:"breakdown,omitempty"`
+	// Served from the cache rather than calculated for this request
+	Cached bool `json:"cached,omitempty"`
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level,omitempty"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Access token lifetime in seconds
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+	User      *User `json:"user,omitempty"`
+}
+
+type LogoutResponse struct {
+	Message string `json:"message"`
+}
+
+// LossLimits are the loss limits of a portfolio as fractions of NAV; zero disables a limit
+type LossLimits struct {
+	MaxDailyLoss  float64 `json:"max_daily_loss,omitempty"`
+	MaxWeeklyLoss float64 `json:"max_weekly_loss,omitempty"`
+	MaxDrawdown   float64 `json:"max_drawdown,omitempty"`
+}
+
+// LotClosure is the part of a lot one sale fill closed and the gain it realized. A sale of more
+// than the open lots hold closes the rest without a lot, at the position's average price.
+type LotClosure struct {
+	ID uuid.UUID `json:"id,omitempty"`
+	// Nil when no open lot covered the sale
+	LotID       *uuid.UUID `json:"lot_id,omitempty"`
+	PortfolioID uuid.UUID  `json:"portfolio_id,omitempty"`
+	Symbol      string     `json:"symbol,omitempty"`
+	// The sale
+	TransactionID uuid.UUID `json:"transaction_id,omitempty"`
+	FillID        uuid.UUID `json:"fill_id,omitempty"`
+	// FIFO, LIFO, HIFO
+	Method    string          `json:"method,omitempty"`
+	Quantity  decimal.Decimal `json:"quantity,omitempty"`
+	CostPrice decimal.Decimal `json:"cost_price,omitempty"`
+	SalePrice decimal.Decimal `json:"sale_price,omitempty"`
+	// Converts the sale currency into the portfolio currency
+	FXRate decimal.Decimal `json:"fx_rate,omitempty"`
+	// In the portfolio currency
+	RealizedPnL decimal.Decimal `json:"realized_pnl,omitempty"`
+	// SHORT, LONG; empty without a lot
+	HoldingTerm string     `json:"holding_term,omitempty"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	ClosedAt    time.Time  `json:"closed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+}
+
+// MarginAccount is the cash and borrowing side of a portfolio
+type MarginAccount struct {
+	CashBalance float64 `json:"cash_balance,omitempty"`
+	MarginLoan  float64 `json:"margin_loan,omitempty"`
+	// Equity required as a fraction of gross exposure
+	MaintenanceMarginRate float64 `json:"maintenance_margin_rate,omitempty"`
+}
+
+// MarketDataRequest sets the trading activity of a symbol
+type MarketDataRequest struct {
+	AverageDailyVolume decimal.Decimal `json:"average_daily_volume,omitempty"`
+	BidAskSpread       decimal.Decimal `json:"bid_ask_spread,omitempty"`
+}
+
+// MarketDepthSnapshot is the order book of a symbol as captured from the market data feed, kept so
+// that the depth a liquidity calculation used can be retrieved later. Bids are best first, highest
+// price first, and asks lowest price first. Snapshots are deleted under the market_depth_snapshots
+// retention policy.
+type MarketDepthSnapshot struct {
+	ID     uuid.UUID `json:"id,omitempty"`
+	Symbol string    `json:"symbol,omitempty"`
+	// The feed it was captured from
+	Source   string          `json:"source,omitempty"`
+	Bids     []DepthLevel    `json:"bids,omitempty"`
+	Asks     []DepthLevel    `json:"asks,omitempty"`
+	BestBid  decimal.Decimal `json:"best_bid,omitempty"`
+	BestAsk  decimal.Decimal `json:"best_ask,omitempty"`
+	MidPrice decimal.Decimal `json:"mid_price,omitempty"`
+	// As a fraction of the mid price; zero when a side is empty
+	Spread decimal.Decimal `json:"spread,omitempty"`
+	// Value of all bid levels
+	BidDepth decimal.Decimal `json:"bid_depth,omitempty"`
+	// Value of all ask levels
+	AskDepth   decimal.Decimal `json:"ask_depth,omitempty"`
+	CapturedAt time.Time       `json:"captured_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at,omitempty"`
+}
+
+// MaturityBucket is the bond market value and DV01 maturing within a range of years
+type MaturityBucket struct {
+	Bucket      string  `json:"bucket,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	DV01        float64 `json:"dv01,omitempty"`
+	Positions   int     `json:"positions,omitempty"`
+}
+
+// MetricMarketDepth is the order book depth a stored risk metric was calculated with
+type MetricMarketDepth struct {
+	MetricID     uuid.UUID             `json:"metric_id,omitempty"`
+	PortfolioID  uuid.UUID             `json:"portfolio_id,omitempty"`
+	MetricType   string                `json:"metric_type,omitempty"`
+	CalculatedAt time.Time             `json:"calculated_at,omitempty"`
+	Snapshots    []MarketDepthSnapshot `json:"snapshots,omitempty"`
+	// Symbols whose snapshot has since been deleted under its retention policy
+	Purged []string `json:"purged,omitempty"`
+}
+
+// MockProfileRequest changes the settings it sets. Symbols replace the universe's symbols; a
+// universe alone selects all of its symbols.
+type MockProfileRequest struct {
+	Universe                   *string  `json:"universe,omitempty"`
+	Symbols                    []string `json:"symbols,omitempty"`
+	TransactionIntervalSeconds *int     `json:"transaction_interval_seconds,omitempty"`
+	RiskMetricIntervalSeconds  *int     `json:"risk_metric_interval_seconds,omitempty"`
+	AlertIntervalSeconds       *int     `json:"alert_interval_seconds,omitempty"`
+	AlertProbability           *float64 `json:"alert_probability,omitempty"`
+	AMLThreshold               *float64 `json:"aml_threshold,omitempty"`
+}
+
+// MockScenarioRequest selects a scenario and its parameters; unset parameters take their defaults
+type MockScenarioRequest struct {
+	Scenario string `json:"scenario"`
+	// Structuring: the oldest portfolio when unset; alert storm: every portfolio
+	PortfolioID *uuid.UUID `json:"portfolio_id,omitempty"`
+	// Flash crash: the profile's symbols when unset
+	Symbols []string `json:"symbols,omitempty"`
+	// Flash crash: fraction the prices fall by, 0.2 by default
+	Drop float64 `json:"drop,omitempty"`
+	// Structuring: 5 transactions by default; alert storm: 50 alerts
+	Count int `json:"count,omitempty"`
+}
+
+// MockScenarioResult is what an injected scenario produced
+type MockScenarioResult struct {
+	Scenario     string             `json:"scenario,omitempty"`
+	Prices       map[string]float64 `json:"prices,omitempty"`
+	Transactions int                `json:"transactions,omitempty"`
+	Alerts       int                `json:"alerts,omitempty"`
+}
+
+// MockStatus is the generator's running state and profile, with the tick intervals in seconds
+type MockStatus struct {
+	Running                    bool     `json:"running,omitempty"`
+	Universe                   string   `json:"universe,omitempty"`
+	Symbols                    []string `json:"symbols,omitempty"`
+	TransactionIntervalSeconds int      `json:"transaction_interval_seconds,omitempty"`
+	RiskMetricIntervalSeconds  int      `json:"risk_metric_interval_seconds,omitempty"`
+	AlertIntervalSeconds       int      `json:"alert_interval_seconds,omitempty"`
+	AlertProbability           float64  `json:"alert_probability,omitempty"`
+	AMLThreshold               float64  `json:"aml_threshold,omitempty"`
+	Universes                  []string `json:"universes,omitempty"`
+	Scenarios                  []string `json:"scenarios,omitempty"`
+}
+
+// MonitoringRuleHit is a transaction monitoring rule a transaction triggered
+type MonitoringRuleHit struct {
+	Rule   string `json:"rule,omitempty"`
+	Score  int    `json:"score,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NAVPoint is a portfolio's net asset value at the close of a day
+type NAVPoint struct {
+	Date time.Time `json:"date,omitempty"`
+	Nav  float64   `json:"nav,omitempty"`
+}
+
+// NotificationChannel is a destination that alerts of the configured severities are delivered to
+type NotificationChannel struct {
+	ID   uuid.UUID `json:"id,omitempty"`
+	Name string    `json:"name,omitempty"`
+	// EMAIL, SLACK, WEBHOOK
+	Type string `json:"type,omitempty"`
+	// Comma-separated addresses or a webhook URL
+	Target string `json:"target,omitempty"`
+	// Comma-separated, e.g. "HIGH,CRITICAL"
+	Severities string `json:"severities,omitempty"`
+	// Comma-separated: alert.created, alert.resolved
+	Events string `json:"events,omitempty"`
+	// Channel-specific options such as webhook headers and secret
+	Config    map[string]interface{} `json:"config,omitempty"`
+	IsActive  bool                   `json:"is_active,omitempty"`
+	CreatedAt time.Time              `json:"created_at,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at,omitempty"`
+}
+
+type NotificationChannelRequest struct {
+	Name       string `json:"name,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Target     string `json:"target,omitempty"`
+	Severities string `json:"severities,omitempty"`
+	// Comma-separated: alert.created, alert.resolved
+	Events string `json:"events,omitempty"`
+	// A "secret" signs webhook bodies with HMAC-SHA256
+	Config   map[string]interface{} `json:"config,omitempty"`
+	IsActive *bool                  `json:"is_active,omitempty"`
+}
+
+// NotificationDelivery records each attempt to deliver an alert event to a channel. Payload keeps
+// the alert as it was when the event happened, so retries and redeliveries send what the first
+// attempt did; FAILED deliveries are the dead letters.
+type NotificationDelivery struct {
+	ID           uuid.UUID              `json:"id,omitempty"`
+	AlertID      uuid.UUID              `json:"alert_id,omitempty"`
+	ChannelID    uuid.UUID              `json:"channel_id,omitempty"`
+	Event        string                 `json:"event,omitempty"`
+	Payload      map[string]interface{} `json:"payload,omitempty"`
+	RedeliveryOf *uuid.UUID             `json:"redelivery_of,omitempty"`
+	// PENDING, RETRYING, SENT, FAILED
+	Status        string               `json:"status,omitempty"`
+	Attempts      int                  `json:"attempts,omitempty"`
+	LastError     string               `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time           `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time           `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time            `json:"created_at,omitempty"`
+	UpdatedAt     time.Time            `json:"updated_at,omitempty"`
+	Channel       *NotificationChannel `json:"channel,omitempty"`
+}
+
+// NotificationDigest is what a user's digest reports on: the alerts raised in the period that were
+// not emailed one by one, the risk metrics whose status changed and the risk limits changed
+type NotificationDigest struct {
+	Since            time.Time           `json:"since,omitempty"`
+	Until            time.Time           `json:"until,omitempty"`
+	Alerts           []Alert             `json:"alerts,omitempty"`
+	RiskChanges      []RiskStatusChange  `json:"risk_changes,omitempty"`
+	ThresholdChanges []PortfolioActivity `json:"threshold_changes,omitempty"`
+}
+
+// NotificationPreference is what a user is told about alerts on the portfolios they can see. Users
+// without one are only reached through the notification channels and escalations.
+type NotificationPreference struct {
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	// Severity -> EMAIL and/or DIGEST; none for silence
+	Channels map[string][]string `json:"channels,omitempty"`
+	// HH:MM in Timezone; emails are held for the digest in between
+	QuietHoursStart *string    `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string    `json:"quiet_hours_end,omitempty"`
+	Timezone        string     `json:"timezone,omitempty"`
+	DailyDigest     bool       `json:"daily_digest,omitempty"`
+	LastDigestAt    *time.Time `json:"last_digest_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+}
+
+// NotificationPreferenceRequest replaces a user's notification preferences. Without channels, high
+// and critical alerts are emailed and the rest left to the digest; the digest defaults to on.
+type NotificationPreferenceRequest struct {
+	Channels        map[string][]string `json:"channels,omitempty"`
+	QuietHoursStart *string             `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string             `json:"quiet_hours_end,omitempty"`
+	Timezone        string              `json:"timezone,omitempty"`
+	DailyDigest     *bool               `json:"daily_digest,omitempty"`
+}
+
+// OnCallShift puts a user on call for a team from StartsAt until EndsAt. Overlapping shifts put
+// several users on call at once.
+type OnCallShift struct {
+	ID        uuid.UUID `json:"id,omitempty"`
+	TeamID    uuid.UUID `json:"team_id,omitempty"`
+	UserID    uuid.UUID `json:"user_id,omitempty"`
+	StartsAt  time.Time `json:"starts_at,omitempty"`
+	EndsAt    time.Time `json:"ends_at,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	User      *User     `json:"user,omitempty"`
+}
+
+type OnCallShiftRequest struct {
+	UserID   uuid.UUID `json:"user_id,omitempty"`
+	StartsAt time.Time `json:"starts_at,omitempty"`
+	EndsAt   time.Time `json:"ends_at,omitempty"`
+}
+
+// ParentRequest places a portfolio under a parent portfolio, or at the top of the hierarchy when
+// parent_id is null
+type ParentRequest struct {
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// PerformanceObservation pairs a day's portfolio return with the benchmark return over the same
+// day, both as fractions
+type PerformanceObservation struct {
+	Date            time.Time `json:"date,omitempty"`
+	PortfolioReturn float64   `json:"portfolio_return,omitempty"`
+	BenchmarkReturn float64   `json:"benchmark_return,omitempty"`
+	ExcessReturn    float64   `json:"excess_return,omitempty"`
+}
+
+// PerformanceReport compares a portfolio's daily returns, from its P&L history, with its benchmark
+type PerformanceReport struct {
+	PortfolioID uuid.UUID          `json:"portfolio_id,omitempty"`
+	Benchmark   string             `json:"benchmark,omitempty"`
+	Window      string             `json:"window,omitempty"`
+	From        time.Time          `json:"from,omitempty"`
+	To          time.Time          `json:"to,omitempty"`
+	Result      *PerformanceResult `json:"result,omitempty"`
+	Warnings    []string           `json:"warnings,omitempty"`
+}
+
+// PerformanceResult compares portfolio returns with a benchmark. Returns are compounded over the
+// observations; tracking error and the information ratio are annualized from daily figures. Beta,
+// tracking error and the information ratio need at least two observations and are nil otherwise, as
+// is beta when the benchmark did not move.
+type PerformanceResult struct {
+	Observations     int                      `json:"observations,omitempty"`
+	PortfolioReturn  float64                  `json:"portfolio_return,omitempty"`
+	BenchmarkReturn  float64                  `json:"benchmark_return,omitempty"`
+	ExcessReturn     float64                  `json:"excess_return,omitempty"`
+	TrackingError    *float64                 `json:"tracking_error,omitempty"`
+	Beta             *float64                 `json:"beta,omitempty"`
+	InformationRatio *float64                 `json:"information_ratio,omitempty"`
+	Series           []PerformanceObservation `json:"series,omitempty"`
+}
+
+// PersonalDataExport bundles the personal data held about a user, for a data subject access request
+type PersonalDataExport struct {
+	GeneratedAt             time.Time                `json:"generated_at,omitempty"`
+	User                    *User                    `json:"user,omitempty"`
+	KYCProfile              *KYCProfile              `json:"kyc_profile,omitempty"`
+	Sessions                []RefreshToken           `json:"sessions,omitempty"`
+	APIKeys                 []APIKey                 `json:"api_keys,omitempty"`
+	TeamMemberships         []TeamMember             `json:"team_memberships,omitempty"`
+	NotificationPreferences []NotificationPreference `json:"notification_preferences,omitempty"`
+	Supervisions            []PortfolioSupervisor    `json:"supervisions,omitempty"`
+	// Owned, including deleted ones
+	Portfolios []Portfolio `json:"portfolios,omitempty"`
+	// In the owned portfolios
+	Transactions []Transaction `json:"transactions,omitempty"`
+	// Actions the user took
+	AuditTrail []AuditLog `json:"audit_trail,omitempty"`
+}
+
+// PnLHistory is a daily profit and loss snapshot for a portfolio. The row for the current day is
+// overwritten on each price update, so it always holds the latest intraday figures.
+type PnLHistory struct {
+	ID            uuid.UUID       `json:"id,omitempty"`
+	PortfolioID   uuid.UUID       `json:"portfolio_id,omitempty"`
+	Date          time.Time       `json:"date,omitempty"`
+	MarketValue   decimal.Decimal `json:"market_value,omitempty"`
+	CostBasis     decimal.Decimal `json:"cost_basis,omitempty"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl,omitempty"`
+	// Realized during the day
+	RealizedPnL decimal.Decimal `json:"realized_pnl,omitempty"`
+	// Change in market value since the previous snapshot plus realized P&L
+	DailyPnL  decimal.Decimal `json:"daily_pnl,omitempty"`
+	CreatedAt time.Time       `json:"created_at,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at,omitempty"`
+}
+
+// PnLReport is a portfolio's P&L now and over a period
+type PnLReport struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id,omitempty"`
+	Period        string          `json:"period,omitempty"`
+	From          time.Time       `json:"from,omitempty"`
+	To            time.Time       `json:"to,omitempty"`
+	MarketValue   decimal.Decimal `json:"market_value,omitempty"`
+	CostBasis     decimal.Decimal `json:"cost_basis,omitempty"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl,omitempty"`
+	// Realized during the period
+	RealizedPnL decimal.Decimal `json:"realized_pnl,omitempty"`
+	// Lots closed during the period
+	RealizedLots []LotClosure `json:"realized_lots,omitempty"`
+	// Market value change over the period plus realized P&L
+	PeriodPnL decimal.Decimal `json:"period_pnl,omitempty"`
+	// Percent of the starting market value
+	PeriodReturn decimal.Decimal `json:"period_return,omitempty"`
+	Positions    []PositionPnL   `json:"positions,omitempty"`
+	History      []PnLHistory    `json:"history,omitempty"`
+}
+
+type Portfolio struct {
+	ID     uuid.UUID `json:"id,omitempty"`
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	// Team sharing the portfolio with its members
+	TeamID *uuid.UUID `json:"team_id,omitempty"`
+	// Book or strategy the portfolio rolls up into
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	// Set by the database from the owner's tenant
+	TenantID    *uuid.UUID      `json:"tenant_id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	TotalValue  decimal.Decimal `json:"total_value,omitempty"`
+	Currency    string          `json:"currency,omitempty"`
+	// Index or ETF symbol returns are compared with, such as SPX
+	Benchmark string `json:"benchmark,omitempty"`
+	// Account number the custodian reports the portfolio's positions under, for reconciliation
+	CustodianAccount string `json:"custodian_account,omitempty"`
+	// Margin account. A negative cash balance is a debit; MarginLoan is borrowing against positions.
+	CashBalance decimal.Decimal `json:"cash_balance,omitempty"`
+	MarginLoan  decimal.Decimal `json:"margin_loan,omitempty"`
+	// Equity required as a fraction of gross exposure
+	MaintenanceMarginRate decimal.Decimal `json:"maintenance_margin_rate,omitempty"`
+	// Which open lots a sale closes: FIFO, LIFO or HIFO
+	CostBasisMethod string `json:"cost_basis_method,omitempty"`
+	// Raised by the database with each change to the portfolio's settings or cash, not its value
+	Version   int64     `json:"version,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// Soft deleted, kept until the retention purge
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	User      *User      `json:"user,omitempty"`
+	Team      *Team      `json:"team,omitempty"`
+	Positions []Position `json:"positions,omitempty"`
+}
+
+// PortfolioActivity is an entry of a portfolio's timeline. Entries are written by database triggers
+// on transactions, alerts, risk thresholds and transaction amendments, never by the application.
+type PortfolioActivity struct {
+	ID           uuid.UUID `json:"id,omitempty"`
+	PortfolioID  uuid.UUID `json:"portfolio_id,omitempty"`
+	ActivityType string    `json:"activity_type,omitempty"`
+	// transaction, alert, risk_thresholds, transaction_amendment
+	EntityType string    `json:"entity_type,omitempty"`
+	EntityID   uuid.UUID `json:"entity_id,omitempty"`
+	Summary    string    `json:"summary,omitempty"`
+	// What was recorded, or the statuses or limits before and after
+	Details    map[string]interface{} `json:"details,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at,omitempty"`
+}
+
+// PortfolioExposure is one portfolio's holding of a symbol, valued in the report currency
+type PortfolioExposure struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	TeamID      *uuid.UUID      `json:"team_id,omitempty"`
+	Quantity    decimal.Decimal `json:"quantity,omitempty"`
+	MarketValue decimal.Decimal `json:"market_value,omitempty"`
+}
+
+// PortfolioFromTemplateRequest names a portfolio created from a template or cloned from another
+// portfolio; the template's or source's name and description are used when empty
+type PortfolioFromTemplateRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PortfolioGuidelineBreaches are the guideline breaches found in one portfolio
+type PortfolioGuidelineBreaches struct {
+	PortfolioID uuid.UUID         `json:"portfolio_id,omitempty"`
+	Breaches    []GuidelineBreach `json:"breaches,omitempty"`
+}
+
+// PortfolioRiskProfile is the VaR, liquidity and concentration of a portfolio's positions and cash
+type PortfolioRiskProfile struct {
+	// Positions only, as on the portfolio
+	TotalValue     decimal.Decimal      `json:"total_value,omitempty"`
+	CashBalance    decimal.Decimal      `json:"cash_balance,omitempty"`
+	VaR95          decimal.Decimal      `json:"var_95,omitempty"`
+	VaRLimit       decimal.Decimal      `json:"var_limit,omitempty"`
+	VaRPercent     decimal.Decimal      `json:"var_percent,omitempty"`
+	LiquidityRatio decimal.Decimal      `json:"liquidity_ratio,omitempty"`
+	Concentration  *ConcentrationResult `json:"concentration,omitempty"`
+	Weights        []SimulatedWeight    `json:"weights,omitempty"`
+}
+
+// PortfolioSnapshot is a portfolio's end-of-day valuation. Portfolio.TotalValue is overwritten as
+// prices move, so these rows are the NAV history; a day's row is replaced if it is taken again.
+type PortfolioSnapshot struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	Date        time.Time `json:"date,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+	// Market value plus cash less the margin loan
+	Nav         decimal.Decimal    `json:"nav,omitempty"`
+	MarketValue decimal.Decimal    `json:"market_value,omitempty"`
+	CashBalance decimal.Decimal    `json:"cash_balance,omitempty"`
+	MarginLoan  decimal.Decimal    `json:"margin_loan,omitempty"`
+	Positions   []SnapshotPosition `json:"positions,omitempty"`
+	CreatedAt   time.Time          `json:"created_at,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at,omitempty"`
+}
+
+// PortfolioSummary is the dashboard view of a portfolio: its value, latest risk metrics and active
+// alerts
+type PortfolioSummary struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id,omitempty"`
+	Name          string          `json:"name,omitempty"`
+	Currency      string          `json:"currency,omitempty"`
+	TotalValue    decimal.Decimal `json:"total_value,omitempty"`
+	CashBalance   decimal.Decimal `json:"cash_balance,omitempty"`
+	ValueWithCash decimal.Decimal `json:"value_with_cash,omitempty"`
+	PositionCount int64           `json:"position_count,omitempty"`
+	LatestMetrics []RiskMetric    `json:"latest_metrics,omitempty"`
+	ActiveAlerts  *AlertCounts    `json:"active_alerts,omitempty"`
+}
+
+// PortfolioSupervisor grants a user access to a portfolio they do not own
+type PortfolioSupervisor struct {
+	ID          uuid.UUID  `json:"id,omitempty"`
+	PortfolioID uuid.UUID  `json:"portfolio_id,omitempty"`
+	UserID      uuid.UUID  `json:"user_id,omitempty"`
+	AssignedBy  *uuid.UUID `json:"assigned_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	User        *User      `json:"user,omitempty"`
+}
+
+// PortfolioTemplate is a predefined portfolio that new portfolios can be created from: a set of
+// positions and cash, risk thresholds and an investment guideline. Thresholds not set keep their
+// defaults, and a template without a guideline sets none.
+type PortfolioTemplate struct {
+	ID          uuid.UUID            `json:"id,omitempty"`
+	Name        string               `json:"name,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Currency    string               `json:"currency,omitempty"`
+	Benchmark   string               `json:"benchmark,omitempty"`
+	CashBalance decimal.Decimal      `json:"cash_balance,omitempty"`
+	Positions   []TemplatePosition   `json:"positions,omitempty"`
+	Thresholds  *ThresholdOverrides  `json:"thresholds,omitempty"`
+	Guideline   *GuidelineDefinition `json:"guideline,omitempty"`
+	CreatedBy   *uuid.UUID           `json:"created_by,omitempty"`
+	UpdatedBy   *uuid.UUID           `json:"updated_by,omitempty"`
+	CreatedAt   time.Time            `json:"created_at,omitempty"`
+	UpdatedAt   time.Time            `json:"updated_at,omitempty"`
+}
+
+// PortfolioTemplateRequest creates or replaces a portfolio template
+type PortfolioTemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// USD when empty
+	Currency    string               `json:"currency,omitempty"`
+	Benchmark   string               `json:"benchmark,omitempty"`
+	CashBalance decimal.Decimal      `json:"cash_balance,omitempty"`
+	Positions   []TemplatePosition   `json:"positions,omitempty"`
+	Thresholds  *ThresholdOverrides  `json:"thresholds,omitempty"`
+	Guideline   *GuidelineDefinition `json:"guideline,omitempty"`
+}
+
+type Position struct {
+	ID           uuid.UUID       `json:"id,omitempty"`
+	PortfolioID  uuid.UUID       `json:"portfolio_id,omitempty"`
+	Symbol       string          `json:"symbol,omitempty"`
+	Quantity     decimal.Decimal `json:"quantity,omitempty"`
+	AveragePrice decimal.Decimal `json:"average_price,omitempty"`
+	CurrentPrice decimal.Decimal `json:"current_price,omitempty"`
+	// In the portfolio currency
+	MarketValue decimal.Decimal `json:"market_value,omitempty"`
+	// In the portfolio currency
+	PnL        decimal.Decimal `json:"pnl,omitempty"`
+	PnLPercent decimal.Decimal `json:"pnl_percent,omitempty"`
+	// Position weight in portfolio
+	Weight decimal.Decimal `json:"weight,omitempty"`
+	// STOCK, BOND, COMMODITY, etc.
+	AssetType string `json:"asset_type,omitempty"`
+	// HIGH, MEDIUM, LOW
+	Liquidity string `json:"liquidity,omitempty"`
+	// Issue rating of bonds, such as AA+
+	CreditRating string `json:"credit_rating,omitempty"`
+	// Prices are quoted in Currency; FXRate converts them into the portfolio currency
+	Currency         string          `json:"currency,omitempty"`
+	FXRate           decimal.Decimal `json:"fx_rate,omitempty"`
+	LocalMarketValue decimal.Decimal `json:"local_market_value,omitempty"`
+	UpdatedAt        time.Time       `json:"updated_at,omitempty"`
+	DeletedAt        *time.Time      `json:"deleted_at,omitempty"`
+}
+
+// PositionLiquidity is the liquidity classification of a position. The calculated class is
+// refreshed nightly; an override, when set, takes precedence and is what the position carries.
+type PositionLiquidity struct {
+	ID         uuid.UUID `json:"id,omitempty"`
+	PositionID uuid.UUID `json:"position_id,omitempty"`
+	// MARKET_DATA, ASSET_TYPE, NONE
+	Source          string `json:"source,omitempty"`
+	CalculatedClass string `json:"calculated_class,omitempty"`
+	// 0-100, higher is more liquid
+	Score              decimal.Decimal `json:"score,omitempty"`
+	DaysToLiquidate    decimal.Decimal `json:"days_to_liquidate,omitempty"`
+	AverageDailyVolume decimal.Decimal `json:"average_daily_volume,omitempty"`
+	BidAskSpread       decimal.Decimal `json:"bid_ask_spread,omitempty"`
+	CalculatedAt       *time.Time      `json:"calculated_at,omitempty"`
+	OverrideClass      *string         `json:"override_class,omitempty"`
+	OverrideReason     string          `json:"override_reason,omitempty"`
+	OverriddenBy       *uuid.UUID      `json:"overridden_by,omitempty"`
+	OverriddenAt       *time.Time      `json:"overridden_at,omitempty"`
+	CreatedAt          time.Time       `json:"created_at,omitempty"`
+	UpdatedAt          time.Time       `json:"updated_at,omitempty"`
+}
+
+// PositionLiquidityVaR is one position's part of the liquidity-adjusted VaR
+type PositionLiquidityVaR struct {
+	Symbol          string  `json:"symbol,omitempty"`
+	LiquidationDays float64 `json:"liquidation_days,omitempty"`
+	VaRContribution float64 `json:"var_contribution,omitempty"`
+	AdjustedVaR     float64 `json:"adjusted_var,omitempty"`
+	SpreadCost      float64 `json:"spread_cost,omitempty"`
+}
+
+// PositionLiquidityView is a position with its liquidity classification, if it has one
+type PositionLiquidityView struct {
+	PositionID     uuid.UUID          `json:"position_id,omitempty"`
+	Symbol         string             `json:"symbol,omitempty"`
+	AssetType      string             `json:"asset_type,omitempty"`
+	Quantity       decimal.Decimal    `json:"quantity,omitempty"`
+	MarketValue    decimal.Decimal    `json:"market_value,omitempty"`
+	Liquidity      string             `json:"liquidity,omitempty"`
+	Classification *PositionLiquidity `json:"classification,omitempty"`
+}
+
+// PositionLot is the quantity of a symbol bought by one fill, at that fill's price. Sales consume
+// open lots in the order of the portfolio's cost basis method.
+type PositionLot struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// Position of the symbol when the lot was opened
+	PositionID        *uuid.UUID      `json:"position_id,omitempty"`
+	Symbol            string          `json:"symbol,omitempty"`
+	TransactionID     uuid.UUID       `json:"transaction_id,omitempty"`
+	FillID            uuid.UUID       `json:"fill_id,omitempty"`
+	Currency          string          `json:"currency,omitempty"`
+	Quantity          decimal.Decimal `json:"quantity,omitempty"`
+	RemainingQuantity decimal.Decimal `json:"remaining_quantity,omitempty"`
+	// In Currency
+	CostPrice decimal.Decimal `json:"cost_price,omitempty"`
+	OpenedAt  time.Time       `json:"opened_at,omitempty"`
+	// When the last of the lot was sold
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+}
+
+// PositionPnL is the unrealized P&L of one position. Prices are in the position currency; values
+// and P&L are in the portfolio currency.
+type PositionPnL struct {
+	PositionID    uuid.UUID       `json:"position_id,omitempty"`
+	Symbol        string          `json:"symbol,omitempty"`
+	Currency      string          `json:"currency,omitempty"`
+	FXRate        decimal.Decimal `json:"fx_rate,omitempty"`
+	Quantity      decimal.Decimal `json:"quantity,omitempty"`
+	AveragePrice  decimal.Decimal `json:"average_price,omitempty"`
+	CurrentPrice  decimal.Decimal `json:"current_price,omitempty"`
+	MarketValue   decimal.Decimal `json:"market_value,omitempty"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl,omitempty"`
+	PnLPercent    decimal.Decimal `json:"pnl_percent,omitempty"`
+}
+
+// PositionVaRContribution is one position's share of the portfolio VaR
+type PositionVaRContribution struct {
+	// By component VaR, largest first
+	Rank        int     `json:"rank,omitempty"`
+	Symbol      string  `json:"symbol,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	// Change in VaR per unit of market value
+	MarginalVaR float64 `json:"marginal_var,omitempty"`
+	// Marginal VaR times market value; components add up to the VaR
+	ComponentVaR float64 `json:"component_var,omitempty"`
+	// Component VaR as a fraction of the VaR
+	Contribution float64 `json:"contribution,omitempty"`
+	// VaR removed by closing the position
+	IncrementalVaR float64 `json:"incremental_var,omitempty"`
+}
+
+// PositionWeight is a symbol's share of the portfolio
+type PositionWeight struct {
+	Symbol      string  `json:"symbol,omitempty"`
+	Sector      string  `json:"sector,omitempty"`
+	AssetClass  string  `json:"asset_class,omitempty"`
+	MarketValue float64 `json:"market_value,omitempty"`
+	Weight      float64 `json:"weight,omitempty"`
+}
+
+// PreTradeCheck is the outcome of one check with its findings
+type PreTradeCheck struct {
+	Check   string           `json:"check,omitempty"`
+	Status  string           `json:"status,omitempty"`
+	Reasons []PreTradeReason `json:"reasons,omitempty"`
+}
+
+// PreTradeCheckRequest is a proposed order, with the fields of CreateTransactionRequest
+type PreTradeCheckRequest struct {
+	PortfolioID string `json:"portfolio_id"`
+	// BUY or SELL
+	TransactionType     string  `json:"transaction_type"`
+	Symbol              string  `json:"symbol"`
+	Quantity            float64 `json:"quantity,omitempty"`
+	Price               float64 `json:"price,omitempty"`
+	Currency            string  `json:"currency,omitempty"`
+	AssetType           string  `json:"asset_type,omitempty"`
+	CreditRating        string  `json:"credit_rating,omitempty"`
+	CounterpartyID      string  `json:"counterparty_id,omitempty"`
+	CounterpartyName    string  `json:"counterparty_name,omitempty"`
+	CounterpartyCountry string  `json:"counterparty_country,omitempty"`
+}
+
+// PreTradeReason is one finding of a check. A blocking reason fails the order; the others should be
+// reviewed but do not stop it.
+type PreTradeReason struct {
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Blocking bool   `json:"blocking,omitempty"`
+}
+
+// PreTradeResult is the outcome of the pre-trade compliance checks on a proposed order. Passed is
+// false when any check failed.
+type PreTradeResult struct {
+	Passed      bool            `json:"passed,omitempty"`
+	PortfolioID uuid.UUID       `json:"portfolio_id,omitempty"`
+	Side        string          `json:"side,omitempty"`
+	Symbol      string          `json:"symbol,omitempty"`
+	Checks      []PreTradeCheck `json:"checks,omitempty"`
+	CheckedAt   time.Time       `json:"checked_at,omitempty"`
+}
+
+type ReactivateResponse struct {
+	Message string `json:"message"`
+	Data    User   `json:"data"`
+}
+
+// RebalanceTrade is a suggested trade in the portfolio currency. Quantity is zero when the
+// portfolio holds nothing to price the trade from; Symbol is empty for an asset class it does not
+// hold at all.
+type RebalanceTrade struct {
+	Symbol     string  `json:"symbol,omitempty"`
+	AssetClass string  `json:"asset_class,omitempty"`
+	Side       string  `json:"side,omitempty"`
+	Quantity   float64 `json:"quantity,omitempty"`
+	Value      float64 `json:"value,omitempty"`
+}
+
+// ReconciliationBreak is a difference between our position in a symbol and the custodian's.
+// Quantities missing on one side are zero, and QuantityDifference is the custodian's quantity less
+// ours.
+type ReconciliationBreak struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	RunID       uuid.UUID `json:"run_id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	Symbol      string    `json:"symbol,omitempty"`
+	// QUANTITY, PRICE, MISSING_INTERNAL, MISSING_EXTERNAL
+	BreakType          string          `json:"break_type,omitempty"`
+	InternalQuantity   decimal.Decimal `json:"internal_quantity,omitempty"`
+	ExternalQuantity   decimal.Decimal `json:"external_quantity,omitempty"`
+	QuantityDifference decimal.Decimal `json:"quantity_difference,omitempty"`
+	InternalPrice      decimal.Decimal `json:"internal_price,omitempty"`
+	ExternalPrice      decimal.Decimal `json:"external_price,omitempty"`
+	// OPEN, EXPLAINED, RESOLVED
+	Status      string     `json:"status,omitempty"`
+	Explanation string     `json:"explanation,omitempty"`
+	ExplainedBy *uuid.UUID `json:"explained_by,omitempty"`
+	ExplainedAt *time.Time `json:"explained_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+}
+
+// ReconciliationRun is one comparison of a custodian position file with the positions of the
+// portfolios it covers
+type ReconciliationRun struct {
+	ID uuid.UUID `json:"id,omitempty"`
+	// upload, http, inbox
+	Source   string    `json:"source,omitempty"`
+	FileName string    `json:"file_name,omitempty"`
+	AsOf     time.Time `json:"as_of,omitempty"`
+	// COMPLETED, FAILED
+	Status     string `json:"status,omitempty"`
+	Portfolios int    `json:"portfolios,omitempty"`
+	// Portfolios the file covered
+	PortfolioIDs []uuid.UUID `json:"portfolio_ids,omitempty"`
+	// Custodian positions read
+	Positions   int `json:"positions,omitempty"`
+	Matched     int `json:"matched,omitempty"`
+	Breaks      int `json:"breaks,omitempty"`
+	Unexplained int `json:"unexplained,omitempty"`
+	// Why the file was rejected, or the unknown accounts skipped
+	Message   string     `json:"message,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+}
+
+type RecordFillResponse struct {
+	Message     string      `json:"message"`
+	Fill        Fill        `json:"fill"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type RecordPricesRequest struct {
+	Prices []BenchmarkPriceInput `json:"prices,omitempty"`
+}
+
+type RecordPricesResponse struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// RedisStatus describes the Redis connection for health checks
+type RedisStatus struct {
+	// up, down
+	Status   string `json:"status,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// When the status last changed
+	Since               time.Time `json:"since,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is a long-lived token used to obtain new access tokens. Only a hash is stored.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id,omitempty"`
+	UserID    uuid.UUID  `json:"user_id,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Set when rotated on refresh
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+}
+
+type RegisterRequest struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type RegisterResponse struct {
+	Message string `json:"message"`
+	User    User   `json:"user"`
+}
+
+type RemoveMemberResponse struct {
+	Message string `json:"message"`
+}
+
+type RemoveSupervisorResponse struct {
+	Message string `json:"message"`
+}
+
+// Report is a generated regulatory or risk report. The rendered file is stored with the record and
+// only returned through the download endpoint.
+type Report struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// DAILY_RISK, COMPLIANCE_SUMMARY, ALERT_HISTORY
+	ReportType string `json:"report_type,omitempty"`
+	// pdf, csv
+	Format      string     `json:"format,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	FileName    string     `json:"file_name,omitempty"`
+	ContentType string     `json:"content_type,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	Sha256      string     `json:"sha256,omitempty"`
+	PeriodFrom  time.Time  `json:"period_from,omitempty"`
+	PeriodTo    time.Time  `json:"period_to,omitempty"`
+	GeneratedBy *uuid.UUID `json:"generated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+type ResolveAlertResponse struct {
+	Message string `json:"message"`
+	Data    Alert  `json:"data"`
+}
+
+type RestoreAlertResponse struct {
+	Message string `json:"message"`
+	Data    Alert  `json:"data"`
+}
+
+type RestorePortfolioResponse struct {
+	Message string    `json:"message"`
+	Data    Portfolio `json:"data"`
+}
+
+type RestoreTransactionResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// RetentionPolicy sets how long records of an entity are kept, counted from their creation or, for
+// user accounts, from their closing
+type RetentionPolicy struct {
+	EntityType    string     `json:"entity_type,omitempty"`
+	RetentionDays int        `json:"retention_days,omitempty"`
+	Enabled       bool       `json:"enabled,omitempty"`
+	UpdatedBy     *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at,omitempty"`
+}
+
+// RetentionPolicyRequest changes a retention policy
+type RetentionPolicyRequest struct {
+	RetentionDays int   `json:"retention_days,omitempty"`
+	Enabled       *bool `json:"enabled,omitempty"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Message string `json:"message"`
+	Data    APIKey `json:"data"`
+}
+
+type RevokeUserSessionsResponse struct {
+	Message string `json:"message"`
+}
+
+type RiskGetSummaryResponse struct {
+	Portfolios   []PortfolioRiskSummary `json:"portfolios"`
+	Count        int                    `json:"count"`
+	CalculatedAt time.Time              `json:"calculated_at"`
+}
+
+// RiskMetric represents calculated risk metrics for a portfolio
+type RiskMetric struct {
+	ID              uuid.UUID              `json:"id,omitempty"`
+	PortfolioID     uuid.UUID              `json:"portfolio_id,omitempty"`
+	MetricType      string                 `json:"metric_type,omitempty"`
+	Value           decimal.Decimal        `json:"value,omitempty"`
+	Threshold       decimal.Decimal        `json:"threshold,omitempty"`
+	Status          string                 `json:"status,omitempty"`
+	CalculatedAt    time.Time              `json:"calculated_at,omitempty"`
+	TimeHorizon     int                    `json:"time_horizon,omitempty"`
+	ConfidenceLevel decimal.Decimal        `json:"confidence_level,omitempty"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+	Portfolio       *Portfolio             `json:"portfolio,omitempty"`
+}
+
+// RiskStatusChange is a risk metric of a portfolio whose latest status differs from the one it had
+// at the start of a digest's period
+type RiskStatusChange struct {
+	PortfolioID   uuid.UUID `json:"portfolio_id,omitempty"`
+	PortfolioName string    `json:"portfolio_name,omitempty"`
+	MetricType    string    `json:"metric_type,omitempty"`
+	// Nil when first calculated in the period
+	FromStatus   *string         `json:"from_status,omitempty"`
+	ToStatus     string          `json:"to_status,omitempty"`
+	Value        decimal.Decimal `json:"value,omitempty"`
+	CalculatedAt time.Time       `json:"calculated_at,omitempty"`
+}
+
+// RiskThresholds defines the risk limits for portfolios
+type RiskThresholds struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// VaR Limits
+	MaxVaR95 decimal.Decimal `json:"max_var_95,omitempty"`
+	MaxVaR99 decimal.Decimal `json:"max_var_99,omitempty"`
+	// Position Limits % of portfolio
+	MaxPositionSize        decimal.Decimal `json:"max_position_size,omitempty"`
+	MaxSingleAssetExposure decimal.Decimal `json:"max_single_asset_exposure,omitempty"`
+	MaxSectorExposure      decimal.Decimal `json:"max_sector_exposure,omitempty"`
+	// Risk Metrics Limits
+	MinLiquidityRatio decimal.Decimal `json:"min_liquidity_ratio,omitempty"`
+	MaxLeverage       decimal.Decimal `json:"max_leverage,omitempty"`
+	MaxConcentration  decimal.Decimal `json:"max_concentration,omitempty"`
+	// Share of gross exposure outside the portfolio currency
+	MaxFXExposure decimal.Decimal `json:"max_fx_exposure,omitempty"`
+	// Interest Rate Limits Loss from a one basis point rise in rates, as a share of portfolio value
+	MaxDV01 decimal.Decimal `json:"max_dv01,omitempty"`
+	// Crypto Limits Share of crypto value one exchange or custodian may hold
+	MaxVenueExposure decimal.Decimal `json:"max_venue_exposure,omitempty"`
+	// Deviation of a stablecoin from its peg treated as a depeg
+	MaxStablecoinDepeg decimal.Decimal `json:"max_stablecoin_depeg,omitempty"`
+	// Loss Limits % of portfolio
+	MaxDailyLoss  decimal.Decimal `json:"max_daily_loss,omitempty"`
+	MaxWeeklyLoss decimal.Decimal `json:"max_weekly_loss,omitempty"`
+	MaxDrawdown   decimal.Decimal `json:"max_drawdown,omitempty"`
+	// Stop Loss Rules
+	RequireStopLoss bool `json:"require_stop_loss,omitempty"`
+	// Max % from entry
+	MaxStopLossDistance decimal.Decimal     `json:"max_stop_loss_distance,omitempty"`
+	Overrides           *ThresholdOverrides `json:"overrides,omitempty"`
+	// Raised by the database with each change
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	Portfolio *Portfolio `json:"portfolio,omitempty"`
+}
+
+// RiskViolation represents a specific risk limit breach
+type RiskViolation struct {
+	Type         string          `json:"type,omitempty"`
+	Severity     string          `json:"severity,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	CurrentValue decimal.Decimal `json:"current_value,omitempty"`
+	Limit        decimal.Decimal `json:"limit,omitempty"`
+	Impact       decimal.Decimal `json:"impact,omitempty"`
+}
+
+// RuleBreach describes a rule that evaluated as breached
+type RuleBreach struct {
+	RuleID        uuid.UUID       `json:"rule_id,omitempty"`
+	RuleName      string          `json:"rule_name,omitempty"`
+	Scope         string          `json:"scope,omitempty"`
+	Metric        string          `json:"metric,omitempty"`
+	Parameter     string          `json:"parameter,omitempty"`
+	Operator      string          `json:"operator,omitempty"`
+	Threshold     decimal.Decimal `json:"threshold,omitempty"`
+	Value         decimal.Decimal `json:"value,omitempty"`
+	Severity      string          `json:"severity,omitempty"`
+	PortfolioID   uuid.UUID       `json:"portfolio_id,omitempty"`
+	TransactionID *uuid.UUID      `json:"transaction_id,omitempty"`
+}
+
+// RuleEvaluationResult summarizes a rule engine run
+type RuleEvaluationResult struct {
+	RulesEvaluated        int          `json:"rules_evaluated,omitempty"`
+	PortfoliosEvaluated   int          `json:"portfolios_evaluated,omitempty"`
+	TransactionsEvaluated int          `json:"transactions_evaluated,omitempty"`
+	Breaches              []RuleBreach `json:"breaches,omitempty"`
+	EvaluatedAt           time.Time    `json:"evaluated_at,omitempty"`
+}
+
+type RunRetentionPoliciesResponse struct {
+	Applied map[string]int64 `json:"applied"`
+}
+
+// SARActivity summarises the suspicious activity window and volume
+type SARActivity struct {
+	From             *time.Time      `json:"from,omitempty"`
+	To               *time.Time      `json:"to,omitempty"`
+	TransactionCount int             `json:"transaction_count,omitempty"`
+	TotalAmount      decimal.Decimal `json:"total_amount,omitempty"`
+	Currencies       []string        `json:"currencies,omitempty"`
+	PortfolioCount   int             `json:"portfolio_count,omitempty"`
+}
+
+type SARAlert struct {
+	ID       uuid.UUID `json:"id,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	RaisedAt time.Time `json:"raised_at,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Summary  string    `json:"summary,omitempty"`
+}
+
+type SAREvidence struct {
+	FileName   string    `json:"file_name,omitempty"`
+	Sha256     string    `json:"sha256,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at,omitempty"`
+}
+
+type SARNote struct {
+	At     time.Time `json:"at,omitempty"`
+	Kind   string    `json:"kind,omitempty"`
+	Author string    `json:"author,omitempty"`
+	Body   string    `json:"body,omitempty"`
+}
+
+// SARReport is the exportable Suspicious Activity Report assembled from a case
+type SARReport struct {
+	CaseNumber      string           `json:"case_number,omitempty"`
+	Title           string           `json:"title,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	Priority        string           `json:"priority,omitempty"`
+	FilingReference string           `json:"filing_reference,omitempty"`
+	FiledAt         *time.Time       `json:"filed_at,omitempty"`
+	OpenedAt        time.Time        `json:"opened_at,omitempty"`
+	GeneratedAt     time.Time        `json:"generated_at,omitempty"`
+	Investigator    string           `json:"investigator,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Narrative       string           `json:"narrative,omitempty"`
+	Alert           *SARAlert        `json:"alert,omitempty"`
+	Activity        *SARActivity     `json:"activity,omitempty"`
+	Subjects        []SARSubject     `json:"subjects,omitempty"`
+	Transactions    []SARTransaction `json:"transactions,omitempty"`
+	Timeline        []SARNote        `json:"timeline,omitempty"`
+	Evidence        []SAREvidence    `json:"evidence,omitempty"`
+}
+
+// SARSubject is a party to the reported transactions
+type SARSubject struct {
+	Name           string     `json:"name,omitempty"`
+	Country        string     `json:"country,omitempty"`
+	CounterpartyID *uuid.UUID `json:"counterparty_id,omitempty"`
+	LEI            string     `json:"lei,omitempty"`
+	RiskRating     string     `json:"risk_rating,omitempty"`
+	KYCStatus      string     `json:"kyc_status,omitempty"`
+}
+
+type SARTransaction struct {
+	ID           uuid.UUID       `json:"id,omitempty"`
+	Date         time.Time       `json:"date,omitempty"`
+	PortfolioID  uuid.UUID       `json:"portfolio_id,omitempty"`
+	Type         string          `json:"type,omitempty"`
+	Symbol       string          `json:"symbol,omitempty"`
+	Amount       decimal.Decimal `json:"amount,omitempty"`
+	Currency     string          `json:"currency,omitempty"`
+	Status       string          `json:"status,omitempty"`
+	Counterparty string          `json:"counterparty,omitempty"`
+	RiskScore    int             `json:"risk_score,omitempty"`
+}
+
+// SanctionsEntry is a single record imported from a sanctions or PEP list
+type SanctionsEntry struct {
+	ID uuid.UUID `json:"id,omitempty"`
+	// OFAC, EU, UN, PEP, INTERNAL
+	Source     string `json:"source,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	// Semicolon-separated alternate names
+	Aliases string `json:"aliases,omitempty"`
+	// INDIVIDUAL, ENTITY, VESSEL, AIRCRAFT
+	EntityType string     `json:"entity_type,omitempty"`
+	Country    string     `json:"country,omitempty"`
+	Programs   string     `json:"programs,omitempty"`
+	IsPep      bool       `json:"is_pep,omitempty"`
+	Remarks    string     `json:"remarks,omitempty"`
+	ListedAt   *time.Time `json:"listed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+type ScreenNameRequest struct {
+	Name    string `json:"name"`
+	Country string `json:"country,omitempty"`
+}
+
+// ScreeningResult records the outcome of screening one party of a transaction
+type ScreeningResult struct {
+	ID            uuid.UUID  `json:"id,omitempty"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	SubjectName   string     `json:"subject_name,omitempty"`
+	// CUSTOMER, COUNTERPARTY, AD_HOC
+	SubjectType string `json:"subject_type,omitempty"`
+	// CLEAR, POTENTIAL_MATCH, MATCH
+	Status       string                 `json:"status,omitempty"`
+	SanctionsHit bool                   `json:"sanctions_hit,omitempty"`
+	PepHit       bool                   `json:"pep_hit,omitempty"`
+	TopScore     float64                `json:"top_score,omitempty"`
+	Matches      map[string]interface{} `json:"matches,omitempty"`
+	ScreenedBy   *uuid.UUID             `json:"screened_by,omitempty"`
+	ScreenedAt   time.Time              `json:"screened_at,omitempty"`
+}
+
+type SearchTransactionsResponse struct {
+	Data []Transaction `json:"data"`
+	// Number of matches across all pages
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+type SetCustodyRequest struct {
+	Custody []CryptoCustodyInput `json:"custody,omitempty"`
+}
+
+type SetCustodyResponse struct {
+	Message string          `json:"message"`
+	Data    []CryptoCustody `json:"data"`
+}
+
+type SetLiquidityOverrideRequest struct {
+	Class  string `json:"class,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type SetLiquidityOverrideResponse struct {
+	Message string            `json:"message"`
+	Data    PositionLiquidity `json:"data"`
+}
+
+type SetLogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+type SetMemberRequest struct {
+	// VIEWER (default) or EDITOR
+	Role string `json:"role,omitempty"`
+}
+
+type SetTargetsRequest struct {
+	Targets []TargetAllocationInput `json:"targets,omitempty"`
+}
+
+type SetTargetsResponse struct {
+	Message string             `json:"message"`
+	Data    []TargetAllocation `json:"data"`
+}
+
+type SetTeamRequest struct {
+	TeamID *uuid.UUID `json:"team_id,omitempty"`
+}
+
+type SetUserStatusRequest struct {
+	IsActive *bool `json:"is_active,omitempty"`
+}
+
+type SetUserStatusResponse struct {
+	Message string `json:"message"`
+	Data    User   `json:"data"`
+}
+
+type SimulateRequest struct {
+	Trades []SimulatedTrade `json:"trades,omitempty"`
+}
+
+// SimulatedTrade is a hypothetical BUY or SELL order. Prices are in the currency of the position
+// traded; a trade in a symbol the portfolio does not hold opens a position in Currency.
+type SimulatedTrade struct {
+	Symbol string `json:"symbol,omitempty"`
+	// BUY or SELL
+	Side     string          `json:"side,omitempty"`
+	Quantity decimal.Decimal `json:"quantity,omitempty"`
+	// Defaults to the held position's current price
+	Price decimal.Decimal `json:"price,omitempty"`
+	// For a new position; defaults to STOCK
+	AssetType string `json:"asset_type,omitempty"`
+	// For a new position; defaults to the portfolio currency
+	Currency string `json:"currency,omitempty"`
+	// In the portfolio currency, filled in by the simulation
+	Value decimal.Decimal `json:"value,omitempty"`
+}
+
+// SimulatedWeight is a symbol's share of the positions' value, in percent like Position.Weight
+type SimulatedWeight struct {
+	Symbol      string          `json:"symbol,omitempty"`
+	Quantity    decimal.Decimal `json:"quantity,omitempty"`
+	MarketValue decimal.Decimal `json:"market_value,omitempty"`
+	Weight      decimal.Decimal `json:"weight,omitempty"`
+}
+
+// SimulationResult compares a portfolio before and after a basket of hypothetical trades. Passed is
+// false when the portfolio would breach a risk limit or a compliance check would fail.
+type SimulationResult struct {
+	PortfolioID uuid.UUID             `json:"portfolio_id,omitempty"`
+	Passed      bool                  `json:"passed,omitempty"`
+	Trades      []SimulatedTrade      `json:"trades,omitempty"`
+	Before      *PortfolioRiskProfile `json:"before,omitempty"`
+	After       *PortfolioRiskProfile `json:"after,omitempty"`
+	Violations  []RiskViolation       `json:"violations,omitempty"`
+	Compliance  []PreTradeCheck       `json:"compliance,omitempty"`
+	SimulatedAt time.Time             `json:"simulated_at,omitempty"`
+}
+
+// SnapshotPosition is a position as it stood when a portfolio snapshot was taken. Values are in the
+// portfolio currency and the weight is a percent of the positions' market value.
+type SnapshotPosition struct {
+	Symbol       string          `json:"symbol,omitempty"`
+	AssetType    string          `json:"asset_type,omitempty"`
+	Currency     string          `json:"currency,omitempty"`
+	Quantity     decimal.Decimal `json:"quantity,omitempty"`
+	AveragePrice decimal.Decimal `json:"average_price,omitempty"`
+	Price        decimal.Decimal `json:"price,omitempty"`
+	MarketValue  decimal.Decimal `json:"market_value,omitempty"`
+	PnL          decimal.Decimal `json:"pnl,omitempty"`
+	Weight       decimal.Decimal `json:"weight,omitempty"`
+}
+
+// StablecoinPeg compares a stablecoin's price with its peg; Deviation is a signed fraction
+type StablecoinPeg struct {
+	Symbol    string  `json:"symbol,omitempty"`
+	PegPrice  float64 `json:"peg_price,omitempty"`
+	Price     float64 `json:"price,omitempty"`
+	Deviation float64 `json:"deviation,omitempty"`
+	Depegged  bool    `json:"depegged,omitempty"`
+}
+
+type StartReportJobRequest struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// SymbolExposure is the exposure to a symbol across the portfolios a user can access
+type SymbolExposure struct {
+	Symbol       string          `json:"symbol,omitempty"`
+	Currency     string          `json:"currency,omitempty"`
+	TeamID       *uuid.UUID      `json:"team_id,omitempty"`
+	Quantity     decimal.Decimal `json:"quantity,omitempty"`
+	LongExposure decimal.Decimal `json:"long_exposure,omitempty"`
+	// Market value of short positions, as a positive amount
+	ShortExposure  decimal.Decimal     `json:"short_exposure,omitempty"`
+	NetExposure    decimal.Decimal     `json:"net_exposure,omitempty"`
+	GrossExposure  decimal.Decimal     `json:"gross_exposure,omitempty"`
+	PortfolioCount int                 `json:"portfolio_count,omitempty"`
+	Teams          []TeamExposure      `json:"teams,omitempty"`
+	Portfolios     []PortfolioExposure `json:"portfolios,omitempty"`
+	Firm           *FirmExposure       `json:"firm,omitempty"`
+	CalculatedAt   time.Time           `json:"calculated_at,omitempty"`
+}
+
+type SymbolListRequest struct {
+	Symbol string `json:"symbol"`
+	// BUY, SELL or empty for both
+	Side   string `json:"side,omitempty"`
+	Reason string `json:"reason"`
+	// RFC3339, defaults to now for new entries
+	EffectiveFrom string `json:"effective_from,omitempty"`
+	// RFC3339, empty for no end
+	EffectiveTo string `json:"effective_to,omitempty"`
+}
+
+// SymbolMarketData is the trading activity a symbol's liquidity is classified from
+type SymbolMarketData struct {
+	Symbol string `json:"symbol,omitempty"`
+	// Units traded per day
+	AverageDailyVolume decimal.Decimal `json:"average_daily_volume,omitempty"`
+	// As a fraction of price
+	BidAskSpread decimal.Decimal `json:"bid_ask_spread,omitempty"`
+	UpdatedBy    *uuid.UUID      `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time       `json:"updated_at,omitempty"`
+}
+
+// TargetAllocation is the weight a portfolio aims to hold in one symbol or asset class, as a
+// fraction of its positions and cash, and how far it may drift either side before rebalancing
+type TargetAllocation struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// SYMBOL or ASSET_CLASS
+	Kind string `json:"kind,omitempty"`
+	// Symbol, or asset class as in the concentration breakdown
+	Key          string          `json:"key,omitempty"`
+	TargetWeight decimal.Decimal `json:"target_weight,omitempty"`
+	// Allowed drift either side of the target
+	Tolerance decimal.Decimal `json:"tolerance,omitempty"`
+	UpdatedBy *uuid.UUID      `json:"updated_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at,omitempty"`
+}
+
+// TargetAllocationInput is the weight a portfolio aims to hold in one symbol or asset class
+type TargetAllocationInput struct {
+	// SYMBOL or ASSET_CLASS
+	Kind         string          `json:"kind,omitempty"`
+	Key          string          `json:"key,omitempty"`
+	TargetWeight decimal.Decimal `json:"target_weight,omitempty"`
+	// Defaults to 0.05
+	Tolerance decimal.Decimal `json:"tolerance,omitempty"`
+}
+
+// Team is a group of users sharing an on-call rotation and, through its members, the portfolios it
+// owns
+type Team struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// TeamExposure is the exposure of the portfolios shared with a team; portfolios without a team are
+// grouped under a null team_id
+type TeamExposure struct {
+	TeamID       *uuid.UUID      `json:"team_id,omitempty"`
+	TeamName     string          `json:"team_name,omitempty"`
+	Quantity     decimal.Decimal `json:"quantity,omitempty"`
+	LongExposure decimal.Decimal `json:"long_exposure,omitempty"`
+	// Market value of short positions, as a positive amount
+	ShortExposure  decimal.Decimal `json:"short_exposure,omitempty"`
+	NetExposure    decimal.Decimal `json:"net_exposure,omitempty"`
+	GrossExposure  decimal.Decimal `json:"gross_exposure,omitempty"`
+	PortfolioCount int             `json:"portfolio_count,omitempty"`
+}
+
+// TeamMember makes a user a member of a team, with access to the portfolios the team owns
+type TeamMember struct {
+	ID     uuid.UUID `json:"id,omitempty"`
+	TeamID uuid.UUID `json:"team_id,omitempty"`
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	// VIEWER or EDITOR
+	Role      string     `json:"role,omitempty"`
+	AddedBy   *uuid.UUID `json:"added_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	User      *User      `json:"user,omitempty"`
+}
+
+type TeamRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TemplatePosition is a holding of a portfolio template, bought at Price in Currency
+type TemplatePosition struct {
+	Symbol string `json:"symbol,omitempty"`
+	// STOCK, BOND, CRYPTO, etc.
+	AssetType string          `json:"asset_type,omitempty"`
+	Quantity  decimal.Decimal `json:"quantity,omitempty"`
+	Price     decimal.Decimal `json:"price,omitempty"`
+	// The portfolio currency when empty
+	Currency string `json:"currency,omitempty"`
+}
+
+// Tenant is an organisation whose users, portfolios, transactions and alerts are kept apart from
+// other tenants'. Users without a tenant belong to the platform operator.
+type Tenant struct {
+	ID       uuid.UUID       `json:"id,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Settings *TenantSettings `json:"settings,omitempty"`
+	// Users' names are stored encrypted with the tenant's data key
+	EncryptPii bool       `json:"encrypt_pii,omitempty"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+// TenantAssignmentRequest moves a user into a tenant, or out of any when TenantID is nil
+type TenantAssignmentRequest struct {
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+}
+
+// TenantRequest creates or changes a tenant
+type TenantRequest struct {
+	Name       string          `json:"name"`
+	Settings   *TenantSettings `json:"settings,omitempty"`
+	EncryptPii bool            `json:"encrypt_pii,omitempty"`
+}
+
+// TenantSettings override the platform's defaults for a tenant's portfolios and transactions
+type TenantSettings struct {
+	RiskThresholds *ThresholdOverrides `json:"risk_thresholds,omitempty"`
+	AML            *AMLOverrides       `json:"aml,omitempty"`
+}
+
+type TestChannelResponse struct {
+	Message string `json:"message"`
+}
+
+// TestResult is a likelihood-ratio test outcome
+type TestResult struct {
+	Statistic float64 `json:"statistic,omitempty"`
+	PValue    float64 `json:"p_value,omitempty"`
+	Rejected  bool    `json:"rejected,omitempty"`
+}
+
+// ThresholdOverrides are the risk thresholds a portfolio template, tenant or portfolio sets; nil
+// fields keep the limits inherited, from the defaults of GetDefaultThresholds at the top
+type ThresholdOverrides struct {
+	MaxVaR95               *decimal.Decimal `json:"max_var_95,omitempty"`
+	MaxVaR99               *decimal.Decimal `json:"max_var_99,omitempty"`
+	MaxPositionSize        *decimal.Decimal `json:"max_position_size,omitempty"`
+	MaxSingleAssetExposure *decimal.Decimal `json:"max_single_asset_exposure,omitempty"`
+	MaxSectorExposure      *decimal.Decimal `json:"max_sector_exposure,omitempty"`
+	MinLiquidityRatio      *decimal.Decimal `json:"min_liquidity_ratio,omitempty"`
+	MaxLeverage            *decimal.Decimal `json:"max_leverage,omitempty"`
+	MaxConcentration       *decimal.Decimal `json:"max_concentration,omitempty"`
+	MaxFXExposure          *decimal.Decimal `json:"max_fx_exposure,omitempty"`
+	MaxDV01                *decimal.Decimal `json:"max_dv01,omitempty"`
+	MaxVenueExposure       *decimal.Decimal `json:"max_venue_exposure,omitempty"`
+	MaxStablecoinDepeg     *decimal.Decimal `json:"max_stablecoin_depeg,omitempty"`
+	MaxDailyLoss           *decimal.Decimal `json:"max_daily_loss,omitempty"`
+	MaxWeeklyLoss          *decimal.Decimal `json:"max_weekly_loss,omitempty"`
+	MaxDrawdown            *decimal.Decimal `json:"max_drawdown,omitempty"`
+	RequireStopLoss        *bool            `json:"require_stop_loss,omitempty"`
+	MaxStopLossDistance    *decimal.Decimal `json:"max_stop_loss_distance,omitempty"`
+}
+
+// ThresholdsRequest changes some of the limits set on a portfolio; limits left out are unchanged
+// and those named in inherit are taken from the parent portfolio again
+type ThresholdsRequest struct {
+	MaxVaR95               *decimal.Decimal `json:"max_var_95,omitempty"`
+	MaxVaR99               *decimal.Decimal `json:"max_var_99,omitempty"`
+	MaxPositionSize        *decimal.Decimal `json:"max_position_size,omitempty"`
+	MaxSingleAssetExposure *decimal.Decimal `json:"max_single_asset_exposure,omitempty"`
+	MaxSectorExposure      *decimal.Decimal `json:"max_sector_exposure,omitempty"`
+	MinLiquidityRatio      *decimal.Decimal `json:"min_liquidity_ratio,omitempty"`
+	MaxLeverage            *decimal.Decimal `json:"max_leverage,omitempty"`
+	MaxConcentration       *decimal.Decimal `json:"max_concentration,omitempty"`
+	MaxFXExposure          *decimal.Decimal `json:"max_fx_exposure,omitempty"`
+	MaxDV01                *decimal.Decimal `json:"max_dv01,omitempty"`
+	MaxVenueExposure       *decimal.Decimal `json:"max_venue_exposure,omitempty"`
+	MaxStablecoinDepeg     *decimal.Decimal `json:"max_stablecoin_depeg,omitempty"`
+	MaxDailyLoss           *decimal.Decimal `json:"max_daily_loss,omitempty"`
+	MaxWeeklyLoss          *decimal.Decimal `json:"max_weekly_loss,omitempty"`
+	MaxDrawdown            *decimal.Decimal `json:"max_drawdown,omitempty"`
+	RequireStopLoss        *bool            `json:"require_stop_loss,omitempty"`
+	MaxStopLossDistance    *decimal.Decimal `json:"max_stop_loss_distance,omitempty"`
+	// JSON names of thresholds no longer set on the portfolio itself
+	Inherit []string `json:"inherit,omitempty"`
+	// Version the change was made against, when If-Match is not sent
+	Version *int64 `json:"version,omitempty"`
+}
+
+type Transaction struct {
+	ID          uuid.UUID `json:"id,omitempty"`
+	PortfolioID uuid.UUID `json:"portfolio_id,omitempty"`
+	// Set by the database from the portfolio's tenant
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+	// BUY, SELL, DEPOSIT, WITHDRAWAL, DIVIDEND
+	TransactionType string          `json:"transaction_type,omitempty"`
+	Symbol          string          `json:"symbol,omitempty"`
+	Quantity        decimal.Decimal `json:"quantity,omitempty"`
+	Price           decimal.Decimal `json:"price,omitempty"`
+	Amount          decimal.Decimal `json:"amount,omitempty"`
+	Currency        string          `json:"currency,omitempty"`
+	// PENDING, PENDING_APPROVAL, COMPLETED, FAILED, CANCELLED, AMENDED
+	Status     string     `json:"status,omitempty"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+	Notes      string     `json:"notes,omitempty"`
+	// Order lifecycle of BUY and SELL transactions, which Status follows: FILLED is COMPLETED, as is
+	// CANCELLED after a partial fill for the filled quantity, and REJECTED is FAILED. NEW,
+	// PARTIALLY_FILLED, FILLED, CANCELLED, REJECTED
+	OrderStatus       string          `json:"order_status,omitempty"`
+	OrderStatusReason string          `json:"order_status_reason,omitempty"`
+	FilledQuantity    decimal.Decimal `json:"filled_quantity,omitempty"`
+	AverageFillPrice  decimal.Decimal `json:"average_fill_price,omitempty"`
+	// Gain a SELL realized on the lots it closed, in the portfolio currency
+	RealizedPnL *decimal.Decimal `json:"realized_pnl,omitempty"`
+	// Bulk import details. ExternalID is the source system's trade reference, or a hash of the row
+	// when the file has none, and makes re-importing the same trade a no-op.
+	ExternalID *string    `json:"external_id,omitempty"`
+	ImportID   *uuid.UUID `json:"import_id,omitempty"`
+	// Counterparty details used for sanctions screening. When CounterpartyID is set the name and
+	// country are copied from the counterparty record at the time of the trade.
+	CounterpartyID      *uuid.UUID `json:"counterparty_id,omitempty"`
+	CounterpartyName    string     `json:"counterparty_name,omitempty"`
+	CounterpartyCountry string     `json:"counterparty_country,omitempty"`
+	// Compliance fields
+	KYCVerified bool `json:"kyc_verified,omitempty"`
+	AMLChecked  bool `json:"aml_checked,omitempty"`
+	// 0-100
+	RiskScore       int    `json:"risk_score,omitempty"`
+	ComplianceNotes string `json:"compliance_notes,omitempty"`
+	// Four-eyes approval. A pending transaction flagged for review is held as PENDING_APPROVAL until a
+	// user other than CreatedBy approves or rejects it.
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	// PENDING, APPROVED, REJECTED; empty when never held
+	ApprovalStatus string     `json:"approval_status,omitempty"`
+	ApprovalReason string     `json:"approval_reason,omitempty"`
+	ReviewedBy     *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ReviewComment  string     `json:"review_comment,omitempty"`
+	// Amendments. An approved amendment of a completed transaction marks it AMENDED and books a
+	// replacement amending it, or marks it CANCELLED; either way SupersededAt is set and what it
+	// traded is kept as it was.
+	AmendsID     *uuid.UUID    `json:"amends_id,omitempty"`
+	SupersededAt *time.Time    `json:"superseded_at,omitempty"`
+	CreatedAt    time.Time     `json:"created_at,omitempty"`
+	UpdatedAt    time.Time     `json:"updated_at,omitempty"`
+	DeletedAt    *time.Time    `json:"deleted_at,omitempty"`
+	Portfolio    *Portfolio    `json:"portfolio,omitempty"`
+	Counterparty *Counterparty `json:"counterparty,omitempty"`
+	Fills        []Fill        `json:"fills,omitempty"`
+	// Risk Management Fields (add these) BUY or SELL
+	Side string `json:"side,omitempty"`
+	// STOCK, BOND, COMMODITY, CRYPTO
+	AssetType string `json:"asset_type,omitempty"`
+	// Issue rating of a bond traded
+	CreditRating string          `json:"credit_rating,omitempty"`
+	StopLoss     decimal.Decimal `json:"stop_loss,omitempty"`
+	TakeProfit   decimal.Decimal `json:"take_profit,omitempty"`
+	// Risk Analysis Results
+	RiskApproved   bool                   `json:"risk_approved,omitempty"`
+	RequiresReview bool                   `json:"requires_review,omitempty"`
+	RiskViolations map[string]interface{} `json:"risk_violations,omitempty"`
+}
+
+// TransactionAmendment is a requested correction or cancellation of a completed transaction, with
+// the values it had and those asked for. It waits as PENDING until a user other than the one who
+// requested it approves or rejects it; the transaction itself is never edited.
+type TransactionAmendment struct {
+	ID            uuid.UUID `json:"id,omitempty"`
+	TransactionID uuid.UUID `json:"transaction_id,omitempty"`
+	PortfolioID   uuid.UUID `json:"portfolio_id,omitempty"`
+	// AMEND, CANCEL
+	Action string `json:"action,omitempty"`
+	// PENDING, APPROVED, REJECTED
+	Status    string           `json:"status,omitempty"`
+	Reason    string           `json:"reason,omitempty"`
+	OldValues *AmendmentValues `json:"old_values,omitempty"`
+	NewValues *AmendmentValues `json:"new_values,omitempty"`
+	// Transaction an approved AMEND booked
+	ReplacementID *uuid.UUID `json:"replacement_id,omitempty"`
+	RequestedBy   uuid.UUID  `json:"requested_by,omitempty"`
+	ReviewedBy    *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewComment string     `json:"review_comment,omitempty"`
+	CreatedAt     time.Time  `json:"created_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at,omitempty"`
+}
+
+// TransactionImport records one bulk upload of trades. A repeated upload with the same idempotency
+// key from the same user returns this record instead of importing again.
+type TransactionImport struct {
+	ID             uuid.UUID `json:"id,omitempty"`
+	ImportedBy     uuid.UUID `json:"imported_by,omitempty"`
+	IdempotencyKey *string   `json:"idempotency_key,omitempty"`
+	// csv, fix
+	Format   string `json:"format,omitempty"`
+	FileName string `json:"file_name,omitempty"`
+	// PROCESSING, COMPLETED, FAILED
+	Status          string           `json:"status,omitempty"`
+	TotalRows       int              `json:"total_rows,omitempty"`
+	Imported        int              `json:"imported,omitempty"`
+	Duplicates      int              `json:"duplicates,omitempty"`
+	Failed          int              `json:"failed,omitempty"`
+	Errors          []ImportRowError `json:"errors,omitempty"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+	// Why the whole file was rejected
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// TransactionMonitoringEvaluation is the trail of one run of the transaction monitoring pipeline:
+// the features extracted from the transaction and its portfolio's history, the rules run and hit,
+// and the anomaly score when a scorer is configured
+type TransactionMonitoringEvaluation struct {
+	ID             uuid.UUID           `json:"id,omitempty"`
+	TransactionID  uuid.UUID           `json:"transaction_id,omitempty"`
+	PortfolioID    uuid.UUID           `json:"portfolio_id,omitempty"`
+	Features       map[string]float64  `json:"features,omitempty"`
+	RulesEvaluated []string            `json:"rules_evaluated,omitempty"`
+	Hits           []MonitoringRuleHit `json:"hits,omitempty"`
+	AnomalyScorer  string              `json:"anomaly_scorer,omitempty"`
+	// 0-1, nil without a scorer or when scoring failed
+	AnomalyScore *float64 `json:"anomaly_score,omitempty"`
+	// Why scoring failed; the rules still apply
+	AnomalyError string `json:"anomaly_error,omitempty"`
+	// Sum of the hit scores, capped at 100
+	RiskScore      int  `json:"risk_score,omitempty"`
+	RequiresReview bool `json:"requires_review,omitempty"`
+	// AML_CHECK, SWEEP
+	Trigger     string    `json:"trigger,omitempty"`
+	EvaluatedAt time.Time `json:"evaluated_at,omitempty"`
+}
+
+// UpdateBreakRequest explains a break or marks it resolved
+type UpdateBreakRequest struct {
+	Status string `json:"status"`
+	// Required to explain or resolve
+	Explanation string `json:"explanation,omitempty"`
+}
+
+type UpdateCaseRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Priority    *string `json:"priority,omitempty"`
+	Narrative   *string `json:"narrative,omitempty"`
+}
+
+type UpdateCaseResponse struct {
+	Message string `json:"message"`
+	Data    Case   `json:"data"`
+}
+
+type UpdateCaseStatusRequest struct {
+	Status          string `json:"status,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	Narrative       string `json:"narrative,omitempty"`
+	FilingReference string `json:"filing_reference,omitempty"`
+}
+
+type UpdateCaseStatusResponse struct {
+	Message string `json:"message"`
+	Data    Case   `json:"data"`
+}
+
+type UpdateChannelResponse struct {
+	Message string              `json:"message"`
+	Data    NotificationChannel `json:"data"`
+}
+
+type UpdateCounterpartyResponse struct {
+	Message string       `json:"message"`
+	Data    Counterparty `json:"data"`
+}
+
+type UpdateMarketDataResponse struct {
+	Message string           `json:"message"`
+	Data    SymbolMarketData `json:"data"`
+}
+
+type UpdateOrderStatusRequest struct {
+	// CANCELLED or REJECTED
+	OrderStatus string `json:"order_status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type UpdateOrderStatusResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type UpdatePolicyResponse struct {
+	Message string           `json:"message"`
+	Data    EscalationPolicy `json:"data"`
+}
+
+type UpdatePortfolioRequest struct {
+	Name             string  `json:"name,omitempty"`
+	Description      string  `json:"description,omitempty"`
+	Benchmark        *string `json:"benchmark,omitempty"`
+	CustodianAccount *string `json:"custodian_account,omitempty"`
+	CostBasisMethod  string  `json:"cost_basis_method,omitempty"`
+	// Version the update was made against, when If-Match is not sent
+	Version               *int64           `json:"version,omitempty"`
+	CashBalance           *decimal.Decimal `json:"cash_balance,omitempty"`
+	MarginLoan            *decimal.Decimal `json:"margin_loan,omitempty"`
+	MaintenanceMarginRate *decimal.Decimal `json:"maintenance_margin_rate,omitempty"`
+}
+
+type UpdatePortfolioResponse struct {
+	Message string    `json:"message"`
+	Data    Portfolio `json:"data"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role,omitempty"`
+}
+
+type UpdateRoleResponse struct {
+	Message string `json:"message"`
+	Data    User   `json:"data"`
+}
+
+type UpdateRuleResponse struct {
+	Message string         `json:"message"`
+	Data    ComplianceRule `json:"data"`
+}
+
+type UpdateTeamResponse struct {
+	Message string `json:"message"`
+	Data    Team   `json:"data"`
+}
+
+type UpdateTransactionResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type UpdateTransactionStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type UpdateTransactionStatusResponse struct {
+	Message     string      `json:"message"`
+	Transaction Transaction `json:"transaction"`
+}
+
+type UpsertAssetResponse struct {
+	Message string      `json:"message"`
+	Data    CryptoAsset `json:"data"`
+}
+
+type UpsertBondResponse struct {
+	Message string        `json:"message"`
+	Data    BondReference `json:"data"`
+}
+
+type User struct {
+	ID        uuid.UUID `json:"id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	FirstName string    `json:"first_name,omitempty"`
+	LastName  string    `json:"last_name,omitempty"`
+	// admin, analyst, trader, compliance_officer
+	Role     string `json:"role,omitempty"`
+	IsActive bool   `json:"is_active,omitempty"`
+	// Login refused until the user changes the password
+	PasswordResetRequired bool `json:"password_reset_required,omitempty"`
+	// Nil for the platform operator's users
+	TenantID *uuid.UUID `json:"tenant_id,omitempty"`
+	// Personal data replaced after the account was closed
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at,omitempty"`
+}
+
+// VaRContributionResult contains the parametric VaR of a portfolio broken down per position
+type VaRContributionResult struct {
+	ConfidenceLevel float64 `json:"confidence_level,omitempty"`
+	TimeHorizon     int     `json:"time_horizon,omitempty"`
+	// Daily returns the covariance is estimated from
+	Observations  int                       `json:"observations,omitempty"`
+	VaR           float64                   `json:"var,omitempty"`
+	Contributions []PositionVaRContribution `json:"contributions,omitempty"`
+	// Symbols without enough price history
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+// VaRResponse is a portfolio's VaR as CalculateVAR returns and caches it
+type VaRResponse struct {
+	PortfolioID          uuid.UUID              `json:"portfolio_id,omitempty"`
+	VaRValue             decimal.Decimal        `json:"var_value,omitempty"`
+	VaRPercentage        decimal.Decimal        `json:"var_percentage,omitempty"`
+	ConfidenceLevel      float64                `json:"confidence_level,omitempty"`
+	TimeHorizon          int                    `json:"time_horizon,omitempty"`
+	Method               string                 `json:"method,omitempty"`
+	Details              map[string]interface{} `json:"details,omitempty"`
+	PortfolioValue       decimal.Decimal        `json:"portfolio_value,omitempty"`
+	PositionsValue       decimal.Decimal        `json:"positions_value,omitempty"`
+	CashBalance          decimal.Decimal        `json:"cash_balance,omitempty"`
+	Status               string                 `json:"status,omitempty"`
+	Threshold            decimal.Decimal        `json:"threshold,omitempty"`
+	LiquidityAdjustedVaR *LiquidityAdjustedVaR  `json:"liquidity_adjusted_var,omitempty"`
+	CalculatedAt         time.Time              `json:"calculated_at,omitempty"`
+	// Served from the cache rather than calculated for this request
+	Cached bool `json:"cached,omitempty"`
+}
+
+// VenueExposure is the crypto value a portfolio keeps at one exchange, custodian or wallet
+type VenueExposure struct {
+	VenueType      string     `json:"venue_type,omitempty"`
+	VenueName      string     `json:"venue_name,omitempty"`
+	CounterpartyID *uuid.UUID `json:"counterparty_id,omitempty"`
+	Value          float64    `json:"value,omitempty"`
+	// Share of the portfolio's crypto value
+	Share         float64 `json:"share,omitempty"`
+	ExposureLimit float64 `json:"exposure_limit,omitempty"`
+	Holdings      int     `json:"holdings,omitempty"`
+	Breached      bool    `json:"breached,omitempty"`
+}
+
+// limitUtilization is a risk threshold with the portfolio's current value against it. Utilization
+// is the value as a percentage of a maximum, or the limit as a percentage of the value for a
+// minimum, so 100 is the limit either way. A limit of zero is not enforced and has no utilization.
+type LimitUtilization struct {
+	Name string `json:"name,omitempty"`
+	// Field of the portfolio's risk thresholds
+	Threshold string `json:"threshold,omitempty"`
+	// max, min
+	Direction          string           `json:"direction,omitempty"`
+	Value              decimal.Decimal  `json:"value,omitempty"`
+	Limit              decimal.Decimal  `json:"limit,omitempty"`
+	UtilizationPercent *decimal.Decimal `json:"utilization_percent,omitempty"`
+	// SAFE, WARNING, CRITICAL, NOT_ENFORCED
+	Status       string    `json:"status,omitempty"`
+	Detail       string    `json:"detail,omitempty"`
+	CalculatedAt time.Time `json:"calculated_at,omitempty"`
+}
+
+// portfolioRiskSummary is the latest VaR, liquidity, concentration and alert counts of a portfolio.
+// A metric that cannot be calculated is left out with the reason in Errors.
+type PortfolioRiskSummary struct {
+	PortfolioID   uuid.UUID         `json:"portfolio_id,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Currency      string            `json:"currency,omitempty"`
+	ValueWithCash decimal.Decimal   `json:"value_with_cash,omitempty"`
+	VaR           *SummaryMetric    `json:"var,omitempty"`
+	Liquidity     *SummaryMetric    `json:"liquidity,omitempty"`
+	Concentration *SummaryMetric    `json:"concentration,omitempty"`
+	ActiveAlerts  *AlertCounts      `json:"active_alerts,omitempty"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+// summaryMetric is a risk metric as reported by the risk summary
+type SummaryMetric struct {
+	Value        decimal.Decimal `json:"value,omitempty"`
+	Threshold    decimal.Decimal `json:"threshold,omitempty"`
+	Status       string          `json:"status,omitempty"`
+	CalculatedAt time.Time       `json:"calculated_at,omitempty"`
+	// Calculated for this request rather than stored
+	Recalculated bool `json:"recalculated,omitempty"`
+}
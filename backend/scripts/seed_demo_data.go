@@ -1,25 +1,41 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"math/rand"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/Taf0711/financial-risk-monitor/internal/config"
 	"github.com/Taf0711/financial-risk-monitor/internal/database"
+	"github.com/Taf0711/financial-risk-monitor/internal/mock"
 	"github.com/Taf0711/financial-risk-monitor/internal/models"
+	"github.com/Taf0711/financial-risk-monitor/internal/risk/calculator"
+	"github.com/Taf0711/financial-risk-monitor/internal/services"
 )
 
 func main() {
+	seed := flag.Int64("seed", 0, "random seed; the same seed seeds the same data (default MOCK_SEED, or the scenario's)")
+	scenarioFile := flag.String("scenario", "", "YAML demo scenario to seed instead of the default demo data (default MOCK_SCENARIO)")
+	flag.Parse()
+
 	// Load config
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
+	if *seed == 0 {
+		*seed = cfg.Mock.Seed
+	}
+	if *scenarioFile == "" {
+		*scenarioFile = cfg.Mock.ScenarioFile
+	}
 
 	// Initialize database
 	if err := database.InitPostgres(&cfg.Database); err != nil {
@@ -28,6 +44,24 @@ func main() {
 
 	db := database.GetDB()
 
+	if *scenarioFile != "" {
+		scenario, err := mock.LoadDemoScenario(*scenarioFile)
+		if err != nil {
+			log.Fatal("Failed to load scenario:", err)
+		}
+		if *seed != 0 {
+			scenario.Seed = *seed
+		}
+		seedScenario(db, cfg, scenario)
+		return
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	log.Printf("Seeding demo data with seed %d", *seed)
+	rng := rand.New(rand.NewSource(*seed))
+
 	// Create demo users
 	users := createUsers(db)
 	log.Printf("Created %d users", len(users))
@@ -41,7 +75,7 @@ func main() {
 			positions := createPositionsForPortfolio(db, portfolio)
 			log.Printf("Created %d positions for portfolio %s", len(positions), portfolio.Name)
 
-			transactions := createTransactionsForPortfolio(db, portfolio)
+			transactions := createTransactionsForPortfolio(db, portfolio, rng)
 			log.Printf("Created %d transactions for portfolio %s", len(transactions), portfolio.Name)
 
 			// Update portfolio total value
@@ -161,6 +195,10 @@ func createPositionsForPortfolio(db *gorm.DB, portfolio models.Portfolio) []mode
 			Weight:       weight,
 			AssetType:    data.AssetType,
 			Liquidity:    data.Liquidity,
+
+			Currency:         "USD",
+			FXRate:           decimal.NewFromInt(1),
+			LocalMarketValue: marketValue,
 		}
 
 		if err := db.Create(&position).Error; err != nil {
@@ -173,7 +211,7 @@ func createPositionsForPortfolio(db *gorm.DB, portfolio models.Portfolio) []mode
 	return positions
 }
 
-func createTransactionsForPortfolio(db *gorm.DB, portfolio models.Portfolio) []models.Transaction {
+func createTransactionsForPortfolio(db *gorm.DB, portfolio models.Portfolio, rng *rand.Rand) []models.Transaction {
 	symbols := []string{"AAPL", "GOOGL", "MSFT", "TSLA", "JPM"}
 	transactionTypes := []string{"BUY", "SELL"}
 
@@ -181,14 +219,14 @@ func createTransactionsForPortfolio(db *gorm.DB, portfolio models.Portfolio) []m
 
 	// Create 20 sample transactions
 	for i := 0; i < 20; i++ {
-		symbol := symbols[rand.Intn(len(symbols))]
-		txType := transactionTypes[rand.Intn(len(transactionTypes))]
-		quantity := decimal.NewFromFloat(10 + rand.Float64()*90) // 10-100 shares
-		price := decimal.NewFromFloat(100 + rand.Float64()*200)  // $100-300 per share
+		symbol := symbols[rng.Intn(len(symbols))]
+		txType := transactionTypes[rng.Intn(len(transactionTypes))]
+		quantity := decimal.NewFromFloat(10 + rng.Float64()*90) // 10-100 shares
+		price := decimal.NewFromFloat(100 + rng.Float64()*200)  // $100-300 per share
 		amount := quantity.Mul(price)
 
 		// Create transaction from 1-30 days ago
-		executedAt := time.Now().Add(-time.Duration(rand.Intn(30)) * 24 * time.Hour)
+		executedAt := time.Now().Add(-time.Duration(rng.Intn(30)) * 24 * time.Hour)
 
 		transaction := models.Transaction{
 			PortfolioID:     portfolio.ID,
@@ -202,7 +240,7 @@ func createTransactionsForPortfolio(db *gorm.DB, portfolio models.Portfolio) []m
 			ExecutedAt:      &executedAt,
 			KYCVerified:     true,
 			AMLChecked:      true,
-			RiskScore:       rand.Intn(30), // Low risk scores
+			RiskScore:       rng.Intn(30), // Low risk scores
 		}
 
 		if err := db.Create(&transaction).Error; err != nil {
@@ -235,3 +273,253 @@ func updatePortfolioValue(db *gorm.DB, portfolioID interface{}) {
 
 	log.Printf("Updated portfolio %v total value to $%s", portfolioID, totalValue.String())
 }
+
+// seedScenario seeds the users and portfolios of a demo scenario, with positions at the last close
+// of each price path and an end-of-day snapshot for every earlier day, then checks the breaches the
+// scenario expects. The last day of the paths is today.
+func seedScenario(db *gorm.DB, cfg *config.Config, scenario *mock.DemoScenario) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(scenario.Seed))
+	paths := scenario.PricePaths()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	dayDate := func(day int) time.Time {
+		return today.AddDate(0, 0, day-scenario.Days+1)
+	}
+	log.Printf("Seeding scenario %q over %d days with seed %d", scenario.Name, scenario.Days, scenario.Seed)
+
+	portfolios := make(map[string]uuid.UUID) // By owner email and portfolio name
+	for _, demoUser := range scenario.Users {
+		user := createScenarioUser(db, demoUser)
+		for _, demoPortfolio := range demoUser.Portfolios {
+			portfolio := createScenarioPortfolio(db, user, demoPortfolio, paths)
+
+			transactions := createScenarioTransactions(db, portfolio, demoPortfolio.Transactions, paths, dayDate, rng)
+			log.Printf("Created %d transactions for portfolio %s", transactions, portfolio.Name)
+
+			snapshots := createScenarioSnapshots(ctx, portfolio, scenario.Days, paths, dayDate)
+			log.Printf("Recorded %d snapshots for portfolio %s", snapshots, portfolio.Name)
+
+			portfolios[demoUser.Email+"/"+demoPortfolio.Name] = portfolio.ID
+			portfolios["/"+demoPortfolio.Name] = portfolio.ID
+		}
+	}
+
+	failures := 0
+	for _, breach := range scenario.ExpectedBreaches {
+		portfolioID := portfolios[breach.User+"/"+breach.Portfolio]
+		status, err := breachStatus(ctx, db, cfg, breach.Metric, portfolioID)
+		switch {
+		case err != nil:
+			log.Printf("❌ %s of %s could not be checked: %v", breach.Metric, breach.Portfolio, err)
+			failures++
+		case status != breach.Status:
+			log.Printf("❌ %s of %s is %s, expected %s", breach.Metric, breach.Portfolio, status, breach.Status)
+			failures++
+		default:
+			log.Printf("✅ %s of %s is %s", breach.Metric, breach.Portfolio, status)
+		}
+	}
+	if failures > 0 {
+		log.Fatalf("%d of %d expected breaches not met", failures, len(scenario.ExpectedBreaches))
+	}
+
+	log.Printf("✅ Scenario %q seeded successfully!", scenario.Name)
+	for _, demoUser := range scenario.Users {
+		log.Printf("🔐 %s / %s", demoUser.Email, demoUser.Password)
+	}
+}
+
+func createScenarioUser(db *gorm.DB, demoUser mock.DemoUser) models.User {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(demoUser.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatal("Failed to hash password:", err)
+	}
+
+	user := models.User{
+		Email:     demoUser.Email,
+		Password:  string(hashedPassword),
+		FirstName: demoUser.FirstName,
+		LastName:  demoUser.LastName,
+		Role:      demoUser.Role,
+		IsActive:  true,
+	}
+	if user.Role == "" {
+		user.Role = "analyst"
+	}
+
+	// A scenario only reproduces its data in a database it has not been seeded into
+	if err := db.Create(&user).Error; err != nil {
+		log.Fatalf("Failed to create user %s, seed scenarios into an empty database: %v", user.Email, err)
+	}
+	log.Printf("Created user %s", user.Email)
+	return user
+}
+
+// createScenarioPortfolio creates a portfolio with its positions priced at their last close
+func createScenarioPortfolio(db *gorm.DB, user models.User, demoPortfolio mock.DemoPortfolio, paths map[string][]float64) models.Portfolio {
+	portfolio := models.Portfolio{
+		UserID:      user.ID,
+		Name:        demoPortfolio.Name,
+		Description: demoPortfolio.Description,
+		Currency:    demoPortfolio.Currency,
+		CashBalance: decimal.NewFromFloat(demoPortfolio.CashBalance),
+		MarginLoan:  decimal.NewFromFloat(demoPortfolio.MarginLoan),
+	}
+	if portfolio.Currency == "" {
+		portfolio.Currency = "USD"
+	}
+
+	for _, demoPosition := range demoPortfolio.Positions {
+		closes := paths[demoPosition.Symbol]
+		position := models.Position{
+			Symbol:       demoPosition.Symbol,
+			Quantity:     decimal.NewFromFloat(demoPosition.Quantity),
+			AveragePrice: decimal.NewFromFloat(demoPosition.AveragePrice),
+			AssetType:    demoPosition.AssetType,
+			Liquidity:    demoPosition.Liquidity,
+			Currency:     portfolio.Currency,
+			FXRate:       decimal.NewFromInt(1),
+		}
+		if position.AveragePrice.IsZero() {
+			position.AveragePrice = decimal.NewFromFloat(closes[0])
+		}
+		if position.AssetType == "" {
+			position.AssetType = "STOCK"
+		}
+		if position.Liquidity == "" {
+			position.Liquidity = "HIGH"
+		}
+		priceScenarioPosition(&position, closes[len(closes)-1])
+		portfolio.Positions = append(portfolio.Positions, position)
+		portfolio.TotalValue = portfolio.TotalValue.Add(position.MarketValue)
+	}
+	for i := range portfolio.Positions {
+		if portfolio.TotalValue.IsPositive() {
+			portfolio.Positions[i].Weight = portfolio.Positions[i].MarketValue.Div(portfolio.TotalValue).Mul(decimal.NewFromInt(100)).Round(4)
+		}
+	}
+
+	if err := db.Create(&portfolio).Error; err != nil {
+		log.Fatalf("Failed to create portfolio %s: %v", portfolio.Name, err)
+	}
+	log.Printf("Created portfolio %s with %d positions worth $%s", portfolio.Name, len(portfolio.Positions), portfolio.TotalValue.StringFixed(2))
+	return portfolio
+}
+
+// priceScenarioPosition values a position at a close
+func priceScenarioPosition(position *models.Position, close float64) {
+	price := decimal.NewFromFloat(close)
+	cost := position.Quantity.Mul(position.AveragePrice)
+
+	position.CurrentPrice = price
+	position.MarketValue = position.Quantity.Mul(price).Round(2)
+	position.LocalMarketValue = position.MarketValue
+	position.PnL = position.MarketValue.Sub(cost).Round(2)
+	position.PnLPercent = decimal.Zero
+	if cost.IsPositive() {
+		position.PnLPercent = position.PnL.Div(cost).Mul(decimal.NewFromInt(100)).Round(4)
+	}
+}
+
+// createScenarioTransactions creates count trades of the portfolio's symbols at their close on
+// days before today
+func createScenarioTransactions(db *gorm.DB, portfolio models.Portfolio, count int, paths map[string][]float64, dayDate func(int) time.Time, rng *rand.Rand) int {
+	if len(portfolio.Positions) == 0 {
+		return 0
+	}
+	transactionTypes := []string{"BUY", "SELL"}
+
+	created := 0
+	for range count {
+		symbol := portfolio.Positions[rng.Intn(len(portfolio.Positions))].Symbol
+		closes := paths[symbol]
+		day := rng.Intn(len(closes) - 1)
+		quantity := decimal.NewFromFloat(1 + rng.Float64()*99).Round(4)
+		price := decimal.NewFromFloat(closes[day])
+
+		// During trading hours, 9:30 to 16:00
+		executedAt := dayDate(day).Add(9*time.Hour + 30*time.Minute + time.Duration(rng.Intn(390))*time.Minute)
+
+		transaction := models.Transaction{
+			PortfolioID:      portfolio.ID,
+			TransactionType:  transactionTypes[rng.Intn(len(transactionTypes))],
+			Symbol:           symbol,
+			Quantity:         quantity,
+			Price:            price,
+			Amount:           quantity.Mul(price).Round(2),
+			Currency:         portfolio.Currency,
+			Status:           "COMPLETED",
+			ExecutedAt:       &executedAt,
+			KYCVerified:      true,
+			AMLChecked:       true,
+			RiskScore:        rng.Intn(30),
+			CreatedAt:        executedAt,
+			OrderStatus:      models.OrderStatusFilled,
+			FilledQuantity:   quantity,
+			AverageFillPrice: price,
+		}
+		if err := db.Create(&transaction).Error; err != nil {
+			log.Printf("Transaction creation error: %v", err)
+			continue
+		}
+		created++
+	}
+	return created
+}
+
+// createScenarioSnapshots records the portfolio at the close of every day before today
+func createScenarioSnapshots(ctx context.Context, portfolio models.Portfolio, days int, paths map[string][]float64, dayDate func(int) time.Time) int {
+	snapshotService := services.NewPortfolioSnapshotService()
+
+	recorded := 0
+	for day := range days - 1 {
+		historical := portfolio
+		historical.Positions = make([]models.Position, len(portfolio.Positions))
+		for i, position := range portfolio.Positions {
+			priceScenarioPosition(&position, paths[position.Symbol][day])
+			historical.Positions[i] = position
+		}
+		if _, err := snapshotService.Snapshot(ctx, &historical, dayDate(day)); err != nil {
+			log.Printf("Snapshot error: %v", err)
+			continue
+		}
+		recorded++
+	}
+	return recorded
+}
+
+// breachStatus runs the risk check behind an expected breach's metric and returns the status it
+// reports, recording the metric and raising alerts as the scheduled check would
+func breachStatus(ctx context.Context, db *gorm.DB, cfg *config.Config, metric string, portfolioID uuid.UUID) (string, error) {
+	switch metric {
+	case mock.DemoMetricDrawdown:
+		result, err := services.NewDrawdownService().CheckDrawdown(ctx, portfolioID)
+		if err != nil {
+			return "", err
+		}
+		return result.Status, nil
+
+	case mock.DemoMetricLeverage:
+		result, err := services.NewLeverageService().CheckLeverage(ctx, portfolioID)
+		if err != nil {
+			return "", err
+		}
+		return result.Status, nil
+
+	default:
+		var portfolio models.Portfolio
+		if err := db.WithContext(ctx).Preload("Positions").First(&portfolio, portfolioID).Error; err != nil {
+			return "", err
+		}
+		params := services.DefaultVaRParams(&cfg.Risk)
+		params.Method = calculator.MethodHistorical
+		riskMetric, _, err := services.NewVaRService(&cfg.Risk).Calculate(ctx, &portfolio, params)
+		if err != nil {
+			return "", err
+		}
+		if err := db.WithContext(ctx).Create(riskMetric).Error; err != nil {
+			return "", err
+		}
+		return riskMetric.Status, nil
+	}
+}
@@ -1,10 +1,10 @@
 #!/bin/bash
 
 # Create migrations directory
-mkdir -p migrations
+mkdir -p db/migrations
 
 # Create 001_create_users.up.sql
-cat > migrations/001_create_users.up.sql << 'EOF'
+cat > db/migrations/001_create_users.up.sql << 'EOF'
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 CREATE TABLE IF NOT EXISTS users (
@@ -25,12 +25,12 @@ CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
 EOF
 
 # Create 001_create_users.down.sql
-cat > migrations/001_create_users.down.sql << 'EOF'
+cat > db/migrations/001_create_users.down.sql << 'EOF'
 DROP TABLE IF EXISTS users;
 EOF
 
 # Create 002_create_portfolios.up.sql
-cat > migrations/002_create_portfolios.up.sql << 'EOF'
+cat > db/migrations/002_create_portfolios.up.sql << 'EOF'
 CREATE TABLE IF NOT EXISTS portfolios (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
@@ -46,12 +46,12 @@ CREATE INDEX IF NOT EXISTS idx_portfolios_user_id ON portfolios(user_id);
 EOF
 
 # Create 002_create_portfolios.down.sql
-cat > migrations/002_create_portfolios.down.sql << 'EOF'
+cat > db/migrations/002_create_portfolios.down.sql << 'EOF'
 DROP TABLE IF EXISTS portfolios;
 EOF
 
 # Create 003_create_positions.up.sql
-cat > migrations/003_create_positions.up.sql << 'EOF'
+cat > db/migrations/003_create_positions.up.sql << 'EOF'
 CREATE TABLE IF NOT EXISTS positions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
@@ -73,12 +73,12 @@ CREATE INDEX IF NOT EXISTS idx_positions_symbol ON positions(symbol);
 EOF
 
 # Create 003_create_positions.down.sql
-cat > migrations/003_create_positions.down.sql << 'EOF'
+cat > db/migrations/003_create_positions.down.sql << 'EOF'
 DROP TABLE IF EXISTS positions;
 EOF
 
 # Create 004_create_transactions.up.sql
-cat > migrations/004_create_transactions.up.sql << 'EOF'
+cat > db/migrations/004_create_transactions.up.sql << 'EOF'
 CREATE TABLE IF NOT EXISTS transactions (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
@@ -105,12 +105,12 @@ CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions(created_a
 EOF
 
 # Create 004_create_transactions.down.sql
-cat > migrations/004_create_transactions.down.sql << 'EOF'
+cat > db/migrations/004_create_transactions.down.sql << 'EOF'
 DROP TABLE IF EXISTS transactions;
 EOF
 
 # Create 005_create_risk_metrics.up.sql
-cat > migrations/005_create_risk_metrics.up.sql << 'EOF'
+cat > db/migrations/005_create_risk_metrics.up.sql << 'EOF'
 CREATE TABLE IF NOT EXISTS risk_metrics (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
@@ -138,13 +138,13 @@ CREATE INDEX IF NOT EXISTS idx_risk_histories_portfolio_id ON risk_histories(por
 EOF
 
 # Create 005_create_risk_metrics.down.sql
-cat > migrations/005_create_risk_metrics.down.sql << 'EOF'
+cat > db/migrations/005_create_risk_metrics.down.sql << 'EOF'
 DROP TABLE IF EXISTS risk_histories;
 DROP TABLE IF EXISTS risk_metrics;
 EOF
 
 # Create 006_create_alerts.up.sql
-cat > migrations/006_create_alerts.up.sql << 'EOF'
+cat > db/migrations/006_create_alerts.up.sql << 'EOF'
 CREATE TABLE IF NOT EXISTS alerts (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
@@ -170,11 +170,11 @@ CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at);
 EOF
 
 # Create 006_create_alerts.down.sql
-cat > migrations/006_create_alerts.down.sql << 'EOF'
+cat > db/migrations/006_create_alerts.down.sql << 'EOF'
 DROP TABLE IF EXISTS alerts;
 EOF
 
 echo "✅ Migration files created successfully!"
 echo ""
-echo "Files created in ./migrations/:"
-ls -la migrations/
\ No newline at end of file
+echo "Files created in ./db/migrations/:"
+ls -la db/migrations/
\ No newline at end of file
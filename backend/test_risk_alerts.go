@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -140,6 +141,7 @@ func main() {
 	fmt.Println("\n--- Testing Alert Generation ---")
 	if varResult != nil && varResult.Status != "SAFE" {
 		err := alertService.CreateRiskBreachAlert(
+			context.Background(),
 			portfolio.ID,
 			"VAR",
 			varResult.VaRValue.InexactFloat64(),
@@ -1,17 +1,20 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+
+	"github.com/Taf0711/financial-risk-monitor/pkg/client"
 )
 
 const (
@@ -29,11 +32,12 @@ type TestResult struct {
 
 type TestSuite struct {
 	Results       []TestResult
+	API           *client.Client
 	Token         string
-	UserID        string
-	PortfolioID   string
-	TransactionID string
-	AlertID       string
+	UserID        uuid.UUID
+	PortfolioID   uuid.UUID
+	TransactionID uuid.UUID
+	AlertID       uuid.UUID
 }
 
 var (
@@ -48,6 +52,7 @@ func main() {
 
 	suite := &TestSuite{
 		Results: []TestResult{},
+		API:     client.New(BASE_URL, client.WithHTTPClient(&http.Client{Timeout: 30 * time.Second})),
 	}
 
 	// Run all tests
@@ -143,23 +148,19 @@ func (s *TestSuite) RunAllTests() {
 func (s *TestSuite) TestHealthCheck() {
 	start := time.Now()
 
-	// Test basic server connectivity with a simple GET request
-	resp, err := http.Get(BASE_URL + "/api/v1/portfolios")
-	if err != nil {
-		s.AddResult("Server Connectivity", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
+	// Test basic server connectivity with a request the server refuses without a token
+	_, err := client.New(BASE_URL).GetPortfolios(context.Background())
 
 	// We expect 401 (unauthorized) which means server is running
-	passed := resp.StatusCode == 401 || resp.StatusCode == 200
+	status := statusCode(err)
+	passed := status == 401 || status == 200
 	errMsg := ""
 	if !passed {
-		errMsg = fmt.Sprintf("Unexpected status code: %d", resp.StatusCode)
+		errMsg = err.Error()
 	}
 
 	s.AddResult("Server Connectivity", passed, errMsg, map[string]interface{}{
-		"status_code":   resp.StatusCode,
+		"status_code":   status,
 		"response_time": time.Since(start).Milliseconds(),
 	})
 }
@@ -171,47 +172,26 @@ func (s *TestSuite) TestUserRegistration() {
 	// Generate unique email
 	email := fmt.Sprintf("test_%d@example.com", time.Now().Unix())
 
-	payload := map[string]string{
-		"email":      email,
-		"password":   "TestPass123!",
-		"first_name": "Test",
-		"last_name":  "User",
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(BASE_URL+"/api/v1/auth/register", "application/json", bytes.NewBuffer(body))
-
+	result, err := s.API.Register(context.Background(), client.RegisterRequest{
+		Email:     email,
+		Password:  "TestPass123!",
+		FirstName: "Test",
+		LastName:  "User",
+	})
 	if err != nil {
 		s.AddResult("User Registration", false, err.Error(), nil)
 		return
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	if resp.StatusCode == 201 {
-		if user, ok := result["user"].(map[string]interface{}); ok {
-			s.UserID = user["id"].(string)
-		}
-	}
-
-	passed := resp.StatusCode == 201
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Status: %d, Response: %v", resp.StatusCode, result)
-	}
+	s.UserID = result.User.ID
 
-	s.AddResult("User Registration", passed, errMsg, map[string]interface{}{
+	s.AddResult("User Registration", true, "", map[string]interface{}{
 		"response_time": time.Since(start).Milliseconds(),
-		"data":          result,
+		"user_id":       result.User.ID,
 	})
 
 	// Store credentials for login test
-	if passed {
-		os.Setenv("TEST_EMAIL", email)
-		os.Setenv("TEST_PASSWORD", "TestPass123!")
-	}
+	os.Setenv("TEST_EMAIL", email)
+	os.Setenv("TEST_PASSWORD", "TestPass123!")
 }
 
 // User Login Test
@@ -224,37 +204,29 @@ func (s *TestSuite) TestUserLogin() {
 		return
 	}
 
-	payload := map[string]string{
-		"email":    email,
-		"password": password,
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(BASE_URL+"/api/v1/auth/login", "application/json", bytes.NewBuffer(body))
-
+	result, err := s.API.Login(context.Background(), client.LoginRequest{
+		Email:    email,
+		Password: password,
+	})
 	if err != nil {
 		s.AddResult("User Login", false, err.Error(), nil)
 		return
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	passed := resp.StatusCode == 200 && result["token"] != nil
 
+	passed := result.Token != ""
 	if passed {
-		s.Token = result["token"].(string)
+		s.Token = result.Token
+		s.API.SetToken(result.Token)
 	}
 
 	errMsg := ""
 	if !passed {
-		errMsg = fmt.Sprintf("Status: %d, Response: %v", resp.StatusCode, result)
+		errMsg = "Response has no token"
 	}
 
 	s.AddResult("User Login", passed, errMsg, map[string]interface{}{
-		"has_token": result["token"] != nil,
-		"has_user":  result["user"] != nil,
+		"has_token": result.Token != "",
+		"has_user":  result.User != nil,
 	})
 }
 
@@ -267,56 +239,26 @@ func (s *TestSuite) TestDuplicateRegistration() {
 		return
 	}
 
-	payload := map[string]string{
-		"email":      email,
-		"password":   "AnotherPass123!",
-		"first_name": "Another",
-		"last_name":  "User",
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(BASE_URL+"/api/v1/auth/register", "application/json", bytes.NewBuffer(body))
-
-	if err != nil {
-		s.AddResult("Duplicate Registration Check", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
+	_, err := s.API.Register(context.Background(), client.RegisterRequest{
+		Email:     email,
+		Password:  "AnotherPass123!",
+		FirstName: "Another",
+		LastName:  "User",
+	})
 
 	// Should fail with 400
-	passed := resp.StatusCode == 400
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Expected 400, got %d", resp.StatusCode)
-	}
-
-	s.AddResult("Duplicate Registration Check", passed, errMsg, nil)
+	s.expectStatus("Duplicate Registration Check", err, 400)
 }
 
 // Test Invalid Login
 func (s *TestSuite) TestInvalidLogin() {
-	payload := map[string]string{
-		"email":    "nonexistent@example.com",
-		"password": "WrongPass123!",
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(BASE_URL+"/api/v1/auth/login", "application/json", bytes.NewBuffer(body))
-
-	if err != nil {
-		s.AddResult("Invalid Login Check", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
+	_, err := s.API.Login(context.Background(), client.LoginRequest{
+		Email:    "nonexistent@example.com",
+		Password: "WrongPass123!",
+	})
 
 	// Should fail with 401
-	passed := resp.StatusCode == 401
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Expected 401, got %d", resp.StatusCode)
-	}
-
-	s.AddResult("Invalid Login Check", passed, errMsg, nil)
+	s.expectStatus("Invalid Login Check", err, 401)
 }
 
 // Portfolio Tests
@@ -326,39 +268,20 @@ func (s *TestSuite) TestCreatePortfolio() {
 		return
 	}
 
-	payload := map[string]string{
-		"name":        "Test Portfolio",
-		"description": "Automated test portfolio",
-	}
-
-	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("POST", BASE_URL+"/api/v1/portfolios", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
+	portfolio, err := s.API.CreatePortfolio(context.Background(), client.CreatePortfolioRequest{
+		Name:        "Test Portfolio",
+		Description: "Automated test portfolio",
+	}, nil)
 	if err != nil {
 		s.AddResult("Create Portfolio", false, err.Error(), nil)
 		return
 	}
-	defer resp.Body.Close()
+	s.PortfolioID = portfolio.ID
 
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	if resp.StatusCode == 201 && result["id"] != nil {
-		s.PortfolioID = result["id"].(string)
-	}
-
-	passed := resp.StatusCode == 201
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Status: %d, Response: %v", resp.StatusCode, result)
-	}
-
-	s.AddResult("Create Portfolio", passed, errMsg, result)
+	s.AddResult("Create Portfolio", true, "", map[string]interface{}{
+		"id":   portfolio.ID,
+		"name": portfolio.Name,
+	})
 }
 
 func (s *TestSuite) TestGetPortfolios() {
@@ -367,174 +290,85 @@ func (s *TestSuite) TestGetPortfolios() {
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/portfolios", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
+	portfolios, err := s.API.GetPortfolios(context.Background())
 	if err != nil {
 		s.AddResult("Get Portfolios", false, err.Error(), nil)
 		return
 	}
-	defer resp.Body.Close()
-
-	var result []interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
 
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Portfolios", passed, errMsg, map[string]interface{}{
-		"count": len(result),
+	s.AddResult("Get Portfolios", true, "", map[string]interface{}{
+		"count": len(portfolios),
 	})
 }
 
 func (s *TestSuite) TestGetSinglePortfolio() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Get Single Portfolio", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/portfolios/"+s.PortfolioID, nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Single Portfolio", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Single Portfolio", passed, errMsg, nil)
+	_, err := s.API.GetPortfolio(context.Background(), s.PortfolioID)
+	s.expectSuccess("Get Single Portfolio", err)
 }
 
 func (s *TestSuite) TestUpdatePortfolio() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Update Portfolio", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	payload := map[string]string{
-		"name":        "Updated Portfolio",
-		"description": "Updated description",
-	}
-
-	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("PUT", BASE_URL+"/api/v1/portfolios/"+s.PortfolioID, bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
+	// Updates name the version they were made against
+	portfolio, err := s.API.GetPortfolio(context.Background(), s.PortfolioID)
 	if err != nil {
-		s.AddResult("Update Portfolio", false, err.Error(), nil)
+		s.expectSuccess("Update Portfolio", err)
 		return
 	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
 
-	s.AddResult("Update Portfolio", passed, errMsg, nil)
+	_, err = s.API.UpdatePortfolio(context.Background(), s.PortfolioID, client.UpdatePortfolioRequest{
+		Name:        "Updated Portfolio",
+		Description: "Updated description",
+		Version:     &portfolio.Version,
+	}, nil)
+	s.expectSuccess("Update Portfolio", err)
 }
 
 func (s *TestSuite) TestDeletePortfolio() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Delete Portfolio", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("DELETE", BASE_URL+"/api/v1/portfolios/"+s.PortfolioID, nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Delete Portfolio", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200 || resp.StatusCode == 204
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Delete Portfolio", passed, errMsg, nil)
+	_, err := s.API.DeletePortfolio(context.Background(), s.PortfolioID)
+	s.expectSuccess("Delete Portfolio", err)
 }
 
 // Transaction Tests
 func (s *TestSuite) TestCreateTransaction() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Create Transaction", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	transactionData := map[string]interface{}{
-		"portfolio_id":     s.PortfolioID,
-		"transaction_type": "BUY",
-		"symbol":           "AAPL",
-		"quantity":         10.0,
-		"price":            150.50,
-		"currency":         "USD",
-		"executed_at":      time.Now().Format(time.RFC3339),
-		"notes":            "Test transaction",
-	}
-
-	body, _ := json.Marshal(transactionData)
-	req, _ := http.NewRequest("POST", BASE_URL+"/api/v1/transactions", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
+	result, err := s.API.CreateTransaction(context.Background(), client.CreateTransactionRequest{
+		PortfolioID:     s.PortfolioID.String(),
+		TransactionType: "BUY",
+		Symbol:          "AAPL",
+		Quantity:        10.0,
+		Price:           150.50,
+		Currency:        "USD",
+		ExecutedAt:      time.Now().Format(time.RFC3339),
+		Notes:           "Test transaction",
+	}, nil)
 	if err != nil {
 		s.AddResult("Create Transaction", false, err.Error(), nil)
 		return
 	}
-	defer resp.Body.Close()
+	s.TransactionID = result.Transaction.ID
 
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	passed := resp.StatusCode == 201
-	errMsg := ""
-
-	if passed {
-		if transaction, ok := result["transaction"].(map[string]interface{}); ok {
-			if id, ok := transaction["id"].(string); ok {
-				s.TransactionID = id
-			}
-		}
-	} else {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Create Transaction", passed, errMsg, result)
+	s.AddResult("Create Transaction", true, "", map[string]interface{}{
+		"id":     result.Transaction.ID,
+		"status": result.Transaction.Status,
+	})
 }
 
 func (s *TestSuite) TestGetTransactions() {
@@ -543,265 +377,100 @@ func (s *TestSuite) TestGetTransactions() {
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/transactions", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Transactions", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Transactions", passed, errMsg, nil)
+	_, err := s.API.GetTransactions(context.Background(), nil)
+	s.expectSuccess("Get Transactions", err)
 }
 
 func (s *TestSuite) TestUpdateTransactionStatus() {
-	if s.Token == "" || s.TransactionID == "" {
+	if s.Token == "" || s.TransactionID == uuid.Nil {
 		s.AddResult("Update Transaction Status", false, "No auth token or transaction ID", nil)
 		return
 	}
 
-	payload := map[string]string{
-		"status": "COMPLETED",
-	}
-
-	body, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("PUT", BASE_URL+"/api/v1/transactions/"+s.TransactionID+"/status", bytes.NewBuffer(body))
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Update Transaction Status", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
+	_, err := s.API.UpdateTransactionStatus(context.Background(), s.TransactionID, client.UpdateTransactionStatusRequest{
+		Status: "COMPLETED",
+	})
 
-	s.AddResult("Update Transaction Status", passed, errMsg, nil)
+	// The test user registers as an analyst, which may not approve transactions
+	s.expectStatus("Update Transaction Status", err, 403)
 }
 
 // Risk Metrics Tests - These may return errors if risk engine isn't fully implemented
 func (s *TestSuite) TestCalculateVAR() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Calculate VaR", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/risk/portfolio/"+s.PortfolioID+"/var", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Calculate VaR", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+	_, err := s.API.CalculateVAR(context.Background(), s.PortfolioID.String(), nil)
 
 	// Accept 200 (success) or 501 (not implemented) as valid responses
-	passed := resp.StatusCode == 200 || resp.StatusCode == 501
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Status: %d, Response: %v", resp.StatusCode, result)
-	}
-
-	s.AddResult("Calculate VaR", passed, errMsg, result)
+	s.expectStatus("Calculate VaR", err, 200, 501)
 }
 
 func (s *TestSuite) TestCalculateLiquidity() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Calculate Liquidity", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/risk/portfolio/"+s.PortfolioID+"/liquidity", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Calculate Liquidity", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
+	_, err := s.API.CalculateLiquidityRisk(context.Background(), s.PortfolioID.String(), nil)
 
 	// Accept 200 (success) or 501 (not implemented) as valid responses
-	passed := resp.StatusCode == 200 || resp.StatusCode == 501
-	errMsg := ""
-	if !passed {
-		errMsg = fmt.Sprintf("Status: %d, Response: %v", resp.StatusCode, result)
-	}
-
-	s.AddResult("Calculate Liquidity", passed, errMsg, result)
+	s.expectStatus("Calculate Liquidity", err, 200, 501)
 }
 
 func (s *TestSuite) TestGetRiskMetrics() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Get Risk Metrics", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/risk/portfolio/"+s.PortfolioID+"/metrics", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Risk Metrics", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Risk Metrics", passed, errMsg, nil)
+	_, err := s.API.GetRiskMetrics(context.Background(), s.PortfolioID.String(), nil)
+	s.expectSuccess("Get Risk Metrics", err)
 }
 
 func (s *TestSuite) TestGetRiskHistory() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Get Risk History", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/risk/portfolio/"+s.PortfolioID+"/history", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Risk History", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Risk History", passed, errMsg, nil)
+	_, err := s.API.GetRiskHistory(context.Background(), s.PortfolioID.String(), nil)
+	s.expectSuccess("Get Risk History", err)
 }
 
 // Compliance Tests - These may return errors if compliance engine isn't fully implemented
 func (s *TestSuite) TestComplianceCheck() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Compliance Check", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/compliance/portfolio/"+s.PortfolioID+"/check", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Compliance Check", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
+	_, err := s.API.CheckCompliance(context.Background(), s.PortfolioID.String())
 
 	// Accept 200 (success), 404 (not found), or 501 (not implemented) as valid responses
-	passed := resp.StatusCode == 200 || resp.StatusCode == 404 || resp.StatusCode == 501
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Compliance Check", passed, errMsg, nil)
+	s.expectStatus("Compliance Check", err, 200, 404, 501)
 }
 
 func (s *TestSuite) TestPositionLimits() {
-	if s.Token == "" || s.PortfolioID == "" {
+	if s.Token == "" || s.PortfolioID == uuid.Nil {
 		s.AddResult("Position Limits Check", false, "No auth token or portfolio ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/compliance/portfolio/"+s.PortfolioID+"/position-limits", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Position Limits Check", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Position Limits Check", passed, errMsg, nil)
+	_, err := s.API.CheckPositionLimits(context.Background(), s.PortfolioID.String())
+	s.expectSuccess("Position Limits Check", err)
 }
 
 func (s *TestSuite) TestAMLCheck() {
-	if s.Token == "" || s.TransactionID == "" {
+	if s.Token == "" || s.TransactionID == uuid.Nil {
 		s.AddResult("AML Check", false, "No auth token or transaction ID", nil)
 		return
 	}
 
-	req, _ := http.NewRequest("POST", BASE_URL+"/api/v1/compliance/transaction/"+s.TransactionID+"/aml-check", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("AML Check", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("AML Check", passed, errMsg, nil)
+	_, err := s.API.CheckAML(context.Background(), s.TransactionID)
+	s.expectSuccess("AML Check", err)
 }
 
 // Alert Tests
@@ -811,26 +480,8 @@ func (s *TestSuite) TestGetAlerts() {
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/alerts", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Alerts", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Alerts", passed, errMsg, nil)
+	_, err := s.API.GetAlerts(context.Background(), nil)
+	s.expectSuccess("Get Alerts", err)
 }
 
 func (s *TestSuite) TestGetActiveAlerts() {
@@ -839,26 +490,8 @@ func (s *TestSuite) TestGetActiveAlerts() {
 		return
 	}
 
-	req, _ := http.NewRequest("GET", BASE_URL+"/api/v1/alerts/active", nil)
-	req.Header.Set("Authorization", "Bearer "+s.Token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
-	if err != nil {
-		s.AddResult("Get Active Alerts", false, err.Error(), nil)
-		return
-	}
-	defer resp.Body.Close()
-
-	passed := resp.StatusCode == 200
-	errMsg := ""
-	if !passed {
-		body, _ := io.ReadAll(resp.Body)
-		errMsg = fmt.Sprintf("Status: %d, Response: %s", resp.StatusCode, string(body))
-	}
-
-	s.AddResult("Get Active Alerts", passed, errMsg, nil)
+	_, err := s.API.GetActiveAlerts(context.Background(), nil)
+	s.expectSuccess("Get Active Alerts", err)
 }
 
 func (s *TestSuite) TestAcknowledgeAlert() {
@@ -868,8 +501,13 @@ func (s *TestSuite) TestAcknowledgeAlert() {
 
 // WebSocket Tests
 func (s *TestSuite) TestWebSocketConnection() {
+	if s.Token == "" {
+		s.AddResult("WebSocket Connection", false, "No auth token available", nil)
+		return
+	}
+
 	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.Dial(WS_URL+"?user_id=test", nil)
+	conn, _, err := dialer.Dial(WS_URL+"?token="+s.Token, nil)
 
 	if err != nil {
 		s.AddResult("WebSocket Connection", false, err.Error(), nil)
@@ -881,8 +519,13 @@ func (s *TestSuite) TestWebSocketConnection() {
 }
 
 func (s *TestSuite) TestWebSocketMessages() {
+	if s.Token == "" {
+		s.AddResult("WebSocket Messages", false, "No auth token available", nil)
+		return
+	}
+
 	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.Dial(WS_URL+"?user_id=test", nil)
+	conn, _, err := dialer.Dial(WS_URL+"?token="+s.Token, nil)
 
 	if err != nil {
 		s.AddResult("WebSocket Messages", false, err.Error(), nil)
@@ -912,6 +555,46 @@ func (s *TestSuite) TestWebSocketMessages() {
 }
 
 // Helper Functions
+
+// statusCode returns the status of a call: 200 when it succeeded, the response status when the API
+// refused it, and 0 when it never got a response
+func statusCode(err error) int {
+	if err == nil {
+		return 200
+	}
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// expectSuccess records whether a call succeeded
+func (s *TestSuite) expectSuccess(name string, err error) {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	s.AddResult(name, err == nil, errMsg, nil)
+}
+
+// expectStatus records whether a call ended with one of the expected statuses
+func (s *TestSuite) expectStatus(name string, err error, expected ...int) {
+	status := statusCode(err)
+	for _, want := range expected {
+		if status == want {
+			s.AddResult(name, true, "", nil)
+			return
+		}
+	}
+
+	errMsg := fmt.Sprintf("Expected %v, got %d", expected, status)
+	if err != nil {
+		errMsg += ": " + err.Error()
+	}
+	s.AddResult(name, false, errMsg, nil)
+}
+
 func (s *TestSuite) AddResult(name string, passed bool, error string, details map[string]interface{}) {
 	result := TestResult{
 		Name:    name,
@@ -46,11 +46,16 @@ func main() {
 	fmt.Println(strings.Repeat("=", 50))
 	fmt.Println()
 
-	// Connect to WebSocket
-	url := "ws://localhost:8080/ws?user_id=test-client"
+	// Connect to WebSocket with a JWT from /api/v1/auth/login
+	token := os.Getenv("WS_TOKEN")
+	if token == "" {
+		log.Fatal("WS_TOKEN must be set to a valid JWT")
+	}
+
+	url := "ws://localhost:8080/ws"
 	fmt.Printf("Connecting to %s...\n", url)
 
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token="+token, nil)
 	if err != nil {
 		log.Fatal("Failed to connect:", err)
 	}
@@ -66,6 +71,17 @@ func main() {
 		},
 	}
 
+	// Optionally narrow the stream, e.g. WS_SYMBOLS=AAPL,BTC
+	if symbols := os.Getenv("WS_SYMBOLS"); symbols != "" {
+		err := conn.WriteJSON(map[string]interface{}{
+			"action":  "subscribe",
+			"symbols": strings.Split(symbols, ","),
+		})
+		if err != nil {
+			log.Fatal("Failed to subscribe:", err)
+		}
+	}
+
 	// Handle Ctrl+C
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
@@ -96,6 +112,9 @@ func main() {
 			case "welcome":
 				fmt.Printf("[%s] %s Welcome message received\n", timestamp, blue("👋"))
 
+			case "subscribed", "unsubscribed":
+				fmt.Printf("[%s] %s Subscriptions updated: %v\n", timestamp, blue("🔔"), data["data"])
+
 			case "price_update":
 				client.stats.PriceUpdates++
 				fmt.Printf("[%s] %s PRICE UPDATE #%d\n", timestamp, green("📈"), client.stats.PriceUpdates)